@@ -0,0 +1,155 @@
+// Command switchboard-loadgen drives a running Switchboard server with simulated
+// classrooms and reports throughput, latency and failures
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"switchboard/pkg/loadgen"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run parses flags, drives the load and writes the report
+// FUNCTIONAL DISCOVERY: Exits 0 after a run, 1 when the run could not start or the
+// report could not be written, and 2 on usage errors. Flags override the -profile
+// file, which overrides the defaults; an interrupt ends the run early and still
+// writes the report
+func run(args []string, stdout, stderr io.Writer) int {
+	profile, format, out, code := parseFlags(args, stderr)
+	if profile == nil {
+		return code
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	logf := func(format string, args ...interface{}) {
+		fmt.Fprintf(stderr, format+"\n", args...)
+	}
+	logf("Driving %d instructors and %d students against %s for %v", profile.Instructors, profile.Students, profile.URL, profile.Duration)
+	report, err := loadgen.Run(ctx, profile, logf)
+	if err != nil {
+		fmt.Fprintf(stderr, "Load run failed: %v\n", err)
+		return 1
+	}
+
+	output := stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			fmt.Fprintf(stderr, "Cannot write report: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		output = file
+	}
+	if format == "csv" {
+		err = report.WriteCSV(output)
+	} else {
+		err = report.WriteJSON(output)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Cannot write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// parseFlags builds the run's profile; a nil profile means exit with code
+func parseFlags(args []string, stderr io.Writer) (profile *loadgen.Profile, format, out string, code int) {
+	defaults := loadgen.DefaultProfile()
+	fs := flag.NewFlagSet("switchboard-loadgen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	profilePath := fs.String("profile", "", "JSON or YAML profile `file`; flags given override it")
+	url := fs.String("url", defaults.URL, "server base URL")
+	sessionID := fs.String("session", "", "join this active session instead of creating one")
+	instructors := fs.Int("instructors", defaults.Instructors, "instructor connections")
+	students := fs.Int("students", defaults.Students, "student connections")
+	duration := fs.Duration("duration", defaults.Duration, "how long clients send")
+	rate := fs.Int("rate", defaults.MessagesPerMinute, "messages per minute per client")
+	mix := fs.String("mix", "", "message mix as `type=weight,...` (default: a classroom mix)")
+	disconnectEvery := fs.Duration("disconnect-every", 0, "drop one random client per interval (0 disables)")
+	reconnectAfter := fs.Duration("reconnect-after", defaults.Failures.ReconnectAfter, "how long a dropped client stays away")
+	slowReaders := fs.Float64("slow-readers", 0, "share of clients that read slowly (0-1)")
+	slowReadDelay := fs.Duration("slow-read-delay", defaults.Failures.SlowReadDelay, "pause a slow reader takes before every read")
+	oversized := fs.Float64("oversized", 0, "share of messages padded past the content limit (0-1)")
+	fs.StringVar(&format, "format", "json", "report format: json or csv")
+	fs.StringVar(&out, "out", "", "write the report to `file` instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: switchboard-loadgen [-profile file] [flags]")
+		fmt.Fprintln(stderr)
+		fmt.Fprintln(stderr, "Flags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil, "", "", 0
+		}
+		return nil, "", "", 2
+	}
+	if fs.NArg() > 0 || (format != "json" && format != "csv") {
+		fs.Usage()
+		return nil, "", "", 2
+	}
+
+	profile = defaults
+	if *profilePath != "" {
+		loaded, err := loadgen.LoadProfile(*profilePath)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return nil, "", "", 2
+		}
+		profile = loaded
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "url":
+			profile.URL = *url
+		case "session":
+			profile.SessionID = *sessionID
+		case "instructors":
+			profile.Instructors = *instructors
+		case "students":
+			profile.Students = *students
+		case "duration":
+			profile.Duration = *duration
+		case "rate":
+			profile.MessagesPerMinute = *rate
+		case "mix":
+			parsed, err := loadgen.ParseMix(*mix)
+			if err != nil {
+				flagErr = err
+			}
+			profile.Mix = parsed
+		case "disconnect-every":
+			profile.Failures.DisconnectEvery = *disconnectEvery
+		case "reconnect-after":
+			profile.Failures.ReconnectAfter = *reconnectAfter
+		case "slow-readers":
+			profile.Failures.SlowReaders = *slowReaders
+		case "slow-read-delay":
+			profile.Failures.SlowReadDelay = *slowReadDelay
+		case "oversized":
+			profile.Failures.Oversized = *oversized
+		}
+	})
+	if flagErr == nil {
+		flagErr = profile.Validate()
+	}
+	if flagErr != nil {
+		fmt.Fprintf(stderr, "Invalid profile: %v\n", flagErr)
+		return nil, "", "", 2
+	}
+	return profile, format, out, 0
+}
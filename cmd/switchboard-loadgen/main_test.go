@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// FUNCTIONAL VALIDATION TEST: Flags override the profile file, which overrides the defaults
+func TestParseFlags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "soak.yml")
+	os.WriteFile(path, []byte("students: 80\nduration: 10m\nfailures:\n  oversized: 0.05\n"), 0o644)
+
+	var stderr bytes.Buffer
+	profile, format, _, code := parseFlags([]string{"-profile", path, "-duration", "30s", "-slow-readers", "0.1", "-format", "csv"}, &stderr)
+	if profile == nil {
+		t.Fatalf("Expected a profile, got exit %d: %s", code, stderr.String())
+	}
+	if profile.Students != 80 || profile.Duration != 30*time.Second || profile.Instructors != 2 {
+		t.Errorf("Expected the file's students, the flag's duration and default instructors, got %+v", profile)
+	}
+	if profile.Failures.Oversized != 0.05 || profile.Failures.SlowReaders != 0.1 || format != "csv" {
+		t.Errorf("Expected failures from both sources and csv output, got %+v %s", profile.Failures, format)
+	}
+
+	for _, args := range [][]string{
+		{"-format", "xml"},
+		{"-mix", "instructor_inbox"},
+		{"-oversized", "2"},
+		{"extra"},
+	} {
+		if profile, _, _, code := parseFlags(args, &stderr); profile != nil || code != 2 {
+			t.Errorf("Expected %v refused with exit 2, got %d", args, code)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"switchboard/internal/config"
+)
+
+// cliOptions holds the parsed command line
+type cliOptions struct {
+	configPath     string
+	overrides      config.Overrides
+	showVersion    bool
+	validateConfig bool
+	bootstrapDemo  bool
+}
+
+// parseFlags parses args into cliOptions
+// ARCHITECTURAL DISCOVERY: Only the settings operators change for a quick run are
+// flags; everything else stays in the environment or config file, and flags that were
+// not given leave those layers untouched
+func parseFlags(args []string, output io.Writer) (*cliOptions, error) {
+	opts := &cliOptions{}
+	fs := flag.NewFlagSet("switchboard", flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	fs.StringVar(&opts.configPath, "config", os.Getenv("SWITCHBOARD_CONFIG_FILE"), "JSON config `file` (env SWITCHBOARD_CONFIG_FILE)")
+	host := fs.String("host", "", "HTTP listen `address` (env SWITCHBOARD_HTTP_HOST)")
+	port := fs.Int("port", 0, "HTTP listen `port`, 0 picks a free one (env SWITCHBOARD_HTTP_PORT)")
+	dbPath := fs.String("db", "", "SQLite database `path` (env SWITCHBOARD_DATABASE_PATH)")
+	logLevel := fs.String("log-level", "", "minimum log `level`: "+strings.Join(config.LogLevels, ", ")+" (env SWITCHBOARD_LOG_LEVEL)")
+	restoreFrom := fs.String("restore-from", "", "import the state snapshot at `path` before loading sessions (warm standby failover)")
+	fs.BoolVar(&opts.showVersion, "version", false, "print version and build information, then exit")
+	fs.BoolVar(&opts.validateConfig, "validate-config", false, "print the effective configuration and exit nonzero if it is invalid")
+	bootstrapDemo, _ := strconv.ParseBool(os.Getenv("SWITCHBOARD_BOOTSTRAP_DEMO"))
+	fs.BoolVar(&opts.bootstrapDemo, "bootstrap-demo", bootstrapDemo, "on a first start, create a one-hour demo session and print how to join it (env SWITCHBOARD_BOOTSTRAP_DEMO)")
+
+	fs.Usage = func() {
+		fmt.Fprintf(output, "%s switchboard [flags]\n", colorize(output, colorBold, "Usage:"))
+		fmt.Fprintf(output, "       switchboard verify -db path [-repair]\n")
+		fmt.Fprintf(output, "       switchboard migrate -db path [-to N] [-dry-run]\n")
+		fmt.Fprintf(output, "       switchboard replay -db path -session id [-config file] [-json]\n\n")
+		fmt.Fprintf(output, "Precedence: %s\n\n", colorize(output, colorCyan, "flags > environment > config file > defaults"))
+		fmt.Fprintln(output, colorize(output, colorBold, "Flags:"))
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	// FUNCTIONAL DISCOVERY: fs.Visit only sees flags that were given, so "-port 0"
+	// still overrides while an absent -port leaves the port to lower layers
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "host":
+			opts.overrides.Host = host
+		case "port":
+			opts.overrides.Port = port
+		case "db":
+			opts.overrides.DatabasePath = dbPath
+		case "log-level":
+			opts.overrides.LogLevel = logLevel
+		case "restore-from":
+			opts.overrides.RestoreFrom = restoreFrom
+		}
+	})
+
+	return opts, nil
+}
+
+// validateConfig loads the layered configuration, prints it and reports validity
+// FUNCTIONAL DISCOVERY: Uses the same loader as startup, so a passing check means the
+// server would start with exactly the printed settings
+func validateConfig(opts *cliOptions, output io.Writer) int {
+	cfg, err := config.LoadLayered(opts.configPath, &opts.overrides)
+	if err != nil {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Configuration invalid:"), err)
+		return 1
+	}
+
+	configFile := opts.configPath
+	if configFile == "" {
+		configFile = "none"
+	}
+	fmt.Fprintf(output, "%s (config file: %s)\n", colorize(output, colorGreen, "Configuration valid"), configFile)
+
+	width := 0
+	settings := cfg.Describe()
+	for _, setting := range settings {
+		if len(setting.Key) > width {
+			width = len(setting.Key)
+		}
+	}
+	for _, setting := range settings {
+		key := fmt.Sprintf("%-*s", width, setting.Key)
+		fmt.Fprintf(output, "  %s  %s\n", colorize(output, colorCyan, key), setting.Value)
+	}
+	return 0
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FUNCTIONAL VALIDATION TEST: Only given flags become overrides
+func TestParseFlags(t *testing.T) {
+	t.Setenv("SWITCHBOARD_CONFIG_FILE", "env.json")
+
+	opts, err := parseFlags(nil, io.Discard)
+	if err != nil {
+		t.Fatalf("parseFlags without arguments failed: %v", err)
+	}
+	if opts.configPath != "env.json" {
+		t.Errorf("Expected -config to default to SWITCHBOARD_CONFIG_FILE, got %q", opts.configPath)
+	}
+	if opts.bootstrapDemo {
+		t.Error("Expected -bootstrap-demo off by default")
+	}
+	if opts.overrides.Port != nil || opts.overrides.Host != nil || opts.overrides.DatabasePath != nil || opts.overrides.LogLevel != nil || opts.overrides.RestoreFrom != nil {
+		t.Errorf("Absent flags should not override, got %+v", opts.overrides)
+	}
+
+	opts, err = parseFlags([]string{"-port", "0", "-db", "demo.db", "-log-level", "debug", "-config", "flag.json", "-restore-from", "state.json"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseFlags failed: %v", err)
+	}
+	if opts.overrides.Port == nil || *opts.overrides.Port != 0 {
+		t.Error("Explicit -port 0 should override")
+	}
+	if *opts.overrides.DatabasePath != "demo.db" || *opts.overrides.LogLevel != "debug" || *opts.overrides.RestoreFrom != "state.json" || opts.configPath != "flag.json" {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	t.Setenv("SWITCHBOARD_BOOTSTRAP_DEMO", "true")
+	if opts, _ := parseFlags(nil, io.Discard); !opts.bootstrapDemo {
+		t.Error("Expected -bootstrap-demo to default to SWITCHBOARD_BOOTSTRAP_DEMO")
+	}
+	if opts, _ := parseFlags([]string{"-bootstrap-demo=false"}, io.Discard); opts.bootstrapDemo {
+		t.Error("Expected the flag to override SWITCHBOARD_BOOTSTRAP_DEMO")
+	}
+
+	var usage bytes.Buffer
+	if _, err := parseFlags([]string{"-help"}, &usage); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("Expected flag.ErrHelp, got %v", err)
+	}
+	if !strings.Contains(usage.String(), "flags > environment > config file > defaults") {
+		t.Errorf("Usage should document precedence, got:\n%s", usage.String())
+	}
+	if strings.Contains(usage.String(), "\033[") {
+		t.Error("Usage written to a buffer should not contain color codes")
+	}
+
+	if _, err := parseFlags([]string{"serve"}, io.Discard); err == nil {
+		t.Error("Positional arguments should be rejected")
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: -validate-config prints effective settings and fails on errors
+func TestValidateConfig(t *testing.T) {
+	t.Setenv("SWITCHBOARD_CONFIG_FILE", "")
+	t.Setenv("SWITCHBOARD_HTTP_PORT", "9000")
+
+	opts, _ := parseFlags([]string{"-db", "file:demo.db?_auth_pass=hunter2"}, io.Discard)
+	var out bytes.Buffer
+	if code := validateConfig(opts, &out); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d:\n%s", code, out.String())
+	}
+	for _, want := range []string{"Configuration valid", "http.port", "9000", "_auth_pass=REDACTED"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "hunter2") {
+		t.Error("Secrets must not be printed")
+	}
+
+	badFile := filepath.Join(t.TempDir(), "bad.json")
+	os.WriteFile(badFile, []byte(`{"logging": {"level": "loud"}}`), 0o644)
+	opts, _ = parseFlags([]string{"-config", badFile}, io.Discard)
+	out.Reset()
+	if code := validateConfig(opts, &out); code == 0 {
+		t.Errorf("Invalid configuration should exit nonzero:\n%s", out.String())
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Log lines below the configured level are dropped
+func TestLevelWriter(t *testing.T) {
+	var out bytes.Buffer
+	level := "warn"
+	logger := log.New(newLevelWriter(&out, func() string { return level }), "", log.LstdFlags)
+
+	logger.Printf("DEBUG: verbose detail")
+	logger.Printf("Created session: id=1")
+	logger.Printf("WARNING: low disk")
+	logger.Printf("ERROR: write failed")
+
+	got := out.String()
+	if strings.Contains(got, "verbose detail") || strings.Contains(got, "Created session") {
+		t.Errorf("Debug and info lines should be dropped at warn level:\n%s", got)
+	}
+	if !strings.Contains(got, "low disk") || !strings.Contains(got, "write failed") {
+		t.Errorf("Warning and error lines should be kept:\n%s", got)
+	}
+	
+	// A reloaded level applies from the next line
+	level = "debug"
+	out.Reset()
+	logger.Printf("DEBUG: verbose detail")
+	if !strings.Contains(out.String(), "verbose detail") {
+		t.Errorf("Debug lines should be kept after lowering the level:\n%s", out.String())
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Version output includes injected build information
+func TestVersionString(t *testing.T) {
+	defer func(v, c, d string) { version, commit, buildDate = v, c, d }(version, commit, buildDate)
+	version, commit, buildDate = "v1.2.0", "abc1234", "2025-01-31"
+
+	if got := versionString(); !strings.HasPrefix(got, "switchboard v1.2.0 (commit abc1234, built 2025-01-31") {
+		t.Errorf("Unexpected version string: %q", got)
+	}
+}
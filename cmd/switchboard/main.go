@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
+	"runtime/pprof"
 	"syscall"
 	"time"
 
@@ -17,20 +21,51 @@ import (
 // FUNCTIONAL DISCOVERY: Main entry point with comprehensive error handling and signal management
 // Graceful shutdown on SIGINT/SIGTERM ensures proper resource cleanup
 func main() {
-	if err := run(); err != nil {
-		log.Fatal(err)
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:], os.Stdout))
+	}
+	
+	opts, err := parseFlags(os.Args[1:], os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, colorize(os.Stderr, colorRed, err.Error()))
+		os.Exit(2)
+	}
+	
+	switch {
+	case opts.showVersion:
+		fmt.Println(versionString())
+	case opts.validateConfig:
+		os.Exit(validateConfig(opts, os.Stdout))
+	default:
+		if err := runService(func(hooks serviceHooks) error { return run(hooks, opts) }); err != nil {
+			log.Fatal(err)
+		}
 	}
 }
 
 // ARCHITECTURAL DISCOVERY: Separate run function enables testing and error handling
-// Signal handling ensures graceful shutdown in production environments
-func run() error {
-	// STEP 1: Load configuration with precedence (file > env > defaults)
-	configPath := os.Getenv("SWITCHBOARD_CONFIG_FILE")
-	cfg := config.LoadConfigWithPrecedence(configPath)
+// Signal handling ensures graceful shutdown in production environments; hooks report
+// readiness and shutdown to systemd or the Windows service control manager
+func run(hooks serviceHooks, opts *cliOptions) error {
+	// STEP 1: Load configuration with precedence (flags > env > file > defaults)
+	cfg, err := config.LoadLayered(opts.configPath, &opts.overrides)
+	if err != nil {
+		return err
+	}
+	store := config.NewStore(cfg)
+	log.SetOutput(newLevelWriter(os.Stderr, store.LogLevel))
 	
 	// STEP 2: Create application with configuration
-	application, err := app.NewApplication(cfg)
+	application, err := app.NewApplicationWithStore(cfg, store)
 	if err != nil {
 		return fmt.Errorf("failed to create application: %w", err)
 	}
@@ -42,31 +77,136 @@ func run() error {
 	signalCh := make(chan os.Signal, 1)
 	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
 	
-	// STEP 4: Start application in background
-	appErrCh := make(chan error, 1)
+	// FUNCTIONAL DISCOVERY: SIGQUIT dumps goroutines to the log instead of killing
+	// the process, but only when profiling is enabled to keep default Go behavior
+	if cfg.Debug != nil && cfg.Debug.EnableProfiling {
+		quitCh := make(chan os.Signal, 1)
+		signal.Notify(quitCh, syscall.SIGQUIT)
+		defer signal.Stop(quitCh)
+		go func() {
+			for {
+				select {
+				case <-quitCh:
+					dumpGoroutines()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	
+	// FUNCTIONAL DISCOVERY: SIGHUP reloads the configuration; only the settings in
+	// config.EffectiveConfig change, and an invalid file leaves them as they were
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
 	go func() {
-		if err := application.Start(ctx); err != nil {
-			appErrCh <- err
+		for {
+			select {
+			case <-hupCh:
+				reloadConfig(store, opts)
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 	
-	// STEP 5: Wait for shutdown signal or application error
+	// STEP 4: Start application
+	// FUNCTIONAL DISCOVERY: Start returns once active sessions are loaded, the hub is
+	// running and the listener is accepting, so readiness is reported only then
+	if err := application.Start(ctx); err != nil {
+		return fmt.Errorf("application error: %w", err)
+	}
+	
+	// STEP 4.1: Create the demo session on a first start when asked
+	// FUNCTIONAL DISCOVERY: A failed bootstrap is logged, never fatal - the server
+	// itself is fine, only the demo is missing
+	if opts.bootstrapDemo {
+		if err := application.BootstrapDemo(ctx, os.Stdout); err != nil {
+			log.Printf("WARNING: Demo bootstrap failed: %v", err)
+		}
+	}
+	hooks.ready()
+	
+	// STEP 4.5: Send systemd watchdog keepalives while the health check passes
+	if interval := sdWatchdogInterval(); interval > 0 {
+		go keepalive(ctx, application, interval)
+	}
+	
+	// STEP 5: Wait for shutdown signal, service stop or application error
 	select {
-	case err := <-appErrCh:
-		// Application startup/runtime error
+	case err := <-application.Errors():
+		// Application runtime error
 		return fmt.Errorf("application error: %w", err)
 	case sig := <-signalCh:
 		// Graceful shutdown requested
 		log.Printf("Received signal %v, shutting down gracefully", sig)
-		
-		// FUNCTIONAL DISCOVERY: Timeout context prevents hanging shutdown
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer shutdownCancel()
-		
-		if err := application.Stop(shutdownCtx); err != nil {
-			return fmt.Errorf("shutdown error: %w", err)
+	case <-hooks.stop:
+		log.Printf("Service stop requested, shutting down gracefully")
+	}
+	
+	hooks.stopping()
+	
+	// FUNCTIONAL DISCOVERY: Timeout context prevents hanging shutdown
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	
+	if err := application.Stop(shutdownCtx); err != nil {
+		return fmt.Errorf("shutdown error: %w", err)
+	}
+	
+	return nil
+}
+
+// reloadConfig loads the configuration layers again and swaps them into store
+func reloadConfig(store *config.Store, opts *cliOptions) {
+	cfg, err := config.LoadLayered(opts.configPath, &opts.overrides)
+	if err == nil {
+		err = store.Reload(cfg)
+	}
+	if err != nil {
+		log.Printf("ERROR: Configuration reload refused, keeping current settings: %v", err)
+		return
+	}
+	current := store.Load()
+	log.Printf("Configuration reloaded: rate limit %d per %s, read timeout %s, ping interval %s, log level %s",
+		current.RateLimitMessages, current.RateLimitWindow, current.ReadTimeout, current.PingInterval, current.LogLevel)
+}
+
+// keepalive sends WATCHDOG=1 each interval while the application is healthy
+// FUNCTIONAL DISCOVERY: A failing health check withholds the keepalive, so systemd
+// restarts a process that is running but can no longer reach its database
+func keepalive(ctx context.Context, application *app.Application, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			err := application.HealthCheck(checkCtx)
+			cancel()
+			if err != nil {
+				log.Printf("Health check failed, withholding watchdog keepalive: %v", err)
+				continue
+			}
+			if err := sdNotify("WATCHDOG=1"); err != nil {
+				log.Printf("Failed to send watchdog keepalive: %v", err)
+			}
+		case <-ctx.Done():
+			return
 		}
-		
-		return nil
 	}
-}
\ No newline at end of file
+}
+
+// dumpGoroutines writes full goroutine stacks to the logger
+// TECHNICAL DISCOVERY: debug=2 matches the format of Go's default SIGQUIT dump
+// so existing tooling for reading panics also works on these logs
+func dumpGoroutines() {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		log.Printf("Failed to dump goroutines: %v", err)
+		return
+	}
+	log.Printf("SIGQUIT goroutine dump:\n%s", buf.String())
+}
@@ -1,9 +1,20 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 	"switchboard/internal/app"
 	"switchboard/internal/config"
+	"switchboard/pkg/types"
 )
 
 // ARCHITECTURAL VALIDATION TEST: Application structure and dependency injection
@@ -35,6 +46,8 @@ func TestApplication_ConfigurationValidation(t *testing.T) {
 
 // FUNCTIONAL VALIDATION TEST: Application construction validation
 func TestApplication_ConstructorValidation(t *testing.T) {
+	t.Chdir(t.TempDir()) // The default database path is relative; keep it out of the tree
+	
 	// Test constructor with nil config (should use defaults)
 	application, err := app.NewApplication(nil)
 	if application != nil || err == nil {
@@ -71,6 +84,7 @@ func TestApplication_DependencyStructure(t *testing.T) {
 	// Test that Application struct has expected fields for dependency injection
 	// This is architectural validation without requiring actual initialization
 	
+	t.Chdir(t.TempDir()) // The default database path is relative; keep it out of the tree
 	cfg := config.DefaultConfig()
 	
 	// Attempt construction (will fail due to database requirement)
@@ -125,7 +139,7 @@ func TestApplication_ErrorHandling(t *testing.T) {
 		{
 			name: "invalid_port",
 			modify: func(c *config.Config) {
-				c.HTTP.Port = 0
+				c.HTTP.Port = 70000
 			},
 		},
 		{
@@ -153,4 +167,149 @@ func TestApplication_ErrorHandling(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+
+// FUNCTIONAL VALIDATION TEST: Start returns only after the listener is bound
+func TestApplication_StartBindsListener(t *testing.T) {
+	// Migrations are resolved relative to the project root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalDir)
+	
+	newTestApp := func(port int) *app.Application {
+		cfg := config.DefaultConfig()
+		cfg.HTTP.Host = "127.0.0.1"
+		cfg.HTTP.Port = port
+		cfg.Database.Path = filepath.Join(t.TempDir(), "start.db")
+		application, err := app.NewApplication(cfg)
+		if err != nil {
+			t.Fatalf("NewApplication failed: %v", err)
+		}
+		return application
+	}
+	
+	first := newTestApp(0)
+	if err := first.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		first.Stop(ctx)
+	}()
+	
+	// Port 0 resolves to the kernel-assigned port, which accepts immediately
+	_, portStr, err := net.SplitHostPort(first.Addr())
+	if err != nil {
+		t.Fatalf("Invalid bound address %q: %v", first.Addr(), err)
+	}
+	port, _ := strconv.Atoi(portStr)
+	if port == 0 {
+		t.Fatalf("Expected a bound port, got address %q", first.Addr())
+	}
+	conn, err := net.Dial("tcp", first.Addr())
+	if err != nil {
+		t.Fatalf("Listener not accepting after Start returned: %v", err)
+	}
+	conn.Close()
+	
+	// Port in use is reported synchronously by Start
+	second := newTestApp(port)
+	defer second.Stop(context.Background())
+	if err := second.Start(context.Background()); err == nil {
+		t.Error("Expected Start to fail when the port is already in use")
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: -bootstrap-demo creates one demo session on an empty
+// database, prints it again after a restart and leaves other databases alone
+func TestApplication_BootstrapDemo(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "demo.db")
+	startApp := func(path string) *app.Application {
+		cfg := config.DefaultConfig()
+		cfg.HTTP.Host = "127.0.0.1"
+		cfg.HTTP.Port = 0
+		cfg.Database.Path = path
+		cfg.Database.EmbeddedMigrations = true
+		application, err := app.NewApplication(cfg)
+		if err != nil {
+			t.Fatalf("NewApplication failed: %v", err)
+		}
+		if err := application.Start(context.Background()); err != nil {
+			t.Fatalf("Start failed: %v", err)
+		}
+		return application
+	}
+	stopApp := func(application *app.Application) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		application.Stop(ctx)
+	}
+	ctx := context.Background()
+	
+	first := startApp(dbPath)
+	var printed bytes.Buffer
+	if err := first.BootstrapDemo(ctx, &printed); err != nil {
+		t.Fatalf("BootstrapDemo failed: %v", err)
+	}
+	output := printed.String()
+	instructorURL := regexp.MustCompile(`ws://\S+role=instructor\S*`).FindString(output)
+	restURL := regexp.MustCompile(`curl '(http://\S+)'`).FindStringSubmatch(output)
+	if instructorURL == "" || strings.Count(output, "role=student") != 3 || restURL == nil {
+		t.Fatalf("Expected instructor and three student URLs plus a curl example, got:\n%s", output)
+	}
+	
+	// The printed curl example works as pasted
+	resp, err := http.Get(restURL[1])
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", restURL[1], err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the printed REST URL to return 200, got status %d", resp.StatusCode)
+	}
+	
+	// The printed URL resolves its join code: without upgrade headers the handshake
+	// gets past session validation and fails at the upgrade
+	resp, err = http.Get("http://" + strings.TrimPrefix(instructorURL, "ws://"))
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", instructorURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected the join code to resolve, got status %d", resp.StatusCode)
+	}
+	stopApp(first)
+	
+	restarted := startApp(dbPath)
+	var again bytes.Buffer
+	if err := restarted.BootstrapDemo(ctx, &again); err != nil {
+		t.Fatalf("BootstrapDemo after restart failed: %v", err)
+	}
+	count, _ := restarted.Database().SessionCount(ctx)
+	code := regexp.MustCompile(`join_code=[\w-]+`).FindString(output)
+	if count != 1 || !strings.Contains(again.String(), code) {
+		t.Errorf("Expected the same demo session printed again, got %d sessions and:\n%s", count, again.String())
+	}
+	sessions, _ := restarted.Database().ListActiveSessions(ctx)
+	if len(sessions) != 1 || sessions[0].DurationMinutes != 60 || sessions[0].Metadata["demo"] != "true" {
+		t.Errorf("Expected one active demo session limited to an hour, got %+v", sessions)
+	}
+	stopApp(restarted)
+	
+	existing := startApp(filepath.Join(t.TempDir(), "existing.db"))
+	defer stopApp(existing)
+	session := &types.Session{ID: "real-session", Name: "CS101", CreatedBy: "instructor1", StudentIDs: []string{"student1"}, StartTime: time.Now(), Status: "active"}
+	if err := existing.Database().CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	var skipped bytes.Buffer
+	if err := existing.BootstrapDemo(ctx, &skipped); err != nil || skipped.Len() != 0 {
+		t.Errorf("Expected a database with sessions left alone, got %v:\n%s", err, skipped.String())
+	}
+	if count, _ := existing.Database().SessionCount(ctx); count != 1 {
+		t.Errorf("Expected no demo session added, got %d sessions", count)
+	}
+}
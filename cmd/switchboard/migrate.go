@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"switchboard/internal/database"
+	pkgdatabase "switchboard/pkg/database"
+)
+
+// runMigrate implements "switchboard migrate": bring a database to a migration version
+// FUNCTIONAL DISCOVERY: Without -to the database is brought to the latest migration;
+// a lower version rolls back through the .down.sql files. -dry-run prints the SQL
+// each step would execute and changes nothing. Exits 0 on success, 1 on failure and
+// 2 on usage errors
+func runMigrate(args []string, output io.Writer) int {
+	fs := flag.NewFlagSet("switchboard migrate", flag.ContinueOnError)
+	fs.SetOutput(output)
+	dbPath := fs.String("db", os.Getenv("SWITCHBOARD_DATABASE_PATH"), "SQLite database `path` to migrate (env SWITCHBOARD_DATABASE_PATH)")
+	migrationsPath := fs.String("migrations", "migrations", "`directory` holding the migration files")
+	target := fs.Int("to", -1, "migration `version` to end at; 0 rolls back everything (default latest)")
+	dryRun := fs.Bool("dry-run", false, "print the SQL that would run without changing the database")
+	fs.Usage = func() {
+		fmt.Fprintf(output, "%s switchboard migrate -db path [-to N] [-dry-run]\n\n", colorize(output, colorBold, "Usage:"))
+		fmt.Fprintln(output, colorize(output, colorBold, "Flags:"))
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if *dbPath == "" || fs.NArg() > 0 || *target < -1 {
+		fs.Usage()
+		return 2
+	}
+	// TECHNICAL DISCOVERY: A dry run opens read-only, which needs an existing file;
+	// a real run creates the database like the server does
+	if _, err := os.Stat(*dbPath); err != nil && *dryRun {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Cannot open database:"), err)
+		return 2
+	}
+
+	cfg := pkgdatabase.DefaultConfig()
+	cfg.DatabasePath = *dbPath
+	cfg.MigrationsPath = *migrationsPath
+	cfg.ReadOnly = *dryRun
+	manager, err := database.NewManager(cfg)
+	if err != nil {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Cannot open database:"), err)
+		return 1
+	}
+	defer manager.Close()
+	migrations := pkgdatabase.NewMigrationManager(manager.GetDB(), *migrationsPath)
+
+	if *dryRun {
+		steps, err := migrations.Plan(*target)
+		if err != nil {
+			fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Cannot plan migration:"), err)
+			return 1
+		}
+		if len(steps) == 0 {
+			fmt.Fprintln(output, "Nothing to do")
+			return 0
+		}
+		for _, step := range steps {
+			fmt.Fprintf(output, "-- %s\n%s\n\n", step, strings.TrimSpace(step.Statement()))
+		}
+		fmt.Fprintf(output, "-- %d step(s); dry run, nothing was changed\n", len(steps))
+		return 0
+	}
+
+	steps, err := migrations.MigrateTo(*target)
+	for _, step := range steps {
+		fmt.Fprintf(output, "  %s\n", colorize(output, colorCyan, step.String()))
+	}
+	if err != nil {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Migration failed:"), err)
+		return 1
+	}
+	if len(steps) == 0 {
+		fmt.Fprintln(output, "Nothing to do")
+		return 0
+	}
+	fmt.Fprintf(output, "%s\n", colorize(output, colorGreen, fmt.Sprintf("Ran %d migration step(s)", len(steps))))
+	return 0
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// FUNCTIONAL VALIDATION TEST: migrate applies, dry-runs and rolls back by version
+func TestRunMigrate(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "migrate.db")
+	migrations := []string{"-db", dbPath, "-migrations", "../../migrations"}
+
+	if code := runMigrate(nil, io.Discard); code != 2 {
+		t.Errorf("Expected usage error without -db, got exit %d", code)
+	}
+	if code := runMigrate(append(migrations, "-dry-run"), io.Discard); code != 2 {
+		t.Errorf("Expected a dry run of a missing file to be a usage error, got exit %d", code)
+	}
+
+	var output bytes.Buffer
+	if code := runMigrate(migrations, &output); code != 0 {
+		t.Fatalf("Expected exit 0 migrating a new database, got %d:\n%s", code, output.String())
+	}
+	if !strings.Contains(output.String(), "up 028_message_sizes") || !strings.Contains(output.String(), "Ran 28 migration step(s)") {
+		t.Errorf("Unexpected migrate output:\n%s", output.String())
+	}
+
+	output.Reset()
+	if code := runMigrate(append(migrations, "-to", "8", "-dry-run"), &output); code != 0 {
+		t.Fatalf("Expected exit 0 for a dry run, got %d:\n%s", code, output.String())
+	}
+	for _, want := range []string{"-- down 009_content_store", "ALTER TABLE messages DROP COLUMN content_hash;", "nothing was changed"} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("Expected %q in dry-run output:\n%s", want, output.String())
+		}
+	}
+
+	output.Reset()
+	if code := runMigrate(append(migrations, "-to", "8"), &output); code != 0 {
+		t.Fatalf("Expected exit 0 rolling back, got %d:\n%s", code, output.String())
+	}
+	if !strings.Contains(output.String(), "down 009_content_store") || !strings.Contains(output.String(), "Ran 20 migration step(s)") {
+		t.Errorf("Unexpected rollback output:\n%s", output.String())
+	}
+
+	output.Reset()
+	if code := runMigrate(append(migrations, "-to", "8"), &output); code != 0 || !strings.Contains(output.String(), "Nothing to do") {
+		t.Errorf("Expected nothing to do at the target version, got exit %d:\n%s", code, output.String())
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"switchboard/internal/config"
+	"switchboard/internal/database"
+	"switchboard/internal/replay"
+	pkgdatabase "switchboard/pkg/database"
+)
+
+// runReplay implements "switchboard replay": re-route a session's persisted history
+// through the current router and report where the outcome differs
+// FUNCTIONAL DISCOVERY: Exits 0 when every message routes as recorded, 1 when some
+// would be routed differently or the replay failed, and 2 on usage errors. The routing
+// rules come from -config and the environment, as the server would load them; the
+// database is opened read-only
+func runReplay(args []string, output io.Writer) int {
+	fs := flag.NewFlagSet("switchboard replay", flag.ContinueOnError)
+	fs.SetOutput(output)
+	dbPath := fs.String("db", os.Getenv("SWITCHBOARD_DATABASE_PATH"), "SQLite database `path` to read (env SWITCHBOARD_DATABASE_PATH)")
+	sessionID := fs.String("session", "", "`id` of the session to replay")
+	configPath := fs.String("config", "", "configuration `file` whose routing rules to replay under")
+	asJSON := fs.Bool("json", false, "write the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(output, "%s switchboard replay -db path -session id [-config file] [-json]\n\n", colorize(output, colorBold, "Usage:"))
+		fmt.Fprintln(output, colorize(output, colorBold, "Flags:"))
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if *dbPath == "" || *sessionID == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if _, err := os.Stat(*dbPath); err != nil {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Cannot open database:"), err)
+		return 2
+	}
+	cfg, err := config.LoadLayered(*configPath, nil)
+	if err != nil {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Cannot load configuration:"), err)
+		return 2
+	}
+
+	dbConfig := pkgdatabase.DefaultConfig()
+	dbConfig.DatabasePath = *dbPath
+	dbConfig.ReadOnly = true
+	manager, err := database.NewManager(dbConfig)
+	if err != nil {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Cannot open database:"), err)
+		return 1
+	}
+	defer manager.Close()
+
+	report, err := replay.Run(context.Background(), manager, *sessionID, cfg)
+	if err != nil {
+		fmt.Fprintf(output, "%s %v\n", colorize(output, colorRed, "Replay failed:"), err)
+		return 1
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(output)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return 1
+		}
+	} else {
+		printReplayReport(output, report)
+	}
+	if report.Differing() > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printReplayReport writes each message's replayed recipients and differences, then
+// what each recipient would receive
+func printReplayReport(output io.Writer, report *replay.Report) {
+	fmt.Fprintf(output, "Replayed %d message(s) of session %s with %d participant(s)\n\n", len(report.Messages), report.SessionID, report.Participants)
+
+	fmt.Fprintln(output, colorize(output, colorBold, "Messages:"))
+	for _, outcome := range report.Messages {
+		recipients := strings.Join(outcome.Recipients, ", ")
+		if outcome.Error != "" {
+			recipients = colorize(output, colorRed, "refused")
+		}
+		fmt.Fprintf(output, "  %s  %s  %s from %s -> %s\n", outcome.Timestamp.UTC().Format(time.RFC3339), colorize(output, colorCyan, outcome.MessageID), outcome.Type, outcome.FromUser, recipients)
+		for _, difference := range outcome.Differences {
+			fmt.Fprintf(output, "      %s\n", difference)
+		}
+	}
+
+	fmt.Fprintf(output, "\n%s\n", colorize(output, colorBold, "Deliveries:"))
+	recipients := make([]string, 0, len(report.Deliveries))
+	for recipient := range report.Deliveries {
+		recipients = append(recipients, recipient)
+	}
+	sort.Strings(recipients)
+	for _, recipient := range recipients {
+		messageIDs := report.Deliveries[recipient]
+		fmt.Fprintf(output, "  %s (%d): %s\n", colorize(output, colorCyan, recipient), len(messageIDs), strings.Join(messageIDs, ", "))
+	}
+
+	if len(report.RulesChanged) > 0 {
+		fmt.Fprintf(output, "\nRules changed since the session started: %s\n", strings.Join(report.RulesChanged, ", "))
+	}
+	if differing := report.Differing(); differing > 0 {
+		fmt.Fprintf(output, "\n%s\n", colorize(output, colorRed, fmt.Sprintf("%d message(s) would be routed differently under current rules", differing)))
+		return
+	}
+	fmt.Fprintf(output, "\n%s\n", colorize(output, colorGreen, "Every message routes as recorded"))
+}
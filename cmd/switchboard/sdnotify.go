@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends a state update to systemd for Type=notify units
+// ARCHITECTURAL DISCOVERY: The protocol is one datagram on $NOTIFY_SOCKET, so no
+// libsystemd dependency is needed; outside systemd the variable is unset and this
+// is a no-op
+func sdNotify(state string) error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return nil
+	}
+
+	// TECHNICAL DISCOVERY: A leading '@' names a Linux abstract socket
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
+
+// sdWatchdogInterval returns how often systemd expects WATCHDOG=1, or 0 if disabled
+// FUNCTIONAL DISCOVERY: Keepalives are sent at half of WatchdogSec, as systemd
+// recommends, so one slow health check does not trigger a restart
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	// WATCHDOG_PID, when set, names the process the watchdog applies to
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond / 2
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+)
+
+// serviceHooks connects run to the host service manager
+// ARCHITECTURAL DISCOVERY: run only knows "ready", "stopping" and "stop requested";
+// systemd and the Windows service control manager each supply their own hooks
+type serviceHooks struct {
+	stop     <-chan struct{} // Closed when the service manager requests shutdown
+	ready    func()          // Called once Start has returned and the listener accepts
+	stopping func()          // Called when graceful shutdown begins
+}
+
+// systemdHooks reports lifecycle transitions through sd_notify
+// FUNCTIONAL DISCOVERY: Also used for plain console runs, where NOTIFY_SOCKET is
+// unset and every notification is a no-op
+func systemdHooks() serviceHooks {
+	return serviceHooks{
+		stop: nil, // Shutdown arrives as SIGTERM, handled in run
+		ready: func() {
+			if err := sdNotify("READY=1"); err != nil {
+				log.Printf("Failed to notify systemd of readiness: %v", err)
+			}
+		},
+		stopping: func() {
+			if err := sdNotify("STOPPING=1"); err != nil {
+				log.Printf("Failed to notify systemd of shutdown: %v", err)
+			}
+		},
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package main
+
+// runService runs the server under systemd or a console session
+func runService(run func(serviceHooks) error) error {
+	return run(systemdHooks())
+}
@@ -0,0 +1,123 @@
+//go:build !windows
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// listenNotifySocket stands in for systemd's notification socket
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to create notify socket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+	return conn
+}
+
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 256)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("No notification received: %v", err)
+	}
+	return string(buf[:n])
+}
+
+// FUNCTIONAL VALIDATION TEST: sd_notify datagrams and watchdog interval parsing
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := sdNotify("READY=1"); err != nil {
+		t.Errorf("sdNotify without NOTIFY_SOCKET should be a no-op, got %v", err)
+	}
+
+	conn := listenNotifySocket(t)
+	if err := sdNotify("READY=1"); err != nil {
+		t.Fatalf("sdNotify failed: %v", err)
+	}
+	if got := readNotification(t, conn); got != "READY=1" {
+		t.Errorf("Expected READY=1, got %q", got)
+	}
+
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if interval := sdWatchdogInterval(); interval != 15*time.Second {
+		t.Errorf("Expected keepalive every 15s (half of WatchdogSec), got %v", interval)
+	}
+
+	t.Setenv("WATCHDOG_PID", "1")
+	if interval := sdWatchdogInterval(); interval != 0 {
+		t.Errorf("Watchdog for another PID should be ignored, got %v", interval)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: READY=1 is sent only once the server accepts requests
+func TestRun_ServiceLifecycle(t *testing.T) {
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := probe.Addr().(*net.TCPAddr).Port
+	probe.Close()
+
+	t.Setenv("SWITCHBOARD_CONFIG_FILE", "")
+	opts, err := parseFlags([]string{
+		"-host", "127.0.0.1",
+		"-port", fmt.Sprint(port),
+		"-db", filepath.Join(t.TempDir(), "service.db"),
+	}, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn := listenNotifySocket(t)
+
+	// Migrations are resolved relative to the project root
+	originalDir, _ := os.Getwd()
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalDir)
+
+	stop := make(chan struct{})
+	hooks := systemdHooks()
+	hooks.stop = stop
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- run(hooks, opts) }()
+
+	if got := readNotification(t, conn); got != "READY=1" {
+		t.Fatalf("Expected READY=1, got %q", got)
+	}
+
+	// No retry loop: readiness must mean the listener is already accepting
+	resp, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d/health", port))
+	if err != nil {
+		t.Fatalf("Server not accepting after READY=1: %v", err)
+	}
+	resp.Body.Close()
+
+	close(stop)
+	if got := readNotification(t, conn); got != "STOPPING=1" {
+		t.Errorf("Expected STOPPING=1, got %q", got)
+	}
+
+	select {
+	case err := <-runErr:
+		if err != nil {
+			t.Errorf("run returned error: %v", err)
+		}
+	case <-time.After(35 * time.Second):
+		t.Fatal("run did not return after service stop")
+	}
+}
@@ -0,0 +1,192 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// Windows service control manager bindings
+// ARCHITECTURAL DISCOVERY: Only the four advapi32 calls a single-process service
+// needs are bound here, keeping golang.org/x/sys out of the module
+var (
+	advapi32                         = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcherW  = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerEx = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus             = advapi32.NewProc("SetServiceStatus")
+)
+
+const (
+	serviceName = "switchboard"
+
+	serviceWin32OwnProcess = 0x00000010
+
+	serviceStopped      = 1
+	serviceStartPending = 2
+	serviceStopPending  = 3
+	serviceRunning      = 4
+
+	serviceAcceptStop     = 0x00000001
+	serviceAcceptShutdown = 0x00000004
+
+	serviceControlStop        = 1
+	serviceControlInterrogate = 4
+	serviceControlShutdown    = 5
+
+	errorCallNotImplemented             = 120
+	errorFailedServiceControllerConnect = 1063
+	errorServiceSpecificError           = 1066
+
+	// TECHNICAL DISCOVERY: Matches the 30 second shutdown timeout in run
+	stopWaitHintMillis = 30000
+)
+
+// serviceStatus mirrors SERVICE_STATUS
+type serviceStatus struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+}
+
+// serviceTableEntry mirrors SERVICE_TABLE_ENTRYW
+type serviceTableEntry struct {
+	serviceName *uint16
+	serviceProc uintptr
+}
+
+// windowsService holds the state shared between the SCM callbacks and run
+// TECHNICAL DISCOVERY: The SCM calls back on its own threads, so the status handle
+// and stop channel are package state guarded by a mutex
+type windowsService struct {
+	run      func(serviceHooks) error
+	runErr   error
+	handle   uintptr
+	stop     chan struct{}
+	stopOnce sync.Once
+	mu       sync.Mutex
+}
+
+var activeService *windowsService
+
+// runService runs the server as a Windows service when started by the SCM,
+// otherwise as a console process
+// FUNCTIONAL DISCOVERY: The dispatcher fails with ERROR_FAILED_SERVICE_CONTROLLER_CONNECT
+// when there is no SCM on the other end, which is how console runs are detected
+func runService(run func(serviceHooks) error) error {
+	activeService = &windowsService{
+		run:  run,
+		stop: make(chan struct{}),
+	}
+
+	name, err := syscall.UTF16PtrFromString(serviceName)
+	if err != nil {
+		return err
+	}
+	table := []serviceTableEntry{
+		{serviceName: name, serviceProc: syscall.NewCallback(serviceMain)},
+		{}, // Terminator
+	}
+
+	// Blocks until serviceMain returns when running under the SCM
+	r, _, callErr := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0])))
+	if r == 0 {
+		var errno syscall.Errno
+		if errors.As(callErr, &errno) && errno == errorFailedServiceControllerConnect {
+			return run(systemdHooks())
+		}
+		return callErr
+	}
+	return activeService.runErr
+}
+
+// serviceMain is the SERVICE_MAIN_FUNCTION invoked by the dispatcher
+func serviceMain(argc uint32, argv **uint16) uintptr {
+	svc := activeService
+
+	name, _ := syscall.UTF16PtrFromString(serviceName)
+	handle, _, err := procRegisterServiceCtrlHandlerEx.Call(
+		uintptr(unsafe.Pointer(name)),
+		syscall.NewCallback(serviceControlHandler),
+		0,
+	)
+	if handle == 0 {
+		svc.runErr = err
+		return 0
+	}
+
+	svc.mu.Lock()
+	svc.handle = handle
+	svc.mu.Unlock()
+
+	svc.setStatus(serviceStartPending, 0, 0)
+
+	svc.runErr = svc.run(serviceHooks{
+		stop: svc.stop,
+		ready: func() {
+			svc.setStatus(serviceRunning, serviceAcceptStop|serviceAcceptShutdown, 0)
+		},
+		stopping: func() {
+			svc.setStatus(serviceStopPending, 0, stopWaitHintMillis)
+		},
+	})
+
+	if svc.runErr != nil {
+		log.Printf("Service stopped with error: %v", svc.runErr)
+		svc.setStatusExit(errorServiceSpecificError, 1)
+	} else {
+		svc.setStatus(serviceStopped, 0, 0)
+	}
+	return 0
+}
+
+// serviceControlHandler is the HANDLER_FUNCTION_EX invoked for control requests
+// FUNCTIONAL DISCOVERY: Stop and system shutdown both close the stop channel, so
+// run drains connections exactly as it does for SIGTERM
+func serviceControlHandler(control, eventType uint32, eventData, context uintptr) uintptr {
+	svc := activeService
+
+	switch control {
+	case serviceControlStop, serviceControlShutdown:
+		svc.stopOnce.Do(func() { close(svc.stop) })
+		return 0
+	case serviceControlInterrogate:
+		return 0
+	default:
+		return errorCallNotImplemented
+	}
+}
+
+func (svc *windowsService) setStatus(state, accepts, waitHint uint32) {
+	svc.report(serviceStatus{
+		ServiceType:      serviceWin32OwnProcess,
+		CurrentState:     state,
+		ControlsAccepted: accepts,
+		WaitHint:         waitHint,
+	})
+}
+
+func (svc *windowsService) setStatusExit(win32Code, serviceCode uint32) {
+	svc.report(serviceStatus{
+		ServiceType:             serviceWin32OwnProcess,
+		CurrentState:            serviceStopped,
+		Win32ExitCode:           win32Code,
+		ServiceSpecificExitCode: serviceCode,
+	})
+}
+
+func (svc *windowsService) report(status serviceStatus) {
+	svc.mu.Lock()
+	defer svc.mu.Unlock()
+
+	if r, _, err := procSetServiceStatus.Call(svc.handle, uintptr(unsafe.Pointer(&status))); r == 0 {
+		log.Printf("Failed to report service status %d: %v", status.CurrentState, err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pkgdatabase "switchboard/pkg/database"
+)
+
+// FUNCTIONAL VALIDATION TEST: verify reports problems read-only and -repair fixes the safe ones
+func TestRunVerify(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "verify.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pkgdatabase.NewMigrationManager(db, "../../migrations").ApplyMigrations(); err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO sessions (id, name, created_by, student_ids, start_time, status)
+		VALUES ('s1', 'Class', 'instructor1', '["student1"]', '2026-03-02 10:00:00', 'ended')`); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	if code := runVerify(nil, io.Discard); code != 2 {
+		t.Errorf("Expected usage error without -db, got exit %d", code)
+	}
+	if code := runVerify([]string{"-db", filepath.Join(t.TempDir(), "missing.db")}, io.Discard); code != 2 {
+		t.Errorf("Expected a missing file to be a usage error, got exit %d", code)
+	}
+
+	var output bytes.Buffer
+	if code := runVerify([]string{"-db", dbPath}, &output); code != 1 {
+		t.Fatalf("Expected exit 1 with an unrepaired problem, got %d:\n%s", code, output.String())
+	}
+	for _, want := range []string{"sessions/s1: ended without end_time [repairable]", "1 can be fixed with -repair", "skipped ("} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, output.String())
+		}
+	}
+
+	output.Reset()
+	if code := runVerify([]string{"-db", dbPath, "-repair"}, &output); code != 0 {
+		t.Fatalf("Expected exit 0 after repair, got %d:\n%s", code, output.String())
+	}
+	if !strings.Contains(output.String(), "Repaired 1 issue(s)") || !strings.Contains(output.String(), "Database is consistent") {
+		t.Errorf("Unexpected repair output:\n%s", output.String())
+	}
+}
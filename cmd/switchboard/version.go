@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build information injected at link time, e.g.
+// go build -ldflags "-X main.version=v1.2.0 -X main.commit=abc1234 -X main.buildDate=2025-01-31"
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// versionString describes the running binary for -version
+// FUNCTIONAL DISCOVERY: Without ldflags the VCS revision recorded by the Go toolchain
+// is used, so plain "go build" binaries can still be traced to a commit
+func versionString() string {
+	rev, date := commit, buildDate
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && rev == "":
+				rev = setting.Value
+			case setting.Key == "vcs.time" && date == "":
+				date = setting.Value
+			}
+		}
+	}
+	if rev == "" {
+		rev = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return fmt.Sprintf("switchboard %s (commit %s, built %s, %s %s/%s)",
+		version, rev, date, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,260 @@
+// Package alerting raises alerts when internal counters breach configured thresholds
+// ARCHITECTURAL DISCOVERY: For deployments without Prometheus; the same write, delivery
+// and connection counters /metrics exposes are read here every interval, and what to
+// do about a breach - log, webhook, tell instructors - is plugged in as named actions
+package alerting
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"switchboard/internal/clock"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// DefaultInterval is how often rules are evaluated when NewEvaluator is given zero
+const DefaultInterval = 15 * time.Second
+
+var (
+	ErrEvaluatorAlreadyRunning = errors.New("alert evaluator is already running")
+	ErrEvaluatorNotRunning     = errors.New("alert evaluator is not running")
+)
+
+// Rule raises an alert when Metric stays above Threshold for For
+type Rule struct {
+	Name       string
+	Metric     string        // One of types.AlertMetrics
+	Threshold  float64       // Breached when the metric is strictly above it
+	For        time.Duration // How long the breach must last; 0 fires on the first breaching evaluation
+	MinSamples uint64        // Observations an interval needs before it can breach, e.g. writes for a latency rule
+	Actions    []string      // Names given to SetAction
+}
+
+// DefaultRules returns the built-in rules, taking actions
+// FUNCTIONAL DISCOVERY: Write latency p95 above 200ms and more than 1% of deliveries
+// failing, each for a minute. The sample floors keep a quiet classroom - three slow
+// writes, or one failure among a handful of deliveries - from paging anyone
+func DefaultRules(actions []string) []Rule {
+	return []Rule{
+		{Name: "write_latency_p95", Metric: types.AlertMetricWriteLatencyP95, Threshold: 200, For: time.Minute, MinSamples: 20, Actions: actions},
+		{Name: "delivery_failure_rate", Metric: types.AlertMetricDeliveryFailureRate, Threshold: 0.01, For: time.Minute, MinSamples: 100, Actions: actions},
+	}
+}
+
+// Sample is a metric's value over one interval and the observations behind it
+type Sample struct {
+	Value   float64
+	Samples uint64
+}
+
+// Source reads a metric over the interval since its previous call
+type Source func() Sample
+
+// Action is told about each alert as it fires or resolves
+// TECHNICAL DISCOVERY: Called on the evaluator goroutine; an action that may block,
+// like a webhook, queues the alert and returns
+type Action func(types.Alert)
+
+// ruleState is where one rule stands between evaluations
+type ruleState struct {
+	since  time.Time // Start of the current breach; zero while not breaching
+	firing bool
+}
+
+// Evaluator reads every metric once an interval and checks each rule against it
+// FUNCTIONAL DISCOVERY: A rule fires once, when its breach has lasted For, and stays
+// quiet until it resolves, which it does once on the first evaluation that does not
+// breach. An interval with fewer than MinSamples observations does not breach, so a
+// class going quiet resolves its alerts
+type Evaluator struct {
+	rules    []Rule
+	interval time.Duration
+	clock    interfaces.Clock
+	sources  map[string]Source
+	actions  map[string]Action
+
+	states  []ruleState // One per rule
+	stateMu sync.Mutex  // Serializes evaluations
+
+	shutdownChannel chan struct{} // Closed by Stop
+	done            chan struct{} // Closed when the run goroutine exits
+
+	running bool
+	mu      sync.Mutex
+}
+
+// NewEvaluator creates an evaluator of rules every interval; interval <= 0 means DefaultInterval
+func NewEvaluator(rules []Rule, interval time.Duration) *Evaluator {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	return &Evaluator{
+		rules:           rules,
+		interval:        interval,
+		clock:           clock.Real(),
+		sources:         make(map[string]Source),
+		actions:         make(map[string]Action),
+		states:          make([]ruleState, len(rules)),
+		shutdownChannel: make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// SetClock replaces the clock evaluations are timed and stamped with
+// TECHNICAL DISCOVERY: Set before Start, like SetSource and SetAction; none lock
+func (e *Evaluator) SetClock(c interfaces.Clock) {
+	e.clock = c
+}
+
+// SetSource sets where metric is read from; rules on a metric without a source never breach
+func (e *Evaluator) SetSource(metric string, source Source) {
+	e.sources[metric] = source
+}
+
+// SetAction makes name available to rules' Actions
+func (e *Evaluator) SetAction(name string, action Action) {
+	e.actions[name] = action
+}
+
+// Interval returns how often rules are evaluated
+func (e *Evaluator) Interval() time.Duration {
+	return e.interval
+}
+
+// Start begins evaluating every interval
+// FUNCTIONAL DISCOVERY: The first evaluation is one interval in, so each source's
+// first reading covers a whole interval
+func (e *Evaluator) Start(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.running {
+		return ErrEvaluatorAlreadyRunning
+	}
+	e.running = true
+
+	for _, rule := range e.rules {
+		if _, exists := e.sources[rule.Metric]; !exists {
+			log.Printf("WARNING: Alert rule %s watches %s, which is not measured here; it will never fire", rule.Name, rule.Metric)
+		}
+		for _, action := range rule.Actions {
+			if _, exists := e.actions[action]; !exists {
+				log.Printf("WARNING: Alert rule %s names action %s, which is not available; it is skipped", rule.Name, action)
+			}
+		}
+	}
+
+	log.Printf("Evaluating %d alert rules every %s", len(e.rules), e.interval)
+	go e.run(ctx, e.clock.NewTicker(e.interval))
+	return nil
+}
+
+// Stop ends evaluation; firing alerts are left unresolved
+func (e *Evaluator) Stop() error {
+	e.mu.Lock()
+	if !e.running {
+		e.mu.Unlock()
+		return ErrEvaluatorNotRunning
+	}
+	e.running = false
+	close(e.shutdownChannel)
+	e.mu.Unlock()
+
+	<-e.done
+	return nil
+}
+
+// run evaluates on each tick; the ticker is made by Start so the first interval runs
+// from Start rather than from whenever this goroutine is scheduled
+func (e *Evaluator) run(ctx context.Context, ticker interfaces.Ticker) {
+	defer close(e.done)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			e.Evaluate()
+		case <-e.shutdownChannel:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Evaluate reads every source once, applies each rule and runs the actions of the
+// alerts that fired or resolved, which it returns
+// TECHNICAL DISCOVERY: Sources measure since their previous call, so each is read
+// exactly once per evaluation however many rules watch it
+func (e *Evaluator) Evaluate() []types.Alert {
+	e.stateMu.Lock()
+	now := e.clock.Now()
+	samples := make(map[string]Sample, len(e.sources))
+	for metric, source := range e.sources {
+		samples[metric] = source()
+	}
+
+	var alerts []types.Alert
+	var actions [][]string
+	for i, rule := range e.rules {
+		sample, measured := samples[rule.Metric]
+		breaching := measured && sample.Samples >= rule.MinSamples && sample.Value > rule.Threshold
+		state := &e.states[i]
+
+		status := ""
+		since := state.since
+		switch {
+		case breaching && since.IsZero():
+			since = now
+			state.since = now
+			fallthrough
+		case breaching:
+			if !state.firing && now.Sub(since) >= rule.For {
+				state.firing = true
+				status = types.AlertFiring
+			}
+		default:
+			state.since = time.Time{}
+			if state.firing {
+				state.firing = false
+				status = types.AlertResolved
+			}
+		}
+		if status == "" {
+			continue
+		}
+		alerts = append(alerts, types.Alert{
+			Rule:      rule.Name,
+			Metric:    rule.Metric,
+			Status:    status,
+			Value:     sample.Value,
+			Threshold: rule.Threshold,
+			Since:     since,
+			At:        now,
+		})
+		actions = append(actions, rule.Actions)
+	}
+	e.stateMu.Unlock()
+
+	for i, alert := range alerts {
+		for _, name := range actions[i] {
+			if action, exists := e.actions[name]; exists {
+				action(alert)
+			}
+		}
+	}
+	return alerts
+}
+
+// LogAlert is the log action: an ERROR: line when an alert fires, an info line when
+// it resolves
+func LogAlert(alert types.Alert) {
+	if alert.Status == types.AlertFiring {
+		log.Printf("ERROR: Alert %s firing: %s is %g, above %g since %s", alert.Rule, alert.Metric, alert.Value, alert.Threshold, alert.Since.Format(time.RFC3339))
+		return
+	}
+	log.Printf("Alert %s resolved: %s is %g after %s above %g", alert.Rule, alert.Metric, alert.Value, alert.At.Sub(alert.Since).Round(time.Second), alert.Threshold)
+}
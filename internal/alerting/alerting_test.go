@@ -0,0 +1,203 @@
+package alerting
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/pkg/types"
+)
+
+// recordingAction collects the alerts an action is told about
+type recordingAction struct {
+	mu     sync.Mutex
+	alerts []types.Alert
+}
+
+func (r *recordingAction) notify(alert types.Alert) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+}
+
+func (r *recordingAction) statuses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]string, len(r.alerts))
+	for i, alert := range r.alerts {
+		statuses[i] = alert.Rule + ":" + alert.Status
+	}
+	return statuses
+}
+
+// TestEvaluator_FiresOnceAndResolves tests functional validation - a breach fires only
+// after lasting For, does not repeat while it lasts, and resolves once
+func TestEvaluator_FiresOnceAndResolves(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	clock := testsupport.NewFakeClock(start)
+	latency := Sample{}
+	rule := Rule{Name: "slow_writes", Metric: types.AlertMetricWriteLatencyP95, Threshold: 200, For: 30 * time.Second, MinSamples: 10, Actions: []string{"record", "missing"}}
+	evaluator := NewEvaluator([]Rule{rule}, 15*time.Second)
+	evaluator.SetClock(clock)
+	evaluator.SetSource(types.AlertMetricWriteLatencyP95, func() Sample { return latency })
+	recorder := &recordingAction{}
+	evaluator.SetAction("record", recorder.notify)
+
+	step := func(sample Sample) []types.Alert {
+		latency = sample
+		clock.Advance(15 * time.Second)
+		return evaluator.Evaluate()
+	}
+
+	if alerts := step(Sample{Value: 50, Samples: 100}); len(alerts) != 0 {
+		t.Fatalf("Expected no alert below the threshold, got %+v", alerts)
+	}
+	breachStart := clock.Now().Add(15 * time.Second)
+	for i := 0; i < 2; i++ {
+		if alerts := step(Sample{Value: 350, Samples: 100}); len(alerts) != 0 {
+			t.Fatalf("Expected no alert before the breach lasted 30s, got %+v at step %d", alerts, i)
+		}
+	}
+	alerts := step(Sample{Value: 400, Samples: 100})
+	if len(alerts) != 1 {
+		t.Fatalf("Expected the rule to fire once the breach lasted 30s, got %+v", alerts)
+	}
+	if fired := alerts[0]; fired.Status != types.AlertFiring || fired.Value != 400 || fired.Threshold != 200 || !fired.Since.Equal(breachStart) || !fired.At.Equal(clock.Now()) {
+		t.Errorf("Unexpected firing alert: %+v", fired)
+	}
+	for i := 0; i < 3; i++ {
+		if alerts := step(Sample{Value: 500, Samples: 100}); len(alerts) != 0 {
+			t.Fatalf("Expected no repeat while still firing, got %+v", alerts)
+		}
+	}
+
+	alerts = step(Sample{Value: 120, Samples: 100})
+	if len(alerts) != 1 || alerts[0].Status != types.AlertResolved || alerts[0].Value != 120 || !alerts[0].Since.Equal(breachStart) {
+		t.Fatalf("Expected one resolution carrying the breach start, got %+v", alerts)
+	}
+	if alerts := step(Sample{Value: 100, Samples: 100}); len(alerts) != 0 {
+		t.Errorf("Expected no second resolution, got %+v", alerts)
+	}
+
+	if got := recorder.statuses(); len(got) != 2 || got[0] != "slow_writes:firing" || got[1] != "slow_writes:resolved" {
+		t.Errorf("Expected the action told of the firing and the resolution, got %v", got)
+	}
+}
+
+// TestEvaluator_BreachRules tests edge case validation - too few samples never breach
+// and end a breach, For 0 fires at once, an interrupted breach starts over, and a rule
+// on an unmeasured metric stays quiet
+func TestEvaluator_BreachRules(t *testing.T) {
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	var failures Sample
+	evaluator := NewEvaluator([]Rule{
+		{Name: "delivery", Metric: types.AlertMetricDeliveryFailureRate, Threshold: 0.01, For: 0, MinSamples: 100},
+		{Name: "sustained", Metric: types.AlertMetricDeliveryFailureRate, Threshold: 0.01, For: 20 * time.Second},
+		{Name: "drops", Metric: types.AlertMetricConnectionDrops, Threshold: 0},
+	}, 10*time.Second)
+	evaluator.SetClock(clock)
+	evaluator.SetSource(types.AlertMetricDeliveryFailureRate, func() Sample { return failures })
+
+	step := func(sample Sample) []string {
+		failures = sample
+		clock.Advance(10 * time.Second)
+		var got []string
+		for _, alert := range evaluator.Evaluate() {
+			got = append(got, alert.Rule+":"+alert.Status)
+		}
+		return got
+	}
+
+	if got := step(Sample{Value: 0.5, Samples: 4}); len(got) != 0 {
+		t.Errorf("Expected 4 deliveries too few for delivery, and sustained not yet due, got %v", got)
+	}
+	if got := step(Sample{Value: 0.05, Samples: 200}); len(got) != 1 || got[0] != "delivery:firing" {
+		t.Errorf("Expected delivery to fire at once with For 0, got %v", got)
+	}
+	if got := step(Sample{Value: 0.05, Samples: 3}); len(got) != 2 || got[0] != "delivery:resolved" || got[1] != "sustained:firing" {
+		t.Errorf("Expected too few samples to resolve delivery while sustained fired after 20s, got %v", got)
+	}
+	if got := step(Sample{}); len(got) != 1 || got[0] != "sustained:resolved" {
+		t.Errorf("Expected a quiet interval to resolve sustained, got %v", got)
+	}
+	step(Sample{Value: 0.05, Samples: 200})
+	step(Sample{Value: 0, Samples: 200})
+	if got := step(Sample{Value: 0.05, Samples: 200}); len(got) != 1 || got[0] != "delivery:firing" {
+		t.Errorf("Expected delivery to fire afresh and sustained to start its 20s over, got %v", got)
+	}
+}
+
+// TestEvaluator_StartStop tests integration validation - a running evaluator reads its
+// sources once per interval and stops cleanly
+func TestEvaluator_StartStop(t *testing.T) {
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	evaluator := NewEvaluator([]Rule{{Name: "drops", Metric: types.AlertMetricConnectionDrops, Threshold: 5, Actions: []string{"record"}}}, 0)
+	if evaluator.Interval() != DefaultInterval {
+		t.Errorf("Expected the default interval, got %s", evaluator.Interval())
+	}
+	evaluator.SetClock(clock)
+	reads := make(chan struct{}, 10)
+	evaluator.SetSource(types.AlertMetricConnectionDrops, func() Sample {
+		reads <- struct{}{}
+		return Sample{Value: 10, Samples: 10}
+	})
+	recorder := &recordingAction{}
+	evaluator.SetAction("record", recorder.notify)
+
+	if err := evaluator.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := evaluator.Start(context.Background()); err != ErrEvaluatorAlreadyRunning {
+		t.Errorf("Expected ErrEvaluatorAlreadyRunning, got %v", err)
+	}
+	clock.Advance(DefaultInterval)
+	select {
+	case <-reads:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the source read after one interval")
+	}
+	if err := evaluator.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := evaluator.Stop(); err != ErrEvaluatorNotRunning {
+		t.Errorf("Expected ErrEvaluatorNotRunning, got %v", err)
+	}
+	if got := recorder.statuses(); len(got) != 1 || got[0] != "drops:firing" {
+		t.Errorf("Expected the drops rule fired once, got %v", got)
+	}
+}
+
+// TestSources tests functional validation - counter sources report the change since
+// their previous read
+func TestSources(t *testing.T) {
+	var total, failed uint64 = 1000, 10
+	rate := FailureRate(func() (uint64, uint64) { return total, failed })
+	if sample := rate(); sample != (Sample{}) {
+		t.Errorf("Expected nothing before the counters move, got %+v", sample)
+	}
+	total, failed = 1200, 15
+	if sample := rate(); sample.Samples != 200 || sample.Value != 0.025 {
+		t.Errorf("Expected 5 of 200 failed, got %+v", sample)
+	}
+
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	var drops uint64 = 7
+	perMinute := PerMinute(func() uint64 { return drops }, clock)
+	drops = 12
+	clock.Advance(30 * time.Second)
+	if sample := perMinute(); sample.Samples != 5 || sample.Value != 10 {
+		t.Errorf("Expected 5 drops in 30s to read 10 a minute, got %+v", sample)
+	}
+
+	p95 := LatencyP95(func() types.WriteLatencyStats { return types.WriteLatencyStats{Count: 40, P95Ms: 250} })
+	if sample := p95(); sample.Samples != 40 || sample.Value != 250 {
+		t.Errorf("Expected the window's p95 and count, got %+v", sample)
+	}
+
+	defaults := DefaultRules([]string{types.AlertActionLog})
+	if len(defaults) != 2 || defaults[0].Threshold != 200 || defaults[1].Threshold != 0.01 || defaults[1].Actions[0] != types.AlertActionLog {
+		t.Errorf("Unexpected default rules: %+v", defaults)
+	}
+}
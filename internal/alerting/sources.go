@@ -0,0 +1,56 @@
+package alerting
+
+import (
+	"sync"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// FailureRate returns a Source reporting the failed share of the events counted since
+// its previous call; counters returns running totals that only grow
+// FUNCTIONAL DISCOVERY: Samples is the events in the interval, so a rule's MinSamples
+// is how many writes or deliveries it takes to judge the rate
+func FailureRate(counters func() (total, failed uint64)) Source {
+	var mu sync.Mutex
+	lastTotal, lastFailed := counters()
+	return func() Sample {
+		mu.Lock()
+		defer mu.Unlock()
+		total, failed := counters()
+		events, failures := total-lastTotal, failed-lastFailed
+		lastTotal, lastFailed = total, failed
+		if events == 0 {
+			return Sample{}
+		}
+		return Sample{Value: float64(failures) / float64(events), Samples: events}
+	}
+}
+
+// PerMinute returns a Source reporting how fast a growing counter rose since its
+// previous call, per minute of clk; Samples is the rise itself
+func PerMinute(counter func() uint64, clk interfaces.Clock) Source {
+	var mu sync.Mutex
+	last, lastAt := counter(), clk.Now()
+	return func() Sample {
+		mu.Lock()
+		defer mu.Unlock()
+		current, now := counter(), clk.Now()
+		rise, elapsed := current-last, now.Sub(lastAt)
+		last, lastAt = current, now
+		if elapsed < time.Second {
+			return Sample{}
+		}
+		return Sample{Value: float64(rise) / elapsed.Minutes(), Samples: rise}
+	}
+}
+
+// LatencyP95 returns a Source reporting the p95 of a latency window in milliseconds;
+// Samples is the writes behind it
+func LatencyP95(window func() types.WriteLatencyStats) Source {
+	return func() Sample {
+		stats := window()
+		return Sample{Value: stats.P95Ms, Samples: stats.Count}
+	}
+}
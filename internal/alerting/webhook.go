@@ -0,0 +1,142 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"switchboard/internal/retry"
+	"switchboard/pkg/types"
+)
+
+// webhookQueueSize bounds the alerts waiting to be posted
+// TECHNICAL DISCOVERY: Each rule raises at most two alerts per breach, so this is only
+// reached when the receiver has been down through many breaches
+const webhookQueueSize = 64
+
+// ErrWebhookRejected reports a 4xx answer other than 429; the alert is not sent again
+var ErrWebhookRejected = errors.New("alert webhook rejected the alert")
+
+// Webhook is the webhook action: it POSTs each alert as a JSON types.Alert
+// ARCHITECTURAL DISCOVERY: One goroutine posts alerts in the order they were raised,
+// so a receiver never sees a resolution before its firing; Notify only queues
+// FUNCTIONAL DISCOVERY: Network errors, 429 and 5xx answers are retried with backoff
+// like analytics export; an alert that still fails is logged and dropped
+type Webhook struct {
+	url    string
+	client *http.Client
+	policy retry.Policy
+
+	queue  chan types.Alert
+	closed bool
+	mu     sync.RWMutex // Orders Notify against Close
+
+	ctx    context.Context // Cancelled when Close gives up waiting
+	cancel context.CancelFunc
+	done   chan struct{} // Closed when the posting goroutine exits
+}
+
+// NewWebhook creates a webhook action posting to url and starts its goroutine
+func NewWebhook(url string) *Webhook {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Webhook{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		policy: retry.Policy{
+			MaxAttempts: 3,
+			Initial:     time.Second,
+			Max:         10 * time.Second,
+			Multiplier:  2,
+			Jitter:      0.2,
+			Retryable:   func(err error) bool { return !errors.Is(err, ErrWebhookRejected) },
+		},
+		queue:  make(chan types.Alert, webhookQueueSize),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// Notify queues alert to be posted; when the queue is full or the webhook closed it
+// is logged and dropped
+func (w *Webhook) Notify(alert types.Alert) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+	select {
+	case w.queue <- alert:
+	default:
+		log.Printf("WARNING: Alert webhook queue full, dropped %s %s", alert.Rule, alert.Status)
+	}
+}
+
+// Close stops taking alerts and posts the ones queued until ctx is done
+// FUNCTIONAL DISCOVERY: Runs at shutdown, so an alert raised just before still goes
+// out unless the receiver is down for the whole shutdown budget
+func (w *Webhook) Close(ctx context.Context) {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.cancel()
+		<-w.done
+	}
+	w.cancel()
+}
+
+func (w *Webhook) run() {
+	defer close(w.done)
+	for alert := range w.queue {
+		if err := w.post(alert); err != nil {
+			log.Printf("Failed to post alert %s %s to webhook: %v", alert.Rule, alert.Status, err)
+		}
+	}
+}
+
+// post sends one alert, retrying as the policy allows
+func (w *Webhook) post(alert types.Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	return w.policy.Do(w.ctx, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrWebhookRejected, err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := w.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body) // Drain so the connection can be reused
+
+		switch {
+		case resp.StatusCode >= 200 && resp.StatusCode < 300:
+			return nil
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			return fmt.Errorf("alert webhook returned %s", resp.Status)
+		default:
+			return fmt.Errorf("%w: %s", ErrWebhookRejected, resp.Status)
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// TestWebhook_PostsInOrder tests integration validation - alerts arrive as JSON in the
+// order raised, a 503 is retried and a 400 is not
+func TestWebhook_PostsInOrder(t *testing.T) {
+	var mu sync.Mutex
+	var received []types.Alert
+	attempts := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert types.Alert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON alert, got %v", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[alert.Rule+alert.Status]++
+		switch {
+		case alert.Rule == "flaky" && attempts[alert.Rule+alert.Status] == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case alert.Rule == "refused":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			received = append(received, alert)
+		}
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL)
+	webhook.policy.Initial = time.Millisecond
+	webhook.policy.Max = time.Millisecond
+	webhook.Notify(types.Alert{Rule: "flaky", Status: types.AlertFiring, Value: 0.05})
+	webhook.Notify(types.Alert{Rule: "refused", Status: types.AlertFiring})
+	webhook.Notify(types.Alert{Rule: "flaky", Status: types.AlertResolved})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	webhook.Close(ctx)
+	webhook.Notify(types.Alert{Rule: "late", Status: types.AlertFiring}) // Dropped, must not panic
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[0].Status != types.AlertFiring || received[0].Value != 0.05 || received[1].Status != types.AlertResolved {
+		t.Errorf("Expected the firing then the resolution, got %+v", received)
+	}
+	if attempts["flaky"+types.AlertFiring] != 2 || attempts["refused"+types.AlertFiring] != 1 {
+		t.Errorf("Expected the 503 retried once and the 400 not retried, got %v", attempts)
+	}
+}
+
+// TestWebhook_CloseGivesUp tests error handling - Close stops retrying once its context is done
+func TestWebhook_CloseGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(server.URL)
+	webhook.policy.Initial = time.Hour
+	webhook.policy.Max = time.Hour
+	webhook.Notify(types.Alert{Rule: "down", Status: types.AlertFiring})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	webhook.Close(ctx)
+	if elapsed := time.Since(started); elapsed > 2*time.Second {
+		t.Errorf("Expected Close to give up with its context, took %s", elapsed)
+	}
+}
@@ -0,0 +1,182 @@
+package analytics
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// Flusher is implemented by sinks that buffer messages
+// TECHNICAL DISCOVERY: The dispatcher flushes on its interval and at shutdown, so
+// batching sinks need no timer of their own
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// Dispatcher feeds a sink from its own worker goroutine
+// ARCHITECTURAL DISCOVERY: Implements interfaces.AnalyticsSink itself, so the router
+// hands messages to a bounded queue and never waits on file or network I/O
+// FUNCTIONAL DISCOVERY: Export is best-effort - a full queue drops and counts the
+// message rather than applying backpressure to routing
+type Dispatcher struct {
+	sink          interfaces.AnalyticsSink
+	flushInterval time.Duration
+
+	queue           chan *types.Message
+	shutdownChannel chan struct{} // Closed by Stop
+	done            chan struct{} // Closed when the run goroutine exits
+
+	dropped atomic.Int64 // Messages refused because the queue was full
+	failed  atomic.Int64 // Sink Consume or Flush calls that returned an error
+
+	running bool
+	mu      sync.Mutex
+}
+
+// NewDispatcher creates a dispatcher that queues up to queueSize messages for sink
+func NewDispatcher(sink interfaces.AnalyticsSink, queueSize int, flushInterval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		sink:            sink,
+		flushInterval:   flushInterval,
+		queue:           make(chan *types.Message, queueSize),
+		shutdownChannel: make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// Start begins delivering queued messages to the sink
+func (d *Dispatcher) Start(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.running {
+		return ErrDispatcherAlreadyRunning
+	}
+	d.running = true
+
+	go d.run(ctx)
+	return nil
+}
+
+// Stop delivers the remaining queue, flushes and closes the sink
+// TECHNICAL DISCOVERY: Waits for the run goroutine so buffered batches are exported
+// before the process exits
+func (d *Dispatcher) Stop() error {
+	d.mu.Lock()
+	if !d.running {
+		d.mu.Unlock()
+		return ErrDispatcherNotRunning
+	}
+	d.running = false
+	close(d.shutdownChannel)
+	d.mu.Unlock()
+
+	<-d.done
+	return nil
+}
+
+// Consume queues a routed analytics message for the sink
+// FUNCTIONAL DISCOVERY: Non-blocking by design - called on the routing path
+func (d *Dispatcher) Consume(ctx context.Context, message *types.Message) error {
+	copied := *message // Shallow copy; content maps are not mutated after routing
+	select {
+	case d.queue <- &copied:
+		return nil
+	default:
+		if dropped := d.dropped.Add(1); dropped == 1 || dropped%100 == 0 {
+			log.Printf("WARNING: Analytics queue full, %d messages dropped so far", dropped)
+		}
+		return ErrQueueFull
+	}
+}
+
+// Dropped returns how many messages were refused because the queue was full
+func (d *Dispatcher) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+// Failed returns how many sink calls returned an error
+func (d *Dispatcher) Failed() int64 {
+	return d.failed.Load()
+}
+
+func (d *Dispatcher) run(ctx context.Context) {
+	defer close(d.done)
+	defer d.closeSink()
+
+	ticker := time.NewTicker(d.flushInterval)
+	defer ticker.Stop()
+
+	// TECHNICAL DISCOVERY: Sink calls use their own context so the final drain and
+	// flush still run after the application context is cancelled
+	sinkCtx := context.Background()
+
+	for {
+		select {
+		case message := <-d.queue:
+			d.consume(sinkCtx, message)
+
+		case <-ticker.C:
+			d.flush(sinkCtx)
+
+		case <-d.shutdownChannel:
+			d.drain(sinkCtx)
+			return
+
+		case <-ctx.Done():
+			d.drain(sinkCtx)
+			return
+		}
+	}
+}
+
+// drain delivers messages queued before shutdown and flushes the sink
+func (d *Dispatcher) drain(ctx context.Context) {
+	for {
+		select {
+		case message := <-d.queue:
+			d.consume(ctx, message)
+		default:
+			d.flush(ctx)
+			return
+		}
+	}
+}
+
+func (d *Dispatcher) consume(ctx context.Context, message *types.Message) {
+	if err := d.sink.Consume(ctx, message); err != nil {
+		d.recordFailure(err)
+	}
+}
+
+func (d *Dispatcher) flush(ctx context.Context) {
+	flusher, ok := d.sink.(Flusher)
+	if !ok {
+		return
+	}
+	if err := flusher.Flush(ctx); err != nil {
+		d.recordFailure(err)
+	}
+}
+
+func (d *Dispatcher) closeSink() {
+	closer, ok := d.sink.(io.Closer)
+	if !ok {
+		return
+	}
+	if err := closer.Close(); err != nil {
+		log.Printf("Failed to close analytics sink: %v", err)
+	}
+}
+
+func (d *Dispatcher) recordFailure(err error) {
+	if failed := d.failed.Add(1); failed == 1 || failed%100 == 0 {
+		log.Printf("WARNING: Analytics sink error (%d so far): %v", failed, err)
+	}
+}
@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+func analyticsMessage(id string) *types.Message {
+	return &types.Message{
+		ID:        id,
+		SessionID: "session1",
+		Type:      types.MessageTypeAnalytics,
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"event": "progress", "percent": 50},
+		Timestamp: time.Now(),
+	}
+}
+
+// recordingSink remembers consumed IDs and lifecycle calls; block stalls Consume
+type recordingSink struct {
+	mu      sync.Mutex
+	ids     []string
+	flushes int
+	closed  bool
+	block   chan struct{}
+}
+
+func (s *recordingSink) Consume(ctx context.Context, message *types.Message) error {
+	if s.block != nil {
+		<-s.block
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ids = append(s.ids, message.ID)
+	return nil
+}
+
+func (s *recordingSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushes++
+	return nil
+}
+
+func (s *recordingSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// Functional Validation Tests
+func TestDispatcher_SlowSinkDropsInsteadOfBlocking(t *testing.T) {
+	sink := &recordingSink{block: make(chan struct{})}
+	dispatcher := NewDispatcher(sink, 2, time.Hour)
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+
+	// One message is held by the stalled sink, two fill the queue, the rest drop
+	start := time.Now()
+	var dropped int
+	for i := 0; i < 10; i++ {
+		if err := dispatcher.Consume(context.Background(), analyticsMessage("m")); errors.Is(err, ErrQueueFull) {
+			dropped++
+		}
+		if i == 0 {
+			time.Sleep(20 * time.Millisecond) // Let the worker pick up the first message
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Consume blocked on a slow sink for %v", elapsed)
+	}
+	if dropped != 7 || dispatcher.Dropped() != 7 {
+		t.Errorf("Expected 7 drops, got %d returned and %d counted", dropped, dispatcher.Dropped())
+	}
+
+	close(sink.block)
+	if err := dispatcher.Stop(); err != nil {
+		t.Fatalf("Stop should succeed: %v", err)
+	}
+	if len(sink.ids) != 3 {
+		t.Errorf("Expected the 3 accepted messages to reach the sink, got %d", len(sink.ids))
+	}
+}
+
+func TestDispatcher_StopDrainsFlushesAndCloses(t *testing.T) {
+	sink := &recordingSink{}
+	dispatcher := NewDispatcher(sink, 100, time.Hour)
+	for _, id := range []string{"a", "b", "c"} {
+		_ = dispatcher.Consume(context.Background(), analyticsMessage(id))
+	}
+	if err := dispatcher.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+	if err := dispatcher.Start(context.Background()); err != ErrDispatcherAlreadyRunning {
+		t.Errorf("Expected ErrDispatcherAlreadyRunning, got %v", err)
+	}
+
+	if err := dispatcher.Stop(); err != nil {
+		t.Fatalf("Stop should succeed: %v", err)
+	}
+	if len(sink.ids) != 3 || sink.flushes == 0 || !sink.closed {
+		t.Errorf("Expected 3 messages, a flush and a close, got %v, %d flushes, closed=%v", sink.ids, sink.flushes, sink.closed)
+	}
+	if err := dispatcher.Stop(); err != ErrDispatcherNotRunning {
+		t.Errorf("Expected ErrDispatcherNotRunning, got %v", err)
+	}
+}
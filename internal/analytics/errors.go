@@ -0,0 +1,11 @@
+package analytics
+
+import "errors"
+
+// Analytics dispatcher and sink errors
+var (
+	ErrQueueFull                = errors.New("analytics queue full, message dropped")
+	ErrDispatcherAlreadyRunning = errors.New("analytics dispatcher is already running")
+	ErrDispatcherNotRunning     = errors.New("analytics dispatcher is not running")
+	ErrExportRejected           = errors.New("analytics endpoint rejected batch")
+)
@@ -0,0 +1,126 @@
+package analytics
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// HTTPSink batches messages and POSTs them as JSON arrays to a collector URL
+// ARCHITECTURAL DISCOVERY: A batch is sent when it reaches batchSize or when the
+// dispatcher flushes on its interval, trading a few seconds of latency for far fewer
+// requests during classroom bursts
+// FUNCTIONAL DISCOVERY: Network errors, 429 and 5xx responses are retried with
+// exponential backoff; other 4xx responses mean the batch will never be accepted and
+// it is dropped immediately
+type HTTPSink struct {
+	url          string
+	client       *http.Client
+	batchSize    int
+	maxRetries   int
+	retryBackoff time.Duration // Doubled after each failed attempt
+
+	batch []*types.Message
+	mu    sync.Mutex
+}
+
+// NewHTTPSink creates a sink that posts batches of up to batchSize messages to url
+func NewHTTPSink(url string, batchSize, maxRetries int) *HTTPSink {
+	return &HTTPSink{
+		url:          url,
+		client:       &http.Client{Timeout: 10 * time.Second},
+		batchSize:    batchSize,
+		maxRetries:   maxRetries,
+		retryBackoff: 500 * time.Millisecond,
+	}
+}
+
+// Consume adds a message to the current batch, sending it once full
+func (s *HTTPSink) Consume(ctx context.Context, message *types.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.batch = append(s.batch, message)
+	if len(s.batch) < s.batchSize {
+		return nil
+	}
+	return s.sendLocked(ctx)
+}
+
+// Flush sends a partial batch
+func (s *HTTPSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sendLocked(ctx)
+}
+
+// Close sends any remaining messages
+func (s *HTTPSink) Close() error {
+	return s.Flush(context.Background())
+}
+
+// sendLocked posts the current batch; the batch is cleared whether or not it succeeds
+// TECHNICAL DISCOVERY: Keeping a failed batch would grow without bound while the
+// collector is down, so after the last retry its messages are given up
+func (s *HTTPSink) sendLocked(ctx context.Context) error {
+	if len(s.batch) == 0 {
+		return nil
+	}
+	batch := s.batch
+	s.batch = nil
+
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	backoff := s.retryBackoff
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= s.maxRetries {
+			return fmt.Errorf("analytics export of %d messages failed after %d attempts: %w", len(batch), attempt+1, err)
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return fmt.Errorf("analytics export of %d messages abandoned: %w", len(batch), ctx.Err())
+		}
+	}
+}
+
+// post sends one request and reports whether a failure is worth retrying
+func (s *HTTPSink) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body) // Drain so the connection can be reused
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("analytics endpoint returned %s", resp.Status)
+	default:
+		return false, fmt.Errorf("%w: %s", ErrExportRejected, resp.Status)
+	}
+}
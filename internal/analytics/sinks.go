@@ -0,0 +1,78 @@
+package analytics
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"switchboard/pkg/types"
+)
+
+// NoopSink discards every message
+// FUNCTIONAL DISCOVERY: Keeps export configured but paused, and measures dispatcher
+// overhead without an external dependency
+type NoopSink struct{}
+
+// Consume discards the message
+func (NoopSink) Consume(ctx context.Context, message *types.Message) error {
+	return nil
+}
+
+// FileSink appends messages to a newline-delimited JSON file
+// FUNCTIONAL DISCOVERY: NDJSON loads directly into most warehouses and can be tailed
+// by a log shipper, so deployments without an HTTP collector still get an export
+type FileSink struct {
+	file   *os.File
+	writer *bufio.Writer
+	mu     sync.Mutex
+}
+
+// NewFileSink opens path for appending, creating it and its directory if needed
+func NewFileSink(path string) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create analytics directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics file: %w", err)
+	}
+	return &FileSink{file: file, writer: bufio.NewWriter(file)}, nil
+}
+
+// Consume appends one JSON line
+func (s *FileSink) Consume(ctx context.Context, message *types.Message) error {
+	line, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Flush writes buffered lines to the file
+func (s *FileSink) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.writer.Flush()
+}
+
+// Close flushes and closes the file
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flushErr := s.writer.Flush()
+	closeErr := s.file.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
@@ -0,0 +1,109 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestFileSink_AppendsNDJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export", "analytics.ndjson")
+	for round := 0; round < 2; round++ {
+		sink, err := NewFileSink(path)
+		if err != nil {
+			t.Fatalf("NewFileSink should succeed: %v", err)
+		}
+		_ = sink.Consume(context.Background(), analyticsMessage("first"))
+		_ = sink.Consume(context.Background(), analyticsMessage("second"))
+		if err := sink.Close(); err != nil {
+			t.Fatalf("Close should succeed: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read export: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 appended lines, got %d:\n%s", len(lines), data)
+	}
+	var decoded types.Message
+	if err := json.Unmarshal([]byte(lines[3]), &decoded); err != nil || decoded.ID != "second" {
+		t.Errorf("Expected each line to be a JSON message, got %q (%v)", lines[3], err)
+	}
+}
+
+func TestHTTPSink_BatchesAndRetries(t *testing.T) {
+	var mu sync.Mutex
+	var attempts int
+	var batches [][]types.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var batch []types.Message
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Errorf("Expected a JSON array body: %v", err)
+		}
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, 2, 3)
+	sink.retryBackoff = time.Millisecond
+
+	ctx := context.Background()
+	_ = sink.Consume(ctx, analyticsMessage("a"))
+	if err := sink.Consume(ctx, analyticsMessage("b")); err != nil {
+		t.Fatalf("Full batch should be sent after a retry: %v", err)
+	}
+	_ = sink.Consume(ctx, analyticsMessage("c"))
+	if err := sink.Flush(ctx); err != nil {
+		t.Fatalf("Flush should send the partial batch: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 || len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 1 {
+		t.Errorf("Expected a retried batch of 2 and a flushed batch of 1, got %d attempts and %v", attempts, batches)
+	}
+}
+
+func TestHTTPSink_RejectedBatchIsNotRetried(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sink := NewHTTPSink(server.URL, 1, 3)
+	sink.retryBackoff = time.Millisecond
+
+	err := sink.Consume(context.Background(), analyticsMessage("a"))
+	if !errors.Is(err, ErrExportRejected) {
+		t.Errorf("Expected ErrExportRejected, got %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("A 400 response should not be retried, got %d attempts", attempts)
+	}
+	if err := sink.Flush(context.Background()); err != nil {
+		t.Errorf("Rejected batch should be dropped, not resent: %v", err)
+	}
+}
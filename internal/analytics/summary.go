@@ -0,0 +1,36 @@
+package analytics
+
+import (
+	"sort"
+
+	"switchboard/pkg/types"
+)
+
+// Summarize builds a user's per-context time series from minute buckets
+// ARCHITECTURAL DISCOVERY: The database does the grouping; this only shapes the
+// rows, so any caller that can produce buckets (a per-student view, a session
+// rollup) gets the same series layout
+func Summarize(sessionID, userID string, buckets []*types.AnalyticsBucket) *types.AnalyticsSummary {
+	summary := &types.AnalyticsSummary{
+		SessionID: sessionID,
+		UserID:    userID,
+		Contexts:  make(map[string]*types.AnalyticsSeries),
+	}
+	for _, bucket := range buckets {
+		series, exists := summary.Contexts[bucket.Context]
+		if !exists {
+			series = &types.AnalyticsSeries{}
+			summary.Contexts[bucket.Context] = series
+		}
+		series.Points = append(series.Points, types.AnalyticsPoint{Minute: bucket.Minute, Count: bucket.Count})
+		series.Total += bucket.Count
+		summary.Total += bucket.Count
+	}
+
+	for _, series := range summary.Contexts {
+		sort.SliceStable(series.Points, func(i, j int) bool {
+			return series.Points[i].Minute.Before(series.Points[j].Minute)
+		})
+	}
+	return summary
+}
@@ -0,0 +1,38 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+func TestSummarize(t *testing.T) {
+	minute := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	summary := Summarize("session1", "student1", []*types.AnalyticsBucket{
+		{Context: "engagement", Minute: minute.Add(2 * time.Minute), Count: 1},
+		{Context: "error", Minute: minute, Count: 4},
+		{Context: "engagement", Minute: minute, Count: 2},
+	})
+
+	if summary.SessionID != "session1" || summary.UserID != "student1" || summary.Total != 7 {
+		t.Errorf("Unexpected summary header: %+v", summary)
+	}
+	engagement := summary.Contexts["engagement"]
+	if engagement == nil || engagement.Total != 3 || len(engagement.Points) != 2 {
+		t.Fatalf("Unexpected engagement series: %+v", engagement)
+	}
+	if !engagement.Points[0].Minute.Equal(minute) || engagement.Points[1].Count != 1 {
+		t.Errorf("Expected engagement points in minute order, got %+v", engagement.Points)
+	}
+	if errors := summary.Contexts["error"]; errors == nil || errors.Total != 4 {
+		t.Errorf("Unexpected error series: %+v", errors)
+	}
+}
+
+func TestSummarize_Empty(t *testing.T) {
+	summary := Summarize("session1", "student1", nil)
+	if summary.Total != 0 || summary.Contexts == nil || len(summary.Contexts) != 0 {
+		t.Errorf("Expected an empty summary with no contexts, got %+v", summary)
+	}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"switchboard/pkg/interfaces"
+)
+
+// FUNCTIONAL DISCOVERY: GET /api/admin/messages/{id}/metadata - Sender connection details
+// ARCHITECTURAL DISCOVERY: Client IP and user agent live in a side table and are only
+// reachable through this endpoint, never in message envelopes sent to participants.
+// The endpoint is 404 until SetMessageMetadata turns it on
+func (s *Server) handleMessageMetadata(w http.ResponseWriter, r *http.Request) {
+	if s.metadataIP == nil {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+	
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	path := strings.TrimPrefix(r.URL.Path, "/api/admin/messages/")
+	messageID, rest, found := strings.Cut(path, "/")
+	if !found || messageID == "" || strings.Trim(rest, "/") != "metadata" {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+	
+	metadata, err := s.dbManager.GetMessageMetadata(r.Context(), messageID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			s.sendError(w, "Message metadata not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get message metadata", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	served := *metadata // The reader may hand out a cached value
+	served.ClientIP = s.metadataIP(metadata.ClientIP)
+	json.NewEncoder(w).Encode(served)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"switchboard/internal/analytics"
+	"switchboard/pkg/types"
+)
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/analytics/me?user_id=...&requested_by=... -
+// Per-context, per-minute counts of the analytics user_id sent in the session.
+// requested_by defaults to user_id; a requester enrolled as a student may only ask
+// about themselves, while anyone else (an instructor) may ask about any user
+func (s *Server) myAnalytics(w http.ResponseWriter, r *http.Request, sessionID string) {
+	query := r.URL.Query()
+	userID := query.Get("user_id")
+	if !types.IsValidUserID(userID) {
+		s.sendError(w, "user_id must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+	requestedBy := query.Get("requested_by")
+	if requestedBy == "" {
+		requestedBy = userID
+	} else if !types.IsValidUserID(requestedBy) {
+		s.sendError(w, "requested_by must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+
+	current, err := s.sessionManager.GetSession(r.Context(), sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		}
+		return
+	}
+	if requestedBy != userID {
+		for _, studentID := range current.StudentIDs {
+			if studentID == requestedBy {
+				s.sendError(w, "Students may only view their own analytics", http.StatusForbidden)
+				return
+			}
+		}
+	}
+
+	buckets, err := s.dbManager.GetAnalyticsBuckets(r.Context(), sessionID, userID)
+	if err != nil {
+		s.sendError(w, "Failed to get analytics", http.StatusInternalServerError)
+		return
+	}
+
+	json.NewEncoder(w).Encode(analytics.Summarize(sessionID, userID, buckets))
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"switchboard/internal/session"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// annotationsForbidden is the 403 message for students reaching annotated history
+const annotationsForbidden = "Only instructors may view or change annotations"
+
+// AnnotateMessageRequest sets an instructor's tags and note on a message via PATCH
+// FUNCTIONAL DISCOVERY: Identity comes from the body, like requested_by on transfer;
+// empty tags and note remove the instructor's annotation
+type AnnotateMessageRequest struct {
+	InstructorID string   `json:"instructor_id"`
+	Tags         []string `json:"tags"`
+	Note         string   `json:"note"`
+}
+
+type AnnotationResponse struct {
+	Annotation *types.MessageAnnotation `json:"annotation"`
+}
+
+type SessionHistoryResponse struct {
+	Timezone string               `json:"timezone"`       // Zone the timestamps are rendered in
+	Config   *types.SessionConfig `json:"session_config"` // Routing at creation; null for sessions older than snapshots
+	Messages []*types.Message     `json:"messages"`
+}
+
+// messageSubresourceID extracts the message ID from "messages/{id}/{suffix}"
+func messageSubresourceID(subresource, suffix string) (string, bool) {
+	rest, found := strings.CutPrefix(subresource, "messages/")
+	if !found {
+		return "", false
+	}
+	messageID, rest, found := strings.Cut(rest, "/")
+	if !found || messageID == "" || rest != suffix {
+		return "", false
+	}
+	return messageID, true
+}
+
+// requireInstructor loads the session and refuses user IDs enrolled as its students,
+// answering 403 with forbidden
+// ARCHITECTURAL DISCOVERY: Annotations and stats are instructor-only; without authentication
+// the roster is the only role information the API has, so enrolled students are refused
+func (s *Server) requireInstructor(w http.ResponseWriter, r *http.Request, sessionID, instructorID, forbidden string) (*types.Session, bool) {
+	if !types.IsValidUserID(instructorID) {
+		s.sendError(w, "instructor_id must be a valid user ID", http.StatusBadRequest)
+		return nil, false
+	}
+
+	current, err := s.sessionManager.GetSession(r.Context(), sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	for _, studentID := range current.StudentIDs {
+		if studentID == instructorID {
+			s.sendError(w, forbidden, http.StatusForbidden)
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// FUNCTIONAL DISCOVERY: PATCH /api/sessions/{id}/messages/{message_id}/annotations -
+// Replace the instructor's annotation on a message; nothing is broadcast, so students
+// never learn that a message was starred. Works on ended sessions too
+func (s *Server) annotateMessage(w http.ResponseWriter, r *http.Request, sessionID, messageID string) {
+	var req AnnotateMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	_, instructorID, ok := s.requireInstructorOrKey(w, r, sessionID, req.InstructorID, types.APIKeyScopeManage, annotationsForbidden)
+	if !ok {
+		return
+	}
+
+	annotation := &types.MessageAnnotation{
+		MessageID:    messageID,
+		InstructorID: instructorID,
+		Tags:         req.Tags,
+		Note:         req.Note,
+		UpdatedAt:    time.Now(),
+	}
+	if err := annotation.Validate(); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.dbManager.SetMessageAnnotation(r.Context(), sessionID, annotation); err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			s.sendError(w, "Message not found in session", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to store annotation", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(AnnotationResponse{Annotation: annotation})
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/messages?instructor_id=...&tag=...&tz=... -
+// Full session history with every instructor's annotations and the reaction tallies
+// inline; tag keeps only
+// messages that some instructor tagged with it, e.g. ?tag=star. Timestamps are rendered
+// in the session's time zone, or in tz when given
+func (s *Server) sessionHistory(w http.ResponseWriter, r *http.Request, sessionID string) {
+	query := r.URL.Query()
+	current, _, ok := s.requireInstructorOrKey(w, r, sessionID, query.Get("instructor_id"), types.APIKeyScopeReadHistory, annotationsForbidden)
+	if !ok {
+		return
+	}
+	zone := current.Zone()
+	if tz := query.Get("tz"); tz != "" {
+		if err := session.ValidateTimezone(tz); err != nil {
+			s.sendError(w, "tz must be an IANA time zone name, e.g. America/Chicago", http.StatusBadRequest)
+			return
+		}
+		zone = tz
+	}
+
+	messages, err := s.dbManager.GetSessionHistory(r.Context(), sessionID)
+	if err != nil {
+		s.sendError(w, "Failed to get session history", http.StatusInternalServerError)
+		return
+	}
+	annotations, err := s.dbManager.GetSessionAnnotations(r.Context(), sessionID)
+	if err != nil {
+		s.sendError(w, "Failed to get message annotations", http.StatusInternalServerError)
+		return
+	}
+	types.AttachAnnotations(messages, annotations)
+	reactions, err := s.dbManager.GetSessionReactionCounts(r.Context(), sessionID)
+	if err != nil {
+		s.sendError(w, "Failed to get message reactions", http.StatusInternalServerError)
+		return
+	}
+	types.AttachReactions(messages, reactions)
+	config, err := s.dbManager.GetSessionConfig(r.Context(), sessionID)
+	if err != nil {
+		s.sendError(w, "Failed to get session config", http.StatusInternalServerError)
+		return
+	}
+
+	if tag := query.Get("tag"); tag != "" {
+		tagged := make([]*types.Message, 0, len(messages))
+		for _, message := range messages {
+			for _, annotation := range message.Annotations {
+				if annotation.HasTag(tag) {
+					tagged = append(tagged, message)
+					break
+				}
+			}
+		}
+		messages = tagged
+	}
+	if messages == nil {
+		messages = []*types.Message{}
+	}
+	localizeMessages(messages, zone)
+
+	json.NewEncoder(w).Encode(SessionHistoryResponse{Timezone: zone, Config: config, Messages: messages})
+}
+
+// localizeMessages renders message and annotation timestamps in zone
+// TECHNICAL DISCOVERY: In only changes the zone used for formatting; the instant is
+// the same, so clients that parse the offset recover the stored UTC time exactly
+func localizeMessages(messages []*types.Message, zone string) {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		location = time.UTC
+	}
+	for _, message := range messages {
+		message.Timestamp = message.Timestamp.In(location)
+		for _, annotation := range message.Annotations {
+			annotation.UpdatedAt = annotation.UpdatedAt.In(location)
+		}
+	}
+}
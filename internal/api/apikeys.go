@@ -0,0 +1,176 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+	"switchboard/internal/apikey"
+)
+
+// apiKeysForbidden is the 403 message for students reaching the key endpoints
+const apiKeysForbidden = "Only instructors may manage API keys"
+
+// CreateAPIKeyRequest issues a session API key via POST
+// FUNCTIONAL DISCOVERY: expires_in_minutes 0 means a day; scopes are any of
+// send_messages, read_history and manage
+type CreateAPIKeyRequest struct {
+	InstructorID     string   `json:"instructor_id"`
+	Name             string   `json:"name"`
+	Scopes           []string `json:"scopes"`
+	ExpiresInMinutes int      `json:"expires_in_minutes,omitempty"`
+}
+
+// CreateAPIKeyResponse carries the secret, the only time it is ever shown
+type CreateAPIKeyResponse struct {
+	Key    string        `json:"key"`
+	APIKey *types.APIKey `json:"api_key"`
+}
+
+type ListAPIKeysResponse struct {
+	APIKeys []*types.APIKey `json:"api_keys"`
+}
+
+// SetAPIKeys enables session API keys on the key endpoints and instructor-only reads
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetAPIKeys(apiKeys interfaces.APIKeyManager) {
+	s.apiKeys = apiKeys
+}
+
+// apiKeyID extracts the key ID from "api-keys/{key_id}"
+func apiKeyID(subresource string) (string, bool) {
+	keyID, found := strings.CutPrefix(subresource, "api-keys/")
+	return keyID, found && keyID != "" && !strings.Contains(keyID, "/")
+}
+
+// requireInstructorOrKey is requireInstructor for endpoints API keys may also call
+// FUNCTIONAL DISCOVERY: A request carrying a key acts as the key's user and must hold
+// scope; instructorID is then ignored. A key that does not work answers 401 and a
+// key for another session or without scope answers 403
+func (s *Server) requireInstructorOrKey(w http.ResponseWriter, r *http.Request, sessionID, instructorID, scope, forbidden string) (*types.Session, string, bool) {
+	secret := apikey.FromRequest(r)
+	if secret == "" {
+		current, ok := s.requireInstructor(w, r, sessionID, instructorID, forbidden)
+		return current, instructorID, ok
+	}
+	if s.apiKeys == nil {
+		s.sendError(w, "API keys are not enabled on this server", http.StatusUnauthorized)
+		return nil, "", false
+	}
+
+	key, err := s.apiKeys.Authenticate(r.Context(), secret)
+	if errors.Is(err, interfaces.ErrInvalidAPIKey) {
+		s.sendError(w, "Invalid, expired or revoked API key", http.StatusUnauthorized)
+		return nil, "", false
+	}
+	if err != nil {
+		log.Printf("API key authentication failed: %v", err)
+		s.sendError(w, "API key validation failed", http.StatusInternalServerError)
+		return nil, "", false
+	}
+	if key.SessionID != sessionID {
+		s.sendError(w, "API key does not belong to this session", http.StatusForbidden)
+		return nil, "", false
+	}
+	if !key.HasScope(scope) {
+		s.sendError(w, "API key lacks the "+scope+" scope", http.StatusForbidden)
+		return nil, "", false
+	}
+
+	current, err := s.sessionManager.GetSession(r.Context(), sessionID)
+	if err != nil {
+		s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		return nil, "", false
+	}
+	return current, key.UserID(), true
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/sessions/{id}/api-keys - Issue a key for a grader or
+// bot. Instructors only, and never with a key, so a leaked key cannot mint more
+func (s *Server) createAPIKey(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.apiKeys == nil {
+		s.sendError(w, "API keys are not enabled on this server", http.StatusNotFound)
+		return
+	}
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	current, ok := s.requireInstructor(w, r, sessionID, req.InstructorID, apiKeysForbidden)
+	if !ok {
+		return
+	}
+	if current.Status != types.SessionActive {
+		s.sendError(w, "Session has ended", http.StatusConflict)
+		return
+	}
+	if req.ExpiresInMinutes < 0 {
+		s.sendError(w, apikey.ErrInvalidTTL.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ttl := time.Duration(req.ExpiresInMinutes) * time.Minute
+	secret, key, err := s.apiKeys.CreateKey(r.Context(), sessionID, req.InstructorID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		if errors.Is(err, apikey.ErrNoScopes) || errors.Is(err, apikey.ErrInvalidScope) || errors.Is(err, apikey.ErrInvalidTTL) {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+		} else {
+			s.sendError(w, "Failed to create API key", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(CreateAPIKeyResponse{Key: secret, APIKey: key})
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/api-keys?instructor_id=... - The
+// session's keys without their secrets, revoked and expired ones included
+func (s *Server) listAPIKeys(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.apiKeys == nil {
+		s.sendError(w, "API keys are not enabled on this server", http.StatusNotFound)
+		return
+	}
+	if _, _, ok := s.requireInstructorOrKey(w, r, sessionID, r.URL.Query().Get("instructor_id"), types.APIKeyScopeManage, apiKeysForbidden); !ok {
+		return
+	}
+
+	keys, err := s.apiKeys.ListKeys(r.Context(), sessionID)
+	if err != nil {
+		s.sendError(w, "Failed to list API keys", http.StatusInternalServerError)
+		return
+	}
+	if keys == nil {
+		keys = []*types.APIKey{}
+	}
+	json.NewEncoder(w).Encode(ListAPIKeysResponse{APIKeys: keys})
+}
+
+// FUNCTIONAL DISCOVERY: DELETE /api/sessions/{id}/api-keys/{key_id}?instructor_id=... -
+// Revoke a key; it fails from the next request and its open connection is closed
+func (s *Server) revokeAPIKey(w http.ResponseWriter, r *http.Request, sessionID, keyID string) {
+	if s.apiKeys == nil {
+		s.sendError(w, "API keys are not enabled on this server", http.StatusNotFound)
+		return
+	}
+	if _, _, ok := s.requireInstructorOrKey(w, r, sessionID, r.URL.Query().Get("instructor_id"), types.APIKeyScopeManage, apiKeysForbidden); !ok {
+		return
+	}
+
+	if err := s.apiKeys.RevokeKey(r.Context(), sessionID, keyID); err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			s.sendError(w, "API key not found in session", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to revoke API key", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]string{"message": "API key revoked"})
+}
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Announcement fan-out defaults
+// FUNCTIONAL DISCOVERY: A hundred active sessions are announced to in about a second,
+// with at most four sessions' fan-outs in flight, so classroom traffic routed by the
+// hub at the same time is not crowded out
+const (
+	defaultAnnounceWorkers = 4
+	defaultAnnouncePacing  = 10 * time.Millisecond // Between starting one session and the next
+)
+
+// Announcer routes an instructor_broadcast from the system sender into one session
+type Announcer func(ctx context.Context, sessionID, messageContext string, content map[string]interface{}) (types.RouteResult, error)
+
+// AdminBroadcastRequest is the body of POST /api/admin/broadcast
+type AdminBroadcastRequest struct {
+	Content       map[string]interface{}  `json:"content"`
+	Contexts      []string                `json:"contexts"`       // One announcement per context; empty uses the broadcast default
+	SessionFilter *BroadcastSessionFilter `json:"session_filter"` // nil announces to every active session
+}
+
+// BroadcastSessionFilter narrows the active sessions an announcement reaches; set
+// fields must all match
+type BroadcastSessionFilter struct {
+	SessionIDs []string `json:"session_ids"`
+	OwnerID    string   `json:"owner_id"`
+}
+
+// BroadcastSessionResult reports one session's share of an announcement
+type BroadcastSessionResult struct {
+	SessionID  string   `json:"session_id"`
+	MessageIDs []string `json:"message_ids"` // Routed announcements, one per context
+	Delivered  int      `json:"delivered"`   // Deliveries summed over contexts
+	Dropped    int      `json:"dropped"`     // Recipients that disconnected or could not be written to
+	Error      string   `json:"error,omitempty"`
+}
+
+type AdminBroadcastResponse struct {
+	Sessions  []BroadcastSessionResult `json:"sessions"`
+	Delivered int                      `json:"delivered"`
+	Failed    int                      `json:"failed"` // Sessions where an announcement was not routed
+}
+
+// SetAnnouncer sets how POST /api/admin/broadcast reaches each session
+func (s *Server) SetAnnouncer(announce Announcer) {
+	s.announce = announce
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/admin/broadcast - Announce to every active session,
+// e.g. {"content": {"text": "Campus closing at noon"}}. Each session receives an
+// instructor_broadcast from the system sender with system=true in the envelope
+func (s *Server) handleAdminBroadcast(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.announce == nil {
+		s.sendError(w, "Announcements are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req AdminBroadcastRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.Content) == 0 {
+		s.sendError(w, "content is required", http.StatusBadRequest)
+		return
+	}
+	contexts := req.Contexts
+	if len(contexts) == 0 {
+		contexts = []string{""}
+	}
+	// TECHNICAL DISCOVERY: Checked once up front so a bad request is refused instead
+	// of failing identically in every session
+	for _, messageContext := range contexts {
+		probe := &types.Message{Type: types.MessageTypeInstructorBroadcast, Context: messageContext, Content: req.Content}
+		if err := probe.Validate(); err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	sessions, err := s.sessionManager.ListActiveSessions(r.Context())
+	if err != nil {
+		s.sendError(w, "Failed to list active sessions", http.StatusInternalServerError)
+		return
+	}
+	sessions = req.SessionFilter.apply(sessions)
+
+	// ARCHITECTURAL DISCOVERY: Detached from the request, so an admin client that gives
+	// up waiting does not leave the announcement half delivered
+	ctx := context.WithoutCancel(r.Context())
+	results := s.fanOutAnnouncement(ctx, sessions, contexts, req.Content)
+
+	response := AdminBroadcastResponse{Sessions: results}
+	for _, result := range results {
+		response.Delivered += result.Delivered
+		if result.Error != "" {
+			response.Failed++
+		}
+	}
+	log.Printf("Admin broadcast: sessions=%d delivered=%d failed=%d", len(results), response.Delivered, response.Failed)
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// apply keeps the sessions matching every set field; a nil filter keeps all
+func (f *BroadcastSessionFilter) apply(sessions []*types.Session) []*types.Session {
+	if f == nil {
+		return sessions
+	}
+	wanted := make(map[string]bool, len(f.SessionIDs))
+	for _, id := range f.SessionIDs {
+		wanted[id] = true
+	}
+	var kept []*types.Session
+	for _, session := range sessions {
+		if len(wanted) > 0 && !wanted[session.ID] {
+			continue
+		}
+		if f.OwnerID != "" && session.Owner() != f.OwnerID {
+			continue
+		}
+		kept = append(kept, session)
+	}
+	return kept
+}
+
+// fanOutAnnouncement announces to each session from a small worker pool
+// TECHNICAL DISCOVERY: Sessions are handed out one per pacing interval, and a worker
+// only takes the next session once its current fan-out has been queued on every
+// recipient connection, so slow sessions hold back the dispatch instead of piling up
+func (s *Server) fanOutAnnouncement(ctx context.Context, sessions []*types.Session, contexts []string, content map[string]interface{}) []BroadcastSessionResult {
+	results := make([]BroadcastSessionResult, len(sessions))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < min(s.announceWorkers, len(sessions)); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.announceTo(ctx, sessions[i].ID, contexts, content)
+			}
+		}()
+	}
+
+	for i := range sessions {
+		if i > 0 && s.announcePacing > 0 {
+			time.Sleep(s.announcePacing)
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// announceTo routes the announcement into one session, once per context
+func (s *Server) announceTo(ctx context.Context, sessionID string, contexts []string, content map[string]interface{}) BroadcastSessionResult {
+	result := BroadcastSessionResult{SessionID: sessionID, MessageIDs: []string{}}
+	for _, messageContext := range contexts {
+		routed, err := s.announce(ctx, sessionID, messageContext, content)
+		if err != nil {
+			log.Printf("Admin broadcast to session %s failed: %v", sessionID, err)
+			result.Error = err.Error()
+			return result
+		}
+		result.MessageIDs = append(result.MessageIDs, routed.MessageID)
+		result.Delivered += len(routed.Delivered)
+		result.Dropped += len(routed.Dropped)
+	}
+	return result
+}
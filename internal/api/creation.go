@@ -0,0 +1,46 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"switchboard/pkg/types"
+)
+
+// creationRejections counts session creations refused with 403, by CreateReject* reason
+// TECHNICAL DISCOVERY: One counter per known reason, made up front, so counting never
+// takes a lock and every reason reports even while it is still zero
+type creationRejections map[string]*atomic.Uint64
+
+func newCreationRejections() creationRejections {
+	counts := make(creationRejections, len(types.CreateRejectReasons))
+	for _, reason := range types.CreateRejectReasons {
+		counts[reason] = new(atomic.Uint64)
+	}
+	return counts
+}
+
+// stats returns the counts so far
+func (c creationRejections) stats() types.SessionCreationStats {
+	rejected := make(map[string]uint64, len(c))
+	for reason, count := range c {
+		rejected[reason] = count.Load()
+	}
+	return types.SessionCreationStats{Rejected: rejected}
+}
+
+// sendCreateRejection counts a refused creation and answers 403 with reason, one of
+// types.CreateRejectReasons
+func (s *Server) sendCreateRejection(w http.ResponseWriter, reason string, err error) {
+	if count, known := s.creationRejections[reason]; known {
+		count.Add(1)
+	}
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error:   http.StatusText(http.StatusForbidden),
+		Code:    http.StatusForbidden,
+		Message: err.Error(),
+		Reason:  reason,
+	})
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"switchboard/internal/session"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// delegationsForbidden is the 403 message for students reaching the grant endpoints
+const delegationsForbidden = "Only instructors may delegate message types"
+
+// defaultDelegationSeconds applies when a grant omits expires_in
+const defaultDelegationSeconds = 600
+
+// GrantDelegationRequest lets a student send instructor message types via POST
+// FUNCTIONAL DISCOVERY: expires_in is in seconds, 10 minutes when omitted
+type GrantDelegationRequest struct {
+	InstructorID string   `json:"instructor_id"`
+	UserID       string   `json:"user_id"`
+	Types        []string `json:"types"`
+	ExpiresIn    int      `json:"expires_in,omitempty"`
+}
+
+type ListDelegationsResponse struct {
+	Delegations []*types.Delegation `json:"delegations"`
+}
+
+// SetDelegations enables the delegation endpoints
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetDelegations(delegations interfaces.DelegationManager) {
+	s.delegations = delegations
+}
+
+// delegationUserID extracts the student ID from "delegations/{user_id}"
+func delegationUserID(subresource string) (string, bool) {
+	userID, found := strings.CutPrefix(subresource, "delegations/")
+	return userID, found && userID != "" && !strings.Contains(userID, "/")
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/sessions/{id}/delegations - Let a rostered student
+// send the listed instructor types until expires_in runs out; granting again replaces
+// the grant. Everyone in the session is told, so clients can show who is presenting
+func (s *Server) grantDelegation(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.delegations == nil {
+		s.sendError(w, "Delegation not configured", http.StatusServiceUnavailable)
+		return
+	}
+	var req GrantDelegationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.requireInstructor(w, r, sessionID, req.InstructorID, delegationsForbidden); !ok {
+		return
+	}
+	if !types.IsValidUserID(req.UserID) {
+		s.sendError(w, "user_id must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresIn == 0 {
+		req.ExpiresIn = defaultDelegationSeconds
+	}
+
+	ttl := time.Duration(req.ExpiresIn) * time.Second
+	grant, err := s.delegations.GrantDelegation(r.Context(), sessionID, req.InstructorID, req.UserID, req.Types, ttl)
+	if err != nil {
+		s.sendDelegationError(w, err, "Failed to grant delegation")
+		return
+	}
+
+	s.announceDelegation("delegation_granted", grant)
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(grant)
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/delegations - Unexpired grants, so a
+// client that reconnects can restore who is presenting; open to students for that
+func (s *Server) listDelegations(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.delegations == nil {
+		s.sendError(w, "Delegation not configured", http.StatusServiceUnavailable)
+		return
+	}
+	json.NewEncoder(w).Encode(ListDelegationsResponse{Delegations: s.delegations.ListDelegations(sessionID)})
+}
+
+// FUNCTIONAL DISCOVERY: DELETE /api/sessions/{id}/delegations/{user_id}?instructor_id=...
+// - End a grant before it expires; the student's next delegated message is refused
+func (s *Server) revokeDelegation(w http.ResponseWriter, r *http.Request, sessionID, userID string) {
+	if s.delegations == nil {
+		s.sendError(w, "Delegation not configured", http.StatusServiceUnavailable)
+		return
+	}
+	instructorID := r.URL.Query().Get("instructor_id")
+	if _, ok := s.requireInstructor(w, r, sessionID, instructorID, delegationsForbidden); !ok {
+		return
+	}
+
+	grant, err := s.delegations.RevokeDelegation(r.Context(), sessionID, userID, instructorID)
+	if err != nil {
+		s.sendDelegationError(w, err, "Failed to revoke delegation")
+		return
+	}
+
+	s.announceDelegation("delegation_revoked", grant)
+	json.NewEncoder(w).Encode(grant)
+}
+
+// sendDelegationError maps grant and revoke failures to status codes
+func (s *Server) sendDelegationError(w http.ResponseWriter, err error, fallback string) {
+	switch {
+	case errors.Is(err, session.ErrSessionNotFound), errors.Is(err, session.ErrDelegationNotFound):
+		s.sendError(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, session.ErrSessionEnded):
+		s.sendError(w, "Session has ended", http.StatusConflict)
+	case errors.Is(err, session.ErrNotOnRoster), errors.Is(err, session.ErrNotDelegable), errors.Is(err, session.ErrDelegationTTL):
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("%s: %v", fallback, err)
+		s.sendError(w, fallback, http.StatusInternalServerError)
+	}
+}
+
+// DelegationExpired tells a session that a grant ran out
+// ARCHITECTURAL DISCOVERY: Registered with the session manager, whose timers expire
+// grants, as its delegation notifier
+func (s *Server) DelegationExpired(grant *types.Delegation) {
+	s.announceDelegation("delegation_expired", grant)
+}
+
+// announceDelegation tells everyone in the session that a grant started or ended
+func (s *Server) announceDelegation(event string, grant *types.Delegation) {
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "delegation",
+		"content": map[string]interface{}{
+			"event":      event,
+			"session_id": grant.SessionID,
+			"delegation": grant,
+		},
+		"timestamp": time.Now(),
+	}
+
+	for _, conn := range s.registry.GetSessionWriters(grant.SessionID) {
+		if err := conn.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send %s notice to %s: %v", event, conn.GetUserID(), err)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Drainer moves this node's connections to other nodes ahead of maintenance
+type Drainer interface {
+	Drain(window time.Duration) types.DrainStatus
+	Undrain() (types.DrainStatus, bool)
+	Status() types.DrainStatus
+}
+
+// DrainRequest is the optional body of POST /api/admin/drain
+type DrainRequest struct {
+	Window string `json:"window"` // e.g. "45s"; empty uses the configured drain window
+}
+
+// maxDrainWindow bounds the window a drain request may ask for
+const maxDrainWindow = 10 * time.Minute
+
+// SetDrainer sets what POST /api/admin/drain and /api/admin/undrain control
+func (s *Server) SetDrainer(drainer Drainer) {
+	s.drainer = drainer
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/admin/drain - Fail readiness and move this node's
+// connections elsewhere within the window, e.g. {"window": "45s"}. Sessions are not
+// ended; draining an already draining node returns the running drain
+func (s *Server) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.drainer == nil {
+		s.sendError(w, "Draining not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	var req DrainRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+	}
+	var window time.Duration
+	if req.Window != "" {
+		parsed, err := time.ParseDuration(req.Window)
+		if err != nil || parsed <= 0 || parsed > maxDrainWindow {
+			s.sendError(w, "window must be a duration between 0s and 10m", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	json.NewEncoder(w).Encode(s.drainer.Drain(window))
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/admin/undrain - Cancel a drain; readiness passes
+// again and new connections are admitted. 409 when the node is not draining
+func (s *Server) handleUndrain(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.drainer == nil {
+		s.sendError(w, "Draining not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	status, cancelled := s.drainer.Undrain()
+	if !cancelled {
+		s.sendError(w, "Node is not draining", http.StatusConflict)
+		return
+	}
+	json.NewEncoder(w).Encode(status)
+}
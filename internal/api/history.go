@@ -0,0 +1,136 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// HistoryAsOfResponse is the session history one viewer had been sent by AsOf
+type HistoryAsOfResponse struct {
+	SessionID  string           `json:"session_id"`
+	AsOf       time.Time        `json:"as_of"`
+	Viewer     string           `json:"viewer"`
+	ViewerRole string           `json:"viewer_role"` // "student" for roster members, otherwise "instructor"
+	Messages   []*types.Message `json:"messages"`
+	// FUNCTIONAL DISCOVERY: Why the viewer had each message, in message order, under
+	// the session's routing snapshot; both are null for sessions older than snapshots
+	Config     *types.SessionConfig        `json:"session_config"`
+	Deliveries []types.DeliveryExplanation `json:"deliveries"`
+	HasMore    bool                        `json:"has_more"` // A limited page left older messages out
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/history?as_of=<RFC3339>&viewer=<user_id>[&before=<message_id>][&limit=N] -
+// The messages viewer could see at as_of, filtered with the same rules as history
+// replay. Works on ended sessions, so disputes can be settled after class
+// FUNCTIONAL DISCOVERY: before and limit page back from a message: the newest limit
+// messages before it, which is how clients reach what a truncated replay left out
+// ARCHITECTURAL DISCOVERY: As with annotations, the roster is the only role
+// information available; viewers not enrolled as students get the instructor view
+func (s *Server) historyAsOf(w http.ResponseWriter, r *http.Request, sessionID string) {
+	query := r.URL.Query()
+	viewer := query.Get("viewer")
+	if !types.IsValidUserID(viewer) {
+		s.sendError(w, "viewer must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+	asOf, err := time.Parse(time.RFC3339Nano, query.Get("as_of"))
+	if err != nil {
+		s.sendError(w, "as_of must be an RFC3339 timestamp", http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if raw := query.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 1 || limit > types.MaxHistoryPage {
+			s.sendError(w, fmt.Sprintf("limit must be between 1 and %d", types.MaxHistoryPage), http.StatusBadRequest)
+			return
+		}
+	}
+
+	current, err := s.sessionManager.GetSession(r.Context(), sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		}
+		return
+	}
+	if asOf.Before(current.StartTime) {
+		s.sendError(w, "as_of is before the session started", http.StatusUnprocessableEntity)
+		return
+	}
+
+	role := "instructor"
+	for _, studentID := range current.StudentIDs {
+		if studentID == viewer {
+			role = "student"
+			break
+		}
+	}
+
+	messages, err := s.dbManager.GetSessionHistoryAsOf(r.Context(), sessionID, asOf)
+	if err != nil {
+		s.sendError(w, "Failed to get session history", http.StatusInternalServerError)
+		return
+	}
+	config, err := s.dbManager.GetSessionConfig(r.Context(), sessionID)
+	if err != nil {
+		s.sendError(w, "Failed to get session config", http.StatusInternalServerError)
+		return
+	}
+	visible := make([]*types.Message, 0, len(messages))
+	var deliveries []types.DeliveryExplanation
+	for _, message := range messages {
+		if message.VisibleTo(viewer, role) {
+			visible = append(visible, message)
+			if config != nil {
+				deliveries = append(deliveries, config.ExplainDelivery(message, viewer, role))
+			}
+		}
+	}
+	if before := query.Get("before"); before != "" {
+		cut := -1
+		for i, message := range visible {
+			if message.ID == before {
+				cut = i
+				break
+			}
+		}
+		if cut < 0 {
+			s.sendError(w, "before is not a message the viewer could see", http.StatusNotFound)
+			return
+		}
+		visible = visible[:cut]
+		if deliveries != nil {
+			deliveries = deliveries[:cut]
+		}
+	}
+	hasMore := false
+	if limit > 0 && len(visible) > limit {
+		hasMore = true
+		visible = visible[len(visible)-limit:]
+		if deliveries != nil {
+			deliveries = deliveries[len(deliveries)-limit:]
+		}
+	}
+	if config != nil && deliveries == nil {
+		deliveries = []types.DeliveryExplanation{}
+	}
+
+	json.NewEncoder(w).Encode(HistoryAsOfResponse{
+		SessionID:  sessionID,
+		AsOf:       asOf,
+		Viewer:     viewer,
+		ViewerRole: role,
+		Messages:   visible,
+		Config:     config,
+		Deliveries: deliveries,
+		HasMore:    hasMore,
+	})
+}
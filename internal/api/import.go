@@ -0,0 +1,189 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+	"switchboard/pkg/types"
+)
+
+// maxImportBodyBytes caps a single transcript import request
+// TECHNICAL DISCOVERY: 32MB covers several thousand 64KB-limited messages while
+// preventing an unbounded body from exhausting server memory
+const maxImportBodyBytes = 32 << 20
+
+// ImportMessagesResponse reports the outcome of a bulk transcript import
+type ImportMessagesResponse struct {
+	Imported int                 `json:"imported"`
+	Skipped  []string            `json:"skipped"`
+	Errors   []ImportRecordError `json:"errors"`
+}
+
+// ImportRecordError describes why a single record was rejected
+// FUNCTIONAL DISCOVERY: Index refers to the record position in the request body
+// so clients can correlate errors even when the record had no ID
+type ImportRecordError struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/sessions/{id}/messages/import - Import historical messages
+// ARCHITECTURAL DISCOVERY: Imported messages go straight to the database manager and
+// never through the router, so they are not fanned out to live connections
+func (s *Server) importMessages(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if _, err := s.sessionManager.GetSession(r.Context(), sessionID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	records, err := decodeImportRecords(http.MaxBytesReader(w, r.Body, maxImportBodyBytes))
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Invalid import body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	response := ImportMessagesResponse{
+		Skipped: []string{},
+		Errors:  []ImportRecordError{},
+	}
+
+	// FUNCTIONAL DISCOVERY: Per-record validation - invalid records are reported
+	// while the remaining records are still imported
+	valid := make([]*types.Message, 0, len(records))
+	for i, message := range records {
+		if err := validateImportRecord(message, sessionID); err != nil {
+			recordErr := ImportRecordError{Index: i, Error: err.Error()}
+			if message != nil {
+				recordErr.ID = message.ID
+			}
+			response.Errors = append(response.Errors, recordErr)
+			continue
+		}
+		if message.ID == "" {
+			message.ID = uuid.New().String()
+		}
+		valid = append(valid, message)
+	}
+
+	// TECHNICAL DISCOVERY: Stable sort keeps the submitted sequence for equal timestamps
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].Timestamp.Before(valid[j].Timestamp)
+	})
+
+	skipped, err := s.dbManager.ImportMessages(r.Context(), valid)
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Failed to import messages: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if skipped != nil {
+		response.Skipped = skipped
+	}
+	response.Imported = len(valid) - len(skipped)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(response)
+}
+
+// decodeImportRecords accepts either a JSON array or an NDJSON stream of messages
+// TECHNICAL DISCOVERY: json.Decoder reads consecutive values, so NDJSON needs no
+// line splitting - only the leading '[' distinguishes the two formats
+func decodeImportRecords(body io.Reader) ([]*types.Message, error) {
+	reader := bufio.NewReader(body)
+
+	first, err := peekNonSpace(reader)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("body is empty")
+		}
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(reader)
+
+	if first == '[' {
+		var records []*types.Message
+		if err := decoder.Decode(&records); err != nil {
+			return nil, fmt.Errorf("invalid JSON array: %w", err)
+		}
+		return records, nil
+	}
+
+	var records []*types.Message
+	for {
+		var message types.Message
+		if err := decoder.Decode(&message); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("invalid NDJSON record %d: %w", len(records), err)
+		}
+		records = append(records, &message)
+	}
+	return records, nil
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, reader.UnreadByte()
+	}
+}
+
+// validateImportRecord applies types.Message rules plus import-specific requirements
+// FUNCTIONAL DISCOVERY: Imported records must carry explicit timestamps because
+// server-side timestamping would destroy the original transcript chronology
+func validateImportRecord(message *types.Message, sessionID string) error {
+	if message == nil {
+		return errors.New("record is null")
+	}
+
+	if message.SessionID == "" {
+		message.SessionID = sessionID
+	} else if message.SessionID != sessionID {
+		return fmt.Errorf("session_id %q does not match import session", message.SessionID)
+	}
+
+	if message.Timestamp.IsZero() {
+		return errors.New("timestamp is required")
+	}
+
+	// Reactions live in their own table and are tallied, never stored as messages
+	if message.Type == types.MessageTypeReaction {
+		return errors.New("reaction records cannot be imported")
+	}
+
+	if !types.IsValidUserID(message.FromUser) {
+		return types.ErrInvalidUserID
+	}
+
+	if message.ToUser != nil && !types.IsValidUserID(*message.ToUser) {
+		return fmt.Errorf("to_user: %w", types.ErrInvalidUserID)
+	}
+
+	if message.Content == nil {
+		message.Content = map[string]interface{}{}
+	}
+
+	return message.Validate()
+}
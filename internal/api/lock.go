@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// setSessionLocked applies a lock change and tells the session when it toggles
+// FUNCTIONAL DISCOVERY: Repeating the current state succeeds without a broadcast, so
+// students only hear about real changes
+func (s *Server) setSessionLocked(ctx context.Context, sessionID string, locked bool) (*types.Session, error) {
+	wasLocked := s.sessionManager.IsSessionLocked(sessionID)
+	updated, err := s.sessionManager.SetSessionLocked(ctx, sessionID, locked)
+	if err != nil {
+		return nil, err
+	}
+	if wasLocked != locked {
+		s.announceSessionLock(updated)
+	}
+	return updated, nil
+}
+
+// announceSessionLock tells everyone in the session that student sending was locked
+// or unlocked
+// FUNCTIONAL DISCOVERY: Instructors get the notice too, so a co-instructor's view
+// matches what students see
+func (s *Server) announceSessionLock(updated *types.Session) {
+	event := "session_unlocked"
+	if updated.Locked {
+		event = "session_locked"
+	}
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "session_lock",
+		"content": map[string]interface{}{
+			"event":      event,
+			"session_id": updated.ID,
+			"locked":     updated.Locked,
+		},
+		"timestamp": time.Now(),
+	}
+
+	for _, conn := range s.registry.GetSessionWriters(updated.ID) {
+		if err := conn.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send %s notice to %s: %v", event, conn.GetUserID(), err)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"switchboard/pkg/interfaces"
+)
+
+// MaintenanceRequest is the body of POST /api/admin/maintenance
+type MaintenanceRequest struct {
+	Tasks []string `json:"tasks"` // Run in this order: "vacuum", "integrity_check", "analyze"
+}
+
+// SetMaintenance enables the /api/admin/maintenance endpoints
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetMaintenance(maintenance interfaces.MaintenanceRunner) {
+	s.maintenance = maintenance
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/admin/maintenance - Start database maintenance, e.g.
+// {"tasks": ["vacuum", "integrity_check", "analyze"]}, answering 202 with the job to
+// poll; GET /api/admin/maintenance/{job_id} - The job's progress and results
+func (s *Server) handleMaintenance(w http.ResponseWriter, r *http.Request) {
+	if s.maintenance == nil {
+		s.sendError(w, "Maintenance not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	jobID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/maintenance"), "/")
+	if jobID != "" {
+		if r.Method != http.MethodGet {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.getMaintenanceJob(w, r, jobID)
+		return
+	}
+	if r.Method != http.MethodPost {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if len(req.Tasks) == 0 {
+		s.sendError(w, "tasks is required", http.StatusBadRequest)
+		return
+	}
+
+	job, err := s.maintenance.StartMaintenance(req.Tasks)
+	switch {
+	case errors.Is(err, interfaces.ErrUnknownMaintenanceTask):
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, interfaces.ErrMaintenanceRunning), errors.Is(err, interfaces.ErrBackupInProgress):
+		s.sendError(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		s.sendError(w, "Failed to start maintenance", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/api/admin/maintenance/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// getMaintenanceJob answers GET /api/admin/maintenance/{job_id}
+func (s *Server) getMaintenanceJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	job, err := s.maintenance.MaintenanceJob(r.Context(), jobID)
+	if errors.Is(err, interfaces.ErrNotFound) {
+		s.sendError(w, "Maintenance job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.sendError(w, "Failed to get maintenance job", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(job)
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"switchboard/pkg/types"
+)
+
+// metadataFilterPrefix marks list query parameters that filter on session metadata
+const metadataFilterPrefix = "metadata."
+
+// metadataFilters collects ?metadata.<key>=<value> parameters; a repeated key keeps
+// its first value
+func metadataFilters(r *http.Request) map[string]string {
+	var filters map[string]string
+	for name, values := range r.URL.Query() {
+		key, found := strings.CutPrefix(name, metadataFilterPrefix)
+		if !found || len(values) == 0 {
+			continue
+		}
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[key] = values[0]
+	}
+	return filters
+}
+
+// filterByMetadata keeps the sessions whose metadata matches every filter
+// ARCHITECTURAL DISCOVERY: The match runs in SQL against the metadata column and
+// only selects IDs; the sessions themselves still come from the session manager's
+// cache, so filtered and unfiltered lists show the same objects
+func (s *Server) filterByMetadata(ctx context.Context, sessions []*types.Session, filters map[string]string) ([]*types.Session, error) {
+	fields := make(map[string]string)
+	for key := range filters {
+		if !types.IsValidMetadataKey(key) {
+			fields[metadataFilterPrefix+key] = "not a valid metadata key"
+		}
+	}
+	if len(fields) > 0 {
+		return nil, &types.MetadataError{Fields: fields}
+	}
+
+	ids, err := s.dbManager.ActiveSessionsByMetadata(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		matched[id] = true
+	}
+	filtered := make([]*types.Session, 0, len(ids))
+	for _, session := range sessions {
+		if matched[session.ID] {
+			filtered = append(filtered, session)
+		}
+	}
+	return filtered, nil
+}
+
+// sendMetadataError answers 400 with the per-field problems of a *types.MetadataError
+func (s *Server) sendMetadataError(w http.ResponseWriter, err error) {
+	response := ErrorResponse{
+		Error:   http.StatusText(http.StatusBadRequest),
+		Code:    http.StatusBadRequest,
+		Message: types.ErrInvalidMetadata.Error(),
+	}
+	var metadataErr *types.MetadataError
+	if errors.As(err, &metadataErr) {
+		response.Fields = metadataErr.Fields
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// SetWriteStats sets the source of database write stats for GET /api/stats and /metrics
+func (s *Server) SetWriteStats(stats func() types.DatabaseWriteStats) {
+	s.writeStats = stats
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/stats - Server-wide stats: uptime, the database write
+// queue and write latency percentiles per kind since start; unauthenticated like /health,
+// since it carries only counts and timings
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.writeStats == nil {
+		s.sendError(w, "Stats not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	json.NewEncoder(w).Encode(types.ServerStats{
+		UptimeSeconds:   time.Since(s.startTime).Seconds(),
+		Database:        s.writeStats(),
+		SessionCreation: s.creationRejections.stats(),
+	})
+}
+
+// writeQuantiles are the write latency quantiles /metrics reports
+var writeQuantiles = []struct {
+	label string
+	value func(types.WriteLatencyStats) float64
+}{
+	{"0.5", func(l types.WriteLatencyStats) float64 { return l.P50Ms }},
+	{"0.95", func(l types.WriteLatencyStats) float64 { return l.P95Ms }},
+	{"0.99", func(l types.WriteLatencyStats) float64 { return l.P99Ms }},
+}
+
+// FUNCTIONAL DISCOVERY: GET /metrics - The same write stats in the Prometheus text
+// format, for scraping; latency is a summary in seconds labelled by kind and stored
+// message content size a histogram in bytes labelled by message type. Errors stay
+// JSON like the rest of the API; only a successful scrape replaces the content type
+// TECHNICAL DISCOVERY: Written by hand rather than through the Prometheus client
+// library, which would add a dependency tree for a handful of lines
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.writeStats == nil {
+		s.sendError(w, "Stats not configured", http.StatusServiceUnavailable)
+		return
+	}
+	stats := s.writeStats()
+
+	kinds := make([]string, 0, len(stats.Latency))
+	for kind := range stats.Latency {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var b strings.Builder
+	b.WriteString("# HELP switchboard_database_write_duration_seconds Database write latency from queueing to completion.\n")
+	b.WriteString("# TYPE switchboard_database_write_duration_seconds summary\n")
+	for _, kind := range kinds {
+		latency := stats.Latency[kind]
+		for _, quantile := range writeQuantiles {
+			fmt.Fprintf(&b, "switchboard_database_write_duration_seconds{kind=%q,quantile=%q} %g\n", kind, quantile.label, quantile.value(latency)/1000)
+		}
+		fmt.Fprintf(&b, "switchboard_database_write_duration_seconds_sum{kind=%q} %g\n", kind, latency.SumMs/1000)
+		fmt.Fprintf(&b, "switchboard_database_write_duration_seconds_count{kind=%q} %d\n", kind, latency.Count)
+	}
+	sizeTypes := make([]string, 0, len(stats.MessageSizes))
+	for messageType := range stats.MessageSizes {
+		sizeTypes = append(sizeTypes, messageType)
+	}
+	sort.Strings(sizeTypes)
+	b.WriteString("# HELP switchboard_message_content_bytes Stored message content size by message type.\n")
+	b.WriteString("# TYPE switchboard_message_content_bytes histogram\n")
+	for _, messageType := range sizeTypes {
+		sizes := stats.MessageSizes[messageType]
+		for i, bound := range types.MessageSizeBuckets {
+			fmt.Fprintf(&b, "switchboard_message_content_bytes_bucket{type=%q,le=\"%d\"} %d\n", messageType, bound, sizes.Buckets[i])
+		}
+		fmt.Fprintf(&b, "switchboard_message_content_bytes_bucket{type=%q,le=\"+Inf\"} %d\n", messageType, sizes.Count)
+		fmt.Fprintf(&b, "switchboard_message_content_bytes_sum{type=%q} %d\n", messageType, sizes.SumBytes)
+		fmt.Fprintf(&b, "switchboard_message_content_bytes_count{type=%q} %d\n", messageType, sizes.Count)
+	}
+	gauges := []struct {
+		name, help string
+		value      int
+	}{
+		{"switchboard_database_write_queue_depth", "Writes waiting for the single database writer.", stats.QueueDepth},
+		{"switchboard_database_write_queue_capacity", "Capacity of the database write queue.", stats.QueueCapacity},
+		{"switchboard_database_write_queue_max_depth", "Deepest the database write queue has been since start.", stats.MaxQueueDepth},
+	}
+	for _, gauge := range gauges {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n", gauge.name, gauge.help, gauge.name, gauge.name, gauge.value)
+	}
+
+	rejected := s.creationRejections.stats().Rejected
+	b.WriteString("# HELP switchboard_session_create_rejected_total Session creations refused by the creator policy or per-creator quota.\n")
+	b.WriteString("# TYPE switchboard_session_create_rejected_total counter\n")
+	for _, reason := range types.CreateRejectReasons {
+		fmt.Fprintf(&b, "switchboard_session_create_rejected_total{reason=%q} %d\n", reason, rejected[reason])
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
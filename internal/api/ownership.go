@@ -0,0 +1,143 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"switchboard/internal/session"
+	"switchboard/pkg/types"
+)
+
+// defaultOwnerTransferGrace is used until the application applies configuration
+const defaultOwnerTransferGrace = 5 * time.Minute
+
+// TransferSessionRequest hands a session to a new owner via POST
+// FUNCTIONAL DISCOVERY: Identity comes from the body, like instructor_id on create;
+// requested_by is the instructor asking and is recorded in the audit trail
+type TransferSessionRequest struct {
+	NewOwner    string `json:"new_owner"`
+	RequestedBy string `json:"requested_by"`
+}
+
+// SetOwnerTransferGrace sets how long an owner must be disconnected before a connected
+// co-instructor may take over their session
+func (s *Server) SetOwnerTransferGrace(grace time.Duration) {
+	s.ownerTransferGrace = grace
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/sessions/{id}/transfer - Change the session owner
+// The current owner may always transfer; another instructor must be connected to the
+// session and the owner must have been disconnected for the whole grace period
+func (s *Server) transferSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var req TransferSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if !types.IsValidUserID(req.NewOwner) {
+		s.sendError(w, "new_owner must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+	if !types.IsValidUserID(req.RequestedBy) {
+		s.sendError(w, "requested_by must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+
+	current, err := s.sessionManager.GetSession(r.Context(), sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		}
+		return
+	}
+	if current.Status != types.SessionActive {
+		s.sendError(w, "Session already ended", http.StatusBadRequest)
+		return
+	}
+
+	previousOwner := current.Owner()
+	if req.RequestedBy != previousOwner {
+		if connected, _ := s.registry.InstructorPresence(sessionID, req.RequestedBy); !connected {
+			s.sendError(w, "Only the owner or an instructor connected to the session may transfer it", http.StatusForbidden)
+			return
+		}
+		if absent := s.ownerAbsence(current); absent < s.ownerTransferGrace {
+			s.sendError(w, "Session owner has not been absent for the transfer grace period", http.StatusConflict)
+			return
+		}
+	}
+
+	updated, err := s.sessionManager.TransferOwnership(r.Context(), sessionID, req.NewOwner, req.RequestedBy)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrSessionNotFound):
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		case errors.Is(err, session.ErrSessionEnded):
+			s.sendError(w, "Session already ended", http.StatusBadRequest)
+		case errors.Is(err, session.ErrInvalidOwner):
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, session.ErrAlreadyOwner), errors.Is(err, session.ErrOwnerChanged):
+			s.sendError(w, err.Error(), http.StatusConflict)
+		default:
+			s.sendError(w, "Failed to transfer session", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.announceOwnerTransfer(updated, previousOwner, req.RequestedBy)
+	json.NewEncoder(w).Encode(SessionResponse{
+		Session:         updated,
+		ConnectionCount: len(s.registry.GetSessionWriters(sessionID)),
+	})
+}
+
+// ownerAbsence returns how long a session's owner has been disconnected from it
+// TECHNICAL DISCOVERY: An owner not seen since this server started counts as absent
+// from the later of session start and server start, so a restart never makes a
+// long-running session instantly transferable
+func (s *Server) ownerAbsence(current *types.Session) time.Duration {
+	connected, leftAt := s.registry.InstructorPresence(current.ID, current.Owner())
+	if connected {
+		return 0
+	}
+	absentSince := current.StartTime
+	for _, t := range []time.Time{s.startTime, leftAt} {
+		if t.After(absentSince) {
+			absentSince = t
+		}
+	}
+	return time.Since(absentSince)
+}
+
+// announceOwnerTransfer tells instructors connected to the session who owns it now
+// FUNCTIONAL DISCOVERY: Students are not notified - ownership only affects what
+// instructors may do with the session
+func (s *Server) announceOwnerTransfer(updated *types.Session, previousOwner, actor string) {
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "session_owner",
+		"content": map[string]interface{}{
+			"event":          "owner_transferred",
+			"session_id":     updated.ID,
+			"previous_owner": previousOwner,
+			"new_owner":      updated.Owner(),
+			"transferred_by": actor,
+		},
+		"timestamp": time.Now(),
+	}
+
+	for _, conn := range s.registry.GetSessionWriters(updated.ID) {
+		if conn.GetRole() != "instructor" {
+			continue
+		}
+		if err := conn.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send ownership transfer notice to %s: %v", conn.GetUserID(), err)
+		}
+	}
+}
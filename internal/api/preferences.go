@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"switchboard/pkg/types"
+)
+
+// preferencesForbidden is the 403 message for students setting inbox preferences
+const preferencesForbidden = "Only instructors may set inbox preferences"
+
+// PreferenceSetter stores an instructor's inbox preferences for a session
+type PreferenceSetter func(ctx context.Context, sessionID, instructorID string, filters map[string][]string) (*types.InboxPreferencesUpdate, error)
+
+// InboxPreferencesRequest replaces an instructor's inbox preferences via PATCH
+// FUNCTIONAL DISCOVERY: filters maps an instructor fan-out type to the contexts the
+// instructor receives, e.g. {"instructor_inbox": ["technical_issue"]}; an empty
+// object resets them to receiving everything
+type InboxPreferencesRequest struct {
+	InstructorID string              `json:"instructor_id"`
+	Filters      map[string][]string `json:"filters"`
+}
+
+// SetInboxPreferences sets what PATCH /api/sessions/{id}/preferences changes
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetInboxPreferences(set PreferenceSetter) {
+	s.preferences = set
+}
+
+// FUNCTIONAL DISCOVERY: PATCH /api/sessions/{id}/preferences - Choose which
+// instructor_inbox, request_response and analytics messages reach an instructor's
+// connection in an active session; the same change as an inbox_preferences control
+// message. Warnings say when a context now reaches no instructor by preference
+func (s *Server) setInboxPreferences(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.preferences == nil {
+		s.sendError(w, "Inbox preferences not configured", http.StatusServiceUnavailable)
+		return
+	}
+	var req InboxPreferencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	current, instructorID, ok := s.requireInstructorOrKey(w, r, sessionID, req.InstructorID, types.APIKeyScopeManage, preferencesForbidden)
+	if !ok {
+		return
+	}
+	if current.Status != types.SessionActive {
+		s.sendError(w, "Session has ended", http.StatusConflict)
+		return
+	}
+
+	update, err := s.preferences(r.Context(), sessionID, instructorID, req.Filters)
+	if errors.Is(err, types.ErrInvalidInboxFilter) {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.sendError(w, "Failed to set inbox preferences", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(update)
+}
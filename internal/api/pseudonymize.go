@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"switchboard/internal/transcript"
+	"switchboard/pkg/types"
+)
+
+// SetScrubRules sets what a pseudonymized report scrubs from message content
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetScrubRules(rules transcript.ScrubRules) {
+	s.scrubRules = rules
+}
+
+// reportOptions are a report download's query parameters
+type reportOptions struct {
+	format         string
+	pseudonymize   bool
+	seed           string
+	includeMapping bool // Only honored on the admin route
+}
+
+// parseReportOptions reads a report's query parameters, answering 400 for bad ones
+// and 403 for a mapping requested outside the admin route
+func (s *Server) parseReportOptions(w http.ResponseWriter, r *http.Request, admin bool) (reportOptions, bool) {
+	query := r.URL.Query()
+	options := reportOptions{format: query.Get("format"), seed: query.Get("seed")}
+	if options.format == "" {
+		options.format = "html"
+	}
+	if options.format != "html" && options.format != "json" {
+		s.sendError(w, "format must be html or json", http.StatusBadRequest)
+		return options, false
+	}
+	flags := []struct {
+		name  string
+		value *bool
+	}{{"pseudonymize", &options.pseudonymize}, {"include_mapping", &options.includeMapping}}
+	for _, flag := range flags {
+		raw := query.Get(flag.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			s.sendError(w, flag.name+" must be true or false", http.StatusBadRequest)
+			return options, false
+		}
+		*flag.value = value
+	}
+
+	if options.seed != "" && !options.pseudonymize {
+		s.sendError(w, "seed requires pseudonymize=true", http.StatusBadRequest)
+		return options, false
+	}
+	if options.includeMapping && !admin {
+		s.sendError(w, "The pseudonym mapping is only exported through /api/admin/sessions/{id}/report", http.StatusForbidden)
+		return options, false
+	}
+	if options.includeMapping && (!options.pseudonymize || options.format != "json") {
+		s.sendError(w, "include_mapping requires pseudonymize=true and format=json", http.StatusBadRequest)
+		return options, false
+	}
+	return options, true
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/admin/sessions/{id}/report - The session report for
+// any session, with no instructor check; the only route where include_mapping=true
+// adds the pseudonym-to-user-ID mapping to a pseudonymized json report. Unauthenticated
+// like the other admin endpoints, which deployments keep off the public network
+func (s *Server) handleAdminSessionReport(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.reports == nil {
+		s.sendError(w, "Session reports are not enabled on this server", http.StatusNotFound)
+		return
+	}
+	options, ok := s.parseReportOptions(w, r, true)
+	if !ok {
+		return
+	}
+	current, err := s.sessionManager.GetSession(r.Context(), sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		}
+		return
+	}
+	s.serveReport(w, r, current, options)
+}
+
+// newReportPseudonymizer returns the pseudonymizer for one report, or nil when the
+// report is not pseudonymized
+// FUNCTIONAL DISCOVERY: The session's creator, owner and co-instructors keep their IDs;
+// only students are pseudonymized
+func (s *Server) newReportPseudonymizer(current *types.Session, options reportOptions) *transcript.Pseudonymizer {
+	if !options.pseudonymize {
+		return nil
+	}
+	return transcript.NewPseudonymizer(options.seed, current.StudentIDs, current.ListsInstructor, s.scrubRules)
+}
+
+// pseudonymizeReport returns a copy of report with students pseudonymized
+// FUNCTIONAL DISCOVERY: Audit event details are dropped rather than scrubbed - they
+// carry addresses and user IDs in no fixed shape - while their types, actors and times
+// stay; the summary was computed from them before
+func pseudonymizeReport(report *types.SessionReport, p *transcript.Pseudonymizer) *types.SessionReport {
+	session := *report.Session
+	session.StudentIDs = p.Users(report.Session.StudentIDs)
+	copied := *report
+	copied.Session = &session
+
+	copied.Participation = make([]types.ReportParticipant, len(report.Participation))
+	for i, participant := range report.Participation {
+		participant.UserID = p.User(participant.UserID)
+		copied.Participation[i] = participant
+	}
+	sort.Slice(copied.Participation, func(i, j int) bool {
+		a, b := copied.Participation[i].UserID, copied.Participation[j].UserID
+		if len(a) != len(b) {
+			return len(a) < len(b) // Student Z before Student AA
+		}
+		return a < b
+	})
+
+	copied.Events = make([]*types.SessionEvent, len(report.Events))
+	for i, event := range report.Events {
+		scrubbed := *event
+		scrubbed.Actor = p.User(event.Actor)
+		scrubbed.Details = nil
+		copied.Events[i] = &scrubbed
+	}
+	return &copied
+}
+
+// pseudonymizeQuestion returns a copy of question with its messages pseudonymized
+func pseudonymizeQuestion(question *types.ReportQuestion, p *transcript.Pseudonymizer) *types.ReportQuestion {
+	copied := &types.ReportQuestion{Question: p.Message(question.Question), Responses: make([]*types.Message, len(question.Responses))}
+	for i, response := range question.Responses {
+		copied.Responses[i] = p.Message(response)
+	}
+	return copied
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"switchboard/internal/session"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// PurgeConfirmHeader must carry the session ID for a purge to run
+// FUNCTIONAL DISCOVERY: A purge cannot be undone; repeating the ID in a header keeps a
+// stray ?purge=true on a retried end, or a mistyped URL, from deleting anything
+const PurgeConfirmHeader = "X-Confirm-Purge"
+
+// adminPurgeActor is recorded as the requester of admin purges that name nobody
+const adminPurgeActor = "admin"
+
+// SetSessionPurger enables DELETE /api/sessions/{id}?purge=true and DELETE /api/admin/sessions/{id}
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetSessionPurger(purger interfaces.SessionPurger) {
+	s.sessionPurger = purger
+}
+
+// exportTracker keeps purges and streamed exports of the same session apart
+// ARCHITECTURAL DISCOVERY: In memory, like the session manager's end claims - a purge
+// and an export only conflict within one process's lifetime
+type exportTracker struct {
+	mu        sync.Mutex
+	streaming map[string]int  // Exports being written, per session
+	purging   map[string]bool // Sessions being purged
+}
+
+func newExportTracker() *exportTracker {
+	return &exportTracker{streaming: make(map[string]int), purging: make(map[string]bool)}
+}
+
+// beginExport registers an export of sessionID; false while it is being purged
+func (t *exportTracker) beginExport(sessionID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.purging[sessionID] {
+		return false
+	}
+	t.streaming[sessionID]++
+	return true
+}
+
+func (t *exportTracker) endExport(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.streaming[sessionID]--; t.streaming[sessionID] <= 0 {
+		delete(t.streaming, sessionID)
+	}
+}
+
+// beginPurge claims sessionID for a purge, refusing while it is exported or purged
+func (t *exportTracker) beginPurge(sessionID string) (string, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.streaming[sessionID] > 0 {
+		return "Session export in progress; retry the purge when it finishes", false
+	}
+	if t.purging[sessionID] {
+		return "Session purge already in progress", false
+	}
+	t.purging[sessionID] = true
+	return "", true
+}
+
+func (t *exportTracker) endPurge(sessionID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.purging, sessionID)
+}
+
+// purgeRequested reports whether an end asked for ?purge=true, answering 400 for a
+// value that is not a boolean
+func (s *Server) purgeRequested(w http.ResponseWriter, r *http.Request) (purge, ok bool) {
+	raw := r.URL.Query().Get("purge")
+	if raw == "" {
+		return false, true
+	}
+	purge, err := strconv.ParseBool(raw)
+	if err != nil {
+		s.sendError(w, "purge must be true or false", http.StatusBadRequest)
+		return false, false
+	}
+	return purge, true
+}
+
+// FUNCTIONAL DISCOVERY: DELETE /api/admin/sessions/{id}[?requested_by=...] - Purge any
+// session, with the same confirmation header; unauthenticated like the other admin
+// endpoints, which deployments keep off the public network. .../{id}/report is the
+// admin session report
+func (s *Server) handleAdminSessionPurge(w http.ResponseWriter, r *http.Request) {
+	sessionID := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/sessions/"), "/")
+	if reportOf, isReport := strings.CutSuffix(sessionID, "/report"); isReport && reportOf != "" && !strings.Contains(reportOf, "/") {
+		s.handleAdminSessionReport(w, r, reportOf)
+		return
+	}
+	if r.Method != http.MethodDelete {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if sessionID == "" || strings.Contains(sessionID, "/") {
+		s.sendError(w, "Not found", http.StatusNotFound)
+		return
+	}
+	requestedBy := r.URL.Query().Get("requested_by")
+	if requestedBy == "" {
+		requestedBy = adminPurgeActor
+	}
+	s.purgeSession(w, r, sessionID, requestedBy, false)
+}
+
+// purgeSession ends a session if it is active and deletes it with all its data,
+// answering with the rows removed per table
+// FUNCTIONAL DISCOVERY: DELETE /api/sessions/{id}?purge=true&requested_by=... - Only
+// the session's creator may purge through the session endpoint. A purge that fails or
+// is cut short is resumed by sending the same request again
+func (s *Server) purgeSession(w http.ResponseWriter, r *http.Request, sessionID, requestedBy string, creatorOnly bool) {
+	if s.sessionPurger == nil {
+		s.sendError(w, "Session purge not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if r.Header.Get(PurgeConfirmHeader) != sessionID {
+		s.sendError(w, PurgeConfirmHeader+" must repeat the session ID to purge it", http.StatusPreconditionRequired)
+		return
+	}
+	if !types.IsValidUserID(requestedBy) {
+		s.sendError(w, "requested_by must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+
+	current, err := s.sessionManager.GetSession(r.Context(), sessionID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+		}
+		return
+	}
+	if creatorOnly && requestedBy != current.CreatedBy {
+		s.sendError(w, "Only the session's creator may purge it", http.StatusForbidden)
+		return
+	}
+
+	if reason, ok := s.exports.beginPurge(sessionID); !ok {
+		s.sendError(w, reason, http.StatusConflict)
+		return
+	}
+	defer s.exports.endPurge(sessionID)
+
+	if current.Status == types.SessionActive {
+		err := s.terminateSession(r.Context(), sessionID, "Session deleted")
+		if errors.Is(err, session.ErrSessionEndVetoed) {
+			s.sendError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil && !errors.Is(err, session.ErrSessionAlreadyEnded) {
+			s.sendError(w, "Failed to end session", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	purge, err := s.sessionPurger.PurgeSession(r.Context(), sessionID, requestedBy)
+	if errors.Is(err, interfaces.ErrNotFound) {
+		s.sendError(w, "Session not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Printf("Failed to purge session %s: %v", sessionID, err)
+		}
+		s.sendError(w, "Failed to purge session; repeat the request to resume", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(purge)
+}
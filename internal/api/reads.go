@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// SetReadReceipts enables GET /api/sessions/{id}/messages/{message_id}/reads
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetReadReceipts(store interfaces.ReadReceiptStore) {
+	s.readReceipts = store
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/messages/{message_id}/reads?instructor_id=... -
+// Which of the students a message reached have read it. Recipients are the roster for
+// a whole-session broadcast and the addressed students otherwise; messages students
+// send reach no students and list none. Works on ended sessions too
+func (s *Server) messageReads(w http.ResponseWriter, r *http.Request, sessionID, messageID string) {
+	if s.readReceipts == nil {
+		s.sendError(w, "Read receipts not configured", http.StatusServiceUnavailable)
+		return
+	}
+	current, _, ok := s.requireInstructorOrKey(w, r, sessionID, r.URL.Query().Get("instructor_id"), types.APIKeyScopeReadHistory, "Only instructors may view read receipts")
+	if !ok {
+		return
+	}
+
+	message, err := s.dbManager.GetMessage(r.Context(), sessionID, messageID)
+	if errors.Is(err, interfaces.ErrNotFound) {
+		s.sendError(w, "Message not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.sendError(w, "Failed to get message", http.StatusInternalServerError)
+		return
+	}
+	reads, err := s.readReceipts.GetMessageReads(r.Context(), messageID)
+	if err != nil {
+		s.sendError(w, "Failed to get read receipts", http.StatusInternalServerError)
+		return
+	}
+
+	read := make(map[string]bool, len(reads))
+	for _, receipt := range reads {
+		read[receipt.UserID] = true
+	}
+	response := types.MessageReads{
+		MessageID: messageID,
+		ReadCount: len(reads),
+		Read:      reads,
+		Unread:    []string{},
+	}
+	for _, studentID := range current.StudentIDs {
+		if !message.ReachesStudent(studentID) {
+			continue
+		}
+		response.RecipientCount++
+		if !read[studentID] {
+			response.Unread = append(response.Unread, studentID)
+		}
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
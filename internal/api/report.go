@@ -0,0 +1,383 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"time"
+
+	"switchboard/internal/transcript"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// SetReports enables GET /api/sessions/{id}/report
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetReports(reports interfaces.ReportReader) {
+	s.reports = reports
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/report?instructor_id=...&format=html|json[&pseudonymize=true[&seed=...]] -
+// A downloadable after-class report: summary, participation table, questions with
+// their responses, per-minute and analytics chart data, the session's audit trail, its
+// routing snapshot and starred messages. html (the default) is one self-contained
+// document; json is the same data for custom rendering, as {"report":...,"questions":[...],"starred_messages":[...]}
+// FUNCTIONAL DISCOVERY: pseudonymize=true names students "Student A", "Student B", ...
+// throughout and scrubs the configured content fields. The names differ from export to
+// export unless the same seed is passed
+// TECHNICAL DISCOVERY: Aggregates are built first, then questions and starred messages
+// are written as they are read, so memory does not grow with the session. Once the
+// first byte is out a failure can only cut the document short, and is logged
+func (s *Server) sessionReport(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.reports == nil {
+		s.sendError(w, "Session reports are not enabled on this server", http.StatusNotFound)
+		return
+	}
+	options, ok := s.parseReportOptions(w, r, false)
+	if !ok {
+		return
+	}
+
+	current, _, ok := s.requireInstructorOrKey(w, r, sessionID, r.URL.Query().Get("instructor_id"), types.APIKeyScopeReadHistory, "Only instructors may download session reports")
+	if !ok {
+		return
+	}
+	s.serveReport(w, r, current, options)
+}
+
+// serveReport builds and streams a session's report once the caller may read it
+func (s *Server) serveReport(w http.ResponseWriter, r *http.Request, current *types.Session, options reportOptions) {
+	sessionID := current.ID
+	if !s.exports.beginExport(sessionID) {
+		s.sendError(w, "Session is being purged", http.StatusConflict)
+		return
+	}
+	defer s.exports.endExport(sessionID)
+
+	report, err := s.buildReport(r.Context(), current)
+	if err != nil {
+		s.sendError(w, "Failed to build session report", http.StatusInternalServerError)
+		return
+	}
+	pseudonyms := s.newReportPseudonymizer(current, options)
+	if pseudonyms != nil {
+		report = pseudonymizeReport(report, pseudonyms)
+	}
+
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": fmt.Sprintf("session-%s-report.%s", sessionID, options.format),
+	}))
+	if options.format == "json" {
+		err = s.writeJSONReport(r.Context(), w, report, pseudonyms, options.includeMapping)
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err = s.writeHTMLReport(r.Context(), w, report, pseudonyms)
+	}
+	if err != nil {
+		log.Printf("Session report for %s ended early: %v", sessionID, err)
+	}
+}
+
+// buildReport reads a session's aggregates through the report reader
+func (s *Server) buildReport(ctx context.Context, current *types.Session) (*types.SessionReport, error) {
+	counts, err := s.reports.GetSessionMessageCounts(ctx, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.reports.GetSessionEvents(ctx, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	analytics, err := s.reports.GetSessionAnalytics(ctx, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	config, err := s.reports.GetSessionConfig(ctx, current.ID)
+	if err != nil {
+		return nil, err
+	}
+	if events == nil {
+		events = []*types.SessionEvent{}
+	}
+
+	now := time.Now()
+	until := now
+	if current.EndTime != nil {
+		until = *current.EndTime
+	}
+	duration := until.Sub(current.StartTime).Minutes()
+	perMinute := duration
+	if perMinute < 1 {
+		perMinute = 1 // As in session stats, a first-seconds burst is not extrapolated
+	}
+
+	report := &types.SessionReport{
+		Session:     current,
+		GeneratedAt: now,
+		Summary: types.ReportSummary{
+			TotalMessages:            counts.Total,
+			MessagesByType:           counts.ByType,
+			BytesByType:              counts.BytesByType,
+			MessagesPerMinute:        counts.ByMinute,
+			AverageMessagesPerMinute: float64(counts.Total) / perMinute,
+			DurationMinutes:          duration,
+			Students:                 len(current.StudentIDs),
+			RoutingLatency:           routingLatencyFromEvents(events),
+			Delivery:                 deliveryStatsFromEvents(events),
+			Bandwidth:                bandwidthFromEvents(events),
+		},
+		Participation: make([]types.ReportParticipant, 0, len(current.StudentIDs)),
+		Analytics:     analytics,
+		Events:        events,
+		Config:        config,
+	}
+	if current.Status == types.SessionActive && s.routingLatency != nil {
+		report.Summary.RoutingLatency = s.routingLatency(current.ID)
+	}
+	if current.Status == types.SessionActive && s.deliveryStats != nil {
+		report.Summary.Delivery = s.deliveryStats(current.ID)
+	}
+	if current.Status == types.SessionActive && s.bandwidth != nil {
+		report.Summary.Bandwidth = s.bandwidth(current.ID)
+	}
+	for _, studentID := range current.StudentIDs {
+		sent := counts.BySender[studentID]
+		report.Participation = append(report.Participation, types.ReportParticipant{UserID: studentID, Messages: sent, Attended: sent > 0})
+		if sent > 0 {
+			report.Summary.Attended++
+		}
+	}
+	sort.Slice(report.Participation, func(i, j int) bool {
+		return report.Participation[i].UserID < report.Participation[j].UserID
+	})
+	return report, nil
+}
+
+// writeJSONReport streams the report object followed by the two streamed arrays, and
+// the pseudonym mapping last when withMapping is set
+// TECHNICAL DISCOVERY: The mapping comes last because students who left the roster are
+// only named as their messages stream past
+func (s *Server) writeJSONReport(ctx context.Context, w io.Writer, report *types.SessionReport, pseudonyms *transcript.Pseudonymizer, withMapping bool) error {
+	encoder := json.NewEncoder(w)
+	if _, err := io.WriteString(w, `{"report":`); err != nil {
+		return err
+	}
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, `,"questions":[`); err != nil {
+		return err
+	}
+	written := 0
+	err := s.reports.StreamQuestions(ctx, report.Session.ID, func(question *types.ReportQuestion) error {
+		if pseudonyms != nil {
+			question = pseudonymizeQuestion(question, pseudonyms)
+		}
+		return writeJSONElement(w, encoder, &written, question)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, `],"starred_messages":[`); err != nil {
+		return err
+	}
+	written = 0
+	err = s.reports.StreamStarredMessages(ctx, report.Session.ID, func(message *types.Message) error {
+		if pseudonyms != nil {
+			message = pseudonyms.Message(message)
+		}
+		return writeJSONElement(w, encoder, &written, message)
+	})
+	if err != nil {
+		return err
+	}
+
+	if withMapping {
+		if _, err := io.WriteString(w, `],"pseudonyms":`); err != nil {
+			return err
+		}
+		if err := encoder.Encode(pseudonyms.Mapping()); err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, "}\n")
+		return err
+	}
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
+
+// writeJSONElement writes one array element, preceded by a comma after the first
+func writeJSONElement(w io.Writer, encoder *json.Encoder, written *int, element interface{}) error {
+	if *written > 0 {
+		if _, err := io.WriteString(w, ","); err != nil {
+			return err
+		}
+	}
+	*written++
+	return encoder.Encode(element)
+}
+
+// reportView is what the HTML report's summary templates render
+type reportView struct {
+	*types.SessionReport
+	MaxPerMinute int     // Longest bar of the per-minute chart
+	MaxAnalytics int     // Longest bar of the analytics chart
+	Attempted    int     // Deliveries attempted, delivered or failed
+	Reliability  float64 // Delivery reliability as a percentage
+}
+
+// writeHTMLReport renders the report template section by section
+// TECHNICAL DISCOVERY: Each question and starred message is its own template execution,
+// so the document is never assembled in memory
+func (s *Server) writeHTMLReport(ctx context.Context, w io.Writer, report *types.SessionReport, pseudonyms *transcript.Pseudonymizer) error {
+	view := reportView{
+		SessionReport: report,
+		Attempted:     report.Summary.Delivery.Delivered + report.Summary.Delivery.Failed,
+		Reliability:   report.Summary.Delivery.Reliability * 100,
+	}
+	for _, minute := range report.Summary.MessagesPerMinute {
+		view.MaxPerMinute = max(view.MaxPerMinute, minute.Count)
+	}
+	for _, bucket := range report.Analytics {
+		view.MaxAnalytics = max(view.MaxAnalytics, bucket.Count)
+	}
+
+	if err := reportTemplates.ExecuteTemplate(w, "head", view); err != nil {
+		return err
+	}
+	questions := 0
+	err := s.reports.StreamQuestions(ctx, report.Session.ID, func(question *types.ReportQuestion) error {
+		questions++
+		if pseudonyms != nil {
+			question = pseudonymizeQuestion(question, pseudonyms)
+		}
+		return reportTemplates.ExecuteTemplate(w, "question", question)
+	})
+	if err != nil {
+		return err
+	}
+	if err := reportTemplates.ExecuteTemplate(w, "starred", questions); err != nil {
+		return err
+	}
+	starred := 0
+	err = s.reports.StreamStarredMessages(ctx, report.Session.ID, func(message *types.Message) error {
+		starred++
+		if pseudonyms != nil {
+			message = pseudonyms.Message(message)
+		}
+		return reportTemplates.ExecuteTemplate(w, "message", message)
+	})
+	if err != nil {
+		return err
+	}
+	return reportTemplates.ExecuteTemplate(w, "foot", starred)
+}
+
+// messageText is what the HTML report shows of a message
+// ARCHITECTURAL DISCOVERY: Rendered by transcript.FlattenContent like every other
+// export, so a starred message reads the same in the report as in a transcript
+func messageText(message *types.Message) string {
+	return transcript.FlattenContent(message.Content)
+}
+
+// reportTemplates render the HTML report; "head" ends inside the question list and
+// "starred" inside the starred list, which "question" and "message" items fill
+// FUNCTIONAL DISCOVERY: Styles are inline and charts are CSS bars, so the downloaded
+// file opens anywhere without fetching anything
+var reportTemplates = template.Must(template.New("report").Funcs(template.FuncMap{
+	"percent": func(count, longest int) int {
+		if longest == 0 {
+			return 0
+		}
+		return count * 100 / longest
+	},
+	"clock":  func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04:05 UTC") },
+	"minute": func(t time.Time) string { return t.UTC().Format("15:04") },
+	"text":   messageText,
+}).Parse(`{{define "head"}}<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Session report: {{.Session.Name}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 60em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; text-align: left; }
+.bar { background: #4a7bd0; height: 0.8em; }
+.chart td:last-child { width: 30em; }
+.absent { color: #a33; }
+.meta { color: #666; font-size: 0.9em; }
+blockquote { margin: 0.25em 0 0.25em 1.5em; padding-left: 0.5em; border-left: 3px solid #4a7bd0; }
+</style>
+</head>
+<body>
+<h1>{{.Session.Name}}</h1>
+<p class="meta">Session {{.Session.ID}} &middot; {{.Session.Status}} &middot; started {{clock .Session.StartTime}}{{with .Session.EndTime}} &middot; ended {{clock .}}{{end}} &middot; report generated {{clock .GeneratedAt}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Messages</th><td>{{.Summary.TotalMessages}}</td></tr>
+<tr><th>Duration (minutes)</th><td>{{printf "%.0f" .Summary.DurationMinutes}}</td></tr>
+<tr><th>Messages per minute</th><td>{{printf "%.1f" .Summary.AverageMessagesPerMinute}}</td></tr>
+<tr><th>Students attending</th><td>{{.Summary.Attended}} of {{.Summary.Students}}</td></tr>
+<tr><th>Routing latency p50 / p95</th><td>{{.Summary.RoutingLatency.P50Ms}} ms / {{.Summary.RoutingLatency.P95Ms}} ms ({{.Summary.RoutingLatency.Samples}} samples)</td></tr>
+<tr><th>Delivery reliability</th><td>{{printf "%.2f" .Reliability}}% ({{.Summary.Delivery.Failed}} of {{.Attempted}} failed)</td></tr>
+<tr><th>WebSocket bytes sent / received</th><td>{{.Summary.Bandwidth.BytesSent}} / {{.Summary.Bandwidth.BytesReceived}}</td></tr>
+{{range $type, $count := .Summary.MessagesByType}}<tr><th>{{$type}}</th><td>{{$count}} ({{index $.Summary.BytesByType $type}} bytes)</td></tr>
+{{end}}</table>
+
+<h2>Participation</h2>
+<table>
+<tr><th>Student</th><th>Messages</th><th>Attended</th></tr>
+{{range .Participation}}<tr{{if not .Attended}} class="absent"{{end}}><td>{{.UserID}}</td><td>{{.Messages}}</td><td>{{if .Attended}}yes{{else}}no{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Activity per minute</h2>
+{{if .Summary.MessagesPerMinute}}<table class="chart">
+{{range .Summary.MessagesPerMinute}}<tr><td>{{minute .Minute}}</td><td>{{.Count}}</td><td><div class="bar" style="width: {{percent .Count $.MaxPerMinute}}%"></div></td></tr>
+{{end}}</table>{{else}}<p>No messages.</p>{{end}}
+
+<h2>Analytics</h2>
+{{if .Analytics}}<table class="chart">
+<tr><th>Minute</th><th>Context</th><th>Count</th><th></th></tr>
+{{range .Analytics}}<tr><td>{{minute .Minute}}</td><td>{{.Context}}</td><td>{{.Count}}</td><td><div class="bar" style="width: {{percent .Count $.MaxAnalytics}}%"></div></td></tr>
+{{end}}</table>{{else}}<p>No analytics.</p>{{end}}
+
+<h2>Timeline</h2>
+<ul>
+<li>{{clock .Session.StartTime}} &middot; session started</li>
+{{range .Events}}<li>{{clock .CreatedAt}} &middot; {{.Type}}{{with .Actor}} by {{.}}{{end}}</li>
+{{end}}{{with .Session.EndTime}}<li>{{clock .}} &middot; session ended</li>
+{{end}}</ul>
+
+<h2>Routing configuration</h2>
+{{with .Config}}<p class="meta">Snapshot version {{.Version}} &middot; content up to {{.Limits.MaxContentBytes}} bytes{{if .Limits.RateLimitMessages}} &middot; {{.Limits.RateLimitMessages}} messages per {{.Limits.RateLimitWindowSeconds}} s per user{{end}}{{with .LockExemptTypes}} &middot; routed while locked: {{range $i, $type := .}}{{if $i}}, {{end}}{{$type}}{{end}}{{end}}</p>
+<table>
+<tr><th>Type</th><th>Sender</th><th>Recipients</th><th>Default context</th></tr>
+{{range .Routing}}<tr><td>{{.Type}}</td><td>{{.SenderRole}}</td><td>{{.Recipients}}</td><td>{{.DefaultContext}}</td></tr>
+{{end}}</table>{{else}}<p>Not recorded for this session.</p>{{end}}
+
+<h2>Questions</h2>
+<ol>
+{{end}}{{define "question"}}<li><p><strong>{{.Question.FromUser}}</strong> <span class="meta">{{clock .Question.Timestamp}}</span><br>{{text .Question}}</p>
+{{range .Responses}}<blockquote><strong>{{.FromUser}}</strong> <span class="meta">{{clock .Timestamp}}</span><br>{{text .}}</blockquote>
+{{end}}</li>
+{{end}}{{define "starred"}}</ol>
+{{if eq . 0}}<p>No questions.</p>
+{{end}}
+<h2>Starred messages</h2>
+<ul>
+{{end}}{{define "message"}}<li><strong>{{.FromUser}}</strong> <span class="meta">{{.Type}} &middot; {{clock .Timestamp}}</span><br>{{text .}}</li>
+{{end}}{{define "foot"}}</ul>
+{{if eq . 0}}<p>No starred messages.</p>
+{{end}}</body>
+</html>
+{{end}}`))
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// revisionsForbidden is the 403 message for students reaching the edit endpoints
+const revisionsForbidden = "Only instructors may edit messages or view revisions"
+
+// EditMessageRequest replaces a sent message's content via PATCH
+// FUNCTIONAL DISCOVERY: content replaces the whole content map, as sent over WebSocket
+type EditMessageRequest struct {
+	InstructorID string                 `json:"instructor_id"`
+	Content      map[string]interface{} `json:"content"`
+}
+
+type MessageRevisionsResponse struct {
+	Message   *types.Message           `json:"message"`
+	Revisions []*types.MessageRevision `json:"revisions"`
+}
+
+// SetMessageEditor enables the edit and revision endpoints; messages may be edited
+// until window after they were sent, and window 0 turns editing off
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetMessageEditor(editor interfaces.MessageEditor, window time.Duration) {
+	s.messageEditor = editor
+	s.editWindow = window
+}
+
+// SetContentCheck applies the router's content rules to edited content
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking. Without
+// it edits are only checked by Message.Validate
+func (s *Server) SetContentCheck(check func(*types.Message) error) {
+	s.contentCheck = check
+}
+
+// messagePathID extracts the message ID from "messages/{id}"
+func messagePathID(subresource string) (string, bool) {
+	messageID, found := strings.CutPrefix(subresource, "messages/")
+	return messageID, found && messageID != "" && !strings.Contains(messageID, "/")
+}
+
+// FUNCTIONAL DISCOVERY: PATCH /api/sessions/{id}/messages/{message_id} - Replace the
+// content of an instructor message within the edit window. Only its sender may edit it,
+// and students' messages are never editable. Everyone the message reached is sent a
+// message_edited notice with the new content and revision
+func (s *Server) editMessage(w http.ResponseWriter, r *http.Request, sessionID, messageID string) {
+	if s.messageEditor == nil {
+		s.sendError(w, "Message editing not configured", http.StatusServiceUnavailable)
+		return
+	}
+	var req EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if _, ok := s.requireInstructor(w, r, sessionID, req.InstructorID, revisionsForbidden); !ok {
+		return
+	}
+
+	message, ok := s.loadMessage(w, r, sessionID, messageID)
+	if !ok {
+		return
+	}
+	if !types.IsEditableMessageType(message.Type) {
+		s.sendError(w, "Only instructor messages may be edited", http.StatusForbidden)
+		return
+	}
+	if message.FromUser != req.InstructorID {
+		s.sendError(w, "Only the sender may edit a message", http.StatusForbidden)
+		return
+	}
+	// FUNCTIONAL DISCOVERY: A zero window has always passed, which turns editing off
+	if time.Since(message.Timestamp) >= s.editWindow {
+		s.sendError(w, "Edit window has passed", http.StatusConflict)
+		return
+	}
+
+	// Check the new content as if the message were sent with it
+	edited := *message
+	edited.Content = req.Content
+	if err := edited.Validate(); err != nil {
+		s.sendError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.contentCheck != nil {
+		if err := s.contentCheck(&edited); err != nil {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	updated, err := s.messageEditor.EditMessage(r.Context(), sessionID, messageID, req.InstructorID, edited.Content)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			s.sendError(w, "Message not found in session", http.StatusNotFound)
+		} else {
+			log.Printf("Failed to edit message %s: %v", messageID, err)
+			s.sendError(w, "Failed to edit message", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	s.announceEdit(updated)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/messages/{message_id}/revisions?instructor_id=...
+// - A message as it now reads and the content each edit replaced, oldest first
+func (s *Server) messageRevisions(w http.ResponseWriter, r *http.Request, sessionID, messageID string) {
+	if s.messageEditor == nil {
+		s.sendError(w, "Message editing not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if _, ok := s.requireInstructor(w, r, sessionID, r.URL.Query().Get("instructor_id"), revisionsForbidden); !ok {
+		return
+	}
+	message, ok := s.loadMessage(w, r, sessionID, messageID)
+	if !ok {
+		return
+	}
+
+	revisions, err := s.messageEditor.GetMessageRevisions(r.Context(), messageID)
+	if err != nil {
+		s.sendError(w, "Failed to get message revisions", http.StatusInternalServerError)
+		return
+	}
+	if revisions == nil {
+		revisions = []*types.MessageRevision{}
+	}
+	json.NewEncoder(w).Encode(MessageRevisionsResponse{Message: message, Revisions: revisions})
+}
+
+// loadMessage fetches a persisted message, answering 404 when it is not in the session
+func (s *Server) loadMessage(w http.ResponseWriter, r *http.Request, sessionID, messageID string) (*types.Message, bool) {
+	message, err := s.dbManager.GetMessage(r.Context(), sessionID, messageID)
+	if err != nil {
+		if errors.Is(err, interfaces.ErrNotFound) {
+			s.sendError(w, "Message not found in session", http.StatusNotFound)
+		} else {
+			s.sendError(w, "Failed to get message", http.StatusInternalServerError)
+		}
+		return nil, false
+	}
+	return message, true
+}
+
+// announceEdit sends the edited content to everyone connected who can see the message
+// FUNCTIONAL DISCOVERY: Uses the history visibility rule, so students who never
+// received the message do not learn of it through the edit
+func (s *Server) announceEdit(message *types.Message) {
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "message_edited",
+		"content": map[string]interface{}{
+			"session_id": message.SessionID,
+			"message_id": message.ID,
+			"content":    message.Content,
+			"revision":   message.Revision,
+			"edited_at":  message.EditedAt,
+		},
+		"timestamp": time.Now(),
+	}
+
+	for _, conn := range s.registry.GetSessionWriters(message.SessionID) {
+		if !message.VisibleTo(conn.GetUserID(), conn.GetRole()) {
+			continue
+		}
+		if err := conn.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send message_edited notice to %s: %v", conn.GetUserID(), err)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package api
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"switchboard/internal/session"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// maxRosterBodyBytes caps a roster upload
+// TECHNICAL DISCOVERY: 1MB holds tens of thousands of IDs, far past the student cap
+const maxRosterBodyBytes = 1 << 20
+
+// rosterForbidden is the 403 message for students reaching the roster endpoints
+const rosterForbidden = "Only instructors may export or import the roster"
+
+// Roster import modes
+const (
+	rosterModeMerge   = "merge"   // Add the uploaded students, keep everyone else
+	rosterModeReplace = "replace" // The roster becomes exactly the uploaded students
+)
+
+// RosterPresence returns each student's connections to a session, keyed by student ID
+type RosterPresence func(sessionID string) map[string]types.StudentPresence
+
+// RosterResponse is the JSON body of GET /api/sessions/{id}/roster
+// FUNCTIONAL DISCOVERY: POST accepts the same shape, so an export can be edited and
+// uploaded again; the counts are ignored on the way in
+type RosterResponse struct {
+	SessionID string              `json:"session_id"`
+	Students  []types.RosterEntry `json:"students"`
+}
+
+// rosterRow is one student ID read from an upload, with where it was found
+type rosterRow struct {
+	line      int
+	studentID string
+}
+
+// SetRoster enables the roster endpoints; presence may be nil, leaving the counts zero
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (s *Server) SetRoster(editor interfaces.RosterEditor, presence RosterPresence) {
+	s.rosterEditor = editor
+	s.rosterPresence = presence
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/roster?instructor_id=...&format=csv -
+// The roster in its stored order with each student's joins, leaves, whether they are
+// connected and their connection's bytes, as JSON or as a CSV for spreadsheets
+func (s *Server) exportRoster(w http.ResponseWriter, r *http.Request, sessionID string) {
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		s.sendError(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+	current, _, ok := s.requireInstructorOrKey(w, r, sessionID, query.Get("instructor_id"), types.APIKeyScopeReadHistory, rosterForbidden)
+	if !ok {
+		return
+	}
+
+	var presence map[string]types.StudentPresence
+	if s.rosterPresence != nil {
+		presence = s.rosterPresence(sessionID)
+	}
+	entries := make([]types.RosterEntry, len(current.StudentIDs))
+	for i, studentID := range current.StudentIDs {
+		entries[i] = types.RosterEntry{StudentID: studentID, StudentPresence: presence[studentID]}
+	}
+
+	if format == "json" {
+		json.NewEncoder(w).Encode(RosterResponse{SessionID: sessionID, Students: entries})
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": fmt.Sprintf("session-%s-roster.csv", sessionID),
+	}))
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"student_id", "joins", "leaves", "connected", "bytes_sent", "bytes_received"})
+	for _, entry := range entries {
+		_ = writer.Write([]string{
+			entry.StudentID, strconv.Itoa(entry.Joins), strconv.Itoa(entry.Leaves), strconv.FormatBool(entry.Connected),
+			strconv.FormatUint(entry.BytesSent, 10), strconv.FormatUint(entry.BytesReceived, 10),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		log.Printf("Roster export for %s ended early: %v", sessionID, err)
+	}
+}
+
+// FUNCTIONAL DISCOVERY: POST /api/sessions/{id}/roster?instructor_id=...&mode=merge -
+// Add the students in a CSV or JSON upload to the roster, or with mode=replace make
+// the roster exactly those students. dry_run=true returns the change without making
+// it. Rows that cannot be used are reported by line and the rest are applied, unless
+// strict=true, which refuses the whole upload with 422 when any row is bad
+func (s *Server) importRoster(w http.ResponseWriter, r *http.Request, sessionID string) {
+	if s.rosterEditor == nil {
+		s.sendError(w, "Roster import not configured", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+	mode := query.Get("mode")
+	if mode == "" {
+		mode = rosterModeMerge
+	}
+	if mode != rosterModeMerge && mode != rosterModeReplace {
+		s.sendError(w, "mode must be merge or replace", http.StatusBadRequest)
+		return
+	}
+	var flags [2]bool // dry_run, strict
+	for i, name := range []string{"dry_run", "strict"} {
+		if raw := query.Get(name); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				s.sendError(w, name+" must be true or false", http.StatusBadRequest)
+				return
+			}
+			flags[i] = parsed
+		}
+	}
+	dryRun, strict := flags[0], flags[1]
+	current, _, ok := s.requireInstructorOrKey(w, r, sessionID, query.Get("instructor_id"), types.APIKeyScopeManage, rosterForbidden)
+	if !ok {
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxRosterBodyBytes)
+	var rows []rosterRow
+	var lineErrors []types.RosterLineError
+	var err error
+	if rosterUploadIsCSV(r) {
+		rows, lineErrors, err = readRosterCSV(body)
+	} else {
+		rows, lineErrors, err = readRosterJSON(body)
+	}
+	if err != nil {
+		s.sendError(w, fmt.Sprintf("Invalid roster body: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	studentIDs, rowErrors := checkRosterRows(rows)
+	result := types.RosterImport{
+		Mode:    mode,
+		DryRun:  dryRun,
+		Added:   []string{},
+		Removed: []string{},
+		Errors:  append(append([]types.RosterLineError{}, lineErrors...), rowErrors...),
+	}
+	sort.SliceStable(result.Errors, func(i, j int) bool { return result.Errors[i].Line < result.Errors[j].Line })
+	if strict && len(result.Errors) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	change := types.RosterChange{Add: studentIDs}
+	if mode == rosterModeReplace {
+		uploaded := make(map[string]bool, len(studentIDs))
+		for _, studentID := range studentIDs {
+			uploaded[studentID] = true
+		}
+		for _, studentID := range current.StudentIDs {
+			if !uploaded[studentID] {
+				change.Remove = append(change.Remove, studentID)
+			}
+		}
+	}
+
+	updated, effective, err := s.rosterEditor.UpdateRoster(r.Context(), sessionID, change, dryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, session.ErrSessionNotFound):
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		case errors.Is(err, session.ErrSessionEnded):
+			s.sendError(w, "Session has ended", http.StatusConflict)
+		case errors.Is(err, session.ErrEmptyStudentList), errors.Is(err, session.ErrTooManyStudents), errors.Is(err, session.ErrInvalidStudentID):
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+		default:
+			s.sendError(w, "Failed to update roster", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	result.Added, result.Removed = effective.Add, effective.Remove
+	result.Unchanged = len(studentIDs) - len(effective.Add)
+	if !dryRun {
+		result.Session = updated
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// rosterUploadIsCSV reports whether the upload is CSV: format=csv, or a text/csv body
+// without a format
+func rosterUploadIsCSV(r *http.Request) bool {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return format == "csv"
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return mediaType == "text/csv"
+}
+
+// readRosterCSV reads student IDs from a CSV upload
+// FUNCTIONAL DISCOVERY: A first row with a student_id column is a header and names the
+// column to read, so an exported roster can be uploaded as is; without one the first
+// column is read from the first row on. Rows the CSV reader cannot parse are reported
+// and skipped
+func readRosterCSV(body io.Reader) ([]rosterRow, []types.RosterLineError, error) {
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []rosterRow
+	var lineErrors []types.RosterLineError
+	column, first := 0, true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			lineErrors = append(lineErrors, types.RosterLineError{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		if first {
+			first = false
+			if header := rosterHeaderColumn(record); header >= 0 {
+				column = header
+				continue
+			}
+		}
+		if column >= len(record) {
+			lineErrors = append(lineErrors, types.RosterLineError{Line: line, Error: "row has no student_id column"})
+			continue
+		}
+		rows = append(rows, rosterRow{line: line, studentID: strings.TrimSpace(record[column])})
+	}
+	return rows, lineErrors, nil
+}
+
+// rosterHeaderColumn returns the index of the student_id column, -1 when record is not a header
+func rosterHeaderColumn(record []string) int {
+	for i, field := range record {
+		if strings.EqualFold(strings.TrimSpace(field), "student_id") {
+			return i
+		}
+	}
+	return -1
+}
+
+// readRosterJSON reads student IDs from {"students": [...]}, whose entries are either
+// student ID strings or objects with a student_id
+func readRosterJSON(body io.Reader) ([]rosterRow, []types.RosterLineError, error) {
+	var upload struct {
+		Students []json.RawMessage `json:"students"`
+	}
+	if err := json.NewDecoder(body).Decode(&upload); err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	rows := make([]rosterRow, 0, len(upload.Students))
+	var lineErrors []types.RosterLineError
+	for i, raw := range upload.Students {
+		var studentID string
+		if err := json.Unmarshal(raw, &studentID); err != nil {
+			var entry struct {
+				StudentID string `json:"student_id"`
+			}
+			if err := json.Unmarshal(raw, &entry); err != nil {
+				lineErrors = append(lineErrors, types.RosterLineError{Line: i + 1, Error: "entry must be a student ID or an object with student_id"})
+				continue
+			}
+			studentID = entry.StudentID
+		}
+		rows = append(rows, rosterRow{line: i + 1, studentID: strings.TrimSpace(studentID)})
+	}
+	return rows, lineErrors, nil
+}
+
+// checkRosterRows returns the distinct valid student IDs in upload order and an
+// error for every other row
+func checkRosterRows(rows []rosterRow) ([]string, []types.RosterLineError) {
+	studentIDs := make([]string, 0, len(rows))
+	var lineErrors []types.RosterLineError
+	seen := make(map[string]int, len(rows)) // Student ID -> line first seen
+	for _, row := range rows {
+		switch firstLine, duplicate := seen[row.studentID]; {
+		case row.studentID == "":
+			lineErrors = append(lineErrors, types.RosterLineError{Line: row.line, Error: "student_id is empty"})
+		case !types.IsValidUserID(row.studentID):
+			lineErrors = append(lineErrors, types.RosterLineError{Line: row.line, StudentID: row.studentID, Error: "invalid student ID format"})
+		case duplicate:
+			lineErrors = append(lineErrors, types.RosterLineError{Line: row.line, StudentID: row.studentID, Error: fmt.Sprintf("duplicate of line %d", firstLine)})
+		default:
+			seen[row.studentID] = row.line
+			studentIDs = append(studentIDs, row.studentID)
+		}
+	}
+	return studentIDs, lineErrors
+}
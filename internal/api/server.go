@@ -3,40 +3,100 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"runtime"
 	"strings"
 	"time"
 
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
-	"switchboard/internal/websocket"
+	"switchboard/internal/session"
+	"switchboard/internal/transcript"
 )
 
 // Registry interface to avoid tight coupling to websocket.Registry implementation
+// ARCHITECTURAL DISCOVERY: Connections come back as interfaces.ConnectionWriter, so this
+// package depends only on pkg/interfaces (enforced by TestServer_ImportBoundaries)
 type Registry interface {
-	GetSessionConnections(sessionID string) []*websocket.Connection
+	GetSessionWriters(sessionID string) []interfaces.ConnectionWriter
+	GetSessionConnectionCounts() map[string]int
+	InstructorPresence(sessionID, userID string) (connected bool, leftAt time.Time)
 	GetStats() map[string]int
 }
 
 // ARCHITECTURAL DISCOVERY: HTTP API layer serves as pure interface between external clients and internal components
 // Clean separation - no business logic, only HTTP handling and JSON serialization
 type Server struct {
-	sessionManager interfaces.SessionManager
-	dbManager      interfaces.DatabaseManager
-	registry       Registry
-	router         *http.ServeMux
+	sessionManager     interfaces.SessionManager
+	dbManager          interfaces.DatabaseManager
+	registry           Registry
+	router             *http.ServeMux
+	startTime          time.Time
+	capabilities       *types.Capabilities              // Set by the application from configuration
+	validationRules    *types.ValidationRules           // Set by the application from configuration
+	ownerTransferGrace time.Duration                    // Owner absence required before a co-instructor takes over
+	contentFilterStats func() types.ContentFilterStats  // nil until the application wires the router
+	admissionStats     func() types.AdmissionStats      // nil unless upgrade pacing is configured
+	idempotentEnd      bool                             // Ending an ended session answers 200 instead of 409
+	scalingHint        func() types.ScalingHint         // nil unless a scaling section is configured
+	resourceStats      func() map[string]int            // nil until the application wires the hub
+	routingLatency     func(string) types.LatencyStats  // nil until the application wires the router
+	deliveryStats      func(string) types.DeliveryStats // nil until the application wires the router
+	bandwidth          BandwidthSource                  // nil until the application wires the handler
+	statsCache         *statsCache                      // Short-lived message counts for /stats polling
+	announce           Announcer                        // nil until the application wires the router
+	announceWorkers    int                              // Sessions announced to concurrently
+	announcePacing     time.Duration                    // Delay between starting sessions' announcements
+	selfTestStatus     func() types.SelfTestStatus      // nil unless the self-test is enabled
+	apiKeys            interfaces.APIKeyManager         // nil until the application wires session API keys
+	reports            interfaces.ReportReader          // nil until the application wires the report reader
+	scrubRules         transcript.ScrubRules            // What pseudonymized reports scrub from content
+	throughput         ThroughputHistory                // nil until the application wires the router
+	messageSizes       MessageSizeStats                 // nil until the application wires the database
+	drainer            Drainer                          // nil until the application wires the WebSocket handler
+	maintenance        interfaces.MaintenanceRunner     // nil until the application wires the database
+	preferences        PreferenceSetter                 // nil until the application wires the router
+	readReceipts       interfaces.ReadReceiptStore      // nil until the application wires the database
+	writeStats         func() types.DatabaseWriteStats  // nil until the application wires the database
+	sessionPurger      interfaces.SessionPurger         // nil until the application wires the database
+	delegations        interfaces.DelegationManager     // nil until the application wires the session manager
+	messageEditor      interfaces.MessageEditor         // nil until the application wires the database
+	editWindow         time.Duration                    // How long after sending a message may be edited
+	contentCheck       func(*types.Message) error       // nil until the application wires the router
+	sessionActivity    func(string) (time.Time, bool)   // nil until the application wires the session manager
+	rosterEditor       interfaces.RosterEditor          // nil until the application wires the session manager
+	rosterPresence     RosterPresence                   // nil until the application wires the registry
+	exports            *exportTracker                   // Streamed exports and purges in progress
+	metadataIP         func(string) string              // Client IP as served by the metadata endpoint; nil keeps it off
+	creationRejections creationRejections               // Creations refused by the creator policy or quota
 }
 
+// Runtime baselines observed in tests/scenarios load tests (classroom scale)
+// FUNCTIONAL DISCOVERY: Reported alongside live values in /health so memory
+// growth during long runs can be compared against known-good numbers
+const (
+	baselineMaxGoroutines = 50  // TestClassroomScaleLoad leak threshold after cleanup
+	baselineMaxMemoryMB   = 100 // TestClassroomScaleLoad peak allocation limit
+)
+
 // FUNCTIONAL DISCOVERY: Constructor initializes all dependencies and sets up routing
 // Dependency injection pattern maintains architectural boundaries
 func NewServer(sessionManager interfaces.SessionManager, dbManager interfaces.DatabaseManager, registry Registry) *Server {
 	s := &Server{
-		sessionManager: sessionManager,
-		dbManager:      dbManager,
-		registry:       registry,
-		router:         http.NewServeMux(),
+		sessionManager:     sessionManager,
+		dbManager:          dbManager,
+		registry:           registry,
+		router:             http.NewServeMux(),
+		startTime:          time.Now(),
+		ownerTransferGrace: defaultOwnerTransferGrace,
+		statsCache:         newStatsCache(),
+		exports:            newExportTracker(),
+		creationRejections: newCreationRejections(),
+		announceWorkers:    defaultAnnounceWorkers,
+		announcePacing:     defaultAnnouncePacing,
 	}
 	
 	s.setupRoutes()
@@ -48,8 +108,24 @@ func NewServer(sessionManager interfaces.SessionManager, dbManager interfaces.Da
 func (s *Server) setupRoutes() {
 	// Apply middleware to all routes
 	s.router.Handle("/api/sessions", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleSessions))))
+	s.router.Handle("/api/sessions/validate", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleValidateSession))))
 	s.router.Handle("/api/sessions/", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleSessionByID))))
+	s.router.Handle("/api/me/sessions", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleMySessions))))
+	s.router.Handle("/api/capabilities", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleCapabilities))))
+	s.router.Handle("/api/validation-rules", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleValidationRules))))
+	s.router.Handle("/api/admin/messages/", s.jsonMiddleware(http.HandlerFunc(s.handleMessageMetadata))) // No CORS: holds client IPs
+	s.router.Handle("/api/admin/broadcast", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleAdminBroadcast))))
+	s.router.Handle("/api/admin/maintenance", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleMaintenance))))
+	s.router.Handle("/api/admin/maintenance/", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleMaintenance))))
+	s.router.Handle("/api/admin/sessions/", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleAdminSessionPurge))))
+	s.router.Handle("/api/admin/drain", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleDrain))))
+	s.router.Handle("/api/admin/undrain", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleUndrain))))
+	s.router.Handle("/api/stats", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleStats))))
+	s.router.Handle("/api/stats/history", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleThroughputHistory))))
+	s.router.Handle("/api/stats/message-sizes", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleMessageSizes))))
+	s.router.Handle("/api/scaling-hint", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleScalingHint))))
 	s.router.Handle("/health", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.healthCheck))))
+	s.router.Handle("/metrics", s.corsMiddleware(s.jsonMiddleware(http.HandlerFunc(s.handleMetrics))))
 }
 
 // FUNCTIONAL DISCOVERY: Implement http.Handler interface for integration with standard HTTP server
@@ -81,15 +157,24 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	sessionID := strings.Split(path, "/")[0]
+	parts := strings.Split(path, "/")
+	sessionID := parts[0]
 	if sessionID == "" {
 		s.sendError(w, "Invalid session ID", http.StatusBadRequest)
 		return
 	}
 	
+	// ARCHITECTURAL DISCOVERY: Session-scoped sub-resources share the /api/sessions/ prefix
+	if subresource := strings.Trim(strings.Join(parts[1:], "/"), "/"); subresource != "" {
+		s.handleSessionSubresource(w, r, sessionID, subresource)
+		return
+	}
+	
 	switch r.Method {
 	case http.MethodGet:
 		s.getSession(w, r, sessionID)
+	case http.MethodPatch:
+		s.updateSession(w, r, sessionID)
 	case http.MethodDelete:
 		s.endSession(w, r, sessionID)
 	case http.MethodOptions:
@@ -100,11 +185,163 @@ func (s *Server) handleSessionByID(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// FUNCTIONAL DISCOVERY: Dispatch /api/sessions/{id}/{subresource} endpoints
+func (s *Server) handleSessionSubresource(w http.ResponseWriter, r *http.Request, sessionID, subresource string) {
+	switch subresource {
+	case "messages/import":
+		if r.Method != http.MethodPost {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.importMessages(w, r, sessionID)
+	case "transfer":
+		if r.Method != http.MethodPost {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.transferSession(w, r, sessionID)
+	case "messages":
+		if r.Method != http.MethodGet {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.sessionHistory(w, r, sessionID)
+	case "history":
+		if r.Method != http.MethodGet {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.historyAsOf(w, r, sessionID)
+	case "analytics/me":
+		if r.Method != http.MethodGet {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.myAnalytics(w, r, sessionID)
+	case "stats":
+		if r.Method != http.MethodGet {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.sessionStats(w, r, sessionID)
+	case "report":
+		if r.Method != http.MethodGet {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.sessionReport(w, r, sessionID)
+	case "preferences":
+		if r.Method != http.MethodPatch {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.setInboxPreferences(w, r, sessionID)
+	case "roster":
+		switch r.Method {
+		case http.MethodGet:
+			s.exportRoster(w, r, sessionID)
+		case http.MethodPost:
+			s.importRoster(w, r, sessionID)
+		default:
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "api-keys":
+		switch r.Method {
+		case http.MethodPost:
+			s.createAPIKey(w, r, sessionID)
+		case http.MethodGet:
+			s.listAPIKeys(w, r, sessionID)
+		default:
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	case "delegations":
+		switch r.Method {
+		case http.MethodPost:
+			s.grantDelegation(w, r, sessionID)
+		case http.MethodGet:
+			s.listDelegations(w, r, sessionID)
+		default:
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	default:
+		if userID, ok := delegationUserID(subresource); ok {
+			if r.Method != http.MethodDelete {
+				s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			s.revokeDelegation(w, r, sessionID, userID)
+			return
+		}
+		if keyID, ok := apiKeyID(subresource); ok {
+			if r.Method != http.MethodDelete {
+				s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			s.revokeAPIKey(w, r, sessionID, keyID)
+			return
+		}
+		if studentID, ok := threadStudentID(subresource); ok {
+			if r.Method != http.MethodGet {
+				s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			s.studentThread(w, r, sessionID, studentID)
+			return
+		}
+		if messageID, ok := messagePathID(subresource); ok {
+			if r.Method != http.MethodPatch {
+				s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			s.editMessage(w, r, sessionID, messageID)
+			return
+		}
+		if messageID, ok := messageSubresourceID(subresource, "revisions"); ok {
+			if r.Method != http.MethodGet {
+				s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			s.messageRevisions(w, r, sessionID, messageID)
+			return
+		}
+		if messageID, ok := messageSubresourceID(subresource, "reads"); ok {
+			if r.Method != http.MethodGet {
+				s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			s.messageReads(w, r, sessionID, messageID)
+			return
+		}
+		messageID, ok := messageSubresourceID(subresource, "annotations")
+		if !ok {
+			s.sendError(w, "Resource not found", http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodPatch {
+			s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.annotateMessage(w, r, sessionID, messageID)
+	}
+}
+
 // Request/Response types for JSON serialization
 type CreateSessionRequest struct {
-	Name         string   `json:"name"`
-	InstructorID string   `json:"instructor_id"`
-	StudentIDs   []string `json:"student_ids"`
+	Name            string   `json:"name"`
+	InstructorID    string   `json:"instructor_id"`
+	StudentIDs      []string `json:"student_ids"`
+	DurationMinutes int      `json:"duration_minutes,omitempty"` // Optional auto-end after this many minutes
+	Timezone        string   `json:"timezone,omitempty"`         // IANA zone exports render in; UTC when empty
+	Metadata        map[string]string `json:"metadata,omitempty"` // Frontend key/value data, see types.ValidateSessionMetadata
+	InstructorIDs   []string `json:"instructor_ids,omitempty"` // Co-instructors who may join when instructors are scoped to members
+}
+
+// UpdateSessionRequest changes mutable session settings via PATCH
+type UpdateSessionRequest struct {
+	DurationMinutes *int  `json:"duration_minutes"` // Counted from start_time; 0 removes the limit
+	Locked          *bool `json:"locked"`           // true stops students sending until unlocked
+	Metadata        map[string]*string `json:"metadata"` // Merge patch; a null value removes its key
+	InstructorIDs   []string `json:"instructor_ids"` // Replaces the co-instructor list; [] clears it
 }
 
 type CreateSessionResponse struct {
@@ -122,21 +359,48 @@ type ListSessionsResponse struct {
 
 type SessionWithConnections struct {
 	*types.Session
-	ConnectionCount int `json:"connection_count"`
+	ConnectionCount int        `json:"connection_count"`
+	LastActivity    *time.Time `json:"last_activity,omitempty"` // Last routed message or connection change, if known
+}
+
+type UserSessionsResponse struct {
+	Sessions []UserSessionSummary `json:"sessions"`
+}
+
+type UserSessionSummary struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedBy string    `json:"created_by"`
+	StartTime time.Time `json:"start_time"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
 }
 
 type HealthResponse struct {
-	Status      string                 `json:"status"`
-	Timestamp   time.Time             `json:"timestamp"`
-	Database    string                `json:"database"`
-	Connections map[string]int        `json:"connections"`
-	System      map[string]interface{} `json:"system"`
+	Status        string                    `json:"status"`
+	Timestamp     time.Time                 `json:"timestamp"`
+	Database      string                    `json:"database"`
+	Connections   map[string]int            `json:"connections"`
+	System        map[string]interface{}    `json:"system"`
+	Persistence   types.DatabaseHealth      `json:"persistence"`
+	ContentFilter *types.ContentFilterStats `json:"content_filter,omitempty"`
+	Admission     *types.AdmissionStats     `json:"admission,omitempty"`
+	SelfTest      *types.SelfTestStatus     `json:"self_test,omitempty"`
+	Drain         *types.DrainStatus        `json:"drain,omitempty"`
+	// FUNCTIONAL DISCOVERY: Entries each feature holds for sessions; all drop back
+	// once the sessions that created them end
+	SessionResources map[string]int `json:"session_resources,omitempty"`
 }
 
 type ErrorResponse struct {
 	Error   string `json:"error"`
 	Code    int    `json:"code"`
 	Message string `json:"message"`
+	// FUNCTIONAL DISCOVERY: Per-field problems keyed by field path, e.g.
+	// "metadata.course", for validation failures a form can point at
+	Fields map[string]string `json:"fields,omitempty"`
+	// FUNCTIONAL DISCOVERY: A machine-readable cause for refusals that have more than
+	// one, e.g. types.CreateRejectReasons on a 403 from POST /api/sessions
+	Reason string `json:"reason,omitempty"`
 }
 
 // FUNCTIONAL DISCOVERY: POST /api/sessions - Create new session with duplicate student ID removal
@@ -160,11 +424,29 @@ func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
 		s.sendError(w, "At least one student ID is required", http.StatusBadRequest)
 		return
 	}
+	// TECHNICAL DISCOVERY: Duration is checked before creation so an invalid value
+	// never leaves behind a session without its requested limit
+	if problem := req.optionsProblem(); problem != "" {
+		s.sendError(w, problem, http.StatusBadRequest)
+		return
+	}
+	if err := types.ValidateSessionMetadata(req.Metadata); err != nil {
+		s.sendMetadataError(w, err)
+		return
+	}
 	
 	// FUNCTIONAL DISCOVERY: Create session through SessionManager (handles duplicate removal)
-	session, err := s.sessionManager.CreateSession(r.Context(), req.Name, req.InstructorID, req.StudentIDs)
+	created, err := s.sessionManager.CreateSession(r.Context(), req.Name, req.InstructorID, req.StudentIDs)
 	if err != nil {
-		if strings.Contains(err.Error(), "validation") {
+		if errors.Is(err, session.ErrCreatorNotAllowed) {
+			s.sendCreateRejection(w, types.CreateRejectCreatorNotAllowed, err)
+		} else if errors.Is(err, session.ErrQuotaExceeded) {
+			s.sendCreateRejection(w, types.CreateRejectQuotaExceeded, err)
+		} else if errors.Is(err, session.ErrDuplicateName) {
+			s.sendError(w, err.Error(), http.StatusConflict)
+		} else if errors.Is(err, session.ErrTooManyStudents) {
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+		} else if strings.Contains(err.Error(), "validation") {
 			s.sendError(w, err.Error(), http.StatusBadRequest)
 		} else {
 			s.sendError(w, "Failed to create session", http.StatusInternalServerError)
@@ -172,9 +454,42 @@ func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if req.DurationMinutes > 0 {
+		created, err = s.sessionManager.SetSessionDuration(r.Context(), created.ID, req.DurationMinutes)
+		if err != nil {
+			s.sendError(w, "Failed to set session duration", http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.Timezone != "" && req.Timezone != created.Zone() {
+		created, err = s.sessionManager.SetSessionTimezone(r.Context(), created.ID, req.Timezone)
+		if err != nil {
+			s.sendError(w, "Failed to set session time zone", http.StatusInternalServerError)
+			return
+		}
+	}
+	if len(req.InstructorIDs) > 0 {
+		created, err = s.sessionManager.SetSessionInstructors(r.Context(), created.ID, req.InstructorIDs)
+		if err != nil {
+			s.sendError(w, "Failed to set session instructors", http.StatusInternalServerError)
+			return
+		}
+	}
+	if len(req.Metadata) > 0 {
+		patch := make(map[string]*string, len(req.Metadata))
+		for key, value := range req.Metadata {
+			patch[key] = &value
+		}
+		created, err = s.sessionManager.UpdateSessionMetadata(r.Context(), created.ID, patch)
+		if err != nil {
+			s.sendError(w, "Failed to set session metadata", http.StatusInternalServerError)
+			return
+		}
+	}
+	
 	// FUNCTIONAL DISCOVERY: Return 201 Created with session data
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(CreateSessionResponse{Session: session})
+	json.NewEncoder(w).Encode(CreateSessionResponse{Session: created})
 }
 
 // FUNCTIONAL DISCOVERY: GET /api/sessions/{id} - Get session details with connection count
@@ -190,7 +505,7 @@ func (s *Server) getSession(w http.ResponseWriter, r *http.Request, sessionID st
 	}
 	
 	// FUNCTIONAL DISCOVERY: Include current connection count from registry
-	connections := s.registry.GetSessionConnections(sessionID)
+	connections := s.registry.GetSessionWriters(sessionID)
 	connectionCount := len(connections)
 	
 	json.NewEncoder(w).Encode(SessionResponse{
@@ -199,48 +514,95 @@ func (s *Server) getSession(w http.ResponseWriter, r *http.Request, sessionID st
 	})
 }
 
-// FUNCTIONAL DISCOVERY: DELETE /api/sessions/{id} - End session
-func (s *Server) endSession(w http.ResponseWriter, r *http.Request, sessionID string) {
-	log.Printf("DEBUG: endSession() called for sessionID: %s", sessionID)
-	
-	// Notify all connected clients before ending the session
-	connections := s.registry.GetSessionConnections(sessionID)
-	log.Printf("DEBUG: GetSessionConnections() returned %d connections for session %s", len(connections), sessionID)
-	
-	if len(connections) > 0 {
-		sessionEndedMsg := map[string]interface{}{
-			"type":    "system",
-			"context": "session_ended",
-			"content": map[string]interface{}{
-				"event":  "session_ended",
-				"reason": "Session ended by instructor",
-			},
-		}
-		
-		log.Printf("DEBUG: Prepared session_ended message: %+v", sessionEndedMsg)
-		
-		// Send session_ended message to all connected clients
-		successCount := 0
-		for i, conn := range connections {
-			log.Printf("DEBUG: Attempting to send session_ended to connection %d", i)
-			if err := conn.WriteJSON(sessionEndedMsg); err != nil {
-				log.Printf("ERROR: Failed to send session_ended to client %d: %v", i, err)
-			} else {
-				successCount++
-				log.Printf("DEBUG: Successfully sent session_ended to connection %d", i)
-			}
+// FUNCTIONAL DISCOVERY: PATCH /api/sessions/{id} - Change the duration limit, lock,
+// metadata or co-instructors
+func (s *Server) updateSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	var req UpdateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if req.DurationMinutes == nil && req.Locked == nil && req.Metadata == nil && req.InstructorIDs == nil {
+		s.sendError(w, "duration_minutes, locked, metadata or instructor_ids is required", http.StatusBadRequest)
+		return
+	}
+	for _, instructorID := range req.InstructorIDs {
+		if !types.IsValidUserID(instructorID) {
+			s.sendError(w, fmt.Sprintf("instructor_ids must be valid user IDs, got %q", instructorID), http.StatusBadRequest)
+			return
 		}
-		log.Printf("SUCCESS: Sent session_ended message to %d/%d connected clients", successCount, len(connections))
-	} else {
-		log.Printf("WARNING: No connections found for session %s - cannot send session_ended message", sessionID)
 	}
 	
-	err := s.sessionManager.EndSession(r.Context(), sessionID)
+	var session *types.Session
+	var err error
+	if req.DurationMinutes != nil {
+		session, err = s.sessionManager.SetSessionDuration(r.Context(), sessionID, *req.DurationMinutes)
+	}
+	if err == nil && req.Locked != nil {
+		session, err = s.setSessionLocked(r.Context(), sessionID, *req.Locked)
+	}
+	if err == nil && req.Metadata != nil {
+		session, err = s.sessionManager.UpdateSessionMetadata(r.Context(), sessionID, req.Metadata)
+	}
+	if err == nil && req.InstructorIDs != nil {
+		session, err = s.sessionManager.SetSessionInstructors(r.Context(), sessionID, req.InstructorIDs)
+	}
 	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
+		switch {
+		case errors.Is(err, types.ErrInvalidMetadata):
+			s.sendMetadataError(w, err)
+		case strings.Contains(err.Error(), "not found"):
 			s.sendError(w, "Session not found", http.StatusNotFound)
-		} else if strings.Contains(err.Error(), "already ended") {
+		case strings.Contains(err.Error(), "ended"):
 			s.sendError(w, "Session already ended", http.StatusBadRequest)
+		case strings.Contains(err.Error(), "duration"):
+			s.sendError(w, err.Error(), http.StatusBadRequest)
+		default:
+			s.sendError(w, "Failed to update session", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	json.NewEncoder(w).Encode(SessionResponse{
+		Session:         session,
+		ConnectionCount: len(s.registry.GetSessionWriters(sessionID)),
+	})
+}
+
+// SetIdempotentEnd controls how ending an already-ended session is answered
+// FUNCTIONAL DISCOVERY: Off, a repeated DELETE is a 409 conflict; on, it is a 200 with
+// already_ended so clients that retry on timeouts treat it as success
+func (s *Server) SetIdempotentEnd(idempotent bool) {
+	s.idempotentEnd = idempotent
+}
+
+// FUNCTIONAL DISCOVERY: DELETE /api/sessions/{id} - End session; with ?purge=true,
+// end it and delete it with all its data
+func (s *Server) endSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	purge, ok := s.purgeRequested(w, r)
+	if !ok {
+		return
+	}
+	if purge {
+		s.purgeSession(w, r, sessionID, r.URL.Query().Get("requested_by"), true)
+		return
+	}
+	
+	err := s.terminateSession(r.Context(), sessionID, "Session ended by instructor")
+	if errors.Is(err, session.ErrSessionAlreadyEnded) && s.idempotentEnd {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":       "Session already ended",
+			"already_ended": true,
+		})
+		return
+	}
+	if err != nil {
+		if errors.Is(err, session.ErrSessionAlreadyEnded) {
+			s.sendError(w, "Session already ended", http.StatusConflict)
+		} else if strings.Contains(err.Error(), "not found") {
+			s.sendError(w, "Session not found", http.StatusNotFound)
+		} else if errors.Is(err, session.ErrSessionEndVetoed) || errors.Is(err, types.ErrIllegalSessionTransition) {
+			s.sendError(w, err.Error(), http.StatusConflict)
 		} else {
 			s.sendError(w, "Failed to end session", http.StatusInternalServerError)
 		}
@@ -252,27 +614,234 @@ func (s *Server) endSession(w http.ResponseWriter, r *http.Request, sessionID st
 	json.NewEncoder(w).Encode(map[string]string{"message": "Session ended successfully"})
 }
 
+// terminateSession is the single end-of-session flow for manual and automatic ends
+// ARCHITECTURAL DISCOVERY: Client notification and other side effects are session
+// manager hooks; the API only supplies the reason clients are shown
+func (s *Server) terminateSession(ctx context.Context, sessionID, reason string) error {
+	return s.sessionManager.EndSession(session.WithEndReason(ctx, reason), sessionID)
+}
+
+// SessionWarning broadcasts a countdown warning to everyone in a session
+// FUNCTIONAL DISCOVERY: Implements session.ExpiryNotifier; clients show
+// remaining_seconds so the class can wrap up before the automatic end
+func (s *Server) SessionWarning(sessionID string, remaining time.Duration) {
+	warning := map[string]interface{}{
+		"type":    "system",
+		"context": "session_warning",
+		"content": map[string]interface{}{
+			"event":             "session_warning",
+			"remaining_seconds": int(remaining.Seconds()),
+		},
+		"timestamp": time.Now(),
+	}
+	
+	for _, conn := range s.registry.GetSessionWriters(sessionID) {
+		if err := conn.WriteJSON(warning); err != nil {
+			log.Printf("Failed to send session warning for %s: %v", sessionID, err)
+		}
+	}
+}
+
+// SessionExpired ends a session whose duration limit has passed
+// FUNCTIONAL DISCOVERY: Implements session.ExpiryNotifier through the normal end flow
+func (s *Server) SessionExpired(sessionID string) {
+	err := s.terminateSession(context.Background(), sessionID, "Session time limit reached")
+	if err != nil && !errors.Is(err, session.ErrSessionAlreadyEnded) { // Ended manually as the timer fired
+		log.Printf("Failed to auto-end session %s: %v", sessionID, err)
+	}
+}
+
+// DatabaseHealthChanged tells connected instructors that history is or is no longer at risk
+// FUNCTIONAL DISCOVERY: Registered as a database watchdog listener; students are not
+// notified because nothing changes for them while routing continues
+func (s *Server) DatabaseHealthChanged(health types.DatabaseHealth) {
+	event := "database_recovered"
+	if health.Degraded {
+		event = "database_degraded"
+	}
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "database_health",
+		"content": map[string]interface{}{
+			"event":      event,
+			"reason":     health.Reason,
+			"route_only": health.RouteOnly,
+		},
+		"timestamp": time.Now(),
+	}
+	
+	sessions, err := s.sessionManager.ListActiveSessions(context.Background())
+	if err != nil {
+		log.Printf("Failed to list sessions for database health notice: %v", err)
+		return
+	}
+	for _, session := range sessions {
+		for _, conn := range s.registry.GetSessionWriters(session.ID) {
+			if conn.GetRole() != "instructor" {
+				continue
+			}
+			if err := conn.WriteJSON(notice); err != nil {
+				log.Printf("Failed to send database health notice to %s: %v", conn.GetUserID(), err)
+			}
+		}
+	}
+}
+
 // FUNCTIONAL DISCOVERY: GET /api/sessions - List active sessions with connection counts
+// ?metadata.<key>=<value> parameters keep only sessions whose metadata matches them all
 func (s *Server) listSessions(w http.ResponseWriter, r *http.Request) {
 	sessions, err := s.sessionManager.ListActiveSessions(r.Context())
 	if err != nil {
 		s.sendError(w, "Failed to list sessions", http.StatusInternalServerError)
 		return
 	}
+	if filters := metadataFilters(r); len(filters) > 0 {
+		if sessions, err = s.filterByMetadata(r.Context(), sessions, filters); err != nil {
+			if errors.Is(err, types.ErrInvalidMetadata) {
+				s.sendMetadataError(w, err)
+			} else {
+				s.sendError(w, "Failed to list sessions", http.StatusInternalServerError)
+			}
+			return
+		}
+	}
 	
 	// FUNCTIONAL DISCOVERY: Enhance with connection counts from registry
+	// TECHNICAL DISCOVERY: All counts come from one registry pass; sessions without
+	// connections are absent from the map and count as zero
+	counts := s.registry.GetSessionConnectionCounts()
 	sessionsWithConnections := make([]SessionWithConnections, len(sessions))
 	for i, session := range sessions {
-		connections := s.registry.GetSessionConnections(session.ID)
 		sessionsWithConnections[i] = SessionWithConnections{
 			Session:         session,
-			ConnectionCount: len(connections),
+			ConnectionCount: counts[session.ID],
+		}
+		if s.sessionActivity != nil {
+			if at, known := s.sessionActivity(session.ID); known {
+				sessionsWithConnections[i].LastActivity = &at
+			}
 		}
 	}
 	
 	json.NewEncoder(w).Encode(ListSessionsResponse{Sessions: sessionsWithConnections})
 }
 
+// FUNCTIONAL DISCOVERY: GET /api/me/sessions?user_id=...&role=... - Sessions a user may join
+// Identity comes from query parameters, mirroring the WebSocket handshake
+func (s *Server) handleMySessions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	
+	userID := r.URL.Query().Get("user_id")
+	role := r.URL.Query().Get("role")
+	if !types.IsValidUserID(userID) {
+		s.sendError(w, "Invalid user_id format", http.StatusBadRequest)
+		return
+	}
+	if role != "student" && role != "instructor" {
+		s.sendError(w, "Invalid role: must be 'student' or 'instructor'", http.StatusBadRequest)
+		return
+	}
+	
+	sessions, err := s.sessionManager.ListUserSessions(r.Context(), userID, role)
+	if err != nil {
+		s.sendError(w, "Failed to list sessions", http.StatusInternalServerError)
+		return
+	}
+	
+	summaries := make([]UserSessionSummary, len(sessions))
+	for i, session := range sessions {
+		summaries[i] = UserSessionSummary{
+			ID:        session.ID,
+			Name:      session.Name,
+			CreatedBy: session.CreatedBy,
+			StartTime: session.StartTime,
+			Metadata:  session.Metadata,
+		}
+	}
+	
+	json.NewEncoder(w).Encode(UserSessionsResponse{Sessions: summaries})
+}
+
+// SetCapabilities sets the document served by GET /api/capabilities
+func (s *Server) SetCapabilities(capabilities *types.Capabilities) {
+	s.capabilities = capabilities
+}
+
+// SetSessionResourceStats sets the source of per-session resource counts for /health
+func (s *Server) SetSessionResourceStats(stats func() map[string]int) {
+	s.resourceStats = stats
+}
+
+// SetSessionActivity sets where the sessions list reads each session's last activity
+func (s *Server) SetSessionActivity(lastActivity func(sessionID string) (time.Time, bool)) {
+	s.sessionActivity = lastActivity
+}
+
+// SetContentFilterStats sets the source of content allowlist counters for /health
+func (s *Server) SetContentFilterStats(stats func() types.ContentFilterStats) {
+	s.contentFilterStats = stats
+}
+
+// SetAdmissionStats sets the source of upgrade pacing state for /health
+// FUNCTIONAL DISCOVERY: Pacing is reported but does not fail readiness - pulling the
+// instance from a load balancer would only send the reconnect wave elsewhere
+func (s *Server) SetAdmissionStats(stats func() types.AdmissionStats) {
+	s.admissionStats = stats
+}
+
+// SetSelfTestStatus sets the source of routing self-test results for /health
+// FUNCTIONAL DISCOVERY: Unlike admission pacing, a degraded self-test fails readiness -
+// messages are not getting through end to end
+func (s *Server) SetSelfTestStatus(status func() types.SelfTestStatus) {
+	s.selfTestStatus = status
+}
+
+// SetMessageMetadata turns on GET /api/admin/messages/{id}/metadata, serving client
+// IPs through maskIP
+// FUNCTIONAL DISCOVERY: Off unless configured - the API is unauthenticated, so the
+// application passes the same masking audit events use while IPs are redacted
+func (s *Server) SetMessageMetadata(maskIP func(string) string) {
+	s.metadataIP = maskIP
+}
+
+// SetScalingHint sets the source of the autoscaling signal for GET /api/scaling-hint
+func (s *Server) SetScalingHint(hint func() types.ScalingHint) {
+	s.scalingHint = hint
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/scaling-hint - Connection load, write queue use and a
+// suggested replica delta for an orchestrator's custom metric; unauthenticated like /health
+func (s *Server) handleScalingHint(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.scalingHint == nil {
+		s.sendError(w, "Scaling hint not configured", http.StatusServiceUnavailable)
+		return
+	}
+	
+	json.NewEncoder(w).Encode(s.scalingHint())
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/capabilities - Protocol versions, enabled features,
+// limits and routing rules for client feature detection; unauthenticated like /health
+func (s *Server) handleCapabilities(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.capabilities == nil {
+		s.sendError(w, "Capabilities not configured", http.StatusServiceUnavailable)
+		return
+	}
+	
+	json.NewEncoder(w).Encode(s.capabilities)
+}
+
 // FUNCTIONAL DISCOVERY: GET /health - System health check with component validation
 func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
@@ -287,14 +856,32 @@ func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 		dbStatus = fmt.Sprintf("error: %v", err)
 	}
 	
+	// FUNCTIONAL DISCOVERY: Degraded persistence fails readiness even though live
+	// routing may continue, so load balancers and operators see the problem
+	persistence := s.dbManager.HealthStatus()
+	if persistence.Degraded {
+		status = "degraded"
+	}
+	
 	// FUNCTIONAL DISCOVERY: Get connection statistics from registry
 	connectionStats := s.registry.GetStats()
 	
-	// FUNCTIONAL DISCOVERY: Include system information
+	// FUNCTIONAL DISCOVERY: Include runtime information with load-test baselines
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
 	systemInfo := map[string]interface{}{
-		"goroutines": "not_implemented", // Would use runtime.NumGoroutine() in production
-		"memory":     "not_implemented", // Would use runtime.MemStats in production
-		"uptime":     "not_implemented", // Would track application start time
+		"goroutines": runtime.NumGoroutine(),
+		"memory": map[string]interface{}{
+			"alloc_mb":     memStats.Alloc / 1024 / 1024,
+			"sys_mb":       memStats.Sys / 1024 / 1024,
+			"heap_objects": memStats.HeapObjects,
+			"num_gc":       memStats.NumGC,
+		},
+		"uptime": time.Since(s.startTime).String(),
+		"baseline": map[string]interface{}{
+			"max_goroutines": baselineMaxGoroutines,
+			"max_memory_mb":  baselineMaxMemoryMB,
+		},
 	}
 	
 	response := HealthResponse{
@@ -303,10 +890,38 @@ func (s *Server) healthCheck(w http.ResponseWriter, r *http.Request) {
 		Database:    dbStatus,
 		Connections: connectionStats,
 		System:      systemInfo,
+		Persistence: persistence,
+	}
+	if s.contentFilterStats != nil {
+		contentFilter := s.contentFilterStats()
+		response.ContentFilter = &contentFilter
+	}
+	if s.admissionStats != nil {
+		admission := s.admissionStats()
+		response.Admission = &admission
+	}
+	if s.resourceStats != nil {
+		response.SessionResources = s.resourceStats()
+	}
+	if s.selfTestStatus != nil {
+		selfTest := s.selfTestStatus()
+		response.SelfTest = &selfTest
+		if selfTest.Degraded && response.Status == "healthy" {
+			response.Status = "degraded"
+		}
+	}
+	// FUNCTIONAL DISCOVERY: A draining node fails readiness so the load balancer stops
+	// sending it new connections; an unhealthy database still reports as unhealthy
+	if s.drainer != nil {
+		drain := s.drainer.Status()
+		response.Drain = &drain
+		if drain.Draining && response.Status == "healthy" {
+			response.Status = "draining"
+		}
 	}
 	
-	// FUNCTIONAL DISCOVERY: Return 503 if any component is unhealthy
-	if status == "unhealthy" {
+	// FUNCTIONAL DISCOVERY: Return 503 if any component is unhealthy or degraded
+	if response.Status != "healthy" {
 		w.WriteHeader(http.StatusServiceUnavailable)
 	} else {
 		w.WriteHeader(http.StatusOK)
@@ -331,8 +946,8 @@ func (s *Server) corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// FUNCTIONAL DISCOVERY: Set CORS headers for web client compatibility
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
 		w.Header().Set("Access-Control-Max-Age", "86400")
 		
 		// FUNCTIONAL DISCOVERY: Handle preflight requests
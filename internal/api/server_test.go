@@ -5,13 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
-	"switchboard/internal/websocket"
+	"switchboard/internal/apikey"
+	"switchboard/internal/session"
+	"switchboard/internal/transcript"
 )
 
 // ARCHITECTURAL VALIDATION TEST: Interface compliance and boundary enforcement
@@ -80,6 +91,9 @@ func TestServer_EndSession(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
 	}
+	if reason, ended := sessionManager.endReasons["test-session-id"]; !ended || reason != "Session ended by instructor" {
+		t.Errorf("Expected test-session-id ended by instructor, got %v", sessionManager.endReasons)
+	}
 }
 
 // FUNCTIONAL VALIDATION TEST: GET /api/sessions endpoint
@@ -101,6 +115,47 @@ func TestServer_ListSessions(t *testing.T) {
 	}
 }
 
+// FUNCTIONAL VALIDATION TEST: Listed sessions carry their connection counts and last activity
+func TestServer_ListSessionsConnectionCounts(t *testing.T) {
+	registry := newMockRegistry()
+	registry.sessionConnections["session1"] = []interfaces.ConnectionWriter{
+		&mockConnection{userID: "instructor1", role: "instructor"},
+		&mockConnection{userID: "student1", role: "student"},
+	}
+	registry.sessionConnections["other-session"] = []interfaces.ConnectionWriter{
+		&mockConnection{userID: "student9", role: "student"},
+	}
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, registry)
+	
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions", nil))
+	
+	var response ListSessionsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Sessions) != 1 || response.Sessions[0].ConnectionCount != 2 {
+		t.Errorf("Expected session1 with 2 connections, got %+v", response.Sessions)
+	}
+	if response.Sessions[0].LastActivity != nil {
+		t.Errorf("Expected no last_activity before it is wired, got %v", response.Sessions[0].LastActivity)
+	}
+	
+	lastActive := time.Date(2026, 10, 5, 9, 58, 0, 0, time.UTC)
+	server.SetSessionActivity(func(sessionID string) (time.Time, bool) {
+		return lastActive, sessionID == "session1"
+	})
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions", nil))
+	response = ListSessionsResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(response.Sessions) != 1 || response.Sessions[0].LastActivity == nil || !response.Sessions[0].LastActivity.Equal(lastActive) {
+		t.Errorf("Expected session1's last_activity listed, got %+v", response.Sessions)
+	}
+}
+
 // FUNCTIONAL VALIDATION TEST: GET /health endpoint
 func TestServer_HealthCheck(t *testing.T) {
 	// Create mock dependencies
@@ -120,6 +175,347 @@ func TestServer_HealthCheck(t *testing.T) {
 	}
 }
 
+// FUNCTIONAL VALIDATION TEST: A degraded routing self-test fails readiness
+func TestServer_HealthCheckSelfTest(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	status := types.SelfTestStatus{LastSuccess: time.Now(), LatencyMs: 1.5}
+	server.SetSelfTestStatus(func() types.SelfTestStatus { return status })
+	
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	var response HealthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response.SelfTest == nil || response.SelfTest.LatencyMs != 1.5 {
+		t.Errorf("Expected a healthy response with self-test results, got %d %+v", w.Code, response.SelfTest)
+	}
+	
+	status = types.SelfTestStatus{Degraded: true, ConsecutiveFailures: 3}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusServiceUnavailable || response.Status != "degraded" {
+		t.Errorf("Expected 503 degraded, got %d %s", w.Code, response.Status)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: GET /api/capabilities serves the configured document
+func TestServer_Capabilities(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/capabilities", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before capabilities are set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	
+	server.SetCapabilities(&types.Capabilities{
+		ProtocolVersions: []int{types.ProtocolVersion},
+		Features:         map[string]bool{types.FeatureTranscripts: true},
+		Limits:           types.CapabilityLimits{MaxContentBytes: types.MaxContentBytes},
+	})
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/capabilities", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	
+	var capabilities types.Capabilities
+	if err := json.NewDecoder(w.Body).Decode(&capabilities); err != nil {
+		t.Fatalf("Failed to decode capabilities: %v", err)
+	}
+	if !capabilities.Features[types.FeatureTranscripts] || capabilities.Limits.MaxContentBytes != types.MaxContentBytes {
+		t.Errorf("Unexpected capabilities: %+v", capabilities)
+	}
+	
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("POST", "/api/capabilities", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for POST, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: GET /api/validation-rules serves the descriptor with its
+// hash as ETag, and a client checking messages against the served schema and limits
+// reaches the same verdict as Message.Validate
+func TestServer_ValidationRules(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/validation-rules", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before rules are set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	
+	rules := types.NewValidationRules([]types.RouteSummary{
+		{Type: types.MessageTypeAnalytics, SenderRole: "student", Recipients: "session_instructors", DefaultContext: "general"},
+		{Type: types.MessageTypeInstructorBroadcast, SenderRole: "instructor", Recipients: "session_students", DefaultContext: "general"},
+	})
+	rules.Seal()
+	server.SetValidationRules(rules)
+	
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/validation-rules", nil))
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"`+rules.Hash+`"` || len(rules.Hash) != 16 {
+		t.Fatalf("Expected the rules with their hash as ETag, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	var served struct {
+		Hash            string         `json:"hash"`
+		MaxContentBytes int            `json:"max_content_bytes"`
+		MessageSchema   map[string]any `json:"message_schema"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&served); err != nil {
+		t.Fatalf("Failed to decode validation rules: %v", err)
+	}
+	
+	req := httptest.NewRequest("GET", "/api/validation-rules", nil)
+	req.Header.Set("If-None-Match", `"`+served.Hash+`"`)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("Expected %d for the current hash, got %d", http.StatusNotModified, w.Code)
+	}
+	
+	tooLong := strings.Repeat("a", 51)
+	bigContent := `{"blob": "` + strings.Repeat("x", types.MaxContentBytes) + `"}`
+	samples := []string{
+		`{"type": "analytics", "content": {"score": 1}}`,
+		`{"type": "analytics", "context": "", "content": null}`,
+		`{"type": "analytics", "context": "quiz_1"}`,
+		`{"type": "chat"}`,
+		`{"context": "general"}`,
+		`{"type": "analytics", "context": "has space"}`,
+		`{"type": "analytics", "context": "` + tooLong + `"}`,
+		`{"type": "analytics", "content": ` + bigContent + `}`,
+		`{"type": "analytics", "to_users": ["student1"]}`,
+		`{"type": "analytics", "to_users": []}`,
+		`{"type": "instructor_broadcast", "to_users": ["student1", "student1"]}`,
+		`{"type": "instructor_broadcast", "to_users": ["SYSTEM"]}`,
+		`{"type": "instructor_broadcast", "to_users": ["sys:announcements"]}`,
+		`{"type": "instructor_broadcast", "to_users": ["` + tooLong + `"]}`,
+		`{"type": "instructor_broadcast", "to_users": [""]}`,
+		`{"type": "request", "to_user": "student1", "content": {"q": "?"}}`,
+	}
+	for _, sample := range samples {
+		var message types.Message
+		if err := json.Unmarshal([]byte(sample), &message); err != nil {
+			t.Fatalf("Sample %s does not decode: %v", sample, err)
+		}
+		serverVerdict := message.Validate() == nil
+		
+		var document map[string]any
+		json.Unmarshal([]byte(sample), &document)
+		content, _ := json.Marshal(document["content"])
+		clientVerdict := schemaAccepts(t, served.MessageSchema, document) && len(content) <= served.MaxContentBytes
+		
+		if clientVerdict != serverVerdict {
+			t.Errorf("Descriptor says %v but Validate says %v for %.120s", clientVerdict, serverVerdict, sample)
+		}
+	}
+}
+
+// schemaAccepts checks value against a JSON Schema, supporting the keywords
+// types.MessageSchema uses; an unknown keyword fails the test rather than passing silently
+func schemaAccepts(t *testing.T, schema map[string]any, value any) bool {
+	t.Helper()
+	sub := func(s any, v any) bool { return schemaAccepts(t, s.(map[string]any), v) }
+	object, isObject := value.(map[string]any)
+	array, isArray := value.([]any)
+	text, isString := value.(string)
+	
+	for keyword, arg := range schema {
+		switch keyword {
+		case "$schema", "then", "else":
+		case "type":
+			names, ok := arg.([]any)
+			if !ok {
+				names = []any{arg}
+			}
+			matched := false
+			for _, name := range names {
+				matched = matched || jsonType(value) == name
+			}
+			if !matched {
+				return false
+			}
+		case "enum":
+			found := false
+			for _, candidate := range arg.([]any) {
+				found = found || reflect.DeepEqual(candidate, value)
+			}
+			if !found {
+				return false
+			}
+		case "const":
+			if !reflect.DeepEqual(arg, value) {
+				return false
+			}
+		case "required":
+			for _, key := range arg.([]any) {
+				if _, present := object[key.(string)]; isObject && !present {
+					return false
+				}
+			}
+		case "properties":
+			for key, property := range arg.(map[string]any) {
+				if v, present := object[key]; isObject && present && !sub(property, v) {
+					return false
+				}
+			}
+		case "items":
+			for _, item := range array {
+				if !sub(arg, item) {
+					return false
+				}
+			}
+		case "maxItems":
+			if isArray && len(array) > int(arg.(float64)) {
+				return false
+			}
+		case "minLength":
+			if isString && utf8.RuneCountInString(text) < int(arg.(float64)) {
+				return false
+			}
+		case "maxLength":
+			if isString && utf8.RuneCountInString(text) > int(arg.(float64)) {
+				return false
+			}
+		case "pattern":
+			if isString && !regexp.MustCompile(arg.(string)).MatchString(text) {
+				return false
+			}
+		case "not":
+			if sub(arg, value) {
+				return false
+			}
+		case "anyOf":
+			matched := false
+			for _, option := range arg.([]any) {
+				matched = matched || sub(option, value)
+			}
+			if !matched {
+				return false
+			}
+		case "if":
+			branch, present := schema["else"]
+			if sub(arg, value) {
+				branch, present = schema["then"]
+			}
+			if present && !sub(branch, value) {
+				return false
+			}
+		default:
+			t.Fatalf("Schema uses keyword %q the test validator does not support", keyword)
+		}
+	}
+	return true
+}
+
+// jsonType names a decoded JSON value's type as JSON Schema does
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+
+// FUNCTIONAL VALIDATION TEST: GET /api/scaling-hint serves the wired estimator's hint
+func TestServer_ScalingHint(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/scaling-hint", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before the hint is set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	
+	server.SetScalingHint(func() types.ScalingHint {
+		return types.ScalingHint{TotalConnections: 450, MaxConnections: 500, WriteQueuePercent: 12.5, SuggestedReplicaDelta: 1}
+	})
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/scaling-hint", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	
+	var hint types.ScalingHint
+	if err := json.NewDecoder(w.Body).Decode(&hint); err != nil {
+		t.Fatalf("Failed to decode scaling hint: %v", err)
+	}
+	if hint.TotalConnections != 450 || hint.WriteQueuePercent != 12.5 || hint.SuggestedReplicaDelta != 1 {
+		t.Errorf("Unexpected scaling hint: %+v", hint)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: GET /api/stats and GET /metrics serve the wired write stats
+func TestServer_WriteStats(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before stats are set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	
+	server.SetWriteStats(func() types.DatabaseWriteStats {
+		return types.DatabaseWriteStats{
+			QueueDepth:    3,
+			QueueCapacity: 100,
+			MaxQueueDepth: 40,
+			Latency: map[string]types.WriteLatencyStats{
+				"message": {Count: 200, SumMs: 500, P50Ms: 2, P95Ms: 12, P99Ms: 30, MaxMs: 45},
+				"session": {Count: 4, SumMs: 8, P50Ms: 1.5, P95Ms: 3, P99Ms: 3, MaxMs: 3},
+			},
+			MessageSizes: map[string]types.MessageSizeHistogram{
+				"analytics": {Buckets: []uint64{90, 100, 100, 100, 100, 100}, Count: 100, SumBytes: 9000},
+			},
+		}
+	})
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var stats types.ServerStats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	if stats.Database.MaxQueueDepth != 40 || stats.Database.Latency["message"].P95Ms != 12 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Expected a text scrape, got %d with %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE switchboard_database_write_duration_seconds summary",
+		`switchboard_database_write_duration_seconds{kind="message",quantile="0.95"} 0.012`,
+		`switchboard_database_write_duration_seconds_count{kind="session"} 4`,
+		"switchboard_database_write_queue_max_depth 40",
+		"# TYPE switchboard_message_content_bytes histogram",
+		`switchboard_message_content_bytes_bucket{type="analytics",le="128"} 90`,
+		`switchboard_message_content_bytes_bucket{type="analytics",le="+Inf"} 100`,
+		`switchboard_message_content_bytes_sum{type="analytics"} 9000`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
 // FUNCTIONAL VALIDATION TEST: CORS middleware
 func TestServer_CORSMiddleware(t *testing.T) {
 	// Create mock dependencies
@@ -199,22 +595,34 @@ func TestServer_TimeoutHandling(t *testing.T) {
 }
 
 // ARCHITECTURAL VALIDATION TEST: Import boundary enforcement
+// The API layer reaches connections through pkg/interfaces only - importing
+// internal/websocket (or the database and router implementations) fails here
 func TestServer_ImportBoundaries(t *testing.T) {
-	// This test validates that the API layer only imports allowed packages
-	// It will be implemented during GREEN phase to verify architectural compliance
-	
-	// Should only import:
-	// - pkg/interfaces (SessionManager, DatabaseManager)
-	// - pkg/types (Session struct)
-	// - net/http, encoding/json (standard library)
-	// - internal/websocket (for Registry interface only)
-	
-	// Should NOT import:
-	// - internal/database (direct database access)
-	// - internal/router (message routing logic)  
-	// - internal/session (business logic implementation)
+	forbidden := []string{
+		"switchboard/internal/websocket",
+		"switchboard/internal/database",
+		"switchboard/internal/router",
+	}
 	
-	t.Skip("Will be implemented during GREEN phase")
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatalf("Failed to list package files: %v", err)
+	}
+	fset := token.NewFileSet()
+	for _, file := range files {
+		parsed, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+		for _, spec := range parsed.Imports {
+			path := strings.Trim(spec.Path.Value, `"`)
+			for _, banned := range forbidden {
+				if path == banned {
+					t.Errorf("%s imports %s; depend on pkg/interfaces instead", file, path)
+				}
+			}
+		}
+	}
 }
 
 // FUNCTIONAL VALIDATION TEST: Session creation with duplicate removal
@@ -253,6 +661,142 @@ func TestServer_CreateSessionDuplicateRemoval(t *testing.T) {
 	}
 }
 
+// FUNCTIONAL VALIDATION TEST: Duplicate names rejected by the name policy return 409
+func TestServer_CreateSessionDuplicateName(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		createErr: fmt.Errorf("%w: %q", session.ErrDuplicateName, "Test Session"),
+	}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	
+	req := httptest.NewRequest("POST", "/api/sessions", bytes.NewReader([]byte(`{
+		"name": "Test Session",
+		"instructor_id": "instructor1",
+		"student_ids": ["student1"]
+	}`)))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Rosters over the session manager's cap return 400
+func TestServer_CreateSessionTooManyStudents(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		createErr: fmt.Errorf("%w: %d students, at most %d allowed", session.ErrTooManyStudents, 501, 500),
+	}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	
+	req := httptest.NewRequest("POST", "/api/sessions", bytes.NewReader([]byte(`{
+		"name": "Test Session",
+		"instructor_id": "instructor1",
+		"student_ids": ["student1"]
+	}`)))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "at most 500") {
+		t.Errorf("Expected status %d naming the limit, got %d: %s", http.StatusBadRequest, w.Code, w.Body.String())
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Creations refused by the creator policy or quota return
+// 403 with a reason and are counted in /api/stats and /metrics
+func TestServer_CreateSessionForbidden(t *testing.T) {
+	sessionManager := &mockSessionManager{}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	server.SetWriteStats(func() types.DatabaseWriteStats { return types.DatabaseWriteStats{} })
+	
+	tests := []struct {
+		err    error
+		reason string
+	}{
+		{fmt.Errorf("%w: instructor2 is not on the creator allowlist", session.ErrCreatorNotAllowed), types.CreateRejectCreatorNotAllowed},
+		{fmt.Errorf("%w: instructor1 has 2 active sessions, at most 2 allowed", session.ErrQuotaExceeded), types.CreateRejectQuotaExceeded},
+		{fmt.Errorf("%w: instructor1 has 2 active sessions, at most 2 allowed", session.ErrQuotaExceeded), types.CreateRejectQuotaExceeded},
+	}
+	for _, tt := range tests {
+		sessionManager.createErr = tt.err
+		req := httptest.NewRequest("POST", "/api/sessions", bytes.NewReader([]byte(`{
+			"name": "Test Session",
+			"instructor_id": "instructor1",
+			"student_ids": ["student1"]
+		}`)))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		
+		var response ErrorResponse
+		json.NewDecoder(w.Body).Decode(&response)
+		if w.Code != http.StatusForbidden || response.Code != http.StatusForbidden || response.Reason != tt.reason || response.Message != tt.err.Error() {
+			t.Errorf("Expected 403 with reason %s, got %d: %+v", tt.reason, w.Code, response)
+		}
+	}
+	
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats", nil))
+	var stats types.ServerStats
+	json.NewDecoder(w.Body).Decode(&stats)
+	if rejected := stats.SessionCreation.Rejected; rejected[types.CreateRejectCreatorNotAllowed] != 1 || rejected[types.CreateRejectQuotaExceeded] != 2 {
+		t.Errorf("Expected 1 policy and 2 quota rejections counted, got %v", rejected)
+	}
+	
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(w.Body.String(), `switchboard_session_create_rejected_total{reason="creator_quota_exceeded"} 2`) {
+		t.Errorf("Expected the quota rejections in /metrics, got:\n%s", w.Body.String())
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: POST /api/sessions/validate reports without creating
+func TestServer_ValidateSession(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		validation: &types.SessionValidation{
+			Valid:      true,
+			Errors:     []string{},
+			Students:   []types.StudentVerdict{{StudentID: "student1", Valid: true}, {StudentID: "student1", Valid: true, Duplicate: true}},
+			Duplicates: []string{"student1"},
+		},
+	}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	
+	validate := func(body string) (*httptest.ResponseRecorder, types.SessionValidation) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("POST", "/api/sessions/validate", strings.NewReader(body)))
+		var report types.SessionValidation
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatalf("Failed to decode report: %v", err)
+			}
+		}
+		return w, report
+	}
+	
+	w, report := validate(`{"name": "Lab", "instructor_id": "instructor1", "student_ids": ["student1", "student1"]}`)
+	if w.Code != http.StatusOK || !report.Valid || len(report.Students) != 2 || report.Duplicates[0] != "student1" {
+		t.Errorf("Expected the manager's report, got %d: %+v", w.Code, report)
+	}
+	
+	// Options createSession applies after creation are checked too
+	w, report = validate(`{"name": "Lab", "instructor_id": "instructor1", "student_ids": ["student1"], "duration_minutes": 2000, "timezone": "Mars/Olympus"}`)
+	if w.Code != http.StatusOK || report.Valid || len(report.Errors) != 1 || !strings.Contains(report.Errors[0], "duration_minutes") {
+		t.Errorf("Expected the invalid duration reported, got %d: %+v", w.Code, report)
+	}
+	
+	if w, _ := validate(`{`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected %d for invalid JSON, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/validate", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected %d for GET, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+	if sessionManager.validated != 2 {
+		t.Errorf("Expected 2 dry runs, got %d", sessionManager.validated)
+	}
+}
+
 // FUNCTIONAL VALIDATION TEST: Health check with component validation
 func TestServer_HealthCheckValidation(t *testing.T) {
 	// Create mock dependencies
@@ -287,12 +831,39 @@ func TestServer_HealthCheckValidation(t *testing.T) {
 	if response["connections"] == nil {
 		t.Error("Expected connection statistics")
 	}
+	
+	// Should include live runtime values alongside load-test baselines
+	system, ok := response["system"].(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected system information")
+	}
+	if _, ok := system["goroutines"].(float64); !ok {
+		t.Errorf("Expected numeric goroutine count, got %v", system["goroutines"])
+	}
+	if system["baseline"] == nil {
+		t.Error("Expected load-test baselines in system information")
+	}
 }
 
 // Mock implementations for testing (will be replaced during GREEN phase)
-type mockSessionManager struct{}
+type mockSessionManager struct {
+	endReasons map[string]string // sessionID -> reason passed to EndSession
+	createErr  error
+	transfers  []string // "actor->new_owner" per TransferOwnership call
+	startedAt  time.Time // StartTime of sessions returned by GetSession, now if zero
+	locked     map[string]bool
+	timezones  map[string]string // sessionID -> zone set by SetSessionTimezone
+	metadata   map[string]map[string]string // sessionID -> metadata set by UpdateSessionMetadata
+	instructors map[string][]string // sessionID -> co-instructors set by SetSessionInstructors
+	active     []*types.Session  // Returned by ListActiveSessions when set
+	validation *types.SessionValidation // Returned by ValidateSession when set
+	validated  int                      // ValidateSession calls
+}
 
 func (m *mockSessionManager) CreateSession(ctx context.Context, name, instructorID string, studentIDs []string) (*types.Session, error) {
+	if m.createErr != nil {
+		return nil, m.createErr
+	}
 	// Mock successful session creation with duplicate removal
 	uniqueStudents := removeDuplicates(studentIDs)
 	return &types.Session{
@@ -305,6 +876,14 @@ func (m *mockSessionManager) CreateSession(ctx context.Context, name, instructor
 	}, nil
 }
 
+func (m *mockSessionManager) ValidateSession(ctx context.Context, name, instructorID string, studentIDs []string) (*types.SessionValidation, error) {
+	m.validated++
+	if m.validation != nil {
+		return m.validation, nil
+	}
+	return &types.SessionValidation{Valid: true, Errors: []string{}, Duplicates: []string{}}, nil
+}
+
 // Helper function to remove duplicates for testing
 func removeDuplicates(slice []string) []string {
 	seen := make(map[string]bool)
@@ -320,23 +899,46 @@ func removeDuplicates(slice []string) []string {
 
 func (m *mockSessionManager) GetSession(ctx context.Context, sessionID string) (*types.Session, error) {
 	// Mock successful session retrieval
-	return &types.Session{
+	startTime := m.startedAt
+	if startTime.IsZero() {
+		startTime = time.Now()
+	}
+	current := &types.Session{
 		ID:        sessionID,
 		Name:      "Test Session",
 		CreatedBy: "instructor1",
 		StudentIDs: []string{"student1", "student2"},
 		Status:    "active",
-		StartTime: time.Now(),
-	}, nil
+		StartTime: startTime,
+		Timezone:  m.timezones[sessionID],
+		Metadata:  m.metadata[sessionID],
+		InstructorIDs: m.instructors[sessionID],
+	}
+	if _, ended := m.endReasons[sessionID]; ended {
+		endTime := time.Now()
+		current.Status = "ended"
+		current.EndTime = &endTime
+	}
+	return current, nil
 }
 
 func (m *mockSessionManager) EndSession(ctx context.Context, sessionID string) error {
 	// Mock successful session ending
+	if m.endReasons == nil {
+		m.endReasons = make(map[string]string)
+	}
+	if _, ended := m.endReasons[sessionID]; ended {
+		return session.ErrSessionAlreadyEnded
+	}
+	m.endReasons[sessionID] = session.EndReason(ctx)
 	return nil
 }
 
 func (m *mockSessionManager) ListActiveSessions(ctx context.Context) ([]*types.Session, error) {
 	// Mock active sessions list
+	if m.active != nil {
+		return m.active, nil
+	}
 	return []*types.Session{
 		{
 			ID:        "session1",
@@ -354,7 +956,110 @@ func (m *mockSessionManager) ValidateSessionMembership(sessionID, userID, role s
 	return nil
 }
 
-type mockDatabaseManager struct{}
+func (m *mockSessionManager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) (*types.Session, error) {
+	if m.timezones == nil {
+		m.timezones = make(map[string]string)
+	}
+	m.timezones[sessionID] = timezone
+	return m.GetSession(ctx, sessionID)
+}
+
+func (m *mockSessionManager) UpdateSessionMetadata(ctx context.Context, sessionID string, patch map[string]*string) (*types.Session, error) {
+	if _, ended := m.endReasons[sessionID]; ended {
+		return nil, session.ErrSessionEnded
+	}
+	metadata := types.MergeSessionMetadata(m.metadata[sessionID], patch)
+	if err := types.ValidateSessionMetadata(metadata); err != nil {
+		return nil, err
+	}
+	if m.metadata == nil {
+		m.metadata = make(map[string]map[string]string)
+	}
+	m.metadata[sessionID] = metadata
+	return m.GetSession(ctx, sessionID)
+}
+
+func (m *mockSessionManager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) (*types.Session, error) {
+	if _, ended := m.endReasons[sessionID]; ended {
+		return nil, session.ErrSessionEnded
+	}
+	if m.instructors == nil {
+		m.instructors = make(map[string][]string)
+	}
+	m.instructors[sessionID] = instructorIDs
+	return m.GetSession(ctx, sessionID)
+}
+
+func (m *mockSessionManager) IsSessionLocked(sessionID string) bool {
+	return m.locked[sessionID]
+}
+
+func (m *mockSessionManager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) (*types.Session, error) {
+	if sessionID == "missing-session" {
+		return nil, fmt.Errorf("session not found")
+	}
+	if m.locked == nil {
+		m.locked = make(map[string]bool)
+	}
+	m.locked[sessionID] = locked
+	updated, _ := m.GetSession(ctx, sessionID)
+	updated.Locked = locked
+	return updated, nil
+}
+
+func (m *mockSessionManager) TransferOwnership(ctx context.Context, sessionID, newOwner, actor string) (*types.Session, error) {
+	transferred, _ := m.GetSession(ctx, sessionID)
+	if transferred.Owner() == newOwner {
+		return nil, session.ErrAlreadyOwner
+	}
+	m.transfers = append(m.transfers, actor+"->"+newOwner)
+	transferred.OwnerID = newOwner
+	return transferred, nil
+}
+
+func (m *mockSessionManager) SetSessionDuration(ctx context.Context, sessionID string, durationMinutes int) (*types.Session, error) {
+	if sessionID == "missing-session" {
+		return nil, fmt.Errorf("session not found")
+	}
+	if durationMinutes < 0 {
+		return nil, fmt.Errorf("duration must be 0-1440 minutes")
+	}
+	session, _ := m.GetSession(ctx, sessionID)
+	session.DurationMinutes = durationMinutes
+	return session, nil
+}
+
+func (m *mockSessionManager) ListUserSessions(ctx context.Context, userID, role string) ([]*types.Session, error) {
+	sessions, _ := m.ListActiveSessions(ctx)
+	if role == "instructor" {
+		return sessions, nil
+	}
+	var result []*types.Session
+	for _, session := range sessions {
+		for _, studentID := range session.StudentIDs {
+			if studentID == userID {
+				result = append(result, session)
+			}
+		}
+	}
+	return result, nil
+}
+
+type mockDatabaseManager struct {
+	importedMessages map[string]*types.Message
+	metadata         map[string]*types.MessageMetadata
+	health           types.DatabaseHealth
+	history          []*types.Message                    // Returned by GetSessionHistory when set
+	annotations      []*types.MessageAnnotation          // Latest annotation per message and instructor
+	reactions        map[string]map[string]int           // Reaction counts by message ID
+	analytics        map[string][]*types.AnalyticsBucket // Buckets by user ID
+	counts           *types.SessionMessageCounts         // Returned by GetSessionMessageCounts when set
+	countQueries     int                                 // GetSessionMessageCounts calls
+	events           []*types.SessionEvent               // Returned by GetSessionEvents when set
+	config           *types.SessionConfig                // Returned by GetSessionConfig
+	metadataMatches  []string                            // Returned by ActiveSessionsByMetadata
+	metadataFilters  map[string]string                   // Last ActiveSessionsByMetadata filters
+}
 
 func (m *mockDatabaseManager) CreateSession(ctx context.Context, session *types.Session) error {
 	return fmt.Errorf("not implemented")
@@ -377,7 +1082,15 @@ func (m *mockDatabaseManager) StoreMessage(ctx context.Context, message *types.M
 }
 
 func (m *mockDatabaseManager) GetSessionHistory(ctx context.Context, sessionID string) ([]*types.Message, error) {
-	return nil, fmt.Errorf("not implemented")
+	if m.history == nil {
+		return nil, fmt.Errorf("not implemented")
+	}
+	messages := make([]*types.Message, len(m.history))
+	for i, message := range m.history {
+		copied := *message
+		messages[i] = &copied
+	}
+	return messages, nil
 }
 
 func (m *mockDatabaseManager) HealthCheck(ctx context.Context) error {
@@ -385,57 +1098,2493 @@ func (m *mockDatabaseManager) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-func (m *mockDatabaseManager) Close() error {
-	return fmt.Errorf("not implemented")
+func (m *mockDatabaseManager) ImportMessages(ctx context.Context, messages []*types.Message) ([]string, error) {
+	if m.importedMessages == nil {
+		m.importedMessages = make(map[string]*types.Message)
+	}
+	var skipped []string
+	for _, message := range messages {
+		if _, exists := m.importedMessages[message.ID]; exists {
+			skipped = append(skipped, message.ID)
+			continue
+		}
+		m.importedMessages[message.ID] = message
+	}
+	return skipped, nil
 }
 
-// Create a proper mock that implements the Registry interface
-type mockRegistry struct {
-	connections map[string]*websocket.Connection
-	sessionConnections map[string][]*websocket.Connection
-	stats map[string]int
+func (m *mockDatabaseManager) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error {
+	if m.metadata == nil {
+		m.metadata = make(map[string]*types.MessageMetadata)
+	}
+	m.metadata[metadata.MessageID] = metadata
+	return nil
 }
 
-func newMockRegistry() *mockRegistry {
-	return &mockRegistry{
-		connections: make(map[string]*websocket.Connection),
-		sessionConnections: make(map[string][]*websocket.Connection),
-		stats: map[string]int{
-			"total_connections": 2,
-			"active_sessions": 1,
-			"instructor_connections": 1,
-			"student_connections": 1,
-		},
+func (m *mockDatabaseManager) GetMessageMetadata(ctx context.Context, messageID string) (*types.MessageMetadata, error) {
+	metadata, exists := m.metadata[messageID]
+	if !exists {
+		return nil, interfaces.ErrNotFound
 	}
+	return metadata, nil
 }
 
-func (m *mockRegistry) RegisterConnection(conn *websocket.Connection) error {
-	return fmt.Errorf("not implemented")
+func (m *mockDatabaseManager) HealthStatus() types.DatabaseHealth {
+	return m.health
+}
+
+func (m *mockDatabaseManager) ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error) {
+	return nil, fmt.Errorf("not implemented")
 }
 
-func (m *mockRegistry) UnregisterConnection(userID string) {
-	// not implemented
+func (m *mockDatabaseManager) TransferSessionOwner(ctx context.Context, sessionID, previousOwner, newOwner, actor string) error {
+	return fmt.Errorf("not implemented")
 }
 
-func (m *mockRegistry) GetUserConnection(userID string) (*websocket.Connection, bool) {
-	return nil, false
+func (m *mockDatabaseManager) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) {
+	return m.config, nil
 }
 
-func (m *mockRegistry) GetSessionConnections(sessionID string) []*websocket.Connection {
-	if conns, exists := m.sessionConnections[sessionID]; exists {
-		return conns
+func (m *mockDatabaseManager) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) {
+	if m.events == nil {
+		return nil, fmt.Errorf("not implemented")
 	}
-	return []*websocket.Connection{}
+	return m.events, nil
+}
+
+func (m *mockDatabaseManager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) error {
+	return fmt.Errorf("not implemented")
 }
 
-func (m *mockRegistry) GetSessionInstructors(sessionID string) []*websocket.Connection {
+func (m *mockDatabaseManager) SetMessageAnnotation(ctx context.Context, sessionID string, annotation *types.MessageAnnotation) error {
+	found := false
+	for _, message := range m.history {
+		found = found || message.ID == annotation.MessageID
+	}
+	if !found {
+		return interfaces.ErrNotFound
+	}
+	kept := m.annotations[:0]
+	for _, existing := range m.annotations {
+		if existing.MessageID != annotation.MessageID || existing.InstructorID != annotation.InstructorID {
+			kept = append(kept, existing)
+		}
+	}
+	m.annotations = kept
+	if len(annotation.Tags) > 0 || annotation.Note != "" {
+		m.annotations = append(m.annotations, annotation)
+	}
 	return nil
 }
 
-func (m *mockRegistry) GetSessionStudents(sessionID string) []*websocket.Connection {
+func (m *mockDatabaseManager) GetSessionAnnotations(ctx context.Context, sessionID string) ([]*types.MessageAnnotation, error) {
+	return m.annotations, nil
+}
+
+func (m *mockDatabaseManager) SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error {
 	return nil
 }
 
-func (m *mockRegistry) GetStats() map[string]int {
-	return m.stats
-}
\ No newline at end of file
+func (m *mockDatabaseManager) GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error) {
+	return m.reactions[messageID], nil
+}
+
+func (m *mockDatabaseManager) GetSessionReactionCounts(ctx context.Context, sessionID string) (map[string]map[string]int, error) {
+	return m.reactions, nil
+}
+
+func (m *mockDatabaseManager) GetSessionHistoryAsOf(ctx context.Context, sessionID string, asOf time.Time) ([]*types.Message, error) {
+	messages, err := m.GetSessionHistory(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var sent []*types.Message
+	for _, message := range messages {
+		if !message.Timestamp.After(asOf) {
+			sent = append(sent, message)
+		}
+	}
+	return sent, nil
+}
+
+func (m *mockDatabaseManager) GetStudentThread(ctx context.Context, sessionID, studentID, afterID string, limit int) ([]*types.Message, error) {
+	messages, err := m.GetSessionHistory(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	var thread []*types.Message
+	started := afterID == ""
+	for _, message := range messages {
+		if message.ID == afterID {
+			started = true
+			continue
+		}
+		inThread := message.FromUser == studentID && (message.Type == types.MessageTypeInstructorInbox || message.Type == types.MessageTypeRequestResponse) ||
+			message.ToUser != nil && *message.ToUser == studentID && (message.Type == types.MessageTypeInboxResponse || message.Type == types.MessageTypeRequest)
+		if started && inThread && len(thread) < limit {
+			thread = append(thread, message)
+		}
+	}
+	if !started {
+		return nil, interfaces.ErrNotFound
+	}
+	return thread, nil
+}
+
+func (m *mockDatabaseManager) GetAnalyticsBuckets(ctx context.Context, sessionID, userID string) ([]*types.AnalyticsBucket, error) {
+	if m.analytics == nil {
+		return nil, fmt.Errorf("not implemented")
+	}
+	return m.analytics[userID], nil
+}
+
+func (m *mockDatabaseManager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionStudents(ctx context.Context, sessionID string, studentIDs []string) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockDatabaseManager) ActiveSessionsByMetadata(ctx context.Context, filters map[string]string) ([]string, error) {
+	m.metadataFilters = filters
+	return m.metadataMatches, nil
+}
+
+func (m *mockDatabaseManager) GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error) {
+	for _, message := range m.history {
+		if message.ID == messageID && message.SessionID == sessionID {
+			return message, nil
+		}
+	}
+	return nil, interfaces.ErrNotFound
+}
+
+func (m *mockDatabaseManager) RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (m *mockDatabaseManager) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) {
+	m.countQueries++
+	if m.counts == nil {
+		return nil, fmt.Errorf("not implemented")
+	}
+	return m.counts, nil
+}
+
+func (m *mockDatabaseManager) Close() error {
+	return fmt.Errorf("not implemented")
+}
+
+// FUNCTIONAL VALIDATION TEST: POST /api/sessions/{id}/transfer authorization rules
+func TestServer_TransferSession(t *testing.T) {
+	sessionManager := &mockSessionManager{startedAt: time.Now().Add(-2 * time.Hour)}
+	registry := newMockRegistry()
+	server := NewServer(sessionManager, &mockDatabaseManager{}, registry)
+	server.startTime = sessionManager.startedAt
+	server.SetOwnerTransferGrace(time.Hour)
+	
+	transfer := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/sessions/test-session-id/transfer", bytes.NewReader([]byte(body)))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	
+	if w := transfer(`{"new_owner": "bad id!", "requested_by": "instructor1"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid new_owner: expected 400, got %d", w.Code)
+	}
+	
+	// A co-instructor who is not connected may not take over
+	if w := transfer(`{"new_owner": "instructor2", "requested_by": "instructor2"}`); w.Code != http.StatusForbidden {
+		t.Errorf("Disconnected co-instructor: expected 403, got %d", w.Code)
+	}
+	
+	// Connected, but the owner left less than the grace period ago
+	registry.present["instructor2"] = true
+	registry.departures["instructor1"] = time.Now().Add(-time.Minute)
+	if w := transfer(`{"new_owner": "instructor2", "requested_by": "instructor2"}`); w.Code != http.StatusConflict {
+		t.Errorf("Within grace period: expected 409, got %d", w.Code)
+	}
+	
+	// After the grace period the co-instructor may take over
+	server.SetOwnerTransferGrace(30 * time.Second)
+	w := transfer(`{"new_owner": "instructor2", "requested_by": "instructor2"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("After grace period: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var response SessionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Session.OwnerID != "instructor2" || response.Session.CreatedBy != "instructor1" {
+		t.Errorf("Expected owner instructor2 with creator kept, got %+v", response.Session)
+	}
+	
+	// The owner may transfer at any time, even while present
+	server.SetOwnerTransferGrace(time.Hour)
+	if w := transfer(`{"new_owner": "instructor3", "requested_by": "instructor1"}`); w.Code != http.StatusOK {
+		t.Errorf("Owner transfer: expected 200, got %d", w.Code)
+	}
+	if w := transfer(`{"new_owner": "instructor1", "requested_by": "instructor1"}`); w.Code != http.StatusConflict {
+		t.Errorf("Transfer to current owner: expected 409, got %d", w.Code)
+	}
+	
+	if got := strings.Join(sessionManager.transfers, ","); got != "instructor2->instructor2,instructor1->instructor3" {
+		t.Errorf("Unexpected transfers: %s", got)
+	}
+}
+
+// Create a proper mock that implements the Registry interface
+type mockRegistry struct {
+	sessionConnections map[string][]interfaces.ConnectionWriter
+	stats map[string]int
+	present map[string]bool         // Instructors reported as connected
+	departures map[string]time.Time // Instructors reported as gone since
+}
+
+func newMockRegistry() *mockRegistry {
+	return &mockRegistry{
+		sessionConnections: make(map[string][]interfaces.ConnectionWriter),
+		present: make(map[string]bool),
+		departures: make(map[string]time.Time),
+		stats: map[string]int{
+			"total_connections": 2,
+			"active_sessions": 1,
+			"instructor_connections": 1,
+			"student_connections": 1,
+		},
+	}
+}
+
+func (m *mockRegistry) GetSessionWriters(sessionID string) []interfaces.ConnectionWriter {
+	return m.sessionConnections[sessionID]
+}
+
+func (m *mockRegistry) GetSessionConnectionCounts() map[string]int {
+	counts := make(map[string]int, len(m.sessionConnections))
+	for sessionID, connections := range m.sessionConnections {
+		if len(connections) > 0 {
+			counts[sessionID] = len(connections)
+		}
+	}
+	return counts
+}
+
+func (m *mockRegistry) InstructorPresence(sessionID, userID string) (bool, time.Time) {
+	leftAt, absent := m.departures[userID]
+	if absent {
+		return false, leftAt
+	}
+	return m.present[userID], time.Time{}
+}
+
+func (m *mockRegistry) GetStats() map[string]int {
+	return m.stats
+}
+
+// mockConnection records the messages written to it
+type mockConnection struct {
+	userID string
+	role string
+	messages []map[string]interface{}
+}
+
+func (c *mockConnection) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var message map[string]interface{}
+	json.Unmarshal(data, &message)
+	c.messages = append(c.messages, message)
+	return nil
+}
+
+func (c *mockConnection) Close() error      { return nil }
+func (c *mockConnection) GetUserID() string { return c.userID }
+func (c *mockConnection) GetRole() string   { return c.role }
+// FUNCTIONAL VALIDATION TEST: Bulk transcript import with per-record errors and idempotency
+func TestServer_ImportMessages(t *testing.T) {
+	sessionManager := &mockSessionManager{}
+	dbManager := &mockDatabaseManager{}
+	registry := newMockRegistry()
+	
+	server := NewServer(sessionManager, dbManager, registry)
+	
+	body := `[
+		{"id": "m2", "type": "instructor_inbox", "from_user": "student1", "content": {"text": "second"}, "timestamp": "2024-01-01T10:01:00Z"},
+		{"id": "m1", "type": "instructor_inbox", "from_user": "student1", "content": {"text": "first"}, "timestamp": "2024-01-01T10:00:00Z"},
+		{"type": "inbox_response", "from_user": "instructor1", "to_user": "student1", "content": {"text": "reply"}, "timestamp": "2024-01-01T10:02:00Z"},
+		{"id": "bad1", "type": "not_a_type", "from_user": "student1", "content": {}, "timestamp": "2024-01-01T10:03:00Z"},
+		{"id": "bad2", "type": "analytics", "from_user": "student1", "content": {}}
+	]`
+	
+	req := httptest.NewRequest("POST", "/api/sessions/test-session-id/messages/import", bytes.NewReader([]byte(body)))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	
+	var response ImportMessagesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Imported != 3 {
+		t.Errorf("Expected 3 imported messages, got %d", response.Imported)
+	}
+	if len(response.Errors) != 2 || response.Errors[0].Index != 3 || response.Errors[1].Index != 4 {
+		t.Errorf("Expected errors for records 3 and 4, got %+v", response.Errors)
+	}
+	
+	// Server-assigned ID for the record without one, session ID filled from path
+	for id, message := range dbManager.importedMessages {
+		if id == "" {
+			t.Error("Imported message should have an ID assigned")
+		}
+		if message.SessionID != "test-session-id" {
+			t.Errorf("Expected session ID from path, got %s", message.SessionID)
+		}
+	}
+	
+	// Re-importing the same IDs as NDJSON must skip them
+	ndjson := `{"id": "m1", "type": "instructor_inbox", "from_user": "student1", "content": {}, "timestamp": "2024-01-01T10:00:00Z"}
+{"id": "m3", "type": "analytics", "from_user": "student1", "content": {}, "timestamp": "2024-01-01T10:05:00Z"}`
+	req = httptest.NewRequest("POST", "/api/sessions/test-session-id/messages/import", bytes.NewReader([]byte(ndjson)))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	response = ImportMessagesResponse{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Imported != 1 || len(response.Skipped) != 1 || response.Skipped[0] != "m1" {
+		t.Errorf("Expected 1 imported and m1 skipped, got %+v", response)
+	}
+}
+
+// TECHNICAL VALIDATION TEST: Import rejects mismatched session IDs and malformed bodies
+func TestServer_ImportMessagesValidation(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	req := httptest.NewRequest("POST", "/api/sessions/test-session-id/messages/import", bytes.NewReader([]byte(`[{"id": "x", "session_id": "other", "type": "analytics", "from_user": "s1", "content": {}, "timestamp": "2024-01-01T10:00:00Z"}]`)))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	var response ImportMessagesResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Imported != 0 || len(response.Errors) != 1 {
+		t.Errorf("Expected mismatched session record to be rejected, got %+v", response)
+	}
+	
+	req = httptest.NewRequest("POST", "/api/sessions/test-session-id/messages/import", bytes.NewReader([]byte(`[{"id": "r", "type": "reaction", "from_user": "s1", "content": {"target_message_id": "x", "reaction": "thumbs_up"}, "timestamp": "2024-01-01T10:00:00Z"}]`)))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	response = ImportMessagesResponse{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if response.Imported != 0 || len(response.Errors) != 1 {
+		t.Errorf("Expected a reaction record to be rejected, got %+v", response)
+	}
+	
+	req = httptest.NewRequest("POST", "/api/sessions/test-session-id/messages/import", bytes.NewReader([]byte(`[{broken`)))
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for malformed body, got %d", http.StatusBadRequest, w.Code)
+	}
+	
+	req = httptest.NewRequest("GET", "/api/sessions/test-session-id/messages/import", nil)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for GET, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: GET /api/me/sessions returns joinable sessions
+func TestServer_MySessions(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	tests := []struct {
+		query        string
+		expectedCode int
+		expectedLen  int
+	}{
+		{"user_id=student1&role=student", http.StatusOK, 1},
+		{"user_id=stranger&role=student", http.StatusOK, 0},
+		{"user_id=instructor9&role=instructor", http.StatusOK, 1},
+		{"user_id=student1&role=admin", http.StatusBadRequest, 0},
+		{"role=student", http.StatusBadRequest, 0},
+	}
+	
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/me/sessions?"+tt.query, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		
+		if w.Code != tt.expectedCode {
+			t.Errorf("%s: expected status %d, got %d", tt.query, tt.expectedCode, w.Code)
+			continue
+		}
+		if tt.expectedCode != http.StatusOK {
+			continue
+		}
+		
+		var response UserSessionsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Sessions) != tt.expectedLen {
+			t.Errorf("%s: expected %d sessions, got %d", tt.query, tt.expectedLen, len(response.Sessions))
+		}
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Message metadata only reachable via the opt-in admin endpoint
+func TestServer_MessageMetadata(t *testing.T) {
+	dbManager := &mockDatabaseManager{}
+	server := NewServer(&mockSessionManager{}, dbManager, newMockRegistry())
+	
+	_ = dbManager.StoreMessageMetadata(context.Background(), &types.MessageMetadata{
+		MessageID:    "msg-1",
+		ConnectionID: "conn-1",
+		ClientIP:     "203.0.113.7",
+		UserAgent:    "test-agent",
+		RecordedAt:   time.Now(),
+	})
+	
+	off := httptest.NewRecorder()
+	server.ServeHTTP(off, httptest.NewRequest("GET", "/api/admin/messages/msg-1/metadata", nil))
+	if off.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 until the endpoint is enabled, got %d", off.Code)
+	}
+	server.SetMessageMetadata(func(ip string) string { return "masked:" + ip })
+	
+	tests := []struct {
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{"GET", "/api/admin/messages/msg-1/metadata", http.StatusOK},
+		{"GET", "/api/admin/messages/missing/metadata", http.StatusNotFound},
+		{"GET", "/api/admin/messages/msg-1", http.StatusNotFound},
+		{"POST", "/api/admin/messages/msg-1/metadata", http.StatusMethodNotAllowed},
+	}
+	
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		
+		if w.Code != tt.expectedCode {
+			t.Errorf("%s %s: expected status %d, got %d", tt.method, tt.path, tt.expectedCode, w.Code)
+		}
+	}
+	
+	req := httptest.NewRequest("GET", "/api/admin/messages/msg-1/metadata", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	var metadata types.MessageMetadata
+	if err := json.Unmarshal(w.Body.Bytes(), &metadata); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if metadata.ClientIP != "masked:203.0.113.7" || metadata.UserAgent != "test-agent" || metadata.ConnectionID != "conn-1" {
+		t.Errorf("Unexpected metadata: %+v", metadata)
+	}
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "" {
+		t.Errorf("Metadata must not be readable cross-origin, got Access-Control-Allow-Origin %q", origin)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Duration limits on create and PATCH
+func TestServer_SessionDuration(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	body, _ := json.Marshal(CreateSessionRequest{
+		Name:            "Timed Class",
+		InstructorID:    "instructor1",
+		StudentIDs:      []string{"student1"},
+		DurationMinutes: 50,
+	})
+	req := httptest.NewRequest("POST", "/api/sessions", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var created CreateSessionResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if created.Session.DurationMinutes != 50 {
+		t.Errorf("Expected duration 50, got %d", created.Session.DurationMinutes)
+	}
+	
+	tests := []struct {
+		method       string
+		path         string
+		body         string
+		expectedCode int
+	}{
+		{"POST", "/api/sessions", `{"name":"x","instructor_id":"instructor1","student_ids":["student1"],"duration_minutes":5000}`, http.StatusBadRequest},
+		{"PATCH", "/api/sessions/test-session-id", `{"duration_minutes":60}`, http.StatusOK},
+		{"PATCH", "/api/sessions/test-session-id", `{}`, http.StatusBadRequest},
+		{"PATCH", "/api/sessions/test-session-id", `{"duration_minutes":-5}`, http.StatusBadRequest},
+		{"PATCH", "/api/sessions/missing-session", `{"duration_minutes":60}`, http.StatusNotFound},
+	}
+	
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		
+		if w.Code != tt.expectedCode {
+			t.Errorf("%s %s %s: expected status %d, got %d", tt.method, tt.path, tt.body, tt.expectedCode, w.Code)
+		}
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: PATCH locks and unlocks student sending
+func TestServer_SessionLock(t *testing.T) {
+	sessionManager := &mockSessionManager{}
+	registry := newMockRegistry()
+	student := &mockConnection{userID: "student1", role: "student"}
+	registry.sessionConnections["test-session-id"] = []interfaces.ConnectionWriter{student}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, registry)
+	
+	patch := func(path, body string) (*httptest.ResponseRecorder, SessionResponse) {
+		req := httptest.NewRequest("PATCH", path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		var response SessionResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	
+	w, response := patch("/api/sessions/test-session-id", `{"locked":true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if response.Session == nil || !response.Session.Locked || !sessionManager.IsSessionLocked("test-session-id") {
+		t.Errorf("Expected the session to be locked, got %+v", response.Session)
+	}
+	
+	// Duration and lock can change in one request
+	w, response = patch("/api/sessions/test-session-id", `{"duration_minutes":45,"locked":false}`)
+	if w.Code != http.StatusOK || response.Session.Locked || sessionManager.IsSessionLocked("test-session-id") {
+		t.Errorf("Expected the session to be unlocked, got %d %+v", w.Code, response.Session)
+	}
+	
+	// One notice per change, none for repeating the current state
+	patch("/api/sessions/test-session-id", `{"locked":false}`)
+	if len(student.messages) != 2 {
+		t.Fatalf("Expected 2 lock notices, got %d", len(student.messages))
+	}
+	if content, _ := student.messages[0]["content"].(map[string]interface{}); content["event"] != "session_locked" {
+		t.Errorf("Expected a session_locked notice first, got %v", student.messages[0])
+	}
+	
+	if w, _ := patch("/api/sessions/missing-session", `{"locked":true}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for a missing session, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Expiry runs the same end flow as DELETE
+func TestServer_SessionExpiredUsesEndFlow(t *testing.T) {
+	sessionManager := &mockSessionManager{}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	
+	server.SessionWarning("timed-session", 2*time.Minute) // No connections - must not panic
+	server.SessionExpired("timed-session")
+	
+	if reason := sessionManager.endReasons["timed-session"]; reason != "Session time limit reached" {
+		t.Errorf("Expected timed-session ended for its time limit, got %v", sessionManager.endReasons)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Degraded persistence fails readiness
+func TestServer_HealthCheckDegraded(t *testing.T) {
+	dbManager := &mockDatabaseManager{health: types.DatabaseHealth{
+		Degraded:      true,
+		Reason:        "low disk space: 1024 bytes free",
+		RouteOnly:     true,
+		DegradedCount: 1,
+		SkippedWrites: 3,
+	}}
+	server := NewServer(&mockSessionManager{}, dbManager, newMockRegistry())
+	
+	req := httptest.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	
+	var response HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Status != "degraded" {
+		t.Errorf("Expected status 'degraded', got %q", response.Status)
+	}
+	if !response.Persistence.RouteOnly || response.Persistence.SkippedWrites != 3 {
+		t.Errorf("Expected persistence details in response, got %+v", response.Persistence)
+	}
+	
+	// Watchdog notifications must tolerate sessions without connections
+	server.DatabaseHealthChanged(dbManager.health)
+}
+
+// FUNCTIONAL VALIDATION TEST: Admission pacing is reported without failing readiness
+func TestServer_HealthCheckAdmission(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	server.SetAdmissionStats(func() types.AdmissionStats {
+		return types.AdmissionStats{Active: true, Rate: 20, Admitted: 40, Rejected: 7}
+	})
+	
+	req := httptest.NewRequest("GET", "/health", nil)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d while pacing, got %d", http.StatusOK, w.Code)
+	}
+	var response HealthResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if response.Admission == nil || !response.Admission.Active || response.Admission.Rejected != 7 {
+		t.Errorf("Expected admission details in response, got %+v", response.Admission)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: As-of history applies the viewer's visibility and the cutoff
+func TestServer_HistoryAsOf(t *testing.T) {
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	student1, student2 := "student1", "student2"
+	dbManager := &mockDatabaseManager{history: []*types.Message{
+		{ID: "broadcast", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1", Timestamp: start.Add(10 * time.Minute)},
+		{ID: "question", Type: types.MessageTypeInstructorInbox, FromUser: student1, Timestamp: start.Add(20 * time.Minute)},
+		{ID: "reply-other", Type: types.MessageTypeInboxResponse, FromUser: "instructor1", ToUser: &student2, Timestamp: start.Add(25 * time.Minute)},
+		{ID: "reply", Type: types.MessageTypeInboxResponse, FromUser: "instructor1", ToUser: &student1, Timestamp: start.Add(32 * time.Minute)},
+		{ID: "later", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1", Timestamp: start.Add(40 * time.Minute)},
+	}}
+	server := NewServer(&mockSessionManager{startedAt: start}, dbManager, newMockRegistry())
+	
+	fetch := func(query string) (*httptest.ResponseRecorder, HistoryAsOfResponse) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/test-session-id/history?"+query, nil))
+		var response HistoryAsOfResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	ids := func(messages []*types.Message) string {
+		var names []string
+		for _, message := range messages {
+			names = append(names, message.ID)
+		}
+		return strings.Join(names, ",")
+	}
+	
+	w, response := fetch("viewer=student1&as_of=2026-03-02T10:32:00Z")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if got := ids(response.Messages); got != "broadcast,question,reply" || response.ViewerRole != "student" {
+		t.Errorf("Expected student view up to 10:32, got %s as %s", got, response.ViewerRole)
+	}
+	
+	// The same instant in another zone
+	_, response = fetch("viewer=instructor1&as_of=2026-03-02T05:31:59-05:00")
+	if got := ids(response.Messages); got != "broadcast,question,reply-other" || response.ViewerRole != "instructor" {
+		t.Errorf("Expected instructor view up to 10:31:59, got %s as %s", got, response.ViewerRole)
+	}
+	if response.Config != nil || response.Deliveries != nil {
+		t.Errorf("Expected no explanations without a config snapshot, got %+v", response.Deliveries)
+	}
+	
+	// With a snapshot each message says why the viewer had it
+	dbManager.config = &types.SessionConfig{Version: types.SessionConfigVersion, Routing: []types.RouteSummary{
+		{Type: types.MessageTypeInstructorInbox, SenderRole: "student", Recipients: "session_instructors"},
+		{Type: types.MessageTypeInboxResponse, SenderRole: "instructor", Recipients: "to_user", RequiresToUser: true},
+		{Type: types.MessageTypeInstructorBroadcast, SenderRole: "instructor", Recipients: "session_students"},
+	}}
+	reasons := func(deliveries []types.DeliveryExplanation) string {
+		var names []string
+		for _, delivery := range deliveries {
+			names = append(names, delivery.MessageID+":"+delivery.Reason)
+		}
+		return strings.Join(names, ",")
+	}
+	_, response = fetch("viewer=student1&as_of=2026-03-02T10:32:00Z")
+	if got := reasons(response.Deliveries); got != "broadcast:route_recipient,question:sender,reply:addressed_to_viewer" {
+		t.Errorf("Unexpected student explanations: %s", got)
+	}
+	_, response = fetch("viewer=instructor2&as_of=2026-03-02T10:31:59Z")
+	if got := reasons(response.Deliveries); got != "broadcast:history_replay,question:route_recipient,reply-other:history_replay" {
+		t.Errorf("Unexpected instructor explanations: %s", got)
+	}
+	if response.Config == nil || len(response.Config.Routing) != 3 || response.Deliveries[1].Recipients != "session_instructors" {
+		t.Errorf("Expected the snapshot and its recipients in the response, got %+v", response)
+	}
+	
+	// Paging back from a message, as a truncated replay's history_url does
+	_, response = fetch("viewer=instructor1&as_of=2026-03-02T11:00:00Z&before=later&limit=2")
+	if got := ids(response.Messages); got != "reply-other,reply" || !response.HasMore || reasons(response.Deliveries) != "reply-other:sender,reply:sender" {
+		t.Errorf("Expected the newest two before later with more to come, got %s %+v", got, response)
+	}
+	_, response = fetch("viewer=instructor1&as_of=2026-03-02T11:00:00Z&before=reply-other&limit=2")
+	if got := ids(response.Messages); got != "broadcast,question" || response.HasMore {
+		t.Errorf("Expected the last page, got %s (has_more %v)", got, response.HasMore)
+	}
+	
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"viewer=student1&as_of=2026-03-02T09:59:59Z", http.StatusUnprocessableEntity},
+		{"viewer=student1&as_of=10:32", http.StatusBadRequest},
+		{"viewer=student1", http.StatusBadRequest},
+		{"as_of=2026-03-02T10:32:00Z", http.StatusBadRequest},
+		{"viewer=student1&as_of=2026-03-02T10:32:00Z&limit=0", http.StatusBadRequest},
+		{"viewer=student1&as_of=2026-03-02T10:32:00Z&limit=1001", http.StatusBadRequest},
+		{"viewer=student1&as_of=2026-03-02T11:00:00Z&before=reply-other", http.StatusNotFound}, // Not visible to student1
+	}
+	for _, tt := range tests {
+		if w, _ := fetch(tt.query); w.Code != tt.want {
+			t.Errorf("%s: expected status %d, got %d", tt.query, tt.want, w.Code)
+		}
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Students see only their own analytics summary; instructors see anyone's
+func TestServer_MyAnalytics(t *testing.T) {
+	minute := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	dbManager := &mockDatabaseManager{analytics: map[string][]*types.AnalyticsBucket{
+		"student1": {
+			{Context: "engagement", Minute: minute, Count: 2},
+			{Context: "progress", Minute: minute, Count: 1},
+			{Context: "engagement", Minute: minute.Add(time.Minute), Count: 3},
+		},
+	}}
+	server := NewServer(&mockSessionManager{}, dbManager, newMockRegistry())
+	
+	fetch := func(query string) (*httptest.ResponseRecorder, types.AnalyticsSummary) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/test-session-id/analytics/me?"+query, nil))
+		var summary types.AnalyticsSummary
+		json.Unmarshal(w.Body.Bytes(), &summary)
+		return w, summary
+	}
+	
+	w, summary := fetch("user_id=student1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	engagement := summary.Contexts["engagement"]
+	if summary.Total != 6 || engagement == nil || engagement.Total != 5 || len(engagement.Points) != 2 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	
+	if w, summary := fetch("user_id=student1&requested_by=instructor1"); w.Code != http.StatusOK || summary.Total != 6 {
+		t.Errorf("Expected instructors to see a student's analytics, got %d", w.Code)
+	}
+	if w, _ := fetch("user_id=student1&requested_by=student2"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a classmate, got %d", http.StatusForbidden, w.Code)
+	}
+	if w, summary := fetch("user_id=student2"); w.Code != http.StatusOK || summary.Total != 0 || summary.Contexts == nil {
+		t.Errorf("Expected an empty summary for a student without analytics, got %d %+v", w.Code, summary)
+	}
+	if w, _ := fetch(""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without user_id, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: GET /api/sessions/{id}/stats counts, participation, latency and caching
+func TestServer_SessionStats(t *testing.T) {
+	minute := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	dbManager := &mockDatabaseManager{
+		counts: &types.SessionMessageCounts{
+			Total:    6,
+			ByType:   map[string]int{types.MessageTypeInstructorInbox: 4, types.MessageTypeInboxResponse: 2},
+			ByMinute: []types.MinuteCount{{Minute: minute, Count: 4}, {Minute: minute.Add(time.Minute), Count: 2}},
+			BySender: map[string]int{"student1": 4, "instructor1": 2},
+		},
+		events: []*types.SessionEvent{
+			{Type: types.SessionEventOwnerTransferred},
+			{Type: types.SessionEventRoutingLatency, Details: map[string]interface{}{"samples": float64(6), "p50_ms": 0.5, "p95_ms": 2.0}},
+			{Type: types.SessionEventDeliveryReliability, Details: map[string]interface{}{
+				"delivered": float64(99), "failed": float64(1), "failure_rate": 0.01, "reliability": 0.99,
+				"by_reason": map[string]interface{}{"write_timeout": float64(1), "filtered": float64(3)},
+			}},
+			{Type: types.SessionEventBandwidth, Details: map[string]interface{}{
+				"bytes_sent": float64(9000), "bytes_received": float64(400), "frames_sent": float64(30), "frames_received": float64(4),
+				"by_type": map[string]interface{}{"instructor_inbox": map[string]interface{}{"bytes_received": float64(400), "frames_received": float64(4)}},
+			}},
+		},
+	}
+	sessionManager := &mockSessionManager{startedAt: time.Now().Add(-3 * time.Minute)}
+	server := NewServer(sessionManager, dbManager, newMockRegistry())
+	server.SetRoutingLatency(func(sessionID string) types.LatencyStats {
+		return types.LatencyStats{Samples: 6, P50Ms: 0.25, P95Ms: 1}
+	})
+	server.SetDeliveryStats(func(sessionID string) types.DeliveryStats {
+		return types.DeliveryStats{Delivered: 8, Failed: 2, FailureRate: 0.2, Reliability: 0.8}
+	})
+	server.SetBandwidth(func(sessionID string) types.BandwidthStats {
+		return types.BandwidthStats{ByteCounts: types.ByteCounts{BytesSent: 1200, FramesSent: 6}}
+	})
+	
+	fetch := func(sessionID, query string) (*httptest.ResponseRecorder, types.SessionStats) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/"+sessionID+"/stats?"+query, nil))
+		var stats types.SessionStats
+		json.Unmarshal(w.Body.Bytes(), &stats)
+		return w, stats
+	}
+	
+	w, stats := fetch("test-session-id", "instructor_id=instructor1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if stats.TotalMessages != 6 || stats.MessagesByType[types.MessageTypeInstructorInbox] != 4 || len(stats.MessagesPerMinute) != 2 {
+		t.Errorf("Unexpected counts: %+v", stats)
+	}
+	if len(stats.Participation) != 2 || stats.Participation["student1"] != 4 || stats.Participation["student2"] != 0 {
+		t.Errorf("Expected every rostered student in participation, got %v", stats.Participation)
+	}
+	if stats.AverageMessagesPerMinute < 1.9 || stats.AverageMessagesPerMinute > 2.1 {
+		t.Errorf("Expected about 2 messages per minute over 3 minutes, got %v", stats.AverageMessagesPerMinute)
+	}
+	if stats.RoutingLatency.P50Ms != 0.25 || stats.RoutingLatency.P95Ms != 1 {
+		t.Errorf("Expected live routing latency for an active session, got %+v", stats.RoutingLatency)
+	}
+	if stats.Delivery.Failed != 2 || stats.Delivery.FailureRate != 0.2 {
+		t.Errorf("Expected live delivery stats for an active session, got %+v", stats.Delivery)
+	}
+	if stats.Bandwidth.BytesSent != 1200 || stats.Bandwidth.FramesSent != 6 {
+		t.Errorf("Expected live bandwidth for an active session, got %+v", stats.Bandwidth)
+	}
+	
+	// Polls within the cache window reuse the aggregation
+	if w, _ := fetch("test-session-id", "instructor_id=instructor1"); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if dbManager.countQueries != 1 {
+		t.Errorf("Expected cached counts to be reused, got %d queries", dbManager.countQueries)
+	}
+	
+	// Ended sessions report the latency recorded at session end
+	sessionManager.endReasons = map[string]string{"ended-session-id": "manual"}
+	w, stats = fetch("ended-session-id", "instructor_id=instructor1")
+	if w.Code != http.StatusOK || stats.Status != "ended" {
+		t.Fatalf("Expected stats for an ended session, got %d: %s", w.Code, w.Body.String())
+	}
+	if stats.RoutingLatency.Samples != 6 || stats.RoutingLatency.P95Ms != 2 {
+		t.Errorf("Expected recorded routing latency, got %+v", stats.RoutingLatency)
+	}
+	if stats.Delivery.Delivered != 99 || stats.Delivery.Reliability != 0.99 || stats.Delivery.ByReason["filtered"] != 3 {
+		t.Errorf("Expected recorded delivery reliability, got %+v", stats.Delivery)
+	}
+	if inbox := stats.Bandwidth.ByType["instructor_inbox"]; stats.Bandwidth.BytesSent != 9000 || stats.Bandwidth.FramesReceived != 4 || inbox.BytesReceived != 400 {
+		t.Errorf("Expected recorded bandwidth, got %+v", stats.Bandwidth)
+	}
+	
+	if w, _ := fetch("test-session-id", "instructor_id=student1"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a student, got %d", http.StatusForbidden, w.Code)
+	}
+	if w, _ := fetch("test-session-id", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without instructor_id, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("POST", "/api/sessions/test-session-id/stats?instructor_id=instructor1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for POST, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// TestServer_ThroughputHistory tests functional validation - the history window and
+// session filter are passed through, and bad windows are refused
+func TestServer_ThroughputHistory(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/history", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before history is set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	var gotHours int
+	var gotSession string
+	server.SetThroughputHistory(func(hours int, sessionID string) types.ThroughputHistory {
+		gotHours, gotSession = hours, sessionID
+		return types.ThroughputHistory{Hours: hours, BucketSeconds: 60, SessionID: sessionID, Buckets: []types.ThroughputBucket{{Messages: 12, Connections: 30}}}
+	})
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/history", nil))
+	if w.Code != http.StatusOK || gotHours != 24 || gotSession != "" {
+		t.Fatalf("Expected the full day by default, got status %d, hours %d, session %q", w.Code, gotHours, gotSession)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/history?hours=3&session_id=session-1", nil))
+	var history types.ThroughputHistory
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatalf("Failed to decode history: %v", err)
+	}
+	if gotHours != 3 || gotSession != "session-1" || history.SessionID != "session-1" || history.Buckets[0].Messages != 12 {
+		t.Errorf("Expected 3 hours of session-1, got hours %d, session %q, %+v", gotHours, gotSession, history)
+	}
+
+	for _, hours := range []string{"0", "25", "day"} {
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/history?hours="+hours, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for hours=%s, got %d", http.StatusBadRequest, hours, w.Code)
+		}
+	}
+}
+
+// TestServer_MessageSizes tests functional validation - the window is passed through as
+// a start time, and bad windows are refused
+func TestServer_MessageSizes(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/message-sizes", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before sizes are set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	var gotSince time.Time
+	server.SetMessageSizeStats(func(ctx context.Context, since time.Time) ([]types.MessageSizeStats, error) {
+		gotSince = since
+		return []types.MessageSizeStats{{Type: "request_response", Count: 30, TotalBytes: 90000, P50Bytes: 2500, P95Bytes: 9000, MaxBytes: 12000}}, nil
+	})
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/message-sizes", nil))
+	if w.Code != http.StatusOK || time.Since(gotSince) < 24*time.Hour || time.Since(gotSince) > 25*time.Hour {
+		t.Fatalf("Expected the last day by default, got status %d since %v", w.Code, gotSince)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/message-sizes?hours=720", nil))
+	var report types.MessageSizeReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatalf("Failed to decode message sizes: %v", err)
+	}
+	if report.Hours != 720 || !report.Since.Equal(gotSince) || len(report.Types) != 1 || report.Types[0].P95Bytes != 9000 {
+		t.Errorf("Expected 30 days of sizes, got %+v", report)
+	}
+
+	for _, hours := range []string{"0", "721", "week"} {
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/stats/message-sizes?hours="+hours, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for hours=%s, got %d", http.StatusBadRequest, hours, w.Code)
+		}
+	}
+}
+
+// fakeDrainer records drain requests without touching connections
+type fakeDrainer struct {
+	status types.DrainStatus
+	window time.Duration
+}
+
+func (d *fakeDrainer) Drain(window time.Duration) types.DrainStatus {
+	d.window = window
+	d.status = types.DrainStatus{Draining: true, Notified: 2}
+	return d.status
+}
+
+func (d *fakeDrainer) Undrain() (types.DrainStatus, bool) {
+	if !d.status.Draining {
+		return d.status, false
+	}
+	d.status = types.DrainStatus{}
+	return d.status, true
+}
+
+func (d *fakeDrainer) Status() types.DrainStatus {
+	return d.status
+}
+
+// TestServer_Drain tests functional validation - draining fails readiness until
+// undrained, and the window is passed through
+func TestServer_Drain(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("POST", path, strings.NewReader(body)))
+		return w
+	}
+
+	if w := post("/api/admin/drain", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before a drainer is set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	drainer := &fakeDrainer{}
+	server.SetDrainer(drainer)
+	if w := post("/api/admin/undrain", ""); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d undraining a node that is not draining, got %d", http.StatusConflict, w.Code)
+	}
+	for _, body := range []string{`{"window": "soon"}`, `{"window": "11m"}`, `{"window": "-1s"}`, `{`} {
+		if w := post("/api/admin/drain", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+
+	w := post("/api/admin/drain", `{"window": "45s"}`)
+	var status types.DrainStatus
+	json.Unmarshal(w.Body.Bytes(), &status)
+	if w.Code != http.StatusOK || !status.Draining || status.Notified != 2 || drainer.window != 45*time.Second {
+		t.Fatalf("Expected a 45s drain, got %d %+v (window %v)", w.Code, status, drainer.window)
+	}
+
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	var response HealthResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusServiceUnavailable || response.Status != "draining" || response.Drain == nil || !response.Drain.Draining {
+		t.Errorf("Expected 503 draining, got %d %s %+v", w.Code, response.Status, response.Drain)
+	}
+
+	if w := post("/api/admin/drain", ""); w.Code != http.StatusOK || drainer.window != 0 {
+		t.Errorf("Expected an empty body to use the default window, got %d (window %v)", w.Code, drainer.window)
+	}
+	if w := post("/api/admin/undrain", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d undraining, got %d", http.StatusOK, w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/health", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected readiness to pass after undrain, got %d", w.Code)
+	}
+}
+
+// fakeMaintenance starts jobs without touching a database
+type fakeMaintenance struct {
+	jobs map[string]*types.MaintenanceJob
+	err  error
+}
+
+func (m *fakeMaintenance) StartMaintenance(tasks []string) (*types.MaintenanceJob, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	job := &types.MaintenanceJob{ID: "job-1", Status: types.MaintenanceRunning}
+	for _, task := range tasks {
+		job.Tasks = append(job.Tasks, types.MaintenanceTask{Task: task, Status: types.MaintenancePending})
+	}
+	m.jobs[job.ID] = job
+	return job, nil
+}
+
+func (m *fakeMaintenance) MaintenanceJob(ctx context.Context, jobID string) (*types.MaintenanceJob, error) {
+	if job, exists := m.jobs[jobID]; exists {
+		return job, nil
+	}
+	return nil, interfaces.ErrNotFound
+}
+
+// TestServer_Maintenance tests functional validation - jobs start with 202 and are
+// polled by ID; refusals map to 400 and 409
+func TestServer_Maintenance(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	request := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	if w := request("POST", "/api/admin/maintenance", `{"tasks": ["vacuum"]}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before maintenance is set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	maintenance := &fakeMaintenance{jobs: map[string]*types.MaintenanceJob{}}
+	server.SetMaintenance(maintenance)
+	w := request("POST", "/api/admin/maintenance", `{"tasks": ["vacuum", "integrity_check"]}`)
+	var job types.MaintenanceJob
+	json.Unmarshal(w.Body.Bytes(), &job)
+	if w.Code != http.StatusAccepted || job.ID != "job-1" || len(job.Tasks) != 2 || w.Header().Get("Location") != "/api/admin/maintenance/job-1" {
+		t.Fatalf("Expected 202 with the job, got %d %+v", w.Code, job)
+	}
+
+	if w := request("GET", "/api/admin/maintenance/job-1", ""); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"integrity_check"`) {
+		t.Errorf("Expected the job by ID, got %d %s", w.Code, w.Body.String())
+	}
+	if w := request("GET", "/api/admin/maintenance/job-2", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown job, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := request("POST", "/api/admin/maintenance", `{"tasks": []}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without tasks, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	for err, code := range map[error]int{
+		interfaces.ErrUnknownMaintenanceTask: http.StatusBadRequest,
+		interfaces.ErrMaintenanceRunning:     http.StatusConflict,
+		interfaces.ErrBackupInProgress:       http.StatusConflict,
+	} {
+		maintenance.err = err
+		if w := request("POST", "/api/admin/maintenance", `{"tasks": ["analyze"]}`); w.Code != code {
+			t.Errorf("Expected status %d for %v, got %d", code, err, w.Code)
+		}
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Instructors set their inbox preferences over HTTP and
+// see the setter's warnings; students and bad filters are refused
+func TestServer_InboxPreferences(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	patch := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("PATCH", "/api/sessions/test-session-id/preferences", strings.NewReader(body)))
+		return w
+	}
+	body := `{"instructor_id":"ta","filters":{"instructor_inbox":["technical_issue"]}}`
+
+	if w := patch(body); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before preferences are set, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	var gotInstructor string
+	server.SetInboxPreferences(func(ctx context.Context, sessionID, instructorID string, filters map[string][]string) (*types.InboxPreferencesUpdate, error) {
+		if err := types.ValidateInboxFilters(filters); err != nil {
+			return nil, err
+		}
+		gotInstructor = instructorID
+		return &types.InboxPreferencesUpdate{
+			Preferences: &types.InboxPreferences{SessionID: sessionID, InstructorID: instructorID, Filters: filters},
+			Warnings:    []string{"No instructor receives instructor_inbox messages outside technical_issue"},
+		}, nil
+	})
+	w := patch(body)
+	var update types.InboxPreferencesUpdate
+	json.Unmarshal(w.Body.Bytes(), &update)
+	if w.Code != http.StatusOK || gotInstructor != "ta" || len(update.Warnings) != 1 || len(update.Preferences.Filters["instructor_inbox"]) != 1 {
+		t.Fatalf("Expected the TA's preferences with a warning, got %d %s", w.Code, w.Body.String())
+	}
+
+	if w := patch(`{"instructor_id":"student1","filters":{}}`); w.Code != http.StatusForbidden {
+		t.Errorf("Students setting preferences: expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := patch(`{"instructor_id":"ta","filters":{"instructor_broadcast":["general"]}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Unfilterable type: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/test-session-id/preferences", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET preferences: expected %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// TestStatsCache_Expiry tests technical validation - entries expire after the TTL and are swept on store
+func TestStatsCache_Expiry(t *testing.T) {
+	cache := newStatsCache()
+	start := time.Now()
+	cache.put("session1", cachedCounts{counts: &types.SessionMessageCounts{Total: 1}, at: start})
+	
+	if _, hit := cache.get("session1", start.Add(statsCacheTTL-time.Millisecond)); !hit {
+		t.Error("Expected a hit within the TTL")
+	}
+	if _, hit := cache.get("session1", start.Add(statsCacheTTL)); hit {
+		t.Error("Expected a miss once the TTL has passed")
+	}
+	
+	cache.put("session2", cachedCounts{counts: &types.SessionMessageCounts{}, at: start.Add(statsCacheTTL)})
+	if len(cache.entries) != 1 {
+		t.Errorf("Expected the expired entry to be swept, got %d entries", len(cache.entries))
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: POST /api/admin/broadcast fans out to active sessions and reports each
+func TestServer_AdminBroadcast(t *testing.T) {
+	sessionManager := &mockSessionManager{active: []*types.Session{
+		{ID: "s1", CreatedBy: "instructor1", Status: "active"},
+		{ID: "s2", CreatedBy: "instructor2", Status: "active"},
+		{ID: "s3", CreatedBy: "instructor1", OwnerID: "instructor3", Status: "active"},
+	}}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	server.announcePacing = 0
+	
+	post := func(body string) (*httptest.ResponseRecorder, AdminBroadcastResponse) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("POST", "/api/admin/broadcast", strings.NewReader(body)))
+		var response AdminBroadcastResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	
+	if w, _ := post(`{"content": {"text": "Campus closing at noon"}}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without an announcer, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	
+	var mu sync.Mutex
+	announced := make(map[string][]string) // sessionID -> contexts
+	server.SetAnnouncer(func(ctx context.Context, sessionID, messageContext string, content map[string]interface{}) (types.RouteResult, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if sessionID == "s2" {
+			return types.RouteResult{}, fmt.Errorf("database unavailable")
+		}
+		announced[sessionID] = append(announced[sessionID], messageContext)
+		return types.RouteResult{MessageID: sessionID + "-" + messageContext, Delivered: []string{"a", "b"}, Dropped: []string{"c"}}, nil
+	})
+	
+	w, response := post(`{"content": {"text": "Campus closing at noon"}, "contexts": ["general", "announcements"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(response.Sessions) != 3 || response.Delivered != 8 || response.Failed != 1 {
+		t.Fatalf("Unexpected response: %+v", response)
+	}
+	for _, result := range response.Sessions {
+		switch result.SessionID {
+		case "s2":
+			if result.Error == "" || len(result.MessageIDs) != 0 {
+				t.Errorf("Expected s2 to report its failure, got %+v", result)
+			}
+		default:
+			if result.Error != "" || len(result.MessageIDs) != 2 || result.Delivered != 4 || result.Dropped != 2 {
+				t.Errorf("Unexpected result for %s: %+v", result.SessionID, result)
+			}
+		}
+	}
+	if contexts := announced["s1"]; len(contexts) != 2 || contexts[0] != "general" || contexts[1] != "announcements" {
+		t.Errorf("Expected one announcement per context in order, got %v", contexts)
+	}
+	
+	// Filters keep sessions matching every set field; ownership follows transfers
+	announced = make(map[string][]string)
+	w, response = post(`{"content": {"text": "hi"}, "session_filter": {"session_ids": ["s1", "s3"], "owner_id": "instructor1"}}`)
+	if w.Code != http.StatusOK || len(response.Sessions) != 1 || response.Sessions[0].SessionID != "s1" {
+		t.Errorf("Expected only s1 to match the filter, got %d %+v", w.Code, response)
+	}
+	if contexts := announced["s1"]; len(contexts) != 1 || contexts[0] != "" {
+		t.Errorf("Expected the broadcast default context without contexts, got %v", contexts)
+	}
+	
+	for _, body := range []string{
+		`{"content": {}}`,
+		`{"content": {"text": "hi"}, "contexts": ["not a context!"]}`,
+		`not json`,
+	} {
+		if w, _ := post(body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d for %s, got %d", http.StatusBadRequest, body, w.Code)
+		}
+	}
+	
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/admin/broadcast", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status %d for GET, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// TestFanOutAnnouncement_BoundedWorkers tests technical validation - no more than announceWorkers sessions are in flight
+func TestFanOutAnnouncement_BoundedWorkers(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	server.announceWorkers = 2
+	server.announcePacing = time.Millisecond
+	
+	var mu sync.Mutex
+	inFlight, peak := 0, 0
+	server.SetAnnouncer(func(ctx context.Context, sessionID, messageContext string, content map[string]interface{}) (types.RouteResult, error) {
+		mu.Lock()
+		inFlight++
+		peak = max(peak, inFlight)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return types.RouteResult{MessageID: sessionID}, nil
+	})
+	
+	sessions := make([]*types.Session, 10)
+	for i := range sessions {
+		sessions[i] = &types.Session{ID: fmt.Sprintf("s%d", i)}
+	}
+	results := server.fanOutAnnouncement(context.Background(), sessions, []string{""}, map[string]interface{}{"text": "hi"})
+	
+	if peak > 2 {
+		t.Errorf("Expected at most 2 sessions in flight, got %d", peak)
+	}
+	for i, result := range results {
+		if result.SessionID != sessions[i].ID || len(result.MessageIDs) != 1 {
+			t.Errorf("Result %d out of order or missing: %+v", i, result)
+		}
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Instructor annotations, reaction tallies and tag-filtered history
+func TestServer_MessageAnnotations(t *testing.T) {
+	dbManager := &mockDatabaseManager{history: []*types.Message{
+		{ID: "q1", SessionID: "test-session-id", Type: types.MessageTypeInstructorInbox, FromUser: "student1"},
+		{ID: "q2", SessionID: "test-session-id", Type: types.MessageTypeInstructorInbox, FromUser: "student2"},
+	}, reactions: map[string]map[string]int{"q1": {"thumbs_up": 2, "question": 1}}}
+	registry := newMockRegistry()
+	student := &mockConnection{userID: "student1", role: "student"}
+	registry.sessionConnections["test-session-id"] = []interfaces.ConnectionWriter{student}
+	server := NewServer(&mockSessionManager{}, dbManager, registry)
+	
+	annotate := func(messageID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PATCH", "/api/sessions/test-session-id/messages/"+messageID+"/annotations", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	history := func(query string) (*httptest.ResponseRecorder, SessionHistoryResponse) {
+		req := httptest.NewRequest("GET", "/api/sessions/test-session-id/messages?"+query, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		var response SessionHistoryResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	
+	w := annotate("q2", `{"instructor_id":"instructor1","tags":["star","followup"],"note":"revisit Monday"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := annotate("q2", `{"instructor_id":"student1","tags":["star"]}`); w.Code != http.StatusForbidden {
+		t.Errorf("Students annotating: expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := annotate("q2", `{"instructor_id":"instructor1","tags":["not valid"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid tag: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := annotate("missing", `{"instructor_id":"instructor1","tags":["star"]}`); w.Code != http.StatusNotFound {
+		t.Errorf("Unknown message: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if len(student.messages) != 0 {
+		t.Errorf("Annotations must not be broadcast, student received %v", student.messages)
+	}
+	
+	w, response := history("instructor_id=instructor1")
+	if w.Code != http.StatusOK || len(response.Messages) != 2 {
+		t.Fatalf("Expected full history, got %d with %d messages", w.Code, len(response.Messages))
+	}
+	if response.Messages[0].Annotations != nil || len(response.Messages[1].Annotations) != 1 {
+		t.Errorf("Expected the annotation inline on q2 only, got %+v", response.Messages)
+	}
+	if response.Messages[0].Reactions["thumbs_up"] != 2 || response.Messages[1].Reactions != nil {
+		t.Errorf("Expected reaction tallies inline on q1 only, got %+v", response.Messages)
+	}
+	
+	_, response = history("instructor_id=instructor1&tag=star")
+	if len(response.Messages) != 1 || response.Messages[0].ID != "q2" {
+		t.Errorf("Expected only the starred message, got %+v", response.Messages)
+	}
+	
+	if w, _ := history("instructor_id=student2"); w.Code != http.StatusForbidden {
+		t.Errorf("Students reading annotated history: expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+// mockReadStore holds read receipts by message ID
+type mockReadStore struct {
+	reads map[string][]*types.MessageRead
+}
+
+func (m *mockReadStore) RecordMessageRead(ctx context.Context, sessionID string, read *types.MessageRead) (bool, error) {
+	return false, fmt.Errorf("not implemented")
+}
+
+func (m *mockReadStore) GetMessageReads(ctx context.Context, messageID string) ([]*types.MessageRead, error) {
+	return m.reads[messageID], nil
+}
+
+func (m *mockReadStore) CountMessageReads(ctx context.Context, messageID string) (int, error) {
+	return len(m.reads[messageID]), nil
+}
+
+// FUNCTIONAL VALIDATION TEST: Instructors see who has and has not read a message
+func TestServer_MessageReads(t *testing.T) {
+	readAt := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	dbManager := &mockDatabaseManager{history: []*types.Message{
+		{ID: "b1", SessionID: "test-session-id", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1"},
+		{ID: "b2", SessionID: "test-session-id", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1", ToUsers: []string{"student2"}},
+	}}
+	server := NewServer(&mockSessionManager{}, dbManager, newMockRegistry())
+	
+	reads := func(messageID, instructorID string) (*httptest.ResponseRecorder, types.MessageReads) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/test-session-id/messages/"+messageID+"/reads?instructor_id="+instructorID, nil))
+		var response types.MessageReads
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	
+	if w, _ := reads("b1", "instructor1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Without a read store: expected %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	server.SetReadReceipts(&mockReadStore{reads: map[string][]*types.MessageRead{
+		"b1": {{MessageID: "b1", UserID: "student1", ReadAt: readAt}},
+	}})
+	
+	w, response := reads("b1", "instructor1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if response.RecipientCount != 2 || response.ReadCount != 1 || len(response.Read) != 1 || response.Read[0].UserID != "student1" {
+		t.Errorf("Expected student1 of 2 recipients read the broadcast, got %+v", response)
+	}
+	if len(response.Unread) != 1 || response.Unread[0] != "student2" {
+		t.Errorf("Expected student2 unread, got %v", response.Unread)
+	}
+	
+	_, response = reads("b2", "instructor1")
+	if response.RecipientCount != 1 || response.ReadCount != 0 || len(response.Unread) != 1 || response.Unread[0] != "student2" {
+		t.Errorf("Expected only the addressed student as recipient, got %+v", response)
+	}
+	
+	if w, _ := reads("missing", "instructor1"); w.Code != http.StatusNotFound {
+		t.Errorf("Unknown message: expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+	if w, _ := reads("b1", "student1"); w.Code != http.StatusForbidden {
+		t.Errorf("Students reading receipts: expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("POST", "/api/sessions/test-session-id/messages/b1/reads", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: A student's thread pages through their conversation only,
+// for the student themself and for instructors
+func TestServer_StudentThread(t *testing.T) {
+	student1 := "student1"
+	dbManager := &mockDatabaseManager{history: []*types.Message{
+		{ID: "q1", SessionID: "test-session-id", Type: types.MessageTypeInstructorInbox, FromUser: "student1"},
+		{ID: "q2", SessionID: "test-session-id", Type: types.MessageTypeInstructorInbox, FromUser: "student2"},
+		{ID: "a1", SessionID: "test-session-id", Type: types.MessageTypeInboxResponse, FromUser: "instructor1", ToUser: &student1},
+		{ID: "b1", SessionID: "test-session-id", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1"},
+		{ID: "rr1", SessionID: "test-session-id", Type: types.MessageTypeRequestResponse, FromUser: "student1"},
+	}}
+	server := NewServer(&mockSessionManager{}, dbManager, newMockRegistry())
+
+	thread := func(query string) (*httptest.ResponseRecorder, ThreadResponse) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/test-session-id/threads/student1"+query, nil))
+		var response ThreadResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+
+	w, response := thread("?requested_by=instructor1&limit=2")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(response.Messages) != 2 || response.Messages[0].ID != "q1" || response.Messages[1].ID != "a1" || response.NextAfter != "a1" {
+		t.Errorf("Expected q1 and a1 with a cursor at a1, got %+v", response)
+	}
+	_, response = thread("?requested_by=instructor1&limit=2&after=a1")
+	if len(response.Messages) != 1 || response.Messages[0].ID != "rr1" || response.NextAfter != "" {
+		t.Errorf("Expected rr1 on the last page, got %+v", response)
+	}
+
+	if w, response := thread(""); w.Code != http.StatusOK || len(response.Messages) != 3 {
+		t.Errorf("Student reading their own thread: expected 3 messages, got %d %+v", w.Code, response)
+	}
+	if w, _ := thread("?requested_by=student2"); w.Code != http.StatusForbidden {
+		t.Errorf("Another student: expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if w, _ := thread("?limit=0"); w.Code != http.StatusBadRequest {
+		t.Errorf("limit=0: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w, _ := thread("?after=missing"); w.Code != http.StatusBadRequest {
+		t.Errorf("Unknown cursor: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("POST", "/api/sessions/test-session-id/threads/student1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: expected %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Sessions carry a time zone that exports render timestamps in
+func TestServer_SessionTimezone(t *testing.T) {
+	stored := time.Date(2026, 3, 2, 15, 30, 0, 0, time.UTC)
+	dbManager := &mockDatabaseManager{history: []*types.Message{
+		{ID: "q1", SessionID: "test-session-id", Type: types.MessageTypeInstructorInbox, FromUser: "student1", Timestamp: stored},
+	}}
+	sessionManager := &mockSessionManager{}
+	server := NewServer(sessionManager, dbManager, newMockRegistry())
+	
+	create := func(body string) (*httptest.ResponseRecorder, ErrorResponse) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("POST", "/api/sessions", bytes.NewBufferString(body)))
+		var response ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	w, errResponse := create(`{"name":"Class","instructor_id":"instructor1","student_ids":["student1"],"timezone":"Mars/Olympus"}`)
+	if w.Code != http.StatusBadRequest || errResponse.Code != http.StatusBadRequest || !strings.Contains(errResponse.Message, "timezone") {
+		t.Errorf("Invalid zone: expected structured 400, got %d %+v", w.Code, errResponse)
+	}
+	if _, set := sessionManager.timezones["test-session-id"]; set {
+		t.Error("An invalid zone must be rejected before the session is created")
+	}
+	
+	if w, _ := create(`{"name":"Class","instructor_id":"instructor1","student_ids":["student1"]}`); w.Code != http.StatusCreated || sessionManager.timezones != nil {
+		t.Errorf("Missing zone should create a UTC session without setting one, got %d", w.Code)
+	}
+	w, _ = create(`{"name":"Class","instructor_id":"instructor1","student_ids":["student1"],"timezone":"America/Chicago"}`)
+	var created CreateSessionResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created.Session.Timezone != "America/Chicago" {
+		t.Fatalf("Expected a session in America/Chicago, got %d %+v", w.Code, created.Session)
+	}
+	
+	history := func(query string) (*httptest.ResponseRecorder, string) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/test-session-id/messages?instructor_id=instructor1"+query, nil))
+		return w, w.Body.String()
+	}
+	w, body := history("")
+	if w.Code != http.StatusOK || !strings.Contains(body, `"timezone":"America/Chicago"`) || !strings.Contains(body, `"timestamp":"2026-03-02T09:30:00-06:00"`) {
+		t.Errorf("Expected timestamps in the session zone, got %d %s", w.Code, body)
+	}
+	_, body = history("&tz=Asia/Tokyo")
+	if !strings.Contains(body, `"timezone":"Asia/Tokyo"`) || !strings.Contains(body, `"timestamp":"2026-03-03T00:30:00+09:00"`) {
+		t.Errorf("Expected tz to override the session zone, got %s", body)
+	}
+	_, body = history("&tz=UTC")
+	if !strings.Contains(body, `"timestamp":"2026-03-02T15:30:00Z"`) {
+		t.Errorf("Expected UTC timestamps, got %s", body)
+	}
+	if w, _ := history("&tz=Local"); w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid tz: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Repeated DELETE is a 409, or a 200 for idempotent clients
+func TestServer_EndSessionTwice(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	
+	end := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/api/sessions/"+sessionID, nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	
+	if w := end("session-a"); w.Code != http.StatusOK {
+		t.Fatalf("First end: expected %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := end("session-a"); w.Code != http.StatusConflict {
+		t.Errorf("Second end: expected %d, got %d", http.StatusConflict, w.Code)
+	}
+	
+	server.SetIdempotentEnd(true)
+	end("session-b")
+	w := end("session-b")
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusOK || response["already_ended"] != true {
+		t.Errorf("Idempotent second end: expected 200 with already_ended, got %d %v", w.Code, response)
+	}
+}
+
+// mockAPIKeyManager issues secrets "sbk_<n>" and tracks revocations
+type mockAPIKeyManager struct {
+	mu      sync.Mutex
+	keys    map[string]*types.APIKey // By secret
+	created int
+}
+
+func newMockAPIKeyManager() *mockAPIKeyManager {
+	return &mockAPIKeyManager{keys: make(map[string]*types.APIKey)}
+}
+
+func (m *mockAPIKeyManager) add(secret, sessionID string, scopes ...string) *types.APIKey {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := &types.APIKey{ID: fmt.Sprintf("key-%d", len(m.keys)+1), SessionID: sessionID, Scopes: scopes, CreatedBy: "instructor1", CreatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	m.keys[secret] = key
+	return key
+}
+
+func (m *mockAPIKeyManager) CreateKey(ctx context.Context, sessionID, createdBy, name string, scopes []string, ttl time.Duration) (string, *types.APIKey, error) {
+	if len(scopes) == 0 {
+		return "", nil, apikey.ErrNoScopes
+	}
+	m.mu.Lock()
+	m.created++
+	secret := fmt.Sprintf("sbk_%d", m.created)
+	m.mu.Unlock()
+	key := m.add(secret, sessionID, scopes...)
+	key.Name = name
+	return secret, key, nil
+}
+
+func (m *mockAPIKeyManager) ListKeys(ctx context.Context, sessionID string) ([]*types.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keys []*types.APIKey
+	for _, key := range m.keys {
+		if key.SessionID == sessionID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockAPIKeyManager) RevokeKey(ctx context.Context, sessionID, keyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, key := range m.keys {
+		if key.ID == keyID && key.SessionID == sessionID {
+			now := time.Now()
+			key.RevokedAt = &now
+			return nil
+		}
+	}
+	return interfaces.ErrNotFound
+}
+
+func (m *mockAPIKeyManager) Authenticate(ctx context.Context, secret string) (*types.APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key, exists := m.keys[secret]
+	if !exists || key.RevokedAt != nil {
+		return nil, interfaces.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// FUNCTIONAL VALIDATION TEST: Instructors issue, list and revoke keys; keys act within
+// their session and scopes only
+func TestServer_APIKeys(t *testing.T) {
+	dbManager := &mockDatabaseManager{counts: &types.SessionMessageCounts{}}
+	server := NewServer(&mockSessionManager{}, dbManager, newMockRegistry())
+	keys := newMockAPIKeyManager()
+	server.SetAPIKeys(keys)
+	
+	do := func(method, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		server.ServeHTTP(w, r)
+		return w
+	}
+	
+	// Issue
+	w := do("POST", "/api/sessions/test-session-id/api-keys", `{"instructor_id":"instructor1","name":"grader","scopes":["read_history"]}`, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created CreateAPIKeyResponse
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.Key == "" || created.APIKey == nil || created.APIKey.Name != "grader" {
+		t.Fatalf("Expected the secret and key once, got %s", w.Body.String())
+	}
+	if w := do("POST", "/api/sessions/test-session-id/api-keys", `{"instructor_id":"student1","scopes":["read_history"]}`, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a student, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := do("POST", "/api/sessions/test-session-id/api-keys", `{"instructor_id":"instructor1","scopes":[]}`, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d without scopes, got %d", http.StatusBadRequest, w.Code)
+	}
+	
+	// The read_history key reads stats but may not manage keys
+	if w := do("GET", "/api/sessions/test-session-id/stats", "", map[string]string{"X-API-Key": created.Key}); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d for stats with a key, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := do("GET", "/api/sessions/test-session-id/api-keys", "", map[string]string{"Authorization": "Bearer " + created.Key}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d listing keys without manage, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := do("GET", "/api/sessions/other-session/stats?api_key="+created.Key, "", nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for another session, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := do("GET", "/api/sessions/test-session-id/stats", "", map[string]string{"X-API-Key": "sbk_unknown"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for an unknown key, got %d", http.StatusUnauthorized, w.Code)
+	}
+	
+	// A manage key lists and revokes
+	keys.add("sbk_manage", "test-session-id", types.APIKeyScopeManage)
+	w = do("GET", "/api/sessions/test-session-id/api-keys", "", map[string]string{"X-API-Key": "sbk_manage"})
+	var listed ListAPIKeysResponse
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed.APIKeys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), created.Key) {
+		t.Error("Expected listed keys to omit their secrets")
+	}
+	if w := do("DELETE", "/api/sessions/test-session-id/api-keys/"+created.APIKey.ID, "", map[string]string{"X-API-Key": "sbk_manage"}); w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d revoking, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := do("GET", "/api/sessions/test-session-id/stats", "", map[string]string{"X-API-Key": created.Key}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d for a revoked key, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w := do("DELETE", "/api/sessions/test-session-id/api-keys/missing?instructor_id=instructor1", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for an unknown key, got %d", http.StatusNotFound, w.Code)
+	}
+	
+	// Keys can never mint keys
+	if w := do("POST", "/api/sessions/test-session-id/api-keys", `{"scopes":["manage"]}`, map[string]string{"X-API-Key": "sbk_manage"}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d creating a key with a key, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// mockReportReader serves fixed report data and counts streamed items
+type mockReportReader struct {
+	counts    *types.SessionMessageCounts
+	questions []*types.ReportQuestion
+	starred   []*types.Message
+	streamErr error // Returned by StreamStarredMessages when set
+	config    *types.SessionConfig
+}
+
+func (m *mockReportReader) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) {
+	return m.counts, nil
+}
+
+func (m *mockReportReader) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) {
+	return []*types.SessionEvent{
+		{Type: types.SessionEventRoutingLatency, Actor: "system", Details: map[string]interface{}{"samples": float64(3), "p50_ms": 0.5, "p95_ms": 2.0}},
+	}, nil
+}
+
+func (m *mockReportReader) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) {
+	return m.config, nil
+}
+
+func (m *mockReportReader) GetSessionAnalytics(ctx context.Context, sessionID string) ([]*types.AnalyticsBucket, error) {
+	return []*types.AnalyticsBucket{{Context: "progress", Minute: time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC), Count: 5}}, nil
+}
+
+func (m *mockReportReader) StreamQuestions(ctx context.Context, sessionID string, fn func(*types.ReportQuestion) error) error {
+	for _, question := range m.questions {
+		if err := fn(question); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockReportReader) StreamStarredMessages(ctx context.Context, sessionID string, fn func(*types.Message) error) error {
+	for _, message := range m.starred {
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	return m.streamErr
+}
+
+// FUNCTIONAL VALIDATION TEST: Reports render as JSON and self-contained HTML for
+// instructors, with every rostered student and the streamed questions and stars
+func TestServer_SessionReport(t *testing.T) {
+	minute := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	question := &types.Message{ID: "q1", Type: types.MessageTypeInstructorInbox, FromUser: "student1", Content: map[string]interface{}{"text": "Why <script>?"}, Timestamp: minute}
+	reports := &mockReportReader{
+		counts: &types.SessionMessageCounts{
+			Total:       3,
+			ByType:      map[string]int{types.MessageTypeInstructorInbox: 2, types.MessageTypeInboxResponse: 1},
+			ByMinute:    []types.MinuteCount{{Minute: minute, Count: 3}},
+			BySender:    map[string]int{"student1": 2, "instructor1": 1},
+			BytesByType: map[string]int64{types.MessageTypeInstructorInbox: 61, types.MessageTypeInboxResponse: 23},
+		},
+		questions: []*types.ReportQuestion{{
+			Question:  question,
+			Responses: []*types.Message{{ID: "a1", Type: types.MessageTypeInboxResponse, FromUser: "instructor1", Content: map[string]interface{}{"text": "Escaping"}, Timestamp: minute}},
+		}},
+		starred: []*types.Message{question, {ID: "s2", Type: types.MessageTypeAnalytics, FromUser: "student1", Content: map[string]interface{}{"code": map[string]interface{}{"lang": "go"}, "score": 3}, Timestamp: minute}},
+		config: &types.SessionConfig{
+			Version: types.SessionConfigVersion,
+			Routing: []types.RouteSummary{{Type: types.MessageTypeInstructorBroadcast, SenderRole: "instructor", Recipients: "session_students", DefaultContext: "general"}},
+			Limits:  types.SessionLimits{MaxContentBytes: types.MaxContentBytes, RateLimitMessages: 100, RateLimitWindowSeconds: 60},
+		},
+	}
+	sessionManager := &mockSessionManager{endReasons: map[string]string{"ended-session-id": "manual"}}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	
+	fetch := func(query string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions/ended-session-id/report?"+query, nil))
+		return w
+	}
+	
+	if w := fetch("instructor_id=instructor1"); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d before reports are wired, got %d", http.StatusNotFound, w.Code)
+	}
+	server.SetReports(reports)
+	
+	w := fetch("instructor_id=instructor1&format=json")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), `filename=session-ended-session-id-report.json`) {
+		t.Errorf("Expected a download filename, got %q", w.Header().Get("Content-Disposition"))
+	}
+	var document struct {
+		Report          types.SessionReport    `json:"report"`
+		Questions       []types.ReportQuestion `json:"questions"`
+		StarredMessages []types.Message        `json:"starred_messages"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &document); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, w.Body.String())
+	}
+	summary := document.Report.Summary
+	if summary.TotalMessages != 3 || summary.Students != 2 || summary.Attended != 1 || summary.RoutingLatency.Samples != 3 || summary.BytesByType[types.MessageTypeInstructorInbox] != 61 {
+		t.Errorf("Unexpected summary: %+v", summary)
+	}
+	participation := document.Report.Participation
+	if len(participation) != 2 || participation[0].UserID != "student1" || !participation[0].Attended || participation[1].Attended {
+		t.Errorf("Expected every rostered student with attendance, got %+v", participation)
+	}
+	if len(document.Report.Analytics) != 1 || len(document.Report.Events) != 1 {
+		t.Errorf("Expected analytics and events, got %+v", document.Report)
+	}
+	if config := document.Report.Config; config == nil || config.Version != types.SessionConfigVersion || len(config.Routing) != 1 {
+		t.Errorf("Expected the session's config snapshot, got %+v", config)
+	}
+	if len(document.Questions) != 1 || len(document.Questions[0].Responses) != 1 || len(document.StarredMessages) != 2 {
+		t.Errorf("Expected the streamed question, response and stars, got %+v, %+v", document.Questions, document.StarredMessages)
+	}
+	
+	w = fetch("instructor_id=instructor1")
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("Expected an HTML report by default, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	for _, want := range []string{"<!DOCTYPE html>", "Why &lt;script&gt;?", "Escaping", "code.lang=go score=3", "1 of 2", "2 (61 bytes)", "progress", "routing_latency", "session ended", "session_students", "100 messages per 60 s", "</html>"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in the HTML report", want)
+		}
+	}
+	if strings.Contains(body, "<script") || strings.Contains(body, "http://") || strings.Contains(body, "https://") {
+		t.Error("Expected an escaped, self-contained document")
+	}
+	
+	// A failure while streaming cuts the document short rather than changing its status
+	reports.streamErr = fmt.Errorf("disk went away")
+	if w := fetch("instructor_id=instructor1&format=json"); w.Code != http.StatusOK || json.Valid(w.Body.Bytes()) {
+		t.Errorf("Expected a truncated document after a stream failure, got %d", w.Code)
+	}
+	reports.streamErr = nil
+	
+	if w := fetch("instructor_id=instructor1&format=pdf"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown format, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := fetch("instructor_id=student1"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a student, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Pseudonymized reports name students consistently, scrub
+// content and only carry the mapping on the admin route
+func TestServer_SessionReportPseudonymized(t *testing.T) {
+	minute := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	toStudent := "student1"
+	question := &types.Message{ID: "q1", Type: types.MessageTypeInstructorInbox, FromUser: "student1", Content: map[string]interface{}{"text": "student2 and I are stuck, mail me at s1@example.edu"}, Timestamp: minute}
+	reports := &mockReportReader{
+		counts: &types.SessionMessageCounts{Total: 2, BySender: map[string]int{"student1": 1, "instructor1": 1}},
+		questions: []*types.ReportQuestion{{
+			Question:  question,
+			Responses: []*types.Message{{ID: "a1", Type: types.MessageTypeInboxResponse, FromUser: "instructor1", ToUser: &toStudent, Content: map[string]interface{}{"text": "Hi student1"}, Timestamp: minute}},
+		}},
+		starred: []*types.Message{{ID: "s1", Type: types.MessageTypeInstructorInbox, FromUser: "student9", Content: map[string]interface{}{"text": "Dropped the class"}, Timestamp: minute}},
+	}
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	server.SetReports(reports)
+	server.SetScrubRules(transcript.ScrubRules{Fields: []string{"text"}, Patterns: []*regexp.Regexp{regexp.MustCompile(`\S+@\S+`)}})
+	
+	type document struct {
+		Report          types.SessionReport    `json:"report"`
+		Questions       []types.ReportQuestion `json:"questions"`
+		StarredMessages []types.Message        `json:"starred_messages"`
+		Pseudonyms      map[string]string      `json:"pseudonyms"`
+	}
+	fetch := func(path, query string) (*httptest.ResponseRecorder, document) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest("GET", path+"?"+query, nil))
+		var decoded document
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), &decoded); err != nil {
+				t.Fatalf("Expected valid JSON, got %v: %s", err, w.Body.String())
+			}
+		}
+		return w, decoded
+	}
+	const reportPath = "/api/sessions/test-session-id/report"
+	
+	w, first := fetch(reportPath, "instructor_id=instructor1&format=json&pseudonymize=true&seed=fall")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "student1") || strings.Contains(w.Body.String(), "student2") || strings.Contains(w.Body.String(), "example.edu") {
+		t.Errorf("Expected no student IDs or emails in a pseudonymized report: %s", w.Body.String())
+	}
+	if first.Pseudonyms != nil {
+		t.Error("Expected no mapping outside the admin route")
+	}
+	asker := first.Questions[0].Question.FromUser
+	if !strings.HasPrefix(asker, "Student ") || *first.Questions[0].Responses[0].ToUser != asker || first.Questions[0].Responses[0].FromUser != "instructor1" {
+		t.Errorf("Expected the student pseudonymized throughout and the instructor kept, got %+v", first.Questions[0])
+	}
+	if text := first.Questions[0].Question.Content["text"]; !strings.Contains(text.(string), "[redacted]") {
+		t.Errorf("Expected the email scrubbed, got %q", text)
+	}
+	participation := first.Report.Participation
+	if len(participation) != 2 || participation[0].UserID != "Student A" || participation[1].UserID != "Student B" {
+		t.Errorf("Expected participation by pseudonym, got %+v", participation)
+	}
+	if first.StarredMessages[0].FromUser != "Student C" {
+		t.Errorf("Expected an unrostered sender named after the roster, got %q", first.StarredMessages[0].FromUser)
+	}
+	if first.Report.Events[0].Details != nil {
+		t.Errorf("Expected event details dropped, got %v", first.Report.Events[0].Details)
+	}
+	
+	_, again := fetch(reportPath, "instructor_id=instructor1&format=json&pseudonymize=true&seed=fall")
+	if again.Questions[0].Question.FromUser != asker {
+		t.Error("Expected the same seed to give the same pseudonyms")
+	}
+	
+	if w, _ := fetch(reportPath, "instructor_id=instructor1&format=json&pseudonymize=true&include_mapping=true"); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a mapping outside the admin route, got %d", http.StatusForbidden, w.Code)
+	}
+	if w, _ := fetch(reportPath, "instructor_id=instructor1&pseudonymize=maybe"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a bad flag, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w, _ := fetch(reportPath, "instructor_id=instructor1&seed=fall"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a seed without pseudonymize, got %d", http.StatusBadRequest, w.Code)
+	}
+	
+	const adminPath = "/api/admin/sessions/test-session-id/report"
+	if w, _ := fetch(adminPath, "format=html&pseudonymize=true&include_mapping=true"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for a mapping in HTML, got %d", http.StatusBadRequest, w.Code)
+	}
+	w, admin := fetch(adminPath, "format=json&pseudonymize=true&seed=fall&include_mapping=true")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if len(admin.Pseudonyms) != 3 || admin.Pseudonyms[asker] != "student1" || admin.Pseudonyms["Student C"] != "student9" {
+		t.Errorf("Expected the mapping of every named student, got %v", admin.Pseudonyms)
+	}
+	
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", reportPath+"?instructor_id=instructor1&pseudonymize=true", nil))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "student1") || !strings.Contains(w.Body.String(), "Student A") {
+		t.Errorf("Expected a pseudonymized HTML report, got %d", w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Co-instructors are set at creation and replaced by PATCH
+func TestServer_SessionInstructors(t *testing.T) {
+	sessionManager := &mockSessionManager{}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	
+	do := func(method, path, body string) (*httptest.ResponseRecorder, SessionResponse) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		var response SessionResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w, response
+	}
+	
+	if w, _ := do("POST", "/api/sessions", `{"name":"Class","instructor_id":"instructor1","student_ids":["student1"],"instructor_ids":["ta 1"]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Invalid instructor ID: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	if sessionManager.instructors != nil {
+		t.Error("An invalid list must be rejected before the session is created")
+	}
+	
+	w, created := do("POST", "/api/sessions", `{"name":"Class","instructor_id":"instructor1","student_ids":["student1"],"instructor_ids":["ta1","ta2"]}`)
+	if w.Code != http.StatusCreated || len(created.Session.InstructorIDs) != 2 {
+		t.Fatalf("Expected the session created with two co-instructors, got %d %+v", w.Code, created.Session)
+	}
+	
+	w, patched := do("PATCH", "/api/sessions/test-session-id", `{"instructor_ids":["ta3"]}`)
+	if w.Code != http.StatusOK || len(patched.Session.InstructorIDs) != 1 || patched.Session.InstructorIDs[0] != "ta3" {
+		t.Errorf("Expected the list replaced, got %d %+v", w.Code, patched.Session)
+	}
+	w, patched = do("PATCH", "/api/sessions/test-session-id", `{"instructor_ids":[]}`)
+	if w.Code != http.StatusOK || len(patched.Session.InstructorIDs) != 0 {
+		t.Errorf("Expected an empty list to clear co-instructors, got %d %+v", w.Code, patched.Session)
+	}
+	if w, _ := do("PATCH", "/api/sessions/test-session-id", `{"instructor_ids":[""]}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Empty instructor ID: expected %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Session metadata is set at creation, patched, listed and
+// filtered, with field-level errors for invalid entries
+func TestServer_SessionMetadata(t *testing.T) {
+	sessionManager := &mockSessionManager{}
+	dbManager := &mockDatabaseManager{}
+	server := NewServer(sessionManager, dbManager, newMockRegistry())
+	
+	do := func(method, path, body string) (*httptest.ResponseRecorder, SessionResponse, ErrorResponse) {
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, httptest.NewRequest(method, path, bytes.NewBufferString(body)))
+		var response SessionResponse
+		var errResponse ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &response)
+		json.Unmarshal(w.Body.Bytes(), &errResponse)
+		return w, response, errResponse
+	}
+	
+	w, _, errResponse := do("POST", "/api/sessions", `{"name":"Class","instructor_id":"instructor1","student_ids":["student1"],"metadata":{"switchboard_id":"x","room number":"B12"}}`)
+	if w.Code != http.StatusBadRequest || errResponse.Fields["metadata.switchboard_id"] == "" || errResponse.Fields["metadata.room number"] == "" {
+		t.Errorf("Expected a 400 naming both fields, got %d %+v", w.Code, errResponse)
+	}
+	
+	w, created, _ := do("POST", "/api/sessions", `{"name":"Class","instructor_id":"instructor1","student_ids":["student1"],"metadata":{"course":"CS101","room":"B12"}}`)
+	if w.Code != http.StatusCreated || created.Session.Metadata["course"] != "CS101" {
+		t.Fatalf("Expected the session created with metadata, got %d %+v", w.Code, created.Session)
+	}
+	
+	w, patched, _ := do("PATCH", "/api/sessions/test-session-id", `{"metadata":{"room":null,"lms_assignment":"42"}}`)
+	if w.Code != http.StatusOK || len(patched.Session.Metadata) != 2 || patched.Session.Metadata["lms_assignment"] != "42" {
+		t.Errorf("Expected the patch merged, got %d %+v", w.Code, patched.Session)
+	}
+	w, _, errResponse = do("PATCH", "/api/sessions/test-session-id", fmt.Sprintf(`{"metadata":{"notes":%q}}`, strings.Repeat("x", 1025)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(errResponse.Fields["metadata.notes"], "1024 bytes") {
+		t.Errorf("Expected an oversized value refused, got %d %+v", w.Code, errResponse)
+	}
+	
+	dbManager.metadataMatches = []string{"session1"}
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions?metadata.course=CS101&status=active", nil))
+	var listed ListSessionsResponse
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed.Sessions) != 1 || dbManager.metadataFilters["course"] != "CS101" || len(dbManager.metadataFilters) != 1 {
+		t.Errorf("Expected the list filtered on course only, got %d %+v with %v", w.Code, listed.Sessions, dbManager.metadataFilters)
+	}
+	dbManager.metadataMatches = nil
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, httptest.NewRequest("GET", "/api/sessions?metadata.course=BIO200", nil))
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed.Sessions) != 0 {
+		t.Errorf("Expected no sessions for an unmatched filter, got %+v", listed.Sessions)
+	}
+	w, _, errResponse = do("GET", "/api/sessions?metadata.bad.key=x", "")
+	if w.Code != http.StatusBadRequest || errResponse.Fields["metadata.bad.key"] == "" {
+		t.Errorf("Expected an invalid filter key refused, got %d %+v", w.Code, errResponse)
+	}
+}
+
+// mockSessionPurger records purge calls and answers with a completed purge
+type mockSessionPurger struct {
+	calls []string // "sessionID by requestedBy" per call
+}
+
+func (m *mockSessionPurger) PurgeSession(ctx context.Context, sessionID, requestedBy string) (*types.SessionPurge, error) {
+	m.calls = append(m.calls, sessionID+" by "+requestedBy)
+	completedAt := time.Now()
+	return &types.SessionPurge{
+		SessionID:   sessionID,
+		RequestedBy: requestedBy,
+		Stage:       types.PurgeStageCompleted,
+		Removed:     map[string]int64{types.PurgeStageMessages: 12, types.PurgeStageSession: 1},
+		CompletedAt: &completedAt,
+	}, nil
+}
+
+// FUNCTIONAL VALIDATION TEST: DELETE ?purge=true needs the creator and the confirmation
+// header, ends an active session first, and is refused while the session is exported
+func TestServer_PurgeSession(t *testing.T) {
+	sessionManager := &mockSessionManager{}
+	server := NewServer(sessionManager, &mockDatabaseManager{}, newMockRegistry())
+	purge := func(path, confirm string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", path, nil)
+		if confirm != "" {
+			req.Header.Set(PurgeConfirmHeader, confirm)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	
+	if w := purge("/api/sessions/s1?purge=true&requested_by=instructor1", "s1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before purging is enabled, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	purger := &mockSessionPurger{}
+	server.SetSessionPurger(purger)
+	
+	for _, refused := range []struct {
+		path, confirm string
+		code          int
+	}{
+		{"/api/sessions/s1?purge=maybe&requested_by=instructor1", "s1", http.StatusBadRequest},
+		{"/api/sessions/s1?purge=true&requested_by=instructor1", "", http.StatusPreconditionRequired},
+		{"/api/sessions/s1?purge=true&requested_by=instructor1", "s2", http.StatusPreconditionRequired},
+		{"/api/sessions/s1?purge=true&requested_by=instructor2", "s1", http.StatusForbidden},
+	} {
+		if w := purge(refused.path, refused.confirm); w.Code != refused.code {
+			t.Errorf("Expected status %d for %s confirmed as %q, got %d", refused.code, refused.path, refused.confirm, w.Code)
+		}
+	}
+	
+	server.exports.beginExport("s1")
+	if w := purge("/api/sessions/s1?purge=true&requested_by=instructor1", "s1"); w.Code != http.StatusConflict {
+		t.Errorf("Expected status %d while the session is exported, got %d", http.StatusConflict, w.Code)
+	}
+	server.exports.endExport("s1")
+	if len(purger.calls) != 0 || len(sessionManager.endReasons) != 0 {
+		t.Fatalf("Expected refused purges to change nothing, got %v and %v", purger.calls, sessionManager.endReasons)
+	}
+	
+	w := purge("/api/sessions/s1?purge=true&requested_by=instructor1", "s1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var result types.SessionPurge
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("Failed to decode purge: %v", err)
+	}
+	if result.Removed[types.PurgeStageMessages] != 12 || result.Stage != types.PurgeStageCompleted {
+		t.Errorf("Unexpected purge: %+v", result)
+	}
+	if sessionManager.endReasons["s1"] != "Session deleted" {
+		t.Errorf("Expected the active session ended before the purge, got %q", sessionManager.endReasons["s1"])
+	}
+	
+	if w := purge("/api/admin/sessions/s2", "s2"); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d for an admin purge, got %d", http.StatusOK, w.Code)
+	}
+	if len(purger.calls) != 2 || purger.calls[0] != "s1 by instructor1" || purger.calls[1] != "s2 by admin" {
+		t.Errorf("Unexpected purge calls: %v", purger.calls)
+	}
+	
+	server.exports.beginPurge("s3")
+	if server.exports.beginExport("s3") {
+		t.Error("Expected exports refused while the session is purged")
+	}
+}
+
+// mockDelegationManager keeps grants in a map and refuses unrostered students
+type mockDelegationManager struct {
+	grants map[string]*types.Delegation // userID -> grant
+}
+
+func (m *mockDelegationManager) GrantDelegation(ctx context.Context, sessionID, grantedBy, userID string, messageTypes []string, ttl time.Duration) (*types.Delegation, error) {
+	if userID != "student1" {
+		return nil, session.ErrNotOnRoster
+	}
+	grant := &types.Delegation{SessionID: sessionID, UserID: userID, Types: messageTypes, GrantedBy: grantedBy, ExpiresAt: time.Now().Add(ttl)}
+	m.grants[userID] = grant
+	return grant, nil
+}
+
+func (m *mockDelegationManager) RevokeDelegation(ctx context.Context, sessionID, userID, revokedBy string) (*types.Delegation, error) {
+	grant, exists := m.grants[userID]
+	if !exists {
+		return nil, session.ErrDelegationNotFound
+	}
+	delete(m.grants, userID)
+	return grant, nil
+}
+
+func (m *mockDelegationManager) ListDelegations(sessionID string) []*types.Delegation {
+	grants := []*types.Delegation{}
+	for _, grant := range m.grants {
+		grants = append(grants, grant)
+	}
+	return grants
+}
+
+// FUNCTIONAL VALIDATION TEST: instructors grant and revoke delegations, students may
+// not, and everyone connected hears about each change
+func TestServer_Delegations(t *testing.T) {
+	registry := newMockRegistry()
+	listener := &mockConnection{userID: "student2", role: "student"}
+	registry.sessionConnections["s1"] = []interfaces.ConnectionWriter{listener}
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, registry)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	grantBody := `{"instructor_id":"instructor1","user_id":"student1","types":["instructor_broadcast"]}`
+	
+	if w := send("POST", "/api/sessions/s1/delegations", grantBody); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before delegation is enabled, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	delegations := &mockDelegationManager{grants: make(map[string]*types.Delegation)}
+	server.SetDelegations(delegations)
+	
+	for _, refused := range []struct {
+		body string
+		code int
+	}{
+		{`{"instructor_id":"student2","user_id":"student1","types":["instructor_broadcast"]}`, http.StatusForbidden},
+		{`{"instructor_id":"instructor1","user_id":"student9","types":["instructor_broadcast"]}`, http.StatusBadRequest},
+		{`{"instructor_id":"instructor1","user_id":"","types":["instructor_broadcast"]}`, http.StatusBadRequest},
+	} {
+		if w := send("POST", "/api/sessions/s1/delegations", refused.body); w.Code != refused.code {
+			t.Errorf("Expected status %d for %s, got %d", refused.code, refused.body, w.Code)
+		}
+	}
+	
+	w := send("POST", "/api/sessions/s1/delegations", grantBody)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var grant types.Delegation
+	json.NewDecoder(w.Body).Decode(&grant)
+	if remaining := time.Until(grant.ExpiresAt); grant.GrantedBy != "instructor1" || remaining < 9*time.Minute || remaining > 10*time.Minute {
+		t.Errorf("Expected a 10 minute grant from instructor1, got %+v", grant)
+	}
+	
+	w = send("GET", "/api/sessions/s1/delegations", "")
+	var listed ListDelegationsResponse
+	json.NewDecoder(w.Body).Decode(&listed)
+	if w.Code != http.StatusOK || len(listed.Delegations) != 1 || listed.Delegations[0].UserID != "student1" {
+		t.Errorf("Expected student1's grant listed, got %d: %+v", w.Code, listed)
+	}
+	
+	if w := send("DELETE", "/api/sessions/s1/delegations/student1?instructor_id=student2", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a student revoking, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := send("DELETE", "/api/sessions/s1/delegations/student1?instructor_id=instructor1", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected status %d revoking, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w := send("DELETE", "/api/sessions/s1/delegations/student1?instructor_id=instructor1", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d revoking twice, got %d", http.StatusNotFound, w.Code)
+	}
+	
+	server.DelegationExpired(&grant)
+	var events []string
+	for _, message := range listener.messages {
+		content, _ := message["content"].(map[string]interface{})
+		if message["context"] == "delegation" && content != nil {
+			events = append(events, content["event"].(string))
+		}
+	}
+	if len(events) != 3 || events[0] != "delegation_granted" || events[1] != "delegation_revoked" || events[2] != "delegation_expired" {
+		t.Errorf("Expected grant, revoke and expiry notices, got %v", events)
+	}
+}
+
+// mockMessageEditor edits messages held by the mock database in place
+type mockMessageEditor struct {
+	db        *mockDatabaseManager
+	revisions []*types.MessageRevision
+}
+
+func (m *mockMessageEditor) EditMessage(ctx context.Context, sessionID, messageID, editedBy string, content map[string]interface{}) (*types.Message, error) {
+	message, err := m.db.GetMessage(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	m.revisions = append(m.revisions, &types.MessageRevision{MessageID: messageID, Revision: message.Revision, Content: message.Content, EditedBy: editedBy, ReplacedAt: now})
+	message.Content = content
+	message.Revision++
+	message.EditedAt = &now
+	return message, nil
+}
+
+func (m *mockMessageEditor) GetMessageRevisions(ctx context.Context, messageID string) ([]*types.MessageRevision, error) {
+	return m.revisions, nil
+}
+
+// FUNCTIONAL VALIDATION TEST: the sender edits an instructor message within the
+// window, the students it reached hear the new content, and the original is kept
+func TestServer_EditMessage(t *testing.T) {
+	registry := newMockRegistry()
+	addressed := &mockConnection{userID: "student2", role: "student"}
+	bystander := &mockConnection{userID: "student3", role: "student"}
+	registry.sessionConnections["s1"] = []interfaces.ConnectionWriter{addressed, bystander}
+	student2 := "student2"
+	db := &mockDatabaseManager{history: []*types.Message{
+		{ID: "m1", SessionID: "s1", Type: types.MessageTypeInboxResponse, FromUser: "instructor1", ToUser: &student2, Content: map[string]interface{}{"text": "See page 4"}, Timestamp: time.Now()},
+		{ID: "m2", SessionID: "s1", Type: types.MessageTypeInstructorInbox, FromUser: "student2", Content: map[string]interface{}{"text": "Help"}, Timestamp: time.Now()},
+		{ID: "m3", SessionID: "s1", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1", Content: map[string]interface{}{"text": "Hello"}, Timestamp: time.Now().Add(-time.Hour)},
+	}}
+	server := NewServer(&mockSessionManager{}, db, registry)
+	send := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	edit := func(messageID, instructorID string) *httptest.ResponseRecorder {
+		return send("PATCH", "/api/sessions/s1/messages/"+messageID, `{"instructor_id":"`+instructorID+`","content":{"text":"See page 5"}}`)
+	}
+
+	if w := edit("m1", "instructor1"); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before editing is enabled, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	editor := &mockMessageEditor{db: db}
+	server.SetMessageEditor(editor, 15*time.Minute)
+	server.SetContentCheck(func(message *types.Message) error {
+		if _, exists := message.Content["script"]; exists {
+			return fmt.Errorf("content key script is not allowed")
+		}
+		return nil
+	})
+
+	for _, refused := range []struct {
+		name      string
+		messageID string
+		sender    string
+		code      int
+	}{
+		{"student caller", "m1", "student2", http.StatusForbidden},
+		{"another instructor", "m1", "instructor2", http.StatusForbidden},
+		{"student message", "m2", "instructor1", http.StatusForbidden},
+		{"window passed", "m3", "instructor1", http.StatusConflict},
+		{"unknown message", "m9", "instructor1", http.StatusNotFound},
+	} {
+		if w := edit(refused.messageID, refused.sender); w.Code != refused.code {
+			t.Errorf("%s: expected status %d, got %d", refused.name, refused.code, w.Code)
+		}
+	}
+	if w := send("PATCH", "/api/sessions/s1/messages/m1", `{"instructor_id":"instructor1","content":{"script":"x"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for disallowed content, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	w := edit("m1", "instructor1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var updated types.Message
+	json.NewDecoder(w.Body).Decode(&updated)
+	if updated.Revision != 1 || updated.EditedAt == nil || updated.Content["text"] != "See page 5" {
+		t.Errorf("Expected revision 1 with the new content, got %+v", updated)
+	}
+	if len(addressed.messages) != 1 || addressed.messages[0]["context"] != "message_edited" {
+		t.Errorf("Expected the addressed student told of the edit, got %v", addressed.messages)
+	}
+	if len(bystander.messages) != 0 {
+		t.Errorf("Students the message never reached should not hear of the edit, got %v", bystander.messages)
+	}
+
+	if w := send("GET", "/api/sessions/s1/messages/m1/revisions?instructor_id=student2", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a student reading revisions, got %d", http.StatusForbidden, w.Code)
+	}
+	w = send("GET", "/api/sessions/s1/messages/m1/revisions?instructor_id=instructor1", "")
+	var revisions MessageRevisionsResponse
+	json.NewDecoder(w.Body).Decode(&revisions)
+	if w.Code != http.StatusOK || len(revisions.Revisions) != 1 || revisions.Revisions[0].Content["text"] != "See page 4" {
+		t.Errorf("Expected the original content kept, got %d: %+v", w.Code, revisions)
+	}
+}
+
+// mockRosterEditor applies roster changes to the mock session's two students
+type mockRosterEditor struct {
+	changes []types.RosterChange
+	dryRuns []bool
+}
+
+func (m *mockRosterEditor) UpdateRoster(ctx context.Context, sessionID string, change types.RosterChange, dryRun bool) (*types.Session, types.RosterChange, error) {
+	m.changes = append(m.changes, change)
+	m.dryRuns = append(m.dryRuns, dryRun)
+	roster := map[string]bool{"student1": true, "student2": true}
+	effective := types.RosterChange{Add: []string{}, Remove: []string{}}
+	for _, studentID := range change.Remove {
+		if roster[studentID] {
+			delete(roster, studentID)
+			effective.Remove = append(effective.Remove, studentID)
+		}
+	}
+	for _, studentID := range change.Add {
+		if !roster[studentID] {
+			roster[studentID] = true
+			effective.Add = append(effective.Add, studentID)
+		}
+	}
+	if len(roster) == 0 {
+		return nil, effective, session.ErrEmptyStudentList
+	}
+	return &types.Session{ID: sessionID, StudentIDs: change.Add}, effective, nil
+}
+
+// TestServer_Roster tests functional validation - the roster exports as JSON or CSV with
+// presence, and uploads report bad rows by line while applying the rest
+func TestServer_Roster(t *testing.T) {
+	server := NewServer(&mockSessionManager{}, &mockDatabaseManager{}, newMockRegistry())
+	send := func(method, path, contentType, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	upload := "student_id,joins\nstudent1,4\nstudent3,0\nbad id!,1\n\"unterminated,2\n"
+	
+	if w := send("POST", "/api/sessions/s1/roster?instructor_id=instructor1", "text/csv", upload); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d before the roster is enabled, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	editor := &mockRosterEditor{}
+	server.SetRoster(editor, func(sessionID string) map[string]types.StudentPresence {
+		return map[string]types.StudentPresence{"student2": {Joins: 3, Leaves: 2, Connected: true, BytesSent: 2048, BytesReceived: 512}}
+	})
+	
+	w := send("GET", "/api/sessions/s1/roster?instructor_id=instructor1&format=csv", "", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("Expected a CSV export, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if want := "student_id,joins,leaves,connected,bytes_sent,bytes_received\nstudent1,0,0,false,0,0\nstudent2,3,2,true,2048,512\n"; w.Body.String() != want {
+		t.Errorf("Unexpected CSV export:\n%s", w.Body.String())
+	}
+	w = send("GET", "/api/sessions/s1/roster?instructor_id=instructor1", "", "")
+	var exported RosterResponse
+	json.NewDecoder(w.Body).Decode(&exported)
+	if len(exported.Students) != 2 || exported.Students[1].StudentID != "student2" || !exported.Students[1].Connected {
+		t.Errorf("Unexpected JSON export: %+v", exported)
+	}
+	if w := send("GET", "/api/sessions/s1/roster?instructor_id=student1", "", ""); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status %d for a student, got %d", http.StatusForbidden, w.Code)
+	}
+	
+	// Bad rows are reported by line and the good ones still applied
+	w = send("POST", "/api/sessions/s1/roster?instructor_id=instructor1&mode=replace&dry_run=true", "text/csv", upload)
+	var result types.RosterImport
+	json.NewDecoder(w.Body).Decode(&result)
+	if w.Code != http.StatusOK || !result.DryRun || result.Session != nil || editor.dryRuns[0] != true {
+		t.Fatalf("Expected a dry run, got %d: %+v", w.Code, result)
+	}
+	if fmt.Sprint(result.Added, result.Removed, result.Unchanged) != "[student3] [student2] 1" {
+		t.Errorf("Unexpected diff: %+v", result)
+	}
+	if len(result.Errors) != 2 || result.Errors[0].Line != 4 || result.Errors[0].StudentID != "bad id!" || result.Errors[1].Line != 5 {
+		t.Errorf("Expected lines 4 and 5 reported, got %+v", result.Errors)
+	}
+	
+	if w := send("POST", "/api/sessions/s1/roster?instructor_id=instructor1&strict=true", "text/csv", upload); w.Code != http.StatusUnprocessableEntity || len(editor.changes) != 1 {
+		t.Errorf("Expected strict mode to refuse the upload untouched, got %d", w.Code)
+	}
+	
+	// JSON uploads take IDs or exported entries; merge never removes
+	w = send("POST", "/api/sessions/s1/roster?instructor_id=instructor1", "", `{"students": ["student4", {"student_id": "student1", "joins": 9}, 7]}`)
+	result = types.RosterImport{}
+	json.NewDecoder(w.Body).Decode(&result)
+	if w.Code != http.StatusOK || result.Mode != "merge" || result.Session == nil || len(editor.changes[1].Remove) != 0 {
+		t.Fatalf("Expected a merge, got %d: %+v", w.Code, result)
+	}
+	if fmt.Sprint(result.Added) != "[student4]" || len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Errorf("Unexpected JSON import: %+v", result)
+	}
+	
+	if w := send("POST", "/api/sessions/s1/roster?instructor_id=instructor1&mode=replace", "", `{"students": []}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d replacing with nobody, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := send("POST", "/api/sessions/s1/roster?instructor_id=instructor1&mode=swap", "", `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status %d for an unknown mode, got %d", http.StatusBadRequest, w.Code)
+	}
+}
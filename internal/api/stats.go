@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// statsCacheTTL is how long a session's message counts are reused between polls
+// FUNCTIONAL DISCOVERY: Dashboards poll every ~10 seconds during class; several
+// instructors polling the same session share one aggregation per window
+const statsCacheTTL = 5 * time.Second
+
+// statsCache keeps each session's most recent message counts
+type statsCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedCounts
+}
+
+type cachedCounts struct {
+	counts *types.SessionMessageCounts
+	at     time.Time
+}
+
+func newStatsCache() *statsCache {
+	return &statsCache{entries: make(map[string]cachedCounts)}
+}
+
+func (c *statsCache) get(sessionID string, now time.Time) (cachedCounts, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, exists := c.entries[sessionID]
+	if !exists || now.Sub(entry.at) >= statsCacheTTL {
+		return cachedCounts{}, false
+	}
+	return entry, true
+}
+
+// put stores counts and drops expired entries, so sessions nobody polls any more
+// do not accumulate
+func (c *statsCache) put(sessionID string, entry cachedCounts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, existing := range c.entries {
+		if entry.at.Sub(existing.at) >= statsCacheTTL {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[sessionID] = entry
+}
+
+// SetRoutingLatency sets the source of live per-session routing latency for session stats
+func (s *Server) SetRoutingLatency(latency func(sessionID string) types.LatencyStats) {
+	s.routingLatency = latency
+}
+
+// SetDeliveryStats sets the source of live per-session delivery counts for session stats
+func (s *Server) SetDeliveryStats(delivery func(sessionID string) types.DeliveryStats) {
+	s.deliveryStats = delivery
+}
+
+// BandwidthSource returns a session's live WebSocket traffic
+type BandwidthSource func(sessionID string) types.BandwidthStats
+
+// SetBandwidth sets the source of live per-session traffic for session stats and reports
+func (s *Server) SetBandwidth(bandwidth BandwidthSource) {
+	s.bandwidth = bandwidth
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/stats?instructor_id=... - Message counts
+// per type and per minute, per-student participation, p50/p95 routing latency and
+// delivery failure rates. Counts are cached for a few seconds; latency and delivery are
+// live while the session is active and come from the summaries recorded at session end
+// afterwards
+func (s *Server) sessionStats(w http.ResponseWriter, r *http.Request, sessionID string) {
+	current, _, ok := s.requireInstructorOrKey(w, r, sessionID, r.URL.Query().Get("instructor_id"), types.APIKeyScopeReadHistory, "Only instructors may view session stats")
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	cached, hit := s.statsCache.get(sessionID, now)
+	if !hit {
+		counts, err := s.dbManager.GetSessionMessageCounts(r.Context(), sessionID)
+		if err != nil {
+			s.sendError(w, "Failed to get session stats", http.StatusInternalServerError)
+			return
+		}
+		cached = cachedCounts{counts: counts, at: now}
+		s.statsCache.put(sessionID, cached)
+	}
+	counts := cached.counts
+
+	// Every rostered student appears, so silent students show up as zero
+	participation := make(map[string]int, len(current.StudentIDs))
+	for _, studentID := range current.StudentIDs {
+		participation[studentID] = counts.BySender[studentID]
+	}
+
+	stats := types.SessionStats{
+		SessionID:         sessionID,
+		Status:            current.Status,
+		TotalMessages:     counts.Total,
+		MessagesByType:    counts.ByType,
+		MessagesPerMinute: counts.ByMinute,
+		Participation:     participation,
+		CountsAsOf:        cached.at,
+	}
+
+	// TECHNICAL DISCOVERY: Averaged over whole minutes since start (at least one), so a
+	// burst in a session's first seconds is not extrapolated to a huge rate
+	until := now
+	if current.EndTime != nil {
+		until = *current.EndTime
+	}
+	minutes := until.Sub(current.StartTime).Minutes()
+	if minutes < 1 {
+		minutes = 1
+	}
+	stats.AverageMessagesPerMinute = float64(counts.Total) / minutes
+
+	if current.Status == types.SessionActive {
+		if s.routingLatency != nil {
+			stats.RoutingLatency = s.routingLatency(sessionID)
+		}
+		if s.deliveryStats != nil {
+			stats.Delivery = s.deliveryStats(sessionID)
+		}
+		stats.Bandwidth = types.BandwidthStats{ByType: map[string]types.ByteCounts{}}
+		if s.bandwidth != nil {
+			stats.Bandwidth = s.bandwidth(sessionID)
+		}
+	} else {
+		events, err := s.dbManager.GetSessionEvents(r.Context(), sessionID)
+		if err != nil {
+			s.sendError(w, "Failed to get session stats", http.StatusInternalServerError)
+			return
+		}
+		stats.RoutingLatency = routingLatencyFromEvents(events)
+		stats.Delivery = deliveryStatsFromEvents(events)
+		stats.Bandwidth = bandwidthFromEvents(events)
+	}
+
+	json.NewEncoder(w).Encode(stats)
+}
+
+// routingLatencyFromEvents returns the last routing latency summary in an audit trail;
+// sessions that routed nothing have none and report zero samples
+func routingLatencyFromEvents(events []*types.SessionEvent) types.LatencyStats {
+	var latency types.LatencyStats
+	for _, event := range events {
+		if event.Type != types.SessionEventRoutingLatency {
+			continue
+		}
+		// Details round-trip through JSON, so numbers arrive as float64
+		samples, _ := event.Details["samples"].(float64)
+		p50, _ := event.Details["p50_ms"].(float64)
+		p95, _ := event.Details["p95_ms"].(float64)
+		latency = types.LatencyStats{Samples: int(samples), P50Ms: p50, P95Ms: p95}
+	}
+	return latency
+}
+
+// deliveryStatsFromEvents returns the last delivery reliability summary in an audit
+// trail; sessions that routed nothing, or ended before it was recorded, report a
+// reliability of 1 with nothing attempted
+func deliveryStatsFromEvents(events []*types.SessionEvent) types.DeliveryStats {
+	delivery := types.DeliveryStats{ByReason: map[string]int{}, Reliability: 1}
+	for _, event := range events {
+		if event.Type != types.SessionEventDeliveryReliability {
+			continue
+		}
+		// Details round-trip through JSON, so numbers arrive as float64
+		delivered, _ := event.Details["delivered"].(float64)
+		failed, _ := event.Details["failed"].(float64)
+		failureRate, _ := event.Details["failure_rate"].(float64)
+		reliability, _ := event.Details["reliability"].(float64)
+		delivery = types.DeliveryStats{
+			Delivered:   int(delivered),
+			Failed:      int(failed),
+			ByReason:    map[string]int{},
+			FailureRate: failureRate,
+			Reliability: reliability,
+		}
+		byReason, _ := event.Details["by_reason"].(map[string]interface{})
+		for reason, count := range byReason {
+			n, _ := count.(float64)
+			delivery.ByReason[reason] = int(n)
+		}
+	}
+	return delivery
+}
+
+// bandwidthFromEvents returns the last traffic summary in an audit trail; sessions
+// that ended before it was recorded report nothing sent or received
+func bandwidthFromEvents(events []*types.SessionEvent) types.BandwidthStats {
+	bandwidth := types.BandwidthStats{ByType: map[string]types.ByteCounts{}}
+	for _, event := range events {
+		if event.Type != types.SessionEventBandwidth {
+			continue
+		}
+		// Details round-trip through JSON, so numbers arrive as float64
+		bandwidth = types.BandwidthStats{ByteCounts: byteCountsFromDetails(event.Details), ByType: map[string]types.ByteCounts{}}
+		byType, _ := event.Details["by_type"].(map[string]interface{})
+		for kind, counts := range byType {
+			details, _ := counts.(map[string]interface{})
+			bandwidth.ByType[kind] = byteCountsFromDetails(details)
+		}
+	}
+	return bandwidth
+}
+
+// byteCountsFromDetails reads ByteCounts from event details decoded from JSON
+func byteCountsFromDetails(details map[string]interface{}) types.ByteCounts {
+	count := func(key string) uint64 {
+		n, _ := details[key].(float64)
+		return uint64(n)
+	}
+	return types.ByteCounts{
+		BytesSent:      count("bytes_sent"),
+		BytesReceived:  count("bytes_received"),
+		FramesSent:     count("frames_sent"),
+		FramesReceived: count("frames_received"),
+	}
+}
+
+// ThroughputHistory returns the last hours of per-minute throughput, narrowed to one
+// session when sessionID is set
+type ThroughputHistory func(hours int, sessionID string) types.ThroughputHistory
+
+// maxThroughputHours is the most history GET /api/stats/history serves
+const maxThroughputHours = 24
+
+// SetThroughputHistory sets the source of GET /api/stats/history
+func (s *Server) SetThroughputHistory(history ThroughputHistory) {
+	s.throughput = history
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/stats/history?hours=24[&session_id=...] - Messages and
+// connections per minute, for capacity planning; unauthenticated like /api/scaling-hint,
+// since it carries counts and session IDs but no content
+func (s *Server) handleThroughputHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.throughput == nil {
+		s.sendError(w, "Throughput history not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	hours := maxThroughputHours
+	if raw := r.URL.Query().Get("hours"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxThroughputHours {
+			s.sendError(w, "hours must be between 1 and 24", http.StatusBadRequest)
+			return
+		}
+		hours = parsed
+	}
+
+	json.NewEncoder(w).Encode(s.throughput(hours, r.URL.Query().Get("session_id")))
+}
+
+// MessageSizeStats returns the stored content sizes of each message type sent since since
+type MessageSizeStats func(ctx context.Context, since time.Time) ([]types.MessageSizeStats, error)
+
+// maxMessageSizeHours is the widest window GET /api/stats/message-sizes serves
+const maxMessageSizeHours = 30 * 24
+
+// SetMessageSizeStats sets the source of GET /api/stats/message-sizes
+func (s *Server) SetMessageSizeStats(stats MessageSizeStats) {
+	s.messageSizes = stats
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/stats/message-sizes?hours=24 - Count, total, p50, p95
+// and max stored content bytes per message type over the last hours (1 to 720), to
+// see which types use the storage; unauthenticated like /api/stats/history, since it
+// carries only sizes
+func (s *Server) handleMessageSizes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.messageSizes == nil {
+		s.sendError(w, "Message size stats not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	hours := 24
+	if raw := r.URL.Query().Get("hours"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxMessageSizeHours {
+			s.sendError(w, fmt.Sprintf("hours must be between 1 and %d", maxMessageSizeHours), http.StatusBadRequest)
+			return
+		}
+		hours = parsed
+	}
+
+	since := time.Now().Add(-time.Duration(hours) * time.Hour)
+	sizes, err := s.messageSizes(r.Context(), since)
+	if err != nil {
+		s.sendError(w, "Failed to read message sizes", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(types.MessageSizeReport{Since: since, Hours: hours, Types: sizes})
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+	"switchboard/internal/apikey"
+)
+
+// Thread page sizes
+const (
+	DefaultThreadPageSize = 100
+	MaxThreadPageSize     = 500
+)
+
+const threadsForbidden = "Students may only view their own thread"
+
+// ThreadResponse is one page of a student's conversation thread
+type ThreadResponse struct {
+	SessionID string           `json:"session_id"`
+	StudentID string           `json:"student_id"`
+	Messages  []*types.Message `json:"messages"`
+	NextAfter string           `json:"next_after,omitempty"` // Pass as ?after= for the next page; absent on the last page
+}
+
+// threadStudentID extracts the student ID from "threads/{student_id}"
+func threadStudentID(subresource string) (string, bool) {
+	studentID, found := strings.CutPrefix(subresource, "threads/")
+	return studentID, found && studentID != "" && !strings.Contains(studentID, "/")
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/sessions/{id}/threads/{student_id}?requested_by=...&after=...&limit=... -
+// One student's conversation: their instructor_inbox questions and request responses
+// and the inbox_responses and requests sent back, oldest first. requested_by defaults
+// to the student, who may read their own thread; anyone else must be an instructor or
+// hold a read_history key. Works on ended sessions too
+// ARCHITECTURAL DISCOVERY: Pages come from an indexed query rather than filtering the
+// full history, so a long session's thread costs what the page does
+func (s *Server) studentThread(w http.ResponseWriter, r *http.Request, sessionID, studentID string) {
+	query := r.URL.Query()
+	if !types.IsValidUserID(studentID) {
+		s.sendError(w, "student_id must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+	limit := DefaultThreadPageSize
+	if raw := query.Get("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > MaxThreadPageSize {
+			s.sendError(w, fmt.Sprintf("limit must be between 1 and %d", MaxThreadPageSize), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+	requestedBy := query.Get("requested_by")
+	if requestedBy == "" {
+		requestedBy = studentID
+	} else if !types.IsValidUserID(requestedBy) {
+		s.sendError(w, "requested_by must be a valid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if requestedBy == studentID && apikey.FromRequest(r) == "" {
+		if _, err := s.sessionManager.GetSession(r.Context(), sessionID); err != nil {
+			if strings.Contains(err.Error(), "not found") {
+				s.sendError(w, "Session not found", http.StatusNotFound)
+			} else {
+				s.sendError(w, "Failed to get session", http.StatusInternalServerError)
+			}
+			return
+		}
+	} else if _, _, ok := s.requireInstructorOrKey(w, r, sessionID, requestedBy, types.APIKeyScopeReadHistory, threadsForbidden); !ok {
+		return
+	}
+
+	// TECHNICAL DISCOVERY: One extra message is read to learn whether another page exists
+	messages, err := s.dbManager.GetStudentThread(r.Context(), sessionID, studentID, query.Get("after"), limit+1)
+	if errors.Is(err, interfaces.ErrNotFound) {
+		s.sendError(w, "after must be the ID of a message in this session", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		s.sendError(w, "Failed to get thread", http.StatusInternalServerError)
+		return
+	}
+	response := ThreadResponse{SessionID: sessionID, StudentID: studentID, Messages: messages}
+	if len(messages) > limit {
+		response.Messages = messages[:limit]
+		response.NextAfter = messages[limit-1].ID
+	}
+	if response.Messages == nil {
+		response.Messages = []*types.Message{}
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"switchboard/pkg/types"
+	"switchboard/internal/session"
+)
+
+// optionsProblem checks the settings createSession applies after creation, returning
+// the error message for the first invalid one or "" when all are valid
+func (req *CreateSessionRequest) optionsProblem() string {
+	if req.DurationMinutes < 0 || req.DurationMinutes > session.MaxDurationMinutes {
+		return "duration_minutes must be between 0 and 1440"
+	}
+	if req.Timezone != "" {
+		if err := session.ValidateTimezone(req.Timezone); err != nil {
+			return "timezone must be an IANA time zone name, e.g. America/Chicago"
+		}
+	}
+	for _, instructorID := range req.InstructorIDs {
+		if !types.IsValidUserID(instructorID) {
+			return "instructor_ids must be valid user IDs, got " + strconv.Quote(instructorID)
+		}
+	}
+	return ""
+}
+
+// FUNCTIONAL DISCOVERY: Handle POST /api/sessions/validate - dry run of POST /api/sessions
+func (s *Server) handleValidateSession(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		s.validateSession(w, r)
+	case http.MethodOptions:
+		// CORS preflight handled by middleware
+		w.WriteHeader(http.StatusOK)
+	default:
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// validateSession reports whether a create-session payload would be accepted
+// ARCHITECTURAL DISCOVERY: The roster and name go through the session manager's own
+// create validation and the options through optionsProblem, the checks createSession
+// runs, so the verdict matches what the real call would do
+// FUNCTIONAL DISCOVERY: An invalid payload is still 200 OK with "valid": false; nothing
+// is created and no name is reserved
+func (s *Server) validateSession(w http.ResponseWriter, r *http.Request) {
+	var req CreateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.sendError(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	report, err := s.sessionManager.ValidateSession(r.Context(), req.Name, req.InstructorID, req.StudentIDs)
+	if err != nil {
+		log.Printf("Failed to validate session %q: %v", req.Name, err)
+		s.sendError(w, "Failed to validate session", http.StatusInternalServerError)
+		return
+	}
+	if problem := req.optionsProblem(); problem != "" {
+		report.Errors = append(report.Errors, problem)
+		report.Valid = false
+	}
+	var metadataErr *types.MetadataError
+	if errors.As(types.ValidateSessionMetadata(req.Metadata), &metadataErr) {
+		report.Errors = append(report.Errors, metadataErr.Error())
+		report.Valid = false
+	}
+
+	json.NewEncoder(w).Encode(report)
+}
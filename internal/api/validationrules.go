@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"switchboard/pkg/types"
+)
+
+// SetValidationRules sets the descriptor served by GET /api/validation-rules
+func (s *Server) SetValidationRules(rules *types.ValidationRules) {
+	s.validationRules = rules
+}
+
+// FUNCTIONAL DISCOVERY: GET /api/validation-rules - The message validation rules as
+// data, for client-side pre-validation; unauthenticated like /api/capabilities. The
+// rules' hash is the ETag, so a client holding the current copy gets 304 Not Modified
+func (s *Server) handleValidationRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		s.sendError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.validationRules == nil {
+		s.sendError(w, "Validation rules not configured", http.StatusServiceUnavailable)
+		return
+	}
+
+	etag := `"` + s.validationRules.Hash + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	json.NewEncoder(w).Encode(s.validationRules)
+}
@@ -0,0 +1,10 @@
+package apikey
+
+import "errors"
+
+// Key creation errors
+var (
+	ErrNoScopes     = errors.New("an API key needs at least one scope")
+	ErrInvalidScope = errors.New("unknown API key scope")
+	ErrInvalidTTL   = errors.New("API key lifetime must be between a minute and 30 days")
+)
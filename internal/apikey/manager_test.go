@@ -0,0 +1,181 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// memoryStore is an in-memory Storage counting hash lookups
+type memoryStore struct {
+	mu      sync.Mutex
+	keys    map[string]*types.APIKey // By hash
+	lookups int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{keys: make(map[string]*types.APIKey)}
+}
+
+func (s *memoryStore) CreateAPIKey(ctx context.Context, key *types.APIKey, keyHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored := *key
+	s.keys[keyHash] = &stored
+	return nil
+}
+
+func (s *memoryStore) GetAPIKeyByHash(ctx context.Context, keyHash string) (*types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lookups++
+	key, exists := s.keys[keyHash]
+	if !exists {
+		return nil, interfaces.ErrNotFound
+	}
+	stored := *key
+	return &stored, nil
+}
+
+func (s *memoryStore) ListAPIKeys(ctx context.Context, sessionID string) ([]*types.APIKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []*types.APIKey
+	for _, key := range s.keys {
+		if key.SessionID == sessionID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) RevokeAPIKey(ctx context.Context, sessionID, keyID string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range s.keys {
+		if key.ID == keyID && key.SessionID == sessionID {
+			key.RevokedAt = &revokedAt
+			return nil
+		}
+	}
+	return interfaces.ErrNotFound
+}
+
+func (s *memoryStore) lookupCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lookups
+}
+
+// FUNCTIONAL VALIDATION TEST: Creation refuses bad scopes and lifetimes
+func TestManager_CreateKeyValidation(t *testing.T) {
+	manager := NewManager(newMemoryStore(), func(string) bool { return true })
+	ctx := context.Background()
+
+	if _, _, err := manager.CreateKey(ctx, "session1", "instructor1", "", nil, 0); !errors.Is(err, ErrNoScopes) {
+		t.Errorf("Expected ErrNoScopes, got %v", err)
+	}
+	if _, _, err := manager.CreateKey(ctx, "session1", "instructor1", "", []string{"admin"}, 0); !errors.Is(err, ErrInvalidScope) {
+		t.Errorf("Expected ErrInvalidScope, got %v", err)
+	}
+	if _, _, err := manager.CreateKey(ctx, "session1", "instructor1", "", []string{types.APIKeyScopeManage}, MaxTTL+time.Hour); !errors.Is(err, ErrInvalidTTL) {
+		t.Errorf("Expected ErrInvalidTTL, got %v", err)
+	}
+
+	secret, key, err := manager.CreateKey(ctx, "session1", "instructor1", "grader", []string{types.APIKeyScopeReadHistory, types.APIKeyScopeReadHistory}, 0)
+	if err != nil {
+		t.Fatalf("CreateKey should succeed: %v", err)
+	}
+	if !strings.HasPrefix(secret, SecretPrefix) || len(secret) < 40 {
+		t.Errorf("Expected a long prefixed secret, got %q", secret)
+	}
+	if len(key.Scopes) != 1 || key.ExpiresAt.Sub(key.CreatedAt) != DefaultTTL {
+		t.Errorf("Expected deduplicated scopes and the default lifetime, got %+v", key)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Keys authenticate from cache and stop working when revoked,
+// expired or their session ends
+func TestManager_Authenticate(t *testing.T) {
+	store := newMemoryStore()
+	active := map[string]bool{"session1": true}
+	var activeMu sync.Mutex
+	manager := NewManager(store, func(sessionID string) bool {
+		activeMu.Lock()
+		defer activeMu.Unlock()
+		return active[sessionID]
+	})
+	var revoked []string
+	manager.OnRevoke(func(key *types.APIKey) { revoked = append(revoked, key.UserID()) })
+	ctx := context.Background()
+
+	secret, key, err := manager.CreateKey(ctx, "session1", "instructor1", "bot", []string{types.APIKeyScopeSendMessages}, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateKey should succeed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		authenticated, err := manager.Authenticate(ctx, secret)
+		if err != nil || authenticated.ID != key.ID {
+			t.Fatalf("Expected the key to authenticate, got %+v, %v", authenticated, err)
+		}
+	}
+	if lookups := store.lookupCount(); lookups != 1 {
+		t.Errorf("Expected repeated authentication to be cached, got %d lookups", lookups)
+	}
+	if manager.CachedKeys() != 1 {
+		t.Errorf("Expected 1 cached key, got %d", manager.CachedKeys())
+	}
+
+	if _, err := manager.Authenticate(ctx, "not-a-key"); !errors.Is(err, interfaces.ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for a malformed secret, got %v", err)
+	}
+	if _, err := manager.Authenticate(ctx, SecretPrefix+"unknown"); !errors.Is(err, interfaces.ErrInvalidAPIKey) {
+		t.Errorf("Expected ErrInvalidAPIKey for an unknown secret, got %v", err)
+	}
+
+	// Expiry is checked against the clock on every call
+	manager.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := manager.Authenticate(ctx, secret); !errors.Is(err, interfaces.ErrInvalidAPIKey) {
+		t.Errorf("Expected an expired key to fail, got %v", err)
+	}
+	manager.now = time.Now
+
+	// Ending the session invalidates the key and releases its cache entry
+	activeMu.Lock()
+	active["session1"] = false
+	activeMu.Unlock()
+	if _, err := manager.Authenticate(ctx, secret); !errors.Is(err, interfaces.ErrInvalidAPIKey) {
+		t.Errorf("Expected a key of an ended session to fail, got %v", err)
+	}
+	manager.ReleaseSession(types.Session{ID: "session1"})
+	if manager.CachedKeys() != 0 {
+		t.Errorf("Expected ReleaseSession to empty the cache, %d remain", manager.CachedKeys())
+	}
+	activeMu.Lock()
+	active["session1"] = true
+	activeMu.Unlock()
+
+	// Revocation applies at once despite the cache
+	if _, err := manager.Authenticate(ctx, secret); err != nil {
+		t.Fatalf("Expected the key to authenticate again, got %v", err)
+	}
+	if err := manager.RevokeKey(ctx, "session2", key.ID); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound revoking through another session, got %v", err)
+	}
+	if err := manager.RevokeKey(ctx, "session1", key.ID); err != nil {
+		t.Fatalf("RevokeKey should succeed: %v", err)
+	}
+	if _, err := manager.Authenticate(ctx, secret); !errors.Is(err, interfaces.ErrInvalidAPIKey) {
+		t.Errorf("Expected a revoked key to fail, got %v", err)
+	}
+	if len(revoked) != 1 || revoked[0] != key.UserID() {
+		t.Errorf("Expected OnRevoke with the key's user, got %v", revoked)
+	}
+}
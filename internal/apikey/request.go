@@ -0,0 +1,25 @@
+package apikey
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HeaderAPIKey carries a key on REST requests and WebSocket handshakes
+const HeaderAPIKey = "X-API-Key"
+
+// FromRequest returns the key secret r carries, or "" when it carries none
+// FUNCTIONAL DISCOVERY: Checked in order X-API-Key, "Authorization: Bearer", then the
+// api_key query parameter, which browsers' WebSocket API needs since it cannot set
+// headers. Query strings end up in access logs, so headers are preferred
+func FromRequest(r *http.Request) string {
+	if secret := strings.TrimSpace(r.Header.Get(HeaderAPIKey)); secret != "" {
+		return secret
+	}
+	if token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); found {
+		if secret := strings.TrimSpace(token); secret != "" {
+			return secret
+		}
+	}
+	return r.URL.Query().Get("api_key")
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"switchboard/internal/alerting"
+	"switchboard/internal/config"
+	"switchboard/pkg/types"
+)
+
+// alertRules returns the rules cfg asks for: the built-in ones when DefaultRules is
+// set, with a configured rule of the same name taking a built-in one's place
+func alertRules(cfg *config.AlertingConfig) []alerting.Rule {
+	var rules []alerting.Rule
+	configured := make(map[string]bool, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		configured[rule.Name] = true
+	}
+	if cfg.DefaultRules {
+		for _, rule := range alerting.DefaultRules(cfg.DefaultActions) {
+			if !configured[rule.Name] {
+				rules = append(rules, rule)
+			}
+		}
+	}
+	for _, rule := range cfg.Rules {
+		rules = append(rules, alerting.Rule{
+			Name:       rule.Name,
+			Metric:     rule.Metric,
+			Threshold:  rule.Threshold,
+			For:        rule.For,
+			MinSamples: rule.MinSamples,
+			Actions:    rule.Actions,
+		})
+	}
+	return rules
+}
+
+// alertsBroadcast reports whether any rule cfg runs tells instructors about its alerts
+func alertsBroadcast(cfg *config.AlertingConfig) bool {
+	if !cfg.Enabled() {
+		return false
+	}
+	for _, rule := range alertRules(cfg) {
+		for _, action := range rule.Actions {
+			if action == types.AlertActionBroadcast {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,46 +2,108 @@ package app
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
-	"time"
 
+	"switchboard/internal/alerting"
+	"switchboard/internal/analytics"
 	"switchboard/internal/api"
+	"switchboard/internal/apikey"
+	"switchboard/internal/capacity"
+	"switchboard/internal/clock"
 	"switchboard/internal/config"
 	"switchboard/internal/database"
 	"switchboard/internal/hub"
 	"switchboard/internal/router"
 	"switchboard/internal/session"
+	"switchboard/internal/snapshot"
+	"switchboard/internal/transcript"
 	"switchboard/internal/websocket"
+	"switchboard/migrations"
 	pkgdatabase "switchboard/pkg/database"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
 )
 
 // Application coordinates all system components
 // Clean dependency injection pattern with proper initialization order
 type Application struct {
 	config        *config.Config
-	dbManager     *database.Manager
+	configStore   *config.Store // Runtime-mutable settings; swapped whole by a reload
+	db            interfaces.DatabaseManager
+	dbManager     *database.Manager // nil when WithDatabaseManager supplied another implementation
 	sessionManager *session.Manager
 	registry      *websocket.Registry
 	messageRouter *router.Router
 	messageHub    *hub.Hub
 	apiServer     *api.Server
-	httpServer    *http.Server
+	wsHandler     *websocket.Handler
+	handler       http.Handler       // The mux serving the API, /ws and /metrics
+	httpServer    *http.Server       // nil when built WithHTTPServer(nil)
+	debugServer   *http.Server // nil unless profiling runs on a separate listener
+	transcripts   *transcript.Writer // nil unless config.Transcripts.Dir is set
+	analytics     *analytics.Dispatcher // nil unless config.Analytics.Sink is set
+	snapshots     *snapshot.Writer   // nil unless config.Snapshot.Path is set
+	rollups       *router.ThroughputRollups // Persists the router's per-minute throughput
+	selfTest      *hub.SelfTest      // nil unless config.SelfTest.Interval is set
+	alerts        *alerting.Evaluator // nil unless config.Alerting.Interval is set
+	alertWebhook  *alerting.Webhook   // nil unless config.Alerting.WebhookURL is set too
+	listener      net.Listener       // Bound by Start, or supplied WithListener
+	ownsDB        bool               // False when supplied WithDatabaseManager
+	serveErrors   chan error         // Fatal HTTP server errors after Start returns
 }
 
 // NewApplication creates a new application instance with all components initialized
 // Component initialization follows strict dependency order:
 // Database → Session → Registry → Router → Hub → API → HTTP
-func NewApplication(cfg *config.Config) (*Application, error) {
+// Options replace components with ones the caller built; see Option
+func NewApplication(cfg *config.Config, opts ...Option) (*Application, error) {
 	if cfg == nil {
 		cfg = config.DefaultConfig()
 	}
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.store == nil {
+		o.store = config.NewStore(cfg)
+	}
+	if o.clock == nil {
+		o.clock = clock.Real()
+	}
+	return newApplication(cfg, o)
+}
+
+// NewApplicationWithStore creates an application whose router and WebSocket handler
+// read rate limits and heartbeat timing from store on every use
+// ARCHITECTURAL DISCOVERY: The caller owns the store so it can share it with what
+// starts before the application (the log level filter) and reload it later
+func NewApplicationWithStore(cfg *config.Config, store *config.Store) (*Application, error) {
+	return NewApplication(cfg, WithStore(store))
+}
+
+// NewApplicationWithClock creates an application whose hub, session manager, router
+// and database manager all read time from clk
+// ARCHITECTURAL DISCOVERY: The one place a clock enters the system, so end-to-end
+// tests can drive session durations and rate limit windows with a fake clock
+func NewApplicationWithClock(cfg *config.Config, store *config.Store, clk interfaces.Clock) (*Application, error) {
+	return NewApplication(cfg, WithStore(store), WithClock(clk))
+}
+
+// newApplication builds every component the options did not supply
+func newApplication(cfg *config.Config, o *options) (*Application, error) {
+	store, clk := o.store, o.clock
 	
 	// Validate configuration before component initialization
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
+	if o.skipHTTP && o.listener != nil {
+		return nil, fmt.Errorf("WithListener requires an HTTP server")
+	}
 	
 	// STEP 1: Initialize database manager (foundation layer)
 	dbConfig := &pkgdatabase.Config{
@@ -50,101 +112,564 @@ func NewApplication(cfg *config.Config) (*Application, error) {
 		ConnMaxLifetime: cfg.Database.Timeout,
 		ConnMaxIdleTime: cfg.Database.Timeout / 3,
 		MigrationsPath:  "migrations",
+		ImportBatchSize: cfg.Database.ImportBatchSize,
+		CompactContent:  cfg.Database.CompactContent,
+		NoiseKeys:       cfg.Database.ContentNoiseKeys,
+		DedupThreshold:  cfg.Database.DedupThreshold,
+		
+		LockRecoveryTimeout: cfg.Database.LockRecoveryTimeout,
+	}
+	if cfg.Database.EmbeddedMigrations {
+		dbConfig.Migrations = migrations.Files
 	}
 	
-	dbManager, err := database.NewManager(dbConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database manager: %w", err)
+	// ARCHITECTURAL DISCOVERY: Only interfaces.DatabaseManager is required of a supplied
+	// manager. Optional features are wired when it implements their pkg/interfaces
+	// capability; write health, write stats and message sizes need a *database.Manager
+	db, ownsDB := o.dbManager, o.dbManager == nil
+	dbManager, _ := db.(*database.Manager)
+	if ownsDB {
+		var err error
+		dbManager, err = database.NewManager(dbConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize database manager: %w", err)
+		}
+		db = dbManager
+	}
+	closeDB := func() {
+		if ownsDB {
+			dbManager.Close()
+		}
+	}
+	if clocked, ok := db.(interface{ SetClock(interfaces.Clock) }); ok {
+		clocked.SetClock(clk)
 	}
 	
 	// STEP 1.5: Apply database migrations to ensure schema is up to date
-	migrationManager := pkgdatabase.NewMigrationManager(dbManager.GetDB(), dbConfig.MigrationsPath)
-	if err := migrationManager.ApplyMigrations(); err != nil {
-		dbManager.Close()
-		return nil, fmt.Errorf("failed to apply database migrations: %w", err)
+	// FUNCTIONAL DISCOVERY: Skipped for a supplied manager without a *sql.DB, which owns
+	// its own schema
+	if migratable, ok := db.(interface{ GetDB() *sql.DB }); ok {
+		migrationManager := pkgdatabase.NewMigrationManager(migratable.GetDB(), dbConfig.MigrationsPath)
+		if dbConfig.Migrations != nil {
+			migrationManager = pkgdatabase.NewMigrationManagerFS(migratable.GetDB(), dbConfig.Migrations)
+		}
+		if err := migrationManager.ApplyMigrations(); err != nil {
+			closeDB()
+			return nil, fmt.Errorf("failed to apply database migrations: %w", err)
+		}
+		log.Println("Database migrations applied successfully")
+	}
+	if dbManager != nil {
+		if err := dbManager.LoadLastMaintenance(context.Background()); err != nil {
+			log.Printf("WARNING: Failed to load the last maintenance job: %v", err)
+		}
+	}
+	
+	// STEP 1.6: Import a warm-standby snapshot before sessions are loaded
+	// FUNCTIONAL DISCOVERY: Unlike writing snapshots, a requested restore that fails is
+	// fatal - a standby that silently starts empty would strand every reconnecting client
+	if cfg.Snapshot != nil && cfg.Snapshot.RestoreFrom != "" {
+		restored, err := snapshot.RestoreFile(context.Background(), db, cfg.Snapshot.RestoreFrom)
+		if err != nil {
+			closeDB()
+			return nil, fmt.Errorf("failed to restore snapshot: %w", err)
+		}
+		log.Printf("Restored %d sessions from snapshot %s", restored, cfg.Snapshot.RestoreFrom)
 	}
-	log.Println("Database migrations applied successfully")
 	
 	// STEP 2: Initialize session manager with database dependency
-	sessionManager := session.NewManager(dbManager)
+	sessionManager := session.NewManager(db)
+	sessionManager.SetClock(clk)
+	if cfg.Sessions != nil {
+		sessionManager.SetWarningOffsets(cfg.Sessions.WarningOffsets)
+		sessionManager.SetHookBudget(cfg.Sessions.HookBudget)
+		sessionManager.SetMaxStudents(cfg.Sessions.MaxStudents)
+		sessionManager.SetNamePolicy(session.NamePolicy(cfg.Sessions.NamePolicy))
+		sessionManager.SetInstructorScope(session.InstructorScope(cfg.Sessions.InstructorScope))
+		if cfg.Sessions.Creators == "listed" {
+			sessionManager.SetCreatorAllowlist(cfg.Sessions.CreatorAllowlist)
+		}
+		sessionManager.SetMaxActivePerCreator(cfg.Sessions.MaxActivePerCreator)
+		if activity, ok := db.(interfaces.ActivityStore); ok {
+			sessionManager.SetActivityStore(activity, cfg.Sessions.ActivityInterval)
+		}
+		sessionManager.SetIdleEndAfter(cfg.Sessions.IdleEndAfter)
+	}
 	if err := sessionManager.LoadActiveSessions(context.Background()); err != nil {
 		return nil, fmt.Errorf("failed to load active sessions: %w", err)
 	}
 	
 	// STEP 3: Initialize WebSocket registry for connection tracking
+	// FUNCTIONAL DISCOVERY: A registry supplied WithRegistry hears about every connection
+	// this one registers and unregisters
 	registry := websocket.NewRegistry()
+	if o.registry != nil {
+		mirror := o.registry
+		registry.OnRegister(func(conn *websocket.Connection) {
+			if err := mirror.RegisterConnection(conn); err != nil {
+				log.Printf("WARNING: Supplied registry refused connection for %s: %v", conn.GetUserID(), err)
+			}
+		})
+		registry.OnUnregister(func(conn *websocket.Connection) {
+			mirror.UnregisterConnection(conn)
+		})
+	}
 	
 	// STEP 4: Initialize message router with dependencies
-	messageRouter := router.NewRouter(registry, dbManager)
+	messageRouter := router.NewRouter(registry, db)
+	messageRouter.SetClock(clk)
+	if cfg.Router != nil {
+		messageRouter.SetContentAllowlist(cfg.Router.ContentAllowlist, cfg.Router.StrictContent)
+		messageRouter.SetAttachmentRules(cfg.Router.AttachmentMIMETypes, cfg.Router.MaxAttachmentBytes, cfg.Router.StrictAttachments)
+		messageRouter.SetRejectLateSubmissions(cfg.Router.RejectLateSubmissions)
+		messageRouter.SetDefaultContexts(cfg.Router.DefaultContexts)
+		messageRouter.SetReactionCodes(cfg.Router.ReactionCodes)
+		messageRouter.SetMaxBroadcastRecipients(cfg.Router.MaxBroadcastRecipients)
+	}
+	messageRouter.SetSessionLock(sessionManager.IsSessionLocked, cfg.Sessions != nil && cfg.Sessions.LockExemptAnalytics)
+	messageRouter.SetDelegations(sessionManager.Delegation)
+	messageRouter.SetRecipientRoster(sessionManager.RosterMembership, cfg.Router != nil && cfg.Router.PersistUnknownRecipients)
+	messageRouter.SetRateLimits(store.RateLimits)
+	if preferences, ok := db.(interfaces.PreferenceStore); ok {
+		messageRouter.SetPreferenceStore(preferences)
+	}
+	readReceipts, _ := db.(interfaces.ReadReceiptStore)
+	if readReceipts != nil {
+		messageRouter.SetReadReceiptStore(readReceipts)
+	}
+	if failures, ok := db.(interfaces.DeliveryFailureStore); ok {
+		if cfg.Router != nil {
+			messageRouter.SetDeliveryFailureStore(failures, cfg.Router.DeliverySampleAbove, cfg.Router.DeliverySampleRate, cfg.Router.DeliveryFailureRows)
+		} else {
+			messageRouter.SetDeliveryFailureStore(failures, 0, 0, 0)
+		}
+	}
+	sessionManager.SetConfigSnapshot(messageRouter.ConfigSnapshot)
 	
 	// STEP 5: Initialize message hub for coordination
 	messageHub := hub.NewHub(registry, messageRouter)
+	messageHub.SetClock(clk)
+	if cfg.Router != nil {
+		messageHub.SetWorkers(cfg.Router.HubWorkers)
+		messageHub.SetBroadcastDedupWindow(cfg.Router.BroadcastDedupWindow)
+		messageHub.SetDiagnosticsSampleRate(cfg.Router.DiagnosticsSampleRate)
+	}
+	messageHub.SetInboxPreferences(messageRouter.SetInboxPreferences)
+	messageHub.SetReadReceipts(messageRouter.RecordReadReceipt)
+	messageHub.SetActivityRecorder(sessionManager.TouchSession)
 	
 	// STEP 6: Initialize API server with all business dependencies
-	apiServer := api.NewServer(sessionManager, dbManager, registry)
+	apiServer := api.NewServer(sessionManager, db, registry)
+	sessionManager.SetExpiryNotifier(apiServer)
+	sessionManager.SetDelegationNotifier(apiServer.DelegationExpired)
+	apiServer.SetDelegations(sessionManager)
+	apiServer.SetRoster(sessionManager, registry.StudentPresence)
+	apiServer.SetContentCheck(messageRouter.CheckContent)
+	apiServer.SetSessionActivity(sessionManager.LastActivity)
+	if cfg.Sessions != nil {
+		apiServer.SetOwnerTransferGrace(cfg.Sessions.OwnerTransferGrace)
+		apiServer.SetIdempotentEnd(cfg.Sessions.IdempotentEnd)
+		if editor, ok := db.(interfaces.MessageEditor); ok {
+			apiServer.SetMessageEditor(editor, cfg.Sessions.MessageEditWindow)
+		}
+	}
+	apiServer.SetContentFilterStats(messageRouter.ContentFilterStats)
+	apiServer.SetRoutingLatency(messageRouter.RoutingLatency)
+	apiServer.SetDeliveryStats(messageRouter.DeliveryStats)
+	apiServer.SetThroughputHistory(messageRouter.ThroughputHistory)
+	apiServer.SetAnnouncer(messageRouter.Announce)
+	apiServer.SetInboxPreferences(messageRouter.SetInboxPreferences)
+	if readReceipts != nil {
+		apiServer.SetReadReceipts(readReceipts)
+	}
+	if dbManager != nil {
+		apiServer.SetReports(dbManager.Reports())
+		apiServer.SetWriteStats(dbManager.WriteStats)
+		apiServer.SetMessageSizeStats(dbManager.MessageSizeStats)
+	} else if reports, ok := db.(interfaces.ReportReader); ok {
+		apiServer.SetReports(reports)
+	}
+	
+	// STEP 6.2: Session lifecycle hooks - the hub tells clients when their session ends
+	// and releases what features hold for it, without touching other sessions
+	sessionManager.OnSessionEnded(messageHub.SessionEnded)
+	messageHub.Resources().Register("rate_limits", messageRouter.ReleaseSession, messageRouter.RateLimitedUsers)
+	messageHub.Resources().Register("routing_latency", messageRouter.ReleaseSessionLatency, messageRouter.LatencySessions)
+	messageHub.Resources().Register("delivery_stats", messageRouter.ReleaseDeliveryStats, messageRouter.DeliverySessions)
+	messageHub.Resources().Register("reactions", messageRouter.ReleaseReactions, messageRouter.ReactionTargets)
+	messageHub.Resources().Register("inbox_preferences", messageRouter.ReleaseInboxPreferences, messageRouter.PreferenceSessions)
+	messageHub.Resources().Register("read_receipts", messageRouter.ReleaseReadReceipts, messageRouter.ReadReceiptTargets)
+	apiServer.SetSessionResourceStats(messageHub.Resources().Stats)
+	
+	// STEP 6.3: Senders hear about messages that did not make it into the record
+	if dbManager != nil {
+		dbManager.OnPersistFailure(messageHub.PersistFailed)
+	}
+	
+	// STEP 6.5: Watch disk space and write health; instructors hear about transitions
+	if cfg.Watchdog != nil && dbManager != nil {
+		dbManager.OnHealthChange(apiServer.DatabaseHealthChanged)
+		dbManager.StartWatchdog(database.WatchdogConfig{
+			CheckInterval:       cfg.Watchdog.CheckInterval,
+			MinFreeBytes:        cfg.Watchdog.MinFreeBytes,
+			MinWriteSuccessRate: cfg.Watchdog.MinWriteSuccessRate,
+			RouteOnly:           cfg.Watchdog.RouteOnly,
+		})
+	}
 	
 	// STEP 7: Initialize WebSocket handler
-	wsHandler := websocket.NewHandler(registry, sessionManager, dbManager, messageHub)
+	wsHandler := websocket.NewHandler(registry, sessionManager, db, messageHub)
+	trustedProxies, err := cfg.HTTP.TrustedProxyNets()
+	if err != nil {
+		closeDB()
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	wsHandler.SetTrustedProxies(trustedProxies)
+	wsHandler.SetHistoryPacing(cfg.WebSocket.HistoryBatchSize, cfg.WebSocket.HistoryBatchDelay)
+	wsHandler.SetMaxReplay(cfg.WebSocket.MaxReplayMessages)
+	wsHandler.SetDuplicateUserPolicy(websocket.DuplicateUserPolicy(cfg.WebSocket.DuplicateUserPolicy))
+	wsHandler.SetSessionMismatchPolicy(websocket.SessionMismatchPolicy(cfg.WebSocket.SessionMismatchPolicy))
+	wsHandler.SetHeartbeat(store.Heartbeat)
+	if cfg.Privacy != nil {
+		wsHandler.SetRedactIPs(cfg.Privacy.RedactIPs)
+		scrubPatterns, err := cfg.Privacy.ScrubRegexps()
+		if err != nil {
+			closeDB()
+			return nil, err
+		}
+		apiServer.SetScrubRules(transcript.ScrubRules{Fields: cfg.Privacy.ScrubFields, Patterns: scrubPatterns})
+		if cfg.Privacy.MessageMetadataEndpoint {
+			maskIP := func(ip string) string { return ip }
+			if cfg.Privacy.RedactIPs {
+				maskIP = websocket.RedactIP
+			}
+			apiServer.SetMessageMetadata(maskIP)
+		}
+	}
+	apiServer.SetBandwidth(wsHandler.BandwidthStats)
+	messageHub.Resources().Register("bandwidth", wsHandler.ReleaseBandwidth, wsHandler.BandwidthSessions)
+	
+	// STEP 7.1: Pace the reconnect wave after a restart; the window runs from here
+	if cfg.WebSocket.AdmissionPacingEnabled() {
+		pacer := websocket.NewAdmissionPacer(cfg.WebSocket.AdmissionRate, cfg.WebSocket.AdmissionWindow)
+		wsHandler.SetAdmissionPacer(pacer)
+		apiServer.SetAdmissionStats(pacer.Stats)
+	}
+	
+	// STEP 7.1.5: Let an operator move connections off this node before maintenance
+	drainer := websocket.NewDrainer(registry, cfg.WebSocket.DrainWindow)
+	drainer.SetClock(clk)
+	wsHandler.SetDrainer(drainer)
+	apiServer.SetDrainer(drainer)
+	
+	// STEP 7.2: Session API keys for graders and bots, checked by REST and handshakes
+	// FUNCTIONAL DISCOVERY: Revoking a key also closes the connection it opened, so
+	// a revoked bot stops receiving at once rather than at its next reconnect
+	if keyStore, ok := db.(apikey.Storage); ok {
+		apiKeys := apikey.NewManager(keyStore, sessionManager.IsSessionActive)
+		apiKeys.OnRevoke(func(key *types.APIKey) {
+			if conn, exists := registry.GetUserConnection(key.UserID()); exists {
+				_ = conn.Close()
+			}
+		})
+		apiServer.SetAPIKeys(apiKeys)
+		wsHandler.SetAPIKeys(apiKeys)
+		messageHub.Resources().Register("api_keys", apiKeys.ReleaseSession, apiKeys.CachedKeys)
+	}
+	
+	// STEP 7.5: Attach the optional transcript writer to routing and session lifecycle
+	var transcripts *transcript.Writer
+	if cfg.Transcripts.Enabled() {
+		transcripts = transcript.NewWriter(cfg.Transcripts.Dir, cfg.Transcripts.FlushInterval, cfg.Transcripts.MaxFileBytes)
+		messageRouter.AddPersistedObserver(transcripts.Record)
+		messageHub.Resources().Register("transcripts", func(ended types.Session) {
+			transcripts.SessionEnded(ended.ID)
+		}, transcripts.OpenFiles)
+	}
+	
+	// STEP 7.6: Export routed analytics messages through the configured sink
+	// FUNCTIONAL DISCOVERY: Like transcripts, export is optional - a sink that cannot
+	// be opened is logged and the server runs without it
+	var analyticsDispatcher *analytics.Dispatcher
+	if cfg.Analytics.Enabled() {
+		sink, err := newAnalyticsSink(cfg.Analytics)
+		if err != nil {
+			log.Printf("WARNING: Analytics export disabled: %v", err)
+		} else {
+			analyticsDispatcher = analytics.NewDispatcher(sink, cfg.Analytics.QueueSize, cfg.Analytics.FlushInterval)
+			messageRouter.SetAnalyticsSink(analyticsDispatcher)
+		}
+	}
+	
+	// STEP 7.65: Keep a warm-standby snapshot of active sessions on disk
+	var snapshots *snapshot.Writer
+	if cfg.Snapshot.Enabled() {
+		snapshots = snapshot.NewWriter(cfg.Snapshot.Path, cfg.Snapshot.Interval, db)
+	}
+	
+	// STEP 7.653: Database maintenance, refused while a snapshot is being taken
+	if snapshots != nil && dbManager != nil {
+		dbManager.SetBackupInProgress(snapshots.InProgress)
+	}
+	if maintenance, ok := db.(interfaces.MaintenanceRunner); ok {
+		apiServer.SetMaintenance(maintenance)
+	}
+	if purger, ok := db.(interfaces.SessionPurger); ok {
+		apiServer.SetSessionPurger(purger)
+	}
+	
+	// STEP 7.654: Keep per-minute throughput across restarts for capacity planning
+	var rollups *router.ThroughputRollups
+	if rollupStore, ok := db.(interfaces.RollupStore); ok {
+		rollups = router.NewThroughputRollups(messageRouter, rollupStore, router.DefaultRollupInterval)
+	}
+	
+	// STEP 7.655: Probe routing end to end through a hidden loopback session
+	var selfTest *hub.SelfTest
+	if cfg.SelfTest.Enabled() {
+		selfTest = hub.NewSelfTest(messageHub, cfg.SelfTest.Interval, cfg.SelfTest.FailureThreshold)
+		apiServer.SetSelfTestStatus(selfTest.Status)
+	}
+	
+	// STEP 7.656: Watch write latency, failures and connection drops for alert rules
+	// FUNCTIONAL DISCOVERY: Reads the same counters as /metrics, for deployments that
+	// have nothing scraping it
+	var alerts *alerting.Evaluator
+	var alertWebhook *alerting.Webhook
+	if cfg.Alerting.Enabled() {
+		alerts = alerting.NewEvaluator(alertRules(cfg.Alerting), cfg.Alerting.Interval)
+		alerts.SetClock(clk)
+		if dbManager != nil {
+			alerts.SetSource(types.AlertMetricWriteLatencyP95, alerting.LatencyP95(dbManager.WriteLatencyWindow()))
+			alerts.SetSource(types.AlertMetricWriteFailureRate, alerting.FailureRate(func() (uint64, uint64) {
+				attempts, failures := dbManager.WriteOutcomes()
+				return uint64(attempts), uint64(failures)
+			}))
+		}
+		alerts.SetSource(types.AlertMetricDeliveryFailureRate, alerting.FailureRate(func() (uint64, uint64) {
+			delivered, failed := messageRouter.DeliveryTotals()
+			return delivered + failed, failed
+		}))
+		alerts.SetSource(types.AlertMetricConnectionDrops, alerting.PerMinute(func() uint64 {
+			return uint64(wsHandler.ConnectionDrops())
+		}, clk))
+		alerts.SetAction(types.AlertActionLog, alerting.LogAlert)
+		alerts.SetAction(types.AlertActionBroadcast, func(alert types.Alert) {
+			messageHub.AlertInstructors(alert)
+		})
+		if cfg.Alerting.WebhookURL != "" {
+			alertWebhook = alerting.NewWebhook(cfg.Alerting.WebhookURL)
+			alerts.SetAction(types.AlertActionWebhook, alertWebhook.Notify)
+		}
+	}
+	
+	// STEP 7.66: Serve the autoscaling signal from live connection and write queue state
+	if cfg.Scaling != nil {
+		queue, _ := db.(capacity.QueueSource)
+		estimator := capacity.NewEstimator(registry, queue, capacity.Thresholds{
+			MaxConnections:      cfg.Scaling.MaxConnections,
+			ScaleUpPercent:      cfg.Scaling.ScaleUpPercent,
+			ScaleDownPercent:    cfg.Scaling.ScaleDownPercent,
+			QueueScaleUpPercent: cfg.Scaling.QueueScaleUpPercent,
+		})
+		apiServer.SetScalingHint(estimator.Hint)
+	}
+	
+	// STEP 7.7: Publish what this configuration supports over HTTP and the handshake
+	capabilities := buildCapabilities(cfg)
+	validationRules := buildValidationRules(cfg)
+	capabilities.ValidationRulesHash = validationRules.Hash
+	apiServer.SetCapabilities(capabilities)
+	apiServer.SetValidationRules(validationRules)
+	wsHandler.SetCapabilities(capabilities)
 	
 	// STEP 8: Setup HTTP server with both API and WebSocket endpoints
 	mux := http.NewServeMux()
 	mux.Handle("/api/", apiServer)
 	mux.Handle("/health", apiServer)
+	mux.Handle("/metrics", apiServer)
 	mux.HandleFunc("/ws", wsHandler.HandleWebSocket)
 	
-	httpServer := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", cfg.HTTP.Host, cfg.HTTP.Port),
-		Handler:      mux,
-		ReadTimeout:  cfg.HTTP.ReadTimeout,
-		WriteTimeout: cfg.HTTP.WriteTimeout,
+	// STEP 8.5: Mount profiling handlers only when explicitly enabled
+	// FUNCTIONAL DISCOVERY: A separate localhost listener keeps /debug/ unreachable
+	// from production traffic; without Addr the handlers share the main mux
+	var debugServer *http.Server
+	if cfg.Debug != nil && cfg.Debug.EnableProfiling {
+		debugMux := newDebugMux()
+		if cfg.Debug.Addr != "" {
+			debugServer = &http.Server{
+				Addr:    cfg.Debug.Addr,
+				Handler: debugMux,
+			}
+		} else {
+			mux.Handle("/debug/", debugMux)
+		}
+	}
+	
+	// STEP 8.6: Build the HTTP server unless the caller supplied one or serves the mux itself
+	httpServer := o.httpServer
+	if httpServer == nil && !o.skipHTTP {
+		httpServer = &http.Server{
+			ReadTimeout:  cfg.HTTP.ReadTimeout,
+			WriteTimeout: cfg.HTTP.WriteTimeout,
+		}
+	}
+	if httpServer != nil {
+		if httpServer.Addr == "" {
+			httpServer.Addr = fmt.Sprintf("%s:%d", cfg.HTTP.Host, cfg.HTTP.Port)
+		}
+		if httpServer.Handler == nil {
+			httpServer.Handler = mux
+		}
 	}
 	
 	return &Application{
 		config:         cfg,
+		configStore:    store,
+		db:             db,
 		dbManager:      dbManager,
 		sessionManager: sessionManager,
 		registry:       registry,
 		messageRouter:  messageRouter,
 		messageHub:     messageHub,
 		apiServer:      apiServer,
+		wsHandler:      wsHandler,
+		handler:        mux,
 		httpServer:     httpServer,
+		debugServer:    debugServer,
+		transcripts:    transcripts,
+		snapshots:      snapshots,
+		rollups:        rollups,
+		selfTest:       selfTest,
+		alerts:         alerts,
+		alertWebhook:   alertWebhook,
+		analytics:      analyticsDispatcher,
+		listener:       o.listener,
+		ownsDB:         ownsDB,
+		serveErrors:    make(chan error, 1),
 	}, nil
 }
 
 // Start begins application execution
 // Startup coordination ensures all components ready before serving
 // Hub starts first to handle messages, then HTTP server accepts connections
+// FUNCTIONAL DISCOVERY: Start returns only once the listener is bound and accepting,
+// so a nil return is a real readiness signal for service managers
+// FUNCTIONAL DISCOVERY: Without an HTTP server Start only starts the background
+// components; the caller serves Handler and must still call Stop
 func (app *Application) Start(ctx context.Context) error {
-	log.Printf("Starting Switchboard application on %s", app.httpServer.Addr)
+	log.Printf("Starting Switchboard application on %s", app.Addr())
 	
 	// STEP 1: Start message hub (background message processing)
 	if err := app.messageHub.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start message hub: %w", err)
 	}
 	
-	// STEP 2: Start HTTP server (accepts connections)
-	serverErrCh := make(chan error, 1)
-	go func() {
-		if err := app.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			serverErrCh <- fmt.Errorf("HTTP server error: %w", err)
+	// STEP 1.5: Start transcript writer
+	// FUNCTIONAL DISCOVERY: Transcripts are optional - a bad directory is logged and
+	// the server runs without them rather than refusing to start
+	if app.transcripts != nil {
+		if err := app.transcripts.Start(ctx); err != nil {
+			log.Printf("WARNING: Session transcripts disabled: %v", err)
+			app.transcripts = nil
 		}
-	}()
+	}
 	
-	// Verify server is ready before returning
-	select {
-	case err := <-serverErrCh:
-		// Cleanup on startup failure
+	// STEP 1.6: Start analytics export
+	if app.analytics != nil {
+		if err := app.analytics.Start(ctx); err != nil {
+			log.Printf("WARNING: Analytics export disabled: %v", err)
+		}
+	}
+	
+	// STEP 1.7: Start periodic state snapshots
+	if app.snapshots != nil {
+		if err := app.snapshots.Start(ctx); err != nil {
+			log.Printf("WARNING: State snapshots disabled: %v", err)
+			app.snapshots = nil
+		}
+	}
+	
+	// STEP 1.75: Restore the last day of throughput and start sampling it
+	if app.rollups != nil {
+		if err := app.rollups.Start(ctx); err != nil {
+			log.Printf("WARNING: Throughput rollups disabled: %v", err)
+		}
+	}
+	
+	// STEP 1.8: Start routing self-test probes once the hub can route them
+	// FUNCTIONAL DISCOVERY: Optional like snapshots - a loopback pair that cannot be
+	// opened is logged and the server runs without it
+	if app.selfTest != nil {
+		if err := app.selfTest.Start(ctx); err != nil {
+			log.Printf("WARNING: Routing self-test disabled: %v", err)
+			app.selfTest = nil
+		}
+	}
+	
+	// STEP 1.9: Start evaluating alert rules; the first check is one interval in
+	if app.alerts != nil {
+		if err := app.alerts.Start(ctx); err != nil {
+			log.Printf("WARNING: Alerting disabled: %v", err)
+			app.alerts = nil
+		}
+	}
+	
+	// Context cancelled during startup
+	if err := ctx.Err(); err != nil {
 		app.messageHub.Stop()
 		return err
-	case <-time.After(100 * time.Millisecond):
-		// Server started successfully
-		log.Printf("Switchboard application started successfully")
-		return nil
-	case <-ctx.Done():
-		// Context cancelled during startup
-		app.messageHub.Stop()
-		return ctx.Err()
 	}
+	
+	// STEP 2: Bind the listener synchronously so address errors surface here
+	// TECHNICAL DISCOVERY: The kernel queues connections as soon as Listen returns,
+	// before Serve runs - no startup sleep is needed
+	if app.httpServer != nil {
+		listener := app.listener
+		if listener == nil {
+			var err error
+			listener, err = net.Listen("tcp", app.httpServer.Addr)
+			if err != nil {
+				// Cleanup on startup failure
+				app.messageHub.Stop()
+				return fmt.Errorf("HTTP server error: %w", err)
+			}
+			app.listener = listener
+		}
+		
+		go func() {
+			if err := app.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+				app.serveErrors <- fmt.Errorf("HTTP server error: %w", err)
+			}
+		}()
+	}
+	
+	// STEP 2.5: Start profiling listener when configured separately
+	// TECHNICAL DISCOVERY: Debug listener failures are logged, never fatal
+	if app.debugServer != nil {
+		go func() {
+			log.Printf("Profiling endpoints available on %s/debug/", app.debugServer.Addr)
+			if err := app.debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("Debug server error: %v", err)
+			}
+		}()
+	}
+	
+	log.Printf("Switchboard application started successfully on %s", app.Addr())
+	return nil
+}
+
+// Errors delivers fatal HTTP server errors that occur after Start has returned
+func (app *Application) Errors() <-chan error {
+	return app.serveErrors
+}
+
+// HealthCheck verifies the application can still serve requests
+// FUNCTIONAL DISCOVERY: Drives service-manager keepalives. Only database
+// connectivity counts - degraded persistence (e.g. a full disk) keeps live routing
+// up, and a restart would not free any space
+func (app *Application) HealthCheck(ctx context.Context) error {
+	return app.db.HealthCheck(ctx)
 }
 
 // Stop gracefully shuts down the application
@@ -154,8 +679,33 @@ func (app *Application) Stop(ctx context.Context) error {
 	log.Printf("Shutting down Switchboard application")
 	
 	// STEP 1: Stop accepting new connections
-	if err := app.httpServer.Shutdown(ctx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
+	if app.httpServer != nil {
+		if err := app.httpServer.Shutdown(ctx); err != nil {
+			log.Printf("HTTP server shutdown error: %v", err)
+		}
+	}
+	if app.debugServer != nil {
+		if err := app.debugServer.Shutdown(ctx); err != nil {
+			log.Printf("Debug server shutdown error: %v", err)
+		}
+	}
+	
+	// STEP 1.5: Stop probing before the hub the probes go through
+	if app.selfTest != nil {
+		if err := app.selfTest.Stop(); err != nil {
+			log.Printf("Self-test shutdown error: %v", err)
+		}
+	}
+	
+	// STEP 1.6: Stop alerting before the hub that broadcasts alerts, then post the
+	// alerts already raised to the webhook within the shutdown budget
+	if app.alerts != nil {
+		if err := app.alerts.Stop(); err != nil {
+			log.Printf("Alert evaluator shutdown error: %v", err)
+		}
+	}
+	if app.alertWebhook != nil {
+		app.alertWebhook.Close(ctx)
 	}
 	
 	// STEP 2: Stop message processing
@@ -163,16 +713,103 @@ func (app *Application) Stop(ctx context.Context) error {
 		log.Printf("Message hub shutdown error: %v", err)
 	}
 	
-	// STEP 3: Close database connections
-	if err := app.dbManager.Close(); err != nil {
-		log.Printf("Database shutdown error: %v", err)
+	// STEP 2.4: Write session activity and delivery failures recorded since the last flush
+	app.sessionManager.FlushActivity()
+	app.messageRouter.FlushDeliveryFailures()
+	
+	// STEP 2.5: Flush transcripts after the hub stops producing messages
+	if app.transcripts != nil {
+		if err := app.transcripts.Stop(); err != nil {
+			log.Printf("Transcript writer shutdown error: %v", err)
+		}
+	}
+	
+	// STEP 2.6: Export queued analytics after the hub stops producing messages
+	if app.analytics != nil {
+		if err := app.analytics.Stop(); err != nil {
+			log.Printf("Analytics dispatcher shutdown error: %v", err)
+		}
+	}
+	
+	// STEP 2.7: Write a final snapshot while the database is still open
+	if app.snapshots != nil {
+		if err := app.snapshots.Stop(); err != nil {
+			log.Printf("Snapshot writer shutdown error: %v", err)
+		}
+	}
+	
+	// STEP 2.8: Flush throughput since the last rollup while the database is still open
+	if app.rollups != nil {
+		app.rollups.Stop()
+	}
+	
+	// STEP 3: Close database connections, unless the caller supplied the manager
+	if app.ownsDB {
+		if err := app.dbManager.Close(); err != nil {
+			log.Printf("Database shutdown error: %v", err)
+		}
 	}
 	
 	log.Printf("Switchboard application shutdown complete")
 	return nil
 }
 
-// GetAddr returns the server address for external connections
-func (app *Application) GetAddr() string {
+// newAnalyticsSink builds the sink selected by cfg.Sink
+func newAnalyticsSink(cfg *config.AnalyticsConfig) (interfaces.AnalyticsSink, error) {
+	switch cfg.Sink {
+	case "file":
+		sink, err := analytics.NewFileSink(cfg.FilePath)
+		if err != nil {
+			return nil, err
+		}
+		return sink, nil
+	case "http":
+		return analytics.NewHTTPSink(cfg.URL, cfg.BatchSize, cfg.MaxRetries), nil
+	default:
+		return analytics.NoopSink{}, nil
+	}
+}
+
+// Database returns the application's database manager; nil when WithDatabaseManager
+// supplied another interfaces.DatabaseManager implementation
+// FUNCTIONAL DISCOVERY: Embedded test servers read persisted state through the same
+// manager as the application, never a second handle on the database
+func (app *Application) Database() *database.Manager {
+	return app.dbManager
+}
+
+// ConfigStore returns the store holding the application's runtime-mutable settings
+func (app *Application) ConfigStore() *config.Store {
+	return app.configStore
+}
+
+// Addr returns the server address for external connections
+// TECHNICAL DISCOVERY: After Start this is the bound address, so tests can configure
+// port 0 and read back the port the kernel assigned
+// An application without an HTTP server has no address
+func (app *Application) Addr() string {
+	if app.listener != nil {
+		return app.listener.Addr().String()
+	}
+	if app.httpServer == nil {
+		return ""
+	}
 	return app.httpServer.Addr
+}
+
+// Handler returns the mux serving /api/, /health, /metrics and /ws
+// FUNCTIONAL DISCOVERY: For callers that built the application WithHTTPServer(nil) and
+// serve it from their own server, whole or under a prefix with http.StripPrefix
+func (app *Application) Handler() http.Handler {
+	return app.handler
+}
+
+// APIServer returns the REST API handler, for callers mounting routes one by one
+func (app *Application) APIServer() *api.Server {
+	return app.apiServer
+}
+
+// WebSocketHandler returns the handler whose HandleWebSocket upgrades client connections
+func (app *Application) WebSocketHandler() *websocket.Handler {
+	return app.wsHandler
 }
\ No newline at end of file
@@ -0,0 +1,114 @@
+package app
+
+import (
+	"switchboard/internal/config"
+	"switchboard/internal/router"
+	"switchboard/internal/session"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// buildCapabilities derives the capabilities document from configuration
+// ARCHITECTURAL DISCOVERY: Features that depend on config are read from the same
+// sections NewApplication uses to wire them, so the document cannot advertise a
+// component that was never started
+// TECHNICAL DISCOVERY: Built once at startup; rate limits changed by a reload apply
+// to routing but are not reflected here until the next restart
+func buildCapabilities(cfg *config.Config) *types.Capabilities {
+	effective := config.Effective(cfg)
+	capabilities := &types.Capabilities{
+		ProtocolVersions: []int{types.ProtocolVersion},
+		Encodings:        []string{websocket.SubprotocolJSON, websocket.SubprotocolMsgpack},
+		Features: map[string]bool{
+			types.FeatureMsgpack:               true,
+			types.FeatureAcks:                  false,
+			types.FeatureSequenceNumbers:       false,
+			types.FeatureResumeTokens:          false,
+			types.FeaturePolls:                 false,
+			types.FeatureSessionDuration:       true,
+			types.FeatureMessageImport:         true,
+			types.FeaturePersistFailureNotices: true,
+			types.FeatureTranscripts:           cfg.Transcripts.Enabled(),
+			types.FeatureAnalyticsExport:       cfg.Analytics.Enabled(),
+			types.FeaturePersistenceWatchdog:   cfg.Watchdog != nil,
+			types.FeatureRouteOnlyDegradation:  cfg.Watchdog != nil && cfg.Watchdog.RouteOnly,
+			types.FeatureSessionLock:           true,
+			types.FeatureMessageAnnotations:    true,
+			types.FeatureBroadcastDedup:        cfg.Router != nil && cfg.Router.BroadcastDedupWindow > 0,
+			types.FeatureDiagnosticsOverlay:    cfg.Router == nil || cfg.Router.DiagnosticsSampleRate > 0,
+			types.FeatureAPIKeys:               true,
+			types.FeatureReactions:             true,
+			types.FeatureSenderOrdering:        true,
+			types.FeatureTargetedBroadcasts:    true,
+			types.FeatureJoinCodes:             true,
+			types.FeatureSystemAlerts:          alertsBroadcast(cfg.Alerting),
+		},
+		Limits: types.CapabilityLimits{
+			MaxContentBytes:           types.MaxContentBytes,
+			RateLimitMessages:         effective.RateLimitMessages,
+			RateLimitWindowSeconds:    int(effective.RateLimitWindow.Seconds()),
+			MaxSessionDurationMinutes: session.MaxDurationMinutes,
+			MaxStudentsPerSession:     maxStudents(cfg),
+			MaxBroadcastRecipients:    maxBroadcastRecipients(cfg),
+		},
+		Routing: router.RoutingTable(defaultContexts(cfg)),
+	}
+	if cfg.Router != nil && len(cfg.Router.AttachmentMIMETypes) > 0 {
+		capabilities.Limits.AttachmentMIMETypes = cfg.Router.AttachmentMIMETypes
+		capabilities.Limits.MaxAttachmentBytes = cfg.Router.MaxAttachmentBytes
+		if capabilities.Limits.MaxAttachmentBytes <= 0 {
+			capabilities.Limits.MaxAttachmentBytes = router.DefaultMaxAttachmentBytes
+		}
+	}
+	return capabilities
+}
+
+// buildValidationRules derives the client validation descriptor from configuration
+// FUNCTIONAL DISCOVERY: Content allowlists and attachment rules are only described
+// while configured, as the router only enforces them then
+func buildValidationRules(cfg *config.Config) *types.ValidationRules {
+	rules := types.NewValidationRules(router.RoutingTable(defaultContexts(cfg)))
+	rules.MaxBroadcastRecipients = maxBroadcastRecipients(cfg)
+	if cfg.Router != nil {
+		for i := range rules.MessageTypes {
+			rules.MessageTypes[i].ContentKeys = cfg.Router.ContentAllowlist[rules.MessageTypes[i].Type]
+		}
+		rules.StrictContent = cfg.Router.StrictContent && len(cfg.Router.ContentAllowlist) > 0
+		if len(cfg.Router.AttachmentMIMETypes) > 0 {
+			rules.Attachments = &types.AttachmentRule{
+				MIMETypes: cfg.Router.AttachmentMIMETypes,
+				MaxBytes:  cfg.Router.MaxAttachmentBytes,
+				Strict:    cfg.Router.StrictAttachments,
+			}
+			if rules.Attachments.MaxBytes <= 0 {
+				rules.Attachments.MaxBytes = router.DefaultMaxAttachmentBytes
+			}
+		}
+	}
+	rules.Seal()
+	return rules
+}
+
+// maxStudents returns the configured roster cap, the session default without a sessions section
+func maxStudents(cfg *config.Config) int {
+	if cfg.Sessions == nil || cfg.Sessions.MaxStudents <= 0 {
+		return session.DefaultMaxStudents
+	}
+	return cfg.Sessions.MaxStudents
+}
+
+// maxBroadcastRecipients returns the configured to_users cap, the router default without one
+func maxBroadcastRecipients(cfg *config.Config) int {
+	if cfg.Router == nil || cfg.Router.MaxBroadcastRecipients <= 0 {
+		return router.DefaultMaxBroadcastRecipients
+	}
+	return cfg.Router.MaxBroadcastRecipients
+}
+
+// defaultContexts returns the configured per-type default contexts, nil without a router section
+func defaultContexts(cfg *config.Config) map[string]string {
+	if cfg.Router == nil {
+		return nil
+	}
+	return cfg.Router.DefaultContexts
+}
@@ -0,0 +1,22 @@
+package app
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+)
+
+// newDebugMux builds the /debug/ handler tree for runtime profiling
+// ARCHITECTURAL DISCOVERY: Explicit registration instead of the net/http/pprof
+// init side effect keeps profiling off http.DefaultServeMux and out of production
+// traffic unless config.Debug.EnableProfiling is set
+func newDebugMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
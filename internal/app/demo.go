@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/url"
+
+	"switchboard/pkg/types"
+)
+
+// The session -bootstrap-demo creates
+const (
+	demoSessionName     = "Switchboard demo"
+	demoInstructorID    = "demo-instructor"
+	demoDurationMinutes = 60
+)
+
+var demoStudentIDs = []string{"demo-student-1", "demo-student-2", "demo-student-3"}
+
+// Words join codes are made from; short, unambiguous when read aloud
+var (
+	joinCodeAdjectives = []string{"amber", "brave", "calm", "clever", "eager", "gentle", "happy", "lively", "merry", "quiet", "rapid", "sunny", "swift", "tidy", "vivid", "witty"}
+	joinCodeAnimals    = []string{"badger", "falcon", "gecko", "heron", "koala", "lemur", "lynx", "marten", "otter", "panda", "puffin", "robin", "tapir", "walrus", "wombat", "yak"}
+)
+
+// BootstrapDemo makes sure a first start has a demo session and prints how to join it
+// FUNCTIONAL DISCOVERY: Idempotent across restarts - an active demo session is found by
+// its metadata and its instructions printed again; a database holding any other
+// session, or a demo that has already ended, is left alone
+// ARCHITECTURAL DISCOVERY: Runs after Start so the printed URLs carry the bound
+// address, and goes through the session manager so the hour's duration limit and the
+// session's cache entry are set up as for any other session
+func (app *Application) BootstrapDemo(ctx context.Context, out io.Writer) error {
+	session, err := app.demoSession(ctx)
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		log.Printf("Demo bootstrap skipped: the database already has sessions")
+		return nil
+	}
+	printDemoInstructions(out, app.Addr(), session)
+	return nil
+}
+
+// demoSession returns the active demo session, creating it when the database is empty;
+// nil when the database has other sessions
+func (app *Application) demoSession(ctx context.Context) (*types.Session, error) {
+	if app.dbManager == nil {
+		return nil, fmt.Errorf("demo bootstrap needs the built-in database manager")
+	}
+	ids, err := app.db.ActiveSessionsByMetadata(ctx, map[string]string{types.MetadataKeyDemo: "true"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up demo session: %w", err)
+	}
+	if len(ids) > 0 {
+		return app.sessionManager.GetSession(ctx, ids[0])
+	}
+
+	count, err := app.dbManager.SessionCount(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, nil
+	}
+
+	created, err := app.sessionManager.CreateSession(ctx, demoSessionName, demoInstructorID, demoStudentIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create demo session: %w", err)
+	}
+	// An empty database has no other active join codes, so the first code is unique
+	demo, code := "true", newJoinCode()
+	session, err := app.sessionManager.UpdateSessionMetadata(ctx, created.ID, map[string]*string{
+		types.MetadataKeyDemo:     &demo,
+		types.MetadataKeyJoinCode: &code,
+	})
+	if err == nil {
+		session, err = app.sessionManager.SetSessionDuration(ctx, created.ID, demoDurationMinutes)
+	}
+	if err != nil {
+		// An untagged session would never be found again, so end it rather than leave
+		// an unlimited session behind
+		if endErr := app.sessionManager.EndSession(ctx, created.ID); endErr != nil {
+			log.Printf("Failed to end untagged demo session %s: %v", created.ID, endErr)
+		}
+		return nil, fmt.Errorf("failed to tag demo session: %w", err)
+	}
+	log.Printf("Created demo session: id=%s join_code=%s", session.ID, code)
+	return session, nil
+}
+
+// newJoinCode returns a code such as "calm-otter-42"
+// TECHNICAL DISCOVERY: 16 x 16 x 100 codes; enough for a demo on an empty database,
+// not for guessing-resistant access to real classes
+func newJoinCode() string {
+	pick := func(n int) int {
+		value, err := rand.Int(rand.Reader, big.NewInt(int64(n)))
+		if err != nil {
+			return 0
+		}
+		return int(value.Int64())
+	}
+	return fmt.Sprintf("%s-%s-%d", joinCodeAdjectives[pick(len(joinCodeAdjectives))], joinCodeAnimals[pick(len(joinCodeAnimals))], pick(100))
+}
+
+// printDemoInstructions writes ready-to-paste WebSocket URLs and a REST example
+func printDemoInstructions(out io.Writer, addr string, session *types.Session) {
+	host := demoHost(addr)
+	code := session.Metadata[types.MetadataKeyJoinCode]
+	joinURL := func(userID, role string) string {
+		query := url.Values{"user_id": {userID}, "role": {role}, "join_code": {code}}
+		return fmt.Sprintf("ws://%s/ws?%s", host, query.Encode())
+	}
+
+	fmt.Fprintf(out, "\nDemo session %q is ready (join code %s)\n", session.Name, code)
+	if expiresAt, limited := session.ExpiresAt(); limited {
+		fmt.Fprintf(out, "It ends on its own at %s\n", expiresAt.Format("15:04 MST"))
+	}
+	fmt.Fprintf(out, "\nInstructor:\n  %s\n\nStudents:\n", joinURL(demoInstructorID, "instructor"))
+	for _, studentID := range session.StudentIDs {
+		fmt.Fprintf(out, "  %s\n", joinURL(studentID, "student"))
+	}
+	stats := url.Values{"instructor_id": {demoInstructorID}}
+	fmt.Fprintf(out, "\nREST API:\n  curl 'http://%s/api/sessions/%s/stats?%s'\n\n", host, session.ID, stats.Encode())
+}
+
+// demoHost returns addr with a wildcard listen host replaced by localhost
+func demoHost(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if ip := net.ParseIP(host); host == "" || ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
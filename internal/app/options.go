@@ -0,0 +1,72 @@
+package app
+
+import (
+	"net"
+	"net/http"
+
+	"switchboard/internal/config"
+	"switchboard/pkg/interfaces"
+)
+
+// Option replaces one component NewApplication would otherwise build itself
+// ARCHITECTURAL DISCOVERY: Lets another Go service embed the hub and router - sharing
+// its database manager or connection registry, or serving the API and WebSocket
+// handlers from its own mux - without forking the wiring in NewApplication
+type Option func(*options)
+
+// options are the components callers supplied; nil fields are built from the config
+type options struct {
+	store      *config.Store
+	clock      interfaces.Clock
+	dbManager  interfaces.DatabaseManager
+	registry   interfaces.ConnectionRegistry
+	httpServer *http.Server
+	skipHTTP   bool // WithHTTPServer(nil)
+	listener   net.Listener
+}
+
+// WithStore has the router and WebSocket handler read rate limits and heartbeat timing
+// from store, which the caller can share and reload
+func WithStore(store *config.Store) Option {
+	return func(o *options) { o.store = store }
+}
+
+// WithClock has the hub, session manager, router and database manager read time from clk
+func WithClock(clk interfaces.Clock) Option {
+	return func(o *options) { o.clock = clk }
+}
+
+// WithDatabaseManager uses an open database manager instead of opening cfg.Database.Path
+// FUNCTIONAL DISCOVERY: Sessions, messages and history need only interfaces.DatabaseManager.
+// Read receipts, inbox preferences, message edits, reports, maintenance, purges, API
+// keys and throughput rollups are served when dbManager also implements their
+// pkg/interfaces or package interface; write health, write stats and message sizes need
+// a *database.Manager. Migrations are applied when it exposes GetDB() *sql.DB. The
+// caller owns it - Stop leaves it open
+func WithDatabaseManager(dbManager interfaces.DatabaseManager) Option {
+	return func(o *options) { o.dbManager = dbManager }
+}
+
+// WithRegistry reports every WebSocket connection the application registers and
+// unregisters to registry, so the caller can see and close the connections it serves
+func WithRegistry(registry interfaces.ConnectionRegistry) Option {
+	return func(o *options) { o.registry = registry }
+}
+
+// WithHTTPServer serves through server instead of one built from cfg.HTTP
+// FUNCTIONAL DISCOVERY: A nil server skips serving altogether - Start binds nothing and
+// the caller mounts Handler, APIServer or WebSocketHandler on its own mux. A server
+// without a Handler is given the application's mux, and one without an Addr listens
+// on cfg.HTTP's host and port
+func WithHTTPServer(server *http.Server) Option {
+	return func(o *options) {
+		o.httpServer = server
+		o.skipHTTP = server == nil
+	}
+}
+
+// WithListener has Start serve on listener instead of binding the server's address
+// TECHNICAL DISCOVERY: The HTTP server closes the listener when Stop shuts it down
+func WithListener(listener net.Listener) Option {
+	return func(o *options) { o.listener = listener }
+}
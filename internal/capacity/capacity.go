@@ -0,0 +1,105 @@
+package capacity
+
+import (
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// cacheTTL is how long a computed hint is reused
+// TECHNICAL DISCOVERY: Autoscalers poll every few seconds, often from more than one
+// controller; a second of reuse keeps each poll to a map read
+const cacheTTL = time.Second
+
+// ConnectionSource reports live connection counts; implemented by websocket.Registry
+type ConnectionSource interface {
+	GetStats() map[string]int
+}
+
+// QueueSource reports database write queue occupancy; implemented by database.Manager
+type QueueSource interface {
+	WriteQueueDepth() (depth, capacity int)
+}
+
+// Thresholds decide when an instance suggests adding or removing a replica
+// FUNCTIONAL DISCOVERY: Percentages are of MaxConnections and of write queue
+// capacity. Scaling up needs either signal; scaling down needs both to be low
+type Thresholds struct {
+	MaxConnections      int
+	ScaleUpPercent      float64 // Connection load at or above this suggests +1
+	ScaleDownPercent    float64 // Connection load at or below this suggests -1
+	QueueScaleUpPercent float64 // Write queue use at or above this suggests +1
+}
+
+// Estimator computes scaling hints from live connection and queue state
+// ARCHITECTURAL DISCOVERY: Reads only in-memory counters - no database queries - so
+// a busy autoscaler cannot add load to the instance it is measuring
+type Estimator struct {
+	connections ConnectionSource
+	queue       QueueSource
+	thresholds  Thresholds
+	now         func() time.Time // Replaced in tests
+
+	mu     sync.Mutex
+	cached types.ScalingHint
+}
+
+// NewEstimator creates an estimator over the given sources; a nil queue leaves write
+// queue use out of the hint
+func NewEstimator(connections ConnectionSource, queue QueueSource, thresholds Thresholds) *Estimator {
+	return &Estimator{
+		connections: connections,
+		queue:       queue,
+		thresholds:  thresholds,
+		now:         time.Now,
+	}
+}
+
+// Hint returns the current scaling hint, recomputed at most once per cacheTTL
+func (e *Estimator) Hint() types.ScalingHint {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	if !e.cached.ComputedAt.IsZero() && now.Sub(e.cached.ComputedAt) < cacheTTL {
+		return e.cached
+	}
+
+	stats := e.connections.GetStats()
+	hint := types.ScalingHint{
+		TotalConnections: stats["total_connections"],
+		MaxConnections:   e.thresholds.MaxConnections,
+		ActiveSessions:   stats["active_sessions"],
+		ComputedAt:       now,
+	}
+	if e.queue != nil {
+		if depth, capacity := e.queue.WriteQueueDepth(); capacity > 0 {
+			hint.WriteQueuePercent = percent(depth, capacity)
+		}
+	}
+	hint.SuggestedReplicaDelta = e.delta(percent(hint.TotalConnections, hint.MaxConnections), hint.WriteQueuePercent)
+
+	e.cached = hint
+	return hint
+}
+
+// delta turns connection load and queue use into a replica suggestion
+func (e *Estimator) delta(load, queue float64) int {
+	switch {
+	case load >= e.thresholds.ScaleUpPercent || queue >= e.thresholds.QueueScaleUpPercent:
+		return 1
+	case load <= e.thresholds.ScaleDownPercent && queue <= e.thresholds.ScaleDownPercent:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// percent returns part as a percentage of whole, rounded to one decimal place
+func percent(part, whole int) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return float64(part*1000/whole) / 10
+}
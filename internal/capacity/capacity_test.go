@@ -0,0 +1,86 @@
+package capacity
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeConnections struct {
+	total, sessions int
+	calls           int
+}
+
+func (f *fakeConnections) GetStats() map[string]int {
+	f.calls++
+	return map[string]int{"total_connections": f.total, "active_sessions": f.sessions}
+}
+
+type fakeQueue struct {
+	depth, capacity int
+}
+
+func (f *fakeQueue) WriteQueueDepth() (int, int) {
+	return f.depth, f.capacity
+}
+
+var testThresholds = Thresholds{
+	MaxConnections:      100,
+	ScaleUpPercent:      80,
+	ScaleDownPercent:    20,
+	QueueScaleUpPercent: 50,
+}
+
+func TestEstimator_Hint(t *testing.T) {
+	tests := []struct {
+		name        string
+		connections int
+		queueDepth  int
+		wantDelta   int
+		wantQueue   float64
+	}{
+		{"busy connections", 85, 0, 1, 0},
+		{"busy write queue", 40, 60, 1, 60},
+		{"steady", 50, 10, 0, 10},
+		{"idle", 10, 0, -1, 0},
+		{"few connections but queue backed up", 10, 30, 0, 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connections := &fakeConnections{total: tt.connections, sessions: 3}
+			estimator := NewEstimator(connections, &fakeQueue{depth: tt.queueDepth, capacity: 100}, testThresholds)
+
+			hint := estimator.Hint()
+			if hint.SuggestedReplicaDelta != tt.wantDelta {
+				t.Errorf("Expected delta %d, got %d", tt.wantDelta, hint.SuggestedReplicaDelta)
+			}
+			if hint.WriteQueuePercent != tt.wantQueue {
+				t.Errorf("Expected queue %.1f%%, got %.1f%%", tt.wantQueue, hint.WriteQueuePercent)
+			}
+			if hint.TotalConnections != tt.connections || hint.MaxConnections != 100 || hint.ActiveSessions != 3 {
+				t.Errorf("Unexpected counts in hint: %+v", hint)
+			}
+		})
+	}
+}
+
+func TestEstimator_CachesForOneSecond(t *testing.T) {
+	connections := &fakeConnections{total: 10}
+	estimator := NewEstimator(connections, &fakeQueue{capacity: 100}, testThresholds)
+	now := time.Now()
+	estimator.now = func() time.Time { return now }
+
+	estimator.Hint()
+	connections.total = 90
+	if hint := estimator.Hint(); hint.TotalConnections != 10 {
+		t.Errorf("Expected cached hint within the TTL, got %d connections", hint.TotalConnections)
+	}
+
+	now = now.Add(cacheTTL)
+	if hint := estimator.Hint(); hint.TotalConnections != 90 || hint.SuggestedReplicaDelta != 1 {
+		t.Errorf("Expected a fresh hint after the TTL, got %+v", hint)
+	}
+	if connections.calls != 2 {
+		t.Errorf("Expected 2 registry reads, got %d", connections.calls)
+	}
+}
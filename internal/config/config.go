@@ -3,31 +3,89 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
+
+	"switchboard/pkg/types"
 )
 
 // ARCHITECTURAL DISCOVERY: Configuration layer serves as system-wide settings coordinator
 // Clean separation between configuration management and business logic
 type Config struct {
-	Database  *DatabaseConfig  `json:"database"`
-	HTTP      *HTTPConfig      `json:"http"`
-	WebSocket *WebSocketConfig `json:"websocket"`
+	Database    *DatabaseConfig    `json:"database"`
+	HTTP        *HTTPConfig        `json:"http"`
+	WebSocket   *WebSocketConfig   `json:"websocket"`
+	Debug       *DebugConfig       `json:"debug"`
+	Privacy     *PrivacyConfig     `json:"privacy"`
+	Transcripts *TranscriptsConfig `json:"transcripts"`
+	Analytics   *AnalyticsConfig   `json:"analytics"`
+	Sessions    *SessionsConfig    `json:"sessions"`
+	Watchdog    *WatchdogConfig    `json:"watchdog"`
+	Logging     *LoggingConfig     `json:"logging"`
+	Snapshot    *SnapshotConfig    `json:"snapshot"`
+	Router      *RouterConfig      `json:"router"`
+	Scaling     *ScalingConfig     `json:"scaling"`
+	SelfTest    *SelfTestConfig    `json:"self_test"`
+	Alerting    *AlertingConfig    `json:"alerting"`
 }
 
 // FUNCTIONAL DISCOVERY: Database configuration supports SQLite optimizations
+// CompactContent canonicalizes message content before it is stored; turn it off for
+// deployments that must store content byte-exact as serialized by the server
+// DedupThreshold is the stored content size in bytes at which identical bodies are kept
+// once and shared between messages; 0 stores every message's content inline
+// EmbeddedMigrations applies the migrations compiled into the binary instead of reading
+// the migrations directory relative to the working directory
+// LockRecoveryTimeout is how long startup keeps retrying a database left locked by an
+// unclean shutdown; 0 fails at once, leaving the retry to the process supervisor
 type DatabaseConfig struct {
-	Path    string        `json:"path"`
-	Timeout time.Duration `json:"timeout"`
+	Path                string        `json:"path"` // ":memory:" keeps the database in process memory
+	Timeout             time.Duration `json:"timeout"`
+	ImportBatchSize     int           `json:"import_batch_size"` // 0 uses the database layer default
+	CompactContent      bool          `json:"compact_content"`
+	ContentNoiseKeys    []string      `json:"content_noise_keys"` // Top-level content keys dropped when compacting, e.g. "ui_state"
+	DedupThreshold      int           `json:"dedup_threshold"`
+	EmbeddedMigrations  bool          `json:"embedded_migrations"`
+	LockRecoveryTimeout time.Duration `json:"lock_recovery_timeout"`
 }
 
 // FUNCTIONAL DISCOVERY: HTTP configuration balances performance and reliability
 type HTTPConfig struct {
-	Port         int           `json:"port"`
-	ReadTimeout  time.Duration `json:"read_timeout"`
-	WriteTimeout time.Duration `json:"write_timeout"`
-	Host         string        `json:"host"`
+	Port           int           `json:"port"`
+	ReadTimeout    time.Duration `json:"read_timeout"`
+	WriteTimeout   time.Duration `json:"write_timeout"`
+	Host           string        `json:"host"`
+	TrustedProxies []string      `json:"trusted_proxies"` // IPs or CIDRs allowed to set X-Forwarded-For/-Proto
+}
+
+// TrustedProxyNets parses TrustedProxies into networks
+// TECHNICAL DISCOVERY: Bare IPs are widened to single-host networks so callers
+// only need one containment check
+func (h *HTTPConfig) TrustedProxyNets() ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(h.TrustedProxies))
+	for _, entry := range h.TrustedProxies {
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			nets = append(nets, ipNet)
+			continue
+		}
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: must be an IP or CIDR", entry)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip = ip.To4()
+			bits = 32
+		}
+		nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+	}
+	return nets, nil
 }
 
 // FUNCTIONAL DISCOVERY: WebSocket configuration optimized for classroom scenarios
@@ -36,6 +94,298 @@ type WebSocketConfig struct {
 	ReadTimeout  time.Duration `json:"read_timeout"`
 	WriteTimeout time.Duration `json:"write_timeout"`
 	BufferSize   int           `json:"buffer_size"`
+	
+	// History replay pacing: messages per batch and pause between batches
+	HistoryBatchSize  int           `json:"history_batch_size"`
+	HistoryBatchDelay time.Duration `json:"history_batch_delay"`
+	
+	// Most recent visible messages a new connection is replayed; older ones are
+	// announced in a history_truncated notice. Zero replays everything
+	MaxReplayMessages int `json:"max_replay_messages"`
+	
+	// Upgrade pacing after startup: new connections per second for the first
+	// AdmissionWindow; a zero window admits everyone immediately
+	AdmissionRate   int           `json:"admission_rate"`
+	AdmissionWindow time.Duration `json:"admission_window"`
+	
+	// What happens when a user_id already connected to a session connects again
+	// from a different client IP: "allow", "replace" or "reject"
+	DuplicateUserPolicy string `json:"duplicate_user_policy"`
+	
+	// What happens to a frame whose session_id names a session other than the
+	// connection's: "reject" refuses it, "correct" rewrites it for legacy clients
+	SessionMismatchPolicy string `json:"session_mismatch_policy"`
+	
+	// How long POST /api/admin/drain spreads reconnects before closing what is left;
+	// zero means 30 seconds
+	DrainWindow time.Duration `json:"drain_window"`
+}
+
+// AdmissionPacingEnabled reports whether new upgrades are paced after startup
+func (w *WebSocketConfig) AdmissionPacingEnabled() bool {
+	return w.AdmissionWindow > 0
+}
+
+// FUNCTIONAL DISCOVERY: Debug configuration keeps pprof/expvar off by default
+// Addr moves the /debug/ handlers to a separate localhost-only listener
+type DebugConfig struct {
+	EnableProfiling bool   `json:"enable_profiling"`
+	Addr            string `json:"addr"`
+}
+
+// FUNCTIONAL DISCOVERY: Privacy configuration controls how personal data appears in logs
+// and in pseudonymized exports
+// FUNCTIONAL DISCOVERY: ScrubFields are the content paths scrubbed when a report is
+// exported with pseudonymize=true, nested keys joined with dots; ScrubPatterns are
+// regular expressions whose matches in those fields are replaced. The default pattern
+// catches email addresses
+type PrivacyConfig struct {
+	RedactIPs     bool     `json:"redact_ips"`
+	ScrubFields   []string `json:"scrub_fields"`
+	ScrubPatterns []string `json:"scrub_patterns"`
+	
+	// Serves GET /api/admin/messages/{id}/metadata; off by default since the API is
+	// unauthenticated and the metadata holds client IPs and user agents
+	MessageMetadataEndpoint bool `json:"message_metadata_endpoint"`
+}
+
+// DefaultScrubPatterns matches email addresses
+var DefaultScrubPatterns = []string{`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`}
+
+// ScrubRegexps compiles ScrubPatterns
+func (p *PrivacyConfig) ScrubRegexps() ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(p.ScrubPatterns))
+	for _, pattern := range p.ScrubPatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid privacy scrub pattern %q: %w", pattern, err)
+		}
+		patterns = append(patterns, compiled)
+	}
+	return patterns, nil
+}
+
+// FUNCTIONAL DISCOVERY: Session configuration controls countdown warnings for
+// duration-limited sessions; each offset is time remaining before auto-end
+// TECHNICAL DISCOVERY: HookBudget caps how long each lifecycle hook may delay
+// creating or ending a session
+// FUNCTIONAL DISCOVERY: NamePolicy decides what happens when an instructor creates a
+// session named like one of their active sessions; see SessionNamePolicies
+// FUNCTIONAL DISCOVERY: OwnerTransferGrace is how long the owner must be disconnected
+// before a connected co-instructor may take a session over; 0 allows it immediately
+// FUNCTIONAL DISCOVERY: LockExemptAnalytics keeps student analytics flowing while a
+// session is locked, so engagement dashboards keep working during an exam
+// FUNCTIONAL DISCOVERY: IdempotentEnd answers DELETE on an already-ended session with
+// 200 and already_ended instead of 409, for dashboards that retry blindly
+// FUNCTIONAL DISCOVERY: MaxStudents caps the distinct student IDs a session may be
+// created with; larger rosters are refused with 400
+// FUNCTIONAL DISCOVERY: InstructorScope decides which instructors may join a session;
+// see SessionInstructorScopes
+// FUNCTIONAL DISCOVERY: MessageEditWindow is how long after sending an instructor may
+// still edit a message; 0 turns editing off
+// FUNCTIONAL DISCOVERY: ActivityInterval is how often session activity is written;
+// IdleEndAfter ends sessions at startup that were idle longer than it, 0 resumes all
+// FUNCTIONAL DISCOVERY: Creators decides who may create sessions; see
+// SessionCreatorPolicies. MaxActivePerCreator caps each creator's active sessions,
+// 0 leaves them unlimited
+type SessionsConfig struct {
+	WarningOffsets      []time.Duration `json:"warning_offsets"`
+	HookBudget          time.Duration   `json:"hook_budget"`
+	NamePolicy          string          `json:"name_policy"`
+	OwnerTransferGrace  time.Duration   `json:"owner_transfer_grace"`
+	LockExemptAnalytics bool            `json:"lock_exempt_analytics"`
+	IdempotentEnd       bool            `json:"idempotent_end"`
+	MaxStudents         int             `json:"max_students"`
+	InstructorScope     string          `json:"instructor_scope"`
+	MessageEditWindow   time.Duration   `json:"message_edit_window"`
+	ActivityInterval    time.Duration   `json:"activity_interval"`
+	IdleEndAfter        time.Duration   `json:"idle_end_after"`
+	Creators            string          `json:"creators"`
+	CreatorAllowlist    []string        `json:"creator_allowlist"` // Instructor IDs that may create under "listed"
+	MaxActivePerCreator int             `json:"max_active_per_creator"`
+}
+
+// SessionCreatorPolicies lists the accepted creator policies: "anyone" lets every
+// caller create sessions, "listed" only the instructor IDs in creator_allowlist
+var SessionCreatorPolicies = []string{"anyone", "listed"}
+
+// SessionInstructorScopes lists the accepted instructor scopes: "any" lets every
+// instructor join every active session, "members" only the session's creator, owner
+// and listed instructor_ids
+var SessionInstructorScopes = []string{"any", "members"}
+
+// SessionNamePolicies lists the accepted duplicate-name policies: "allow" permits
+// duplicates, "unique_active" rejects them, "suffix" appends " (2)", " (3)", ...
+var SessionNamePolicies = []string{"allow", "unique_active", "suffix"}
+
+// FUNCTIONAL DISCOVERY: Logging configuration sets the minimum level written to the log;
+// "debug" includes the verbose DEBUG: lines, "warn" keeps only WARNING: and ERROR: lines
+type LoggingConfig struct {
+	Level string `json:"level"`
+}
+
+// LogLevels lists the accepted logging levels from most to least verbose
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// FUNCTIONAL DISCOVERY: Watchdog configuration sets when persistence is considered
+// degraded; RouteOnly keeps live routing going without history while degraded
+type WatchdogConfig struct {
+	CheckInterval       time.Duration `json:"check_interval"`
+	MinFreeBytes        uint64        `json:"min_free_bytes"`
+	MinWriteSuccessRate float64       `json:"min_write_success_rate"` // 0-1 over one check interval
+	RouteOnly           bool          `json:"route_only"`
+}
+
+// FUNCTIONAL DISCOVERY: Transcript configuration enables plain-text per-session logs
+// for deployments without a log stack; an empty Dir disables the writer
+type TranscriptsConfig struct {
+	Dir           string        `json:"dir"`
+	FlushInterval time.Duration `json:"flush_interval"`
+	MaxFileBytes  int64         `json:"max_file_bytes"` // Rotate once a session file reaches this size
+}
+
+// Enabled reports whether transcripts should be written
+func (t *TranscriptsConfig) Enabled() bool {
+	return t != nil && t.Dir != ""
+}
+
+// FUNCTIONAL DISCOVERY: Snapshot configuration keeps a warm standby ready; the primary
+// writes its active sessions to Path every Interval, and a standby started with
+// RestoreFrom imports that file before loading sessions. An empty Path disables writing
+type SnapshotConfig struct {
+	Path        string        `json:"path"`
+	Interval    time.Duration `json:"interval"`
+	RestoreFrom string        `json:"restore_from"` // Usually set once with -restore-from
+}
+
+// Enabled reports whether periodic snapshots should be written
+func (s *SnapshotConfig) Enabled() bool {
+	return s != nil && s.Path != ""
+}
+
+// FUNCTIONAL DISCOVERY: Analytics configuration exports routed analytics messages to
+// an external store; Sink selects one of AnalyticsSinks and an empty Sink disables export
+type AnalyticsConfig struct {
+	Sink          string        `json:"sink"`
+	FilePath      string        `json:"file_path"` // NDJSON file for the "file" sink
+	URL           string        `json:"url"`       // Collector receiving JSON arrays for the "http" sink
+	BatchSize     int           `json:"batch_size"`
+	FlushInterval time.Duration `json:"flush_interval"` // Also sends partial HTTP batches
+	MaxRetries    int           `json:"max_retries"`
+	QueueSize     int           `json:"queue_size"` // Messages beyond this are dropped, not waited on
+}
+
+// AnalyticsSinks lists the built-in analytics sink names
+var AnalyticsSinks = []string{"noop", "file", "http"}
+
+// Enabled reports whether analytics messages should be exported
+func (a *AnalyticsConfig) Enabled() bool {
+	return a != nil && a.Sink != ""
+}
+
+// FUNCTIONAL DISCOVERY: Router configuration limits what message content may carry;
+// ContentAllowlist maps a message type to the content keys it may contain. Types
+// without an entry, and the empty default, route content untouched
+// FUNCTIONAL DISCOVERY: BroadcastDedupWindow suppresses an instructor's identical
+// broadcast repeated within the window (a double-clicked send); 0 disables it
+// FUNCTIONAL DISCOVERY: DiagnosticsSampleRate is the share of messages that carry the
+// latency overlay in sessions where an instructor turned diagnostic mode on
+// FUNCTIONAL DISCOVERY: RejectLateSubmissions refuses a request_response sent after
+// its request's deadline instead of delivering it flagged late
+// FUNCTIONAL DISCOVERY: DefaultContexts maps a message type to the context applied
+// when a client omits one; unmapped types default to "general"
+// FUNCTIONAL DISCOVERY: PersistUnknownRecipients stores a direct message whose to_user
+// is not in the session instead of refusing it, for integrations that message users
+// before they are enrolled; it is still not delivered
+// FUNCTIONAL DISCOVERY: RateLimitMessages per RateLimitWindow is each user's message
+// budget, 0 meaning the default; both are read through the config Store, so a reload
+// applies from the next message
+// FUNCTIONAL DISCOVERY: ReactionCodes is the allowlist of codes students may react
+// with; an empty list means the built-in thumbs_up, thumbs_down and question
+// FUNCTIONAL DISCOVERY: HubWorkers is how many messages the hub routes at once, 0
+// meaning the default; each sender is pinned to one worker, so one user's messages
+// are never reordered
+// FUNCTIONAL DISCOVERY: MaxBroadcastRecipients caps the to_users list of a targeted
+// instructor_broadcast, 0 meaning the default
+// FUNCTIONAL DISCOVERY: AttachmentMIMETypes is the allowlist for inline attachments
+// ({"attachment": {"mime", "data_base64", "filename"}} in content), e.g. "image/png" or
+// "image/*"; empty leaves attachments unchecked. MaxAttachmentBytes caps the decoded
+// data, 0 meaning the default
+// FUNCTIONAL DISCOVERY: Delivery failures are recorded in the delivery_failures table,
+// every one up to DeliverySampleAbove a minute and a DeliverySampleRate share of those
+// beyond, so an incident does not turn into a write storm; the table keeps the newest
+// DeliveryFailureRows rows. Zero values mean the defaults
+type RouterConfig struct {
+	ContentAllowlist         map[string][]string `json:"content_allowlist"`          // Dotted paths reach into nested maps, e.g. "scores.math"
+	StrictContent            bool                `json:"strict_content"`             // Reject messages with unknown keys instead of stripping them
+	BroadcastDedupWindow     time.Duration       `json:"broadcast_dedup_window"`     // 0 delivers every broadcast
+	DiagnosticsSampleRate    float64             `json:"diagnostics_sample_rate"`    // 0-1; 0 turns the overlay off
+	RejectLateSubmissions    bool                `json:"reject_late_submissions"`    // false flags late submissions with late=true
+	DefaultContexts          map[string]string   `json:"default_contexts"`           // e.g. {"analytics": "engagement"}
+	PersistUnknownRecipients bool                `json:"persist_unknown_recipients"` // false refuses them with UNKNOWN_RECIPIENT
+	RateLimitMessages        int                 `json:"rate_limit_messages"`
+	RateLimitWindow          time.Duration       `json:"rate_limit_window"`
+	ReactionCodes            []string            `json:"reaction_codes"` // Lowercase letters, digits and underscores, up to 20 each
+	HubWorkers               int                 `json:"hub_workers"`    // Up to 64
+	MaxBroadcastRecipients   int                 `json:"max_broadcast_recipients"` // Up to 1000
+	AttachmentMIMETypes      []string            `json:"attachment_mime_types"`
+	MaxAttachmentBytes       int                 `json:"max_attachment_bytes"` // Up to the 64KB content limit
+	StrictAttachments        bool                `json:"strict_attachments"`   // Reject messages with a bad attachment instead of stripping it
+	DeliverySampleAbove      int                 `json:"delivery_sample_above"` // Failures per minute recorded in full
+	DeliverySampleRate       float64             `json:"delivery_sample_rate"`  // 0-1 share recorded above that
+	DeliveryFailureRows      int                 `json:"delivery_failure_rows"` // Rows kept in delivery_failures
+}
+
+// FUNCTIONAL DISCOVERY: Scaling configuration feeds GET /api/scaling-hint; MaxConnections
+// is what one instance is sized for (it is not enforced), and the percentages of it and
+// of the database write queue decide the suggested replica delta
+type ScalingConfig struct {
+	MaxConnections      int     `json:"max_connections"`
+	ScaleUpPercent      float64 `json:"scale_up_percent"`
+	ScaleDownPercent    float64 `json:"scale_down_percent"`
+	QueueScaleUpPercent float64 `json:"queue_scale_up_percent"`
+}
+
+// FUNCTIONAL DISCOVERY: Self-test configuration routes a probe between two loopback
+// connections every Interval and reports it in /health; FailureThreshold consecutive
+// misses mark readiness degraded. A zero Interval disables the self-test
+type SelfTestConfig struct {
+	Interval         time.Duration `json:"interval"`
+	FailureThreshold int           `json:"failure_threshold"`
+}
+
+// Enabled reports whether self-test probes should be sent
+func (s *SelfTestConfig) Enabled() bool {
+	return s != nil && s.Interval > 0
+}
+
+// FUNCTIONAL DISCOVERY: Alerting configuration checks Rules every Interval against the
+// write, delivery and connection counters; DefaultRules adds the built-in write latency
+// and delivery failure rules, acting on DefaultActions, and a rule in Rules with the
+// same name replaces a built-in one. A zero Interval disables alerting
+type AlertingConfig struct {
+	Interval       time.Duration `json:"interval"`
+	DefaultRules   bool          `json:"default_rules"`
+	DefaultActions []string      `json:"default_actions"` // Any of types.AlertActions
+	WebhookURL     string        `json:"webhook_url"`     // Receives a JSON types.Alert per firing and resolution for the "webhook" action
+	Rules          []AlertRule   `json:"rules"`
+}
+
+// AlertRule raises an alert when Metric, one of types.AlertMetrics, stays above
+// Threshold for For; MinSamples is the writes, deliveries or drops an interval needs
+// before it counts
+type AlertRule struct {
+	Name       string        `json:"name"`
+	Metric     string        `json:"metric"`
+	Threshold  float64       `json:"threshold"`
+	For        time.Duration `json:"for"`
+	MinSamples uint64        `json:"min_samples"`
+	Actions    []string      `json:"actions"`
+}
+
+// Enabled reports whether alert rules should be evaluated
+func (a *AlertingConfig) Enabled() bool {
+	return a != nil && a.Interval > 0
 }
 
 // FUNCTIONAL DISCOVERY: Production-ready defaults based on classroom requirements
@@ -43,8 +393,12 @@ type WebSocketConfig struct {
 func DefaultConfig() *Config {
 	return &Config{
 		Database: &DatabaseConfig{
-			Path:    "./switchboard.db",
-			Timeout: 30 * time.Second,
+			Path:            "./switchboard.db",
+			Timeout:         30 * time.Second,
+			ImportBatchSize: 500,
+			CompactContent:  true,
+			DedupThreshold:  8 << 10,
+			LockRecoveryTimeout: 30 * time.Second,
 		},
 		HTTP: &HTTPConfig{
 			Port:         8080,
@@ -55,8 +409,100 @@ func DefaultConfig() *Config {
 		WebSocket: &WebSocketConfig{
 			PingInterval: 30 * time.Second,
 			ReadTimeout:  60 * time.Second,
-			WriteTimeout: 10 * time.Second,
-			BufferSize:   100,
+			WriteTimeout:      10 * time.Second,
+			BufferSize:        100,
+			HistoryBatchSize:  100,
+			HistoryBatchDelay: 10 * time.Millisecond,
+			MaxReplayMessages: 500,
+			AdmissionRate:     20,
+			DuplicateUserPolicy: "allow",
+			SessionMismatchPolicy: "reject",
+			DrainWindow:       30 * time.Second,
+		},
+		Debug: &DebugConfig{
+			EnableProfiling: false,
+			Addr:            "",
+		},
+		Privacy: &PrivacyConfig{
+			RedactIPs:     true,
+			ScrubFields:   []string{"text"},
+			ScrubPatterns: append([]string(nil), DefaultScrubPatterns...),
+		},
+		Sessions: &SessionsConfig{
+			WarningOffsets:      []time.Duration{10 * time.Minute, 2 * time.Minute},
+			HookBudget:          5 * time.Second,
+			NamePolicy:          "allow",
+			OwnerTransferGrace:  5 * time.Minute,
+			LockExemptAnalytics: false,
+			IdempotentEnd:       false,
+			MaxStudents:         500,
+			InstructorScope:     "any",
+			MessageEditWindow:   15 * time.Minute,
+			ActivityInterval:    30 * time.Second,
+			IdleEndAfter:        4 * time.Hour,
+			Creators:            "anyone",
+			CreatorAllowlist:    []string{},
+			MaxActivePerCreator: 0,
+		},
+		Watchdog: &WatchdogConfig{
+			CheckInterval:       30 * time.Second,
+			MinFreeBytes:        256 << 20,
+			MinWriteSuccessRate: 0.9,
+			RouteOnly:           false,
+		},
+		Router: &RouterConfig{
+			ContentAllowlist:         map[string][]string{},
+			StrictContent:            false,
+			BroadcastDedupWindow:     0,
+			DiagnosticsSampleRate:    0.1,
+			RejectLateSubmissions:    false,
+			DefaultContexts:          map[string]string{},
+			PersistUnknownRecipients: false,
+			RateLimitMessages:        100,
+			RateLimitWindow:          time.Minute,
+			ReactionCodes:            append([]string(nil), types.DefaultReactionCodes...),
+			HubWorkers:               4,
+			MaxBroadcastRecipients:   100,
+			AttachmentMIMETypes:      []string{},
+			MaxAttachmentBytes:       32 << 10,
+			StrictAttachments:        false,
+			DeliverySampleAbove:      600,
+			DeliverySampleRate:       0.01,
+			DeliveryFailureRows:      100000,
+		},
+		Scaling: &ScalingConfig{
+			MaxConnections:      500,
+			ScaleUpPercent:      80,
+			ScaleDownPercent:    30,
+			QueueScaleUpPercent: 50,
+		},
+		Snapshot: &SnapshotConfig{
+			Path:     "",
+			Interval: 30 * time.Second,
+		},
+		SelfTest: &SelfTestConfig{
+			Interval:         0,
+			FailureThreshold: 3,
+		},
+		Alerting: &AlertingConfig{
+			Interval:       15 * time.Second,
+			DefaultRules:   true,
+			DefaultActions: []string{types.AlertActionLog},
+		},
+		Transcripts: &TranscriptsConfig{
+			Dir:           "",
+			FlushInterval: time.Second,
+			MaxFileBytes:  64 << 20,
+		},
+		Analytics: &AnalyticsConfig{
+			Sink:          "",
+			BatchSize:     100,
+			FlushInterval: 5 * time.Second,
+			MaxRetries:    3,
+			QueueSize:     1000,
+		},
+		Logging: &LoggingConfig{
+			Level: "info",
 		},
 	}
 }
@@ -76,12 +522,25 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("database timeout must be positive")
 	}
 	
+	if c.Database.ImportBatchSize < 0 {
+		return fmt.Errorf("database import batch size cannot be negative")
+	}
+	
+	if c.Database.DedupThreshold < 0 {
+		return fmt.Errorf("database dedup threshold cannot be negative")
+	}
+	
+	if c.Database.LockRecoveryTimeout < 0 {
+		return fmt.Errorf("database lock recovery timeout cannot be negative")
+	}
+	
 	if c.HTTP == nil {
 		return fmt.Errorf("HTTP configuration is required")
 	}
 	
-	if c.HTTP.Port <= 0 || c.HTTP.Port > 65535 {
-		return fmt.Errorf("HTTP port must be between 1 and 65535")
+	// FUNCTIONAL DISCOVERY: Port 0 binds any free port; Application.Addr reports it
+	if c.HTTP.Port < 0 || c.HTTP.Port > 65535 {
+		return fmt.Errorf("HTTP port must be between 0 and 65535")
 	}
 	
 	if c.HTTP.ReadTimeout <= 0 {
@@ -96,6 +555,16 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("HTTP host cannot be empty")
 	}
 	
+	if _, err := c.HTTP.TrustedProxyNets(); err != nil {
+		return err
+	}
+	
+	if c.Privacy != nil {
+		if _, err := c.Privacy.ScrubRegexps(); err != nil {
+			return err
+		}
+	}
+	
 	if c.WebSocket == nil {
 		return fmt.Errorf("WebSocket configuration is required")
 	}
@@ -116,195 +585,1558 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("WebSocket buffer size must be positive")
 	}
 	
-	return nil
-}
-
-// FUNCTIONAL DISCOVERY: Environment variable configuration enables deployment flexibility
-// Supports containerized deployments and configuration management systems
-func LoadFromEnv() *Config {
-	config := DefaultConfig()
+	if c.WebSocket.HistoryBatchSize <= 0 {
+		return fmt.Errorf("WebSocket history batch size must be positive")
+	}
 	
-	// FUNCTIONAL DISCOVERY: Environment variables override defaults with fallback
-	if port := os.Getenv("SWITCHBOARD_HTTP_PORT"); port != "" {
-		if p, err := strconv.Atoi(port); err == nil {
-			config.HTTP.Port = p
-		}
+	if c.WebSocket.HistoryBatchDelay < 0 {
+		return fmt.Errorf("WebSocket history batch delay cannot be negative")
 	}
 	
-	if host := os.Getenv("SWITCHBOARD_HTTP_HOST"); host != "" {
-		config.HTTP.Host = host
+	if c.WebSocket.MaxReplayMessages < 0 {
+		return fmt.Errorf("WebSocket max replay messages cannot be negative")
 	}
 	
-	if dbPath := os.Getenv("SWITCHBOARD_DATABASE_PATH"); dbPath != "" {
-		config.Database.Path = dbPath
+	if c.WebSocket.AdmissionWindow < 0 {
+		return fmt.Errorf("WebSocket admission window cannot be negative")
 	}
 	
-	if readTimeout := os.Getenv("SWITCHBOARD_HTTP_READ_TIMEOUT"); readTimeout != "" {
-		if timeout, err := time.ParseDuration(readTimeout); err == nil {
-			config.HTTP.ReadTimeout = timeout
-		}
+	if c.WebSocket.AdmissionPacingEnabled() && c.WebSocket.AdmissionRate <= 0 {
+		return fmt.Errorf("WebSocket admission rate must be positive when an admission window is set")
 	}
 	
-	if writeTimeout := os.Getenv("SWITCHBOARD_HTTP_WRITE_TIMEOUT"); writeTimeout != "" {
-		if timeout, err := time.ParseDuration(writeTimeout); err == nil {
-			config.HTTP.WriteTimeout = timeout
-		}
+	if c.WebSocket.DrainWindow < 0 || c.WebSocket.DrainWindow > 10*time.Minute {
+		return fmt.Errorf("WebSocket drain window must be between 0s and 10m")
 	}
 	
-	if dbTimeout := os.Getenv("SWITCHBOARD_DATABASE_TIMEOUT"); dbTimeout != "" {
-		if timeout, err := time.ParseDuration(dbTimeout); err == nil {
-			config.Database.Timeout = timeout
-		}
+	switch c.WebSocket.DuplicateUserPolicy {
+	case "", "allow", "replace", "reject": // Empty allows, for configs built in code
+	default:
+		return fmt.Errorf("WebSocket duplicate user policy must be allow, replace or reject, got %q", c.WebSocket.DuplicateUserPolicy)
 	}
 	
-	if pingInterval := os.Getenv("SWITCHBOARD_WEBSOCKET_PING_INTERVAL"); pingInterval != "" {
-		if interval, err := time.ParseDuration(pingInterval); err == nil {
-			config.WebSocket.PingInterval = interval
-		}
+	switch c.WebSocket.SessionMismatchPolicy {
+	case "", "reject", "correct": // Empty rejects, for configs built in code
+	default:
+		return fmt.Errorf("WebSocket session mismatch policy must be reject or correct, got %q", c.WebSocket.SessionMismatchPolicy)
 	}
 	
-	if wsReadTimeout := os.Getenv("SWITCHBOARD_WEBSOCKET_READ_TIMEOUT"); wsReadTimeout != "" {
-		if timeout, err := time.ParseDuration(wsReadTimeout); err == nil {
-			config.WebSocket.ReadTimeout = timeout
+	// ARCHITECTURAL DISCOVERY: Router section is optional - nil disables content filtering
+	if c.Router != nil {
+		for messageType, paths := range c.Router.ContentAllowlist {
+			if !types.IsValidMessageType(messageType) {
+				return fmt.Errorf("content allowlist names unknown message type %q", messageType)
+			}
+			for _, path := range paths {
+				if path == "" || strings.HasPrefix(path, ".") || strings.HasSuffix(path, ".") || strings.Contains(path, "..") {
+					return fmt.Errorf("invalid content allowlist path %q for %s", path, messageType)
+				}
+			}
+		}
+		if c.Router.BroadcastDedupWindow < 0 || c.Router.BroadcastDedupWindow > time.Hour {
+			return fmt.Errorf("broadcast dedup window must be between 0 and 1h")
+		}
+		if c.Router.DiagnosticsSampleRate < 0 || c.Router.DiagnosticsSampleRate > 1 {
+			return fmt.Errorf("diagnostics sample rate must be between 0 and 1")
+		}
+		if c.Router.RateLimitMessages < 0 {
+			return fmt.Errorf("rate limit messages must not be negative")
+		}
+		if c.Router.RateLimitWindow < 0 || c.Router.RateLimitWindow > time.Hour {
+			return fmt.Errorf("rate limit window must be between 0 and 1h")
+		}
+		for messageType, context := range c.Router.DefaultContexts {
+			if !types.IsValidMessageType(messageType) {
+				return fmt.Errorf("default contexts name unknown message type %q", messageType)
+			}
+			if !types.IsValidContext(context) {
+				return fmt.Errorf("invalid default context %q for %s", context, messageType)
+			}
+		}
+		for _, code := range c.Router.ReactionCodes {
+			if !types.IsValidReactionCode(code) {
+				return fmt.Errorf("invalid reaction code %q", code)
+			}
+		}
+		if c.Router.HubWorkers < 0 || c.Router.HubWorkers > 64 {
+			return fmt.Errorf("hub workers must be between 0 and 64")
+		}
+		if c.Router.MaxBroadcastRecipients < 0 || c.Router.MaxBroadcastRecipients > 1000 {
+			return fmt.Errorf("max broadcast recipients must be between 0 and 1000")
+		}
+		for _, mimeType := range c.Router.AttachmentMIMETypes {
+			if !isValidAttachmentMIME(mimeType) {
+				return fmt.Errorf("invalid attachment MIME type %q", mimeType)
+			}
+		}
+		if c.Router.MaxAttachmentBytes < 0 || c.Router.MaxAttachmentBytes > types.MaxContentBytes {
+			return fmt.Errorf("max attachment bytes must be between 0 and %d", types.MaxContentBytes)
+		}
+		if c.Router.DeliverySampleAbove < 0 {
+			return fmt.Errorf("delivery sample threshold must not be negative")
+		}
+		if c.Router.DeliverySampleRate < 0 || c.Router.DeliverySampleRate > 1 {
+			return fmt.Errorf("delivery sample rate must be between 0 and 1")
+		}
+		if c.Router.DeliveryFailureRows < 0 {
+			return fmt.Errorf("delivery failure rows must not be negative")
 		}
 	}
 	
-	if wsWriteTimeout := os.Getenv("SWITCHBOARD_WEBSOCKET_WRITE_TIMEOUT"); wsWriteTimeout != "" {
-		if timeout, err := time.ParseDuration(wsWriteTimeout); err == nil {
-			config.WebSocket.WriteTimeout = timeout
+	if c.Sessions != nil {
+		for _, offset := range c.Sessions.WarningOffsets {
+			if offset <= 0 {
+				return fmt.Errorf("session warning offsets must be positive")
+			}
+		}
+		if c.Sessions.HookBudget <= 0 {
+			return fmt.Errorf("session hook budget must be positive")
+		}
+		if !isSessionNamePolicy(c.Sessions.NamePolicy) {
+			return fmt.Errorf("session name policy must be one of %s", strings.Join(SessionNamePolicies, ", "))
+		}
+		if c.Sessions.OwnerTransferGrace < 0 {
+			return fmt.Errorf("session owner transfer grace cannot be negative")
+		}
+		if c.Sessions.MaxStudents <= 0 {
+			return fmt.Errorf("session max students must be positive")
+		}
+		if !isSessionInstructorScope(c.Sessions.InstructorScope) {
+			return fmt.Errorf("session instructor scope must be one of %s", strings.Join(SessionInstructorScopes, ", "))
+		}
+		if c.Sessions.MessageEditWindow < 0 || c.Sessions.MessageEditWindow > 24*time.Hour {
+			return fmt.Errorf("session message edit window must be between 0 and 24h")
+		}
+		if c.Sessions.ActivityInterval < time.Second || c.Sessions.ActivityInterval > 10*time.Minute {
+			return fmt.Errorf("session activity interval must be between 1s and 10m")
+		}
+		if c.Sessions.IdleEndAfter < 0 {
+			return fmt.Errorf("session idle end after cannot be negative")
+		}
+		if !isKnown(SessionCreatorPolicies, c.Sessions.Creators) {
+			return fmt.Errorf("session creators must be one of %s", strings.Join(SessionCreatorPolicies, ", "))
+		}
+		for _, creator := range c.Sessions.CreatorAllowlist {
+			if !types.IsValidUserID(creator) {
+				return fmt.Errorf("session creator allowlist entry %q is not a valid user ID", creator)
+			}
+		}
+		if c.Sessions.Creators == "listed" && len(c.Sessions.CreatorAllowlist) == 0 {
+			return fmt.Errorf("session creators \"listed\" needs a non-empty creator allowlist")
+		}
+		if c.Sessions.MaxActivePerCreator < 0 {
+			return fmt.Errorf("session max active per creator cannot be negative")
 		}
 	}
 	
-	if bufferSize := os.Getenv("SWITCHBOARD_WEBSOCKET_BUFFER_SIZE"); bufferSize != "" {
-		if size, err := strconv.Atoi(bufferSize); err == nil {
-			config.WebSocket.BufferSize = size
+	if c.Logging != nil && !isLogLevel(c.Logging.Level) {
+		return fmt.Errorf("log level must be one of %s", strings.Join(LogLevels, ", "))
+	}
+	
+	// ARCHITECTURAL DISCOVERY: Watchdog section is optional - nil disables health checks
+	if c.Watchdog != nil {
+		if c.Watchdog.CheckInterval <= 0 {
+			return fmt.Errorf("watchdog check interval must be positive")
+		}
+		if c.Watchdog.MinWriteSuccessRate < 0 || c.Watchdog.MinWriteSuccessRate > 1 {
+			return fmt.Errorf("watchdog min write success rate must be between 0 and 1")
 		}
 	}
 	
-	return config
-}
-
-// ConfigFile represents the JSON structure for file-based configuration
-// FUNCTIONAL DISCOVERY: Separate struct for JSON parsing to handle duration strings
-type ConfigFile struct {
-	Database  *DatabaseConfigFile  `json:"database"`
-	HTTP      *HTTPConfigFile      `json:"http"`
-	WebSocket *WebSocketConfigFile `json:"websocket"`
-}
-
-type DatabaseConfigFile struct {
-	Path    string `json:"path"`
-	Timeout string `json:"timeout"`
-}
-
-type HTTPConfigFile struct {
-	Port         int    `json:"port"`
-	ReadTimeout  string `json:"read_timeout"`
-	WriteTimeout string `json:"write_timeout"`
-	Host         string `json:"host"`
-}
-
-type WebSocketConfigFile struct {
-	PingInterval string `json:"ping_interval"`
-	ReadTimeout  string `json:"read_timeout"`
-	WriteTimeout string `json:"write_timeout"`
-	BufferSize   int    `json:"buffer_size"`
-}
-
-// FUNCTIONAL DISCOVERY: File-based configuration supports complex deployment scenarios
-// JSON format chosen for readability and tooling support
-func LoadFromFile(filepath string) (*Config, error) {
-	data, err := os.ReadFile(filepath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", filepath, err)
+	// ARCHITECTURAL DISCOVERY: Scaling section is optional - nil disables the scaling hint
+	if c.Scaling != nil {
+		if c.Scaling.MaxConnections <= 0 {
+			return fmt.Errorf("scaling max connections must be positive")
+		}
+		if c.Scaling.ScaleDownPercent < 0 || c.Scaling.ScaleUpPercent > 100 || c.Scaling.ScaleDownPercent >= c.Scaling.ScaleUpPercent {
+			return fmt.Errorf("scaling percents must satisfy 0 <= scale_down_percent < scale_up_percent <= 100")
+		}
+		if c.Scaling.QueueScaleUpPercent <= 0 || c.Scaling.QueueScaleUpPercent > 100 {
+			return fmt.Errorf("scaling queue scale up percent must be between 0 and 100")
+		}
 	}
 	
-	var configFile ConfigFile
-	if err := json.Unmarshal(data, &configFile); err != nil {
-		return nil, fmt.Errorf("failed to parse config file %s: %w", filepath, err)
+	if c.Transcripts.Enabled() {
+		if c.Transcripts.FlushInterval <= 0 {
+			return fmt.Errorf("transcript flush interval must be positive")
+		}
+		if c.Transcripts.MaxFileBytes <= 0 {
+			return fmt.Errorf("transcript max file bytes must be positive")
+		}
 	}
 	
-	// Convert to runtime config with duration parsing
-	config := DefaultConfig()
+	if c.Snapshot.Enabled() && c.Snapshot.Interval <= 0 {
+		return fmt.Errorf("snapshot interval must be positive")
+	}
 	
-	if configFile.Database != nil {
-		config.Database.Path = configFile.Database.Path
-		if configFile.Database.Timeout != "" {
-			if timeout, err := time.ParseDuration(configFile.Database.Timeout); err == nil {
-				config.Database.Timeout = timeout
-			}
+	if c.SelfTest != nil {
+		if c.SelfTest.Interval < 0 {
+			return fmt.Errorf("self-test interval cannot be negative")
+		}
+		if c.SelfTest.Enabled() && c.SelfTest.FailureThreshold <= 0 {
+			return fmt.Errorf("self-test failure threshold must be positive")
 		}
 	}
 	
-	if configFile.HTTP != nil {
-		if configFile.HTTP.Port > 0 {
-			config.HTTP.Port = configFile.HTTP.Port
+	if c.Analytics.Enabled() {
+		if err := c.Analytics.validate(); err != nil {
+			return err
 		}
-		if configFile.HTTP.Host != "" {
-			config.HTTP.Host = configFile.HTTP.Host
+	}
+	
+	if c.Alerting != nil {
+		if c.Alerting.Interval < 0 {
+			return fmt.Errorf("alerting interval cannot be negative")
 		}
-		if configFile.HTTP.ReadTimeout != "" {
-			if timeout, err := time.ParseDuration(configFile.HTTP.ReadTimeout); err == nil {
-				config.HTTP.ReadTimeout = timeout
+		if c.Alerting.Enabled() {
+			if err := c.Alerting.validate(); err != nil {
+				return err
 			}
 		}
-		if configFile.HTTP.WriteTimeout != "" {
-			if timeout, err := time.ParseDuration(configFile.HTTP.WriteTimeout); err == nil {
-				config.HTTP.WriteTimeout = timeout
-			}
+	}
+	
+	// ARCHITECTURAL DISCOVERY: Debug section is optional - nil means profiling disabled
+	if c.Debug != nil && c.Debug.Addr != "" {
+		host, _, err := net.SplitHostPort(c.Debug.Addr)
+		if err != nil {
+			return fmt.Errorf("debug address must be host:port: %w", err)
+		}
+		if !isLoopbackHost(host) {
+			return fmt.Errorf("debug address must bind to localhost, got %q", host)
 		}
 	}
 	
-	if configFile.WebSocket != nil {
-		if configFile.WebSocket.BufferSize > 0 {
-			config.WebSocket.BufferSize = configFile.WebSocket.BufferSize
+	return nil
+}
+
+// validate checks the settings the selected sink depends on
+func (a *AnalyticsConfig) validate() error {
+	switch a.Sink {
+	case "noop":
+	case "file":
+		if a.FilePath == "" {
+			return fmt.Errorf("analytics file sink requires file_path")
 		}
-		if configFile.WebSocket.PingInterval != "" {
-			if interval, err := time.ParseDuration(configFile.WebSocket.PingInterval); err == nil {
-				config.WebSocket.PingInterval = interval
-			}
+	case "http":
+		u, err := url.Parse(a.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("analytics http sink requires an http(s) url")
 		}
-		if configFile.WebSocket.ReadTimeout != "" {
-			if timeout, err := time.ParseDuration(configFile.WebSocket.ReadTimeout); err == nil {
-				config.WebSocket.ReadTimeout = timeout
-			}
+		if a.BatchSize <= 0 {
+			return fmt.Errorf("analytics batch size must be positive")
 		}
-		if configFile.WebSocket.WriteTimeout != "" {
-			if timeout, err := time.ParseDuration(configFile.WebSocket.WriteTimeout); err == nil {
-				config.WebSocket.WriteTimeout = timeout
-			}
+		if a.MaxRetries < 0 {
+			return fmt.Errorf("analytics max retries cannot be negative")
 		}
+	default:
+		return fmt.Errorf("analytics sink must be one of %s", strings.Join(AnalyticsSinks, ", "))
 	}
-	
-	// ARCHITECTURAL DISCOVERY: Validate configuration after loading to catch errors early
-	if err := config.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid configuration in %s: %w", filepath, err)
+	if a.FlushInterval <= 0 {
+		return fmt.Errorf("analytics flush interval must be positive")
 	}
-	
-	return config, nil
+	if a.QueueSize <= 0 {
+		return fmt.Errorf("analytics queue size must be positive")
+	}
+	return nil
 }
 
-// FUNCTIONAL DISCOVERY: Configuration precedence: file > environment > defaults
-// Enables flexible deployment patterns while maintaining sane defaults
-func LoadConfigWithPrecedence(filepath string) *Config {
-	var config *Config
-	
-	// Start with defaults
-	config = DefaultConfig()
+// validate checks the rules and that every action they name can run
+func (a *AlertingConfig) validate() error {
+	if a.Interval < time.Second {
+		return fmt.Errorf("alerting interval must be at least 1s")
+	}
+	actions := make([]string, 0, len(a.DefaultActions))
+	if a.DefaultRules {
+		actions = append(actions, a.DefaultActions...)
+	}
+	names := make(map[string]bool, len(a.Rules))
+	for _, rule := range a.Rules {
+		if rule.Name == "" {
+			return fmt.Errorf("alert rules must be named")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("alert rule %s is defined twice", rule.Name)
+		}
+		names[rule.Name] = true
+		if !isKnown(types.AlertMetrics, rule.Metric) {
+			return fmt.Errorf("alert rule %s metric must be one of %s", rule.Name, strings.Join(types.AlertMetrics, ", "))
+		}
+		if rule.Threshold < 0 || rule.For < 0 {
+			return fmt.Errorf("alert rule %s threshold and for cannot be negative", rule.Name)
+		}
+		actions = append(actions, rule.Actions...)
+	}
+	for _, action := range actions {
+		if !isKnown(types.AlertActions, action) {
+			return fmt.Errorf("alert action must be one of %s, got %q", strings.Join(types.AlertActions, ", "), action)
+		}
+		if action == types.AlertActionWebhook {
+			u, err := url.Parse(a.WebhookURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("alert webhook action requires an http(s) webhook_url")
+			}
+		}
+	}
+	return nil
+}
+
+// isKnown reports whether value is one of known
+func isKnown(known []string, value string) bool {
+	for _, candidate := range known {
+		if value == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// isSessionNamePolicy reports whether policy is one of SessionNamePolicies
+func isSessionNamePolicy(policy string) bool {
+	for _, known := range SessionNamePolicies {
+		if policy == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isSessionInstructorScope reports whether scope is one of SessionInstructorScopes
+func isSessionInstructorScope(scope string) bool {
+	for _, known := range SessionInstructorScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isLogLevel reports whether level is one of LogLevels
+func isLogLevel(level string) bool {
+	for _, known := range LogLevels {
+		if level == known {
+			return true
+		}
+	}
+	return false
+}
+
+// isLoopbackHost reports whether host only accepts local connections
+// TECHNICAL DISCOVERY: Profiling handlers expose heap contents, so the separate
+// listener is restricted to loopback addresses
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// FUNCTIONAL DISCOVERY: Environment variable configuration enables deployment flexibility
+// Supports containerized deployments and configuration management systems
+func LoadFromEnv() *Config {
+	config := DefaultConfig()
+	applyEnv(config)
+	return config
+}
+
+// applyEnv overrides config with any SWITCHBOARD_* variables that are set
+// FUNCTIONAL DISCOVERY: Unparseable values are ignored so one typo does not discard
+// the rest of the environment
+func applyEnv(config *Config) {
+	// FUNCTIONAL DISCOVERY: Environment variables override defaults with fallback
+	if port := os.Getenv("SWITCHBOARD_HTTP_PORT"); port != "" {
+		if p, err := strconv.Atoi(port); err == nil {
+			config.HTTP.Port = p
+		}
+	}
+	
+	if host := os.Getenv("SWITCHBOARD_HTTP_HOST"); host != "" {
+		config.HTTP.Host = host
+	}
+	
+	if proxies := os.Getenv("SWITCHBOARD_HTTP_TRUSTED_PROXIES"); proxies != "" {
+		config.HTTP.TrustedProxies = splitList(proxies)
+	}
+	
+	if redact := os.Getenv("SWITCHBOARD_PRIVACY_REDACT_IPS"); redact != "" {
+		if enabled, err := strconv.ParseBool(redact); err == nil {
+			config.Privacy.RedactIPs = enabled
+		}
+	}
+	
+	if endpoint := os.Getenv("SWITCHBOARD_PRIVACY_MESSAGE_METADATA_ENDPOINT"); endpoint != "" {
+		if enabled, err := strconv.ParseBool(endpoint); err == nil {
+			config.Privacy.MessageMetadataEndpoint = enabled
+		}
+	}
+	
+	// TECHNICAL DISCOVERY: Patterns are file-only; regular expressions often contain
+	// the commas a list variable is split on
+	if fields := os.Getenv("SWITCHBOARD_PRIVACY_SCRUB_FIELDS"); fields != "" {
+		config.Privacy.ScrubFields = splitList(fields)
+	}
+	
+	if strict := os.Getenv("SWITCHBOARD_ROUTER_STRICT_CONTENT"); strict != "" {
+		if enabled, err := strconv.ParseBool(strict); err == nil {
+			config.Router.StrictContent = enabled
+		}
+	}
+	
+	if window := os.Getenv("SWITCHBOARD_ROUTER_BROADCAST_DEDUP_WINDOW"); window != "" {
+		if d, err := time.ParseDuration(window); err == nil {
+			config.Router.BroadcastDedupWindow = d
+		}
+	}
+	
+	if rate := os.Getenv("SWITCHBOARD_ROUTER_DIAGNOSTICS_SAMPLE_RATE"); rate != "" {
+		if sampleRate, err := strconv.ParseFloat(rate, 64); err == nil {
+			config.Router.DiagnosticsSampleRate = sampleRate
+		}
+	}
+	
+	if reject := os.Getenv("SWITCHBOARD_ROUTER_REJECT_LATE_SUBMISSIONS"); reject != "" {
+		if enabled, err := strconv.ParseBool(reject); err == nil {
+			config.Router.RejectLateSubmissions = enabled
+		}
+	}
+	
+	if persist := os.Getenv("SWITCHBOARD_ROUTER_PERSIST_UNKNOWN_RECIPIENTS"); persist != "" {
+		if enabled, err := strconv.ParseBool(persist); err == nil {
+			config.Router.PersistUnknownRecipients = enabled
+		}
+	}
+	
+	if messages := os.Getenv("SWITCHBOARD_ROUTER_RATE_LIMIT_MESSAGES"); messages != "" {
+		if n, err := strconv.Atoi(messages); err == nil {
+			config.Router.RateLimitMessages = n
+		}
+	}
+	
+	if window := os.Getenv("SWITCHBOARD_ROUTER_RATE_LIMIT_WINDOW"); window != "" {
+		if d, err := time.ParseDuration(window); err == nil {
+			config.Router.RateLimitWindow = d
+		}
+	}
+	
+	if codes := os.Getenv("SWITCHBOARD_ROUTER_REACTION_CODES"); codes != "" {
+		config.Router.ReactionCodes = splitList(codes)
+	}
+	
+	if workers := os.Getenv("SWITCHBOARD_ROUTER_HUB_WORKERS"); workers != "" {
+		if n, err := strconv.Atoi(workers); err == nil {
+			config.Router.HubWorkers = n
+		}
+	}
+	
+	if recipients := os.Getenv("SWITCHBOARD_ROUTER_MAX_BROADCAST_RECIPIENTS"); recipients != "" {
+		if n, err := strconv.Atoi(recipients); err == nil {
+			config.Router.MaxBroadcastRecipients = n
+		}
+	}
+	
+	if mimeTypes := os.Getenv("SWITCHBOARD_ROUTER_ATTACHMENT_MIME_TYPES"); mimeTypes != "" {
+		config.Router.AttachmentMIMETypes = splitList(mimeTypes)
+	}
+	
+	if maxBytes := os.Getenv("SWITCHBOARD_ROUTER_MAX_ATTACHMENT_BYTES"); maxBytes != "" {
+		if n, err := strconv.Atoi(maxBytes); err == nil {
+			config.Router.MaxAttachmentBytes = n
+		}
+	}
+	
+	if strict := os.Getenv("SWITCHBOARD_ROUTER_STRICT_ATTACHMENTS"); strict != "" {
+		if enabled, err := strconv.ParseBool(strict); err == nil {
+			config.Router.StrictAttachments = enabled
+		}
+	}
+	
+	if above := os.Getenv("SWITCHBOARD_ROUTER_DELIVERY_SAMPLE_ABOVE"); above != "" {
+		if n, err := strconv.Atoi(above); err == nil {
+			config.Router.DeliverySampleAbove = n
+		}
+	}
+	
+	if rate := os.Getenv("SWITCHBOARD_ROUTER_DELIVERY_SAMPLE_RATE"); rate != "" {
+		if sampleRate, err := strconv.ParseFloat(rate, 64); err == nil {
+			config.Router.DeliverySampleRate = sampleRate
+		}
+	}
+	
+	if rows := os.Getenv("SWITCHBOARD_ROUTER_DELIVERY_FAILURE_ROWS"); rows != "" {
+		if n, err := strconv.Atoi(rows); err == nil {
+			config.Router.DeliveryFailureRows = n
+		}
+	}
+	
+	// FUNCTIONAL DISCOVERY: Comma-separated type=context pairs, e.g.
+	// "analytics=engagement,request=code"; they replace the file's mapping entirely
+	if contexts := os.Getenv("SWITCHBOARD_ROUTER_DEFAULT_CONTEXTS"); contexts != "" {
+		defaults := make(map[string]string)
+		for _, pair := range splitList(contexts) {
+			if messageType, context, ok := strings.Cut(pair, "="); ok {
+				defaults[strings.TrimSpace(messageType)] = strings.TrimSpace(context)
+			}
+		}
+		config.Router.DefaultContexts = defaults
+	}
+	
+	if dbPath := os.Getenv("SWITCHBOARD_DATABASE_PATH"); dbPath != "" {
+		config.Database.Path = dbPath
+	}
+	
+	if readTimeout := os.Getenv("SWITCHBOARD_HTTP_READ_TIMEOUT"); readTimeout != "" {
+		if timeout, err := time.ParseDuration(readTimeout); err == nil {
+			config.HTTP.ReadTimeout = timeout
+		}
+	}
+	
+	if writeTimeout := os.Getenv("SWITCHBOARD_HTTP_WRITE_TIMEOUT"); writeTimeout != "" {
+		if timeout, err := time.ParseDuration(writeTimeout); err == nil {
+			config.HTTP.WriteTimeout = timeout
+		}
+	}
+	
+	if dbTimeout := os.Getenv("SWITCHBOARD_DATABASE_TIMEOUT"); dbTimeout != "" {
+		if timeout, err := time.ParseDuration(dbTimeout); err == nil {
+			config.Database.Timeout = timeout
+		}
+	}
+	
+	if batchSize := os.Getenv("SWITCHBOARD_DATABASE_IMPORT_BATCH_SIZE"); batchSize != "" {
+		if size, err := strconv.Atoi(batchSize); err == nil {
+			config.Database.ImportBatchSize = size
+		}
+	}
+	
+	if compact := os.Getenv("SWITCHBOARD_DATABASE_COMPACT_CONTENT"); compact != "" {
+		if enabled, err := strconv.ParseBool(compact); err == nil {
+			config.Database.CompactContent = enabled
+		}
+	}
+	
+	if noiseKeys := os.Getenv("SWITCHBOARD_DATABASE_CONTENT_NOISE_KEYS"); noiseKeys != "" {
+		config.Database.ContentNoiseKeys = splitList(noiseKeys)
+	}
+	
+	if dedupThreshold := os.Getenv("SWITCHBOARD_DATABASE_DEDUP_THRESHOLD"); dedupThreshold != "" {
+		if threshold, err := strconv.Atoi(dedupThreshold); err == nil {
+			config.Database.DedupThreshold = threshold
+		}
+	}
+	
+	if embedded := os.Getenv("SWITCHBOARD_DATABASE_EMBEDDED_MIGRATIONS"); embedded != "" {
+		if enabled, err := strconv.ParseBool(embedded); err == nil {
+			config.Database.EmbeddedMigrations = enabled
+		}
+	}
+	
+	if lockRecovery := os.Getenv("SWITCHBOARD_DATABASE_LOCK_RECOVERY_TIMEOUT"); lockRecovery != "" {
+		if timeout, err := time.ParseDuration(lockRecovery); err == nil {
+			config.Database.LockRecoveryTimeout = timeout
+		}
+	}
+	
+	if pingInterval := os.Getenv("SWITCHBOARD_WEBSOCKET_PING_INTERVAL"); pingInterval != "" {
+		if interval, err := time.ParseDuration(pingInterval); err == nil {
+			config.WebSocket.PingInterval = interval
+		}
+	}
+	
+	if wsReadTimeout := os.Getenv("SWITCHBOARD_WEBSOCKET_READ_TIMEOUT"); wsReadTimeout != "" {
+		if timeout, err := time.ParseDuration(wsReadTimeout); err == nil {
+			config.WebSocket.ReadTimeout = timeout
+		}
+	}
+	
+	if wsWriteTimeout := os.Getenv("SWITCHBOARD_WEBSOCKET_WRITE_TIMEOUT"); wsWriteTimeout != "" {
+		if timeout, err := time.ParseDuration(wsWriteTimeout); err == nil {
+			config.WebSocket.WriteTimeout = timeout
+		}
+	}
+	
+	if bufferSize := os.Getenv("SWITCHBOARD_WEBSOCKET_BUFFER_SIZE"); bufferSize != "" {
+		if size, err := strconv.Atoi(bufferSize); err == nil {
+			config.WebSocket.BufferSize = size
+		}
+	}
+	
+	if batchSize := os.Getenv("SWITCHBOARD_WEBSOCKET_HISTORY_BATCH_SIZE"); batchSize != "" {
+		if size, err := strconv.Atoi(batchSize); err == nil {
+			config.WebSocket.HistoryBatchSize = size
+		}
+	}
+	
+	if batchDelay := os.Getenv("SWITCHBOARD_WEBSOCKET_HISTORY_BATCH_DELAY"); batchDelay != "" {
+		if delay, err := time.ParseDuration(batchDelay); err == nil {
+			config.WebSocket.HistoryBatchDelay = delay
+		}
+	}
+	
+	if maxReplay := os.Getenv("SWITCHBOARD_WEBSOCKET_MAX_REPLAY_MESSAGES"); maxReplay != "" {
+		if n, err := strconv.Atoi(maxReplay); err == nil {
+			config.WebSocket.MaxReplayMessages = n
+		}
+	}
+	
+	if admissionRate := os.Getenv("SWITCHBOARD_WEBSOCKET_ADMISSION_RATE"); admissionRate != "" {
+		if rate, err := strconv.Atoi(admissionRate); err == nil {
+			config.WebSocket.AdmissionRate = rate
+		}
+	}
+	
+	if admissionWindow := os.Getenv("SWITCHBOARD_WEBSOCKET_ADMISSION_WINDOW"); admissionWindow != "" {
+		if window, err := time.ParseDuration(admissionWindow); err == nil {
+			config.WebSocket.AdmissionWindow = window
+		}
+	}
+	
+	if drainWindow := os.Getenv("SWITCHBOARD_WEBSOCKET_DRAIN_WINDOW"); drainWindow != "" {
+		if window, err := time.ParseDuration(drainWindow); err == nil {
+			config.WebSocket.DrainWindow = window
+		}
+	}
+	
+	if duplicatePolicy := os.Getenv("SWITCHBOARD_WEBSOCKET_DUPLICATE_USER_POLICY"); duplicatePolicy != "" {
+		config.WebSocket.DuplicateUserPolicy = duplicatePolicy
+	}
+	
+	if mismatchPolicy := os.Getenv("SWITCHBOARD_WEBSOCKET_SESSION_MISMATCH_POLICY"); mismatchPolicy != "" {
+		config.WebSocket.SessionMismatchPolicy = mismatchPolicy
+	}
+	
+	if profiling := os.Getenv("SWITCHBOARD_DEBUG_ENABLE_PROFILING"); profiling != "" {
+		if enabled, err := strconv.ParseBool(profiling); err == nil {
+			config.Debug.EnableProfiling = enabled
+		}
+	}
+	
+	if debugAddr := os.Getenv("SWITCHBOARD_DEBUG_ADDR"); debugAddr != "" {
+		config.Debug.Addr = debugAddr
+	}
+	
+	if offsets := os.Getenv("SWITCHBOARD_SESSIONS_WARNING_OFFSETS"); offsets != "" {
+		if parsed, err := parseDurations(splitList(offsets)); err == nil {
+			config.Sessions.WarningOffsets = parsed
+		}
+	}
+	
+	if budget := os.Getenv("SWITCHBOARD_SESSIONS_HOOK_BUDGET"); budget != "" {
+		if d, err := time.ParseDuration(budget); err == nil {
+			config.Sessions.HookBudget = d
+		}
+	}
+	
+	if policy := os.Getenv("SWITCHBOARD_SESSIONS_NAME_POLICY"); policy != "" {
+		config.Sessions.NamePolicy = policy
+	}
+	
+	if grace := os.Getenv("SWITCHBOARD_SESSIONS_OWNER_TRANSFER_GRACE"); grace != "" {
+		if d, err := time.ParseDuration(grace); err == nil {
+			config.Sessions.OwnerTransferGrace = d
+		}
+	}
+	
+	if window := os.Getenv("SWITCHBOARD_SESSIONS_MESSAGE_EDIT_WINDOW"); window != "" {
+		if d, err := time.ParseDuration(window); err == nil {
+			config.Sessions.MessageEditWindow = d
+		}
+	}
+	
+	if interval := os.Getenv("SWITCHBOARD_SESSIONS_ACTIVITY_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			config.Sessions.ActivityInterval = d
+		}
+	}
+	
+	if idle := os.Getenv("SWITCHBOARD_SESSIONS_IDLE_END_AFTER"); idle != "" {
+		if d, err := time.ParseDuration(idle); err == nil {
+			config.Sessions.IdleEndAfter = d
+		}
+	}
+	
+	if exempt := os.Getenv("SWITCHBOARD_SESSIONS_LOCK_EXEMPT_ANALYTICS"); exempt != "" {
+		if enabled, err := strconv.ParseBool(exempt); err == nil {
+			config.Sessions.LockExemptAnalytics = enabled
+		}
+	}
+	
+	if idempotent := os.Getenv("SWITCHBOARD_SESSIONS_IDEMPOTENT_END"); idempotent != "" {
+		if enabled, err := strconv.ParseBool(idempotent); err == nil {
+			config.Sessions.IdempotentEnd = enabled
+		}
+	}
+	
+	if maxStudents := os.Getenv("SWITCHBOARD_SESSIONS_MAX_STUDENTS"); maxStudents != "" {
+		if n, err := strconv.Atoi(maxStudents); err == nil {
+			config.Sessions.MaxStudents = n
+		}
+	}
+	
+	if scope := os.Getenv("SWITCHBOARD_SESSIONS_INSTRUCTOR_SCOPE"); scope != "" {
+		config.Sessions.InstructorScope = scope
+	}
+	
+	if creators := os.Getenv("SWITCHBOARD_SESSIONS_CREATORS"); creators != "" {
+		config.Sessions.Creators = creators
+	}
+	
+	if allowlist := os.Getenv("SWITCHBOARD_SESSIONS_CREATOR_ALLOWLIST"); allowlist != "" {
+		config.Sessions.CreatorAllowlist = splitList(allowlist)
+	}
+	
+	if maxActive := os.Getenv("SWITCHBOARD_SESSIONS_MAX_ACTIVE_PER_CREATOR"); maxActive != "" {
+		if n, err := strconv.Atoi(maxActive); err == nil {
+			config.Sessions.MaxActivePerCreator = n
+		}
+	}
+	
+	if interval := os.Getenv("SWITCHBOARD_WATCHDOG_CHECK_INTERVAL"); interval != "" {
+		if d, err := time.ParseDuration(interval); err == nil {
+			config.Watchdog.CheckInterval = d
+		}
+	}
+	
+	if minFree := os.Getenv("SWITCHBOARD_WATCHDOG_MIN_FREE_BYTES"); minFree != "" {
+		if bytes, err := strconv.ParseUint(minFree, 10, 64); err == nil {
+			config.Watchdog.MinFreeBytes = bytes
+		}
+	}
+	
+	if minRate := os.Getenv("SWITCHBOARD_WATCHDOG_MIN_WRITE_SUCCESS_RATE"); minRate != "" {
+		if rate, err := strconv.ParseFloat(minRate, 64); err == nil {
+			config.Watchdog.MinWriteSuccessRate = rate
+		}
+	}
+	
+	if routeOnly := os.Getenv("SWITCHBOARD_WATCHDOG_ROUTE_ONLY"); routeOnly != "" {
+		if enabled, err := strconv.ParseBool(routeOnly); err == nil {
+			config.Watchdog.RouteOnly = enabled
+		}
+	}
+	
+	if maxConnections := os.Getenv("SWITCHBOARD_SCALING_MAX_CONNECTIONS"); maxConnections != "" {
+		if limit, err := strconv.Atoi(maxConnections); err == nil {
+			config.Scaling.MaxConnections = limit
+		}
+	}
+	
+	if scaleUp := os.Getenv("SWITCHBOARD_SCALING_SCALE_UP_PERCENT"); scaleUp != "" {
+		if percent, err := strconv.ParseFloat(scaleUp, 64); err == nil {
+			config.Scaling.ScaleUpPercent = percent
+		}
+	}
+	
+	if scaleDown := os.Getenv("SWITCHBOARD_SCALING_SCALE_DOWN_PERCENT"); scaleDown != "" {
+		if percent, err := strconv.ParseFloat(scaleDown, 64); err == nil {
+			config.Scaling.ScaleDownPercent = percent
+		}
+	}
+	
+	if queueScaleUp := os.Getenv("SWITCHBOARD_SCALING_QUEUE_SCALE_UP_PERCENT"); queueScaleUp != "" {
+		if percent, err := strconv.ParseFloat(queueScaleUp, 64); err == nil {
+			config.Scaling.QueueScaleUpPercent = percent
+		}
+	}
+	
+	if transcriptDir := os.Getenv("SWITCHBOARD_TRANSCRIPTS_DIR"); transcriptDir != "" {
+		config.Transcripts.Dir = transcriptDir
+	}
+	
+	if flushInterval := os.Getenv("SWITCHBOARD_TRANSCRIPTS_FLUSH_INTERVAL"); flushInterval != "" {
+		if interval, err := time.ParseDuration(flushInterval); err == nil {
+			config.Transcripts.FlushInterval = interval
+		}
+	}
+	
+	if maxBytes := os.Getenv("SWITCHBOARD_TRANSCRIPTS_MAX_FILE_BYTES"); maxBytes != "" {
+		if size, err := strconv.ParseInt(maxBytes, 10, 64); err == nil {
+			config.Transcripts.MaxFileBytes = size
+		}
+	}
+	
+	if snapshotPath := os.Getenv("SWITCHBOARD_SNAPSHOT_PATH"); snapshotPath != "" {
+		config.Snapshot.Path = snapshotPath
+	}
+	
+	if snapshotInterval := os.Getenv("SWITCHBOARD_SNAPSHOT_INTERVAL"); snapshotInterval != "" {
+		if interval, err := time.ParseDuration(snapshotInterval); err == nil {
+			config.Snapshot.Interval = interval
+		}
+	}
+	
+	if selfTestInterval := os.Getenv("SWITCHBOARD_SELFTEST_INTERVAL"); selfTestInterval != "" {
+		if interval, err := time.ParseDuration(selfTestInterval); err == nil {
+			config.SelfTest.Interval = interval
+		}
+	}
+	
+	if failureThreshold := os.Getenv("SWITCHBOARD_SELFTEST_FAILURE_THRESHOLD"); failureThreshold != "" {
+		if threshold, err := strconv.Atoi(failureThreshold); err == nil {
+			config.SelfTest.FailureThreshold = threshold
+		}
+	}
+	
+	if sink := os.Getenv("SWITCHBOARD_ANALYTICS_SINK"); sink != "" {
+		config.Analytics.Sink = strings.ToLower(sink)
+	}
+	
+	if filePath := os.Getenv("SWITCHBOARD_ANALYTICS_FILE_PATH"); filePath != "" {
+		config.Analytics.FilePath = filePath
+	}
+	
+	if analyticsURL := os.Getenv("SWITCHBOARD_ANALYTICS_URL"); analyticsURL != "" {
+		config.Analytics.URL = analyticsURL
+	}
+	
+	if batchSize := os.Getenv("SWITCHBOARD_ANALYTICS_BATCH_SIZE"); batchSize != "" {
+		if size, err := strconv.Atoi(batchSize); err == nil {
+			config.Analytics.BatchSize = size
+		}
+	}
+	
+	if flushInterval := os.Getenv("SWITCHBOARD_ANALYTICS_FLUSH_INTERVAL"); flushInterval != "" {
+		if interval, err := time.ParseDuration(flushInterval); err == nil {
+			config.Analytics.FlushInterval = interval
+		}
+	}
+	
+	if maxRetries := os.Getenv("SWITCHBOARD_ANALYTICS_MAX_RETRIES"); maxRetries != "" {
+		if retries, err := strconv.Atoi(maxRetries); err == nil {
+			config.Analytics.MaxRetries = retries
+		}
+	}
+	
+	if queueSize := os.Getenv("SWITCHBOARD_ANALYTICS_QUEUE_SIZE"); queueSize != "" {
+		if size, err := strconv.Atoi(queueSize); err == nil {
+			config.Analytics.QueueSize = size
+		}
+	}
+	
+	if alertingInterval := os.Getenv("SWITCHBOARD_ALERTING_INTERVAL"); alertingInterval != "" {
+		if interval, err := time.ParseDuration(alertingInterval); err == nil {
+			config.Alerting.Interval = interval
+		}
+	}
+	
+	if defaultRules := os.Getenv("SWITCHBOARD_ALERTING_DEFAULT_RULES"); defaultRules != "" {
+		if enabled, err := strconv.ParseBool(defaultRules); err == nil {
+			config.Alerting.DefaultRules = enabled
+		}
+	}
+	
+	if defaultActions := os.Getenv("SWITCHBOARD_ALERTING_DEFAULT_ACTIONS"); defaultActions != "" {
+		config.Alerting.DefaultActions = splitList(strings.ToLower(defaultActions))
+	}
+	
+	if webhookURL := os.Getenv("SWITCHBOARD_ALERTING_WEBHOOK_URL"); webhookURL != "" {
+		config.Alerting.WebhookURL = webhookURL
+	}
+	
+	if level := os.Getenv("SWITCHBOARD_LOG_LEVEL"); level != "" {
+		config.Logging.Level = strings.ToLower(level)
+	}
+}
+
+// isValidAttachmentMIME accepts a media type without parameters, or "type/*"
+func isValidAttachmentMIME(mimeType string) bool {
+	if topLevel, found := strings.CutSuffix(mimeType, "/*"); found {
+		mimeType = topLevel + "/x"
+	}
+	mediaType, params, err := mime.ParseMediaType(mimeType)
+	return err == nil && len(params) == 0 && strings.Contains(mediaType, "/") && strings.EqualFold(mediaType, mimeType)
+}
+
+// splitList parses a comma-separated environment value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseDurations parses a list of duration strings, failing on the first invalid entry
+func parseDurations(values []string) ([]time.Duration, error) {
+	durations := make([]time.Duration, 0, len(values))
+	for _, value := range values {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, d)
+	}
+	return durations, nil
+}
+
+// ConfigFile represents the JSON structure for file-based configuration
+// FUNCTIONAL DISCOVERY: Separate struct for JSON parsing to handle duration strings
+type ConfigFile struct {
+	Database    *DatabaseConfigFile    `json:"database"`
+	HTTP        *HTTPConfigFile        `json:"http"`
+	WebSocket   *WebSocketConfigFile   `json:"websocket"`
+	Debug       *DebugConfigFile       `json:"debug"`
+	Privacy     *PrivacyConfigFile     `json:"privacy"`
+	Transcripts *TranscriptsConfigFile `json:"transcripts"`
+	Analytics   *AnalyticsConfigFile   `json:"analytics"`
+	Sessions    *SessionsConfigFile    `json:"sessions"`
+	Watchdog    *WatchdogConfigFile    `json:"watchdog"`
+	Logging     *LoggingConfigFile     `json:"logging"`
+	Snapshot    *SnapshotConfigFile    `json:"snapshot"`
+	Router      *RouterConfigFile      `json:"router"`
+	Scaling     *ScalingConfigFile     `json:"scaling"`
+	SelfTest    *SelfTestConfigFile    `json:"self_test"`
+	Alerting    *AlertingConfigFile    `json:"alerting"`
+}
+
+type DatabaseConfigFile struct {
+	Path                string   `json:"path"`
+	Timeout             string   `json:"timeout"`
+	ImportBatchSize     int      `json:"import_batch_size"`
+	CompactContent      *bool    `json:"compact_content"` // pointer distinguishes "false" from "unset"
+	ContentNoiseKeys    []string `json:"content_noise_keys"`
+	DedupThreshold      *int     `json:"dedup_threshold"` // pointer so 0 can turn sharing off
+	EmbeddedMigrations  *bool    `json:"embedded_migrations"`
+	LockRecoveryTimeout string   `json:"lock_recovery_timeout"` // "0s" fails fast
+}
+
+type HTTPConfigFile struct {
+	Port           int      `json:"port"`
+	ReadTimeout    string   `json:"read_timeout"`
+	WriteTimeout   string   `json:"write_timeout"`
+	Host           string   `json:"host"`
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+type WebSocketConfigFile struct {
+	PingInterval string `json:"ping_interval"`
+	ReadTimeout  string `json:"read_timeout"`
+	WriteTimeout string `json:"write_timeout"`
+	BufferSize   int    `json:"buffer_size"`
 	
-	// Override with environment variables
-	envConfig := LoadFromEnv()
-	if envConfig != nil {
-		config = envConfig
+	HistoryBatchSize  int    `json:"history_batch_size"`
+	HistoryBatchDelay string `json:"history_batch_delay"`
+	MaxReplayMessages *int   `json:"max_replay_messages"` // pointer allows an explicit 0
+	
+	AdmissionRate   int    `json:"admission_rate"`
+	AdmissionWindow string `json:"admission_window"` // "0s" turns pacing off
+	
+	DuplicateUserPolicy   string `json:"duplicate_user_policy"`
+	SessionMismatchPolicy string `json:"session_mismatch_policy"`
+	
+	DrainWindow string `json:"drain_window"`
+}
+
+type DebugConfigFile struct {
+	EnableProfiling bool   `json:"enable_profiling"`
+	Addr            string `json:"addr"`
+}
+
+type PrivacyConfigFile struct {
+	RedactIPs     *bool    `json:"redact_ips"` // pointer distinguishes "false" from "unset"
+	ScrubFields   []string `json:"scrub_fields"`
+	ScrubPatterns []string `json:"scrub_patterns"` // A present list replaces the default; [] scrubs no patterns
+	
+	MessageMetadataEndpoint bool `json:"message_metadata_endpoint"`
+}
+
+type SessionsConfigFile struct {
+	WarningOffsets      []string `json:"warning_offsets"` // duration strings, e.g. ["10m", "2m"]
+	HookBudget          string   `json:"hook_budget"`     // duration string, e.g. "5s"
+	NamePolicy          string   `json:"name_policy"`
+	OwnerTransferGrace  string   `json:"owner_transfer_grace"` // duration string, e.g. "5m"
+	LockExemptAnalytics *bool    `json:"lock_exempt_analytics"` // pointer distinguishes "false" from "unset"
+	IdempotentEnd       *bool    `json:"idempotent_end"`
+	MaxStudents         int      `json:"max_students"`
+	InstructorScope     string   `json:"instructor_scope"`
+	MessageEditWindow   string   `json:"message_edit_window"` // duration string, e.g. "15m"
+	ActivityInterval    string   `json:"activity_interval"`   // duration string, e.g. "30s"
+	IdleEndAfter        string   `json:"idle_end_after"`      // duration string, e.g. "4h"; "0s" resumes every session
+	Creators            string   `json:"creators"`
+	CreatorAllowlist    []string `json:"creator_allowlist"`
+	MaxActivePerCreator *int     `json:"max_active_per_creator"` // pointer allows an explicit 0
+}
+
+type WatchdogConfigFile struct {
+	CheckInterval       string   `json:"check_interval"`
+	MinFreeBytes        uint64   `json:"min_free_bytes"`
+	MinWriteSuccessRate *float64 `json:"min_write_success_rate"` // pointer allows an explicit 0
+	RouteOnly           bool     `json:"route_only"`
+}
+
+type LoggingConfigFile struct {
+	Level string `json:"level"`
+}
+
+type TranscriptsConfigFile struct {
+	Dir           string `json:"dir"`
+	FlushInterval string `json:"flush_interval"`
+	MaxFileBytes  int64  `json:"max_file_bytes"`
+}
+
+type RouterConfigFile struct {
+	ContentAllowlist         map[string][]string `json:"content_allowlist"`
+	StrictContent            *bool               `json:"strict_content"`         // pointer distinguishes "false" from "unset"
+	BroadcastDedupWindow     string              `json:"broadcast_dedup_window"` // duration string, e.g. "3s"
+	DiagnosticsSampleRate    *float64            `json:"diagnostics_sample_rate"`
+	RejectLateSubmissions    *bool               `json:"reject_late_submissions"`
+	DefaultContexts          map[string]string   `json:"default_contexts"`
+	PersistUnknownRecipients *bool               `json:"persist_unknown_recipients"`
+	RateLimitMessages        int                 `json:"rate_limit_messages"`
+	RateLimitWindow          string              `json:"rate_limit_window"` // duration string, e.g. "1m"
+	ReactionCodes            []string            `json:"reaction_codes"`
+	HubWorkers               int                 `json:"hub_workers"`
+	MaxBroadcastRecipients   int                 `json:"max_broadcast_recipients"`
+	AttachmentMIMETypes      []string            `json:"attachment_mime_types"`
+	MaxAttachmentBytes       int                 `json:"max_attachment_bytes"`
+	StrictAttachments        *bool               `json:"strict_attachments"`
+	DeliverySampleAbove      int                 `json:"delivery_sample_above"`
+	DeliverySampleRate       *float64            `json:"delivery_sample_rate"`
+	DeliveryFailureRows      int                 `json:"delivery_failure_rows"`
+}
+
+type SnapshotConfigFile struct {
+	Path        string `json:"path"`
+	Interval    string `json:"interval"` // duration string, e.g. "30s"
+	RestoreFrom string `json:"restore_from"`
+}
+
+type SelfTestConfigFile struct {
+	Interval         string `json:"interval"` // duration string, e.g. "30s"
+	FailureThreshold int    `json:"failure_threshold"`
+}
+
+type AnalyticsConfigFile struct {
+	Sink          string `json:"sink"`
+	FilePath      string `json:"file_path"`
+	URL           string `json:"url"`
+	BatchSize     int    `json:"batch_size"`
+	FlushInterval string `json:"flush_interval"`
+	MaxRetries    *int   `json:"max_retries"` // pointer allows an explicit 0
+	QueueSize     int    `json:"queue_size"`
+}
+
+type AlertingConfigFile struct {
+	Interval       string          `json:"interval"`      // duration string, e.g. "15s"; "0s" disables alerting
+	DefaultRules   *bool           `json:"default_rules"` // pointer distinguishes "false" from "unset"
+	DefaultActions []string        `json:"default_actions"`
+	WebhookURL     string          `json:"webhook_url"`
+	Rules          []AlertRuleFile `json:"rules"`
+}
+
+type AlertRuleFile struct {
+	Name       string   `json:"name"`
+	Metric     string   `json:"metric"`
+	Threshold  float64  `json:"threshold"`
+	For        string   `json:"for"` // duration string, e.g. "1m"; empty fires on the first breach
+	MinSamples uint64   `json:"min_samples"`
+	Actions    []string `json:"actions"`
+}
+
+type ScalingConfigFile struct {
+	MaxConnections      int      `json:"max_connections"`
+	ScaleUpPercent      *float64 `json:"scale_up_percent"`
+	ScaleDownPercent    *float64 `json:"scale_down_percent"` // pointer allows an explicit 0
+	QueueScaleUpPercent *float64 `json:"queue_scale_up_percent"`
+}
+
+// FUNCTIONAL DISCOVERY: File-based configuration supports complex deployment scenarios
+// JSON format chosen for readability and tooling support
+func LoadFromFile(filepath string) (*Config, error) {
+	config := DefaultConfig()
+	if err := applyFile(config, filepath); err != nil {
+		return nil, err
+	}
+	
+	// ARCHITECTURAL DISCOVERY: Validate configuration after loading to catch errors early
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration in %s: %w", filepath, err)
+	}
+	
+	return config, nil
+}
+
+// applyFile overrides config with the settings present in a JSON config file
+// TECHNICAL DISCOVERY: Only keys present in the file change config, so the file can be
+// layered over defaults and under environment variables and flags
+func applyFile(config *Config, filepath string) error {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", filepath, err)
+	}
+	
+	var configFile ConfigFile
+	if err := json.Unmarshal(data, &configFile); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", filepath, err)
+	}
+	
+	// Convert to runtime config with duration parsing
+	if configFile.Database != nil {
+		config.Database.Path = configFile.Database.Path
+		if configFile.Database.Timeout != "" {
+			if timeout, err := time.ParseDuration(configFile.Database.Timeout); err == nil {
+				config.Database.Timeout = timeout
+			}
+		}
+		if configFile.Database.ImportBatchSize > 0 {
+			config.Database.ImportBatchSize = configFile.Database.ImportBatchSize
+		}
+		if configFile.Database.CompactContent != nil {
+			config.Database.CompactContent = *configFile.Database.CompactContent
+		}
+		if configFile.Database.ContentNoiseKeys != nil {
+			config.Database.ContentNoiseKeys = configFile.Database.ContentNoiseKeys
+		}
+		if configFile.Database.DedupThreshold != nil {
+			config.Database.DedupThreshold = *configFile.Database.DedupThreshold
+		}
+		if configFile.Database.EmbeddedMigrations != nil {
+			config.Database.EmbeddedMigrations = *configFile.Database.EmbeddedMigrations
+		}
+		if configFile.Database.LockRecoveryTimeout != "" {
+			if timeout, err := time.ParseDuration(configFile.Database.LockRecoveryTimeout); err == nil {
+				config.Database.LockRecoveryTimeout = timeout
+			}
+		}
+	}
+	
+	if configFile.HTTP != nil {
+		if configFile.HTTP.Port > 0 {
+			config.HTTP.Port = configFile.HTTP.Port
+		}
+		if configFile.HTTP.Host != "" {
+			config.HTTP.Host = configFile.HTTP.Host
+		}
+		if configFile.HTTP.ReadTimeout != "" {
+			if timeout, err := time.ParseDuration(configFile.HTTP.ReadTimeout); err == nil {
+				config.HTTP.ReadTimeout = timeout
+			}
+		}
+		if configFile.HTTP.WriteTimeout != "" {
+			if timeout, err := time.ParseDuration(configFile.HTTP.WriteTimeout); err == nil {
+				config.HTTP.WriteTimeout = timeout
+			}
+		}
+		if len(configFile.HTTP.TrustedProxies) > 0 {
+			config.HTTP.TrustedProxies = configFile.HTTP.TrustedProxies
+		}
+	}
+	
+	if configFile.WebSocket != nil {
+		if configFile.WebSocket.BufferSize > 0 {
+			config.WebSocket.BufferSize = configFile.WebSocket.BufferSize
+		}
+		if configFile.WebSocket.PingInterval != "" {
+			if interval, err := time.ParseDuration(configFile.WebSocket.PingInterval); err == nil {
+				config.WebSocket.PingInterval = interval
+			}
+		}
+		if configFile.WebSocket.ReadTimeout != "" {
+			if timeout, err := time.ParseDuration(configFile.WebSocket.ReadTimeout); err == nil {
+				config.WebSocket.ReadTimeout = timeout
+			}
+		}
+		if configFile.WebSocket.WriteTimeout != "" {
+			if timeout, err := time.ParseDuration(configFile.WebSocket.WriteTimeout); err == nil {
+				config.WebSocket.WriteTimeout = timeout
+			}
+		}
+		if configFile.WebSocket.HistoryBatchSize > 0 {
+			config.WebSocket.HistoryBatchSize = configFile.WebSocket.HistoryBatchSize
+		}
+		if configFile.WebSocket.HistoryBatchDelay != "" {
+			if delay, err := time.ParseDuration(configFile.WebSocket.HistoryBatchDelay); err == nil {
+				config.WebSocket.HistoryBatchDelay = delay
+			}
+		}
+		if configFile.WebSocket.MaxReplayMessages != nil {
+			config.WebSocket.MaxReplayMessages = *configFile.WebSocket.MaxReplayMessages
+		}
+		if configFile.WebSocket.AdmissionRate > 0 {
+			config.WebSocket.AdmissionRate = configFile.WebSocket.AdmissionRate
+		}
+		if configFile.WebSocket.AdmissionWindow != "" {
+			if window, err := time.ParseDuration(configFile.WebSocket.AdmissionWindow); err == nil {
+				config.WebSocket.AdmissionWindow = window
+			}
+		}
+		if configFile.WebSocket.DuplicateUserPolicy != "" {
+			config.WebSocket.DuplicateUserPolicy = configFile.WebSocket.DuplicateUserPolicy
+		}
+		if configFile.WebSocket.SessionMismatchPolicy != "" {
+			config.WebSocket.SessionMismatchPolicy = configFile.WebSocket.SessionMismatchPolicy
+		}
+		if configFile.WebSocket.DrainWindow != "" {
+			if window, err := time.ParseDuration(configFile.WebSocket.DrainWindow); err == nil {
+				config.WebSocket.DrainWindow = window
+			}
+		}
+	}
+	
+	if configFile.Debug != nil {
+		config.Debug.EnableProfiling = configFile.Debug.EnableProfiling
+		config.Debug.Addr = configFile.Debug.Addr
+	}
+	
+	if configFile.Privacy != nil && configFile.Privacy.RedactIPs != nil {
+		config.Privacy.RedactIPs = *configFile.Privacy.RedactIPs
 	}
+	if configFile.Privacy != nil && configFile.Privacy.ScrubFields != nil {
+		config.Privacy.ScrubFields = configFile.Privacy.ScrubFields
+	}
+	if configFile.Privacy != nil && configFile.Privacy.ScrubPatterns != nil {
+		config.Privacy.ScrubPatterns = configFile.Privacy.ScrubPatterns
+	}
+	if configFile.Privacy != nil && configFile.Privacy.MessageMetadataEndpoint {
+		config.Privacy.MessageMetadataEndpoint = true
+	}
+	
+	// FUNCTIONAL DISCOVERY: A present warning_offsets list replaces the defaults;
+	// an empty list disables countdown warnings while keeping auto-end
+	if configFile.Sessions != nil && configFile.Sessions.WarningOffsets != nil {
+		offsets, err := parseDurations(configFile.Sessions.WarningOffsets)
+		if err != nil {
+			return fmt.Errorf("invalid session warning offset in %s: %w", filepath, err)
+		}
+		config.Sessions.WarningOffsets = offsets
+	}
+	if configFile.Sessions != nil && configFile.Sessions.HookBudget != "" {
+		budget, err := time.ParseDuration(configFile.Sessions.HookBudget)
+		if err != nil {
+			return fmt.Errorf("invalid session hook budget in %s: %w", filepath, err)
+		}
+		config.Sessions.HookBudget = budget
+	}
+	if configFile.Sessions != nil && configFile.Sessions.NamePolicy != "" {
+		config.Sessions.NamePolicy = configFile.Sessions.NamePolicy
+	}
+	if configFile.Sessions != nil && configFile.Sessions.OwnerTransferGrace != "" {
+		grace, err := time.ParseDuration(configFile.Sessions.OwnerTransferGrace)
+		if err != nil {
+			return fmt.Errorf("invalid session owner transfer grace in %s: %w", filepath, err)
+		}
+		config.Sessions.OwnerTransferGrace = grace
+	}
+	if configFile.Sessions != nil && configFile.Sessions.MessageEditWindow != "" {
+		window, err := time.ParseDuration(configFile.Sessions.MessageEditWindow)
+		if err != nil {
+			return fmt.Errorf("invalid session message edit window in %s: %w", filepath, err)
+		}
+		config.Sessions.MessageEditWindow = window
+	}
+	if configFile.Sessions != nil && configFile.Sessions.ActivityInterval != "" {
+		interval, err := time.ParseDuration(configFile.Sessions.ActivityInterval)
+		if err != nil {
+			return fmt.Errorf("invalid session activity interval in %s: %w", filepath, err)
+		}
+		config.Sessions.ActivityInterval = interval
+	}
+	if configFile.Sessions != nil && configFile.Sessions.IdleEndAfter != "" {
+		idle, err := time.ParseDuration(configFile.Sessions.IdleEndAfter)
+		if err != nil {
+			return fmt.Errorf("invalid session idle end after in %s: %w", filepath, err)
+		}
+		config.Sessions.IdleEndAfter = idle
+	}
+	if configFile.Sessions != nil && configFile.Sessions.LockExemptAnalytics != nil {
+		config.Sessions.LockExemptAnalytics = *configFile.Sessions.LockExemptAnalytics
+	}
+	if configFile.Sessions != nil && configFile.Sessions.IdempotentEnd != nil {
+		config.Sessions.IdempotentEnd = *configFile.Sessions.IdempotentEnd
+	}
+	if configFile.Sessions != nil && configFile.Sessions.MaxStudents != 0 {
+		config.Sessions.MaxStudents = configFile.Sessions.MaxStudents
+	}
+	if configFile.Sessions != nil && configFile.Sessions.InstructorScope != "" {
+		config.Sessions.InstructorScope = configFile.Sessions.InstructorScope
+	}
+	if configFile.Sessions != nil && configFile.Sessions.Creators != "" {
+		config.Sessions.Creators = configFile.Sessions.Creators
+	}
+	if configFile.Sessions != nil && configFile.Sessions.CreatorAllowlist != nil {
+		config.Sessions.CreatorAllowlist = configFile.Sessions.CreatorAllowlist
+	}
+	if configFile.Sessions != nil && configFile.Sessions.MaxActivePerCreator != nil {
+		config.Sessions.MaxActivePerCreator = *configFile.Sessions.MaxActivePerCreator
+	}
+	
+	if configFile.Watchdog != nil {
+		if configFile.Watchdog.CheckInterval != "" {
+			if interval, err := time.ParseDuration(configFile.Watchdog.CheckInterval); err == nil {
+				config.Watchdog.CheckInterval = interval
+			}
+		}
+		if configFile.Watchdog.MinFreeBytes > 0 {
+			config.Watchdog.MinFreeBytes = configFile.Watchdog.MinFreeBytes
+		}
+		if configFile.Watchdog.MinWriteSuccessRate != nil {
+			config.Watchdog.MinWriteSuccessRate = *configFile.Watchdog.MinWriteSuccessRate
+		}
+		config.Watchdog.RouteOnly = configFile.Watchdog.RouteOnly
+	}
+	
+	if configFile.Transcripts != nil {
+		config.Transcripts.Dir = configFile.Transcripts.Dir
+		if configFile.Transcripts.FlushInterval != "" {
+			if interval, err := time.ParseDuration(configFile.Transcripts.FlushInterval); err == nil {
+				config.Transcripts.FlushInterval = interval
+			}
+		}
+		if configFile.Transcripts.MaxFileBytes > 0 {
+			config.Transcripts.MaxFileBytes = configFile.Transcripts.MaxFileBytes
+		}
+	}
+	
+	if configFile.Snapshot != nil {
+		config.Snapshot.Path = configFile.Snapshot.Path
+		config.Snapshot.RestoreFrom = configFile.Snapshot.RestoreFrom
+		if configFile.Snapshot.Interval != "" {
+			if interval, err := time.ParseDuration(configFile.Snapshot.Interval); err == nil {
+				config.Snapshot.Interval = interval
+			}
+		}
+	}
+	
+	if configFile.SelfTest != nil {
+		if configFile.SelfTest.Interval != "" {
+			if interval, err := time.ParseDuration(configFile.SelfTest.Interval); err == nil {
+				config.SelfTest.Interval = interval
+			}
+		}
+		if configFile.SelfTest.FailureThreshold > 0 {
+			config.SelfTest.FailureThreshold = configFile.SelfTest.FailureThreshold
+		}
+	}
+	
+	// FUNCTIONAL DISCOVERY: File rules replace the configured list as a whole; the
+	// built-in rules are kept or dropped with default_rules
+	if configFile.Alerting != nil {
+		if configFile.Alerting.Interval != "" {
+			interval, err := time.ParseDuration(configFile.Alerting.Interval)
+			if err != nil {
+				return fmt.Errorf("invalid alerting interval in %s: %w", filepath, err)
+			}
+			config.Alerting.Interval = interval
+		}
+		if configFile.Alerting.DefaultRules != nil {
+			config.Alerting.DefaultRules = *configFile.Alerting.DefaultRules
+		}
+		if configFile.Alerting.DefaultActions != nil {
+			config.Alerting.DefaultActions = configFile.Alerting.DefaultActions
+		}
+		if configFile.Alerting.WebhookURL != "" {
+			config.Alerting.WebhookURL = configFile.Alerting.WebhookURL
+		}
+		if configFile.Alerting.Rules != nil {
+			rules := make([]AlertRule, len(configFile.Alerting.Rules))
+			for i, rule := range configFile.Alerting.Rules {
+				rules[i] = AlertRule{Name: rule.Name, Metric: rule.Metric, Threshold: rule.Threshold, MinSamples: rule.MinSamples, Actions: rule.Actions}
+				if rule.For != "" {
+					sustain, err := time.ParseDuration(rule.For)
+					if err != nil {
+						return fmt.Errorf("invalid for in alert rule %s in %s: %w", rule.Name, filepath, err)
+					}
+					rules[i].For = sustain
+				}
+			}
+			config.Alerting.Rules = rules
+		}
+	}
+	
+	if configFile.Analytics != nil {
+		if configFile.Analytics.Sink != "" {
+			config.Analytics.Sink = strings.ToLower(configFile.Analytics.Sink)
+		}
+		if configFile.Analytics.FilePath != "" {
+			config.Analytics.FilePath = configFile.Analytics.FilePath
+		}
+		if configFile.Analytics.URL != "" {
+			config.Analytics.URL = configFile.Analytics.URL
+		}
+		if configFile.Analytics.BatchSize > 0 {
+			config.Analytics.BatchSize = configFile.Analytics.BatchSize
+		}
+		if configFile.Analytics.FlushInterval != "" {
+			interval, err := time.ParseDuration(configFile.Analytics.FlushInterval)
+			if err != nil {
+				return fmt.Errorf("invalid analytics flush interval in %s: %w", filepath, err)
+			}
+			config.Analytics.FlushInterval = interval
+		}
+		if configFile.Analytics.MaxRetries != nil {
+			config.Analytics.MaxRetries = *configFile.Analytics.MaxRetries
+		}
+		if configFile.Analytics.QueueSize > 0 {
+			config.Analytics.QueueSize = configFile.Analytics.QueueSize
+		}
+	}
+	
+	// FUNCTIONAL DISCOVERY: File allowlists merge over defaults per message type
+	if configFile.Router != nil {
+		for messageType, paths := range configFile.Router.ContentAllowlist {
+			config.Router.ContentAllowlist[messageType] = paths
+		}
+		if configFile.Router.StrictContent != nil {
+			config.Router.StrictContent = *configFile.Router.StrictContent
+		}
+		if configFile.Router.BroadcastDedupWindow != "" {
+			window, err := time.ParseDuration(configFile.Router.BroadcastDedupWindow)
+			if err != nil {
+				return fmt.Errorf("invalid broadcast dedup window in %s: %w", filepath, err)
+			}
+			config.Router.BroadcastDedupWindow = window
+		}
+		if configFile.Router.DiagnosticsSampleRate != nil {
+			config.Router.DiagnosticsSampleRate = *configFile.Router.DiagnosticsSampleRate
+		}
+		if configFile.Router.RejectLateSubmissions != nil {
+			config.Router.RejectLateSubmissions = *configFile.Router.RejectLateSubmissions
+		}
+		if configFile.Router.PersistUnknownRecipients != nil {
+			config.Router.PersistUnknownRecipients = *configFile.Router.PersistUnknownRecipients
+		}
+		if configFile.Router.RateLimitMessages != 0 {
+			config.Router.RateLimitMessages = configFile.Router.RateLimitMessages
+		}
+		if configFile.Router.ReactionCodes != nil {
+			config.Router.ReactionCodes = configFile.Router.ReactionCodes
+		}
+		if configFile.Router.HubWorkers != 0 {
+			config.Router.HubWorkers = configFile.Router.HubWorkers
+		}
+		if configFile.Router.MaxBroadcastRecipients != 0 {
+			config.Router.MaxBroadcastRecipients = configFile.Router.MaxBroadcastRecipients
+		}
+		if configFile.Router.AttachmentMIMETypes != nil {
+			config.Router.AttachmentMIMETypes = configFile.Router.AttachmentMIMETypes
+		}
+		if configFile.Router.MaxAttachmentBytes != 0 {
+			config.Router.MaxAttachmentBytes = configFile.Router.MaxAttachmentBytes
+		}
+		if configFile.Router.StrictAttachments != nil {
+			config.Router.StrictAttachments = *configFile.Router.StrictAttachments
+		}
+		if configFile.Router.DeliverySampleAbove != 0 {
+			config.Router.DeliverySampleAbove = configFile.Router.DeliverySampleAbove
+		}
+		if configFile.Router.DeliverySampleRate != nil {
+			config.Router.DeliverySampleRate = *configFile.Router.DeliverySampleRate
+		}
+		if configFile.Router.DeliveryFailureRows != 0 {
+			config.Router.DeliveryFailureRows = configFile.Router.DeliveryFailureRows
+		}
+		if configFile.Router.RateLimitWindow != "" {
+			window, err := time.ParseDuration(configFile.Router.RateLimitWindow)
+			if err != nil {
+				return fmt.Errorf("invalid rate limit window in %s: %w", filepath, err)
+			}
+			config.Router.RateLimitWindow = window
+		}
+		for messageType, context := range configFile.Router.DefaultContexts {
+			config.Router.DefaultContexts[messageType] = context
+		}
+	}
+	
+	if configFile.Scaling != nil {
+		if configFile.Scaling.MaxConnections > 0 {
+			config.Scaling.MaxConnections = configFile.Scaling.MaxConnections
+		}
+		if configFile.Scaling.ScaleUpPercent != nil {
+			config.Scaling.ScaleUpPercent = *configFile.Scaling.ScaleUpPercent
+		}
+		if configFile.Scaling.ScaleDownPercent != nil {
+			config.Scaling.ScaleDownPercent = *configFile.Scaling.ScaleDownPercent
+		}
+		if configFile.Scaling.QueueScaleUpPercent != nil {
+			config.Scaling.QueueScaleUpPercent = *configFile.Scaling.QueueScaleUpPercent
+		}
+	}
+	
+	if configFile.Logging != nil && configFile.Logging.Level != "" {
+		config.Logging.Level = strings.ToLower(configFile.Logging.Level)
+	}
+	
+	return nil
+}
+
+// FUNCTIONAL DISCOVERY: Configuration precedence: environment > file > defaults
+// Enables flexible deployment patterns while maintaining sane defaults
+// TECHNICAL DISCOVERY: An unreadable or invalid file is ignored as a whole, so the
+// server still starts on environment and defaults; use LoadLayered to surface errors
+func LoadConfigWithPrecedence(filepath string) *Config {
+	// Start with defaults
+	config := DefaultConfig()
 	
-	// Override with file if provided and exists
+	// Override with file if provided, exists and is valid
 	if filepath != "" {
-		if fileConfig, err := LoadFromFile(filepath); err == nil {
+		fileConfig := DefaultConfig()
+		if err := applyFile(fileConfig, filepath); err == nil && fileConfig.Validate() == nil {
 			config = fileConfig
 		}
-		// Silently ignore file errors - environment/defaults still work
 	}
 	
+	// Override with environment variables
+	applyEnv(config)
+	
 	return config
-}
\ No newline at end of file
+}
+
+// Overrides holds settings given explicitly on the command line
+// FUNCTIONAL DISCOVERY: nil fields were not given and leave lower layers untouched
+type Overrides struct {
+	Host         *string
+	Port         *int
+	DatabasePath *string
+	LogLevel     *string
+	RestoreFrom  *string
+}
+
+// apply overrides config with every setting that was given
+func (o *Overrides) apply(config *Config) {
+	if o == nil {
+		return
+	}
+	if o.Host != nil {
+		config.HTTP.Host = *o.Host
+	}
+	if o.Port != nil {
+		config.HTTP.Port = *o.Port
+	}
+	if o.DatabasePath != nil {
+		config.Database.Path = *o.DatabasePath
+	}
+	if o.LogLevel != nil {
+		config.Logging.Level = strings.ToLower(*o.LogLevel)
+	}
+	if o.RestoreFrom != nil {
+		if config.Snapshot == nil {
+			config.Snapshot = &SnapshotConfig{}
+		}
+		config.Snapshot.RestoreFrom = *o.RestoreFrom
+	}
+}
+
+// LoadLayered builds the effective configuration: flags > environment > file > defaults
+// ARCHITECTURAL DISCOVERY: Each layer only changes the settings it names, so a quick
+// "-port 9000" demo keeps everything else from the operator's file and environment
+// FUNCTIONAL DISCOVERY: Unlike LoadConfigWithPrecedence, a broken file or an invalid
+// result is an error - the same loader backs both startup and -validate-config
+func LoadLayered(filepath string, overrides *Overrides) (*Config, error) {
+	config := DefaultConfig()
+	
+	if filepath != "" {
+		if err := applyFile(config, filepath); err != nil {
+			return nil, err
+		}
+	}
+	
+	applyEnv(config)
+	overrides.apply(config)
+	
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	
+	return config, nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Setting is one effective configuration value in printable form
+type Setting struct {
+	Key   string // Dotted JSON path, e.g. "http.port"
+	Value string
+}
+
+// redactedValue replaces credentials in printed settings
+const redactedValue = "REDACTED"
+
+// secretParamMarkers identify DSN query parameters that carry credentials,
+// e.g. _auth_pass for SQLite user authentication or _pragma_key for encryption
+var secretParamMarkers = []string{"pass", "key", "secret", "token"}
+
+// Describe lists every setting in config file order with secrets redacted
+// FUNCTIONAL DISCOVERY: Keys use the JSON file names so -validate-config output can
+// be matched line by line against a config file; nil sections print as disabled
+func (c *Config) Describe() []Setting {
+	var settings []Setting
+	root := reflect.ValueOf(c).Elem()
+	rootType := root.Type()
+
+	for i := 0; i < root.NumField(); i++ {
+		section := jsonName(rootType.Field(i))
+		sectionValue := root.Field(i)
+		if sectionValue.IsNil() {
+			settings = append(settings, Setting{Key: section, Value: "disabled"})
+			continue
+		}
+
+		fields := sectionValue.Elem()
+		for j := 0; j < fields.NumField(); j++ {
+			settings = append(settings, Setting{
+				Key:   section + "." + jsonName(fields.Type().Field(j)),
+				Value: formatSetting(fields.Field(j).Interface()),
+			})
+		}
+	}
+	return settings
+}
+
+// jsonName returns the JSON key of a struct field
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// formatSetting renders a value the way it would be written in a config file
+func formatSetting(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return redactSecrets(v)
+	case time.Duration:
+		return v.String()
+	case []time.Duration:
+		parts := make([]string, len(v))
+		for i, d := range v {
+			parts[i] = d.String()
+		}
+		return strings.Join(parts, ", ")
+	case []string:
+		return strings.Join(v, ", ")
+	case []AlertRule:
+		parts := make([]string, len(v))
+		for i, rule := range v {
+			parts[i] = fmt.Sprintf("%s=%s>%g for %s (%s)", rule.Name, rule.Metric, rule.Threshold, rule.For, strings.Join(rule.Actions, "|"))
+		}
+		return strings.Join(parts, ", ")
+	case map[string][]string:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key + "=" + strings.Join(v[key], "|")
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// redactSecrets masks credentials embedded in URL or DSN style values
+// TECHNICAL DISCOVERY: The database path becomes the prefix of the SQLite DSN, so a
+// file: URI path may carry passwords in user info or query parameters
+func redactSecrets(value string) string {
+	base, query, hasQuery := strings.Cut(value, "?")
+
+	if u, err := url.Parse(base); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), redactedValue)
+			base = u.String()
+		}
+	}
+	if !hasQuery {
+		return base
+	}
+
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		name, _, _ := strings.Cut(param, "=")
+		lower := strings.ToLower(name)
+		for _, marker := range secretParamMarkers {
+			if strings.Contains(lower, marker) {
+				params[i] = name + "=" + redactedValue
+				break
+			}
+		}
+	}
+	return base + "?" + strings.Join(params, "&")
+}
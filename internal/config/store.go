@@ -0,0 +1,98 @@
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EffectiveConfig is the runtime-mutable part of the configuration
+// ARCHITECTURAL DISCOVERY: Holds values only, never section pointers, so a snapshot
+// handed out by Store.Load cannot change under its reader; a reload builds a new one
+type EffectiveConfig struct {
+	RateLimitMessages int           // Per user per RateLimitWindow
+	RateLimitWindow   time.Duration
+	ReadTimeout       time.Duration // WebSocket read deadline, extended by each pong
+	PingInterval      time.Duration // WebSocket heartbeat
+	LogLevel          string
+}
+
+// Effective extracts the runtime-mutable settings of cfg
+// TECHNICAL DISCOVERY: Missing sections and zero rate limits fall back to defaults, so
+// a partial config built in code still yields usable limits
+func Effective(cfg *Config) *EffectiveConfig {
+	defaults := DefaultConfig()
+	effective := &EffectiveConfig{
+		RateLimitMessages: defaults.Router.RateLimitMessages,
+		RateLimitWindow:   defaults.Router.RateLimitWindow,
+		ReadTimeout:       defaults.WebSocket.ReadTimeout,
+		PingInterval:      defaults.WebSocket.PingInterval,
+		LogLevel:          defaults.Logging.Level,
+	}
+	if cfg.WebSocket != nil {
+		effective.ReadTimeout = cfg.WebSocket.ReadTimeout
+		effective.PingInterval = cfg.WebSocket.PingInterval
+	}
+	if cfg.Router != nil && cfg.Router.RateLimitMessages > 0 {
+		effective.RateLimitMessages = cfg.Router.RateLimitMessages
+	}
+	if cfg.Router != nil && cfg.Router.RateLimitWindow > 0 {
+		effective.RateLimitWindow = cfg.Router.RateLimitWindow
+	}
+	if cfg.Logging != nil {
+		effective.LogLevel = cfg.Logging.Level
+	}
+	return effective
+}
+
+// Store publishes the current EffectiveConfig to components that read it per operation
+// ARCHITECTURAL DISCOVERY: Readers never lock - Load is one atomic pointer read - and
+// a reload swaps the whole snapshot, so no reader sees half of an old and half of a
+// new configuration
+type Store struct {
+	current  atomic.Pointer[EffectiveConfig]
+	reloadMu sync.Mutex // Serializes Reload so validation and swap happen together
+}
+
+// NewStore creates a store holding cfg's runtime-mutable settings
+func NewStore(cfg *Config) *Store {
+	s := &Store{}
+	s.current.Store(Effective(cfg))
+	return s
+}
+
+// Load returns the current snapshot; callers must not modify it
+func (s *Store) Load() *EffectiveConfig {
+	return s.current.Load()
+}
+
+// Reload validates cfg and makes its runtime-mutable settings current
+// FUNCTIONAL DISCOVERY: An invalid configuration is refused as a whole and the running
+// settings stay in place; settings outside EffectiveConfig need a restart to change
+func (s *Store) Reload(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	s.current.Store(Effective(cfg))
+	return nil
+}
+
+// RateLimits returns the current rate limit; shaped for Router.SetRateLimits
+func (s *Store) RateLimits() (messages int, window time.Duration) {
+	current := s.Load()
+	return current.RateLimitMessages, current.RateLimitWindow
+}
+
+// Heartbeat returns the current WebSocket timeouts; shaped for Handler.SetHeartbeat
+func (s *Store) Heartbeat() (readTimeout, pingInterval time.Duration) {
+	current := s.Load()
+	return current.ReadTimeout, current.PingInterval
+}
+
+// LogLevel returns the current minimum log level
+func (s *Store) LogLevel() string {
+	return s.Load().LogLevel
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// RecordSessionActivity stores the last activity of each session in one transaction
+// TECHNICAL DISCOVERY: One queued write per batch, however many sessions it covers;
+// the session manager coalesces activity so this runs at most once per interval.
+// Ended sessions are updated too, keeping the last activity before the end
+func (m *Manager) RecordSessionActivity(ctx context.Context, activity map[string]time.Time) error {
+	if len(activity) == 0 {
+		return nil
+	}
+	return m.executeWrite(func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		stmt, err := tx.PrepareContext(ctx, `
+			UPDATE sessions SET last_activity = ?
+			WHERE id = ? AND (last_activity IS NULL OR last_activity < ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare activity update: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for sessionID, at := range activity {
+			if _, err := stmt.ExecContext(ctx, at, sessionID, at); err != nil {
+				return fmt.Errorf("failed to record session activity: %w", err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit session activity: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetSessionActivity returns the stored last activity of active sessions
+func (m *Manager) GetSessionActivity(ctx context.Context) (map[string]time.Time, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT id, last_activity FROM sessions
+		WHERE status = 'active' AND last_activity IS NOT NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session activity: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	activity := make(map[string]time.Time)
+	for rows.Next() {
+		var sessionID string
+		var at time.Time
+		if err := rows.Scan(&sessionID, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan session activity: %w", err)
+		}
+		activity[sessionID] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session activity: %w", err)
+	}
+	return activity, nil
+}
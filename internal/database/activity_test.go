@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// TestManager_SessionActivity tests functional validation - one batch updates several
+// sessions, never moves activity backwards, and only active sessions are read back
+func TestManager_SessionActivity(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	var _ interfaces.ActivityStore = manager
+
+	ctx := context.Background()
+	start := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	for _, id := range []string{"s1", "s2", "s3"} {
+		session := &types.Session{ID: id, Name: id, CreatedBy: "instructor1", StudentIDs: []string{"student1"}, StartTime: start, Status: "active"}
+		if err := manager.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession should succeed: %v", err)
+		}
+	}
+
+	activity, err := manager.GetSessionActivity(ctx)
+	if err != nil || len(activity) != 0 {
+		t.Fatalf("Expected no activity before any is recorded, got %v: %v", activity, err)
+	}
+
+	later := start.Add(10 * time.Minute)
+	if err := manager.RecordSessionActivity(ctx, map[string]time.Time{"s1": later, "s2": later, "s3": later}); err != nil {
+		t.Fatalf("RecordSessionActivity should succeed: %v", err)
+	}
+	if err := manager.RecordSessionActivity(ctx, map[string]time.Time{"s1": start.Add(time.Minute), "s2": later.Add(time.Minute)}); err != nil {
+		t.Fatalf("RecordSessionActivity should succeed: %v", err)
+	}
+	if _, err := manager.db.Exec("UPDATE sessions SET status = 'ended' WHERE id = 's3'"); err != nil {
+		t.Fatalf("Ending s3 should succeed: %v", err)
+	}
+
+	activity, err = manager.GetSessionActivity(ctx)
+	if err != nil {
+		t.Fatalf("GetSessionActivity should succeed: %v", err)
+	}
+	if len(activity) != 2 || !activity["s1"].Equal(later) || !activity["s2"].Equal(later.Add(time.Minute)) {
+		t.Errorf("Expected s1 kept at its later activity and s2 advanced, got %v", activity)
+	}
+}
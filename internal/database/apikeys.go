@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// selectAPIKeys reads api_keys rows in the order scanAPIKey expects
+const selectAPIKeys = `
+		SELECT id, session_id, name, scopes, created_by, created_at, expires_at, revoked_at
+		FROM api_keys
+`
+
+// CreateAPIKey stores a new key under the hash of its secret
+func (m *Manager) CreateAPIKey(ctx context.Context, key *types.APIKey, keyHash string) error {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal API key scopes: %w", err)
+	}
+
+	return m.executeWrite(func(db *sql.DB) error {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO api_keys (id, session_id, key_hash, name, scopes, created_by, created_at, expires_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, key.ID, key.SessionID, keyHash, key.Name, string(scopes), key.CreatedBy, key.CreatedAt, key.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("failed to store API key: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetAPIKeyByHash retrieves the key whose secret hashes to keyHash
+// FUNCTIONAL DISCOVERY: Revoked and expired keys are returned too; deciding whether
+// a key still works is the caller's job
+func (m *Manager) GetAPIKeyByHash(ctx context.Context, keyHash string) (*types.APIKey, error) {
+	row := m.db.QueryRowContext(ctx, selectAPIKeys+`WHERE key_hash = ?`, keyHash)
+	key, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, interfaces.ErrNotFound
+	}
+	return key, err
+}
+
+// ListAPIKeys retrieves a session's keys, oldest first
+func (m *Manager) ListAPIKeys(ctx context.Context, sessionID string) ([]*types.APIKey, error) {
+	rows, err := m.db.QueryContext(ctx, selectAPIKeys+`WHERE session_id = ? ORDER BY created_at, id`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var keys []*types.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey marks one of a session's keys revoked
+// FUNCTIONAL DISCOVERY: Revoking a revoked key succeeds and keeps the first revoked_at;
+// a key ID from another session is ErrNotFound, like an unknown one
+func (m *Manager) RevokeAPIKey(ctx context.Context, sessionID, keyID string, revokedAt time.Time) error {
+	return m.executeWrite(func(db *sql.DB) error {
+		result, err := db.ExecContext(ctx,
+			`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, ?) WHERE id = ? AND session_id = ?`,
+			revokedAt, keyID, sessionID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to revoke API key: %w", err)
+		}
+		if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+			return interfaces.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// scanAPIKey reads one row selected with selectAPIKeys
+func scanAPIKey(row interface{ Scan(dest ...interface{}) error }) (*types.APIKey, error) {
+	var key types.APIKey
+	var scopes string
+	var revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.SessionID, &key.Name, &scopes, &key.CreatedBy, &key.CreatedAt, &key.ExpiresAt, &revokedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan API key: %w", err)
+	}
+	if err := json.Unmarshal([]byte(scopes), &key.Scopes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal API key scopes: %w", err)
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
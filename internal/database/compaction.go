@@ -0,0 +1,100 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+)
+
+// contentStats counts message content bytes before and after compaction
+// FUNCTIONAL DISCOVERY: Reported in DatabaseHealth so operators can see what
+// compaction and shared bodies save; with both off the first two counters grow together
+type contentStats struct {
+	originalBytes atomic.Int64
+	storedBytes   atomic.Int64
+	dedupedBytes  atomic.Int64 // Not stored because an identical body was already shared
+}
+
+// encodeContent serializes message content into its stored form
+// ARCHITECTURAL DISCOVERY: Called before executeWrite so the writer's retry does not
+// count a message twice and the routed message is never modified
+// FUNCTIONAL DISCOVERY: Without CompactContent the stored bytes are exactly what
+// json.Marshal produced before compaction existed
+// TECHNICAL DISCOVERY: A MarshalJSON that panics is returned as ErrUnencodableContent
+// rather than unwinding the routing goroutine that is storing the message
+func (m *Manager) encodeContent(content map[string]interface{}) (stored []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			stored, err = nil, fmt.Errorf("%w: panic: %v", ErrUnencodableContent, r)
+		}
+	}()
+
+	original, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	stored = original
+	if m.config.CompactContent {
+		stored, err = compactContent(content, m.noiseKeys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	m.contentStats.originalBytes.Add(int64(len(original)))
+	m.contentStats.storedBytes.Add(int64(len(stored)))
+	return stored, nil
+}
+
+// compactContent returns the canonical JSON form of content
+// FUNCTIONAL DISCOVERY: Keys are sorted and insignificant whitespace removed (as
+// json.Marshal already does for maps), null object members are stripped at every
+// depth and top-level noise keys are dropped; every remaining value round-trips exactly
+// TECHNICAL DISCOVERY: HTML escaping is disabled because code submissions are full of
+// <, > and &, each of which json.Marshal would store as a six-byte \u escape
+func compactContent(content map[string]interface{}, noiseKeys map[string]bool) ([]byte, error) {
+	compacted := make(map[string]interface{}, len(content))
+	for key, value := range content {
+		if noiseKeys[key] || value == nil {
+			continue
+		}
+		compacted[key] = stripNulls(value)
+	}
+	if content == nil {
+		compacted = nil
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(compacted); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// stripNulls copies value without null object members
+// TECHNICAL DISCOVERY: Nulls inside arrays are kept because removing them would shift
+// the positions of the remaining elements
+func stripNulls(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		stripped := make(map[string]interface{}, len(v))
+		for key, member := range v {
+			if member != nil {
+				stripped[key] = stripNulls(member)
+			}
+		}
+		return stripped
+	case []interface{}:
+		stripped := make([]interface{}, len(v))
+		for i, element := range v {
+			stripped[i] = stripNulls(element)
+		}
+		return stripped
+	default:
+		return value
+	}
+}
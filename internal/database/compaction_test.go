@@ -0,0 +1,124 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// representativeContents holds one typical payload per message type, with the
+// nulls and client noise that compaction removes
+var representativeContents = map[string]string{
+	"instructor_inbox":     `{"text": "Why does my loop never end?", "ui_state": {"draft": true}, "attachment": null}`,
+	"inbox_response":       `{"text": "Check the condition on line 4", "reply_to": "msg-1", "ui_state": null}`,
+	"request":              `{"text": "Please submit your solution", "request_type": "code", "deadline": null}`,
+	"request_response":     `{"code": "if (a < b && b > 0) {\n  return a;\n}", "language": "javascript", "lines": [1, null, 3], "meta": {"editor": null, "tabs": 2}}`,
+	"analytics":            `{"event": "progress", "completion": 0.75, "score": 12, "steps": [{"id": 1, "hint": null}], "ui_state": {"scroll": 420}}`,
+	"instructor_broadcast": `{"text": "Break for 5 minutes", "priority": "high", "flags": {"pinned": false, "color": null}}`,
+}
+
+// Functional Validation Tests
+func TestCompactContent_RoundTrip(t *testing.T) {
+	noiseKeys := map[string]bool{"ui_state": true}
+
+	for messageType, raw := range representativeContents {
+		t.Run(messageType, func(t *testing.T) {
+			var content map[string]interface{}
+			if err := json.Unmarshal([]byte(raw), &content); err != nil {
+				t.Fatalf("Invalid fixture: %v", err)
+			}
+			expected := stripNulls(content).(map[string]interface{})
+			delete(expected, "ui_state")
+
+			compacted, err := compactContent(content, noiseKeys)
+			if err != nil {
+				t.Fatalf("compactContent failed: %v", err)
+			}
+
+			var decoded map[string]interface{}
+			if err := json.Unmarshal(compacted, &decoded); err != nil {
+				t.Fatalf("Compacted content is not valid JSON: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, expected) {
+				t.Errorf("Remaining fields changed:\nexpected %v\ngot      %v", expected, decoded)
+			}
+
+			// Canonical form is stable and never larger than the uncompacted encoding
+			again, _ := compactContent(decoded, noiseKeys)
+			if !bytes.Equal(again, compacted) {
+				t.Errorf("Compaction is not idempotent:\n%s\n%s", compacted, again)
+			}
+			original, _ := json.Marshal(content)
+			if len(compacted) > len(original) {
+				t.Errorf("Compacted %d bytes, original %d", len(compacted), len(original))
+			}
+			if bytes.Contains(compacted, []byte("null}")) || bytes.Contains(compacted, []byte("ui_state")) {
+				t.Errorf("Nulls and noise keys should be removed: %s", compacted)
+			}
+		})
+	}
+}
+
+func TestManager_ContentCompactionStorage(t *testing.T) {
+	for _, compact := range []bool{true, false} {
+		manager, cleanup := setupTestDB(t)
+		manager.config.CompactContent = compact
+		manager.noiseKeys = map[string]bool{"ui_state": true}
+
+		ctx := context.Background()
+		manager.CreateSession(ctx, &types.Session{
+			ID:         "compaction-session",
+			Name:       "Compaction Session",
+			CreatedBy:  "instructor1",
+			StudentIDs: []string{"student1"},
+			StartTime:  time.Now(),
+			Status:     "active",
+		})
+
+		var content map[string]interface{}
+		json.Unmarshal([]byte(representativeContents["request_response"]), &content)
+		message := &types.Message{
+			ID:        "msg-compaction",
+			SessionID: "compaction-session",
+			Type:      "request_response",
+			Context:   "general",
+			FromUser:  "student1",
+			Content:   content,
+			Timestamp: time.Now(),
+		}
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+		if meta, _ := message.Content["meta"].(map[string]interface{}); len(meta) != 2 {
+			t.Errorf("Compaction must not modify the routed message, meta is now %v", meta)
+		}
+
+		var stored string
+		if err := manager.GetDB().QueryRow(`SELECT content FROM messages WHERE id = ?`, message.ID).Scan(&stored); err != nil {
+			t.Fatalf("Failed to read stored content: %v", err)
+		}
+		original, _ := json.Marshal(content)
+		health := manager.HealthStatus()
+
+		if compact {
+			expected, _ := compactContent(content, manager.noiseKeys)
+			if stored != string(expected) {
+				t.Errorf("Expected compacted content %s, got %s", expected, stored)
+			}
+			if health.ContentBytesStored >= health.ContentBytesOriginal {
+				t.Errorf("Expected savings, got original=%d stored=%d", health.ContentBytesOriginal, health.ContentBytesStored)
+			}
+		} else if stored != string(original) {
+			t.Errorf("Compaction disabled should store byte-exact content %s, got %s", original, stored)
+		}
+		if health.ContentBytesOriginal != int64(len(original)) || health.ContentBytesStored != int64(len(stored)) {
+			t.Errorf("Unexpected content metrics: original=%d stored=%d", health.ContentBytesOriginal, health.ContentBytesStored)
+		}
+		cleanup()
+	}
+}
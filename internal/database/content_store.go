@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// selectMessages reads messages with shared bodies reassembled
+// ARCHITECTURAL DISCOVERY: The join lives in the query, so scanMessages and every
+// caller see inline and shared content alike
+const selectMessages = `
+		SELECT m.id, m.session_id, m.type, m.context, m.from_user, m.to_user, COALESCE(cs.body, m.content), m.timestamp, m.to_users,
+		       m.revision, m.edited_at
+		FROM messages m
+		LEFT JOIN content_store cs ON cs.hash = m.content_hash
+`
+
+// execer is the part of *sql.DB and *sql.Tx that shareContent needs
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// sharedContent is how one message's content is written to the messages row
+type sharedContent struct {
+	inline string         // messages.content; empty when the body is shared
+	hash   sql.NullString // messages.content_hash; valid when the body is shared
+	reused bool           // The body was already in content_store
+}
+
+// shareContent stores content at or above the dedup threshold once in content_store
+// FUNCTIONAL DISCOVERY: stored is the canonical form from encodeContent, so the same
+// submission hashes alike whatever key order or whitespace the client sent
+// TECHNICAL DISCOVERY: The body is inserted with refcount 0; the insert trigger on
+// messages counts the reference, so a message row that is never written leaves the
+// count untouched
+func (m *Manager) shareContent(ctx context.Context, db execer, stored []byte) (sharedContent, error) {
+	if m.config.DedupThreshold <= 0 || len(stored) < m.config.DedupThreshold {
+		return sharedContent{inline: string(stored)}, nil
+	}
+
+	sum := sha256.Sum256(stored)
+	hash := hex.EncodeToString(sum[:])
+	result, err := db.ExecContext(ctx, `INSERT OR IGNORE INTO content_store (hash, body) VALUES (?, ?)`, hash, string(stored))
+	if err != nil {
+		return sharedContent{}, fmt.Errorf("failed to store shared content: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return sharedContent{}, fmt.Errorf("failed to store shared content: %w", err)
+	}
+	return sharedContent{hash: sql.NullString{String: hash, Valid: true}, reused: affected == 0}, nil
+}
+
+// releaseUnreferenced deletes a shared body no message references, e.g. after an
+// import skipped the only message that would have used it
+func releaseUnreferenced(ctx context.Context, db execer, hash sql.NullString) error {
+	if !hash.Valid {
+		return nil
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM content_store WHERE hash = ? AND refcount <= 0`, hash.String); err != nil {
+		return fmt.Errorf("failed to release shared content: %w", err)
+	}
+	return nil
+}
+
+// recordDeduplicated moves bytes that were shared instead of stored from the stored
+// count to the deduplicated count
+// TECHNICAL DISCOVERY: Called once the write has committed, so a retried write is
+// not counted twice
+func (m *Manager) recordDeduplicated(bytes int64) {
+	if bytes == 0 {
+		return
+	}
+	m.contentStats.storedBytes.Add(-bytes)
+	m.contentStats.dedupedBytes.Add(bytes)
+}
@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/database"
+	"switchboard/pkg/types"
+)
+
+// storedContentBytes sums message content as it sits on disk: inline content plus
+// every shared body once
+func storedContentBytes(t *testing.T, manager *Manager) int64 {
+	var inline, shared int64
+	if err := manager.GetDB().QueryRow(`SELECT COALESCE(SUM(length(content)), 0) FROM messages`).Scan(&inline); err != nil {
+		t.Fatalf("Failed to measure inline content: %v", err)
+	}
+	if err := manager.GetDB().QueryRow(`SELECT COALESCE(SUM(length(body)), 0) FROM content_store`).Scan(&shared); err != nil {
+		t.Fatalf("Failed to measure shared content: %v", err)
+	}
+	return inline + shared
+}
+
+func sharedBodies(t *testing.T, manager *Manager) (bodies, references int) {
+	if err := manager.GetDB().QueryRow(`SELECT COUNT(*), COALESCE(SUM(refcount), 0) FROM content_store`).Scan(&bodies, &references); err != nil {
+		t.Fatalf("Failed to read content_store: %v", err)
+	}
+	return bodies, references
+}
+
+func createSharedContentSession(t *testing.T, manager *Manager, sessionID string, students []string) {
+	err := manager.CreateSession(context.Background(), &types.Session{
+		ID:         sessionID,
+		Name:       "Shared Content " + sessionID,
+		CreatedBy:  "instructor1",
+		StudentIDs: students,
+		StartTime:  time.Now(),
+		Status:     "active",
+	})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+}
+
+// TestManager_SharedContentSavings tests functional validation - thirty identical 50KB
+// submissions store their body once and read back whole
+func TestManager_SharedContentSavings(t *testing.T) {
+	ctx := context.Background()
+	starterCode := strings.Repeat("def solve(items):\n    return sorted(items)  # TODO\n", 1100)[:50<<10]
+
+	var students []string
+	for i := 0; i < 30; i++ {
+		students = append(students, fmt.Sprintf("student%d", i))
+	}
+
+	storeSubmissions := func(t *testing.T, threshold int) *Manager {
+		manager, cleanup := setupTestDB(t)
+		t.Cleanup(cleanup)
+		manager.config.CompactContent = true
+		manager.config.DedupThreshold = threshold
+		createSharedContentSession(t, manager, "lab", students)
+
+		for i, student := range students {
+			err := manager.StoreMessage(ctx, &types.Message{
+				ID:        fmt.Sprintf("submission-%d", i),
+				SessionID: "lab",
+				Type:      types.MessageTypeRequestResponse,
+				Context:   "code",
+				FromUser:  student,
+				Content:   map[string]interface{}{"code": starterCode, "language": "python"},
+				Timestamp: time.Now(),
+			})
+			if err != nil {
+				t.Fatalf("StoreMessage failed: %v", err)
+			}
+		}
+		// Below the threshold content stays inline
+		err := manager.StoreMessage(ctx, &types.Message{
+			ID:        "question",
+			SessionID: "lab",
+			Type:      types.MessageTypeInstructorInbox,
+			Context:   "general",
+			FromUser:  students[0],
+			Content:   map[string]interface{}{"text": "Is sorting allowed?"},
+			Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("StoreMessage failed: %v", err)
+		}
+		return manager
+	}
+
+	inline := storeSubmissions(t, 0)
+	shared := storeSubmissions(t, database.DefaultDedupThreshold)
+
+	inlineBytes, sharedBytes := storedContentBytes(t, inline), storedContentBytes(t, shared)
+	t.Logf("30 identical 50KB submissions: %d bytes inline, %d bytes shared (%.1f%% saved)",
+		inlineBytes, sharedBytes, 100*float64(inlineBytes-sharedBytes)/float64(inlineBytes))
+	if inlineBytes < 30*50<<10 {
+		t.Fatalf("Expected every submission stored inline without dedup, got %d bytes", inlineBytes)
+	}
+	if sharedBytes > inlineBytes/30+1<<10 {
+		t.Errorf("Expected the submission body stored once, got %d bytes", sharedBytes)
+	}
+	if bodies, references := sharedBodies(t, shared); bodies != 1 || references != 30 {
+		t.Errorf("Expected one body with 30 references, got %d bodies and %d references", bodies, references)
+	}
+
+	var inlineRows int
+	shared.GetDB().QueryRow(`SELECT COUNT(*) FROM messages WHERE content_hash IS NULL`).Scan(&inlineRows)
+	if inlineRows != 1 {
+		t.Errorf("Expected only the short question stored inline, got %d rows", inlineRows)
+	}
+
+	if health := shared.HealthStatus(); health.ContentBytesDeduplicated < 29*50<<10 || health.ContentBytesStored > health.ContentBytesOriginal/15 {
+		t.Errorf("Expected 29 submissions counted as deduplicated, got %+v", health)
+	}
+
+	history, err := shared.GetSessionHistory(ctx, "lab")
+	if err != nil {
+		t.Fatalf("GetSessionHistory failed: %v", err)
+	}
+	if len(history) != 31 {
+		t.Fatalf("Expected 31 messages, got %d", len(history))
+	}
+	for _, message := range history[:30] {
+		if message.Content["code"] != starterCode || message.Content["language"] != "python" {
+			t.Fatalf("Submission %s did not read back whole", message.ID)
+		}
+	}
+	if history[30].Content["text"] != "Is sorting allowed?" {
+		t.Errorf("Unexpected inline message %+v", history[30])
+	}
+
+	message, err := shared.GetMessage(ctx, "lab", "submission-7")
+	if err != nil || message.Content["code"] != starterCode {
+		t.Errorf("GetMessage should reassemble shared content, got %v", err)
+	}
+	asOf, err := shared.GetSessionHistoryAsOf(ctx, "lab", time.Now())
+	if err != nil || len(asOf) != 31 || asOf[0].Content["code"] != starterCode {
+		t.Errorf("GetSessionHistoryAsOf should reassemble shared content, got %d messages, %v", len(asOf), err)
+	}
+}
+
+// TestManager_SharedContentRelease tests functional validation - deleting messages
+// releases references and the last one deletes the body
+func TestManager_SharedContentRelease(t *testing.T) {
+	ctx := context.Background()
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	manager.config.DedupThreshold = 1 << 10
+
+	body := map[string]interface{}{"code": strings.Repeat("x", 2<<10)}
+	createSharedContentSession(t, manager, "morning", []string{"student1", "student2"})
+	createSharedContentSession(t, manager, "afternoon", []string{"student1"})
+	for _, id := range []string{"morning-1", "morning-2", "afternoon-1"} {
+		sessionID, _, _ := strings.Cut(id, "-")
+		err := manager.StoreMessage(ctx, &types.Message{
+			ID: id, SessionID: sessionID, Type: types.MessageTypeRequestResponse, Context: "code",
+			FromUser: "student1", Content: body, Timestamp: time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("StoreMessage failed: %v", err)
+		}
+	}
+
+	// A duplicate ID rolls back with its reference
+	err := manager.StoreMessage(ctx, &types.Message{
+		ID: "morning-1", SessionID: "morning", Type: types.MessageTypeRequestResponse, Context: "code",
+		FromUser: "student1", Content: map[string]interface{}{"code": strings.Repeat("y", 2<<10)}, Timestamp: time.Now(),
+	})
+	if err == nil {
+		t.Fatal("Expected a duplicate message ID to fail")
+	}
+	if bodies, references := sharedBodies(t, manager); bodies != 1 || references != 3 {
+		t.Fatalf("Expected one body with 3 references, got %d bodies and %d references", bodies, references)
+	}
+
+	// Imported messages share bodies too; a skipped import leaves nothing behind
+	skipped, err := manager.ImportMessages(ctx, []*types.Message{
+		{ID: "afternoon-2", SessionID: "afternoon", Type: types.MessageTypeRequestResponse, Context: "code", FromUser: "student1", Content: body, Timestamp: time.Now()},
+		{ID: "afternoon-1", SessionID: "afternoon", Type: types.MessageTypeRequestResponse, Context: "code", FromUser: "student1", Content: map[string]interface{}{"code": strings.Repeat("z", 2<<10)}, Timestamp: time.Now()},
+	})
+	if err != nil || len(skipped) != 1 {
+		t.Fatalf("Expected one skipped import, got %v, %v", skipped, err)
+	}
+	if bodies, references := sharedBodies(t, manager); bodies != 1 || references != 4 {
+		t.Errorf("Expected one body with 4 references after import, got %d bodies and %d references", bodies, references)
+	}
+
+	if _, err := manager.GetDB().Exec(`DELETE FROM messages WHERE id = 'morning-2'`); err != nil {
+		t.Fatalf("Failed to delete message: %v", err)
+	}
+	if bodies, references := sharedBodies(t, manager); bodies != 1 || references != 3 {
+		t.Errorf("Expected 3 references after a delete, got %d bodies and %d references", bodies, references)
+	}
+
+	// Removing sessions cascades to their messages, which releases the body
+	for _, sessionID := range []string{"morning", "afternoon"} {
+		if _, err := manager.GetDB().Exec(`DELETE FROM sessions WHERE id = ?`, sessionID); err != nil {
+			t.Fatalf("Failed to delete session: %v", err)
+		}
+	}
+	if bodies, references := sharedBodies(t, manager); bodies != 0 || references != 0 {
+		t.Errorf("Expected the orphaned body deleted, got %d bodies and %d references", bodies, references)
+	}
+}
+
+// TestVerify_MissingSharedContent tests technical validation - verify reports a message whose shared body is gone
+func TestVerify_MissingSharedContent(t *testing.T) {
+	ctx := context.Background()
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	manager.config.DedupThreshold = 1 << 10
+
+	createSharedContentSession(t, manager, "lab", []string{"student1"})
+	err := manager.StoreMessage(ctx, &types.Message{
+		ID: "submission", SessionID: "lab", Type: types.MessageTypeRequestResponse, Context: "code",
+		FromUser: "student1", Content: map[string]interface{}{"code": strings.Repeat("x", 2<<10)}, Timestamp: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("StoreMessage failed: %v", err)
+	}
+	if report, err := manager.Verify(ctx); err != nil || report.Problems() != 0 {
+		t.Fatalf("Expected a clean report with shared content, got %+v, %v", report, err)
+	}
+
+	if _, err := manager.GetDB().Exec(`DELETE FROM content_store`); err != nil {
+		t.Fatalf("Failed to delete shared content: %v", err)
+	}
+	report, err := manager.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	for _, check := range report.Checks {
+		if check.Name == CheckForeignKeys {
+			if len(check.Issues) != 1 || check.Issues[0].Subject != "messages/submission" {
+				t.Errorf("Expected the dangling reference reported, got %+v", check.Issues)
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"switchboard/pkg/types"
+)
+
+// RecordDeliveryFailures stores failures in one transaction and drops the oldest rows
+// beyond keep
+// ARCHITECTURAL DISCOVERY: One writeOperation per batch, like StoreThroughputBuckets;
+// the router batches failures so an incident costs a write per flush, not per recipient
+// TECHNICAL DISCOVERY: ids only grow, so the cap trims by id in the same transaction
+// and the table never holds more than keep rows once a batch commits
+func (m *Manager) RecordDeliveryFailures(ctx context.Context, failures []types.DeliveryFailure, keep int) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return m.executeWrite(func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO delivery_failures (message_id, session_id, recipient, reason, weight, failed_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare delivery failure statement: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for _, failure := range failures {
+			weight := failure.Weight
+			if weight < 1 {
+				weight = 1
+			}
+			if _, err := stmt.ExecContext(ctx, failure.MessageID, failure.SessionID, failure.Recipient, failure.Reason, weight, failure.FailedAt); err != nil {
+				return fmt.Errorf("failed to store delivery failure: %w", err)
+			}
+		}
+
+		if keep > 0 {
+			if _, err := tx.ExecContext(ctx, `
+				DELETE FROM delivery_failures WHERE id <= (
+					SELECT id FROM delivery_failures ORDER BY id DESC LIMIT 1 OFFSET ?
+				)
+			`, keep); err != nil {
+				return fmt.Errorf("failed to trim delivery failures: %w", err)
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit delivery failures: %w", err)
+		}
+		return nil
+	})
+}
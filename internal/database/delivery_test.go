@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// TestManager_RecordDeliveryFailures tests functional validation - batches are stored
+// with their weights and the table keeps only the newest rows
+func TestManager_RecordDeliveryFailures(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	var _ interfaces.DeliveryFailureStore = manager
+
+	ctx := context.Background()
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	batch := func(first, count int) []types.DeliveryFailure {
+		failures := make([]types.DeliveryFailure, count)
+		for i := range failures {
+			failures[i] = types.DeliveryFailure{
+				MessageID: fmt.Sprintf("msg-%d", first+i),
+				SessionID: "s1",
+				Recipient: "instructor1",
+				Reason:    types.DeliveryFailureWriteTimeout,
+				Weight:    first + i, // 0 is stored as 1
+				FailedAt:  at,
+			}
+		}
+		return failures
+	}
+
+	if err := manager.RecordDeliveryFailures(ctx, nil, 3); err != nil {
+		t.Fatalf("An empty batch should succeed: %v", err)
+	}
+	if err := manager.RecordDeliveryFailures(ctx, batch(0, 2), 3); err != nil {
+		t.Fatalf("RecordDeliveryFailures should succeed: %v", err)
+	}
+	if err := manager.RecordDeliveryFailures(ctx, batch(2, 3), 3); err != nil {
+		t.Fatalf("RecordDeliveryFailures should succeed: %v", err)
+	}
+
+	rows, err := manager.db.Query("SELECT message_id, weight FROM delivery_failures ORDER BY id")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer func() { _ = rows.Close() }()
+	var kept []string
+	for rows.Next() {
+		var messageID string
+		var weight int
+		if err := rows.Scan(&messageID, &weight); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+		kept = append(kept, fmt.Sprintf("%s:%d", messageID, weight))
+	}
+	if fmt.Sprint(kept) != "[msg-2:2 msg-3:3 msg-4:4]" {
+		t.Errorf("Expected the newest 3 rows kept, got %v", kept)
+	}
+
+	if err := manager.RecordDeliveryFailures(ctx, batch(0, 1), 0); err != nil {
+		t.Fatalf("RecordDeliveryFailures should succeed: %v", err)
+	}
+	var weight, count int
+	if err := manager.db.QueryRow("SELECT COUNT(*), MIN(weight) FROM delivery_failures").Scan(&count, &weight); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if count != 4 || weight != 1 {
+		t.Errorf("Expected keep 0 to trim nothing and weight 0 stored as 1, got %d rows, min weight %d", count, weight)
+	}
+}
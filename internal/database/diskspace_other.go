@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package database
+
+import "errors"
+
+// freeDiskBytes is unsupported here; the watchdog then relies on write success rate alone
+func freeDiskBytes(dir string) (uint64, error) {
+	return 0, errors.New("free disk space check not supported on this platform")
+}
@@ -0,0 +1,343 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// incrementalVacuumPages is how many free pages one incremental_vacuum step returns
+// FUNCTIONAL DISCOVERY: About 4MB at the default page size, which SQLite gives back in
+// milliseconds; message writes queued behind the vacuum wait for one step, not all
+const incrementalVacuumPages = 1000
+
+// maxIntegrityProblems bounds how many integrity problems a verdict lists
+const maxIntegrityProblems = 10
+
+// maintenanceState tracks the running and most recent maintenance job
+type maintenanceState struct {
+	mu               sync.Mutex
+	last             *types.MaintenanceJob // Running or most recently finished
+	running          bool
+	backupInProgress func() bool // nil when nothing backs the database up
+}
+
+// SetBackupInProgress lets StartMaintenance refuse to run while a backup is written
+// TECHNICAL DISCOVERY: Set before serving; read under the maintenance lock
+func (m *Manager) SetBackupInProgress(inProgress func() bool) {
+	m.maintenance.mu.Lock()
+	defer m.maintenance.mu.Unlock()
+	m.maintenance.backupInProgress = inProgress
+}
+
+// StartMaintenance starts running tasks in order in the background
+// ARCHITECTURAL DISCOVERY: Every task runs through the write channel, so maintenance
+// is ordered with message writes instead of competing with them for the file lock;
+// one job runs at a time
+// FUNCTIONAL DISCOVERY: A task that fails is recorded and the rest still run; the
+// job is persisted as it starts and after every task, so progress survives a restart
+func (m *Manager) StartMaintenance(tasks []string) (*types.MaintenanceJob, error) {
+	for _, task := range tasks {
+		if !types.IsMaintenanceTask(task) {
+			return nil, fmt.Errorf("%w: %q", interfaces.ErrUnknownMaintenanceTask, task)
+		}
+	}
+
+	m.maintenance.mu.Lock()
+	if m.maintenance.running {
+		m.maintenance.mu.Unlock()
+		return nil, interfaces.ErrMaintenanceRunning
+	}
+	if m.maintenance.backupInProgress != nil && m.maintenance.backupInProgress() {
+		m.maintenance.mu.Unlock()
+		return nil, interfaces.ErrBackupInProgress
+	}
+	job := &types.MaintenanceJob{
+		ID:        uuid.NewString(),
+		Status:    types.MaintenanceRunning,
+		StartedAt: m.clock.Now().UTC(),
+	}
+	for _, task := range tasks {
+		job.Tasks = append(job.Tasks, types.MaintenanceTask{Task: task, Status: types.MaintenancePending})
+	}
+	m.maintenance.running = true
+	m.maintenance.last = job
+	started := job.Copy()
+	m.maintenance.mu.Unlock()
+
+	log.Printf("Maintenance job %s started: %s", job.ID, strings.Join(tasks, ", "))
+	go m.runMaintenance(job)
+	return started, nil
+}
+
+// MaintenanceJob returns the job with jobID, from memory while it is the latest and
+// from maintenance_jobs otherwise
+func (m *Manager) MaintenanceJob(ctx context.Context, jobID string) (*types.MaintenanceJob, error) {
+	m.maintenance.mu.Lock()
+	if last := m.maintenance.last; last != nil && last.ID == jobID {
+		defer m.maintenance.mu.Unlock()
+		return last.Copy(), nil
+	}
+	m.maintenance.mu.Unlock()
+
+	row := m.db.QueryRowContext(ctx, `
+		SELECT id, status, tasks, started_at, finished_at FROM maintenance_jobs WHERE id = ?
+	`, jobID)
+	job, err := scanMaintenanceJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, interfaces.ErrNotFound
+	}
+	return job, err
+}
+
+// LoadLastMaintenance restores the most recent stored job, so /health reports it
+// after a restart
+// TECHNICAL DISCOVERY: Called once after migrations; a job stored as running was cut
+// short by the restart and is reported as failed
+func (m *Manager) LoadLastMaintenance(ctx context.Context) error {
+	row := m.db.QueryRowContext(ctx, `
+		SELECT id, status, tasks, started_at, finished_at FROM maintenance_jobs
+		ORDER BY started_at DESC LIMIT 1
+	`)
+	job, err := scanMaintenanceJob(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if job.Status == types.MaintenanceRunning {
+		job.Status = types.MaintenanceFailed
+		m.storeMaintenanceJob(ctx, job)
+	}
+
+	m.maintenance.mu.Lock()
+	defer m.maintenance.mu.Unlock()
+	if m.maintenance.last == nil {
+		m.maintenance.last = job
+	}
+	return nil
+}
+
+// lastMaintenance returns a copy of the latest job for HealthStatus
+func (m *Manager) lastMaintenance() *types.MaintenanceJob {
+	m.maintenance.mu.Lock()
+	defer m.maintenance.mu.Unlock()
+	if m.maintenance.last == nil {
+		return nil
+	}
+	return m.maintenance.last.Copy()
+}
+
+// runMaintenance runs job's tasks in order and records each outcome
+func (m *Manager) runMaintenance(job *types.MaintenanceJob) {
+	ctx := context.Background()
+	m.storeMaintenanceJob(ctx, job)
+
+	for i := range job.Tasks {
+		m.maintenance.mu.Lock()
+		job.Tasks[i].Status = types.MaintenanceRunning
+		task := job.Tasks[i]
+		m.maintenance.mu.Unlock()
+
+		start := m.clock.Now()
+		err := m.runMaintenanceTask(ctx, &task)
+		task.DurationMs = m.clock.Now().Sub(start).Milliseconds()
+		task.Status = types.MaintenanceCompleted
+		if err != nil {
+			task.Status = types.MaintenanceFailed
+			task.Error = err.Error()
+			log.Printf("ERROR: Maintenance task %s failed: %v", task.Task, err)
+		}
+
+		m.maintenance.mu.Lock()
+		job.Tasks[i] = task
+		m.maintenance.mu.Unlock()
+		m.storeMaintenanceJob(ctx, job)
+	}
+
+	m.maintenance.mu.Lock()
+	finishedAt := m.clock.Now().UTC()
+	job.FinishedAt = &finishedAt
+	job.Status = types.MaintenanceCompleted
+	for _, task := range job.Tasks {
+		if task.Status == types.MaintenanceFailed {
+			job.Status = types.MaintenanceFailed
+		}
+	}
+	m.maintenance.mu.Unlock()
+	m.storeMaintenanceJob(ctx, job)
+
+	m.maintenance.mu.Lock()
+	m.maintenance.running = false
+	m.maintenance.mu.Unlock()
+	log.Printf("Maintenance job %s %s", job.ID, job.Status)
+}
+
+// runMaintenanceTask runs one task, filling in its results
+func (m *Manager) runMaintenanceTask(ctx context.Context, task *types.MaintenanceTask) error {
+	switch task.Task {
+	case types.MaintenanceVacuum:
+		return m.vacuum(ctx, task)
+	case types.MaintenanceIntegrityCheck:
+		var check *VerifyCheck
+		err := m.executeWrite(func(db *sql.DB) error {
+			var err error
+			check, err = m.verifyIntegrity(ctx)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		task.Verdict = integrityVerdict(check)
+		return nil
+	case types.MaintenanceAnalyze:
+		return m.executeWrite(func(db *sql.DB) error {
+			_, err := db.ExecContext(ctx, "ANALYZE")
+			return err
+		})
+	}
+	return fmt.Errorf("%w: %q", interfaces.ErrUnknownMaintenanceTask, task.Task)
+}
+
+// vacuum gives free pages back to the filesystem
+// FUNCTIONAL DISCOVERY: With auto_vacuum configured, free pages are returned by
+// incremental_vacuum in steps of incrementalVacuumPages, each its own write, so message
+// writes interleave with the vacuum. Otherwise a full VACUUM rebuilds the file in one
+// write, pausing message writes until it finishes
+func (m *Manager) vacuum(ctx context.Context, task *types.MaintenanceTask) error {
+	var autoVacuum int
+	if err := m.db.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil {
+		return fmt.Errorf("failed to read auto_vacuum: %w", err)
+	}
+	before, err := m.databaseBytes(ctx)
+	if err != nil {
+		return err
+	}
+
+	if autoVacuum == 0 {
+		err = m.executeWrite(func(db *sql.DB) error {
+			_, err := db.ExecContext(ctx, "VACUUM")
+			return err
+		})
+	} else {
+		task.Incremental = true
+		err = m.incrementalVacuum(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	after, err := m.databaseBytes(ctx)
+	if err != nil {
+		return err
+	}
+	task.FreedBytes = before - after
+	return nil
+}
+
+// incrementalVacuum returns free pages a step at a time until none are left
+func (m *Manager) incrementalVacuum(ctx context.Context) error {
+	for {
+		var remaining, freed int64
+		err := m.executeWrite(func(db *sql.DB) error {
+			var before int64
+			if err := db.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&before); err != nil {
+				return err
+			}
+			if _, err := db.ExecContext(ctx, fmt.Sprintf("PRAGMA incremental_vacuum(%d)", incrementalVacuumPages)); err != nil {
+				return err
+			}
+			if err := db.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&remaining); err != nil {
+				return err
+			}
+			freed = before - remaining
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("incremental vacuum failed: %w", err)
+		}
+		// TECHNICAL DISCOVERY: auto_vacuum=FULL keeps the freelist empty itself and
+		// incremental_vacuum does nothing, so a step that frees nothing also ends it
+		if remaining == 0 || freed == 0 {
+			return nil
+		}
+	}
+}
+
+// databaseBytes returns the database's size in pages times the page size
+func (m *Manager) databaseBytes(ctx context.Context) (int64, error) {
+	var pages, pageSize int64
+	if err := m.db.QueryRowContext(ctx, "PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to read page_count: %w", err)
+	}
+	if err := m.db.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to read page_size: %w", err)
+	}
+	return pages * pageSize, nil
+}
+
+// integrityVerdict summarizes an integrity check as "ok" or its first problems
+func integrityVerdict(check *VerifyCheck) string {
+	if len(check.Issues) == 0 {
+		return "ok"
+	}
+	problems := make([]string, 0, maxIntegrityProblems)
+	for i, issue := range check.Issues {
+		if i == maxIntegrityProblems {
+			problems = append(problems, fmt.Sprintf("and %d more", len(check.Issues)-i))
+			break
+		}
+		problems = append(problems, issue.Detail)
+	}
+	return strings.Join(problems, "; ")
+}
+
+// storeMaintenanceJob writes job's current state to maintenance_jobs
+// FUNCTIONAL DISCOVERY: Best-effort - a failed write is logged, and the job keeps
+// running and stays readable from memory
+func (m *Manager) storeMaintenanceJob(ctx context.Context, job *types.MaintenanceJob) {
+	m.maintenance.mu.Lock()
+	snapshot := job.Copy()
+	m.maintenance.mu.Unlock()
+
+	tasks, err := json.Marshal(snapshot.Tasks)
+	if err == nil {
+		err = m.executeWrite(func(db *sql.DB) error {
+			_, err := db.ExecContext(ctx, `
+				INSERT OR REPLACE INTO maintenance_jobs (id, status, tasks, started_at, finished_at)
+				VALUES (?, ?, ?, ?, ?)
+			`, snapshot.ID, snapshot.Status, string(tasks), snapshot.StartedAt, snapshot.FinishedAt)
+			return err
+		})
+	}
+	if err != nil {
+		log.Printf("WARNING: Failed to store maintenance job %s: %v", snapshot.ID, err)
+	}
+}
+
+// scanMaintenanceJob reads one maintenance_jobs row
+func scanMaintenanceJob(row *sql.Row) (*types.MaintenanceJob, error) {
+	job := &types.MaintenanceJob{}
+	var tasks string
+	var finishedAt sql.NullTime
+	if err := row.Scan(&job.ID, &job.Status, &tasks, &job.StartedAt, &finishedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(tasks), &job.Tasks); err != nil {
+		return nil, fmt.Errorf("failed to parse maintenance job tasks: %w", err)
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return job, nil
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// fillAndDelete leaves free pages behind, as retention deletes do
+func fillAndDelete(t *testing.T, manager *Manager) {
+	t.Helper()
+	statements := []string{
+		"CREATE TABLE IF NOT EXISTS scratch (body TEXT)",
+		"WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2000) INSERT INTO scratch SELECT hex(randomblob(1024)) FROM n",
+		"DELETE FROM scratch",
+	}
+	for _, statement := range statements {
+		if _, err := manager.db.Exec(statement); err != nil {
+			t.Fatalf("Failed to run %q: %v", statement, err)
+		}
+	}
+}
+
+func waitForMaintenance(t *testing.T, manager *Manager, jobID string) *types.MaintenanceJob {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		job, err := manager.MaintenanceJob(context.Background(), jobID)
+		if err != nil {
+			t.Fatalf("MaintenanceJob failed: %v", err)
+		}
+		if job.FinishedAt != nil {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for maintenance job, last seen %+v", job)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// Functional Validation Tests
+func TestManager_Maintenance(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := manager.StartMaintenance([]string{"vacuum", "defrag"}); !errors.Is(err, interfaces.ErrUnknownMaintenanceTask) {
+		t.Errorf("Expected ErrUnknownMaintenanceTask, got %v", err)
+	}
+	backingUp := true
+	manager.SetBackupInProgress(func() bool { return backingUp })
+	if _, err := manager.StartMaintenance([]string{"analyze"}); !errors.Is(err, interfaces.ErrBackupInProgress) {
+		t.Errorf("Expected ErrBackupInProgress, got %v", err)
+	}
+	backingUp = false
+
+	// Without auto_vacuum a full VACUUM rebuilds the file
+	fillAndDelete(t, manager)
+	started, err := manager.StartMaintenance([]string{"vacuum", "integrity_check", "analyze"})
+	if err != nil {
+		t.Fatalf("StartMaintenance failed: %v", err)
+	}
+	if started.Status != types.MaintenanceRunning || len(started.Tasks) != 3 {
+		t.Errorf("Expected a running job with 3 tasks, got %+v", started)
+	}
+	job := waitForMaintenance(t, manager, started.ID)
+	if job.Status != types.MaintenanceCompleted {
+		t.Fatalf("Expected the job completed, got %+v", job)
+	}
+	vacuum, integrity, analyze := job.Tasks[0], job.Tasks[1], job.Tasks[2]
+	if vacuum.Status != types.MaintenanceCompleted || vacuum.Incremental || vacuum.FreedBytes < 1<<20 {
+		t.Errorf("Expected a full vacuum freeing the deleted rows, got %+v", vacuum)
+	}
+	if integrity.Verdict != "ok" || analyze.Status != types.MaintenanceCompleted {
+		t.Errorf("Expected integrity ok and analyze completed, got %+v, %+v", integrity, analyze)
+	}
+	if last := manager.HealthStatus().LastMaintenance; last == nil || last.ID != job.ID {
+		t.Errorf("Expected the job in the health report, got %+v", last)
+	}
+
+	// With auto_vacuum the free pages are returned incrementally
+	conn, err := manager.db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{"PRAGMA auto_vacuum = INCREMENTAL", "VACUUM"} {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("Failed to run %q: %v", statement, err)
+		}
+	}
+	_ = conn.Close()
+	fillAndDelete(t, manager)
+	started, err = manager.StartMaintenance([]string{"vacuum"})
+	if err != nil {
+		t.Fatalf("StartMaintenance failed: %v", err)
+	}
+	incremental := waitForMaintenance(t, manager, started.ID).Tasks[0]
+	if !incremental.Incremental || incremental.FreedBytes < 1<<20 {
+		t.Errorf("Expected an incremental vacuum freeing the deleted rows, got %+v", incremental)
+	}
+
+	// Finished jobs are read back from the database after a restart
+	restarted, err := NewManager(manager.config)
+	if err != nil {
+		t.Fatalf("Failed to reopen: %v", err)
+	}
+	defer func() { _ = restarted.Close() }()
+	if err := restarted.LoadLastMaintenance(ctx); err != nil {
+		t.Fatalf("LoadLastMaintenance failed: %v", err)
+	}
+	if last := restarted.HealthStatus().LastMaintenance; last == nil || last.ID != started.ID || last.Status != types.MaintenanceCompleted {
+		t.Errorf("Expected the last job restored, got %+v", last)
+	}
+	stored, err := restarted.MaintenanceJob(ctx, job.ID)
+	if err != nil || stored.Tasks[1].Verdict != "ok" || stored.FinishedAt == nil {
+		t.Errorf("Expected the first job from the database, got %+v, %v", stored, err)
+	}
+	if _, err := restarted.MaintenanceJob(ctx, "missing"); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestIntegrityVerdict tests functional validation - long problem lists are cut short
+func TestIntegrityVerdict(t *testing.T) {
+	check := &VerifyCheck{}
+	for i := 0; i < maxIntegrityProblems+3; i++ {
+		check.Issues = append(check.Issues, VerifyIssue{Detail: "row missing from index"})
+	}
+	if verdict := integrityVerdict(check); !strings.HasSuffix(verdict, "; and 3 more") {
+		t.Errorf("Expected the verdict cut after %d problems, got %q", maxIntegrityProblems, verdict)
+	}
+}
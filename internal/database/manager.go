@@ -4,32 +4,65 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 	
+	"github.com/google/uuid"
 	// ARCHITECTURAL DISCOVERY: Import SQLite driver but only reference in connection string
 	_ "github.com/mattn/go-sqlite3"
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
 	dbconfig "switchboard/pkg/database"
+	"switchboard/internal/clock"
+	"switchboard/internal/retry"
 )
 
 // Manager implements the DatabaseManager interface
 type Manager struct {
 	db           *sql.DB
+	reader       *sql.DB // Read-only handle for reports; db itself when none is opened
 	config       *dbconfig.Config
 	writeChannel chan writeOperation  // TECHNICAL: Single-writer pattern for SQLite
 	shutdown     chan struct{}
 	wg           sync.WaitGroup
 	closed       bool
 	mu           sync.RWMutex  // TECHNICAL: Protect closed status
+	pinned       *sql.Conn     // Keeps a memory database alive, nil for files
+	clock        interfaces.Clock // Write retries and timeouts, event times, watchdog checks
+	
+	// Health watchdog inputs and state
+	writeAttempts atomic.Int64
+	writeFailures atomic.Int64
+	watchdog      watchdogState
+	diskFree      func(dir string) (uint64, error) // Replaceable in tests
+	
+	// Content compaction
+	noiseKeys    map[string]bool // Top-level content keys dropped when compacting
+	contentStats contentStats
+	
+	maintenance maintenanceState // Admin maintenance jobs
+	purgeBatchSize int            // Rows per purge transaction, 0 = default; lowered in tests
+	
+	uncleanShutdown atomic.Bool // Set when open recovered a stale lock, until the first health report
+	
+	writeStats   writeStats   // Write latency and queue high-water mark for /api/stats
+	messageSizes messageSizes // Stored content sizes by message type, for /metrics
 }
 
+// reportConnections caps the read-only pool reports use
+// FUNCTIONAL DISCOVERY: Reports are downloaded after class, a few at a time; two
+// connections let one report wait behind another rather than fan out over the disk
+const reportConnections = 2
+
 // writeOperation represents a database write operation
 type writeOperation struct {
+	kind      writeKind
 	operation func(*sql.DB) error
+	message   *types.Message // The message being written, quarantined if the write panics
 	result    chan error
 }
 
@@ -37,29 +70,78 @@ type writeOperation struct {
 func NewManager(config *dbconfig.Config) (*Manager, error) {
 	// ARCHITECTURAL DISCOVERY: SQLite connection string includes optimizations from Phase 1
 	// Open database connection with SQLite-specific optimizations
-	db, err := sql.Open("sqlite3", config.DatabasePath+"?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on")
+	dsn := config.DatabasePath + "?_busy_timeout=5000&_journal_mode=WAL&_foreign_keys=on"
+	if config.ReadOnly {
+		// TECHNICAL DISCOVERY: mode=ro needs a file: URI, refuses to create a missing
+		// file, and rules out the journal mode change, which is a write
+		dsn = "file:" + config.DatabasePath + "?mode=ro&_busy_timeout=5000&_foreign_keys=on"
+	}
+	memory := config.DatabasePath == dbconfig.MemoryPath
+	if memory {
+		// TECHNICAL DISCOVERY: Plain :memory: gives every pooled connection its own empty
+		// database; the memdb VFS shares one per name across the pool, and a unique name
+		// keeps managers in the same process apart
+		dsn = "file:/" + uuid.NewString() + "?vfs=memdb&_busy_timeout=5000&_foreign_keys=on"
+	}
+	open := func() (*sql.DB, error) {
+		return openPool(dsn, config, memory)
+	}
+	db, err := open()
+	recovered := false
+	if err != nil && !memory && !config.ReadOnly && isLockError(err) {
+		// FUNCTIONAL DISCOVERY: A lock at open usually means the last run was killed
+		// mid-write; see recoverLockedDatabase
+		db, err = recoverLockedDatabase(config, open, err)
+		recovered = err == nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 	
-	// FUNCTIONAL DISCOVERY: Connection pool configuration critical for concurrent reads
-	// Configure connection pool for concurrent read access
-	db.SetMaxOpenConns(config.MaxConnections)
-	db.SetConnMaxLifetime(config.ConnMaxLifetime)
-	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	// TECHNICAL DISCOVERY: A memdb database is freed with its last connection, so one
+	// is held until Close regardless of the pool's idle limits
+	var pinned *sql.Conn
+	if memory {
+		if pinned, err = db.Conn(context.Background()); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to pin memory database: %w", err)
+		}
+	}
 	
-	// Apply SQLite optimizations from Phase 1 configuration
-	if err := applySQLiteOptimizations(db); err != nil {
-		_ = db.Close()
-		return nil, fmt.Errorf("failed to apply SQLite optimizations: %w", err)
+	// ARCHITECTURAL DISCOVERY: Reports read through their own read-only pool, so a
+	// long report scan never holds connections live traffic is waiting for. Memory and
+	// read-only databases have nothing to protect and share the one pool
+	reader := db
+	if !memory && !config.ReadOnly {
+		reader, err = sql.Open("sqlite3", "file:"+config.DatabasePath+"?mode=ro&_busy_timeout=5000&_foreign_keys=on")
+		if err == nil {
+			err = reader.Ping()
+		}
+		if err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to open read-only report handle: %w", err)
+		}
+		reader.SetMaxOpenConns(reportConnections)
+		reader.SetConnMaxIdleTime(config.ConnMaxIdleTime)
 	}
 	
 	manager := &Manager{
 		db:           db,
+		reader:       reader,
 		config:       config,
+		pinned:       pinned,
+		clock:        clock.Real(),
 		writeChannel: make(chan writeOperation, 100), // TECHNICAL: Buffer for write operations prevents blocking
 		shutdown:     make(chan struct{}),
+		watchdog:     watchdogState{rate: 1},
+		diskFree:     freeDiskBytes,
+		noiseKeys:    make(map[string]bool, len(config.NoiseKeys)),
+		messageSizes: newMessageSizes(),
+	}
+	for _, key := range config.NoiseKeys {
+		manager.noiseKeys[key] = true
 	}
+	manager.uncleanShutdown.Store(recovered)
 	
 	// ARCHITECTURAL DISCOVERY: Single-writer goroutine prevents SQLite write contention
 	// Start single-writer goroutine - critical for SQLite performance
@@ -69,6 +151,47 @@ func NewManager(config *dbconfig.Config) (*Manager, error) {
 	return manager, nil
 }
 
+// openPool opens dsn with the configured pool limits and per-connection settings
+func openPool(dsn string, config *dbconfig.Config, memory bool) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	
+	// FUNCTIONAL DISCOVERY: Connection pool configuration critical for concurrent reads
+	// Configure connection pool for concurrent read access
+	db.SetMaxOpenConns(config.MaxConnections)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	
+	// Apply SQLite optimizations from Phase 1 configuration
+	if config.ReadOnly {
+		if err := db.Ping(); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to open database read-only: %w", err)
+		}
+		return db, nil
+	}
+	if err := applySQLiteOptimizations(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to apply SQLite optimizations: %w", err)
+	}
+	if !memory {
+		if err := probeWriteLock(db); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("failed to take the database write lock: %w", err)
+		}
+	}
+	return db, nil
+}
+
+// SetClock sets the clock write retries, write timeouts and event times use
+// TECHNICAL DISCOVERY: Set right after NewManager, before any write or StartWatchdog;
+// read without locking
+func (m *Manager) SetClock(c interfaces.Clock) {
+	m.clock = c
+}
+
 // writeLoop processes all write operations in a single goroutine
 func (m *Manager) writeLoop() {
 	defer m.wg.Done()
@@ -76,16 +199,18 @@ func (m *Manager) writeLoop() {
 	for {
 		select {
 		case op := <-m.writeChannel:
-			// FUNCTIONAL DISCOVERY: Retry logic exactly once after 5 seconds as specified
-			err := op.operation(m.db)
-			if err != nil {
-				log.Printf("Database write failed, retrying in 5 seconds: %v", err)
-				time.Sleep(5 * time.Second)
-				err = op.operation(m.db) // Retry once
-				if err != nil {
-					log.Printf("Database write failed after retry: %v", err)
-				}
+			attempts := 0
+			err := m.writeRetry().Do(context.Background(), func(context.Context) error {
+				attempts++
+				return runOperation(op, m.db)
+			})
+			if err != nil && attempts > 1 {
+				log.Printf("Database write failed after %d attempts: %v", attempts, err)
 			}
+			if errors.Is(err, ErrWritePanic) && op.message != nil {
+				_ = m.quarantine(m.db, op.message, err)
+			}
+			m.recordWrite(err)
 			op.result <- err
 			
 		case <-m.shutdown:
@@ -95,8 +220,45 @@ func (m *Manager) writeLoop() {
 	}
 }
 
+// writeRetry is how the write loop retries a failed write
+// FUNCTIONAL DISCOVERY: Two more attempts after short jittered waits, about 250ms then
+// 500ms, since every queued write waits behind them. While degraded, fail fast - the
+// retry only stalls the queue behind a full disk. A panic is never retried: the same
+// input panics again
+func (m *Manager) writeRetry() retry.Policy {
+	return retry.Policy{
+		MaxAttempts: 3,
+		Initial:     250 * time.Millisecond,
+		Multiplier:  2,
+		Max:         time.Second,
+		Jitter:      0.2,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, ErrWritePanic) && !m.isDegraded()
+		},
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			log.Printf("Database write failed, retrying in %v: %v", delay.Round(time.Millisecond), err)
+		},
+		Clock: m.clock,
+	}
+}
+
 // executeWrite queues a write operation and waits for completion
 func (m *Manager) executeWrite(operation func(*sql.DB) error) error {
+	return m.queueWrite(writeOperation{kind: writeKindOther, operation: operation})
+}
+
+// executeSessionWrite queues a write to the sessions table
+func (m *Manager) executeSessionWrite(operation func(*sql.DB) error) error {
+	return m.queueWrite(writeOperation{kind: writeKindSession, operation: operation})
+}
+
+// executeMessageWrite queues a write of message, which is quarantined if the write panics
+func (m *Manager) executeMessageWrite(message *types.Message, operation func(*sql.DB) error) error {
+	return m.queueWrite(writeOperation{kind: writeKindMessage, operation: operation, message: message})
+}
+
+// queueWrite hands op to the single writer, waits for its result and records its latency
+func (m *Manager) queueWrite(op writeOperation) error {
 	// TECHNICAL DISCOVERY: Check if manager is closed before attempting write
 	m.mu.RLock()
 	if m.closed {
@@ -105,12 +267,16 @@ func (m *Manager) executeWrite(operation func(*sql.DB) error) error {
 	}
 	m.mu.RUnlock()
 	
-	result := make(chan error, 1)
+	op.result = make(chan error, 1)
+	queued := time.Now() // Real time even under a test clock; latency is measured, not simulated
 	
 	select {
-	case m.writeChannel <- writeOperation{operation: operation, result: result}:
-		return <-result
-	case <-time.After(30 * time.Second):
+	case m.writeChannel <- op:
+		m.writeStats.observeDepth(len(m.writeChannel))
+		err := <-op.result
+		m.writeStats.latency[op.kind].observe(time.Since(queued))
+		return err
+	case <-m.clock.After(30 * time.Second):
 		return fmt.Errorf("write operation timeout")
 	case <-m.shutdown:
 		return fmt.Errorf("database manager is shutting down")
@@ -119,7 +285,10 @@ func (m *Manager) executeWrite(operation func(*sql.DB) error) error {
 
 // CreateSession creates a new session in the database
 func (m *Manager) CreateSession(ctx context.Context, session *types.Session) error {
-	return m.executeWrite(func(db *sql.DB) error {
+	if state := session.State(); !state.Valid() {
+		return fmt.Errorf("%w: %q", types.ErrUnknownSessionState, state)
+	}
+	return m.executeSessionWrite(func(db *sql.DB) error {
 		// FUNCTIONAL DISCOVERY: Transaction support essential for atomic session operations
 		// Begin transaction for atomic session creation
 		tx, err := db.BeginTx(ctx, nil)
@@ -135,10 +304,29 @@ func (m *Manager) CreateSession(ctx context.Context, session *types.Session) err
 			return fmt.Errorf("failed to marshal student IDs: %w", err)
 		}
 		
+		// Sessions created without a snapshot, e.g. restored ones, store NULL
+		var configJSON sql.NullString
+		if session.Config != nil {
+			encoded, err := json.Marshal(session.Config)
+			if err != nil {
+				return fmt.Errorf("failed to marshal session config: %w", err)
+			}
+			configJSON = sql.NullString{String: string(encoded), Valid: true}
+		}
+		
+		metadataJSON, err := encodeMetadata(session.Metadata)
+		if err != nil {
+			return err
+		}
+		instructorIDsJSON, err := encodeInstructorIDs(session.InstructorIDs)
+		if err != nil {
+			return err
+		}
+		
 		// Insert session with all required fields
 		query := `
-			INSERT INTO sessions (id, name, created_by, student_ids, start_time, status)
-			VALUES (?, ?, ?, ?, ?, ?)
+			INSERT INTO sessions (id, name, created_by, student_ids, start_time, status, duration_minutes, owner_id, locked, timezone, session_config, metadata, instructor_ids)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 		_, err = tx.ExecContext(ctx, query,
 			session.ID,
@@ -146,7 +334,14 @@ func (m *Manager) CreateSession(ctx context.Context, session *types.Session) err
 			session.CreatedBy,
 			string(studentIDsJSON),
 			session.StartTime,
-			session.Status,
+			session.State(),
+			session.DurationMinutes,
+			session.Owner(),
+			session.Locked,
+			session.Zone(),
+			configJSON,
+			metadataJSON,
+			instructorIDsJSON,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert session: %w", err)
@@ -166,7 +361,7 @@ func (m *Manager) CreateSession(ctx context.Context, session *types.Session) err
 func (m *Manager) GetSession(ctx context.Context, sessionID string) (*types.Session, error) {
 	// ARCHITECTURAL DISCOVERY: Read operations can be concurrent - no need for writeChannel
 	query := `
-		SELECT id, name, created_by, student_ids, start_time, end_time, status
+		SELECT id, name, created_by, student_ids, start_time, end_time, status, duration_minutes, owner_id, locked, timezone, metadata, instructor_ids
 		FROM sessions
 		WHERE id = ?
 	`
@@ -174,7 +369,7 @@ func (m *Manager) GetSession(ctx context.Context, sessionID string) (*types.Sess
 	row := m.db.QueryRowContext(ctx, query, sessionID)
 	
 	var session types.Session
-	var studentIDsJSON string
+	var studentIDsJSON, metadataJSON, instructorIDsJSON string
 	var endTime sql.NullTime
 	
 	err := row.Scan(
@@ -185,6 +380,12 @@ func (m *Manager) GetSession(ctx context.Context, sessionID string) (*types.Sess
 		&session.StartTime,
 		&endTime,
 		&session.Status,
+		&session.DurationMinutes,
+		&session.OwnerID,
+		&session.Locked,
+		&session.Timezone,
+		&metadataJSON,
+		&instructorIDsJSON,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -199,6 +400,12 @@ func (m *Manager) GetSession(ctx context.Context, sessionID string) (*types.Sess
 	if err := json.Unmarshal([]byte(studentIDsJSON), &session.StudentIDs); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal student IDs: %w", err)
 	}
+	if session.Metadata, err = decodeMetadata(metadataJSON); err != nil {
+		return nil, err
+	}
+	if session.InstructorIDs, err = decodeInstructorIDs(instructorIDsJSON); err != nil {
+		return nil, err
+	}
 	
 	// FUNCTIONAL DISCOVERY: Handle nullable end_time field properly
 	// Handle nullable end_time
@@ -210,18 +417,39 @@ func (m *Manager) GetSession(ctx context.Context, sessionID string) (*types.Sess
 }
 
 // UpdateSession updates an existing session
+// FUNCTIONAL DISCOVERY: A status change must be a legal types.SessionState transition,
+// checked against the stored status, so a duration change that raced the end cannot
+// make an ended session active again
 func (m *Manager) UpdateSession(ctx context.Context, session *types.Session) error {
-	return m.executeWrite(func(db *sql.DB) error {
-		// FUNCTIONAL DISCOVERY: Update only end_time and status during session termination
+	return m.executeSessionWrite(func(db *sql.DB) error {
+		// TECHNICAL DISCOVERY: Read and update need no transaction - this is the only
+		// writer, so nothing can change the status in between
+		var current types.SessionState
+		err := db.QueryRowContext(ctx, `SELECT status FROM sessions WHERE id = ?`, session.ID).Scan(&current)
+		if err == sql.ErrNoRows {
+			return interfaces.ErrSessionNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read session status: %w", err)
+		}
+		if session.Status != current {
+			if _, err := current.Transition(session.Status); err != nil {
+				return err
+			}
+		}
+		
+		// FUNCTIONAL DISCOVERY: Update only the mutable fields - end_time and status during
+		// termination, duration_minutes when a session is extended
 		query := `
 			UPDATE sessions
-			SET end_time = ?, status = ?
+			SET end_time = ?, status = ?, duration_minutes = ?
 			WHERE id = ?
 		`
 		
-		_, err := db.ExecContext(ctx, query,
+		_, err = db.ExecContext(ctx, query,
 			session.EndTime,
 			session.Status,
+			session.DurationMinutes,
 			session.ID,
 		)
 		if err != nil {
@@ -236,7 +464,7 @@ func (m *Manager) UpdateSession(ctx context.Context, session *types.Session) err
 func (m *Manager) ListActiveSessions(ctx context.Context) ([]*types.Session, error) {
 	// ARCHITECTURAL DISCOVERY: Read operations concurrent, ordered by start_time DESC for recency
 	query := `
-		SELECT id, name, created_by, student_ids, start_time, end_time, status
+		SELECT id, name, created_by, student_ids, start_time, end_time, status, duration_minutes, owner_id, locked, timezone, metadata, instructor_ids
 		FROM sessions
 		WHERE status = 'active'
 		ORDER BY start_time DESC
@@ -252,7 +480,7 @@ func (m *Manager) ListActiveSessions(ctx context.Context) ([]*types.Session, err
 	
 	for rows.Next() {
 		var session types.Session
-		var studentIDsJSON string
+		var studentIDsJSON, metadataJSON, instructorIDsJSON string
 		var endTime sql.NullTime
 		
 		err := rows.Scan(
@@ -263,6 +491,12 @@ func (m *Manager) ListActiveSessions(ctx context.Context) ([]*types.Session, err
 			&session.StartTime,
 			&endTime,
 			&session.Status,
+			&session.DurationMinutes,
+			&session.OwnerID,
+			&session.Locked,
+			&session.Timezone,
+			&metadataJSON,
+			&instructorIDsJSON,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan session row: %w", err)
@@ -273,6 +507,12 @@ func (m *Manager) ListActiveSessions(ctx context.Context) ([]*types.Session, err
 		if err := json.Unmarshal([]byte(studentIDsJSON), &session.StudentIDs); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal student IDs: %w", err)
 		}
+		if session.Metadata, err = decodeMetadata(metadataJSON); err != nil {
+			return nil, err
+		}
+		if session.InstructorIDs, err = decodeInstructorIDs(instructorIDsJSON); err != nil {
+			return nil, err
+		}
 		
 		// Handle nullable end_time
 		if endTime.Valid {
@@ -289,48 +529,731 @@ func (m *Manager) ListActiveSessions(ctx context.Context) ([]*types.Session, err
 	return sessions, nil
 }
 
+// ActiveSessionNames returns the names of a creator's active sessions
+// TECHNICAL DISCOVERY: Served by the partial idx_sessions_active_name index
+func (m *Manager) ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT name FROM sessions WHERE created_by = ? AND status = 'active'`, createdBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active session names: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan session name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session names: %w", err)
+	}
+	
+	return names, nil
+}
+
+// TransferSessionOwner changes an active session's owner and audits the change
+// TECHNICAL DISCOVERY: "AND owner_id = ?" makes the update a compare-and-swap, so of two
+// concurrent transfers from the same owner only the first changes a row
+func (m *Manager) TransferSessionOwner(ctx context.Context, sessionID, previousOwner, newOwner, actor string) error {
+	details, err := json.Marshal(map[string]string{
+		"previous_owner": previousOwner,
+		"new_owner":      newOwner,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal transfer details: %w", err)
+	}
+	
+	return m.executeSessionWrite(func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		
+		result, err := tx.ExecContext(ctx,
+			`UPDATE sessions SET owner_id = ? WHERE id = ? AND owner_id = ? AND status = 'active'`,
+			newOwner, sessionID, previousOwner,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update session owner: %w", err)
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to read updated rows: %w", err)
+		} else if updated == 0 {
+			return interfaces.ErrSessionNotFound
+		}
+		
+		_, err = tx.ExecContext(ctx,
+			`INSERT INTO session_events (session_id, event_type, actor, details, created_at) VALUES (?, ?, ?, ?, ?)`,
+			sessionID, types.SessionEventOwnerTransferred, actor, string(details), m.clock.Now(),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert session event: %w", err)
+		}
+		
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit owner transfer: %w", err)
+		}
+		return nil
+	})
+}
+
+// SetSessionLocked sets the lock flag of an active session
+// TECHNICAL DISCOVERY: "AND status = 'active'" keeps a request that raced the end
+// from changing an ended session
+func (m *Manager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) error {
+	return m.executeSessionWrite(func(db *sql.DB) error {
+		result, err := db.ExecContext(ctx,
+			`UPDATE sessions SET locked = ? WHERE id = ? AND status = 'active'`,
+			locked, sessionID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update session lock: %w", err)
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to read updated rows: %w", err)
+		} else if updated == 0 {
+			return interfaces.ErrSessionNotFound
+		}
+		return nil
+	})
+}
+
+// SetSessionTimezone sets the IANA time zone of an active session
+// TECHNICAL DISCOVERY: Like SetSessionLocked, touches only its own column so it cannot
+// overwrite a concurrent duration change, lock toggle or transfer
+func (m *Manager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) error {
+	return m.executeSessionWrite(func(db *sql.DB) error {
+		result, err := db.ExecContext(ctx,
+			`UPDATE sessions SET timezone = ? WHERE id = ? AND status = 'active'`,
+			timezone, sessionID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update session time zone: %w", err)
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to read updated rows: %w", err)
+		} else if updated == 0 {
+			return interfaces.ErrSessionNotFound
+		}
+		return nil
+	})
+}
+
+// SetSessionMetadata replaces the metadata of an active session
+// TECHNICAL DISCOVERY: Like SetSessionTimezone, touches only its own column
+func (m *Manager) SetSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error {
+	metadataJSON, err := encodeMetadata(metadata)
+	if err != nil {
+		return err
+	}
+	return m.executeSessionWrite(func(db *sql.DB) error {
+		result, err := db.ExecContext(ctx,
+			`UPDATE sessions SET metadata = ? WHERE id = ? AND status = 'active'`,
+			metadataJSON, sessionID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update session metadata: %w", err)
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to read updated rows: %w", err)
+		} else if updated == 0 {
+			return interfaces.ErrSessionNotFound
+		}
+		return nil
+	})
+}
+
+// SetSessionInstructors replaces the instructor_ids of an active session
+// TECHNICAL DISCOVERY: Like SetSessionMetadata, touches only its own column
+func (m *Manager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) error {
+	instructorIDsJSON, err := encodeInstructorIDs(instructorIDs)
+	if err != nil {
+		return err
+	}
+	return m.executeSessionWrite(func(db *sql.DB) error {
+		result, err := db.ExecContext(ctx,
+			`UPDATE sessions SET instructor_ids = ? WHERE id = ? AND status = 'active'`,
+			instructorIDsJSON, sessionID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update session instructors: %w", err)
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to read updated rows: %w", err)
+		} else if updated == 0 {
+			return interfaces.ErrSessionNotFound
+		}
+		return nil
+	})
+}
+
+// SetSessionStudents replaces the student_ids of an active session
+func (m *Manager) SetSessionStudents(ctx context.Context, sessionID string, studentIDs []string) error {
+	studentIDsJSON, err := json.Marshal(studentIDs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal student IDs: %w", err)
+	}
+	return m.executeSessionWrite(func(db *sql.DB) error {
+		result, err := db.ExecContext(ctx,
+			`UPDATE sessions SET student_ids = ? WHERE id = ? AND status = 'active'`,
+			string(studentIDsJSON), sessionID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update session students: %w", err)
+		}
+		if updated, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to read updated rows: %w", err)
+		} else if updated == 0 {
+			return interfaces.ErrSessionNotFound
+		}
+		return nil
+	})
+}
+
+// ActiveSessionsByMetadata returns the IDs of active sessions whose metadata has every
+// key of filters set to the given value
+// TECHNICAL DISCOVERY: Matched with json_extract in SQL; keys are quoted in the JSON
+// path and refused unless IsValidMetadataKey, so no key can change the path
+func (m *Manager) ActiveSessionsByMetadata(ctx context.Context, filters map[string]string) ([]string, error) {
+	query := `SELECT id FROM sessions WHERE status = 'active'`
+	args := make([]interface{}, 0, 2*len(filters))
+	for key, value := range filters {
+		if !types.IsValidMetadataKey(key) {
+			return nil, fmt.Errorf("%w: invalid key %q", types.ErrInvalidMetadata, key)
+		}
+		query += ` AND json_extract(metadata, ?) = ?`
+		args = append(args, `$."`+key+`"`, value)
+	}
+	
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions by metadata: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SessionCount returns how many sessions the database holds, active or ended
+// FUNCTIONAL DISCOVERY: Zero means a first start; -bootstrap-demo only creates its
+// session then, so pointing it at a real database never adds one
+func (m *Manager) SessionCount(ctx context.Context) (int, error) {
+	var count int
+	if err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sessions`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count sessions: %w", err)
+	}
+	return count, nil
+}
+
+// encodeMetadata stores nil metadata as an empty object, matching the column default
+func encodeMetadata(metadata map[string]string) (string, error) {
+	if len(metadata) == 0 {
+		return "{}", nil
+	}
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session metadata: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// encodeInstructorIDs stores a nil list as an empty array, matching the column default
+func encodeInstructorIDs(instructorIDs []string) (string, error) {
+	if len(instructorIDs) == 0 {
+		return "[]", nil
+	}
+	encoded, err := json.Marshal(instructorIDs)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal session instructors: %w", err)
+	}
+	return string(encoded), nil
+}
+
+// decodeInstructorIDs reads the instructor_ids column; an empty array reads back as nil
+func decodeInstructorIDs(instructorIDsJSON string) ([]string, error) {
+	var instructorIDs []string
+	if err := json.Unmarshal([]byte(instructorIDsJSON), &instructorIDs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session instructors: %w", err)
+	}
+	if len(instructorIDs) == 0 {
+		return nil, nil
+	}
+	return instructorIDs, nil
+}
+
+// decodeMetadata reads the metadata column; an empty object reads back as nil
+func decodeMetadata(metadataJSON string) (map[string]string, error) {
+	var metadata map[string]string
+	if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session metadata: %w", err)
+	}
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
+
+// RecordSessionEvent appends an entry to a session's audit trail
+// FUNCTIONAL DISCOVERY: CreatedAt defaults to now; events are never updated or deleted
+func (m *Manager) RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error {
+	details, err := json.Marshal(event.Details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session event details: %w", err)
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = m.clock.Now()
+	}
+	
+	return m.executeWrite(func(db *sql.DB) error {
+		result, err := db.ExecContext(ctx,
+			`INSERT INTO session_events (session_id, event_type, actor, details, created_at) VALUES (?, ?, ?, ?, ?)`,
+			event.SessionID, event.Type, event.Actor, string(details), event.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert session event: %w", err)
+		}
+		if event.ID, err = result.LastInsertId(); err != nil {
+			return fmt.Errorf("failed to read session event id: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetSessionConfig retrieves the routing configuration a session was created under
+func (m *Manager) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) {
+	return sessionConfig(ctx, m.db, sessionID)
+}
+
+func sessionConfig(ctx context.Context, db *sql.DB, sessionID string) (*types.SessionConfig, error) {
+	var stored sql.NullString
+	err := db.QueryRowContext(ctx, `SELECT session_config FROM sessions WHERE id = ?`, sessionID).Scan(&stored)
+	if err == sql.ErrNoRows {
+		return nil, interfaces.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session config: %w", err)
+	}
+	if !stored.Valid {
+		return nil, nil // Created before snapshots were recorded
+	}
+	
+	config, err := types.ParseSessionConfig([]byte(stored.String))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config of session %s: %w", sessionID, err)
+	}
+	return config, nil
+}
+
+// GetSessionEvents retrieves a session's audit trail in the order it was written
+func (m *Manager) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) {
+	return sessionEvents(ctx, m.db, sessionID)
+}
+
+func sessionEvents(ctx context.Context, db *sql.DB, sessionID string) ([]*types.SessionEvent, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, session_id, event_type, actor, details, created_at
+		FROM session_events
+		WHERE session_id = ?
+		ORDER BY created_at, id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session events: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	var events []*types.SessionEvent
+	for rows.Next() {
+		var event types.SessionEvent
+		var details string
+		if err := rows.Scan(&event.ID, &event.SessionID, &event.Type, &event.Actor, &details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session event: %w", err)
+		}
+		if err := json.Unmarshal([]byte(details), &event.Details); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal session event details: %w", err)
+		}
+		events = append(events, &event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session events: %w", err)
+	}
+	
+	return events, nil
+}
+
 // StoreMessage stores a message in the database
+// FUNCTIONAL DISCOVERY: In route-only degraded mode the write is skipped and counted,
+// so live teaching continues while history stops
+// FUNCTIONAL DISCOVERY: Skipped writes and writes that still fail after the retry are
+// reported to persist-failure listeners so the sender learns which message is missing
 func (m *Manager) StoreMessage(ctx context.Context, message *types.Message) error {
-	return m.executeWrite(func(db *sql.DB) error {
-		// TECHNICAL DISCOVERY: JSON serialization for message content enables flexible payloads
-		// Serialize message content to JSON
-		contentJSON, err := json.Marshal(message.Content)
+	if m.routeOnly() {
+		m.recordSkippedWrite()
+		m.persistFailed(message, interfaces.ErrPersistSkipped)
+		return nil
+	}
+	if message.SessionID == types.SelfTestSessionID {
+		return m.storeProbe(ctx, message)
+	}
+	
+	// TECHNICAL DISCOVERY: JSON serialization for message content enables flexible payloads
+	// Serialize message content to JSON
+	contentJSON, err := m.encodeContent(message.Content)
+	if err != nil {
+		err = fmt.Errorf("failed to marshal message content: %w", err)
+		if quarantineErr := m.executeWrite(func(db *sql.DB) error { return m.quarantine(db, message, err) }); quarantineErr != nil {
+			log.Printf("Poison message %s was not quarantined: %v", message.ID, quarantineErr)
+		}
+		m.persistFailed(message, err)
+		return err
+	}
+	
+	var shared sharedContent
+	err = m.executeMessageWrite(message, func(db *sql.DB) error {
+		// TECHNICAL DISCOVERY: A shared body and the row referencing it commit together
+		tx, err := db.BeginTx(ctx, nil)
 		if err != nil {
-			return fmt.Errorf("failed to marshal message content: %w", err)
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		
+		if shared, err = m.shareContent(ctx, tx, contentJSON); err != nil {
+			return err
 		}
 		
 		// FUNCTIONAL DISCOVERY: Handle nullable to_user field for different message types
 		query := `
-			INSERT INTO messages (id, session_id, type, context, from_user, to_user, content, timestamp)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			INSERT INTO messages (id, session_id, type, context, from_user, to_user, content, content_hash, timestamp, to_users, attachment_mime, attachment_bytes, content_bytes)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
 		
-		_, err = db.ExecContext(ctx, query,
+		attachmentMIME, attachmentBytes := attachmentColumns(message.Content)
+		_, err = tx.ExecContext(ctx, query,
 			message.ID,
 			message.SessionID,
 			message.Type,
 			message.Context,
 			message.FromUser,
 			message.ToUser,
-			string(contentJSON),
+			shared.inline,
+			shared.hash,
 			message.Timestamp,
+			encodeToUsers(message.ToUsers),
+			attachmentMIME,
+			attachmentBytes,
+			len(contentJSON),
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert message: %w", err)
 		}
 		
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit message: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		m.persistFailed(message, err)
+		return err
+	}
+	if shared.reused {
+		m.recordDeduplicated(int64(len(contentJSON)))
+	}
+	m.messageSizes.observe(message.Type, len(contentJSON))
+	return nil
+}
+
+// selfTestProbesKept bounds the selftest_probes table
+const selfTestProbesKept = 100
+
+// storeProbe persists a self-test probe in place of a message
+// FUNCTIONAL DISCOVERY: Goes through the same single writer as live messages, so a
+// stalled write queue or failing disk fails the probe too; older probes are pruned in
+// the same write
+func (m *Manager) storeProbe(ctx context.Context, message *types.Message) error {
+	contentJSON, err := json.Marshal(message.Content)
+	if err != nil {
+		return fmt.Errorf("failed to marshal probe content: %w", err)
+	}
+	
+	return m.executeWrite(func(db *sql.DB) error {
+		query := `
+			INSERT INTO selftest_probes (id, from_user, content, timestamp)
+			VALUES (?, ?, ?, ?)
+		`
+		if _, err := db.ExecContext(ctx, query, message.ID, message.FromUser, string(contentJSON), message.Timestamp); err != nil {
+			return fmt.Errorf("failed to insert probe: %w", err)
+		}
+		
+		prune := `
+			DELETE FROM selftest_probes WHERE id NOT IN (
+				SELECT id FROM selftest_probes ORDER BY timestamp DESC LIMIT ?
+			)
+		`
+		if _, err := db.ExecContext(ctx, prune, selfTestProbesKept); err != nil {
+			return fmt.Errorf("failed to prune probes: %w", err)
+		}
+		return nil
+	})
+}
+
+// ImportMessages persists historical messages in batched transactions
+// ARCHITECTURAL DISCOVERY: Each batch is a single writeOperation so imports share
+// the single-writer channel with live traffic instead of bypassing it
+// FUNCTIONAL DISCOVERY: INSERT OR IGNORE skips messages whose IDs already exist
+func (m *Manager) ImportMessages(ctx context.Context, messages []*types.Message) ([]string, error) {
+	batchSize := m.config.ImportBatchSize
+	if batchSize <= 0 {
+		batchSize = dbconfig.DefaultImportBatchSize
+	}
+	
+	var skipped []string
+	for start := 0; start < len(messages); start += batchSize {
+		batch := messages[start:min(start+batchSize, len(messages))]
+		
+		contents := make([][]byte, len(batch))
+		for i, message := range batch {
+			contentJSON, err := m.encodeContent(message.Content)
+			if err != nil {
+				return skipped, fmt.Errorf("import failed at record %d: failed to marshal content for message %s: %w", start+i, message.ID, err)
+			}
+			contents[i] = contentJSON
+		}
+		
+		var batchSkipped []string
+		var batchImported []int // Positions in batch of the messages inserted
+		var batchDeduped int64
+		err := m.executeMessageWrite(nil, func(db *sql.DB) error {
+			// TECHNICAL DISCOVERY: Reset per attempt - writeLoop retries failed operations
+			batchSkipped = nil
+			batchImported = batchImported[:0]
+			batchDeduped = 0
+			
+			tx, err := db.BeginTx(ctx, nil)
+			if err != nil {
+				return fmt.Errorf("failed to begin transaction: %w", err)
+			}
+			defer func() { _ = tx.Rollback() }()
+			
+			stmt, err := tx.PrepareContext(ctx, `
+				INSERT OR IGNORE INTO messages (id, session_id, type, context, from_user, to_user, content, content_hash, timestamp, to_users, attachment_mime, attachment_bytes, content_bytes)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			`)
+			if err != nil {
+				return fmt.Errorf("failed to prepare import statement: %w", err)
+			}
+			defer func() { _ = stmt.Close() }()
+			
+			for i, message := range batch {
+				shared, err := m.shareContent(ctx, tx, contents[i])
+				if err != nil {
+					return fmt.Errorf("failed to import message %s: %w", message.ID, err)
+				}
+				
+				attachmentMIME, attachmentBytes := attachmentColumns(message.Content)
+				result, err := stmt.ExecContext(ctx,
+					message.ID,
+					message.SessionID,
+					message.Type,
+					message.Context,
+					message.FromUser,
+					message.ToUser,
+					shared.inline,
+					shared.hash,
+					message.Timestamp,
+					encodeToUsers(message.ToUsers),
+					attachmentMIME,
+					attachmentBytes,
+					len(contents[i]),
+				)
+				if err != nil {
+					return fmt.Errorf("failed to import message %s: %w", message.ID, err)
+				}
+				
+				if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+					batchSkipped = append(batchSkipped, message.ID)
+					if err := releaseUnreferenced(ctx, tx, shared.hash); err != nil {
+						return fmt.Errorf("failed to import message %s: %w", message.ID, err)
+					}
+					continue
+				}
+				batchImported = append(batchImported, i)
+				if shared.reused {
+					batchDeduped += int64(len(contents[i]))
+				}
+			}
+			
+			if err := tx.Commit(); err != nil {
+				return fmt.Errorf("failed to commit import batch: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return skipped, fmt.Errorf("import failed at record %d: %w", start, err)
+		}
+		
+		skipped = append(skipped, batchSkipped...)
+		m.recordDeduplicated(batchDeduped)
+		for _, i := range batchImported {
+			m.messageSizes.observe(batch[i].Type, len(contents[i]))
+		}
+	}
+	
+	return skipped, nil
+}
+
+// StoreMessageMetadata records connection details for a persisted message
+// FUNCTIONAL DISCOVERY: INSERT OR REPLACE keeps the call idempotent if a caller retries
+func (m *Manager) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error {
+	if m.routeOnly() {
+		return nil // The message row was skipped too, so the foreign key would fail
+	}
+	
+	return m.executeWrite(func(db *sql.DB) error {
+		query := `
+			INSERT OR REPLACE INTO message_metadata (message_id, connection_id, client_ip, user_agent, recorded_at)
+			VALUES (?, ?, ?, ?, ?)
+		`
+		_, err := db.ExecContext(ctx, query,
+			metadata.MessageID,
+			metadata.ConnectionID,
+			metadata.ClientIP,
+			metadata.UserAgent,
+			metadata.RecordedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert message metadata: %w", err)
+		}
 		return nil
 	})
 }
 
+// GetMessageMetadata retrieves connection details for a message
+func (m *Manager) GetMessageMetadata(ctx context.Context, messageID string) (*types.MessageMetadata, error) {
+	query := `
+		SELECT message_id, connection_id, client_ip, user_agent, recorded_at
+		FROM message_metadata
+		WHERE message_id = ?
+	`
+	
+	var metadata types.MessageMetadata
+	err := m.db.QueryRowContext(ctx, query, messageID).Scan(
+		&metadata.MessageID,
+		&metadata.ConnectionID,
+		&metadata.ClientIP,
+		&metadata.UserAgent,
+		&metadata.RecordedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, interfaces.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to query message metadata: %w", err)
+	}
+	
+	return &metadata, nil
+}
+
+// SetMessageAnnotation stores or clears an instructor's annotation on a message
+// TECHNICAL DISCOVERY: The session check and the write share one transaction, so a
+// message ID from another session is never annotated through this session's URL
+func (m *Manager) SetMessageAnnotation(ctx context.Context, sessionID string, annotation *types.MessageAnnotation) error {
+	tags, err := json.Marshal(annotation.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to marshal annotation tags: %w", err)
+	}
+	
+	return m.executeWrite(func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		
+		var found int
+		err = tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM messages WHERE id = ? AND session_id = ?`,
+			annotation.MessageID, sessionID,
+		).Scan(&found)
+		if err != nil {
+			return fmt.Errorf("failed to check message: %w", err)
+		}
+		if found == 0 {
+			return interfaces.ErrNotFound
+		}
+		
+		if len(annotation.Tags) == 0 && annotation.Note == "" {
+			_, err = tx.ExecContext(ctx,
+				`DELETE FROM message_annotations WHERE message_id = ? AND instructor_id = ?`,
+				annotation.MessageID, annotation.InstructorID,
+			)
+		} else {
+			_, err = tx.ExecContext(ctx, `
+				INSERT OR REPLACE INTO message_annotations (message_id, instructor_id, tags, note, updated_at)
+				VALUES (?, ?, ?, ?, ?)
+			`, annotation.MessageID, annotation.InstructorID, string(tags), annotation.Note, annotation.UpdatedAt)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store message annotation: %w", err)
+		}
+		
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit message annotation: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetSessionAnnotations retrieves all annotations on a session's messages
+func (m *Manager) GetSessionAnnotations(ctx context.Context, sessionID string) ([]*types.MessageAnnotation, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT a.message_id, a.instructor_id, a.tags, a.note, a.updated_at
+		FROM message_annotations a
+		JOIN messages msg ON msg.id = a.message_id
+		WHERE msg.session_id = ?
+		ORDER BY a.updated_at, a.instructor_id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message annotations: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	var annotations []*types.MessageAnnotation
+	for rows.Next() {
+		var annotation types.MessageAnnotation
+		var tags string
+		if err := rows.Scan(&annotation.MessageID, &annotation.InstructorID, &tags, &annotation.Note, &annotation.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message annotation: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tags), &annotation.Tags); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal annotation tags: %w", err)
+		}
+		annotations = append(annotations, &annotation)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message annotations: %w", err)
+	}
+	
+	return annotations, nil
+}
+
 // GetSessionHistory retrieves all messages for a session
 func (m *Manager) GetSessionHistory(ctx context.Context, sessionID string) ([]*types.Message, error) {
 	// FUNCTIONAL DISCOVERY: Order by timestamp ASC for chronological message history
-	query := `
-		SELECT id, session_id, type, context, from_user, to_user, content, timestamp
-		FROM messages
-		WHERE session_id = ?
-		ORDER BY timestamp ASC
+	// rowid breaks timestamp ties so imported transcripts keep their original sequence
+	query := selectMessages + `
+		WHERE m.session_id = ?
+		ORDER BY m.timestamp ASC, m.rowid ASC
 	`
 	
 	rows, err := m.db.QueryContext(ctx, query, sessionID)
@@ -339,49 +1262,274 @@ func (m *Manager) GetSessionHistory(ctx context.Context, sessionID string) ([]*t
 	}
 	defer func() { _ = rows.Close() }()
 	
-	var messages []*types.Message
+	return scanMessages(rows)
+}
+
+// GetMessage retrieves one message of a session by ID
+func (m *Manager) GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error) {
+	query := selectMessages + `
+		WHERE m.id = ? AND m.session_id = ?
+	`
+	
+	rows, err := m.db.QueryContext(ctx, query, messageID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
 	
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, interfaces.ErrNotFound
+	}
+	return messages[0], nil
+}
+
+// GetSessionHistoryAsOf retrieves the messages of a session sent at or before asOf
+// TECHNICAL DISCOVERY: Timestamps are stored as text with the writer's zone offset and
+// trimmed fractional seconds, so text comparison is only approximately chronological.
+// The query bounds the idx_messages_session_time range scan a day past asOf (wider
+// than any zone offset) and the exact cut is made on parsed times
+func (m *Manager) GetSessionHistoryAsOf(ctx context.Context, sessionID string, asOf time.Time) ([]*types.Message, error) {
+	query := selectMessages + `
+		WHERE m.session_id = ? AND m.timestamp <= ?
+		ORDER BY m.timestamp ASC, m.rowid ASC
+	`
+	
+	rows, err := m.db.QueryContext(ctx, query, sessionID, asOf.Add(24*time.Hour))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session history: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	messages, err := scanMessages(rows)
+	if err != nil {
+		return nil, err
+	}
+	
+	visible := messages[:0]
+	for _, message := range messages {
+		if !message.Timestamp.After(asOf) {
+			visible = append(visible, message)
+		}
+	}
+	return visible, nil
+}
+
+// GetAnalyticsBuckets counts the analytics messages a user sent in a session per
+// context and minute
+// TECHNICAL DISCOVERY: strftime normalizes each stored timestamp, offset included, to
+// UTC before truncating, so buckets line up across writers in different zones. The
+// scan is bounded by idx_messages_session_type
+func (m *Manager) GetAnalyticsBuckets(ctx context.Context, sessionID, userID string) ([]*types.AnalyticsBucket, error) {
+	query := `
+		SELECT context, strftime('%Y-%m-%dT%H:%M:00Z', timestamp) AS minute, COUNT(*)
+		FROM messages
+		WHERE session_id = ? AND type = ? AND from_user = ?
+		GROUP BY context, minute
+		ORDER BY minute ASC, context ASC
+	`
+	
+	rows, err := m.db.QueryContext(ctx, query, sessionID, types.MessageTypeAnalytics, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analytics buckets: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	var buckets []*types.AnalyticsBucket
 	for rows.Next() {
-		var message types.Message
-		var contentJSON string
-		var toUser sql.NullString
-		
-		err := rows.Scan(
-			&message.ID,
-			&message.SessionID,
-			&message.Type,
-			&message.Context,
-			&message.FromUser,
-			&toUser,
-			&contentJSON,
-			&message.Timestamp,
-		)
+		var bucket types.AnalyticsBucket
+		var minute string
+		if err := rows.Scan(&bucket.Context, &minute, &bucket.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan analytics bucket: %w", err)
+		}
+		if bucket.Minute, err = time.Parse(time.RFC3339, minute); err != nil {
+			return nil, fmt.Errorf("failed to parse analytics bucket minute %q: %w", minute, err)
+		}
+		buckets = append(buckets, &bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating analytics buckets: %w", err)
+	}
+	
+	return buckets, nil
+}
+
+// GetSessionMessageCounts counts a session's messages per type, UTC minute and sender,
+// and sums their stored content bytes per type
+// TECHNICAL DISCOVERY: Four grouped scans bounded by the session_id indexes; like
+// GetAnalyticsBuckets, strftime normalizes timestamps to UTC before truncating
+func (m *Manager) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) {
+	return sessionMessageCounts(ctx, m.db, sessionID)
+}
+
+func sessionMessageCounts(ctx context.Context, db *sql.DB, sessionID string) (*types.SessionMessageCounts, error) {
+	counts := &types.SessionMessageCounts{
+		ByType:      make(map[string]int),
+		ByMinute:    []types.MinuteCount{},
+		BySender:    make(map[string]int),
+		BytesByType: make(map[string]int64),
+	}
+	
+	grouped := func(query string, scan func(key string, count int) error) error {
+		rows, err := db.QueryContext(ctx, query, sessionID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan message row: %w", err)
+			return err
 		}
-		
-		// FUNCTIONAL DISCOVERY: Handle nullable to_user field for broadcast vs targeted messages
-		// Handle nullable to_user
-		if toUser.Valid {
-			message.ToUser = &toUser.String
+		defer func() { _ = rows.Close() }()
+		for rows.Next() {
+			var key string
+			var count int
+			if err := rows.Scan(&key, &count); err != nil {
+				return err
+			}
+			if err := scan(key, count); err != nil {
+				return err
+			}
 		}
-		
-		// TECHNICAL DISCOVERY: JSON deserialization restores message content structure
-		// Deserialize message content
-		if err := json.Unmarshal([]byte(contentJSON), &message.Content); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
+		return rows.Err()
+	}
+	
+	err := grouped(`SELECT type, COUNT(*) FROM messages WHERE session_id = ? GROUP BY type`, func(messageType string, count int) error {
+		counts.ByType[messageType] = count
+		counts.Total += count
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages by type: %w", err)
+	}
+	
+	err = grouped(`SELECT type, SUM(content_bytes) FROM messages WHERE session_id = ? GROUP BY type`, func(messageType string, bytes int) error {
+		counts.BytesByType[messageType] = int64(bytes)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to sum message sizes by type: %w", err)
+	}
+	
+	err = grouped(`
+		SELECT strftime('%Y-%m-%dT%H:%M:00Z', timestamp) AS minute, COUNT(*)
+		FROM messages
+		WHERE session_id = ?
+		GROUP BY minute
+		ORDER BY minute ASC
+	`, func(minute string, count int) error {
+		start, err := time.Parse(time.RFC3339, minute)
+		if err != nil {
+			return fmt.Errorf("failed to parse minute %q: %w", minute, err)
 		}
-		
-		messages = append(messages, &message)
+		counts.ByMinute = append(counts.ByMinute, types.MinuteCount{Minute: start, Count: count})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages by minute: %w", err)
 	}
 	
-	if err = rows.Err(); err != nil {
+	err = grouped(`SELECT from_user, COUNT(*) FROM messages WHERE session_id = ? GROUP BY from_user`, func(sender string, count int) error {
+		counts.BySender[sender] = count
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count messages by sender: %w", err)
+	}
+	
+	return counts, nil
+}
+
+// scanMessages reads message rows selected in history column order
+func scanMessages(rows *sql.Rows) ([]*types.Message, error) {
+	var messages []*types.Message
+	
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating message rows: %w", err)
 	}
 	
 	return messages, nil
 }
 
+// scanMessage reads the current row of a history-ordered selection
+func scanMessage(rows *sql.Rows) (*types.Message, error) {
+	var message types.Message
+	var contentJSON string
+	var toUser, toUsers sql.NullString
+	var editedAt sql.NullTime
+	
+	err := rows.Scan(
+		&message.ID,
+		&message.SessionID,
+		&message.Type,
+		&message.Context,
+		&message.FromUser,
+		&toUser,
+		&contentJSON,
+		&message.Timestamp,
+		&toUsers,
+		&message.Revision,
+		&editedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan message row: %w", err)
+	}
+	
+	// FUNCTIONAL DISCOVERY: Handle nullable to_user field for broadcast vs targeted messages
+	// Handle nullable to_user
+	if toUser.Valid {
+		message.ToUser = &toUser.String
+	}
+	if toUsers.Valid {
+		if err := json.Unmarshal([]byte(toUsers.String), &message.ToUsers); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal message recipients: %w", err)
+		}
+	}
+	
+	if editedAt.Valid {
+		message.EditedAt = &editedAt.Time
+	}
+	
+	// FUNCTIONAL DISCOVERY: The system flag is derived from the reserved sender, not stored
+	message.System = types.IsSystemUserID(message.FromUser)
+	
+	// TECHNICAL DISCOVERY: JSON deserialization restores message content structure
+	// Deserialize message content
+	if err := json.Unmarshal([]byte(contentJSON), &message.Content); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message content: %w", err)
+	}
+	
+	return &message, nil
+}
+
+// encodeToUsers stores a targeted broadcast's recipients as a JSON array; NULL for
+// every other message
+func encodeToUsers(toUsers []string) sql.NullString {
+	if len(toUsers) == 0 {
+		return sql.NullString{}
+	}
+	encoded, _ := json.Marshal(toUsers) // A []string always encodes
+	return sql.NullString{String: string(encoded), Valid: true}
+}
+
+// attachmentColumns returns the media type and decoded size of content's inline
+// attachment; both NULL without one, or with one that does not follow the convention
+// FUNCTIONAL DISCOVERY: Recorded whether or not the router checks attachments, so
+// storage accounting covers imports and servers with no attachment allowlist too
+func attachmentColumns(content map[string]interface{}) (sql.NullString, sql.NullInt64) {
+	attachment, err := types.ParseAttachment(content)
+	if err != nil || attachment == nil {
+		return sql.NullString{}, sql.NullInt64{}
+	}
+	return sql.NullString{String: attachment.MIME, Valid: true}, sql.NullInt64{Int64: int64(attachment.Size), Valid: true}
+}
+
 // HealthCheck validates database connectivity
 func (m *Manager) HealthCheck(ctx context.Context) error {
 	// FUNCTIONAL DISCOVERY: Health check validates both connectivity and basic operations
@@ -399,6 +1547,13 @@ func (m *Manager) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+// WriteQueueDepth reports writes waiting for the single writer and the queue's capacity
+// TECHNICAL DISCOVERY: Reads the channel length without locking; the value is a
+// momentary sample for capacity signals, not an exact count
+func (m *Manager) WriteQueueDepth() (depth, capacity int) {
+	return len(m.writeChannel), cap(m.writeChannel)
+}
+
 // GetDB returns the underlying database connection for migrations
 func (m *Manager) GetDB() *sql.DB {
 	return m.db
@@ -420,6 +1575,13 @@ func (m *Manager) Close() error {
 	close(m.shutdown)
 	m.wg.Wait() // Wait for write loop to finish processing
 	
+	if m.pinned != nil {
+		_ = m.pinned.Close()
+	}
+	if m.reader != m.db {
+		_ = m.reader.Close()
+	}
+	
 	// Close database connection
 	if err := m.db.Close(); err != nil {
 		return fmt.Errorf("failed to close database: %w", err)
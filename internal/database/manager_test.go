@@ -3,9 +3,11 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -44,6 +46,14 @@ func setupTestDB(t *testing.T) (*Manager, func()) {
 		start_time DATETIME NOT NULL,
 		end_time DATETIME,
 		status TEXT NOT NULL DEFAULT 'active',
+		duration_minutes INTEGER NOT NULL DEFAULT 0,
+		owner_id TEXT NOT NULL DEFAULT '',
+		locked BOOLEAN NOT NULL DEFAULT 0,
+		timezone TEXT NOT NULL DEFAULT 'UTC',
+		metadata TEXT NOT NULL DEFAULT '{}',
+		instructor_ids TEXT NOT NULL DEFAULT '[]',
+		session_config TEXT,
+		last_activity DATETIME,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
@@ -58,12 +68,180 @@ func setupTestDB(t *testing.T) (*Manager, func()) {
 		content TEXT NOT NULL,
 		timestamp DATETIME NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		content_hash TEXT,
+		to_users TEXT,
+		attachment_mime TEXT,
+		attachment_bytes INTEGER,
+		revision INTEGER NOT NULL DEFAULT 0,
+		edited_at DATETIME,
+		content_bytes INTEGER NOT NULL DEFAULT 0,
 		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
 	);
 	
+	CREATE TABLE content_store (
+		hash TEXT PRIMARY KEY,
+		body TEXT NOT NULL,
+		refcount INTEGER NOT NULL DEFAULT 0
+	);
+	
+	CREATE TRIGGER content_store_reference AFTER INSERT ON messages
+	WHEN NEW.content_hash IS NOT NULL
+	BEGIN
+		UPDATE content_store SET refcount = refcount + 1 WHERE hash = NEW.content_hash;
+	END;
+	
+	CREATE TRIGGER content_store_release AFTER DELETE ON messages
+	WHEN OLD.content_hash IS NOT NULL
+	BEGIN
+		UPDATE content_store SET refcount = refcount - 1 WHERE hash = OLD.content_hash;
+		DELETE FROM content_store WHERE hash = OLD.content_hash AND refcount <= 0;
+	END;
+	
+	CREATE TRIGGER content_store_rehash AFTER UPDATE OF content_hash ON messages
+	WHEN OLD.content_hash IS NOT NEW.content_hash
+	BEGIN
+		UPDATE content_store SET refcount = refcount + 1 WHERE hash = NEW.content_hash;
+		UPDATE content_store SET refcount = refcount - 1 WHERE hash = OLD.content_hash;
+		DELETE FROM content_store WHERE hash = OLD.content_hash AND refcount <= 0;
+	END;
+	
+	CREATE TABLE message_metadata (
+		message_id TEXT PRIMARY KEY,
+		connection_id TEXT NOT NULL,
+		client_ip TEXT NOT NULL DEFAULT '',
+		user_agent TEXT NOT NULL DEFAULT '',
+		recorded_at DATETIME NOT NULL,
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+	
+	CREATE TABLE message_annotations (
+		message_id TEXT NOT NULL,
+		instructor_id TEXT NOT NULL,
+		tags TEXT NOT NULL DEFAULT '[]',
+		note TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (message_id, instructor_id),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	);
+	
+	CREATE TABLE session_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		session_id TEXT NOT NULL,
+		event_type TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		details TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+	
+	CREATE TABLE selftest_probes (
+		id TEXT PRIMARY KEY,
+		from_user TEXT NOT NULL,
+		content TEXT NOT NULL,
+		timestamp DATETIME NOT NULL
+	);
+	
+	CREATE TABLE api_keys (
+		id TEXT PRIMARY KEY,
+		session_id TEXT NOT NULL,
+		key_hash TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL DEFAULT '',
+		scopes TEXT NOT NULL DEFAULT '[]',
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		expires_at DATETIME NOT NULL,
+		revoked_at DATETIME,
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+	
+	CREATE TABLE message_reactions (
+		message_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		reaction TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (message_id, user_id),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	) WITHOUT ROWID;
+	
+	CREATE TABLE message_reads (
+		message_id TEXT NOT NULL,
+		user_id TEXT NOT NULL,
+		read_at DATETIME NOT NULL,
+		PRIMARY KEY (message_id, user_id),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	) WITHOUT ROWID;
+	
+	CREATE TABLE message_revisions (
+		message_id TEXT NOT NULL,
+		revision INTEGER NOT NULL,
+		content TEXT NOT NULL,
+		edited_by TEXT NOT NULL,
+		replaced_at DATETIME NOT NULL,
+		PRIMARY KEY (message_id, revision),
+		FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+	) WITHOUT ROWID;
+	
+	CREATE TABLE poison_messages (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		message_id TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		from_user TEXT NOT NULL,
+		type TEXT NOT NULL,
+		payload TEXT NOT NULL,
+		error TEXT NOT NULL,
+		quarantined_at DATETIME NOT NULL
+	);
+	
+	CREATE TABLE metrics_rollups (
+		bucket_start INTEGER NOT NULL,
+		session_id TEXT NOT NULL,
+		messages INTEGER NOT NULL,
+		connections INTEGER NOT NULL,
+		PRIMARY KEY (bucket_start, session_id)
+	) WITHOUT ROWID;
+	
+	CREATE TABLE maintenance_jobs (
+		id TEXT PRIMARY KEY,
+		status TEXT NOT NULL,
+		tasks TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		finished_at DATETIME
+	);
+	
+	CREATE TABLE instructor_preferences (
+		session_id TEXT NOT NULL,
+		instructor_id TEXT NOT NULL,
+		filters TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (session_id, instructor_id),
+		FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+	);
+	
+	CREATE TABLE delivery_failures (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		message_id TEXT NOT NULL,
+		session_id TEXT NOT NULL,
+		recipient TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		weight INTEGER NOT NULL DEFAULT 1,
+		failed_at DATETIME NOT NULL
+	);
+	
+	CREATE TABLE session_purges (
+		session_id TEXT PRIMARY KEY,
+		requested_by TEXT NOT NULL,
+		stage TEXT NOT NULL,
+		removed TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL,
+		completed_at DATETIME
+	);
+	
 	CREATE INDEX idx_sessions_status ON sessions(status);
 	CREATE INDEX idx_messages_session_time ON messages(session_id, timestamp);
 	CREATE INDEX idx_messages_type ON messages(type);
+	CREATE INDEX idx_messages_session_from ON messages(session_id, from_user, timestamp);
+	CREATE INDEX idx_messages_session_to ON messages(session_id, to_user, timestamp) WHERE to_user IS NOT NULL;
 	`
 	
 	_, err = sqliteDB.Exec(schema)
@@ -214,6 +392,67 @@ func TestManager_UpdateSessionBehavior(t *testing.T) {
 	}
 }
 
+// TestManager_SessionStateTransitions tests edge case validation - a status write must be
+// a legal transition from the stored status, and unknown states are never stored
+func TestManager_SessionStateTransitions(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "lifecycle",
+		Name:       "Lifecycle",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	stored, err := manager.GetSession(ctx, "lifecycle")
+	if err != nil || stored.Status != types.SessionActive {
+		t.Fatalf("Expected a session without a status stored active, got %+v, %v", stored, err)
+	}
+	
+	// Same status, other fields: not a transition
+	stored.DurationMinutes = 45
+	if err := manager.UpdateSession(ctx, stored); err != nil {
+		t.Fatalf("Updating an active session's duration should succeed: %v", err)
+	}
+	
+	endTime := time.Now()
+	ended := *stored
+	ended.Status = types.SessionEnded
+	ended.EndTime = &endTime
+	if err := manager.UpdateSession(ctx, &ended); err != nil {
+		t.Fatalf("Ending an active session should succeed: %v", err)
+	}
+	
+	// A duration change built from the session before it ended must not revive it
+	stored.DurationMinutes = 90
+	err = manager.UpdateSession(ctx, stored)
+	var transitionErr *types.SessionTransitionError
+	if !errors.As(err, &transitionErr) || transitionErr.From != types.SessionEnded || transitionErr.To != types.SessionActive {
+		t.Errorf("Expected ended to active refused, got %v", err)
+	}
+	if current, _ := manager.GetSession(ctx, "lifecycle"); current.Status != types.SessionEnded || current.DurationMinutes != 45 {
+		t.Errorf("A refused transition should change nothing, got %+v", current)
+	}
+	
+	ended.Status = "paused"
+	if err := manager.UpdateSession(ctx, &ended); !errors.Is(err, types.ErrUnknownSessionState) {
+		t.Errorf("Expected an unknown state refused on update, got %v", err)
+	}
+	if err := manager.UpdateSession(ctx, &types.Session{ID: "missing", Status: types.SessionEnded}); !errors.Is(err, interfaces.ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound updating a missing session, got %v", err)
+	}
+	unknown := *session
+	unknown.ID, unknown.Status = "unknown", "paused"
+	if err := manager.CreateSession(ctx, &unknown); !errors.Is(err, types.ErrUnknownSessionState) {
+		t.Errorf("Expected an unknown state refused on create, got %v", err)
+	}
+}
+
 func TestManager_ListActiveSessionsBehavior(t *testing.T) {
 	// This test will FAIL until ListActiveSessions is implemented
 	manager, cleanup := setupTestDB(t)
@@ -266,6 +505,15 @@ func TestManager_ListActiveSessionsBehavior(t *testing.T) {
 	if len(activeSessions) > 0 && activeSessions[0].ID != "active-session" {
 		t.Errorf("Expected active session ID 'active-session', got '%s'", activeSessions[0].ID)
 	}
+	
+	// Name lookup backing the duplicate-name policy also skips ended sessions
+	names, err := manager.ActiveSessionNames(ctx, "instructor1")
+	if err != nil {
+		t.Errorf("ActiveSessionNames should succeed: %v", err)
+	}
+	if len(names) != 1 || names[0] != "Active Session" {
+		t.Errorf("Expected active names [Active Session], got %v", names)
+	}
 }
 
 func TestManager_StoreMessageBehavior(t *testing.T) {
@@ -328,6 +576,48 @@ func TestManager_StoreMessageBehavior(t *testing.T) {
 	}
 }
 
+func TestManager_TargetedBroadcastRecipients(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID: "session-1", Name: "Class", CreatedBy: "instructor1",
+		StudentIDs: []string{"student1", "student2"}, StartTime: time.Now(), Status: "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	
+	for _, message := range []*types.Message{
+		{ID: "targeted", ToUsers: []string{"student2", "student1"}},
+		{ID: "everyone"},
+	} {
+		message.SessionID, message.Type, message.Context, message.FromUser = "session-1", types.MessageTypeInstructorBroadcast, "general", "instructor1"
+		message.Content, message.Timestamp = map[string]interface{}{"text": "Submit by noon"}, time.Now()
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage failed: %v", err)
+		}
+	}
+	
+	targeted, err := manager.GetMessage(ctx, "session-1", "targeted")
+	if err != nil || len(targeted.ToUsers) != 2 || targeted.ToUsers[0] != "student2" || targeted.ToUsers[1] != "student1" {
+		t.Errorf("Expected the recipient list read back in order, got %+v, %v", targeted, err)
+	}
+	everyone, err := manager.GetMessage(ctx, "session-1", "everyone")
+	if err != nil || everyone.ToUsers != nil {
+		t.Errorf("Expected no recipient list on a whole-session broadcast, got %+v, %v", everyone, err)
+	}
+	
+	report, err := manager.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if report.Problems() != 0 {
+		t.Errorf("Expected stored recipient lists to verify, got %d problems", report.Problems())
+	}
+}
+
 func TestManager_GetSessionHistoryOrdering(t *testing.T) {
 	// This test will FAIL until GetSessionHistory ordering is implemented
 	manager, cleanup := setupTestDB(t)
@@ -410,428 +700,1764 @@ func TestManager_GetSessionHistoryOrdering(t *testing.T) {
 	}
 }
 
-// Error Handling Validation Tests
-func TestManager_TransactionRollback(t *testing.T) {
-	// This test will FAIL until transaction handling is implemented
-	// This test simulates transaction failure to verify rollback behavior
+func TestManager_ImportMessagesBatching(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
 	defer cleanup()
 	
-	ctx := context.Background()
+	// Small batch size forces multiple import transactions
+	manager.config.ImportBatchSize = 2
 	
-	// First create a valid session to establish baseline
-	validSession := &types.Session{
-		ID:         "valid-session",
-		Name:       "Valid Session",
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "import-session",
+		Name:       "Import Test",
 		CreatedBy:  "instructor1",
 		StudentIDs: []string{"student1"},
 		StartTime:  time.Now(),
 		Status:     "active",
 	}
-	
-	err := manager.CreateSession(ctx, validSession)
-	if err != nil {
-		t.Fatalf("CreateSession with valid data should succeed: %v", err)
-	}
-	
-	// Now attempt to create session with duplicate ID to cause primary key violation
-	duplicateSession := &types.Session{
-		ID:         "valid-session", // Same ID should cause constraint violation
-		Name:       "Duplicate Session",
-		CreatedBy:  "instructor2",
-		StudentIDs: []string{"student2"},
-		StartTime:  time.Now(),
-		Status:     "active",
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
 	}
 	
-	err = manager.CreateSession(ctx, duplicateSession)
-	if err == nil {
-		t.Error("CreateSession with duplicate ID should fail")
+	// Identical timestamps - history must keep the imported sequence
+	timestamp := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	var messages []*types.Message
+	for i := 0; i < 5; i++ {
+		messages = append(messages, &types.Message{
+			ID:        fmt.Sprintf("import-%d", i),
+			SessionID: "import-session",
+			Type:      types.MessageTypeInstructorInbox,
+			Context:   "general",
+			FromUser:  "student1",
+			Content:   map[string]interface{}{"seq": float64(i)},
+			Timestamp: timestamp,
+		})
 	}
 	
-	// Verify only one session exists (transaction rollback worked)
-	sessions, err := manager.ListActiveSessions(ctx)
+	skipped, err := manager.ImportMessages(ctx, messages)
 	if err != nil {
-		t.Errorf("ListActiveSessions should work: %v", err)
+		t.Fatalf("ImportMessages should succeed: %v", err)
 	}
-	
-	if len(sessions) != 1 {
-		t.Errorf("Expected 1 session after failed transaction, got %d", len(sessions))
+	if len(skipped) != 0 {
+		t.Errorf("Expected no skipped messages on first import, got %v", skipped)
 	}
 	
-	// Verify the original session data is unchanged
-	if len(sessions) > 0 && sessions[0].Name != "Valid Session" {
-		t.Errorf("Expected original session name 'Valid Session', got '%s'", sessions[0].Name)
+	history, err := manager.GetSessionHistory(ctx, "import-session")
+	if err != nil {
+		t.Fatalf("GetSessionHistory should succeed: %v", err)
 	}
-}
-
-func TestManager_DatabaseConnectionFailure(t *testing.T) {
-	// This test will FAIL until connection error handling is implemented
-	// Test with invalid database path to simulate connection failure
-	config := &database.Config{
-		DatabasePath:    "/invalid/path/database.db",
-		MaxConnections:  10,
-		ConnMaxLifetime: time.Hour,
-		ConnMaxIdleTime: time.Minute * 30,
+	if len(history) != 5 {
+		t.Fatalf("Expected 5 messages, got %d", len(history))
+	}
+	for i, message := range history {
+		if message.Content["seq"] != float64(i) {
+			t.Errorf("Expected sequence %d at position %d, got %v", i, i, message.Content["seq"])
+		}
 	}
 	
-	_, err := NewManager(config)
-	if err == nil {
-		t.Error("NewManager should fail with invalid database path")
+	// Re-import is idempotent - existing IDs are skipped
+	skipped, err = manager.ImportMessages(ctx, messages[:3])
+	if err != nil {
+		t.Fatalf("Re-import should succeed: %v", err)
+	}
+	if len(skipped) != 3 {
+		t.Errorf("Expected 3 skipped messages on re-import, got %v", skipped)
 	}
 }
 
-// Technical Validation Tests - Performance and Concurrency
-func TestManager_SingleWriterPattern(t *testing.T) {
-	// This test will FAIL until single-writer goroutine is implemented
+func TestManager_SessionDurationRoundTrip(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
 	defer cleanup()
 	
 	ctx := context.Background()
-	
-	// Test concurrent write operations to verify single-writer pattern
-	const numWrites = 10
-	var wg sync.WaitGroup
-	errors := make(chan error, numWrites)
-	
-	wg.Add(numWrites)
-	for i := 0; i < numWrites; i++ {
-		go func(id int) {
-			defer wg.Done()
-			
-			session := &types.Session{
-				ID:         fmt.Sprintf("concurrent-session-%d", id),
-				Name:       fmt.Sprintf("Concurrent Session %d", id),
-				CreatedBy:  "instructor1",
-				StudentIDs: []string{"student1"},
-				StartTime:  time.Now(),
-				Status:     "active",
-			}
-			
-			err := manager.CreateSession(ctx, session)
-			if err != nil {
-				errors <- err
-			}
-		}(i)
+	session := &types.Session{
+		ID:              "timed-session",
+		Name:            "Timed",
+		CreatedBy:       "instructor1",
+		StudentIDs:      []string{"student1"},
+		StartTime:       time.Now(),
+		Status:          "active",
+		DurationMinutes: 50,
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
 	}
 	
-	wg.Wait()
-	close(errors)
-	
-	// Check for any errors from concurrent writes
-	for err := range errors {
-		t.Errorf("Concurrent write failed: %v", err)
+	session.DurationMinutes = 60
+	if err := manager.UpdateSession(ctx, session); err != nil {
+		t.Fatalf("UpdateSession should succeed: %v", err)
 	}
 	
-	// Verify all sessions were created (single-writer should handle concurrency)
-	sessions, err := manager.ListActiveSessions(ctx)
-	if err != nil {
-		t.Errorf("ListActiveSessions should work: %v", err)
+	active, err := manager.ListActiveSessions(ctx)
+	if err != nil || len(active) != 1 {
+		t.Fatalf("Expected 1 active session, got %d (%v)", len(active), err)
 	}
-	
-	if len(sessions) != numWrites {
-		t.Errorf("Expected %d sessions, got %d", numWrites, len(sessions))
+	if active[0].DurationMinutes != 60 {
+		t.Errorf("Expected persisted duration 60, got %d", active[0].DurationMinutes)
 	}
 }
 
-func TestManager_WriteOperationPerformance(t *testing.T) {
-	// This test will FAIL until performance optimization is implemented
+func TestManager_TransferSessionOwner(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
 	defer cleanup()
 	
 	ctx := context.Background()
-	
-	// Test write operation performance - should complete in <50ms
 	session := &types.Session{
-		ID:         "perf-test-session",
-		Name:       "Performance Test Session",
+		ID:         "owned-session",
+		Name:       "Owned",
 		CreatedBy:  "instructor1",
-		StudentIDs: []string{},
+		StudentIDs: []string{"student1"},
 		StartTime:  time.Now(),
 		Status:     "active",
 	}
-	
-	// Add many students to increase data size
-	for i := 0; i < 100; i++ {
-		session.StudentIDs = append(session.StudentIDs, fmt.Sprintf("student%d", i))
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
 	}
 	
-	start := time.Now()
-	err := manager.CreateSession(ctx, session)
-	duration := time.Since(start)
+	if err := manager.TransferSessionOwner(ctx, session.ID, "instructor1", "instructor2", "instructor2"); err != nil {
+		t.Fatalf("TransferSessionOwner should succeed: %v", err)
+	}
+	// A second transfer from the old owner lost the race and must not apply
+	if err := manager.TransferSessionOwner(ctx, session.ID, "instructor1", "instructor3", "instructor3"); !errors.Is(err, interfaces.ErrSessionNotFound) {
+		t.Errorf("Transfer from a stale owner should fail with ErrSessionNotFound, got %v", err)
+	}
 	
+	stored, err := manager.GetSession(ctx, session.ID)
 	if err != nil {
-		t.Errorf("CreateSession should succeed: %v", err)
+		t.Fatalf("GetSession should succeed: %v", err)
+	}
+	if stored.OwnerID != "instructor2" || stored.CreatedBy != "instructor1" {
+		t.Errorf("Expected owner instructor2 with creator kept, got owner=%s created_by=%s", stored.OwnerID, stored.CreatedBy)
 	}
 	
-	if duration > 50*time.Millisecond {
-		t.Errorf("Write operation too slow: %v (should be <50ms)", duration)
+	events, err := manager.GetSessionEvents(ctx, session.ID)
+	if err != nil {
+		t.Fatalf("GetSessionEvents should succeed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 audited event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Type != types.SessionEventOwnerTransferred || event.Actor != "instructor2" ||
+		event.Details["previous_owner"] != "instructor1" || event.Details["new_owner"] != "instructor2" {
+		t.Errorf("Unexpected audit event: %+v", event)
 	}
 }
 
-func TestManager_ReadOperationPerformance(t *testing.T) {
-	// This test will FAIL until read performance optimization is implemented
+func TestManager_SetSessionTimezone(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
 	defer cleanup()
 	
 	ctx := context.Background()
-	
-	// Create session for message history test
 	session := &types.Session{
-		ID:         "read-perf-session",
-		Name:      "Read Performance Test",
+		ID:         "zoned-session",
+		Name:       "Biology",
 		CreatedBy:  "instructor1",
 		StudentIDs: []string{"student1"},
 		StartTime:  time.Now(),
 		Status:     "active",
 	}
-	
-	err := manager.CreateSession(ctx, session)
-	if err != nil {
+	if err := manager.CreateSession(ctx, session); err != nil {
 		t.Fatalf("CreateSession should succeed: %v", err)
 	}
-	
-	// Create many messages to test read performance
-	for i := 0; i < 1000; i++ {
-		message := &types.Message{
-			ID:        fmt.Sprintf("msg-%d", i),
-			SessionID: "read-perf-session",
-			Type:      "instructor_broadcast",
-			FromUser:  "instructor1",
-			Content:   map[string]interface{}{"text": fmt.Sprintf("Message %d", i)},
-			Timestamp: time.Now().Add(time.Duration(i) * time.Millisecond),
-		}
-		
-		err = manager.StoreMessage(ctx, message)
-		if err != nil {
-			t.Fatalf("StoreMessage should succeed: %v", err)
-		}
+	if stored, _ := manager.GetSession(ctx, session.ID); stored.Timezone != "UTC" {
+		t.Errorf("Sessions created without a zone should store UTC, got %q", stored.Timezone)
 	}
 	
-	// Test read performance - should complete in <100ms for 1000 messages
-	start := time.Now()
-	messages, err := manager.GetSessionHistory(ctx, "read-perf-session")
-	duration := time.Since(start)
-	
-	if err != nil {
-		t.Errorf("GetSessionHistory should succeed: %v", err)
+	if err := manager.SetSessionTimezone(ctx, session.ID, "Europe/Berlin"); err != nil {
+		t.Fatalf("SetSessionTimezone should succeed: %v", err)
 	}
-	
-	if len(messages) != 1000 {
-		t.Errorf("Expected 1000 messages, got %d", len(messages))
+	active, err := manager.ListActiveSessions(ctx)
+	if err != nil || len(active) != 1 || active[0].Timezone != "Europe/Berlin" {
+		t.Fatalf("Expected the active session to load in Europe/Berlin, got %+v, %v", active, err)
 	}
 	
-	if duration > 100*time.Millisecond {
-		t.Errorf("Read operation too slow: %v (should be <100ms)", duration)
+	ended := *active[0]
+	now := time.Now()
+	ended.Status, ended.EndTime = "ended", &now
+	if err := manager.UpdateSession(ctx, &ended); err != nil {
+		t.Fatalf("UpdateSession should succeed: %v", err)
+	}
+	if err := manager.SetSessionTimezone(ctx, session.ID, "UTC"); !errors.Is(err, interfaces.ErrSessionNotFound) {
+		t.Errorf("Changing an ended session should fail with ErrSessionNotFound, got %v", err)
 	}
 }
 
-func TestManager_ConcurrentReadAccess(t *testing.T) {
-	// This test will FAIL until concurrent read support is implemented
+func TestManager_SessionMetadata(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
 	defer cleanup()
 	
 	ctx := context.Background()
-	
+	if count, err := manager.SessionCount(ctx); err != nil || count != 0 {
+		t.Fatalf("Expected an empty database, got %d, %v", count, err)
+	}
+	for i, course := range []string{"CS101", "CS101", "BIO200"} {
+		session := &types.Session{
+			ID:         fmt.Sprintf("meta-session-%d", i),
+			Name:       "Section " + course,
+			CreatedBy:  "instructor1",
+			StudentIDs: []string{"student1"},
+			StartTime:  time.Now(),
+			Status:     "active",
+			Metadata:   map[string]string{"course": course, "room": fmt.Sprintf("B%d", i)},
+		}
+		if err := manager.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession should succeed: %v", err)
+		}
+	}
+	if count, _ := manager.SessionCount(ctx); count != 3 {
+		t.Errorf("Expected 3 sessions counted, got %d", count)
+	}
+	if stored, _ := manager.GetSession(ctx, "meta-session-2"); stored.Metadata["course"] != "BIO200" {
+		t.Errorf("Expected metadata stored at creation, got %v", stored.Metadata)
+	}
+	
+	ids, err := manager.ActiveSessionsByMetadata(ctx, map[string]string{"course": "CS101"})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("Expected two CS101 sessions, got %v, %v", ids, err)
+	}
+	if ids, _ := manager.ActiveSessionsByMetadata(ctx, map[string]string{"course": "CS101", "room": "B1"}); len(ids) != 1 || ids[0] != "meta-session-1" {
+		t.Errorf("Expected every filter to apply, got %v", ids)
+	}
+	if _, err := manager.ActiveSessionsByMetadata(ctx, map[string]string{`x") OR 1=1 --`: "y"}); !errors.Is(err, types.ErrInvalidMetadata) {
+		t.Errorf("Expected an invalid key refused, got %v", err)
+	}
+	
+	if err := manager.SetSessionMetadata(ctx, "meta-session-0", nil); err != nil {
+		t.Fatalf("SetSessionMetadata should succeed: %v", err)
+	}
+	active, _ := manager.ListActiveSessions(ctx)
+	for _, session := range active {
+		if session.ID == "meta-session-0" && session.Metadata != nil {
+			t.Errorf("Expected cleared metadata to load as nil, got %v", session.Metadata)
+		}
+	}
+	if ids, _ := manager.ActiveSessionsByMetadata(ctx, map[string]string{"course": "CS101"}); len(ids) != 1 {
+		t.Errorf("Expected the cleared session no longer to match, got %v", ids)
+	}
+}
+
+func TestManager_SessionInstructors(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:            "scoped-session",
+		Name:          "Scoped",
+		CreatedBy:     "instructor1",
+		StudentIDs:    []string{"student1"},
+		StartTime:     time.Now(),
+		Status:        "active",
+		InstructorIDs: []string{"ta1"},
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	if stored, _ := manager.GetSession(ctx, "scoped-session"); len(stored.InstructorIDs) != 1 || stored.InstructorIDs[0] != "ta1" {
+		t.Errorf("Expected instructors stored at creation, got %v", stored.InstructorIDs)
+	}
+	
+	if err := manager.SetSessionInstructors(ctx, "scoped-session", []string{"ta2", "ta3"}); err != nil {
+		t.Fatalf("SetSessionInstructors should succeed: %v", err)
+	}
+	active, _ := manager.ListActiveSessions(ctx)
+	if len(active) != 1 || len(active[0].InstructorIDs) != 2 || active[0].InstructorIDs[1] != "ta3" {
+		t.Errorf("Expected the replaced list loaded, got %+v", active)
+	}
+	if err := manager.SetSessionInstructors(ctx, "scoped-session", nil); err != nil {
+		t.Fatalf("SetSessionInstructors should succeed: %v", err)
+	}
+	if stored, _ := manager.GetSession(ctx, "scoped-session"); stored.InstructorIDs != nil {
+		t.Errorf("Expected a cleared list to load as nil, got %v", stored.InstructorIDs)
+	}
+	
+	if err := manager.SetSessionInstructors(ctx, "missing-session", []string{"ta1"}); !errors.Is(err, interfaces.ErrSessionNotFound) {
+		t.Errorf("Missing session should fail with ErrSessionNotFound, got %v", err)
+	}
+}
+
+func TestManager_SetSessionLocked(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "locked-session",
+		Name:       "Exam",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	if err := manager.SetSessionLocked(ctx, session.ID, true); err != nil {
+		t.Fatalf("SetSessionLocked should succeed: %v", err)
+	}
+	active, err := manager.ListActiveSessions(ctx)
+	if err != nil || len(active) != 1 || !active[0].Locked {
+		t.Fatalf("Expected the active session to load locked, got %+v, %v", active, err)
+	}
+	
+	// Ending the session does not touch the lock, and an ended session cannot change
+	ended := *active[0]
+	now := time.Now()
+	ended.Status, ended.EndTime = "ended", &now
+	if err := manager.UpdateSession(ctx, &ended); err != nil {
+		t.Fatalf("UpdateSession should succeed: %v", err)
+	}
+	if err := manager.SetSessionLocked(ctx, session.ID, false); !errors.Is(err, interfaces.ErrSessionNotFound) {
+		t.Errorf("Unlocking an ended session should fail with ErrSessionNotFound, got %v", err)
+	}
+	if stored, _ := manager.GetSession(ctx, session.ID); !stored.Locked {
+		t.Error("Ended session should keep its last lock state")
+	}
+}
+
+func TestManager_MessageMetadataRoundTrip(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "metadata-session",
+		Name:       "Metadata Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	message := &types.Message{
+		ID:        "metadata-msg",
+		SessionID: "metadata-session",
+		Type:      types.MessageTypeInstructorInbox,
+		Context:   "general",
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"text": "hello"},
+		Timestamp: time.Now(),
+	}
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage should succeed: %v", err)
+	}
+	
+	if _, err := manager.GetMessageMetadata(ctx, "metadata-msg"); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound before metadata is stored, got %v", err)
+	}
+	
+	metadata := &types.MessageMetadata{
+		MessageID:    "metadata-msg",
+		ConnectionID: "conn-1",
+		ClientIP:     "203.0.113.7",
+		UserAgent:    "test-agent",
+		RecordedAt:   time.Now(),
+	}
+	if err := manager.StoreMessageMetadata(ctx, metadata); err != nil {
+		t.Fatalf("StoreMessageMetadata should succeed: %v", err)
+	}
+	
+	retrieved, err := manager.GetMessageMetadata(ctx, "metadata-msg")
+	if err != nil {
+		t.Fatalf("GetMessageMetadata should succeed: %v", err)
+	}
+	if retrieved.ConnectionID != "conn-1" || retrieved.ClientIP != "203.0.113.7" || retrieved.UserAgent != "test-agent" {
+		t.Errorf("Unexpected metadata: %+v", retrieved)
+	}
+}
+
+// TestManager_GetMessage tests functional validation - a message is only found through its own session
+func TestManager_GetMessage(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "get-session",
+		Name:       "Get Message Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	student := "student1"
+	message := &types.Message{
+		ID:        "request-1",
+		SessionID: "get-session",
+		Type:      types.MessageTypeRequest,
+		Context:   "code",
+		FromUser:  "instructor1",
+		ToUser:    &student,
+		Content:   map[string]interface{}{"text": "Share your code", "deadline": "2026-01-01T10:00:00Z"},
+		Timestamp: time.Now(),
+	}
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage should succeed: %v", err)
+	}
+	
+	retrieved, err := manager.GetMessage(ctx, "get-session", "request-1")
+	if err != nil {
+		t.Fatalf("GetMessage should succeed: %v", err)
+	}
+	if retrieved.Type != types.MessageTypeRequest || retrieved.ToUser == nil || *retrieved.ToUser != "student1" || retrieved.Content["deadline"] != "2026-01-01T10:00:00Z" {
+		t.Errorf("Unexpected message: %+v", retrieved)
+	}
+	
+	if _, err := manager.GetMessage(ctx, "other-session", "request-1"); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound through another session, got %v", err)
+	}
+	if _, err := manager.GetMessage(ctx, "get-session", "missing"); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing message, got %v", err)
+	}
+}
+
+// Error Handling Validation Tests
+func TestManager_TransactionRollback(t *testing.T) {
+	// This test will FAIL until transaction handling is implemented
+	// This test simulates transaction failure to verify rollback behavior
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	
+	// First create a valid session to establish baseline
+	validSession := &types.Session{
+		ID:         "valid-session",
+		Name:       "Valid Session",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	err := manager.CreateSession(ctx, validSession)
+	if err != nil {
+		t.Fatalf("CreateSession with valid data should succeed: %v", err)
+	}
+	
+	// Now attempt to create session with duplicate ID to cause primary key violation
+	duplicateSession := &types.Session{
+		ID:         "valid-session", // Same ID should cause constraint violation
+		Name:       "Duplicate Session",
+		CreatedBy:  "instructor2",
+		StudentIDs: []string{"student2"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	err = manager.CreateSession(ctx, duplicateSession)
+	if err == nil {
+		t.Error("CreateSession with duplicate ID should fail")
+	}
+	
+	// Verify only one session exists (transaction rollback worked)
+	sessions, err := manager.ListActiveSessions(ctx)
+	if err != nil {
+		t.Errorf("ListActiveSessions should work: %v", err)
+	}
+	
+	if len(sessions) != 1 {
+		t.Errorf("Expected 1 session after failed transaction, got %d", len(sessions))
+	}
+	
+	// Verify the original session data is unchanged
+	if len(sessions) > 0 && sessions[0].Name != "Valid Session" {
+		t.Errorf("Expected original session name 'Valid Session', got '%s'", sessions[0].Name)
+	}
+}
+
+func TestManager_DatabaseConnectionFailure(t *testing.T) {
+	// This test will FAIL until connection error handling is implemented
+	// Test with invalid database path to simulate connection failure
+	config := &database.Config{
+		DatabasePath:    "/invalid/path/database.db",
+		MaxConnections:  10,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: time.Minute * 30,
+	}
+	
+	_, err := NewManager(config)
+	if err == nil {
+		t.Error("NewManager should fail with invalid database path")
+	}
+}
+
+// Technical Validation Tests - Performance and Concurrency
+func TestManager_SingleWriterPattern(t *testing.T) {
+	// This test will FAIL until single-writer goroutine is implemented
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	
+	// Test concurrent write operations to verify single-writer pattern
+	const numWrites = 10
+	var wg sync.WaitGroup
+	errors := make(chan error, numWrites)
+	
+	wg.Add(numWrites)
+	for i := 0; i < numWrites; i++ {
+		go func(id int) {
+			defer wg.Done()
+			
+			session := &types.Session{
+				ID:         fmt.Sprintf("concurrent-session-%d", id),
+				Name:       fmt.Sprintf("Concurrent Session %d", id),
+				CreatedBy:  "instructor1",
+				StudentIDs: []string{"student1"},
+				StartTime:  time.Now(),
+				Status:     "active",
+			}
+			
+			err := manager.CreateSession(ctx, session)
+			if err != nil {
+				errors <- err
+			}
+		}(i)
+	}
+	
+	wg.Wait()
+	close(errors)
+	
+	// Check for any errors from concurrent writes
+	for err := range errors {
+		t.Errorf("Concurrent write failed: %v", err)
+	}
+	
+	// Verify all sessions were created (single-writer should handle concurrency)
+	sessions, err := manager.ListActiveSessions(ctx)
+	if err != nil {
+		t.Errorf("ListActiveSessions should work: %v", err)
+	}
+	
+	if len(sessions) != numWrites {
+		t.Errorf("Expected %d sessions, got %d", numWrites, len(sessions))
+	}
+}
+
+func TestManager_WriteOperationPerformance(t *testing.T) {
+	// This test will FAIL until performance optimization is implemented
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	
+	// Test write operation performance - should complete in <50ms
+	session := &types.Session{
+		ID:         "perf-test-session",
+		Name:       "Performance Test Session",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	// Add many students to increase data size
+	for i := 0; i < 100; i++ {
+		session.StudentIDs = append(session.StudentIDs, fmt.Sprintf("student%d", i))
+	}
+	
+	start := time.Now()
+	err := manager.CreateSession(ctx, session)
+	duration := time.Since(start)
+	
+	if err != nil {
+		t.Errorf("CreateSession should succeed: %v", err)
+	}
+	
+	if duration > 50*time.Millisecond {
+		t.Errorf("Write operation too slow: %v (should be <50ms)", duration)
+	}
+}
+
+func TestManager_ReadOperationPerformance(t *testing.T) {
+	// This test will FAIL until read performance optimization is implemented
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	
+	// Create session for message history test
+	session := &types.Session{
+		ID:         "read-perf-session",
+		Name:      "Read Performance Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	err := manager.CreateSession(ctx, session)
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	// Create many messages to test read performance
+	for i := 0; i < 1000; i++ {
+		message := &types.Message{
+			ID:        fmt.Sprintf("msg-%d", i),
+			SessionID: "read-perf-session",
+			Type:      "instructor_broadcast",
+			FromUser:  "instructor1",
+			Content:   map[string]interface{}{"text": fmt.Sprintf("Message %d", i)},
+			Timestamp: time.Now().Add(time.Duration(i) * time.Millisecond),
+		}
+		
+		err = manager.StoreMessage(ctx, message)
+		if err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+	
+	// Test read performance - should complete in <100ms for 1000 messages
+	start := time.Now()
+	messages, err := manager.GetSessionHistory(ctx, "read-perf-session")
+	duration := time.Since(start)
+	
+	if err != nil {
+		t.Errorf("GetSessionHistory should succeed: %v", err)
+	}
+	
+	if len(messages) != 1000 {
+		t.Errorf("Expected 1000 messages, got %d", len(messages))
+	}
+	
+	if duration > 100*time.Millisecond {
+		t.Errorf("Read operation too slow: %v (should be <100ms)", duration)
+	}
+}
+
+func TestManager_ConcurrentReadAccess(t *testing.T) {
+	// This test will FAIL until concurrent read support is implemented
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	
 	// Create test session
 	session := &types.Session{
-		ID:         "concurrent-read-session",
-		Name:       "Concurrent Read Test",
+		ID:         "concurrent-read-session",
+		Name:       "Concurrent Read Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	err := manager.CreateSession(ctx, session)
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	// Test concurrent read operations
+	const numReads = 50
+	var wg sync.WaitGroup
+	errors := make(chan error, numReads)
+	
+	wg.Add(numReads)
+	for i := 0; i < numReads; i++ {
+		go func() {
+			defer wg.Done()
+			
+			_, err := manager.GetSession(ctx, "concurrent-read-session")
+			if err != nil {
+				errors <- err
+			}
+		}()
+	}
+	
+	wg.Wait()
+	close(errors)
+	
+	// Check for any errors from concurrent reads
+	for err := range errors {
+		t.Errorf("Concurrent read failed: %v", err)
+	}
+}
+
+func TestManager_HealthCheckBehavior(t *testing.T) {
+	// This test will FAIL until HealthCheck is implemented
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	
+	err := manager.HealthCheck(ctx)
+	if err != nil {
+		t.Errorf("HealthCheck should succeed for healthy database: %v", err)
+	}
+}
+
+func TestManager_CleanShutdown(t *testing.T) {
+	// This test will FAIL until proper shutdown is implemented
+	manager, cleanup := setupTestDB(t)
+	defer func() {
+		// Don't call cleanup() as we're testing Close() directly
+		_ = cleanup
+	}()
+	
+	// Start some operations
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "shutdown-test-session",
+		Name:       "Shutdown Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	err := manager.CreateSession(ctx, session)
+	if err != nil {
+		t.Errorf("CreateSession should succeed: %v", err)
+	}
+	
+	// Test clean shutdown
+	err = manager.Close()
+	if err != nil {
+		t.Errorf("Close should succeed: %v", err)
+	}
+	
+	// Verify operations fail after shutdown
+	err = manager.CreateSession(ctx, session)
+	if err == nil {
+		t.Error("Operations should fail after Close()")
+	}
+}
+
+// Integration Validation Tests
+func TestManager_CompleteSessionLifecycle(t *testing.T) {
+	// This test will FAIL until complete integration is implemented
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	
+	// Step 1: Create session
+	session := &types.Session{
+		ID:         "lifecycle-session",
+		Name:       "Lifecycle Test Session",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1", "student2"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	err := manager.CreateSession(ctx, session)
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	// Step 2: Store messages
+	messages := []*types.Message{
+		{
+			ID:        "msg-1",
+			SessionID: "lifecycle-session",
+			Type:      "instructor_broadcast",
+			FromUser:  "instructor1",
+			Content:   map[string]interface{}{"text": "Welcome"},
+			Timestamp: time.Now(),
+		},
+		{
+			ID:        "msg-2",
+			SessionID: "lifecycle-session",
+			Type:      "inbox_response",
+			FromUser:  "instructor1",
+			ToUser:    &[]string{"student1"}[0],
+			Content:   map[string]interface{}{"text": "Response"},
+			Timestamp: time.Now().Add(time.Minute),
+		},
+	}
+	
+	for _, msg := range messages {
+		err = manager.StoreMessage(ctx, msg)
+		if err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+	
+	// Step 3: Verify session is in active list
+	activeSessions, err := manager.ListActiveSessions(ctx)
+	if err != nil {
+		t.Errorf("ListActiveSessions should succeed: %v", err)
+	}
+	
+	found := false
+	for _, s := range activeSessions {
+		if s.ID == "lifecycle-session" {
+			found = true
+			break
+		}
+	}
+	
+	if !found {
+		t.Error("Created session should be in active sessions list")
+	}
+	
+	// Step 4: Retrieve session history
+	history, err := manager.GetSessionHistory(ctx, "lifecycle-session")
+	if err != nil {
+		t.Errorf("GetSessionHistory should succeed: %v", err)
+	}
+	
+	if len(history) != 2 {
+		t.Errorf("Expected 2 messages in history, got %d", len(history))
+	}
+	
+	// Step 5: End session
+	now := time.Now()
+	session.EndTime = &now
+	session.Status = "ended"
+	
+	err = manager.UpdateSession(ctx, session)
+	if err != nil {
+		t.Errorf("UpdateSession should succeed: %v", err)
+	}
+	
+	// Step 6: Verify session no longer in active list
+	activeSessions, err = manager.ListActiveSessions(ctx)
+	if err != nil {
+		t.Errorf("ListActiveSessions should succeed: %v", err)
+	}
+	
+	for _, s := range activeSessions {
+		if s.ID == "lifecycle-session" {
+			t.Error("Ended session should not be in active sessions list")
+		}
+	}
+	
+	// Step 7: Verify session can still be retrieved with ended status
+	endedSession, err := manager.GetSession(ctx, "lifecycle-session")
+	if err != nil {
+		t.Errorf("GetSession should work for ended sessions: %v", err)
+	}
+	
+	if endedSession.Status != "ended" {
+		t.Errorf("Expected status 'ended', got '%s'", endedSession.Status)
+	}
+}
+func TestManager_MessageAnnotations(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	for _, id := range []string{"annotated-session", "other-session"} {
+		session := &types.Session{
+			ID:         id,
+			Name:       id,
+			CreatedBy:  "instructor1",
+			StudentIDs: []string{"student1"},
+			StartTime:  time.Now(),
+			Status:     "active",
+		}
+		if err := manager.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession should succeed: %v", err)
+		}
+	}
+	message := &types.Message{
+		ID:        "question-1",
+		SessionID: "annotated-session",
+		Type:      types.MessageTypeInstructorInbox,
+		Context:   "question",
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"text": "why?"},
+		Timestamp: time.Now(),
+	}
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage should succeed: %v", err)
+	}
+	
+	annotate := func(sessionID, instructorID string, tags []string, note string) error {
+		return manager.SetMessageAnnotation(ctx, sessionID, &types.MessageAnnotation{
+			MessageID:    message.ID,
+			InstructorID: instructorID,
+			Tags:         tags,
+			Note:         note,
+			UpdatedAt:    time.Now(),
+		})
+	}
+	
+	if err := annotate("annotated-session", "instructor1", []string{"star"}, ""); err != nil {
+		t.Fatalf("SetMessageAnnotation should succeed: %v", err)
+	}
+	if err := annotate("annotated-session", "instructor1", []string{"star", "followup"}, "revisit Monday"); err != nil {
+		t.Fatalf("Replacing an annotation should succeed: %v", err)
+	}
+	if err := annotate("annotated-session", "instructor2", []string{"star"}, ""); err != nil {
+		t.Fatalf("A second instructor's annotation should succeed: %v", err)
+	}
+	if err := annotate("other-session", "instructor1", []string{"star"}, ""); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Annotating through another session should fail with ErrNotFound, got %v", err)
+	}
+	
+	annotations, err := manager.GetSessionAnnotations(ctx, "annotated-session")
+	if err != nil || len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, got %d, %v", len(annotations), err)
+	}
+	for _, annotation := range annotations {
+		if annotation.InstructorID == "instructor1" && (len(annotation.Tags) != 2 || annotation.Note != "revisit Monday") {
+			t.Errorf("Expected the replaced annotation, got %+v", annotation)
+		}
+	}
+	
+	// Empty tags and note clear the annotation
+	if err := annotate("annotated-session", "instructor2", nil, ""); err != nil {
+		t.Fatalf("Clearing an annotation should succeed: %v", err)
+	}
+	if annotations, _ := manager.GetSessionAnnotations(ctx, "annotated-session"); len(annotations) != 1 {
+		t.Errorf("Expected 1 annotation after clearing, got %d", len(annotations))
+	}
+	
+	// Deleting the session's data removes its annotations
+	if _, err := manager.GetDB().ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, "annotated-session"); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	var remaining int
+	manager.GetDB().QueryRowContext(ctx, `SELECT COUNT(*) FROM message_annotations`).Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected annotations to cascade with the session, %d remain", remaining)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Write queue depth reports an idle queue and its capacity
+func TestManager_WriteQueueDepth(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	depth, capacity := manager.WriteQueueDepth()
+	if depth != 0 || capacity != 100 {
+		t.Errorf("Expected an empty queue of 100, got %d of %d", depth, capacity)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: As-of history is inclusive and correct across zone offsets
+func TestManager_GetSessionHistoryAsOf(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "asof-session",
+		Name:       "As Of Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	asOf := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	messages := []*types.Message{
+		{ID: "before", Timestamp: asOf.Add(-time.Minute)},
+		{ID: "at", Timestamp: asOf},
+		{ID: "before-other-zone", Timestamp: asOf.Add(-time.Second).In(tokyo)}, // Sorts after asOf as text
+		{ID: "after", Timestamp: asOf.Add(time.Millisecond)},
+		{ID: "after-other-zone", Timestamp: asOf.Add(time.Hour).In(time.FixedZone("PST", -8*60*60))}, // Sorts before asOf as text
+	}
+	for _, msg := range messages {
+		msg.SessionID = "asof-session"
+		msg.Type = "instructor_broadcast"
+		msg.FromUser = "instructor1"
+		msg.Content = map[string]interface{}{"text": msg.ID}
+		if err := manager.StoreMessage(ctx, msg); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+	
+	history, err := manager.GetSessionHistoryAsOf(ctx, "asof-session", asOf)
+	if err != nil {
+		t.Fatalf("GetSessionHistoryAsOf should succeed: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, msg := range history {
+		got[msg.ID] = true
+	}
+	if len(history) != 3 || !got["before"] || !got["at"] || !got["before-other-zone"] {
+		t.Errorf("Expected before, at and before-other-zone, got %v", got)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Analytics buckets group one sender's analytics by context and UTC minute
+func TestManager_GetAnalyticsBuckets(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "analytics-session",
+		Name:       "Analytics Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1", "student2"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	minute := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	messages := []*types.Message{
+		{ID: "e1", FromUser: "student1", Type: types.MessageTypeAnalytics, Context: "engagement", Timestamp: minute.Add(5 * time.Second)},
+		{ID: "e2", FromUser: "student1", Type: types.MessageTypeAnalytics, Context: "engagement", Timestamp: minute.Add(59*time.Second + 999*time.Millisecond).In(tokyo)},
+		{ID: "e3", FromUser: "student1", Type: types.MessageTypeAnalytics, Context: "engagement", Timestamp: minute.Add(time.Minute + 123456789)},
+		{ID: "p1", FromUser: "student1", Type: types.MessageTypeAnalytics, Context: "progress", Timestamp: minute.Add(10 * time.Second)},
+		{ID: "other", FromUser: "student2", Type: types.MessageTypeAnalytics, Context: "engagement", Timestamp: minute},
+		{ID: "question", FromUser: "student1", Type: types.MessageTypeInstructorInbox, Context: "general", Timestamp: minute},
+	}
+	for _, msg := range messages {
+		msg.SessionID = "analytics-session"
+		msg.Content = map[string]interface{}{"score": 1}
+		if err := manager.StoreMessage(ctx, msg); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+	
+	buckets, err := manager.GetAnalyticsBuckets(ctx, "analytics-session", "student1")
+	if err != nil {
+		t.Fatalf("GetAnalyticsBuckets should succeed: %v", err)
+	}
+	want := []types.AnalyticsBucket{
+		{Context: "engagement", Minute: minute, Count: 2},
+		{Context: "progress", Minute: minute, Count: 1},
+		{Context: "engagement", Minute: minute.Add(time.Minute), Count: 1},
+	}
+	if len(buckets) != len(want) {
+		t.Fatalf("Expected %d buckets, got %d", len(want), len(buckets))
+	}
+	for i, bucket := range buckets {
+		if bucket.Context != want[i].Context || !bucket.Minute.Equal(want[i].Minute) || bucket.Count != want[i].Count {
+			t.Errorf("Bucket %d: expected %+v, got %+v", i, want[i], *bucket)
+		}
+	}
+}
+
+func TestManager_GetSessionMessageCounts(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "stats-session",
+		Name:       "Stats Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1", "student2"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	minute := time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)
+	student := "student1"
+	messages := []*types.Message{
+		{ID: "q1", FromUser: "student1", Type: types.MessageTypeInstructorInbox, Timestamp: minute.Add(5 * time.Second)},
+		{ID: "q2", FromUser: "student1", Type: types.MessageTypeInstructorInbox, Timestamp: minute.Add(50 * time.Second).In(time.FixedZone("JST", 9*60*60))},
+		{ID: "a1", FromUser: "instructor1", Type: types.MessageTypeInboxResponse, ToUser: &student, Timestamp: minute.Add(70 * time.Second)},
+		{ID: "q3", FromUser: "student2", Type: types.MessageTypeInstructorInbox, Timestamp: minute.Add(3 * time.Minute)},
+	}
+	for _, msg := range messages {
+		msg.SessionID = "stats-session"
+		msg.Context = "general"
+		msg.Content = map[string]interface{}{"text": "hello"}
+		if err := manager.StoreMessage(ctx, msg); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+	
+	counts, err := manager.GetSessionMessageCounts(ctx, "stats-session")
+	if err != nil {
+		t.Fatalf("GetSessionMessageCounts should succeed: %v", err)
+	}
+	if counts.Total != 4 {
+		t.Errorf("Expected 4 messages, got %d", counts.Total)
+	}
+	if counts.ByType[types.MessageTypeInstructorInbox] != 3 || counts.ByType[types.MessageTypeInboxResponse] != 1 {
+		t.Errorf("Unexpected counts by type: %v", counts.ByType)
+	}
+	if counts.BySender["student1"] != 2 || counts.BySender["student2"] != 1 || counts.BySender["instructor1"] != 1 {
+		t.Errorf("Unexpected counts by sender: %v", counts.BySender)
+	}
+	want := []types.MinuteCount{
+		{Minute: minute, Count: 2},
+		{Minute: minute.Add(time.Minute), Count: 1},
+		{Minute: minute.Add(3 * time.Minute), Count: 1},
+	}
+	if len(counts.ByMinute) != len(want) {
+		t.Fatalf("Expected %d minutes, got %+v", len(want), counts.ByMinute)
+	}
+	for i, got := range counts.ByMinute {
+		if !got.Minute.Equal(want[i].Minute) || got.Count != want[i].Count {
+			t.Errorf("Minute %d: expected %+v, got %+v", i, want[i], got)
+		}
+	}
+	
+	empty, err := manager.GetSessionMessageCounts(ctx, "no-such-session")
+	if err != nil {
+		t.Fatalf("GetSessionMessageCounts should succeed for a session without messages: %v", err)
+	}
+	if empty.Total != 0 || len(empty.ByMinute) != 0 {
+		t.Errorf("Expected no counts, got %+v", empty)
+	}
+}
+
+func TestManager_RecordSessionEvent(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "event-session",
+		Name:       "Event Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	event := &types.SessionEvent{
+		SessionID: "event-session",
+		Type:      types.SessionEventRoutingLatency,
+		Actor:     "system",
+		Details:   map[string]interface{}{"samples": 12, "p50_ms": 0.5},
+	}
+	if err := manager.RecordSessionEvent(ctx, event); err != nil {
+		t.Fatalf("RecordSessionEvent should succeed: %v", err)
+	}
+	if event.ID == 0 || event.CreatedAt.IsZero() {
+		t.Errorf("Expected ID and CreatedAt to be set, got %+v", event)
+	}
+	
+	events, err := manager.GetSessionEvents(ctx, "event-session")
+	if err != nil {
+		t.Fatalf("GetSessionEvents should succeed: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(events))
+	}
+	if events[0].Type != types.SessionEventRoutingLatency || events[0].Actor != "system" || events[0].Details["samples"] != float64(12) {
+		t.Errorf("Unexpected stored event: %+v", events[0])
+	}
+}
+
+func TestManager_SystemSenderFlag(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "system-session",
+		Name:       "System Sender Test",
 		CreatedBy:  "instructor1",
 		StudentIDs: []string{"student1"},
 		StartTime:  time.Now(),
 		Status:     "active",
 	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	
+	for _, msg := range []*types.Message{
+		{ID: "announcement", FromUser: types.SystemSenderID, Type: types.MessageTypeInstructorBroadcast},
+		{ID: "broadcast", FromUser: "instructor1", Type: types.MessageTypeInstructorBroadcast},
+	} {
+		msg.SessionID = "system-session"
+		msg.Context = "general"
+		msg.Content = map[string]interface{}{"text": "hello"}
+		msg.Timestamp = time.Now()
+		if err := manager.StoreMessage(ctx, msg); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+	
+	history, err := manager.GetSessionHistory(ctx, "system-session")
+	if err != nil {
+		t.Fatalf("GetSessionHistory should succeed: %v", err)
+	}
+	for _, msg := range history {
+		if msg.System != (msg.ID == "announcement") {
+			t.Errorf("Message %s: expected system=%v", msg.ID, msg.ID == "announcement")
+		}
+	}
+}
+
+// TestManager_StoreSelfTestProbe tests functional validation - probes go to their own table, not messages
+func TestManager_StoreSelfTestProbe(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < selfTestProbesKept+5; i++ {
+		probe := &types.Message{
+			ID:        fmt.Sprintf("probe-%d", i),
+			SessionID: types.SelfTestSessionID,
+			Type:      types.MessageTypeInstructorInbox,
+			Context:   "general",
+			FromUser:  "sys:selftest-student",
+			Content:   map[string]interface{}{"probe_id": fmt.Sprintf("probe-%d", i)},
+			Timestamp: start.Add(time.Duration(i) * time.Millisecond),
+		}
+		if err := manager.StoreMessage(ctx, probe); err != nil {
+			t.Fatalf("StoreMessage for a probe should succeed without a sessions row: %v", err)
+		}
+	}
 	
-	err := manager.CreateSession(ctx, session)
-	if err != nil {
-		t.Fatalf("CreateSession should succeed: %v", err)
+	var probes, messages int
+	manager.GetDB().QueryRow("SELECT COUNT(*) FROM selftest_probes").Scan(&probes)
+	manager.GetDB().QueryRow("SELECT COUNT(*) FROM messages").Scan(&messages)
+	if probes != selfTestProbesKept {
+		t.Errorf("Expected the newest %d probes kept, got %d", selfTestProbesKept, probes)
+	}
+	if messages != 0 {
+		t.Errorf("Expected no probe in messages, got %d", messages)
 	}
+	var oldest string
+	manager.GetDB().QueryRow("SELECT id FROM selftest_probes ORDER BY timestamp LIMIT 1").Scan(&oldest)
+	if oldest != "probe-5" {
+		t.Errorf("Expected the oldest probes pruned first, oldest kept is %s", oldest)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: API keys round-trip by hash, list per session and revoke once
+func TestManager_APIKeys(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
 	
-	// Test concurrent read operations
-	const numReads = 50
-	var wg sync.WaitGroup
-	errors := make(chan error, numReads)
+	ctx := context.Background()
+	for _, id := range []string{"keyed-session", "other-session"} {
+		session := &types.Session{
+			ID:         id,
+			Name:       id,
+			CreatedBy:  "instructor1",
+			StudentIDs: []string{"student1"},
+			StartTime:  time.Now(),
+			Status:     "active",
+		}
+		if err := manager.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession should succeed: %v", err)
+		}
+	}
 	
-	wg.Add(numReads)
-	for i := 0; i < numReads; i++ {
-		go func() {
-			defer wg.Done()
-			
-			_, err := manager.GetSession(ctx, "concurrent-read-session")
-			if err != nil {
-				errors <- err
-			}
-		}()
+	created := time.Now().UTC().Truncate(time.Second)
+	key := &types.APIKey{
+		ID:        "key-1",
+		SessionID: "keyed-session",
+		Name:      "grader",
+		Scopes:    []string{types.APIKeyScopeSendMessages, types.APIKeyScopeReadHistory},
+		CreatedBy: "instructor1",
+		CreatedAt: created,
+		ExpiresAt: created.Add(time.Hour),
+	}
+	if err := manager.CreateAPIKey(ctx, key, "hash-1"); err != nil {
+		t.Fatalf("CreateAPIKey should succeed: %v", err)
+	}
+	if err := manager.CreateAPIKey(ctx, &types.APIKey{ID: "key-2", SessionID: "keyed-session", Scopes: []string{types.APIKeyScopeManage}, CreatedBy: "instructor1", CreatedAt: created.Add(time.Second), ExpiresAt: created.Add(time.Hour)}, "hash-1"); err == nil {
+		t.Error("Expected a duplicate key hash to be refused")
 	}
 	
-	wg.Wait()
-	close(errors)
+	loaded, err := manager.GetAPIKeyByHash(ctx, "hash-1")
+	if err != nil {
+		t.Fatalf("GetAPIKeyByHash should succeed: %v", err)
+	}
+	if loaded.ID != "key-1" || loaded.Name != "grader" || len(loaded.Scopes) != 2 || !loaded.ExpiresAt.Equal(key.ExpiresAt) || loaded.RevokedAt != nil {
+		t.Errorf("Expected the stored key back, got %+v", loaded)
+	}
+	if _, err := manager.GetAPIKeyByHash(ctx, "unknown"); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown hash, got %v", err)
+	}
 	
-	// Check for any errors from concurrent reads
-	for err := range errors {
-		t.Errorf("Concurrent read failed: %v", err)
+	if err := manager.RevokeAPIKey(ctx, "other-session", "key-1", time.Now()); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Revoking through another session should fail with ErrNotFound, got %v", err)
+	}
+	revokedAt := created.Add(time.Minute)
+	if err := manager.RevokeAPIKey(ctx, "keyed-session", "key-1", revokedAt); err != nil {
+		t.Fatalf("RevokeAPIKey should succeed: %v", err)
+	}
+	if err := manager.RevokeAPIKey(ctx, "keyed-session", "key-1", revokedAt.Add(time.Hour)); err != nil {
+		t.Errorf("Revoking twice should succeed: %v", err)
+	}
+	
+	keys, err := manager.ListAPIKeys(ctx, "keyed-session")
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("Expected 1 key, got %d, %v", len(keys), err)
+	}
+	if keys[0].RevokedAt == nil || !keys[0].RevokedAt.Equal(revokedAt) {
+		t.Errorf("Expected the first revocation time to be kept, got %v", keys[0].RevokedAt)
+	}
+	if keys, _ := manager.ListAPIKeys(ctx, "other-session"); len(keys) != 0 {
+		t.Errorf("Expected no keys in another session, got %d", len(keys))
 	}
 }
 
-func TestManager_HealthCheckBehavior(t *testing.T) {
-	// This test will FAIL until HealthCheck is implemented
+// FUNCTIONAL VALIDATION TEST: Reactions keep each user's latest code and are counted per message
+func TestManager_MessageReactions(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
 	defer cleanup()
 	
 	ctx := context.Background()
+	for _, id := range []string{"reacted-session", "other-session"} {
+		session := &types.Session{
+			ID:         id,
+			Name:       id,
+			CreatedBy:  "instructor1",
+			StudentIDs: []string{"student1", "student2"},
+			StartTime:  time.Now(),
+			Status:     "active",
+		}
+		if err := manager.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession should succeed: %v", err)
+		}
+	}
+	for _, id := range []string{"broadcast-1", "broadcast-2"} {
+		message := &types.Message{
+			ID:        id,
+			SessionID: "reacted-session",
+			Type:      types.MessageTypeInstructorBroadcast,
+			Context:   "general",
+			FromUser:  "instructor1",
+			Content:   map[string]interface{}{"text": "ready?"},
+			Timestamp: time.Now(),
+		}
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
 	
-	err := manager.HealthCheck(ctx)
-	if err != nil {
-		t.Errorf("HealthCheck should succeed for healthy database: %v", err)
+	react := func(sessionID, messageID, userID, code string) error {
+		return manager.SetMessageReaction(ctx, sessionID, &types.MessageReaction{
+			MessageID: messageID,
+			UserID:    userID,
+			Reaction:  code,
+			UpdatedAt: time.Now(),
+		})
+	}
+	for _, reaction := range [][3]string{
+		{"broadcast-1", "student1", "thumbs_up"},
+		{"broadcast-1", "student2", "thumbs_up"},
+		{"broadcast-1", "student1", "question"},
+		{"broadcast-2", "student2", "thumbs_down"},
+	} {
+		if err := react("reacted-session", reaction[0], reaction[1], reaction[2]); err != nil {
+			t.Fatalf("SetMessageReaction should succeed: %v", err)
+		}
+	}
+	if err := react("other-session", "broadcast-1", "student1", "thumbs_up"); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Reacting through another session should fail with ErrNotFound, got %v", err)
+	}
+	
+	counts, err := manager.GetReactionCounts(ctx, "broadcast-1")
+	if err != nil || len(counts) != 2 || counts["thumbs_up"] != 1 || counts["question"] != 1 {
+		t.Errorf("Expected one thumbs_up and one question, got %v, %v", counts, err)
+	}
+	sessionCounts, err := manager.GetSessionReactionCounts(ctx, "reacted-session")
+	if err != nil || len(sessionCounts) != 2 || sessionCounts["broadcast-2"]["thumbs_down"] != 1 {
+		t.Errorf("Expected tallies for both broadcasts, got %v, %v", sessionCounts, err)
+	}
+	if other, _ := manager.GetSessionReactionCounts(ctx, "other-session"); len(other) != 0 {
+		t.Errorf("Expected no tallies in another session, got %v", other)
+	}
+	
+	// Deleting the session's data removes its reactions
+	if _, err := manager.GetDB().ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, "reacted-session"); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	var remaining int
+	manager.GetDB().QueryRowContext(ctx, `SELECT COUNT(*) FROM message_reactions`).Scan(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected reactions to cascade with the session, %d remain", remaining)
 	}
 }
 
-func TestManager_CleanShutdown(t *testing.T) {
-	// This test will FAIL until proper shutdown is implemented
+// FUNCTIONAL VALIDATION TEST: Read receipts keep each student's first read and cascade with the session
+func TestManager_MessageReads(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
-	defer func() {
-		// Don't call cleanup() as we're testing Close() directly
-		_ = cleanup
-	}()
+	defer cleanup()
 	
-	// Start some operations
 	ctx := context.Background()
 	session := &types.Session{
-		ID:         "shutdown-test-session",
-		Name:       "Shutdown Test",
+		ID:         "read-session",
+		Name:       "read-session",
 		CreatedBy:  "instructor1",
-		StudentIDs: []string{"student1"},
+		StudentIDs: []string{"student1", "student2"},
 		StartTime:  time.Now(),
 		Status:     "active",
 	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	message := &types.Message{
+		ID:        "announcement",
+		SessionID: "read-session",
+		Type:      types.MessageTypeInstructorBroadcast,
+		Context:   "general",
+		FromUser:  "instructor1",
+		Content:   map[string]interface{}{"text": "Quiz moved to Friday"},
+		Timestamp: time.Now(),
+	}
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage should succeed: %v", err)
+	}
 	
-	err := manager.CreateSession(ctx, session)
-	if err != nil {
-		t.Errorf("CreateSession should succeed: %v", err)
+	first := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	read := func(sessionID, userID string, at time.Time) (bool, error) {
+		return manager.RecordMessageRead(ctx, sessionID, &types.MessageRead{MessageID: "announcement", UserID: userID, ReadAt: at})
+	}
+	if recorded, err := read("read-session", "student2", first); err != nil || !recorded {
+		t.Fatalf("First read should be recorded, got %v, %v", recorded, err)
+	}
+	if recorded, err := read("read-session", "student2", first.Add(time.Hour)); err != nil || recorded {
+		t.Errorf("A repeated read should change nothing, got %v, %v", recorded, err)
+	}
+	if _, err := read("read-session", "student1", first.Add(time.Minute)); err != nil {
+		t.Fatalf("RecordMessageRead should succeed: %v", err)
+	}
+	if _, err := read("other-session", "student1", first); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Reading through another session should fail with ErrNotFound, got %v", err)
 	}
 	
-	// Test clean shutdown
-	err = manager.Close()
-	if err != nil {
-		t.Errorf("Close should succeed: %v", err)
+	reads, err := manager.GetMessageReads(ctx, "announcement")
+	if err != nil || len(reads) != 2 || reads[0].UserID != "student2" || !reads[0].ReadAt.Equal(first) || reads[1].UserID != "student1" {
+		t.Errorf("Expected student2's first read then student1's, got %+v, %v", reads, err)
+	}
+	if count, err := manager.CountMessageReads(ctx, "announcement"); err != nil || count != 2 {
+		t.Errorf("Expected 2 reads, got %d, %v", count, err)
 	}
 	
-	// Verify operations fail after shutdown
-	err = manager.CreateSession(ctx, session)
-	if err == nil {
-		t.Error("Operations should fail after Close()")
+	// Deleting the session's data removes its receipts
+	if _, err := manager.GetDB().ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, "read-session"); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if count, _ := manager.CountMessageReads(ctx, "announcement"); count != 0 {
+		t.Errorf("Expected receipts to cascade with the session, %d remain", count)
 	}
 }
 
-// Integration Validation Tests
-func TestManager_CompleteSessionLifecycle(t *testing.T) {
-	// This test will FAIL until complete integration is implemented
+// FUNCTIONAL VALIDATION TEST: Report reads pair questions with responses, stream starred
+// messages once each and run on a handle that cannot write
+func TestManager_Reports(t *testing.T) {
 	manager, cleanup := setupTestDB(t)
 	defer cleanup()
 	
 	ctx := context.Background()
-	
-	// Step 1: Create session
 	session := &types.Session{
-		ID:         "lifecycle-session",
-		Name:       "Lifecycle Test Session",
+		ID:         "report-session",
+		Name:       "report-session",
 		CreatedBy:  "instructor1",
 		StudentIDs: []string{"student1", "student2"},
 		StartTime:  time.Now(),
 		Status:     "active",
 	}
-	
-	err := manager.CreateSession(ctx, session)
-	if err != nil {
+	if err := manager.CreateSession(ctx, session); err != nil {
 		t.Fatalf("CreateSession should succeed: %v", err)
 	}
 	
-	// Step 2: Store messages
-	messages := []*types.Message{
-		{
-			ID:        "msg-1",
-			SessionID: "lifecycle-session",
-			Type:      "instructor_broadcast",
-			FromUser:  "instructor1",
-			Content:   map[string]interface{}{"text": "Welcome"},
-			Timestamp: time.Now(),
-		},
-		{
-			ID:        "msg-2",
-			SessionID: "lifecycle-session",
-			Type:      "inbox_response",
-			FromUser:  "instructor1",
-			ToUser:    &[]string{"student1"}[0],
-			Content:   map[string]interface{}{"text": "Response"},
-			Timestamp: time.Now().Add(time.Minute),
-		},
+	start := time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC)
+	store := func(id, messageType, from, to string, offset time.Duration) {
+		message := &types.Message{
+			ID:        id,
+			SessionID: "report-session",
+			Type:      messageType,
+			Context:   "general",
+			FromUser:  from,
+			Content:   map[string]interface{}{"text": id},
+			Timestamp: start.Add(offset),
+		}
+		if to != "" {
+			message.ToUser = &to
+		}
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
 	}
-	
-	for _, msg := range messages {
-		err = manager.StoreMessage(ctx, msg)
+	store("early-reply", types.MessageTypeInboxResponse, "instructor1", "student1", 0)
+	store("s1-q1", types.MessageTypeInstructorInbox, "student1", "", time.Minute)
+	store("s2-q1", types.MessageTypeInstructorInbox, "student2", "", 2*time.Minute)
+	store("s1-a1", types.MessageTypeInboxResponse, "instructor1", "student1", 3*time.Minute)
+	store("s1-q2", types.MessageTypeInstructorInbox, "student1", "", 4*time.Minute)
+	store("s1-a2", types.MessageTypeInboxResponse, "instructor1", "student1", 5*time.Minute)
+	store("s1-a2b", types.MessageTypeInboxResponse, "instructor2", "student1", 6*time.Minute)
+	store("progress-1", types.MessageTypeAnalytics, "student1", "", 6*time.Minute)
+	store("progress-2", types.MessageTypeAnalytics, "student2", "", 6*time.Minute+10*time.Second)
+	
+	for _, instructorID := range []string{"instructor1", "instructor2"} {
+		err := manager.SetMessageAnnotation(ctx, "report-session", &types.MessageAnnotation{
+			MessageID:    "s2-q1",
+			InstructorID: instructorID,
+			Tags:         []string{"followup", "star"},
+			UpdatedAt:    time.Now(),
+		})
 		if err != nil {
-			t.Fatalf("StoreMessage should succeed: %v", err)
+			t.Fatalf("SetMessageAnnotation should succeed: %v", err)
 		}
 	}
-	
-	// Step 3: Verify session is in active list
-	activeSessions, err := manager.ListActiveSessions(ctx)
+	err := manager.SetMessageAnnotation(ctx, "report-session", &types.MessageAnnotation{
+		MessageID:    "s1-q1",
+		InstructorID: "instructor1",
+		Tags:         []string{"followup"},
+		UpdatedAt:    time.Now(),
+	})
 	if err != nil {
-		t.Errorf("ListActiveSessions should succeed: %v", err)
+		t.Fatalf("SetMessageAnnotation should succeed: %v", err)
 	}
 	
-	found := false
-	for _, s := range activeSessions {
-		if s.ID == "lifecycle-session" {
-			found = true
+	reports := manager.Reports()
+	
+	var questions []string
+	err = reports.StreamQuestions(ctx, "report-session", func(question *types.ReportQuestion) error {
+		entry := question.Question.ID + ":"
+		for _, response := range question.Responses {
+			entry += response.ID + ","
+		}
+		questions = append(questions, entry)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamQuestions should succeed: %v", err)
+	}
+	want := []string{"s1-q1:s1-a1,", "s1-q2:s1-a2,s1-a2b,", "s2-q1:"}
+	if len(questions) != len(want) {
+		t.Fatalf("Expected questions %v, got %v", want, questions)
+	}
+	for i := range want {
+		if questions[i] != want[i] {
+			t.Errorf("Expected questions %v, got %v", want, questions)
 			break
 		}
 	}
 	
-	if !found {
-		t.Error("Created session should be in active sessions list")
+	stop := errors.New("stop")
+	calls := 0
+	err = reports.StreamQuestions(ctx, "report-session", func(*types.ReportQuestion) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected the callback's error to stop the scan, got %v after %d calls", err, calls)
 	}
 	
-	// Step 4: Retrieve session history
-	history, err := manager.GetSessionHistory(ctx, "lifecycle-session")
-	if err != nil {
-		t.Errorf("GetSessionHistory should succeed: %v", err)
+	var starred []string
+	err = reports.StreamStarredMessages(ctx, "report-session", func(message *types.Message) error {
+		starred = append(starred, message.ID)
+		return nil
+	})
+	if err != nil || len(starred) != 1 || starred[0] != "s2-q1" {
+		t.Errorf("Expected s2-q1 starred once, got %v, %v", starred, err)
 	}
 	
-	if len(history) != 2 {
-		t.Errorf("Expected 2 messages in history, got %d", len(history))
+	analytics, err := reports.GetSessionAnalytics(ctx, "report-session")
+	if err != nil || len(analytics) != 1 || analytics[0].Count != 2 {
+		t.Errorf("Expected both students' analytics in one bucket, got %v, %v", analytics, err)
+	}
+	counts, err := reports.GetSessionMessageCounts(ctx, "report-session")
+	if err != nil || counts.Total != 9 {
+		t.Errorf("Expected 9 messages counted, got %+v, %v", counts, err)
 	}
 	
-	// Step 5: End session
-	now := time.Now()
-	session.EndTime = &now
-	session.Status = "ended"
-	
-	err = manager.UpdateSession(ctx, session)
-	if err != nil {
-		t.Errorf("UpdateSession should succeed: %v", err)
+	// The report handle is separate and read-only
+	if manager.reader == manager.GetDB() {
+		t.Fatal("Expected a file database to open a separate report handle")
+	}
+	if _, err := manager.reader.ExecContext(ctx, `DELETE FROM messages`); err == nil {
+		t.Error("Expected the report handle to refuse writes")
 	}
+}
+
+// TestManager_SessionConfig tests functional validation - a session's config snapshot
+// is stored with it, and sessions created without one read back as nil
+func TestManager_SessionConfig(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
 	
-	// Step 6: Verify session no longer in active list
-	activeSessions, err = manager.ListActiveSessions(ctx)
-	if err != nil {
-		t.Errorf("ListActiveSessions should succeed: %v", err)
+	ctx := context.Background()
+	config := &types.SessionConfig{
+		Version:         types.SessionConfigVersion,
+		Routing:         []types.RouteSummary{{Type: types.MessageTypeInstructorBroadcast, SenderRole: "instructor", Recipients: "session_students", DefaultContext: "general"}},
+		Limits:          types.SessionLimits{MaxContentBytes: types.MaxContentBytes, RateLimitMessages: 100, RateLimitWindowSeconds: 60},
+		LockExemptTypes: []string{types.MessageTypeAnalytics},
+	}
+	for _, session := range []*types.Session{
+		{ID: "snapshot", Name: "snapshot", CreatedBy: "instructor1", StudentIDs: []string{"student1"}, StartTime: time.Now(), Status: "active", Config: config},
+		{ID: "restored", Name: "restored", CreatedBy: "instructor1", StudentIDs: []string{"student1"}, StartTime: time.Now(), Status: "active"},
+	} {
+		if err := manager.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession should succeed: %v", err)
+		}
 	}
 	
-	for _, s := range activeSessions {
-		if s.ID == "lifecycle-session" {
-			t.Error("Ended session should not be in active sessions list")
+	for name, reader := range map[string]interface {
+		GetSessionConfig(context.Context, string) (*types.SessionConfig, error)
+	}{"manager": manager, "reports": manager.Reports()} {
+		stored, err := reader.GetSessionConfig(ctx, "snapshot")
+		if err != nil {
+			t.Fatalf("%s: GetSessionConfig should succeed: %v", name, err)
+		}
+		if stored == nil || len(stored.Routing) != 1 || stored.Routing[0] != config.Routing[0] || stored.Limits != config.Limits || len(stored.LockExemptTypes) != 1 {
+			t.Errorf("%s: expected the snapshot back, got %+v", name, stored)
+		}
+		if stored, err := reader.GetSessionConfig(ctx, "restored"); err != nil || stored != nil {
+			t.Errorf("%s: expected no snapshot for a session created without one, got %+v, %v", name, stored, err)
+		}
+		if _, err := reader.GetSessionConfig(ctx, "missing"); !errors.Is(err, interfaces.ErrSessionNotFound) {
+			t.Errorf("%s: expected ErrSessionNotFound, got %v", name, err)
 		}
 	}
 	
-	// Step 7: Verify session can still be retrieved with ended status
-	endedSession, err := manager.GetSession(ctx, "lifecycle-session")
+	// A snapshot written by a newer server is refused rather than half read
+	if _, err := manager.db.Exec(`UPDATE sessions SET session_config = '{"v":99}' WHERE id = 'snapshot'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := manager.GetSessionConfig(ctx, "snapshot"); !errors.Is(err, types.ErrUnknownSessionConfigVersion) {
+		t.Errorf("Expected ErrUnknownSessionConfigVersion, got %v", err)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: A student's thread holds only their conversation, pages
+// in history order through the (session, user) indexes, and refuses an unknown cursor
+func TestManager_StudentThread(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	session := &types.Session{
+		ID:         "thread-session",
+		Name:       "Threads",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1", "student2"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	student1, student2 := "student1", "student2"
+	start := time.Now()
+	for i, message := range []*types.Message{
+		{ID: "q1", Type: types.MessageTypeInstructorInbox, FromUser: student1},
+		{ID: "other", Type: types.MessageTypeInstructorInbox, FromUser: student2},
+		{ID: "a1", Type: types.MessageTypeInboxResponse, FromUser: "instructor1", ToUser: &student1},
+		{ID: "all", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1"},
+		{ID: "r1", Type: types.MessageTypeRequest, FromUser: "instructor1", ToUser: &student1},
+		{ID: "rr1", Type: types.MessageTypeRequestResponse, FromUser: student1},
+		{ID: "stats", Type: types.MessageTypeAnalytics, FromUser: student1},
+	} {
+		message.SessionID = "thread-session"
+		message.Content = map[string]interface{}{"text": message.ID}
+		message.Timestamp = start.Add(time.Duration(i) * time.Second)
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage failed: %v", err)
+		}
+	}
+
+	page, err := manager.GetStudentThread(ctx, "thread-session", "student1", "", 2)
+	if err != nil || len(page) != 2 || page[0].ID != "q1" || page[1].ID != "a1" {
+		t.Fatalf("Expected q1 and a1 on the first page, got %v (%v)", messageIDs(page), err)
+	}
+	page, err = manager.GetStudentThread(ctx, "thread-session", "student1", "a1", 10)
+	if err != nil || len(page) != 2 || page[0].ID != "r1" || page[1].ID != "rr1" {
+		t.Errorf("Expected r1 and rr1 after a1, got %v (%v)", messageIDs(page), err)
+	}
+	if _, err := manager.GetStudentThread(ctx, "thread-session", "student1", "missing", 10); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown cursor, got %v", err)
+	}
+
+	rows, err := manager.db.Query("EXPLAIN QUERY PLAN "+threadQuery+" ORDER BY m.timestamp ASC, m.rowid ASC LIMIT ?",
+		"thread-session", "student1", "thread-session", "student1", "a", "b", "c", "d", 10)
 	if err != nil {
-		t.Errorf("GetSession should work for ended sessions: %v", err)
+		t.Fatalf("EXPLAIN failed: %v", err)
+	}
+	defer rows.Close()
+	var plan []string
+	for rows.Next() {
+		var id, parent, unused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			t.Fatal(err)
+		}
+		plan = append(plan, detail)
 	}
-	
-	if endedSession.Status != "ended" {
-		t.Errorf("Expected status 'ended', got '%s'", endedSession.Status)
+	joined := strings.Join(plan, "\n")
+	if !strings.Contains(joined, "idx_messages_session_from") || !strings.Contains(joined, "idx_messages_session_to") {
+		t.Errorf("Expected the thread query to use both thread indexes, got plan:\n%s", joined)
+	}
+}
+
+func messageIDs(messages []*types.Message) []string {
+	ids := make([]string, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	return ids
+}
+
+// TestManager_AttachmentColumns tests functional validation - an inline attachment's media
+// type and decoded size are recorded beside the message, NULL without one
+func TestManager_AttachmentColumns(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "attachment-session",
+		Name:       "Attachment Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
 	}
-}
\ No newline at end of file
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+
+	contents := map[string]map[string]interface{}{
+		"with-attachment": {"text": "my screen", "attachment": map[string]interface{}{
+			"mime": "image/png; charset=binary", "data_base64": "iVBORw0KGgo=", "filename": "screen.png",
+		}},
+		"without-attachment": {"text": "hello"},
+	}
+	for id, content := range contents {
+		message := &types.Message{
+			ID:        id,
+			SessionID: "attachment-session",
+			Type:      types.MessageTypeInstructorInbox,
+			FromUser:  "student1",
+			Content:   content,
+			Timestamp: time.Now(),
+		}
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+
+	var mimeType sql.NullString
+	var size sql.NullInt64
+	if err := manager.db.QueryRow("SELECT attachment_mime, attachment_bytes FROM messages WHERE id = 'with-attachment'").Scan(&mimeType, &size); err != nil {
+		t.Fatalf("Query should succeed: %v", err)
+	}
+	if mimeType.String != "image/png" || size.Int64 != 8 {
+		t.Errorf("Expected image/png of 8 bytes, got %v and %v", mimeType, size)
+	}
+	if err := manager.db.QueryRow("SELECT attachment_mime, attachment_bytes FROM messages WHERE id = 'without-attachment'").Scan(&mimeType, &size); err != nil {
+		t.Fatalf("Query should succeed: %v", err)
+	}
+	if mimeType.Valid || size.Valid {
+		t.Errorf("Expected NULL columns without an attachment, got %v and %v", mimeType, size)
+	}
+}
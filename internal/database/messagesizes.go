@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// sizeHistogram counts one message type's stored content sizes
+// ARCHITECTURAL DISCOVERY: Lock-free like writeHistogram - observed by every caller of
+// StoreMessage after its write returns
+type sizeHistogram struct {
+	buckets  [len(types.MessageSizeBuckets) + 1]atomic.Uint64 // The last holds sizes above every bound
+	sumBytes atomic.Uint64
+}
+
+func (h *sizeHistogram) observe(bytes int) {
+	h.buckets[sort.SearchInts(types.MessageSizeBuckets[:], bytes)].Add(1)
+	h.sumBytes.Add(uint64(bytes))
+}
+
+// snapshot returns the histogram with cumulative bucket counts
+func (h *sizeHistogram) snapshot() types.MessageSizeHistogram {
+	cumulative := make([]uint64, len(types.MessageSizeBuckets))
+	var seen uint64
+	for i := range cumulative {
+		seen += h.buckets[i].Load()
+		cumulative[i] = seen
+	}
+	return types.MessageSizeHistogram{
+		Buckets:  cumulative,
+		Count:    seen + h.buckets[len(cumulative)].Load(),
+		SumBytes: h.sumBytes.Load(),
+	}
+}
+
+// messageSizes holds a size histogram per message type stored as a message
+// TECHNICAL DISCOVERY: Built once in NewManager and never added to, so it is read
+// without locking; unknown types are not counted
+type messageSizes map[string]*sizeHistogram
+
+func newMessageSizes() messageSizes {
+	sizes := make(messageSizes, len(types.MessageTypes))
+	for _, messageType := range types.MessageTypes {
+		if messageType != types.MessageTypeReaction {
+			sizes[messageType] = &sizeHistogram{}
+		}
+	}
+	return sizes
+}
+
+func (s messageSizes) observe(messageType string, bytes int) {
+	if histogram, known := s[messageType]; known {
+		histogram.observe(bytes)
+	}
+}
+
+func (s messageSizes) snapshot() map[string]types.MessageSizeHistogram {
+	histograms := make(map[string]types.MessageSizeHistogram, len(s))
+	for messageType, histogram := range s {
+		histograms[messageType] = histogram.snapshot()
+	}
+	return histograms
+}
+
+// MessageSizeStats returns the count, total, p50, p95 and max stored content size of
+// each message type sent since since
+// ARCHITECTURAL DISCOVERY: Runs on the read-only handle like reports. Every query is
+// answered from idx_messages_type_size alone: one grouped scan for counts, totals and
+// maximums, then each percentile is a seek to its rank within the type's size order
+// TECHNICAL DISCOVERY: Timestamps are stored as text with the writer's zone offset, so
+// the window edge is as approximate as GetSessionHistoryAsOf's bound; fine for a window
+// measured in hours
+func (m *Manager) MessageSizeStats(ctx context.Context, since time.Time) ([]types.MessageSizeStats, error) {
+	stats, err := m.messageSizeTotals(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats {
+		entry := &stats[i]
+		if entry.P50Bytes, err = m.messageSizeAtRank(ctx, entry.Type, since, nearestRank(50, entry.Count)); err != nil {
+			return nil, err
+		}
+		if entry.P95Bytes, err = m.messageSizeAtRank(ctx, entry.Type, since, nearestRank(95, entry.Count)); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// messageSizeTotals returns each type's count, total and maximum, without percentiles
+func (m *Manager) messageSizeTotals(ctx context.Context, since time.Time) ([]types.MessageSizeStats, error) {
+	rows, err := m.reader.QueryContext(ctx, `
+		SELECT type, COUNT(*), SUM(content_bytes), MAX(content_bytes)
+		FROM messages
+		WHERE timestamp >= ?
+		GROUP BY type
+		ORDER BY type
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message sizes: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	stats := []types.MessageSizeStats{}
+	for rows.Next() {
+		var entry types.MessageSizeStats
+		if err := rows.Scan(&entry.Type, &entry.Count, &entry.TotalBytes, &entry.MaxBytes); err != nil {
+			return nil, fmt.Errorf("failed to scan message sizes: %w", err)
+		}
+		stats = append(stats, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message sizes: %w", err)
+	}
+	return stats, nil
+}
+
+// nearestRank returns the 1-based rank of a percentile among count sorted values: the
+// smallest rank with at least percent of the values at or below it
+// TECHNICAL DISCOVERY: Integer arithmetic, so 95% of 20 is rank 19 rather than a float
+// rounding a hair above 19 up to 20
+func nearestRank(percent, count int) int {
+	rank := (percent*count + 99) / 100
+	return min(max(rank, 1), count)
+}
+
+// messageSizeAtRank returns the rank-th smallest content size of a type since since
+func (m *Manager) messageSizeAtRank(ctx context.Context, messageType string, since time.Time, rank int) (int, error) {
+	var size int
+	err := m.reader.QueryRowContext(ctx, `
+		SELECT content_bytes
+		FROM messages
+		WHERE type = ? AND timestamp >= ?
+		ORDER BY content_bytes
+		LIMIT 1 OFFSET ?
+	`, messageType, since, rank-1).Scan(&size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query %s message size percentile: %w", messageType, err)
+	}
+	return size, nil
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// TestManager_MessageSizeStats tests functional validation - sizes are recorded as
+// stored, shared bodies count in full, and the window, percentiles, session totals and
+// histograms all agree
+func TestManager_MessageSizeStats(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	manager.config.DedupThreshold = 64
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "size-session",
+		Name:       "Size Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+
+	now := time.Now()
+	store := func(id, messageType string, content map[string]interface{}, at time.Time) int {
+		t.Helper()
+		message := &types.Message{ID: id, SessionID: "size-session", Type: messageType, FromUser: "student1", Content: content, Timestamp: at}
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+		encoded, _ := json.Marshal(content)
+		return len(encoded)
+	}
+	var analyticsBytes int64
+	for i := 1; i <= 20; i++ {
+		analyticsBytes += int64(store(fmt.Sprintf("a%d", i), types.MessageTypeAnalytics, map[string]interface{}{"n": strings.Repeat("x", i)}, now))
+	}
+	code := map[string]interface{}{"code": strings.Repeat("print('hi')\n", 20)}
+	codeBytes := store("c1", types.MessageTypeRequestResponse, code, now)
+	store("c2", types.MessageTypeRequestResponse, code, now) // Shares c1's body
+	store("old", types.MessageTypeRequestResponse, map[string]interface{}{"code": strings.Repeat("y", 5000)}, now.Add(-48*time.Hour))
+
+	stats, err := manager.MessageSizeStats(ctx, now.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("MessageSizeStats failed: %v", err)
+	}
+	if len(stats) != 2 || stats[0].Type != types.MessageTypeAnalytics || stats[1].Type != types.MessageTypeRequestResponse {
+		t.Fatalf("Expected analytics then request_response inside the window, got %+v", stats)
+	}
+	// {"n":"x…"} is 8 bytes plus one per x: p50 is the 10th smallest, p95 the 19th
+	if analytics := stats[0]; analytics.Count != 20 || analytics.TotalBytes != analyticsBytes || analytics.P50Bytes != 18 || analytics.P95Bytes != 27 || analytics.MaxBytes != 28 {
+		t.Errorf("Unexpected analytics sizes: %+v", analytics)
+	}
+	if responses := stats[1]; responses.Count != 2 || responses.TotalBytes != int64(2*codeBytes) || responses.P50Bytes != codeBytes || responses.MaxBytes != codeBytes {
+		t.Errorf("Expected the shared body counted at full size on both messages, got %+v", responses)
+	}
+
+	counts, err := manager.GetSessionMessageCounts(ctx, "size-session")
+	if err != nil {
+		t.Fatalf("GetSessionMessageCounts failed: %v", err)
+	}
+	if counts.BytesByType[types.MessageTypeAnalytics] != analyticsBytes || counts.BytesByType[types.MessageTypeRequestResponse] != int64(2*codeBytes)+5011 {
+		t.Errorf("Expected session totals to include messages outside any window, got %v", counts.BytesByType)
+	}
+
+	histograms := manager.WriteStats().MessageSizes
+	if _, reactions := histograms[types.MessageTypeReaction]; reactions || len(histograms) != len(types.MessageTypes)-1 {
+		t.Errorf("Expected a histogram for every stored message type, got %v", histograms)
+	}
+	analytics := histograms[types.MessageTypeAnalytics]
+	if analytics.Count != 20 || analytics.SumBytes != uint64(analyticsBytes) || analytics.Buckets[0] != 20 {
+		t.Errorf("Expected every analytics message in the first bucket, got %+v", analytics)
+	}
+	responses := histograms[types.MessageTypeRequestResponse]
+	if responses.Count != 3 || responses.Buckets[0] != 0 || responses.Buckets[1] != 2 || responses.Buckets[3] != 3 {
+		t.Errorf("Expected cumulative request_response buckets, got %+v", responses)
+	}
+}
+
+// TestNearestRank tests technical validation - ranks are exact for whole percentages
+func TestNearestRank(t *testing.T) {
+	for _, check := range []struct{ percent, count, want int }{
+		{50, 1, 1}, {95, 1, 1}, {50, 20, 10}, {95, 20, 19}, {95, 21, 20}, {50, 3, 2}, {95, 100, 95},
+	} {
+		if got := nearestRank(check.percent, check.count); got != check.want {
+			t.Errorf("Expected p%d of %d at rank %d, got %d", check.percent, check.count, check.want, got)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+
+	"switchboard/pkg/types"
+)
+
+// ErrWritePanic reports a write operation that panicked; the write loop recovered
+var ErrWritePanic = errors.New("database write panicked")
+
+// ErrUnencodableContent reports message content that could not be serialized
+var ErrUnencodableContent = errors.New("message content cannot be encoded")
+
+// poisonDegradeCount is how many messages quarantined within one watchdog interval
+// degrade health
+// FUNCTIONAL DISCOVERY: One poison message is a buggy client; several in a row
+// suggest a broken client release or schema change worth an operator's attention
+const poisonDegradeCount = 3
+
+// runOperation runs one queued write, turning a panic into ErrWritePanic
+// ARCHITECTURAL DISCOVERY: The write loop is the only writer, so a panic escaping it
+// would silently stop all persistence until restart
+func runOperation(op writeOperation, db *sql.DB) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Database write panicked: %v\n%s", r, debug.Stack())
+			err = fmt.Errorf("%w: %v", ErrWritePanic, r)
+		}
+	}()
+	return op.operation(db)
+}
+
+// quarantine records message in poison_messages with the error that made it poison
+// TECHNICAL DISCOVERY: Writes through db directly; the write loop calls it between
+// operations, and other callers pass it to executeWrite
+func (m *Manager) quarantine(db *sql.DB, message *types.Message, cause error) error {
+	_, err := db.Exec(`
+		INSERT INTO poison_messages (message_id, session_id, from_user, type, payload, error, quarantined_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, message.ID, message.SessionID, message.FromUser, message.Type, poisonPayload(message), cause.Error(), m.clock.Now())
+	if err != nil {
+		log.Printf("Failed to quarantine message %s: %v", message.ID, err)
+		return fmt.Errorf("failed to quarantine message: %w", err)
+	}
+
+	m.watchdog.mu.Lock()
+	m.watchdog.poisonMessages++
+	m.watchdog.mu.Unlock()
+	log.Printf("AUDIT: poison_message message_id=%s session=%s from=%s type=%s: %v",
+		message.ID, message.SessionID, message.FromUser, message.Type, cause)
+	return nil
+}
+
+// poisonPayload serializes as much of message as will encode
+// FUNCTIONAL DISCOVERY: When the content is what broke, it is kept as Go-syntax text
+// under "unencodable" so the record still shows what the client sent
+func poisonPayload(message *types.Message) string {
+	if encoded, err := encodeGuarded(message); err == nil {
+		return string(encoded)
+	}
+	stripped := *message
+	stripped.Content = map[string]interface{}{"unencodable": fmt.Sprintf("%+v", message.Content)}
+	encoded, err := encodeGuarded(&stripped)
+	if err != nil {
+		return "{}"
+	}
+	return string(encoded)
+}
+
+// encodeGuarded is json.Marshal with a panicking MarshalJSON reported as an error
+func encodeGuarded(v interface{}) (encoded []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: panic: %v", ErrUnencodableContent, r)
+		}
+	}()
+	return json.Marshal(v)
+}
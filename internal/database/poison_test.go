@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// panickingValue breaks json.Marshal the way a buggy custom type would
+type panickingValue struct{}
+
+func (panickingValue) MarshalJSON() ([]byte, error) {
+	panic("broken marshaler")
+}
+
+// Functional Validation Tests
+func TestManager_PoisonMessages(t *testing.T) {
+	manager, _, transitions, cleanup := watchdogTestSetup(t, false)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := &types.Session{
+		ID: "session-1", Name: "Class", CreatedBy: "instructor1",
+		StudentIDs: []string{"student1"}, StartTime: time.Now(), Status: "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	message := func(id string, content map[string]interface{}) *types.Message {
+		return &types.Message{
+			ID: id, SessionID: "session-1", Type: types.MessageTypeInstructorInbox,
+			Context: "general", FromUser: "student1", Content: content, Timestamp: time.Now(),
+		}
+	}
+
+	// Content that will not encode is refused and quarantined
+	if err := manager.StoreMessage(ctx, message("nan", map[string]interface{}{"score": math.NaN()})); err == nil {
+		t.Error("Expected NaN content to be refused")
+	}
+	if err := manager.StoreMessage(ctx, message("panics", map[string]interface{}{"value": panickingValue{}})); !errors.Is(err, ErrUnencodableContent) {
+		t.Errorf("Expected ErrUnencodableContent for a panicking marshaler, got %v", err)
+	}
+
+	// A write that panics inside the write loop is recovered and quarantined
+	err := manager.executeMessageWrite(message("write-panic", map[string]interface{}{"text": "hi"}), func(db *sql.DB) error {
+		var missing map[string]int
+		missing["boom"]++ // Assignment to a nil map
+		return nil
+	})
+	if !errors.Is(err, ErrWritePanic) {
+		t.Fatalf("Expected ErrWritePanic, got %v", err)
+	}
+
+	// The write loop is still running
+	if err := manager.StoreMessage(ctx, message("after", map[string]interface{}{"text": "still here"})); err != nil {
+		t.Fatalf("Expected writes to succeed after poison messages: %v", err)
+	}
+	if history, err := manager.GetSessionHistory(ctx, "session-1"); err != nil || len(history) != 1 || history[0].ID != "after" {
+		t.Errorf("Expected only the good message stored, got %d messages, %v", len(history), err)
+	}
+
+	rows, err := manager.GetDB().QueryContext(ctx, `SELECT message_id, payload, error FROM poison_messages ORDER BY id`)
+	if err != nil {
+		t.Fatalf("Failed to read poison_messages: %v", err)
+	}
+	defer rows.Close()
+	records := make(map[string][2]string)
+	var order []string
+	for rows.Next() {
+		var id, payload, cause string
+		if err := rows.Scan(&id, &payload, &cause); err != nil {
+			t.Fatal(err)
+		}
+		records[id] = [2]string{payload, cause}
+		order = append(order, id)
+	}
+	if strings.Join(order, ",") != "nan,panics,write-panic" {
+		t.Fatalf("Expected three quarantined messages in order, got %v", order)
+	}
+	if payload, cause := records["nan"][0], records["nan"][1]; !strings.Contains(payload, `"unencodable":"map[score:NaN]"`) || !strings.Contains(cause, "NaN") {
+		t.Errorf("Expected the NaN content kept as text with its error, got %s / %s", payload, cause)
+	}
+	if payload, cause := records["write-panic"][0], records["write-panic"][1]; !strings.Contains(payload, `"text":"hi"`) || !strings.Contains(cause, "nil map") {
+		t.Errorf("Expected the encodable message kept whole with the panic, got %s / %s", payload, cause)
+	}
+
+	// Repeated poisons within one interval degrade health, and clear with the next
+	health := manager.HealthStatus()
+	if health.PoisonMessages != 3 {
+		t.Errorf("Expected 3 poison messages counted, got %d", health.PoisonMessages)
+	}
+	manager.checkHealth()
+	if health := manager.HealthStatus(); !health.Degraded || !strings.Contains(health.Reason, "3 poison messages") {
+		t.Fatalf("Expected degraded health naming the poisons, got %+v", health)
+	}
+	manager.checkHealth()
+	if health := manager.HealthStatus(); health.Degraded || len(transitions()) != 2 {
+		t.Errorf("Expected recovery once the poisons stop, got %+v after %d transitions", health, len(transitions()))
+	}
+}
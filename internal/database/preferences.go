@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"switchboard/pkg/types"
+)
+
+// SetInboxPreferences stores or clears an instructor's inbox preferences in a session
+// FUNCTIONAL DISCOVERY: Empty filters delete the row, so an instructor who resets
+// their preferences leaves nothing behind to load on the next reconnect
+func (m *Manager) SetInboxPreferences(ctx context.Context, preferences *types.InboxPreferences) error {
+	filters, err := json.Marshal(preferences.Filters)
+	if err != nil {
+		return fmt.Errorf("failed to marshal preference filters: %w", err)
+	}
+
+	return m.executeWrite(func(db *sql.DB) error {
+		if len(preferences.Filters) == 0 {
+			_, err = db.ExecContext(ctx,
+				`DELETE FROM instructor_preferences WHERE session_id = ? AND instructor_id = ?`,
+				preferences.SessionID, preferences.InstructorID,
+			)
+		} else {
+			_, err = db.ExecContext(ctx, `
+				INSERT OR REPLACE INTO instructor_preferences (session_id, instructor_id, filters, updated_at)
+				VALUES (?, ?, ?, ?)
+			`, preferences.SessionID, preferences.InstructorID, string(filters), preferences.UpdatedAt)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to store inbox preferences: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetInboxPreferences retrieves every instructor's inbox preferences in a session
+func (m *Manager) GetInboxPreferences(ctx context.Context, sessionID string) ([]*types.InboxPreferences, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT session_id, instructor_id, filters, updated_at
+		FROM instructor_preferences
+		WHERE session_id = ?
+		ORDER BY instructor_id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query inbox preferences: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var all []*types.InboxPreferences
+	for rows.Next() {
+		var preferences types.InboxPreferences
+		var filters string
+		if err := rows.Scan(&preferences.SessionID, &preferences.InstructorID, &filters, &preferences.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan inbox preferences: %w", err)
+		}
+		if err := json.Unmarshal([]byte(filters), &preferences.Filters); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal preference filters: %w", err)
+		}
+		all = append(all, &preferences)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating inbox preferences: %w", err)
+	}
+
+	return all, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestManager_InboxPreferences(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "preferences-session",
+		Name:       "Preferences",
+		CreatedBy:  "teacher",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	set := func(instructorID string, filters map[string][]string) {
+		t.Helper()
+		preferences := &types.InboxPreferences{
+			SessionID:    session.ID,
+			InstructorID: instructorID,
+			Filters:      filters,
+			UpdatedAt:    time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		}
+		if err := manager.SetInboxPreferences(ctx, preferences); err != nil {
+			t.Fatalf("SetInboxPreferences failed: %v", err)
+		}
+	}
+	set("ta", map[string][]string{types.MessageTypeInstructorInbox: {"question"}})
+	set("ta", map[string][]string{types.MessageTypeInstructorInbox: {"technical_issue"}, types.MessageTypeAnalytics: {}})
+	set("teacher", map[string][]string{types.MessageTypeRequestResponse: {"poll"}})
+
+	loaded, err := manager.GetInboxPreferences(ctx, session.ID)
+	if err != nil || len(loaded) != 2 {
+		t.Fatalf("Expected preferences for 2 instructors, got %d, %v", len(loaded), err)
+	}
+	ta := loaded[0]
+	if ta.InstructorID != "ta" || !ta.Receives(types.MessageTypeInstructorInbox, "technical_issue") ||
+		ta.Receives(types.MessageTypeInstructorInbox, "question") || ta.Receives(types.MessageTypeAnalytics, "general") {
+		t.Errorf("Expected the TA's replaced filters, got %+v", ta)
+	}
+
+	// Empty filters delete the row; the rest go with the session
+	set("ta", nil)
+	if loaded, _ := manager.GetInboxPreferences(ctx, session.ID); len(loaded) != 1 || loaded[0].InstructorID != "teacher" {
+		t.Errorf("Expected only the teacher's preferences after the TA reset, got %+v", loaded)
+	}
+	if _, err := manager.GetDB().ExecContext(ctx, `DELETE FROM sessions WHERE id = ?`, session.ID); err != nil {
+		t.Fatalf("Failed to delete session: %v", err)
+	}
+	if loaded, _ := manager.GetInboxPreferences(ctx, session.ID); len(loaded) != 0 {
+		t.Errorf("Expected preferences to cascade with the session, %d remain", len(loaded))
+	}
+}
@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// defaultPurgeBatchSize bounds the rows each purge transaction deletes
+// TECHNICAL DISCOVERY: The same 500 as imports - each batch is one trip through the
+// write channel, so live sessions' messages are written between batches
+const defaultPurgeBatchSize = 500
+
+// purgeStage deletes up to a batch of one table's rows belonging to a session
+type purgeStage struct {
+	name  string
+	query string // Arguments: session ID, batch size
+}
+
+// purgeStages run in order; the stage names are the tables they empty
+// TECHNICAL DISCOVERY: Rows pointing at messages are deleted before the messages, so
+// every row is counted under its own table instead of vanishing through ON DELETE
+// CASCADE. Tables without a rowid are batched by their primary key
+var purgeStages = []purgeStage{
+	{types.PurgeStageMessageReads, `DELETE FROM message_reads WHERE (message_id, user_id) IN (
+		SELECT r.message_id, r.user_id FROM message_reads r JOIN messages m ON m.id = r.message_id WHERE m.session_id = ? LIMIT ?)`},
+	{types.PurgeStageMessageReactions, `DELETE FROM message_reactions WHERE (message_id, user_id) IN (
+		SELECT r.message_id, r.user_id FROM message_reactions r JOIN messages m ON m.id = r.message_id WHERE m.session_id = ? LIMIT ?)`},
+	{types.PurgeStageMessageAnnotations, `DELETE FROM message_annotations WHERE rowid IN (
+		SELECT a.rowid FROM message_annotations a JOIN messages m ON m.id = a.message_id WHERE m.session_id = ? LIMIT ?)`},
+	{types.PurgeStageMessageMetadata, `DELETE FROM message_metadata WHERE rowid IN (
+		SELECT d.rowid FROM message_metadata d JOIN messages m ON m.id = d.message_id WHERE m.session_id = ? LIMIT ?)`},
+	{types.PurgeStageMessageRevisions, `DELETE FROM message_revisions WHERE (message_id, revision) IN (
+		SELECT r.message_id, r.revision FROM message_revisions r JOIN messages m ON m.id = r.message_id WHERE m.session_id = ? LIMIT ?)`},
+	{types.PurgeStageMessages, `DELETE FROM messages WHERE rowid IN (
+		SELECT rowid FROM messages WHERE session_id = ? LIMIT ?)`},
+	{types.PurgeStagePoisonMessages, `DELETE FROM poison_messages WHERE id IN (
+		SELECT id FROM poison_messages WHERE session_id = ? LIMIT ?)`},
+	{types.PurgeStageDeliveryFailures, `DELETE FROM delivery_failures WHERE id IN (
+		SELECT id FROM delivery_failures WHERE session_id = ? LIMIT ?)`},
+	{types.PurgeStageSessionEvents, `DELETE FROM session_events WHERE id IN (
+		SELECT id FROM session_events WHERE session_id = ? LIMIT ?)`},
+	{types.PurgeStageAPIKeys, `DELETE FROM api_keys WHERE rowid IN (
+		SELECT rowid FROM api_keys WHERE session_id = ? LIMIT ?)`},
+	{types.PurgeStageInstructorPreferences, `DELETE FROM instructor_preferences WHERE rowid IN (
+		SELECT rowid FROM instructor_preferences WHERE session_id = ? LIMIT ?)`},
+	{types.PurgeStageMetricsRollups, `DELETE FROM metrics_rollups WHERE (bucket_start, session_id) IN (
+		SELECT bucket_start, session_id FROM metrics_rollups WHERE session_id = ? LIMIT ?)`},
+	{types.PurgeStageSession, `DELETE FROM sessions WHERE id IN (
+		SELECT id FROM sessions WHERE id = ? LIMIT ?)`},
+}
+
+// PurgeSession permanently deletes a session and everything attached to it
+// FUNCTIONAL DISCOVERY: Progress lives in session_purges and is updated in each
+// batch's own transaction, so a purge cut short by a crash, a shutdown or a cancelled
+// request loses nothing it counted and continues from its stage when called again.
+// Content bodies shared through content_store go when the purged messages held their
+// last references
+// ARCHITECTURAL DISCOVERY: Does not check that the session has ended - the API ends it
+// first, so clients are told and nothing new arrives while it is emptied
+func (m *Manager) PurgeSession(ctx context.Context, sessionID, requestedBy string) (*types.SessionPurge, error) {
+	purge, err := m.startPurge(sessionID, requestedBy)
+	if err != nil {
+		return nil, err
+	}
+	if purge.Resumed {
+		log.Printf("Resuming purge of session %s at %s", sessionID, purge.Stage)
+	}
+
+	for purge.CompletedAt == nil {
+		if err := ctx.Err(); err != nil {
+			log.Printf("Purge of session %s interrupted at %s: %v", sessionID, purge.Stage, err)
+			return purge, err
+		}
+		if err := m.purgeBatch(purge); err != nil {
+			return purge, fmt.Errorf("purge of session %s failed at %s: %w", sessionID, purge.Stage, err)
+		}
+	}
+	log.Printf("Purged session %s for %s: %v", sessionID, purge.RequestedBy, purge.Removed)
+	return purge, nil
+}
+
+// startPurge returns sessionID's purge record, creating it when the session exists
+// and has none; ErrNotFound when neither exists
+func (m *Manager) startPurge(sessionID, requestedBy string) (*types.SessionPurge, error) {
+	var purge *types.SessionPurge
+	// TECHNICAL DISCOVERY: An error inside a write is retried by writeRetry - up to 3
+	// attempts, about 250ms then 500ms apart with jitter, and none while degraded or
+	// after a panic - so a missing session is reported after the write rather than
+	// retried, and the queries are not cancelled with the request
+	ctx := context.Background()
+	err := m.executeWrite(func(db *sql.DB) error {
+		purge = nil
+		existing, err := scanSessionPurge(db.QueryRowContext(ctx, `
+			SELECT session_id, requested_by, stage, removed, started_at, updated_at, completed_at
+			FROM session_purges WHERE session_id = ?
+		`, sessionID))
+		if err == nil {
+			existing.Resumed = existing.CompletedAt == nil
+			purge = existing
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		var exists int
+		if err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sessions WHERE id = ?", sessionID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return nil
+		}
+		now := m.clock.Now()
+		_, err = db.ExecContext(ctx, `
+			INSERT INTO session_purges (session_id, requested_by, stage, removed, started_at, updated_at)
+			VALUES (?, ?, ?, '{}', ?, ?)
+		`, sessionID, requestedBy, purgeStages[0].name, now, now)
+		if err != nil {
+			return err
+		}
+		purge = &types.SessionPurge{
+			SessionID:   sessionID,
+			RequestedBy: requestedBy,
+			Stage:       purgeStages[0].name,
+			Removed:     map[string]int64{},
+			StartedAt:   now,
+			UpdatedAt:   now,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start purge of session %s: %w", sessionID, err)
+	}
+	if purge == nil {
+		return nil, interfaces.ErrNotFound
+	}
+	return purge, nil
+}
+
+// purgeBatch deletes one batch of purge's current stage and records it, moving to the
+// next stage once a batch comes back short
+func (m *Manager) purgeBatch(purge *types.SessionPurge) error {
+	stage := -1
+	for i := range purgeStages {
+		if purgeStages[i].name == purge.Stage {
+			stage = i
+		}
+	}
+	if stage < 0 {
+		return fmt.Errorf("unknown purge stage %q", purge.Stage)
+	}
+	batchSize := m.purgeBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultPurgeBatchSize
+	}
+
+	var removed map[string]int64
+	var next string
+	var updatedAt time.Time
+	ctx := context.Background() // As in startPurge, a cancelled request stops between batches only
+	err := m.executeWrite(func(db *sql.DB) error {
+		// TECHNICAL DISCOVERY: Rebuilt per attempt - writeLoop retries failed operations
+		removed = make(map[string]int64, len(purge.Removed)+2)
+		for table, count := range purge.Removed {
+			removed[table] = count
+		}
+
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		var blobsBefore int64
+		if purge.Stage == types.PurgeStageMessages {
+			if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM content_store").Scan(&blobsBefore); err != nil {
+				return err
+			}
+		}
+		result, err := tx.ExecContext(ctx, purgeStages[stage].query, purge.SessionID, batchSize)
+		if err != nil {
+			return err
+		}
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		removed[purge.Stage] += deleted
+		if purge.Stage == types.PurgeStageMessages {
+			var blobsAfter int64
+			if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM content_store").Scan(&blobsAfter); err != nil {
+				return err
+			}
+			removed[types.PurgeContentBlobs] += blobsBefore - blobsAfter
+		}
+
+		next = purge.Stage
+		if deleted < int64(batchSize) {
+			next = types.PurgeStageCompleted
+			if stage+1 < len(purgeStages) {
+				next = purgeStages[stage+1].name
+			}
+		}
+		updatedAt = m.clock.Now()
+		var completedAt *time.Time
+		if next == types.PurgeStageCompleted {
+			completedAt = &updatedAt
+		}
+
+		removedJSON, err := json.Marshal(removed)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE session_purges SET stage = ?, removed = ?, updated_at = ?, completed_at = ?
+			WHERE session_id = ?
+		`, next, string(removedJSON), updatedAt, completedAt, purge.SessionID); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return err
+	}
+
+	purge.Removed = removed
+	purge.Stage = next
+	purge.UpdatedAt = updatedAt
+	if next == types.PurgeStageCompleted {
+		purge.CompletedAt = &updatedAt
+	}
+	return nil
+}
+
+// scanSessionPurge reads one session_purges row
+func scanSessionPurge(row *sql.Row) (*types.SessionPurge, error) {
+	purge := &types.SessionPurge{}
+	var removed string
+	var completedAt sql.NullTime
+	if err := row.Scan(&purge.SessionID, &purge.RequestedBy, &purge.Stage, &removed, &purge.StartedAt, &purge.UpdatedAt, &completedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(removed), &purge.Removed); err != nil {
+		return nil, fmt.Errorf("failed to parse purge counts: %w", err)
+	}
+	if completedAt.Valid {
+		purge.CompletedAt = &completedAt.Time
+	}
+	return purge, nil
+}
@@ -0,0 +1,200 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// seedPurgeSession creates a session with messages and a row in every table a purge
+// empties; the first message's content is large enough to be stored in content_store
+func seedPurgeSession(t *testing.T, manager *Manager, sessionID string, messages int, sharedBody string) {
+	t.Helper()
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         sessionID,
+		Name:       "Purge " + sessionID,
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "ended",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	for i := 0; i < messages; i++ {
+		content := map[string]interface{}{"text": fmt.Sprintf("message %d", i)}
+		switch i {
+		case 0:
+			content["code"] = strings.Repeat(sessionID, 10)
+		case 1:
+			content["code"] = sharedBody
+		}
+		message := &types.Message{
+			ID:        fmt.Sprintf("%s-msg-%d", sessionID, i),
+			SessionID: sessionID,
+			Type:      types.MessageTypeInstructorInbox,
+			FromUser:  "student1",
+			Content:   content,
+			Timestamp: time.Now(),
+		}
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage should succeed: %v", err)
+		}
+	}
+
+	first := sessionID + "-msg-0"
+	for _, statement := range []string{
+		fmt.Sprintf("INSERT INTO message_reads (message_id, user_id, read_at) VALUES ('%s', 'instructor1', CURRENT_TIMESTAMP)", first),
+		fmt.Sprintf("INSERT INTO message_reactions (message_id, user_id, reaction, updated_at) VALUES ('%s', 'instructor1', 'thumbs_up', CURRENT_TIMESTAMP)", first),
+		fmt.Sprintf("INSERT INTO message_annotations (message_id, instructor_id, tags, note, updated_at) VALUES ('%s', 'instructor1', '[]', '', CURRENT_TIMESTAMP)", first),
+		fmt.Sprintf("INSERT INTO message_metadata (message_id, connection_id, recorded_at) VALUES ('%s', 'conn-1', CURRENT_TIMESTAMP)", first),
+		fmt.Sprintf("INSERT INTO message_revisions (message_id, revision, content, edited_by, replaced_at) VALUES ('%s', 0, '{}', 'instructor1', CURRENT_TIMESTAMP)", first),
+		fmt.Sprintf("INSERT INTO poison_messages (message_id, session_id, from_user, type, payload, error, quarantined_at) VALUES ('poison', '%s', 'student1', 'instructor_inbox', '{}', 'panic', CURRENT_TIMESTAMP)", sessionID),
+		fmt.Sprintf("INSERT INTO session_events (session_id, event_type, actor, details, created_at) VALUES ('%s', 'session_ended', 'instructor1', '{}', CURRENT_TIMESTAMP)", sessionID),
+		fmt.Sprintf("INSERT INTO api_keys (id, session_id, key_hash, created_by, created_at, expires_at) VALUES ('%s-key', '%s', '%s-hash', 'instructor1', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)", sessionID, sessionID, sessionID),
+		fmt.Sprintf("INSERT INTO instructor_preferences (session_id, instructor_id, filters, updated_at) VALUES ('%s', 'instructor1', '{}', CURRENT_TIMESTAMP)", sessionID),
+		fmt.Sprintf("INSERT INTO metrics_rollups (bucket_start, session_id, messages, connections) VALUES (1, '%s', 5, 2)", sessionID),
+		fmt.Sprintf("INSERT INTO delivery_failures (message_id, session_id, recipient, reason, failed_at) VALUES ('%s', '%s', 'student1', 'write_timeout', CURRENT_TIMESTAMP)", first, sessionID),
+	} {
+		if _, err := manager.db.Exec(statement); err != nil {
+			t.Fatalf("Seeding %q failed: %v", statement, err)
+		}
+	}
+}
+
+// countSessionRows counts the rows left in each purged table for sessionID
+func countSessionRows(t *testing.T, manager *Manager, sessionID string) int {
+	t.Helper()
+	var total int
+	for _, query := range []string{
+		"SELECT COUNT(*) FROM message_reads r JOIN messages m ON m.id = r.message_id WHERE m.session_id = ?",
+		"SELECT COUNT(*) FROM message_metadata d JOIN messages m ON m.id = d.message_id WHERE m.session_id = ?",
+		"SELECT COUNT(*) FROM message_revisions r JOIN messages m ON m.id = r.message_id WHERE m.session_id = ?",
+		"SELECT COUNT(*) FROM messages WHERE session_id = ?",
+		"SELECT COUNT(*) FROM poison_messages WHERE session_id = ?",
+		"SELECT COUNT(*) FROM session_events WHERE session_id = ?",
+		"SELECT COUNT(*) FROM api_keys WHERE session_id = ?",
+		"SELECT COUNT(*) FROM metrics_rollups WHERE session_id = ?",
+		"SELECT COUNT(*) FROM delivery_failures WHERE session_id = ?",
+		"SELECT COUNT(*) FROM sessions WHERE id = ?",
+	} {
+		var count int
+		if err := manager.db.QueryRow(query, sessionID).Scan(&count); err != nil {
+			t.Fatalf("Query %q failed: %v", query, err)
+		}
+		total += count
+	}
+	return total
+}
+
+// TestManager_PurgeSession tests functional validation - every table loses the session's
+// rows in small batches, counted per table, and other sessions keep theirs
+func TestManager_PurgeSession(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	var _ interfaces.SessionPurger = manager
+
+	manager.config.DedupThreshold = 64
+	manager.purgeBatchSize = 2
+	shared := strings.Repeat("shared starter code ", 10)
+	seedPurgeSession(t, manager, "purged", 5, shared)
+	seedPurgeSession(t, manager, "kept", 2, shared)
+	ctx := context.Background()
+
+	if _, err := manager.PurgeSession(ctx, "missing", "instructor1"); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown session, got %v", err)
+	}
+
+	purge, err := manager.PurgeSession(ctx, "purged", "instructor1")
+	if err != nil {
+		t.Fatalf("PurgeSession should succeed: %v", err)
+	}
+	want := map[string]int64{
+		types.PurgeStageMessageReads:          1,
+		types.PurgeStageMessageReactions:      1,
+		types.PurgeStageMessageAnnotations:    1,
+		types.PurgeStageMessageMetadata:       1,
+		types.PurgeStageMessageRevisions:      1,
+		types.PurgeStageMessages:              5,
+		types.PurgeContentBlobs:               1, // The shared body is still referenced by kept
+		types.PurgeStagePoisonMessages:        1,
+		types.PurgeStageDeliveryFailures:      1,
+		types.PurgeStageSessionEvents:         1,
+		types.PurgeStageAPIKeys:               1,
+		types.PurgeStageInstructorPreferences: 1,
+		types.PurgeStageMetricsRollups:        1,
+		types.PurgeStageSession:               1,
+	}
+	for table, count := range want {
+		if purge.Removed[table] != count {
+			t.Errorf("Expected %d rows removed from %s, got %d", count, table, purge.Removed[table])
+		}
+	}
+	if purge.Stage != types.PurgeStageCompleted || purge.CompletedAt == nil || purge.Resumed {
+		t.Errorf("Expected a completed purge, got %+v", purge)
+	}
+
+	if left := countSessionRows(t, manager, "purged"); left != 0 {
+		t.Errorf("Expected nothing left of the purged session, got %d rows", left)
+	}
+	if kept := countSessionRows(t, manager, "kept"); kept != 11 {
+		t.Errorf("Expected the other session's 11 rows kept, got %d", kept)
+	}
+	if history, err := manager.GetSessionHistory(ctx, "kept"); err != nil || len(history) != 2 || history[1].Content["code"] != shared {
+		t.Errorf("Expected the other session's history intact, got %d messages: %v", len(history), err)
+	}
+
+	again, err := manager.PurgeSession(ctx, "purged", "admin")
+	if err != nil || again.CompletedAt == nil || again.RequestedBy != "instructor1" || again.Removed[types.PurgeStageMessages] != 5 {
+		t.Errorf("Expected the finished purge returned as it ended, got %+v: %v", again, err)
+	}
+}
+
+// TestManager_PurgeSessionResumes tests functional validation - a purge interrupted
+// partway continues from its stage and its counts cover both attempts
+func TestManager_PurgeSessionResumes(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	manager.purgeBatchSize = 2
+	seedPurgeSession(t, manager, "interrupted", 5, "short")
+	ctx := context.Background()
+
+	// Run the first stages and one batch of messages, as a crash partway through would
+	first, err := manager.startPurge("interrupted", "instructor1")
+	if err != nil {
+		t.Fatalf("startPurge should succeed: %v", err)
+	}
+	for first.Stage != types.PurgeStageMessages || first.Removed[types.PurgeStageMessages] == 0 {
+		if err := manager.purgeBatch(first); err != nil {
+			t.Fatalf("purgeBatch should succeed: %v", err)
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := manager.PurgeSession(cancelled, "interrupted", "instructor1"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled purge to stop, got %v", err)
+	}
+
+	purge, err := manager.PurgeSession(ctx, "interrupted", "admin")
+	if err != nil {
+		t.Fatalf("PurgeSession should succeed: %v", err)
+	}
+	if !purge.Resumed || purge.RequestedBy != "instructor1" || !purge.StartedAt.Equal(first.StartedAt) {
+		t.Errorf("Expected the first purge resumed, got %+v", purge)
+	}
+	if purge.Removed[types.PurgeStageMessages] != 5 || purge.Removed[types.PurgeStageMessageReads] != 1 || purge.Removed[types.PurgeStageSession] != 1 {
+		t.Errorf("Expected counts across both attempts, got %v", purge.Removed)
+	}
+	if left := countSessionRows(t, manager, "interrupted"); left != 0 {
+		t.Errorf("Expected nothing left of the purged session, got %d rows", left)
+	}
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// SetMessageReaction stores a student's reaction to a message in sessionID, replacing
+// the student's previous reaction to it
+// TECHNICAL DISCOVERY: The session check and the write share one transaction, like
+// SetMessageAnnotation, so a message ID from another session is never reacted to
+func (m *Manager) SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error {
+	return m.executeWrite(func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		var found int
+		err = tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM messages WHERE id = ? AND session_id = ?`,
+			reaction.MessageID, sessionID,
+		).Scan(&found)
+		if err != nil {
+			return fmt.Errorf("failed to check message: %w", err)
+		}
+		if found == 0 {
+			return interfaces.ErrNotFound
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO message_reactions (message_id, user_id, reaction, updated_at)
+			VALUES (?, ?, ?, ?)
+		`, reaction.MessageID, reaction.UserID, reaction.Reaction, reaction.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to store message reaction: %w", err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit message reaction: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetReactionCounts counts a message's reactions per code
+func (m *Manager) GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT reaction, COUNT(*)
+		FROM message_reactions
+		WHERE message_id = ?
+		GROUP BY reaction
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reaction counts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var reaction string
+		var count int
+		if err := rows.Scan(&reaction, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction count: %w", err)
+		}
+		counts[reaction] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reaction counts: %w", err)
+	}
+
+	return counts, nil
+}
+
+// GetSessionReactionCounts counts reactions per code for every message of a session,
+// keyed by message ID; messages nobody reacted to are absent
+func (m *Manager) GetSessionReactionCounts(ctx context.Context, sessionID string) (map[string]map[string]int, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT r.message_id, r.reaction, COUNT(*)
+		FROM message_reactions r
+		JOIN messages msg ON msg.id = r.message_id
+		WHERE msg.session_id = ?
+		GROUP BY r.message_id, r.reaction
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session reaction counts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var messageID, reaction string
+		var count int
+		if err := rows.Scan(&messageID, &reaction, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan reaction count: %w", err)
+		}
+		if counts[messageID] == nil {
+			counts[messageID] = make(map[string]int)
+		}
+		counts[messageID][reaction] = count
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session reaction counts: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,94 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// RecordMessageRead stores a student's first read of a message in sessionID
+// TECHNICAL DISCOVERY: The session check and the write share one transaction, like
+// SetMessageReaction; INSERT OR IGNORE keeps the first read_at and reports a repeat
+// as no rows changed
+func (m *Manager) RecordMessageRead(ctx context.Context, sessionID string, read *types.MessageRead) (bool, error) {
+	recorded := false
+	err := m.executeWrite(func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		var found int
+		err = tx.QueryRowContext(ctx,
+			`SELECT COUNT(*) FROM messages WHERE id = ? AND session_id = ?`,
+			read.MessageID, sessionID,
+		).Scan(&found)
+		if err != nil {
+			return fmt.Errorf("failed to check message: %w", err)
+		}
+		if found == 0 {
+			return interfaces.ErrNotFound
+		}
+
+		result, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO message_reads (message_id, user_id, read_at)
+			VALUES (?, ?, ?)
+		`, read.MessageID, read.UserID, read.ReadAt)
+		if err != nil {
+			return fmt.Errorf("failed to store message read: %w", err)
+		}
+		inserted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to read inserted rows: %w", err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit message read: %w", err)
+		}
+		recorded = inserted > 0
+		return nil
+	})
+	return recorded, err
+}
+
+// GetMessageReads returns a message's reads, oldest first
+func (m *Manager) GetMessageReads(ctx context.Context, messageID string) ([]*types.MessageRead, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT message_id, user_id, read_at
+		FROM message_reads
+		WHERE message_id = ?
+		ORDER BY read_at, user_id
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message reads: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	reads := []*types.MessageRead{}
+	for rows.Next() {
+		var read types.MessageRead
+		if err := rows.Scan(&read.MessageID, &read.UserID, &read.ReadAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message read: %w", err)
+		}
+		reads = append(reads, &read)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message reads: %w", err)
+	}
+	return reads, nil
+}
+
+// CountMessageReads counts the students who have read a message
+// TECHNICAL DISCOVERY: Served by the primary key's message_id prefix
+func (m *Manager) CountMessageReads(ctx context.Context, messageID string) (int, error) {
+	var count int
+	err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM message_reads WHERE message_id = ?`, messageID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count message reads: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// Reports returns the manager's session report reader
+// ARCHITECTURAL DISCOVERY: Every query runs on the read-only handle opened in
+// NewManager, so report scans only ever wait on, and hold, that pool
+func (m *Manager) Reports() interfaces.ReportReader {
+	return &reportReader{db: m.reader}
+}
+
+// reportReader implements interfaces.ReportReader over one handle
+type reportReader struct {
+	db *sql.DB
+}
+
+func (r *reportReader) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) {
+	return sessionMessageCounts(ctx, r.db, sessionID)
+}
+
+func (r *reportReader) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) {
+	return sessionEvents(ctx, r.db, sessionID)
+}
+
+func (r *reportReader) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) {
+	return sessionConfig(ctx, r.db, sessionID)
+}
+
+// GetSessionAnalytics is GetAnalyticsBuckets without the sender filter
+func (r *reportReader) GetSessionAnalytics(ctx context.Context, sessionID string) ([]*types.AnalyticsBucket, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT context, strftime('%Y-%m-%dT%H:%M:00Z', timestamp) AS minute, COUNT(*)
+		FROM messages
+		WHERE session_id = ? AND type = ?
+		GROUP BY context, minute
+		ORDER BY minute ASC, context ASC
+	`, sessionID, types.MessageTypeAnalytics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session analytics: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	buckets := []*types.AnalyticsBucket{}
+	for rows.Next() {
+		var bucket types.AnalyticsBucket
+		var minute string
+		if err := rows.Scan(&bucket.Context, &minute, &bucket.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan analytics bucket: %w", err)
+		}
+		if bucket.Minute, err = time.Parse(time.RFC3339, minute); err != nil {
+			return nil, fmt.Errorf("failed to parse analytics bucket minute %q: %w", minute, err)
+		}
+		buckets = append(buckets, &bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session analytics: %w", err)
+	}
+	
+	return buckets, nil
+}
+
+// StreamQuestions pairs inbox questions with the responses that followed them
+// TECHNICAL DISCOVERY: inbox_response carries no reference to the question it answers,
+// so rows are ordered by the student each is from or to; a response belongs to the
+// student's latest question before it, and responses before any question are left out
+func (r *reportReader) StreamQuestions(ctx context.Context, sessionID string, fn func(*types.ReportQuestion) error) error {
+	rows, err := r.db.QueryContext(ctx, selectMessages+`
+		WHERE m.session_id = ? AND m.type IN (?, ?)
+		ORDER BY CASE WHEN m.type = ? THEN m.from_user ELSE m.to_user END, m.timestamp ASC, m.rowid ASC
+	`, sessionID, types.MessageTypeInstructorInbox, types.MessageTypeInboxResponse, types.MessageTypeInstructorInbox)
+	if err != nil {
+		return fmt.Errorf("failed to query session questions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	var current *types.ReportQuestion
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return err
+		}
+		if message.Type == types.MessageTypeInstructorInbox {
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
+			current = &types.ReportQuestion{Question: message, Responses: []*types.Message{}}
+			continue
+		}
+		if current != nil && message.ToUser != nil && *message.ToUser == current.Question.FromUser {
+			current.Responses = append(current.Responses, message)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating session questions: %w", err)
+	}
+	if current != nil {
+		return fn(current)
+	}
+	return nil
+}
+
+// StreamStarredMessages streams messages any instructor starred, each once
+func (r *reportReader) StreamStarredMessages(ctx context.Context, sessionID string, fn func(*types.Message) error) error {
+	rows, err := r.db.QueryContext(ctx, selectMessages+`
+		WHERE m.session_id = ? AND EXISTS (
+			SELECT 1 FROM message_annotations a, json_each(a.tags) tag
+			WHERE a.message_id = m.id AND tag.value = 'star'
+		)
+		ORDER BY m.timestamp ASC, m.rowid ASC
+	`, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to query starred messages: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(message); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating starred messages: %w", err)
+	}
+	return nil
+}
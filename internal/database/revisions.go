@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// EditMessage replaces a message's content, keeping the content it replaced as a
+// revision
+// FUNCTIONAL DISCOVERY: The revision row and the new content commit together, so a
+// message never reads as edited without its earlier content on record. New content
+// goes through content_store like a new message; the attachment columns follow it
+func (m *Manager) EditMessage(ctx context.Context, sessionID, messageID, editedBy string, content map[string]interface{}) (*types.Message, error) {
+	contentJSON, err := m.encodeContent(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message content: %w", err)
+	}
+
+	found, reused := false, false
+	// TECHNICAL DISCOVERY: As in startPurge, a missing message is reported after the
+	// write so the writer does not retry it, and the queries are not cancelled with
+	// the request
+	writeCtx := context.Background()
+	err = m.executeMessageWrite(nil, func(db *sql.DB) error {
+		found, reused = false, false
+		tx, err := db.BeginTx(writeCtx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		var revision int
+		var previous string
+		err = tx.QueryRowContext(writeCtx, `
+			SELECT m.revision, COALESCE(cs.body, m.content)
+			FROM messages m
+			LEFT JOIN content_store cs ON cs.hash = m.content_hash
+			WHERE m.id = ? AND m.session_id = ?
+		`, messageID, sessionID).Scan(&revision, &previous)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read message: %w", err)
+		}
+
+		now := m.clock.Now()
+		if _, err := tx.ExecContext(writeCtx, `
+			INSERT INTO message_revisions (message_id, revision, content, edited_by, replaced_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, messageID, revision, previous, editedBy, now); err != nil {
+			return fmt.Errorf("failed to store message revision: %w", err)
+		}
+
+		shared, err := m.shareContent(writeCtx, tx, contentJSON)
+		if err != nil {
+			return err
+		}
+		attachmentMIME, attachmentBytes := attachmentColumns(content)
+		if _, err := tx.ExecContext(writeCtx, `
+			UPDATE messages
+			SET content = ?, content_hash = ?, attachment_mime = ?, attachment_bytes = ?, content_bytes = ?, revision = ?, edited_at = ?
+			WHERE id = ?
+		`, shared.inline, shared.hash, attachmentMIME, attachmentBytes, len(contentJSON), revision+1, now, messageID); err != nil {
+			return fmt.Errorf("failed to update message: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit message edit: %w", err)
+		}
+		found, reused = true, shared.reused
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, interfaces.ErrNotFound
+	}
+	if reused {
+		m.recordDeduplicated(int64(len(contentJSON)))
+	}
+	return m.GetMessage(ctx, sessionID, messageID)
+}
+
+// GetMessageRevisions returns the content a message's edits replaced, oldest first
+func (m *Manager) GetMessageRevisions(ctx context.Context, messageID string) ([]*types.MessageRevision, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT message_id, revision, content, edited_by, replaced_at
+		FROM message_revisions
+		WHERE message_id = ?
+		ORDER BY revision ASC
+	`, messageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query message revisions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var revisions []*types.MessageRevision
+	for rows.Next() {
+		revision := &types.MessageRevision{}
+		var contentJSON string
+		if err := rows.Scan(&revision.MessageID, &revision.Revision, &contentJSON, &revision.EditedBy, &revision.ReplacedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan message revision: %w", err)
+		}
+		if err := json.Unmarshal([]byte(contentJSON), &revision.Content); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal message revision: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating message revisions: %w", err)
+	}
+	return revisions, nil
+}
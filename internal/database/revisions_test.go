@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// TestManager_EditMessage tests functional validation - edits keep every earlier
+// content as a revision, history reads the newest one, and shared bodies are released
+// when an edit moves a message off them
+func TestManager_EditMessage(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	var _ interfaces.MessageEditor = manager
+
+	manager.config.DedupThreshold = 64
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "edit-session",
+		Name:       "Edit Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	long := strings.Repeat("Quiz moved to Friday. ", 5)
+	message := &types.Message{
+		ID:        "announcement",
+		SessionID: "edit-session",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		Content:   map[string]interface{}{"text": long},
+		Timestamp: time.Now(),
+	}
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage should succeed: %v", err)
+	}
+
+	if _, err := manager.EditMessage(ctx, "other-session", "announcement", "instructor1", map[string]interface{}{"text": "x"}); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for another session, got %v", err)
+	}
+
+	edited, err := manager.EditMessage(ctx, "edit-session", "announcement", "instructor1", map[string]interface{}{"text": "Quiz moved to Thursday."})
+	if err != nil {
+		t.Fatalf("EditMessage should succeed: %v", err)
+	}
+	if edited.Revision != 1 || edited.EditedAt == nil || edited.Content["text"] != "Quiz moved to Thursday." {
+		t.Errorf("Expected revision 1 with the new content, got %+v", edited)
+	}
+	var blobs int
+	if err := manager.db.QueryRow("SELECT COUNT(*) FROM content_store").Scan(&blobs); err != nil || blobs != 0 {
+		t.Errorf("Expected the replaced shared body released, got %d: %v", blobs, err)
+	}
+
+	if _, err := manager.EditMessage(ctx, "edit-session", "announcement", "instructor2", map[string]interface{}{"text": "Quiz moved to Thursday at 9."}); err != nil {
+		t.Fatalf("Second EditMessage should succeed: %v", err)
+	}
+	history, err := manager.GetSessionHistory(ctx, "edit-session")
+	if err != nil || len(history) != 1 || history[0].Revision != 2 || history[0].Content["text"] != "Quiz moved to Thursday at 9." {
+		t.Errorf("Expected history to read revision 2, got %+v: %v", history, err)
+	}
+
+	revisions, err := manager.GetMessageRevisions(ctx, "announcement")
+	if err != nil {
+		t.Fatalf("GetMessageRevisions should succeed: %v", err)
+	}
+	if len(revisions) != 2 || revisions[0].Revision != 0 || revisions[0].Content["text"] != long || revisions[0].EditedBy != "instructor1" ||
+		revisions[1].Revision != 1 || revisions[1].EditedBy != "instructor2" {
+		t.Errorf("Expected the original and the first edit kept in order, got %+v", revisions)
+	}
+
+	if _, err := manager.db.Exec("DELETE FROM messages WHERE id = 'announcement'"); err != nil {
+		t.Fatalf("Delete should succeed: %v", err)
+	}
+	if revisions, err := manager.GetMessageRevisions(ctx, "announcement"); err != nil || len(revisions) != 0 {
+		t.Errorf("Expected revisions deleted with the message, got %d: %v", len(revisions), err)
+	}
+}
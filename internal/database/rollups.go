@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// globalRollup is the session_id of a bucket's whole-server row in metrics_rollups
+const globalRollup = ""
+
+// StoreThroughputBuckets writes buckets to metrics_rollups in one transaction
+// ARCHITECTURAL DISCOVERY: One writeOperation per flush, however many buckets, so an
+// hourly flush waits in the write queue once rather than once per minute
+// FUNCTIONAL DISCOVERY: INSERT OR REPLACE makes re-flushing a minute that gained
+// messages since the last flush overwrite the earlier counts
+func (m *Manager) StoreThroughputBuckets(ctx context.Context, buckets []types.ThroughputBucket) error {
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	return m.executeWrite(func(db *sql.DB) error {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT OR REPLACE INTO metrics_rollups (bucket_start, session_id, messages, connections)
+			VALUES (?, ?, ?, ?)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare rollup statement: %w", err)
+		}
+		defer func() { _ = stmt.Close() }()
+
+		for _, bucket := range buckets {
+			start := bucket.Start.Unix()
+			if _, err := stmt.ExecContext(ctx, start, globalRollup, bucket.Messages, bucket.Connections); err != nil {
+				return fmt.Errorf("failed to store rollup: %w", err)
+			}
+			for sessionID, session := range bucket.Sessions {
+				if _, err := stmt.ExecContext(ctx, start, sessionID, session.Messages, session.Connections); err != nil {
+					return fmt.Errorf("failed to store rollup: %w", err)
+				}
+			}
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit rollups: %w", err)
+		}
+		return nil
+	})
+}
+
+// LoadThroughputBuckets returns the stored buckets starting at or after since, oldest first
+func (m *Manager) LoadThroughputBuckets(ctx context.Context, since time.Time) ([]types.ThroughputBucket, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT bucket_start, session_id, messages, connections
+		FROM metrics_rollups
+		WHERE bucket_start >= ?
+		ORDER BY bucket_start
+	`, since.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rollups: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var buckets []types.ThroughputBucket
+	for rows.Next() {
+		var start int64
+		var sessionID string
+		var counts types.SessionThroughput
+		if err := rows.Scan(&start, &sessionID, &counts.Messages, &counts.Connections); err != nil {
+			return nil, fmt.Errorf("failed to scan rollup: %w", err)
+		}
+
+		if len(buckets) == 0 || buckets[len(buckets)-1].Start.Unix() != start {
+			buckets = append(buckets, types.ThroughputBucket{Start: time.Unix(start, 0).UTC()})
+		}
+		bucket := &buckets[len(buckets)-1]
+		if sessionID == globalRollup {
+			bucket.Messages = counts.Messages
+			bucket.Connections = counts.Connections
+			continue
+		}
+		if bucket.Sessions == nil {
+			bucket.Sessions = make(map[string]types.SessionThroughput)
+		}
+		bucket.Sessions[sessionID] = counts
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rollups: %w", err)
+	}
+	return buckets, nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestManager_ThroughputRollups(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	buckets := []types.ThroughputBucket{
+		{Start: start.Add(-2 * time.Hour), Messages: 50},
+		{Start: start, Messages: 3, Connections: 2, Sessions: map[string]types.SessionThroughput{
+			"session-1": {Messages: 2, Connections: 1},
+			"session-2": {Messages: 1, Connections: 1},
+		}},
+	}
+	if err := manager.StoreThroughputBuckets(ctx, buckets); err != nil {
+		t.Fatalf("StoreThroughputBuckets failed: %v", err)
+	}
+
+	// A minute flushed again replaces its earlier counts
+	buckets[1].Messages = 4
+	buckets[1].Sessions["session-1"] = types.SessionThroughput{Messages: 3, Connections: 1}
+	if err := manager.StoreThroughputBuckets(ctx, buckets[1:]); err != nil {
+		t.Fatalf("StoreThroughputBuckets failed on reflush: %v", err)
+	}
+
+	loaded, err := manager.LoadThroughputBuckets(ctx, start.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("LoadThroughputBuckets failed: %v", err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("Expected only buckets since the cutoff, got %+v", loaded)
+	}
+	got := loaded[0]
+	if !got.Start.Equal(start) || got.Messages != 4 || got.Connections != 2 || len(got.Sessions) != 2 {
+		t.Errorf("Expected the reflushed totals, got %+v", got)
+	}
+	if got.Sessions["session-1"] != (types.SessionThroughput{Messages: 3, Connections: 1}) {
+		t.Errorf("Expected session-1's replaced counts, got %+v", got.Sessions["session-1"])
+	}
+
+	if all, err := manager.LoadThroughputBuckets(ctx, time.Time{}); err != nil || len(all) != 2 || all[0].Messages != 50 || all[0].Sessions != nil {
+		t.Errorf("Expected both buckets oldest first, got %+v, %v", all, err)
+	}
+	if err := manager.StoreThroughputBuckets(ctx, nil); err != nil {
+		t.Errorf("Expected an empty flush to be a no-op, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// threadQuery selects a student's thread; the OR keeps each branch on its own index,
+// idx_messages_session_from and idx_messages_session_to
+const threadQuery = selectMessages + `
+		WHERE ((m.session_id = ? AND m.from_user = ?) OR (m.session_id = ? AND m.to_user = ?))
+		AND m.type IN (?, ?, ?, ?)
+`
+
+// GetStudentThread returns up to limit messages of studentID's thread in sessionID,
+// oldest first, starting after the message afterID when it is given
+// FUNCTIONAL DISCOVERY: The thread is the student's questions and request responses
+// and the inbox responses and requests sent to them; broadcasts are not part of it
+// TECHNICAL DISCOVERY: Pages are keyed on (timestamp, rowid), the history order, so a
+// message stored while a client pages is neither skipped nor repeated. An afterID that
+// is not a message of the session returns ErrNotFound
+func (m *Manager) GetStudentThread(ctx context.Context, sessionID, studentID, afterID string, limit int) ([]*types.Message, error) {
+	query := threadQuery
+	args := []interface{}{
+		sessionID, studentID, sessionID, studentID,
+		types.MessageTypeInstructorInbox, types.MessageTypeInboxResponse, types.MessageTypeRequest, types.MessageTypeRequestResponse,
+	}
+	if afterID != "" {
+		var found int
+		err := m.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM messages WHERE id = ? AND session_id = ?`, afterID, sessionID).Scan(&found)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check thread cursor: %w", err)
+		}
+		if found == 0 {
+			return nil, interfaces.ErrNotFound
+		}
+		query += `		AND (m.timestamp, m.rowid) > (SELECT timestamp, rowid FROM messages WHERE id = ?)
+`
+		args = append(args, afterID)
+	}
+	query += `		ORDER BY m.timestamp ASC, m.rowid ASC
+		LIMIT ?
+`
+	args = append(args, limit)
+
+	rows, err := m.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query student thread: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	return scanMessages(rows)
+}
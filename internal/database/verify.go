@@ -0,0 +1,303 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Consistency check names, in the order Verify runs them
+const (
+	CheckIntegrity       = "integrity"
+	CheckForeignKeys     = "foreign_keys"
+	CheckEndedSessions   = "ended_sessions"
+	CheckJSONColumns     = "json_columns"
+	CheckSequenceNumbers = "sequence_numbers"
+	CheckFullTextIndex   = "full_text_index"
+)
+
+// VerifyIssue is one inconsistency found by Verify
+type VerifyIssue struct {
+	Subject    string // Table and row the issue concerns, e.g. "sessions/abc"
+	Detail     string
+	Repairable bool // Repair can fix it without losing data
+}
+
+// VerifyCheck is the outcome of one consistency check
+type VerifyCheck struct {
+	Name    string
+	Skipped string // Why the check does not apply to this schema; empty when it ran
+	Issues  []VerifyIssue
+}
+
+// VerifyReport collects the outcome of every consistency check
+type VerifyReport struct {
+	Checks []*VerifyCheck
+}
+
+// Problems counts the issues across all checks
+func (r *VerifyReport) Problems() int {
+	total := 0
+	for _, check := range r.Checks {
+		total += len(check.Issues)
+	}
+	return total
+}
+
+// Repairable counts the issues Repair can fix
+func (r *VerifyReport) Repairable() int {
+	total := 0
+	for _, check := range r.Checks {
+		for _, issue := range check.Issues {
+			if issue.Repairable {
+				total++
+			}
+		}
+	}
+	return total
+}
+
+// jsonColumn is a TEXT column that must hold JSON of a given shape
+type jsonColumn struct {
+	table, key, column string
+	array              bool   // Array when true, object otherwise
+	where              string // Rows to check; empty checks every row
+}
+
+// jsonColumns lists the JSON columns of the current schema
+// FUNCTIONAL DISCOVERY: Messages whose body is shared hold no inline content; the
+// body is checked once in content_store instead
+var jsonColumns = []jsonColumn{
+	{"sessions", "id", "student_ids", true, ""},
+	{"sessions", "id", "session_config", false, "session_config IS NOT NULL"},
+	{"sessions", "id", "metadata", false, ""},
+	{"sessions", "id", "instructor_ids", true, ""},
+	{"messages", "id", "content", false, "content_hash IS NULL"},
+	{"messages", "id", "to_users", true, "to_users IS NOT NULL"},
+	{"content_store", "hash", "body", false, ""},
+	{"session_events", "id", "details", false, ""},
+	{"message_annotations", "message_id || '/' || instructor_id", "tags", true, ""},
+	{"api_keys", "id", "scopes", true, ""},
+	{"poison_messages", "id", "payload", false, ""},
+	{"maintenance_jobs", "id", "tasks", true, ""},
+	{"instructor_preferences", "session_id || '/' || instructor_id", "filters", false, ""},
+	{"message_revisions", "message_id || '/' || revision", "content", false, ""},
+}
+
+// Verify checks the database for inconsistencies left behind by crashes or manual edits
+// ARCHITECTURAL DISCOVERY: Read-only - safe to run against a live database or one
+// opened with ReadOnly. SQLite's own integrity and foreign key checks cover index/table
+// agreement and every parent reference, including messages to sessions
+// FUNCTIONAL DISCOVERY: Checks that do not apply to this schema are reported as
+// skipped rather than silently passing
+func (m *Manager) Verify(ctx context.Context) (*VerifyReport, error) {
+	report := &VerifyReport{}
+	for _, run := range []func(context.Context) (*VerifyCheck, error){
+		m.verifyIntegrity,
+		m.verifyForeignKeys,
+		m.verifyEndedSessions,
+		m.verifyJSONColumns,
+	} {
+		check, err := run(ctx)
+		if err != nil {
+			return nil, err
+		}
+		report.Checks = append(report.Checks, check)
+	}
+	report.Checks = append(report.Checks,
+		&VerifyCheck{Name: CheckSequenceNumbers, Skipped: "messages have no sequence numbers in this schema"},
+		&VerifyCheck{Name: CheckFullTextIndex, Skipped: "no full-text index in this schema"},
+	)
+	return report, nil
+}
+
+// verifyIntegrity runs SQLite's integrity check over every table and index
+// TECHNICAL DISCOVERY: Index mismatches ("missing from index", "wrong # of entries")
+// are rebuilt by REINDEX; page-level corruption is not repairable here
+func (m *Manager) verifyIntegrity(ctx context.Context) (*VerifyCheck, error) {
+	check := &VerifyCheck{Name: CheckIntegrity}
+	rows, err := m.db.QueryContext(ctx, "PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return nil, fmt.Errorf("failed to scan integrity check: %w", err)
+		}
+		if result == "ok" {
+			continue
+		}
+		check.Issues = append(check.Issues, VerifyIssue{
+			Subject:    "database",
+			Detail:     result,
+			Repairable: strings.Contains(result, "index"),
+		})
+	}
+	return check, rows.Err()
+}
+
+// verifyForeignKeys reports rows whose parent row is missing
+func (m *Manager) verifyForeignKeys(ctx context.Context) (*VerifyCheck, error) {
+	check := &VerifyCheck{Name: CheckForeignKeys}
+	rows, err := m.db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run foreign key check: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var index int
+		if err := rows.Scan(&table, &rowID, &parent, &index); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign key check: %w", err)
+		}
+		check.Issues = append(check.Issues, VerifyIssue{
+			Subject: fmt.Sprintf("%s/rowid %d", table, rowID.Int64),
+			Detail:  fmt.Sprintf("references a missing row in %s", parent),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return check, m.verifySharedContent(ctx, check)
+}
+
+// verifySharedContent reports messages whose shared body is missing
+// TECHNICAL DISCOVERY: content_hash is not a declared foreign key, so PRAGMA
+// foreign_key_check cannot see these; such a message fails to load with its session
+func (m *Manager) verifySharedContent(ctx context.Context, check *VerifyCheck) error {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT m.id, m.content_hash FROM messages m
+		LEFT JOIN content_store cs ON cs.hash = m.content_hash
+		WHERE m.content_hash IS NOT NULL AND cs.hash IS NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to check shared content: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return fmt.Errorf("failed to scan shared content check: %w", err)
+		}
+		check.Issues = append(check.Issues, VerifyIssue{
+			Subject: "messages/" + id,
+			Detail:  fmt.Sprintf("references missing shared content %s", hash),
+		})
+	}
+	return rows.Err()
+}
+
+// verifyEndedSessions reports ended sessions without an end time
+func (m *Manager) verifyEndedSessions(ctx context.Context) (*VerifyCheck, error) {
+	check := &VerifyCheck{Name: CheckEndedSessions}
+	rows, err := m.db.QueryContext(ctx, "SELECT id FROM sessions WHERE status = 'ended' AND end_time IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ended sessions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var sessionID string
+		if err := rows.Scan(&sessionID); err != nil {
+			return nil, fmt.Errorf("failed to scan ended session: %w", err)
+		}
+		check.Issues = append(check.Issues, VerifyIssue{
+			Subject:    "sessions/" + sessionID,
+			Detail:     "ended without end_time",
+			Repairable: true,
+		})
+	}
+	return check, rows.Err()
+}
+
+// verifyJSONColumns reports JSON columns that do not parse or have the wrong shape
+// TECHNICAL DISCOVERY: Parsed in Go with the same decoder the read paths use, so a
+// value that passes here also loads in GetSession and GetSessionHistory
+func (m *Manager) verifyJSONColumns(ctx context.Context) (*VerifyCheck, error) {
+	check := &VerifyCheck{Name: CheckJSONColumns}
+	for _, column := range jsonColumns {
+		query := fmt.Sprintf("SELECT %s, %s FROM %s", column.key, column.column, column.table)
+		if column.where != "" {
+			query += " WHERE " + column.where
+		}
+		rows, err := m.db.QueryContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s.%s: %w", column.table, column.column, err)
+		}
+		for rows.Next() {
+			var key, value string
+			if err := rows.Scan(&key, &value); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("failed to scan %s.%s: %w", column.table, column.column, err)
+			}
+			var target interface{} = &map[string]interface{}{}
+			if column.array {
+				target = &[]interface{}{}
+			}
+			if err := json.Unmarshal([]byte(value), target); err != nil {
+				check.Issues = append(check.Issues, VerifyIssue{
+					Subject: column.table + "/" + key,
+					Detail:  fmt.Sprintf("%s is not valid: %v", column.column, err),
+				})
+			}
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error iterating %s.%s: %w", column.table, column.column, err)
+		}
+	}
+	return check, nil
+}
+
+// Repair applies the safe fixes for the repairable issues in report
+// FUNCTIONAL DISCOVERY: Rebuilds indexes and gives ended sessions an end_time of
+// their last message (or their start when they have none). Nothing is deleted;
+// orphaned rows and unparseable JSON are left for an operator to decide on
+func (m *Manager) Repair(ctx context.Context, report *VerifyReport) (int, error) {
+	repaired := 0
+	reindexed := false
+	for _, check := range report.Checks {
+		for _, issue := range check.Issues {
+			if !issue.Repairable {
+				continue
+			}
+			var err error
+			switch check.Name {
+			case CheckIntegrity:
+				if !reindexed { // One REINDEX rebuilds every index
+					err = m.executeWrite(func(db *sql.DB) error {
+						_, err := db.ExecContext(ctx, "REINDEX")
+						return err
+					})
+					reindexed = true
+				}
+			case CheckEndedSessions:
+				sessionID := strings.TrimPrefix(issue.Subject, "sessions/")
+				err = m.executeWrite(func(db *sql.DB) error {
+					_, err := db.ExecContext(ctx, `
+						UPDATE sessions
+						SET end_time = COALESCE((SELECT MAX(timestamp) FROM messages WHERE session_id = sessions.id), start_time)
+						WHERE id = ? AND end_time IS NULL
+					`, sessionID)
+					return err
+				})
+			default:
+				continue
+			}
+			if err != nil {
+				return repaired, fmt.Errorf("failed to repair %s: %w", issue.Subject, err)
+			}
+			repaired++
+		}
+	}
+	return repaired, nil
+}
@@ -0,0 +1,167 @@
+package database
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	dbconfig "switchboard/pkg/database"
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestVerify_CleanDatabase(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := &types.Session{
+		ID: "session-1", Name: "Class", CreatedBy: "instructor1",
+		StudentIDs: []string{"student1"}, StartTime: time.Now(), Status: "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	report, err := manager.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if report.Problems() != 0 {
+		t.Errorf("Expected no problems, got %+v", report.Checks)
+	}
+	skipped := 0
+	for _, check := range report.Checks {
+		if check.Skipped != "" {
+			skipped++
+		}
+	}
+	if skipped != 2 {
+		t.Errorf("Expected sequence and full-text checks to be skipped, got %d skipped", skipped)
+	}
+}
+
+func TestVerify_FindsAndRepairsProblems(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for _, id := range []string{"ended-1", "ended-quiet", "broken-json"} {
+		session := &types.Session{
+			ID: id, Name: "Class", CreatedBy: "instructor1",
+			StudentIDs: []string{"student1"}, StartTime: start, Status: "active",
+		}
+		if err := manager.CreateSession(ctx, session); err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+	}
+	lastMessage := start.Add(40 * time.Minute)
+	for i, ts := range []time.Time{start.Add(5 * time.Minute), lastMessage} {
+		message := &types.Message{
+			ID: "msg-" + string(rune('a'+i)), SessionID: "ended-1", Type: types.MessageTypeAnalytics,
+			Context: "general", FromUser: "student1", Content: map[string]interface{}{"n": i}, Timestamp: ts,
+		}
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatalf("StoreMessage failed: %v", err)
+		}
+	}
+
+	// Simulate what a crash or a manual edit could leave behind
+	conn, err := manager.db.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, statement := range []string{
+		"PRAGMA foreign_keys = OFF",
+		"UPDATE sessions SET status = 'ended' WHERE id IN ('ended-1', 'ended-quiet')",
+		"UPDATE sessions SET student_ids = '[\"student1\"' WHERE id = 'broken-json'",
+		`INSERT INTO messages (id, session_id, type, context, from_user, content, timestamp)
+		 VALUES ('orphan', 'gone', 'analytics', 'general', 'student1', '{}', CURRENT_TIMESTAMP)`,
+	} {
+		if _, err := conn.ExecContext(ctx, statement); err != nil {
+			t.Fatalf("%s: %v", statement, err)
+		}
+	}
+	_ = conn.Close()
+
+	report, err := manager.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	issues := make(map[string]int)
+	for _, check := range report.Checks {
+		issues[check.Name] = len(check.Issues)
+	}
+	if issues[CheckForeignKeys] != 1 || issues[CheckEndedSessions] != 2 || issues[CheckJSONColumns] != 1 || issues[CheckIntegrity] != 0 {
+		t.Fatalf("Unexpected issues per check: %v", issues)
+	}
+	if report.Repairable() != 2 {
+		t.Errorf("Expected only the ended sessions to be repairable, got %d", report.Repairable())
+	}
+
+	repaired, err := manager.Repair(ctx, report)
+	if err != nil || repaired != 2 {
+		t.Fatalf("Expected 2 repairs, got %d (%v)", repaired, err)
+	}
+	for id, want := range map[string]time.Time{"ended-1": lastMessage, "ended-quiet": start} {
+		session, err := manager.GetSession(ctx, id)
+		if err != nil {
+			t.Fatalf("GetSession %s failed: %v", id, err)
+		}
+		if session.EndTime == nil || !session.EndTime.Equal(want) {
+			t.Errorf("%s: expected end_time %v, got %v", id, want, session.EndTime)
+		}
+	}
+
+	report, err = manager.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify after repair failed: %v", err)
+	}
+	if report.Problems() != 2 || report.Repairable() != 0 {
+		t.Errorf("Expected only the orphan and broken JSON to remain, got %+v", report.Checks)
+	}
+}
+
+func TestVerify_ReadOnly(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+	path := manager.config.DatabasePath
+	_ = manager.Close()
+
+	readOnly, err := NewManager(&dbconfig.Config{
+		DatabasePath:    path,
+		MaxConnections:  1,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: time.Minute,
+		ReadOnly:        true,
+	})
+	if err != nil {
+		t.Fatalf("NewManager read-only failed: %v", err)
+	}
+	defer readOnly.Close()
+
+	ctx := context.Background()
+	if _, err := readOnly.Verify(ctx); err != nil {
+		t.Errorf("Verify should work read-only: %v", err)
+	}
+	session := &types.Session{
+		ID: "session-1", Name: "Class", CreatedBy: "instructor1",
+		StudentIDs: []string{"student1"}, StartTime: time.Now(), Status: "active",
+	}
+	if err := readOnly.CreateSession(ctx, session); err == nil {
+		t.Error("Expected writes to fail on a read-only manager")
+	}
+
+	_, err = NewManager(&dbconfig.Config{
+		DatabasePath:    filepath.Join(t.TempDir(), "missing.db"),
+		MaxConnections:  1,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: time.Minute,
+		ReadOnly:        true,
+	})
+	if err == nil {
+		t.Error("Expected a read-only open of a missing file to fail")
+	}
+}
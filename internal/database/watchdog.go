@@ -0,0 +1,274 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// WatchdogConfig sets the thresholds for the database health watchdog
+// FUNCTIONAL DISCOVERY: Any condition - low free disk, a failing write rate or
+// repeated poison messages - flips the manager into degraded mode; all must clear
+// before it recovers
+type WatchdogConfig struct {
+	CheckInterval       time.Duration
+	MinFreeBytes        uint64  // Degrade when the database volume has less free space
+	MinWriteSuccessRate float64 // Degrade when the success rate over one interval drops below this
+	RouteOnly           bool    // Skip message persistence while degraded so routing continues
+}
+
+// minWriteSamples is the number of writes per interval needed to judge the success rate
+// TECHNICAL DISCOVERY: A single failure in a quiet classroom would otherwise read as 0%
+const minWriteSamples = 5
+
+// watchdogState tracks degraded mode and the counters reported in DatabaseHealth
+type watchdogState struct {
+	config    WatchdogConfig
+	degraded  bool
+	reason    string
+	since     time.Time
+	freeBytes uint64
+	rate      float64
+
+	lastAttempts int64
+	lastFailures int64
+	lastPoisons  int64
+
+	degradedCount   int64
+	recoveredCount  int64
+	skippedWrites   int64
+	persistFailures int64
+	poisonMessages  int64
+
+	listeners        []func(types.DatabaseHealth)
+	persistListeners []func(*types.Message, error)
+	mu               sync.Mutex
+}
+
+// StartWatchdog begins periodic disk-space and write-health checks
+// ARCHITECTURAL DISCOVERY: Runs inside the manager next to the write loop so it sees
+// every write outcome; it stops with Close like the write loop
+func (m *Manager) StartWatchdog(config WatchdogConfig) {
+	m.watchdog.mu.Lock()
+	m.watchdog.config = config
+	m.watchdog.mu.Unlock()
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		ticker := m.clock.NewTicker(config.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				m.checkHealth()
+			case <-m.shutdown:
+				return
+			}
+		}
+	}()
+}
+
+// OnHealthChange registers fn to run on each transition into or out of degraded mode
+// TECHNICAL DISCOVERY: Listeners run on the watchdog goroutine; register before StartWatchdog
+func (m *Manager) OnHealthChange(fn func(types.DatabaseHealth)) {
+	m.watchdog.mu.Lock()
+	defer m.watchdog.mu.Unlock()
+	m.watchdog.listeners = append(m.watchdog.listeners, fn)
+}
+
+// HealthStatus returns the watchdog's current view of persistence health
+// FUNCTIONAL DISCOVERY: Recovery from an unclean shutdown is reported once, in the
+// first report after start, so it reads as an event rather than a standing condition
+func (m *Manager) HealthStatus() types.DatabaseHealth {
+	m.watchdog.mu.Lock()
+	health := m.watchdog.snapshotLocked()
+	m.watchdog.mu.Unlock()
+
+	health.ContentBytesOriginal = m.contentStats.originalBytes.Load()
+	health.ContentBytesStored = m.contentStats.storedBytes.Load()
+	health.ContentBytesDeduplicated = m.contentStats.dedupedBytes.Load()
+	health.LastMaintenance = m.lastMaintenance()
+	health.RecoveredFromUncleanShutdown = m.uncleanShutdown.Swap(false)
+	return health
+}
+
+// routeOnly reports whether message writes should be skipped right now
+func (m *Manager) routeOnly() bool {
+	m.watchdog.mu.Lock()
+	defer m.watchdog.mu.Unlock()
+	return m.watchdog.degraded && m.watchdog.config.RouteOnly
+}
+
+// recordSkippedWrite counts a message routed without persistence
+// FUNCTIONAL DISCOVERY: Counted so lost history is visible in /health
+func (m *Manager) recordSkippedWrite() {
+	m.watchdog.mu.Lock()
+	defer m.watchdog.mu.Unlock()
+	m.watchdog.skippedWrites++
+}
+
+// OnPersistFailure registers fn to run when a message is not persisted
+// ARCHITECTURAL DISCOVERY: Called from the write completion path in StoreMessage, so
+// the hub can tell the sender which message is missing from the record
+// TECHNICAL DISCOVERY: Listeners run on the caller's goroutine; register before serving
+func (m *Manager) OnPersistFailure(fn func(message *types.Message, err error)) {
+	m.watchdog.mu.Lock()
+	defer m.watchdog.mu.Unlock()
+	m.watchdog.persistListeners = append(m.watchdog.persistListeners, fn)
+}
+
+// persistFailed counts, audits and reports a message missing from the record
+// FUNCTIONAL DISCOVERY: The AUDIT line carries everything needed to reconcile a
+// transcript against history later; err is interfaces.ErrPersistSkipped in route-only mode
+func (m *Manager) persistFailed(message *types.Message, err error) {
+	m.watchdog.mu.Lock()
+	if !errors.Is(err, interfaces.ErrPersistSkipped) {
+		m.watchdog.persistFailures++
+	}
+	listeners := m.watchdog.persistListeners
+	m.watchdog.mu.Unlock()
+
+	log.Printf("AUDIT: persist_failed message_id=%s session=%s from=%s type=%s timestamp=%s: %v",
+		message.ID, message.SessionID, message.FromUser, message.Type,
+		message.Timestamp.Format(time.RFC3339Nano), err)
+
+	for _, listener := range listeners {
+		listener(message, err)
+	}
+}
+
+// isDegraded reports whether the watchdog has flagged persistence as unhealthy
+func (m *Manager) isDegraded() bool {
+	m.watchdog.mu.Lock()
+	defer m.watchdog.mu.Unlock()
+	return m.watchdog.degraded
+}
+
+// recordWrite tallies one write outcome for the success-rate check
+func (m *Manager) recordWrite(err error) {
+	m.writeAttempts.Add(1)
+	if err != nil {
+		m.writeFailures.Add(1)
+	}
+}
+
+// checkHealth evaluates both conditions and applies any state transition
+// FUNCTIONAL DISCOVERY: While degraded with too few writes to judge (route-only
+// mode stops message writes), a probe write decides whether writes work again
+func (m *Manager) checkHealth() {
+	m.watchdog.mu.Lock()
+	config := m.watchdog.config
+	degraded := m.watchdog.degraded
+	m.watchdog.mu.Unlock()
+
+	var reasons []string
+
+	freeBytes, diskErr := m.diskFree(filepath.Dir(m.config.DatabasePath))
+	if diskErr == nil && freeBytes < config.MinFreeBytes {
+		reasons = append(reasons, fmt.Sprintf("low disk space: %d bytes free", freeBytes))
+	}
+
+	attempts := m.writeAttempts.Load()
+	failures := m.writeFailures.Load()
+
+	m.watchdog.mu.Lock()
+	windowAttempts := attempts - m.watchdog.lastAttempts
+	windowFailures := failures - m.watchdog.lastFailures
+	m.watchdog.lastAttempts = attempts
+	m.watchdog.lastFailures = failures
+	windowPoisons := m.watchdog.poisonMessages - m.watchdog.lastPoisons
+	m.watchdog.lastPoisons = m.watchdog.poisonMessages
+	m.watchdog.mu.Unlock()
+
+	rate := 1.0
+	switch {
+	case windowAttempts >= minWriteSamples:
+		rate = float64(windowAttempts-windowFailures) / float64(windowAttempts)
+	case degraded:
+		if err := m.probeWrite(); err != nil {
+			rate = 0
+		}
+	}
+	if rate < config.MinWriteSuccessRate {
+		reasons = append(reasons, fmt.Sprintf("write success rate %.0f%%", rate*100))
+	}
+	if windowPoisons >= poisonDegradeCount {
+		reasons = append(reasons, fmt.Sprintf("%d poison messages quarantined", windowPoisons))
+	}
+
+	m.watchdog.mu.Lock()
+	m.watchdog.freeBytes = freeBytes
+	m.watchdog.rate = rate
+
+	nowDegraded := len(reasons) > 0
+	if nowDegraded == m.watchdog.degraded {
+		if nowDegraded {
+			m.watchdog.reason = strings.Join(reasons, "; ")
+		}
+		m.watchdog.mu.Unlock()
+		return
+	}
+
+	m.watchdog.degraded = nowDegraded
+	m.watchdog.since = m.clock.Now()
+	if nowDegraded {
+		m.watchdog.reason = strings.Join(reasons, "; ")
+		m.watchdog.degradedCount++
+		log.Printf("WARNING: Database degraded (%s); route-only=%v", m.watchdog.reason, config.RouteOnly)
+	} else {
+		m.watchdog.reason = ""
+		m.watchdog.recoveredCount++
+		log.Printf("Database recovered after degraded mode; %d writes were skipped", m.watchdog.skippedWrites)
+	}
+	health := m.watchdog.snapshotLocked()
+	listeners := m.watchdog.listeners
+	m.watchdog.mu.Unlock()
+
+	for _, notify := range listeners {
+		notify(health)
+	}
+}
+
+// probeWrite performs a minimal real write through the single writer
+// TECHNICAL DISCOVERY: Rewriting user_version touches the database header without
+// changing any data, so it fails exactly when ordinary writes would
+func (m *Manager) probeWrite() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return m.executeWrite(func(db *sql.DB) error {
+		var version int
+		if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+			return err
+		}
+		_, err := db.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", version))
+		return err
+	})
+}
+
+func (w *watchdogState) snapshotLocked() types.DatabaseHealth {
+	return types.DatabaseHealth{
+		Degraded:         w.degraded,
+		Reason:           w.reason,
+		Since:            w.since,
+		RouteOnly:        w.degraded && w.config.RouteOnly,
+		FreeBytes:        w.freeBytes,
+		WriteSuccessRate: w.rate,
+		DegradedCount:    w.degradedCount,
+		RecoveredCount:   w.recoveredCount,
+		SkippedWrites:    w.skippedWrites,
+		PersistFailures:  w.persistFailures,
+		PoisonMessages:   w.poisonMessages,
+	}
+}
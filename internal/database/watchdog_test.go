@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// watchdogTestSetup returns a manager with a controllable free-space reading and a
+// recorder for health transitions
+func watchdogTestSetup(t *testing.T, routeOnly bool) (*Manager, *uint64, func() []types.DatabaseHealth, func()) {
+	manager, cleanup := setupTestDB(t)
+
+	free := uint64(10 << 30)
+	manager.diskFree = func(dir string) (uint64, error) {
+		return free, nil
+	}
+	manager.watchdog.config = WatchdogConfig{
+		CheckInterval:       time.Hour, // Checks are driven manually
+		MinFreeBytes:        1 << 30,
+		MinWriteSuccessRate: 0.9,
+		RouteOnly:           routeOnly,
+	}
+
+	var transitions []types.DatabaseHealth
+	manager.OnHealthChange(func(health types.DatabaseHealth) {
+		transitions = append(transitions, health)
+	})
+
+	return manager, &free, func() []types.DatabaseHealth { return transitions }, cleanup
+}
+
+// Functional Validation Tests
+func TestWatchdog_LowDiskDegradesAndRecovers(t *testing.T) {
+	manager, free, transitions, cleanup := watchdogTestSetup(t, true)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := &types.Session{
+		ID: "session-1", Name: "Class", CreatedBy: "instructor1",
+		StudentIDs: []string{"student1"}, StartTime: time.Now(), Status: "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	manager.checkHealth()
+	if manager.HealthStatus().Degraded || len(transitions()) != 0 {
+		t.Fatal("Expected healthy state with ample disk space")
+	}
+
+	*free = 100 << 20
+	manager.checkHealth()
+
+	health := manager.HealthStatus()
+	if !health.Degraded || !health.RouteOnly || health.DegradedCount != 1 {
+		t.Fatalf("Expected degraded route-only state, got %+v", health)
+	}
+	if len(transitions()) != 1 || !transitions()[0].Degraded {
+		t.Fatalf("Expected one degraded notification, got %+v", transitions())
+	}
+
+	// Route-only: the message is accepted without being written
+	message := &types.Message{
+		ID: "msg-1", SessionID: "session-1", Type: types.MessageTypeInstructorInbox,
+		Context: "general", FromUser: "student1",
+		Content: map[string]interface{}{"text": "hello"}, Timestamp: time.Now(),
+	}
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage should succeed in route-only mode: %v", err)
+	}
+	history, err := manager.GetSessionHistory(ctx, "session-1")
+	if err != nil {
+		t.Fatalf("GetSessionHistory failed: %v", err)
+	}
+	if len(history) != 0 {
+		t.Errorf("Expected message not persisted in route-only mode, found %d", len(history))
+	}
+	if manager.HealthStatus().SkippedWrites != 1 {
+		t.Errorf("Expected 1 skipped write, got %d", manager.HealthStatus().SkippedWrites)
+	}
+
+	*free = 10 << 30
+	manager.checkHealth()
+
+	health = manager.HealthStatus()
+	if health.Degraded || health.RecoveredCount != 1 || health.Reason != "" {
+		t.Fatalf("Expected automatic recovery, got %+v", health)
+	}
+	if len(transitions()) != 2 || transitions()[1].Degraded {
+		t.Errorf("Expected recovery notification, got %+v", transitions())
+	}
+
+	message.ID = "msg-2"
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage failed after recovery: %v", err)
+	}
+	history, _ = manager.GetSessionHistory(ctx, "session-1")
+	if len(history) != 1 {
+		t.Errorf("Expected persistence to resume after recovery, found %d messages", len(history))
+	}
+}
+
+func TestWatchdog_WriteFailureRate(t *testing.T) {
+	manager, _, transitions, cleanup := watchdogTestSetup(t, false)
+	defer cleanup()
+
+	// Too few samples to judge - one failure in a quiet period is not an outage
+	manager.recordWrite(errors.New("disk I/O error"))
+	manager.checkHealth()
+	if manager.HealthStatus().Degraded {
+		t.Fatal("Single failure below sample threshold should not degrade")
+	}
+
+	for i := 0; i < 8; i++ {
+		manager.recordWrite(errors.New("disk I/O error"))
+	}
+	manager.recordWrite(nil)
+	manager.recordWrite(nil)
+	manager.checkHealth()
+
+	health := manager.HealthStatus()
+	if !health.Degraded || health.RouteOnly {
+		t.Fatalf("Expected degraded (not route-only) after 80%% failures, got %+v", health)
+	}
+	if health.WriteSuccessRate != 0.2 {
+		t.Errorf("Expected success rate 0.2, got %v", health.WriteSuccessRate)
+	}
+
+	// No traffic while degraded: the probe write decides, and it succeeds
+	manager.checkHealth()
+	if manager.HealthStatus().Degraded {
+		t.Errorf("Expected recovery after successful probe write, got %+v", manager.HealthStatus())
+	}
+	if len(transitions()) != 2 {
+		t.Errorf("Expected degrade and recover transitions, got %d", len(transitions()))
+	}
+}
+
+func TestWatchdog_StopsWithClose(t *testing.T) {
+	manager, _, _, cleanup := watchdogTestSetup(t, false)
+	defer cleanup()
+
+	manager.StartWatchdog(WatchdogConfig{
+		CheckInterval:       10 * time.Millisecond,
+		MinFreeBytes:        1,
+		MinWriteSuccessRate: 0.9,
+	})
+	time.Sleep(30 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		manager.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not stop the watchdog goroutine")
+	}
+}
+
+func TestWatchdog_PersistFailureReported(t *testing.T) {
+	manager, free, _, cleanup := watchdogTestSetup(t, true)
+	defer cleanup()
+
+	var reported []error
+	manager.OnPersistFailure(func(message *types.Message, err error) {
+		if message.ID != "msg-1" {
+			t.Errorf("Expected failure for msg-1, got %s", message.ID)
+		}
+		reported = append(reported, err)
+	})
+
+	ctx := context.Background()
+	message := &types.Message{
+		ID: "msg-1", SessionID: "missing-session", Type: types.MessageTypeInstructorInbox,
+		Context: "general", FromUser: "student1",
+		Content: map[string]interface{}{"text": "hello"}, Timestamp: time.Now(),
+	}
+
+	// Route-only skip is reported but not counted as a failed write
+	*free = 100 << 20
+	manager.checkHealth()
+	if err := manager.StoreMessage(ctx, message); err != nil {
+		t.Fatalf("StoreMessage should succeed in route-only mode: %v", err)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], interfaces.ErrPersistSkipped) {
+		t.Fatalf("Expected ErrPersistSkipped report, got %v", reported)
+	}
+
+	// Degraded without route-only fails fast; the failed write is reported and counted
+	manager.watchdog.mu.Lock()
+	manager.watchdog.config.RouteOnly = false
+	manager.watchdog.mu.Unlock()
+	manager.GetDB().Exec("DROP TABLE messages")
+	if err := manager.StoreMessage(ctx, message); err == nil {
+		t.Fatal("StoreMessage should fail without a messages table")
+	}
+	if len(reported) != 2 || errors.Is(reported[1], interfaces.ErrPersistSkipped) {
+		t.Fatalf("Expected a write failure report, got %v", reported)
+	}
+	if health := manager.HealthStatus(); health.PersistFailures != 1 || health.SkippedWrites != 1 {
+		t.Errorf("Expected 1 persist failure and 1 skipped write, got %+v", health)
+	}
+}
@@ -0,0 +1,225 @@
+package database
+
+import (
+	"math/bits"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// writeKind classifies a queued write for latency stats
+type writeKind int
+
+const (
+	writeKindOther   writeKind = iota // Events, keys, reactions, maintenance and the rest
+	writeKindSession                  // Rows of the sessions table
+	writeKindMessage                  // Stored and imported messages
+	writeKindCount
+)
+
+// writeKindNames are the kinds as reported in DatabaseWriteStats and metrics labels
+var writeKindNames = [writeKindCount]string{"other", "session", "message"}
+
+// Write latency histogram layout, in microseconds
+// TECHNICAL DISCOVERY: Log-linear like HDR histograms - values below 16µs get a bucket
+// each, then every power of two is split into 8 linear buckets, so a percentile is
+// within 12.5% of the true value from microseconds up to the last bucket (~19 hours)
+const (
+	latencyExactBuckets = 16
+	latencySubBuckets   = 8
+	latencyMaxShift     = 32
+	latencyBucketCount  = latencyExactBuckets + latencyMaxShift*latencySubBuckets
+)
+
+// writeHistogram records write durations without locks or allocation
+// ARCHITECTURAL DISCOVERY: Observed by each writer's caller after its result arrives,
+// so many goroutines record at once; every field is atomic and the buckets are a fixed
+// array, which keeps recording off the <50ms path it measures
+type writeHistogram struct {
+	buckets [latencyBucketCount]atomic.Uint64
+	count   atomic.Uint64
+	sumUs   atomic.Uint64
+	maxUs   atomic.Uint64
+}
+
+// latencyBucket returns the bucket holding a duration of us microseconds
+func latencyBucket(us uint64) int {
+	if us < latencyExactBuckets {
+		return int(us)
+	}
+	shift := bits.Len64(us) - 4 // us>>shift is in [8, 16)
+	if shift > latencyMaxShift {
+		return latencyBucketCount - 1
+	}
+	return latencyExactBuckets + (shift-1)*latencySubBuckets + int(us>>shift) - latencySubBuckets
+}
+
+// latencyBucketUpper returns the largest duration, in microseconds, bucket i holds
+func latencyBucketUpper(i int) uint64 {
+	if i < latencyExactBuckets {
+		return uint64(i)
+	}
+	shift := (i-latencyExactBuckets)/latencySubBuckets + 1
+	sub := uint64((i-latencyExactBuckets)%latencySubBuckets + latencySubBuckets)
+	return (sub+1)<<shift - 1
+}
+
+func (h *writeHistogram) observe(duration time.Duration) {
+	us := uint64(0)
+	if duration > 0 {
+		us = uint64(duration / time.Microsecond)
+	}
+	h.buckets[latencyBucket(us)].Add(1)
+	h.count.Add(1)
+	h.sumUs.Add(us)
+	for {
+		current := h.maxUs.Load()
+		if us <= current || h.maxUs.CompareAndSwap(current, us) {
+			return
+		}
+	}
+}
+
+// snapshot summarizes the histogram
+// TECHNICAL DISCOVERY: Buckets are read one by one while writes continue, so the count
+// used for ranks is the buckets' own total rather than count, which may have moved on
+func (h *writeHistogram) snapshot() types.WriteLatencyStats {
+	var counts [latencyBucketCount]uint64
+	var total uint64
+	for i := range h.buckets {
+		counts[i] = h.buckets[i].Load()
+		total += counts[i]
+	}
+	if total == 0 {
+		return types.WriteLatencyStats{}
+	}
+	maxUs := h.maxUs.Load()
+	return types.WriteLatencyStats{
+		Count: total,
+		SumMs: microsecondsToMs(h.sumUs.Load()),
+		P50Ms: latencyPercentile(&counts, total, maxUs, 0.50),
+		P95Ms: latencyPercentile(&counts, total, maxUs, 0.95),
+		P99Ms: latencyPercentile(&counts, total, maxUs, 0.99),
+		MaxMs: microsecondsToMs(maxUs),
+	}
+}
+
+// latencyPercentile returns quantile q of total writes spread over counts, in
+// milliseconds; no value is reported above maxUs
+func latencyPercentile(counts *[latencyBucketCount]uint64, total, maxUs uint64, q float64) float64 {
+	rank := uint64(q*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, count := range counts {
+		seen += count
+		if seen >= rank {
+			return microsecondsToMs(min(latencyBucketUpper(i), maxUs))
+		}
+	}
+	return microsecondsToMs(maxUs)
+}
+
+func microsecondsToMs(us uint64) float64 {
+	return float64(us) / 1000
+}
+
+// writeStats holds the write latency histograms and the queue's high-water mark
+type writeStats struct {
+	latency       [writeKindCount]writeHistogram
+	maxQueueDepth atomic.Int64
+}
+
+// observeDepth raises the high-water mark to depth
+func (s *writeStats) observeDepth(depth int) {
+	for {
+		current := s.maxQueueDepth.Load()
+		if int64(depth) <= current || s.maxQueueDepth.CompareAndSwap(current, int64(depth)) {
+			return
+		}
+	}
+}
+
+// WriteStats reports write queue depth, enqueue-to-complete write latency per kind and
+// the stored content sizes of messages per type
+// FUNCTIONAL DISCOVERY: Latency runs from queueing a write until its result returns,
+// so it includes time waiting behind other writes and the writer's one retry; writes
+// that time out before being queued are not counted. Totals are since start
+func (m *Manager) WriteStats() types.DatabaseWriteStats {
+	depth, capacity := m.WriteQueueDepth()
+	stats := types.DatabaseWriteStats{
+		QueueDepth:    depth,
+		QueueCapacity: capacity,
+		MaxQueueDepth: int(m.writeStats.maxQueueDepth.Load()),
+		Latency:       make(map[string]types.WriteLatencyStats, writeKindCount),
+		MessageSizes:  m.messageSizes.snapshot(),
+	}
+	for kind := range m.writeStats.latency {
+		stats.Latency[writeKindNames[kind]] = m.writeStats.latency[kind].snapshot()
+	}
+	return stats
+}
+
+// WriteLatencyWindow returns a function reporting the latency of the writes of every
+// kind completed since its previous call, or since WriteLatencyWindow for the first
+// FUNCTIONAL DISCOVERY: Unlike WriteStats, which is since start, a slow minute shows
+// up at once after hours of fast writes; SumMs is not tracked per window and stays 0,
+// and MaxMs is the upper bound of the slowest bucket written to
+// ARCHITECTURAL DISCOVERY: Each window keeps its own copy of the bucket counts, so the
+// alert evaluator reading one disturbs neither WriteStats nor another window
+func (m *Manager) WriteLatencyWindow() func() types.WriteLatencyStats {
+	var mu sync.Mutex
+	var last [latencyBucketCount]uint64
+	m.writeLatencyCounts(&last)
+
+	return func() types.WriteLatencyStats {
+		mu.Lock()
+		defer mu.Unlock()
+		var current, delta [latencyBucketCount]uint64
+		m.writeLatencyCounts(&current)
+		var total uint64
+		top := -1
+		for i := range current {
+			delta[i] = current[i] - last[i]
+			total += delta[i]
+			if delta[i] > 0 {
+				top = i
+			}
+		}
+		last = current
+		if total == 0 {
+			return types.WriteLatencyStats{}
+		}
+
+		var maxUs uint64
+		for kind := range m.writeStats.latency {
+			maxUs = max(maxUs, m.writeStats.latency[kind].maxUs.Load())
+		}
+		maxUs = min(latencyBucketUpper(top), maxUs)
+		return types.WriteLatencyStats{
+			Count: total,
+			P50Ms: latencyPercentile(&delta, total, maxUs, 0.50),
+			P95Ms: latencyPercentile(&delta, total, maxUs, 0.95),
+			P99Ms: latencyPercentile(&delta, total, maxUs, 0.99),
+			MaxMs: microsecondsToMs(maxUs),
+		}
+	}
+}
+
+// writeLatencyCounts fills counts with each bucket summed over write kinds
+func (m *Manager) writeLatencyCounts(counts *[latencyBucketCount]uint64) {
+	for kind := range m.writeStats.latency {
+		for i := range m.writeStats.latency[kind].buckets {
+			counts[i] += m.writeStats.latency[kind].buckets[i].Load()
+		}
+	}
+}
+
+// WriteOutcomes returns the writes attempted and failed since start
+// TECHNICAL DISCOVERY: The same counters the watchdog judges its success rate by
+func (m *Manager) WriteOutcomes() (attempts, failures int64) {
+	return m.writeAttempts.Load(), m.writeFailures.Load()
+}
@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// TestWriteHistogram_Buckets tests technical validation - buckets are contiguous and each
+// holds values within 12.5% of its upper bound
+func TestWriteHistogram_Buckets(t *testing.T) {
+	previous := -1
+	for us := uint64(0); us < 1<<20; us++ {
+		bucket := latencyBucket(us)
+		if bucket != previous && bucket != previous+1 {
+			t.Fatalf("Bucket of %dµs jumped from %d to %d", us, previous, bucket)
+		}
+		previous = bucket
+		upper := latencyBucketUpper(bucket)
+		if upper < us || float64(upper-us) > float64(us)/8+1 {
+			t.Fatalf("Bucket %d for %dµs has upper bound %d", bucket, us, upper)
+		}
+	}
+	if got := latencyBucket(1 << 62); got != latencyBucketCount-1 {
+		t.Errorf("Expected huge durations in the last bucket, got %d", got)
+	}
+}
+
+// TestWriteHistogram_Percentiles tests functional validation - percentiles track the
+// samples, max is exact, and recording does not allocate
+func TestWriteHistogram_Percentiles(t *testing.T) {
+	var histogram writeHistogram
+	if stats := histogram.snapshot(); stats != (types.WriteLatencyStats{}) {
+		t.Errorf("Expected empty stats without samples, got %+v", stats)
+	}
+
+	for ms := 1; ms <= 1000; ms++ {
+		histogram.observe(time.Duration(ms) * time.Millisecond)
+	}
+	stats := histogram.snapshot()
+	if stats.Count != 1000 || stats.MaxMs != 1000 || stats.SumMs != 500500 {
+		t.Errorf("Unexpected totals: %+v", stats)
+	}
+	for _, check := range []struct {
+		name      string
+		got, want float64
+	}{{"p50", stats.P50Ms, 500}, {"p95", stats.P95Ms, 950}, {"p99", stats.P99Ms, 990}} {
+		if check.got < check.want || check.got > check.want*1.125 {
+			t.Errorf("Expected %s near %vms, got %vms", check.name, check.want, check.got)
+		}
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { histogram.observe(3 * time.Millisecond) }); allocs != 0 {
+		t.Errorf("Expected observe not to allocate, got %v allocations", allocs)
+	}
+}
+
+// TestManager_WriteStats tests integration validation - concurrent writes are counted
+// under their kind and the queue high-water mark only rises
+func TestManager_WriteStats(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	session := &types.Session{
+		ID:         "stats-session",
+		Name:       "Stats Test",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	if err := manager.CreateSession(ctx, session); err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			message := &types.Message{
+				ID:        "stats-msg-" + string(rune('a'+i)),
+				SessionID: "stats-session",
+				Type:      types.MessageTypeInstructorInbox,
+				FromUser:  "student1",
+				Content:   map[string]interface{}{"text": "hello"},
+				Timestamp: time.Now(),
+			}
+			if err := manager.StoreMessage(ctx, message); err != nil {
+				t.Errorf("StoreMessage should succeed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	stats := manager.WriteStats()
+	if stats.QueueCapacity != 100 {
+		t.Errorf("Expected the queue capacity, got %d", stats.QueueCapacity)
+	}
+	if got := stats.Latency["message"].Count; got != 20 {
+		t.Errorf("Expected 20 message writes, got %d", got)
+	}
+	if got := stats.Latency["session"].Count; got != 1 {
+		t.Errorf("Expected 1 session write, got %d", got)
+	}
+	if stats.Latency["message"].MaxMs <= 0 || stats.Latency["message"].P50Ms > stats.Latency["message"].MaxMs {
+		t.Errorf("Unexpected message latency: %+v", stats.Latency["message"])
+	}
+
+	manager.writeStats.observeDepth(7)
+	manager.writeStats.observeDepth(3)
+	if got := manager.WriteStats().MaxQueueDepth; got < 7 {
+		t.Errorf("Expected the high-water mark kept at 7 or more, got %d", got)
+	}
+}
+
+// TestManager_WriteLatencyWindow tests functional validation - a window reports only the
+// writes since its previous read, across kinds, and leaves the totals alone
+func TestManager_WriteLatencyWindow(t *testing.T) {
+	manager, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	for i := 0; i < 50; i++ {
+		manager.writeStats.latency[writeKindMessage].observe(2 * time.Millisecond)
+	}
+	window := manager.WriteLatencyWindow()
+	if stats := window(); stats != (types.WriteLatencyStats{}) {
+		t.Errorf("Expected earlier writes outside the window, got %+v", stats)
+	}
+
+	for i := 0; i < 90; i++ {
+		manager.writeStats.latency[writeKindMessage].observe(2 * time.Millisecond)
+	}
+	for i := 0; i < 10; i++ {
+		manager.writeStats.latency[writeKindSession].observe(400 * time.Millisecond)
+	}
+	stats := window()
+	if stats.Count != 100 {
+		t.Errorf("Expected 100 writes in the window, got %d", stats.Count)
+	}
+	if stats.P50Ms < 2 || stats.P50Ms > 2.25 {
+		t.Errorf("Expected p50 near 2ms, got %vms", stats.P50Ms)
+	}
+	if stats.P95Ms < 400 || stats.P95Ms > 450 || stats.MaxMs != 400 {
+		t.Errorf("Expected the slow session writes at p95 and max, got %+v", stats)
+	}
+	if stats := window(); stats.Count != 0 {
+		t.Errorf("Expected an empty window after reading, got %+v", stats)
+	}
+	if got := manager.WriteStats().Latency["message"].Count; got < 140 {
+		t.Errorf("Expected the totals to keep every write, got %d message writes", got)
+	}
+}
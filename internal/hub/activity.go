@@ -0,0 +1,26 @@
+package hub
+
+import "switchboard/internal/websocket"
+
+// ActivityRecorder is told that a session routed a message or gained or lost a connection
+type ActivityRecorder func(sessionID string)
+
+// SetActivityRecorder sets what is told of session activity
+// ARCHITECTURAL DISCOVERY: The session manager's TouchSession, which only updates
+// memory, so it is called inline on the message path
+// TECHNICAL DISCOVERY: Must be called before Start; read without locking
+func (h *Hub) SetActivityRecorder(record ActivityRecorder) {
+	h.activity = record
+}
+
+// recordActivity tells the activity recorder, if any, that a session was active
+func (h *Hub) recordActivity(sessionID string) {
+	if h.activity != nil {
+		h.activity(sessionID)
+	}
+}
+
+// activityLeft counts a disconnect as activity in the connection's session
+func (h *Hub) activityLeft(conn *websocket.Connection) {
+	h.recordActivity(conn.GetSessionID())
+}
@@ -0,0 +1,43 @@
+package hub
+
+import (
+	"log"
+
+	"switchboard/pkg/types"
+)
+
+// AlertInstructors sends an alert to every connected instructor as a system message
+// with context system_alert, and returns how many it was written to
+// ARCHITECTURAL DISCOVERY: Written straight to the connections like session_ended
+// rather than routed, so an alert about slow writes is neither persisted nor queued
+// behind the writes it is about
+// FUNCTIONAL DISCOVERY: The self-test's synthetic instructor is skipped; students
+// never see alerts
+func (h *Hub) AlertInstructors(alert types.Alert) int {
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": types.ContextSystemAlert,
+		"content": map[string]interface{}{
+			"event":     "alert_" + alert.Status,
+			"rule":      alert.Rule,
+			"metric":    alert.Metric,
+			"value":     alert.Value,
+			"threshold": alert.Threshold,
+			"since":     alert.Since,
+		},
+		"timestamp": alert.At,
+	}
+
+	sent := 0
+	for _, conn := range h.registry.GetAllConnections() {
+		if conn.GetRole() != "instructor" || conn.GetSessionID() == types.SelfTestSessionID {
+			continue
+		}
+		if err := conn.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send alert %s to %s: %v", alert.Rule, conn.GetUserID(), err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
@@ -0,0 +1,49 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestHub_AlertInstructors tests functional validation - every instructor in every
+// session hears about an alert; students and the self-test instructor do not
+func TestHub_AlertInstructors(t *testing.T) {
+	registry := websocket.NewRegistry()
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
+	instructor1 := connectAs(t, registry, "instructor1", "instructor", "session1")
+	instructor2 := connectAs(t, registry, "instructor2", "instructor", "session2")
+	student := connectAs(t, registry, "student1", "student", "session1")
+	probe := connectAs(t, registry, "sys:selftest-instructor", "instructor", types.SelfTestSessionID)
+
+	at := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	alert := types.Alert{Rule: "write_latency_p95", Metric: types.AlertMetricWriteLatencyP95, Status: types.AlertFiring, Value: 350, Threshold: 200, Since: at.Add(-time.Minute), At: at}
+	if sent := hub.AlertInstructors(alert); sent != 2 {
+		t.Errorf("Expected the alert sent to 2 instructors, got %d", sent)
+	}
+
+	for _, received := range []<-chan map[string]interface{}{instructor1, instructor2} {
+		select {
+		case msg := <-received:
+			content, _ := msg["content"].(map[string]interface{})
+			if msg["type"] != "system" || msg["context"] != types.ContextSystemAlert {
+				t.Errorf("Expected a system_alert message, got %v", msg)
+			}
+			if content["event"] != "alert_firing" || content["rule"] != "write_latency_p95" || content["value"] != 350.0 || content["threshold"] != 200.0 {
+				t.Errorf("Unexpected alert content: %v", content)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Instructor did not receive the alert")
+		}
+	}
+	for name, received := range map[string]<-chan map[string]interface{}{"student": student, "self-test instructor": probe} {
+		select {
+		case msg := <-received:
+			t.Errorf("Expected nothing sent to the %s, got %v", name, msg)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
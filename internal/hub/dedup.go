@@ -0,0 +1,131 @@
+package hub
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// maxDedupEntries bounds the broadcasts remembered per session
+// TECHNICAL DISCOVERY: A double click repeats the most recent broadcast, so a short
+// history is enough; the oldest entry is evicted when a session exceeds it
+const maxDedupEntries = 32
+
+// broadcastDedup remembers recent instructor broadcasts so a repeat can be suppressed
+// ARCHITECTURAL DISCOVERY: Consulted by the hub goroutine before routing, so a
+// suppressed duplicate is never persisted or delivered; the mutex only guards against
+// SessionEnded clearing a session from the session manager's hook goroutine
+type broadcastDedup struct {
+	window   time.Duration
+	mu       sync.Mutex
+	sessions map[string][]dedupEntry // sessionID -> recent broadcasts, oldest first
+}
+
+// dedupEntry is one delivered broadcast
+type dedupEntry struct {
+	key       string // broadcastKey of the message
+	messageID string
+	sentAt    time.Time
+}
+
+func newBroadcastDedup(window time.Duration) *broadcastDedup {
+	return &broadcastDedup{
+		window:   window,
+		sessions: make(map[string][]dedupEntry),
+	}
+}
+
+// original returns the ID of an identical broadcast delivered within the window
+func (d *broadcastDedup) original(sessionID, key string, now time.Time) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, entry := range d.pruneLocked(sessionID, now) {
+		if entry.key == key {
+			return entry.messageID, true
+		}
+	}
+	return "", false
+}
+
+// remember records a delivered broadcast
+func (d *broadcastDedup) remember(sessionID, key, messageID string, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entries := d.pruneLocked(sessionID, now)
+	if len(entries) >= maxDedupEntries {
+		entries = entries[len(entries)-maxDedupEntries+1:]
+	}
+	d.sessions[sessionID] = append(entries, dedupEntry{key: key, messageID: messageID, sentAt: now})
+}
+
+// forget drops everything remembered for an ended session
+func (d *broadcastDedup) forget(sessionID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.sessions, sessionID)
+}
+
+// sessionCount returns how many sessions have broadcasts remembered
+func (d *broadcastDedup) sessionCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.sessions)
+}
+
+// pruneLocked drops a session's entries older than the window and returns the rest
+// TECHNICAL DISCOVERY: Caller must hold d.mu. Entries are appended in time order, so
+// expired ones are always a prefix
+func (d *broadcastDedup) pruneLocked(sessionID string, now time.Time) []dedupEntry {
+	entries := d.sessions[sessionID]
+	expired := 0
+	for expired < len(entries) && now.Sub(entries[expired].sentAt) >= d.window {
+		expired++
+	}
+	if expired == len(entries) {
+		delete(d.sessions, sessionID)
+		return nil
+	}
+	entries = entries[expired:]
+	d.sessions[sessionID] = entries
+	return entries
+}
+
+// broadcastKey hashes a broadcast's sender, context, normalized content and to_users
+// FUNCTIONAL DISCOVERY: Whitespace inside string values is collapsed and map keys are
+// sorted by encoding/json, so a resend with a trailing space or reordered fields still
+// counts as the same announcement
+func broadcastKey(message *types.Message) string {
+	content, _ := json.Marshal(normalizeContent(message.Content))
+	toUsers := append([]string(nil), message.ToUsers...)
+	sort.Strings(toUsers) // The same students in any order are the same broadcast
+	sum := sha256.Sum256([]byte(message.FromUser + "\x00" + message.Context + "\x00" + string(content) + "\x00" + strings.Join(toUsers, ",")))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeContent returns a copy of value with whitespace collapsed in every string
+func normalizeContent(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		normalized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			normalized[key] = normalizeContent(item)
+		}
+		return normalized
+	case []interface{}:
+		normalized := make([]interface{}, len(v))
+		for i, item := range v {
+			normalized[i] = normalizeContent(item)
+		}
+		return normalized
+	case string:
+		return strings.Join(strings.Fields(v), " ")
+	default:
+		return v
+	}
+}
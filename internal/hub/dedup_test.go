@@ -0,0 +1,125 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestBroadcastDedup_Window tests functional validation - repeats count only within the window
+func TestBroadcastDedup_Window(t *testing.T) {
+	dedup := newBroadcastDedup(3 * time.Second)
+	now := time.Now()
+	
+	dedup.remember("session1", "key", "msg-1", now)
+	if id, duplicate := dedup.original("session1", "key", now.Add(2*time.Second)); !duplicate || id != "msg-1" {
+		t.Errorf("Expected msg-1 within the window, got %q %v", id, duplicate)
+	}
+	if _, duplicate := dedup.original("session2", "key", now); duplicate {
+		t.Error("Sessions should not share broadcasts")
+	}
+	if _, duplicate := dedup.original("session1", "key", now.Add(3*time.Second)); duplicate {
+		t.Error("A repeat after the window should be delivered")
+	}
+	if _, exists := dedup.sessions["session1"]; exists {
+		t.Error("Expired entries should be pruned")
+	}
+	
+	dedup.remember("session1", "key", "msg-2", now)
+	dedup.forget("session1")
+	if _, duplicate := dedup.original("session1", "key", now); duplicate {
+		t.Error("Forgotten sessions should start over")
+	}
+}
+
+// TestBroadcastDedup_Bounded tests technical validation - each session keeps a bounded history
+func TestBroadcastDedup_Bounded(t *testing.T) {
+	dedup := newBroadcastDedup(time.Minute)
+	now := time.Now()
+	for i := 0; i < maxDedupEntries+10; i++ {
+		dedup.remember("session1", fmt.Sprintf("key-%d", i), fmt.Sprintf("msg-%d", i), now)
+	}
+	if entries := len(dedup.sessions["session1"]); entries != maxDedupEntries {
+		t.Errorf("Expected %d entries, got %d", maxDedupEntries, entries)
+	}
+	if _, duplicate := dedup.original("session1", "key-0", now); duplicate {
+		t.Error("The oldest entry should have been evicted")
+	}
+	if id, duplicate := dedup.original("session1", fmt.Sprintf("key-%d", maxDedupEntries+9), now); !duplicate || id != fmt.Sprintf("msg-%d", maxDedupEntries+9) {
+		t.Errorf("The newest entry should be kept, got %q %v", id, duplicate)
+	}
+}
+
+// TestBroadcastKey tests functional validation - normalization ignores whitespace and key order
+func TestBroadcastKey(t *testing.T) {
+	base := &types.Message{FromUser: "instructor1", Context: "general", Content: map[string]interface{}{"text": "Quiz at 3", "priority": "high"}}
+	same := &types.Message{FromUser: "instructor1", Context: "general", Content: map[string]interface{}{"priority": "high", "text": "  Quiz  at 3\n"}}
+	if broadcastKey(base) != broadcastKey(same) {
+		t.Error("Whitespace and key order should not change the key")
+	}
+	for name, other := range map[string]*types.Message{
+		"sender":  {FromUser: "instructor2", Context: "general", Content: base.Content},
+		"context": {FromUser: "instructor1", Context: "exam", Content: base.Content},
+		"content": {FromUser: "instructor1", Context: "general", Content: map[string]interface{}{"text": "Quiz at 4", "priority": "high"}},
+		"to_users": {FromUser: "instructor1", Context: "general", Content: base.Content, ToUsers: []string{"student1"}},
+	} {
+		if broadcastKey(base) == broadcastKey(other) {
+			t.Errorf("A different %s should change the key", name)
+		}
+	}
+}
+
+// TestHub_DuplicateBroadcastSuppressed tests functional validation - a double click is routed once
+func TestHub_DuplicateBroadcastSuppressed(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := testsupport.NewRecordingRouter()
+	hub := NewHub(registry, router)
+	hub.SetBroadcastDedupWindow(time.Minute)
+	received := connectSender(t, registry, "instructor1", "session1")
+	
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+	
+	send := func(id, text string) {
+		message := &types.Message{
+			ID:      id, // The recording router reports it as the routed message ID
+			Type:    types.MessageTypeInstructorBroadcast,
+			Content: map[string]interface{}{"text": text},
+		}
+		if err := hub.SendMessage(message, "instructor1"); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+	
+	send("msg-1", "Quiz at 3")
+	send("msg-2", "Quiz at 3 ")
+	select {
+	case msg := <-received:
+		content, _ := msg["content"].(map[string]interface{})
+		if msg["context"] != "message_status" || content["event"] != "duplicate_suppressed" || content["message_id"] != "msg-1" {
+			t.Errorf("Unexpected duplicate notice: %v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Sender did not receive duplicate_suppressed")
+	}
+	
+	send("msg-3", "Quiz moved to 4")
+	if calls, ok := router.WaitForCalls(2, 2*time.Second); !ok || calls[1].Message.Content["text"] != "Quiz moved to 4" {
+		t.Fatalf("Expected the duplicate skipped and the new broadcast routed, got %d calls", len(calls))
+	}
+	
+	// Ending the session clears what was remembered
+	hub.SessionEnded(context.Background(), types.Session{ID: "session1"})
+	<-received // session_ended
+	send("msg-4", "Quiz at 3")
+	if _, ok := router.WaitForCalls(3, 2*time.Second); !ok {
+		t.Error("The broadcast should be routed again after the session ended")
+	}
+}
@@ -0,0 +1,145 @@
+package hub
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// DefaultDiagnosticsSampleRate is the share of messages measured while a session's
+// diagnostic mode is on
+// TECHNICAL DISCOVERY: One in ten keeps the per-recipient copies cheap in a busy
+// session while still giving a teacher a reading every few messages
+const DefaultDiagnosticsSampleRate = 0.1
+
+// diagnosticsMode tracks which sessions an instructor has turned the latency overlay on for
+// ARCHITECTURAL DISCOVERY: Consulted by the hub goroutine for every message; the mutex
+// guards against disconnects and session ends arriving from other goroutines
+type diagnosticsMode struct {
+	sampleRate float64
+	mu         sync.Mutex
+	sessions   map[string]string // sessionID -> instructor who turned it on
+}
+
+func newDiagnosticsMode(sampleRate float64) *diagnosticsMode {
+	return &diagnosticsMode{
+		sampleRate: sampleRate,
+		sessions:   make(map[string]string),
+	}
+}
+
+// enable turns the overlay on for a session; the latest instructor to enable owns it
+func (d *diagnosticsMode) enable(sessionID, userID string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.sessions[sessionID] = userID
+}
+
+// disable turns the overlay off and reports whether it was on
+func (d *diagnosticsMode) disable(sessionID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, enabled := d.sessions[sessionID]
+	delete(d.sessions, sessionID)
+	return enabled
+}
+
+// disableIfOwner turns the overlay off when userID is the instructor who enabled it
+func (d *diagnosticsMode) disableIfOwner(sessionID, userID string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if owner, enabled := d.sessions[sessionID]; !enabled || owner != userID {
+		return false
+	}
+	delete(d.sessions, sessionID)
+	return true
+}
+
+// sample reports whether a message in the session should carry the overlay
+func (d *diagnosticsMode) sample(sessionID string) bool {
+	d.mu.Lock()
+	_, enabled := d.sessions[sessionID]
+	d.mu.Unlock()
+	return enabled && rand.Float64() < d.sampleRate
+}
+
+// sessionCount returns how many sessions have the overlay on
+func (d *diagnosticsMode) sessionCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.sessions)
+}
+
+// SetDiagnosticsSampleRate sets the share of messages measured in diagnostic mode
+// TECHNICAL DISCOVERY: Must be called before Start; 1 measures every message
+func (h *Hub) SetDiagnosticsSampleRate(rate float64) {
+	h.diagnostics.sampleRate = rate
+}
+
+// handleDiagnosticsControl turns a session's diagnostic overlay on or off
+// FUNCTIONAL DISCOVERY: Instructor-only; every instructor in the session is told, as
+// they all receive the overlay while it is on
+func (h *Hub) handleDiagnosticsControl(messageCtx *MessageContext) {
+	if messageCtx.Sender == nil || messageCtx.Sender.GetRole() != "instructor" {
+		h.sendErrorToSender(messageCtx.SenderID, ErrDiagnosticsInstructorOnly)
+		return
+	}
+
+	enabled, _ := messageCtx.Message.Content["enabled"].(bool)
+	if enabled {
+		h.diagnostics.enable(messageCtx.SessionID, messageCtx.SenderID)
+		log.Printf("Diagnostic mode enabled: session=%s by=%s", messageCtx.SessionID, messageCtx.SenderID)
+		h.notifyDiagnostics(messageCtx.SessionID, map[string]interface{}{
+			"event":       "diagnostics_enabled",
+			"by":          messageCtx.SenderID,
+			"sample_rate": h.diagnostics.sampleRate,
+		})
+		return
+	}
+	if h.diagnostics.disable(messageCtx.SessionID) {
+		log.Printf("Diagnostic mode disabled: session=%s by=%s", messageCtx.SessionID, messageCtx.SenderID)
+	}
+	h.notifyDiagnostics(messageCtx.SessionID, map[string]interface{}{
+		"event":  "diagnostics_disabled",
+		"by":     messageCtx.SenderID,
+		"reason": "disabled",
+	})
+}
+
+// connectionLeft turns diagnostic mode off when the instructor who enabled it disconnects
+// ARCHITECTURAL DISCOVERY: Registered as a registry unregister listener; a reconnect
+// replaces the connection without unregistering it, so a network blip keeps the mode on
+func (h *Hub) connectionLeft(conn *websocket.Connection) {
+	if conn.GetRole() != "instructor" {
+		return
+	}
+	sessionID, userID := conn.GetSessionID(), conn.GetUserID()
+	if !h.diagnostics.disableIfOwner(sessionID, userID) {
+		return
+	}
+	log.Printf("Diagnostic mode disabled: session=%s instructor %s left", sessionID, userID)
+	h.notifyDiagnostics(sessionID, map[string]interface{}{
+		"event":  "diagnostics_disabled",
+		"by":     userID,
+		"reason": "instructor_left",
+	})
+}
+
+// notifyDiagnostics sends a diagnostics system message to the session's instructors
+func (h *Hub) notifyDiagnostics(sessionID string, content map[string]interface{}) {
+	notice := map[string]interface{}{
+		"type":      "system",
+		"context":   types.ControlTypeDiagnostics,
+		"content":   content,
+		"timestamp": time.Now(),
+	}
+	for _, conn := range h.registry.GetSessionInstructors(sessionID) {
+		if err := conn.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send %v to %s: %v", content["event"], conn.GetUserID(), err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/internal/router"
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// receiveDiagnostics waits for the next diagnostics notice on an instructor's peer
+func receiveDiagnostics(t *testing.T, received <-chan map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	select {
+	case msg := <-received:
+		content, _ := msg["content"].(map[string]interface{})
+		if msg["context"] != types.ControlTypeDiagnostics {
+			t.Fatalf("Expected a diagnostics notice, got %v", msg)
+		}
+		return content
+	case <-time.After(2 * time.Second):
+		t.Fatal("Instructor did not receive a diagnostics notice")
+		return nil
+	}
+}
+
+// TestHub_DiagnosticsControl tests functional validation - instructors toggle the overlay, students cannot
+func TestHub_DiagnosticsControl(t *testing.T) {
+	registry := websocket.NewRegistry()
+	recorder := testsupport.NewRecordingRouter()
+	hub := NewHub(registry, recorder)
+	hub.SetDiagnosticsSampleRate(1)
+	student := connectAs(t, registry, "student1", "student", "session1")
+	instructor1 := connectAs(t, registry, "instructor1", "instructor", "session1")
+	instructor2 := connectAs(t, registry, "instructor2", "instructor", "session1")
+	
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+	
+	control := func(senderID string, enabled bool) {
+		message := &types.Message{Type: types.ControlTypeDiagnostics, Content: map[string]interface{}{"enabled": enabled}}
+		if err := hub.SendMessage(message, senderID); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+	
+	control("student1", true)
+	select {
+	case msg := <-student:
+		content, _ := msg["content"].(map[string]interface{})
+		if content["event"] != "message_error" || content["error"] != ErrDiagnosticsInstructorOnly.Error() {
+			t.Errorf("Expected the student to be refused, got %v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Student did not receive message_error")
+	}
+	
+	control("instructor1", true)
+	for _, received := range []<-chan map[string]interface{}{instructor1, instructor2} {
+		if content := receiveDiagnostics(t, received); content["event"] != "diagnostics_enabled" || content["by"] != "instructor1" {
+			t.Errorf("Expected diagnostics_enabled by instructor1, got %v", content)
+		}
+	}
+	
+	// Messages in the session are now marked for the overlay; control messages never reach the router
+	question := &types.Message{ID: "msg-1", Type: types.MessageTypeInstructorInbox, Content: map[string]interface{}{"text": "Why?"}}
+	if err := hub.SendMessage(question, "student1"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	calls, ok := recorder.WaitForCalls(1, 2*time.Second)
+	if !ok || len(calls) != 1 || calls[0].Message.ID != "msg-1" {
+		t.Fatalf("Expected only the question routed, got %d calls", len(calls))
+	}
+	if router.DiagnosticsFrom(calls[0].Context) == nil {
+		t.Error("Expected the sampled message to carry hub diagnostics")
+	}
+	
+	control("instructor2", false)
+	for _, received := range []<-chan map[string]interface{}{instructor1, instructor2} {
+		if content := receiveDiagnostics(t, received); content["event"] != "diagnostics_disabled" || content["reason"] != "disabled" {
+			t.Errorf("Expected diagnostics_disabled, got %v", content)
+		}
+	}
+	if count := hub.diagnostics.sessionCount(); count != 0 {
+		t.Errorf("Expected no sessions in diagnostic mode, got %d", count)
+	}
+}
+
+// TestHub_DiagnosticsInstructorLeft tests functional validation - the overlay turns off with its instructor
+func TestHub_DiagnosticsInstructorLeft(t *testing.T) {
+	registry := websocket.NewRegistry()
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
+	instructor1 := connectAs(t, registry, "instructor1", "instructor", "session1")
+	instructor2 := connectAs(t, registry, "instructor2", "instructor", "session1")
+	
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+	
+	message := &types.Message{Type: types.ControlTypeDiagnostics, Content: map[string]interface{}{"enabled": true}}
+	if err := hub.SendMessage(message, "instructor1"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	receiveDiagnostics(t, instructor1)
+	receiveDiagnostics(t, instructor2)
+	
+	// Another instructor leaving keeps the mode on
+	other, _ := registry.GetUserConnection("instructor2")
+	registry.UnregisterConnection(other)
+	if count := hub.diagnostics.sessionCount(); count != 1 {
+		t.Fatalf("Expected diagnostic mode still on, got %d sessions", count)
+	}
+	
+	third := connectAs(t, registry, "instructor3", "instructor", "session1")
+	owner, _ := registry.GetUserConnection("instructor1")
+	registry.UnregisterConnection(owner)
+	if content := receiveDiagnostics(t, third); content["event"] != "diagnostics_disabled" || content["reason"] != "instructor_left" {
+		t.Errorf("Expected diagnostics_disabled for instructor_left, got %v", content)
+	}
+	if count := hub.diagnostics.sessionCount(); count != 0 {
+		t.Errorf("Expected no sessions in diagnostic mode, got %d", count)
+	}
+}
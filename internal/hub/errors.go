@@ -10,4 +10,16 @@ var (
 	ErrMessageChannelFull    = errors.New("message channel is full")
 	ErrRegisterChannelFull   = errors.New("register channel is full")
 	ErrUnregisterChannelFull = errors.New("unregister channel is full")
-)
\ No newline at end of file
+	// ErrDiagnosticsInstructorOnly refuses a diagnostics control message from a student
+	ErrDiagnosticsInstructorOnly = errors.New("only instructors can change diagnostic mode")
+	// ErrPreferencesInstructorOnly refuses an inbox_preferences control message from a student
+	ErrPreferencesInstructorOnly = errors.New("only instructors can set inbox preferences")
+	ErrPreferencesNotConfigured  = errors.New("inbox preferences are not enabled on this server")
+	// ErrReadReceiptsStudentOnly refuses a read_receipt control message from an instructor
+	ErrReadReceiptsStudentOnly   = errors.New("only students send read receipts")
+	ErrReadReceiptsNotConfigured = errors.New("read receipts are not enabled on this server")
+	ErrSelfTestAlreadyRunning    = errors.New("self-test is already running")
+	ErrSelfTestNotRunning        = errors.New("self-test is not running")
+	ErrProbeTimeout              = errors.New("self-test probe was not delivered within one interval")
+	ErrProbeRefused              = errors.New("self-test probe was refused")
+)
@@ -2,13 +2,18 @@ package hub
 
 import (
 	"context"
+	"errors"
+	"hash/fnv"
 	"log"
 	"sync"
 	"time"
 
+	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
-	"switchboard/internal/websocket"
+	"switchboard/internal/clock"
 	"switchboard/internal/router"
+	"switchboard/internal/session"
+	"switchboard/internal/websocket"
 )
 
 // Hub coordinates message routing and connection management
@@ -17,15 +22,22 @@ import (
 type Hub struct {
 	// Channels for coordination
 	// FUNCTIONAL DISCOVERY: Buffered channels prevent blocking during message bursts
-	messageChannel    chan *MessageContext // TECHNICAL DISCOVERY: 1000 buffer handles classroom message bursts
+	messageShards     []chan *MessageContext // One per worker; TECHNICAL DISCOVERY: 1000 buffer each handles classroom message bursts
 	registerChannel   chan *websocket.Connection // 100 buffer for connection lifecycle events
 	unregisterChannel chan string // userID - smaller buffer for deregistration events
 	shutdownChannel   chan struct{} // Unbuffered for immediate shutdown signaling
 	
 	// Components
 	// ARCHITECTURAL DISCOVERY: Dependency injection enables clean testing with mocks
-	registry *websocket.Registry
-	router   *router.Router
+	registry     *websocket.Registry
+	router       interfaces.MessageRouter
+	dedup        *broadcastDedup     // nil unless a broadcast dedup window is configured
+	diagnostics  *diagnosticsMode    // Sessions with the instructor latency overlay on
+	resources    *SessionResources   // Per-session cleanups run when a session ends
+	clock        interfaces.Clock    // Ingest times, dedup windows and notice timestamps
+	preferences  PreferenceSetter    // nil refuses inbox_preferences control messages
+	readReceipts ReadReceiptRecorder // nil refuses read_receipt control messages
+	activity     ActivityRecorder    // nil when session activity is not tracked
 	
 	// State
 	// TECHNICAL DISCOVERY: RWMutex allows concurrent reads of running state
@@ -40,28 +52,114 @@ type MessageContext struct {
 	Message    *types.Message
 	SenderID   string
 	SessionID  string
+	Sender     interfaces.ConnectionInfo // Connection the message arrived on
 	Timestamp  time.Time
 }
 
 // NewHub creates a new hub
 // ARCHITECTURAL DISCOVERY: Constructor pattern with dependency injection
-// enables clean testing and component isolation
-func NewHub(registry *websocket.Registry, router *router.Router) *Hub {
-	return &Hub{
+// enables clean testing and component isolation; tests pass a recording
+// MessageRouter instead of a router backed by SQLite
+func NewHub(registry *websocket.Registry, router interfaces.MessageRouter) *Hub {
+	h := &Hub{
 		// TECHNICAL DISCOVERY: Channel buffer sizes based on classroom scale testing
-		messageChannel:    make(chan *MessageContext, 1000), // Buffer for message bursts
+		messageShards:     newMessageShards(DefaultWorkers), // Buffers for message bursts
 		registerChannel:   make(chan *websocket.Connection, 100), // Connection lifecycle events
 		unregisterChannel: make(chan string, 100), // Deregistration events
 		shutdownChannel:   make(chan struct{}), // Immediate shutdown signaling
 		registry:         registry,
 		router:          router,
 		running:         false,
+		diagnostics:     newDiagnosticsMode(DefaultDiagnosticsSampleRate),
+		resources:       NewSessionResources(),
+		clock:           clock.Real(),
+	}
+	registry.OnUnregister(h.connectionLeft)
+	registry.OnUnregister(h.participantLeft)
+	registry.OnUnregister(h.activityLeft)
+	h.resources.Register("instructor_departures", func(ended types.Session) {
+		registry.ForgetSession(ended.ID)
+	}, registry.DepartureCount)
+	h.resources.Register("student_visits", func(ended types.Session) {
+		registry.ForgetStudentVisits(ended.ID)
+	}, registry.VisitSessions)
+	h.resources.Register("broadcast_dedup", func(ended types.Session) {
+		if h.dedup != nil {
+			h.dedup.forget(ended.ID)
+		}
+	}, func() int {
+		if h.dedup == nil {
+			return 0
+		}
+		return h.dedup.sessionCount()
+	})
+	h.resources.Register("diagnostics", func(ended types.Session) {
+		h.diagnostics.disable(ended.ID)
+	}, h.diagnostics.sessionCount)
+	return h
+}
+
+// Resources returns the registry of per-session cleanups run when a session ends
+// ARCHITECTURAL DISCOVERY: Features outside the hub (router limits, transcripts)
+// register here during startup instead of adding their own session-ended hooks
+func (h *Hub) Resources() *SessionResources {
+	return h.resources
+}
+
+// SetBroadcastDedupWindow suppresses identical instructor broadcasts repeated within window
+// TECHNICAL DISCOVERY: Must be called before Start; a zero window disables suppression
+func (h *Hub) SetBroadcastDedupWindow(window time.Duration) {
+	if window <= 0 {
+		h.dedup = nil
+		return
+	}
+	h.dedup = newBroadcastDedup(window)
+}
+
+// DefaultWorkers is how many messages the hub routes at once unless SetWorkers changes it
+const DefaultWorkers = 4
+
+// messageBuffer is each worker's queue; a full queue refuses with ErrMessageChannelFull
+const messageBuffer = 1000
+
+func newMessageShards(workers int) []chan *MessageContext {
+	shards := make([]chan *MessageContext, workers)
+	for i := range shards {
+		shards[i] = make(chan *MessageContext, messageBuffer)
 	}
+	return shards
+}
+
+// SetWorkers sets how many messages are routed at once; n <= 0 restores DefaultWorkers
+// FUNCTIONAL DISCOVERY: Messages are sharded by session and sender, so each user's
+// messages are routed one at a time in the order they arrived - a student's answer
+// can never overtake their question - while different senders route in parallel
+// TECHNICAL DISCOVERY: Must be called before Start; the shards are read without locking
+func (h *Hub) SetWorkers(n int) {
+	if n <= 0 {
+		n = DefaultWorkers
+	}
+	h.messageShards = newMessageShards(n)
+}
+
+// shardFor returns the worker queue of a sender in a session
+func (h *Hub) shardFor(sessionID, senderID string) chan *MessageContext {
+	hash := fnv.New32a()
+	hash.Write([]byte(sessionID))
+	hash.Write([]byte{0}) // Keeps ("ab", "c") and ("a", "bc") apart
+	hash.Write([]byte(senderID))
+	return h.messageShards[hash.Sum32()%uint32(len(h.messageShards))]
+}
+
+// SetClock sets the clock the hub stamps and deduplicates messages by
+// TECHNICAL DISCOVERY: Must be called before Start; read without locking
+func (h *Hub) SetClock(c interfaces.Clock) {
+	h.clock = c
 }
 
 // Start begins hub processing
-// FUNCTIONAL DISCOVERY: Single hub goroutine prevents race conditions
-// while maintaining high throughput message processing
+// FUNCTIONAL DISCOVERY: One hub goroutine for connection lifecycle prevents race
+// conditions, while message workers keep throughput high
 func (h *Hub) Start(ctx context.Context) error {
 	h.mu.Lock()
 	if h.running {
@@ -75,7 +173,11 @@ func (h *Hub) Start(ctx context.Context) error {
 	
 	// Start the main hub goroutine
 	// ARCHITECTURAL DISCOVERY: Single goroutine coordination prevents race conditions
+	// for connection lifecycle; messages are routed by one worker per shard
 	go h.run(ctx)
+	for _, shard := range h.messageShards {
+		go h.work(ctx, shard)
+	}
 	
 	return nil
 }
@@ -128,12 +230,13 @@ func (h *Hub) SendMessage(message *types.Message, senderID string) error {
 		Message:   message,
 		SenderID:  senderID,
 		SessionID: sender.GetSessionID(),
-		Timestamp: time.Now(),
+		Sender:    sender,
+		Timestamp: h.clock.Now(),
 	}
 	
 	// TECHNICAL DISCOVERY: Non-blocking send with error handling prevents hub lockup
 	select {
-	case h.messageChannel <- messageCtx:
+	case h.shardFor(messageCtx.SessionID, senderID) <- messageCtx:
 		return nil
 	default:
 		return ErrMessageChannelFull
@@ -186,10 +289,6 @@ func (h *Hub) run(ctx context.Context) {
 	
 	for {
 		select {
-		case messageCtx := <-h.messageChannel:
-			// FUNCTIONAL DISCOVERY: Message processing continues despite individual failures
-			h.handleMessage(ctx, messageCtx)
-			
 		case conn := <-h.registerChannel:
 			// ARCHITECTURAL DISCOVERY: Registration coordination through hub
 			// ensures consistent state between registry and connection tracking
@@ -211,20 +310,79 @@ func (h *Hub) run(ctx context.Context) {
 	}
 }
 
+// work routes one shard's messages in arrival order
+func (h *Hub) work(ctx context.Context, shard chan *MessageContext) {
+	for {
+		select {
+		case messageCtx := <-shard:
+			// FUNCTIONAL DISCOVERY: Message processing continues despite individual failures
+			h.handleMessage(ctx, messageCtx, len(shard))
+			
+		case <-h.shutdownChannel:
+			return
+			
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // handleMessage processes a message through the router
 // FUNCTIONAL DISCOVERY: Message context restoration ensures proper routing
 // even when message doesn't contain complete sender information
-func (h *Hub) handleMessage(ctx context.Context, messageCtx *MessageContext) {
+func (h *Hub) handleMessage(ctx context.Context, messageCtx *MessageContext, hubQueue int) {
+	pickedUp := h.clock.Now()
+	
 	// Set message metadata from context
 	// ARCHITECTURAL DISCOVERY: Message enrichment at hub level
 	// keeps message struct clean while ensuring routing context
 	messageCtx.Message.FromUser = messageCtx.SenderID
 	messageCtx.Message.SessionID = messageCtx.SessionID
 	
+	// Control messages change session state and are never routed or stored
+	if messageCtx.Message.Type == types.ControlTypeDiagnostics {
+		h.handleDiagnosticsControl(messageCtx)
+		return
+	}
+	if messageCtx.Message.Type == types.ControlTypeInboxPreferences {
+		h.handlePreferencesControl(ctx, messageCtx)
+		return
+	}
+	if messageCtx.Message.Type == types.ControlTypeReadReceipt {
+		h.handleReadReceiptControl(ctx, messageCtx)
+		return
+	}
+	
+	// Suppress a repeated instructor broadcast before it is stored or delivered
+	// FUNCTIONAL DISCOVERY: The sender is told which message already went out, so a
+	// client can show the double click as sent rather than failed
+	// TECHNICAL DISCOVERY: Keys include the sender, whose messages all reach one worker,
+	// so the check and the remember below cannot interleave with a repeat
+	var dedupKey string
+	if h.dedup != nil && messageCtx.Message.Type == types.MessageTypeInstructorBroadcast {
+		dedupKey = broadcastKey(messageCtx.Message)
+		if originalID, duplicate := h.dedup.original(messageCtx.SessionID, dedupKey, h.clock.Now()); duplicate {
+			log.Printf("Duplicate broadcast suppressed: from=%s session=%s original=%s",
+				messageCtx.SenderID, messageCtx.SessionID, originalID)
+			h.sendDuplicateSuppressed(messageCtx.SenderID, originalID)
+			return
+		}
+	}
+	
 	// Route the message
 	// TECHNICAL DISCOVERY: Router errors logged but don't crash hub
 	// ensuring system resilience during partial failures
-	if err := h.router.RouteMessage(ctx, messageCtx.Message); err != nil {
+	// FUNCTIONAL DISCOVERY: Sampled messages in diagnostic mode carry the hub's share
+	// of the latency to the router, which adds its own and attaches the overlay
+	routeCtx := ctx
+	if h.diagnostics.sample(messageCtx.SessionID) {
+		routeCtx = router.WithDiagnostics(ctx, &router.Diagnostics{
+			Ingest:   pickedUp.Sub(messageCtx.Timestamp),
+			HubQueue: hubQueue,
+		})
+	}
+	result, err := h.router.RouteMessage(routeCtx, messageCtx.Message, messageCtx.Sender)
+	if err != nil {
 		log.Printf("Message routing failed for user %s in session %s: %v", 
 			messageCtx.SenderID, messageCtx.SessionID, err)
 		
@@ -233,8 +391,17 @@ func (h *Hub) handleMessage(ctx context.Context, messageCtx *MessageContext) {
 		// during message delivery failures
 		h.sendErrorToSender(messageCtx.SenderID, err)
 	} else {
-		log.Printf("Message routed successfully: type=%s from=%s session=%s", 
-			messageCtx.Message.Type, messageCtx.SenderID, messageCtx.SessionID)
+		if dedupKey != "" {
+			h.dedup.remember(messageCtx.SessionID, dedupKey, result.MessageID, h.clock.Now())
+		}
+		h.recordActivity(messageCtx.SessionID)
+		if len(messageCtx.Message.ToUsers) > 0 {
+			h.sendDeliveryReceipt(messageCtx.SenderID, result)
+		}
+		log.Printf("Message routed successfully: type=%s from=%s session=%s delivered=%d/%d dropped=%d route=%v write=%v", 
+			messageCtx.Message.Type, messageCtx.SenderID, messageCtx.SessionID,
+			len(result.Delivered), len(result.Resolved), len(result.Dropped),
+			result.Timings.Route, result.Timings.Write)
 	}
 }
 
@@ -258,6 +425,7 @@ func (h *Hub) handleRegistration(conn *websocket.Connection) {
 	} else {
 		log.Printf("Connection registered: user=%s role=%s session=%s", 
 			conn.GetUserID(), conn.GetRole(), conn.GetSessionID())
+		h.recordActivity(conn.GetSessionID())
 	}
 }
 
@@ -284,17 +452,180 @@ func (h *Hub) sendErrorToSender(senderID string, routingErr error) {
 		return // Sender already disconnected
 	}
 	
+	content := map[string]interface{}{
+		"event": "message_error",
+		"message": "Message could not be delivered",
+		"error": routingErr.Error(),
+	}
+	switch {
+	case errors.Is(routingErr, interfaces.ErrSessionLocked):
+		content["code"] = types.ErrorCodeSessionLocked
+	case errors.Is(routingErr, interfaces.ErrDeadlinePassed):
+		content["code"] = types.ErrorCodeDeadlinePassed
+	}
+	var unknown *router.UnknownRecipientError
+	if errors.As(routingErr, &unknown) {
+		content["code"] = types.ErrorCodeUnknownRecipient
+		content["to_user"] = unknown.UserID
+		content["reason"] = unknown.Reason
+	}
+	var attachment *router.AttachmentError
+	if errors.As(routingErr, &attachment) {
+		content["code"] = types.ErrorCodeAttachmentRejected
+		content["reason"] = attachment.Reason
+		if attachment.Reason == router.AttachmentTooLarge {
+			content["max_bytes"] = attachment.MaxBytes
+		}
+	}
+	
 	errorMsg := map[string]interface{}{
-		"type": "system",
-		"content": map[string]interface{}{
-			"event": "message_error",
-			"message": "Message could not be delivered",
-			"error": routingErr.Error(),
-		},
-		"timestamp": time.Now(),
+		"type":      "system",
+		"content":   content,
+		"timestamp": h.clock.Now(),
 	}
 	
 	if err := sender.WriteJSON(errorMsg); err != nil {
 		log.Printf("Failed to send error message to %s: %v", senderID, err)
 	}
-}
\ No newline at end of file
+}
+// SessionEnded tells every connection in an ended session why it ended
+// ARCHITECTURAL DISCOVERY: Registered as a session manager ended-hook, so manual,
+// automatic and admin ends all notify clients without each caller hand-rolling it
+// FUNCTIONAL DISCOVERY: Runs after the end is persisted, so a vetoed or failed end
+// never tells clients the session is over
+// TECHNICAL DISCOVERY: Per-session resources are released first so a slow client
+// write cannot leave them behind once the hook budget runs out
+func (h *Hub) SessionEnded(ctx context.Context, ended types.Session) {
+	h.resources.Release(ctx, ended)
+	
+	connections := h.registry.GetSessionConnections(ended.ID)
+	if len(connections) == 0 {
+		log.Printf("No connections found for session %s - no session_ended message sent", ended.ID)
+		return
+	}
+	
+	reason := session.EndReason(ctx)
+	if reason == "" {
+		reason = "Session ended"
+	}
+	sessionEnded := websocket.NewPreparedMessage(map[string]interface{}{
+		"type":    "system",
+		"context": "session_ended",
+		"content": map[string]interface{}{
+			"event":  "session_ended",
+			"reason": reason,
+		},
+	})
+	
+	successCount := 0
+	for _, conn := range connections {
+		// TECHNICAL DISCOVERY: Stop once the hook budget is spent; the session has
+		// already ended, remaining clients find out when they next send
+		if ctx.Err() != nil {
+			break
+		}
+		if err := conn.WritePrepared(sessionEnded); err != nil {
+			log.Printf("Failed to send session_ended to %s: %v", conn.GetUserID(), err)
+		} else {
+			successCount++
+		}
+	}
+	log.Printf("Sent session_ended message to %d/%d connected clients", successCount, len(connections))
+}
+
+// PersistFailed tells a message's sender that it is not in the session record
+// ARCHITECTURAL DISCOVERY: Registered as a database persist-failure listener; the
+// notice is correlated by message_id so clients can mark the exact message
+// FUNCTIONAL DISCOVERY: In route-only mode the message was still delivered, so the
+// notice says so; a failed write is also reported as message_error by handleMessage
+func (h *Hub) PersistFailed(message *types.Message, persistErr error) {
+	sender, exists := h.registry.GetUserConnection(message.FromUser)
+	if !exists || sender.GetSessionID() != message.SessionID {
+		return // Sender already gone - the audit log still records the gap
+	}
+	
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "message_status",
+		"content": map[string]interface{}{
+			"event":      "persist_failed",
+			"message_id": message.ID,
+			"delivered":  errors.Is(persistErr, interfaces.ErrPersistSkipped),
+		},
+		"timestamp": h.clock.Now(),
+	}
+	
+	if err := sender.WriteJSON(notice); err != nil {
+		log.Printf("Failed to send persist_failed to %s: %v", message.FromUser, err)
+	}
+}
+
+// sendDuplicateSuppressed tells a broadcast's sender that it repeated originalID
+func (h *Hub) sendDuplicateSuppressed(senderID, originalID string) {
+	sender, exists := h.registry.GetUserConnection(senderID)
+	if !exists {
+		return // Sender already disconnected
+	}
+	
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "message_status",
+		"content": map[string]interface{}{
+			"event":      "duplicate_suppressed",
+			"message_id": originalID,
+		},
+		"timestamp": h.clock.Now(),
+	}
+	
+	if err := sender.WriteJSON(notice); err != nil {
+		log.Printf("Failed to send duplicate_suppressed to %s: %v", senderID, err)
+	}
+}
+
+// sendDeliveryReceipt tells a targeted broadcast's sender what happened to each student
+// FUNCTIONAL DISCOVERY: The instructor picked the students, so they are told which
+// ones it reached live; dropped students were offline and see it in history replay
+func (h *Hub) sendDeliveryReceipt(senderID string, result types.RouteResult) {
+	sender, exists := h.registry.GetUserConnection(senderID)
+	if !exists {
+		return // Sender already disconnected
+	}
+	
+	receipt := map[string]interface{}{
+		"type":    "system",
+		"context": "message_status",
+		"content": map[string]interface{}{
+			"event":      "delivery_receipt",
+			"message_id": result.MessageID,
+			"recipients": result.RecipientStatuses(),
+		},
+		"timestamp": h.clock.Now(),
+	}
+	
+	if err := sender.WriteJSON(receipt); err != nil {
+		log.Printf("Failed to send delivery_receipt to %s: %v", senderID, err)
+	}
+}
+
+// participantLeft tells a session's instructors that a participant disconnected
+// ARCHITECTURAL DISCOVERY: Registered as a registry unregister listener, so it fires
+// for clean closes, read errors and failed writes alike; a reconnect replaces the
+// connection without unregistering it and is not reported
+func (h *Hub) participantLeft(conn *websocket.Connection) {
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": "presence",
+		"content": map[string]interface{}{
+			"event":   "participant_left",
+			"user_id": conn.GetUserID(),
+			"role":    conn.GetRole(),
+		},
+		"timestamp": h.clock.Now(),
+	}
+	
+	for _, instructor := range h.registry.GetSessionInstructors(conn.GetSessionID()) {
+		if err := instructor.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send participant_left to %s: %v", instructor.GetUserID(), err)
+		}
+	}
+}
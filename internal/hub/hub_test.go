@@ -2,20 +2,25 @@ package hub
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	gorilla "github.com/gorilla/websocket"
+	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
-	"switchboard/internal/websocket"
 	"switchboard/internal/router"
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
 )
 
 // TestHub_StructExists tests architectural validation - Hub struct existence
 func TestHub_StructExists(t *testing.T) {
 	registry := websocket.NewRegistry()
-	router := router.NewRouter(registry, nil)
-	
-	hub := NewHub(registry, router)
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
 	if hub == nil {
 		t.Error("NewHub should return a valid Hub instance")
 	}
@@ -24,8 +29,7 @@ func TestHub_StructExists(t *testing.T) {
 // TestHub_StartStop tests functional validation - hub lifecycle management
 func TestHub_StartStop(t *testing.T) {
 	registry := websocket.NewRegistry()
-	router := router.NewRouter(registry, nil)
-	hub := NewHub(registry, router)
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
 	
 	ctx := context.Background()
 	
@@ -57,8 +61,7 @@ func TestHub_StartStop(t *testing.T) {
 // TestHub_SendMessage tests functional validation - message queuing
 func TestHub_SendMessage(t *testing.T) {
 	registry := websocket.NewRegistry()
-	router := router.NewRouter(registry, nil)
-	hub := NewHub(registry, router)
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
 	
 	ctx := context.Background()
 	if err := hub.Start(ctx); err != nil {
@@ -88,8 +91,7 @@ func TestHub_SendMessage(t *testing.T) {
 // TestHub_RegisterConnection tests functional validation - connection management
 func TestHub_RegisterConnection(t *testing.T) {
 	registry := websocket.NewRegistry()
-	router := router.NewRouter(registry, nil) // Use real router
-	hub := NewHub(registry, router)
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
 	
 	ctx := context.Background()
 	if err := hub.Start(ctx); err != nil {
@@ -132,8 +134,7 @@ func TestHub_MessageContext(t *testing.T) {
 // TestHub_ChannelBuffering tests technical validation - channel capacity
 func TestHub_ChannelBuffering(t *testing.T) {
 	registry := websocket.NewRegistry()
-	router := router.NewRouter(registry, nil)
-	hub := NewHub(registry, router)
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
 	
 	// Should have proper channel buffering to prevent blocking
 	// This test will verify the implementation creates buffered channels
@@ -145,8 +146,7 @@ func TestHub_ChannelBuffering(t *testing.T) {
 // TestHub_ConcurrentAccess tests technical validation - thread safety
 func TestHub_ConcurrentAccess(t *testing.T) {
 	registry := websocket.NewRegistry()
-	router := router.NewRouter(registry, nil)
-	hub := NewHub(registry, router)
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
 	
 	ctx := context.Background()
 	if err := hub.Start(ctx); err != nil {
@@ -197,8 +197,7 @@ func TestHub_ErrorTypes(t *testing.T) {
 // TestHub_MessageFlow tests functional validation - message processing structure
 func TestHub_MessageFlow(t *testing.T) {
 	registry := websocket.NewRegistry()
-	router := router.NewRouter(registry, nil)
-	hub := NewHub(registry, router)
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
 	
 	ctx := context.Background()
 	if err := hub.Start(ctx); err != nil {
@@ -225,4 +224,318 @@ func TestHub_MessageFlow(t *testing.T) {
 	}
 }
 
-// Test focusing on hub coordination logic with real components
\ No newline at end of file
+// Test focusing on hub coordination logic with real components
+// connectSender registers a live connection for userID and returns what its peer receives
+func connectSender(t *testing.T, registry *websocket.Registry, userID, sessionID string) <-chan map[string]interface{} {
+	return connectAs(t, registry, userID, "student", sessionID)
+}
+
+// connectAs is connectSender for a given role
+func connectAs(t *testing.T, registry *websocket.Registry, userID, role, sessionID string) <-chan map[string]interface{} {
+	received := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, err := (&gorilla.Upgrader{}).Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer peer.Close()
+		for {
+			var msg map[string]interface{}
+			if err := peer.ReadJSON(&msg); err != nil {
+				return
+			}
+			received <- msg
+		}
+	}))
+	t.Cleanup(server.Close)
+	
+	raw, _, err := gorilla.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	conn := websocket.NewConnection(raw)
+	t.Cleanup(func() { conn.Close() })
+	if err := conn.SetCredentials(userID, role, sessionID); err != nil {
+		t.Fatalf("SetCredentials failed: %v", err)
+	}
+	if err := registry.RegisterConnection(conn); err != nil {
+		t.Fatalf("RegisterConnection failed: %v", err)
+	}
+	return received
+}
+
+// TestHub_PersistFailed tests functional validation - sender learns which message is missing
+func TestHub_PersistFailed(t *testing.T) {
+	registry := websocket.NewRegistry()
+	hub := NewHub(registry, testsupport.NewRecordingRouter())
+	received := connectSender(t, registry, "student1", "session1")
+	
+	hub.PersistFailed(&types.Message{ID: "msg-1", SessionID: "session1", FromUser: "student1"}, interfaces.ErrPersistSkipped)
+	hub.PersistFailed(&types.Message{ID: "msg-2", SessionID: "other-session", FromUser: "student1"}, errors.New("disk I/O error"))
+	
+	select {
+	case msg := <-received:
+		content, _ := msg["content"].(map[string]interface{})
+		if msg["context"] != "message_status" || content["event"] != "persist_failed" ||
+			content["message_id"] != "msg-1" || content["delivered"] != true {
+			t.Errorf("Unexpected persist notice: %v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Sender did not receive persist_failed notice")
+	}
+	
+	select {
+	case msg := <-received:
+		t.Errorf("Notice for another session should not reach the sender: %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestHub_DeliveryReceipt tests functional validation - a targeted broadcast's sender
+// hears what happened to each listed student
+func TestHub_DeliveryReceipt(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := testsupport.NewRecordingRouter()
+	router.SetResult(types.RouteResult{
+		Resolved:  []string{"student1", "student2"},
+		Delivered: []string{"student1"},
+		Dropped:   []string{"student2"},
+	}, nil)
+	hub := NewHub(registry, router)
+	received := connectAs(t, registry, "instructor1", "instructor", "session1")
+	
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+	
+	send := func(toUsers ...string) {
+		message := &types.Message{
+			ID:      "msg-1", // The recording router reports it as the routed message ID
+			Type:    types.MessageTypeInstructorBroadcast,
+			ToUsers: toUsers,
+			Content: map[string]interface{}{"text": "Submit now"},
+		}
+		if err := hub.SendMessage(message, "instructor1"); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+	
+	send("student1", "student2")
+	select {
+	case msg := <-received:
+		content, _ := msg["content"].(map[string]interface{})
+		recipients, _ := content["recipients"].(map[string]interface{})
+		if msg["context"] != "message_status" || content["event"] != "delivery_receipt" || content["message_id"] != "msg-1" ||
+			recipients["student1"] != types.RecipientDelivered || recipients["student2"] != types.RecipientDropped {
+			t.Errorf("Unexpected delivery receipt: %v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Sender did not receive delivery_receipt")
+	}
+	
+	// Whole-session broadcasts carry no receipt
+	send()
+	if _, ok := router.WaitForCalls(2, 2*time.Second); !ok {
+		t.Fatal("Expected the second broadcast routed")
+	}
+	select {
+	case msg := <-received:
+		t.Errorf("Expected no receipt for a whole-session broadcast, got %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestHub_ParticipantLeft tests functional validation - instructors hear when a participant disconnects
+func TestHub_ParticipantLeft(t *testing.T) {
+	registry := websocket.NewRegistry()
+	NewHub(registry, testsupport.NewRecordingRouter())
+	instructor := connectAs(t, registry, "instructor1", "instructor", "session1")
+	student := connectSender(t, registry, "student1", "session1")
+	connectSender(t, registry, "student2", "session2")
+	
+	for _, userID := range []string{"student1", "student2"} {
+		conn, _ := registry.GetUserConnection(userID)
+		registry.UnregisterConnection(conn)
+	}
+	
+	select {
+	case msg := <-instructor:
+		content, _ := msg["content"].(map[string]interface{})
+		if msg["context"] != "presence" || content["event"] != "participant_left" ||
+			content["user_id"] != "student1" || content["role"] != "student" {
+			t.Errorf("Unexpected presence notice: %v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Instructor did not receive participant_left notice")
+	}
+	
+	select {
+	case msg := <-instructor:
+		t.Errorf("Departure from another session should not reach the instructor: %v", msg)
+	case msg := <-student:
+		t.Errorf("Students should not receive presence notices: %v", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// TestHub_RoutesWithSender tests functional validation - hub enriches messages and passes the sender
+func TestHub_RoutesWithSender(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := testsupport.NewRecordingRouter()
+	hub := NewHub(registry, router)
+	connectSender(t, registry, "student1", "session1")
+	
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+	
+	for _, text := range []string{"first", "second"} {
+		message := &types.Message{
+			Type:     types.MessageTypeInstructorInbox,
+			FromUser: "spoofed",
+			Content:  map[string]interface{}{"text": text},
+		}
+		if err := hub.SendMessage(message, "student1"); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+	
+	calls, ok := router.WaitForCalls(2, 2*time.Second)
+	if !ok {
+		t.Fatalf("Expected 2 routed messages, got %d", len(calls))
+	}
+	for i, text := range []string{"first", "second"} {
+		call := calls[i]
+		if call.Message.Content["text"] != text {
+			t.Errorf("Call %d: expected %q in order, got %v", i, text, call.Message.Content["text"])
+		}
+		if call.Message.FromUser != "student1" || call.Message.SessionID != "session1" {
+			t.Errorf("Call %d: hub should set sender and session, got from=%s session=%s", i, call.Message.FromUser, call.Message.SessionID)
+		}
+		if call.Sender == nil || call.Sender.GetUserID() != "student1" || call.Sender.GetSessionID() != "session1" {
+			t.Errorf("Call %d: router should receive the sending connection, got %v", i, call.Sender)
+		}
+	}
+}
+
+// TestHub_RoutingErrorNotifiesSender tests functional validation - routing failures reach the sender
+func TestHub_RoutingErrorNotifiesSender(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := testsupport.NewRecordingRouter()
+	router.SetResult(types.RouteResult{}, errors.New("recipient not found"))
+	hub := NewHub(registry, router)
+	received := connectSender(t, registry, "student1", "session1")
+	
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+	
+	message := &types.Message{
+		Type:    types.MessageTypeInstructorInbox,
+		Content: map[string]interface{}{"text": "Help needed"},
+	}
+	if err := hub.SendMessage(message, "student1"); err != nil {
+		t.Fatalf("SendMessage failed: %v", err)
+	}
+	
+	select {
+	case msg := <-received:
+		content, _ := msg["content"].(map[string]interface{})
+		if content["event"] != "message_error" || content["error"] != "recipient not found" {
+			t.Errorf("Unexpected error notice: %v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Sender did not receive message_error")
+	}
+}
+
+// TestHub_RefusalErrorCodes tests functional validation - lock and deadline refusals carry a code
+func TestHub_RefusalErrorCodes(t *testing.T) {
+	refusals := map[string]error{
+		types.ErrorCodeSessionLocked:  interfaces.ErrSessionLocked,
+		types.ErrorCodeDeadlinePassed: interfaces.ErrDeadlinePassed,
+	}
+	for code, refusal := range refusals {
+		registry := websocket.NewRegistry()
+		router := testsupport.NewRecordingRouter()
+		router.SetResult(types.RouteResult{}, refusal)
+		hub := NewHub(registry, router)
+		received := connectSender(t, registry, "student1", "session1")
+		
+		if err := hub.Start(context.Background()); err != nil {
+			t.Fatalf("Failed to start hub: %v", err)
+		}
+		
+		message := &types.Message{
+			Type:    types.MessageTypeInstructorInbox,
+			Content: map[string]interface{}{"text": "Can I ask during the exam?"},
+		}
+		if err := hub.SendMessage(message, "student1"); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+		
+		select {
+		case msg := <-received:
+			content, _ := msg["content"].(map[string]interface{})
+			if content["event"] != "message_error" || content["code"] != code {
+				t.Errorf("Expected message_error with code %s, got %v", code, msg)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Sender did not receive message_error with code %s", code)
+		}
+		hub.Stop()
+	}
+}
+
+// TestHub_PerSenderOrdering tests functional validation - with parallel workers, each
+// sender's messages still reach the instructor in the order they were sent
+func TestHub_PerSenderOrdering(t *testing.T) {
+	const perSender = 500
+	registry := websocket.NewRegistry()
+	messageRouter := router.NewRouter(registry, nil)
+	messageRouter.SetRateLimits(func() (int, time.Duration) { return 2 * perSender, time.Minute })
+	hub := NewHub(registry, messageRouter)
+	hub.SetWorkers(8)
+	senders := []string{"student1", "student2"}
+	for _, sender := range senders {
+		connectSender(t, registry, sender, "session1")
+	}
+	instructor := connectAs(t, registry, "instructor1", "instructor", "session1")
+	
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+	
+	// Interleave the two senders, numbering each one's messages
+	for seq := 0; seq < perSender; seq++ {
+		for _, sender := range senders {
+			message := &types.Message{
+				Type:    types.MessageTypeInstructorInbox,
+				Content: map[string]interface{}{"seq": seq},
+			}
+			if err := hub.SendMessage(message, sender); err != nil {
+				t.Fatalf("SendMessage %d from %s failed: %v", seq, sender, err)
+			}
+		}
+	}
+	
+	next := map[string]int{}
+	for received := 0; received < perSender*len(senders); received++ {
+		select {
+		case msg := <-instructor:
+			from, _ := msg["from_user"].(string)
+			content, _ := msg["content"].(map[string]interface{})
+			seq, _ := content["seq"].(float64)
+			if int(seq) != next[from] {
+				t.Fatalf("Expected %s's message %d next, got %d", from, next[from], int(seq))
+			}
+			next[from]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out after %d messages, progress %v", received, next)
+		}
+	}
+}
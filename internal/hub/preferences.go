@@ -0,0 +1,69 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"switchboard/pkg/types"
+)
+
+// PreferenceSetter stores an instructor's inbox preferences for a session
+type PreferenceSetter func(ctx context.Context, sessionID, instructorID string, filters map[string][]string) (*types.InboxPreferencesUpdate, error)
+
+// SetInboxPreferences sets what inbox_preferences control messages change
+// TECHNICAL DISCOVERY: Must be called before Start; read without locking
+func (h *Hub) SetInboxPreferences(set PreferenceSetter) {
+	h.preferences = set
+}
+
+// handlePreferencesControl changes which instructor fan-out messages reach the sender
+// FUNCTIONAL DISCOVERY: Instructor-only; only the sender is answered, with what was
+// stored and any warning that a context now reaches no instructor by preference
+func (h *Hub) handlePreferencesControl(ctx context.Context, messageCtx *MessageContext) {
+	if messageCtx.Sender == nil || messageCtx.Sender.GetRole() != "instructor" {
+		h.sendErrorToSender(messageCtx.SenderID, ErrPreferencesInstructorOnly)
+		return
+	}
+	if h.preferences == nil {
+		h.sendErrorToSender(messageCtx.SenderID, ErrPreferencesNotConfigured)
+		return
+	}
+
+	// TECHNICAL DISCOVERY: Content arrives as generic JSON; a round trip gives the
+	// filters their real type and refuses lists holding anything but strings
+	var filters map[string][]string
+	raw, err := json.Marshal(messageCtx.Message.Content["filters"])
+	if err == nil {
+		err = json.Unmarshal(raw, &filters)
+	}
+	if err != nil {
+		h.sendErrorToSender(messageCtx.SenderID, fmt.Errorf("%w: filters must map message types to lists of contexts", types.ErrInvalidInboxFilter))
+		return
+	}
+
+	update, err := h.preferences(ctx, messageCtx.SessionID, messageCtx.SenderID, filters)
+	if err != nil {
+		h.sendErrorToSender(messageCtx.SenderID, err)
+		return
+	}
+
+	sender, exists := h.registry.GetUserConnection(messageCtx.SenderID)
+	if !exists {
+		return
+	}
+	notice := map[string]interface{}{
+		"type":    "system",
+		"context": types.ControlTypeInboxPreferences,
+		"content": map[string]interface{}{
+			"event":       "inbox_preferences_updated",
+			"preferences": update.Preferences,
+			"warnings":    update.Warnings,
+		},
+		"timestamp": h.clock.Now(),
+	}
+	if err := sender.WriteJSON(notice); err != nil {
+		log.Printf("Failed to send inbox_preferences_updated to %s: %v", messageCtx.SenderID, err)
+	}
+}
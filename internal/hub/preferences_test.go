@@ -0,0 +1,80 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestHub_PreferencesControl tests functional validation - instructors set their inbox
+// filters over the socket and hear back warnings; students cannot
+func TestHub_PreferencesControl(t *testing.T) {
+	registry := websocket.NewRegistry()
+	recorder := testsupport.NewRecordingRouter()
+	hub := NewHub(registry, recorder)
+	type call struct {
+		sessionID, instructorID string
+		filters                 map[string][]string
+	}
+	calls := make(chan call, 1)
+	hub.SetInboxPreferences(func(ctx context.Context, sessionID, instructorID string, filters map[string][]string) (*types.InboxPreferencesUpdate, error) {
+		calls <- call{sessionID, instructorID, filters}
+		return &types.InboxPreferencesUpdate{
+			Preferences: &types.InboxPreferences{SessionID: sessionID, InstructorID: instructorID, Filters: filters},
+			Warnings:    []string{"No instructor receives instructor_inbox messages outside technical_issue"},
+		}, nil
+	})
+	student := connectAs(t, registry, "student1", "student", "session1")
+	ta := connectAs(t, registry, "ta", "instructor", "session1")
+
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+
+	control := func(senderID string) {
+		message := &types.Message{
+			Type:    types.ControlTypeInboxPreferences,
+			Content: map[string]interface{}{"filters": map[string]interface{}{"instructor_inbox": []interface{}{"technical_issue"}}},
+		}
+		if err := hub.SendMessage(message, senderID); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+	receive := func(received <-chan map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+		t.Helper()
+		select {
+		case msg := <-received:
+			content, _ := msg["content"].(map[string]interface{})
+			return msg, content
+		case <-time.After(2 * time.Second):
+			t.Fatal("No reply to the inbox_preferences control message")
+			return nil, nil
+		}
+	}
+
+	control("student1")
+	if _, content := receive(student); content["event"] != "message_error" || content["error"] != ErrPreferencesInstructorOnly.Error() {
+		t.Errorf("Expected the student to be refused, got %v", content)
+	}
+
+	control("ta")
+	msg, content := receive(ta)
+	if msg["context"] != types.ControlTypeInboxPreferences || content["event"] != "inbox_preferences_updated" {
+		t.Fatalf("Expected inbox_preferences_updated, got %v", msg)
+	}
+	if warnings, _ := content["warnings"].([]interface{}); len(warnings) != 1 {
+		t.Errorf("Expected the orphan warning passed on, got %v", content["warnings"])
+	}
+	got := <-calls
+	if got.sessionID != "session1" || got.instructorID != "ta" || len(got.filters["instructor_inbox"]) != 1 {
+		t.Errorf("Expected the TA's filters set for session1, got %+v", got)
+	}
+	if len(recorder.Calls()) != 0 {
+		t.Error("Control messages should never reach the router")
+	}
+}
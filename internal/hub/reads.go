@@ -0,0 +1,35 @@
+package hub
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// ReadReceiptRecorder marks a message read by a student in a session
+type ReadReceiptRecorder func(ctx context.Context, sessionID, userID, messageID string) error
+
+// SetReadReceipts sets what read_receipt control messages record
+// TECHNICAL DISCOVERY: Must be called before Start; read without locking
+func (h *Hub) SetReadReceipts(record ReadReceiptRecorder) {
+	h.readReceipts = record
+}
+
+// handleReadReceiptControl records that the sending student displayed a message
+// FUNCTIONAL DISCOVERY: Student-only and unacknowledged - a client sends receipts as
+// messages scroll into view and only hears back when one is refused
+func (h *Hub) handleReadReceiptControl(ctx context.Context, messageCtx *MessageContext) {
+	if messageCtx.Sender == nil || messageCtx.Sender.GetRole() != "student" {
+		h.sendErrorToSender(messageCtx.SenderID, ErrReadReceiptsStudentOnly)
+		return
+	}
+	if h.readReceipts == nil {
+		h.sendErrorToSender(messageCtx.SenderID, ErrReadReceiptsNotConfigured)
+		return
+	}
+
+	messageID, _ := messageCtx.Message.Content[types.ReadReceiptContentMessage].(string)
+	if err := h.readReceipts(ctx, messageCtx.SessionID, messageCtx.SenderID, messageID); err != nil {
+		h.sendErrorToSender(messageCtx.SenderID, err)
+	}
+}
@@ -0,0 +1,73 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestHub_ReadReceiptControl tests functional validation - students report reads over
+// the socket without an acknowledgement; instructors cannot
+func TestHub_ReadReceiptControl(t *testing.T) {
+	registry := websocket.NewRegistry()
+	recorder := testsupport.NewRecordingRouter()
+	hub := NewHub(registry, recorder)
+	type call struct {
+		sessionID, userID, messageID string
+	}
+	calls := make(chan call, 1)
+	hub.SetReadReceipts(func(ctx context.Context, sessionID, userID, messageID string) error {
+		calls <- call{sessionID, userID, messageID}
+		return nil
+	})
+	student := connectAs(t, registry, "student1", "student", "session1")
+	instructor := connectAs(t, registry, "instructor1", "instructor", "session1")
+
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+
+	control := func(senderID string) {
+		message := &types.Message{
+			Type:    types.ControlTypeReadReceipt,
+			Content: map[string]interface{}{types.ReadReceiptContentMessage: "announcement"},
+		}
+		if err := hub.SendMessage(message, senderID); err != nil {
+			t.Fatalf("SendMessage failed: %v", err)
+		}
+	}
+
+	control("instructor1")
+	select {
+	case msg := <-instructor:
+		content, _ := msg["content"].(map[string]interface{})
+		if content["event"] != "message_error" || content["error"] != ErrReadReceiptsStudentOnly.Error() {
+			t.Errorf("Expected the instructor to be refused, got %v", content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("No reply to the instructor's read_receipt")
+	}
+
+	control("student1")
+	select {
+	case got := <-calls:
+		if got.sessionID != "session1" || got.userID != "student1" || got.messageID != "announcement" {
+			t.Errorf("Expected student1's read of announcement in session1, got %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("The student's read_receipt was not recorded")
+	}
+	select {
+	case reply := <-student:
+		t.Errorf("Expected no acknowledgement, got %v", reply)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if len(recorder.Calls()) != 0 {
+		t.Error("Control messages should never reach the router")
+	}
+}
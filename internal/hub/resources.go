@@ -0,0 +1,114 @@
+package hub
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// resourceReleaseTimeout bounds each feature's cleanup when a session ends
+// TECHNICAL DISCOVERY: Cleanups only drop in-memory state or queue work, so a second
+// is generous; one that overruns is logged and left to finish on its own rather than
+// holding up the remaining features or the session_ended notices
+const resourceReleaseTimeout = time.Second
+
+// SessionResources releases the per-session state features keep in memory
+// ARCHITECTURAL DISCOVERY: Features register a release function once at startup and
+// the hub runs them all from its SessionEnded hook, so ending one session frees its
+// caches, limiter entries and open files without stopping the server or any other
+// session. Releases run in registration order
+type SessionResources struct {
+	mu        sync.RWMutex
+	resources []sessionResource
+}
+
+// sessionResource is one feature's cleanup and its stats source
+type sessionResource struct {
+	name    string
+	release func(ended types.Session)
+	tracked func() int // Entries currently held; nil when the feature cannot tell
+}
+
+// NewSessionResources creates an empty resource registry
+func NewSessionResources() *SessionResources {
+	return &SessionResources{}
+}
+
+// Register adds a feature's per-session cleanup
+// FUNCTIONAL DISCOVERY: tracked reports how many entries the feature still holds,
+// which /health exposes so a leak shows up as a count that never returns to zero
+func (r *SessionResources) Register(name string, release func(ended types.Session), tracked func() int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resources = append(r.resources, sessionResource{name: name, release: release, tracked: tracked})
+}
+
+// Release runs every registered cleanup for an ended session
+// TECHNICAL DISCOVERY: Each cleanup gets resourceReleaseTimeout or what is left of
+// ctx, whichever is shorter; a panic is logged and does not stop the others
+func (r *SessionResources) Release(ctx context.Context, ended types.Session) {
+	r.mu.RLock()
+	resources := append([]sessionResource(nil), r.resources...)
+	r.mu.RUnlock()
+
+	released := 0
+	for _, resource := range resources {
+		if ctx.Err() != nil {
+			log.Printf("Session %s: hook budget spent, skipped releasing %s", ended.ID, resource.name)
+			continue
+		}
+		started := time.Now()
+		if r.releaseOne(ctx, resource, ended) {
+			released++
+			if elapsed := time.Since(started); elapsed > resourceReleaseTimeout/10 {
+				log.Printf("Session %s: releasing %s took %v", ended.ID, resource.name, elapsed)
+			}
+		}
+	}
+	if len(resources) > 0 {
+		log.Printf("Session %s: released %d/%d session resources", ended.ID, released, len(resources))
+	}
+}
+
+// releaseOne runs a single cleanup and reports whether it finished in time
+func (r *SessionResources) releaseOne(ctx context.Context, resource sessionResource, ended types.Session) bool {
+	timeout, cancel := context.WithTimeout(ctx, resourceReleaseTimeout)
+	defer cancel()
+
+	done := make(chan bool, 1) // Buffered so an abandoned cleanup can still finish
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Printf("Session %s: releasing %s panicked: %v", ended.ID, resource.name, recovered)
+				done <- false
+			}
+		}()
+		resource.release(ended)
+		done <- true
+	}()
+
+	select {
+	case ok := <-done:
+		return ok
+	case <-timeout.Done():
+		log.Printf("WARNING: Session %s: releasing %s did not finish within %v", ended.ID, resource.name, resourceReleaseTimeout)
+		return false
+	}
+}
+
+// Stats returns the entries each feature still holds, keyed by feature name
+func (r *SessionResources) Stats() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]int, len(r.resources))
+	for _, resource := range r.resources {
+		if resource.tracked != nil {
+			stats[resource.name] = resource.tracked()
+		}
+	}
+	return stats
+}
@@ -0,0 +1,74 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestSessionResources_Release tests functional validation - every cleanup runs even
+// when one panics, and stats report what each feature still holds
+func TestSessionResources_Release(t *testing.T) {
+	resources := NewSessionResources()
+	held := map[string]int{"first": 2, "second": 1}
+	
+	resources.Register("first", func(ended types.Session) { held["first"] = 0 }, func() int { return held["first"] })
+	resources.Register("broken", func(ended types.Session) { panic("boom") }, nil)
+	resources.Register("second", func(ended types.Session) { held["second"] = 0 }, func() int { return held["second"] })
+	
+	stats := resources.Stats()
+	if stats["first"] != 2 || stats["second"] != 1 {
+		t.Fatalf("Unexpected stats before release: %v", stats)
+	}
+	if _, exists := stats["broken"]; exists {
+		t.Error("Features without a stats source should not be reported")
+	}
+	
+	resources.Release(context.Background(), types.Session{ID: "session1"})
+	if stats := resources.Stats(); stats["first"] != 0 || stats["second"] != 0 {
+		t.Errorf("Expected every feature released despite the panic, got %v", stats)
+	}
+}
+
+// TestSessionResources_Bounded tests technical validation - a stuck cleanup cannot
+// hold the session-ended hook past its budget
+func TestSessionResources_Bounded(t *testing.T) {
+	resources := NewSessionResources()
+	stuck := make(chan struct{})
+	defer close(stuck)
+	
+	resources.Register("stuck", func(ended types.Session) { <-stuck }, nil)
+	skipped := true
+	resources.Register("after", func(ended types.Session) { skipped = false }, nil)
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	
+	started := time.Now()
+	resources.Release(ctx, types.Session{ID: "session1"})
+	if elapsed := time.Since(started); elapsed > time.Second/2 {
+		t.Errorf("Release should return once the budget is spent, took %v", elapsed)
+	}
+	if !skipped {
+		t.Error("Cleanups after the budget is spent should be skipped")
+	}
+}
+
+// TestHub_SessionEndedReleasesDedup tests functional validation - the hub's own
+// dedup cache is registered and freed by SessionEnded
+func TestHub_SessionEndedReleasesDedup(t *testing.T) {
+	h := NewHub(websocket.NewRegistry(), nil)
+	h.SetBroadcastDedupWindow(time.Minute)
+	h.dedup.remember("session1", "key", "msg-1", time.Now())
+	
+	if h.Resources().Stats()["broadcast_dedup"] != 1 {
+		t.Fatalf("Expected one session in the dedup cache, got %v", h.Resources().Stats())
+	}
+	h.resources.Release(context.Background(), types.Session{ID: "session1"})
+	if h.Resources().Stats()["broadcast_dedup"] != 0 {
+		t.Errorf("Expected the dedup cache released, got %v", h.Resources().Stats())
+	}
+}
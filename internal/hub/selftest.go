@@ -0,0 +1,316 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	gorillaws "github.com/gorilla/websocket"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// Self-test participants; the sys: prefix is reserved, so no client can claim either
+const (
+	selfTestInstructorID = "sys:selftest-instructor"
+	selfTestStudentID    = "sys:selftest-student"
+)
+
+// contentProbeID carries a probe's identity through routing
+const contentProbeID = "probe_id"
+
+// SelfTest periodically routes a probe between two loopback connections
+// ARCHITECTURAL DISCOVERY: The synthetic student and instructor are real WebSocket
+// connections over 127.0.0.1, registered in the hidden types.SelfTestSessionID session,
+// so a probe crosses the hub queue, the router, persistence and a socket write like any
+// student message; only the inbound frame parsing of the /ws handler is skipped
+// FUNCTIONAL DISCOVERY: A probe that is not delivered within one interval is a miss,
+// and the configured number of consecutive misses marks the status degraded
+type SelfTest struct {
+	hub              *Hub
+	interval         time.Duration
+	failureThreshold int
+
+	// Loopback pair; replaced when either connection leaves the registry
+	server     *http.Server
+	instructor *websocket.Connection
+	student    *websocket.Connection
+	peers      []*gorillaws.Conn // Client ends of both connections
+	delivered  chan string       // Probe IDs read by the instructor's client end
+	refused    chan string       // message_error text read by the student's client end
+
+	status   types.SelfTestStatus
+	statusMu sync.Mutex
+
+	shutdownChannel chan struct{} // Closed by Stop
+	done            chan struct{} // Closed when the run goroutine exits
+
+	running bool
+	mu      sync.Mutex
+}
+
+// NewSelfTest creates a self-test that probes h every interval
+func NewSelfTest(h *Hub, interval time.Duration, failureThreshold int) *SelfTest {
+	return &SelfTest{
+		hub:              h,
+		interval:         interval,
+		failureThreshold: failureThreshold,
+		shutdownChannel:  make(chan struct{}),
+		done:             make(chan struct{}),
+	}
+}
+
+// Start opens the loopback pair and begins probing
+// TECHNICAL DISCOVERY: The hub must already be running; probes are sent through it
+func (s *SelfTest) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return ErrSelfTestAlreadyRunning
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	s.running = true
+
+	log.Printf("Routing self-test probes every %s", s.interval)
+	go s.run(ctx)
+	return nil
+}
+
+// Stop ends probing and closes the loopback pair
+func (s *SelfTest) Stop() error {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return ErrSelfTestNotRunning
+	}
+	s.running = false
+	close(s.shutdownChannel)
+	s.mu.Unlock()
+
+	<-s.done
+	return nil
+}
+
+// Status returns the outcome of the probes so far
+func (s *SelfTest) Status() types.SelfTestStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	return s.status
+}
+
+func (s *SelfTest) run(ctx context.Context) {
+	defer close(s.done)
+	defer s.disconnect()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.probe(ctx)
+
+		case <-s.shutdownChannel:
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// probe sends one probe and waits up to an interval for its delivery
+func (s *SelfTest) probe(ctx context.Context) {
+	if err := s.ensureConnected(); err != nil {
+		s.record(0, err)
+		return
+	}
+
+	probeID := uuid.New().String()
+	message := &types.Message{
+		Type:    types.MessageTypeInstructorInbox,
+		Content: map[string]interface{}{contentProbeID: probeID},
+	}
+	sent := time.Now()
+	if err := s.hub.SendMessage(message, selfTestStudentID); err != nil {
+		s.record(0, err)
+		return
+	}
+
+	timeout := time.NewTimer(s.interval)
+	defer timeout.Stop()
+	for {
+		select {
+		case id := <-s.delivered:
+			if id != probeID {
+				continue // A late probe from an earlier miss
+			}
+			s.record(time.Since(sent), nil)
+			return
+
+		case reason := <-s.refused:
+			s.record(0, fmt.Errorf("%w: %s", ErrProbeRefused, reason))
+			return
+
+		case <-timeout.C:
+			s.record(0, ErrProbeTimeout)
+			return
+
+		case <-s.shutdownChannel:
+			return
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// record updates the status with one probe's outcome
+func (s *SelfTest) record(latency time.Duration, err error) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	s.status.Probes++
+	if err != nil {
+		s.status.Failures++
+		s.status.ConsecutiveFailures++
+		s.status.LastError = err.Error()
+		if s.status.ConsecutiveFailures >= s.failureThreshold && !s.status.Degraded {
+			s.status.Degraded = true
+			log.Printf("WARNING: Routing self-test degraded after %d consecutive misses: %v", s.status.ConsecutiveFailures, err)
+		}
+		return
+	}
+
+	if s.status.Degraded {
+		log.Printf("Routing self-test recovered")
+	}
+	s.status.Degraded = false
+	s.status.ConsecutiveFailures = 0
+	s.status.LastError = ""
+	s.status.LastSuccess = time.Now()
+	s.status.LatencyMs = float64(latency) / float64(time.Millisecond)
+}
+
+// ensureConnected replaces the loopback pair if either connection left the registry
+// FUNCTIONAL DISCOVERY: A failed socket write unregisters a connection like any
+// client's; reconnecting lets one bad probe count as one miss instead of all of them
+func (s *SelfTest) ensureConnected() error {
+	instructor, _ := s.hub.registry.GetUserConnection(selfTestInstructorID)
+	student, _ := s.hub.registry.GetUserConnection(selfTestStudentID)
+	if instructor == s.instructor && student == s.student {
+		return nil
+	}
+	s.disconnect()
+	return s.connect()
+}
+
+// connect opens the loopback pair and registers its server ends
+func (s *SelfTest) connect() error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to open self-test listener: %w", err)
+	}
+	accepted := make(chan *gorillaws.Conn, 1)
+	upgrader := gorillaws.Upgrader{}
+	s.server = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			accepted <- conn
+		}
+	})}
+	go func() { _ = s.server.Serve(listener) }()
+
+	s.delivered = make(chan string, 8)
+	s.refused = make(chan string, 8)
+	url := "ws://" + listener.Addr().String()
+	for _, participant := range []struct{ userID, role string }{
+		{selfTestInstructorID, "instructor"},
+		{selfTestStudentID, "student"},
+	} {
+		peer, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			s.disconnect()
+			return fmt.Errorf("failed to dial self-test listener: %w", err)
+		}
+		s.peers = append(s.peers, peer)
+
+		conn := websocket.NewConnection(<-accepted)
+		if err := conn.SetCredentials(participant.userID, participant.role, types.SelfTestSessionID); err != nil {
+			_ = conn.Close()
+			s.disconnect()
+			return err
+		}
+		if err := s.hub.registry.RegisterConnection(conn); err != nil {
+			_ = conn.Close()
+			s.disconnect()
+			return err
+		}
+		if participant.role == "instructor" {
+			s.instructor = conn
+			go readPeer(peer, s.delivered, probeIDOf)
+		} else {
+			s.student = conn
+			go readPeer(peer, s.refused, messageErrorOf)
+		}
+	}
+	return nil
+}
+
+// disconnect unregisters and closes whatever part of the loopback pair is open
+func (s *SelfTest) disconnect() {
+	for _, conn := range []*websocket.Connection{s.instructor, s.student} {
+		if conn != nil {
+			s.hub.registry.UnregisterConnection(conn)
+			_ = conn.Close()
+		}
+	}
+	for _, peer := range s.peers {
+		_ = peer.Close()
+	}
+	if s.server != nil {
+		_ = s.server.Close()
+	}
+	s.instructor, s.student, s.peers, s.server = nil, nil, nil, nil
+}
+
+// readPeer forwards what extract finds in each frame a client end reads, until it closes
+// TECHNICAL DISCOVERY: Sends never block; a full channel means nobody is waiting
+func readPeer(peer *gorillaws.Conn, out chan<- string, extract func(map[string]interface{}) (string, bool)) {
+	for {
+		var frame map[string]interface{}
+		if err := peer.ReadJSON(&frame); err != nil {
+			return
+		}
+		if value, ok := extract(frame); ok {
+			select {
+			case out <- value:
+			default:
+			}
+		}
+	}
+}
+
+// probeIDOf returns the probe ID of a delivered probe
+func probeIDOf(frame map[string]interface{}) (string, bool) {
+	content, _ := frame["content"].(map[string]interface{})
+	probeID, ok := content[contentProbeID].(string)
+	return probeID, ok
+}
+
+// messageErrorOf returns the error text of a message_error sent to the probe's sender
+func messageErrorOf(frame map[string]interface{}) (string, bool) {
+	content, _ := frame["content"].(map[string]interface{})
+	if content["event"] != "message_error" {
+		return "", false
+	}
+	reason, _ := content["error"].(string)
+	return reason, true
+}
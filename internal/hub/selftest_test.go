@@ -0,0 +1,107 @@
+package hub
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/internal/router"
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// waitForSelfTest polls until done reports true for the self-test's status
+func waitForSelfTest(t *testing.T, selfTest *SelfTest, done func(types.SelfTestStatus) bool) types.SelfTestStatus {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		status := selfTest.Status()
+		if done(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Self-test did not reach the expected status, last %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestSelfTest_ProbeRoutesEndToEnd tests functional validation - probes cross the real router and stay hidden
+func TestSelfTest_ProbeRoutesEndToEnd(t *testing.T) {
+	registry := websocket.NewRegistry()
+	hub := NewHub(registry, router.NewRouter(registry, nil))
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+
+	selfTest := NewSelfTest(hub, 50*time.Millisecond, 2)
+	if err := selfTest.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start self-test: %v", err)
+	}
+	if err := selfTest.Start(context.Background()); err != ErrSelfTestAlreadyRunning {
+		t.Errorf("Expected ErrSelfTestAlreadyRunning, got %v", err)
+	}
+
+	status := waitForSelfTest(t, selfTest, func(status types.SelfTestStatus) bool {
+		return !status.LastSuccess.IsZero()
+	})
+	if status.Degraded || status.LatencyMs <= 0 {
+		t.Errorf("Expected a healthy probe with a latency, got %+v", status)
+	}
+
+	// The loopback pair is not counted in what users are shown
+	if stats := registry.GetStats(); stats["total_connections"] != 0 || stats["active_sessions"] != 0 {
+		t.Errorf("Expected the self-test session hidden from stats, got %v", stats)
+	}
+	if _, exists := registry.GetSessionConnectionCounts()[types.SelfTestSessionID]; exists {
+		t.Error("Expected the self-test session hidden from connection counts")
+	}
+
+	if err := selfTest.Stop(); err != nil {
+		t.Errorf("Failed to stop self-test: %v", err)
+	}
+	if _, exists := registry.GetUserConnection(selfTestStudentID); exists {
+		t.Error("Expected the loopback pair unregistered after Stop")
+	}
+	if err := selfTest.Stop(); err != ErrSelfTestNotRunning {
+		t.Errorf("Expected ErrSelfTestNotRunning, got %v", err)
+	}
+}
+
+// TestSelfTest_ConsecutiveMissesDegrade tests functional validation - refused probes degrade the status until one succeeds
+func TestSelfTest_ConsecutiveMissesDegrade(t *testing.T) {
+	registry := websocket.NewRegistry()
+	recording := testsupport.NewRecordingRouter()
+	recording.SetResult(types.RouteResult{}, errors.New("failed to persist message: disk I/O error"))
+	hub := NewHub(registry, recording)
+	if err := hub.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start hub: %v", err)
+	}
+	defer hub.Stop()
+
+	selfTest := NewSelfTest(hub, 50*time.Millisecond, 2)
+	if err := selfTest.Start(context.Background()); err != nil {
+		t.Fatalf("Failed to start self-test: %v", err)
+	}
+	defer selfTest.Stop()
+
+	status := waitForSelfTest(t, selfTest, func(status types.SelfTestStatus) bool {
+		return status.Degraded
+	})
+	if status.ConsecutiveFailures < 2 || !strings.Contains(status.LastError, "disk I/O error") {
+		t.Errorf("Expected degraded after two refused probes, got %+v", status)
+	}
+
+	// A probe the router accepts but never delivers still misses
+	recording.SetResult(types.RouteResult{}, nil)
+	status = waitForSelfTest(t, selfTest, func(status types.SelfTestStatus) bool {
+		return strings.Contains(status.LastError, ErrProbeTimeout.Error())
+	})
+	if !status.Degraded {
+		t.Errorf("Expected undelivered probes to keep the status degraded, got %+v", status)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	
 	_ "github.com/mattn/go-sqlite3"
@@ -22,15 +23,23 @@ func InitializeTestDatabase(t *testing.T, dbPath string) {
 		}
 	}()
 	
-	// Read migration file
-	migrationPath := filepath.Join("..", "..", "migrations", "001_initial_schema.sql")
-	migration, err := os.ReadFile(migrationPath)
-	if err != nil {
-		t.Fatalf("Failed to read migration file: %v", err)
+	// Apply every migration file in version order (Glob returns sorted names)
+	migrationPaths, err := filepath.Glob(filepath.Join("..", "..", "migrations", "*.sql"))
+	if err != nil || len(migrationPaths) == 0 {
+		t.Fatalf("Failed to find migration files: %v", err)
 	}
 	
-	// Apply migration
-	if _, err := db.Exec(string(migration)); err != nil {
-		t.Fatalf("Failed to apply migration: %v", err)
+	for _, migrationPath := range migrationPaths {
+		if strings.HasSuffix(migrationPath, ".down.sql") {
+			continue // Rollback halves
+		}
+		migration, err := os.ReadFile(migrationPath)
+		if err != nil {
+			t.Fatalf("Failed to read migration file %s: %v", migrationPath, err)
+		}
+		
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("Failed to apply migration %s: %v", migrationPath, err)
+		}
 	}
 }
\ No newline at end of file
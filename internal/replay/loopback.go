@@ -0,0 +1,159 @@
+package replay
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	gorillaws "github.com/gorilla/websocket"
+	"switchboard/internal/router"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// loopback holds a replay's synthetic connections, one per participant
+// ARCHITECTURAL DISCOVERY: The same 127.0.0.1 pairing as the routing self-test, so the
+// router writes to real connections; the client ends only drain what they are sent
+type loopback struct {
+	registry *websocket.Registry
+	server   *http.Server
+	conns    map[string]*websocket.Connection
+	peers    []*gorillaws.Conn
+}
+
+// connectParticipants connects everyone the session lists or its history mentions
+// FUNCTIONAL DISCOVERY: The session's instructors and roster join in their roles; a
+// sender found only in history joins in the role its message type requires, and an
+// addressee found only in history joins as a student, since they were connected
+// when it was addressed
+func connectParticipants(registry *websocket.Registry, session *types.Session, history []*types.Message) (*loopback, error) {
+	var order []string
+	roles := make(map[string]string)
+	join := func(userID, role string) {
+		if userID == "" || role == "" || userID == types.SystemSenderID {
+			return
+		}
+		if _, joined := roles[userID]; !joined {
+			roles[userID] = role
+			order = append(order, userID)
+		}
+	}
+
+	join(session.CreatedBy, "instructor")
+	join(session.OwnerID, "instructor")
+	for _, instructorID := range session.InstructorIDs {
+		join(instructorID, "instructor")
+	}
+	for _, studentID := range session.StudentIDs {
+		join(studentID, "student")
+	}
+	senderRoles := make(map[string]string)
+	for _, route := range router.RoutingTable(nil) {
+		senderRoles[route.Type] = route.SenderRole
+	}
+	for _, message := range history {
+		join(message.FromUser, senderRoles[message.Type])
+	}
+	for _, message := range history {
+		if message.ToUser != nil {
+			join(*message.ToUser, "student")
+		}
+		for _, toUser := range message.ToUsers {
+			join(toUser, "student")
+		}
+	}
+
+	l := &loopback{registry: registry, conns: make(map[string]*websocket.Connection, len(order))}
+	if err := l.connect(session.ID, order, roles); err != nil {
+		l.close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// connect dials one loopback connection per user and registers its server end
+func (l *loopback) connect(sessionID string, userIDs []string, roles map[string]string) error {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to open replay listener: %w", err)
+	}
+	accepted := make(chan *gorillaws.Conn, 1)
+	upgrader := gorillaws.Upgrader{}
+	l.server = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, err := upgrader.Upgrade(w, r, nil); err == nil {
+			accepted <- conn
+		}
+	})}
+	go func() { _ = l.server.Serve(listener) }()
+
+	url := "ws://" + listener.Addr().String()
+	for _, userID := range userIDs {
+		peer, _, err := gorillaws.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			return fmt.Errorf("failed to dial replay listener: %w", err)
+		}
+		l.peers = append(l.peers, peer)
+		go drain(peer)
+
+		conn := websocket.NewConnection(<-accepted)
+		if err := conn.SetCredentials(userID, roles[userID], sessionID); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("failed to connect %s: %w", userID, err)
+		}
+		if err := l.registry.RegisterConnection(conn); err != nil {
+			_ = conn.Close()
+			return fmt.Errorf("failed to connect %s: %w", userID, err)
+		}
+		l.conns[userID] = conn
+	}
+	return nil
+}
+
+// sender returns the connection message is replayed from
+// TECHNICAL DISCOVERY: Announcements have no connection; they are sent as the system
+// sender, exactly as the router's Announce does
+func (l *loopback) sender(message *types.Message) interfaces.ConnectionInfo {
+	if message.FromUser == types.SystemSenderID {
+		return systemSender{sessionID: message.SessionID}
+	}
+	if conn, ok := l.conns[message.FromUser]; ok {
+		return conn
+	}
+	return nil
+}
+
+// close unregisters and closes every connection
+func (l *loopback) close() {
+	for _, conn := range l.conns {
+		l.registry.UnregisterConnection(conn)
+		_ = conn.Close()
+	}
+	for _, peer := range l.peers {
+		_ = peer.Close()
+	}
+	if l.server != nil {
+		_ = l.server.Close()
+	}
+}
+
+// drain reads and discards frames until the client end closes
+func drain(peer *gorillaws.Conn) {
+	for {
+		if _, _, err := peer.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// systemSender is the sender of replayed announcements
+type systemSender struct {
+	sessionID string
+}
+
+func (s systemSender) GetUserID() string       { return types.SystemSenderID }
+func (s systemSender) GetRole() string         { return types.SystemRole }
+func (s systemSender) GetSessionID() string    { return s.sessionID }
+func (s systemSender) GetConnectionID() string { return "" }
+func (s systemSender) GetClientIP() string     { return "" }
+func (s systemSender) GetUserAgent() string    { return "" }
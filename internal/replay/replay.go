@@ -0,0 +1,262 @@
+// Package replay re-routes a session's persisted messages through the current router
+// in a sandbox, for debugging routing reports after the fact
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"switchboard/internal/config"
+	"switchboard/internal/router"
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// Store is what a replay reads: the session, its history, its configuration snapshot,
+// the read receipts recorded live and the instructors' inbox preferences
+type Store interface {
+	interfaces.DatabaseManager
+	interfaces.ReadReceiptStore
+	interfaces.PreferenceStore
+}
+
+// Report is the outcome of replaying one session
+type Report struct {
+	SessionID    string              `json:"session_id"`
+	Participants int                 `json:"participants"`
+	Messages     []*MessageOutcome   `json:"messages"`
+	Deliveries   map[string][]string `json:"deliveries"`              // Recipient -> message IDs current rules deliver to them, in order
+	RulesChanged []string            `json:"rules_changed,omitempty"` // Config snapshot fields that differ from the session's recorded one
+}
+
+// MessageOutcome is how current rules route one persisted message
+type MessageOutcome struct {
+	MessageID   string    `json:"message_id"`
+	Type        string    `json:"type"`
+	FromUser    string    `json:"from_user"`
+	Timestamp   time.Time `json:"timestamp"`
+	Recipients  []string  `json:"recipients"`            // Delivered to in the replay
+	ReadBy      []string  `json:"read_by,omitempty"`     // Read receipts recorded live
+	Error       string    `json:"error,omitempty"`       // Why current rules refuse it
+	Differences []string  `json:"differences,omitempty"` // How the outcome differs from what was recorded
+}
+
+// Differing counts the messages whose outcome differs from what was recorded
+func (r *Report) Differing() int {
+	count := 0
+	for _, outcome := range r.Messages {
+		if len(outcome.Differences) > 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// Run replays sessionID's history from store through a router configured from cfg
+// ARCHITECTURAL DISCOVERY: A fresh registry holds a loopback WebSocket connection for
+// every participant, and the real Router routes each message from its sender's
+// connection; writes to store are discarded, so the database is only read
+// FUNCTIONAL DISCOVERY: The router's clock is moved to each message's recorded time,
+// so rate limit windows and request deadlines are judged as they were live. Everyone
+// is connected throughout, so a recipient list is who current rules address, not who
+// happened to be online; reactions and session locks are not in history and are not
+// replayed
+func Run(ctx context.Context, store Store, sessionID string, cfg *config.Config) (*Report, error) {
+	session, err := store.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session %s: %w", sessionID, err)
+	}
+	history, err := store.GetSessionHistory(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load history of session %s: %w", sessionID, err)
+	}
+
+	start := session.StartTime
+	if len(history) > 0 {
+		start = history[0].Timestamp
+	}
+	clk := testsupport.NewFakeClock(start)
+	registry := websocket.NewRegistry()
+	messageRouter := newRouter(registry, &sandboxStore{Store: store}, session, cfg, clk)
+
+	participants, err := connectParticipants(registry, session, history)
+	if err != nil {
+		return nil, err
+	}
+	defer participants.close()
+
+	report := &Report{
+		SessionID:    sessionID,
+		Participants: len(participants.conns),
+		Deliveries:   make(map[string][]string),
+	}
+	for _, recorded := range history {
+		if now := clk.Now(); recorded.Timestamp.After(now) {
+			clk.Advance(recorded.Timestamp.Sub(now))
+		}
+		outcome, err := replayMessage(ctx, messageRouter, participants, store, recorded)
+		if err != nil {
+			return nil, err
+		}
+		for _, recipient := range outcome.Recipients {
+			report.Deliveries[recipient] = append(report.Deliveries[recipient], outcome.MessageID)
+		}
+		report.Messages = append(report.Messages, outcome)
+	}
+
+	recordedConfig, err := store.GetSessionConfig(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config of session %s: %w", sessionID, err)
+	}
+	if recordedConfig != nil {
+		report.RulesChanged = changedRules(recordedConfig, messageRouter.ConfigSnapshot())
+	}
+	return report, nil
+}
+
+// newRouter builds a router with the routing rules the application applies from cfg
+// TECHNICAL DISCOVERY: The roster is the session's recorded student list, standing in
+// for the session manager a running server asks
+func newRouter(registry *websocket.Registry, store *sandboxStore, session *types.Session, cfg *config.Config, clk interfaces.Clock) *router.Router {
+	messageRouter := router.NewRouter(registry, store)
+	messageRouter.SetClock(clk)
+	if cfg.Router != nil {
+		messageRouter.SetContentAllowlist(cfg.Router.ContentAllowlist, cfg.Router.StrictContent)
+		messageRouter.SetAttachmentRules(cfg.Router.AttachmentMIMETypes, cfg.Router.MaxAttachmentBytes, cfg.Router.StrictAttachments)
+		messageRouter.SetRejectLateSubmissions(cfg.Router.RejectLateSubmissions)
+		messageRouter.SetDefaultContexts(cfg.Router.DefaultContexts)
+		messageRouter.SetReactionCodes(cfg.Router.ReactionCodes)
+		messageRouter.SetMaxBroadcastRecipients(cfg.Router.MaxBroadcastRecipients)
+	}
+	rostered := make(map[string]bool, len(session.StudentIDs))
+	for _, studentID := range session.StudentIDs {
+		rostered[studentID] = true
+	}
+	messageRouter.SetRecipientRoster(func(sessionID, userID string) (bool, bool) {
+		return sessionID == session.ID && rostered[userID], rostered[userID]
+	}, cfg.Router != nil && cfg.Router.PersistUnknownRecipients)
+	messageRouter.SetRateLimits(config.NewStore(cfg).RateLimits)
+	messageRouter.SetPreferenceStore(store)
+	return messageRouter
+}
+
+// replayMessage routes a copy of recorded from its sender and compares the outcome
+// with what was recorded
+func replayMessage(ctx context.Context, messageRouter *router.Router, participants *loopback, store Store, recorded *types.Message) (*MessageOutcome, error) {
+	outcome := &MessageOutcome{
+		MessageID:  recorded.ID,
+		Type:       recorded.Type,
+		FromUser:   recorded.FromUser,
+		Timestamp:  recorded.Timestamp,
+		Recipients: []string{},
+	}
+	reads, err := store.GetMessageReads(ctx, recorded.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reads of message %s: %w", recorded.ID, err)
+	}
+	for _, read := range reads {
+		outcome.ReadBy = append(outcome.ReadBy, read.UserID)
+	}
+
+	message, err := copyMessage(recorded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy message %s: %w", recorded.ID, err)
+	}
+	result, err := messageRouter.RouteMessage(ctx, message, participants.sender(recorded))
+	if err != nil {
+		outcome.Error = err.Error()
+		outcome.Differences = append(outcome.Differences, "refused: "+err.Error())
+		return outcome, nil
+	}
+	outcome.Recipients = append(outcome.Recipients, result.Delivered...)
+
+	delivered := make(map[string]bool, len(result.Delivered))
+	for _, recipient := range result.Delivered {
+		delivered[recipient] = true
+	}
+	for _, reader := range outcome.ReadBy {
+		if !delivered[reader] {
+			outcome.Differences = append(outcome.Differences, fmt.Sprintf("read by %s, who would not receive it", reader))
+		}
+	}
+	if recordedLate, late := recorded.Content["late"] == true, message.Content["late"] == true; recordedLate != late {
+		outcome.Differences = append(outcome.Differences, fmt.Sprintf("late flag would be %t, recorded %t", late, recordedLate))
+	}
+	if !sameContentIgnoringLate(recorded.Content, message.Content) {
+		outcome.Differences = append(outcome.Differences, "content would be filtered differently")
+	}
+	return outcome, nil
+}
+
+// copyMessage returns the fields a client sends, with content deep-copied through JSON
+// TECHNICAL DISCOVERY: The router rewrites content in place (deadlines, late flags,
+// allowlists), and the recorded copy is needed afterwards for the comparison
+func copyMessage(recorded *types.Message) (*types.Message, error) {
+	encoded, err := json.Marshal(recorded.Content)
+	if err != nil {
+		return nil, err
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal(encoded, &content); err != nil {
+		return nil, err
+	}
+	return &types.Message{
+		SessionID: recorded.SessionID,
+		Type:      recorded.Type,
+		Context:   recorded.Context,
+		FromUser:  recorded.FromUser,
+		ToUser:    recorded.ToUser,
+		ToUsers:   append([]string(nil), recorded.ToUsers...),
+		Content:   content,
+	}, nil
+}
+
+func sameContentIgnoringLate(recorded, replayed map[string]interface{}) bool {
+	strip := func(content map[string]interface{}) map[string]interface{} {
+		copied := make(map[string]interface{}, len(content))
+		for key, value := range content {
+			if key != "late" {
+				copied[key] = value
+			}
+		}
+		return copied
+	}
+	return reflect.DeepEqual(strip(recorded), strip(replayed))
+}
+
+// changedRules names the top-level snapshot fields whose values differ
+func changedRules(recorded, current *types.SessionConfig) []string {
+	var changed []string
+	recordedValue, currentValue := reflect.ValueOf(*recorded), reflect.ValueOf(*current)
+	configType := recordedValue.Type()
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		if field.Name == "Version" {
+			continue
+		}
+		if !reflect.DeepEqual(recordedValue.Field(i).Interface(), currentValue.Field(i).Interface()) {
+			changed = append(changed, field.Name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// sandboxStore reads through to the replayed database and discards the router's writes
+type sandboxStore struct {
+	Store
+}
+
+func (s *sandboxStore) StoreMessage(ctx context.Context, message *types.Message) error {
+	return nil
+}
+
+func (s *sandboxStore) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error {
+	return nil
+}
@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/internal/config"
+	"switchboard/internal/database"
+	pkgdatabase "switchboard/pkg/database"
+	"switchboard/pkg/types"
+)
+
+// FUNCTIONAL VALIDATION TEST: Stored history is re-routed under current rules, and
+// refusals, stripped content and read receipts the new routing contradicts are reported
+func TestRun(t *testing.T) {
+	ctx := context.Background()
+	dbConfig := pkgdatabase.DefaultConfig()
+	dbConfig.DatabasePath = filepath.Join(t.TempDir(), "replay.db")
+	manager, err := database.NewManager(dbConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Close()
+	if err := pkgdatabase.NewMigrationManager(manager.GetDB(), "../../migrations").ApplyMigrations(); err != nil {
+		t.Fatalf("Failed to apply migrations: %v", err)
+	}
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	if err := manager.CreateSession(ctx, &types.Session{
+		ID:         "s1",
+		Name:       "Class",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1", "student2"},
+		StartTime:  start,
+		Status:     "active",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	store := func(id string, offset time.Duration, message *types.Message) {
+		message.ID, message.SessionID, message.Context, message.Timestamp = id, "s1", "general", start.Add(offset)
+		if err := manager.StoreMessage(ctx, message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	store("m1", time.Second, &types.Message{Type: types.MessageTypeInstructorInbox, FromUser: "student1", Content: map[string]interface{}{"text": "hi"}})
+	store("m2", 2*time.Second, &types.Message{Type: types.MessageTypeInstructorInbox, FromUser: "student1", Content: map[string]interface{}{"text": "again", "draft": "x"}})
+	store("m3", 3*time.Second, &types.Message{Type: types.MessageTypeInstructorInbox, FromUser: "student1", Content: map[string]interface{}{"text": "third"}})
+	store("m4", time.Hour, &types.Message{Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1", ToUsers: []string{"student1"}, Content: map[string]interface{}{"text": "hint"}})
+	if _, err := manager.RecordMessageRead(ctx, "s1", &types.MessageRead{MessageID: "m4", UserID: "student2", ReadAt: start.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Router.RateLimitMessages = 2
+	cfg.Router.RateLimitWindow = time.Minute
+	cfg.Router.ContentAllowlist = map[string][]string{types.MessageTypeInstructorInbox: {"text"}}
+	report, err := Run(ctx, manager, "s1", cfg)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if report.Participants != 3 || len(report.Messages) != 4 {
+		t.Fatalf("Expected 4 messages among 3 participants, got %+v", report)
+	}
+	if m1 := report.Messages[0]; len(m1.Differences) != 0 || len(m1.Recipients) != 1 || m1.Recipients[0] != "instructor1" {
+		t.Errorf("Expected m1 delivered to instructor1 unchanged, got %+v", m1)
+	}
+	if m2 := report.Messages[1]; len(m2.Differences) != 1 || m2.Differences[0] != "content would be filtered differently" {
+		t.Errorf("Expected m2's unlisted key reported, got %+v", m2)
+	}
+	if m3 := report.Messages[2]; !strings.Contains(m3.Error, "rate limit") || len(m3.Recipients) != 0 {
+		t.Errorf("Expected m3 refused by the tighter rate limit, got %+v", m3)
+	}
+	if m4 := report.Messages[3]; len(m4.Differences) != 1 || !strings.Contains(m4.Differences[0], "read by student2") {
+		t.Errorf("Expected m4's read by an unaddressed student reported, got %+v", m4)
+	}
+	if report.Differing() != 3 {
+		t.Errorf("Expected 3 differing messages, got %d", report.Differing())
+	}
+	if got := report.Deliveries["instructor1"]; len(got) != 2 || got[0] != "m1" || got[1] != "m2" {
+		t.Errorf("Expected instructor1 to receive m1 and m2, got %v", got)
+	}
+	if got := report.Deliveries["student1"]; len(got) != 1 || got[0] != "m4" {
+		t.Errorf("Expected student1 to receive m4, got %v", got)
+	}
+
+	history, err := manager.GetSessionHistory(ctx, "s1")
+	if err != nil || len(history) != 4 {
+		t.Errorf("Expected the replay to store nothing, got %d messages (%v)", len(history), err)
+	}
+}
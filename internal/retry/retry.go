@@ -0,0 +1,154 @@
+// Package retry runs operations again after failures, with exponential backoff
+// ARCHITECTURAL DISCOVERY: One policy type replaces the loops that each grew their own
+// sleep, cap and attempt count; callers describe how to retry and keep only the
+// decision of what is worth retrying
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"switchboard/internal/clock"
+	"switchboard/pkg/interfaces"
+)
+
+// Policy describes how often and how soon an operation is retried
+// FUNCTIONAL DISCOVERY: The delay before attempt n+1 is Initial * Multiplier^(n-1),
+// capped at Max, less up to Jitter of itself at random so retriers that failed
+// together do not retry together
+// TECHNICAL DISCOVERY: A Policy is never changed by Do, so one value can be shared by
+// any number of goroutines
+type Policy struct {
+	MaxAttempts    int                                               // Attempts including the first; 0 retries until ctx is done
+	Initial        time.Duration                                     // Delay after the first failure
+	Max            time.Duration                                     // Cap on any one delay; 0 leaves delays uncapped
+	Multiplier     float64                                           // Growth per failure; values below 1 keep delays at Initial
+	Jitter         float64                                           // Fraction of each delay randomized, 0 to 1
+	AttemptTimeout time.Duration                                     // Deadline for each attempt's context; 0 leaves attempts unbounded
+	Retryable      func(err error) bool                              // nil retries every error
+	OnRetry        func(attempt int, err error, delay time.Duration) // Told before each wait, e.g. to log; may be nil
+	Clock          interfaces.Clock                                  // nil waits on the real clock
+}
+
+// Validate reports settings that could never retry sensibly
+func (p Policy) Validate() error {
+	switch {
+	case p.MaxAttempts < 0:
+		return fmt.Errorf("retry max attempts cannot be negative")
+	case p.Initial < 0 || p.Max < 0 || p.AttemptTimeout < 0:
+		return fmt.Errorf("retry delays and timeouts cannot be negative")
+	case p.Max > 0 && p.Max < p.Initial:
+		return fmt.Errorf("retry max delay %v is below the initial delay %v", p.Max, p.Initial)
+	case p.Jitter < 0 || p.Jitter > 1:
+		return fmt.Errorf("retry jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Backoff is the delay after the given failed attempt, counted from 1, before jitter
+func (p Policy) Backoff(attempt int) time.Duration {
+	if attempt < 1 || p.Initial <= 0 {
+		return 0
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if p.Max > 0 && delay > float64(p.Max) {
+		return p.Max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// jittered removes up to Jitter of delay at random
+func (p Policy) jittered(delay time.Duration) time.Duration {
+	if p.Jitter <= 0 || delay <= 0 {
+		return delay
+	}
+	return delay - time.Duration(p.Jitter*rand.Float64()*float64(delay))
+}
+
+// Do runs op until it succeeds, fails with an error the policy does not retry, or
+// runs out of attempts, and returns op's last error
+// FUNCTIONAL DISCOVERY: A wait is cut short when ctx is done, and Do then returns an
+// error wrapping ctx.Err() that also names op's last error
+func (p Policy) Do(ctx context.Context, op func(ctx context.Context) error) error {
+	clk := p.Clock
+	if clk == nil {
+		clk = clock.Real()
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := p.run(ctx, op)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil || (p.MaxAttempts > 0 && attempt >= p.MaxAttempts) {
+			return err
+		}
+		if p.Retryable != nil && !p.Retryable(err) {
+			return err
+		}
+
+		delay := p.jittered(p.Backoff(attempt))
+		var requested *afterError
+		if errors.As(err, &requested) {
+			delay = requested.delay + time.Duration(p.Jitter*rand.Float64()*float64(requested.delay))
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+
+		timer := clk.NewTimer(delay)
+		select {
+		case <-timer.C():
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("retry stopped after %d attempt(s), last error %v: %w", attempt, err, ctx.Err())
+		}
+	}
+}
+
+// run makes one attempt, bounded by AttemptTimeout when set
+func (p Policy) run(ctx context.Context, op func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.AttemptTimeout <= 0 {
+		return op(ctx)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+	return op(attemptCtx)
+}
+
+// afterError carries a delay the failed party asked for, such as a Retry-After header
+type afterError struct {
+	delay time.Duration
+	err   error
+}
+
+func (e *afterError) Error() string { return e.err.Error() }
+func (e *afterError) Unwrap() error { return e.err }
+
+// After wraps err so Do waits delay before the next attempt instead of its backoff
+// FUNCTIONAL DISCOVERY: Jitter is added to a requested delay rather than taken from
+// it, so a server that asked for a pause never sees the retry early
+func After(delay time.Duration, err error) error {
+	return &afterError{delay: delay, err: err}
+}
+
+// Requested reports whether err carries a delay from After; as a Policy's Retryable it
+// retries only failures that asked to be retried
+func Requested(err error) bool {
+	var requested *afterError
+	return errors.As(err, &requested)
+}
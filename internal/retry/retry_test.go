@@ -0,0 +1,244 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errTransient = errors.New("transient")
+
+// FUNCTIONAL VALIDATION TEST: Delays grow by the multiplier and stop at the cap
+func TestPolicy_Backoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		attempt int
+		want    time.Duration
+	}{
+		{"first failure", Policy{Initial: 100 * time.Millisecond, Multiplier: 2}, 1, 100 * time.Millisecond},
+		{"doubles", Policy{Initial: 100 * time.Millisecond, Multiplier: 2}, 4, 800 * time.Millisecond},
+		{"capped", Policy{Initial: 100 * time.Millisecond, Multiplier: 2, Max: 300 * time.Millisecond}, 4, 300 * time.Millisecond},
+		{"fractional multiplier", Policy{Initial: time.Second, Multiplier: 1.5}, 3, 2250 * time.Millisecond},
+		{"constant below 1", Policy{Initial: time.Second, Multiplier: 0.5}, 5, time.Second},
+		{"no initial delay", Policy{Multiplier: 2}, 3, 0},
+		{"before any failure", Policy{Initial: time.Second}, 0, 0},
+		{"overflow uncapped", Policy{Initial: time.Hour, Multiplier: 10}, 40, time.Duration(1<<63 - 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Backoff(tt.attempt); got != tt.want {
+				t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Jitter only ever shortens a computed delay, by at most its fraction
+func TestPolicy_Jitter(t *testing.T) {
+	policy := Policy{Initial: time.Second, Jitter: 0.25}
+	varied := false
+	for i := 0; i < 200; i++ {
+		got := policy.jittered(time.Second)
+		if got < 750*time.Millisecond || got > time.Second {
+			t.Fatalf("Jittered delay %v outside [750ms, 1s]", got)
+		}
+		varied = varied || got != time.Second
+	}
+	if !varied {
+		t.Error("Expected jitter to vary the delay")
+	}
+	if got := (Policy{}).jittered(time.Second); got != time.Second {
+		t.Errorf("Expected no jitter by default, got %v", got)
+	}
+}
+
+// ERROR HANDLING TEST: Settings that could never retry sensibly are refused
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		valid  bool
+	}{
+		{"zero value", Policy{}, true},
+		{"typical", Policy{MaxAttempts: 5, Initial: 100 * time.Millisecond, Max: 2 * time.Second, Multiplier: 2, Jitter: 0.2}, true},
+		{"negative attempts", Policy{MaxAttempts: -1}, false},
+		{"negative delay", Policy{Initial: -time.Second}, false},
+		{"cap below initial", Policy{Initial: time.Second, Max: time.Millisecond}, false},
+		{"jitter above 1", Policy{Jitter: 1.5}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid=%v", err, tt.valid)
+			}
+		})
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Do stops on success, on errors the classifier refuses,
+// and after the last attempt, returning the last error
+func TestPolicy_Do(t *testing.T) {
+	errFatal := errors.New("fatal")
+	tests := []struct {
+		name     string
+		failures []error // Returned by successive attempts; nil afterwards
+		want     error
+		attempts int
+	}{
+		{"first try", nil, nil, 1},
+		{"recovers", []error{errTransient, errTransient}, nil, 3},
+		{"gives up", []error{errTransient, errTransient, errTransient, errTransient}, errTransient, 3},
+		{"not retryable", []error{errFatal, errTransient}, errFatal, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			policy := Policy{
+				MaxAttempts: 3,
+				Initial:     time.Millisecond,
+				Multiplier:  2,
+				Retryable:   func(err error) bool { return !errors.Is(err, errFatal) },
+				OnRetry:     func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) },
+			}
+			attempts := 0
+			err := policy.Do(context.Background(), func(context.Context) error {
+				attempts++
+				if attempts <= len(tt.failures) {
+					return tt.failures[attempts-1]
+				}
+				return nil
+			})
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+			if attempts != tt.attempts {
+				t.Errorf("Expected %d attempts, got %d", tt.attempts, attempts)
+			}
+			for i, delay := range delays {
+				if want := policy.Backoff(i + 1); delay != want {
+					t.Errorf("Wait %d was %v, want %v", i+1, delay, want)
+				}
+			}
+		})
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: A requested delay replaces the backoff and is never shortened
+func TestPolicy_DoRequestedDelay(t *testing.T) {
+	var waited time.Duration
+	policy := Policy{
+		MaxAttempts: 2,
+		Initial:     time.Hour,
+		Jitter:      0.5,
+		Retryable:   Requested,
+		OnRetry:     func(attempt int, err error, delay time.Duration) { waited = delay },
+	}
+	attempts := 0
+	err := policy.Do(context.Background(), func(context.Context) error {
+		attempts++
+		if attempts == 1 {
+			return After(10*time.Millisecond, errTransient)
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("Expected success on the second attempt, got %d attempts: %v", attempts, err)
+	}
+	if waited < 10*time.Millisecond || waited > 15*time.Millisecond {
+		t.Errorf("Expected a wait between 10ms and 15ms, got %v", waited)
+	}
+
+	attempts = 0
+	err = policy.Do(context.Background(), func(context.Context) error {
+		attempts++
+		return errTransient
+	})
+	if !errors.Is(err, errTransient) || attempts != 1 {
+		t.Errorf("Expected failures without a requested delay not retried, got %d attempts: %v", attempts, err)
+	}
+}
+
+// ERROR HANDLING TEST: Each attempt gets its own deadline, and a done context ends the waits
+func TestPolicy_DoDeadlines(t *testing.T) {
+	policy := Policy{MaxAttempts: 2, Initial: time.Millisecond, AttemptTimeout: 5 * time.Millisecond}
+	attempts := 0
+	err := policy.Do(context.Background(), func(ctx context.Context) error {
+		attempts++
+		if _, bounded := ctx.Deadline(); !bounded {
+			t.Error("Expected the attempt context to carry a deadline")
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.DeadlineExceeded) || attempts != 2 {
+		t.Errorf("Expected both attempts to time out, got %d attempts: %v", attempts, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	forever := Policy{Initial: time.Hour}
+	attempts = 0
+	done := make(chan error, 1)
+	go func() {
+		done <- forever.Do(ctx, func(context.Context) error {
+			attempts++
+			return errTransient
+		})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) || attempts != 1 {
+			t.Errorf("Expected the wait cut short after one attempt, got %d attempts: %v", attempts, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Do did not return after its context was cancelled")
+	}
+}
+
+// RACE TEST: Retriers sharing one policy keep their own attempt counts and delays
+func TestPolicy_ConcurrentRetriers(t *testing.T) {
+	var retries atomic.Int64
+	policy := Policy{
+		MaxAttempts: 4,
+		Initial:     time.Millisecond,
+		Max:         2 * time.Millisecond,
+		Multiplier:  2,
+		Jitter:      0.5,
+		OnRetry:     func(attempt int, err error, delay time.Duration) { retries.Add(1) },
+	}
+
+	const retriers = 48
+	var wg sync.WaitGroup
+	failures := make(chan error, retriers)
+	for i := 0; i < retriers; i++ {
+		wg.Add(1)
+		go func(succeedOn int) {
+			defer wg.Done()
+			attempts := 0
+			err := policy.Do(context.Background(), func(context.Context) error {
+				attempts++
+				if attempts < succeedOn {
+					return errTransient
+				}
+				return nil
+			})
+			if err != nil || attempts != succeedOn {
+				failures <- errors.Join(err, errors.New("wrong attempt count"))
+			}
+		}(i%4 + 1)
+	}
+	wg.Wait()
+	close(failures)
+	for err := range failures {
+		t.Error(err)
+	}
+	// Retriers succeeding on attempts 1-4 retry 0-3 times, in equal numbers
+	if got := retries.Load(); got != retriers/4*6 {
+		t.Errorf("Expected %d retries in all, got %d", retriers/4*6, got)
+	}
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// systemSender is the connection identity of messages the server sends itself
+// ARCHITECTURAL DISCOVERY: Only constructed in process; clients cannot claim the
+// system role because the WebSocket handler accepts students and instructors only
+type systemSender struct {
+	sessionID string
+}
+
+func (s systemSender) GetUserID() string       { return types.SystemSenderID }
+func (s systemSender) GetRole() string         { return types.SystemRole }
+func (s systemSender) GetSessionID() string    { return s.sessionID }
+func (s systemSender) GetConnectionID() string { return "" }
+func (s systemSender) GetClientIP() string     { return "" }
+func (s systemSender) GetUserAgent() string    { return "" }
+
+// Announce sends an instructor_broadcast from the system sender into a session
+// FUNCTIONAL DISCOVERY: Goes through RouteMessage like any client message, so the
+// announcement is validated, filtered, persisted and replayed with session history;
+// an empty context uses the broadcast default
+func (r *Router) Announce(ctx context.Context, sessionID, messageContext string, content map[string]interface{}) (types.RouteResult, error) {
+	message := &types.Message{
+		SessionID: sessionID,
+		Type:      types.MessageTypeInstructorBroadcast,
+		Context:   messageContext,
+		FromUser:  types.SystemSenderID,
+		Content:   content,
+	}
+	return r.RouteMessage(ctx, message, systemSender{sessionID: sessionID})
+}
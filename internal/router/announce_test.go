@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestRouter_Announce tests functional validation - system announcements reach session students flagged as system
+func TestRouter_Announce(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := newMessageStore()
+	router := NewRouter(registry, store)
+	_, studentReceived := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	_, otherReceived := setupReceivingConnection(t, registry, "student2", "student", "session2")
+	instructor, _ := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+
+	result, err := router.Announce(context.Background(), "session1", "", map[string]interface{}{"text": "Campus closing at noon"})
+	if err != nil {
+		t.Fatalf("Announce failed: %v", err)
+	}
+	if len(result.Delivered) != 1 || result.Delivered[0] != "student1" {
+		t.Errorf("Expected delivery to the session's student only, got %+v", result)
+	}
+	received := receiveRouted(t, studentReceived)
+	if received["from_user"] != types.SystemSenderID || received["system"] != true || received["type"] != types.MessageTypeInstructorBroadcast {
+		t.Errorf("Unexpected announcement envelope: %v", received)
+	}
+	if stored := store.messages[result.MessageID]; !stored.System || stored.FromUser != types.SystemSenderID {
+		t.Errorf("Expected the announcement persisted from the system sender, got %+v", stored)
+	}
+	select {
+	case msg := <-otherReceived:
+		t.Errorf("Student in another session received %v", msg)
+	default:
+	}
+
+	// One announcement per session would otherwise trip the per-user rate limit
+	for i := 0; i < 100; i++ {
+		if _, err := router.Announce(context.Background(), "empty-session", "", map[string]interface{}{"text": "again"}); err != nil {
+			t.Fatalf("Announcement %d refused: %v", i, err)
+		}
+	}
+
+	// The system sender may only broadcast
+	request := &types.Message{SessionID: "session1", Type: types.MessageTypeRequest, FromUser: types.SystemSenderID, ToUser: stringPtr("student1")}
+	if _, err := router.RouteMessage(context.Background(), request, systemSender{sessionID: "session1"}); !errors.Is(err, ErrUnauthorizedMessageType) {
+		t.Errorf("Expected ErrUnauthorizedMessageType, got %v", err)
+	}
+
+	// Clients cannot mark their own messages as system
+	spoofed := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		Content:   map[string]interface{}{"text": "official"},
+		System:    true,
+	}
+	if _, err := router.RouteMessage(context.Background(), spoofed, instructor); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if received := receiveRouted(t, studentReceived); spoofed.System || received["system"] != nil {
+		t.Errorf("Expected the client-set system flag to be cleared, got %v", received)
+	}
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"switchboard/pkg/types"
+)
+
+// DefaultMaxAttachmentBytes caps an inline attachment's decoded size
+// FUNCTIONAL DISCOVERY: 32KB fits a cropped screenshot and stays well inside the 64KB
+// content limit once base64 adds a third
+const DefaultMaxAttachmentBytes = 32 << 10
+
+// Reasons an AttachmentError gives for refusing an inline attachment
+const (
+	AttachmentMalformed      = "malformed"        // Does not follow the attachment convention
+	AttachmentMIMENotAllowed = "mime_not_allowed" // Media type not on the allowlist
+	AttachmentTooLarge       = "too_large"        // Decoded data over the cap
+)
+
+// AttachmentError refuses a message whose inline attachment breaks the attachment rules
+// TECHNICAL DISCOVERY: Unwraps to ErrAttachmentRejected, so callers can match any
+// attachment refusal without the reasons
+type AttachmentError struct {
+	Reason   string
+	MIME     string // Empty when malformed
+	Size     int    // Decoded bytes, set for too_large
+	MaxBytes int    // The cap, set for too_large
+	Err      error  // The parse error, set for malformed
+}
+
+func (e *AttachmentError) Error() string {
+	switch e.Reason {
+	case AttachmentMIMENotAllowed:
+		return fmt.Sprintf("attachment type %q is not allowed", e.MIME)
+	case AttachmentTooLarge:
+		return fmt.Sprintf("attachment is %d bytes, over the %d byte limit", e.Size, e.MaxBytes)
+	default:
+		return e.Err.Error()
+	}
+}
+
+func (e *AttachmentError) Unwrap() error {
+	return ErrAttachmentRejected
+}
+
+// attachmentPolicy enforces the inline attachment MIME allowlist and size cap
+// ARCHITECTURAL DISCOVERY: Checked after content allowlists, so an allowlist that
+// drops the attachment key leaves nothing here to check
+// FUNCTIONAL DISCOVERY: Like content allowlists, a violation is stripped - the rest of
+// the message is still routed - unless strict, when the message is refused
+type attachmentPolicy struct {
+	allowed  map[string]bool // Media types, or "type/*" for a whole top-level type
+	maxBytes int
+	strict   bool
+
+	stripped atomic.Int64
+	rejected atomic.Int64
+}
+
+// newAttachmentPolicy compiles mimeTypes; nil, with no types, leaves attachments unchecked
+func newAttachmentPolicy(mimeTypes []string, maxBytes int, strict bool) *attachmentPolicy {
+	if len(mimeTypes) == 0 {
+		return nil
+	}
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxAttachmentBytes
+	}
+	policy := &attachmentPolicy{
+		allowed:  make(map[string]bool, len(mimeTypes)),
+		maxBytes: maxBytes,
+		strict:   strict,
+	}
+	for _, mimeType := range mimeTypes {
+		policy.allowed[strings.ToLower(mimeType)] = true
+	}
+	return policy
+}
+
+// allows reports whether mediaType is on the allowlist
+func (p *attachmentPolicy) allows(mediaType string) bool {
+	if p.allowed[mediaType] {
+		return true
+	}
+	topLevel, _, _ := strings.Cut(mediaType, "/")
+	return p.allowed[topLevel+"/*"]
+}
+
+// apply checks message's inline attachment, stripping or refusing a violation
+func (p *attachmentPolicy) apply(message *types.Message) error {
+	if message.Content == nil {
+		return nil
+	}
+	attachment, err := types.ParseAttachment(message.Content)
+	var violation *AttachmentError
+	switch {
+	case err != nil:
+		violation = &AttachmentError{Reason: AttachmentMalformed, Err: err}
+	case attachment == nil:
+		return nil
+	case !p.allows(attachment.MIME):
+		violation = &AttachmentError{Reason: AttachmentMIMENotAllowed, MIME: attachment.MIME}
+	case attachment.Size > p.maxBytes:
+		violation = &AttachmentError{Reason: AttachmentTooLarge, MIME: attachment.MIME, Size: attachment.Size, MaxBytes: p.maxBytes}
+	default:
+		return nil
+	}
+
+	if p.strict {
+		p.rejected.Add(1)
+		return violation
+	}
+	// TECHNICAL DISCOVERY: Copied rather than deleted in place, as filterMap does
+	stripped := make(map[string]interface{}, len(message.Content))
+	for key, value := range message.Content {
+		if key != types.AttachmentContentKey {
+			stripped[key] = value
+		}
+	}
+	message.Content = stripped
+	p.stripped.Add(1)
+	return nil
+}
@@ -0,0 +1,118 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// pngAttachment returns content carrying a PNG of size decoded bytes
+func pngAttachment(size int) map[string]interface{} {
+	data := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, size-8)...)
+	return map[string]interface{}{
+		"text": "my screen",
+		"attachment": map[string]interface{}{
+			"mime":        "image/png",
+			"data_base64": base64.StdEncoding.EncodeToString(data),
+			"filename":    "screen.png",
+		},
+	}
+}
+
+func inboxMessage(content map[string]interface{}) *types.Message {
+	return &types.Message{Type: types.MessageTypeInstructorInbox, FromUser: "student1", Content: content}
+}
+
+// TestAttachmentPolicy_StrictRejects tests functional validation - an oversized PNG and
+// an executable are refused with typed errors, and an allowed image passes untouched
+func TestAttachmentPolicy_StrictRejects(t *testing.T) {
+	policy := newAttachmentPolicy([]string{"image/*", "application/pdf"}, 1024, true)
+
+	if err := policy.apply(inboxMessage(pngAttachment(1024))); err != nil {
+		t.Errorf("Expected a PNG at the cap to pass, got %v", err)
+	}
+
+	err := policy.apply(inboxMessage(pngAttachment(1025)))
+	var attachmentErr *AttachmentError
+	if !errors.As(err, &attachmentErr) || attachmentErr.Reason != AttachmentTooLarge || attachmentErr.Size != 1025 || attachmentErr.MaxBytes != 1024 {
+		t.Errorf("Expected an oversized PNG refused as too_large, got %v", err)
+	}
+
+	executable := inboxMessage(map[string]interface{}{
+		"attachment": map[string]interface{}{
+			"mime":        "application/x-msdownload",
+			"data_base64": base64.StdEncoding.EncodeToString([]byte("MZ\x90\x00")),
+			"filename":    "homework.exe",
+		},
+	})
+	err = policy.apply(executable)
+	if !errors.As(err, &attachmentErr) || attachmentErr.Reason != AttachmentMIMENotAllowed || attachmentErr.MIME != "application/x-msdownload" {
+		t.Errorf("Expected an executable refused as mime_not_allowed, got %v", err)
+	}
+	if !errors.Is(err, ErrAttachmentRejected) {
+		t.Errorf("Expected the refusal to match ErrAttachmentRejected, got %v", err)
+	}
+	if _, kept := executable.Content["attachment"]; !kept {
+		t.Error("Expected a refused message's content left as received")
+	}
+
+	err = policy.apply(inboxMessage(map[string]interface{}{"attachment": map[string]interface{}{"mime": "image/png", "data_base64": "not base64!"}}))
+	if !errors.As(err, &attachmentErr) || attachmentErr.Reason != AttachmentMalformed || !errors.Is(attachmentErr.Err, types.ErrMalformedAttachment) {
+		t.Errorf("Expected undecodable data refused as malformed, got %v", err)
+	}
+
+	if got := policy.rejected.Load(); got != 3 {
+		t.Errorf("Expected 3 rejections counted, got %d", got)
+	}
+}
+
+// TestAttachmentPolicy_StripsViolations tests functional validation - without strict,
+// the attachment is removed and the rest of the message kept
+func TestAttachmentPolicy_StripsViolations(t *testing.T) {
+	policy := newAttachmentPolicy([]string{"image/png"}, 0, false)
+	if policy.maxBytes != DefaultMaxAttachmentBytes {
+		t.Errorf("Expected the default cap, got %d", policy.maxBytes)
+	}
+
+	message := inboxMessage(pngAttachment(DefaultMaxAttachmentBytes + 1))
+	if err := policy.apply(message); err != nil {
+		t.Fatalf("Expected stripping without error, got %v", err)
+	}
+	if _, kept := message.Content["attachment"]; kept || message.Content["text"] != "my screen" {
+		t.Errorf("Expected only the attachment stripped, got %v", message.Content)
+	}
+	if got := policy.stripped.Load(); got != 1 {
+		t.Errorf("Expected 1 attachment stripped, got %d", got)
+	}
+
+	if newAttachmentPolicy(nil, 1024, true) != nil {
+		t.Error("Expected no policy without MIME types")
+	}
+}
+
+// TestRouter_AttachmentRules tests integration validation - refusals reach the caller,
+// nothing refused is stored, and the counters reach ContentFilterStats
+func TestRouter_AttachmentRules(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := newMessageStore()
+	router := NewRouter(registry, store)
+	router.SetAttachmentRules([]string{"image/png"}, 1024, true)
+	student := setupTestConnection(t, registry, "student1", "student", "session1")
+
+	message := inboxMessage(pngAttachment(2048))
+	message.SessionID = "session1"
+	if _, err := router.RouteMessage(context.Background(), message, student); !errors.Is(err, ErrAttachmentRejected) {
+		t.Fatalf("Expected the oversized attachment refused, got %v", err)
+	}
+	if len(store.messages) != 0 {
+		t.Errorf("Expected nothing stored, got %d messages", len(store.messages))
+	}
+	if stats := router.ContentFilterStats(); stats.AttachmentsRejected != 1 || stats.AttachmentsStripped != 0 {
+		t.Errorf("Expected 1 attachment rejection reported, got %+v", stats)
+	}
+}
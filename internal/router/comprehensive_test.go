@@ -24,7 +24,7 @@ func TestRouter_MessageProcessing(t *testing.T) {
 	}
 	
 	// This will fail due to sender not connected, but we can check processing
-	_ = router.RouteMessage(context.Background(), message)
+	_, _ = router.RouteMessage(context.Background(), message, nil)
 	
 	// Verify message was processed (ID and timestamp set)
 	if message.ID == "" {
@@ -52,7 +52,7 @@ func TestRouter_EmptyRegistry(t *testing.T) {
 	}
 	
 	// Should fail because sender is not connected
-	err := router.RouteMessage(context.Background(), message)
+	_, err := router.RouteMessage(context.Background(), message, nil)
 	if err != ErrSenderNotConnected {
 		t.Errorf("Expected ErrSenderNotConnected for empty registry, got %v", err)
 	}
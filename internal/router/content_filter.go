@@ -0,0 +1,152 @@
+package router
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"switchboard/pkg/types"
+)
+
+// contentFilter enforces per-message-type content key allowlists
+// ARCHITECTURAL DISCOVERY: Allowlists are compiled once into path trees, so filtering
+// a message is a single walk of its content with no string splitting on the hot path
+// FUNCTIONAL DISCOVERY: Types without an allowlist pass through untouched; an entry
+// that names a key allows its whole value, while a dotted path such as "scores.math"
+// allows only that branch. Arrays are transparent - "events.type" keeps "type" in
+// every map inside the events array
+type contentFilter struct {
+	allowed map[string]*allowNode // Message type -> compiled allowlist
+	strict  bool
+
+	keysStripped     atomic.Int64
+	messagesStripped atomic.Int64
+	messagesRejected atomic.Int64
+}
+
+// allowNode is one level of a compiled allowlist
+// TECHNICAL DISCOVERY: A node with all set allows everything beneath it, so "scores"
+// listed alongside "scores.math" keeps the broader rule
+type allowNode struct {
+	all      bool
+	children map[string]*allowNode
+}
+
+// newContentFilter compiles allowlist; nil means no filtering
+func newContentFilter(allowlist map[string][]string, strict bool) *contentFilter {
+	if len(allowlist) == 0 {
+		return nil
+	}
+
+	filter := &contentFilter{
+		allowed: make(map[string]*allowNode, len(allowlist)),
+		strict:  strict,
+	}
+	for messageType, paths := range allowlist {
+		root := &allowNode{children: make(map[string]*allowNode)}
+		for _, path := range paths {
+			root.add(strings.Split(path, "."))
+		}
+		filter.allowed[messageType] = root
+	}
+	return filter
+}
+
+func (n *allowNode) add(segments []string) {
+	child, exists := n.children[segments[0]]
+	if !exists {
+		child = &allowNode{children: make(map[string]*allowNode)}
+		n.children[segments[0]] = child
+	}
+	if len(segments) == 1 {
+		child.all = true
+		return
+	}
+	child.add(segments[1:])
+}
+
+// apply filters message content in place
+// FUNCTIONAL DISCOVERY: In strict mode nothing is modified - the message is refused
+// and the error names every disallowed path so client developers can fix the sender
+func (f *contentFilter) apply(message *types.Message) error {
+	root, exists := f.allowed[message.Type]
+	if !exists || message.Content == nil {
+		return nil
+	}
+
+	var stripped []string
+	filtered := filterMap(message.Content, root, "", &stripped)
+	if len(stripped) == 0 {
+		return nil
+	}
+
+	if f.strict {
+		f.messagesRejected.Add(1)
+		return fmt.Errorf("%w: %s", ErrContentKeyNotAllowed, strings.Join(uniqueSorted(stripped), ", "))
+	}
+
+	f.keysStripped.Add(int64(len(stripped)))
+	f.messagesStripped.Add(1)
+	message.Content = filtered
+	return nil
+}
+
+// stats reports the filter's counters
+func (f *contentFilter) stats() types.ContentFilterStats {
+	return types.ContentFilterStats{
+		KeysStripped:     f.keysStripped.Load(),
+		MessagesStripped: f.messagesStripped.Load(),
+		MessagesRejected: f.messagesRejected.Load(),
+	}
+}
+
+// filterMap returns a copy of content holding only keys allowed by node
+// TECHNICAL DISCOVERY: Copies rather than deleting in place, so a rejected message in
+// strict mode leaves the sender's content exactly as received
+func filterMap(content map[string]interface{}, node *allowNode, prefix string, stripped *[]string) map[string]interface{} {
+	filtered := make(map[string]interface{}, len(content))
+	for key, value := range content {
+		child, exists := node.children[key]
+		if !exists {
+			*stripped = append(*stripped, prefix+key)
+			continue
+		}
+		if child.all {
+			filtered[key] = value
+			continue
+		}
+		filtered[key] = filterValue(value, child, prefix+key+".", stripped)
+	}
+	return filtered
+}
+
+// filterValue applies node to a nested value
+// FUNCTIONAL DISCOVERY: Scalars under a partially allowed key are kept - they carry no
+// keys to strip - and arrays are filtered element by element
+func filterValue(value interface{}, node *allowNode, prefix string, stripped *[]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return filterMap(v, node, prefix, stripped)
+	case []interface{}:
+		filtered := make([]interface{}, len(v))
+		for i, element := range v {
+			filtered[i] = filterValue(element, node, prefix, stripped)
+		}
+		return filtered
+	default:
+		return value
+	}
+}
+
+// uniqueSorted sorts paths and drops repeats from array elements
+func uniqueSorted(paths []string) []string {
+	sort.Strings(paths)
+	unique := paths[:0]
+	for i, path := range paths {
+		if i == 0 || path != paths[i-1] {
+			unique = append(unique, path)
+		}
+	}
+	return unique
+}
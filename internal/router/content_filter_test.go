@@ -0,0 +1,123 @@
+package router
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"switchboard/pkg/types"
+)
+
+func analyticsMessage(content map[string]interface{}) *types.Message {
+	return &types.Message{Type: types.MessageTypeAnalytics, FromUser: "student1", Content: content}
+}
+
+// TestContentFilter_NestedMapsAndArrays tests functional validation - dotted paths reach into maps and arrays
+func TestContentFilter_NestedMapsAndArrays(t *testing.T) {
+	filter := newContentFilter(map[string][]string{
+		types.MessageTypeAnalytics: {"attention_level", "scores.math", "events.type", "raw"},
+	}, false)
+
+	message := analyticsMessage(map[string]interface{}{
+		"attention_level": 0.8,
+		"secret":          "exfiltrated",
+		"scores": map[string]interface{}{
+			"math":    90.0,
+			"browser": "history",
+		},
+		"events": []interface{}{
+			map[string]interface{}{"type": "click", "url": "https://example.com"},
+			map[string]interface{}{"type": "scroll"},
+			[]interface{}{map[string]interface{}{"type": "nested", "extra": true}},
+			"plain",
+		},
+		"raw": map[string]interface{}{"anything": "goes"},
+	})
+
+	if err := filter.apply(message); err != nil {
+		t.Fatalf("Expected stripping without error, got %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"attention_level": 0.8,
+		"scores":          map[string]interface{}{"math": 90.0},
+		"events": []interface{}{
+			map[string]interface{}{"type": "click"},
+			map[string]interface{}{"type": "scroll"},
+			[]interface{}{map[string]interface{}{"type": "nested"}},
+			"plain",
+		},
+		"raw": map[string]interface{}{"anything": "goes"},
+	}
+	if !reflect.DeepEqual(message.Content, expected) {
+		t.Errorf("Unexpected filtered content:\n got %v\nwant %v", message.Content, expected)
+	}
+
+	stats := filter.stats()
+	if stats.KeysStripped != 4 || stats.MessagesStripped != 1 || stats.MessagesRejected != 0 {
+		t.Errorf("Expected 4 keys stripped from 1 message, got %+v", stats)
+	}
+}
+
+// TestContentFilter_StrictRejects tests functional validation - strict mode refuses without modifying
+func TestContentFilter_StrictRejects(t *testing.T) {
+	filter := newContentFilter(map[string][]string{
+		types.MessageTypeAnalytics: {"attention_level", "events.type"},
+	}, true)
+
+	content := map[string]interface{}{
+		"attention_level": 0.5,
+		"events": []interface{}{
+			map[string]interface{}{"type": "click", "url": "a"},
+			map[string]interface{}{"type": "click", "url": "b"},
+		},
+	}
+	message := analyticsMessage(content)
+
+	err := filter.apply(message)
+	if !errors.Is(err, ErrContentKeyNotAllowed) {
+		t.Fatalf("Expected ErrContentKeyNotAllowed, got %v", err)
+	}
+	if !strings.HasSuffix(err.Error(), ": events.url") {
+		t.Errorf("Error should name each disallowed path once, got %q", err.Error())
+	}
+	if len(message.Content["events"].([]interface{})[0].(map[string]interface{})) != 2 {
+		t.Error("Rejected content should be left as received")
+	}
+
+	if err := filter.apply(analyticsMessage(map[string]interface{}{"attention_level": 1.0})); err != nil {
+		t.Errorf("Allowed content should pass in strict mode, got %v", err)
+	}
+
+	if stats := filter.stats(); stats.MessagesRejected != 1 || stats.KeysStripped != 0 {
+		t.Errorf("Expected one rejection and no stripping, got %+v", stats)
+	}
+}
+
+// TestContentFilter_UnlistedTypesPassThrough tests functional validation - filtering is opt-in per type
+func TestContentFilter_UnlistedTypesPassThrough(t *testing.T) {
+	if newContentFilter(nil, true) != nil {
+		t.Error("An empty allowlist should disable filtering")
+	}
+
+	filter := newContentFilter(map[string][]string{types.MessageTypeAnalytics: {"attention_level"}}, false)
+	message := &types.Message{
+		Type:    types.MessageTypeInstructorInbox,
+		Content: map[string]interface{}{"text": "question", "attachment": "code"},
+	}
+	if err := filter.apply(message); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(message.Content) != 2 {
+		t.Errorf("Unlisted message types should not be filtered, got %v", message.Content)
+	}
+
+	// A broader rule wins over a narrower one for the same key
+	broad := newContentFilter(map[string][]string{types.MessageTypeAnalytics: {"scores.math", "scores"}}, false)
+	scored := analyticsMessage(map[string]interface{}{"scores": map[string]interface{}{"math": 1.0, "art": 2.0}})
+	broad.apply(scored)
+	if len(scored.Content["scores"].(map[string]interface{})) != 2 {
+		t.Errorf("Listing the whole key should keep all of it, got %v", scored.Content)
+	}
+}
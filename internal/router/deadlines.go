@@ -0,0 +1,100 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// Content keys that tie a request_response to its request's deadline
+const (
+	contentDeadline  = "deadline"   // On a request: RFC 3339 time or Unix seconds responses are due by
+	contentRequestID = "request_id" // On a request_response: ID of the request it answers
+	contentLate      = "late"       // Set by the server on a response sent after the deadline
+)
+
+// checkDeadline records a request's deadline and judges the responses to it
+// ARCHITECTURAL DISCOVERY: The deadline lives in the persisted request's content rather
+// than in router memory, so responses are judged the same after a restart and no
+// per-session state needs releasing when a session ends
+// FUNCTIONAL DISCOVERY: Lateness is decided on server time (the message timestamp set
+// by RouteMessage), never on anything the student's clock reports. A response that
+// names no request, or one not sent to this student, has no deadline to miss
+func (r *Router) checkDeadline(ctx context.Context, message *types.Message) error {
+	switch message.Type {
+	case types.MessageTypeRequest:
+		raw, ok := message.Content[contentDeadline]
+		if !ok {
+			return nil
+		}
+		deadline, ok := parseDeadline(raw)
+		if !ok {
+			return ErrInvalidDeadline
+		}
+		message.Content[contentDeadline] = deadline.UTC().Format(time.RFC3339Nano)
+
+	case types.MessageTypeRequestResponse:
+		delete(message.Content, contentLate) // Only the server decides lateness
+		requestID, _ := message.Content[contentRequestID].(string)
+		if requestID == "" || r.dbManager == nil {
+			return nil
+		}
+		deadline, ok := r.requestDeadline(ctx, message, requestID)
+		if !ok || !message.Timestamp.After(deadline) {
+			return nil
+		}
+		if r.rejectLate {
+			return interfaces.ErrDeadlinePassed
+		}
+		message.Content[contentLate] = true
+	}
+	return nil
+}
+
+// requestDeadline reads the deadline of the request a response answers
+// TECHNICAL DISCOVERY: One indexed primary-key read per correlated response; a lookup
+// failure routes the response unflagged rather than losing a student's submission
+func (r *Router) requestDeadline(ctx context.Context, response *types.Message, requestID string) (time.Time, bool) {
+	request, err := r.dbManager.GetMessage(ctx, response.SessionID, requestID)
+	if err != nil {
+		if !errors.Is(err, interfaces.ErrNotFound) {
+			log.Printf("Failed to load request %s for deadline check: %v", requestID, err)
+		}
+		return time.Time{}, false
+	}
+	if request.Type != types.MessageTypeRequest || request.ToUser == nil || *request.ToUser != response.FromUser {
+		return time.Time{}, false
+	}
+	return parseDeadline(request.Content[contentDeadline])
+}
+
+// parseDeadline accepts an RFC 3339 string or Unix seconds
+// TECHNICAL DISCOVERY: Decoded JSON numbers arrive as float64, while messages built
+// in-process may carry integers; all are normalized to one RFC 3339 form on the request
+func parseDeadline(raw interface{}) (time.Time, bool) {
+	switch value := raw.(type) {
+	case string:
+		deadline, err := time.Parse(time.RFC3339Nano, value)
+		return deadline, err == nil
+	case float64:
+		seconds, fraction := math.Modf(value)
+		return time.Unix(int64(seconds), int64(fraction*1e9)), true
+	case int64:
+		return time.Unix(value, 0), true
+	case int:
+		return time.Unix(int64(value), 0), true
+	}
+	return time.Time{}, false
+}
+
+// SetRejectLateSubmissions refuses late request_responses with ErrDeadlinePassed
+// instead of delivering them with late=true
+// TECHNICAL DISCOVERY: Set before the hub starts; the field is read without locking
+func (r *Router) SetRejectLateSubmissions(reject bool) {
+	r.rejectLate = reject
+}
@@ -0,0 +1,160 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// messageStore keeps routed messages in memory so deadlines can be read back
+// TECHNICAL DISCOVERY: Embeds the interface for the methods routing never calls
+type messageStore struct {
+	interfaces.DatabaseManager
+	mu       sync.Mutex
+	messages map[string]types.Message
+}
+
+func newMessageStore() *messageStore {
+	return &messageStore{messages: make(map[string]types.Message)}
+}
+
+func (s *messageStore) StoreMessage(ctx context.Context, message *types.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages[message.ID] = *message
+	return nil
+}
+
+func (s *messageStore) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error {
+	return nil
+}
+
+func (s *messageStore) GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	message, ok := s.messages[messageID]
+	if !ok || message.SessionID != sessionID {
+		return nil, interfaces.ErrNotFound
+	}
+	return &message, nil
+}
+
+// TestRouteMessage_SubmissionDeadline tests functional validation - late responses are flagged from the stored request
+func TestRouteMessage_SubmissionDeadline(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := newMessageStore()
+	router := NewRouter(registry, store)
+	student, _ := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	setupReceivingConnection(t, registry, "student2", "student", "session1")
+	instructor, instructorReceived := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+
+	request := func(toUser, deadline string) (*types.Message, error) {
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      types.MessageTypeRequest,
+			FromUser:  "instructor1",
+			ToUser:    &toUser,
+			Content:   map[string]interface{}{"text": "Share your code", "deadline": deadline},
+		}
+		_, err := router.RouteMessage(context.Background(), message, instructor)
+		return message, err
+	}
+	respond := func(router *Router, requestID string, content map[string]interface{}) (*types.Message, error) {
+		content["request_id"] = requestID
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      types.MessageTypeRequestResponse,
+			FromUser:  "student1",
+			Content:   content,
+		}
+		_, err := router.RouteMessage(context.Background(), message, student)
+		return message, err
+	}
+
+	if _, err := request("student1", "after class"); !errors.Is(err, ErrInvalidDeadline) {
+		t.Errorf("Expected ErrInvalidDeadline, got %v", err)
+	}
+
+	// Deadlines are stored in UTC
+	passed := time.Now().Add(-time.Hour).In(time.FixedZone("UTC+2", 2*60*60))
+	late, err := request("student1", passed.Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if late.Content["deadline"] != passed.UTC().Format(time.RFC3339) {
+		t.Errorf("Expected the deadline normalized to UTC, got %v", late.Content["deadline"])
+	}
+
+	response, err := respond(router, late.ID, map[string]interface{}{"code": "print(1)"})
+	if err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if stored, _ := store.GetMessage(context.Background(), "session1", response.ID); stored.Content["late"] != true {
+		t.Errorf("Expected the stored response flagged late, got %v", stored.Content)
+	}
+	delivered, _ := receiveRouted(t, instructorReceived)["content"].(map[string]interface{})
+	if delivered["late"] != true {
+		t.Errorf("Expected the instructor delivery flagged late, got %v", delivered)
+	}
+
+	// Unix seconds, as decoded from a JSON number, are normalized the same way
+	unix := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeRequest,
+		FromUser:  "instructor1",
+		ToUser:    late.ToUser,
+		Content:   map[string]interface{}{"deadline": float64(passed.Unix())},
+	}
+	if _, err := router.RouteMessage(context.Background(), unix, instructor); err != nil {
+		t.Fatalf("RouteMessage with Unix deadline failed: %v", err)
+	}
+	if unix.Content["deadline"] != passed.UTC().Format(time.RFC3339) {
+		t.Errorf("Expected the Unix deadline normalized to UTC, got %v", unix.Content["deadline"])
+	}
+	if response, _ := respond(router, unix.ID, map[string]interface{}{"code": "print(1)"}); response.Content["late"] != true {
+		t.Errorf("Expected a response past a Unix deadline flagged late, got %v", response.Content)
+	}
+	receiveRouted(t, instructorReceived)
+
+	// A student cannot clear or set the flag themselves
+	open, err := request("student1", time.Now().Add(time.Hour).Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if response, _ := respond(router, open.ID, map[string]interface{}{"code": "print(2)", "late": true}); response.Content["late"] != nil {
+		t.Errorf("Expected an on-time response unflagged, got %v", response.Content)
+	}
+	if response, _ := respond(router, late.ID, map[string]interface{}{"code": "print(3)", "late": false}); response.Content["late"] != true {
+		t.Errorf("Expected a client late=false overridden, got %v", response.Content)
+	}
+
+	// Only the student the request went to can miss its deadline
+	other, err := request("student2", passed.Format(time.RFC3339))
+	if err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if response, _ := respond(router, other.ID, map[string]interface{}{"code": "print(4)"}); response.Content["late"] != nil {
+		t.Errorf("Expected no deadline from another student's request, got %v", response.Content)
+	}
+
+	// A restarted router reads the deadline back from storage
+	restarted := NewRouter(registry, store)
+	if response, _ := respond(restarted, late.ID, map[string]interface{}{"code": "print(5)"}); response.Content["late"] != true {
+		t.Errorf("Expected the deadline to survive a restart, got %v", response.Content)
+	}
+
+	restarted.SetRejectLateSubmissions(true)
+	response, err = respond(restarted, late.ID, map[string]interface{}{"code": "print(6)"})
+	if !errors.Is(err, interfaces.ErrDeadlinePassed) {
+		t.Errorf("Expected ErrDeadlinePassed, got %v", err)
+	}
+	if _, err := store.GetMessage(context.Background(), "session1", response.ID); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Error("A refused late response should not be stored")
+	}
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// TestRouteMessage_Delegation tests functional validation - a presenter's broadcast is
+// accepted, flagged and sent to everyone else, while ungranted students are refused
+func TestRouteMessage_Delegation(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	locked := false
+	router.SetSessionLock(func(string) bool { return locked }, false)
+
+	presenter := setupTestConnection(t, registry, "student1", "student", "session1")
+	listener := setupTestConnection(t, registry, "student2", "student", "session1")
+	setupTestConnection(t, registry, "instructor1", "instructor", "session1")
+
+	broadcast := func(sender interfaces.ConnectionInfo) (*types.Message, types.RouteResult, error) {
+		message := &types.Message{
+			SessionID:  "session1",
+			Type:       types.MessageTypeInstructorBroadcast,
+			FromUser:   sender.GetUserID(),
+			Content:    map[string]interface{}{"text": "slide 2"},
+			Delegation: &types.MessageDelegation{GrantedBy: "forged"},
+		}
+		result, err := router.RouteMessage(context.Background(), message, sender)
+		return message, result, err
+	}
+
+	if _, _, err := broadcast(presenter); !errors.Is(err, ErrUnauthorizedMessageType) {
+		t.Fatalf("Expected students refused without a delegation lookup, got %v", err)
+	}
+
+	expiresAt := time.Now().Add(10 * time.Minute)
+	router.SetDelegations(func(sessionID, userID, messageType string) (*types.Delegation, bool) {
+		if sessionID != "session1" || userID != "student1" || messageType != types.MessageTypeInstructorBroadcast {
+			return nil, false
+		}
+		return &types.Delegation{SessionID: sessionID, UserID: userID, GrantedBy: "instructor1", ExpiresAt: expiresAt}, true
+	})
+
+	message, result, err := broadcast(presenter)
+	if err != nil {
+		t.Fatalf("Delegated broadcast should route: %v", err)
+	}
+	if message.Delegation == nil || message.Delegation.GrantedBy != "instructor1" || !message.Delegation.ExpiresAt.Equal(expiresAt) {
+		t.Errorf("Expected the message flagged with the grant, got %+v", message.Delegation)
+	}
+	sort.Strings(result.Resolved)
+	if len(result.Resolved) != 2 || result.Resolved[0] != "instructor1" || result.Resolved[1] != "student2" {
+		t.Errorf("Expected the instructor and the other student, got %v", result.Resolved)
+	}
+
+	locked = true
+	if _, _, err := broadcast(presenter); err != nil {
+		t.Errorf("Delegated broadcast should pass a session lock, got %v", err)
+	}
+	if _, _, err := broadcast(listener); !errors.Is(err, ErrUnauthorizedMessageType) {
+		t.Errorf("Expected an ungranted student refused, got %v", err)
+	}
+}
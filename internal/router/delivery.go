@@ -0,0 +1,292 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// Delivery failure recording defaults, used when SetDeliveryFailureStore is given zero
+const (
+	DefaultDeliverySampleAbove = 600    // Failures per minute recorded in full
+	DefaultDeliverySampleRate  = 0.01   // Share recorded above that
+	DefaultDeliveryFailureRows = 100000 // Rows kept in delivery_failures
+)
+
+// deliveryFailureFlushInterval is how long recorded failures wait to be written, so a
+// burst of them costs one write
+const deliveryFailureFlushInterval = 10 * time.Second
+
+// deliveryCounts is one session's delivery record since it started routing
+type deliveryCounts struct {
+	delivered int
+	failed    map[string]int // By reason, filtered included
+}
+
+func (c *deliveryCounts) stats() types.DeliveryStats {
+	stats := types.DeliveryStats{Delivered: c.delivered, ByReason: make(map[string]int, len(c.failed)), Reliability: 1}
+	for reason, count := range c.failed {
+		stats.ByReason[reason] = count
+		if reason != types.DeliveryFailureFiltered {
+			stats.Failed += count
+		}
+	}
+	if attempted := stats.Delivered + stats.Failed; attempted > 0 {
+		stats.FailureRate = float64(stats.Failed) / float64(attempted)
+		stats.Reliability = 1 - stats.FailureRate
+	}
+	return stats
+}
+
+// deliveryTracker counts deliveries per session and samples failures for the store
+// FUNCTIONAL DISCOVERY: Counts are exact; only the rows written are sampled. Up to
+// sampleAbove failures a minute are all kept, after which one in 1/sampleRate is,
+// carrying the weight of those skipped
+// TECHNICAL DISCOVERY: Sampling is a running credit rather than a random draw, so the
+// kept share is exact and the same failures are kept on every run
+type deliveryTracker struct {
+	mu        sync.Mutex
+	sessions  map[string]*deliveryCounts
+	pending   []types.DeliveryFailure
+	scheduled bool
+
+	delivered uint64 // Every session since start, ended ones included
+	failed    uint64 // Likewise, filtered recipients excluded
+
+	minute int64   // Unix minute the sampling window counts
+	seen   int     // Failures seen in that minute
+	credit float64 // Sampled share accrued towards the next kept row
+
+	sampleAbove int
+	sampleRate  float64
+	keep        int
+}
+
+func newDeliveryTracker() *deliveryTracker {
+	return &deliveryTracker{
+		sessions:    make(map[string]*deliveryCounts),
+		sampleAbove: DefaultDeliverySampleAbove,
+		sampleRate:  DefaultDeliverySampleRate,
+		keep:        DefaultDeliveryFailureRows,
+	}
+}
+
+// record counts a routed message's outcome; sampling reports whether failures are
+// kept for the store, and the result whether a flush must be scheduled
+func (t *deliveryTracker) record(sessionID string, delivered int, failures []types.DeliveryFailure, sampling bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts, exists := t.sessions[sessionID]
+	if !exists {
+		counts = &deliveryCounts{failed: make(map[string]int)}
+		t.sessions[sessionID] = counts
+	}
+	counts.delivered += delivered
+	t.delivered += uint64(delivered)
+	for _, failure := range failures {
+		counts.failed[failure.Reason]++
+		if failure.Reason != types.DeliveryFailureFiltered {
+			t.failed++
+		}
+		if sampling {
+			t.sampleLocked(failure)
+		}
+	}
+
+	if len(t.pending) == 0 || t.scheduled {
+		return false
+	}
+	t.scheduled = true
+	return true
+}
+
+// sampleLocked queues failure for the store unless sampling skips it
+func (t *deliveryTracker) sampleLocked(failure types.DeliveryFailure) {
+	if minute := unixMinute(failure.FailedAt); minute != t.minute {
+		t.minute, t.seen, t.credit = minute, 0, 0
+	}
+	t.seen++
+	failure.Weight = 1
+	if t.seen > t.sampleAbove {
+		t.credit += t.sampleRate
+		if t.credit < 1 {
+			return
+		}
+		t.credit--
+		failure.Weight = int(1/t.sampleRate + 0.5)
+	}
+	// A store that is behind loses the newest rows rather than growing memory; the
+	// table would trim anything beyond keep anyway
+	if t.keep > 0 && len(t.pending) >= t.keep {
+		return
+	}
+	t.pending = append(t.pending, failure)
+}
+
+func (t *deliveryTracker) takePending() []types.DeliveryFailure {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pending := t.pending
+	t.pending = nil
+	t.scheduled = false
+	return pending
+}
+
+func (t *deliveryTracker) stats(sessionID string) types.DeliveryStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if counts, exists := t.sessions[sessionID]; exists {
+		return counts.stats()
+	}
+	return (&deliveryCounts{}).stats()
+}
+
+func (t *deliveryTracker) totals() (delivered, failed uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.delivered, t.failed
+}
+
+// forget drops a session's counts and returns its final stats
+func (t *deliveryTracker) forget(sessionID string) (types.DeliveryStats, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts, exists := t.sessions[sessionID]
+	if !exists {
+		return types.DeliveryStats{}, false
+	}
+	delete(t.sessions, sessionID)
+	return counts.stats(), true
+}
+
+func (t *deliveryTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.sessions)
+}
+
+// deliveryFailureReason names why a connection refused a message
+func deliveryFailureReason(err error) string {
+	switch {
+	case errors.Is(err, websocket.ErrReplayBacklogFull):
+		return types.DeliveryFailureBufferFull
+	case errors.Is(err, websocket.ErrWriteTimeout):
+		return types.DeliveryFailureWriteTimeout
+	case errors.Is(err, websocket.ErrInvalidJSON):
+		return types.DeliveryFailureEncodeFailed
+	default:
+		return types.DeliveryFailureConnectionClosed
+	}
+}
+
+// filteredOut returns the recipients inbox preferences removed
+func filteredOut(recipients, kept []*types.Client) []string {
+	if len(kept) == len(recipients) {
+		return nil
+	}
+	keptIDs := make(map[string]bool, len(kept))
+	for _, recipient := range kept {
+		keptIDs[recipient.ID] = true
+	}
+	var removed []string
+	for _, recipient := range recipients {
+		if !keptIDs[recipient.ID] {
+			removed = append(removed, recipient.ID)
+		}
+	}
+	return removed
+}
+
+// SetDeliveryFailureStore records delivery failures in store, every failure up to
+// sampleAbove a minute and a sampleRate share of those beyond, keeping at most keep
+// rows. Zero values take the defaults above
+// ARCHITECTURAL DISCOVERY: Without a store failures are still counted for stats and
+// the end-of-session summary, just not written
+// TECHNICAL DISCOVERY: Set before the hub starts; the fields are read without locking
+func (r *Router) SetDeliveryFailureStore(store interfaces.DeliveryFailureStore, sampleAbove int, sampleRate float64, keep int) {
+	if sampleAbove <= 0 {
+		sampleAbove = DefaultDeliverySampleAbove
+	}
+	if sampleRate <= 0 {
+		sampleRate = DefaultDeliverySampleRate
+	}
+	if keep <= 0 {
+		keep = DefaultDeliveryFailureRows
+	}
+	r.failureStore = store
+	r.deliveries.sampleAbove = sampleAbove
+	r.deliveries.sampleRate = sampleRate
+	r.deliveries.keep = keep
+}
+
+// recordDelivery counts a routed message's outcome and schedules failures to be written
+func (r *Router) recordDelivery(sessionID string, delivered int, failures []types.DeliveryFailure) {
+	if r.deliveries.record(sessionID, delivered, failures, r.failureStore != nil) {
+		r.clock.AfterFunc(deliveryFailureFlushInterval, r.FlushDeliveryFailures)
+	}
+}
+
+// FlushDeliveryFailures writes the failures recorded since the last write
+// FUNCTIONAL DISCOVERY: Also called at shutdown; a failed write is logged and its
+// rows dropped, since the counts behind stats are kept in memory regardless
+func (r *Router) FlushDeliveryFailures() {
+	pending := r.deliveries.takePending()
+	if len(pending) == 0 || r.failureStore == nil {
+		return
+	}
+	if err := r.failureStore.RecordDeliveryFailures(context.Background(), pending, r.deliveries.keep); err != nil {
+		log.Printf("Failed to record %d delivery failures: %v", len(pending), err)
+	}
+}
+
+// DeliveryStats returns a session's delivered and failed recipients so far
+// FUNCTIONAL DISCOVERY: Counted in memory from the first message routed after startup;
+// a restart starts an active session's counts again
+func (r *Router) DeliveryStats(sessionID string) types.DeliveryStats {
+	return r.deliveries.stats(sessionID)
+}
+
+// ReleaseDeliveryStats records an ended session's delivery reliability in its audit
+// trail and drops its counts
+// ARCHITECTURAL DISCOVERY: Registered with the hub's session resources, like
+// ReleaseSessionLatency; the event is what stats and reports show once it has ended
+func (r *Router) ReleaseDeliveryStats(ended types.Session) {
+	stats, exists := r.deliveries.forget(ended.ID)
+	if !exists || r.dbManager == nil {
+		return
+	}
+	event := &types.SessionEvent{
+		SessionID: ended.ID,
+		Type:      types.SessionEventDeliveryReliability,
+		Actor:     "system",
+		Details: map[string]interface{}{
+			"delivered":    stats.Delivered,
+			"failed":       stats.Failed,
+			"by_reason":    stats.ByReason,
+			"failure_rate": stats.FailureRate,
+			"reliability":  stats.Reliability,
+		},
+	}
+	if err := r.dbManager.RecordSessionEvent(context.Background(), event); err != nil {
+		log.Printf("Failed to record delivery reliability for session %s: %v", ended.ID, err)
+	}
+}
+
+// DeliveryTotals returns the recipients delivered to and failed across every session
+// since startup; recipients removed by inbox preferences are not failures
+// FUNCTIONAL DISCOVERY: Kept when sessions end, so a server-wide failure rate can be
+// taken from the difference between two readings
+func (r *Router) DeliveryTotals() (delivered, failed uint64) {
+	return r.deliveries.totals()
+}
+
+// DeliverySessions returns how many sessions have delivery counts
+func (r *Router) DeliverySessions() int {
+	return r.deliveries.count()
+}
@@ -0,0 +1,179 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// failureStore records each batch of delivery failures written
+type failureStore struct {
+	mu      sync.Mutex
+	batches [][]types.DeliveryFailure
+	keep    int
+}
+
+func (s *failureStore) RecordDeliveryFailures(ctx context.Context, failures []types.DeliveryFailure, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.batches = append(s.batches, failures)
+	s.keep = keep
+	return nil
+}
+
+// TestDeliveryTracker_Sampling tests functional validation - failures above the threshold
+// are kept at the sample rate with the weight of those skipped, while counts stay exact
+func TestDeliveryTracker_Sampling(t *testing.T) {
+	tracker := newDeliveryTracker()
+	tracker.sampleAbove = 5
+	tracker.sampleRate = 0.25
+	minute := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	failures := make([]types.DeliveryFailure, 25)
+	for i := range failures {
+		failures[i] = types.DeliveryFailure{Recipient: fmt.Sprintf("student%d", i), Reason: types.DeliveryFailureWriteTimeout, FailedAt: minute.Add(time.Duration(i) * time.Second)}
+	}
+	if !tracker.record("session1", 75, failures, true) {
+		t.Error("Expected the first pending failures to schedule a flush")
+	}
+	if tracker.record("session1", 0, nil, true) {
+		t.Error("Expected one flush scheduled at a time")
+	}
+
+	pending := tracker.takePending()
+	weights := map[int]int{}
+	for _, failure := range pending {
+		weights[failure.Weight]++
+	}
+	if len(pending) != 10 || weights[1] != 5 || weights[4] != 5 {
+		t.Errorf("Expected 5 failures in full and 5 of the other 20 at weight 4, got %v", weights)
+	}
+	stats := tracker.stats("session1")
+	if stats.Delivered != 75 || stats.Failed != 25 || stats.FailureRate != 0.25 || stats.Reliability != 0.75 {
+		t.Errorf("Expected exact counts, got %+v", stats)
+	}
+
+	// The next minute records in full again
+	next := []types.DeliveryFailure{{Reason: types.DeliveryFailureWriteTimeout, FailedAt: minute.Add(time.Minute)}}
+	tracker.record("session1", 0, next, true)
+	if pending := tracker.takePending(); len(pending) != 1 || pending[0].Weight != 1 {
+		t.Errorf("Expected the threshold reset each minute, got %+v", pending)
+	}
+
+	// Without a store failures are only counted
+	tracker.record("session2", 1, next, false)
+	if pending := tracker.takePending(); len(pending) != 0 || tracker.stats("session2").Failed != 1 {
+		t.Errorf("Expected failures counted but not kept, got %d kept", len(pending))
+	}
+}
+
+// TestDeliveryStats_FilteredNotFailed tests functional validation - recipients filtered by
+// preferences are reported by reason but do not lower reliability
+func TestDeliveryStats_FilteredNotFailed(t *testing.T) {
+	counts := &deliveryCounts{delivered: 3, failed: map[string]int{types.DeliveryFailureFiltered: 4, types.DeliveryFailureConnectionClosed: 1}}
+	stats := counts.stats()
+	if stats.Failed != 1 || stats.ByReason[types.DeliveryFailureFiltered] != 4 || stats.Reliability != 0.75 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+	if idle := (&deliveryCounts{}).stats(); idle.Reliability != 1 || idle.FailureRate != 0 {
+		t.Errorf("Expected full reliability with nothing attempted, got %+v", idle)
+	}
+
+	kept := []*types.Client{{ID: "instructor1"}}
+	if removed := filteredOut([]*types.Client{{ID: "instructor1"}, {ID: "instructor2"}}, kept); len(removed) != 1 || removed[0] != "instructor2" {
+		t.Errorf("Expected instructor2 filtered out, got %v", removed)
+	}
+}
+
+// TestDeliveryFailureReason tests error handling - connection write errors map to reasons
+func TestDeliveryFailureReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{websocket.ErrReplayBacklogFull, types.DeliveryFailureBufferFull},
+		{websocket.ErrWriteTimeout, types.DeliveryFailureWriteTimeout},
+		{websocket.ErrConnectionClosed, types.DeliveryFailureConnectionClosed},
+		{websocket.ErrInvalidJSON, types.DeliveryFailureEncodeFailed},
+		{fmt.Errorf("wrapped: %w", websocket.ErrWriteTimeout), types.DeliveryFailureWriteTimeout},
+		{errors.New("unexpected"), types.DeliveryFailureConnectionClosed},
+	}
+	for _, tt := range tests {
+		if got := deliveryFailureReason(tt.err); got != tt.want {
+			t.Errorf("deliveryFailureReason(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestRouter_DeliveryFailures tests functional validation - a failed write is counted,
+// written in a batch after the flush interval and summarized at session end
+func TestRouter_DeliveryFailures(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := &eventStore{messageStore: newMessageStore()}
+	router := NewRouter(registry, store)
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	router.SetClock(clock)
+	failures := &failureStore{}
+	var _ interfaces.DeliveryFailureStore = failures
+	router.SetDeliveryFailureStore(failures, 0, 0, 500)
+
+	student, _ := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	_, instructorReceived := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+	gone, _ := setupReceivingConnection(t, registry, "instructor2", "instructor", "session1")
+	_ = gone.Close()
+
+	message := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorInbox,
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"text": "question"},
+	}
+	result, err := router.RouteMessage(context.Background(), message, student)
+	if err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	receiveRouted(t, instructorReceived)
+	if len(result.Delivered) != 1 || len(result.Dropped) != 1 {
+		t.Fatalf("Expected one delivery and one drop, got %+v", result)
+	}
+
+	stats := router.DeliveryStats("session1")
+	if stats.Delivered != 1 || stats.Failed != 1 || stats.ByReason[types.DeliveryFailureConnectionClosed] != 1 || stats.Reliability != 0.5 {
+		t.Errorf("Unexpected delivery stats: %+v", stats)
+	}
+
+	if len(failures.batches) != 0 {
+		t.Fatal("Expected nothing written before the flush interval")
+	}
+	clock.Advance(deliveryFailureFlushInterval)
+	if len(failures.batches) != 1 || len(failures.batches[0]) != 1 || failures.keep != 500 {
+		t.Fatalf("Expected one batch of one failure kept to 500 rows, got %+v", failures.batches)
+	}
+	failure := failures.batches[0][0]
+	if failure.MessageID != result.MessageID || failure.Recipient != "instructor2" || failure.Reason != types.DeliveryFailureConnectionClosed || failure.Weight != 1 {
+		t.Errorf("Unexpected failure row: %+v", failure)
+	}
+
+	router.ReleaseDeliveryStats(types.Session{ID: "session1"})
+	if router.DeliverySessions() != 0 {
+		t.Error("Expected the session's counts released at session end")
+	}
+	if delivered, failed := router.DeliveryTotals(); delivered != 1 || failed != 1 {
+		t.Errorf("Expected the totals kept past session end, got %d delivered and %d failed", delivered, failed)
+	}
+	if len(store.events) != 1 {
+		t.Fatalf("Expected 1 recorded event, got %d", len(store.events))
+	}
+	event := store.events[0]
+	if event.Type != types.SessionEventDeliveryReliability || event.Details["reliability"] != 0.5 || event.Details["failed"] != 1 {
+		t.Errorf("Unexpected delivery reliability event: %+v", event)
+	}
+}
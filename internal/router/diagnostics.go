@@ -0,0 +1,50 @@
+package router
+
+import (
+	"context"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// diagnosticsKey is the context key for a sampled message's hub measurements
+type diagnosticsKey struct{}
+
+// Diagnostics carries what the hub measured before routing a sampled message
+// ARCHITECTURAL DISCOVERY: Passed through the routing context like the session end
+// reason, so RouteMessage keeps its signature and unsampled messages pay nothing
+type Diagnostics struct {
+	Ingest   time.Duration // Read from the socket until the hub picked the message up
+	HubQueue int           // Messages still waiting in the same hub worker's queue at that moment
+}
+
+// WithDiagnostics marks a message routed with ctx for the instructor overlay
+func WithDiagnostics(ctx context.Context, diagnostics *Diagnostics) context.Context {
+	return context.WithValue(ctx, diagnosticsKey{}, diagnostics)
+}
+
+// DiagnosticsFrom returns the hub measurements, nil when the message is not sampled
+func DiagnosticsFrom(ctx context.Context) *Diagnostics {
+	diagnostics, _ := ctx.Value(diagnosticsKey{}).(*Diagnostics)
+	return diagnostics
+}
+
+// overlay returns a copy of message carrying the diag block for one instructor
+// TECHNICAL DISCOVERY: A copy, because the same message goes to other recipients
+// and observers without it
+func (d *Diagnostics) overlay(message *types.Message, route, write time.Duration, sendQueue int) *types.Message {
+	copied := *message
+	copied.Diag = &types.MessageDiagnostics{
+		IngestMs:  milliseconds(d.Ingest),
+		RouteMs:   milliseconds(route),
+		WriteMs:   milliseconds(write),
+		HubQueue:  d.HubQueue,
+		SendQueue: sendQueue,
+	}
+	return &copied
+}
+
+// milliseconds converts d to fractional milliseconds, rounded to microseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
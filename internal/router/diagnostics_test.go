@@ -0,0 +1,117 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gorillaws "github.com/gorilla/websocket"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// setupReceivingConnection is setupTestConnection whose peer reports what it receives
+func setupReceivingConnection(t *testing.T, registry *websocket.Registry, userID, role, sessionID string) (*websocket.Connection, <-chan map[string]interface{}) {
+	received := make(chan map[string]interface{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		peer, err := testUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer func() { _ = peer.Close() }()
+		for {
+			var msg map[string]interface{}
+			if err := peer.ReadJSON(&msg); err != nil {
+				return
+			}
+			received <- msg
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	raw, _, err := gorillaws.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to dial test server: %v", err)
+	}
+	conn := websocket.NewConnection(raw)
+	t.Cleanup(func() { _ = conn.Close() })
+	if err := conn.SetCredentials(userID, role, sessionID); err != nil {
+		t.Fatalf("Failed to set credentials: %v", err)
+	}
+	if err := registry.RegisterConnection(conn); err != nil {
+		t.Fatalf("Failed to register connection: %v", err)
+	}
+	return conn, received
+}
+
+// receiveRouted waits for the next message delivered to a peer
+func receiveRouted(t *testing.T, received <-chan map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	select {
+	case msg := <-received:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("Recipient did not receive the message")
+		return nil
+	}
+}
+
+// TestRouteMessage_DiagnosticsOverlay tests functional validation - only instructors see sampled timings
+func TestRouteMessage_DiagnosticsOverlay(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	student, studentReceived := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	instructor, instructorReceived := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+
+	sampled := WithDiagnostics(context.Background(), &Diagnostics{Ingest: 1500 * time.Microsecond, HubQueue: 2})
+	question := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorInbox,
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"text": "Why?"},
+	}
+	if _, err := router.RouteMessage(sampled, question, student); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	diag, _ := receiveRouted(t, instructorReceived)["diag"].(map[string]interface{})
+	if diag == nil || diag["ingest_ms"] != 1.5 || diag["hub_queue"] != float64(2) {
+		t.Errorf("Expected the instructor to receive the overlay, got %v", diag)
+	}
+	for _, field := range []string{"route_ms", "write_ms", "send_queue"} {
+		if _, ok := diag[field]; !ok {
+			t.Errorf("Expected %s in the overlay, got %v", field, diag)
+		}
+	}
+	if question.Diag != nil {
+		t.Error("The routed message itself should not carry the overlay")
+	}
+
+	broadcast := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		Content:   map[string]interface{}{"text": "Quiz at 3"},
+	}
+	if _, err := router.RouteMessage(sampled, broadcast, instructor); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if msg := receiveRouted(t, studentReceived); msg["diag"] != nil {
+		t.Errorf("Students should never receive the overlay, got %v", msg["diag"])
+	}
+
+	unsampled := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorInbox,
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"text": "And now?"},
+	}
+	if _, err := router.RouteMessage(context.Background(), unsampled, student); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if msg := receiveRouted(t, instructorReceived); msg["diag"] != nil {
+		t.Errorf("Unsampled messages should not carry the overlay, got %v", msg["diag"])
+	}
+}
@@ -6,11 +6,18 @@ import "errors"
 var (
 	ErrInvalidMessageType      = errors.New("invalid message type")
 	ErrUnauthorizedMessageType = errors.New("user not authorized to send this message type")
-	ErrRateLimitExceeded      = errors.New("rate limit exceeded: 100 messages per minute")
+	ErrRateLimitExceeded      = errors.New("rate limit exceeded")
 	ErrSenderNotConnected     = errors.New("sender not connected")
 	ErrSenderNotInSession     = errors.New("sender not in message session")
 	ErrRecipientNotFound      = errors.New("recipient not found")
 	ErrRecipientNotInSession  = errors.New("recipient not in same session")
 	ErrMissingRecipient       = errors.New("direct message missing recipient")
 	ErrInvalidContext         = errors.New("invalid context field")
+	ErrContentKeyNotAllowed   = errors.New("content key not allowed for message type")
+	ErrAttachmentRejected     = errors.New("attachment rejected")
+	ErrInvalidDeadline        = errors.New("request deadline must be an RFC 3339 time")
+	ErrInvalidReaction        = errors.New("invalid reaction")
+	ErrReactionTargetNotFound = errors.New("reaction target message not found")
+	ErrInvalidReadReceipt     = errors.New("read receipt requires message_id")
+	ErrReadTargetNotFound     = errors.New("read receipt target message not found")
 )
\ No newline at end of file
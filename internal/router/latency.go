@@ -0,0 +1,167 @@
+package router
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// latencyBuckets are the upper bounds of the routing latency histogram
+// TECHNICAL DISCOVERY: Fixed buckets keep each session's histogram at a few hundred
+// bytes however many messages it routes; latencies above the last bound are reported
+// as the largest one seen
+var latencyBuckets = []time.Duration{
+	250 * time.Microsecond,
+	500 * time.Microsecond,
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+}
+
+// latencyHistogram counts routing latencies per bucket; the last count is overflow
+type latencyHistogram struct {
+	counts  [15]int // len(latencyBuckets) + 1
+	samples int
+	max     time.Duration
+}
+
+func (h *latencyHistogram) observe(latency time.Duration) {
+	bucket := len(latencyBuckets)
+	for i, bound := range latencyBuckets {
+		if latency <= bound {
+			bucket = i
+			break
+		}
+	}
+	h.counts[bucket]++
+	h.samples++
+	if latency > h.max {
+		h.max = latency
+	}
+}
+
+// percentile returns the upper bound of the bucket holding quantile q of the samples
+func (h *latencyHistogram) percentile(q float64) time.Duration {
+	rank := int(q*float64(h.samples) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	seen := 0
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			if i == len(latencyBuckets) || latencyBuckets[i] > h.max {
+				return h.max
+			}
+			return latencyBuckets[i]
+		}
+	}
+	return h.max
+}
+
+func (h *latencyHistogram) stats() types.LatencyStats {
+	if h.samples == 0 {
+		return types.LatencyStats{}
+	}
+	return types.LatencyStats{
+		Samples: h.samples,
+		P50Ms:   milliseconds(h.percentile(0.50)),
+		P95Ms:   milliseconds(h.percentile(0.95)),
+	}
+}
+
+// sessionLatencies keeps one routing latency histogram per session
+type sessionLatencies struct {
+	mu       sync.Mutex
+	sessions map[string]*latencyHistogram
+}
+
+func newSessionLatencies() *sessionLatencies {
+	return &sessionLatencies{sessions: make(map[string]*latencyHistogram)}
+}
+
+func (l *sessionLatencies) observe(sessionID string, latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	histogram, exists := l.sessions[sessionID]
+	if !exists {
+		histogram = &latencyHistogram{}
+		l.sessions[sessionID] = histogram
+	}
+	histogram.observe(latency)
+}
+
+func (l *sessionLatencies) stats(sessionID string) types.LatencyStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if histogram, exists := l.sessions[sessionID]; exists {
+		return histogram.stats()
+	}
+	return types.LatencyStats{}
+}
+
+// forget drops a session's histogram and returns its final stats
+func (l *sessionLatencies) forget(sessionID string) (types.LatencyStats, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	histogram, exists := l.sessions[sessionID]
+	if !exists {
+		return types.LatencyStats{}, false
+	}
+	delete(l.sessions, sessionID)
+	return histogram.stats(), true
+}
+
+func (l *sessionLatencies) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.sessions)
+}
+
+// RoutingLatency returns the p50/p95 routing latency of a session's messages so far
+// FUNCTIONAL DISCOVERY: Measured from RouteMessage entry until the message is queued on
+// every recipient connection (RouteTimings.Route + Write); refused messages are not counted
+func (r *Router) RoutingLatency(sessionID string) types.LatencyStats {
+	return r.latencies.stats(sessionID)
+}
+
+// ReleaseSessionLatency records an ended session's routing latency in its audit trail
+// and drops the histogram
+// ARCHITECTURAL DISCOVERY: Registered with the hub's session resources; the persisted
+// routing_latency event is what the stats endpoint reports once the session has ended
+func (r *Router) ReleaseSessionLatency(ended types.Session) {
+	stats, exists := r.latencies.forget(ended.ID)
+	if !exists || r.dbManager == nil {
+		return
+	}
+	event := &types.SessionEvent{
+		SessionID: ended.ID,
+		Type:      types.SessionEventRoutingLatency,
+		Actor:     "system",
+		Details: map[string]interface{}{
+			"samples": stats.Samples,
+			"p50_ms":  stats.P50Ms,
+			"p95_ms":  stats.P95Ms,
+		},
+	}
+	if err := r.dbManager.RecordSessionEvent(context.Background(), event); err != nil {
+		log.Printf("Failed to record routing latency for session %s: %v", ended.ID, err)
+	}
+}
+
+// LatencySessions returns how many sessions have a routing latency histogram
+func (r *Router) LatencySessions() int {
+	return r.latencies.count()
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// eventStore records session events on top of messageStore
+type eventStore struct {
+	*messageStore
+	events []*types.SessionEvent
+}
+
+func (s *eventStore) RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// TestLatencyHistogram_Percentiles tests technical validation - percentiles report bucket bounds, capped at the max sample
+func TestLatencyHistogram_Percentiles(t *testing.T) {
+	histogram := &latencyHistogram{}
+	if stats := histogram.stats(); stats != (types.LatencyStats{}) {
+		t.Errorf("Expected zero stats without samples, got %+v", stats)
+	}
+
+	for i := 0; i < 90; i++ {
+		histogram.observe(300 * time.Microsecond) // 500µs bucket
+	}
+	for i := 0; i < 10; i++ {
+		histogram.observe(40 * time.Millisecond) // 50ms bucket
+	}
+
+	stats := histogram.stats()
+	if stats.Samples != 100 {
+		t.Errorf("Expected 100 samples, got %d", stats.Samples)
+	}
+	if stats.P50Ms != 0.5 {
+		t.Errorf("Expected p50 of 0.5ms, got %v", stats.P50Ms)
+	}
+	if stats.P95Ms != 40 {
+		t.Errorf("Expected p95 capped at the 40ms maximum, got %v", stats.P95Ms)
+	}
+
+	histogram.observe(30 * time.Second) // Overflow
+	if p100 := histogram.percentile(1); p100 != 30*time.Second {
+		t.Errorf("Expected overflow reported as the maximum, got %v", p100)
+	}
+}
+
+// TestRouter_RoutingLatency tests functional validation - latency is tracked per session and recorded at session end
+func TestRouter_RoutingLatency(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := &eventStore{messageStore: newMessageStore()}
+	router := NewRouter(registry, store)
+	student, _ := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	_, instructorReceived := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+
+	for i := 0; i < 3; i++ {
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      types.MessageTypeInstructorInbox,
+			FromUser:  "student1",
+			Content:   map[string]interface{}{"text": "question"},
+		}
+		if _, err := router.RouteMessage(context.Background(), message, student); err != nil {
+			t.Fatalf("RouteMessage failed: %v", err)
+		}
+		receiveRouted(t, instructorReceived)
+	}
+
+	stats := router.RoutingLatency("session1")
+	if stats.Samples != 3 || stats.P50Ms <= 0 || stats.P95Ms < stats.P50Ms {
+		t.Errorf("Unexpected routing latency: %+v", stats)
+	}
+	if other := router.RoutingLatency("session2"); other.Samples != 0 {
+		t.Errorf("Expected no samples for another session, got %+v", other)
+	}
+	if router.LatencySessions() != 1 {
+		t.Errorf("Expected 1 tracked session, got %d", router.LatencySessions())
+	}
+
+	router.ReleaseSessionLatency(types.Session{ID: "session1"})
+	if router.LatencySessions() != 0 || router.RoutingLatency("session1").Samples != 0 {
+		t.Error("Expected the histogram to be reset at session end")
+	}
+	if len(store.events) != 1 {
+		t.Fatalf("Expected 1 recorded event, got %d", len(store.events))
+	}
+	event := store.events[0]
+	if event.SessionID != "session1" || event.Type != types.SessionEventRoutingLatency || event.Details["samples"] != 3 || event.Details["p50_ms"] != stats.P50Ms {
+		t.Errorf("Unexpected routing latency event: %+v", event)
+	}
+
+	// Sessions that routed nothing record nothing
+	router.ReleaseSessionLatency(types.Session{ID: "session2"})
+	if len(store.events) != 1 {
+		t.Errorf("Expected no event for a session without samples, got %d events", len(store.events))
+	}
+}
@@ -44,6 +44,31 @@ func (m *mockDatabaseManager) UpdateSession(ctx context.Context, session *types.
 func (m *mockDatabaseManager) ListActiveSessions(ctx context.Context) ([]*types.Session, error) { return nil, nil }
 func (m *mockDatabaseManager) GetSessionHistory(ctx context.Context, sessionID string) ([]*types.Message, error) { return nil, nil }
 func (m *mockDatabaseManager) HealthCheck(ctx context.Context) error { return nil }
+func (m *mockDatabaseManager) ImportMessages(ctx context.Context, messages []*types.Message) ([]string, error) { return nil, nil }
+func (m *mockDatabaseManager) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error { return nil }
+func (m *mockDatabaseManager) GetMessageMetadata(ctx context.Context, messageID string) (*types.MessageMetadata, error) { return nil, nil }
+func (m *mockDatabaseManager) HealthStatus() types.DatabaseHealth { return types.DatabaseHealth{} }
+func (m *mockDatabaseManager) ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error) { return nil, nil }
+func (m *mockDatabaseManager) TransferSessionOwner(ctx context.Context, sessionID, previousOwner, newOwner, actor string) error { return nil }
+func (m *mockDatabaseManager) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) { return nil, nil }
+func (m *mockDatabaseManager) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) { return nil, nil }
+func (m *mockDatabaseManager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) error { return nil }
+func (m *mockDatabaseManager) SetMessageAnnotation(ctx context.Context, sessionID string, annotation *types.MessageAnnotation) error { return nil }
+func (m *mockDatabaseManager) GetSessionAnnotations(ctx context.Context, sessionID string) ([]*types.MessageAnnotation, error) { return nil, nil }
+func (m *mockDatabaseManager) SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error { return nil }
+func (m *mockDatabaseManager) GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error) { return nil, nil }
+func (m *mockDatabaseManager) GetSessionReactionCounts(ctx context.Context, sessionID string) (map[string]map[string]int, error) { return nil, nil }
+func (m *mockDatabaseManager) GetSessionHistoryAsOf(ctx context.Context, sessionID string, asOf time.Time) ([]*types.Message, error) { return nil, nil }
+func (m *mockDatabaseManager) GetStudentThread(ctx context.Context, sessionID, studentID, afterID string, limit int) ([]*types.Message, error) { return nil, nil }
+func (m *mockDatabaseManager) GetAnalyticsBuckets(ctx context.Context, sessionID, userID string) ([]*types.AnalyticsBucket, error) { return nil, nil }
+func (m *mockDatabaseManager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) error { return nil }
+func (m *mockDatabaseManager) SetSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error { return nil }
+func (m *mockDatabaseManager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) error { return nil }
+func (m *mockDatabaseManager) SetSessionStudents(ctx context.Context, sessionID string, studentIDs []string) error { return nil }
+func (m *mockDatabaseManager) ActiveSessionsByMetadata(ctx context.Context, filters map[string]string) ([]string, error) { return nil, nil }
+func (m *mockDatabaseManager) GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error) { return nil, interfaces.ErrNotFound }
+func (m *mockDatabaseManager) RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error { return nil }
+func (m *mockDatabaseManager) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) { return nil, nil }
 func (m *mockDatabaseManager) Close() error { return nil }
 
 // Test router functionality integration - role permission validation
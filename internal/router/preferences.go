@@ -0,0 +1,193 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// inboxPreferences caches instructors' inbox preferences for the sessions routed so far
+// TECHNICAL DISCOVERY: Each session's map is replaced rather than changed, so the
+// fan-out reads a map it got under the lock without holding it
+type inboxPreferences struct {
+	mu       sync.Mutex
+	sessions map[string]map[string]*types.InboxPreferences // sessionID -> instructorID -> preferences
+}
+
+func newInboxPreferences() *inboxPreferences {
+	return &inboxPreferences{sessions: make(map[string]map[string]*types.InboxPreferences)}
+}
+
+// SetPreferenceStore persists inbox preferences so they survive reconnects and restarts
+// TECHNICAL DISCOVERY: Set before the hub starts; read without locking. Without a
+// store preferences last until the session ends or the process restarts
+func (r *Router) SetPreferenceStore(store interfaces.PreferenceStore) {
+	r.preferenceStore = store
+}
+
+// sessionPreferences returns a session's preferences, loading them on first use
+func (r *Router) sessionPreferences(ctx context.Context, sessionID string) (map[string]*types.InboxPreferences, error) {
+	r.preferences.mu.Lock()
+	cached, loaded := r.preferences.sessions[sessionID]
+	r.preferences.mu.Unlock()
+	if loaded {
+		return cached, nil
+	}
+
+	fresh := make(map[string]*types.InboxPreferences)
+	if r.preferenceStore != nil {
+		stored, err := r.preferenceStore.GetInboxPreferences(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		for _, preferences := range stored {
+			fresh[preferences.InstructorID] = preferences
+		}
+	}
+
+	r.preferences.mu.Lock()
+	defer r.preferences.mu.Unlock()
+	if cached, loaded := r.preferences.sessions[sessionID]; loaded {
+		return cached, nil // Another worker loaded it first
+	}
+	r.preferences.sessions[sessionID] = fresh
+	return fresh, nil
+}
+
+// SetInboxPreferences replaces an instructor's inbox preferences in a session and
+// warns when no instructor connected to it would still receive every inbox context
+// FUNCTIONAL DISCOVERY: Empty filters reset the instructor to receiving everything.
+// A warning never refuses the change - the fan-out falls back to every connected
+// instructor rather than letting a question reach nobody
+func (r *Router) SetInboxPreferences(ctx context.Context, sessionID, instructorID string, filters map[string][]string) (*types.InboxPreferencesUpdate, error) {
+	if err := types.ValidateInboxFilters(filters); err != nil {
+		return nil, err
+	}
+	normalized := make(map[string][]string, len(filters))
+	for messageType, contexts := range filters {
+		normalized[messageType] = append([]string{}, contexts...) // null mutes the type, like []
+	}
+	preferences := &types.InboxPreferences{
+		SessionID:    sessionID,
+		InstructorID: instructorID,
+		Filters:      normalized,
+		UpdatedAt:    r.clock.Now(),
+	}
+
+	if _, err := r.sessionPreferences(ctx, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to load inbox preferences: %w", err)
+	}
+	if r.preferenceStore != nil {
+		if err := r.preferenceStore.SetInboxPreferences(ctx, preferences); err != nil {
+			return nil, err
+		}
+	}
+
+	r.preferences.mu.Lock()
+	next := make(map[string]*types.InboxPreferences, len(r.preferences.sessions[sessionID])+1)
+	for id, existing := range r.preferences.sessions[sessionID] {
+		next[id] = existing
+	}
+	if len(normalized) == 0 {
+		delete(next, instructorID)
+	} else {
+		next[instructorID] = preferences
+	}
+	r.preferences.sessions[sessionID] = next
+	r.preferences.mu.Unlock()
+
+	log.Printf("Inbox preferences updated: session=%s instructor=%s filters=%v", sessionID, instructorID, normalized)
+	return &types.InboxPreferencesUpdate{
+		Preferences: preferences,
+		Warnings:    r.orphanWarnings(sessionID, instructorID, next),
+	}, nil
+}
+
+// orphanWarnings explains which instructor_inbox contexts no instructor would receive
+// ARCHITECTURAL DISCOVERY: Judged against the instructors connected now plus the
+// setter; contexts are open-ended, so when every one of them filters the inbox the
+// warning names the contexts still covered rather than the endless rest
+func (r *Router) orphanWarnings(sessionID, setterID string, preferences map[string]*types.InboxPreferences) []string {
+	instructors := map[string]bool{setterID: true}
+	for _, conn := range r.registry.GetSessionInstructors(sessionID) {
+		instructors[conn.GetUserID()] = true
+	}
+
+	covered := make(map[string]bool)
+	for instructorID := range instructors {
+		contexts, filtered := preferences[instructorID].FilterFor(types.MessageTypeInstructorInbox)
+		if !filtered {
+			return nil // Someone takes every inbox message
+		}
+		for _, c := range contexts {
+			covered[c] = true
+		}
+	}
+
+	if len(covered) == 0 {
+		return []string{"No instructor receives instructor_inbox messages; they will go to every connected instructor"}
+	}
+	names := make([]string, 0, len(covered))
+	for c := range covered {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	return []string{fmt.Sprintf(
+		"No instructor receives instructor_inbox messages outside %s; they will go to every connected instructor",
+		strings.Join(names, ", "),
+	)}
+}
+
+// filterInstructors drops instructors whose preferences exclude message from an
+// instructor fan-out
+// FUNCTIONAL DISCOVERY: An instructor_inbox question every instructor filtered out
+// goes to all of them instead, so a student is never left unanswered by preferences
+// TECHNICAL DISCOVERY: Preferences that cannot be loaded filter nothing; delivering
+// too much beats dropping questions
+func (r *Router) filterInstructors(ctx context.Context, message *types.Message, recipients []*types.Client) []*types.Client {
+	if !types.IsFilterableInboxType(message.Type) || len(recipients) == 0 {
+		return recipients
+	}
+	preferences, err := r.sessionPreferences(ctx, message.SessionID)
+	if err != nil {
+		log.Printf("Failed to load inbox preferences for session %s: %v", message.SessionID, err)
+		return recipients
+	}
+	if len(preferences) == 0 {
+		return recipients
+	}
+
+	kept := make([]*types.Client, 0, len(recipients))
+	for _, recipient := range recipients {
+		if preferences[recipient.ID].Receives(message.Type, message.Context) {
+			kept = append(kept, recipient)
+		}
+	}
+	if len(kept) == 0 && message.Type == types.MessageTypeInstructorInbox {
+		log.Printf("No instructor's preferences take %s context %s in session %s; delivering to all %d",
+			message.Type, message.Context, message.SessionID, len(recipients))
+		return recipients
+	}
+	return kept
+}
+
+// ReleaseInboxPreferences drops an ended session's cached preferences; the stored
+// rows stay with the session
+func (r *Router) ReleaseInboxPreferences(ended types.Session) {
+	r.preferences.mu.Lock()
+	defer r.preferences.mu.Unlock()
+	delete(r.preferences.sessions, ended.ID)
+}
+
+// PreferenceSessions returns how many sessions have preferences cached
+func (r *Router) PreferenceSessions() int {
+	r.preferences.mu.Lock()
+	defer r.preferences.mu.Unlock()
+	return len(r.preferences.sessions)
+}
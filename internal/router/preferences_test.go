@@ -0,0 +1,128 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// preferenceStore keeps inbox preferences in memory for preference tests
+type preferenceStore struct {
+	mu     sync.Mutex
+	stored map[string]*types.InboxPreferences // sessionID/instructorID -> preferences
+	loads  int
+}
+
+func (s *preferenceStore) SetInboxPreferences(ctx context.Context, preferences *types.InboxPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := preferences.SessionID + "/" + preferences.InstructorID
+	if len(preferences.Filters) == 0 {
+		delete(s.stored, key)
+	} else {
+		s.stored[key] = preferences
+	}
+	return nil
+}
+
+func (s *preferenceStore) GetInboxPreferences(ctx context.Context, sessionID string) ([]*types.InboxPreferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	var all []*types.InboxPreferences
+	for _, preferences := range s.stored {
+		if preferences.SessionID == sessionID {
+			all = append(all, preferences)
+		}
+	}
+	return all, nil
+}
+
+// FUNCTIONAL VALIDATION TEST: A TA's filter narrows their inbox, the lead teacher
+// still receives everything, and no question is left without an instructor
+func TestRouter_InboxPreferences(t *testing.T) {
+	ctx := context.Background()
+	registry := websocket.NewRegistry()
+	store := &preferenceStore{stored: make(map[string]*types.InboxPreferences)}
+	router := NewRouter(registry, newMessageStore())
+	router.SetPreferenceStore(store)
+	student, _ := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	setupReceivingConnection(t, registry, "teacher", "instructor", "session1")
+	setupReceivingConnection(t, registry, "ta", "instructor", "session1")
+
+	ask := func(messageType, messageContext string) []string {
+		t.Helper()
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      messageType,
+			Context:   messageContext,
+			FromUser:  "student1",
+			Content:   map[string]interface{}{"text": "Help"},
+		}
+		result, err := router.RouteMessage(ctx, message, student)
+		if err != nil {
+			t.Fatalf("RouteMessage failed: %v", err)
+		}
+		sort.Strings(result.Delivered)
+		return result.Delivered
+	}
+
+	update, err := router.SetInboxPreferences(ctx, "session1", "ta", map[string][]string{
+		types.MessageTypeInstructorInbox: {"technical_issue"},
+	})
+	if err != nil || len(update.Warnings) != 0 {
+		t.Fatalf("Expected the TA's filter stored without warnings, got %+v, %v", update, err)
+	}
+	if got := ask(types.MessageTypeInstructorInbox, "technical_issue"); strings.Join(got, ",") != "ta,teacher" {
+		t.Errorf("Expected a technical issue delivered to both, got %v", got)
+	}
+	if got := ask(types.MessageTypeInstructorInbox, "question"); strings.Join(got, ",") != "teacher" {
+		t.Errorf("Expected a question delivered to the teacher only, got %v", got)
+	}
+	if got := ask(types.MessageTypeAnalytics, "question"); strings.Join(got, ",") != "ta,teacher" {
+		t.Errorf("Expected unfiltered types delivered to both, got %v", got)
+	}
+
+	// The teacher muting their inbox orphans every other context, so the setter is
+	// warned and a question nobody wants still reaches both
+	update, err = router.SetInboxPreferences(ctx, "session1", "teacher", map[string][]string{
+		types.MessageTypeInstructorInbox: nil,
+	})
+	if err != nil || len(update.Warnings) != 1 || !strings.Contains(update.Warnings[0], "outside technical_issue") {
+		t.Fatalf("Expected a warning naming the covered contexts, got %+v, %v", update, err)
+	}
+	if got := ask(types.MessageTypeInstructorInbox, "question"); strings.Join(got, ",") != "ta,teacher" {
+		t.Errorf("Expected an orphaned question delivered to every instructor, got %v", got)
+	}
+
+	// Preferences survive the router's cache: a fresh router loads them from the store
+	restarted := NewRouter(registry, newMessageStore())
+	restarted.SetPreferenceStore(store)
+	router = restarted
+	if got := ask(types.MessageTypeInstructorInbox, "technical_issue"); strings.Join(got, ",") != "ta" {
+		t.Errorf("Expected stored preferences applied after a restart, got %v", got)
+	}
+	ask(types.MessageTypeInstructorInbox, "technical_issue")
+	if store.loads != 2 {
+		t.Errorf("Expected preferences loaded once by each router, got %d loads", store.loads)
+	}
+
+	// Resetting clears the warning; releasing the session drops the cache
+	if update, _ := router.SetInboxPreferences(ctx, "session1", "teacher", nil); len(update.Warnings) != 0 {
+		t.Errorf("Expected no warning once the teacher receives everything, got %v", update.Warnings)
+	}
+	router.ReleaseInboxPreferences(types.Session{ID: "session1"})
+	if router.PreferenceSessions() != 0 {
+		t.Errorf("Expected no cached sessions after release, got %d", router.PreferenceSessions())
+	}
+
+	if _, err := router.SetInboxPreferences(ctx, "session1", "ta", map[string][]string{types.MessageTypeReaction: {"general"}}); !errors.Is(err, types.ErrInvalidInboxFilter) {
+		t.Errorf("Expected reactions refused as a filter type, got %v", err)
+	}
+}
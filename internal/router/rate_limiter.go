@@ -3,6 +3,15 @@ package router
 import (
 	"sync"
 	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/internal/clock"
+)
+
+// Default rate limit applied to each user, used until SetLimits provides another
+const (
+	RateLimitMessages = 100
+	RateLimitWindow   = time.Minute
 )
 
 // RateLimiter implements per-client rate limiting
@@ -10,6 +19,8 @@ import (
 type RateLimiter struct {
 	mu      sync.RWMutex
 	clients map[string]*ClientLimit
+	limits  func() (messages int, window time.Duration) // Set before use; read without locking
+	clock   interfaces.Clock                            // Set before use; read without locking
 }
 
 // ClientLimit tracks rate limiting for a single client
@@ -24,16 +35,40 @@ type ClientLimit struct {
 func NewRateLimiter() *RateLimiter {
 	return &RateLimiter{
 		clients: make(map[string]*ClientLimit),
+		clock:   clock.Real(),
+	}
+}
+
+// SetClock sets the clock windows are measured on
+func (rl *RateLimiter) SetClock(c interfaces.Clock) {
+	rl.clock = c
+}
+
+// SetLimits makes the limiter ask limits for the current rate limit on every message
+// ARCHITECTURAL DISCOVERY: A function rather than values, so a configuration reload
+// applies from the next message without reaching into the limiter; counts already
+// in a window are kept and compared against the new limit
+func (rl *RateLimiter) SetLimits(limits func() (messages int, window time.Duration)) {
+	rl.limits = limits
+}
+
+// currentLimits returns the rate limit to apply to the next message
+func (rl *RateLimiter) currentLimits() (int, time.Duration) {
+	if rl.limits == nil {
+		return RateLimitMessages, RateLimitWindow
 	}
+	return rl.limits()
 }
 
-// Allow checks if client can send a message (100 per minute limit)
+// Allow checks if client can send a message (100 per minute by default)
 // TECHNICAL DISCOVERY: RWMutex for read-heavy operations, upgrade to write lock only when needed
 func (rl *RateLimiter) Allow(userID string) bool {
+	maxMessages, window := rl.currentLimits()
+	
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 	
-	now := time.Now()
+	now := rl.clock.Now()
 	
 	limit, exists := rl.clients[userID]
 	if !exists {
@@ -47,14 +82,14 @@ func (rl *RateLimiter) Allow(userID string) bool {
 	
 	// Check if new minute window needed
 	// TECHNICAL DISCOVERY: Sliding window resets exactly every minute for consistent rate limiting
-	if now.Sub(limit.windowStart) >= time.Minute {
+	if now.Sub(limit.windowStart) >= window {
 		limit.messageCount = 1
 		limit.windowStart = now
 		return true
 	}
 	
-	// Check rate limit (100 messages per minute)
-	if limit.messageCount >= 100 {
+	// Check rate limit
+	if limit.messageCount >= maxMessages {
 		return false
 	}
 	
@@ -69,10 +104,29 @@ func (rl *RateLimiter) Cleanup() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 	
-	now := time.Now()
+	now := rl.clock.Now()
 	for userID, limit := range rl.clients {
 		if now.Sub(limit.windowStart) > 5*time.Minute {
 			delete(rl.clients, userID)
 		}
 	}
+}
+
+// Forget drops the limiter state of the given users
+// FUNCTIONAL DISCOVERY: Called when a session ends; a user still active in another
+// session only gets a fresh window, which is at most one extra window of messages
+func (rl *RateLimiter) Forget(userIDs []string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	
+	for _, userID := range userIDs {
+		delete(rl.clients, userID)
+	}
+}
+
+// TrackedClients returns how many users currently have limiter state
+func (rl *RateLimiter) TrackedClients() int {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return len(rl.clients)
 }
\ No newline at end of file
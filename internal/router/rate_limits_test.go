@@ -0,0 +1,88 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/internal/config"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestRouteMessage_RateLimitReload tests functional validation - reloads swap limits while traffic flows
+// TECHNICAL DISCOVERY: Meaningful under -race; readers and the reloader share only the store
+func TestRouteMessage_RateLimitReload(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	raised := config.DefaultConfig()
+	raised.Router.RateLimitMessages = 1000
+	store := config.NewStore(raised)
+	router.SetRateLimits(store.RateLimits)
+
+	senders := make([]*websocket.Connection, 4)
+	for i := range senders {
+		senders[i], _ = setupReceivingConnection(t, registry, fmt.Sprintf("student%d", i), "student", "session1")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ctx.Err() == nil; i++ {
+			cfg := config.DefaultConfig()
+			cfg.Router.RateLimitMessages = 1000 + i%2
+			cfg.Logging.Level = []string{"info", "debug"}[i%2]
+			if err := store.Reload(cfg); err != nil {
+				t.Errorf("Reload failed: %v", err)
+				return
+			}
+		}
+	}()
+
+	for _, sender := range senders {
+		wg.Add(1)
+		go func(sender *websocket.Connection) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				message := &types.Message{
+					SessionID: "session1",
+					Type:      types.MessageTypeInstructorInbox,
+					FromUser:  sender.GetUserID(),
+					Content:   map[string]interface{}{"text": "Question"},
+				}
+				if _, err := router.RouteMessage(context.Background(), message, sender); err != nil {
+					t.Errorf("Expected routing within the limit, got %v", err)
+					return
+				}
+			}
+		}(sender)
+	}
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	// A lowered limit applies from the next message
+	cfg := config.DefaultConfig()
+	cfg.Router.RateLimitMessages = 1
+	if err := store.Reload(cfg); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+	if messages, _ := router.RateLimits(); messages != 1 {
+		t.Errorf("Expected the router to report the reloaded limit, got %d", messages)
+	}
+	message := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorInbox,
+		FromUser:  "student0",
+		Content:   map[string]interface{}{"text": "One more"},
+	}
+	if _, err := router.RouteMessage(context.Background(), message, senders[0]); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("Expected ErrRateLimitExceeded after lowering the limit, got %v", err)
+	}
+}
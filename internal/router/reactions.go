@@ -0,0 +1,219 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+	"switchboard/internal/clock"
+)
+
+// reactionUpdateInterval is the shortest gap between two tally updates for one message
+// FUNCTIONAL DISCOVERY: A class reacting to a broadcast at once produces one update per
+// second per message rather than one per student
+const reactionUpdateInterval = time.Second
+
+// SetReactionCodes sets the reaction codes students may send; empty means
+// types.DefaultReactionCodes
+// TECHNICAL DISCOVERY: Set before the hub starts; the map is read without locking
+func (r *Router) SetReactionCodes(codes []string) {
+	if len(codes) == 0 {
+		codes = types.DefaultReactionCodes
+	}
+	r.reactionCodes = make(map[string]bool, len(codes))
+	for _, code := range codes {
+		r.reactionCodes[code] = true
+	}
+}
+
+// routeReaction records a student's reaction and schedules a tally update
+// ARCHITECTURAL DISCOVERY: Reactions never become messages - the row in
+// message_reactions is the whole record, and instructors only ever see tallies
+// FUNCTIONAL DISCOVERY: A student may react to broadcasts that reach them and to
+// messages addressed to them; any other target, including one from another session, is refused as not found
+// so message IDs cannot be probed
+func (r *Router) routeReaction(ctx context.Context, message *types.Message) error {
+	target, _ := message.Content[types.ReactionContentTarget].(string)
+	code, _ := message.Content[types.ReactionContentCode].(string)
+	if target == "" {
+		return fmt.Errorf("%w: %s is required", ErrInvalidReaction, types.ReactionContentTarget)
+	}
+	if !r.allowsReaction(code) {
+		return fmt.Errorf("%w: %q is not an allowed reaction", ErrInvalidReaction, code)
+	}
+	if r.dbManager == nil {
+		return ErrReactionTargetNotFound
+	}
+
+	targeted, err := r.dbManager.GetMessage(ctx, message.SessionID, target)
+	if errors.Is(err, interfaces.ErrNotFound) {
+		return ErrReactionTargetNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load reaction target: %w", err)
+	}
+	if !targeted.ReachesStudent(message.FromUser) {
+		return ErrReactionTargetNotFound
+	}
+
+	err = r.dbManager.SetMessageReaction(ctx, message.SessionID, &types.MessageReaction{
+		MessageID: target,
+		UserID:    message.FromUser,
+		Reaction:  code,
+		UpdatedAt: message.Timestamp,
+	})
+	if errors.Is(err, interfaces.ErrNotFound) {
+		return ErrReactionTargetNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to persist reaction: %w", err)
+	}
+
+	r.reactions.schedule(message.SessionID, target, r.sendReactionUpdate)
+	return nil
+}
+
+func (r *Router) allowsReaction(code string) bool {
+	if r.reactionCodes == nil {
+		for _, allowed := range types.DefaultReactionCodes {
+			if code == allowed {
+				return true
+			}
+		}
+		return false
+	}
+	return r.reactionCodes[code]
+}
+
+// sendReactionUpdate sends a message's current tallies to its session's instructors
+// TECHNICAL DISCOVERY: Counts are read when the update goes out, not when it was
+// scheduled, so a throttled update always carries every reaction before it
+func (r *Router) sendReactionUpdate(sessionID, messageID string) {
+	counts, err := r.dbManager.GetReactionCounts(context.Background(), messageID)
+	if err != nil {
+		log.Printf("Failed to count reactions to message %s: %v", messageID, err)
+		return
+	}
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+
+	update := map[string]interface{}{
+		"type":    "system",
+		"context": "reactions",
+		"content": map[string]interface{}{
+			"event":                     "reaction_update",
+			types.ReactionContentTarget: messageID,
+			"counts":                    counts,
+			"total":                     total,
+		},
+		"timestamp": r.clock.Now(),
+	}
+	for _, conn := range r.registry.GetSessionInstructors(sessionID) {
+		if err := conn.WriteJSON(update); err != nil {
+			log.Printf("Failed to send reaction update to %s: %v", conn.GetUserID(), err)
+		}
+	}
+}
+
+// ReleaseReactions cancels an ended session's pending tally updates
+// ARCHITECTURAL DISCOVERY: Registered with the hub's session resources
+func (r *Router) ReleaseReactions(ended types.Session) {
+	r.reactions.release(ended.ID)
+}
+
+// ReactionTargets returns how many messages have reaction throttling state
+func (r *Router) ReactionTargets() int {
+	return r.reactions.tracked()
+}
+
+// tallyUpdates throttles tally updates to one per interval per target message
+// TECHNICAL DISCOVERY: The first change after a quiet interval is sent at once; later
+// ones share a single timer that fires when the interval is up. Reactions and read
+// receipts each keep their own
+type tallyUpdates struct {
+	interval time.Duration
+	clock    interfaces.Clock // Set before use; read without locking
+
+	mu      sync.Mutex
+	targets map[string]*tallyTarget // By target message ID
+}
+
+type tallyTarget struct {
+	sessionID string
+	lastSent  time.Time
+	timer     interfaces.Timer // Non-nil while an update is pending
+}
+
+func newTallyUpdates(interval time.Duration) *tallyUpdates {
+	return &tallyUpdates{
+		interval: interval,
+		clock:    clock.Real(),
+		targets:  make(map[string]*tallyTarget),
+	}
+}
+
+// schedule arranges for send to run for messageID within the interval
+func (u *tallyUpdates) schedule(sessionID, messageID string, send func(sessionID, messageID string)) {
+	now := u.clock.Now()
+
+	u.mu.Lock()
+	for id, target := range u.targets {
+		if target.timer == nil && now.Sub(target.lastSent) >= u.interval {
+			delete(u.targets, id) // Quiet long enough that the next update is not throttled
+		}
+	}
+	target, exists := u.targets[messageID]
+	if !exists {
+		target = &tallyTarget{sessionID: sessionID}
+		u.targets[messageID] = target
+	}
+	if target.timer != nil {
+		u.mu.Unlock()
+		return
+	}
+	wait := u.interval - now.Sub(target.lastSent)
+	if wait <= 0 {
+		target.lastSent = now
+		u.mu.Unlock()
+		send(sessionID, messageID)
+		return
+	}
+	target.timer = u.clock.AfterFunc(wait, func() {
+		u.mu.Lock()
+		if u.targets[messageID] != target {
+			u.mu.Unlock()
+			return // Released while the timer was pending
+		}
+		target.timer = nil
+		target.lastSent = u.clock.Now()
+		u.mu.Unlock()
+		send(sessionID, messageID)
+	})
+	u.mu.Unlock()
+}
+
+func (u *tallyUpdates) release(sessionID string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for id, target := range u.targets {
+		if target.sessionID == sessionID {
+			if target.timer != nil {
+				target.timer.Stop()
+			}
+			delete(u.targets, id)
+		}
+	}
+}
+
+func (u *tallyUpdates) tracked() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.targets)
+}
@@ -0,0 +1,191 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// reactionStore adds in-memory reactions to messageStore, one per message and user
+type reactionStore struct {
+	*messageStore
+	reactions map[string]map[string]string // Message ID -> user ID -> code
+}
+
+func (s *reactionStore) SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error {
+	if _, err := s.GetMessage(ctx, sessionID, reaction.MessageID); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reactions[reaction.MessageID] == nil {
+		s.reactions[reaction.MessageID] = make(map[string]string)
+	}
+	s.reactions[reaction.MessageID][reaction.UserID] = reaction.Reaction
+	return nil
+}
+
+func (s *reactionStore) GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, code := range s.reactions[messageID] {
+		counts[code]++
+	}
+	return counts, nil
+}
+
+// TestRouteMessage_Reactions tests functional validation - reactions are deduplicated,
+// kept out of messages and tallied to instructors at most once per interval
+func TestRouteMessage_Reactions(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := &reactionStore{messageStore: newMessageStore(), reactions: make(map[string]map[string]string)}
+	router := NewRouter(registry, store)
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC))
+	router.SetClock(clock)
+	student1, studentReceived := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	student2, _ := setupReceivingConnection(t, registry, "student2", "student", "session1")
+	instructor, instructorReceived := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+
+	broadcast := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		Content:   map[string]interface{}{"text": "Ready for the quiz?"},
+	}
+	if _, err := router.RouteMessage(context.Background(), broadcast, instructor); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	receiveRouted(t, studentReceived)
+	question := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorInbox,
+		FromUser:  "student2",
+		Content:   map[string]interface{}{"text": "Is it graded?"},
+	}
+	if _, err := router.RouteMessage(context.Background(), question, student2); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	receiveRouted(t, instructorReceived)
+	targeted := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		ToUsers:   []string{"student1"},
+		Content:   map[string]interface{}{"text": "See me after class"},
+	}
+	if _, err := router.RouteMessage(context.Background(), targeted, instructor); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	receiveRouted(t, studentReceived)
+
+	react := func(sender *websocket.Connection, content map[string]interface{}) error {
+		_, err := router.RouteMessage(context.Background(), &types.Message{
+			SessionID: "session1",
+			Type:      types.MessageTypeReaction,
+			FromUser:  sender.GetUserID(),
+			Content:   content,
+		}, sender)
+		return err
+	}
+	expectUpdate := func(counts map[string]float64) {
+		t.Helper()
+		update := receiveRouted(t, instructorReceived)
+		content, _ := update["content"].(map[string]interface{})
+		if content["event"] != "reaction_update" || content["target_message_id"] != broadcast.ID {
+			t.Fatalf("Expected a reaction_update for the broadcast, got %v", update)
+		}
+		got, _ := content["counts"].(map[string]interface{})
+		total := 0.0
+		for code, want := range counts {
+			if got[code] != want {
+				t.Errorf("Expected %v %s, got counts %v", want, code, got)
+			}
+			total += want
+		}
+		if len(got) != len(counts) || content["total"] != total {
+			t.Errorf("Expected counts %v totalling %v, got %v", counts, total, content)
+		}
+	}
+
+	// Bad reactions are refused before anything is stored
+	if err := react(student1, map[string]interface{}{"reaction": "thumbs_up"}); !errors.Is(err, ErrInvalidReaction) {
+		t.Errorf("Expected ErrInvalidReaction without a target, got %v", err)
+	}
+	if err := react(student1, map[string]interface{}{"target_message_id": broadcast.ID, "reaction": "party"}); !errors.Is(err, ErrInvalidReaction) {
+		t.Errorf("Expected ErrInvalidReaction for a code off the allowlist, got %v", err)
+	}
+	if err := react(student1, map[string]interface{}{"target_message_id": "missing", "reaction": "thumbs_up"}); !errors.Is(err, ErrReactionTargetNotFound) {
+		t.Errorf("Expected ErrReactionTargetNotFound for an unknown target, got %v", err)
+	}
+	if err := react(student1, map[string]interface{}{"target_message_id": question.ID, "reaction": "thumbs_up"}); !errors.Is(err, ErrReactionTargetNotFound) {
+		t.Errorf("Expected ErrReactionTargetNotFound for another student's message, got %v", err)
+	}
+	if err := react(student2, map[string]interface{}{"target_message_id": targeted.ID, "reaction": "thumbs_up"}); !errors.Is(err, ErrReactionTargetNotFound) {
+		t.Errorf("Expected ErrReactionTargetNotFound for a broadcast not sent to the student, got %v", err)
+	}
+	if err := react(instructor, map[string]interface{}{"target_message_id": broadcast.ID, "reaction": "thumbs_up"}); !errors.Is(err, ErrUnauthorizedMessageType) {
+		t.Errorf("Expected instructors to be refused, got %v", err)
+	}
+
+	// The first reaction is tallied at once
+	if err := react(student1, map[string]interface{}{"target_message_id": broadcast.ID, "reaction": "thumbs_up"}); err != nil {
+		t.Fatalf("Reaction failed: %v", err)
+	}
+	expectUpdate(map[string]float64{"thumbs_up": 1})
+
+	// Within the interval reactions share one update; a student's latest reaction wins
+	for _, reaction := range []struct {
+		sender *websocket.Connection
+		code   string
+	}{{student2, "thumbs_up"}, {student1, "thumbs_down"}, {student1, "question"}} {
+		if err := react(reaction.sender, map[string]interface{}{"target_message_id": broadcast.ID, "reaction": reaction.code}); err != nil {
+			t.Fatalf("Reaction failed: %v", err)
+		}
+	}
+	select {
+	case early := <-instructorReceived:
+		t.Errorf("Expected the update held until the interval is up, got %v", early)
+	default:
+	}
+	clock.Advance(reactionUpdateInterval)
+	expectUpdate(map[string]float64{"thumbs_up": 1, "question": 1})
+	clock.Advance(2 * reactionUpdateInterval)
+	select {
+	case extra := <-instructorReceived:
+		t.Errorf("Expected one throttled update, also got %v", extra)
+	case <-time.After(50 * time.Millisecond): // Only delivery over the test socket takes real time
+	}
+	select {
+	case leaked := <-studentReceived:
+		t.Errorf("Expected students to receive no tallies, got %v", leaked)
+	default:
+	}
+
+	if stored := len(store.messages); stored != 3 {
+		t.Errorf("Expected reactions kept out of messages, found %d messages", stored)
+	}
+
+	// A configured allowlist replaces the defaults
+	router.SetReactionCodes([]string{"party"})
+	if err := react(student1, map[string]interface{}{"target_message_id": broadcast.ID, "reaction": "thumbs_up"}); !errors.Is(err, ErrInvalidReaction) {
+		t.Errorf("Expected thumbs_up refused under a custom allowlist, got %v", err)
+	}
+	if err := react(student1, map[string]interface{}{"target_message_id": broadcast.ID, "reaction": "party"}); err != nil {
+		t.Fatalf("Reaction failed: %v", err)
+	}
+	expectUpdate(map[string]float64{"thumbs_up": 1, "party": 1}) // Quiet for a whole interval, so sent at once
+
+	if router.ReactionTargets() != 1 {
+		t.Errorf("Expected 1 tracked target, got %d", router.ReactionTargets())
+	}
+	router.ReleaseReactions(types.Session{ID: "session1"})
+	if router.ReactionTargets() != 0 {
+		t.Errorf("Expected ReleaseReactions to drop the session's targets, %d remain", router.ReactionTargets())
+	}
+}
@@ -0,0 +1,102 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// readUpdateInterval is the shortest gap between two read-count updates for one message
+// FUNCTIONAL DISCOVERY: A class opening an announcement at once produces one update
+// per second rather than one per student
+const readUpdateInterval = time.Second
+
+// SetReadReceiptStore sets where read receipts are recorded
+// TECHNICAL DISCOVERY: Set before the hub starts; read without locking
+func (r *Router) SetReadReceiptStore(store interfaces.ReadReceiptStore) {
+	r.readStore = store
+}
+
+// RecordReadReceipt marks messageID read by a student and schedules a read-count
+// update for the session's instructors
+// FUNCTIONAL DISCOVERY: Only messages that reached the student may be marked read -
+// whole-session broadcasts and messages addressed to them. Any other ID, including one
+// from another session, is refused as not found so message IDs cannot be probed. A
+// repeated receipt is accepted and changes nothing
+func (r *Router) RecordReadReceipt(ctx context.Context, sessionID, userID, messageID string) error {
+	if messageID == "" {
+		return ErrInvalidReadReceipt
+	}
+	if r.readStore == nil || r.dbManager == nil {
+		return ErrReadTargetNotFound
+	}
+
+	target, err := r.dbManager.GetMessage(ctx, sessionID, messageID)
+	if errors.Is(err, interfaces.ErrNotFound) {
+		return ErrReadTargetNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load read receipt target: %w", err)
+	}
+	if !target.ReachesStudent(userID) {
+		return ErrReadTargetNotFound
+	}
+
+	recorded, err := r.readStore.RecordMessageRead(ctx, sessionID, &types.MessageRead{
+		MessageID: messageID,
+		UserID:    userID,
+		ReadAt:    r.clock.Now(),
+	})
+	if errors.Is(err, interfaces.ErrNotFound) {
+		return ErrReadTargetNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to persist read receipt: %w", err)
+	}
+	if recorded {
+		r.reads.schedule(sessionID, messageID, r.sendReadUpdate)
+	}
+	return nil
+}
+
+// sendReadUpdate sends a message's current read count to its session's instructors
+// TECHNICAL DISCOVERY: Counted when the update goes out, like reaction tallies
+func (r *Router) sendReadUpdate(sessionID, messageID string) {
+	count, err := r.readStore.CountMessageReads(context.Background(), messageID)
+	if err != nil {
+		log.Printf("Failed to count reads of message %s: %v", messageID, err)
+		return
+	}
+
+	update := map[string]interface{}{
+		"type":    "system",
+		"context": "read_receipts",
+		"content": map[string]interface{}{
+			"event":                         "read_update",
+			types.ReadReceiptContentMessage: messageID,
+			"read_count":                    count,
+		},
+		"timestamp": r.clock.Now(),
+	}
+	for _, conn := range r.registry.GetSessionInstructors(sessionID) {
+		if err := conn.WriteJSON(update); err != nil {
+			log.Printf("Failed to send read update to %s: %v", conn.GetUserID(), err)
+		}
+	}
+}
+
+// ReleaseReadReceipts cancels an ended session's pending read-count updates
+// ARCHITECTURAL DISCOVERY: Registered with the hub's session resources
+func (r *Router) ReleaseReadReceipts(ended types.Session) {
+	r.reads.release(ended.ID)
+}
+
+// ReadReceiptTargets returns how many messages have read-count throttling state
+func (r *Router) ReadReceiptTargets() int {
+	return r.reads.tracked()
+}
@@ -0,0 +1,151 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// readStore adds in-memory read receipts to messageStore, the first per message and user
+type readStore struct {
+	*messageStore
+	reads map[string]map[string]time.Time // Message ID -> user ID -> first read
+}
+
+func (s *readStore) RecordMessageRead(ctx context.Context, sessionID string, read *types.MessageRead) (bool, error) {
+	if _, err := s.GetMessage(ctx, sessionID, read.MessageID); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.reads[read.MessageID] == nil {
+		s.reads[read.MessageID] = make(map[string]time.Time)
+	}
+	if _, seen := s.reads[read.MessageID][read.UserID]; seen {
+		return false, nil
+	}
+	s.reads[read.MessageID][read.UserID] = read.ReadAt
+	return true, nil
+}
+
+func (s *readStore) GetMessageReads(ctx context.Context, messageID string) ([]*types.MessageRead, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	reads := []*types.MessageRead{}
+	for userID, readAt := range s.reads[messageID] {
+		reads = append(reads, &types.MessageRead{MessageID: messageID, UserID: userID, ReadAt: readAt})
+	}
+	return reads, nil
+}
+
+func (s *readStore) CountMessageReads(ctx context.Context, messageID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.reads[messageID]), nil
+}
+
+// TestRecordReadReceipt tests functional validation - students mark messages that
+// reached them read once, and instructors hear the read count at most once per interval
+func TestRecordReadReceipt(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := &readStore{messageStore: newMessageStore(), reads: make(map[string]map[string]time.Time)}
+	router := NewRouter(registry, store)
+	router.SetReadReceiptStore(store)
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	router.SetClock(clock)
+	_, studentReceived := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	setupReceivingConnection(t, registry, "student2", "student", "session1")
+	setupReceivingConnection(t, registry, "student3", "student", "session1")
+	instructor, instructorReceived := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+
+	broadcast := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		Content:   map[string]interface{}{"text": "Quiz moved to Friday"},
+	}
+	targeted := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		ToUsers:   []string{"student1"},
+		Content:   map[string]interface{}{"text": "See me after class"},
+	}
+	for _, message := range []*types.Message{broadcast, targeted} {
+		if _, err := router.RouteMessage(context.Background(), message, instructor); err != nil {
+			t.Fatalf("RouteMessage failed: %v", err)
+		}
+		receiveRouted(t, studentReceived)
+	}
+
+	read := func(userID, messageID string) error {
+		return router.RecordReadReceipt(context.Background(), "session1", userID, messageID)
+	}
+	expectUpdate := func(messageID string, count float64) {
+		t.Helper()
+		update := receiveRouted(t, instructorReceived)
+		content, _ := update["content"].(map[string]interface{})
+		if update["context"] != "read_receipts" || content["event"] != "read_update" || content["message_id"] != messageID || content["read_count"] != count {
+			t.Fatalf("Expected %v reads of %s, got %v", count, messageID, update)
+		}
+	}
+
+	// Only messages that reached the student may be marked read
+	if err := read("student1", ""); !errors.Is(err, ErrInvalidReadReceipt) {
+		t.Errorf("Expected ErrInvalidReadReceipt without a message ID, got %v", err)
+	}
+	if err := read("student1", "missing"); !errors.Is(err, ErrReadTargetNotFound) {
+		t.Errorf("Expected ErrReadTargetNotFound for an unknown message, got %v", err)
+	}
+	if err := read("student2", targeted.ID); !errors.Is(err, ErrReadTargetNotFound) {
+		t.Errorf("Expected ErrReadTargetNotFound for a message not sent to the student, got %v", err)
+	}
+	if err := router.RecordReadReceipt(context.Background(), "session2", "student1", broadcast.ID); !errors.Is(err, ErrReadTargetNotFound) {
+		t.Errorf("Expected ErrReadTargetNotFound through another session, got %v", err)
+	}
+
+	// The first read is counted at once; a repeat changes nothing
+	if err := read("student1", broadcast.ID); err != nil {
+		t.Fatalf("RecordReadReceipt failed: %v", err)
+	}
+	expectUpdate(broadcast.ID, 1)
+	if err := read("student1", broadcast.ID); err != nil {
+		t.Errorf("Expected a repeated receipt accepted, got %v", err)
+	}
+
+	// Within the interval reads share one update
+	for _, userID := range []string{"student2", "student3"} {
+		if err := read(userID, broadcast.ID); err != nil {
+			t.Fatalf("RecordReadReceipt failed: %v", err)
+		}
+	}
+	select {
+	case early := <-instructorReceived:
+		t.Errorf("Expected the update held until the interval is up, got %v", early)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Advance(readUpdateInterval)
+	expectUpdate(broadcast.ID, 3)
+	clock.Advance(2 * readUpdateInterval)
+	select {
+	case extra := <-instructorReceived:
+		t.Errorf("Expected one throttled update, also got %v", extra)
+	case <-time.After(50 * time.Millisecond): // Only delivery over the test socket takes real time
+	}
+
+	if stored := len(store.messages); stored != 2 {
+		t.Errorf("Expected receipts kept out of messages, found %d messages", stored)
+	}
+	if router.ReadReceiptTargets() != 1 {
+		t.Errorf("Expected 1 tracked message, got %d", router.ReadReceiptTargets())
+	}
+	router.ReleaseReadReceipts(types.Session{ID: "session1"})
+	if router.ReadReceiptTargets() != 0 {
+		t.Errorf("Expected ReleaseReadReceipts to drop the session's messages, %d remain", router.ReadReceiptTargets())
+	}
+}
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+
+	"switchboard/pkg/types"
+)
+
+// Reasons an UnknownRecipientError gives for refusing a to_user
+const (
+	RecipientUnknown      = "unknown"        // Not on any active roster and not connected
+	RecipientNotInSession = "not_in_session" // Known, but in another session
+)
+
+// UnknownRecipientError refuses a direct message whose to_user cannot receive it
+// FUNCTIONAL DISCOVERY: Reason tells a typo (unknown) apart from a real user picked
+// from the wrong session, so the sender's client can say which
+// TECHNICAL DISCOVERY: Unwraps to ErrRecipientNotFound or ErrRecipientNotInSession,
+// so callers matching the older errors keep working
+type UnknownRecipientError struct {
+	UserID string
+	Reason string
+}
+
+func (e *UnknownRecipientError) Error() string {
+	return fmt.Sprintf("unknown recipient %q: %s", e.UserID, e.Reason)
+}
+
+func (e *UnknownRecipientError) Unwrap() error {
+	if e.Reason == RecipientUnknown {
+		return ErrRecipientNotFound
+	}
+	return ErrRecipientNotInSession
+}
+
+// SetRecipientRoster lets the router check to_user against session rosters
+// ARCHITECTURAL DISCOVERY: Asked through a function like SetSessionLock, so the router
+// never holds the session manager; inSession reports the session's roster and known
+// whether any active session lists the user
+// TECHNICAL DISCOVERY: Set before the hub starts; the fields are read without locking.
+// Without a roster only connected users are valid recipients
+func (r *Router) SetRecipientRoster(membership func(sessionID, userID string) (inSession, known bool), persistUnknown bool) {
+	r.roster = membership
+	r.persistUnknown = persistUnknown
+}
+
+// resolveDirectRecipient finds who a direct message's to_user refers to
+func (r *Router) resolveDirectRecipient(message *types.Message) (*types.Client, error) {
+	if message.ToUser == nil {
+		return nil, ErrMissingRecipient
+	}
+	return r.resolveRecipient(message.SessionID, *message.ToUser)
+}
+
+// DefaultMaxBroadcastRecipients caps a targeted broadcast's to_users unless configured
+const DefaultMaxBroadcastRecipients = 100
+
+// ErrTooManyRecipients refuses a targeted broadcast listing more students than allowed
+var ErrTooManyRecipients = errors.New("too many to_users recipients")
+
+// SetMaxBroadcastRecipients caps the to_users list of a targeted instructor_broadcast;
+// max <= 0 means DefaultMaxBroadcastRecipients
+// TECHNICAL DISCOVERY: Set before the hub starts; the field is read without locking
+func (r *Router) SetMaxBroadcastRecipients(max int) {
+	r.maxBroadcastRecipients = max
+}
+
+func (r *Router) broadcastRecipientLimit() int {
+	if r.maxBroadcastRecipients <= 0 {
+		return DefaultMaxBroadcastRecipients
+	}
+	return r.maxBroadcastRecipients
+}
+
+// resolveBroadcastRecipients finds the students a targeted broadcast's to_users lists
+// FUNCTIONAL DISCOVERY: Every listed user must be able to receive a direct message in
+// the session, and the first who cannot refuses the whole broadcast with
+// UNKNOWN_RECIPIENT; persistUnknown does not apply, since a partial list would reach
+// a different group than the instructor picked
+func (r *Router) resolveBroadcastRecipients(message *types.Message) ([]*types.Client, error) {
+	if limit := r.broadcastRecipientLimit(); len(message.ToUsers) > limit {
+		return nil, fmt.Errorf("%w: %d listed, at most %d allowed", ErrTooManyRecipients, len(message.ToUsers), limit)
+	}
+
+	recipients := make([]*types.Client, 0, len(message.ToUsers))
+	for _, toUser := range message.ToUsers {
+		recipient, err := r.resolveRecipient(message.SessionID, toUser)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, nil
+}
+
+// resolveRecipient finds who toUser refers to in sessionID
+// FUNCTIONAL DISCOVERY: Valid recipients are users connected to the session (students
+// or instructors) and rostered students who are offline; the latter resolve but are
+// dropped at delivery and can read the message from history later
+func (r *Router) resolveRecipient(sessionID, toUser string) (*types.Client, error) {
+	conn, connected := r.registry.GetUserConnection(toUser)
+	if connected && conn.GetSessionID() == sessionID {
+		return &types.Client{ID: toUser, Role: conn.GetRole()}, nil
+	}
+
+	var inSession, known bool
+	if r.roster != nil {
+		inSession, known = r.roster(sessionID, toUser)
+	}
+	if inSession {
+		return &types.Client{ID: toUser, Role: "student"}, nil
+	}
+	if connected || known {
+		return nil, &UnknownRecipientError{UserID: toUser, Reason: RecipientNotInSession}
+	}
+	return nil, &UnknownRecipientError{UserID: toUser, Reason: RecipientUnknown}
+}
+
+// isDirectMessageType reports whether messageType is delivered to its to_user only
+func isDirectMessageType(messageType string) bool {
+	return messageType == types.MessageTypeInboxResponse || messageType == types.MessageTypeRequest
+}
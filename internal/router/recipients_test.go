@@ -0,0 +1,172 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"switchboard/internal/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// TestRouteMessage_UnknownRecipient tests functional validation - invalid to_user is refused before storage
+func TestRouteMessage_UnknownRecipient(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := newMessageStore()
+	router := NewRouter(registry, store)
+	rosters := map[string][]string{
+		"session1": {"student1", "student3"},
+		"session2": {"student2"},
+	}
+	router.SetRecipientRoster(func(sessionID, userID string) (bool, bool) {
+		inSession, known := false, false
+		for id, roster := range rosters {
+			for _, studentID := range roster {
+				if studentID == userID {
+					known = true
+					inSession = inSession || id == sessionID
+				}
+			}
+		}
+		return inSession, known
+	}, false)
+	instructor, _ := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+	_, coInstructorReceived := setupReceivingConnection(t, registry, "instructor2", "instructor", "session1")
+	setupReceivingConnection(t, registry, "student1", "student", "session1")
+	setupReceivingConnection(t, registry, "instructor3", "instructor", "session2")
+
+	send := func(toUser string) (*types.Message, types.RouteResult, error) {
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      types.MessageTypeInboxResponse,
+			FromUser:  "instructor1",
+			ToUser:    &toUser,
+			Content:   map[string]interface{}{"text": "Nice work"},
+		}
+		result, err := router.RouteMessage(context.Background(), message, instructor)
+		return message, result, err
+	}
+
+	tests := []struct {
+		name   string
+		toUser string
+		reason string
+	}{
+		{"typo", "studnet1", RecipientUnknown},
+		{"rostered in another session", "student2", RecipientNotInSession},
+		{"instructor in another session", "instructor3", RecipientNotInSession},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message, _, err := send(tt.toUser)
+			var unknown *UnknownRecipientError
+			if !errors.As(err, &unknown) {
+				t.Fatalf("Expected UnknownRecipientError, got %v", err)
+			}
+			if unknown.UserID != tt.toUser || unknown.Reason != tt.reason {
+				t.Errorf("Expected %s refused as %s, got %+v", tt.toUser, tt.reason, unknown)
+			}
+			if _, err := store.GetMessage(context.Background(), "session1", message.ID); !errors.Is(err, interfaces.ErrNotFound) {
+				t.Error("A message to an unknown recipient should not be stored")
+			}
+		})
+	}
+
+	// An instructor connected to the session is a valid recipient
+	if _, result, err := send("instructor2"); err != nil || len(result.Delivered) != 1 {
+		t.Errorf("Expected delivery to a co-instructor, got %+v, %v", result, err)
+	}
+	receiveRouted(t, coInstructorReceived)
+
+	// A rostered student who is offline resolves, and is dropped at delivery
+	message, result, err := send("student3")
+	if err != nil {
+		t.Fatalf("Expected an offline rostered student accepted, got %v", err)
+	}
+	if len(result.Dropped) != 1 || len(result.Delivered) != 0 {
+		t.Errorf("Expected the offline student dropped, got %+v", result)
+	}
+	if _, err := store.GetMessage(context.Background(), "session1", message.ID); err != nil {
+		t.Errorf("Expected the message stored for the offline student: %v", err)
+	}
+
+	// Persisting unknown recipients stores the message but delivers it to nobody
+	router.persistUnknown = true
+	message, result, err = send("studnet1")
+	if err != nil {
+		t.Fatalf("Expected the message accepted when persisting unknown recipients, got %v", err)
+	}
+	if len(result.Resolved) != 0 {
+		t.Errorf("Expected no recipients, got %+v", result)
+	}
+	if _, err := store.GetMessage(context.Background(), "session1", message.ID); err != nil {
+		t.Errorf("Expected the message stored: %v", err)
+	}
+}
+
+// TestRouteMessage_TargetedBroadcast tests functional validation - to_users narrows a
+// broadcast to the listed students and every one must be in the session
+func TestRouteMessage_TargetedBroadcast(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := newMessageStore()
+	router := NewRouter(registry, store)
+	router.SetRecipientRoster(func(sessionID, userID string) (bool, bool) {
+		rostered := userID == "student1" || userID == "student2" || userID == "student3"
+		return rostered && sessionID == "session1", rostered
+	}, true)
+	router.SetMaxBroadcastRecipients(3)
+	instructor, _ := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+	_, listedReceived := setupReceivingConnection(t, registry, "student1", "student", "session1")
+	_, unlistedReceived := setupReceivingConnection(t, registry, "student2", "student", "session1")
+
+	send := func(messageType string, toUsers ...string) (*types.Message, types.RouteResult, error) {
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      messageType,
+			FromUser:  "instructor1",
+			ToUsers:   toUsers,
+			Content:   map[string]interface{}{"text": "Submit by noon"},
+		}
+		result, err := router.RouteMessage(context.Background(), message, instructor)
+		return message, result, err
+	}
+
+	// student3 is rostered but offline; the repeat of student1 is dropped
+	message, result, err := send(types.MessageTypeInstructorBroadcast, "student1", "student3", "student1")
+	if err != nil {
+		t.Fatalf("Expected the targeted broadcast routed, got %v", err)
+	}
+	if len(result.Resolved) != 2 || len(result.Delivered) != 1 || result.Delivered[0] != "student1" || len(result.Dropped) != 1 || result.Dropped[0] != "student3" {
+		t.Errorf("Expected delivery to student1 and student3 dropped, got %+v", result)
+	}
+	if got := receiveRouted(t, listedReceived); got["to_users"] == nil {
+		t.Errorf("Expected the delivered message to carry to_users, got %v", got)
+	}
+	select {
+	case got := <-unlistedReceived:
+		t.Errorf("An unlisted student received the targeted broadcast: %v", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if stored, err := store.GetMessage(context.Background(), "session1", message.ID); err != nil || len(stored.ToUsers) != 2 {
+		t.Errorf("Expected the recipient list stored, got %+v, %v", stored, err)
+	}
+
+	// One unknown student refuses the whole broadcast, even when persisting unknown recipients
+	message, _, err = send(types.MessageTypeInstructorBroadcast, "student1", "studnet2")
+	var unknown *UnknownRecipientError
+	if !errors.As(err, &unknown) || unknown.UserID != "studnet2" {
+		t.Errorf("Expected studnet2 refused as unknown, got %v", err)
+	}
+	if _, err := store.GetMessage(context.Background(), "session1", message.ID); !errors.Is(err, interfaces.ErrNotFound) {
+		t.Error("A refused targeted broadcast should not be stored")
+	}
+
+	if _, _, err := send(types.MessageTypeInstructorBroadcast, "student1", "student2", "student3", "student4"); !errors.Is(err, ErrTooManyRecipients) {
+		t.Errorf("Expected ErrTooManyRecipients over the cap, got %v", err)
+	}
+	if _, _, err := send(types.MessageTypeRequest, "student1"); !errors.Is(err, types.ErrToUsersNotAllowed) {
+		t.Errorf("Expected to_users refused on a request, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/google/uuid"
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
+	"switchboard/internal/clock"
 	"switchboard/internal/websocket"
 )
 
@@ -19,6 +21,37 @@ type Router struct {
 	registry    *websocket.Registry
 	dbManager   interfaces.DatabaseManager
 	rateLimiter *RateLimiter
+	observers   []func(*types.Message)   // Notified after each message is persisted
+	analytics   interfaces.AnalyticsSink // Optional export of routed analytics messages
+	content     *contentFilter           // nil unless a content allowlist is configured
+	attachments *attachmentPolicy        // nil unless an attachment MIME allowlist is configured
+
+	sessionLocked   func(sessionID string) bool // nil disables session locks
+	delegation      DelegationLookup            // nil refuses students every instructor type
+	lockExemptTypes map[string]bool             // Student types still routed while locked
+	rejectLate      bool                        // Refuse late request_responses instead of flagging them
+	defaultContexts map[string]string           // Per-type context for messages sent without one
+	latencies       *sessionLatencies           // Per-session routing latency histograms
+	throughput      *throughputCounters         // Messages and connections per minute, last 24 hours
+	
+	roster         func(sessionID, userID string) (inSession, known bool) // nil checks connections only
+	persistUnknown bool                                                   // Store direct messages to unknown recipients undelivered
+	
+	maxBroadcastRecipients int // Cap on a targeted broadcast's to_users; 0 is the default
+	
+	reactionCodes map[string]bool // nil allows types.DefaultReactionCodes
+	reactions     *tallyUpdates   // Throttles tally updates to instructors
+	
+	readStore interfaces.ReadReceiptStore // nil refuses read receipts
+	reads     *tallyUpdates               // Throttles read-count updates to instructors
+	
+	preferenceStore interfaces.PreferenceStore // nil keeps inbox preferences in memory only
+	preferences     *inboxPreferences          // Per-instructor filters on instructor fan-out
+	
+	deliveries   *deliveryTracker                // Per-session delivered and failed recipients
+	failureStore interfaces.DeliveryFailureStore // nil counts failures without writing them
+	
+	clock interfaces.Clock // Message timestamps, stage timings and the throttles' windows
 }
 
 // NewRouter creates a new message router
@@ -28,75 +61,366 @@ func NewRouter(registry *websocket.Registry, dbManager interfaces.DatabaseManage
 		registry:    registry,
 		dbManager:   dbManager,
 		rateLimiter: NewRateLimiter(),
+		latencies:   newSessionLatencies(),
+		throughput:  &throughputCounters{},
+		reactions:   newTallyUpdates(reactionUpdateInterval),
+		reads:       newTallyUpdates(readUpdateInterval),
+		preferences: newInboxPreferences(),
+		deliveries:  newDeliveryTracker(),
+		clock:       clock.Real(),
 	}
 }
 
-// RouteMessage routes a message to appropriate recipients
+// SetClock sets the clock messages are stamped, rate limited and throttled by
+// TECHNICAL DISCOVERY: Set before the hub starts; read without locking
+func (r *Router) SetClock(c interfaces.Clock) {
+	r.clock = c
+	r.rateLimiter.SetClock(c)
+	r.reactions.clock = c
+	r.reads.clock = c
+}
+
+// RouteMessage routes a message from sender to appropriate recipients
 // FUNCTIONAL DISCOVERY: Persist-then-route pattern ensures message durability before delivery
 // Server-side ID generation prevents client tampering and ensures database consistency
-func (r *Router) RouteMessage(ctx context.Context, message *types.Message) error {
+// TECHNICAL DISCOVERY: The result is only meaningful without an error; recipients are
+// resolved after persistence, so a failed route has delivered to nobody
+func (r *Router) RouteMessage(ctx context.Context, message *types.Message, sender interfaces.ConnectionInfo) (types.RouteResult, error) {
 	// Generate server-side message ID (ignore any client-provided ID)
 	// ARCHITECTURAL DISCOVERY: Server controls message IDs to prevent client manipulation
+	started := r.clock.Now()
 	message.ID = uuid.New().String()
-	message.Timestamp = time.Now()
+	message.Timestamp = started
+	message.Annotations = nil // Instructor-only history data; never accepted from clients
+	message.Reactions = nil
+	message.Diag = nil        // Server measurements only
+	message.Delegation = nil  // Set by ValidateMessage from the session's grants
 	
 	// Set default context if empty
-	// FUNCTIONAL DISCOVERY: Context defaults to "general" for consistent behavior
+	// FUNCTIONAL DISCOVERY: Context defaults per message type so older clients that
+	// omit it still categorize correctly; unmapped types default to "general"
 	if message.Context == "" {
-		message.Context = "general"
+		message.Context = DefaultContext(r.defaultContexts, message.Type)
 	}
 	
+	result := types.RouteResult{MessageID: message.ID}
+	
 	// Validate message content and sender permissions
-	sender, exists := r.registry.GetUserConnection(message.FromUser)
-	if !exists {
-		return ErrSenderNotConnected
+	if sender == nil {
+		return result, ErrSenderNotConnected
 	}
 	
+	// The sender's connection decides the session; a payload's session_id never does
+	// ARCHITECTURAL DISCOVERY: The hub and handler already stamp it, so this only
+	// matters for callers that reach the router directly
+	message.SessionID = sender.GetSessionID()
+	
 	// TECHNICAL DISCOVERY: Convert Connection to Client for validation interface
 	senderClient := &types.Client{
 		ID:   sender.GetUserID(),
 		Role: sender.GetRole(),
 	}
+	message.System = senderClient.Role == types.SystemRole // Never taken from the client
 	
 	if err := r.ValidateMessage(message, senderClient); err != nil {
-		return err
+		return result, err
+	}
+	
+	// Refuse student messages while an instructor has locked the session
+	// FUNCTIONAL DISCOVERY: Nothing is queued - a message refused here is gone, so
+	// unlocking never floods instructors with what students typed during the lock.
+	// A presenter's delegated messages are sent with the instructor's authority
+	if r.sessionLocked != nil && senderClient.Role == "student" && !r.lockExemptTypes[message.Type] && message.Delegation == nil && r.sessionLocked(message.SessionID) {
+		return result, interfaces.ErrSessionLocked
 	}
 	
 	// Check rate limit
-	// TECHNICAL DISCOVERY: Rate limiting applied per user before persistence to prevent spam
-	if !r.rateLimiter.Allow(message.FromUser) {
-		return ErrRateLimitExceeded
+	// TECHNICAL DISCOVERY: Rate limiting applied per user before persistence to prevent spam;
+	// the system sender is exempt, since one announcement reaches every active session
+	if !message.System && !r.rateLimiter.Allow(message.FromUser) {
+		return result, ErrRateLimitExceeded
+	}
+	
+	// Enforce content allowlists before anything is stored or delivered
+	// FUNCTIONAL DISCOVERY: Runs after the rate limit so rejected messages still count
+	// against a sender probing which keys get through
+	if err := r.CheckContent(message); err != nil {
+		return result, err
+	}
+	
+	// Reactions are tallied rather than stored and delivered as messages
+	if message.Type == types.MessageTypeReaction {
+		return result, r.routeReaction(ctx, message)
+	}
+	
+	// Check a direct message's to_user, or a targeted broadcast's to_users, before
+	// anything is stored
+	// FUNCTIONAL DISCOVERY: An invalid recipient is refused with UNKNOWN_RECIPIENT, or
+	// with persistUnknown stored for integrations that message users before they are
+	// enrolled - it is still delivered to nobody
+	var addressed []*types.Client
+	undeliverable := false
+	switch {
+	case isDirectMessageType(message.Type):
+		recipient, err := r.resolveDirectRecipient(message)
+		var unknown *UnknownRecipientError
+		switch {
+		case errors.As(err, &unknown) && r.persistUnknown:
+			undeliverable = true
+		case err != nil:
+			return result, err
+		default:
+			addressed = []*types.Client{recipient}
+		}
+	case len(message.ToUsers) > 0:
+		recipients, err := r.resolveBroadcastRecipients(message)
+		if err != nil {
+			return result, err
+		}
+		addressed = recipients
+	}
+	
+	// Record request deadlines and flag or refuse late responses
+	// FUNCTIONAL DISCOVERY: Before persistence, so the late flag is stored, exported
+	// and delivered with the response itself
+	if err := r.checkDeadline(ctx, message); err != nil {
+		return result, err
 	}
 	
 	// Persist message first (persist-then-route pattern)
 	// ARCHITECTURAL DISCOVERY: Database persistence must complete before routing to prevent audit gaps
 	if r.dbManager != nil {
 		if err := r.dbManager.StoreMessage(ctx, message); err != nil {
-			return fmt.Errorf("failed to persist message: %w", err)
+			return result, fmt.Errorf("failed to persist message: %w", err)
+		}
+		// FUNCTIONAL DISCOVERY: Self-test probes stay out of metadata and transcripts
+		if message.SessionID != types.SelfTestSessionID {
+			r.storeMetadata(message.ID, sender)
+			for _, observe := range r.observers {
+				observe(message)
+			}
 		}
 	}
 	
 	// Get recipients based on message type
-	recipients, err := r.GetRecipients(message)
-	if err != nil {
-		return err
+	// TECHNICAL DISCOVERY: Direct messages and targeted broadcasts were resolved before
+	// persistence, which also covers rostered students who are offline
+	var recipients []*types.Client
+	var filtered []string
+	switch {
+	case undeliverable:
+	case addressed != nil:
+		recipients = addressed
+	default:
+		resolved, err := r.GetRecipients(message)
+		if err != nil {
+			return result, err
+		}
+		recipients = r.filterInstructors(ctx, message, resolved)
+		filtered = filteredOut(resolved, recipients)
 	}
 	
 	// Deliver to all recipients
 	// FUNCTIONAL DISCOVERY: Continue delivery to other recipients even if one fails
 	// TECHNICAL DISCOVERY: Need to get actual connections for message delivery
+	// FUNCTIONAL DISCOVERY: Every failure is counted against the session's reliability
+	// except an addressed recipient who is offline - they read it from history later
+	diagnostics := DiagnosticsFrom(ctx)
+	delivering := r.clock.Now()
+	result.Timings.Route = delivering.Sub(started)
+	var failures []types.DeliveryFailure
+	failed := func(recipientID, reason string) {
+		failures = append(failures, types.DeliveryFailure{
+			MessageID: message.ID,
+			SessionID: message.SessionID,
+			Recipient: recipientID,
+			Reason:    reason,
+			FailedAt:  delivering,
+		})
+	}
+	for _, recipientID := range filtered {
+		failed(recipientID, types.DeliveryFailureFiltered)
+	}
+	// TECHNICAL DISCOVERY: Encoded once per codec and shared by every recipient; only
+	// instructors with a diagnostic overlay get a value of their own
+	shared := websocket.NewPreparedMessage(message)
 	for _, recipientClient := range recipients {
-		if conn, exists := r.registry.GetUserConnection(recipientClient.ID); exists {
-			if err := conn.WriteJSON(message); err != nil {
-				// Log error but continue delivery to other recipients
-				log.Printf("Failed to deliver message to %s: %v", recipientClient.ID, err)
+		result.Resolved = append(result.Resolved, recipientClient.ID)
+		conn, exists := r.registry.GetUserConnection(recipientClient.ID)
+		if !exists || conn.GetSessionID() != message.SessionID {
+			result.Dropped = append(result.Dropped, recipientClient.ID) // Not connected to this session
+			if addressed == nil {
+				failed(recipientClient.ID, types.DeliveryFailureConnectionClosed) // Left after recipients were resolved
 			}
+			continue
+		}
+		var err error
+		if diagnostics != nil && conn.GetRole() == "instructor" {
+			err = conn.WriteJSON(diagnostics.overlay(message, result.Timings.Route, r.clock.Now().Sub(delivering), conn.PendingWrites()))
+		} else {
+			err = conn.WritePrepared(shared)
+		}
+		if err != nil {
+			// Log error but continue delivery to other recipients
+			log.Printf("Failed to deliver message to %s: %v", recipientClient.ID, err)
+			result.Dropped = append(result.Dropped, recipientClient.ID)
+			failed(recipientClient.ID, deliveryFailureReason(err))
+			continue
+		}
+		result.Delivered = append(result.Delivered, recipientClient.ID)
+	}
+	result.Timings.Write = r.clock.Now().Sub(delivering)
+	if message.SessionID != types.SelfTestSessionID {
+		r.latencies.observe(message.SessionID, result.Timings.Route+result.Timings.Write)
+		r.throughput.recordMessage(message.SessionID, started)
+		r.recordDelivery(message.SessionID, len(result.Delivered), failures)
+	}
+	
+	// Export analytics after live delivery
+	// FUNCTIONAL DISCOVERY: Export never fails routing - the message is already
+	// persisted and delivered, and the sink counts its own drops
+	if r.analytics != nil && message.Type == types.MessageTypeAnalytics {
+		if err := r.analytics.Consume(ctx, message); err != nil {
+			log.Printf("DEBUG: Analytics sink did not accept message %s: %v", message.ID, err)
 		}
 	}
 	
+	return result, nil
+}
+
+// SetAnalyticsSink sets the sink that receives analytics messages after routing
+// TECHNICAL DISCOVERY: Set before the hub starts; the field is read without locking
+func (r *Router) SetAnalyticsSink(sink interfaces.AnalyticsSink) {
+	r.analytics = sink
+}
+
+// SetSessionLock makes the router refuse student messages in locked sessions
+// ARCHITECTURAL DISCOVERY: The router asks through a function rather than holding the
+// session manager, keeping routing independent of session lifecycle
+// TECHNICAL DISCOVERY: Set before the hub starts; the fields are read without locking
+func (r *Router) SetSessionLock(isLocked func(sessionID string) bool, exemptAnalytics bool) {
+	r.sessionLocked = isLocked
+	r.lockExemptTypes = map[string]bool{types.MessageTypeAnalytics: exemptAnalytics}
+}
+
+// DelegationLookup returns a student's grant of messageType in sessionID, if any
+type DelegationLookup func(sessionID, userID, messageType string) (*types.Delegation, bool)
+
+// SetDelegations lets students send the instructor message types granted to them
+// ARCHITECTURAL DISCOVERY: A function into the session manager's cache, like
+// SetSessionLock, so the grants are checked without the router holding sessions
+// TECHNICAL DISCOVERY: Set before the hub starts; the field is read without locking
+func (r *Router) SetDelegations(lookup DelegationLookup) {
+	r.delegation = lookup
+}
+
+// SetContentAllowlist restricts message content to the allowed keys per message type
+// TECHNICAL DISCOVERY: Set before the hub starts; the field is read without locking.
+// An empty allowlist turns filtering off
+func (r *Router) SetContentAllowlist(allowlist map[string][]string, strict bool) {
+	r.content = newContentFilter(allowlist, strict)
+}
+
+// SetAttachmentRules checks inline attachments against a MIME allowlist and a decoded
+// size cap, stripping violations or, when strict, refusing the message
+// TECHNICAL DISCOVERY: Set before the hub starts; the field is read without locking.
+// No MIME types turns checking off, and maxBytes 0 is DefaultMaxAttachmentBytes
+func (r *Router) SetAttachmentRules(mimeTypes []string, maxBytes int, strict bool) {
+	r.attachments = newAttachmentPolicy(mimeTypes, maxBytes, strict)
+}
+
+// SetDefaultContexts sets the context applied per message type when a message omits it
+// TECHNICAL DISCOVERY: Set before the hub starts; the map is read without locking
+func (r *Router) SetDefaultContexts(defaultContexts map[string]string) {
+	r.defaultContexts = defaultContexts
+}
+
+// CheckContent applies the content allowlist and attachment rules to message, stripping
+// or refusing as RouteMessage does
+// FUNCTIONAL DISCOVERY: Also applied to edited content, which reaches recipients
+// without being routed
+func (r *Router) CheckContent(message *types.Message) error {
+	if r.content != nil {
+		if err := r.content.apply(message); err != nil {
+			return err
+		}
+	}
+	if r.attachments != nil {
+		if err := r.attachments.apply(message); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// ContentFilterStats reports how often content allowlists stripped or rejected content
+func (r *Router) ContentFilterStats() types.ContentFilterStats {
+	var stats types.ContentFilterStats
+	if r.content != nil {
+		stats = r.content.stats()
+	}
+	if r.attachments != nil {
+		stats.AttachmentsStripped = r.attachments.stripped.Load()
+		stats.AttachmentsRejected = r.attachments.rejected.Load()
+	}
+	return stats
+}
+
+// ReleaseSession drops rate limiter state for everyone in an ended session
+// ARCHITECTURAL DISCOVERY: Registered with the hub's session resources; limits are
+// kept per user, so the roster and whoever is still connected stand in for the session
+func (r *Router) ReleaseSession(ended types.Session) {
+	userIDs := append([]string{ended.CreatedBy, ended.OwnerID}, ended.StudentIDs...)
+	for _, conn := range r.registry.GetSessionConnections(ended.ID) {
+		userIDs = append(userIDs, conn.GetUserID())
+	}
+	r.rateLimiter.Forget(userIDs)
+}
+
+// SetRateLimits makes the router read each user's rate limit from limits per message
+// TECHNICAL DISCOVERY: Set before the hub starts, like SetSessionLock; limits itself
+// must be safe to call concurrently, e.g. config.Store.RateLimits
+func (r *Router) SetRateLimits(limits func() (messages int, window time.Duration)) {
+	r.rateLimiter.SetLimits(limits)
+}
+
+// RateLimits returns the rate limit currently applied to each user
+func (r *Router) RateLimits() (messages int, window time.Duration) {
+	return r.rateLimiter.currentLimits()
+}
+
+// RateLimitedUsers returns how many users the rate limiter is tracking
+func (r *Router) RateLimitedUsers() int {
+	return r.rateLimiter.TrackedClients()
+}
+
+// AddPersistedObserver registers fn to run after each message is persisted
+// ARCHITECTURAL DISCOVERY: Observers (e.g. transcript writers) run synchronously on
+// the routing path, so they must hand work off without blocking
+// TECHNICAL DISCOVERY: Register before the hub starts; the slice is read without locking
+func (r *Router) AddPersistedObserver(fn func(*types.Message)) {
+	r.observers = append(r.observers, fn)
+}
+
+// storeMetadata records the sender's connection identity for a persisted message
+// ARCHITECTURAL DISCOVERY: Written to a side table off the hot path - metadata loss
+// is acceptable, delaying delivery for an audit detail is not
+func (r *Router) storeMetadata(messageID string, sender interfaces.ConnectionInfo) {
+	metadata := &types.MessageMetadata{
+		MessageID:    messageID,
+		ConnectionID: sender.GetConnectionID(),
+		ClientIP:     sender.GetClientIP(),
+		UserAgent:    sender.GetUserAgent(),
+		RecordedAt:   r.clock.Now(),
+	}
+	
+	go func() {
+		if err := r.dbManager.StoreMessageMetadata(context.Background(), metadata); err != nil {
+			log.Printf("Failed to store metadata for message %s: %v", messageID, err)
+		}
+	}()
+}
+
 // GetRecipients determines recipients based on message type
 // FUNCTIONAL DISCOVERY: Three distinct routing patterns based on message type and role relationships
 // ARCHITECTURAL DISCOVERY: Interface requires Client slice, conversion from Connection slice needed
@@ -104,7 +428,7 @@ func (r *Router) GetRecipients(message *types.Message) ([]*types.Client, error)
 	sessionID := message.SessionID
 	
 	switch message.Type {
-	case types.MessageTypeInstructorInbox, types.MessageTypeRequestResponse, types.MessageTypeAnalytics:
+	case types.MessageTypeInstructorInbox, types.MessageTypeRequestResponse, types.MessageTypeAnalytics, types.MessageTypeReaction:
 		// Route to all instructors in session
 		// ARCHITECTURAL DISCOVERY: Broadcast pattern for messages that all instructors should see
 		connections := r.registry.GetSessionInstructors(sessionID)
@@ -132,8 +456,19 @@ func (r *Router) GetRecipients(message *types.Message) ([]*types.Client, error)
 		
 	case types.MessageTypeInstructorBroadcast:
 		// Route to all students in session
-		// FUNCTIONAL DISCOVERY: Instructor broadcast pattern for classroom announcements
+		// FUNCTIONAL DISCOVERY: Instructor broadcast pattern for classroom announcements;
+		// a targeted broadcast's to_users are resolved by RouteMessage instead
 		connections := r.registry.GetSessionStudents(sessionID)
+		if message.Delegation != nil {
+			// A presenter's broadcast goes to the other students and to instructors,
+			// who did not write it and would otherwise only see it in history
+			connections = r.registry.GetSessionInstructors(sessionID)
+			for _, conn := range r.registry.GetSessionStudents(sessionID) {
+				if conn.GetUserID() != message.FromUser {
+					connections = append(connections, conn)
+				}
+			}
+		}
 		return r.convertConnectionsToClients(connections), nil
 		
 	default:
@@ -146,14 +481,17 @@ func (r *Router) GetRecipients(message *types.Message) ([]*types.Client, error)
 // ensures proper separation between authentication and authorization
 func (r *Router) ValidateMessage(message *types.Message, sender *types.Client) error {
 	// Verify sender is in the message's session
-	// FUNCTIONAL DISCOVERY: Session membership verified through registry lookup
-	senderConn, exists := r.registry.GetUserConnection(sender.ID)
-	if !exists {
-		return ErrSenderNotConnected
-	}
-	
-	if senderConn.GetSessionID() != message.SessionID {
-		return ErrSenderNotInSession
+	// FUNCTIONAL DISCOVERY: Session membership verified through registry lookup; the
+	// system sender has no connection and may send into any session
+	if sender.Role != types.SystemRole {
+		senderConn, exists := r.registry.GetUserConnection(sender.ID)
+		if !exists {
+			return ErrSenderNotConnected
+		}
+		
+		if senderConn.GetSessionID() != message.SessionID {
+			return ErrSenderNotInSession
+		}
 	}
 	
 	// Validate message type exists
@@ -163,9 +501,15 @@ func (r *Router) ValidateMessage(message *types.Message, sender *types.Client) e
 	
 	// Validate role permissions
 	// TECHNICAL DISCOVERY: Role-based permissions enforced for each message type
+	// FUNCTIONAL DISCOVERY: A student may also send the instructor types an instructor
+	// delegated to them; the message is flagged so recipients see who granted it
 	senderRole := sender.Role
 	if !r.canSendMessageType(senderRole, message.Type) {
-		return ErrUnauthorizedMessageType
+		grant, delegated := r.delegatedType(message, sender)
+		if !delegated {
+			return ErrUnauthorizedMessageType
+		}
+		message.Delegation = &types.MessageDelegation{GrantedBy: grant.GrantedBy, ExpiresAt: grant.ExpiresAt}
 	}
 	
 	// Validate context field
@@ -184,24 +528,68 @@ func (r *Router) ValidateMessage(message *types.Message, sender *types.Client) e
 }
 
 // Role-based message type permissions
-// FUNCTIONAL DISCOVERY: Exact 3-3 split between student and instructor message types
+// FUNCTIONAL DISCOVERY: Four student and three instructor message types; reactions are student-only
 func (r *Router) canSendMessageType(role, messageType string) bool {
 	switch role {
 	case "student":
 		return messageType == types.MessageTypeInstructorInbox ||
 			   messageType == types.MessageTypeRequestResponse ||
-			   messageType == types.MessageTypeAnalytics
+			   messageType == types.MessageTypeAnalytics ||
+			   messageType == types.MessageTypeReaction
 	case "instructor":
 		return messageType == types.MessageTypeInboxResponse ||
 			   messageType == types.MessageTypeRequest ||
 			   messageType == types.MessageTypeInstructorBroadcast
+	case types.SystemRole:
+		return messageType == types.MessageTypeInstructorBroadcast
 	default:
 		return false
 	}
 }
 
-// isValidMessageType checks if message type is one of the 6 allowed types
-// TECHNICAL DISCOVERY: Linear search acceptable for 6 message types, O(1) average case
+// delegatedType looks up a student sender's grant of the message's type
+func (r *Router) delegatedType(message *types.Message, sender *types.Client) (*types.Delegation, bool) {
+	if r.delegation == nil || sender.Role != "student" {
+		return nil, false
+	}
+	return r.delegation(message.SessionID, sender.ID, message.Type)
+}
+
+// routes summarizes GetRecipients and canSendMessageType for the capabilities document
+// TECHNICAL DISCOVERY: TestRoutingTable_MatchesPermissions keeps this table in step
+// with the switch statements it describes
+var routes = []types.RouteSummary{
+	{Type: types.MessageTypeInstructorInbox, SenderRole: "student", Recipients: "session_instructors"},
+	{Type: types.MessageTypeRequestResponse, SenderRole: "student", Recipients: "session_instructors"},
+	{Type: types.MessageTypeAnalytics, SenderRole: "student", Recipients: "session_instructors"},
+	{Type: types.MessageTypeReaction, SenderRole: "student", Recipients: "session_instructors"},
+	{Type: types.MessageTypeInboxResponse, SenderRole: "instructor", Recipients: "to_user", RequiresToUser: true},
+	{Type: types.MessageTypeRequest, SenderRole: "instructor", Recipients: "to_user", RequiresToUser: true},
+	{Type: types.MessageTypeInstructorBroadcast, SenderRole: "instructor", Recipients: "session_students"},
+}
+
+// RoutingTable returns who may send each message type, who receives it and the
+// context it defaults to under defaultContexts
+func RoutingTable(defaultContexts map[string]string) []types.RouteSummary {
+	table := append([]types.RouteSummary(nil), routes...)
+	for i := range table {
+		table[i].DefaultContext = DefaultContext(defaultContexts, table[i].Type)
+	}
+	return table
+}
+
+// DefaultContext returns the context a message of messageType gets when sent without one
+// ARCHITECTURAL DISCOVERY: Shared by routing and the capabilities document, so what
+// clients are told is what the router applies
+func DefaultContext(defaultContexts map[string]string, messageType string) string {
+	if context, ok := defaultContexts[messageType]; ok {
+		return context
+	}
+	return types.DefaultContext
+}
+
+// isValidMessageType checks if message type is one of the 7 allowed types
+// TECHNICAL DISCOVERY: Linear search acceptable for 7 message types, O(1) average case
 func (r *Router) isValidMessageType(messageType string) bool {
 	validTypes := []string{
 		types.MessageTypeInstructorInbox,
@@ -210,6 +598,7 @@ func (r *Router) isValidMessageType(messageType string) bool {
 		types.MessageTypeRequestResponse,
 		types.MessageTypeAnalytics,
 		types.MessageTypeInstructorBroadcast,
+		types.MessageTypeReaction,
 	}
 	
 	for _, validType := range validTypes {
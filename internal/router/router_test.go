@@ -2,14 +2,17 @@ package router
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	gorillaws "github.com/gorilla/websocket"
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
+	"switchboard/internal/testsupport"
 	"switchboard/internal/websocket"
 )
 
@@ -82,7 +85,8 @@ func TestRouteMessage_InstructorInbox(t *testing.T) {
 	router := NewRouter(registry, nil)
 
 	// Set up a student connection that will send the message
-	setupTestConnection(t, registry, "student1", "student", "session1")
+	sender := setupTestConnection(t, registry, "student1", "student", "session1")
+	setupTestConnection(t, registry, "instructor1", "instructor", "session1")
 
 	message := &types.Message{
 		SessionID: "session1",
@@ -92,11 +96,16 @@ func TestRouteMessage_InstructorInbox(t *testing.T) {
 		Content:   map[string]interface{}{"text": "Help needed"},
 	}
 
-	err := router.RouteMessage(context.Background(), message)
+	result, err := router.RouteMessage(context.Background(), message, sender)
 	if err != nil {
 		t.Errorf("Expected no error, got %v", err)
 	}
 
+	// Result should account for every resolved recipient
+	if result.MessageID != message.ID || len(result.Resolved) != 1 || len(result.Delivered) != 1 || result.Delivered[0] != "instructor1" {
+		t.Errorf("Expected delivery to instructor1, got %+v", result)
+	}
+
 	// Message should have server-generated ID and timestamp
 	if message.ID == "" {
 		t.Error("Expected server-generated message ID")
@@ -112,7 +121,7 @@ func TestRouteMessage_RoleValidation(t *testing.T) {
 	router := NewRouter(registry, nil)
 
 	// Set up a student connection that will try to send inbox_response (not allowed)
-	setupTestConnection(t, registry, "student1", "student", "session1")
+	sender := setupTestConnection(t, registry, "student1", "student", "session1")
 
 	// Should fail - students can't send inbox_response
 	message := &types.Message{
@@ -124,12 +133,188 @@ func TestRouteMessage_RoleValidation(t *testing.T) {
 		Content:   map[string]interface{}{"text": "Response"},
 	}
 
-	err := router.RouteMessage(context.Background(), message)
+	_, err := router.RouteMessage(context.Background(), message, sender)
 	if err != ErrUnauthorizedMessageType {
 		t.Errorf("Expected ErrUnauthorizedMessageType, got %v", err)
 	}
 }
 
+// exportRecorder is an AnalyticsSink that records exported message types
+type exportRecorder struct {
+	types []string
+}
+
+func (e *exportRecorder) Consume(ctx context.Context, message *types.Message) error {
+	e.types = append(e.types, message.Type)
+	return nil
+}
+
+// TestRouteMessage_AnalyticsExport tests functional validation - only analytics reach the sink
+func TestRouteMessage_AnalyticsExport(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	exporter := &exportRecorder{}
+	router.SetAnalyticsSink(exporter)
+
+	sender := setupTestConnection(t, registry, "student1", "student", "session1")
+
+	for _, messageType := range []string{types.MessageTypeAnalytics, types.MessageTypeInstructorInbox} {
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      messageType,
+			FromUser:  "student1",
+			Content:   map[string]interface{}{"text": "progress"},
+		}
+		if _, err := router.RouteMessage(context.Background(), message, sender); err != nil {
+			t.Fatalf("Expected no error routing %s, got %v", messageType, err)
+		}
+	}
+
+	if len(exporter.types) != 1 || exporter.types[0] != types.MessageTypeAnalytics {
+		t.Errorf("Expected only the analytics message to be exported, got %v", exporter.types)
+	}
+}
+
+// TestRouteMessage_ContentAllowlist tests functional validation - content is filtered before export
+func TestRouteMessage_ContentAllowlist(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	router.SetContentAllowlist(map[string][]string{types.MessageTypeAnalytics: {"attention_level"}}, false)
+	exporter := &contentRecorder{}
+	router.SetAnalyticsSink(exporter)
+
+	sender := setupTestConnection(t, registry, "student1", "student", "session1")
+	message := &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeAnalytics,
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"attention_level": 0.7, "clipboard": "secret"},
+	}
+	if _, err := router.RouteMessage(context.Background(), message, sender); err != nil {
+		t.Fatalf("Expected stripped message to route, got %v", err)
+	}
+
+	if len(exporter.contents) != 1 || len(exporter.contents[0]) != 1 || exporter.contents[0]["attention_level"] != 0.7 {
+		t.Errorf("Expected only attention_level to be routed, got %v", exporter.contents)
+	}
+	if stats := router.ContentFilterStats(); stats.KeysStripped != 1 {
+		t.Errorf("Expected 1 stripped key, got %+v", stats)
+	}
+
+	router.SetContentAllowlist(map[string][]string{types.MessageTypeAnalytics: {"attention_level"}}, true)
+	message.Content = map[string]interface{}{"clipboard": "secret"}
+	if _, err := router.RouteMessage(context.Background(), message, sender); !errors.Is(err, ErrContentKeyNotAllowed) {
+		t.Errorf("Expected strict mode to reject, got %v", err)
+	}
+	if len(exporter.contents) != 1 {
+		t.Error("Rejected message should not be exported")
+	}
+}
+
+// TestRouteMessage_SessionLocked tests functional validation - locked sessions refuse students only
+func TestRouteMessage_DropsClientAnnotations(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	student := setupTestConnection(t, registry, "student1", "student", "session1")
+
+	message := &types.Message{
+		SessionID:   "session1",
+		Type:        types.MessageTypeInstructorInbox,
+		FromUser:    "student1",
+		Content:     map[string]interface{}{"text": "hello"},
+		Annotations: []*types.MessageAnnotation{{InstructorID: "instructor1", Tags: []string{"star"}}},
+	}
+	if _, err := router.RouteMessage(context.Background(), message, student); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if message.Annotations != nil {
+		t.Errorf("Client-supplied annotations should be dropped, got %v", message.Annotations)
+	}
+}
+
+// TestRouteMessage_SenderSessionAuthoritative tests security validation - a student in
+// session A cannot persist or deliver into session B by naming it in the payload
+func TestRouteMessage_SenderSessionAuthoritative(t *testing.T) {
+	registry := websocket.NewRegistry()
+	store := newMessageStore()
+	router := NewRouter(registry, store)
+	student, _ := setupReceivingConnection(t, registry, "student1", "student", "sessionA")
+	_, ownInstructor := setupReceivingConnection(t, registry, "instructor1", "instructor", "sessionA")
+	_, otherInstructor := setupReceivingConnection(t, registry, "instructor2", "instructor", "sessionB")
+
+	crafted := &types.Message{
+		SessionID: "sessionB",
+		Type:      types.MessageTypeInstructorInbox,
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"text": "crafted"},
+	}
+	result, err := router.RouteMessage(context.Background(), crafted, student)
+	if err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+	if stored := store.messages[result.MessageID]; stored.SessionID != "sessionA" {
+		t.Errorf("Expected the message persisted in the sender's session, got %q", stored.SessionID)
+	}
+	if received := receiveRouted(t, ownInstructor); received["session_id"] != "sessionA" {
+		t.Errorf("Expected delivery in sessionA, got %v", received)
+	}
+	select {
+	case msg := <-otherInstructor:
+		t.Errorf("Instructor in sessionB received %v", msg)
+	default:
+	}
+}
+
+func TestRouteMessage_SessionLocked(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	locked := map[string]bool{"session1": true}
+	router.SetSessionLock(func(sessionID string) bool { return locked[sessionID] }, true)
+
+	student := setupTestConnection(t, registry, "student1", "student", "session1")
+	instructor := setupTestConnection(t, registry, "instructor1", "instructor", "session1")
+
+	route := func(sender interfaces.ConnectionInfo, messageType string) error {
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      messageType,
+			FromUser:  sender.GetUserID(),
+			Content:   map[string]interface{}{"text": "hello"},
+		}
+		_, err := router.RouteMessage(context.Background(), message, sender)
+		return err
+	}
+
+	if err := route(student, types.MessageTypeInstructorInbox); !errors.Is(err, interfaces.ErrSessionLocked) {
+		t.Errorf("Expected ErrSessionLocked for a student question, got %v", err)
+	}
+	if err := route(student, types.MessageTypeAnalytics); err != nil {
+		t.Errorf("Exempt analytics should still route, got %v", err)
+	}
+	if err := route(instructor, types.MessageTypeInstructorBroadcast); err != nil {
+		t.Errorf("Instructors should not be locked out, got %v", err)
+	}
+
+	router.SetSessionLock(func(sessionID string) bool { return locked[sessionID] }, false)
+	if err := route(student, types.MessageTypeAnalytics); !errors.Is(err, interfaces.ErrSessionLocked) {
+		t.Errorf("Analytics should be refused without the exemption, got %v", err)
+	}
+
+	locked["session1"] = false
+	if err := route(student, types.MessageTypeInstructorInbox); err != nil {
+		t.Errorf("Unlocked session should route student messages, got %v", err)
+	}
+}
+
+type contentRecorder struct {
+	contents []map[string]interface{}
+}
+
+func (c *contentRecorder) Consume(ctx context.Context, message *types.Message) error {
+	c.contents = append(c.contents, message.Content)
+	return nil
+}
+
 // TestRouteMessage_ContextDefaulting tests functional validation - context field handling  
 func TestRouteMessage_ContextDefaulting(t *testing.T) {
 	registry := websocket.NewRegistry()
@@ -144,7 +329,7 @@ func TestRouteMessage_ContextDefaulting(t *testing.T) {
 	}
 
 	// This will fail because router doesn't exist yet
-	err := router.RouteMessage(context.Background(), message)
+	_, err := router.RouteMessage(context.Background(), message, nil)
 	if err != nil && err != ErrSenderNotConnected {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -155,6 +340,49 @@ func TestRouteMessage_ContextDefaulting(t *testing.T) {
 	}
 }
 
+// TestRouteMessage_DefaultContexts tests functional validation - per-type defaults only fill an omitted context
+func TestRouteMessage_DefaultContexts(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	router.SetDefaultContexts(map[string]string{types.MessageTypeAnalytics: "engagement"})
+	student := setupTestConnection(t, registry, "student1", "student", "session1")
+
+	route := func(messageType, messageContext string) (*types.Message, error) {
+		message := &types.Message{
+			SessionID: "session1",
+			Type:      messageType,
+			FromUser:  "student1",
+			Context:   messageContext,
+			Content:   map[string]interface{}{"text": "hello"},
+		}
+		_, err := router.RouteMessage(context.Background(), message, student)
+		return message, err
+	}
+
+	if message, err := route(types.MessageTypeAnalytics, ""); err != nil || message.Context != "engagement" {
+		t.Errorf("Expected analytics to default to engagement, got %q (%v)", message.Context, err)
+	}
+	if message, err := route(types.MessageTypeAnalytics, "progress"); err != nil || message.Context != "progress" {
+		t.Errorf("Expected an explicit context kept, got %q (%v)", message.Context, err)
+	}
+	if message, err := route(types.MessageTypeInstructorInbox, ""); err != nil || message.Context != types.DefaultContext {
+		t.Errorf("Expected unmapped types to default to %s, got %q (%v)", types.DefaultContext, message.Context, err)
+	}
+	if _, err := route(types.MessageTypeAnalytics, "not a context!"); !errors.Is(err, ErrInvalidContext) {
+		t.Errorf("Expected an invalid explicit context still rejected, got %v", err)
+	}
+
+	for _, summary := range RoutingTable(map[string]string{types.MessageTypeAnalytics: "engagement"}) {
+		want := types.DefaultContext
+		if summary.Type == types.MessageTypeAnalytics {
+			want = "engagement"
+		}
+		if summary.DefaultContext != want {
+			t.Errorf("Routing table lists default context %q for %s, want %q", summary.DefaultContext, summary.Type, want)
+		}
+	}
+}
+
 // TestRateLimiter_Allow tests technical validation - rate limiting behavior
 func TestRateLimiter_Allow(t *testing.T) {
 	// This should fail because RateLimiter doesn't exist yet
@@ -177,7 +405,9 @@ func TestRateLimiter_Allow(t *testing.T) {
 
 // TestRateLimiter_WindowReset tests technical validation - rate limit window reset
 func TestRateLimiter_WindowReset(t *testing.T) {
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC))
 	limiter := NewRateLimiter()
+	limiter.SetClock(clock)
 	userID := "user1"
 
 	// Fill up the rate limit
@@ -190,10 +420,23 @@ func TestRateLimiter_WindowReset(t *testing.T) {
 		t.Error("Should be denied after 100 messages")
 	}
 
-	// Simulate time passage - this test will need adjustment once implementation exists
-	// For now, just test the structure exists
-	if limiter == nil {
-		t.Error("RateLimiter should exist")
+	// Still denied until the whole window has passed
+	clock.Advance(RateLimitWindow - time.Second)
+	if limiter.Allow(userID) {
+		t.Error("Should still be denied within the window")
+	}
+	clock.Advance(time.Second)
+	if !limiter.Allow(userID) {
+		t.Error("Should be allowed again once the window has passed")
+	}
+
+	// Cleanup drops users idle for more than five windows
+	limiter.Allow("user2")
+	clock.Advance(5*RateLimitWindow + time.Second)
+	limiter.Allow("user3")
+	limiter.Cleanup()
+	if tracked := limiter.TrackedClients(); tracked != 1 {
+		t.Errorf("Expected only the recently active user tracked after cleanup, got %d", tracked)
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"switchboard/internal/websocket"
 	"switchboard/pkg/types"
 )
 
@@ -114,6 +115,7 @@ func TestIsValidMessageType(t *testing.T) {
 		types.MessageTypeRequestResponse,
 		types.MessageTypeAnalytics,
 		types.MessageTypeInstructorBroadcast,
+		types.MessageTypeReaction,
 	}
 	
 	// Test all valid types
@@ -153,6 +155,7 @@ func TestCanSendMessageType(t *testing.T) {
 		{"student", types.MessageTypeInstructorInbox, true},
 		{"student", types.MessageTypeRequestResponse, true},
 		{"student", types.MessageTypeAnalytics, true},
+		{"student", types.MessageTypeReaction, true},
 		{"student", types.MessageTypeInboxResponse, false},
 		{"student", types.MessageTypeRequest, false},
 		{"student", types.MessageTypeInstructorBroadcast, false},
@@ -164,6 +167,7 @@ func TestCanSendMessageType(t *testing.T) {
 		{"instructor", types.MessageTypeInstructorInbox, false},
 		{"instructor", types.MessageTypeRequestResponse, false},
 		{"instructor", types.MessageTypeAnalytics, false},
+		{"instructor", types.MessageTypeReaction, false},
 		
 		// Invalid role
 		{"admin", types.MessageTypeInstructorInbox, false},
@@ -232,4 +236,59 @@ func TestRateLimiter_Cleanup(t *testing.T) {
 	}
 }
 
-// Test focusing on isolated router logic without external dependencies
\ No newline at end of file
+// Test focusing on isolated router logic without external dependencies
+// TestRoutingTable_MatchesPermissions tests that the published routing table matches enforcement
+func TestRoutingTable_MatchesPermissions(t *testing.T) {
+	router := &Router{}
+	table := RoutingTable(nil)
+	
+	if len(table) != 7 {
+		t.Fatalf("Expected 7 routes, got %d", len(table))
+	}
+	for _, route := range table {
+		for _, role := range []string{"student", "instructor"} {
+			if allowed := router.canSendMessageType(role, route.Type); allowed != (role == route.SenderRole) {
+				t.Errorf("Route %s lists sender %s, but canSendMessageType(%s) = %v", route.Type, route.SenderRole, role, allowed)
+			}
+		}
+		
+		// Direct routes fail without a recipient; fan-out routes ignore ToUser
+		_, err := (&Router{registry: websocket.NewRegistry()}).GetRecipients(&types.Message{Type: route.Type})
+		if route.RequiresToUser != (err == ErrMissingRecipient) {
+			t.Errorf("Route %s requires_to_user=%v, but GetRecipients without to_user returned %v", route.Type, route.RequiresToUser, err)
+		}
+	}
+}
+
+// TestRouter_ConfigSnapshot tests functional validation - the snapshot records the
+// routing table and settings the router is applying
+func TestRouter_ConfigSnapshot(t *testing.T) {
+	router := NewRouter(websocket.NewRegistry(), nil)
+	config := router.ConfigSnapshot()
+	if config.Version != types.SessionConfigVersion || len(config.Routing) != 7 || config.Limits.MaxContentBytes != types.MaxContentBytes {
+		t.Errorf("Unexpected default snapshot: %+v", config)
+	}
+	if config.LockExemptTypes != nil || config.RejectLateResponses || len(config.ReactionCodes) != len(types.DefaultReactionCodes) {
+		t.Errorf("Expected default settings, got %+v", config)
+	}
+	
+	router.SetDefaultContexts(map[string]string{types.MessageTypeAnalytics: "telemetry"})
+	router.SetSessionLock(func(string) bool { return false }, true)
+	router.SetRejectLateSubmissions(true)
+	router.SetReactionCodes([]string{"thumbs_up", "party"})
+	router.SetRateLimits(func() (int, time.Duration) { return 20, 30 * time.Second })
+	config = router.ConfigSnapshot()
+	
+	if route, ok := config.Route(types.MessageTypeAnalytics); !ok || route.DefaultContext != "telemetry" {
+		t.Errorf("Expected the configured default context in the snapshot, got %+v", route)
+	}
+	if len(config.LockExemptTypes) != 1 || config.LockExemptTypes[0] != types.MessageTypeAnalytics || !config.RejectLateResponses {
+		t.Errorf("Expected lock exemptions and late rejection recorded, got %+v", config)
+	}
+	if len(config.ReactionCodes) != 2 || config.ReactionCodes[0] != "party" {
+		t.Errorf("Expected sorted reaction codes, got %v", config.ReactionCodes)
+	}
+	if config.Limits.RateLimitMessages != 20 || config.Limits.RateLimitWindowSeconds != 30 {
+		t.Errorf("Expected current rate limits, got %+v", config.Limits)
+	}
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	"sort"
+
+	"switchboard/pkg/types"
+)
+
+// ConfigSnapshot returns the routing configuration new sessions are created under
+// ARCHITECTURAL DISCOVERY: Built from the same fields RouteMessage reads, so a
+// session's snapshot records the rules its messages were actually routed by
+// TECHNICAL DISCOVERY: Rate limits are read at call time, so a reload changes the
+// snapshots of sessions created after it and leaves earlier ones as they were
+func (r *Router) ConfigSnapshot() *types.SessionConfig {
+	messages, window := r.RateLimits()
+	config := &types.SessionConfig{
+		Version: types.SessionConfigVersion,
+		Routing: RoutingTable(r.defaultContexts),
+		Limits: types.SessionLimits{
+			MaxContentBytes:        types.MaxContentBytes,
+			RateLimitMessages:      messages,
+			RateLimitWindowSeconds: int(window.Seconds()),
+			MaxBroadcastRecipients: r.broadcastRecipientLimit(),
+		},
+		RejectLateResponses:      r.rejectLate,
+		PersistUnknownRecipients: r.persistUnknown,
+		ReactionCodes:            types.DefaultReactionCodes,
+	}
+
+	if r.sessionLocked != nil {
+		for messageType, exempt := range r.lockExemptTypes {
+			if exempt {
+				config.LockExemptTypes = append(config.LockExemptTypes, messageType)
+			}
+		}
+		sort.Strings(config.LockExemptTypes)
+	}
+	if r.reactionCodes != nil {
+		config.ReactionCodes = make([]string, 0, len(r.reactionCodes))
+		for code := range r.reactionCodes {
+			config.ReactionCodes = append(config.ReactionCodes, code)
+		}
+		sort.Strings(config.ReactionCodes)
+	}
+	return config
+}
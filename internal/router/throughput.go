@@ -0,0 +1,302 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// throughputWindow is how much per-minute history the router keeps in memory
+const throughputWindow = 24 * time.Hour
+
+// throughputSlots is the ring size: one slot per minute of throughputWindow
+const throughputSlots = int(throughputWindow / time.Minute)
+
+// DefaultRollupInterval is how often throughput buckets are written to the database
+const DefaultRollupInterval = time.Hour
+
+// throughputCounters counts messages and connections per minute for the last 24 hours
+// ARCHITECTURAL DISCOVERY: A fixed ring of slots indexed by Unix minute, so memory is
+// bounded by the window and the number of sessions in it, and recording a message is a
+// lock and two increments
+// TECHNICAL DISCOVERY: A slot whose minute is not the one being looked up holds a day
+// old minute and is reset on first use
+type throughputCounters struct {
+	mu    sync.Mutex
+	slots [throughputSlots]throughputSlot
+}
+
+type throughputSlot struct {
+	minute      int64 // Unix minute held, 0 while unused
+	messages    int
+	connections int
+	sessions    map[string]*types.SessionThroughput
+	dirty       bool // Changed since it was last flushed
+}
+
+// slotLocked returns the slot for minute, reset if it held another minute; caller holds c.mu
+func (c *throughputCounters) slotLocked(minute int64) *throughputSlot {
+	slot := &c.slots[minute%int64(throughputSlots)]
+	if slot.minute != minute {
+		*slot = throughputSlot{minute: minute}
+	}
+	return slot
+}
+
+func (s *throughputSlot) session(sessionID string) *types.SessionThroughput {
+	if s.sessions == nil {
+		s.sessions = make(map[string]*types.SessionThroughput)
+	}
+	counts, exists := s.sessions[sessionID]
+	if !exists {
+		counts = &types.SessionThroughput{}
+		s.sessions[sessionID] = counts
+	}
+	return counts
+}
+
+func (c *throughputCounters) recordMessage(sessionID string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	slot := c.slotLocked(unixMinute(at))
+	slot.messages++
+	slot.session(sessionID).Messages++
+	slot.dirty = true
+}
+
+// sampleConnections records counts as the minute's connections where it is the most seen
+func (c *throughputCounters) sampleConnections(counts map[string]int, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	slot := c.slotLocked(unixMinute(at))
+	total := 0
+	for sessionID, count := range counts {
+		total += count
+		if session := slot.session(sessionID); count > session.Connections {
+			session.Connections = count
+		}
+	}
+	if total > slot.connections {
+		slot.connections = total
+	}
+	slot.dirty = true
+}
+
+// history returns one bucket per minute from since through now, zero where nothing happened
+// FUNCTIONAL DISCOVERY: With a sessionID each bucket carries only that session's
+// counts, in Messages and Connections, and no per-session breakdown
+func (c *throughputCounters) history(since, now time.Time, sessionID string) []types.ThroughputBucket {
+	first, last := unixMinute(since), unixMinute(now)
+	buckets := make([]types.ThroughputBucket, 0, last-first+1)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for minute := first; minute <= last; minute++ {
+		bucket := types.ThroughputBucket{Start: time.Unix(minute*60, 0).UTC()}
+		if slot := &c.slots[minute%int64(throughputSlots)]; slot.minute == minute {
+			if sessionID == "" {
+				bucket = slot.bucket()
+			} else if counts, exists := slot.sessions[sessionID]; exists {
+				bucket.Messages, bucket.Connections = counts.Messages, counts.Connections
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets
+}
+
+func (s *throughputSlot) bucket() types.ThroughputBucket {
+	bucket := types.ThroughputBucket{
+		Start:       time.Unix(s.minute*60, 0).UTC(),
+		Messages:    s.messages,
+		Connections: s.connections,
+	}
+	if len(s.sessions) > 0 {
+		bucket.Sessions = make(map[string]types.SessionThroughput, len(s.sessions))
+		for sessionID, counts := range s.sessions {
+			bucket.Sessions[sessionID] = *counts
+		}
+	}
+	return bucket
+}
+
+// takeDirty copies the slots changed since the last flush and marks them clean
+func (c *throughputCounters) takeDirty() []types.ThroughputBucket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var buckets []types.ThroughputBucket
+	for i := range c.slots {
+		if slot := &c.slots[i]; slot.dirty {
+			buckets = append(buckets, slot.bucket())
+			slot.dirty = false
+		}
+	}
+	return buckets
+}
+
+// markDirty flags buckets for the next flush again, unless their slot has moved on
+func (c *throughputCounters) markDirty(buckets []types.ThroughputBucket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, bucket := range buckets {
+		minute := unixMinute(bucket.Start)
+		if slot := &c.slots[minute%int64(throughputSlots)]; slot.minute == minute {
+			slot.dirty = true
+		}
+	}
+}
+
+// load restores persisted buckets into empty slots
+// TECHNICAL DISCOVERY: Runs before traffic; a slot already counting its minute keeps
+// its counts, since they started after the stored ones were flushed
+func (c *throughputCounters) load(buckets []types.ThroughputBucket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, bucket := range buckets {
+		minute := unixMinute(bucket.Start)
+		slot := &c.slots[minute%int64(throughputSlots)]
+		if slot.minute >= minute {
+			continue
+		}
+		*slot = throughputSlot{minute: minute, messages: bucket.Messages, connections: bucket.Connections}
+		for sessionID, counts := range bucket.Sessions {
+			*slot.session(sessionID) = counts
+		}
+	}
+}
+
+func unixMinute(at time.Time) int64 {
+	return at.Unix() / 60
+}
+
+// ThroughputHistory returns the per-minute series for the last hours, at most 24
+// FUNCTIONAL DISCOVERY: sessionID narrows the series to one session; empty gives the
+// whole server with each minute's per-session breakdown
+func (r *Router) ThroughputHistory(hours int, sessionID string) types.ThroughputHistory {
+	if hours <= 0 || hours > int(throughputWindow/time.Hour) {
+		hours = int(throughputWindow / time.Hour)
+	}
+	now := r.clock.Now()
+	since := now.Add(-time.Duration(hours)*time.Hour + time.Minute)
+	return types.ThroughputHistory{
+		Hours:         hours,
+		BucketSeconds: types.ThroughputBucketSeconds,
+		SessionID:     sessionID,
+		Buckets:       r.throughput.history(since, now, sessionID),
+	}
+}
+
+// ErrRollupsAlreadyRunning is returned by ThroughputRollups.Start when already started
+var ErrRollupsAlreadyRunning = errors.New("throughput rollups already running")
+
+// ThroughputRollups samples connection counts each minute and flushes the router's
+// throughput buckets to a RollupStore
+// FUNCTIONAL DISCOVERY: Best-effort like snapshots - a failed flush is logged and its
+// buckets are flushed again with the next one
+type ThroughputRollups struct {
+	router   *Router
+	store    interfaces.RollupStore
+	interval time.Duration
+
+	shutdownChannel chan struct{} // Closed by Stop
+	done            chan struct{} // Closed when the run goroutine exits
+
+	running bool
+	mu      sync.Mutex
+}
+
+// NewThroughputRollups creates a writer that flushes router's buckets to store every
+// interval; interval <= 0 means DefaultRollupInterval
+func NewThroughputRollups(router *Router, store interfaces.RollupStore, interval time.Duration) *ThroughputRollups {
+	if interval <= 0 {
+		interval = DefaultRollupInterval
+	}
+	return &ThroughputRollups{
+		router:          router,
+		store:           store,
+		interval:        interval,
+		shutdownChannel: make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// Start loads the last 24 hours of stored buckets and begins sampling and flushing
+// FUNCTIONAL DISCOVERY: Loading first means a restart continues the series rather than
+// starting it from zero; a failed load is logged and the series starts empty
+func (w *ThroughputRollups) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.running {
+		return ErrRollupsAlreadyRunning
+	}
+	w.running = true
+
+	since := w.router.clock.Now().Add(-throughputWindow)
+	if buckets, err := w.store.LoadThroughputBuckets(ctx, since); err != nil {
+		log.Printf("WARNING: Throughput history not restored: %v", err)
+	} else {
+		w.router.throughput.load(buckets)
+	}
+
+	go w.run(ctx)
+	return nil
+}
+
+// Stop ends sampling after a final flush
+// TECHNICAL DISCOVERY: Runs before the database closes, so the minutes since the last
+// hourly flush survive a planned restart
+func (w *ThroughputRollups) Stop() {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return
+	}
+	w.running = false
+	close(w.shutdownChannel)
+	w.mu.Unlock()
+
+	<-w.done
+}
+
+func (w *ThroughputRollups) run(ctx context.Context) {
+	defer close(w.done)
+
+	sample := w.router.clock.NewTicker(time.Minute)
+	defer sample.Stop()
+	flush := w.router.clock.NewTicker(w.interval)
+	defer flush.Stop()
+
+	w.sample()
+	for {
+		select {
+		case <-sample.C():
+			w.sample()
+		case <-flush.C():
+			w.flush()
+		case <-w.shutdownChannel:
+			w.flush()
+			return
+		case <-ctx.Done():
+			w.flush()
+			return
+		}
+	}
+}
+
+func (w *ThroughputRollups) sample() {
+	w.router.throughput.sampleConnections(w.router.registry.GetSessionConnectionCounts(), w.router.clock.Now())
+}
+
+// flush writes every bucket changed since the last flush
+func (w *ThroughputRollups) flush() {
+	buckets := w.router.throughput.takeDirty()
+	if err := w.store.StoreThroughputBuckets(context.Background(), buckets); err != nil {
+		log.Printf("WARNING: Throughput rollup failed, retrying with the next flush: %v", err)
+		w.router.throughput.markDirty(buckets)
+	}
+}
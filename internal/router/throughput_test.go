@@ -0,0 +1,199 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/internal/testsupport"
+	"switchboard/internal/websocket"
+	"switchboard/pkg/types"
+)
+
+// rollupStore keeps throughput buckets in memory, by bucket start
+type rollupStore struct {
+	mu      sync.Mutex
+	buckets map[int64]types.ThroughputBucket
+	flushes int // Attempts, including failed ones
+	fail    bool
+}
+
+func (s *rollupStore) StoreThroughputBuckets(ctx context.Context, buckets []types.ThroughputBucket) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushes++
+	if s.fail {
+		return errors.New("database unavailable")
+	}
+	for _, bucket := range buckets {
+		s.buckets[bucket.Start.Unix()] = bucket
+	}
+	return nil
+}
+
+func (s *rollupStore) LoadThroughputBuckets(ctx context.Context, since time.Time) ([]types.ThroughputBucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var buckets []types.ThroughputBucket
+	for start, bucket := range s.buckets {
+		if start >= since.Unix() {
+			buckets = append(buckets, bucket)
+		}
+	}
+	return buckets, nil
+}
+
+func (s *rollupStore) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+func (s *rollupStore) attempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushes
+}
+
+func (s *rollupStore) stored(start time.Time) (types.ThroughputBucket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket, exists := s.buckets[start.Unix()]
+	return bucket, exists
+}
+
+// TestRouter_ThroughputHistory tests functional validation - routed messages and
+// sampled connections are counted per minute, globally and per session
+func TestRouter_ThroughputHistory(t *testing.T) {
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	clock := testsupport.NewFakeClock(start)
+	router.SetClock(clock)
+	instructor1, _ := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+	instructor2, _ := setupReceivingConnection(t, registry, "instructor2", "instructor", "session2")
+
+	broadcast := func(sender *websocket.Connection) {
+		t.Helper()
+		_, err := router.RouteMessage(context.Background(), &types.Message{
+			SessionID: sender.GetSessionID(),
+			Type:      types.MessageTypeInstructorBroadcast,
+			FromUser:  sender.GetUserID(),
+			Content:   map[string]interface{}{"text": "hello"},
+		}, sender)
+		if err != nil {
+			t.Fatalf("RouteMessage failed: %v", err)
+		}
+	}
+
+	broadcast(instructor1)
+	broadcast(instructor1)
+	broadcast(instructor2)
+	router.throughput.sampleConnections(registry.GetSessionConnectionCounts(), clock.Now())
+	clock.Advance(2 * time.Minute)
+	broadcast(instructor2)
+
+	history := router.ThroughputHistory(1, "")
+	if history.Hours != 1 || history.BucketSeconds != 60 || len(history.Buckets) != 60 {
+		t.Fatalf("Expected 60 one-minute buckets, got %d (%+v)", len(history.Buckets), history)
+	}
+	first, quiet, last := history.Buckets[57], history.Buckets[58], history.Buckets[59]
+	if !first.Start.Equal(start) || first.Messages != 3 || first.Connections != 2 {
+		t.Errorf("Expected 3 messages and 2 connections at %v, got %+v", start, first)
+	}
+	if first.Sessions["session1"] != (types.SessionThroughput{Messages: 2, Connections: 1}) {
+		t.Errorf("Expected session1's share, got %+v", first.Sessions["session1"])
+	}
+	if quiet.Messages != 0 || quiet.Sessions != nil {
+		t.Errorf("Expected an empty bucket for the quiet minute, got %+v", quiet)
+	}
+	if last.Messages != 1 || last.Sessions["session2"].Messages != 1 {
+		t.Errorf("Expected the last minute's message, got %+v", last)
+	}
+
+	// Narrowed to one session, with no breakdown
+	session1 := router.ThroughputHistory(1, "session1")
+	if got := session1.Buckets[57]; got.Messages != 2 || got.Connections != 1 || got.Sessions != nil || session1.Buckets[59].Messages != 0 {
+		t.Errorf("Expected session1's series only, got %+v", session1.Buckets[57:])
+	}
+
+	// Out-of-range hours mean the whole window
+	if whole := router.ThroughputHistory(0, ""); whole.Hours != 24 || len(whole.Buckets) != 24*60 {
+		t.Errorf("Expected 24 hours of buckets, got %d hours, %d buckets", whole.Hours, len(whole.Buckets))
+	}
+
+	// A day later the ring has reused the slots
+	clock.Advance(24 * time.Hour)
+	broadcast(instructor1)
+	for _, bucket := range router.ThroughputHistory(24, "").Buckets[:24*60-1] {
+		if bucket.Messages != 0 {
+			t.Fatalf("Expected yesterday's counts gone, found %+v", bucket)
+		}
+	}
+}
+
+// TestThroughputRollups tests functional validation - buckets are flushed on the
+// interval and at Stop, and a restart continues the stored series
+func TestThroughputRollups(t *testing.T) {
+	start := time.Date(2026, 10, 12, 9, 0, 0, 0, time.UTC)
+	store := &rollupStore{buckets: map[int64]types.ThroughputBucket{
+		start.Add(-30 * time.Minute).Unix(): {Start: start.Add(-30 * time.Minute), Messages: 7, Connections: 3},
+		start.Add(-25 * time.Hour).Unix():   {Start: start.Add(-25 * time.Hour), Messages: 99},
+	}}
+
+	registry := websocket.NewRegistry()
+	router := NewRouter(registry, nil)
+	clock := testsupport.NewFakeClock(start)
+	router.SetClock(clock)
+	instructor, _ := setupReceivingConnection(t, registry, "instructor1", "instructor", "session1")
+
+	rollups := NewThroughputRollups(router, store, time.Hour)
+	if err := rollups.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := rollups.Start(context.Background()); !errors.Is(err, ErrRollupsAlreadyRunning) {
+		t.Errorf("Expected ErrRollupsAlreadyRunning, got %v", err)
+	}
+	if restored := router.ThroughputHistory(1, "").Buckets[29]; restored.Messages != 7 || restored.Connections != 3 {
+		t.Errorf("Expected the stored bucket restored, got %+v", restored)
+	}
+
+	if _, err := router.RouteMessage(context.Background(), &types.Message{
+		SessionID: "session1",
+		Type:      types.MessageTypeInstructorBroadcast,
+		FromUser:  "instructor1",
+		Content:   map[string]interface{}{"text": "hello"},
+	}, instructor); err != nil {
+		t.Fatalf("RouteMessage failed: %v", err)
+	}
+
+	// A failed flush is retried with the next one
+	store.setFail(true)
+	waitFor(t, func() bool { return clock.Pending() == 2 })
+	clock.Advance(time.Hour)
+	waitFor(t, func() bool { return store.attempts() == 1 })
+	if _, stored := store.stored(start); stored {
+		t.Fatal("Expected nothing stored by the failed flush")
+	}
+	store.setFail(false)
+
+	rollups.Stop()
+	bucket, stored := store.stored(start)
+	if !stored || bucket.Messages != 1 || bucket.Sessions["session1"].Connections != 1 {
+		t.Errorf("Expected the routed minute flushed at Stop, got %+v", bucket)
+	}
+	rollups.Stop() // Safe to repeat
+}
+
+func waitFor(t *testing.T, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,147 @@
+package session
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// DefaultActivityInterval is how often session activity is written unless
+// SetActivityStore is given another interval
+const DefaultActivityInterval = 30 * time.Second
+
+// SetActivityStore persists session activity, written at most once per interval
+// ARCHITECTURAL DISCOVERY: Without a store TouchSession does nothing and startup
+// resumes every active session
+// TECHNICAL DISCOVERY: Set before LoadActiveSessions, which reads activity back; the
+// fields are read without locking. interval <= 0 restores DefaultActivityInterval
+func (m *Manager) SetActivityStore(store interfaces.ActivityStore, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultActivityInterval
+	}
+	m.activityStore = store
+	m.flushInterval = interval
+}
+
+// SetIdleEndAfter ends sessions at startup whose last activity is older than limit;
+// 0 resumes every active session
+// TECHNICAL DISCOVERY: Set before LoadActiveSessions; read without locking
+func (m *Manager) SetIdleEndAfter(limit time.Duration) {
+	m.idleEndAfter = limit
+}
+
+// TouchSession records activity in an active session
+// FUNCTIONAL DISCOVERY: Called for every routed message and connection change, so it
+// only updates memory; the first touch after a write schedules the next one, which
+// carries every session touched meanwhile. A classroom's messages cost one write per
+// interval however fast they arrive
+func (m *Manager) TouchSession(sessionID string) {
+	if m.activityStore == nil {
+		return
+	}
+	now := m.clock.Now()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if _, active := m.activeSessions[sessionID]; !active {
+		return
+	}
+
+	m.activityMu.Lock()
+	defer m.activityMu.Unlock()
+	m.lastActivity[sessionID] = now
+	m.pendingActivity[sessionID] = now
+	if !m.flushScheduled {
+		m.flushScheduled = true
+		m.clock.AfterFunc(m.flushInterval, m.FlushActivity)
+	}
+}
+
+// LastActivity reports when an active session was last active, if that is known
+func (m *Manager) LastActivity(sessionID string) (time.Time, bool) {
+	m.activityMu.Lock()
+	defer m.activityMu.Unlock()
+	at, known := m.lastActivity[sessionID]
+	return at, known
+}
+
+// FlushActivity writes activity not yet persisted
+// FUNCTIONAL DISCOVERY: Also called at shutdown, so a clean restart resumes sessions
+// with their activity up to the moment the server stopped
+func (m *Manager) FlushActivity() {
+	m.activityMu.Lock()
+	pending := m.pendingActivity
+	m.pendingActivity = make(map[string]time.Time)
+	m.flushScheduled = false
+	m.activityMu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+	if err := m.activityStore.RecordSessionActivity(context.Background(), pending); err != nil {
+		log.Printf("Failed to record activity for %d sessions: %v", len(pending), err)
+	}
+}
+
+// reconcileActivity seeds last activity for loaded sessions and ends those idle
+// longer than the idle limit
+// FUNCTIONAL DISCOVERY: Only sessions with recorded activity are judged; sessions from
+// before activity was recorded are resumed, as they always were
+func (m *Manager) reconcileActivity(ctx context.Context, sessions []*types.Session) {
+	if m.activityStore == nil {
+		return
+	}
+	activity, err := m.activityStore.GetSessionActivity(ctx)
+	if err != nil {
+		log.Printf("Failed to load session activity, resuming every session: %v", err)
+		return
+	}
+
+	now := m.clock.Now()
+	var idle []*types.Session
+	m.activityMu.Lock()
+	for _, session := range sessions {
+		at, known := activity[session.ID]
+		if !known {
+			continue
+		}
+		m.lastActivity[session.ID] = at
+		if m.idleEndAfter > 0 && now.Sub(at) > m.idleEndAfter {
+			idle = append(idle, session)
+		}
+	}
+	m.activityMu.Unlock()
+
+	for _, session := range idle {
+		lastActivity := activity[session.ID]
+		if err := m.EndSession(ctx, session.ID); err != nil {
+			log.Printf("Failed to end idle session %s at startup: %v", session.ID, err)
+			continue
+		}
+		log.Printf("Ended idle session at startup: id=%s last_activity=%s", session.ID, lastActivity.Format(time.RFC3339))
+		event := &types.SessionEvent{
+			SessionID: session.ID,
+			Type:      types.SessionEventIdleEnded,
+			Actor:     types.SystemSenderID,
+			Details: map[string]interface{}{
+				"last_activity": lastActivity,
+				"idle_limit":    m.idleEndAfter.String(),
+			},
+			CreatedAt: now,
+		}
+		if err := m.dbManager.RecordSessionEvent(ctx, event); err != nil {
+			log.Printf("Failed to record %s for session %s: %v", types.SessionEventIdleEnded, session.ID, err)
+		}
+	}
+}
+
+// dropActivityLocked forgets an ended session's last activity; caller holds m.mu.
+// Activity not yet written is kept for the next flush
+func (m *Manager) dropActivityLocked(sessionID string) {
+	m.activityMu.Lock()
+	defer m.activityMu.Unlock()
+	delete(m.lastActivity, sessionID)
+}
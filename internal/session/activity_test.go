@@ -0,0 +1,132 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// mockActivityStore records each batch written and serves stored activity back
+type mockActivityStore struct {
+	mu     sync.Mutex
+	writes []map[string]time.Time
+	stored map[string]time.Time
+}
+
+func (s *mockActivityStore) RecordSessionActivity(ctx context.Context, activity map[string]time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.writes = append(s.writes, activity)
+	return nil
+}
+
+func (s *mockActivityStore) GetSessionActivity(ctx context.Context) (map[string]time.Time, error) {
+	return s.stored, nil
+}
+
+func (s *mockActivityStore) batches() []map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]time.Time(nil), s.writes...)
+}
+
+// Functional Validation Tests
+func TestActivity_CoalescedWrites(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, clock := newFakeClockManager(mockDB)
+	store := &mockActivityStore{}
+	var _ interfaces.ActivityStore = store
+	manager.SetActivityStore(store, 30*time.Second)
+	ctx := context.Background()
+
+	first, err := manager.CreateSession(ctx, "Lecture A", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	second, err := manager.CreateSession(ctx, "Lecture B", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+
+	// A busy classroom: a thousand messages within one interval
+	for i := 0; i < 1000; i++ {
+		manager.TouchSession(first.ID)
+	}
+	clock.Advance(10 * time.Second)
+	manager.TouchSession(second.ID)
+	manager.TouchSession("unknown-session")
+	if batches := store.batches(); len(batches) != 0 {
+		t.Fatalf("Expected nothing written before the interval, got %d writes", len(batches))
+	}
+
+	clock.Advance(20 * time.Second)
+	batches := store.batches()
+	if len(batches) != 1 || len(batches[0]) != 2 {
+		t.Fatalf("Expected one write covering both sessions, got %v", batches)
+	}
+	if !batches[0][second.ID].Equal(clock.Now().Add(-20 * time.Second)) {
+		t.Errorf("Expected the latest touch written, got %v", batches[0][second.ID])
+	}
+	if at, known := manager.LastActivity(second.ID); !known || !at.Equal(batches[0][second.ID]) {
+		t.Errorf("Expected LastActivity to match the write, got %v, %v", at, known)
+	}
+
+	clock.Advance(time.Minute)
+	if batches := store.batches(); len(batches) != 1 {
+		t.Errorf("Idle sessions should not be written again, got %d writes", len(batches))
+	}
+
+	if err := manager.EndSession(ctx, first.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, known := manager.LastActivity(first.ID); known {
+		t.Error("Ended sessions should be forgotten")
+	}
+	manager.TouchSession(first.ID)
+	manager.FlushActivity()
+	if batches := store.batches(); len(batches) != 1 {
+		t.Errorf("Ended sessions should not be touched, got %d writes", len(batches))
+	}
+}
+
+func TestActivity_StartupReconciliation(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, clock := newFakeClockManager(mockDB)
+	now := clock.Now()
+	for _, id := range []string{"live", "idle", "legacy"} {
+		mockDB.sessions[id] = &types.Session{
+			ID:         id,
+			Name:       id,
+			CreatedBy:  "instructor1",
+			StudentIDs: []string{"student1"},
+			StartTime:  now.Add(-8 * time.Hour),
+			Status:     "active",
+		}
+	}
+	store := &mockActivityStore{stored: map[string]time.Time{
+		"live": now.Add(-2 * time.Minute),
+		"idle": now.Add(-5 * time.Hour),
+	}}
+	manager.SetActivityStore(store, 0)
+	manager.SetIdleEndAfter(4 * time.Hour)
+
+	if err := manager.LoadActiveSessions(context.Background()); err != nil {
+		t.Fatalf("LoadActiveSessions should succeed: %v", err)
+	}
+	if manager.IsSessionActive("idle") || mockDB.sessions["idle"].Status != "ended" {
+		t.Error("Session idle past the limit should be ended at startup")
+	}
+	if !manager.IsSessionActive("live") || !manager.IsSessionActive("legacy") {
+		t.Error("Recently active sessions and sessions without activity should resume")
+	}
+	if at, known := manager.LastActivity("live"); !known || !at.Equal(now.Add(-2*time.Minute)) {
+		t.Errorf("Expected stored activity restored, got %v, %v", at, known)
+	}
+	if got := eventTypes(t, mockDB, "idle"); len(got) != 1 || got[0] != types.SessionEventIdleEnded {
+		t.Errorf("Expected the idle end audited, got %v", got)
+	}
+}
@@ -0,0 +1,93 @@
+package session
+
+import (
+	"fmt"
+)
+
+// SetCreatorAllowlist limits who may create sessions to the listed instructor IDs;
+// an empty list lets anyone create them
+// FUNCTIONAL DISCOVERY: Only checked at creation, so sessions an instructor already
+// runs keep running when they are dropped from the list
+func (m *Manager) SetCreatorAllowlist(creators []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(creators) == 0 {
+		m.allowedCreators = nil
+		return
+	}
+	m.allowedCreators = make(map[string]bool, len(creators))
+	for _, creator := range creators {
+		m.allowedCreators[creator] = true
+	}
+}
+
+// SetMaxActivePerCreator caps how many active sessions one creator may have; n <= 0
+// leaves them unlimited
+// TECHNICAL DISCOVERY: Like SetMaxStudents, lowering the cap ends nothing; creators
+// above it just cannot create more until enough of their sessions end
+func (m *Manager) SetMaxActivePerCreator(n int) {
+	if n < 0 {
+		n = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxPerCreator = n
+}
+
+// creatorAllowed reports whether the creator policy lets createdBy create sessions
+func (m *Manager) creatorAllowed(createdBy string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.allowedCreators == nil || m.allowedCreators[createdBy]
+}
+
+// claimCreatorSlot checks createdBy's active sessions against the per-creator cap and
+// returns how many they have and the cap; with reserve it also holds a slot until
+// release is called
+// ARCHITECTURAL DISCOVERY: Reserved like names in claimName, under m.mu until the
+// session is cached, so concurrent creates by one instructor cannot overshoot the cap
+// while the first is still being persisted
+// TECHNICAL DISCOVERY: Counted from the creator index of cached sessions; sessions
+// another process created since the cache was loaded are not seen until RefreshCache
+func (m *Manager) claimCreatorSlot(createdBy string, reserve bool) (int, int, func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	active := len(m.creatorSessions[createdBy]) + m.reservedCreates[createdBy]
+	limit := m.maxPerCreator
+	if limit > 0 && active >= limit {
+		return active, limit, func() {}, fmt.Errorf("%w: %s has %d active sessions, at most %d allowed", ErrQuotaExceeded, createdBy, active, limit)
+	}
+	if !reserve {
+		return active, limit, func() {}, nil
+	}
+
+	m.reservedCreates[createdBy]++
+	return active, limit, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.reservedCreates[createdBy]--; m.reservedCreates[createdBy] <= 0 {
+			delete(m.reservedCreates, createdBy)
+		}
+	}, nil
+}
+
+// indexCreatorLocked records an active session in the creator index
+// TECHNICAL DISCOVERY: Caller must hold m.mu write lock; keyed by session ID like the
+// name index so re-caching an updated session is idempotent
+func (m *Manager) indexCreatorLocked(sessionID, createdBy string) {
+	if m.creatorSessions[createdBy] == nil {
+		m.creatorSessions[createdBy] = make(map[string]bool)
+	}
+	m.creatorSessions[createdBy][sessionID] = true
+}
+
+// unindexCreatorLocked drops a session from the creator index
+func (m *Manager) unindexCreatorLocked(sessionID, createdBy string) {
+	if ids, ok := m.creatorSessions[createdBy]; ok {
+		delete(ids, sessionID)
+		if len(ids) == 0 {
+			delete(m.creatorSessions, createdBy)
+		}
+	}
+}
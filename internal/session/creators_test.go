@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// Functional Validation Tests
+func TestCreatorPolicy_Allowlist(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	manager.SetCreatorAllowlist([]string{"instructor1"})
+	ctx := context.Background()
+
+	if _, err := manager.CreateSession(ctx, "Math", "instructor1", []string{"student1"}); err != nil {
+		t.Fatalf("Listed creator should create sessions: %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Math", "instructor2", []string{"student1"}); !errors.Is(err, ErrCreatorNotAllowed) {
+		t.Fatalf("Expected ErrCreatorNotAllowed, got %v", err)
+	}
+	report, err := manager.ValidateSession(ctx, "Math", "instructor2", []string{"student1"})
+	if err != nil {
+		t.Fatalf("ValidateSession should succeed: %v", err)
+	}
+	if report.Valid || report.Creator.Allowed || len(report.Errors) != 1 {
+		t.Errorf("Expected the dry run to refuse the unlisted creator, got %+v", report)
+	}
+
+	manager.SetCreatorAllowlist(nil)
+	if _, err := manager.CreateSession(ctx, "Math", "instructor2", []string{"student1"}); err != nil {
+		t.Errorf("Empty allowlist should let anyone create sessions: %v", err)
+	}
+}
+
+func TestCreatorQuota_MaxActive(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	manager.SetMaxActivePerCreator(2)
+	ctx := context.Background()
+
+	first, err := manager.CreateSession(ctx, "First", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("First session should be created: %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Second", "instructor1", []string{"student1"}); err != nil {
+		t.Fatalf("Second session should be created: %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Bad", "instructor1", []string{"bad id!"}); !errors.Is(err, ErrInvalidStudentID) {
+		t.Fatalf("Expected the invalid roster reported before the quota, got %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Third", "instructor1", []string{"student1"}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Third", "instructor2", []string{"student1"}); err != nil {
+		t.Errorf("Quota is per creator: %v", err)
+	}
+
+	report, err := manager.ValidateSession(ctx, "Third", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("ValidateSession should succeed: %v", err)
+	}
+	if report.Valid || report.Creator.Active != 2 || report.Creator.MaxActive != 2 || report.Creator.WithinQuota {
+		t.Errorf("Expected the dry run to report the full quota, got %+v", report.Creator)
+	}
+
+	// Ending a session frees its slot
+	if err := manager.EndSession(ctx, first.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Third", "instructor1", []string{"student1"}); err != nil {
+		t.Errorf("Ended session should free a slot: %v", err)
+	}
+}
+
+// Concurrency Tests
+func TestCreatorQuota_ConcurrentCreates(t *testing.T) {
+	const creates, limit = 20, 3
+	manager := NewManager(newMockDatabaseManager())
+	manager.SetMaxActivePerCreator(limit)
+
+	var wg sync.WaitGroup
+	var created, refused atomic.Int32
+	start := make(chan struct{})
+	for i := 0; i < creates; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, err := manager.CreateSession(context.Background(), fmt.Sprintf("Race %d", i), "instructor1", []string{"student1"})
+			switch {
+			case err == nil:
+				created.Add(1)
+			case errors.Is(err, ErrQuotaExceeded):
+				refused.Add(1)
+			default:
+				t.Errorf("Unexpected error: %v", err)
+			}
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if created.Load() != limit || refused.Load() != creates-limit {
+		t.Errorf("Expected %d created and %d refused, got %d and %d", limit, creates-limit, created.Load(), refused.Load())
+	}
+}
@@ -0,0 +1,220 @@
+package session
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// MaxDelegationTTL bounds how long a student may hold instructor message types
+// FUNCTIONAL DISCOVERY: Longer than any class period, so a grant never outlives the
+// lesson it was made for by more than an evening
+const MaxDelegationTTL = 4 * time.Hour
+
+// delegation is one cached grant and the timer that expires it
+// TECHNICAL DISCOVERY: The timer callback compares its grant with the cached one, so
+// a grant replaced or revoked after the timer fired is left alone
+type delegation struct {
+	grant *types.Delegation
+	timer interfaces.Timer
+}
+
+// SetDelegationNotifier sets who is told when a grant expires on its own
+// ARCHITECTURAL DISCOVERY: A function rather than the API server, as with
+// SetConfigSnapshot; grants and revocations are announced by the caller that made them
+func (m *Manager) SetDelegationNotifier(expired func(*types.Delegation)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onDelegationEnd = expired
+}
+
+// GrantDelegation lets a rostered student send instructor message types for ttl
+// FUNCTIONAL DISCOVERY: One grant per student; granting again replaces the types and
+// restarts the clock. The delegation_granted event is recorded before the grant takes
+// effect, so the audit trail never shows a delegated message ahead of its grant
+func (m *Manager) GrantDelegation(ctx context.Context, sessionID, grantedBy, userID string, messageTypes []string, ttl time.Duration) (*types.Delegation, error) {
+	if len(messageTypes) == 0 {
+		return nil, ErrNotDelegable
+	}
+	granted := make([]string, 0, len(messageTypes))
+	seen := make(map[string]bool, len(messageTypes))
+	for _, messageType := range messageTypes {
+		if !types.IsDelegableMessageType(messageType) {
+			return nil, ErrNotDelegable
+		}
+		if !seen[messageType] {
+			seen[messageType] = true
+			granted = append(granted, messageType)
+		}
+	}
+	if ttl < time.Second || ttl > MaxDelegationTTL {
+		return nil, ErrDelegationTTL
+	}
+	if err := m.checkDelegate(ctx, sessionID, userID); err != nil {
+		return nil, err
+	}
+
+	now := m.clock.Now()
+	grant := &types.Delegation{
+		SessionID: sessionID,
+		UserID:    userID,
+		Types:     granted,
+		GrantedBy: grantedBy,
+		GrantedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	m.recordDelegationEvent(ctx, types.SessionEventDelegationGranted, grantedBy, grant)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, stillActive := m.activeSessions[sessionID]; !stillActive {
+		return nil, ErrSessionEnded
+	}
+	if m.delegations[sessionID] == nil {
+		m.delegations[sessionID] = make(map[string]*delegation)
+	}
+	if previous, exists := m.delegations[sessionID][userID]; exists {
+		previous.timer.Stop()
+	}
+	m.delegations[sessionID][userID] = &delegation{
+		grant: grant,
+		timer: m.clock.AfterFunc(ttl, func() { m.expireDelegation(grant) }),
+	}
+
+	log.Printf("Granted delegation: session=%s user=%s types=%v by=%s until=%s",
+		sessionID, userID, granted, grantedBy, grant.ExpiresAt.Format(time.RFC3339))
+	copied := *grant
+	return &copied, nil
+}
+
+// checkDelegate verifies the session is active and userID is on its roster
+func (m *Manager) checkDelegate(ctx context.Context, sessionID, userID string) error {
+	m.mu.RLock()
+	_, active := m.activeSessions[sessionID]
+	_, rostered := m.rosters[sessionID][userID]
+	m.mu.RUnlock()
+	if !active {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return ErrSessionNotFound
+		}
+		return ErrSessionEnded
+	}
+	if !rostered {
+		return ErrNotOnRoster
+	}
+	return nil
+}
+
+// RevokeDelegation ends a student's grant early
+func (m *Manager) RevokeDelegation(ctx context.Context, sessionID, userID, revokedBy string) (*types.Delegation, error) {
+	m.mu.Lock()
+	entry, exists := m.delegations[sessionID][userID]
+	if exists {
+		entry.timer.Stop()
+		m.deleteDelegationLocked(sessionID, userID)
+	}
+	m.mu.Unlock()
+	if !exists {
+		return nil, ErrDelegationNotFound
+	}
+
+	m.recordDelegationEvent(ctx, types.SessionEventDelegationRevoked, revokedBy, entry.grant)
+	log.Printf("Revoked delegation: session=%s user=%s by=%s", sessionID, userID, revokedBy)
+	copied := *entry.grant
+	return &copied, nil
+}
+
+// Delegation returns userID's grant in sessionID when it covers messageType
+// TECHNICAL DISCOVERY: Checks the expiry itself rather than trusting the timer, which
+// may not have run yet; cache-only, so the router can call it per message
+func (m *Manager) Delegation(sessionID, userID, messageType string) (*types.Delegation, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, exists := m.delegations[sessionID][userID]
+	if !exists || !m.clock.Now().Before(entry.grant.ExpiresAt) || !entry.grant.Allows(messageType) {
+		return nil, false
+	}
+	copied := *entry.grant
+	return &copied, true
+}
+
+// ListDelegations returns a session's unexpired grants, ordered by user ID
+func (m *Manager) ListDelegations(sessionID string) []*types.Delegation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	now := m.clock.Now()
+	grants := make([]*types.Delegation, 0, len(m.delegations[sessionID]))
+	for _, entry := range m.delegations[sessionID] {
+		if now.Before(entry.grant.ExpiresAt) {
+			copied := *entry.grant
+			grants = append(grants, &copied)
+		}
+	}
+	sort.Slice(grants, func(i, j int) bool { return grants[i].UserID < grants[j].UserID })
+	return grants
+}
+
+// expireDelegation drops a grant whose time ran out, audits it and tells the notifier
+func (m *Manager) expireDelegation(grant *types.Delegation) {
+	m.mu.Lock()
+	entry, exists := m.delegations[grant.SessionID][grant.UserID]
+	current := exists && entry.grant == grant
+	if current {
+		m.deleteDelegationLocked(grant.SessionID, grant.UserID)
+	}
+	notify := m.onDelegationEnd
+	m.mu.Unlock()
+	if !current {
+		return
+	}
+
+	m.recordDelegationEvent(context.Background(), types.SessionEventDelegationExpired, grant.GrantedBy, grant)
+	log.Printf("Delegation expired: session=%s user=%s", grant.SessionID, grant.UserID)
+	if notify != nil {
+		copied := *grant
+		notify(&copied)
+	}
+}
+
+// deleteDelegationLocked removes one grant; caller holds m.mu
+func (m *Manager) deleteDelegationLocked(sessionID, userID string) {
+	delete(m.delegations[sessionID], userID)
+	if len(m.delegations[sessionID]) == 0 {
+		delete(m.delegations, sessionID)
+	}
+}
+
+// dropDelegationsLocked stops and forgets every grant in an ending session; caller
+// holds m.mu
+// FUNCTIONAL DISCOVERY: Not audited - the session's end closes them all
+func (m *Manager) dropDelegationsLocked(sessionID string) {
+	for _, entry := range m.delegations[sessionID] {
+		entry.timer.Stop()
+	}
+	delete(m.delegations, sessionID)
+}
+
+// recordDelegationEvent appends a grant's change to the session's audit trail
+// TECHNICAL DISCOVERY: Logged rather than returned on failure - the audit trail is a
+// record of enforcement, and refusing a revocation over it would keep a grant alive
+func (m *Manager) recordDelegationEvent(ctx context.Context, eventType, actor string, grant *types.Delegation) {
+	event := &types.SessionEvent{
+		SessionID: grant.SessionID,
+		Type:      eventType,
+		Actor:     actor,
+		Details: map[string]interface{}{
+			"user_id":    grant.UserID,
+			"types":      grant.Types,
+			"granted_by": grant.GrantedBy,
+			"expires_at": grant.ExpiresAt,
+		},
+		CreatedAt: m.clock.Now(),
+	}
+	if err := m.dbManager.RecordSessionEvent(ctx, event); err != nil {
+		log.Printf("Failed to record %s for %s in session %s: %v", eventType, grant.UserID, grant.SessionID, err)
+	}
+}
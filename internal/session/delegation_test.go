@@ -0,0 +1,187 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// eventTypes lists a session's audit trail event types, oldest first
+func eventTypes(t *testing.T, mockDB *mockDatabaseManager, sessionID string) []string {
+	t.Helper()
+	events, err := mockDB.GetSessionEvents(context.Background(), sessionID)
+	if err != nil {
+		t.Fatalf("GetSessionEvents should succeed: %v", err)
+	}
+	var kinds []string
+	for _, event := range events {
+		kinds = append(kinds, event.Type)
+	}
+	return kinds
+}
+
+// Functional Validation Tests
+func TestDelegation_GrantExpireRevoke(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, clock := newFakeClockManager(mockDB)
+	var _ interfaces.DelegationManager = manager
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var expired []*types.Delegation
+	manager.SetDelegationNotifier(func(grant *types.Delegation) {
+		mu.Lock()
+		defer mu.Unlock()
+		expired = append(expired, grant)
+	})
+
+	created, err := manager.CreateSession(ctx, "Presentations", "instructor1", []string{"student1", "student2"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	broadcast := []string{types.MessageTypeInstructorBroadcast}
+
+	grant, err := manager.GrantDelegation(ctx, created.ID, "instructor1", "student1", broadcast, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("GrantDelegation should succeed: %v", err)
+	}
+	if !grant.ExpiresAt.Equal(clock.Now().Add(10 * time.Minute)) {
+		t.Errorf("Expected the grant to expire in 10 minutes, got %v", grant.ExpiresAt)
+	}
+	if _, ok := manager.Delegation(created.ID, "student1", types.MessageTypeInstructorBroadcast); !ok {
+		t.Error("Granted student should hold the broadcast type")
+	}
+	if _, ok := manager.Delegation(created.ID, "student1", types.MessageTypeRequest); ok {
+		t.Error("Types that were not granted should not be delegated")
+	}
+	if _, ok := manager.Delegation(created.ID, "student2", types.MessageTypeInstructorBroadcast); ok {
+		t.Error("Other students should hold nothing")
+	}
+
+	clock.Advance(10 * time.Minute)
+	if _, ok := manager.Delegation(created.ID, "student1", types.MessageTypeInstructorBroadcast); ok {
+		t.Error("Grant should lapse at its expiry")
+	}
+	mu.Lock()
+	if len(expired) != 1 || expired[0].UserID != "student1" {
+		t.Errorf("Expected one expiry notice for student1, got %v", expired)
+	}
+	mu.Unlock()
+	if grants := manager.ListDelegations(created.ID); len(grants) != 0 {
+		t.Errorf("Expected no grants listed after expiry, got %v", grants)
+	}
+
+	if _, err := manager.GrantDelegation(ctx, created.ID, "instructor1", "student2", broadcast, time.Hour); err != nil {
+		t.Fatalf("GrantDelegation should succeed: %v", err)
+	}
+	revoked, err := manager.RevokeDelegation(ctx, created.ID, "student2", "instructor2")
+	if err != nil || revoked.UserID != "student2" {
+		t.Fatalf("RevokeDelegation should return the grant, got %+v, %v", revoked, err)
+	}
+	if _, ok := manager.Delegation(created.ID, "student2", types.MessageTypeInstructorBroadcast); ok {
+		t.Error("Revoked grant should no longer apply")
+	}
+	if _, err := manager.RevokeDelegation(ctx, created.ID, "student2", "instructor2"); !errors.Is(err, ErrDelegationNotFound) {
+		t.Errorf("Expected ErrDelegationNotFound revoking twice, got %v", err)
+	}
+	clock.Advance(time.Hour)
+	mu.Lock()
+	if len(expired) != 1 {
+		t.Errorf("A revoked grant should not also expire, got %d notices", len(expired))
+	}
+	mu.Unlock()
+
+	want := []string{
+		types.SessionEventDelegationGranted, types.SessionEventDelegationExpired,
+		types.SessionEventDelegationGranted, types.SessionEventDelegationRevoked,
+	}
+	if got := eventTypes(t, mockDB, created.ID); len(got) != len(want) {
+		t.Errorf("Expected audit trail %v, got %v", want, got)
+	} else {
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Expected audit trail %v, got %v", want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestDelegation_Regrant(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, clock := newFakeClockManager(mockDB)
+	ctx := context.Background()
+
+	created, err := manager.CreateSession(ctx, "Presentations", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	broadcast := []string{types.MessageTypeInstructorBroadcast}
+	if _, err := manager.GrantDelegation(ctx, created.ID, "instructor1", "student1", broadcast, 5*time.Minute); err != nil {
+		t.Fatalf("GrantDelegation should succeed: %v", err)
+	}
+	clock.Advance(4 * time.Minute)
+	if _, err := manager.GrantDelegation(ctx, created.ID, "instructor1", "student1", broadcast, 5*time.Minute); err != nil {
+		t.Fatalf("Granting again should succeed: %v", err)
+	}
+
+	// The first grant's timer must not end the replacement
+	clock.Advance(2 * time.Minute)
+	if _, ok := manager.Delegation(created.ID, "student1", types.MessageTypeInstructorBroadcast); !ok {
+		t.Error("Replacement grant should restart the clock")
+	}
+	if grants := manager.ListDelegations(created.ID); len(grants) != 1 {
+		t.Errorf("Expected one grant per student, got %d", len(grants))
+	}
+
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, ok := manager.Delegation(created.ID, "student1", types.MessageTypeInstructorBroadcast); ok {
+		t.Error("Grants should end with the session")
+	}
+}
+
+// Error Handling Tests
+func TestDelegation_Validation(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, _ := newFakeClockManager(mockDB)
+	ctx := context.Background()
+
+	created, err := manager.CreateSession(ctx, "Presentations", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	broadcast := []string{types.MessageTypeInstructorBroadcast}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		userID    string
+		types     []string
+		ttl       time.Duration
+		want      error
+	}{
+		{"no types", created.ID, "student1", nil, time.Minute, ErrNotDelegable},
+		{"direct type", created.ID, "student1", []string{types.MessageTypeRequest}, time.Minute, ErrNotDelegable},
+		{"zero ttl", created.ID, "student1", broadcast, 0, ErrDelegationTTL},
+		{"too long", created.ID, "student1", broadcast, MaxDelegationTTL + time.Second, ErrDelegationTTL},
+		{"not rostered", created.ID, "student9", broadcast, time.Minute, ErrNotOnRoster},
+		{"unknown session", "missing", "student1", broadcast, time.Minute, ErrSessionNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := manager.GrantDelegation(ctx, tt.sessionID, "instructor1", tt.userID, tt.types, tt.ttl); !errors.Is(err, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+	if got := eventTypes(t, mockDB, created.ID); len(got) != 0 {
+		t.Errorf("Refused grants should not be audited, got %v", got)
+	}
+}
@@ -1,16 +1,39 @@
 package session
 
-import "errors"
+import (
+	"errors"
+	
+	"switchboard/pkg/interfaces"
+)
 
 // Session management error types - exactly as specified in Phase 4.1
+// ARCHITECTURAL DISCOVERY: Errors a caller outside this package tells apart through
+// the SessionManager interface are the interface's own, so errors.Is matches them
+// without importing this package
 var (
 	ErrInvalidSessionName  = errors.New("session name must be 1-200 characters")
 	ErrInvalidCreatedBy    = errors.New("created_by must be valid user ID")
 	ErrEmptyStudentList    = errors.New("student list cannot be empty")
 	ErrInvalidStudentID    = errors.New("invalid student ID format")
-	ErrSessionNotFound     = errors.New("session not found")
-	ErrSessionEnded        = errors.New("session has ended")
+	ErrSessionNotFound     = interfaces.ErrSessionNotFound
+	ErrSessionEnded        = interfaces.ErrSessionEnded
 	ErrSessionAlreadyEnded = errors.New("session is already ended")
-	ErrUnauthorized        = errors.New("user not authorized for this session")
+	ErrUnauthorized        = interfaces.ErrUnauthorized
 	ErrInvalidRole         = errors.New("invalid role: must be 'student' or 'instructor'")
+	ErrInvalidDuration     = errors.New("duration must be 0-1440 minutes")
+	ErrDurationElapsed     = errors.New("duration has already elapsed")
+	ErrSessionEndVetoed    = errors.New("session end vetoed")
+	ErrDuplicateName       = errors.New("an active session with this name already exists")
+	ErrInvalidOwner        = errors.New("new owner must be valid user ID")
+	ErrAlreadyOwner        = errors.New("user already owns this session")
+	ErrOwnerChanged        = errors.New("session owner changed during transfer")
+	ErrInvalidTimezone     = errors.New("timezone must be an IANA time zone name")
+	ErrTooManyStudents     = errors.New("too many students for one session")
+	ErrInvalidInstructorID = errors.New("invalid instructor ID format")
+	ErrNotOnRoster         = errors.New("user is not a student in this session")
+	ErrNotDelegable        = errors.New("only instructor_broadcast may be delegated")
+	ErrDelegationTTL       = errors.New("delegation must last between 1 second and 4 hours")
+	ErrDelegationNotFound  = errors.New("user holds no delegation in this session")
+	ErrCreatorNotAllowed   = errors.New("creator may not create sessions")
+	ErrQuotaExceeded       = errors.New("creator has too many active sessions")
 )
\ No newline at end of file
@@ -0,0 +1,176 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// ExpiryNotifier receives countdown events for duration-limited sessions
+// ARCHITECTURAL DISCOVERY: The session manager owns the timers but not the
+// connections, so broadcasting warnings and the normal end-session flow (notify
+// clients, end, run hooks) are delegated to the API layer through this interface
+type ExpiryNotifier interface {
+	SessionWarning(sessionID string, remaining time.Duration)
+	SessionExpired(sessionID string)
+}
+
+// MaxDurationMinutes bounds duration limits to one day
+const MaxDurationMinutes = 24 * 60
+
+// sessionTimers holds the pending countdown timers for one session
+// TECHNICAL DISCOVERY: generation guards against a timer whose callback already
+// started before it was cancelled by a reschedule
+type sessionTimers struct {
+	generation int
+	timers     []interfaces.Timer
+}
+
+// SetExpiryNotifier registers the receiver for countdown warnings and expiry
+// FUNCTIONAL DISCOVERY: Without a notifier, expiry ends the session directly and
+// warnings are skipped
+func (m *Manager) SetExpiryNotifier(notifier ExpiryNotifier) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.notifier = notifier
+}
+
+// SetWarningOffsets configures how long before expiry warnings are sent
+// TECHNICAL DISCOVERY: Applies to timers scheduled afterwards; call before
+// LoadActiveSessions so recovered sessions use the configured offsets
+func (m *Manager) SetWarningOffsets(offsets []time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.warningOffsets = append([]time.Duration(nil), offsets...)
+}
+
+// SetSessionDuration sets or changes the duration limit of an active session
+// FUNCTIONAL DISCOVERY: The duration always counts from start_time, so extending a
+// 50-minute class to 60 minutes adds ten minutes; 0 removes the limit
+func (m *Manager) SetSessionDuration(ctx context.Context, sessionID string, durationMinutes int) (*types.Session, error) {
+	if durationMinutes < 0 || durationMinutes > MaxDurationMinutes {
+		return nil, ErrInvalidDuration
+	}
+
+	m.mu.RLock()
+	session, exists := m.activeSessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return nil, ErrSessionNotFound
+		}
+		return nil, ErrSessionEnded
+	}
+
+	// Work on a copy so readers of the cached session never see a duration that
+	// failed to persist
+	updated := *session
+	updated.DurationMinutes = durationMinutes
+	if expiresAt, limited := updated.ExpiresAt(); limited && !expiresAt.After(m.clock.Now()) {
+		return nil, ErrDurationElapsed
+	}
+
+	if err := m.dbManager.UpdateSession(ctx, &updated); err != nil {
+		if errors.Is(err, types.ErrIllegalSessionTransition) { // Ended since the cache was read
+			return nil, ErrSessionEnded
+		}
+		return nil, fmt.Errorf("failed to update session duration: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, stillActive := m.activeSessions[sessionID]
+	if !stillActive {
+		return nil, ErrSessionEnded
+	}
+	updated.OwnerID = current.OwnerID   // A transfer may have landed meanwhile
+	updated.Locked = current.Locked     // So may a lock toggle
+	updated.Timezone = current.Timezone // Or a time zone change
+	m.addActiveSessionLocked(&updated)  // Replaces cache entries and reschedules timers
+
+	log.Printf("Set session duration: id=%s duration=%dm", sessionID, durationMinutes)
+	return &updated, nil
+}
+
+// scheduleLocked (re)creates countdown timers for a session
+// TECHNICAL DISCOVERY: Caller must hold m.mu write lock. Everything is derived from
+// start_time + duration, so the same code serves create, extend and restart recovery;
+// warnings already in the past are skipped, an expiry in the past fires immediately
+func (m *Manager) scheduleLocked(session *types.Session) {
+	m.cancelTimersLocked(session.ID)
+
+	expiresAt, limited := session.ExpiresAt()
+	if !limited || session.Status != types.SessionActive {
+		return
+	}
+
+	m.timerGeneration++
+	entry := &sessionTimers{generation: m.timerGeneration}
+	sessionID := session.ID
+	now := m.clock.Now()
+
+	for _, offset := range m.warningOffsets {
+		fireAt := expiresAt.Add(-offset)
+		if !fireAt.After(now) {
+			continue
+		}
+		remaining := offset
+		entry.timers = append(entry.timers, m.clock.AfterFunc(fireAt.Sub(now), func() {
+			m.fireWarning(sessionID, entry.generation, remaining)
+		}))
+	}
+
+	entry.timers = append(entry.timers, m.clock.AfterFunc(expiresAt.Sub(now), func() {
+		m.fireExpiry(sessionID, entry.generation)
+	}))
+
+	m.timers[sessionID] = entry
+}
+
+// cancelTimersLocked stops pending timers for a session; caller holds m.mu
+func (m *Manager) cancelTimersLocked(sessionID string) {
+	if entry, exists := m.timers[sessionID]; exists {
+		for _, timer := range entry.timers {
+			timer.Stop()
+		}
+		delete(m.timers, sessionID)
+	}
+}
+
+// currentTimer reports whether generation is still the live schedule for a session
+func (m *Manager) currentTimer(sessionID string, generation int) (ExpiryNotifier, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, exists := m.timers[sessionID]
+	return m.notifier, exists && entry.generation == generation
+}
+
+func (m *Manager) fireWarning(sessionID string, generation int, remaining time.Duration) {
+	notifier, current := m.currentTimer(sessionID, generation)
+	if !current || notifier == nil {
+		return
+	}
+	log.Printf("Session %s ends in %v", sessionID, remaining)
+	notifier.SessionWarning(sessionID, remaining)
+}
+
+func (m *Manager) fireExpiry(sessionID string, generation int) {
+	notifier, current := m.currentTimer(sessionID, generation)
+	if !current {
+		return
+	}
+
+	log.Printf("Session %s reached its duration limit", sessionID)
+	if notifier != nil {
+		notifier.SessionExpired(sessionID)
+		return
+	}
+	if err := m.EndSession(context.Background(), sessionID); err != nil && !errors.Is(err, ErrSessionAlreadyEnded) {
+		log.Printf("Failed to auto-end session %s: %v", sessionID, err)
+	}
+}
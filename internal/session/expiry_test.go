@@ -0,0 +1,186 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+	"switchboard/internal/testsupport"
+)
+
+// recordingNotifier captures countdown events for assertions
+type recordingNotifier struct {
+	mu       sync.Mutex
+	warnings []time.Duration
+	expired  []string
+	manager  *Manager
+}
+
+func (n *recordingNotifier) SessionWarning(sessionID string, remaining time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.warnings = append(n.warnings, remaining)
+}
+
+func (n *recordingNotifier) SessionExpired(sessionID string) {
+	n.mu.Lock()
+	n.expired = append(n.expired, sessionID)
+	n.mu.Unlock()
+	_ = n.manager.EndSession(context.Background(), sessionID) // Mirrors the API end flow
+}
+
+func (n *recordingNotifier) snapshot() ([]time.Duration, []string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]time.Duration(nil), n.warnings...), append([]string(nil), n.expired...)
+}
+
+// sessionEndingIn builds an active session whose duration limit expires after remaining
+func sessionEndingIn(id string, now time.Time, remaining time.Duration) *types.Session {
+	return &types.Session{
+		ID:              id,
+		Name:            "Timed Session",
+		CreatedBy:       "instructor1",
+		StudentIDs:      []string{"student1"},
+		StartTime:       now.Add(remaining - 51*time.Minute),
+		Status:          "active",
+		DurationMinutes: 51,
+	}
+}
+
+// newFakeClockManager creates a manager whose timers only fire when the clock advances
+func newFakeClockManager(mockDB *mockDatabaseManager) (*Manager, *testsupport.FakeClock) {
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC))
+	manager := NewManager(mockDB)
+	manager.SetClock(clock)
+	return manager, clock
+}
+
+// Functional Validation Tests
+func TestExpiry_TimersRecomputedOnLoad(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, clock := newFakeClockManager(mockDB)
+	mockDB.sessions["timed"] = sessionEndingIn("timed", clock.Now(), 5*time.Minute)
+	// Warning window already passed while the server was down
+	mockDB.sessions["overdue"] = sessionEndingIn("overdue", clock.Now(), -time.Minute)
+
+	notifier := &recordingNotifier{manager: manager}
+	manager.SetExpiryNotifier(notifier)
+	manager.SetWarningOffsets([]time.Duration{2 * time.Minute, time.Hour})
+
+	if err := manager.LoadActiveSessions(context.Background()); err != nil {
+		t.Fatalf("LoadActiveSessions should succeed: %v", err)
+	}
+
+	// The overdue session expires at once, the timed one only when its time comes
+	clock.Advance(0)
+	if warnings, expired := notifier.snapshot(); len(warnings) != 0 || len(expired) != 1 || expired[0] != "overdue" {
+		t.Fatalf("Expected only the overdue session expired, got warnings %v expired %v", warnings, expired)
+	}
+	clock.Advance(3 * time.Minute)
+	if warnings, expired := notifier.snapshot(); len(warnings) != 1 || warnings[0] != 2*time.Minute || len(expired) != 1 {
+		t.Fatalf("Expected one 2m warning for the timed session only, got warnings %v expired %v", warnings, expired)
+	}
+	clock.Advance(2 * time.Minute)
+	if _, expired := notifier.snapshot(); len(expired) != 2 {
+		t.Errorf("Expected both sessions expired, got %v", expired)
+	}
+	if manager.IsSessionActive("timed") || manager.IsSessionActive("overdue") {
+		t.Error("Expired sessions should no longer be active")
+	}
+}
+
+func TestExpiry_ExtendReschedules(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, clock := newFakeClockManager(mockDB)
+	mockDB.sessions["timed"] = sessionEndingIn("timed", clock.Now(), 5*time.Minute)
+
+	notifier := &recordingNotifier{manager: manager}
+	manager.SetExpiryNotifier(notifier)
+	manager.SetWarningOffsets(nil)
+	_ = manager.LoadActiveSessions(context.Background())
+
+	extended, err := manager.SetSessionDuration(context.Background(), "timed", 60)
+	if err != nil {
+		t.Fatalf("SetSessionDuration should succeed: %v", err)
+	}
+	if extended.DurationMinutes != 60 || mockDB.sessions["timed"].DurationMinutes != 60 {
+		t.Error("Extended duration should be cached and persisted")
+	}
+
+	clock.Advance(8 * time.Minute)
+	if _, expired := notifier.snapshot(); len(expired) != 0 {
+		t.Errorf("Original expiry should have been cancelled, got %v", expired)
+	}
+	if !manager.IsSessionActive("timed") {
+		t.Error("Extended session should still be active")
+	}
+
+	// Removing the limit clears all timers
+	if _, err := manager.SetSessionDuration(context.Background(), "timed", 0); err != nil {
+		t.Fatalf("Removing duration should succeed: %v", err)
+	}
+	manager.mu.RLock()
+	_, hasTimers := manager.timers["timed"]
+	manager.mu.RUnlock()
+	if hasTimers {
+		t.Error("Expected no timers after removing the duration limit")
+	}
+}
+
+func TestExpiry_EndsDirectlyWithoutNotifier(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager, clock := newFakeClockManager(mockDB)
+	mockDB.sessions["timed"] = sessionEndingIn("timed", clock.Now(), time.Minute)
+	_ = manager.LoadActiveSessions(context.Background())
+
+	clock.Advance(time.Minute)
+	if manager.IsSessionActive("timed") {
+		t.Fatal("Expired session should no longer be active")
+	}
+	if mockDB.sessions["timed"].Status != "ended" {
+		t.Error("Expired session should be persisted as ended")
+	}
+}
+
+// Error Handling Validation Tests
+func TestExpiry_SetSessionDurationValidation(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+
+	session, err := manager.CreateSession(context.Background(), "Class", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+
+	tests := []struct {
+		sessionID string
+		minutes   int
+		expected  error
+	}{
+		{session.ID, -1, ErrInvalidDuration},
+		{session.ID, MaxDurationMinutes + 1, ErrInvalidDuration},
+		{"missing", 30, ErrSessionNotFound},
+	}
+	for _, tt := range tests {
+		if _, err := manager.SetSessionDuration(context.Background(), tt.sessionID, tt.minutes); err != tt.expected {
+			t.Errorf("SetSessionDuration(%s, %d): expected %v, got %v", tt.sessionID, tt.minutes, tt.expected, err)
+		}
+	}
+
+	// A duration that already elapsed cannot be applied
+	mockDB.sessions["old"] = sessionEndingIn("old", time.Now(), time.Hour)
+	mockDB.sessions["old"].StartTime = time.Now().Add(-2 * time.Hour)
+	mockDB.sessions["old"].DurationMinutes = 0
+	_ = manager.RefreshCache(context.Background())
+	if _, err := manager.SetSessionDuration(context.Background(), "old", 30); err != ErrDurationElapsed {
+		t.Errorf("Expected ErrDurationElapsed, got %v", err)
+	}
+
+	_ = manager.EndSession(context.Background(), session.ID)
+	if _, err := manager.SetSessionDuration(context.Background(), session.ID, 30); err != ErrSessionEnded {
+		t.Errorf("Expected ErrSessionEnded for ended session, got %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// CreatedHook runs after a session is created and cached
+type CreatedHook func(ctx context.Context, session types.Session)
+
+// EndingHook runs before a session is ended; a non-nil error vetoes the end
+type EndingHook func(ctx context.Context, session types.Session) error
+
+// EndedHook runs after a session is ended and removed from the cache
+type EndedHook func(ctx context.Context, session types.Session)
+
+// defaultHookBudget bounds how long a single hook may hold up CreateSession or EndSession
+const defaultHookBudget = 5 * time.Second
+
+// endReasonKey carries the human-readable end reason through EndSession to hooks
+type endReasonKey struct{}
+
+// WithEndReason attaches the reason a session is ending, e.g. "Session ended by instructor"
+// FUNCTIONAL DISCOVERY: Manual, automatic and admin ends share EndSession, so the
+// reason clients are shown travels on the context rather than the method signature
+func WithEndReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, endReasonKey{}, reason)
+}
+
+// EndReason returns the reason attached with WithEndReason, or "" if none
+func EndReason(ctx context.Context) string {
+	reason, _ := ctx.Value(endReasonKey{}).(string)
+	return reason
+}
+
+// OnSessionCreated registers a hook run after every successful CreateSession
+// TECHNICAL DISCOVERY: Hooks run synchronously in registration order; register
+// during startup before sessions are created
+func (m *Manager) OnSessionCreated(hook CreatedHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.createdHooks = append(m.createdHooks, hook)
+}
+
+// OnSessionEnding registers a hook that may veto EndSession by returning an error
+// FUNCTIONAL DISCOVERY: Ending hooks all run before anything is persisted, so a veto
+// leaves the session fully active; a hook that panics or overruns its budget does not veto
+func (m *Manager) OnSessionEnding(hook EndingHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endingHooks = append(m.endingHooks, hook)
+}
+
+// OnSessionEnded registers a hook run after every successful EndSession
+func (m *Manager) OnSessionEnded(hook EndedHook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.endedHooks = append(m.endedHooks, hook)
+}
+
+// SetHookBudget limits how long each hook may run before EndSession moves on
+// TECHNICAL DISCOVERY: The budget applies per hook; an overrunning hook keeps running
+// in the background with a cancelled context but no longer blocks the caller
+func (m *Manager) SetHookBudget(budget time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hookBudget = budget
+}
+
+// hooksSnapshot copies the registered hooks so they run without holding m.mu
+func (m *Manager) hooksSnapshot() ([]CreatedHook, []EndingHook, []EndedHook, time.Duration) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.createdHooks, m.endingHooks, m.endedHooks, m.hookBudget
+}
+
+// snapshot returns a copy of a session that hooks cannot use to mutate the cache
+func snapshot(session *types.Session) types.Session {
+	copied := *session
+	copied.StudentIDs = append([]string(nil), session.StudentIDs...)
+	return copied
+}
+
+func (m *Manager) runCreatedHooks(ctx context.Context, session *types.Session) {
+	hooks, _, _, budget := m.hooksSnapshot()
+	snap := snapshot(session)
+	for i, hook := range hooks {
+		runHook(ctx, budget, fmt.Sprintf("session-created hook %d", i), func(hookCtx context.Context) error {
+			hook(hookCtx, snap)
+			return nil
+		})
+	}
+}
+
+func (m *Manager) runEndingHooks(ctx context.Context, session *types.Session) error {
+	_, hooks, _, budget := m.hooksSnapshot()
+	snap := snapshot(session)
+	for i, hook := range hooks {
+		err := runHook(ctx, budget, fmt.Sprintf("session-ending hook %d", i), func(hookCtx context.Context) error {
+			return hook(hookCtx, snap)
+		})
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrSessionEndVetoed, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) runEndedHooks(ctx context.Context, session *types.Session) {
+	_, _, hooks, budget := m.hooksSnapshot()
+	snap := snapshot(session)
+	for i, hook := range hooks {
+		runHook(ctx, budget, fmt.Sprintf("session-ended hook %d", i), func(hookCtx context.Context) error {
+			hook(hookCtx, snap)
+			return nil
+		})
+	}
+}
+
+// runHook runs fn with panic isolation and a time budget
+// ARCHITECTURAL DISCOVERY: fn runs on its own goroutine so a hook stuck on a dead
+// connection cannot hold the caller; only a returned error is reported - panics
+// and timeouts are logged and treated as success
+func runHook(ctx context.Context, budget time.Duration, name string, fn func(ctx context.Context) error) error {
+	if budget <= 0 {
+		budget = defaultHookBudget
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				log.Printf("ERROR: %s panicked: %v", name, r)
+				done <- nil
+			}
+		}()
+		done <- fn(hookCtx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-hookCtx.Done():
+		log.Printf("WARNING: %s exceeded its %v budget", name, budget)
+		return nil
+	}
+}
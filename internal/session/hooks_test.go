@@ -0,0 +1,168 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// hookRecorder counts hook invocations in the order they happen
+type hookRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *hookRecorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *hookRecorder) snapshot() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func equalCalls(got, want []string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Functional Validation Tests
+func TestHooks_Ordering(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	recorder := &hookRecorder{}
+
+	for i := 1; i <= 2; i++ {
+		n := i
+		manager.OnSessionCreated(func(ctx context.Context, s types.Session) {
+			recorder.record(fmt.Sprintf("created%d:%s", n, s.Status))
+		})
+		manager.OnSessionEnding(func(ctx context.Context, s types.Session) error {
+			recorder.record(fmt.Sprintf("ending%d:%s", n, s.Status))
+			return nil
+		})
+		manager.OnSessionEnded(func(ctx context.Context, s types.Session) {
+			recorder.record(fmt.Sprintf("ended%d:%s:%s", n, s.Status, EndReason(ctx)))
+		})
+	}
+
+	ctx := context.Background()
+	created, err := manager.CreateSession(ctx, "Hooked Session", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	if err := manager.EndSession(WithEndReason(ctx, "Class over"), created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+
+	want := []string{
+		"created1:active", "created2:active",
+		"ending1:active", "ending2:active",
+		"ended1:ended:Class over", "ended2:ended:Class over",
+	}
+	if got := recorder.snapshot(); !equalCalls(got, want) {
+		t.Errorf("Expected hook order %v, got %v", want, got)
+	}
+}
+
+func TestHooks_EndingVeto(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	recorder := &hookRecorder{}
+
+	veto := errors.New("grading still in progress")
+	manager.OnSessionEnding(func(ctx context.Context, s types.Session) error {
+		recorder.record("ending1")
+		return veto
+	})
+	manager.OnSessionEnding(func(ctx context.Context, s types.Session) error {
+		recorder.record("ending2")
+		return nil
+	})
+	manager.OnSessionEnded(func(ctx context.Context, s types.Session) {
+		recorder.record("ended")
+	})
+
+	ctx := context.Background()
+	created, _ := manager.CreateSession(ctx, "Vetoed Session", "instructor1", []string{"student1"})
+
+	err := manager.EndSession(ctx, created.ID)
+	if !errors.Is(err, ErrSessionEndVetoed) {
+		t.Fatalf("Expected ErrSessionEndVetoed, got %v", err)
+	}
+	if got := recorder.snapshot(); !equalCalls(got, []string{"ending1"}) {
+		t.Errorf("Veto should stop later hooks, got %v", got)
+	}
+	if !manager.IsSessionActive(created.ID) || mockDB.sessions[created.ID].Status != "active" {
+		t.Error("Vetoed session should remain active in cache and database")
+	}
+}
+
+func TestHooks_PanicIsolation(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	recorder := &hookRecorder{}
+
+	manager.OnSessionEnding(func(ctx context.Context, s types.Session) error {
+		panic("broken hook")
+	})
+	manager.OnSessionEnded(func(ctx context.Context, s types.Session) {
+		panic("broken hook")
+	})
+	manager.OnSessionEnded(func(ctx context.Context, s types.Session) {
+		recorder.record("ended")
+	})
+
+	ctx := context.Background()
+	created, _ := manager.CreateSession(ctx, "Panicky Session", "instructor1", []string{"student1"})
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("A panicking hook must not veto or fail EndSession: %v", err)
+	}
+	if got := recorder.snapshot(); !equalCalls(got, []string{"ended"}) {
+		t.Errorf("Hooks after a panicking hook should still run, got %v", got)
+	}
+}
+
+func TestHooks_SlowHookBudget(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	manager.SetHookBudget(50 * time.Millisecond)
+
+	release := make(chan struct{})
+	defer close(release)
+	cancelled := make(chan struct{})
+	manager.OnSessionEnded(func(ctx context.Context, s types.Session) {
+		<-ctx.Done()
+		close(cancelled)
+		<-release
+	})
+
+	ctx := context.Background()
+	created, _ := manager.CreateSession(ctx, "Slow Session", "instructor1", []string{"student1"})
+
+	start := time.Now()
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Slow hook blocked EndSession for %v", elapsed)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Slow hook context should be cancelled when its budget is spent")
+	}
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// InstructorScope controls which instructors ValidateSessionMembership lets into a session
+type InstructorScope string
+
+const (
+	// InstructorScopeAny lets every instructor join every active session
+	InstructorScopeAny InstructorScope = "any"
+	// InstructorScopeMembers only lets in the creator, the owner and the session's
+	// instructor_ids; anyone else gets interfaces.ErrInstructorNotMember
+	InstructorScopeMembers InstructorScope = "members"
+)
+
+// SetInstructorScope selects which instructors may join sessions from now on
+// FUNCTIONAL DISCOVERY: Checked on every join, so connections already open stay
+// open when the scope narrows
+// TECHNICAL DISCOVERY: Unknown scopes behave like InstructorScopeAny; configuration
+// validation rejects them before they reach the manager
+func (m *Manager) SetInstructorScope(scope InstructorScope) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.instructorScope = scope
+}
+
+// SetSessionInstructors replaces the co-instructors listed on an active session
+// FUNCTIONAL DISCOVERY: Duplicates are dropped; the creator and owner are members
+// whether listed or not, so an empty list leaves only them
+func (m *Manager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) (*types.Session, error) {
+	for _, instructorID := range instructorIDs {
+		if !types.IsValidUserID(instructorID) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidInstructorID, instructorID)
+		}
+	}
+	instructors := removeDuplicates(instructorIDs)
+	if len(instructors) == 0 {
+		instructors = nil
+	}
+
+	m.mu.RLock()
+	_, exists := m.activeSessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return nil, ErrSessionNotFound
+		}
+		return nil, ErrSessionEnded
+	}
+
+	if err := m.dbManager.SetSessionInstructors(ctx, sessionID, instructors); err != nil {
+		if errors.Is(err, interfaces.ErrSessionNotFound) {
+			return nil, ErrSessionEnded
+		}
+		return nil, fmt.Errorf("failed to update session instructors: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, stillActive := m.activeSessions[sessionID]
+	if !stillActive {
+		return nil, ErrSessionEnded
+	}
+	// Work on a copy of the current entry so a concurrent lock, duration change or transfer is kept
+	updated := *current
+	updated.InstructorIDs = instructors
+	m.addActiveSessionLocked(&updated)
+
+	log.Printf("Set session instructors: id=%s instructors=%d", sessionID, len(instructors))
+	return &updated, nil
+}
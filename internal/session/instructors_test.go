@@ -0,0 +1,69 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"switchboard/pkg/interfaces"
+)
+
+// Functional Validation Tests
+func TestInstructorScope(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+
+	created, err := manager.CreateSession(ctx, "Biology", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+
+	// The default scope lets every instructor in
+	if err := manager.ValidateSessionMembership(created.ID, "other-school", "instructor"); err != nil {
+		t.Errorf("Expected any instructor to join by default, got %v", err)
+	}
+
+	manager.SetInstructorScope(InstructorScopeMembers)
+	if err := manager.ValidateSessionMembership(created.ID, "other-school", "instructor"); !errors.Is(err, interfaces.ErrInstructorNotMember) {
+		t.Errorf("Expected ErrInstructorNotMember for an unlisted instructor, got %v", err)
+	}
+	if err := manager.ValidateSessionMembership(created.ID, "instructor1", "instructor"); err != nil {
+		t.Errorf("Expected the creator to join, got %v", err)
+	}
+	if listed, _ := manager.ListUserSessions(ctx, "ta1", "instructor"); len(listed) != 0 {
+		t.Errorf("Expected no sessions listed for an unlisted instructor, got %d", len(listed))
+	}
+
+	updated, err := manager.SetSessionInstructors(ctx, created.ID, []string{"ta1", "ta1", "ta2"})
+	if err != nil || len(updated.InstructorIDs) != 2 {
+		t.Fatalf("SetSessionInstructors should succeed without duplicates, got %+v, %v", updated, err)
+	}
+	if err := manager.ValidateSessionMembership(created.ID, "ta2", "instructor"); err != nil {
+		t.Errorf("Expected a listed instructor to join, got %v", err)
+	}
+	if listed, _ := manager.ListUserSessions(ctx, "ta1", "instructor"); len(listed) != 1 {
+		t.Errorf("Expected the session listed for a listed instructor, got %d", len(listed))
+	}
+	if stored, _ := mockDB.GetSession(ctx, created.ID); len(stored.InstructorIDs) != 2 {
+		t.Error("Instructors should be persisted")
+	}
+	if _, err := manager.SetSessionInstructors(ctx, created.ID, []string{"bad id!"}); !errors.Is(err, ErrInvalidInstructorID) {
+		t.Errorf("Expected ErrInvalidInstructorID, got %v", err)
+	}
+
+	// An empty list leaves only the creator and owner
+	if _, err := manager.SetSessionInstructors(ctx, created.ID, nil); err != nil {
+		t.Fatalf("Clearing instructors should succeed: %v", err)
+	}
+	if err := manager.ValidateSessionMembership(created.ID, "ta1", "instructor"); !errors.Is(err, interfaces.ErrInstructorNotMember) {
+		t.Errorf("Expected a removed instructor refused, got %v", err)
+	}
+
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, err := manager.SetSessionInstructors(ctx, created.ID, []string{"ta1"}); !errors.Is(err, ErrSessionEnded) {
+		t.Errorf("Ended session: expected ErrSessionEnded, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// SetSessionLocked locks or unlocks student sending in an active session
+// FUNCTIONAL DISCOVERY: Setting the current state again is a no-op success, so a
+// retried PATCH never fails; the flag is persisted before the cache changes, so a
+// lock the database did not record is never enforced
+func (m *Manager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) (*types.Session, error) {
+	m.mu.RLock()
+	session, exists := m.activeSessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return nil, ErrSessionNotFound
+		}
+		return nil, ErrSessionEnded
+	}
+	if session.Locked == locked {
+		unchanged := *session
+		return &unchanged, nil
+	}
+
+	if err := m.dbManager.SetSessionLocked(ctx, sessionID, locked); err != nil {
+		if errors.Is(err, interfaces.ErrSessionNotFound) {
+			return nil, ErrSessionEnded
+		}
+		return nil, fmt.Errorf("failed to update session lock: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, stillActive := m.activeSessions[sessionID]
+	if !stillActive {
+		return nil, ErrSessionEnded
+	}
+	// Work on a copy of the current entry so a concurrent duration change or transfer is kept
+	updated := *current
+	updated.Locked = locked
+	m.addActiveSessionLocked(&updated)
+
+	log.Printf("Set session lock: id=%s locked=%t", sessionID, locked)
+	return &updated, nil
+}
+
+// IsSessionLocked reports whether students are barred from sending in a session
+// TECHNICAL DISCOVERY: Unknown and ended sessions report unlocked; membership
+// validation already rejects their messages
+func (m *Manager) IsSessionLocked(sessionID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	session, exists := m.activeSessions[sessionID]
+	return exists && session.Locked
+}
@@ -0,0 +1,80 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Functional Validation Tests
+func TestSetSessionLocked(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+
+	created, err := manager.CreateSession(ctx, "Exam", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	if manager.IsSessionLocked(created.ID) {
+		t.Fatal("New sessions should start unlocked")
+	}
+
+	locked, err := manager.SetSessionLocked(ctx, created.ID, true)
+	if err != nil {
+		t.Fatalf("SetSessionLocked should succeed: %v", err)
+	}
+	if !locked.Locked || !manager.IsSessionLocked(created.ID) {
+		t.Error("Session should be locked in the result and the cache")
+	}
+	if stored, _ := mockDB.GetSession(ctx, created.ID); !stored.Locked {
+		t.Error("Lock should be persisted")
+	}
+
+	// Locking again is a no-op success
+	if again, err := manager.SetSessionLocked(ctx, created.ID, true); err != nil || !again.Locked {
+		t.Errorf("Repeating the lock should succeed, got %+v, %v", again, err)
+	}
+
+	// A duration change keeps the lock
+	if _, err := manager.SetSessionDuration(ctx, created.ID, 60); err != nil {
+		t.Fatalf("SetSessionDuration should succeed: %v", err)
+	}
+	if !manager.IsSessionLocked(created.ID) {
+		t.Error("Changing the duration should not unlock the session")
+	}
+
+	// The lock survives a restart
+	restarted := NewManager(mockDB)
+	if err := restarted.LoadActiveSessions(ctx); err != nil {
+		t.Fatalf("LoadActiveSessions should succeed: %v", err)
+	}
+	if !restarted.IsSessionLocked(created.ID) {
+		t.Error("Lock should be restored from the database")
+	}
+
+	if _, err := manager.SetSessionLocked(ctx, created.ID, false); err != nil || manager.IsSessionLocked(created.ID) {
+		t.Errorf("Unlock should succeed, got %v", err)
+	}
+}
+
+func TestSetSessionLocked_Errors(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+	created, _ := manager.CreateSession(ctx, "Exam", "instructor1", []string{"student1"})
+
+	if _, err := manager.SetSessionLocked(ctx, "missing", true); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, err := manager.SetSessionLocked(ctx, created.ID, true); !errors.Is(err, ErrSessionEnded) {
+		t.Errorf("Expected ErrSessionEnded, got %v", err)
+	}
+	if manager.IsSessionLocked(created.ID) {
+		t.Error("Ended sessions should report unlocked")
+	}
+}
@@ -10,21 +10,105 @@ import (
 	"github.com/google/uuid"
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
+	"switchboard/internal/clock"
 )
 
 // Manager implements the SessionManager interface
 type Manager struct {
-	dbManager     interfaces.DatabaseManager
-	activeSessions map[string]*types.Session // sessionID -> Session
-	mu            sync.RWMutex
+	dbManager       interfaces.DatabaseManager
+	activeSessions  map[string]*types.Session            // sessionID -> Session
+	studentSessions map[string]map[string]*types.Session // studentID -> sessionID -> Session (inverted index)
+	rosters         map[string]map[string]struct{}       // sessionID -> student IDs, for O(1) membership checks
+	timers          map[string]*sessionTimers            // sessionID -> countdown timers for duration limits
+	activeNames     map[nameKey]map[string]bool          // creator+name -> sessionIDs of active sessions
+	reservedNames   map[nameKey]bool                     // names claimed by creates still being persisted
+	creatorSessions map[string]map[string]bool           // createdBy -> sessionIDs of active sessions
+	reservedCreates map[string]int                       // createdBy -> creates still being persisted
+	allowedCreators map[string]bool                      // Who may create sessions; nil lets anyone
+	maxPerCreator   int                                  // Active sessions one creator may have; 0 is unlimited
+	ending          map[string]chan struct{}             // sessionID -> closed when its in-flight EndSession returns
+	delegations     map[string]map[string]*delegation    // sessionID -> student ID -> granted instructor types
+	onDelegationEnd func(*types.Delegation)              // Told when a grant expires; nil skips the notice
+	lastActivity    map[string]time.Time                 // sessionID -> last routed message or connection change
+	pendingActivity map[string]time.Time                 // Activity not yet written to activityStore
+	flushScheduled  bool                                 // A write of pendingActivity is scheduled
+	activityStore   interfaces.ActivityStore
+	flushInterval   time.Duration
+	idleEndAfter    time.Duration
+	namePolicy      NamePolicy
+	instructorScope InstructorScope
+	timerGeneration int
+	warningOffsets  []time.Duration
+	notifier        ExpiryNotifier
+	createdHooks    []CreatedHook
+	endingHooks     []EndingHook
+	endedHooks      []EndedHook
+	hookBudget      time.Duration
+	maxStudents     int
+	configSnapshot  func() *types.SessionConfig
+	clock           interfaces.Clock
+	metadataMu      sync.Mutex // Serializes metadata read-merge-writes; taken before mu
+	rosterMu        sync.Mutex // Serializes roster read-change-writes; taken before mu
+	activityMu      sync.Mutex // Guards lastActivity, pendingActivity and flushScheduled; taken after mu
+	mu              sync.RWMutex
 }
 
+// DefaultMaxStudents caps a session's roster unless SetMaxStudents changes it
+// FUNCTIONAL DISCOVERY: Well above any real class, but low enough that a runaway
+// roster sync cannot store thousands of IDs in one student_ids column
+const DefaultMaxStudents = 500
+
 // NewManager creates a new session manager
 func NewManager(dbManager interfaces.DatabaseManager) *Manager {
 	return &Manager{
-		dbManager:      dbManager,
-		activeSessions: make(map[string]*types.Session),
+		dbManager:       dbManager,
+		activeSessions:  make(map[string]*types.Session),
+		studentSessions: make(map[string]map[string]*types.Session),
+		rosters:         make(map[string]map[string]struct{}),
+		timers:          make(map[string]*sessionTimers),
+		activeNames:     make(map[nameKey]map[string]bool),
+		reservedNames:   make(map[nameKey]bool),
+		creatorSessions: make(map[string]map[string]bool),
+		reservedCreates: make(map[string]int),
+		ending:          make(map[string]chan struct{}),
+		delegations:     make(map[string]map[string]*delegation),
+		lastActivity:    make(map[string]time.Time),
+		pendingActivity: make(map[string]time.Time),
+		flushInterval:   DefaultActivityInterval,
+		namePolicy:      NamePolicyAllow,
+		instructorScope: InstructorScopeAny,
+		warningOffsets:  []time.Duration{10 * time.Minute, 2 * time.Minute},
+		hookBudget:      defaultHookBudget,
+		maxStudents:     DefaultMaxStudents,
+		clock:           clock.Real(),
+	}
+}
+
+// SetClock sets the clock start times, end times and duration limits are read from
+// TECHNICAL DISCOVERY: Set before sessions are created or loaded; read without locking
+func (m *Manager) SetClock(c interfaces.Clock) {
+	m.clock = c
+}
+
+// SetConfigSnapshot sets where new sessions get their routing configuration snapshot
+// ARCHITECTURAL DISCOVERY: A function rather than the router itself, as with
+// SetSessionLock in the other direction; nil creates sessions without a snapshot
+// TECHNICAL DISCOVERY: Set before sessions are created; read without locking
+func (m *Manager) SetConfigSnapshot(snapshot func() *types.SessionConfig) {
+	m.configSnapshot = snapshot
+}
+
+// SetMaxStudents sets how many distinct students a new session may list; n <= 0
+// restores DefaultMaxStudents
+// TECHNICAL DISCOVERY: Only checked at creation and when a roster import adds
+// students, so sessions already active keep their rosters when the limit is lowered
+func (m *Manager) SetMaxStudents(n int) {
+	if n <= 0 {
+		n = DefaultMaxStudents
 	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxStudents = n
 }
 
 // LoadActiveSessions loads all active sessions from database into memory
@@ -35,50 +119,43 @@ func (m *Manager) LoadActiveSessions(ctx context.Context) error {
 	}
 	
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	
 	for _, session := range sessions {
-		m.activeSessions[session.ID] = session
+		m.addActiveSessionLocked(session)
 	}
+	m.mu.Unlock()
 	
 	log.Printf("Loaded %d active sessions", len(sessions))
+	m.reconcileActivity(ctx, sessions)
 	return nil
 }
 
 // CreateSession creates a new session
 func (m *Manager) CreateSession(ctx context.Context, name string, createdBy string, studentIDs []string) (*types.Session, error) {
-	// Validate input parameters
-	if name == "" || len(name) > 200 {
-		return nil, ErrInvalidSessionName
-	}
-	
-	if !types.IsValidUserID(createdBy) {
-		return nil, ErrInvalidCreatedBy
+	// Validate input parameters and apply the duplicate-name policy; may rename the session
+	check, err := m.checkCreate(ctx, name, createdBy, studentIDs, true)
+	if err != nil {
+		return nil, err
 	}
-	
-	if len(studentIDs) == 0 {
-		return nil, ErrEmptyStudentList
-	}
-	
-	// Remove duplicate student IDs
-	uniqueStudents := removeDuplicates(studentIDs)
-	
-	// Validate all student IDs
-	for _, studentID := range uniqueStudents {
-		if !types.IsValidUserID(studentID) {
-			return nil, fmt.Errorf("%w: invalid student ID %s", ErrInvalidStudentID, studentID)
-		}
+	defer check.release()
+	if check.refusal != nil {
+		return nil, check.refusal
 	}
 	
 	// Create session object
 	session := &types.Session{
 		ID:         uuid.New().String(),
-		Name:       name,
+		Name:       check.name,
 		CreatedBy:  createdBy,
-		StudentIDs: uniqueStudents,
-		StartTime:  time.Now(),
+		OwnerID:    createdBy,
+		StudentIDs: check.roster,
+		StartTime:  m.clock.Now(),
 		EndTime:    nil,
-		Status:     "active",
+		Status:     types.SessionActive,
+		Timezone:   types.DefaultTimezone,
+	}
+	if m.configSnapshot != nil {
+		session.Config = m.configSnapshot()
 	}
 	
 	// Persist to database
@@ -88,10 +165,13 @@ func (m *Manager) CreateSession(ctx context.Context, name string, createdBy stri
 	
 	// Add to in-memory cache
 	m.mu.Lock()
-	m.activeSessions[session.ID] = session
+	m.addActiveSessionLocked(session)
 	m.mu.Unlock()
 	
+	m.TouchSession(session.ID)
+	
 	log.Printf("Created session: id=%s name=%s students=%d", session.ID, session.Name, len(session.StudentIDs))
+	m.runCreatedHooks(ctx, session)
 	return session, nil
 }
 
@@ -115,7 +195,16 @@ func (m *Manager) GetSession(ctx context.Context, sessionID string) (*types.Sess
 }
 
 // EndSession ends an active session
+// FUNCTIONAL DISCOVERY: Ending twice is safe to retry - the second call returns
+// ErrSessionAlreadyEnded without touching the database or notifying anyone again.
+// Concurrent calls for the same session wait for the first to finish, then see it ended
 func (m *Manager) EndSession(ctx context.Context, sessionID string) error {
+	release, err := m.claimEnd(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	defer release()
+	
 	// Get session from cache
 	m.mu.RLock()
 	session, exists := m.activeSessions[sessionID]
@@ -127,31 +216,71 @@ func (m *Manager) EndSession(ctx context.Context, sessionID string) error {
 		if err != nil {
 			return ErrSessionNotFound
 		}
-		if dbSession.Status == "ended" {
+		if dbSession.Status == types.SessionEnded {
 			return ErrSessionAlreadyEnded
 		}
 		session = dbSession
 	}
+	next, err := session.Status.Transition(types.SessionEnded)
+	if err != nil {
+		return err
+	}
+	
+	// Give ending hooks the chance to veto before anything changes
+	if err := m.runEndingHooks(ctx, session); err != nil {
+		return err
+	}
 	
-	// Update session status
-	now := time.Now()
-	session.EndTime = &now
-	session.Status = "ended"
+	// Update session status on a copy; the cached session stays active until the
+	// database agrees, so a failed write leaves nothing half-ended
+	ended := *session
+	now := m.clock.Now()
+	ended.EndTime = &now
+	ended.Status = next
 	
 	// Persist to database
-	if err := m.dbManager.UpdateSession(ctx, session); err != nil {
+	if err := m.dbManager.UpdateSession(ctx, &ended); err != nil {
 		return fmt.Errorf("failed to end session: %w", err)
 	}
 	
 	// Remove from active sessions cache
 	m.mu.Lock()
-	delete(m.activeSessions, sessionID)
+	m.removeActiveSessionLocked(sessionID)
 	m.mu.Unlock()
 	
-	log.Printf("Ended session: id=%s name=%s", session.ID, session.Name)
+	log.Printf("Ended session: id=%s name=%s", ended.ID, ended.Name)
+	m.runEndedHooks(ctx, &ended)
 	return nil
 }
 
+// claimEnd makes the caller the only EndSession running for sessionID
+// TECHNICAL DISCOVERY: A per-session channel rather than a held lock, so ending hooks
+// run without m.mu and ends of different sessions never wait on each other
+func (m *Manager) claimEnd(ctx context.Context, sessionID string) (func(), error) {
+	for {
+		m.mu.Lock()
+		inFlight, busy := m.ending[sessionID]
+		if !busy {
+			finished := make(chan struct{})
+			m.ending[sessionID] = finished
+			m.mu.Unlock()
+			return func() {
+				m.mu.Lock()
+				delete(m.ending, sessionID)
+				m.mu.Unlock()
+				close(finished)
+			}, nil
+		}
+		m.mu.Unlock()
+		
+		select {
+		case <-inFlight:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
 // ListActiveSessions returns all active sessions
 func (m *Manager) ListActiveSessions(ctx context.Context) ([]*types.Session, error) {
 	m.mu.RLock()
@@ -164,11 +293,50 @@ func (m *Manager) ListActiveSessions(ctx context.Context) ([]*types.Session, err
 	return sessions, nil
 }
 
+// ListUserSessions returns the active sessions a user may join
+// FUNCTIONAL DISCOVERY: Instructors have universal access so they see every active
+// session; students are resolved through the inverted index in O(1) per user
+func (m *Manager) ListUserSessions(ctx context.Context, userID, role string) ([]*types.Session, error) {
+	switch role {
+	case "instructor":
+		sessions, err := m.ListActiveSessions(ctx)
+		m.mu.RLock()
+		scope := m.instructorScope
+		m.mu.RUnlock()
+		if err != nil || scope != InstructorScopeMembers {
+			return sessions, err
+		}
+		// Scoped instructors only discover the sessions they may join
+		listed := make([]*types.Session, 0, len(sessions))
+		for _, session := range sessions {
+			if session.ListsInstructor(userID) {
+				listed = append(listed, session)
+			}
+		}
+		return listed, nil
+	case "student":
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		
+		sessions := make([]*types.Session, 0, len(m.studentSessions[userID]))
+		for _, session := range m.studentSessions[userID] {
+			sessions = append(sessions, session)
+		}
+		return sessions, nil
+	default:
+		return nil, ErrInvalidRole
+	}
+}
+
 // ValidateSessionMembership checks if user can join session
 func (m *Manager) ValidateSessionMembership(sessionID, userID, role string) error {
 	// Get session (check cache first)
+	// TECHNICAL DISCOVERY: Roster sets are replaced, never changed in place, so the
+	// one read here stays consistent with session after the lock is released
 	m.mu.RLock()
 	session, exists := m.activeSessions[sessionID]
+	roster := m.rosters[sessionID]
+	scope := m.instructorScope
 	m.mu.RUnlock()
 	
 	if !exists {
@@ -177,31 +345,43 @@ func (m *Manager) ValidateSessionMembership(sessionID, userID, role string) erro
 		if err != nil {
 			return ErrSessionNotFound
 		}
-		if dbSession.Status == "ended" {
+		if dbSession.Status == types.SessionEnded {
 			return ErrSessionEnded
 		}
 		session = dbSession
 	}
 	
 	// Check session is active
-	if session.Status != "active" {
+	if session.Status != types.SessionActive {
 		return ErrSessionEnded
 	}
 	
 	// Validate role-based access
 	switch role {
 	case "instructor":
-		// Instructors have universal access to all active sessions
+		// Instructors have universal access to all active sessions unless scoped to
+		// the sessions that list them
+		if scope == InstructorScopeMembers && !session.ListsInstructor(userID) {
+			return interfaces.ErrInstructorNotMember
+		}
 		return nil
 		
 	case "student":
 		// Students must be in session's student_ids list
-		for _, studentID := range session.StudentIDs {
-			if studentID == userID {
-				return nil
+		// TECHNICAL DISCOVERY: Cached sessions answer from their roster set in O(1);
+		// only a session read from the database falls back to scanning the slice
+		if !exists {
+			for _, studentID := range session.StudentIDs {
+				if studentID == userID {
+					return nil
+				}
 			}
+			return ErrUnauthorized
 		}
-		return ErrUnauthorized
+		if _, enrolled := roster[userID]; !enrolled {
+			return ErrUnauthorized
+		}
+		return nil
 		
 	default:
 		return ErrInvalidRole
@@ -230,24 +410,86 @@ func (m *Manager) RefreshCache(ctx context.Context) error {
 	defer m.mu.Unlock()
 	
 	// Clear current cache
+	for sessionID := range m.timers {
+		m.cancelTimersLocked(sessionID)
+	}
 	m.activeSessions = make(map[string]*types.Session)
+	m.studentSessions = make(map[string]map[string]*types.Session)
+	m.rosters = make(map[string]map[string]struct{})
+	m.activeNames = make(map[nameKey]map[string]bool)
 	
 	// Reload from database
 	for _, session := range sessions {
-		m.activeSessions[session.ID] = session
+		m.addActiveSessionLocked(session)
 	}
 	
 	log.Printf("Refreshed session cache: %d active sessions", len(sessions))
 	return nil
 }
 
+// RosterMembership reports whether userID is on sessionID's roster, and whether any
+// active session lists them at all
+// FUNCTIONAL DISCOVERY: Lets the router tell a mistyped to_user from a student picked
+// from the wrong session (cache-only check)
+func (m *Manager) RosterMembership(sessionID, userID string) (inSession, known bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	sessions := m.studentSessions[userID]
+	_, inSession = sessions[sessionID]
+	return inSession, len(sessions) > 0
+}
+
 // IsSessionActive checks if a session is active (cache-only check)
 func (m *Manager) IsSessionActive(sessionID string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	
 	session, exists := m.activeSessions[sessionID]
-	return exists && session.Status == "active"
+	return exists && session.Status == types.SessionActive
+}
+
+// addActiveSessionLocked caches a session and indexes its students
+// TECHNICAL DISCOVERY: Caller must hold m.mu write lock - cache and inverted index
+// are always updated together so they never disagree
+func (m *Manager) addActiveSessionLocked(session *types.Session) {
+	m.activeSessions[session.ID] = session
+	m.indexNameLocked(session)
+	m.indexCreatorLocked(session.ID, session.CreatedBy)
+	roster := make(map[string]struct{}, len(session.StudentIDs))
+	for _, studentID := range session.StudentIDs {
+		roster[studentID] = struct{}{}
+		if m.studentSessions[studentID] == nil {
+			m.studentSessions[studentID] = make(map[string]*types.Session)
+		}
+		m.studentSessions[studentID][session.ID] = session
+	}
+	m.rosters[session.ID] = roster
+	m.scheduleLocked(session)
+}
+
+// removeActiveSessionLocked drops a session from the cache and inverted index
+// TECHNICAL DISCOVERY: Empty per-student maps are deleted to prevent memory leaks
+func (m *Manager) removeActiveSessionLocked(sessionID string) {
+	m.cancelTimersLocked(sessionID)
+	session, exists := m.activeSessions[sessionID]
+	if !exists {
+		return
+	}
+	delete(m.activeSessions, sessionID)
+	delete(m.rosters, sessionID)
+	m.dropDelegationsLocked(sessionID)
+	m.dropActivityLocked(sessionID)
+	m.unindexNameLocked(session)
+	m.unindexCreatorLocked(sessionID, session.CreatedBy)
+	for _, studentID := range session.StudentIDs {
+		if sessions, ok := m.studentSessions[studentID]; ok {
+			delete(sessions, sessionID)
+			if len(sessions) == 0 {
+				delete(m.studentSessions, studentID)
+			}
+		}
+	}
 }
 
 // Helper function to remove duplicate student IDs
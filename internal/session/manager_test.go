@@ -3,7 +3,9 @@ package session
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -14,12 +16,15 @@ import (
 // Mock DatabaseManager for testing
 type mockDatabaseManager struct {
 	sessions map[string]*types.Session
+	events   []*types.SessionEvent
+	updates  int // UpdateSession calls that reached the database
 	mu       sync.RWMutex
 	
 	// Control behavior for testing
 	shouldFailCreate bool
 	shouldFailUpdate bool
 	shouldFailList   bool
+	failUpdateWith   error // Returned by UpdateSession when set
 }
 
 func newMockDatabaseManager() *mockDatabaseManager {
@@ -54,10 +59,14 @@ func (m *mockDatabaseManager) UpdateSession(ctx context.Context, session *types.
 	if m.shouldFailUpdate {
 		return errors.New("database update failed")
 	}
+	if m.failUpdateWith != nil {
+		return m.failUpdateWith
+	}
 	
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.sessions[session.ID] = session
+	m.updates++
 	return nil
 }
 
@@ -78,6 +87,78 @@ func (m *mockDatabaseManager) ListActiveSessions(ctx context.Context) ([]*types.
 	return activeSessions, nil
 }
 
+func (m *mockDatabaseManager) ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	var names []string
+	for _, session := range m.sessions {
+		if session.Status == "active" && session.CreatedBy == createdBy {
+			names = append(names, session.Name)
+		}
+	}
+	return names, nil
+}
+
+func (m *mockDatabaseManager) TransferSessionOwner(ctx context.Context, sessionID, previousOwner, newOwner, actor string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	session, exists := m.sessions[sessionID]
+	if !exists || session.Status != "active" || session.Owner() != previousOwner {
+		return interfaces.ErrSessionNotFound
+	}
+	updated := *session
+	updated.OwnerID = newOwner
+	m.sessions[sessionID] = &updated
+	m.events = append(m.events, &types.SessionEvent{
+		SessionID: sessionID,
+		Type:      types.SessionEventOwnerTransferred,
+		Actor:     actor,
+		Details:   map[string]interface{}{"previous_owner": previousOwner, "new_owner": newOwner},
+		CreatedAt: time.Now(),
+	})
+	return nil
+}
+
+func (m *mockDatabaseManager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	session, exists := m.sessions[sessionID]
+	if !exists || session.Status != "active" {
+		return interfaces.ErrSessionNotFound
+	}
+	updated := *session
+	updated.Locked = locked
+	m.sessions[sessionID] = &updated
+	return nil
+}
+
+func (m *mockDatabaseManager) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	session, exists := m.sessions[sessionID]
+	if !exists {
+		return nil, interfaces.ErrSessionNotFound
+	}
+	return session.Config, nil
+}
+
+func (m *mockDatabaseManager) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	
+	var events []*types.SessionEvent
+	for _, event := range m.events {
+		if event.SessionID == sessionID {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
 func (m *mockDatabaseManager) StoreMessage(ctx context.Context, message *types.Message) error {
 	return nil // Not used in session manager tests
 }
@@ -90,6 +171,129 @@ func (m *mockDatabaseManager) HealthCheck(ctx context.Context) error {
 	return nil // Not used in session manager tests
 }
 
+func (m *mockDatabaseManager) ImportMessages(ctx context.Context, messages []*types.Message) ([]string, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error {
+	return nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetMessageMetadata(ctx context.Context, messageID string) (*types.MessageMetadata, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) HealthStatus() types.DatabaseHealth {
+	return types.DatabaseHealth{} // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) SetMessageAnnotation(ctx context.Context, sessionID string, annotation *types.MessageAnnotation) error {
+	return nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetSessionAnnotations(ctx context.Context, sessionID string) ([]*types.MessageAnnotation, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error {
+	return nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetSessionReactionCounts(ctx context.Context, sessionID string) (map[string]map[string]int, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetSessionHistoryAsOf(ctx context.Context, sessionID string, asOf time.Time) ([]*types.Message, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetStudentThread(ctx context.Context, sessionID, studentID, afterID string, limit int) ([]*types.Message, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetAnalyticsBuckets(ctx context.Context, sessionID, userID string) ([]*types.AnalyticsBucket, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	session, exists := m.sessions[sessionID]
+	if !exists || session.Status != "active" {
+		return interfaces.ErrSessionNotFound
+	}
+	updated := *session
+	updated.Timezone = timezone
+	m.sessions[sessionID] = &updated
+	return nil
+}
+
+func (m *mockDatabaseManager) SetSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	session, exists := m.sessions[sessionID]
+	if !exists || session.Status != "active" {
+		return interfaces.ErrSessionNotFound
+	}
+	updated := *session
+	updated.Metadata = metadata
+	m.sessions[sessionID] = &updated
+	return nil
+}
+
+func (m *mockDatabaseManager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	session, exists := m.sessions[sessionID]
+	if !exists || session.Status != "active" {
+		return interfaces.ErrSessionNotFound
+	}
+	updated := *session
+	updated.InstructorIDs = instructorIDs
+	m.sessions[sessionID] = &updated
+	return nil
+}
+
+func (m *mockDatabaseManager) SetSessionStudents(ctx context.Context, sessionID string, studentIDs []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	
+	session, exists := m.sessions[sessionID]
+	if !exists || session.Status != "active" {
+		return interfaces.ErrSessionNotFound
+	}
+	updated := *session
+	updated.StudentIDs = studentIDs
+	m.sessions[sessionID] = &updated
+	return nil
+}
+
+func (m *mockDatabaseManager) ActiveSessionsByMetadata(ctx context.Context, filters map[string]string) ([]string, error) {
+	return nil, nil // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error) {
+	return nil, interfaces.ErrNotFound // Not used in session manager tests
+}
+
+func (m *mockDatabaseManager) RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockDatabaseManager) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) {
+	return nil, nil // Not used in session manager tests
+}
+
 func (m *mockDatabaseManager) Close() error {
 	return nil // Not used in session manager tests
 }
@@ -237,6 +441,68 @@ func TestManager_GetSessionCacheFirst(t *testing.T) {
 	}
 }
 
+// FUNCTIONAL VALIDATION TEST: Double-end racing through the cache ends exactly once
+func TestManager_EndSessionConcurrent(t *testing.T) {
+	dbManager := newMockDatabaseManager()
+	manager := NewManager(dbManager)
+	
+	var endedHooks int32
+	manager.OnSessionEnded(func(ctx context.Context, ended types.Session) {
+		atomic.AddInt32(&endedHooks, 1)
+	})
+	// A slow ending hook keeps the first end in flight while the others arrive
+	manager.OnSessionEnding(func(ctx context.Context, ending types.Session) error {
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	
+	ctx := context.Background()
+	session, err := manager.CreateSession(ctx, "Racing", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	
+	const callers = 8
+	errs := make(chan error, callers)
+	var start sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < callers; i++ {
+		go func() {
+			start.Wait()
+			errs <- manager.EndSession(ctx, session.ID)
+		}()
+	}
+	start.Done()
+	
+	succeeded := 0
+	for i := 0; i < callers; i++ {
+		err := <-errs
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, ErrSessionAlreadyEnded):
+			t.Errorf("Expected ErrSessionAlreadyEnded for a repeated end, got %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("Expected exactly one successful end, got %d", succeeded)
+	}
+	if dbManager.updates != 1 {
+		t.Errorf("Expected one database update, got %d", dbManager.updates)
+	}
+	if hooks := atomic.LoadInt32(&endedHooks); hooks != 1 {
+		t.Errorf("Expected session_ended hooks to run once, ran %d times", hooks)
+	}
+	
+	// A later retry is answered the same way without side effects
+	if err := manager.EndSession(ctx, session.ID); !errors.Is(err, ErrSessionAlreadyEnded) {
+		t.Errorf("Expected ErrSessionAlreadyEnded on retry, got %v", err)
+	}
+	if dbManager.updates != 1 {
+		t.Errorf("Retry should not reach the database, got %d updates", dbManager.updates)
+	}
+}
+
 func TestManager_EndSessionBehavior(t *testing.T) {
 	// This test will FAIL until EndSession is implemented
 	dbManager := newMockDatabaseManager()
@@ -287,6 +553,35 @@ func TestManager_EndSessionBehavior(t *testing.T) {
 	}
 }
 
+// TestManager_EndSessionTransitions tests edge case validation - only an active
+// session can end, and a duration change that lost the race with the end is refused
+func TestManager_EndSessionTransitions(t *testing.T) {
+	dbManager := newMockDatabaseManager()
+	manager := NewManager(dbManager)
+	ctx := context.Background()
+	
+	dbManager.sessions["scheduled"] = &types.Session{ID: "scheduled", Name: "Later", CreatedBy: "instructor1", StudentIDs: []string{"student1"}, Status: types.SessionScheduled}
+	err := manager.EndSession(ctx, "scheduled")
+	if !errors.Is(err, types.ErrIllegalSessionTransition) {
+		t.Errorf("Expected ending a scheduled session refused, got %v", err)
+	}
+	if dbManager.updates != 0 || dbManager.sessions["scheduled"].Status != types.SessionScheduled {
+		t.Error("A refused end should not reach the database")
+	}
+	
+	session, err := manager.CreateSession(ctx, "Now", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if session.Status != types.SessionActive {
+		t.Errorf("Expected a new session to start active, got %q", session.Status)
+	}
+	dbManager.failUpdateWith = &types.SessionTransitionError{From: types.SessionEnded, To: types.SessionActive}
+	if _, err := manager.SetSessionDuration(ctx, session.ID, 30); !errors.Is(err, ErrSessionEnded) {
+		t.Errorf("Expected a duration change refused by the database reported as ended, got %v", err)
+	}
+}
+
 func TestManager_ValidateSessionMembershipRules(t *testing.T) {
 	// This test will FAIL until ValidateSessionMembership is implemented
 	dbManager := newMockDatabaseManager()
@@ -623,6 +918,15 @@ func TestManager_SessionLifecycleIntegration(t *testing.T) {
 	if err != ErrSessionEnded {
 		t.Errorf("Validation should fail for ended session, got: %v", err)
 	}
+	
+	// The WebSocket handler tells these apart through the interface errors
+	if !errors.Is(err, interfaces.ErrSessionEnded) {
+		t.Errorf("Expected the interface's ErrSessionEnded, got: %v", err)
+	}
+	err = manager.ValidateSessionMembership(session.ID+"-missing", "student1", "student")
+	if !errors.Is(err, interfaces.ErrSessionNotFound) {
+		t.Errorf("Expected the interface's ErrSessionNotFound, got: %v", err)
+	}
 }
 
 func TestManager_StatisticsAndCacheManagement(t *testing.T) {
@@ -666,4 +970,154 @@ func TestManager_StatisticsAndCacheManagement(t *testing.T) {
 	if stats["active_sessions"] != 2 {
 		t.Errorf("Expected 2 active sessions after refresh, got %v", stats["active_sessions"])
 	}
-}
\ No newline at end of file
+}
+func TestManager_ListUserSessionsInvertedIndex(t *testing.T) {
+	dbManager := newMockDatabaseManager()
+	manager := NewManager(dbManager)
+	ctx := context.Background()
+	
+	sessionA, err := manager.CreateSession(ctx, "Session A", "instructor1", []string{"student1", "student2"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Session B", "instructor1", []string{"student2"}); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	
+	sessions, err := manager.ListUserSessions(ctx, "student2", "student")
+	if err != nil {
+		t.Fatalf("ListUserSessions failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Errorf("Expected student2 in 2 sessions, got %d", len(sessions))
+	}
+	
+	sessions, _ = manager.ListUserSessions(ctx, "student1", "student")
+	if len(sessions) != 1 || sessions[0].ID != sessionA.ID {
+		t.Errorf("Expected student1 only in session A, got %v", sessions)
+	}
+	
+	// Instructors see all active sessions
+	sessions, _ = manager.ListUserSessions(ctx, "instructor2", "instructor")
+	if len(sessions) != 2 {
+		t.Errorf("Expected instructor to see 2 sessions, got %d", len(sessions))
+	}
+	
+	// Ending a session removes it from the index
+	if err := manager.EndSession(ctx, sessionA.ID); err != nil {
+		t.Fatalf("EndSession failed: %v", err)
+	}
+	sessions, _ = manager.ListUserSessions(ctx, "student1", "student")
+	if len(sessions) != 0 {
+		t.Errorf("Expected no sessions for student1 after end, got %d", len(sessions))
+	}
+	if _, exists := manager.studentSessions["student1"]; exists {
+		t.Error("Empty index entry should be removed")
+	}
+	
+	if _, err := manager.ListUserSessions(ctx, "student1", "admin"); err != ErrInvalidRole {
+		t.Errorf("Expected ErrInvalidRole, got %v", err)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Rosters over the cap are refused after deduplication
+func TestManager_CreateSessionMaxStudents(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	manager.SetMaxStudents(3)
+	ctx := context.Background()
+	
+	// Duplicates do not count against the cap
+	created, err := manager.CreateSession(ctx, "Small Class", "instructor1", []string{"s1", "s2", "s3", "s3", "s1"})
+	if err != nil {
+		t.Fatalf("CreateSession at the cap should succeed: %v", err)
+	}
+	if err := manager.ValidateSessionMembership(created.ID, "s3", "student"); err != nil {
+		t.Errorf("Expected s3 enrolled, got %v", err)
+	}
+	if err := manager.ValidateSessionMembership(created.ID, "s4", "student"); err != ErrUnauthorized {
+		t.Errorf("Expected ErrUnauthorized for s4, got %v", err)
+	}
+	
+	_, err = manager.CreateSession(ctx, "Big Class", "instructor1", []string{"s1", "s2", "s3", "s4"})
+	if !errors.Is(err, ErrTooManyStudents) {
+		t.Fatalf("Expected ErrTooManyStudents, got %v", err)
+	}
+	if len(manager.activeSessions) != 1 {
+		t.Errorf("A refused roster must not create a session, have %d", len(manager.activeSessions))
+	}
+	
+	// Ending a session drops its roster set
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession failed: %v", err)
+	}
+	if _, exists := manager.rosters[created.ID]; exists {
+		t.Error("Ended session's roster should be released")
+	}
+	
+	manager.SetMaxStudents(0)
+	if manager.maxStudents != DefaultMaxStudents {
+		t.Errorf("Expected SetMaxStudents(0) to restore %d, got %d", DefaultMaxStudents, manager.maxStudents)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Each session is created with a fresh config snapshot
+func TestManager_CreateSessionConfigSnapshot(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+	
+	unsnapshotted, err := manager.CreateSession(ctx, "Before", "instructor1", []string{"s1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if config, err := mockDB.GetSessionConfig(ctx, unsnapshotted.ID); err != nil || config != nil {
+		t.Errorf("Expected no snapshot without SetConfigSnapshot, got %+v, %v", config, err)
+	}
+	
+	window := 60
+	manager.SetConfigSnapshot(func() *types.SessionConfig {
+		return &types.SessionConfig{Version: types.SessionConfigVersion, Limits: types.SessionLimits{RateLimitWindowSeconds: window}}
+	})
+	first, err := manager.CreateSession(ctx, "First", "instructor1", []string{"s1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	window = 30
+	second, err := manager.CreateSession(ctx, "Second", "instructor1", []string{"s1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	
+	for id, want := range map[string]int{first.ID: 60, second.ID: 30} {
+		config, err := mockDB.GetSessionConfig(ctx, id)
+		if err != nil || config == nil || config.Limits.RateLimitWindowSeconds != want {
+			t.Errorf("Expected a %ds window persisted for %s, got %+v, %v", want, id, config, err)
+		}
+	}
+}
+
+// BenchmarkManager_ValidateSessionMembership shows membership checks stay flat as rosters grow
+func BenchmarkManager_ValidateSessionMembership(b *testing.B) {
+	for _, size := range []int{30, 500, 5000} {
+		b.Run(fmt.Sprintf("students=%d", size), func(b *testing.B) {
+			manager := NewManager(newMockDatabaseManager())
+			manager.SetMaxStudents(size)
+			studentIDs := make([]string, size)
+			for i := range studentIDs {
+				studentIDs[i] = fmt.Sprintf("student%d", i)
+			}
+			created, err := manager.CreateSession(context.Background(), "Lecture", "instructor1", studentIDs)
+			if err != nil {
+				b.Fatalf("CreateSession failed: %v", err)
+			}
+			last := studentIDs[size-1] // Worst case for a slice scan
+			
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := manager.ValidateSessionMembership(created.ID, last, "student"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
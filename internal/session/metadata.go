@@ -0,0 +1,61 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// UpdateSessionMetadata applies a merge patch to an active session's metadata
+// FUNCTIONAL DISCOVERY: The merged result is validated as a whole, so a patch that
+// would push the session past the key limit fails with a *types.MetadataError and
+// leaves the stored metadata unchanged
+// TECHNICAL DISCOVERY: metadataMu serializes the read-merge-write so two concurrent
+// patches touching different keys both land
+func (m *Manager) UpdateSessionMetadata(ctx context.Context, sessionID string, patch map[string]*string) (*types.Session, error) {
+	m.metadataMu.Lock()
+	defer m.metadataMu.Unlock()
+
+	m.mu.RLock()
+	session, exists := m.activeSessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return nil, ErrSessionNotFound
+		}
+		return nil, ErrSessionEnded
+	}
+
+	metadata := types.MergeSessionMetadata(session.Metadata, patch)
+	if err := types.ValidateSessionMetadata(metadata); err != nil {
+		return nil, err
+	}
+	if len(metadata) == 0 {
+		metadata = nil
+	}
+
+	if err := m.dbManager.SetSessionMetadata(ctx, sessionID, metadata); err != nil {
+		if errors.Is(err, interfaces.ErrSessionNotFound) {
+			return nil, ErrSessionEnded
+		}
+		return nil, fmt.Errorf("failed to update session metadata: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, stillActive := m.activeSessions[sessionID]
+	if !stillActive {
+		return nil, ErrSessionEnded
+	}
+	// Work on a copy of the current entry so a concurrent lock, duration change or transfer is kept
+	updated := *current
+	updated.Metadata = metadata
+	m.addActiveSessionLocked(&updated)
+
+	log.Printf("Updated session metadata: id=%s keys=%d", sessionID, len(metadata))
+	return &updated, nil
+}
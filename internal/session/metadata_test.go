@@ -0,0 +1,59 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestUpdateSessionMetadata(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+	value := func(s string) *string { return &s }
+
+	created, err := manager.CreateSession(ctx, "Biology", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+
+	updated, err := manager.UpdateSessionMetadata(ctx, created.ID, map[string]*string{"course": value("BIO101"), "room": value("B12")})
+	if err != nil || updated.Metadata["course"] != "BIO101" || updated.Metadata["room"] != "B12" {
+		t.Fatalf("UpdateSessionMetadata should succeed, got %+v, %v", updated, err)
+	}
+
+	// A null removes its key; other keys are kept
+	updated, err = manager.UpdateSessionMetadata(ctx, created.ID, map[string]*string{"room": nil, "lms_assignment": value("42")})
+	if err != nil || len(updated.Metadata) != 2 || updated.Metadata["course"] != "BIO101" || updated.Metadata["lms_assignment"] != "42" {
+		t.Fatalf("Expected the patch merged, got %+v, %v", updated, err)
+	}
+	if stored, _ := mockDB.GetSession(ctx, created.ID); stored.Metadata["lms_assignment"] != "42" {
+		t.Error("Metadata should be persisted")
+	}
+
+	// An invalid result changes nothing
+	_, err = manager.UpdateSessionMetadata(ctx, created.ID, map[string]*string{"sb_internal": value("x"), "notes": value(strings.Repeat("x", 2000))})
+	var metadataErr *types.MetadataError
+	if !errors.As(err, &metadataErr) || len(metadataErr.Fields) != 2 || metadataErr.Fields["metadata.notes"] == "" {
+		t.Fatalf("Expected field errors for both keys, got %v", err)
+	}
+	if cached, _ := manager.GetSession(ctx, created.ID); len(cached.Metadata) != 2 {
+		t.Errorf("Expected the metadata unchanged after a refused patch, got %v", cached.Metadata)
+	}
+
+	// A lock toggle keeps the metadata
+	if locked, err := manager.SetSessionLocked(ctx, created.ID, true); err != nil || locked.Metadata["course"] != "BIO101" {
+		t.Errorf("Locking should keep the metadata, got %+v, %v", locked, err)
+	}
+
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, err := manager.UpdateSessionMetadata(ctx, created.ID, nil); !errors.Is(err, ErrSessionEnded) {
+		t.Errorf("Ended session: expected ErrSessionEnded, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"switchboard/pkg/types"
+)
+
+// NamePolicy controls how CreateSession treats a name already used by one of the
+// creator's active sessions
+type NamePolicy string
+
+const (
+	// NamePolicyAllow permits any number of active sessions with the same name
+	NamePolicyAllow NamePolicy = "allow"
+	// NamePolicyUniqueActive rejects the duplicate with ErrDuplicateName
+	NamePolicyUniqueActive NamePolicy = "unique_active"
+	// NamePolicySuffix renames the duplicate to "Name (2)", "Name (3)", ...
+	NamePolicySuffix NamePolicy = "suffix"
+)
+
+// maxSessionNameLength matches the 1-200 character rule enforced by CreateSession
+const maxSessionNameLength = 200
+
+// nameKey identifies a session name within one creator's sessions
+// FUNCTIONAL DISCOVERY: Names only clash per creator, so two instructors may each
+// run a "Math - Review Session"
+type nameKey struct {
+	createdBy string
+	name      string
+}
+
+// SetNamePolicy selects the duplicate-name policy for sessions created afterwards
+// TECHNICAL DISCOVERY: Unknown policies behave like NamePolicyAllow; configuration
+// validation rejects them before they reach the manager
+func (m *Manager) SetNamePolicy(policy NamePolicy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.namePolicy = policy
+}
+
+// claimName applies the name policy to a new session's name and returns the name it
+// gets; with reserve it also holds that name until release is called
+// ARCHITECTURAL DISCOVERY: The reservation is taken under m.mu and held until the
+// session is cached, so concurrent creates with the same name cannot both pass the
+// check while the first one is still being persisted
+// FUNCTIONAL DISCOVERY: The cache index answers for sessions this manager knows about;
+// the database lookup covers the cold path, e.g. sessions another process created
+// since the cache was loaded. The returned release func must always be called
+func (m *Manager) claimName(ctx context.Context, createdBy, name string, reserve bool) (string, func(), error) {
+	m.mu.RLock()
+	policy := m.namePolicy
+	m.mu.RUnlock()
+	if policy != NamePolicyUniqueActive && policy != NamePolicySuffix {
+		return name, func() {}, nil
+	}
+
+	persistedNames, err := m.dbManager.ActiveSessionNames(ctx, createdBy)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to check session name: %w", err)
+	}
+	persisted := make(map[string]bool, len(persistedNames))
+	for _, name := range persistedNames {
+		persisted[name] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	taken := func(name string) bool {
+		key := nameKey{createdBy: createdBy, name: name}
+		return persisted[name] || len(m.activeNames[key]) > 0 || m.reservedNames[key]
+	}
+
+	final := name
+	if taken(final) {
+		if policy == NamePolicyUniqueActive {
+			return "", nil, fmt.Errorf("%w: %q", ErrDuplicateName, name)
+		}
+		// Terminates: only finitely many names are taken
+		for n := 2; taken(final); n++ {
+			final = fmt.Sprintf("%s (%d)", name, n)
+		}
+		if len(final) > maxSessionNameLength {
+			return "", nil, fmt.Errorf("%w: no room for a suffix on %q", ErrDuplicateName, name)
+		}
+		if reserve {
+			log.Printf("Renamed duplicate session name %q to %q for %s", name, final, createdBy)
+		}
+	}
+	if !reserve {
+		return final, func() {}, nil
+	}
+
+	key := nameKey{createdBy: createdBy, name: final}
+	m.reservedNames[key] = true
+	return final, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.reservedNames, key)
+	}, nil
+}
+
+// indexNameLocked records an active session in the name index
+// TECHNICAL DISCOVERY: Caller must hold m.mu write lock; keyed by session ID so
+// re-caching an updated session is idempotent
+func (m *Manager) indexNameLocked(session *types.Session) {
+	key := nameKey{createdBy: session.CreatedBy, name: session.Name}
+	if m.activeNames[key] == nil {
+		m.activeNames[key] = make(map[string]bool)
+	}
+	m.activeNames[key][session.ID] = true
+}
+
+// unindexNameLocked drops a session from the name index
+func (m *Manager) unindexNameLocked(session *types.Session) {
+	key := nameKey{createdBy: session.CreatedBy, name: session.Name}
+	if ids, ok := m.activeNames[key]; ok {
+		delete(ids, session.ID)
+		if len(ids) == 0 {
+			delete(m.activeNames, key)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestNamePolicy_Allow(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	ctx := context.Background()
+	
+	for i := 0; i < 3; i++ {
+		created, err := manager.CreateSession(ctx, "Math - Review Session", "instructor1", []string{"student1"})
+		if err != nil {
+			t.Fatalf("Default policy should allow duplicate names: %v", err)
+		}
+		if created.Name != "Math - Review Session" {
+			t.Errorf("Allow policy should not rename, got %q", created.Name)
+		}
+	}
+}
+
+func TestNamePolicy_UniqueActive(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	manager.SetNamePolicy(NamePolicyUniqueActive)
+	ctx := context.Background()
+	
+	first, err := manager.CreateSession(ctx, "Math - Review Session", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("First session should be created: %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Math - Review Session", "instructor1", []string{"student2"}); !errors.Is(err, ErrDuplicateName) {
+		t.Fatalf("Expected ErrDuplicateName, got %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Math - Review Session", "instructor2", []string{"student2"}); err != nil {
+		t.Errorf("Another creator may reuse the name: %v", err)
+	}
+	
+	// Ending the session frees its name
+	if err := manager.EndSession(ctx, first.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, err := manager.CreateSession(ctx, "Math - Review Session", "instructor1", []string{"student1"}); err != nil {
+		t.Errorf("Name of an ended session should be available: %v", err)
+	}
+}
+
+func TestNamePolicy_Suffix(t *testing.T) {
+	manager := NewManager(newMockDatabaseManager())
+	manager.SetNamePolicy(NamePolicySuffix)
+	ctx := context.Background()
+	
+	want := []string{"Math - Review Session", "Math - Review Session (2)", "Math - Review Session (3)"}
+	for _, expected := range want {
+		created, err := manager.CreateSession(ctx, "Math - Review Session", "instructor1", []string{"student1"})
+		if err != nil {
+			t.Fatalf("CreateSession should succeed: %v", err)
+		}
+		if created.Name != expected {
+			t.Errorf("Expected name %q, got %q", expected, created.Name)
+		}
+	}
+}
+
+func TestNamePolicy_ColdPath(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	manager.SetNamePolicy(NamePolicyUniqueActive)
+	
+	// Persisted by another process after this manager loaded its cache
+	mockDB.sessions["elsewhere"] = &types.Session{
+		ID:         "elsewhere",
+		Name:       "Math - Review Session",
+		CreatedBy:  "instructor1",
+		StudentIDs: []string{"student1"},
+		StartTime:  time.Now(),
+		Status:     "active",
+	}
+	
+	if _, err := manager.CreateSession(context.Background(), "Math - Review Session", "instructor1", []string{"student1"}); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("Database check should catch names missing from the cache, got %v", err)
+	}
+}
+
+// Concurrency Tests
+func TestNamePolicy_ConcurrentCreates(t *testing.T) {
+	const creators = 20
+	
+	for _, policy := range []NamePolicy{NamePolicyUniqueActive, NamePolicySuffix} {
+		t.Run(string(policy), func(t *testing.T) {
+			manager := NewManager(newMockDatabaseManager())
+			manager.SetNamePolicy(policy)
+			
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			names := make(map[string]int)
+			duplicates := 0
+			start := make(chan struct{})
+			
+			for i := 0; i < creators; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					created, err := manager.CreateSession(context.Background(), "Race", "instructor1", []string{fmt.Sprintf("student%d", i)})
+					mu.Lock()
+					defer mu.Unlock()
+					if errors.Is(err, ErrDuplicateName) {
+						duplicates++
+						return
+					}
+					if err != nil {
+						t.Errorf("Unexpected error: %v", err)
+						return
+					}
+					names[created.Name]++
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+			
+			for name, count := range names {
+				if count > 1 {
+					t.Errorf("Name %q was given to %d active sessions", name, count)
+				}
+			}
+			switch policy {
+			case NamePolicyUniqueActive:
+				if len(names) != 1 || duplicates != creators-1 {
+					t.Errorf("Expected exactly one winner, got %d created and %d rejected", len(names), duplicates)
+				}
+			case NamePolicySuffix:
+				if len(names) != creators {
+					t.Errorf("Expected %d distinct names, got %d", creators, len(names))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// TransferOwnership hands an active session to newOwner on behalf of actor
+// FUNCTIONAL DISCOVERY: CreatedBy is left untouched so the historical creator survives;
+// only OwnerID moves, and the database records the change in session_events
+func (m *Manager) TransferOwnership(ctx context.Context, sessionID, newOwner, actor string) (*types.Session, error) {
+	if !types.IsValidUserID(newOwner) {
+		return nil, ErrInvalidOwner
+	}
+
+	m.mu.RLock()
+	session, exists := m.activeSessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return nil, ErrSessionNotFound
+		}
+		return nil, ErrSessionEnded
+	}
+
+	previousOwner := session.Owner()
+	if previousOwner == newOwner {
+		return nil, ErrAlreadyOwner
+	}
+
+	// TECHNICAL DISCOVERY: The database only applies the transfer while previousOwner
+	// still owns the session, so a concurrent transfer makes this one fail cleanly
+	if err := m.dbManager.TransferSessionOwner(ctx, sessionID, previousOwner, newOwner, actor); err != nil {
+		if errors.Is(err, interfaces.ErrSessionNotFound) {
+			return nil, ErrOwnerChanged
+		}
+		return nil, fmt.Errorf("failed to transfer session ownership: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, stillActive := m.activeSessions[sessionID]
+	if !stillActive {
+		return nil, ErrSessionEnded
+	}
+	// Work on a copy of the current entry so a concurrent duration change is kept
+	updated := *current
+	updated.OwnerID = newOwner
+	m.addActiveSessionLocked(&updated)
+
+	log.Printf("Transferred session ownership: id=%s from=%s to=%s by=%s", sessionID, previousOwner, newOwner, actor)
+	return &updated, nil
+}
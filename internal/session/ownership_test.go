@@ -0,0 +1,78 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestTransferOwnership(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+
+	created, err := manager.CreateSession(ctx, "Owned Session", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	if created.OwnerID != "instructor1" {
+		t.Errorf("Creator should own a new session, got %q", created.OwnerID)
+	}
+
+	updated, err := manager.TransferOwnership(ctx, created.ID, "instructor2", "instructor2")
+	if err != nil {
+		t.Fatalf("TransferOwnership should succeed: %v", err)
+	}
+	if updated.OwnerID != "instructor2" || updated.CreatedBy != "instructor1" {
+		t.Errorf("Expected owner instructor2 with creator kept, got %+v", updated)
+	}
+
+	cached, _ := manager.GetSession(ctx, created.ID)
+	if cached.Owner() != "instructor2" {
+		t.Errorf("Cache should reflect the new owner, got %q", cached.Owner())
+	}
+	sessions, _ := manager.ListUserSessions(ctx, "student1", "student")
+	if len(sessions) != 1 || sessions[0].Owner() != "instructor2" {
+		t.Error("Student index should reflect the new owner")
+	}
+
+	events, _ := mockDB.GetSessionEvents(ctx, created.ID)
+	if len(events) != 1 || events[0].Type != types.SessionEventOwnerTransferred || events[0].Actor != "instructor2" {
+		t.Errorf("Expected one audited transfer, got %+v", events)
+	}
+}
+
+func TestTransferOwnership_Errors(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+	created, _ := manager.CreateSession(ctx, "Owned Session", "instructor1", []string{"student1"})
+
+	if _, err := manager.TransferOwnership(ctx, created.ID, "not valid!", "instructor1"); !errors.Is(err, ErrInvalidOwner) {
+		t.Errorf("Expected ErrInvalidOwner, got %v", err)
+	}
+	if _, err := manager.TransferOwnership(ctx, created.ID, "instructor1", "instructor1"); !errors.Is(err, ErrAlreadyOwner) {
+		t.Errorf("Expected ErrAlreadyOwner, got %v", err)
+	}
+	if _, err := manager.TransferOwnership(ctx, "missing", "instructor2", "instructor1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Expected ErrSessionNotFound, got %v", err)
+	}
+
+	// The database rejects a transfer from an owner that no longer holds the session
+	mockDB.mu.Lock()
+	moved := *mockDB.sessions[created.ID]
+	moved.OwnerID = "instructor3"
+	mockDB.sessions[created.ID] = &moved
+	mockDB.mu.Unlock()
+	if _, err := manager.TransferOwnership(ctx, created.ID, "instructor2", "instructor2"); !errors.Is(err, ErrOwnerChanged) {
+		t.Errorf("Expected ErrOwnerChanged, got %v", err)
+	}
+
+	_ = manager.EndSession(ctx, created.ID)
+	if _, err := manager.TransferOwnership(ctx, created.ID, "instructor2", "instructor1"); !errors.Is(err, ErrSessionEnded) {
+		t.Errorf("Expected ErrSessionEnded, got %v", err)
+	}
+}
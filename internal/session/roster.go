@@ -0,0 +1,108 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// UpdateRoster adds and removes students on an active session's roster
+// FUNCTIONAL DISCOVERY: The resulting roster is held to CreateSession's rules - valid
+// IDs, not empty, within the student cap unless it only shrinks - and a dry run checks
+// exactly the same, so it cannot promise a change the real call refuses. Removed
+// students keep an open connection but cannot join again, and lose any delegation
+// they held
+// TECHNICAL DISCOVERY: rosterMu serializes the read-change-write so two imports
+// against one session both land
+func (m *Manager) UpdateRoster(ctx context.Context, sessionID string, change types.RosterChange, dryRun bool) (*types.Session, types.RosterChange, error) {
+	for _, studentID := range append(append([]string(nil), change.Add...), change.Remove...) {
+		if !types.IsValidUserID(studentID) {
+			return nil, types.RosterChange{}, fmt.Errorf("%w: %s", ErrInvalidStudentID, studentID)
+		}
+	}
+
+	m.rosterMu.Lock()
+	defer m.rosterMu.Unlock()
+
+	m.mu.RLock()
+	session, exists := m.activeSessions[sessionID]
+	roster := m.rosters[sessionID]
+	maxStudents := m.maxStudents
+	m.mu.RUnlock()
+	if !exists {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return nil, types.RosterChange{}, ErrSessionNotFound
+		}
+		return nil, types.RosterChange{}, ErrSessionEnded
+	}
+
+	effective := types.RosterChange{Add: []string{}, Remove: []string{}}
+	removing := make(map[string]bool, len(change.Remove))
+	for _, studentID := range removeDuplicates(change.Remove) {
+		if _, enrolled := roster[studentID]; enrolled {
+			removing[studentID] = true
+			effective.Remove = append(effective.Remove, studentID)
+		}
+	}
+	students := make([]string, 0, len(session.StudentIDs)+len(change.Add))
+	for _, studentID := range session.StudentIDs {
+		if !removing[studentID] {
+			students = append(students, studentID)
+		}
+	}
+	for _, studentID := range removeDuplicates(change.Add) {
+		if _, enrolled := roster[studentID]; !enrolled {
+			students = append(students, studentID)
+			effective.Add = append(effective.Add, studentID)
+		}
+	}
+
+	switch {
+	case len(students) == 0:
+		return nil, effective, ErrEmptyStudentList
+	case len(students) > maxStudents && len(effective.Add) > 0:
+		return nil, effective, fmt.Errorf("%w: %d students, at most %d allowed", ErrTooManyStudents, len(students), maxStudents)
+	}
+	if dryRun || len(effective.Add)+len(effective.Remove) == 0 {
+		preview := *session
+		preview.StudentIDs = students
+		return &preview, effective, nil
+	}
+
+	if err := m.dbManager.SetSessionStudents(ctx, sessionID, students); err != nil {
+		if errors.Is(err, interfaces.ErrSessionNotFound) {
+			return nil, effective, ErrSessionEnded
+		}
+		return nil, effective, fmt.Errorf("failed to update session students: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, stillActive := m.activeSessions[sessionID]
+	if !stillActive {
+		return nil, effective, ErrSessionEnded
+	}
+	for _, studentID := range effective.Remove {
+		if sessions, ok := m.studentSessions[studentID]; ok {
+			delete(sessions, sessionID)
+			if len(sessions) == 0 {
+				delete(m.studentSessions, studentID)
+			}
+		}
+		if entry, held := m.delegations[sessionID][studentID]; held {
+			entry.timer.Stop()
+			m.deleteDelegationLocked(sessionID, studentID)
+		}
+	}
+	// Work on a copy of the current entry so a concurrent lock, duration change or transfer is kept
+	updated := *current
+	updated.StudentIDs = students
+	m.addActiveSessionLocked(&updated)
+
+	log.Printf("Updated session roster: id=%s added=%d removed=%d students=%d", sessionID, len(effective.Add), len(effective.Remove), len(students))
+	return &updated, effective, nil
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestUpdateRoster(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+	var _ interfaces.RosterEditor = manager
+
+	created, err := manager.CreateSession(ctx, "Biology", "instructor1", []string{"student1", "student2"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	if _, err := manager.GrantDelegation(ctx, created.ID, "instructor1", "student2", []string{types.MessageTypeInstructorBroadcast}, 10*time.Minute); err != nil {
+		t.Fatalf("GrantDelegation should succeed: %v", err)
+	}
+
+	// A dry run reports the effective change and writes nothing
+	change := types.RosterChange{Add: []string{"student1", "student3", "student3"}, Remove: []string{"student2", "student9"}}
+	preview, effective, err := manager.UpdateRoster(ctx, created.ID, change, true)
+	if err != nil {
+		t.Fatalf("Dry run should succeed: %v", err)
+	}
+	if fmt.Sprint(effective.Add, effective.Remove) != "[student3] [student2]" || fmt.Sprint(preview.StudentIDs) != "[student1 student3]" {
+		t.Errorf("Unexpected dry run: %v, %+v", preview.StudentIDs, effective)
+	}
+	if stored, _ := mockDB.GetSession(ctx, created.ID); len(stored.StudentIDs) != 2 || stored.StudentIDs[1] != "student2" {
+		t.Errorf("A dry run should not persist, got %v", stored.StudentIDs)
+	}
+
+	updated, _, err := manager.UpdateRoster(ctx, created.ID, change, false)
+	if err != nil || fmt.Sprint(updated.StudentIDs) != "[student1 student3]" {
+		t.Fatalf("UpdateRoster should succeed, got %+v, %v", updated, err)
+	}
+	if stored, _ := mockDB.GetSession(ctx, created.ID); fmt.Sprint(stored.StudentIDs) != "[student1 student3]" {
+		t.Errorf("Roster should be persisted, got %v", stored.StudentIDs)
+	}
+	if err := manager.ValidateSessionMembership(created.ID, "student3", "student"); err != nil {
+		t.Errorf("Expected an added student to join, got %v", err)
+	}
+	if err := manager.ValidateSessionMembership(created.ID, "student2", "student"); err == nil {
+		t.Error("Expected a removed student refused")
+	}
+	if listed, _ := manager.ListUserSessions(ctx, "student2", "student"); len(listed) != 0 {
+		t.Errorf("Expected no sessions listed for a removed student, got %d", len(listed))
+	}
+	if len(manager.ListDelegations(created.ID)) != 0 {
+		t.Error("Expected a removed student's delegation dropped")
+	}
+
+	if _, _, err := manager.UpdateRoster(ctx, created.ID, types.RosterChange{Add: []string{"bad id!"}}, true); !errors.Is(err, ErrInvalidStudentID) {
+		t.Errorf("Expected ErrInvalidStudentID, got %v", err)
+	}
+	if _, _, err := manager.UpdateRoster(ctx, created.ID, types.RosterChange{Remove: []string{"student1", "student3"}}, true); !errors.Is(err, ErrEmptyStudentList) {
+		t.Errorf("Expected ErrEmptyStudentList, got %v", err)
+	}
+	manager.SetMaxStudents(2)
+	if _, _, err := manager.UpdateRoster(ctx, created.ID, types.RosterChange{Add: []string{"student4"}}, true); !errors.Is(err, ErrTooManyStudents) {
+		t.Errorf("Expected ErrTooManyStudents on a dry run too, got %v", err)
+	}
+
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, _, err := manager.UpdateRoster(ctx, created.ID, types.RosterChange{Add: []string{"student4"}}, false); !errors.Is(err, ErrSessionEnded) {
+		t.Errorf("Ended session: expected ErrSessionEnded, got %v", err)
+	}
+}
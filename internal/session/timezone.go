@@ -0,0 +1,69 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// ValidateTimezone checks that name is an IANA time zone this system can load
+// TECHNICAL DISCOVERY: time.LoadLocation accepts "" and "Local" as the server's own
+// zone, which would make exports depend on where the server runs, so both are rejected
+func ValidateTimezone(name string) error {
+	if name == "" || name == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
+
+// SetSessionTimezone sets the IANA time zone exports of an active session render in
+// FUNCTIONAL DISCOVERY: Only presentation changes - stored timestamps stay UTC and
+// every other reader keeps seeing UTC
+func (m *Manager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) (*types.Session, error) {
+	if err := ValidateTimezone(timezone); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	session, exists := m.activeSessions[sessionID]
+	m.mu.RUnlock()
+	if !exists {
+		if _, err := m.dbManager.GetSession(ctx, sessionID); err != nil {
+			return nil, ErrSessionNotFound
+		}
+		return nil, ErrSessionEnded
+	}
+	if session.Timezone == timezone {
+		unchanged := *session
+		return &unchanged, nil
+	}
+
+	if err := m.dbManager.SetSessionTimezone(ctx, sessionID, timezone); err != nil {
+		if errors.Is(err, interfaces.ErrSessionNotFound) {
+			return nil, ErrSessionEnded
+		}
+		return nil, fmt.Errorf("failed to update session time zone: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, stillActive := m.activeSessions[sessionID]
+	if !stillActive {
+		return nil, ErrSessionEnded
+	}
+	// Work on a copy of the current entry so a concurrent lock, duration change or transfer is kept
+	updated := *current
+	updated.Timezone = timezone
+	m.addActiveSessionLocked(&updated)
+
+	log.Printf("Set session time zone: id=%s timezone=%s", sessionID, timezone)
+	return &updated, nil
+}
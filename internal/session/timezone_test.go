@@ -0,0 +1,54 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// Functional Validation Tests
+func TestSetSessionTimezone(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	ctx := context.Background()
+
+	created, err := manager.CreateSession(ctx, "Biology", "instructor1", []string{"student1"})
+	if err != nil {
+		t.Fatalf("CreateSession should succeed: %v", err)
+	}
+	if created.Timezone != "UTC" {
+		t.Errorf("New sessions should default to UTC, got %q", created.Timezone)
+	}
+
+	for _, invalid := range []string{"", "Local", "Mars/Olympus"} {
+		if _, err := manager.SetSessionTimezone(ctx, created.ID, invalid); !errors.Is(err, ErrInvalidTimezone) {
+			t.Errorf("Zone %q: expected ErrInvalidTimezone, got %v", invalid, err)
+		}
+	}
+
+	updated, err := manager.SetSessionTimezone(ctx, created.ID, "America/Denver")
+	if err != nil || updated.Timezone != "America/Denver" {
+		t.Fatalf("SetSessionTimezone should succeed, got %+v, %v", updated, err)
+	}
+	if stored, _ := mockDB.GetSession(ctx, created.ID); stored.Timezone != "America/Denver" {
+		t.Error("Time zone should be persisted")
+	}
+
+	// A duration change keeps the zone
+	if extended, err := manager.SetSessionDuration(ctx, created.ID, 60); err != nil || extended.Timezone != "America/Denver" {
+		t.Errorf("Changing the duration should keep the zone, got %+v, %v", extended, err)
+	}
+	if cached, _ := manager.GetSession(ctx, created.ID); cached.Location().String() != "America/Denver" {
+		t.Errorf("Expected the cached session in America/Denver, got %s", cached.Location())
+	}
+
+	if err := manager.EndSession(ctx, created.ID); err != nil {
+		t.Fatalf("EndSession should succeed: %v", err)
+	}
+	if _, err := manager.SetSessionTimezone(ctx, created.ID, "UTC"); !errors.Is(err, ErrSessionEnded) {
+		t.Errorf("Ended session: expected ErrSessionEnded, got %v", err)
+	}
+	if _, err := manager.SetSessionTimezone(ctx, "missing", "UTC"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("Unknown session: expected ErrSessionNotFound, got %v", err)
+	}
+}
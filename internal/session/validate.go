@@ -0,0 +1,142 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"switchboard/pkg/types"
+)
+
+// createCheck is the outcome of checkCreate
+type createCheck struct {
+	report  *types.SessionValidation
+	roster  []string // Distinct student IDs, in submitted order
+	name    string   // Name after the name policy; empty when the policy refuses it
+	refusal error    // The error CreateSession fails with; nil when it would proceed
+	release func()   // Releases the reserved name and creator slot; always non-nil
+}
+
+// checkCreate validates a create request and fills in the dry-run report
+// ARCHITECTURAL DISCOVERY: The one validation path for both CreateSession and
+// ValidateSession, so a dry run can never disagree with the real call
+// FUNCTIONAL DISCOVERY: Every problem is reported; refusal is the first of them in the
+// order CreateSession has always checked: name, creator, creator policy, empty roster,
+// cap, student IDs, per-creator quota, then the name policy
+// TECHNICAL DISCOVERY: With reserve the quota and name policy are only applied once
+// everything else passed, so a refused create never touches the database or holds a
+// name or a creator slot
+func (m *Manager) checkCreate(ctx context.Context, name, createdBy string, studentIDs []string, reserve bool) (*createCheck, error) {
+	m.mu.RLock()
+	maxStudents := m.maxStudents
+	policy := m.namePolicy
+	m.mu.RUnlock()
+
+	report := &types.SessionValidation{
+		Errors:     []string{},
+		Name:       types.SessionNameCheck{Requested: name, Policy: string(policy)},
+		Students:   make([]types.StudentVerdict, 0, len(studentIDs)),
+		Duplicates: []string{},
+	}
+	check := &createCheck{report: report, release: func() {}}
+	var problems []error
+
+	validName := name != "" && len(name) <= maxSessionNameLength
+	if !validName {
+		problems = append(problems, ErrInvalidSessionName)
+	}
+	validCreator := types.IsValidUserID(createdBy)
+	if !validCreator {
+		problems = append(problems, ErrInvalidCreatedBy)
+	}
+	report.Creator.Allowed = validCreator && m.creatorAllowed(createdBy)
+	if validCreator && !report.Creator.Allowed {
+		problems = append(problems, fmt.Errorf("%w: %s is not on the creator allowlist", ErrCreatorNotAllowed, createdBy))
+	}
+	if len(studentIDs) == 0 {
+		problems = append(problems, ErrEmptyStudentList)
+	}
+
+	check.roster = removeDuplicates(studentIDs)
+	report.Capacity = types.CapacityCheck{
+		Students:    len(check.roster),
+		MaxStudents: maxStudents,
+		WithinLimit: len(check.roster) <= maxStudents,
+	}
+	if !report.Capacity.WithinLimit {
+		problems = append(problems, fmt.Errorf("%w: %d students, at most %d allowed", ErrTooManyStudents, len(check.roster), maxStudents))
+	}
+
+	seen := make(map[string]int, len(studentIDs)) // Student ID -> times submitted so far
+	for _, studentID := range studentIDs {
+		verdict := types.StudentVerdict{StudentID: studentID, Valid: types.IsValidUserID(studentID), Duplicate: seen[studentID] > 0}
+		if !verdict.Valid {
+			err := fmt.Errorf("%w: invalid student ID %s", ErrInvalidStudentID, studentID)
+			verdict.Error = err.Error()
+			if !verdict.Duplicate {
+				problems = append(problems, err)
+			}
+		}
+		if seen[studentID] == 1 {
+			report.Duplicates = append(report.Duplicates, studentID)
+		}
+		seen[studentID]++
+		report.Students = append(report.Students, verdict)
+	}
+
+	releaseSlot := func() {}
+	if validCreator && (!reserve || len(problems) == 0) {
+		active, limit, release, err := m.claimCreatorSlot(createdBy, reserve)
+		report.Creator.Active = active
+		report.Creator.MaxActive = limit
+		report.Creator.WithinQuota = err == nil
+		if err != nil {
+			problems = append(problems, err)
+		}
+		releaseSlot = release
+	}
+	check.release = releaseSlot
+
+	if validName && validCreator && (!reserve || len(problems) == 0) {
+		final, release, err := m.claimName(ctx, createdBy, name, reserve)
+		switch {
+		case errors.Is(err, ErrDuplicateName):
+			report.Name.Conflict = true
+			problems = append(problems, err)
+		case err != nil:
+			releaseSlot()
+			return nil, err
+		default:
+			check.name = final
+			check.release = func() {
+				release()
+				releaseSlot()
+			}
+			report.Name.Final = final
+			report.Name.Conflict = final != name
+		}
+	}
+
+	for _, problem := range problems {
+		report.Errors = append(report.Errors, problem.Error())
+	}
+	if len(problems) > 0 {
+		check.refusal = problems[0]
+	}
+	report.Valid = check.refusal == nil
+	return check, nil
+}
+
+// ValidateSession reports whether CreateSession would accept a request, without
+// creating or reserving anything
+// FUNCTIONAL DISCOVERY: Errors only when the check itself fails, e.g. the database
+// cannot list active session names; refusals are in the report
+// TECHNICAL DISCOVERY: A concurrent create may still take the name between a dry
+// run and the real call
+func (m *Manager) ValidateSession(ctx context.Context, name, createdBy string, studentIDs []string) (*types.SessionValidation, error) {
+	check, err := m.checkCreate(ctx, name, createdBy, studentIDs, false)
+	if err != nil {
+		return nil, err
+	}
+	return check.report, nil
+}
@@ -0,0 +1,126 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Functional Validation Tests
+func TestValidateSession_Report(t *testing.T) {
+	mockDB := newMockDatabaseManager()
+	manager := NewManager(mockDB)
+	manager.SetNamePolicy(NamePolicySuffix)
+	manager.SetMaxStudents(3)
+	ctx := context.Background()
+
+	if _, err := manager.CreateSession(ctx, "Math - Review Session", "instructor1", []string{"student1"}); err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	report, err := manager.ValidateSession(ctx, "Math - Review Session", "instructor1",
+		[]string{"student1", "bad id", "student2", "student1", "bad id", "student3", "student4"})
+	if err != nil {
+		t.Fatalf("ValidateSession failed: %v", err)
+	}
+	if report.Valid {
+		t.Error("Expected an invalid report")
+	}
+
+	wantDuplicate := []bool{false, false, false, true, true, false, false}
+	for i, verdict := range report.Students {
+		if verdict.Duplicate != wantDuplicate[i] {
+			t.Errorf("Entry %d (%s): expected duplicate=%v", i, verdict.StudentID, wantDuplicate[i])
+		}
+		if verdict.Valid == (verdict.StudentID == "bad id") {
+			t.Errorf("Entry %d (%s): wrong verdict %+v", i, verdict.StudentID, verdict)
+		}
+	}
+	if len(report.Students) != 7 {
+		t.Fatalf("Expected one verdict per submitted ID, got %d", len(report.Students))
+	}
+	if !reflect.DeepEqual(report.Duplicates, []string{"student1", "bad id"}) {
+		t.Errorf("Expected each duplicate listed once, got %v", report.Duplicates)
+	}
+	if report.Capacity.Students != 5 || report.Capacity.MaxStudents != 3 || report.Capacity.WithinLimit {
+		t.Errorf("Expected 5 distinct students over a cap of 3, got %+v", report.Capacity)
+	}
+	if !report.Name.Conflict || report.Name.Final != "Math - Review Session (2)" || report.Name.Policy != "suffix" {
+		t.Errorf("Expected the suffix policy to rename, got %+v", report.Name)
+	}
+	// The cap, then the invalid ID once; a rename is not an error
+	if len(report.Errors) != 2 || !strings.Contains(report.Errors[0], ErrTooManyStudents.Error()) {
+		t.Errorf("Expected the cap and one invalid ID, got %v", report.Errors)
+	}
+
+	// Nothing was created or reserved
+	if len(mockDB.sessions) != 1 || len(manager.reservedNames) != 0 {
+		t.Errorf("Dry run left %d sessions and %d reserved names", len(mockDB.sessions), len(manager.reservedNames))
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: The dry run agrees with CreateSession on every request
+func TestValidateSession_AgreesWithCreate(t *testing.T) {
+	long := strings.Repeat("x", 201)
+	requests := []struct {
+		name       string
+		session    string
+		createdBy  string
+		studentIDs []string
+	}{
+		{"valid", "Lab", "instructor1", []string{"student1", "student2"}},
+		{"duplicates only", "Lab 2", "instructor1", []string{"student1", "student1"}},
+		{"empty name", "", "instructor1", []string{"student1"}},
+		{"long name", long, "instructor1", []string{"student1"}},
+		{"bad creator", "Lab", "instructor 1", []string{"student1"}},
+		{"no students", "Lab", "instructor1", nil},
+		{"over cap", "Lab", "instructor1", []string{"s1", "s2", "s3"}},
+		{"bad student", "Lab", "instructor1", []string{"student1", "student@2"}},
+		{"duplicate name", "Taken", "instructor1", []string{"student1"}},
+		{"duplicate name and bad student", "Taken", "instructor1", []string{"student@2"}},
+	}
+
+	for _, tc := range requests {
+		t.Run(tc.name, func(t *testing.T) {
+			manager := NewManager(newMockDatabaseManager())
+			manager.SetNamePolicy(NamePolicyUniqueActive)
+			manager.SetMaxStudents(2)
+			ctx := context.Background()
+			if _, err := manager.CreateSession(ctx, "Taken", "instructor1", []string{"student1"}); err != nil {
+				t.Fatalf("CreateSession failed: %v", err)
+			}
+
+			report, err := manager.ValidateSession(ctx, tc.session, tc.createdBy, tc.studentIDs)
+			if err != nil {
+				t.Fatalf("ValidateSession failed: %v", err)
+			}
+			_, createErr := manager.CreateSession(ctx, tc.session, tc.createdBy, tc.studentIDs)
+			if report.Valid != (createErr == nil) {
+				t.Fatalf("Dry run said valid=%v, CreateSession returned %v", report.Valid, createErr)
+			}
+			if createErr != nil && report.Errors[0] != createErr.Error() {
+				t.Errorf("Expected the first reported error to be %q, got %v", createErr, report.Errors)
+			}
+		})
+	}
+}
+
+func TestValidateSession_DatabaseError(t *testing.T) {
+	manager := NewManager(&failingNamesDB{newMockDatabaseManager()})
+	manager.SetNamePolicy(NamePolicyUniqueActive)
+
+	if _, err := manager.ValidateSession(context.Background(), "Lab", "instructor1", []string{"student1"}); err == nil {
+		t.Error("Expected a failed name lookup to fail the dry run")
+	}
+}
+
+// failingNamesDB fails every active session name lookup
+type failingNamesDB struct {
+	*mockDatabaseManager
+}
+
+func (f *failingNamesDB) ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error) {
+	return nil, errors.New("database unavailable")
+}
@@ -0,0 +1,10 @@
+package snapshot
+
+import "errors"
+
+// Snapshot errors
+var (
+	ErrUnsupportedVersion   = errors.New("unsupported snapshot version")
+	ErrWriterAlreadyRunning = errors.New("snapshot writer is already running")
+	ErrWriterNotRunning     = errors.New("snapshot writer is not running")
+)
@@ -0,0 +1,139 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// FormatVersion identifies the snapshot file layout
+// TECHNICAL DISCOVERY: Bumped whenever a section changes meaning; readers refuse
+// versions they do not know instead of importing half-understood state
+const FormatVersion = 1
+
+// State is the warm-standby image of a running server
+// ARCHITECTURAL DISCOVERY: Only in-flight state that a standby cannot rebuild from its
+// own database belongs here. Today that is the active sessions; message history stays
+// in the database and is not duplicated
+type State struct {
+	Version  int              `json:"version"`
+	TakenAt  time.Time        `json:"taken_at"`
+	Sessions []*types.Session `json:"sessions"`
+}
+
+// SessionSource lists the sessions a snapshot captures
+type SessionSource interface {
+	ListActiveSessions(ctx context.Context) ([]*types.Session, error)
+}
+
+// SessionStore receives sessions during a restore
+type SessionStore interface {
+	GetSession(ctx context.Context, sessionID string) (*types.Session, error)
+	CreateSession(ctx context.Context, session *types.Session) error
+}
+
+// Capture builds a snapshot of the active sessions in source
+func Capture(ctx context.Context, source SessionSource) (*State, error) {
+	sessions, err := source.ListActiveSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active sessions: %w", err)
+	}
+	return &State{
+		Version:  FormatVersion,
+		TakenAt:  time.Now().UTC(),
+		Sessions: sessions,
+	}, nil
+}
+
+// WriteFile stores state at path
+// TECHNICAL DISCOVERY: Writes a temporary file in the same directory and renames it
+// over path, so a crash mid-write leaves the previous snapshot intact
+func WriteFile(path string, state *State) error {
+	data, err := json.Marshal(state) // Compact: no indentation
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+	return nil
+}
+
+// ReadFile loads a snapshot written by WriteFile
+func ReadFile(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	if state.Version != FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, state.Version)
+	}
+	return &state, nil
+}
+
+// Restore imports the snapshot's sessions into store and returns how many were added
+// FUNCTIONAL DISCOVERY: Sessions the store already knows are left alone - the standby's
+// own database is newer than any snapshot, including a session it has since ended.
+// Session IDs, owners and start times are preserved, so reconnecting clients keep
+// their session IDs and expiry timers resume where the primary left off
+func Restore(ctx context.Context, store SessionStore, state *State) (int, error) {
+	restored := 0
+	for _, session := range state.Sessions {
+		if session == nil || session.ID == "" {
+			continue
+		}
+
+		_, err := store.GetSession(ctx, session.ID)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, interfaces.ErrSessionNotFound) {
+			return restored, fmt.Errorf("failed to check session %s: %w", session.ID, err)
+		}
+
+		if err := store.CreateSession(ctx, session); err != nil {
+			return restored, fmt.Errorf("failed to restore session %s: %w", session.ID, err)
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// RestoreFile reads the snapshot at path and imports it into store
+func RestoreFile(ctx context.Context, store SessionStore, path string) (int, error) {
+	state, err := ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return Restore(ctx, store, state)
+}
@@ -0,0 +1,172 @@
+package snapshot
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// memoryStore is an in-memory session source and store
+type memoryStore struct {
+	mu       sync.Mutex
+	sessions map[string]*types.Session
+}
+
+func newMemoryStore(sessions ...*types.Session) *memoryStore {
+	store := &memoryStore{sessions: make(map[string]*types.Session)}
+	for _, session := range sessions {
+		store.sessions[session.ID] = session
+	}
+	return store
+}
+
+func (m *memoryStore) ListActiveSessions(ctx context.Context) ([]*types.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var active []*types.Session
+	for _, session := range m.sessions {
+		if session.Status == "active" {
+			active = append(active, session)
+		}
+	}
+	return active, nil
+}
+
+func (m *memoryStore) GetSession(ctx context.Context, sessionID string) (*types.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if session, exists := m.sessions[sessionID]; exists {
+		return session, nil
+	}
+	return nil, interfaces.ErrSessionNotFound
+}
+
+func (m *memoryStore) CreateSession(ctx context.Context, session *types.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[session.ID] = session
+	return nil
+}
+
+func testSession(id string, status types.SessionState) *types.Session {
+	return &types.Session{
+		ID:              id,
+		Name:            "Session " + id,
+		CreatedBy:       "teacher1",
+		OwnerID:         "teacher2",
+		StudentIDs:      []string{"student1", "student2"},
+		StartTime:       time.Now().Add(-10 * time.Minute).UTC().Truncate(time.Second),
+		Status:          status,
+		DurationMinutes: 50,
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: A written snapshot restores active sessions unchanged
+func TestSnapshot_RoundTrip(t *testing.T) {
+	primary := newMemoryStore(testSession("s1", "active"), testSession("s2", "ended"))
+	state, err := Capture(context.Background(), primary)
+	if err != nil {
+		t.Fatalf("Capture failed: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := WriteFile(path, state); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	standby := newMemoryStore()
+	restored, err := RestoreFile(context.Background(), standby, path)
+	if err != nil {
+		t.Fatalf("RestoreFile failed: %v", err)
+	}
+	if restored != 1 {
+		t.Fatalf("Expected only the active session restored, got %d", restored)
+	}
+
+	original, copied := primary.sessions["s1"], standby.sessions["s1"]
+	if copied == nil {
+		t.Fatal("Active session missing after restore")
+	}
+	if copied.Owner() != original.Owner() || copied.CreatedBy != original.CreatedBy ||
+		!copied.StartTime.Equal(original.StartTime) || copied.DurationMinutes != original.DurationMinutes ||
+		len(copied.StudentIDs) != len(original.StudentIDs) {
+		t.Errorf("Restored session differs: got %+v, want %+v", copied, original)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Sessions the standby already has are never overwritten
+func TestSnapshot_RestoreKeepsExistingSessions(t *testing.T) {
+	ended := testSession("s1", "ended")
+	standby := newMemoryStore(ended)
+
+	state := &State{Version: FormatVersion, Sessions: []*types.Session{testSession("s1", "active"), testSession("s3", "active")}}
+	restored, err := Restore(context.Background(), standby, state)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if restored != 1 {
+		t.Errorf("Expected 1 new session, got %d", restored)
+	}
+	if standby.sessions["s1"] != ended {
+		t.Error("Existing session should not be replaced by the snapshot copy")
+	}
+
+	// Re-running the same restore is a no-op
+	if restored, _ := Restore(context.Background(), standby, state); restored != 0 {
+		t.Errorf("Second restore should add nothing, added %d", restored)
+	}
+}
+
+// TECHNICAL VALIDATION TEST: Unknown versions and corrupt files are refused
+func TestSnapshot_ReadFileRejectsUnknownFormat(t *testing.T) {
+	dir := t.TempDir()
+
+	future := filepath.Join(dir, "future.json")
+	os.WriteFile(future, []byte(`{"version": 99, "sessions": []}`), 0o644)
+	if _, err := ReadFile(future); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("Expected ErrUnsupportedVersion, got %v", err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	os.WriteFile(corrupt, []byte(`{"version": 1, "sess`), 0o644)
+	if _, err := ReadFile(corrupt); err == nil {
+		t.Error("Truncated snapshot should fail to decode")
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Stop writes a final snapshot without waiting for a tick
+func TestWriter_FinalSnapshotOnStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	store := newMemoryStore(testSession("s1", "active"))
+
+	writer := NewWriter(path, time.Hour, store)
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := writer.Start(context.Background()); !errors.Is(err, ErrWriterAlreadyRunning) {
+		t.Errorf("Expected ErrWriterAlreadyRunning, got %v", err)
+	}
+
+	store.CreateSession(context.Background(), testSession("s2", "active"))
+	if err := writer.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := writer.Stop(); !errors.Is(err, ErrWriterNotRunning) {
+		t.Errorf("Expected ErrWriterNotRunning, got %v", err)
+	}
+
+	state, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if len(state.Sessions) != 2 {
+		t.Errorf("Expected final snapshot with 2 sessions, got %d", len(state.Sessions))
+	}
+}
@@ -0,0 +1,118 @@
+package snapshot
+
+import (
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Writer periodically captures a snapshot for a warm standby
+// FUNCTIONAL DISCOVERY: Best-effort like transcripts - a failed capture or write is
+// logged and retried on the next tick, and the previous snapshot stays on disk
+type Writer struct {
+	path     string
+	interval time.Duration
+	source   SessionSource
+
+	shutdownChannel chan struct{} // Closed by Stop
+	done            chan struct{} // Closed when the run goroutine exits
+
+	running bool
+	mu      sync.Mutex
+
+	writing atomic.Bool // A snapshot is being captured or written
+}
+
+// NewWriter creates a writer that snapshots source to path every interval
+func NewWriter(path string, interval time.Duration, source SessionSource) *Writer {
+	return &Writer{
+		path:            path,
+		interval:        interval,
+		source:          source,
+		shutdownChannel: make(chan struct{}),
+		done:            make(chan struct{}),
+	}
+}
+
+// Start creates the snapshot directory and begins periodic snapshots
+func (w *Writer) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return ErrWriterAlreadyRunning
+	}
+	if err := os.MkdirAll(filepath.Dir(w.path), 0o755); err != nil {
+		return err
+	}
+	w.running = true
+
+	log.Printf("Writing state snapshots to %s every %s", w.path, w.interval)
+	go w.run(ctx)
+	return nil
+}
+
+// Stop ends periodic snapshots after writing a final one
+// TECHNICAL DISCOVERY: The final snapshot runs before the database closes, so a
+// planned switchover loses nothing created since the last tick. Cancelling the start
+// context writes the same final snapshot, since shutdown usually cancels before Stop
+func (w *Writer) Stop() error {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return ErrWriterNotRunning
+	}
+	w.running = false
+	close(w.shutdownChannel)
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+func (w *Writer) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.snapshot(ctx)
+
+		case <-w.shutdownChannel:
+			w.snapshot(context.Background())
+			return
+
+		case <-ctx.Done():
+			w.snapshot(context.Background())
+			return
+		}
+	}
+}
+
+// InProgress reports whether a snapshot is being taken right now
+// FUNCTIONAL DISCOVERY: Database maintenance refuses to start meanwhile, so a vacuum
+// never holds the database while the standby's copy is being read from it
+func (w *Writer) InProgress() bool {
+	return w.writing.Load()
+}
+
+// snapshot captures and writes one snapshot, logging any failure
+func (w *Writer) snapshot(ctx context.Context) {
+	w.writing.Store(true)
+	defer w.writing.Store(false)
+
+	state, err := Capture(ctx, w.source)
+	if err == nil {
+		err = WriteFile(w.path, state)
+	}
+	if err != nil {
+		log.Printf("WARNING: State snapshot failed: %v", err)
+	}
+}
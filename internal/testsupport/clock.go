@@ -0,0 +1,169 @@
+package testsupport
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"switchboard/pkg/interfaces"
+)
+
+// FakeClock is an interfaces.Clock that only moves when Advance is called
+// ARCHITECTURAL DISCOVERY: Lets tests of rate limit windows, session durations and
+// throttles cover minutes of behavior in microseconds, without sleeping
+// TECHNICAL DISCOVERY: AfterFunc callbacks run synchronously inside Advance, after
+// the clock's lock is released, so when Advance returns everything due has happened
+// and callbacks may create, stop or reset timers themselves
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	pending []*fakeTimer
+}
+
+// NewFakeClock creates a fake clock reading start
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start}
+}
+
+// Now returns the fake time
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel that receives once the clock has advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// NewTimer creates a timer that fires once the clock has advanced by d
+func (c *FakeClock) NewTimer(d time.Duration) interfaces.Timer {
+	return c.schedule(&fakeTimer{clock: c, ch: make(chan time.Time, 1)}, d)
+}
+
+// NewTicker creates a ticker that fires every d of advanced time
+// FUNCTIONAL DISCOVERY: Like time.Ticker, ticks the reader has not taken yet are dropped
+func (c *FakeClock) NewTicker(d time.Duration) interfaces.Ticker {
+	if d <= 0 {
+		panic("testsupport: non-positive interval for NewTicker")
+	}
+	return fakeTicker{c.schedule(&fakeTimer{clock: c, ch: make(chan time.Time, 1), period: d}, d)}
+}
+
+// AfterFunc arranges for f to run within the Advance call that reaches d from now
+// TECHNICAL DISCOVERY: A non-positive d is due at once but still waits for the next
+// Advance, even Advance(0); running f here could deadlock a caller holding its own lock
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) interfaces.Timer {
+	return c.schedule(&fakeTimer{clock: c, fn: f}, d)
+}
+
+// Advance moves the clock forward by d, firing everything that falls due in order
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		if len(c.pending) == 0 || c.pending[0].due.After(target) {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+		timer := c.pending[0]
+		c.pending = c.pending[1:]
+		if timer.due.After(c.now) {
+			c.now = timer.due
+		}
+		firedAt := c.now
+		if timer.period > 0 {
+			timer.due = timer.due.Add(timer.period)
+			c.insertLocked(timer)
+		}
+		c.mu.Unlock()
+
+		if timer.fn != nil {
+			timer.fn()
+			continue
+		}
+		select {
+		case timer.ch <- firedAt:
+		default:
+		}
+	}
+}
+
+// Pending returns how many timers, tickers and AfterFunc calls are waiting to fire
+// FUNCTIONAL DISCOVERY: Lets a test wait until a goroutine under test has started
+// waiting before it advances the clock
+func (c *FakeClock) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.pending)
+}
+
+func (c *FakeClock) schedule(timer *fakeTimer, d time.Duration) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer.due = c.now.Add(d)
+	c.insertLocked(timer)
+	return timer
+}
+
+// insertLocked keeps pending ordered by due time, earlier timers first on ties;
+// caller holds c.mu
+func (c *FakeClock) insertLocked(timer *fakeTimer) {
+	i := sort.Search(len(c.pending), func(i int) bool { return c.pending[i].due.After(timer.due) })
+	c.pending = append(c.pending, nil)
+	copy(c.pending[i+1:], c.pending[i:])
+	c.pending[i] = timer
+}
+
+// removeLocked drops timer from pending and reports whether it was there; caller holds c.mu
+func (c *FakeClock) removeLocked(timer *fakeTimer) bool {
+	for i, pending := range c.pending {
+		if pending == timer {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTimer is a FakeClock timer, ticker or AfterFunc registration
+type fakeTimer struct {
+	clock  *FakeClock
+	due    time.Time
+	period time.Duration // Non-zero for tickers
+	ch     chan time.Time
+	fn     func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.removeLocked(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.removeLocked(t)
+	t.due = t.clock.now.Add(d)
+	t.clock.insertLocked(t)
+	return active
+}
+
+// fakeTicker adapts a periodic fakeTimer to interfaces.Ticker
+type fakeTicker struct {
+	*fakeTimer
+}
+
+func (t fakeTicker) Stop() {
+	t.fakeTimer.Stop()
+}
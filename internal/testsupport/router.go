@@ -0,0 +1,77 @@
+package testsupport
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// RouteCall is one RouteMessage invocation seen by a RecordingRouter
+type RouteCall struct {
+	Message types.Message // Copy taken when the call was made
+	Sender  interfaces.ConnectionInfo
+	Context context.Context // Carries per-message values such as router.WithDiagnostics
+}
+
+// RecordingRouter is a MessageRouter that records every call and returns a
+// scripted result
+// ARCHITECTURAL DISCOVERY: Lets hub tests assert what reached the router - enrichment,
+// sender, ordering - and drive the error path without SQLite or live recipients
+type RecordingRouter struct {
+	mu     sync.Mutex
+	calls  []RouteCall
+	result types.RouteResult
+	err    error
+}
+
+// NewRecordingRouter creates a router fake that accepts every message
+func NewRecordingRouter() *RecordingRouter {
+	return &RecordingRouter{}
+}
+
+// SetResult scripts what later RouteMessage calls return
+// FUNCTIONAL DISCOVERY: The result's MessageID is replaced with each message's ID
+func (r *RecordingRouter) SetResult(result types.RouteResult, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result = result
+	r.err = err
+}
+
+// RouteMessage records the call and returns the scripted result
+func (r *RecordingRouter) RouteMessage(ctx context.Context, message *types.Message, sender interfaces.ConnectionInfo) (types.RouteResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, RouteCall{Message: *message, Sender: sender, Context: ctx})
+
+	result := r.result
+	result.MessageID = message.ID
+	return result, r.err
+}
+
+// Calls returns the calls recorded so far in order
+func (r *RecordingRouter) Calls() []RouteCall {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RouteCall(nil), r.calls...)
+}
+
+// WaitForCalls waits until at least n calls were recorded and returns them
+// TECHNICAL DISCOVERY: The hub routes on its own goroutine, so tests poll instead of
+// reading Calls right after SendMessage; ok is false if the timeout passed first
+func (r *RecordingRouter) WaitForCalls(n int, timeout time.Duration) (calls []RouteCall, ok bool) {
+	deadline := time.Now().Add(timeout)
+	for {
+		calls = r.Calls()
+		if len(calls) >= n {
+			return calls, true
+		}
+		if time.Now().After(deadline) {
+			return calls, false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
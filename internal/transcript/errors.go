@@ -0,0 +1,9 @@
+package transcript
+
+import "errors"
+
+// Transcript writer lifecycle errors
+var (
+	ErrWriterAlreadyRunning = errors.New("transcript writer is already running")
+	ErrWriterNotRunning     = errors.New("transcript writer is not running")
+)
@@ -0,0 +1,88 @@
+package transcript
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// FlattenContent renders a message payload as a single line of plain text
+// ARCHITECTURAL DISCOVERY: Shared by every plain-text output (transcript files,
+// exports) so the same message always reads the same way regardless of destination
+// FUNCTIONAL DISCOVERY: A "text" field is the human-readable body and comes first;
+// remaining fields follow as sorted key=value pairs, nested keys joined with dots
+func FlattenContent(content map[string]interface{}) string {
+	var parts []string
+	if text, ok := content["text"].(string); ok {
+		parts = append(parts, singleLine(text))
+	}
+
+	var pairs []string
+	flattenInto(&pairs, "", content)
+	sort.Strings(pairs)
+
+	return strings.Join(append(parts, pairs...), " ")
+}
+
+// flattenInto appends key=value pairs for every leaf value under prefix
+func flattenInto(pairs *[]string, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if prefix == "" && key == "text" {
+				if _, isString := item.(string); isString {
+					continue // Already rendered as the message body
+				}
+			}
+			flattenInto(pairs, contentPath(prefix, key), item)
+		}
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = singleLine(fmt.Sprint(item))
+		}
+		*pairs = append(*pairs, prefix+"=["+strings.Join(items, ",")+"]")
+	case nil:
+		*pairs = append(*pairs, prefix+"=null")
+	default:
+		*pairs = append(*pairs, prefix+"="+singleLine(fmt.Sprint(v)))
+	}
+}
+
+// contentPath names key under prefix the way flattened content and scrub rules do,
+// nested keys joined with dots
+func contentPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// singleLine collapses line breaks and tabs so one message stays on one line
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// FormatLine renders one message as a tab-separated transcript line
+// FUNCTIONAL DISCOVERY: Fields are timestamp, type, from, to, text; broadcasts and
+// instructor-wide messages without a recipient use "*" in the to column, and targeted
+// broadcasts list their recipients separated by commas
+func FormatLine(message *types.Message) string {
+	to := "*"
+	switch {
+	case message.ToUser != nil:
+		to = *message.ToUser
+	case len(message.ToUsers) > 0:
+		to = strings.Join(message.ToUsers, ",")
+	}
+	return strings.Join([]string{
+		message.Timestamp.UTC().Format(time.RFC3339Nano),
+		message.Type,
+		message.FromUser,
+		to,
+		FlattenContent(message.Content),
+	}, "\t")
+}
@@ -0,0 +1,60 @@
+package transcript
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestFormat_FlattenContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  map[string]interface{}
+		expected string
+	}{
+		{"text only", map[string]interface{}{"text": "hello"}, "hello"},
+		{"text with line breaks", map[string]interface{}{"text": "line one\n\tline two"}, "line one line two"},
+		{"text first then sorted fields", map[string]interface{}{"text": "Q", "line": float64(12), "file": "main.go"}, "Q file=main.go line=12"},
+		{"nested and arrays", map[string]interface{}{"code": map[string]interface{}{"lang": "go"}, "tags": []interface{}{"a", "b"}}, "code.lang=go tags=[a,b]"},
+		{"null value", map[string]interface{}{"answer": nil}, "answer=null"},
+		{"non-string text field", map[string]interface{}{"text": float64(5)}, "text=5"},
+		{"empty", map[string]interface{}{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if flattened := FlattenContent(tt.content); flattened != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, flattened)
+			}
+		})
+	}
+}
+
+func TestFormat_FormatLine(t *testing.T) {
+	toUser := "student1"
+	message := &types.Message{
+		Type:      types.MessageTypeInboxResponse,
+		FromUser:  "instructor1",
+		ToUser:    &toUser,
+		Content:   map[string]interface{}{"text": "See line 4"},
+		Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	expected := "2024-01-01T10:00:00Z\tinbox_response\tinstructor1\tstudent1\tSee line 4"
+	if line := FormatLine(message); line != expected {
+		t.Errorf("Expected %q, got %q", expected, line)
+	}
+
+	message.ToUser = nil
+	if fields := strings.Split(FormatLine(message), "\t"); fields[3] != "*" {
+		t.Errorf("Expected '*' recipient for messages without to_user, got %q", fields[3])
+	}
+
+	message.ToUsers = []string{"student1", "student2"}
+	if fields := strings.Split(FormatLine(message), "\t"); fields[3] != "student1,student2" {
+		t.Errorf("Expected a targeted broadcast's recipients, got %q", fields[3])
+	}
+}
@@ -0,0 +1,220 @@
+package transcript
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"regexp"
+	"sort"
+	"strings"
+
+	"switchboard/pkg/types"
+)
+
+// ScrubReplacement stands in for content text a scrub pattern matched
+const ScrubReplacement = "[redacted]"
+
+// ScrubRules says which content is scrubbed in a pseudonymized export and how
+// FUNCTIONAL DISCOVERY: Fields are content paths named as FlattenContent names them,
+// nested keys joined with dots; a field covers every string under it. Inside those
+// fields Patterns matches become ScrubReplacement and known student IDs become their
+// pseudonyms
+type ScrubRules struct {
+	Fields   []string
+	Patterns []*regexp.Regexp
+}
+
+// Pseudonymizer replaces student IDs with pseudonyms ("Student A", "Student B", ...)
+// for one export and scrubs the content fields its rules name
+// ARCHITECTURAL DISCOVERY: One per export, so a student is the same pseudonym all
+// through it. The order pseudonyms are handed out in comes from an HMAC of each ID
+// keyed by the seed; without a seed the key is random, so two exports cannot be joined
+// on pseudonyms unless the caller asked for that by passing the same seed
+// FUNCTIONAL DISCOVERY: Rostered students are named first; other IDs met in messages,
+// such as students removed from the roster, are named in the order they appear. IDs
+// keep reports, system senders and API key users are left alone
+// TECHNICAL DISCOVERY: Not safe for concurrent use; exports write from one goroutine
+type Pseudonymizer struct {
+	key      []byte
+	keep     func(userID string) bool
+	rules    ScrubRules
+	names    map[string]string // User ID -> pseudonym
+	ids      *regexp.Regexp    // Named IDs as whole words, rebuilt when one is added
+	idsStale bool
+}
+
+// NewPseudonymizer names students in an order derived from seed, or a random order
+// when seed is empty
+func NewPseudonymizer(seed string, students []string, keep func(userID string) bool, rules ScrubRules) *Pseudonymizer {
+	key := []byte(seed)
+	if seed == "" {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key) // Never fails on supported platforms
+	}
+	p := &Pseudonymizer{key: key, keep: keep, rules: rules, names: make(map[string]string, len(students))}
+
+	ordered := make([]string, 0, len(students))
+	digests := make(map[string]string, len(students))
+	for _, studentID := range students {
+		if _, seen := digests[studentID]; seen || p.kept(studentID) {
+			continue
+		}
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(studentID))
+		digests[studentID] = string(mac.Sum(nil))
+		ordered = append(ordered, studentID)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return digests[ordered[i]] < digests[ordered[j]] })
+	for _, studentID := range ordered {
+		p.name(studentID)
+	}
+	return p
+}
+
+func (p *Pseudonymizer) kept(userID string) bool {
+	return userID == "" || types.IsReservedUserID(userID) || strings.HasPrefix(userID, types.APIKeyUserPrefix) || (p.keep != nil && p.keep(userID))
+}
+
+// name hands userID the next pseudonym
+func (p *Pseudonymizer) name(userID string) string {
+	pseudonym := "Student " + pseudonymLetters(len(p.names))
+	p.names[userID] = pseudonym
+	p.idsStale = true
+	return pseudonym
+}
+
+// pseudonymLetters numbers like spreadsheet columns: A..Z, AA..AZ, BA...
+func pseudonymLetters(n int) string {
+	letters := ""
+	for n++; n > 0; n = (n - 1) / 26 {
+		letters = string(rune('A'+(n-1)%26)) + letters
+	}
+	return letters
+}
+
+// User returns userID's pseudonym, or userID itself when it is kept
+func (p *Pseudonymizer) User(userID string) string {
+	if p.kept(userID) {
+		return userID
+	}
+	if pseudonym, named := p.names[userID]; named {
+		return pseudonym
+	}
+	return p.name(userID)
+}
+
+// Users returns the pseudonyms of userIDs, in the same order
+func (p *Pseudonymizer) Users(userIDs []string) []string {
+	if userIDs == nil {
+		return nil
+	}
+	pseudonyms := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		pseudonyms[i] = p.User(userID)
+	}
+	return pseudonyms
+}
+
+// Message returns a copy of message with its users pseudonymized and content scrubbed
+func (p *Pseudonymizer) Message(message *types.Message) *types.Message {
+	if message == nil {
+		return nil
+	}
+	scrubbed := *message
+	scrubbed.FromUser = p.User(message.FromUser)
+	if message.ToUser != nil {
+		toUser := p.User(*message.ToUser)
+		scrubbed.ToUser = &toUser
+	}
+	scrubbed.ToUsers = p.Users(message.ToUsers)
+	scrubbed.Content = p.Content(message.Content)
+	return &scrubbed
+}
+
+// Content returns a copy of content with the rules' fields scrubbed
+func (p *Pseudonymizer) Content(content map[string]interface{}) map[string]interface{} {
+	if content == nil {
+		return nil
+	}
+	scrubbed, _ := p.scrub("", content, false).(map[string]interface{})
+	return scrubbed
+}
+
+// scrub copies value, scrubbing strings at or under a rule's field
+func (p *Pseudonymizer) scrub(path string, value interface{}, inField bool) interface{} {
+	inField = inField || p.isField(path)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = p.scrub(contentPath(path, key), item, inField)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = p.scrub(path, item, inField)
+		}
+		return copied
+	case string:
+		if inField {
+			return p.scrubText(v)
+		}
+	}
+	return value
+}
+
+func (p *Pseudonymizer) isField(path string) bool {
+	for _, field := range p.rules.Fields {
+		if path == field {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubText replaces pattern matches, then named student IDs with pseudonyms
+// TECHNICAL DISCOVERY: Patterns go first so an ID inside a match, like the local part of
+// an email address, cannot keep the pattern from matching
+func (p *Pseudonymizer) scrubText(text string) string {
+	for _, pattern := range p.rules.Patterns {
+		text = pattern.ReplaceAllLiteralString(text, ScrubReplacement)
+	}
+	if p.idsStale {
+		p.rebuildIDs()
+	}
+	if p.ids != nil {
+		text = p.ids.ReplaceAllStringFunc(text, func(userID string) string { return p.names[userID] })
+	}
+	return text
+}
+
+// rebuildIDs matches every named ID as a whole word, longest first so an ID is not
+// matched inside a longer one
+func (p *Pseudonymizer) rebuildIDs() {
+	p.idsStale = false
+	if len(p.names) == 0 {
+		p.ids = nil
+		return
+	}
+	quoted := make([]string, 0, len(p.names))
+	for userID := range p.names {
+		quoted = append(quoted, regexp.QuoteMeta(userID))
+	}
+	sort.Slice(quoted, func(i, j int) bool {
+		if len(quoted[i]) != len(quoted[j]) {
+			return len(quoted[i]) > len(quoted[j])
+		}
+		return quoted[i] < quoted[j]
+	})
+	p.ids = regexp.MustCompile(`\b(?:` + strings.Join(quoted, "|") + `)\b`)
+}
+
+// Mapping returns each pseudonym handed out with the user ID behind it
+func (p *Pseudonymizer) Mapping() map[string]string {
+	mapping := make(map[string]string, len(p.names))
+	for userID, pseudonym := range p.names {
+		mapping[pseudonym] = userID
+	}
+	return mapping
+}
@@ -0,0 +1,110 @@
+package transcript
+
+import (
+	"regexp"
+	"testing"
+
+	"switchboard/pkg/types"
+)
+
+// Functional Validation Tests
+func TestPseudonymizer_StableWithinAndAcrossSeededExports(t *testing.T) {
+	students := []string{"alice", "bob", "carol", "dave"}
+	first := NewPseudonymizer("term-2026", students, nil, ScrubRules{})
+	second := NewPseudonymizer("term-2026", []string{"dave", "carol", "bob", "alice"}, nil, ScrubRules{})
+
+	named := make(map[string]bool)
+	for _, studentID := range students {
+		pseudonym := first.User(studentID)
+		if pseudonym != first.User(studentID) {
+			t.Errorf("Expected %s to keep one pseudonym within an export", studentID)
+		}
+		if pseudonym != second.User(studentID) {
+			t.Errorf("Expected the same seed to give %s the same pseudonym, got %q and %q", studentID, pseudonym, second.User(studentID))
+		}
+		named[pseudonym] = true
+	}
+	for _, pseudonym := range []string{"Student A", "Student B", "Student C", "Student D"} {
+		if !named[pseudonym] {
+			t.Errorf("Expected rostered students to be %s..D, got %v", pseudonym, first.Mapping())
+		}
+	}
+
+	differs := false
+	for _, seed := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		other := NewPseudonymizer(seed, students, nil, ScrubRules{})
+		for _, studentID := range students {
+			differs = differs || other.User(studentID) != first.User(studentID)
+		}
+	}
+	if !differs {
+		t.Error("Expected other seeds to hand out pseudonyms in another order")
+	}
+}
+
+func TestPseudonymizer_KeepsInstructorsAndSystemUsers(t *testing.T) {
+	isInstructor := func(userID string) bool { return userID == "teacher" }
+	p := NewPseudonymizer("", []string{"alice", "teacher"}, isInstructor, ScrubRules{})
+
+	for _, userID := range []string{"teacher", types.ReservedUserID, types.APIKeyUserPrefix + "grader", ""} {
+		if got := p.User(userID); got != userID {
+			t.Errorf("Expected %q to be kept, got %q", userID, got)
+		}
+	}
+	if got := p.User("alice"); got != "Student A" {
+		t.Errorf("Expected the only rostered student to be Student A, got %q", got)
+	}
+	if got := p.User("removed_student"); got != "Student B" {
+		t.Errorf("Expected a student met later to get the next pseudonym, got %q", got)
+	}
+	if mapping := p.Mapping(); len(mapping) != 2 || mapping["Student B"] != "removed_student" {
+		t.Errorf("Expected the mapping of both named students, got %v", mapping)
+	}
+}
+
+func TestPseudonymizer_ScrubsConfiguredFields(t *testing.T) {
+	rules := ScrubRules{
+		Fields:   []string{"text", "code.comment"},
+		Patterns: []*regexp.Regexp{regexp.MustCompile(`[a-z]+@example\.com`)},
+	}
+	isInstructor := func(userID string) bool { return userID == "teacher" }
+	p := NewPseudonymizer("seed", []string{"alice", "alice2"}, isInstructor, rules)
+	toUser := "alice"
+	message := &types.Message{
+		Type:     types.MessageTypeInboxResponse,
+		FromUser: "teacher",
+		ToUser:   &toUser,
+		Content: map[string]interface{}{
+			"text":  "alice and alice2, mail alice@example.com",
+			"code":  map[string]interface{}{"comment": []interface{}{"by alice2"}, "source": "alice := 1"},
+			"title": "alice",
+		},
+	}
+
+	scrubbed := p.Message(message)
+	alice, alice2 := p.User("alice"), p.User("alice2")
+	if *scrubbed.ToUser != alice || scrubbed.FromUser != "teacher" {
+		t.Errorf("Expected the recipient pseudonymized and the sender kept, got %s -> %s", scrubbed.FromUser, *scrubbed.ToUser)
+	}
+	if text := scrubbed.Content["text"]; text != alice+" and "+alice2+", mail "+ScrubReplacement {
+		t.Errorf("Expected IDs as whole words and emails scrubbed, got %q", text)
+	}
+	code := scrubbed.Content["code"].(map[string]interface{})
+	if comment := code["comment"].([]interface{})[0]; comment != "by "+alice2 {
+		t.Errorf("Expected strings under a nested field scrubbed, got %q", comment)
+	}
+	if code["source"] != "alice := 1" || scrubbed.Content["title"] != "alice" {
+		t.Errorf("Expected fields outside the rules left alone, got %v", scrubbed.Content)
+	}
+	if message.Content["text"] != "alice and alice2, mail alice@example.com" || *message.ToUser != "alice" {
+		t.Error("Expected the original message to be left unchanged")
+	}
+}
+
+func TestPseudonymizer_Letters(t *testing.T) {
+	for n, expected := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if letters := pseudonymLetters(n); letters != expected {
+			t.Errorf("Expected %d to be %s, got %s", n, expected, letters)
+		}
+	}
+}
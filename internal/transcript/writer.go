@@ -0,0 +1,324 @@
+package transcript
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Writer appends routed messages to one plain-text transcript file per session
+// ARCHITECTURAL DISCOVERY: A single writer goroutine owns every open file, mirroring
+// the hub's single-goroutine coordination, so no file needs its own lock
+// FUNCTIONAL DISCOVERY: Transcripts are best-effort - a full queue drops lines and a
+// write failure disables the writer, but routing is never blocked by disk I/O
+type Writer struct {
+	dir           string
+	flushInterval time.Duration
+	maxFileBytes  int64
+
+	events          chan event    // TECHNICAL DISCOVERY: 1000 buffer matches the hub message channel
+	shutdownChannel chan struct{} // Closed by Stop
+	done            chan struct{} // Closed when the run goroutine exits
+
+	files    map[string]*sessionFile // Owned by the run goroutine
+	disabled  atomic.Bool
+	dropped   atomic.Int64
+	openFiles atomic.Int64 // len(files), published by the run goroutine for stats
+
+	running bool
+	mu      sync.Mutex
+}
+
+// event is either a message to append or a session whose file should be closed
+type event struct {
+	message    *types.Message
+	endSession string
+}
+
+// sessionFile tracks one open transcript and its current size for rotation
+type sessionFile struct {
+	file   *os.File
+	writer *bufio.Writer
+	size   int64
+}
+
+// NewWriter creates a transcript writer for dir
+func NewWriter(dir string, flushInterval time.Duration, maxFileBytes int64) *Writer {
+	return &Writer{
+		dir:             dir,
+		flushInterval:   flushInterval,
+		maxFileBytes:    maxFileBytes,
+		events:          make(chan event, 1000),
+		shutdownChannel: make(chan struct{}),
+		done:            make(chan struct{}),
+		files:           make(map[string]*sessionFile),
+	}
+}
+
+// Start creates the transcript directory and begins processing
+func (w *Writer) Start(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.running {
+		return ErrWriterAlreadyRunning
+	}
+	if err := os.MkdirAll(w.dir, 0o755); err != nil {
+		w.disabled.Store(true) // Observers keep calling Record; make it a no-op
+		return fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	w.running = true
+
+	log.Printf("Writing session transcripts to %s", w.dir)
+	go w.run(ctx)
+	return nil
+}
+
+// Stop flushes and closes all transcript files
+// TECHNICAL DISCOVERY: Waits for the run goroutine so buffered lines reach disk
+// before the process exits
+func (w *Writer) Stop() error {
+	w.mu.Lock()
+	if !w.running {
+		w.mu.Unlock()
+		return ErrWriterNotRunning
+	}
+	w.running = false
+	close(w.shutdownChannel)
+	w.mu.Unlock()
+
+	<-w.done
+	return nil
+}
+
+// Record queues a persisted message for its session transcript
+// FUNCTIONAL DISCOVERY: Non-blocking by design - called on the routing path
+func (w *Writer) Record(message *types.Message) {
+	if w.disabled.Load() {
+		return
+	}
+
+	copied := *message // Shallow copy; content maps are not mutated after routing
+	select {
+	case w.events <- event{message: &copied}:
+	default:
+		if dropped := w.dropped.Add(1); dropped == 1 || dropped%100 == 0 {
+			log.Printf("WARNING: Transcript queue full, %d lines dropped so far", dropped)
+		}
+	}
+}
+
+// SessionEnded closes the transcript file for a finished session
+func (w *Writer) SessionEnded(sessionID string) {
+	if w.disabled.Load() {
+		return
+	}
+
+	select {
+	case w.events <- event{endSession: sessionID}:
+	default:
+		// File stays open until shutdown; harmless beyond one descriptor
+	}
+}
+
+// OpenFiles returns how many session transcripts are currently open
+func (w *Writer) OpenFiles() int {
+	return int(w.openFiles.Load())
+}
+
+// Disabled reports whether a write failure has turned transcripts off
+func (w *Writer) Disabled() bool {
+	return w.disabled.Load()
+}
+
+func (w *Writer) run(ctx context.Context) {
+	defer close(w.done)
+	defer w.closeAll()
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case ev := <-w.events:
+			w.handle(ev)
+
+		case <-ticker.C:
+			w.flushAll()
+
+		case <-w.shutdownChannel:
+			w.drain()
+			return
+
+		case <-ctx.Done():
+			w.drain()
+			return
+		}
+	}
+}
+
+// drain processes events queued before shutdown
+func (w *Writer) drain() {
+	for {
+		select {
+		case ev := <-w.events:
+			w.handle(ev)
+		default:
+			return
+		}
+	}
+}
+
+func (w *Writer) handle(ev event) {
+	if w.disabled.Load() {
+		return
+	}
+
+	defer func() { w.openFiles.Store(int64(len(w.files))) }()
+
+	if ev.endSession != "" {
+		if f, exists := w.files[ev.endSession]; exists {
+			delete(w.files, ev.endSession)
+			if err := closeFile(f); err != nil {
+				w.disable(err)
+			}
+		}
+		return
+	}
+
+	if err := w.write(ev.message.SessionID, FormatLine(ev.message)+"\n"); err != nil {
+		w.disable(err)
+	}
+}
+
+// write appends one line, opening or rotating the session file as needed
+func (w *Writer) write(sessionID, line string) error {
+	if !isSafeSessionID(sessionID) {
+		log.Printf("WARNING: Skipping transcript line for unsafe session ID %q", sessionID)
+		return nil
+	}
+
+	f, exists := w.files[sessionID]
+	if !exists {
+		var err error
+		if f, err = w.open(sessionID); err != nil {
+			return err
+		}
+		w.files[sessionID] = f
+	}
+
+	// FUNCTIONAL DISCOVERY: Rotate before exceeding the limit; a single oversized
+	// line still goes into a fresh file rather than being split
+	if f.size > 0 && f.size+int64(len(line)) > w.maxFileBytes {
+		delete(w.files, sessionID)
+		if err := w.rotate(sessionID, f); err != nil {
+			return err
+		}
+		var err error
+		if f, err = w.open(sessionID); err != nil {
+			return err
+		}
+		w.files[sessionID] = f
+	}
+
+	n, err := f.writer.WriteString(line)
+	f.size += int64(n)
+	return err
+}
+
+func (w *Writer) path(sessionID string) string {
+	return filepath.Join(w.dir, sessionID+".log")
+}
+
+func (w *Writer) open(sessionID string) (*sessionFile, error) {
+	file, err := os.OpenFile(w.path(sessionID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return &sessionFile{
+		file:   file,
+		writer: bufio.NewWriter(file),
+		size:   info.Size(),
+	}, nil
+}
+
+// rotate closes the current file and renames it to the next free {id}.log.N
+func (w *Writer) rotate(sessionID string, f *sessionFile) error {
+	if err := closeFile(f); err != nil {
+		return err
+	}
+
+	current := w.path(sessionID)
+	for n := 1; ; n++ {
+		rotated := fmt.Sprintf("%s.%d", current, n)
+		_, err := os.Stat(rotated)
+		if os.IsNotExist(err) {
+			return os.Rename(current, rotated)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (w *Writer) flushAll() {
+	for _, f := range w.files {
+		if err := f.writer.Flush(); err != nil {
+			w.disable(err)
+			return
+		}
+	}
+}
+
+func (w *Writer) closeAll() {
+	for sessionID, f := range w.files {
+		if err := closeFile(f); err != nil {
+			log.Printf("Failed to close transcript for session %s: %v", sessionID, err)
+		}
+		delete(w.files, sessionID)
+	}
+	w.openFiles.Store(0)
+}
+
+// disable turns the writer off after a write failure such as a full disk
+func (w *Writer) disable(err error) {
+	if w.disabled.Swap(true) {
+		return
+	}
+	log.Printf("WARNING: Transcript writer disabled after write failure: %v", err)
+
+	for sessionID, f := range w.files {
+		_ = f.file.Close() // Buffered lines are lost; flushing would fail again
+		delete(w.files, sessionID)
+	}
+}
+
+func closeFile(f *sessionFile) error {
+	flushErr := f.writer.Flush()
+	closeErr := f.file.Close()
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+// isSafeSessionID rejects IDs that could escape the transcript directory
+func isSafeSessionID(sessionID string) bool {
+	return sessionID != "" &&
+		!strings.ContainsAny(sessionID, `/\`) &&
+		!strings.HasPrefix(sessionID, ".")
+}
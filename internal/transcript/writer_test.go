@@ -0,0 +1,191 @@
+package transcript
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+func testMessage(sessionID, text string) *types.Message {
+	return &types.Message{
+		ID:        "msg-" + text,
+		SessionID: sessionID,
+		Type:      types.MessageTypeInstructorInbox,
+		FromUser:  "student1",
+		Content:   map[string]interface{}{"text": text},
+		Timestamp: time.Now(),
+	}
+}
+
+// waitFor polls until condition holds or the deadline passes
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Condition not met before deadline")
+}
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+// Functional Validation Tests
+func TestWriter_AppendsAndFlushesPerSession(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewWriter(dir, 20*time.Millisecond, 1<<20)
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+	defer writer.Stop()
+
+	writer.Record(testMessage("session-a", "first"))
+	writer.Record(testMessage("session-b", "other"))
+	writer.Record(testMessage("session-a", "second"))
+
+	pathA := filepath.Join(dir, "session-a.log")
+	waitFor(t, func() bool { return len(readLines(t, pathA)) == 2 })
+
+	lines := readLines(t, pathA)
+	if !strings.HasSuffix(lines[0], "\tfirst") || !strings.HasSuffix(lines[1], "\tsecond") {
+		t.Errorf("Unexpected transcript lines: %q", lines)
+	}
+	if lines := readLines(t, filepath.Join(dir, "session-b.log")); len(lines) != 1 {
+		t.Errorf("Expected 1 line for session-b, got %q", lines)
+	}
+}
+
+func TestWriter_SessionEndClosesFile(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewWriter(dir, time.Hour, 1<<20) // Only session end or Stop flushes
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+	defer writer.Stop()
+
+	writer.Record(testMessage("session-a", "before end"))
+	writer.SessionEnded("session-a")
+
+	waitFor(t, func() bool { return len(readLines(t, filepath.Join(dir, "session-a.log"))) == 1 })
+}
+
+func TestWriter_StopFlushesBufferedLines(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewWriter(dir, time.Hour, 1<<20)
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		writer.Record(testMessage("session-a", fmt.Sprintf("line-%d", i)))
+	}
+	if err := writer.Stop(); err != nil {
+		t.Fatalf("Stop should succeed: %v", err)
+	}
+
+	if lines := readLines(t, filepath.Join(dir, "session-a.log")); len(lines) != 10 {
+		t.Errorf("Expected 10 lines after Stop, got %d", len(lines))
+	}
+	if err := writer.Stop(); err != ErrWriterNotRunning {
+		t.Errorf("Expected ErrWriterNotRunning on second Stop, got %v", err)
+	}
+}
+
+func TestWriter_RotatesLargeTranscripts(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewWriter(dir, time.Hour, 200)
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		writer.Record(testMessage("session-a", fmt.Sprintf("line-%d", i)))
+	}
+	_ = writer.Stop()
+
+	matches, _ := filepath.Glob(filepath.Join(dir, "session-a.log*"))
+	if len(matches) < 2 {
+		t.Fatalf("Expected rotated files, got %v", matches)
+	}
+
+	total := 0
+	for _, path := range matches {
+		info, _ := os.Stat(path)
+		if info.Size() > 200 {
+			t.Errorf("%s exceeds max size: %d bytes", path, info.Size())
+		}
+		total += len(readLines(t, path))
+	}
+	if total != 10 {
+		t.Errorf("Expected 10 lines across rotated files, got %d", total)
+	}
+}
+
+// Error Handling Validation Tests
+func TestWriter_DisablesOnDiskFull(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full not available")
+	}
+
+	dir := t.TempDir()
+	// Every write to /dev/full fails with ENOSPC, like a full disk
+	if err := os.Symlink("/dev/full", filepath.Join(dir, "session-a.log")); err != nil {
+		t.Skipf("Cannot create symlink: %v", err)
+	}
+
+	writer := NewWriter(dir, 10*time.Millisecond, 1<<20)
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+	defer writer.Stop()
+
+	writer.Record(testMessage("session-a", "lost"))
+	waitFor(t, writer.Disabled)
+
+	// Record must keep returning immediately once disabled
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5000; i++ {
+			writer.Record(testMessage("session-b", "ignored"))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Record blocked after writer was disabled")
+	}
+}
+
+func TestWriter_RejectsUnsafeSessionIDs(t *testing.T) {
+	dir := t.TempDir()
+	writer := NewWriter(dir, time.Hour, 1<<20)
+	if err := writer.Start(context.Background()); err != nil {
+		t.Fatalf("Start should succeed: %v", err)
+	}
+
+	writer.Record(testMessage("../escape", "nope"))
+	_ = writer.Stop()
+
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dir), "escape.log")); err == nil {
+		t.Error("Transcript escaped the configured directory")
+	}
+	if writer.Disabled() {
+		t.Error("Unsafe session IDs should be skipped, not disable the writer")
+	}
+}
@@ -0,0 +1,86 @@
+package websocket
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// maxRetryAfter caps the Retry-After spread handed to paced clients
+const maxRetryAfter = 10 * time.Second
+
+// AdmissionPacer limits WebSocket upgrades for a window after startup
+// ARCHITECTURAL DISCOVERY: A token bucket refilled at rate per second with one second
+// of burst. After a restart every client reconnects at once; history replay and
+// session validation for all of them would otherwise hit the database together
+type AdmissionPacer struct {
+	rate   int
+	endsAt time.Time
+	now    func() time.Time // Replaced in tests
+
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+	admitted   int64
+	rejected   int64
+}
+
+// NewAdmissionPacer paces upgrades at rate per second for window, starting now
+func NewAdmissionPacer(rate int, window time.Duration) *AdmissionPacer {
+	now := time.Now()
+	return &AdmissionPacer{
+		rate:       rate,
+		endsAt:     now.Add(window),
+		now:        time.Now,
+		tokens:     float64(rate),
+		lastRefill: now,
+	}
+}
+
+// Admit reports whether an upgrade may proceed and, if not, how long the client
+// should wait before retrying
+// FUNCTIONAL DISCOVERY: Retry-After is spread randomly over the rest of the window
+// (capped at maxRetryAfter) so rejected clients come back staggered instead of as
+// a second herd one second later
+func (p *AdmissionPacer) Admit() (bool, time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	if !now.Before(p.endsAt) {
+		return true, 0
+	}
+
+	p.tokens = math.Min(float64(p.rate), p.tokens+now.Sub(p.lastRefill).Seconds()*float64(p.rate))
+	p.lastRefill = now
+	if p.tokens >= 1 {
+		p.tokens--
+		p.admitted++
+		return true, 0
+	}
+
+	p.rejected++
+	spread := p.endsAt.Sub(now)
+	if spread > maxRetryAfter {
+		spread = maxRetryAfter
+	}
+	seconds := int(math.Ceil(spread.Seconds()))
+	return false, time.Duration(1+rand.Intn(seconds)) * time.Second
+}
+
+// Stats returns the pacing state for /health
+func (p *AdmissionPacer) Stats() types.AdmissionStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return types.AdmissionStats{
+		Active:   p.now().Before(p.endsAt),
+		Rate:     p.rate,
+		EndsAt:   p.endsAt,
+		Admitted: p.admitted,
+		Rejected: p.rejected,
+	}
+}
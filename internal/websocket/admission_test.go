@@ -0,0 +1,72 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// FUNCTIONAL VALIDATION TEST: Token bucket pacing during the startup window
+func TestAdmissionPacer_Admit(t *testing.T) {
+	pacer := NewAdmissionPacer(2, time.Minute)
+	now := pacer.lastRefill
+	pacer.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := pacer.Admit(); !ok {
+			t.Fatalf("Upgrade %d should use the initial burst", i+1)
+		}
+	}
+	ok, retryAfter := pacer.Admit()
+	if ok {
+		t.Fatal("Upgrade beyond the burst should be rejected")
+	}
+	if retryAfter < time.Second || retryAfter > maxRetryAfter {
+		t.Errorf("Retry-After %v outside [1s, %v]", retryAfter, maxRetryAfter)
+	}
+
+	now = now.Add(500 * time.Millisecond) // Refills one token at 2/sec
+	if ok, _ := pacer.Admit(); !ok {
+		t.Error("Upgrade should be admitted after a refill")
+	}
+
+	now = pacer.endsAt
+	for i := 0; i < 10; i++ {
+		if ok, _ := pacer.Admit(); !ok {
+			t.Fatal("Upgrades after the window should always be admitted")
+		}
+	}
+
+	stats := pacer.Stats()
+	if stats.Active || stats.Rate != 2 || stats.Admitted != 3 || stats.Rejected != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Paced upgrades get 503 with Retry-After
+func TestHandler_AdmissionPacing(t *testing.T) {
+	handler := NewHandler(NewRegistry(), &mockSessionManager{}, &mockDatabaseManager{}, &mockHub{})
+	pacer := NewAdmissionPacer(1, time.Minute)
+	handler.SetAdmissionPacer(pacer)
+
+	connect := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/ws?user_id=student1&role=student&session_id=session123", nil)
+		rec := httptest.NewRecorder()
+		handler.HandleWebSocket(rec, req)
+		return rec
+	}
+
+	if rec := connect(); rec.Code == http.StatusServiceUnavailable {
+		t.Fatal("First upgrade should pass admission")
+	}
+
+	rec := connect()
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d, got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+	if seconds, err := strconv.Atoi(rec.Header().Get("Retry-After")); err != nil || seconds < 1 {
+		t.Errorf("Expected a Retry-After of at least 1 second, got %q", rec.Header().Get("Retry-After"))
+	}
+}
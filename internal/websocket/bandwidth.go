@@ -0,0 +1,186 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"switchboard/pkg/types"
+)
+
+// sessionTraffic is one session's WebSocket traffic by message type
+// TECHNICAL DISCOVERY: Connections hold a pointer to it, so counting a frame costs one
+// uncontended lock rather than a lookup in the tracker
+type sessionTraffic struct {
+	mu     sync.Mutex
+	byType map[string]*types.ByteCounts
+}
+
+func (t *sessionTraffic) countsLocked(kind string) *types.ByteCounts {
+	counts, exists := t.byType[kind]
+	if !exists {
+		counts = &types.ByteCounts{}
+		t.byType[kind] = counts
+	}
+	return counts
+}
+
+func (t *sessionTraffic) sent(kind string, n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts := t.countsLocked(kind)
+	counts.BytesSent += uint64(n)
+	counts.FramesSent++
+}
+
+func (t *sessionTraffic) received(kind string, n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts := t.countsLocked(kind)
+	counts.BytesReceived += uint64(n)
+	counts.FramesReceived++
+}
+
+func (t *sessionTraffic) stats() types.BandwidthStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := types.BandwidthStats{ByType: make(map[string]types.ByteCounts, len(t.byType))}
+	for kind, counts := range t.byType {
+		stats.ByType[kind] = *counts
+		stats.Add(*counts)
+	}
+	return stats
+}
+
+// bandwidthTracker keeps each session's traffic until the session ends
+type bandwidthTracker struct {
+	mu       sync.Mutex
+	sessions map[string]*sessionTraffic
+}
+
+func newBandwidthTracker() *bandwidthTracker {
+	return &bandwidthTracker{sessions: make(map[string]*sessionTraffic)}
+}
+
+// session returns sessionID's traffic, starting it if this is its first connection
+func (t *bandwidthTracker) session(sessionID string) *sessionTraffic {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	traffic, exists := t.sessions[sessionID]
+	if !exists {
+		traffic = &sessionTraffic{byType: make(map[string]*types.ByteCounts)}
+		t.sessions[sessionID] = traffic
+	}
+	return traffic
+}
+
+func (t *bandwidthTracker) stats(sessionID string) types.BandwidthStats {
+	t.mu.Lock()
+	traffic, exists := t.sessions[sessionID]
+	t.mu.Unlock()
+	if !exists {
+		return types.BandwidthStats{ByType: map[string]types.ByteCounts{}}
+	}
+	return traffic.stats()
+}
+
+// forget drops a session's traffic and returns its final stats
+func (t *bandwidthTracker) forget(sessionID string) (types.BandwidthStats, bool) {
+	t.mu.Lock()
+	traffic, exists := t.sessions[sessionID]
+	delete(t.sessions, sessionID)
+	t.mu.Unlock()
+	if !exists {
+		return types.BandwidthStats{}, false
+	}
+	return traffic.stats(), true
+}
+
+func (t *bandwidthTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.sessions)
+}
+
+// frameKind names the message type of an outbound value for bandwidth accounting
+// FUNCTIONAL DISCOVERY: Routed messages carry their own type; server notices are maps
+// whose "type" is almost always "system", which is also assumed when there is none
+func frameKind(v interface{}) string {
+	switch value := v.(type) {
+	case *PreparedMessage:
+		return frameKind(value.value)
+	case *types.Message:
+		return value.Type
+	case map[string]interface{}:
+		if kind, ok := value["type"].(string); ok && kind != "" {
+			return kind
+		}
+	}
+	return "system"
+}
+
+// countSent adds a written frame to the connection's and its session's traffic
+func (c *Connection) countSent(kind string, n int) {
+	c.bytesSent.Add(uint64(n))
+	if c.traffic != nil {
+		c.traffic.sent(kind, n)
+	}
+}
+
+// countReceived adds a read frame to the connection's and its session's traffic
+func (c *Connection) countReceived(kind string, n int) {
+	c.bytesReceived.Add(uint64(n))
+	if c.traffic != nil {
+		c.traffic.received(kind, n)
+	}
+}
+
+// BytesSent returns the payload bytes written to this connection's socket
+func (c *Connection) BytesSent() uint64 {
+	return c.bytesSent.Load()
+}
+
+// BytesReceived returns the payload bytes read from this connection's socket
+func (c *Connection) BytesReceived() uint64 {
+	return c.bytesReceived.Load()
+}
+
+// BandwidthStats returns a session's WebSocket traffic so far
+// FUNCTIONAL DISCOVERY: Counted in memory from the first connection after startup; a
+// restart starts an active session's counts again
+func (h *Handler) BandwidthStats(sessionID string) types.BandwidthStats {
+	return h.bandwidth.stats(sessionID)
+}
+
+// ReleaseBandwidth records an ended session's traffic totals in its audit trail and
+// drops its counts
+// ARCHITECTURAL DISCOVERY: Registered with the hub's session resources, like
+// Router.ReleaseDeliveryStats; the event is what stats and reports show once it ended
+// TECHNICAL DISCOVERY: Frames written after this, such as the session_ended notice,
+// still reach the connections' own counters but not the recorded totals
+func (h *Handler) ReleaseBandwidth(ended types.Session) {
+	stats, exists := h.bandwidth.forget(ended.ID)
+	if !exists || h.dbManager == nil {
+		return
+	}
+	event := &types.SessionEvent{
+		SessionID: ended.ID,
+		Type:      types.SessionEventBandwidth,
+		Actor:     "system",
+		Details: map[string]interface{}{
+			"bytes_sent":      stats.BytesSent,
+			"bytes_received":  stats.BytesReceived,
+			"frames_sent":     stats.FramesSent,
+			"frames_received": stats.FramesReceived,
+			"by_type":         stats.ByType,
+		},
+	}
+	if err := h.dbManager.RecordSessionEvent(context.Background(), event); err != nil {
+		log.Printf("Failed to record bandwidth for session %s: %v", ended.ID, err)
+	}
+}
+
+// BandwidthSessions returns how many sessions have traffic counts
+func (h *Handler) BandwidthSessions() int {
+	return h.bandwidth.count()
+}
@@ -0,0 +1,103 @@
+package websocket
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP resolves the originating client address for a request
+// ARCHITECTURAL DISCOVERY: X-Forwarded-For is only honored when the direct peer is a
+// trusted proxy; otherwise any client could spoof its address with a header
+// FUNCTIONAL DISCOVERY: The header is walked right-to-left, skipping trusted hops,
+// so the first untrusted address is the real client even behind proxy chains
+func ClientIP(r *http.Request, trustedProxies []*net.IPNet) string {
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+	
+	if !isTrustedProxy(remoteIP, trustedProxies) {
+		return remoteIP
+	}
+	
+	forwarded := r.Header.Values("X-Forwarded-For")
+	var hops []string
+	for _, header := range forwarded {
+		for _, hop := range strings.Split(header, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	
+	for i := len(hops) - 1; i >= 0; i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break // Malformed hop - stop trusting the chain
+		}
+		if !isTrustedProxy(hops[i], trustedProxies) {
+			return hops[i]
+		}
+	}
+	
+	return remoteIP
+}
+
+// ClientScheme resolves the scheme the client used to reach the first proxy
+// ARCHITECTURAL DISCOVERY: Same trust rule as ClientIP - X-Forwarded-Proto is ignored
+// unless the direct peer is a trusted proxy, so a client cannot claim https it never used
+// FUNCTIONAL DISCOVERY: The rightmost value is the one the nearest trusted proxy set;
+// anything other than "http" or "https" falls back to the connection's own scheme
+func ClientScheme(r *http.Request, trustedProxies []*net.IPNet) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+	if !isTrustedProxy(remoteIP, trustedProxies) {
+		return scheme
+	}
+	
+	forwarded := r.Header.Values("X-Forwarded-Proto")
+	if len(forwarded) == 0 {
+		return scheme
+	}
+	values := strings.Split(forwarded[len(forwarded)-1], ",")
+	switch proto := strings.ToLower(strings.TrimSpace(values[len(values)-1])); proto {
+	case "http", "https":
+		return proto
+	}
+	return scheme
+}
+
+// isTrustedProxy reports whether ip falls inside any trusted proxy network
+func isTrustedProxy(ip string, trustedProxies []*net.IPNet) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, ipNet := range trustedProxies {
+		if ipNet.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactIP masks the host portion of an address for logging
+// FUNCTIONAL DISCOVERY: Keeping the network prefix (/24 for IPv4, /48 for IPv6)
+// still lets operators spot a misbehaving network without logging personal data
+func RedactIP(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return "redacted"
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(24, 32)).String() + "/24"
+	}
+	return parsed.Mask(net.CIDRMask(48, 128)).String() + "/48"
+}
@@ -0,0 +1,100 @@
+package websocket
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+// Functional Validation Tests
+func TestClientIP_TrustedProxyResolution(t *testing.T) {
+	_, proxyNet, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxyNet}
+	
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		trusted    []*net.IPNet
+		expected   string
+	}{
+		{"direct client", "203.0.113.7:5000", "", trusted, "203.0.113.7"},
+		{"untrusted peer spoofing header", "203.0.113.7:5000", "198.51.100.1", trusted, "203.0.113.7"},
+		{"no trusted proxies configured", "10.0.0.2:5000", "198.51.100.1", nil, "10.0.0.2"},
+		{"trusted proxy", "10.0.0.2:5000", "198.51.100.1", trusted, "198.51.100.1"},
+		{"proxy chain skips trusted hops", "10.0.0.2:5000", "192.0.2.9, 198.51.100.1, 10.0.0.3", trusted, "198.51.100.1"},
+		{"client-supplied hops are ignored", "10.0.0.2:5000", "127.0.0.1, 198.51.100.1", trusted, "198.51.100.1"},
+		{"all hops trusted", "10.0.0.2:5000", "10.0.0.3", trusted, "10.0.0.2"},
+		{"malformed hop", "10.0.0.2:5000", "garbage", trusted, "10.0.0.2"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/ws", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			
+			if ip := ClientIP(req, tt.trusted); ip != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestClientScheme_TrustedProxyResolution(t *testing.T) {
+	_, proxyNet, _ := net.ParseCIDR("10.0.0.0/8")
+	trusted := []*net.IPNet{proxyNet}
+	
+	tests := []struct {
+		name       string
+		remoteAddr string
+		proto      []string
+		tls        bool
+		expected   string
+	}{
+		{"direct client", "203.0.113.7:5000", nil, false, "http"},
+		{"direct TLS client", "203.0.113.7:5000", nil, true, "https"},
+		{"untrusted peer spoofing header", "203.0.113.7:5000", []string{"https"}, false, "http"},
+		{"untrusted peer cannot downgrade", "203.0.113.7:5000", []string{"http"}, true, "https"},
+		{"trusted proxy", "10.0.0.2:5000", []string{"https"}, false, "https"},
+		{"proxy chain uses nearest proxy", "10.0.0.2:5000", []string{"http, HTTPS"}, false, "https"},
+		{"repeated headers use the last", "10.0.0.2:5000", []string{"https", "http"}, false, "http"},
+		{"unknown scheme ignored", "10.0.0.2:5000", []string{"gopher"}, false, "http"},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/ws", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, proto := range tt.proto {
+				req.Header.Add("X-Forwarded-Proto", proto)
+			}
+			if !tt.tls {
+				req.TLS = nil
+			} else if req.TLS == nil {
+				req.TLS = &tls.ConnectionState{}
+			}
+			
+			if scheme := ClientScheme(req, trusted); scheme != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, scheme)
+			}
+		})
+	}
+}
+
+func TestClientIP_RedactIP(t *testing.T) {
+	tests := map[string]string{
+		"203.0.113.77":       "203.0.113.0/24",
+		"2001:db8:1:2::abcd": "2001:db8:1::/48",
+		"not-an-ip":          "redacted",
+	}
+	
+	for ip, expected := range tests {
+		if redacted := RedactIP(ip); redacted != expected {
+			t.Errorf("RedactIP(%s): expected %s, got %s", ip, expected, redacted)
+		}
+	}
+}
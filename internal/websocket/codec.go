@@ -0,0 +1,56 @@
+package websocket
+
+import (
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocket subprotocols offered during the upgrade handshake
+// FUNCTIONAL DISCOVERY: Clients without a Sec-WebSocket-Protocol header get JSON,
+// so existing clients keep working unchanged
+const (
+	SubprotocolJSON    = "switchboard.json.v1"
+	SubprotocolMsgpack = "switchboard.msgpack.v1"
+)
+
+// Codec encodes and decodes WebSocket payloads for one connection
+// ARCHITECTURAL DISCOVERY: Encoding is a per-connection property, so the router can
+// fan one message out to JSON and MessagePack clients in the same session without
+// knowing which wire format each recipient negotiated
+type Codec interface {
+	Name() string                             // Subprotocol identifier
+	FrameType() int                           // websocket.TextMessage or websocket.BinaryMessage
+	Marshal(v interface{}) ([]byte, error)    // Encode an outbound value
+	Unmarshal(data []byte, v interface{}) error // Decode an inbound frame
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string                               { return SubprotocolJSON }
+func (jsonCodec) FrameType() int                             { return websocket.TextMessage }
+func (jsonCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string                               { return SubprotocolMsgpack }
+func (msgpackCodec) FrameType() int                             { return websocket.BinaryMessage }
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error)      { return msgpackMarshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error { return msgpackUnmarshal(data, v) }
+
+// JSONCodec and MsgpackCodec are the supported wire encodings
+var (
+	JSONCodec    Codec = jsonCodec{}
+	MsgpackCodec Codec = msgpackCodec{}
+)
+
+// CodecForSubprotocol maps a negotiated subprotocol to its codec
+// TECHNICAL DISCOVERY: An empty or unknown subprotocol falls back to JSON, matching
+// the behavior of clients that predate subprotocol negotiation
+func CodecForSubprotocol(subprotocol string) Codec {
+	if subprotocol == SubprotocolMsgpack {
+		return MsgpackCodec
+	}
+	return JSONCodec
+}
@@ -0,0 +1,242 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"switchboard/pkg/types"
+)
+
+func testCodecMessage() *types.Message {
+	toUser := "student1"
+	return &types.Message{
+		ID:        "msg-1",
+		SessionID: "session-1",
+		Type:      types.MessageTypeInboxResponse,
+		Context:   "question",
+		FromUser:  "instructor1",
+		ToUser:    &toUser,
+		Content: map[string]interface{}{
+			"text":     "Check line 12 - the loop bound is off by one",
+			"score":    float64(42),
+			"ratio":    0.75,
+			"resolved": true,
+			"tags":     []interface{}{"loops", "indexing"},
+			"extra":    nil,
+			"nested":   map[string]interface{}{"line": float64(12), "negative": float64(-300)},
+		},
+		Timestamp: time.Date(2024, 1, 1, 10, 0, 0, 123456789, time.UTC),
+	}
+}
+
+// Functional Validation Tests
+func TestCodec_MessageRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			original := testCodecMessage()
+
+			data, err := codec.Marshal(original)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			var decoded types.Message
+			if err := codec.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+
+			if !decoded.Timestamp.Equal(original.Timestamp) {
+				t.Errorf("Timestamp mismatch: expected %v, got %v", original.Timestamp, decoded.Timestamp)
+			}
+			decoded.Timestamp = original.Timestamp
+			if !reflect.DeepEqual(&decoded, original) {
+				t.Errorf("Round trip mismatch:\nexpected %+v\ngot      %+v", original, &decoded)
+			}
+		})
+	}
+}
+
+func TestCodec_TargetedBroadcastRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			original := testCodecMessage()
+			original.Type = types.MessageTypeInstructorBroadcast
+			original.ToUser = nil
+			original.ToUsers = []string{"student1", "student2"}
+
+			data, err := codec.Marshal(original)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			var decoded types.Message
+			if err := codec.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded.ToUsers, original.ToUsers) {
+				t.Errorf("Expected to_users %v, got %v", original.ToUsers, decoded.ToUsers)
+			}
+		})
+	}
+}
+
+func TestCodec_MsgpackGenericValues(t *testing.T) {
+	// System envelopes are plain maps with time.Time values
+	envelope := map[string]interface{}{
+		"type":      "system",
+		"content":   map[string]interface{}{"event": "history_complete", "count": 300000},
+		"timestamp": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	data, err := MsgpackCodec.Marshal(envelope)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+
+	var decoded map[string]interface{}
+	if err := MsgpackCodec.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	content := decoded["content"].(map[string]interface{})
+	if content["count"] != float64(300000) {
+		t.Errorf("Expected numbers decoded as float64 like JSON, got %T %v", content["count"], content["count"])
+	}
+	if decoded["timestamp"] != "2024-01-01T00:00:00Z" {
+		t.Errorf("Expected RFC 3339 timestamp string, got %v", decoded["timestamp"])
+	}
+}
+
+func TestCodec_MsgpackAnnotations(t *testing.T) {
+	message := testCodecMessage()
+	message.Annotations = []*types.MessageAnnotation{{MessageID: message.ID, InstructorID: "instructor1", Tags: []string{"star"}}}
+
+	data, err := MsgpackCodec.Marshal(message)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded interface{}
+	if err := MsgpackCodec.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	annotations, ok := decoded.(map[string]interface{})["annotations"].([]interface{})
+	if !ok || len(annotations) != 1 || annotations[0].(map[string]interface{})["instructor_id"] != "instructor1" {
+		t.Errorf("Expected the annotation in the encoded envelope, got %v", decoded)
+	}
+}
+
+func TestCodec_MsgpackRejectsMalformedInput(t *testing.T) {
+	inputs := map[string][]byte{
+		"truncated string":   {0xa5, 'a', 'b'},
+		"forged array32":     {0xdd, 0xff, 0xff, 0xff, 0xff},
+		"non-string map key": {0x81, 0x01, 0x01},
+		"trailing data":      {0xc0, 0xc0},
+		"unsupported ext":    {0xd4, 0x01, 0x00},
+	}
+
+	for name, input := range inputs {
+		var v interface{}
+		if err := MsgpackCodec.Unmarshal(input, &v); err == nil {
+			t.Errorf("%s: expected decode error", name)
+		}
+	}
+
+	deep := []byte{}
+	for i := 0; i < msgpackMaxDepth+2; i++ {
+		deep = append(deep, 0x91)
+	}
+	deep = append(deep, 0xc0)
+	var v interface{}
+	if err := MsgpackCodec.Unmarshal(deep, &v); err == nil {
+		t.Error("Expected nesting depth error")
+	}
+}
+
+func TestCodec_SubprotocolNegotiation(t *testing.T) {
+	tests := []struct {
+		name              string
+		clientProtocols   []string
+		expectedFrameType int
+	}{
+		{"no subprotocol defaults to JSON", nil, websocket.TextMessage},
+		{"explicit JSON", []string{SubprotocolJSON}, websocket.TextMessage},
+		{"msgpack", []string{SubprotocolMsgpack}, websocket.BinaryMessage},
+		{"unknown protocol falls back to JSON", []string{"other.v1"}, websocket.TextMessage},
+	}
+
+	// Mixed sessions: each connection encodes the same message independently
+	message := testCodecMessage()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		wsConn := NewConnection(conn)
+		defer func() { _ = wsConn.Close() }()
+
+		_ = wsConn.WriteJSON(message)
+		_, _, _ = conn.ReadMessage() // Wait for client to close
+	}))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := websocket.Dialer{Subprotocols: tt.clientProtocols}
+			client, _, err := dialer.Dial(url, nil)
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer func() { _ = client.Close() }()
+
+			_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+			frameType, data, err := client.ReadMessage()
+			if err != nil {
+				t.Fatalf("ReadMessage failed: %v", err)
+			}
+			if frameType != tt.expectedFrameType {
+				t.Fatalf("Expected frame type %d, got %d", tt.expectedFrameType, frameType)
+			}
+
+			var decoded types.Message
+			if err := CodecForSubprotocol(client.Subprotocol()).Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Decode failed: %v", err)
+			}
+			if decoded.ID != message.ID || decoded.Content["text"] != message.Content["text"] {
+				t.Errorf("Unexpected message: %+v", decoded)
+			}
+		})
+	}
+}
+
+// Performance Validation Tests
+// BenchmarkCodec_Broadcast measures encode cost and wire size for one instructor
+// broadcast fanned out to a 30-student classroom (one encode per connection)
+func BenchmarkCodec_Broadcast(b *testing.B) {
+	const recipients = 30
+	message := testCodecMessage()
+	message.Type = types.MessageTypeInstructorBroadcast
+	message.ToUser = nil
+
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		b.Run(codec.Name(), func(b *testing.B) {
+			var wireBytes int
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				wireBytes = 0
+				for r := 0; r < recipients; r++ {
+					data, err := codec.Marshal(message)
+					if err != nil {
+						b.Fatal(err)
+					}
+					wireBytes += len(data)
+				}
+			}
+			b.ReportMetric(float64(wireBytes), "bytes/broadcast")
+		})
+	}
+}
@@ -2,27 +2,76 @@ package websocket
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
+	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
+	"switchboard/pkg/types"
 )
 
+// CloseSuperseded is the close code sent to a connection replaced by a newer one
+// FUNCTIONAL DISCOVERY: A private-range code lets clients tell "you reconnected elsewhere"
+// apart from network drops, so they stop their reconnect loop instead of fighting the
+// newer connection
+const CloseSuperseded = 4003
+
+// supersedeGracePeriod bounds how long a replaced connection waits for the client to
+// answer the close frame before the socket is torn down
+const supersedeGracePeriod = 2 * time.Second
+
+// maxReplayBacklog bounds the live messages held back while history replays
+const maxReplayBacklog = 1000
+
+// writeTimeout bounds each socket write, and so how long a dead peer stays registered
+// FUNCTIONAL DISCOVERY: 5-second timeout balances responsiveness vs classroom network stability
+const writeTimeout = 5 * time.Second
+
+// outboundFrame is an encoded message waiting for the writer goroutine
+// TECHNICAL DISCOVERY: The original value travels with the bytes so a frame can be
+// re-encoded for a successor connection that negotiated a different codec
+type outboundFrame struct {
+	data    []byte
+	value   interface{}
+	kind    string // Message type the frame is counted under, see frameKind
+	control int    // websocket.PingMessage for heartbeats, 0 for data frames
+}
+
 // Connection implements the interfaces.Connection interface
 // ARCHITECTURAL DISCOVERY: WebSocket writes must be serialized to prevent race conditions
 // Interface boundary maintained - no business logic in connection wrapper
 type Connection struct {
 	conn          *websocket.Conn
-	writeCh       chan []byte         // FUNCTIONAL DISCOVERY: 100 buffer prevents blocking in classroom scenarios
+	writeCh       chan outboundFrame  // FUNCTIONAL DISCOVERY: 100 buffer prevents blocking in classroom scenarios
 	userID        string              // Set after authentication
 	role          string              // Set after authentication  
 	sessionID     string              // Set after authentication
 	authenticated bool                // Authentication status
+	connectionID  string              // Server-assigned, distinguishes devices of one user
+	clientIP      string              // Resolved at upgrade time (proxy-aware)
+	userAgent     string              // Captured at upgrade time
+	codec         Codec               // Negotiated wire encoding, fixed for the connection lifetime
+	strict        bool                // Refuse inbound frames with unknown fields; set before registration
+	apiKey        *types.APIKey       // Key the connection authenticated with; nil for users
 	ctx           context.Context     // For cancellation
 	cancel        context.CancelFunc  // For cleanup
-	closeOnce     sync.Once           // Ensure single close
+	closeMu       sync.RWMutex        // Orders enqueue against Close
+	closed        bool                // Set by Close; later writes get ErrConnectionClosed
 	mu            sync.RWMutex        // Protect auth fields
+	handoffMu     sync.RWMutex        // Orders WriteJSON against supersede
+	successor     *Connection         // Set once a newer connection replaces this one
+	superseded    chan struct{}       // Closed to stop the writer before handoff
+	writerDone    chan struct{}       // Closed when writeLoop exits
+	replayMu      sync.Mutex          // Orders live writes against the history replay
+	replaying     bool                // Live writes are held back while set
+	replayBacklog []interface{}       // Live messages waiting for history_complete
+	writeFailedAt atomic.Int64        // UnixNano of the first failed socket write, 0 if none
+	bytesSent     atomic.Uint64       // Payload bytes written, counted per connection
+	bytesReceived atomic.Uint64       // Payload bytes read, counted per connection
+	traffic       *sessionTraffic     // The session's traffic counts; set before registration
 }
 
 // NewConnection creates a new WebSocket connection wrapper
@@ -30,10 +79,17 @@ func NewConnection(conn *websocket.Conn) *Connection {
 	ctx, cancel := context.WithCancel(context.Background())
 	c := &Connection{
 		conn:          conn,
-		writeCh:       make(chan []byte, 100), // Exactly 100 message buffer
+		writeCh:       make(chan outboundFrame, 100), // Exactly 100 message buffer
 		ctx:           ctx,
 		cancel:        cancel,
 		authenticated: false,
+		connectionID:  uuid.New().String(),
+		codec:         JSONCodec,
+		superseded:    make(chan struct{}),
+		writerDone:    make(chan struct{}),
+	}
+	if conn != nil {
+		c.codec = CodecForSubprotocol(conn.Subprotocol())
 	}
 	
 	// Start the single writer goroutine
@@ -42,80 +98,321 @@ func NewConnection(conn *websocket.Conn) *Connection {
 	return c
 }
 
-// ARCHITECTURAL DISCOVERY: Single writer goroutine pattern eliminates races
+// ARCHITECTURAL DISCOVERY: Single writer goroutine pattern eliminates races. It is the
+// only code that writes to the socket while the connection is live - heartbeat pings
+// are queued here too - so Close never tears the socket down under a second writer
+// TECHNICAL DISCOVERY: writeCh is never closed - WriteJSON may still be sending when the
+// writer exits, and frames left behind are either handed to a successor or dropped
+// with the connection
 func (c *Connection) writeLoop() {
-	defer func() {
-		// Clean up channel on exit
-		for len(c.writeCh) > 0 {
-			<-c.writeCh // Drain remaining messages
-		}
-		close(c.writeCh)
-	}()
+	defer close(c.writerDone)
 	
 	for {
 		select {
-		case data, ok := <-c.writeCh:
-			if !ok {
-				return // Channel closed
+		case frame := <-c.writeCh:
+			// TECHNICAL DISCOVERY: select picks at random when a frame and the close are
+			// both ready, so re-check before touching a socket Close may be tearing down
+			if c.ctx.Err() != nil {
+				return
 			}
 			
-			// FUNCTIONAL DISCOVERY: 5-second timeout balances responsiveness vs classroom network stability
-			if err := c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second)); err != nil {
-				return // Exit if we can't set deadline
+			deadline := time.Now().Add(writeTimeout)
+			if frame.control != 0 {
+				if err := c.conn.WriteControl(frame.control, frame.data, deadline); err != nil {
+					c.writeFailed(err)
+					return
+				}
+				continue
+			}
+			if err := c.conn.SetWriteDeadline(deadline); err != nil {
+				c.writeFailed(err)
+				return
 			}
 			
-			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
-				// Log error but continue processing other messages
+			if err := c.conn.WriteMessage(c.codec.FrameType(), frame.data); err != nil {
+				c.writeFailed(err)
 				return
 			}
+			c.countSent(frame.kind, len(frame.data))
 			
+		case <-c.superseded:
+			return
 		case <-c.ctx.Done():
 			return
 		}
 	}
 }
 
+// writeFailed closes the connection after a socket write error
+// FUNCTIONAL DISCOVERY: A sleeping laptop leaves a half-dead TCP connection that the
+// read loop would only notice at its 60-second read deadline. Closing here ends the
+// read loop at once, so the handler unregisters the user within one write timeout and
+// targeted messages stop vanishing into a socket nobody reads
+func (c *Connection) writeFailed(err error) {
+	if c.isClosed() {
+		return // Close tore the socket down under the write; nothing failed
+	}
+	c.writeFailedAt.CompareAndSwap(0, time.Now().UnixNano())
+	log.Printf("Write to %s (connection %s) failed, closing: %v", c.GetUserID(), c.connectionID, err)
+	_ = c.Close()
+}
+
+// WriteFailedAt returns when a socket write first failed, or the zero time
+func (c *Connection) WriteFailedAt() time.Time {
+	if nanos := c.writeFailedAt.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+// dropped reports whether a read loop ending with readErr lost the client, as opposed
+// to the client sending a close frame or the server closing the connection
+// TECHNICAL DISCOVERY: writeFailed records the failure before it closes, so a failed
+// write still counts even though Close ends the read loop
+func (c *Connection) dropped(readErr error) bool {
+	if c.writeFailedAt.Load() != 0 {
+		return true
+	}
+	if c.isClosed() {
+		return false
+	}
+	var closeErr *websocket.CloseError
+	return !errors.As(readErr, &closeErr) || closeErr.Code == websocket.CloseAbnormalClosure
+}
+
 // WriteJSON implementation with timeout and error handling
+// ARCHITECTURAL DISCOVERY: Encodes with the negotiated codec despite the name, which is
+// kept for interfaces.Connection compatibility - callers stay encoding-agnostic
 func (c *Connection) WriteJSON(v interface{}) error {
+	// FUNCTIONAL DISCOVERY: Live messages arriving while history replays are held back
+	// and flushed after history_complete, so clients never see a live message ahead of
+	// older history
+	c.replayMu.Lock()
+	if c.replaying {
+		defer c.replayMu.Unlock()
+		if c.isClosed() {
+			return ErrConnectionClosed
+		}
+		if len(c.replayBacklog) >= maxReplayBacklog {
+			return ErrReplayBacklogFull
+		}
+		c.replayBacklog = append(c.replayBacklog, v)
+		return nil
+	}
+	c.replayMu.Unlock()
+	return c.enqueue(v)
+}
+
+// PendingWrites returns how many frames are waiting for the writer goroutine
+// FUNCTIONAL DISCOVERY: A value near the 100-frame buffer means this client's link
+// cannot keep up; reported in the instructor diagnostic overlay
+func (c *Connection) PendingWrites() int {
+	return len(c.writeCh)
+}
+
+// beginReplay starts holding back live writes until endReplay
+// TECHNICAL DISCOVERY: Must be called before the connection is registered so no
+// broadcast can slip in ahead of the replay
+func (c *Connection) beginReplay() {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+	c.replaying = true
+}
+
+// writeReplay enqueues a replay frame, bypassing the live backlog
+func (c *Connection) writeReplay(v interface{}) error {
+	return c.enqueue(v)
+}
+
+// endReplay flushes held-back live messages in arrival order and resumes direct writes
+// TECHNICAL DISCOVERY: replayMu stays held during the flush, so a concurrent WriteJSON
+// waits and lands behind the backlog rather than overtaking it
+func (c *Connection) endReplay() {
+	c.replayMu.Lock()
+	defer c.replayMu.Unlock()
+	
+	for i, v := range c.replayBacklog {
+		if err := c.enqueue(v); err != nil {
+			log.Printf("ERROR: Failed to flush %d live messages held during history replay for user %s: %v", len(c.replayBacklog)-i, c.GetUserID(), err)
+			break
+		}
+	}
+	c.replayBacklog = nil
+	c.replaying = false
+}
+
+// enqueue encodes v and hands it to the writer goroutine
+func (c *Connection) enqueue(v interface{}) error {
+	// FUNCTIONAL DISCOVERY: Writes that reach a replaced connection are re-targeted, so
+	// a sender holding a stale connection from before the swap still delivers
+	c.handoffMu.RLock()
+	if next := c.successor; next != nil {
+		c.handoffMu.RUnlock()
+		return next.WriteJSON(v)
+	}
+	defer c.handoffMu.RUnlock()
+	
 	// Check if connection is closed
-	select {
-	case <-c.ctx.Done():
+	if c.isClosed() {
 		return ErrConnectionClosed
-	default:
 	}
 	
-	// Marshal to JSON
-	data, err := json.Marshal(v)
+	// Marshal with the connection's codec, or reuse a shared message's bytes
+	var data []byte
+	var err error
+	if prepared, ok := v.(*PreparedMessage); ok {
+		data, err = prepared.encode(c.codec)
+	} else {
+		data, err = c.codec.Marshal(v)
+	}
 	if err != nil {
 		return ErrInvalidJSON // FUNCTIONAL: Error wrapping for debugging
 	}
+	return c.send(outboundFrame{data: data, value: v, kind: frameKind(v)})
+}
+
+// ping queues a heartbeat ping behind the frames already waiting
+// FUNCTIONAL DISCOVERY: A replaced connection is no longer pinged - it is closing, and
+// its successor runs its own heartbeat
+func (c *Connection) ping() error {
+	c.handoffMu.RLock()
+	defer c.handoffMu.RUnlock()
+	if c.successor != nil {
+		return nil
+	}
+	return c.send(outboundFrame{control: websocket.PingMessage})
+}
+
+// send hands a frame to the writer goroutine
+// TECHNICAL DISCOVERY: The closed check and a non-blocking send happen under closeMu,
+// so once Close returns no write can slip into the buffer and report success; only a
+// full buffer waits, with the 5-second timeout, outside the lock
+func (c *Connection) send(frame outboundFrame) error {
+	c.closeMu.RLock()
+	if c.closed {
+		c.closeMu.RUnlock()
+		return ErrConnectionClosed
+	}
+	select {
+	case c.writeCh <- frame:
+		c.closeMu.RUnlock()
+		return nil
+	default:
+	}
+	c.closeMu.RUnlock()
 	
 	// Send to write channel with timeout  
 	select {
-	case c.writeCh <- data:
+	case <-c.ctx.Done():
+		return ErrConnectionClosed
+	default:
+	}
+	select {
+	case c.writeCh <- frame:
 		return nil
-	case <-time.After(5 * time.Second):
+	case <-time.After(writeTimeout):
 		return ErrWriteTimeout // FUNCTIONAL: Exact timeout as specified
 	case <-c.ctx.Done():
 		return ErrConnectionClosed
 	}
 }
 
+// isClosed reports whether Close has been called
+func (c *Connection) isClosed() bool {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+	return c.closed
+}
+
 // ARCHITECTURAL DISCOVERY: Clean shutdown requires careful goroutine coordination
+// FUNCTIONAL DISCOVERY: Safe to call from any goroutine any number of times - the API
+// server ending a session and the read loop noticing a disconnect often both close the
+// same connection. Only the first call closes the socket and reports its error
 func (c *Connection) Close() error {
-	var err error
-	c.closeOnce.Do(func() {
-		// Cancel context to stop goroutines
-		c.cancel()
-		
-		// Close WebSocket connection
-		if c.conn != nil {
-			err = c.conn.Close()
+	c.closeMu.Lock()
+	if c.closed {
+		c.closeMu.Unlock()
+		return nil
+	}
+	c.closed = true
+	
+	// Cancel context to stop goroutines
+	c.cancel()
+	c.closeMu.Unlock()
+	
+	// Close WebSocket connection
+	// TECHNICAL DISCOVERY: Outside closeMu so writers waiting on a full buffer are not
+	// held up by a slow socket close; they see the cancelled context instead
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
+
+// supersede hands this connection over to next and closes it with CloseSuperseded
+// ARCHITECTURAL DISCOVERY: Runs after the registry has already routed the user to next.
+// Taking handoffMu exclusively waits out in-flight WriteJSON calls, after which every new
+// write forwards; frames still buffered are re-sent to next once the writer has stopped,
+// so nothing enqueued around the swap is lost. Forwarded frames may arrive at next after
+// messages sent to it directly
+// FUNCTIONAL DISCOVERY: Only a reconnect to the same session and role inherits the
+// backlog. A reconnect that joins another session or role must not see this session's
+// traffic, so its buffered frames are dropped and later writes fail with the old one
+func (c *Connection) supersede(next *Connection) {
+	handoff := next.GetSessionID() == c.GetSessionID() && next.GetRole() == c.GetRole()
+	if handoff {
+		c.handoffMu.Lock()
+		c.successor = next
+		c.handoffMu.Unlock()
+	}
+	
+	close(c.superseded)
+	<-c.writerDone
+	
+	pending := 0
+	for buffered := true; buffered; {
+		select {
+		case frame := <-c.writeCh:
+			if frame.control != 0 {
+				continue // Heartbeats are not forwarded
+			}
+			pending++
+			if !handoff {
+				continue
+			}
+			if err := next.WriteJSON(frame.value); err != nil {
+				log.Printf("ERROR: Failed to forward buffered message to new connection for user %s: %v", c.GetUserID(), err)
+			}
+		default:
+			buffered = false
 		}
-		
-		// writeCh will be closed by writeLoop goroutine
-	})
-	return err
+	}
+	if handoff {
+		log.Printf("Connection %s for user %s superseded by %s (%d buffered messages forwarded)", c.connectionID, c.GetUserID(), next.connectionID, pending)
+	} else {
+		log.Printf("Connection %s for user %s superseded by %s in another session or role (%d buffered messages dropped)", c.connectionID, c.GetUserID(), next.connectionID, pending)
+	}
+	
+	c.closeWithCode(CloseSuperseded, "superseded by new connection")
+}
+
+// closeWithCode sends a close frame with code and tears the socket down once the
+// client answers or supersedeGracePeriod passes
+// FUNCTIONAL DISCOVERY: The client echoes the close frame, which ends the handler's
+// read loop and cleans up; the timer only covers clients that never answer
+// TECHNICAL DISCOVERY: gorilla allows WriteControl alongside the writer goroutine
+func (c *Connection) closeWithCode(code int, reason string) {
+	if c.conn == nil {
+		_ = c.Close()
+		return
+	}
+	
+	closeFrame := websocket.FormatCloseMessage(code, reason)
+	if err := c.conn.WriteControl(websocket.CloseMessage, closeFrame, time.Now().Add(5*time.Second)); err != nil {
+		_ = c.Close()
+		return
+	}
+	time.AfterFunc(supersedeGracePeriod, func() { _ = c.Close() })
 }
 
 // Authentication state management
@@ -153,4 +450,64 @@ func (c *Connection) GetSessionID() string {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return c.sessionID
-}
\ No newline at end of file
+}
+
+// SetClientInfo records the client's network identity captured at upgrade time
+// FUNCTIONAL DISCOVERY: Stored on the connection so every message it sends can be
+// attributed to a device/IP during incident investigations
+func (c *Connection) SetClientInfo(clientIP, userAgent string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clientIP = clientIP
+	c.userAgent = userAgent
+}
+
+// Codec returns the wire encoding negotiated at upgrade time
+func (c *Connection) Codec() Codec {
+	return c.codec // Immutable after construction
+}
+
+// SetStrict turns on strict parsing of inbound frames, requested with strict=true
+// TECHNICAL DISCOVERY: Called before the connection is registered or read from, so
+// the flag is read without locking
+func (c *Connection) SetStrict(strict bool) {
+	c.strict = strict
+}
+
+// Strict reports whether inbound frames with unknown top-level fields are refused
+func (c *Connection) Strict() bool {
+	return c.strict
+}
+
+// SetAPIKey marks the connection as opened with key, whose scopes then limit it
+// TECHNICAL DISCOVERY: Called before the connection is registered or read from, so
+// the key is read without locking
+func (c *Connection) SetAPIKey(key *types.APIKey) {
+	c.apiKey = key
+}
+
+// APIKey returns the key the connection was opened with, or nil
+func (c *Connection) APIKey() *types.APIKey {
+	return c.apiKey
+}
+
+// allows reports whether the connection may use scope; only API keys are limited
+func (c *Connection) allows(scope string) bool {
+	return c.apiKey == nil || c.apiKey.HasScope(scope)
+}
+
+func (c *Connection) GetConnectionID() string {
+	return c.connectionID // Immutable after construction
+}
+
+func (c *Connection) GetClientIP() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clientIP
+}
+
+func (c *Connection) GetUserAgent() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.userAgent
+}
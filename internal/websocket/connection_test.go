@@ -198,6 +198,72 @@ func TestConnection_ConcurrentWrites(t *testing.T) {
 	wg.Wait()
 }
 
+// TestConnection_CloseDuringConcurrentWrites tests technical validation - closing under write load
+// never panics, and every write either lands or reports ErrConnectionClosed. Run with -race
+func TestConnection_CloseDuringConcurrentWrites(t *testing.T) {
+	wsConn := createTestWebSocketConnection(t)
+	defer func() { _ = wsConn.Close() }()
+
+	conn := NewConnection(wsConn)
+
+	const numGoroutines = 100
+	start := make(chan struct{})
+	errs := make(chan error, numGoroutines*20)
+	var wg sync.WaitGroup
+	wg.Add(numGoroutines)
+	for i := 0; i < numGoroutines; i++ {
+		go func(id int) {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 20; j++ {
+				errs <- conn.WriteJSON(map[string]interface{}{"worker": id, "message": j})
+			}
+		}(i)
+	}
+
+	// Close from several goroutines at once, as the read loop and API server do
+	var closers sync.WaitGroup
+	closers.Add(3)
+	close(start)
+	for i := 0; i < 3; i++ {
+		go func() {
+			defer closers.Done()
+			time.Sleep(time.Millisecond)
+			_ = conn.Close()
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		closers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Writers or closers blocked after Close")
+	}
+
+	close(errs)
+	for err := range errs {
+		if err != nil && err != ErrConnectionClosed {
+			t.Errorf("Expected nil or ErrConnectionClosed, got %v", err)
+		}
+	}
+	if err := conn.WriteJSON(map[string]interface{}{"type": "late"}); err != ErrConnectionClosed {
+		t.Errorf("Expected ErrConnectionClosed after Close returned, got %v", err)
+	}
+	if err := conn.ping(); err != ErrConnectionClosed {
+		t.Errorf("Expected ping after Close to fail with ErrConnectionClosed, got %v", err)
+	}
+	select {
+	case <-conn.writerDone:
+	case <-time.After(time.Second):
+		t.Error("Writer goroutine did not exit after Close")
+	}
+}
+
 func TestConnection_ConcurrentCredentialAccess(t *testing.T) {
 	wsConn := createTestWebSocketConnection(t)
 	defer func() { _ = wsConn.Close() }()
@@ -0,0 +1,176 @@
+package websocket
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+	"switchboard/internal/clock"
+)
+
+// DefaultDrainWindow is how long a drain spreads reconnects when given no window
+const DefaultDrainWindow = 30 * time.Second
+
+// Drainer moves a node's connections to other nodes ahead of maintenance
+// ARCHITECTURAL DISCOVERY: Only connections are moved. Sessions live in the shared
+// database, so nothing is ended here - clients rejoin the same session on another node
+// and replay history there
+// FUNCTIONAL DISCOVERY: Each connection is told to reconnect after its own random
+// delay within the window, so the other nodes see a spread of reconnects rather than
+// the whole node's clients at once; whoever is still connected when the window ends
+// is closed with 1001 (going away)
+type Drainer struct {
+	registry *Registry
+	window   time.Duration
+	clock    interfaces.Clock
+
+	mu         sync.Mutex
+	status     types.DrainStatus
+	closeTimer interfaces.Timer
+	generation int // Bumped by every Drain and Undrain, so a stale timer does nothing
+}
+
+// NewDrainer creates a drainer for registry's connections; window <= 0 means
+// DefaultDrainWindow
+func NewDrainer(registry *Registry, window time.Duration) *Drainer {
+	if window <= 0 {
+		window = DefaultDrainWindow
+	}
+	return &Drainer{
+		registry: registry,
+		window:   window,
+		clock:    clock.Real(),
+	}
+}
+
+// SetClock sets the clock the window is timed by
+// TECHNICAL DISCOVERY: Must be called before the first Drain; read without locking
+func (d *Drainer) SetClock(c interfaces.Clock) {
+	d.clock = c
+}
+
+// Draining reports whether new connections should be turned away
+func (d *Drainer) Draining() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status.Draining
+}
+
+// Status returns the drain state for the drain endpoints and /health
+func (d *Drainer) Status() types.DrainStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.status
+}
+
+// Drain starts draining, telling every connection when to reconnect; window <= 0
+// uses the drainer's default
+// FUNCTIONAL DISCOVERY: Draining again while already draining changes nothing and
+// returns the running drain, so a retried request does not restart the window
+func (d *Drainer) Drain(window time.Duration) types.DrainStatus {
+	if window <= 0 {
+		window = d.window
+	}
+
+	d.mu.Lock()
+	if d.status.Draining {
+		defer d.mu.Unlock()
+		return d.status
+	}
+	now := d.clock.Now()
+	d.generation++
+	generation := d.generation
+	d.status = types.DrainStatus{Draining: true, StartedAt: now, ClosesAt: now.Add(window)}
+	d.closeTimer = d.clock.AfterFunc(window, func() { d.closeRemaining(generation) })
+	d.mu.Unlock()
+
+	notified := 0
+	for _, conn := range d.registry.GetAllConnections() {
+		delay := time.Duration(rand.Int63n(int64(window)))
+		notice := drainNotice(map[string]interface{}{
+			"event":              "server_draining",
+			"reconnect_after_ms": delay.Milliseconds(),
+			"closes_at":          now.Add(window),
+		}, now)
+		if err := conn.WriteJSON(notice); err != nil {
+			log.Printf("Failed to send server_draining to %s: %v", conn.GetUserID(), err)
+			continue
+		}
+		notified++
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.generation == generation {
+		d.status.Notified = notified
+	}
+	log.Printf("Draining: %d connections told to reconnect within %v", notified, window)
+	return d.status
+}
+
+// Undrain cancels a drain and admits new connections again; it reports false when
+// the node was not draining
+// FUNCTIONAL DISCOVERY: Connections still open are told the drain was cancelled so
+// they stay; those that already reconnected elsewhere are not brought back
+func (d *Drainer) Undrain() (types.DrainStatus, bool) {
+	d.mu.Lock()
+	if !d.status.Draining {
+		defer d.mu.Unlock()
+		return d.status, false
+	}
+	d.generation++
+	if d.closeTimer != nil {
+		d.closeTimer.Stop()
+		d.closeTimer = nil
+	}
+	d.status = types.DrainStatus{}
+	now := d.clock.Now()
+	d.mu.Unlock()
+
+	for _, conn := range d.registry.GetAllConnections() {
+		if err := conn.WriteJSON(drainNotice(map[string]interface{}{"event": "drain_cancelled"}, now)); err != nil {
+			log.Printf("Failed to send drain_cancelled to %s: %v", conn.GetUserID(), err)
+		}
+	}
+	log.Printf("Drain cancelled")
+	return types.DrainStatus{}, true
+}
+
+// closeRemaining closes the connections still open when the window ends
+// TECHNICAL DISCOVERY: The node stays draining afterwards, so readiness keeps failing
+// until Undrain or the restart the drain was for
+func (d *Drainer) closeRemaining(generation int) {
+	d.mu.Lock()
+	if d.generation != generation {
+		d.mu.Unlock()
+		return // Cancelled, or replaced by a later drain
+	}
+	d.closeTimer = nil
+	d.mu.Unlock()
+
+	connections := d.registry.GetAllConnections()
+	for _, conn := range connections {
+		conn.closeWithCode(websocket.CloseGoingAway, "server draining")
+	}
+
+	d.mu.Lock()
+	if d.generation == generation {
+		d.status.Closed = len(connections)
+	}
+	d.mu.Unlock()
+	log.Printf("Drain window ended: closed %d remaining connections", len(connections))
+}
+
+// drainNotice wraps content in the system message announcing a drain event
+func drainNotice(content map[string]interface{}, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"type":      "system",
+		"context":   "server",
+		"content":   content,
+		"timestamp": now,
+	}
+}
@@ -0,0 +1,128 @@
+package websocket
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"switchboard/internal/testsupport"
+)
+
+// FUNCTIONAL VALIDATION TEST: Draining tells every connection when to reconnect,
+// refuses new upgrades and closes what is left with 1001 after the window
+func TestDrainer_DrainAndUndrain(t *testing.T) {
+	registry := NewRegistry()
+	handler := NewHandler(registry, &mockSessionManager{}, &mockDatabaseManager{}, &mockHub{})
+	drainer := NewDrainer(registry, time.Minute)
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC))
+	drainer.SetClock(clock)
+	handler.SetDrainer(drainer)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?role=student&session_id=session1&user_id="
+
+	dial := func(userID string) (*websocket.Conn, *http.Response, error) {
+		return websocket.DefaultDialer.Dial(baseURL+userID, nil)
+	}
+	// readEvent skips frames until a server event arrives
+	readEvent := func(conn *websocket.Conn, event string) map[string]interface{} {
+		t.Helper()
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var frame map[string]interface{}
+			if err := conn.ReadJSON(&frame); err != nil {
+				t.Fatalf("Expected %s, got %v", event, err)
+			}
+			content, _ := frame["content"].(map[string]interface{})
+			if frame["context"] == "server" && content["event"] == event {
+				return content
+			}
+		}
+	}
+
+	var clients []*websocket.Conn
+	for _, userID := range []string{"student1", "student2"} {
+		conn, _, err := dial(userID)
+		if err != nil {
+			t.Fatalf("Failed to connect %s: %v", userID, err)
+		}
+		defer func() { _ = conn.Close() }()
+		clients = append(clients, conn)
+	}
+	waitForConnections := func(want int) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for len(registry.GetAllConnections()) != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d registered connections, got %d", want, len(registry.GetAllConnections()))
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitForConnections(2)
+
+	status := drainer.Drain(0)
+	if !status.Draining || status.Notified != 2 || !status.ClosesAt.Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("Expected both connections notified with the default window, got %+v", status)
+	}
+	for _, client := range clients {
+		notice := readEvent(client, "server_draining")
+		if delay, _ := notice["reconnect_after_ms"].(float64); delay < 0 || delay >= float64(time.Minute.Milliseconds()) {
+			t.Errorf("Expected a reconnect delay within the window, got %v", notice["reconnect_after_ms"])
+		}
+	}
+	if again := drainer.Drain(time.Second); !again.ClosesAt.Equal(status.ClosesAt) {
+		t.Errorf("Expected a second drain to keep the running window, got %+v", again)
+	}
+
+	if _, resp, err := dial("student3"); err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for an upgrade while draining, got %v", err)
+	}
+
+	// The window ends with the remaining connections closed as going away
+	clock.Advance(time.Minute)
+	for _, client := range clients {
+		_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		var closeErr *websocket.CloseError
+		for {
+			_, _, err := client.ReadMessage()
+			if err == nil {
+				continue
+			}
+			if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+				t.Errorf("Expected close code %d, got %v", websocket.CloseGoingAway, err)
+			}
+			break
+		}
+	}
+	if status := drainer.Status(); !status.Draining || status.Closed != 2 {
+		t.Errorf("Expected the node still draining with 2 closed, got %+v", status)
+	}
+	waitForConnections(0)
+
+	// Undrain admits upgrades again and cancels a drain in progress
+	if _, cancelled := drainer.Undrain(); !cancelled {
+		t.Fatal("Expected Undrain to cancel the drain")
+	}
+	if _, cancelled := drainer.Undrain(); cancelled {
+		t.Error("Expected a second Undrain to report the node was not draining")
+	}
+	conn, _, err := dial("student1")
+	if err != nil {
+		t.Fatalf("Expected upgrades admitted after undrain, got %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	waitForConnections(1)
+
+	drainer.Drain(0)
+	readEvent(conn, "server_draining")
+	drainer.Undrain()
+	readEvent(conn, "drain_cancelled")
+	if clock.Pending() != 0 {
+		t.Errorf("Expected the close timer stopped, %d pending", clock.Pending())
+	}
+}
@@ -4,9 +4,10 @@ import "errors"
 
 // Connection-related errors
 var (
-	ErrConnectionClosed = errors.New("connection closed")
-	ErrWriteTimeout     = errors.New("write timeout after 5 seconds")
-	ErrInvalidJSON      = errors.New("invalid JSON data")
+	ErrConnectionClosed  = errors.New("connection closed")
+	ErrWriteTimeout      = errors.New("write timeout after 5 seconds")
+	ErrInvalidJSON       = errors.New("invalid JSON data")
+	ErrReplayBacklogFull = errors.New("too many live messages held during history replay")
 )
 
 // Registry-related errors
@@ -2,14 +2,19 @@ package websocket
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
+	"switchboard/internal/apikey"
 )
 
 // WebSocket upgrader with production-ready settings
@@ -22,6 +27,9 @@ var upgrader = websocket.Upgrader{
 		return true
 	},
 	HandshakeTimeout: 10 * time.Second,
+	// FUNCTIONAL DISCOVERY: The client's preference order wins; clients that send no
+	// Sec-WebSocket-Protocol header get the JSON default
+	Subprotocols: []string{SubprotocolJSON, SubprotocolMsgpack},
 }
 
 // Handler manages WebSocket connections and authentication
@@ -32,8 +40,28 @@ type Handler struct {
 	sessionManager interfaces.SessionManager   // Session validation and management
 	dbManager      interfaces.DatabaseManager  // Message history and persistence
 	hub            HubInterface                 // Message routing coordination
+	trustedProxies []*net.IPNet                 // Peers allowed to set X-Forwarded-For/-Proto
+	redactIPs      bool                         // Mask client IPs in log output
+	capabilities   *types.CompactCapabilities   // Sent in the "connected" message when set
+	historyBatch   int                          // History messages sent per batch
+	historyDelay   time.Duration                // Pause between history batches
+	maxReplay      int                          // Most recent messages replayed; 0 replays all
+	admission      *AdmissionPacer              // Upgrade pacing after startup; nil admits everyone
+	drainer        *Drainer                     // Turns upgrades away while draining; nil never drains
+	duplicates     DuplicateUserPolicy          // Same user_id from another IP; empty allows
+	mismatches     SessionMismatchPolicy        // Frames naming another session; empty rejects
+	heartbeat      func() (readTimeout, pingInterval time.Duration) // Read per deadline and ping; nil uses 60s/30s
+	apiKeys        interfaces.APIKeyManager     // Authenticates api_key handshakes; nil refuses them
+	drops          atomic.Int64                 // Connections lost without a close frame
+	bandwidth      *bandwidthTracker            // Per-session traffic until each session ends
 }
 
+// Heartbeat timing used until SetHeartbeat provides another
+const (
+	defaultReadTimeout  = 60 * time.Second
+	defaultPingInterval = 30 * time.Second
+)
+
 // HubInterface defines the hub methods needed by the WebSocket handler
 type HubInterface interface {
 	SendMessage(message *types.Message, senderID string) error
@@ -48,9 +76,121 @@ func NewHandler(registry *Registry, sessionManager interfaces.SessionManager, db
 		sessionManager: sessionManager,
 		dbManager:      dbManager,
 		hub:            hub,
+		redactIPs:      true,
+		historyBatch:   100,
+		historyDelay:   10 * time.Millisecond,
+		maxReplay:      500,
+		bandwidth:      newBandwidthTracker(),
 	}
 }
 
+// SetTrustedProxies configures which peers may supply X-Forwarded-For and X-Forwarded-Proto
+// TECHNICAL DISCOVERY: Must be called before serving; the slice is read without locking
+func (h *Handler) SetTrustedProxies(trustedProxies []*net.IPNet) {
+	h.trustedProxies = trustedProxies
+}
+
+// SetRedactIPs controls whether client IPs are masked in logs
+// FUNCTIONAL DISCOVERY: Only log output is affected; persisted metadata keeps the
+// full address for incident investigation
+func (h *Handler) SetRedactIPs(redact bool) {
+	h.redactIPs = redact
+}
+
+// SetHeartbeat makes each connection ask heartbeat for its read deadline and ping interval
+// ARCHITECTURAL DISCOVERY: Asked on every pong and every tick rather than once per
+// connection, so a configuration reload reaches connections that are already open
+// TECHNICAL DISCOVERY: Must be called before serving; heartbeat must be safe to call
+// concurrently, e.g. config.Store.Heartbeat
+func (h *Handler) SetHeartbeat(heartbeat func() (readTimeout, pingInterval time.Duration)) {
+	h.heartbeat = heartbeat
+}
+
+// heartbeatTimes returns the current read deadline extension and ping interval
+func (h *Handler) heartbeatTimes() (time.Duration, time.Duration) {
+	if h.heartbeat == nil {
+		return defaultReadTimeout, defaultPingInterval
+	}
+	return h.heartbeat()
+}
+
+// SetCapabilities sets the capabilities summarized in each "connected" message
+// TECHNICAL DISCOVERY: Must be called before serving; compacted once here rather than
+// per connection
+func (h *Handler) SetCapabilities(capabilities *types.Capabilities) {
+	compact := capabilities.Compact()
+	h.capabilities = &compact
+}
+
+// SetHistoryPacing configures how history replay is split into batches
+// FUNCTIONAL DISCOVERY: Pausing between batches keeps large replays from flooding
+// browser clients and lets live traffic to other connections interleave
+func (h *Handler) SetHistoryPacing(batchSize int, delay time.Duration) {
+	if batchSize > 0 {
+		h.historyBatch = batchSize
+	}
+	h.historyDelay = delay
+}
+
+// SetMaxReplay caps history replay at the most recent n visible messages; 0 replays all
+// FUNCTIONAL DISCOVERY: A new joiner of a 50k-message session needs recent context,
+// not the whole session; older messages stay reachable through the REST history
+// endpoint the history_truncated notice links to
+func (h *Handler) SetMaxReplay(n int) {
+	h.maxReplay = n
+}
+
+// SetAdmissionPacer paces new upgrades after startup
+// TECHNICAL DISCOVERY: Must be called before serving; the pointer is read without locking
+func (h *Handler) SetAdmissionPacer(pacer *AdmissionPacer) {
+	h.admission = pacer
+}
+
+// SetDrainer turns new upgrades away while drainer is draining
+// TECHNICAL DISCOVERY: Must be called before serving; the pointer is read without locking
+func (h *Handler) SetDrainer(drainer *Drainer) {
+	h.drainer = drainer
+}
+
+// SetAPIKeys lets handshakes authenticate with a session API key instead of user_id
+// TECHNICAL DISCOVERY: Must be called before serving; read without locking
+func (h *Handler) SetAPIKeys(apiKeys interfaces.APIKeyManager) {
+	h.apiKeys = apiKeys
+}
+
+// authenticateAPIKey resolves a handshake's key, answering the HTTP error itself on failure
+// FUNCTIONAL DISCOVERY: session_id may be left out since the key names its session;
+// naming a different session is refused rather than silently corrected
+func (h *Handler) authenticateAPIKey(w http.ResponseWriter, r *http.Request, secret, sessionID string) (*types.APIKey, bool) {
+	if h.apiKeys == nil {
+		reject(w, http.StatusUnauthorized, types.RejectAPIKeysDisabled, "API keys are not enabled on this server")
+		return nil, false
+	}
+	key, err := h.apiKeys.Authenticate(r.Context(), secret)
+	if errors.Is(err, interfaces.ErrInvalidAPIKey) {
+		reject(w, http.StatusUnauthorized, types.RejectInvalidAPIKey, "Invalid, expired or revoked API key")
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("API key authentication failed: %v", err)
+		reject(w, http.StatusInternalServerError, types.RejectAPIKeyFailed, "API key validation failed")
+		return nil, false
+	}
+	if sessionID != "" && sessionID != key.SessionID {
+		reject(w, http.StatusForbidden, types.RejectAPIKeyWrongSession, "API key does not belong to this session")
+		return nil, false
+	}
+	return key, true
+}
+
+// logIP returns the client IP in the form permitted by the privacy setting
+func (h *Handler) logIP(ip string) string {
+	if h.redactIPs {
+		return RedactIP(ip)
+	}
+	return ip
+}
+
 // HandleWebSocket handles WebSocket connection requests with comprehensive validation
 // ARCHITECTURAL DISCOVERY: Multi-stage validation (parameters -> session -> WebSocket -> auth -> registration)
 // ensures proper error handling and prevents invalid connections from consuming resources
@@ -60,40 +200,132 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	role := r.URL.Query().Get("role")
 	sessionID := r.URL.Query().Get("session_id")
 	
+	// A session API key replaces user_id and role with the key's synthetic instructor
+	// FUNCTIONAL DISCOVERY: The key's user is not on the roster, so the membership check
+	// below is skipped; Authenticate has already refused keys of ended sessions
+	var key *types.APIKey
+	if secret := apikey.FromRequest(r); secret != "" {
+		authenticated, ok := h.authenticateAPIKey(w, r, secret, sessionID)
+		if !ok {
+			return
+		}
+		key = authenticated
+		userID, role, sessionID = key.UserID(), "instructor", key.SessionID
+	}
+	
+	// A join code stands in for session_id; an explicit session_id wins
+	if key == nil && sessionID == "" {
+		if code := r.URL.Query().Get("join_code"); code != "" {
+			resolved, ok := h.resolveJoinCode(w, r, code)
+			if !ok {
+				return
+			}
+			sessionID = resolved
+		}
+	}
+	
 	if userID == "" || role == "" || sessionID == "" {
-		http.Error(w, "Missing required query parameters: user_id, role, session_id", http.StatusBadRequest)
+		reject(w, http.StatusBadRequest, types.RejectMissingParameters, "Missing required query parameters: user_id, role, session_id (or join_code)")
+		return
+	}
+	
+	// Refuse the namespace of server-generated senders before anything else
+	// FUNCTIONAL DISCOVERY: Audited, since a client claiming "system" or "sys:..." is
+	// trying to pass as the server rather than mistyping its ID
+	clientIP := ClientIP(r, h.trustedProxies)
+	if key == nil && types.IsReservedUserID(userID) {
+		h.auditImpersonation(sessionID, userID, role, reasonReservedUserID, clientIP, nil)
+		reject(w, http.StatusForbidden, types.RejectReservedUserID, "user_id is reserved for server-generated senders")
 		return
 	}
 	
 	// Validate user ID format using types package validation
 	// FUNCTIONAL DISCOVERY: Reuse validation logic from types package
 	// ensures consistent validation rules across all components
-	if !types.IsValidUserID(userID) {
-		http.Error(w, "Invalid user_id format", http.StatusBadRequest)
+	if key == nil && !types.IsValidUserID(userID) {
+		reject(w, http.StatusBadRequest, types.RejectInvalidUserID, "Invalid user_id format")
 		return
 	}
 	
 	// Validate role
 	if role != "student" && role != "instructor" {
-		http.Error(w, "Invalid role: must be 'student' or 'instructor'", http.StatusBadRequest)
+		reject(w, http.StatusBadRequest, types.RejectInvalidRole, "Invalid role: must be 'student' or 'instructor'")
+		return
+	}
+	
+	// Optional strict parsing of inbound frames
+	// FUNCTIONAL DISCOVERY: Off by default so production clients that send extra
+	// fields keep working; client developers opt in with strict=true
+	strict := false
+	if raw := r.URL.Query().Get("strict"); raw != "" {
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			reject(w, http.StatusBadRequest, types.RejectInvalidStrict, "Invalid strict: must be true or false")
+			return
+		}
+		strict = parsed
+	}
+	
+	// Optional state a reconnecting client already holds, so history replay can skip it
+	sync := parseSyncState(r.URL.Query().Get("sync_state"))
+	
+	// Turn upgrades away while draining
+	// FUNCTIONAL DISCOVERY: The load balancer stops routing here once readiness fails,
+	// but upgrades already on their way still arrive; a short Retry-After sends them
+	// back through the load balancer to another node
+	if h.drainer != nil && h.drainer.Draining() {
+		w.Header().Set("Retry-After", "1")
+		reject(w, http.StatusServiceUnavailable, types.RejectDraining, "Server is draining; reconnect to another node")
 		return
 	}
 	
+	// Pace upgrades while the server is warming up after a restart
+	// FUNCTIONAL DISCOVERY: Checked before session validation so turned-away clients
+	// cost no session or database work
+	if h.admission != nil {
+		if ok, retryAfter := h.admission.Admit(); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter/time.Second)))
+			reject(w, http.StatusServiceUnavailable, types.RejectStartingUp, "Server is starting up; retry after the Retry-After delay")
+			return
+		}
+	}
+	
 	// Validate session membership using session manager
 	// ARCHITECTURAL DISCOVERY: Delegate session validation to SessionManager interface
 	// enables different validation strategies (cache-first, database-only, etc.)
-	if err := h.sessionManager.ValidateSessionMembership(sessionID, userID, role); err != nil {
-		switch err {
-		case interfaces.ErrSessionNotFound:
-			http.Error(w, "Session not found or ended", http.StatusNotFound)
-		case interfaces.ErrUnauthorized:
-			http.Error(w, "Not authorized to join this session", http.StatusForbidden)
+	// Key connections were authenticated above instead
+	var membershipErr error
+	if key == nil {
+		membershipErr = h.sessionManager.ValidateSessionMembership(sessionID, userID, role)
+	}
+	if err := membershipErr; err != nil {
+		switch {
+		case errors.Is(err, interfaces.ErrSessionNotFound):
+			reject(w, http.StatusNotFound, types.RejectSessionNotFound, "Session not found")
+		case errors.Is(err, interfaces.ErrSessionEnded):
+			h.rejectEndedSession(w, r, sessionID)
+		case errors.Is(err, interfaces.ErrInstructorNotMember):
+			reject(w, http.StatusForbidden, types.RejectInstructorNotMember, "Instructor is not a member of this session")
+		case errors.Is(err, interfaces.ErrUnauthorized):
+			reject(w, http.StatusForbidden, types.RejectNotAuthorized, "Not authorized to join this session")
 		default:
-			http.Error(w, "Session validation failed", http.StatusInternalServerError)
+			reject(w, http.StatusInternalServerError, types.RejectValidationFailed, "Session validation failed")
 		}
 		return
 	}
 	
+	// Session-scoped uniqueness: the same user_id already connected from elsewhere
+	// ARCHITECTURAL DISCOVERY: Checked before the upgrade so a rejected newcomer gets a
+	// plain HTTP 409 and the connected user is never disturbed
+	if existing := h.duplicateConnection(userID, sessionID, clientIP); existing != nil {
+		if h.duplicates == DuplicateUserReject {
+			h.auditImpersonation(sessionID, userID, role, reasonDuplicateUser, clientIP, h.existingDetails(existing))
+			reject(w, http.StatusConflict, types.RejectDuplicateUser, "user_id is already connected to this session from another address")
+			return
+		}
+		h.recordConnectionEvent(types.SessionEventConnectionReplaced, sessionID, userID, role, clientIP, h.existingDetails(existing))
+	}
+	
 	// Upgrade to WebSocket
 	// FUNCTIONAL DISCOVERY: WebSocket upgrade after validation prevents resource waste
 	// on invalid requests while providing proper HTTP error responses
@@ -105,6 +337,12 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	
 	// Create connection wrapper with single-writer pattern from Step 2.1
 	wsConn := NewConnection(conn)
+	wsConn.SetClientInfo(clientIP, r.UserAgent())
+	wsConn.SetStrict(strict)
+	wsConn.SetAPIKey(key)
+	// TECHNICAL DISCOVERY: Set before anything is enqueued, so the writer goroutine
+	// sees it through the channel and reads it without locking
+	wsConn.traffic = h.bandwidth.session(sessionID)
 	
 	// Set credentials after successful validation
 	// TECHNICAL DISCOVERY: Authentication state set immediately after validation
@@ -115,6 +353,11 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	// Hold back live messages until the history replay below has finished
+	// TECHNICAL DISCOVERY: Started before registration so a broadcast routed to the new
+	// connection can never overtake the handshake or older history
+	wsConn.beginReplay()
+	
 	// Register connection with registry from Step 2.2
 	// FUNCTIONAL DISCOVERY: Registration after authentication ensures only valid
 	// connections are tracked and available for message routing
@@ -124,12 +367,17 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		_ = wsConn.Close()
 		return
 	}
-	log.Printf("SUCCESS: Connection registered successfully - userID: %s, role: %s, sessionID: %s", userID, role, sessionID)
+	log.Printf("SUCCESS: Connection registered successfully - userID: %s, role: %s, sessionID: %s, connectionID: %s, clientIP: %s, scheme: %s", userID, role, sessionID, wsConn.GetConnectionID(), h.logIP(clientIP), ClientScheme(r, h.trustedProxies))
+	
+	// Greet the client before any history so it can feature-detect first
+	// FUNCTIONAL DISCOVERY: Enqueued on the single writer ahead of the history replay,
+	// so "connected" is always the first frame a client receives
+	h.sendConnected(wsConn)
 	
 	// Send session history in background
 	// ARCHITECTURAL DISCOVERY: Asynchronous history replay prevents blocking
 	// connection setup while ensuring message history is delivered
-	go h.sendSessionHistory(wsConn)
+	go h.sendSessionHistory(wsConn, sync)
 	
 	// Start connection monitoring and message handling
 	// TECHNICAL DISCOVERY: Separate goroutine for connection lifecycle management
@@ -137,10 +385,63 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	go h.handleConnection(wsConn)
 }
 
+// rejectEndedSession answers 410 Gone with the session's end time
+// FUNCTIONAL DISCOVERY: Students following an old link see when the class ended
+// rather than a generic error; the end time is left out if it cannot be read
+func (h *Handler) rejectEndedSession(w http.ResponseWriter, r *http.Request, sessionID string) {
+	rejection := &types.HandshakeRejection{
+		Error:  "Session has ended",
+		Reason: types.RejectSessionEnded,
+		Code:   http.StatusGone,
+	}
+	if session, err := h.sessionManager.GetSession(r.Context(), sessionID); err == nil && session != nil {
+		rejection.EndTime = session.EndTime
+	}
+	writeRejection(w, rejection)
+}
+
+// sendConnected sends the handshake system message with the compact capabilities
+func (h *Handler) sendConnected(conn *Connection) {
+	content := map[string]interface{}{
+		"event":            "connected",
+		"connection_id":    conn.GetConnectionID(),
+		"encoding":         conn.Codec().Name(),
+		"protocol_version": types.ProtocolVersion,
+		"locked":           h.sessionManager.IsSessionLocked(conn.GetSessionID()), // Joining mid-lock
+		"strict":           conn.Strict(),
+	}
+	if h.capabilities != nil {
+		content["capabilities"] = h.capabilities
+	}
+	
+	connectedMsg := map[string]interface{}{
+		"type":      "system",
+		"context":   "connected",
+		"content":   content,
+		"timestamp": time.Now(),
+	}
+	if err := conn.writeReplay(connectedMsg); err != nil {
+		log.Printf("Failed to send connected message to %s: %v", conn.GetUserID(), err)
+	}
+}
+
 // sendSessionHistory sends all historical messages to new connection with role-based filtering
 // FUNCTIONAL DISCOVERY: Role-based message filtering at delivery time ensures students
 // only see relevant messages while instructors have full visibility
-func (h *Handler) sendSessionHistory(conn *Connection) {
+// FUNCTIONAL DISCOVERY: With a usable sync_state only the messages the client is
+// missing are replayed; messages it already had keep the annotations and reaction
+// tallies they arrived with
+func (h *Handler) sendSessionHistory(conn *Connection, sync historySync) {
+	// Live messages held back during the replay go out after history_complete, or
+	// after the failure notice if history could not be loaded
+	defer conn.endReplay()
+	
+	// API keys without read_history join live traffic only
+	if !conn.allows(types.APIKeyScopeReadHistory) {
+		h.sendHistoryComplete(conn, historySync{})
+		return
+	}
+	
 	sessionID := conn.GetSessionID()
 	userID := conn.GetUserID()
 	role := conn.GetRole()
@@ -158,7 +459,7 @@ func (h *Handler) sendSessionHistory(conn *Connection) {
 			},
 			"timestamp": time.Now(),
 		}
-		if err := conn.WriteJSON(errorMsg); err != nil {
+		if err := conn.writeReplay(errorMsg); err != nil {
 			log.Printf("Failed to send auth error message: %v", err)
 		}
 		return
@@ -167,46 +468,142 @@ func (h *Handler) sendSessionHistory(conn *Connection) {
 	// Filter messages based on role for security and relevance
 	// FUNCTIONAL DISCOVERY: Server-side filtering prevents sensitive message exposure
 	// and reduces bandwidth for student connections with large message histories
+	visible := make([]*types.Message, 0, len(messages))
 	for _, message := range messages {
-		shouldSend := false
-		
-		switch role {
-		case "instructor":
-			// Instructors see all messages for classroom management
-			shouldSend = true
-		case "student":
-			// Students see messages involving them or broadcasts
-			if message.FromUser == userID || 
-			   (message.ToUser != nil && *message.ToUser == userID) ||
-			   message.ToUser == nil { // Broadcast message
-				shouldSend = true
+		if message.VisibleTo(userID, role) {
+			visible = append(visible, message)
+		}
+	}
+	
+	// Instructors get every instructor's annotations and the reaction tallies inline;
+	// history still replays without them if they cannot be loaded
+	if role == "instructor" {
+		if annotations, err := h.dbManager.GetSessionAnnotations(ctx, sessionID); err != nil {
+			log.Printf("Failed to get message annotations for session %s: %v", sessionID, err)
+		} else {
+			types.AttachAnnotations(visible, annotations)
+		}
+		if reactions, err := h.dbManager.GetSessionReactionCounts(ctx, sessionID); err != nil {
+			log.Printf("Failed to get message reactions for session %s: %v", sessionID, err)
+		} else {
+			types.AttachReactions(visible, reactions)
+		}
+	}
+	
+	// Only what the client is missing, when its sync_state can be used
+	visible = sync.missing(visible)
+	if sync.rejected != "" {
+		h.sendSyncStateRejected(conn, sync.rejected)
+	}
+	
+	// Only the most recent of those, the rest left to the REST history endpoint
+	if h.maxReplay > 0 && len(visible) > h.maxReplay {
+		omitted := len(visible) - h.maxReplay
+		visible = visible[omitted:]
+		h.sendHistoryTruncated(conn, omitted, visible[0].ID)
+	}
+	
+	// Replay in paced batches with a progress event after each one
+	// FUNCTIONAL DISCOVERY: Counts refer to the filtered history, so sent reaches total
+	// exactly when the last batch is out
+	for start := 0; start < len(visible); start += h.historyBatch {
+		end := min(start+h.historyBatch, len(visible))
+		for _, message := range visible[start:end] {
+			if err := conn.writeReplay(message); err != nil {
+				log.Printf("Failed to send history message: %v", err)
+				return
 			}
 		}
 		
-		if shouldSend {
-			if err := conn.WriteJSON(message); err != nil {
-				log.Printf("Failed to send history message: %v", err)
+		progressMsg := map[string]interface{}{
+			"type": "system",
+			"content": map[string]interface{}{
+				"event": "history_progress",
+				"sent":  end,
+				"total": len(visible),
+			},
+			"timestamp": time.Now(),
+		}
+		if err := conn.writeReplay(progressMsg); err != nil {
+			log.Printf("Failed to send history progress: %v", err)
+			return
+		}
+		
+		if end < len(visible) && h.historyDelay > 0 {
+			select {
+			case <-time.After(h.historyDelay):
+			case <-conn.ctx.Done():
 				return
 			}
 		}
 	}
 	
-	// Send history complete notification for client synchronization
-	// TECHNICAL DISCOVERY: Explicit completion signal enables client-side loading states
-	// and prevents confusion about history replay status
-	completeMsg := map[string]interface{}{
+	h.sendHistoryComplete(conn, sync)
+}
+
+// sendHistoryTruncated tells the client how many older messages the replay leaves out
+// and where to page back through them
+// FUNCTIONAL DISCOVERY: history_url asks GET /api/sessions/{id}/history for the page
+// before the oldest replayed message, as of now; clients follow it with before set to
+// the oldest message of each page until has_more is false
+// TECHNICAL DISCOVERY: Pages are the replay window's size but no larger than the
+// endpoint serves, so a window above types.MaxHistoryPage still links to a valid page
+func (h *Handler) sendHistoryTruncated(conn *Connection, omitted int, oldestID string) {
+	limit := h.maxReplay
+	if limit > types.MaxHistoryPage {
+		limit = types.MaxHistoryPage
+	}
+	page := url.Values{
+		"viewer": {conn.GetUserID()},
+		"as_of":  {time.Now().UTC().Format(time.RFC3339Nano)},
+		"before": {oldestID},
+		"limit":  {strconv.Itoa(limit)},
+	}
+	notice := map[string]interface{}{
 		"type": "system",
 		"content": map[string]interface{}{
-			"event":   "history_complete",
-			"message": "Message history loaded",
+			"event":       "history_truncated",
+			"omitted":     omitted,
+			"replayed":    h.maxReplay,
+			"history_url": "/api/sessions/" + url.PathEscape(conn.GetSessionID()) + "/history?" + page.Encode(),
 		},
 		"timestamp": time.Now(),
 	}
-	if err := conn.WriteJSON(completeMsg); err != nil {
+	if err := conn.writeReplay(notice); err != nil {
+		log.Printf("Failed to send history_truncated to %s: %v", conn.GetUserID(), err)
+	}
+}
+
+// sendHistoryComplete tells the client the replay is over, and how many messages its
+// sync_state let the replay skip
+// TECHNICAL DISCOVERY: Explicit completion signal enables client-side loading states
+// and prevents confusion about history replay status
+func (h *Handler) sendHistoryComplete(conn *Connection, sync historySync) {
+	content := map[string]interface{}{
+		"event":   "history_complete",
+		"message": "Message history loaded",
+	}
+	if sync.state != nil {
+		content["skipped"] = sync.skipped
+	}
+	completeMsg := map[string]interface{}{
+		"type":      "system",
+		"content":   content,
+		"timestamp": time.Now(),
+	}
+	if err := conn.writeReplay(completeMsg); err != nil {
 		log.Printf("Failed to send auth complete message: %v", err)
 	}
 }
 
+// ConnectionDrops returns how many connections were lost since start - the network
+// went away, the heartbeat timed out or a write failed - rather than closed by either side
+// FUNCTIONAL DISCOVERY: A steady trickle is normal for classroom Wi-Fi; a jump across
+// many clients at once points at the network or this server
+func (h *Handler) ConnectionDrops() int64 {
+	return h.drops.Load()
+}
+
 // handleConnection manages the connection lifecycle with heartbeat monitoring
 // ARCHITECTURAL DISCOVERY: Single goroutine per connection handles both heartbeat
 // and message reading to prevent goroutine proliferation and resource leaks
@@ -218,18 +615,23 @@ func (h *Handler) handleConnection(conn *Connection) {
 		log.Printf("DEBUG: Unregistering connection - userID: %s, role: %s, sessionID: %s", conn.GetUserID(), conn.GetRole(), conn.GetSessionID())
 		h.registry.UnregisterConnection(conn)
 		_ = conn.Close()
+		if failedAt := conn.WriteFailedAt(); !failedAt.IsZero() {
+			log.Printf("Connection %s for user %s unregistered %v after its first failed write", conn.GetConnectionID(), conn.GetUserID(), time.Since(failedAt))
+		}
 		log.Printf("DEBUG: Connection cleanup complete - userID: %s", conn.GetUserID())
 	}()
 	
 	// Set up ping/pong heartbeat monitoring
-	// TECHNICAL DISCOVERY: 60-second read deadline with 30-second ping interval
-	// provides reliable connection health monitoring for classroom environments
-	if err := conn.conn.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
+	// TECHNICAL DISCOVERY: 60-second read deadline with 30-second ping interval by
+	// default provides reliable connection health monitoring for classroom environments
+	readTimeout, pingInterval := h.heartbeatTimes()
+	if err := conn.conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
 		log.Printf("Failed to set read deadline: %v", err)
 		return
 	}
 	conn.conn.SetPongHandler(func(string) error {
-		if err := conn.conn.SetReadDeadline(time.Now().Add(60 * time.Second)); err != nil {
+		readTimeout, _ := h.heartbeatTimes()
+		if err := conn.conn.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
 			log.Printf("Failed to set read deadline in pong handler: %v", err)
 			return err
 		}
@@ -239,15 +641,22 @@ func (h *Handler) handleConnection(conn *Connection) {
 	// Start ping ticker for heartbeat monitoring
 	// FUNCTIONAL DISCOVERY: Separate ticker goroutine enables consistent heartbeat
 	// timing independent of message processing or client responsiveness
-	ticker := time.NewTicker(30 * time.Second)
+	ticker := time.NewTicker(pingInterval)
 	defer ticker.Stop()
 	
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
-				// Send ping message with write timeout
-				if err := conn.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(10*time.Second)); err != nil {
+				// A reloaded interval takes effect from the next beat
+				if _, interval := h.heartbeatTimes(); interval != pingInterval {
+					pingInterval = interval
+					ticker.Reset(pingInterval)
+				}
+				// Queue ping for the connection's writer goroutine
+				// TECHNICAL DISCOVERY: Writing the ping here raced Close and the writer on
+				// the socket; a timeout just skips this beat, a closed connection stops
+				if err := conn.ping(); err == ErrConnectionClosed {
 					return
 				}
 			case <-conn.ctx.Done():
@@ -265,21 +674,68 @@ func (h *Handler) handleConnection(conn *Connection) {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
 			}
+			if conn.dropped(err) {
+				h.drops.Add(1)
+			}
 			break
 		}
 		
-		if messageType == websocket.TextMessage {
+		// FUNCTIONAL DISCOVERY: Only frames matching the negotiated codec are accepted -
+		// text for JSON connections, binary for MessagePack connections
+		if messageType != conn.Codec().FrameType() {
+			conn.countReceived(types.BandwidthUnparsed, len(data))
+		}
+		if messageType == conn.Codec().FrameType() {
 			// Parse incoming message
-			var message types.Message
-			if err := json.Unmarshal(data, &message); err != nil {
+			message, err := decodeMessage(conn.Codec(), data, conn.Strict())
+			conn.countReceived(receivedKind(message, err), len(data))
+			if err != nil {
 				log.Printf("Failed to parse message from %s: %v", conn.GetUserID(), err)
+				var unknownField *UnknownFieldError
+				if errors.As(err, &unknownField) {
+					if err := conn.WriteJSON(unknownFieldNotice(unknownField)); err != nil {
+						log.Printf("Failed to send error message to %s: %v", conn.GetUserID(), err)
+					}
+				}
+				continue
+			}
+			
+			// FUNCTIONAL DISCOVERY: The hub stamps the sender's own ID anyway; claiming a
+			// system sender is refused outright so the attempt is visible
+			if message.FromUser != "" && types.IsReservedUserID(message.FromUser) {
+				log.Printf("Refused message from %s claiming reserved from_user %q", conn.GetUserID(), message.FromUser)
+				h.auditImpersonation(conn.GetSessionID(), conn.GetUserID(), conn.GetRole(), reasonReservedSender, conn.GetClientIP(), map[string]interface{}{"from_user": message.FromUser})
+				if err := conn.WriteJSON(reservedSenderNotice(message.FromUser)); err != nil {
+					log.Printf("Failed to send error message to %s: %v", conn.GetUserID(), err)
+				}
 				continue
 			}
 			
-			log.Printf("Received message from %s: %s", conn.GetUserID(), string(data))
+			// FUNCTIONAL DISCOVERY: The connection's session is authoritative; a frame naming
+			// another one is audited and, unless legacy clients are being corrected, refused
+			if message.SessionID != "" && message.SessionID != conn.GetSessionID() {
+				log.Printf("Frame from %s in session %s claimed session_id %q", conn.GetUserID(), conn.GetSessionID(), message.SessionID)
+				h.auditImpersonation(conn.GetSessionID(), conn.GetUserID(), conn.GetRole(), reasonSessionMismatch, conn.GetClientIP(), map[string]interface{}{"session_id": message.SessionID})
+				if h.mismatches != SessionMismatchCorrect {
+					if err := conn.WriteJSON(sessionMismatchNotice(message.SessionID)); err != nil {
+						log.Printf("Failed to send error message to %s: %v", conn.GetUserID(), err)
+					}
+					continue
+				}
+			}
+			message.SessionID = conn.GetSessionID()
+			
+			if !conn.allows(types.APIKeyScopeSendMessages) {
+				if err := conn.WriteJSON(scopeDeniedNotice(types.APIKeyScopeSendMessages)); err != nil {
+					log.Printf("Failed to send error message to %s: %v", conn.GetUserID(), err)
+				}
+				continue
+			}
+			
+			log.Printf("Received %s message from %s (%d bytes)", conn.Codec().Name(), conn.GetUserID(), len(data))
 			
 			// Forward message to hub for routing
-			if err := h.hub.SendMessage(&message, conn.GetUserID()); err != nil {
+			if err := h.hub.SendMessage(message, conn.GetUserID()); err != nil {
 				log.Printf("Failed to route message from %s: %v", conn.GetUserID(), err)
 				// Send error response back to client
 				errorMsg := map[string]interface{}{
@@ -297,4 +753,27 @@ func (h *Handler) handleConnection(conn *Connection) {
 			}
 		}
 	}
-}
\ No newline at end of file
+}
+// receivedKind names the message type an inbound frame is counted under
+// TECHNICAL DISCOVERY: Only known types get their own entry, so a client sending
+// made-up types cannot grow a session's counts without bound
+func receivedKind(message *types.Message, decodeErr error) string {
+	if decodeErr != nil || message == nil || !types.IsValidMessageType(message.Type) {
+		return types.BandwidthUnparsed
+	}
+	return message.Type
+}
+
+// scopeDeniedNotice is the system error sent when an API key connection lacks scope
+func scopeDeniedNotice(scope string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "system",
+		"content": map[string]interface{}{
+			"event":   "message_error",
+			"message": "Message not sent: this API key lacks the " + scope + " scope",
+			"error":   "missing scope " + scope,
+			"code":    types.ErrorCodeScopeDenied,
+		},
+		"timestamp": time.Now(),
+	}
+}
@@ -2,16 +2,20 @@ package websocket
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"switchboard/internal/api"
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
 )
@@ -19,13 +23,21 @@ import (
 // Mock implementations for testing
 type mockSessionManager struct {
 	validateFunc func(sessionID, userID, role string) error
+	session      *types.Session // Returned by GetSession when set
 }
 
 func (m *mockSessionManager) CreateSession(ctx context.Context, name string, createdBy string, studentIDs []string) (*types.Session, error) {
 	return nil, errors.New("not implemented")
 }
 
+func (m *mockSessionManager) ValidateSession(ctx context.Context, name string, createdBy string, studentIDs []string) (*types.SessionValidation, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockSessionManager) GetSession(ctx context.Context, sessionID string) (*types.Session, error) {
+	if m.session != nil {
+		return m.session, nil
+	}
 	return nil, errors.New("not implemented")
 }
 
@@ -44,8 +56,44 @@ func (m *mockSessionManager) ValidateSessionMembership(sessionID, userID, role s
 	return nil
 }
 
+func (m *mockSessionManager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) (*types.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSessionManager) UpdateSessionMetadata(ctx context.Context, sessionID string, patch map[string]*string) (*types.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSessionManager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) (*types.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSessionManager) IsSessionLocked(sessionID string) bool {
+	return false
+}
+
+func (m *mockSessionManager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) (*types.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSessionManager) TransferOwnership(ctx context.Context, sessionID, newOwner, actor string) (*types.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSessionManager) SetSessionDuration(ctx context.Context, sessionID string, durationMinutes int) (*types.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockSessionManager) ListUserSessions(ctx context.Context, userID, role string) ([]*types.Session, error) {
+	return nil, errors.New("not implemented")
+}
+
 type mockDatabaseManager struct {
 	getHistoryFunc func(ctx context.Context, sessionID string) ([]*types.Message, error)
+	annotations    []*types.MessageAnnotation
+	reactions      map[string]map[string]int
+	events         chan *types.SessionEvent // Receives recorded session events when set
+	joinCodes      map[string][]string      // Active session IDs by join_code
 }
 
 func (m *mockDatabaseManager) CreateSession(ctx context.Context, session *types.Session) error {
@@ -79,6 +127,116 @@ func (m *mockDatabaseManager) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
+func (m *mockDatabaseManager) ImportMessages(ctx context.Context, messages []*types.Message) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) GetMessageMetadata(ctx context.Context, messageID string) (*types.MessageMetadata, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) HealthStatus() types.DatabaseHealth {
+	return types.DatabaseHealth{}
+}
+
+func (m *mockDatabaseManager) ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) TransferSessionOwner(ctx context.Context, sessionID, previousOwner, newOwner, actor string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) {
+	return nil, nil // No routing snapshot, as for sessions older than snapshots
+}
+
+func (m *mockDatabaseManager) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) SetMessageAnnotation(ctx context.Context, sessionID string, annotation *types.MessageAnnotation) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) GetSessionAnnotations(ctx context.Context, sessionID string) ([]*types.MessageAnnotation, error) {
+	return m.annotations, nil
+}
+
+func (m *mockDatabaseManager) SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) GetSessionReactionCounts(ctx context.Context, sessionID string) (map[string]map[string]int, error) {
+	return m.reactions, nil
+}
+
+func (m *mockDatabaseManager) GetSessionHistoryAsOf(ctx context.Context, sessionID string, asOf time.Time) ([]*types.Message, error) {
+	if m.getHistoryFunc != nil {
+		return m.getHistoryFunc(ctx, sessionID)
+	}
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) GetStudentThread(ctx context.Context, sessionID, studentID, afterID string, limit int) ([]*types.Message, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) GetAnalyticsBuckets(ctx context.Context, sessionID, userID string) ([]*types.AnalyticsBucket, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) SetSessionStudents(ctx context.Context, sessionID string, studentIDs []string) error {
+	return errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) ActiveSessionsByMetadata(ctx context.Context, filters map[string]string) ([]string, error) {
+	if m.joinCodes == nil {
+		return nil, errors.New("not implemented")
+	}
+	return m.joinCodes[filters[types.MetadataKeyJoinCode]], nil
+}
+
+func (m *mockDatabaseManager) GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockDatabaseManager) RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error {
+	if m.events == nil {
+		return errors.New("not implemented")
+	}
+	m.events <- event
+	return nil
+}
+
+func (m *mockDatabaseManager) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (m *mockDatabaseManager) Close() error {
 	return nil
 }
@@ -193,6 +351,16 @@ func TestHandler_QueryParameterValidation(t *testing.T) {
 			},
 			expectedStatus: http.StatusBadRequest,
 		},
+		{
+			name: "invalid strict",
+			queryParams: map[string]string{
+				"user_id":    "user123",
+				"role":       "student",
+				"session_id": "session123",
+				"strict":     "yes please",
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
 	}
 	
 	for _, tt := range tests {
@@ -223,6 +391,7 @@ func TestHandler_SessionValidationIntegration(t *testing.T) {
 		name           string
 		validateFunc   func(sessionID, userID, role string) error
 		expectedStatus int
+		expectedReason string
 	}{
 		{
 			name: "session not found",
@@ -230,6 +399,15 @@ func TestHandler_SessionValidationIntegration(t *testing.T) {
 				return interfaces.ErrSessionNotFound
 			},
 			expectedStatus: http.StatusNotFound,
+			expectedReason: types.RejectSessionNotFound,
+		},
+		{
+			name: "session ended",
+			validateFunc: func(sessionID, userID, role string) error {
+				return interfaces.ErrSessionEnded
+			},
+			expectedStatus: http.StatusGone,
+			expectedReason: types.RejectSessionEnded,
 		},
 		{
 			name: "unauthorized access",
@@ -237,6 +415,15 @@ func TestHandler_SessionValidationIntegration(t *testing.T) {
 				return interfaces.ErrUnauthorized
 			},
 			expectedStatus: http.StatusForbidden,
+			expectedReason: types.RejectNotAuthorized,
+		},
+		{
+			name: "instructor not a member",
+			validateFunc: func(sessionID, userID, role string) error {
+				return interfaces.ErrInstructorNotMember
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedReason: types.RejectInstructorNotMember,
 		},
 		{
 			name: "validation error",
@@ -244,12 +431,17 @@ func TestHandler_SessionValidationIntegration(t *testing.T) {
 				return errors.New("internal error")
 			},
 			expectedStatus: http.StatusInternalServerError,
+			expectedReason: types.RejectValidationFailed,
 		},
 	}
+	endTime := time.Date(2026, 3, 2, 11, 0, 0, 0, time.UTC)
 	
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sessionManager := &mockSessionManager{validateFunc: tt.validateFunc}
+			sessionManager := &mockSessionManager{
+				validateFunc: tt.validateFunc,
+				session:      &types.Session{ID: "session456", Status: "ended", EndTime: &endTime},
+			}
 			handler := NewHandler(registry, sessionManager, dbManager, &mockHub{})
 			
 			// Create valid request (without WebSocket headers to avoid upgrade issues)
@@ -261,6 +453,16 @@ func TestHandler_SessionValidationIntegration(t *testing.T) {
 			if rec.Code != tt.expectedStatus {
 				t.Errorf("Expected status %d, got %d", tt.expectedStatus, rec.Code)
 			}
+			var rejection types.HandshakeRejection
+			if err := json.Unmarshal(rec.Body.Bytes(), &rejection); err != nil {
+				t.Fatalf("Expected a JSON rejection body, got %q: %v", rec.Body.String(), err)
+			}
+			if rejection.Reason != tt.expectedReason || rejection.Code != tt.expectedStatus {
+				t.Errorf("Expected reason %q with code %d, got %+v", tt.expectedReason, tt.expectedStatus, rejection)
+			}
+			if ended := tt.expectedStatus == http.StatusGone; ended != (rejection.EndTime != nil) || ended && !rejection.EndTime.Equal(endTime) {
+				t.Errorf("Expected the end time only for an ended session, got %v", rejection.EndTime)
+			}
 		})
 	}
 }
@@ -305,6 +507,56 @@ func TestHandler_ConnectionRegistration(t *testing.T) {
 	}
 }
 
+// TestHandler_ConnectionDrops tests functional validation - a client that closes cleanly
+// is not a drop, one whose socket just goes away is
+func TestHandler_ConnectionDrops(t *testing.T) {
+	registry := NewRegistry()
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error { return nil },
+	}
+	handler := NewHandler(registry, sessionManager, &mockDatabaseManager{}, &mockHub{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	
+	connect := func(userID string) *websocket.Conn {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?role=student&session_id=session456&user_id=" + userID
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect %s: %v", userID, err)
+		}
+		return conn
+	}
+	waitGone := func(userID string) {
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			if _, exists := registry.GetUserConnection(userID); !exists {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s was never unregistered", userID)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	
+	clean := connect("leaver")
+	time.Sleep(50 * time.Millisecond)
+	_ = clean.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	waitGone("leaver")
+	_ = clean.Close()
+	if drops := handler.ConnectionDrops(); drops != 0 {
+		t.Errorf("Expected a clean close not counted, got %d drops", drops)
+	}
+	
+	lost := connect("wifi-lost")
+	time.Sleep(50 * time.Millisecond)
+	_ = lost.UnderlyingConn().Close()
+	waitGone("wifi-lost")
+	if drops := handler.ConnectionDrops(); drops != 1 {
+		t.Errorf("Expected the lost socket counted, got %d drops", drops)
+	}
+}
+
 func TestHandler_HistoryReplay(t *testing.T) {
 	registry := NewRegistry()
 	sessionManager := &mockSessionManager{
@@ -383,106 +635,1055 @@ func TestHandler_HistoryReplay(t *testing.T) {
 	}
 }
 
-// Technical Validation Tests (Race Detection)
-func TestHandler_ConcurrentConnections(t *testing.T) {
-	registry := NewRegistry()
+// FUNCTIONAL VALIDATION TEST: Annotations and reaction tallies ride along in instructor history only
+func TestHandler_HistoryAnnotations(t *testing.T) {
 	sessionManager := &mockSessionManager{
 		validateFunc: func(sessionID, userID, role string) error {
 			return nil
 		},
 	}
-	dbManager := &mockDatabaseManager{}
-	handler := NewHandler(registry, sessionManager, dbManager, &mockHub{})
-	
-	// Test that Handler can handle concurrent requests without panicking
-	// Focus on architectural validation rather than network timing
+	dbManager := &mockDatabaseManager{
+		getHistoryFunc: func(ctx context.Context, sessionID string) ([]*types.Message, error) {
+			return []*types.Message{{
+				ID:        "msg1",
+				Type:      "instructor_broadcast",
+				FromUser:  "instructor1",
+				SessionID: "session456",
+				Content:   map[string]interface{}{"text": "Welcome to class"},
+				Context:   "general",
+				Timestamp: time.Now(),
+			}}, nil
+		},
+		annotations: []*types.MessageAnnotation{
+			{MessageID: "msg1", InstructorID: "instructor1", Tags: []string{"star"}},
+		},
+		reactions: map[string]map[string]int{"msg1": {"thumbs_up": 3}},
+	}
+	handler := NewHandler(NewRegistry(), sessionManager, dbManager, &mockHub{})
 	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
 	defer server.Close()
 	
-	const numRequests = 10
-	var wg sync.WaitGroup
-	errors := make(chan error, numRequests)
-	
-	// Make concurrent HTTP requests (not WebSocket connections to avoid timing issues)
-	for i := 0; i < numRequests; i++ {
-		wg.Add(1)
-		go func(id int) {
-			defer wg.Done()
-			
-			// Make HTTP request without WebSocket upgrade
-			url := server.URL + "?user_id=user" + fmt.Sprintf("%d", id) + "&role=student&session_id=session456"
-			resp, err := http.Get(url)
-			if err != nil {
-				errors <- err
-				return
+	replayed := func(userID, role string) map[string]interface{} {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID + "&role=" + role + "&session_id=session456"
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer func() { _ = conn.Close() }()
+		
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		for {
+			var msg map[string]interface{}
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("History message not received: %v", err)
 			}
-			_ = resp.Body.Close()
-			
-			// Should get 400 (bad request) because no WebSocket headers, but no panic
-			if resp.StatusCode != 400 {
-				errors <- fmt.Errorf("unexpected status: %d", resp.StatusCode)
+			if msg["id"] == "msg1" {
+				return msg
 			}
-		}(i)
-	}
-	
-	wg.Wait()
-	close(errors)
-	
-	// Check for any errors during concurrent access
-	for err := range errors {
-		if err != nil {
-			t.Errorf("Concurrent request failed: %v", err)
 		}
 	}
 	
-	// Handler should remain functional after concurrent access
-	if handler.registry == nil {
-		t.Error("Handler registry should remain valid after concurrent access")
+	instructorView := replayed("instructor2", "instructor")
+	if annotations, ok := instructorView["annotations"].([]interface{}); !ok || len(annotations) != 1 {
+		t.Errorf("Instructor history should carry annotations, got %v", annotations)
+	}
+	if reactions, _ := instructorView["reactions"].(map[string]interface{}); reactions["thumbs_up"] != float64(3) {
+		t.Errorf("Instructor history should carry reaction tallies, got %v", instructorView["reactions"])
+	}
+	studentView := replayed("student1", "student")
+	if annotations, present := studentView["annotations"]; present {
+		t.Errorf("Student history must not carry annotations, got %v", annotations)
+	}
+	if reactions, present := studentView["reactions"]; present {
+		t.Errorf("Student history must not carry reaction tallies, got %v", reactions)
 	}
 }
 
-func TestHandler_HeartbeatMonitoring(t *testing.T) {
-	registry := NewRegistry()
+func TestHandler_ConnectedHandshake(t *testing.T) {
 	sessionManager := &mockSessionManager{
 		validateFunc: func(sessionID, userID, role string) error {
 			return nil
 		},
 	}
-	dbManager := &mockDatabaseManager{}
-	handler := NewHandler(registry, sessionManager, dbManager, &mockHub{})
-	
+	handler := NewHandler(NewRegistry(), sessionManager, &mockDatabaseManager{}, &mockHub{})
+	handler.SetCapabilities(&types.Capabilities{
+		ProtocolVersions: []int{types.ProtocolVersion},
+		Encodings:        []string{SubprotocolJSON, SubprotocolMsgpack},
+		Features:         map[string]bool{types.FeatureTranscripts: true, types.FeatureMsgpack: true, types.FeatureAcks: false},
+	})
 	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
 	defer server.Close()
 	
 	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=user123&role=student&session_id=session456"
-	
 	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
 	if err != nil {
 		t.Fatalf("Failed to connect: %v", err)
 	}
 	defer func() { _ = conn.Close() }()
 	
-	// Test that connection can be established and maintained briefly
-	// Full heartbeat testing would require real-time delays that slow down tests
-	time.Sleep(100 * time.Millisecond)
+	// The handshake must arrive before history_complete
+	var first struct {
+		Type    string `json:"type"`
+		Content struct {
+			Event        string                    `json:"event"`
+			ConnectionID string                    `json:"connection_id"`
+			Encoding     string                    `json:"encoding"`
+			Capabilities types.CompactCapabilities `json:"capabilities"`
+		} `json:"content"`
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := conn.ReadJSON(&first); err != nil {
+		t.Fatalf("Failed to read first message: %v", err)
+	}
+	if first.Type != "system" || first.Content.Event != "connected" || first.Content.ConnectionID == "" {
+		t.Fatalf("Expected connected system message first, got %+v", first)
+	}
+	if first.Content.Encoding != SubprotocolJSON {
+		t.Errorf("Expected encoding %s, got %s", SubprotocolJSON, first.Content.Encoding)
+	}
+	if got := first.Content.Capabilities.Features; len(got) != 2 || got[0] != types.FeatureMsgpack || got[1] != types.FeatureTranscripts {
+		t.Errorf("Expected sorted enabled features [msgpack transcripts], got %v", got)
+	}
+}
+
+func TestHandler_StrictMode(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	forwarded := make(chan *types.Message, 10)
+	hub := &mockHub{sendMessageFunc: func(message *types.Message, senderID string) error {
+		forwarded <- message
+		return nil
+	}}
+	handler := NewHandler(NewRegistry(), sessionManager, &mockDatabaseManager{}, hub)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
 	
-	// Verify connection is registered (indicates handleConnection is running)
-	_, exists := registry.GetUserConnection("user123")
-	if !exists {
-		t.Error("Connection should be registered and maintained")
+	type systemMessage struct {
+		Type    string                 `json:"type"`
+		Content map[string]interface{} `json:"content"`
+	}
+	connect := func(userID, strict string) (*websocket.Conn, systemMessage) {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID + "&role=student&session_id=session456" + strict
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		var connected systemMessage
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := conn.ReadJSON(&connected); err != nil {
+			t.Fatalf("Failed to read handshake: %v", err)
+		}
+		return conn, connected
 	}
+	typo := `{"type": "instructor_inbox", "context": "general", "contnet": {"text": "help"}}`
 	
-	// Test cleanup happens when connection closes
-	_ = conn.Close()
-	time.Sleep(100 * time.Millisecond)
+	strict, connected := connect("strict_user", "&strict=true")
+	if connected.Content["strict"] != true {
+		t.Errorf("Expected the handshake to confirm strict mode, got %v", connected.Content)
+	}
+	if err := strict.WriteMessage(websocket.TextMessage, []byte(typo)); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+	for {
+		var msg systemMessage
+		_ = strict.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := strict.ReadJSON(&msg); err != nil {
+			t.Fatalf("Expected an UNKNOWN_FIELD error: %v", err)
+		}
+		if msg.Content["code"] == types.ErrorCodeUnknownField {
+			if msg.Type != "system" || msg.Content["field"] != "contnet" {
+				t.Errorf("Expected the error to name contnet, got %+v", msg)
+			}
+			break
+		}
+	}
+	select {
+	case msg := <-forwarded:
+		t.Fatalf("Refused frame was forwarded to the hub: %+v", msg)
+	default:
+	}
 	
-	_, exists = registry.GetUserConnection("user123")
-	if exists {
-		t.Error("Connection should be cleaned up after close")
+	// Valid frames still go through on a strict connection
+	if err := strict.WriteMessage(websocket.TextMessage, []byte(`{"type": "instructor_inbox", "content": {"text": "help"}}`)); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+	select {
+	case msg := <-forwarded:
+		if msg.Content["text"] != "help" {
+			t.Errorf("Unexpected forwarded message: %+v", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Valid frame was not forwarded on a strict connection")
+	}
+	
+	// Lenient connections keep ignoring unknown fields
+	lenient, connected := connect("lenient_user", "")
+	if connected.Content["strict"] != false {
+		t.Errorf("Expected strict mode off by default, got %v", connected.Content)
+	}
+	if err := lenient.WriteMessage(websocket.TextMessage, []byte(typo)); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+	select {
+	case msg := <-forwarded:
+		if msg.Content != nil {
+			t.Errorf("Expected the misspelled content to be dropped, got %+v", msg.Content)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Lenient connection did not forward the frame")
 	}
 }
 
-// Helper function
-func stringPtr(s string) *string {
-	return &s
-}
\ No newline at end of file
+// Technical Validation Tests (Race Detection)
+func TestHandler_PacedHistoryReplay(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	var history []*types.Message
+	for i := 1; i <= 5; i++ {
+		history = append(history, &types.Message{
+			ID:        fmt.Sprintf("history%d", i),
+			Type:      "instructor_broadcast",
+			FromUser:  "instructor1",
+			SessionID: "session456",
+			Content:   map[string]interface{}{"text": "old"},
+			Timestamp: time.Now().Add(-time.Hour),
+		})
+	}
+	dbManager := &mockDatabaseManager{
+		getHistoryFunc: func(ctx context.Context, sessionID string) ([]*types.Message, error) {
+			return history, nil
+		},
+	}
+	
+	registry := NewRegistry()
+	handler := NewHandler(registry, sessionManager, dbManager, &mockHub{})
+	handler.SetHistoryPacing(2, 100*time.Millisecond)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=user123&role=student&session_id=session456"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	
+	// Label each frame by message ID, or by system event
+	readLabel := func() string {
+		var msg struct {
+			ID      string                 `json:"id"`
+			Content map[string]interface{} `json:"content"`
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Failed to read frame: %v", err)
+		}
+		if msg.ID != "" {
+			return msg.ID
+		}
+		if msg.Content["event"] == "history_progress" {
+			return fmt.Sprintf("progress %v/%v", msg.Content["sent"], msg.Content["total"])
+		}
+		return fmt.Sprint(msg.Content["event"])
+	}
+	
+	var got []string
+	for len(got) == 0 || got[len(got)-1] != "progress 2/5" {
+		got = append(got, readLabel())
+	}
+	
+	// A broadcast arriving mid-replay is held until the history is complete
+	live, ok := registry.GetUserConnection("user123")
+	if !ok {
+		t.Fatal("Connection should be registered during replay")
+	}
+	if err := live.WriteJSON(&types.Message{ID: "live1", Type: "instructor_broadcast", FromUser: "instructor1", SessionID: "session456"}); err != nil {
+		t.Fatalf("Live write during replay failed: %v", err)
+	}
+	for got[len(got)-1] != "live1" {
+		got = append(got, readLabel())
+	}
+	
+	want := []string{
+		"connected",
+		"history1", "history2", "progress 2/5",
+		"history3", "history4", "progress 4/5",
+		"history5", "progress 5/5",
+		"history_complete", "live1",
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected frames %v, got %v", want, got)
+	}
+	
+	// After history_complete live writes go straight through
+	if err := live.WriteJSON(&types.Message{ID: "live2", Type: "instructor_broadcast", FromUser: "instructor1", SessionID: "session456"}); err != nil {
+		t.Fatalf("Live write after replay failed: %v", err)
+	}
+	if label := readLabel(); label != "live2" {
+		t.Errorf("Expected live2 after replay, got %s", label)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: A usable sync_state replays only what the client missed,
+// and any other falls back to the full replay after a sync_state_rejected notice
+func TestHandler_SyncStateReplay(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	syncedAt := time.Now().Add(-30 * time.Minute)
+	editedAt := syncedAt.Add(time.Minute)
+	var history []*types.Message
+	for i := 1; i <= 5; i++ {
+		history = append(history, &types.Message{
+			ID:        fmt.Sprintf("history%d", i),
+			Type:      "instructor_broadcast",
+			FromUser:  "instructor1",
+			SessionID: "session456",
+			Content:   map[string]interface{}{"text": "old"},
+			Timestamp: time.Now().Add(-time.Hour),
+		})
+	}
+	history[0].Revision, history[0].EditedAt = 1, &editedAt // Edited after the client synced
+	dbManager := &mockDatabaseManager{
+		getHistoryFunc: func(ctx context.Context, sessionID string) ([]*types.Message, error) {
+			return history, nil
+		},
+	}
+	
+	handler := NewHandler(NewRegistry(), sessionManager, dbManager, &mockHub{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	
+	// Labels every frame up to history_complete by message ID or system event
+	replay := func(userID, syncState string) ([]string, map[string]interface{}) {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID + "&role=student&session_id=session456&sync_state=" + url.QueryEscape(syncState)
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer func() { _ = conn.Close() }()
+		var labels []string
+		for {
+			var msg struct {
+				ID      string                 `json:"id"`
+				Content map[string]interface{} `json:"content"`
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Failed to read frame: %v", err)
+			}
+			switch {
+			case msg.ID != "":
+				labels = append(labels, msg.ID)
+			case msg.Content["event"] == "sync_state_rejected":
+				labels = append(labels, fmt.Sprint("rejected ", msg.Content["reason"]))
+			case msg.Content["event"] == "history_complete":
+				return labels, msg.Content
+			}
+		}
+	}
+	
+	state := &types.SyncState{LastMessageID: "history3", SyncedAt: syncedAt}
+	labels, complete := replay("user1", state.Encode())
+	if strings.Join(labels, ",") != "history1,history4,history5" || complete["skipped"] != float64(2) {
+		t.Errorf("Expected the edited message and those after the cursor, got %v (%v)", labels, complete)
+	}
+	
+	state.SyncedAt = editedAt.Add(time.Minute)
+	if labels, _ := replay("user2", state.Encode()); strings.Join(labels, ",") != "history4,history5" {
+		t.Errorf("Expected edits the client already saw skipped, got %v", labels)
+	}
+	
+	full := "history1,history2,history3,history4,history5"
+	for name, tc := range map[string]struct{ state, reason string }{
+		"unknown cursor": {(&types.SyncState{LastMessageID: "purged"}).Encode(), types.SyncStateUnknownCursor},
+		"unparsable":     {"not base64!", types.SyncStateUnparsable},
+		"too large":      {strings.Repeat("A", types.MaxSyncStateBytes+1), types.SyncStateTooLarge},
+	} {
+		labels, complete := replay("user_"+strings.ReplaceAll(name, " ", "_"), tc.state)
+		if strings.Join(labels, ",") != "rejected "+tc.reason+","+full {
+			t.Errorf("%s: expected a rejection and the full replay, got %v", name, labels)
+		}
+		if _, reported := complete["skipped"]; reported {
+			t.Errorf("%s: expected no skipped count without a usable state, got %v", name, complete)
+		}
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Replay is capped at the most recent messages, at the limit
+// and over it, with and without a sync_state cursor
+func TestHandler_MaxReplay(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	var history []*types.Message
+	for i := 1; i <= 5; i++ {
+		history = append(history, &types.Message{
+			ID:        fmt.Sprintf("history%d", i),
+			Type:      "instructor_broadcast",
+			FromUser:  "instructor1",
+			SessionID: "session456",
+			Content:   map[string]interface{}{"text": "old"},
+			Timestamp: time.Now().Add(-time.Hour),
+		})
+	}
+	history = append(history, &types.Message{ // Never visible to the students below
+		ID:        "private",
+		Type:      "inbox_response",
+		FromUser:  "instructor1",
+		ToUser:    func() *string { s := "someone_else"; return &s }(),
+		SessionID: "session456",
+		Content:   map[string]interface{}{"text": "old"},
+		Timestamp: time.Now().Add(-time.Hour),
+	})
+	dbManager := &mockDatabaseManager{
+		getHistoryFunc: func(ctx context.Context, sessionID string) ([]*types.Message, error) {
+			return history, nil
+		},
+	}
+	
+	handler := NewHandler(NewRegistry(), sessionManager, dbManager, &mockHub{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	
+	// Labels every frame up to history_complete, keeping the truncation notice
+	replay := func(userID, syncState string) ([]string, map[string]interface{}) {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID + "&role=student&session_id=session456&sync_state=" + url.QueryEscape(syncState)
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		defer func() { _ = conn.Close() }()
+		var labels []string
+		var truncated map[string]interface{}
+		for {
+			var msg struct {
+				ID      string                 `json:"id"`
+				Content map[string]interface{} `json:"content"`
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Failed to read frame: %v", err)
+			}
+			switch {
+			case msg.ID != "":
+				labels = append(labels, msg.ID)
+			case msg.Content["event"] == "history_truncated":
+				labels = append(labels, "truncated")
+				truncated = msg.Content
+			case msg.Content["event"] == "history_complete":
+				return labels, truncated
+			}
+		}
+	}
+	
+	// Exactly at the limit, counting only visible messages
+	handler.SetMaxReplay(5)
+	if labels, truncated := replay("user1", ""); strings.Join(labels, ",") != "history1,history2,history3,history4,history5" || truncated != nil {
+		t.Errorf("Expected the whole visible history at the limit, got %v", labels)
+	}
+	
+	handler.SetMaxReplay(3)
+	labels, truncated := replay("user2", "")
+	if strings.Join(labels, ",") != "truncated,history3,history4,history5" {
+		t.Fatalf("Expected the notice and the three most recent, got %v", labels)
+	}
+	if truncated["omitted"] != float64(2) || truncated["replayed"] != float64(3) {
+		t.Errorf("Expected 2 omitted of a 3-message window, got %v", truncated)
+	}
+	link, err := url.Parse(truncated["history_url"].(string))
+	if err != nil || link.Path != "/api/sessions/session456/history" {
+		t.Fatalf("Expected a link to the REST history endpoint, got %v", truncated["history_url"])
+	}
+	if page := link.Query(); page.Get("viewer") != "user2" || page.Get("before") != "history3" || page.Get("limit") != "3" || page.Get("as_of") == "" {
+		t.Errorf("Expected the page before the oldest replayed message, got %v", page)
+	}
+	
+	// The window applies to what a sync_state leaves to replay
+	state := &types.SyncState{LastMessageID: "history1"}
+	labels, truncated = replay("user3", state.Encode())
+	if strings.Join(labels, ",") != "truncated,history3,history4,history5" || truncated["omitted"] != float64(1) {
+		t.Errorf("Expected one missed message left out, got %v %v", labels, truncated)
+	}
+	state.LastMessageID = "history3"
+	if labels, truncated := replay("user4", state.Encode()); strings.Join(labels, ",") != "history4,history5" || truncated != nil {
+		t.Errorf("Expected a short resume untouched by the window, got %v", labels)
+	}
+	
+	handler.SetMaxReplay(0)
+	if labels, _ := replay("user5", ""); len(labels) != 5 {
+		t.Errorf("Expected no window when unlimited, got %v", labels)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: A replay window above the REST page cap still links to
+// a page GET /api/sessions/{id}/history serves
+func TestHandler_MaxReplayAboveHistoryPage(t *testing.T) {
+	window := types.MaxHistoryPage + 1
+	start := time.Now().Add(-time.Hour)
+	var history []*types.Message
+	for i := 1; i <= window+types.MaxHistoryPage+1; i++ {
+		history = append(history, &types.Message{
+			ID:        fmt.Sprintf("history%d", i),
+			Type:      "instructor_broadcast",
+			FromUser:  "instructor1",
+			SessionID: "session456",
+			Content:   map[string]interface{}{"text": "old"},
+			Timestamp: start.Add(time.Duration(i) * time.Millisecond),
+		})
+	}
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+		session: &types.Session{ID: "session456", StartTime: start, StudentIDs: []string{"student1"}, Status: "active"},
+	}
+	dbManager := &mockDatabaseManager{
+		getHistoryFunc: func(ctx context.Context, sessionID string) ([]*types.Message, error) {
+			return history, nil
+		},
+	}
+	
+	registry := NewRegistry()
+	handler := NewHandler(registry, sessionManager, dbManager, &mockHub{})
+	handler.SetMaxReplay(window)
+	mux := http.NewServeMux()
+	mux.Handle("/api/", api.NewServer(sessionManager, dbManager, registry))
+	mux.HandleFunc("/ws", handler.HandleWebSocket)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?user_id=student1&role=student&session_id=session456"
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	var historyURL string
+	for historyURL == "" {
+		var msg struct {
+			Content map[string]interface{} `json:"content"`
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Failed to read frame before history_truncated: %v", err)
+		}
+		if msg.Content["event"] == "history_truncated" {
+			historyURL, _ = msg.Content["history_url"].(string)
+		}
+	}
+	
+	resp, err := http.Get(server.URL + historyURL)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", historyURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected history_url to be served, got status %d", resp.StatusCode)
+	}
+	var page api.HistoryAsOfResponse
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		t.Fatalf("Failed to decode history page: %v", err)
+	}
+	if len(page.Messages) != types.MaxHistoryPage || !page.HasMore {
+		t.Fatalf("Expected a full page with more before it, got %d messages (has_more %v)", len(page.Messages), page.HasMore)
+	}
+	if newest := page.Messages[len(page.Messages)-1].ID; newest != fmt.Sprintf("history%d", types.MaxHistoryPage+1) {
+		t.Errorf("Expected the page to end just before the oldest replayed message, got %s", newest)
+	}
+}
+
+func TestHandler_ConcurrentConnections(t *testing.T) {
+	registry := NewRegistry()
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	dbManager := &mockDatabaseManager{}
+	handler := NewHandler(registry, sessionManager, dbManager, &mockHub{})
+	
+	// Test that Handler can handle concurrent requests without panicking
+	// Focus on architectural validation rather than network timing
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	
+	const numRequests = 10
+	var wg sync.WaitGroup
+	errors := make(chan error, numRequests)
+	
+	// Make concurrent HTTP requests (not WebSocket connections to avoid timing issues)
+	for i := 0; i < numRequests; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			
+			// Make HTTP request without WebSocket upgrade
+			url := server.URL + "?user_id=user" + fmt.Sprintf("%d", id) + "&role=student&session_id=session456"
+			resp, err := http.Get(url)
+			if err != nil {
+				errors <- err
+				return
+			}
+			_ = resp.Body.Close()
+			
+			// Should get 400 (bad request) because no WebSocket headers, but no panic
+			if resp.StatusCode != 400 {
+				errors <- fmt.Errorf("unexpected status: %d", resp.StatusCode)
+			}
+		}(i)
+	}
+	
+	wg.Wait()
+	close(errors)
+	
+	// Check for any errors during concurrent access
+	for err := range errors {
+		if err != nil {
+			t.Errorf("Concurrent request failed: %v", err)
+		}
+	}
+	
+	// Handler should remain functional after concurrent access
+	if handler.registry == nil {
+		t.Error("Handler registry should remain valid after concurrent access")
+	}
+}
+
+func TestHandler_HeartbeatMonitoring(t *testing.T) {
+	registry := NewRegistry()
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	dbManager := &mockDatabaseManager{}
+	handler := NewHandler(registry, sessionManager, dbManager, &mockHub{})
+	
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=user123&role=student&session_id=session456"
+	
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	
+	// Test that connection can be established and maintained briefly
+	// Full heartbeat testing would require real-time delays that slow down tests
+	time.Sleep(100 * time.Millisecond)
+	
+	// Verify connection is registered (indicates handleConnection is running)
+	_, exists := registry.GetUserConnection("user123")
+	if !exists {
+		t.Error("Connection should be registered and maintained")
+	}
+	
+	// Test cleanup happens when connection closes
+	_ = conn.Close()
+	time.Sleep(100 * time.Millisecond)
+	
+	_, exists = registry.GetUserConnection("user123")
+	if exists {
+		t.Error("Connection should be cleaned up after close")
+	}
+}
+
+// stallableConn lets a test freeze a server-side socket's writes, as when the client's
+// laptop sleeps and the peer stops acknowledging data
+// TECHNICAL DISCOVERY: Once stalled, writes go to a net.Pipe nobody reads, so they block
+// until the write deadline exactly like a full TCP send buffer would
+type stallableConn struct {
+	net.Conn
+	mu      sync.Mutex
+	stalled net.Conn
+}
+
+func (c *stallableConn) stall() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stalled, _ = net.Pipe()
+}
+
+func (c *stallableConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	stalled := c.stalled
+	c.mu.Unlock()
+	if stalled != nil {
+		return stalled.Write(p)
+	}
+	return c.Conn.Write(p)
+}
+
+func (c *stallableConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	stalled := c.stalled
+	c.mu.Unlock()
+	if stalled != nil {
+		_ = stalled.SetWriteDeadline(t)
+	}
+	return c.Conn.SetWriteDeadline(t)
+}
+
+func (c *stallableConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	stalled := c.stalled
+	c.mu.Unlock()
+	if stalled != nil {
+		_ = stalled.SetDeadline(t)
+	}
+	return c.Conn.SetDeadline(t)
+}
+
+// stallableListener records the connections it accepts so a test can stall them
+type stallableListener struct {
+	net.Listener
+	accepted chan *stallableConn
+}
+
+func (l *stallableListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	stallable := &stallableConn{Conn: conn}
+	l.accepted <- stallable
+	return stallable, nil
+}
+
+// TestHandler_HalfDeadConnectionCleanup tests functional validation - a failed write unregisters within one write timeout
+func TestHandler_HalfDeadConnectionCleanup(t *testing.T) {
+	registry := NewRegistry()
+	handler := NewHandler(registry, &mockSessionManager{}, &mockDatabaseManager{}, &mockHub{})
+	
+	var left sync.WaitGroup
+	left.Add(1)
+	registry.OnUnregister(func(conn *Connection) { left.Done() })
+	
+	server := httptest.NewUnstartedServer(http.HandlerFunc(handler.HandleWebSocket))
+	listener := &stallableListener{Listener: server.Listener, accepted: make(chan *stallableConn, 1)}
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+	
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=user123&role=student&session_id=session456"
+	client, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	serverSide := <-listener.accepted
+	
+	// Wait for the handshake so the stall hits a routed message, not the replay
+	for {
+		var message map[string]interface{}
+		if err := client.ReadJSON(&message); err != nil {
+			t.Fatalf("Failed to read handshake: %v", err)
+		}
+		if content, _ := message["content"].(map[string]interface{}); content["event"] == "history_complete" {
+			break
+		}
+	}
+	conn, exists := registry.GetUserConnection("user123")
+	if !exists {
+		t.Fatal("Connection should be registered")
+	}
+	
+	// The client goes silent: its socket stays open, but nothing written reaches it
+	serverSide.stall()
+	stalledAt := time.Now()
+	if err := conn.WriteJSON(map[string]interface{}{"type": "request", "content": map[string]interface{}{"text": "still there?"}}); err != nil {
+		t.Fatalf("WriteJSON should queue the message: %v", err)
+	}
+	
+	unregistered := make(chan struct{})
+	go func() {
+		left.Wait()
+		close(unregistered)
+	}()
+	bound := writeTimeout + time.Second
+	select {
+	case <-unregistered:
+	case <-time.After(bound):
+		t.Fatalf("Half-dead connection still registered %v after the stall", bound)
+	}
+	
+	if _, exists := registry.GetUserConnection("user123"); exists {
+		t.Error("Expected the user unregistered after the failed write")
+	}
+	if failedAt := conn.WriteFailedAt(); failedAt.Before(stalledAt) {
+		t.Errorf("Expected the failed write recorded after the stall, got %v", failedAt)
+	}
+	if err := conn.WriteJSON(map[string]interface{}{"type": "request"}); err != ErrConnectionClosed {
+		t.Errorf("Expected later writes refused with ErrConnectionClosed, got %v", err)
+	}
+}
+
+// Helper function
+func stringPtr(s string) *string {
+	return &s
+}
+// stubAPIKeys authenticates a fixed set of secrets
+type stubAPIKeys map[string]*types.APIKey
+
+func (s stubAPIKeys) CreateKey(ctx context.Context, sessionID, createdBy, name string, scopes []string, ttl time.Duration) (string, *types.APIKey, error) {
+	return "", nil, errors.New("not implemented")
+}
+
+func (s stubAPIKeys) ListKeys(ctx context.Context, sessionID string) ([]*types.APIKey, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s stubAPIKeys) RevokeKey(ctx context.Context, sessionID, keyID string) error {
+	return errors.New("not implemented")
+}
+
+func (s stubAPIKeys) Authenticate(ctx context.Context, secret string) (*types.APIKey, error) {
+	if key, exists := s[secret]; exists {
+		return key, nil
+	}
+	return nil, interfaces.ErrInvalidAPIKey
+}
+
+// FUNCTIONAL VALIDATION TEST: Key handshakes skip the roster and are limited by scope
+func TestHandler_APIKeyHandshake(t *testing.T) {
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return interfaces.ErrUnauthorized // Nobody is on the roster
+		},
+	}
+	dbManager := &mockDatabaseManager{
+		getHistoryFunc: func(ctx context.Context, sessionID string) ([]*types.Message, error) {
+			return []*types.Message{{ID: "msg1", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1", SessionID: sessionID, Content: map[string]interface{}{"text": "Welcome"}}}, nil
+		},
+	}
+	forwarded := make(chan string, 10)
+	hub := &mockHub{sendMessageFunc: func(message *types.Message, senderID string) error {
+		forwarded <- senderID
+		return nil
+	}}
+	handler := NewHandler(NewRegistry(), sessionManager, dbManager, hub)
+	handler.SetAPIKeys(stubAPIKeys{
+		"sbk_bot":    {ID: "bot", SessionID: "session456", Scopes: []string{types.APIKeyScopeSendMessages}},
+		"sbk_reader": {ID: "reader", SessionID: "session456", Scopes: []string{types.APIKeyScopeReadHistory}},
+	})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	
+	// readUntilHistoryComplete returns the IDs of replayed messages
+	readUntilHistoryComplete := func(conn *websocket.Conn) []string {
+		var replayed []string
+		for {
+			var msg map[string]interface{}
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Expected history_complete: %v", err)
+			}
+			if id, ok := msg["id"].(string); ok {
+				replayed = append(replayed, id)
+			}
+			if content, ok := msg["content"].(map[string]interface{}); ok && content["event"] == "history_complete" {
+				return replayed
+			}
+		}
+	}
+	
+	// A send-only key joins without history and sends as its synthetic user
+	bot, _, err := websocket.DefaultDialer.Dial(baseURL, http.Header{"X-API-Key": {"sbk_bot"}})
+	if err != nil {
+		t.Fatalf("Expected the key to connect: %v", err)
+	}
+	defer func() { _ = bot.Close() }()
+	if replayed := readUntilHistoryComplete(bot); len(replayed) != 0 {
+		t.Errorf("Expected no history without read_history, got %v", replayed)
+	}
+	if err := bot.WriteMessage(websocket.TextMessage, []byte(`{"type": "instructor_broadcast", "content": {"text": "Graded"}}`)); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+	select {
+	case senderID := <-forwarded:
+		if senderID != "apikey:bot" {
+			t.Errorf("Expected the key's synthetic user as sender, got %q", senderID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Frame from a send_messages key was not forwarded")
+	}
+	
+	// A read-only key replays history but may not send
+	reader, _, err := websocket.DefaultDialer.Dial(baseURL+"?session_id=session456&api_key=sbk_reader", nil)
+	if err != nil {
+		t.Fatalf("Expected the key to connect: %v", err)
+	}
+	defer func() { _ = reader.Close() }()
+	if replayed := readUntilHistoryComplete(reader); len(replayed) != 1 {
+		t.Errorf("Expected the history replay, got %v", replayed)
+	}
+	if err := reader.WriteMessage(websocket.TextMessage, []byte(`{"type": "instructor_broadcast", "content": {"text": "Hi"}}`)); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+	var refusal map[string]interface{}
+	_ = reader.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if err := reader.ReadJSON(&refusal); err != nil {
+		t.Fatalf("Expected a SCOPE_DENIED error: %v", err)
+	}
+	if content, _ := refusal["content"].(map[string]interface{}); content["code"] != types.ErrorCodeScopeDenied {
+		t.Errorf("Expected SCOPE_DENIED, got %v", refusal)
+	}
+	select {
+	case senderID := <-forwarded:
+		t.Errorf("Refused frame was forwarded for %s", senderID)
+	default:
+	}
+	
+	// Unknown keys and keys of another session are refused before the upgrade
+	for query, want := range map[string]int{
+		"?api_key=sbk_unknown":                  http.StatusUnauthorized,
+		"?api_key=sbk_bot&session_id=session789": http.StatusForbidden,
+	} {
+		_, resp, err := websocket.DefaultDialer.Dial(baseURL+query, nil)
+		if err == nil || resp == nil || resp.StatusCode != want {
+			t.Errorf("Expected status %d for %s, got %v", want, query, resp)
+		}
+	}
+}
+
+// TestHandler_Bandwidth tests functional validation - frames are counted per connection
+// and per session by type, a broadcast once per recipient, and the session's totals are
+// recorded when it is released
+func TestHandler_Bandwidth(t *testing.T) {
+	registry := NewRegistry()
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	dbManager := &mockDatabaseManager{events: make(chan *types.SessionEvent, 1)}
+	forwarded := make(chan *types.Message, 10)
+	hub := &mockHub{sendMessageFunc: func(message *types.Message, senderID string) error {
+		forwarded <- message
+		return nil
+	}}
+	handler := NewHandler(registry, sessionManager, dbManager, hub)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	
+	connect := func(userID string) *websocket.Conn {
+		wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID + "&role=student&session_id=session456"
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		for {
+			var msg map[string]interface{}
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Failed to read the handshake and history: %v", err)
+			}
+			if content, _ := msg["content"].(map[string]interface{}); content["event"] == "history_complete" {
+				return conn
+			}
+		}
+	}
+	eventually := func(what string, done func() bool) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); !done(); time.Sleep(5 * time.Millisecond) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Timed out waiting for %s", what)
+			}
+		}
+	}
+	student1, student2 := connect("student1"), connect("student2")
+	
+	question := `{"type": "instructor_inbox", "content": {"text": "help"}}`
+	garbage := `not json`
+	madeUp := `{"type": "made_up"}`
+	for _, frame := range []string{question, garbage, madeUp} {
+		if err := student1.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+			t.Fatalf("Failed to send frame: %v", err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case <-forwarded:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected the decodable frames forwarded")
+		}
+	}
+	
+	broadcast := &types.Message{ID: "b1", Type: types.MessageTypeInstructorBroadcast, FromUser: "instructor1", SessionID: "session456"}
+	encoded, _ := JSONCodec.Marshal(broadcast)
+	for _, userID := range []string{"student1", "student2"} {
+		conn, _ := registry.GetUserConnection(userID)
+		if err := conn.WriteJSON(broadcast); err != nil {
+			t.Fatalf("Failed to broadcast to %s: %v", userID, err)
+		}
+	}
+	for _, client := range []*websocket.Conn{student1, student2} {
+		_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if _, _, err := client.ReadMessage(); err != nil {
+			t.Fatalf("Expected the broadcast: %v", err)
+		}
+	}
+	
+	var stats types.BandwidthStats
+	eventually("the broadcast to be counted twice", func() bool {
+		stats = handler.BandwidthStats("session456")
+		return stats.ByType[types.MessageTypeInstructorBroadcast].FramesSent == 2
+	})
+	if got := stats.ByType[types.MessageTypeInstructorBroadcast].BytesSent; got != uint64(2*len(encoded)) {
+		t.Errorf("Expected the broadcast counted once per recipient, %d bytes, got %d", 2*len(encoded), got)
+	}
+	if got := stats.ByType[types.MessageTypeInstructorInbox]; got.BytesReceived != uint64(len(question)) || got.FramesReceived != 1 {
+		t.Errorf("Expected the question counted under its type, got %+v", got)
+	}
+	if got := stats.ByType[types.BandwidthUnparsed]; got.BytesReceived != uint64(len(garbage)+len(madeUp)) || got.FramesReceived != 2 {
+		t.Errorf("Expected undecodable and unknown frames counted as unparsed, got %+v", got)
+	}
+	if _, exists := stats.ByType["made_up"]; exists {
+		t.Error("Expected no entry for a type the client made up")
+	}
+	var summed types.ByteCounts
+	for _, counts := range stats.ByType {
+		summed.Add(counts)
+	}
+	if summed != stats.ByteCounts || stats.BytesReceived != uint64(len(question)+len(garbage)+len(madeUp)) {
+		t.Errorf("Expected totals summed over types, got %+v from %+v", stats.ByteCounts, stats.ByType)
+	}
+	
+	// The roster sees each student's current connection
+	presence := registry.StudentPresence("session456")
+	if got := presence["student1"]; got.BytesReceived != uint64(len(question)+len(garbage)+len(madeUp)) || got.BytesSent == 0 {
+		t.Errorf("Unexpected student1 presence: %+v", got)
+	}
+	if got := presence["student2"]; got.BytesReceived != 0 || got.BytesSent+presence["student1"].BytesSent != stats.BytesSent {
+		t.Errorf("Expected the session's sent bytes split between its two connections, got %+v", got)
+	}
+	
+	handler.ReleaseBandwidth(types.Session{ID: "session456"})
+	select {
+	case event := <-dbManager.events:
+		if event.Type != types.SessionEventBandwidth || event.Details["bytes_sent"] != stats.BytesSent || event.Details["frames_received"] != uint64(3) {
+			t.Errorf("Unexpected bandwidth event: %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a bandwidth event when the session is released")
+	}
+	if handler.BandwidthSessions() != 0 {
+		t.Errorf("Expected the session's counts dropped, %d sessions left", handler.BandwidthSessions())
+	}
+}
@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// DuplicateUserPolicy decides what happens when a user_id already connected to a
+// session connects again from a different client IP
+// FUNCTIONAL DISCOVERY: Without authentication the only signal that a second
+// connection is someone else is its address; a student switching from Wi-Fi to
+// a phone hotspot looks the same, which is why allow stays the default
+type DuplicateUserPolicy string
+
+const (
+	DuplicateUserAllow   DuplicateUserPolicy = "allow"   // Newest connection wins, nothing recorded
+	DuplicateUserReplace DuplicateUserPolicy = "replace" // Newest connection wins; the takeover is recorded as connection_replaced
+	DuplicateUserReject  DuplicateUserPolicy = "reject"  // The existing connection stays; the newcomer gets 409
+)
+
+// SessionMismatchPolicy decides what happens to a frame whose session_id names a
+// session other than the one its connection joined
+// FUNCTIONAL DISCOVERY: Older clients echoed a stale session_id after switching
+// sessions; correct lets them keep working while the attempt is still audited
+type SessionMismatchPolicy string
+
+const (
+	SessionMismatchReject  SessionMismatchPolicy = "reject"  // The frame is refused with SESSION_MISMATCH
+	SessionMismatchCorrect SessionMismatchPolicy = "correct" // The frame is routed in the connection's session
+)
+
+// Impersonation audit reasons, recorded in the event's details
+const (
+	reasonReservedUserID  = "reserved_user_id"   // Handshake claimed a reserved user_id
+	reasonReservedSender  = "reserved_from_user" // A frame claimed a reserved from_user
+	reasonDuplicateUser   = "duplicate_user"     // user_id already connected from another IP
+	reasonSessionMismatch = "session_mismatch"   // A frame claimed another session_id
+)
+
+// SetDuplicateUserPolicy sets how a second connection for a connected user_id is treated
+// TECHNICAL DISCOVERY: Must be called before serving; the value is read without locking
+func (h *Handler) SetDuplicateUserPolicy(policy DuplicateUserPolicy) {
+	h.duplicates = policy
+}
+
+// SetSessionMismatchPolicy sets how a frame naming another session is treated
+// TECHNICAL DISCOVERY: Must be called before serving; the value is read without locking
+func (h *Handler) SetSessionMismatchPolicy(policy SessionMismatchPolicy) {
+	h.mismatches = policy
+}
+
+// duplicateConnection returns the user's connection to sessionID from an IP other than
+// clientIP, or nil when there is none or the policy does not care
+func (h *Handler) duplicateConnection(userID, sessionID, clientIP string) *Connection {
+	if h.duplicates != DuplicateUserReplace && h.duplicates != DuplicateUserReject {
+		return nil
+	}
+	existing, exists := h.registry.GetUserConnection(userID)
+	if !exists || existing.GetSessionID() != sessionID || existing.GetClientIP() == clientIP {
+		return nil
+	}
+	return existing
+}
+
+// auditImpersonation records a refused impersonation attempt in the session's audit trail
+// ARCHITECTURAL DISCOVERY: Best effort - the session may not exist, so a failed write
+// is logged and the refusal itself still goes out
+func (h *Handler) auditImpersonation(sessionID, userID, role, reason, clientIP string, extra map[string]interface{}) {
+	details := map[string]interface{}{"reason": reason}
+	for key, value := range extra {
+		details[key] = value
+	}
+	h.recordConnectionEvent(types.SessionEventImpersonationRejected, sessionID, userID, role, clientIP, details)
+}
+
+// existingDetails describes the connection a duplicate user_id collided with
+func (h *Handler) existingDetails(existing *Connection) map[string]interface{} {
+	return map[string]interface{}{
+		"existing_client_ip":     h.logIP(existing.GetClientIP()),
+		"existing_connection_id": existing.GetConnectionID(),
+	}
+}
+
+func (h *Handler) recordConnectionEvent(eventType, sessionID, userID, role, clientIP string, details map[string]interface{}) {
+	details["role"] = role
+	details["client_ip"] = h.logIP(clientIP)
+	event := &types.SessionEvent{
+		SessionID: sessionID,
+		Type:      eventType,
+		Actor:     userID,
+		Details:   details,
+	}
+	if err := h.dbManager.RecordSessionEvent(context.Background(), event); err != nil {
+		log.Printf("Failed to record %s for %s in session %s: %v", eventType, userID, sessionID, err)
+	}
+}
+
+// reservedSenderNotice is the system error sent for a frame claiming a reserved from_user
+func reservedSenderNotice(fromUser string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "system",
+		"content": map[string]interface{}{
+			"event":   "message_error",
+			"message": "Message not sent: from_user is reserved for server-generated senders",
+			"error":   "reserved user_id " + fromUser,
+			"code":    types.ErrorCodeReservedUserID,
+		},
+		"timestamp": time.Now(),
+	}
+}
+
+// sessionMismatchNotice is the system error sent for a frame claiming another session_id
+func sessionMismatchNotice(sessionID string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "system",
+		"content": map[string]interface{}{
+			"event":   "message_error",
+			"message": "Message not sent: session_id does not match this connection's session",
+			"error":   "session mismatch " + sessionID,
+			"code":    types.ErrorCodeSessionMismatch,
+		},
+		"timestamp": time.Now(),
+	}
+}
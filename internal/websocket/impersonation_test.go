@@ -0,0 +1,247 @@
+package websocket
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"switchboard/pkg/types"
+)
+
+// newImpersonationServer starts a handler that trusts the loopback proxy, so tests can
+// pick each client's IP with X-Forwarded-For
+func newImpersonationServer(t *testing.T, policy DuplicateUserPolicy, hub HubInterface) (*httptest.Server, *mockDatabaseManager) {
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	db := &mockDatabaseManager{events: make(chan *types.SessionEvent, 10)}
+	handler := NewHandler(NewRegistry(), sessionManager, db, hub)
+	_, loopback, _ := net.ParseCIDR("127.0.0.0/8")
+	handler.SetTrustedProxies([]*net.IPNet{loopback})
+	handler.SetRedactIPs(false)
+	handler.SetDuplicateUserPolicy(policy)
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	t.Cleanup(server.Close)
+	return server, db
+}
+
+// dialAs connects as userID from clientIP and returns the connection or the HTTP status
+func dialAs(t *testing.T, server *httptest.Server, userID, clientIP string) (*websocket.Conn, int) {
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=" + userID + "&role=student&session_id=session456"
+	header := http.Header{"X-Forwarded-For": []string{clientIP}}
+	conn, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		if resp == nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		return nil, resp.StatusCode
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn, http.StatusSwitchingProtocols
+}
+
+func expectEvent(t *testing.T, db *mockDatabaseManager) *types.SessionEvent {
+	t.Helper()
+	select {
+	case event := <-db.events:
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a session event")
+		return nil
+	}
+}
+
+func expectNoEvent(t *testing.T, db *mockDatabaseManager) {
+	t.Helper()
+	select {
+	case event := <-db.events:
+		t.Errorf("Unexpected session event: %+v", event)
+	default:
+	}
+}
+
+// TestHandler_ReservedUserID tests security validation - clients cannot claim the system namespace
+func TestHandler_ReservedUserID(t *testing.T) {
+	forwarded := make(chan *types.Message, 10)
+	hub := &mockHub{sendMessageFunc: func(message *types.Message, senderID string) error {
+		forwarded <- message
+		return nil
+	}}
+	server, db := newImpersonationServer(t, DuplicateUserAllow, hub)
+
+	for _, userID := range []string{types.SystemSenderID, "sys:grader", "system", "System"} {
+		if _, status := dialAs(t, server, userID, "10.0.0.1"); status != http.StatusForbidden {
+			t.Errorf("Expected 403 for %s, got %d", userID, status)
+		}
+		event := expectEvent(t, db)
+		if event.Type != types.SessionEventImpersonationRejected || event.Actor != userID || event.SessionID != "session456" ||
+			event.Details["reason"] != reasonReservedUserID || event.Details["client_ip"] != "10.0.0.1" {
+			t.Errorf("Unexpected audit event for %s: %+v", userID, event)
+		}
+	}
+
+	conn, status := dialAs(t, server, "student1", "10.0.0.1")
+	if conn == nil {
+		t.Fatalf("Expected student1 to connect, got %d", status)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "instructor_inbox", "from_user": "sys:announcements", "content": {"text": "official"}}`)); err != nil {
+		t.Fatalf("Failed to send frame: %v", err)
+	}
+	for {
+		var msg struct {
+			Type    string                 `json:"type"`
+			Content map[string]interface{} `json:"content"`
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Expected a RESERVED_USER_ID error: %v", err)
+		}
+		if msg.Content["code"] == types.ErrorCodeReservedUserID {
+			break
+		}
+	}
+	event := expectEvent(t, db)
+	if event.Actor != "student1" || event.Details["reason"] != reasonReservedSender || event.Details["from_user"] != "sys:announcements" {
+		t.Errorf("Unexpected audit event for a reserved from_user: %+v", event)
+	}
+	select {
+	case msg := <-forwarded:
+		t.Errorf("Refused frame was forwarded to the hub: %+v", msg)
+	default:
+	}
+}
+
+// TestHandler_SessionMismatch tests security validation - a frame naming another
+// session is refused, or rewritten under correct, and audited either way
+func TestHandler_SessionMismatch(t *testing.T) {
+	for _, policy := range []SessionMismatchPolicy{"", SessionMismatchReject, SessionMismatchCorrect} {
+		forwarded := make(chan *types.Message, 10)
+		hub := &mockHub{sendMessageFunc: func(message *types.Message, senderID string) error {
+			forwarded <- message
+			return nil
+		}}
+		sessionManager := &mockSessionManager{
+			validateFunc: func(sessionID, userID, role string) error {
+				return nil
+			},
+		}
+		db := &mockDatabaseManager{events: make(chan *types.SessionEvent, 10)}
+		handler := NewHandler(NewRegistry(), sessionManager, db, hub)
+		handler.SetRedactIPs(false)
+		handler.SetSessionMismatchPolicy(policy)
+		server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+		t.Cleanup(server.Close)
+
+		conn, status := dialAs(t, server, "student1", "10.0.0.1")
+		if conn == nil {
+			t.Fatalf("Expected student1 to connect, got %d", status)
+		}
+		for _, sessionID := range []string{"session456", ""} {
+			frame := `{"type": "instructor_inbox", "session_id": "` + sessionID + `", "content": {"text": "own session"}}`
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+				t.Fatalf("Failed to send frame: %v", err)
+			}
+			select {
+			case msg := <-forwarded:
+				if msg.SessionID != "session456" {
+					t.Errorf("Policy %q: expected the connection's session, got %q", policy, msg.SessionID)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("Policy %q: frame for the connection's own session was not forwarded", policy)
+			}
+		}
+		expectNoEvent(t, db)
+
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type": "instructor_inbox", "session_id": "other-session", "content": {"text": "crafted"}}`)); err != nil {
+			t.Fatalf("Failed to send frame: %v", err)
+		}
+		event := expectEvent(t, db)
+		if event.Type != types.SessionEventImpersonationRejected || event.SessionID != "session456" || event.Actor != "student1" ||
+			event.Details["reason"] != reasonSessionMismatch || event.Details["session_id"] != "other-session" {
+			t.Errorf("Policy %q: unexpected audit event: %+v", policy, event)
+		}
+
+		if policy == SessionMismatchCorrect {
+			select {
+			case msg := <-forwarded:
+				if msg.SessionID != "session456" {
+					t.Errorf("Expected the frame corrected to the connection's session, got %q", msg.SessionID)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("Corrected frame was not forwarded")
+			}
+			continue
+		}
+		for {
+			var msg struct {
+				Content map[string]interface{} `json:"content"`
+			}
+			_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Fatalf("Policy %q: expected a SESSION_MISMATCH error: %v", policy, err)
+			}
+			if msg.Content["code"] == types.ErrorCodeSessionMismatch {
+				break
+			}
+		}
+		select {
+		case msg := <-forwarded:
+			t.Errorf("Policy %q: refused frame was forwarded to the hub: %+v", policy, msg)
+		default:
+		}
+	}
+}
+
+// TestHandler_DuplicateUserPolicy tests security validation - a user_id connected from one IP claimed from another
+func TestHandler_DuplicateUserPolicy(t *testing.T) {
+	hub := &mockHub{sendMessageFunc: func(message *types.Message, senderID string) error {
+		return nil
+	}}
+
+	tests := []struct {
+		policy    DuplicateUserPolicy
+		status    int
+		eventType string
+	}{
+		{DuplicateUserAllow, http.StatusSwitchingProtocols, ""},
+		{DuplicateUserReplace, http.StatusSwitchingProtocols, types.SessionEventConnectionReplaced},
+		{DuplicateUserReject, http.StatusConflict, types.SessionEventImpersonationRejected},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			server, db := newImpersonationServer(t, tt.policy, hub)
+			first, _ := dialAs(t, server, "instructor_1", "10.0.0.1")
+			if first == nil {
+				t.Fatal("Expected the first connection to succeed")
+			}
+			time.Sleep(50 * time.Millisecond) // Let registration finish
+
+			// Reconnecting from the same address is never a duplicate
+			if _, status := dialAs(t, server, "instructor_1", "10.0.0.1"); status != http.StatusSwitchingProtocols {
+				t.Fatalf("Expected a same-IP reconnect to succeed, got %d", status)
+			}
+			expectNoEvent(t, db)
+			time.Sleep(50 * time.Millisecond)
+
+			if _, status := dialAs(t, server, "instructor_1", "10.0.0.99"); status != tt.status {
+				t.Fatalf("Expected %d from another IP, got %d", tt.status, status)
+			}
+			if tt.eventType == "" {
+				expectNoEvent(t, db)
+				return
+			}
+			event := expectEvent(t, db)
+			if event.Type != tt.eventType || event.Actor != "instructor_1" ||
+				event.Details["client_ip"] != "10.0.0.99" || event.Details["existing_client_ip"] != "10.0.0.1" {
+				t.Errorf("Unexpected audit event: %+v", event)
+			}
+		})
+	}
+}
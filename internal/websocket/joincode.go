@@ -0,0 +1,32 @@
+package websocket
+
+import (
+	"log"
+	"net/http"
+
+	"switchboard/pkg/types"
+)
+
+// resolveJoinCode finds the active session whose join_code metadata is code, writing
+// the rejection itself when there is not exactly one
+// FUNCTIONAL DISCOVERY: Only active sessions match, so a code stops working when its
+// session ends and may then be reused; two active sessions sharing one are refused
+// rather than guessed between
+func (h *Handler) resolveJoinCode(w http.ResponseWriter, r *http.Request, code string) (string, bool) {
+	ids, err := h.dbManager.ActiveSessionsByMetadata(r.Context(), map[string]string{types.MetadataKeyJoinCode: code})
+	if err != nil {
+		log.Printf("Join code lookup failed: %v", err)
+		reject(w, http.StatusInternalServerError, types.RejectValidationFailed, "Session validation failed")
+		return "", false
+	}
+	switch len(ids) {
+	case 0:
+		reject(w, http.StatusNotFound, types.RejectSessionNotFound, "No active session has this join code")
+		return "", false
+	case 1:
+		return ids[0], true
+	default:
+		reject(w, http.StatusConflict, types.RejectJoinCodeAmbiguous, "More than one active session has this join code")
+		return "", false
+	}
+}
@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"switchboard/pkg/types"
+)
+
+// TestHandler_JoinCode tests functional validation - a join code resolves to the one
+// active session carrying it, and anything else is refused before session validation
+func TestHandler_JoinCode(t *testing.T) {
+	dbManager := &mockDatabaseManager{joinCodes: map[string][]string{
+		"calm-otter-42": {"session456"},
+		"shared-code":   {"session1", "session2"},
+	}}
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedReason string
+		expectedID     string
+	}{
+		{"resolves", "join_code=calm-otter-42", http.StatusBadRequest, "", "session456"},
+		{"session_id wins", "join_code=calm-otter-42&session_id=session789", http.StatusBadRequest, "", "session789"},
+		{"unknown code", "join_code=nope", http.StatusNotFound, types.RejectSessionNotFound, ""},
+		{"ambiguous code", "join_code=shared-code", http.StatusConflict, types.RejectJoinCodeAmbiguous, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validated string
+			sessionManager := &mockSessionManager{validateFunc: func(sessionID, userID, role string) error {
+				validated = sessionID
+				return nil
+			}}
+			handler := NewHandler(NewRegistry(), sessionManager, dbManager, &mockHub{})
+
+			// Without WebSocket headers a resolved code fails at the upgrade, after validation
+			req := httptest.NewRequest("GET", "/ws?user_id=student1&role=student&"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			handler.HandleWebSocket(rec, req)
+
+			if rec.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.expectedStatus, rec.Code, rec.Body.String())
+			}
+			if validated != tt.expectedID {
+				t.Errorf("Expected session %q validated, got %q", tt.expectedID, validated)
+			}
+			if tt.expectedReason == "" {
+				return
+			}
+			var rejection types.HandshakeRejection
+			if err := json.Unmarshal(rec.Body.Bytes(), &rejection); err != nil || rejection.Reason != tt.expectedReason {
+				t.Errorf("Expected reason %q, got %q (%v)", tt.expectedReason, rec.Body.String(), err)
+			}
+		})
+	}
+}
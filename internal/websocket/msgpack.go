@@ -0,0 +1,575 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// MessagePack wire encoding for the switchboard.msgpack.v1 subprotocol
+// ARCHITECTURAL DISCOVERY: A minimal in-tree encoder covering the value shapes that
+// travel over WebSockets (messages, system envelopes, JSON-like content) avoids a
+// reflection-heavy dependency on the broadcast hot path
+// FUNCTIONAL DISCOVERY: Field names and shapes mirror the JSON envelope exactly -
+// timestamps are RFC 3339 strings and decoded numbers are float64 - so clients and
+// server code see identical values regardless of the negotiated codec
+
+const msgpackMaxDepth = 64 // Nesting limit protects the decoder from hostile input
+
+var (
+	errMsgpackTruncated   = errors.New("msgpack: unexpected end of data")
+	errMsgpackTooDeep     = errors.New("msgpack: nesting too deep")
+	errMsgpackTrailing    = errors.New("msgpack: trailing data after value")
+	errMsgpackMapKey      = errors.New("msgpack: map keys must be strings")
+	errMsgpackUnsupported = errors.New("msgpack: unsupported format")
+)
+
+// msgpackMarshal encodes v as a single MessagePack value
+func msgpackMarshal(v interface{}) ([]byte, error) {
+	return msgpackAppend(make([]byte, 0, 256), v, 0)
+}
+
+// msgpackAppend appends the encoding of v to buf
+// TECHNICAL DISCOVERY: Concrete types are handled without reflection; anything else
+// falls back to a JSON round trip so arbitrary structs still encode correctly
+func msgpackAppend(buf []byte, v interface{}, depth int) ([]byte, error) {
+	if depth > msgpackMaxDepth {
+		return nil, errMsgpackTooDeep
+	}
+
+	switch val := v.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if val {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case string:
+		return msgpackAppendString(buf, val), nil
+	case *string:
+		if val == nil {
+			return append(buf, 0xc0), nil
+		}
+		return msgpackAppendString(buf, *val), nil
+	case int:
+		return msgpackAppendInt(buf, int64(val)), nil
+	case int32:
+		return msgpackAppendInt(buf, int64(val)), nil
+	case int64:
+		return msgpackAppendInt(buf, val), nil
+	case uint64:
+		if val > math.MaxInt64 {
+			buf = append(buf, 0xcf)
+			return binary.BigEndian.AppendUint64(buf, val), nil
+		}
+		return msgpackAppendInt(buf, int64(val)), nil
+	case float32:
+		buf = append(buf, 0xca)
+		return binary.BigEndian.AppendUint32(buf, math.Float32bits(val)), nil
+	case float64:
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(val)), nil
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return msgpackAppendInt(buf, i), nil
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return msgpackAppend(buf, f, depth)
+	case time.Time:
+		return msgpackAppendString(buf, val.Format(time.RFC3339Nano)), nil
+	case []byte:
+		return msgpackAppendBinary(buf, val), nil
+	case []string:
+		buf = msgpackAppendArrayHeader(buf, len(val))
+		for _, s := range val {
+			buf = msgpackAppendString(buf, s)
+		}
+		return buf, nil
+	case []interface{}:
+		buf = msgpackAppendArrayHeader(buf, len(val))
+		var err error
+		for _, item := range val {
+			if buf, err = msgpackAppend(buf, item, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]interface{}:
+		buf = msgpackAppendMapHeader(buf, len(val))
+		var err error
+		for key, item := range val {
+			buf = msgpackAppendString(buf, key)
+			if buf, err = msgpackAppend(buf, item, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]string:
+		buf = msgpackAppendMapHeader(buf, len(val))
+		for key, item := range val {
+			buf = msgpackAppendString(buf, key)
+			buf = msgpackAppendString(buf, item)
+		}
+		return buf, nil
+	case types.Message:
+		return msgpackAppendMessage(buf, &val, depth)
+	case *types.Message:
+		if val == nil {
+			return append(buf, 0xc0), nil
+		}
+		return msgpackAppendMessage(buf, val, depth)
+	}
+
+	// Fallback: reuse the JSON field mapping for arbitrary structs
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	return msgpackAppend(buf, generic, depth)
+}
+
+// msgpackAppendMessage encodes a message with the same keys as its JSON tags
+func msgpackAppendMessage(buf []byte, m *types.Message, depth int) ([]byte, error) {
+	fields := 7
+	if m.ToUser != nil {
+		fields++
+	}
+	if len(m.ToUsers) > 0 {
+		fields++
+	}
+	if len(m.Annotations) > 0 {
+		fields++
+	}
+
+	buf = msgpackAppendMapHeader(buf, fields)
+	buf = msgpackAppendString(buf, "id")
+	buf = msgpackAppendString(buf, m.ID)
+	buf = msgpackAppendString(buf, "session_id")
+	buf = msgpackAppendString(buf, m.SessionID)
+	buf = msgpackAppendString(buf, "type")
+	buf = msgpackAppendString(buf, m.Type)
+	buf = msgpackAppendString(buf, "context")
+	buf = msgpackAppendString(buf, m.Context)
+	buf = msgpackAppendString(buf, "from_user")
+	buf = msgpackAppendString(buf, m.FromUser)
+	if m.ToUser != nil {
+		buf = msgpackAppendString(buf, "to_user")
+		buf = msgpackAppendString(buf, *m.ToUser)
+	}
+	if len(m.ToUsers) > 0 {
+		buf = msgpackAppendString(buf, "to_users")
+		buf = msgpackAppendArrayHeader(buf, len(m.ToUsers))
+		for _, toUser := range m.ToUsers {
+			buf = msgpackAppendString(buf, toUser)
+		}
+	}
+	buf = msgpackAppendString(buf, "content")
+	if m.Content == nil {
+		buf = append(buf, 0xc0)
+	} else {
+		var err error
+		if buf, err = msgpackAppend(buf, m.Content, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	if len(m.Annotations) > 0 {
+		buf = msgpackAppendString(buf, "annotations")
+		var err error
+		if buf, err = msgpackAppend(buf, m.Annotations, depth+1); err != nil {
+			return nil, err
+		}
+	}
+	buf = msgpackAppendString(buf, "timestamp")
+	return msgpackAppendString(buf, m.Timestamp.Format(time.RFC3339Nano)), nil
+}
+
+func msgpackAppendString(buf []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xda)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0xdb)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(n))
+	}
+	return append(buf, s...)
+}
+
+func msgpackAppendBinary(buf []byte, b []byte) []byte {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xc5)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0xc6)
+		buf = binary.BigEndian.AppendUint32(buf, uint32(n))
+	}
+	return append(buf, b...)
+}
+
+func msgpackAppendInt(buf []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= 0x7f:
+		return append(buf, byte(i))
+	case i < 0 && i >= -32:
+		return append(buf, byte(i))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		return append(buf, 0xd0, byte(i))
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf = append(buf, 0xd1)
+		return binary.BigEndian.AppendUint16(buf, uint16(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf = append(buf, 0xd2)
+		return binary.BigEndian.AppendUint32(buf, uint32(i))
+	default:
+		buf = append(buf, 0xd3)
+		return binary.BigEndian.AppendUint64(buf, uint64(i))
+	}
+}
+
+func msgpackAppendArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xdc)
+		return binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0xdd)
+		return binary.BigEndian.AppendUint32(buf, uint32(n))
+	}
+}
+
+func msgpackAppendMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n < 16:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		buf = append(buf, 0xde)
+		return binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, 0xdf)
+		return binary.BigEndian.AppendUint32(buf, uint32(n))
+	}
+}
+
+// msgpackUnmarshal decodes a single MessagePack value into v
+// FUNCTIONAL DISCOVERY: *types.Message is populated directly; other targets go
+// through the JSON field mapping so struct tags keep working
+func msgpackUnmarshal(data []byte, v interface{}) error {
+	d := &msgpackDecoder{data: data}
+	generic, err := d.decode(0)
+	if err != nil {
+		return err
+	}
+	if d.pos != len(d.data) {
+		return errMsgpackTrailing
+	}
+
+	switch target := v.(type) {
+	case *interface{}:
+		*target = generic
+		return nil
+	case *types.Message:
+		return msgpackFillMessage(generic, target)
+	}
+
+	intermediate, err := json.Marshal(generic)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(intermediate, v)
+}
+
+// msgpackFillMessage maps a decoded envelope onto a Message
+func msgpackFillMessage(generic interface{}, m *types.Message) error {
+	fields, ok := generic.(map[string]interface{})
+	if !ok {
+		return errors.New("msgpack: message must be a map")
+	}
+
+	for key, value := range fields {
+		var err error
+		switch key {
+		case "id":
+			err = msgpackString(value, &m.ID)
+		case "session_id":
+			err = msgpackString(value, &m.SessionID)
+		case "type":
+			err = msgpackString(value, &m.Type)
+		case "context":
+			err = msgpackString(value, &m.Context)
+		case "from_user":
+			err = msgpackString(value, &m.FromUser)
+		case "to_user":
+			if value == nil {
+				m.ToUser = nil
+				continue
+			}
+			var toUser string
+			if err = msgpackString(value, &toUser); err == nil {
+				m.ToUser = &toUser
+			}
+		case "to_users":
+			m.ToUsers, err = msgpackStrings(value)
+		case "content":
+			if value == nil {
+				m.Content = nil
+				continue
+			}
+			content, isMap := value.(map[string]interface{})
+			if !isMap {
+				err = errors.New("content must be a map")
+			}
+			m.Content = content
+		case "timestamp":
+			var ts string
+			if err = msgpackString(value, &ts); err == nil {
+				m.Timestamp, err = time.Parse(time.RFC3339Nano, ts)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("msgpack: field %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func msgpackString(value interface{}, dst *string) error {
+	if value == nil {
+		*dst = ""
+		return nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		return errors.New("expected string")
+	}
+	*dst = s
+	return nil
+}
+
+func msgpackStrings(value interface{}) ([]string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, errors.New("expected array")
+	}
+	strs := make([]string, len(items))
+	for i, item := range items {
+		if err := msgpackString(item, &strs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return strs, nil
+}
+
+// msgpackDecoder reads values from an in-memory buffer
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, errMsgpackTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *msgpackDecoder) length(size int) (int, error) {
+	b, err := d.next(size)
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return int(b[0]), nil
+	case 2:
+		return int(binary.BigEndian.Uint16(b)), nil
+	default:
+		return int(binary.BigEndian.Uint32(b)), nil
+	}
+}
+
+func (d *msgpackDecoder) decode(depth int) (interface{}, error) {
+	if depth > msgpackMaxDepth {
+		return nil, errMsgpackTooDeep
+	}
+
+	head, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := head[0]
+
+	switch {
+	case c <= 0x7f:
+		return float64(c), nil
+	case c >= 0xe0:
+		return float64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return d.str(int(c & 0x1f))
+	case c&0xf0 == 0x90:
+		return d.array(int(c&0x0f), depth)
+	case c&0xf0 == 0x80:
+		return d.mapValue(int(c&0x0f), depth)
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.length(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(n)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xca:
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+	case 0xcb:
+		b, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		b, err := d.next(1 << (c - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		var u uint64
+		for _, x := range b {
+			u = u<<8 | uint64(x)
+		}
+		return float64(u), nil
+	case 0xd0:
+		b, err := d.next(1)
+		if err != nil {
+			return nil, err
+		}
+		return float64(int8(b[0])), nil
+	case 0xd1:
+		b, err := d.next(2)
+		if err != nil {
+			return nil, err
+		}
+		return float64(int16(binary.BigEndian.Uint16(b))), nil
+	case 0xd2:
+		b, err := d.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(int32(binary.BigEndian.Uint32(b))), nil
+	case 0xd3:
+		b, err := d.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return float64(int64(binary.BigEndian.Uint64(b))), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.length(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd:
+		n, err := d.length(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.array(n, depth)
+	case 0xde, 0xdf:
+		n, err := d.length(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapValue(n, depth)
+	}
+
+	return nil, fmt.Errorf("%w: 0x%02x", errMsgpackUnsupported, c)
+}
+
+func (d *msgpackDecoder) str(n int) (interface{}, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// TECHNICAL DISCOVERY: Capacity is bounded by the remaining input so a forged
+// length header cannot trigger a huge allocation
+func (d *msgpackDecoder) array(n, depth int) (interface{}, error) {
+	if n > len(d.data)-d.pos {
+		return nil, errMsgpackTruncated
+	}
+	items := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		item, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (d *msgpackDecoder) mapValue(n, depth int) (interface{}, error) {
+	if n > (len(d.data)-d.pos)/2 {
+		return nil, errMsgpackTruncated
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		k, ok := key.(string)
+		if !ok {
+			return nil, errMsgpackMapKey
+		}
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[k] = value
+	}
+	return m, nil
+}
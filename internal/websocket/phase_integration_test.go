@@ -2,6 +2,8 @@ package websocket
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -224,6 +226,198 @@ func TestPhase2_ConnectionReplacementFlow(t *testing.T) {
 	}
 }
 
+// Test that reconnects during a broadcast storm never lose a broadcast
+// RACE CONDITION FIX: Replacement swaps routing before the old connection closes and
+// re-targets anything still queued for it, so every broadcast reaches some connection
+func TestPhase2_ReplacementDuringBroadcastStorm(t *testing.T) {
+	const broadcasts = 2000
+	const reconnects = 20
+	
+	registry := NewRegistry()
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	handler := NewHandler(registry, sessionManager, &mockDatabaseManager{}, &mockHubIntegration{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") +
+		"?user_id=user123&role=student&session_id=session456"
+	
+	var mu sync.Mutex
+	received := make(map[int]bool)
+	superseded := 0
+	
+	// Each client records broadcasts until the server closes it
+	connect := func() *websocket.Conn {
+		conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		go func() {
+			for {
+				_, data, err := conn.ReadMessage()
+				if err != nil {
+					var closeErr *websocket.CloseError
+					if errors.As(err, &closeErr) && closeErr.Code == CloseSuperseded {
+						mu.Lock()
+						superseded++
+						mu.Unlock()
+					}
+					return
+				}
+				var frame struct {
+					Type string `json:"type"`
+					Seq  int    `json:"seq"`
+				}
+				if json.Unmarshal(data, &frame) == nil && frame.Type == "storm" {
+					mu.Lock()
+					received[frame.Seq] = true
+					mu.Unlock()
+				}
+			}
+		}()
+		return conn
+	}
+	
+	clients := []*websocket.Conn{connect()}
+	defer func() {
+		for _, conn := range clients {
+			_ = conn.Close()
+		}
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(registry.GetSessionStudents("session456")) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		// Recipients are looked up only every 50 broadcasts so some writes land on
+		// connections that were replaced after the lookup
+		var recipients []*Connection
+		for seq := 0; seq < broadcasts; seq++ {
+			if seq%50 == 0 {
+				recipients = registry.GetSessionStudents("session456")
+			}
+			for _, conn := range recipients {
+				if err := conn.WriteJSON(map[string]interface{}{"type": "storm", "seq": seq}); err != nil {
+					t.Errorf("Broadcast %d failed: %v", seq, err)
+				}
+			}
+			if seq%20 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+	
+	for i := 0; i < reconnects; i++ {
+		time.Sleep(3 * time.Millisecond)
+		clients = append(clients, connect())
+	}
+	<-done
+	
+	deadline = time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		complete := len(received) == broadcasts && superseded == reconnects
+		mu.Unlock()
+		if complete {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	
+	mu.Lock()
+	defer mu.Unlock()
+	if lost := broadcasts - len(received); lost != 0 {
+		t.Errorf("Lost %d of %d broadcasts across %d reconnects", lost, broadcasts, reconnects)
+	}
+	if superseded != reconnects {
+		t.Errorf("Expected %d clients closed with code %d, got %d", reconnects, CloseSuperseded, superseded)
+	}
+}
+
+// Test that a reconnect into another session inherits nothing from the old one
+// FUNCTIONAL DISCOVERY: Supersession is by user ID across sessions, so forwarding is
+// limited to the same session and role; everything else is dropped with the old socket
+func TestPhase2_ReplacementIntoAnotherSession(t *testing.T) {
+	registry := NewRegistry()
+	sessionManager := &mockSessionManager{
+		validateFunc: func(sessionID, userID, role string) error {
+			return nil
+		},
+	}
+	handler := NewHandler(registry, sessionManager, &mockDatabaseManager{}, &mockHubIntegration{})
+	server := httptest.NewServer(http.HandlerFunc(handler.HandleWebSocket))
+	defer server.Close()
+	baseURL := "ws" + strings.TrimPrefix(server.URL, "http") + "?user_id=user123&role=student&session_id="
+	
+	oldClient, _, err := websocket.DefaultDialer.Dial(baseURL+"sessionA", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to sessionA: %v", err)
+	}
+	defer func() { _ = oldClient.Close() }()
+	var stale *Connection
+	deadline := time.Now().Add(2 * time.Second)
+	for stale == nil && time.Now().Before(deadline) {
+		if students := registry.GetSessionStudents("sessionA"); len(students) == 1 {
+			stale = students[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if stale == nil {
+		t.Fatal("First connection was not registered")
+	}
+	
+	// Frames buffered before the swap and written through the stale handle after it
+	stopWriting := make(chan struct{})
+	writerDone := make(chan struct{})
+	go func() {
+		defer close(writerDone)
+		for seq := 0; ; seq++ {
+			select {
+			case <-stopWriting:
+				return
+			default:
+			}
+			_ = stale.WriteJSON(map[string]interface{}{"type": "session_a_only", "seq": seq})
+		}
+	}()
+	
+	newClient, _, err := websocket.DefaultDialer.Dial(baseURL+"sessionB", nil)
+	if err != nil {
+		t.Fatalf("Failed to connect to sessionB: %v", err)
+	}
+	defer func() { _ = newClient.Close() }()
+	time.Sleep(100 * time.Millisecond)
+	close(stopWriting)
+	<-writerDone
+	
+	if conn, _ := registry.GetUserConnection("user123"); conn == stale || conn.GetSessionID() != "sessionB" {
+		t.Fatal("Expected the sessionB connection to replace the sessionA one")
+	}
+	if err := stale.WriteJSON(map[string]interface{}{"type": "session_a_only"}); err == nil {
+		t.Error("Expected writes to the replaced connection to fail rather than forward")
+	}
+	
+	for {
+		_ = newClient.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		_, data, err := newClient.ReadMessage()
+		if err != nil {
+			break // Nothing more within the deadline
+		}
+		var frame struct {
+			Type string `json:"type"`
+		}
+		if json.Unmarshal(data, &frame) == nil && frame.Type == "session_a_only" {
+			t.Fatalf("sessionB connection received a frame meant for sessionA: %s", data)
+		}
+	}
+}
+
 // Test error handling across components
 func TestPhase2_ErrorPropagationFlow(t *testing.T) {
 	registry := NewRegistry()
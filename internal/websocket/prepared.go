@@ -0,0 +1,59 @@
+package websocket
+
+import (
+	"sync"
+)
+
+// PreparedMessage is one outbound value shared by many recipients, encoded at most
+// once per codec
+// ARCHITECTURAL DISCOVERY: Fan-out hands the same PreparedMessage to every recipient's
+// WritePrepared, so a broadcast to 30 students is marshaled once per wire format
+// rather than 30 times. Codec names carry the protocol version (switchboard.json.v1),
+// so the cache key is both
+// FUNCTIONAL DISCOVERY: Every recipient gets identical bytes; anything that differs per
+// recipient, like the instructor diagnostic overlay, is written with WriteJSON instead
+// TECHNICAL DISCOVERY: The encoded bytes are shared between writer goroutines, which
+// only read them - server frames are never masked, so gorilla writes them as they are
+type PreparedMessage struct {
+	value interface{}
+
+	mu      sync.Mutex
+	encoded map[string]preparedFrame // By codec name
+}
+
+// preparedFrame is one codec's encoding, kept with its error so a value that cannot
+// be encoded is only tried once
+type preparedFrame struct {
+	data []byte
+	err  error
+}
+
+// NewPreparedMessage wraps v for fan-out; v must not change once it is written
+func NewPreparedMessage(v interface{}) *PreparedMessage {
+	return &PreparedMessage{value: v, encoded: make(map[string]preparedFrame, 1)}
+}
+
+// Value returns the wrapped value
+func (p *PreparedMessage) Value() interface{} {
+	return p.value
+}
+
+// encode returns the value encoded with codec, marshaling it on first use
+func (p *PreparedMessage) encode(codec Codec) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	frame, exists := p.encoded[codec.Name()]
+	if !exists {
+		frame.data, frame.err = codec.Marshal(p.value)
+		p.encoded[codec.Name()] = frame
+	}
+	return frame.data, frame.err
+}
+
+// WritePrepared writes a shared message to this connection, reusing bytes already
+// encoded for another recipient with the same codec
+// TECHNICAL DISCOVERY: Goes through WriteJSON, so it is held back during history
+// replay and forwarded to a successor connection like any other write
+func (c *Connection) WritePrepared(p *PreparedMessage) error {
+	return c.WriteJSON(p)
+}
@@ -0,0 +1,131 @@
+package websocket
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"switchboard/pkg/types"
+)
+
+// TestPreparedMessage_Fanout tests functional validation - recipients sharing a codec
+// get the same bytes from one encoding, other codecs get their own, and a connection
+// replaying history still holds the shared message back until the replay ends
+func TestPreparedMessage_Fanout(t *testing.T) {
+	connections := make(chan *Connection, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		wsConn := NewConnection(conn)
+		defer func() { _ = wsConn.Close() }()
+		connections <- wsConn
+		_, _, _ = conn.ReadMessage() // Wait for client to close
+	}))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	var clients []*websocket.Conn
+	var servers []*Connection
+	for _, subprotocols := range [][]string{nil, nil, {SubprotocolMsgpack}} {
+		dialer := websocket.Dialer{Subprotocols: subprotocols}
+		client, _, err := dialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Dial failed: %v", err)
+		}
+		defer func() { _ = client.Close() }()
+		clients = append(clients, client)
+		servers = append(servers, <-connections)
+	}
+
+	message := testCodecMessage()
+	message.Type = types.MessageTypeInstructorBroadcast
+	message.ToUser = nil
+	prepared := NewPreparedMessage(message)
+	servers[1].beginReplay()
+	for _, conn := range servers {
+		if err := conn.WritePrepared(prepared); err != nil {
+			t.Fatalf("WritePrepared failed: %v", err)
+		}
+	}
+	if len(prepared.encoded) != 2 {
+		t.Errorf("Expected one encoding per codec, got %d", len(prepared.encoded))
+	}
+
+	read := func(client *websocket.Conn) []byte {
+		_ = client.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, data, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage failed: %v", err)
+		}
+		return data
+	}
+	first := read(clients[0])
+	if err := servers[1].writeReplay(map[string]interface{}{"type": "system", "content": map[string]interface{}{"event": "history_complete"}}); err != nil {
+		t.Fatalf("writeReplay failed: %v", err)
+	}
+	servers[1].endReplay()
+	if replayed := read(clients[1]); !bytes.Contains(replayed, []byte("history_complete")) {
+		t.Fatalf("Expected the shared message held back behind the replay, got %s", replayed)
+	}
+	if held := read(clients[1]); !bytes.Equal(held, first) {
+		t.Errorf("Expected the held-back copy to be the shared bytes, got %s", held)
+	}
+
+	packed := read(clients[2])
+	if bytes.Equal(first, packed) {
+		t.Error("Expected MessagePack recipients to get their own encoding")
+	}
+	var decoded types.Message
+	if err := MsgpackCodec.Unmarshal(packed, &decoded); err != nil || decoded.ID != message.ID {
+		t.Errorf("Expected the MessagePack recipient to decode the message, got %+v (%v)", decoded, err)
+	}
+	if want, _ := JSONCodec.Marshal(message); !bytes.Equal(first, want) {
+		t.Errorf("Expected the JSON recipient to get the message as WriteJSON would encode it:\n%s", first)
+	}
+}
+
+// Performance Validation Tests
+// BenchmarkPreparedMessage_Broadcast compares encoding a broadcast per recipient with
+// encoding it once per codec, for classrooms where one in ten clients uses MessagePack
+func BenchmarkPreparedMessage_Broadcast(b *testing.B) {
+	message := testCodecMessage()
+	message.Type = types.MessageTypeInstructorBroadcast
+	message.ToUser = nil
+	codecFor := func(recipient int) Codec {
+		if recipient%10 == 9 {
+			return MsgpackCodec
+		}
+		return JSONCodec
+	}
+
+	for _, recipients := range []int{30, 100, 500} {
+		b.Run(fmt.Sprintf("per_recipient/%d", recipients), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for r := 0; r < recipients; r++ {
+					if _, err := codecFor(r).Marshal(message); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("prepared/%d", recipients), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				prepared := NewPreparedMessage(message)
+				for r := 0; r < recipients; r++ {
+					if _, err := prepared.encode(codecFor(r)); err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
 )
 
@@ -12,19 +13,27 @@ import (
 // ARCHITECTURAL DISCOVERY: Pure connection management without business logic
 // maintains clean separation between connection tracking and connection operations
 type Registry struct {
-	mu                  sync.RWMutex                          // TECHNICAL DISCOVERY: RWMutex optimizes for read-heavy lookup patterns
-	globalConnections   map[string]*Connection                // userID -> Connection for O(1) global lookup
-	sessionInstructors  map[string]map[string]*Connection     // sessionID -> userID -> Connection
-	sessionStudents     map[string]map[string]*Connection     // sessionID -> userID -> Connection
+	mu                   sync.RWMutex                      // TECHNICAL DISCOVERY: RWMutex optimizes for read-heavy lookup patterns
+	globalConnections    map[string]*Connection            // userID -> Connection for O(1) global lookup
+	sessionInstructors   map[string]map[string]*Connection // sessionID -> userID -> Connection
+	sessionStudents      map[string]map[string]*Connection // sessionID -> userID -> Connection
+	instructorDepartures map[string]map[string]time.Time   // sessionID -> userID -> when they left
+	endedSessions        map[string]bool                   // Ended sessions whose instructors are still connected
+	studentVisits        map[string]map[string]*visits     // sessionID -> userID -> student joins and leaves
+	onRegister           []func(conn *Connection)          // Notified after a connection is added
+	onUnregister         []func(conn *Connection)          // Notified after a connection is removed
 }
 
 // NewRegistry creates a new connection registry
 // FUNCTIONAL DISCOVERY: Initialize all maps to prevent nil pointer access during concurrent operations
 func NewRegistry() *Registry {
 	return &Registry{
-		globalConnections:  make(map[string]*Connection),
-		sessionInstructors: make(map[string]map[string]*Connection),
-		sessionStudents:    make(map[string]map[string]*Connection),
+		globalConnections:    make(map[string]*Connection),
+		sessionInstructors:   make(map[string]map[string]*Connection),
+		sessionStudents:      make(map[string]map[string]*Connection),
+		instructorDepartures: make(map[string]map[string]time.Time),
+		endedSessions:        make(map[string]bool),
+		studentVisits:        make(map[string]map[string]*visits),
 	}
 }
 
@@ -45,32 +54,13 @@ func (r *Registry) RegisterConnection(conn *Connection) error {
 	sessionID := conn.GetSessionID()
 	
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	
-	// FUNCTIONAL DISCOVERY: Send session_ended message to trigger graceful client shutdown
-	// instead of forced connection close to prevent reconnection loops
-	if existingConn, exists := r.globalConnections[userID]; exists {
-		go func() {
-			// Send session_ended message to old connection
-			sessionEndedMsg := &types.Message{
-				Type:    "system",
-				Context: "session_ended",
-				Content: map[string]interface{}{
-					"reason": "Connection replaced by new instance",
-				},
-				Timestamp: time.Now(),
-			}
-			
-			// Send message to trigger graceful client shutdown
-			if err := existingConn.WriteJSON(sessionEndedMsg); err != nil {
-				log.Printf("Failed to send session_ended to replaced connection: %v", err)
-			} else {
-				log.Printf("Sent session_ended message to replaced connection for user %s", userID)
-			}
-			
-			// DON'T close connection - let client handle shutdown gracefully
-			// If client doesn't respond, session cleanup will handle zombie connections
-		}()
+	// FUNCTIONAL DISCOVERY: The new connection takes over every routing map below before
+	// the old one is told anything, so no lookup can return the dying connection once
+	// this lock is released; the old connection then forwards its backlog and closes
+	if existingConn, exists := r.globalConnections[userID]; exists && existingConn != conn {
+		r.removeFromSessionMaps(existingConn) // Reconnects may join a different session or role
+		go existingConn.supersede(conn)
 	}
 	
 	// Add to global map for O(1) user lookup
@@ -83,13 +73,31 @@ func (r *Registry) RegisterConnection(conn *Connection) error {
 			r.sessionInstructors[sessionID] = make(map[string]*Connection)
 		}
 		r.sessionInstructors[sessionID][userID] = conn
+		if departures, exists := r.instructorDepartures[sessionID]; exists {
+			delete(departures, userID)
+			if len(departures) == 0 {
+				delete(r.instructorDepartures, sessionID)
+			}
+		}
 	case "student":
 		if r.sessionStudents[sessionID] == nil {
 			r.sessionStudents[sessionID] = make(map[string]*Connection)
 		}
 		r.sessionStudents[sessionID][userID] = conn
+		if r.studentVisits[sessionID] == nil {
+			r.studentVisits[sessionID] = make(map[string]*visits)
+		}
+		if r.studentVisits[sessionID][userID] == nil {
+			r.studentVisits[sessionID][userID] = &visits{}
+		}
+		r.studentVisits[sessionID][userID].joins++
 	}
+	listeners := r.onRegister
+	r.mu.Unlock()
 	
+	for _, listener := range listeners {
+		listener(conn)
+	}
 	return nil
 }
 
@@ -103,41 +111,83 @@ func (r *Registry) UnregisterConnection(conn *Connection) {
 	
 	userID := conn.GetUserID()
 	r.mu.Lock()
-	defer r.mu.Unlock()
 	
 	registeredConn, exists := r.globalConnections[userID]
 	if !exists {
+		r.mu.Unlock()
 		return // Idempotent - no error if connection doesn't exist
 	}
 	
 	// Only unregister if this is the same connection instance that's registered
 	// This prevents old connections from unregistering newer connections during cleanup
 	if registeredConn != conn {
+		r.mu.Unlock()
 		return // Different connection is now registered, don't remove it
 	}
 	
-	role := conn.GetRole()
-	sessionID := conn.GetSessionID()
-	
 	// Remove from global map
 	delete(r.globalConnections, userID)
+	r.removeFromSessionMaps(conn)
+	listeners := r.onUnregister
+	r.mu.Unlock()
 	
-	// Remove from session-role map and clean up empty session maps
-	// TECHNICAL DISCOVERY: Clean up empty maps to prevent memory leaks
-	switch role {
+	// TECHNICAL DISCOVERY: Listeners run without the lock so they may query the registry
+	for _, listener := range listeners {
+		listener(conn)
+	}
+}
+
+// OnUnregister registers fn to run after a connection leaves the registry
+// FUNCTIONAL DISCOVERY: Only real departures are reported - a connection replaced by
+// a reconnect of the same user is superseded, not unregistered
+func (r *Registry) OnUnregister(fn func(conn *Connection)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onUnregister = append(r.onUnregister, fn)
+}
+
+// OnRegister registers fn to run after a connection joins the registry
+// FUNCTIONAL DISCOVERY: A reconnect of the same user is reported as a new registration;
+// the connection it superseded is never reported to OnUnregister
+func (r *Registry) OnRegister(fn func(conn *Connection)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onRegister = append(r.onRegister, fn)
+}
+
+// removeFromSessionMaps drops conn from its session-role map; caller must hold r.mu
+// TECHNICAL DISCOVERY: Clean up empty maps to prevent memory leaks
+func (r *Registry) removeFromSessionMaps(conn *Connection) {
+	userID := conn.GetUserID()
+	sessionID := conn.GetSessionID()
+	
+	switch conn.GetRole() {
 	case "instructor":
-		if instructors, exists := r.sessionInstructors[sessionID]; exists {
+		if instructors, exists := r.sessionInstructors[sessionID]; exists && instructors[userID] == conn {
 			delete(instructors, userID)
 			if len(instructors) == 0 {
 				delete(r.sessionInstructors, sessionID)
 			}
+			if r.endedSessions[sessionID] {
+				if len(instructors) == 0 {
+					delete(r.endedSessions, sessionID)
+				}
+				return // Nobody asks about presence in an ended session
+			}
+			if r.instructorDepartures[sessionID] == nil {
+				r.instructorDepartures[sessionID] = make(map[string]time.Time)
+			}
+			r.instructorDepartures[sessionID][userID] = time.Now()
 		}
 	case "student":
-		if students, exists := r.sessionStudents[sessionID]; exists {
+		if students, exists := r.sessionStudents[sessionID]; exists && students[userID] == conn {
 			delete(students, userID)
 			if len(students) == 0 {
 				delete(r.sessionStudents, sessionID)
 			}
+			if visited := r.studentVisits[sessionID][userID]; visited != nil {
+				visited.leaves++
+			}
 		}
 	}
 }
@@ -153,6 +203,18 @@ func (r *Registry) GetUserConnection(userID string) (*Connection, bool) {
 	return conn, exists
 }
 
+// GetAllConnections returns every registered connection, across sessions
+func (r *Registry) GetAllConnections() []*Connection {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	connections := make([]*Connection, 0, len(r.globalConnections))
+	for _, conn := range r.globalConnections {
+		connections = append(connections, conn)
+	}
+	return connections
+}
+
 // GetSessionConnections returns all connections in a session for broadcasting
 // FUNCTIONAL DISCOVERY: Combines instructors and students into single slice
 // for efficient iteration during session-wide message delivery
@@ -190,6 +252,18 @@ func (r *Registry) GetSessionConnections(sessionID string) []*Connection {
 	return connections
 }
 
+// GetSessionWriters returns GetSessionConnections as interfaces.ConnectionWriter values
+// ARCHITECTURAL DISCOVERY: Satisfies the API layer's Registry interface so the HTTP
+// handlers can notify clients without depending on the concrete Connection type
+func (r *Registry) GetSessionWriters(sessionID string) []interfaces.ConnectionWriter {
+	connections := r.GetSessionConnections(sessionID)
+	writers := make([]interfaces.ConnectionWriter, len(connections))
+	for i, conn := range connections {
+		writers[i] = conn
+	}
+	return writers
+}
+
 // GetSessionInstructors returns instructor connections for a session
 // FUNCTIONAL DISCOVERY: Role-specific lookup enables efficient message routing
 // for instructor-only message types (inbox_response, request)
@@ -207,6 +281,82 @@ func (r *Registry) GetSessionInstructors(sessionID string) []*Connection {
 	return connections
 }
 
+// InstructorPresence reports whether an instructor is connected to a session and,
+// if not, when their last connection to it went away
+// FUNCTIONAL DISCOVERY: leftAt is zero when the instructor has not been connected since
+// the server started; departures are only kept for instructors, who are few per session
+func (r *Registry) InstructorPresence(sessionID, userID string) (connected bool, leftAt time.Time) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	if _, connected := r.sessionInstructors[sessionID][userID]; connected {
+		return true, time.Time{}
+	}
+	return false, r.instructorDepartures[sessionID][userID]
+}
+
+// ForgetSession drops the instructor departures recorded for an ended session
+// FUNCTIONAL DISCOVERY: Connections are not closed when a session ends, so the
+// session is remembered while its instructors are still connected; their later
+// disconnects then record nothing instead of starting a new departure entry
+func (r *Registry) ForgetSession(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	delete(r.instructorDepartures, sessionID)
+	if len(r.sessionInstructors[sessionID]) > 0 {
+		r.endedSessions[sessionID] = true
+	}
+}
+
+// DepartureCount returns how many sessions have instructor departures recorded
+func (r *Registry) DepartureCount() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.instructorDepartures)
+}
+
+// visits counts one student's connections to a session
+type visits struct {
+	joins  int
+	leaves int
+}
+
+// StudentPresence returns the connections each student has opened and closed in a
+// session, and whether they are connected now with the bytes that connection has moved
+// FUNCTIONAL DISCOVERY: Students who have not connected since the server started are
+// absent; callers list them from the roster with zero counts
+func (r *Registry) StudentPresence(sessionID string) map[string]types.StudentPresence {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	presence := make(map[string]types.StudentPresence, len(r.studentVisits[sessionID]))
+	for userID, visited := range r.studentVisits[sessionID] {
+		entry := types.StudentPresence{Joins: visited.joins, Leaves: visited.leaves}
+		if conn, connected := r.sessionStudents[sessionID][userID]; connected {
+			entry.Connected = true
+			entry.BytesSent = conn.BytesSent()
+			entry.BytesReceived = conn.BytesReceived()
+		}
+		presence[userID] = entry
+	}
+	return presence
+}
+
+// ForgetStudentVisits drops an ended session's join and leave counts
+func (r *Registry) ForgetStudentVisits(sessionID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.studentVisits, sessionID)
+}
+
+// VisitSessions returns how many sessions have join and leave counts
+func (r *Registry) VisitSessions() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.studentVisits)
+}
+
 // GetSessionStudents returns student connections for a session
 // FUNCTIONAL DISCOVERY: Student-specific lookup enables efficient broadcasting
 // for instructor_broadcast message type targeting all session students
@@ -224,24 +374,44 @@ func (r *Registry) GetSessionStudents(sessionID string) []*Connection {
 	return connections
 }
 
-// GetStats returns registry statistics for monitoring and debugging
-// TECHNICAL DISCOVERY: Separate session count calculation for instructors and students
-// provides insight into registry state without exposing internal structure
-func (r *Registry) GetStats() map[string]int {
+// GetSessionConnectionCounts returns the number of connections in every session
+// that has any
+// TECHNICAL DISCOVERY: One pass under one read lock, without building connection
+// slices; dashboards listing hundreds of sessions otherwise take the lock and copy
+// connections once per session. Use GetSessionConnections when the connections
+// themselves are needed
+func (r *Registry) GetSessionConnectionCounts() map[string]int {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	
-	// Calculate unique sessions across instructor and student maps
-	uniqueSessions := make(map[string]bool)
-	for sessionID := range r.sessionInstructors {
-		uniqueSessions[sessionID] = true
+	return r.sessionConnectionCountsLocked()
+}
+
+// sessionConnectionCountsLocked counts connections per session; caller holds r.mu
+// FUNCTIONAL DISCOVERY: The hidden self-test session is left out, here and in
+// GetStats, so its loopback connections never show up in what users are shown
+func (r *Registry) sessionConnectionCountsLocked() map[string]int {
+	counts := make(map[string]int, len(r.sessionInstructors)+len(r.sessionStudents))
+	for sessionID, instructors := range r.sessionInstructors {
+		counts[sessionID] += len(instructors)
 	}
-	for sessionID := range r.sessionStudents {
-		uniqueSessions[sessionID] = true
+	for sessionID, students := range r.sessionStudents {
+		counts[sessionID] += len(students)
 	}
+	delete(counts, types.SelfTestSessionID)
+	return counts
+}
+
+// GetStats returns registry statistics for monitoring and debugging
+// TECHNICAL DISCOVERY: Sessions are counted from the same single pass as
+// GetSessionConnectionCounts, so both always agree on which sessions are active
+func (r *Registry) GetStats() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	
+	hidden := len(r.sessionInstructors[types.SelfTestSessionID]) + len(r.sessionStudents[types.SelfTestSessionID])
 	return map[string]int{
-		"total_connections": len(r.globalConnections),
-		"active_sessions":   len(uniqueSessions),
+		"total_connections": len(r.globalConnections) - hidden,
+		"active_sessions":   len(r.sessionConnectionCountsLocked()),
 	}
 }
\ No newline at end of file
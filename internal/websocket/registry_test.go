@@ -2,9 +2,14 @@ package websocket
 
 import (
 	"fmt"
+	"io"
+	"log"
+	"os"
 	"sync"
 	"testing"
 	"time"
+	
+	"switchboard/pkg/types"
 )
 
 // Test WebSocket upgrader for registry tests  
@@ -169,6 +174,138 @@ func TestRegistry_UnregisterConnection(t *testing.T) {
 	}
 }
 
+// FUNCTIONAL VALIDATION TEST: Register listeners run after the lock is released and
+// see the connection already routable
+func TestRegistry_OnRegister(t *testing.T) {
+	registry := NewRegistry()
+	var seen []string
+	registry.OnRegister(func(conn *Connection) {
+		if _, exists := registry.GetUserConnection(conn.GetUserID()); !exists {
+			t.Error("Listener should see the connection registered")
+		}
+		seen = append(seen, conn.GetUserID())
+	})
+
+	wsConn := createTestWebSocketConnection(t)
+	defer func() { _ = wsConn.Close() }()
+
+	unauthenticated := NewConnection(wsConn)
+	if err := registry.RegisterConnection(unauthenticated); err == nil {
+		t.Error("Expected unauthenticated connection to be refused")
+	}
+
+	conn := NewConnection(wsConn)
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetCredentials("user123", "student", "session456")
+	if err := registry.RegisterConnection(conn); err != nil {
+		t.Fatalf("RegisterConnection failed: %v", err)
+	}
+
+	if len(seen) != 1 || seen[0] != "user123" {
+		t.Errorf("Expected one registration for user123, got %v", seen)
+	}
+}
+
+func TestRegistry_InstructorPresence(t *testing.T) {
+	registry := NewRegistry()
+	
+	if connected, leftAt := registry.InstructorPresence("session456", "teacher1"); connected || !leftAt.IsZero() {
+		t.Error("Unknown instructor should be absent with no departure time")
+	}
+	
+	wsConn := createTestWebSocketConnection(t)
+	defer func() { _ = wsConn.Close() }()
+	conn := NewConnection(wsConn)
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetCredentials("teacher1", "instructor", "session456")
+	_ = registry.RegisterConnection(conn)
+	
+	if connected, _ := registry.InstructorPresence("session456", "teacher1"); !connected {
+		t.Error("Registered instructor should be present")
+	}
+	
+	before := time.Now()
+	registry.UnregisterConnection(conn)
+	connected, leftAt := registry.InstructorPresence("session456", "teacher1")
+	if connected || leftAt.Before(before) {
+		t.Errorf("Expected departure recorded after %v, got connected=%v leftAt=%v", before, connected, leftAt)
+	}
+	
+	// Reconnecting clears the departure
+	rejoined := NewConnection(wsConn)
+	defer func() { _ = rejoined.Close() }()
+	_ = rejoined.SetCredentials("teacher1", "instructor", "session456")
+	_ = registry.RegisterConnection(rejoined)
+	if connected, leftAt := registry.InstructorPresence("session456", "teacher1"); !connected || !leftAt.IsZero() {
+		t.Error("Reconnected instructor should be present again")
+	}
+}
+
+func TestRegistry_ForgetSession(t *testing.T) {
+	registry := NewRegistry()
+	wsConn := createTestWebSocketConnection(t)
+	defer func() { _ = wsConn.Close() }()
+	
+	left := NewConnection(wsConn)
+	defer func() { _ = left.Close() }()
+	_ = left.SetCredentials("teacher1", "instructor", "session456")
+	_ = registry.RegisterConnection(left)
+	registry.UnregisterConnection(left)
+	
+	stayed := NewConnection(wsConn)
+	defer func() { _ = stayed.Close() }()
+	_ = stayed.SetCredentials("teacher2", "instructor", "session456")
+	_ = registry.RegisterConnection(stayed)
+	
+	if registry.DepartureCount() != 1 {
+		t.Fatalf("Expected one session with departures, got %d", registry.DepartureCount())
+	}
+	registry.ForgetSession("session456")
+	if registry.DepartureCount() != 0 {
+		t.Error("Ending the session should drop its departures")
+	}
+	
+	// An instructor leaving the ended session afterwards records nothing
+	registry.UnregisterConnection(stayed)
+	if registry.DepartureCount() != 0 || len(registry.endedSessions) != 0 {
+		t.Errorf("Expected nothing kept for the ended session, got %d departures, %d ended", registry.DepartureCount(), len(registry.endedSessions))
+	}
+}
+
+func TestRegistry_StudentPresence(t *testing.T) {
+	registry := NewRegistry()
+	wsConn := createTestWebSocketConnection(t)
+	defer func() { _ = wsConn.Close() }()
+	connect := func(userID string) *Connection {
+		conn := NewConnection(wsConn)
+		t.Cleanup(func() { _ = conn.Close() })
+		_ = conn.SetCredentials(userID, "student", "session456")
+		_ = registry.RegisterConnection(conn)
+		return conn
+	}
+	
+	first := connect("student1")
+	registry.UnregisterConnection(first)
+	connect("student1")
+	connect("student2")
+	connect("student2") // Takes over the first connection: a leave and a join
+	
+	presence := registry.StudentPresence("session456")
+	if got := presence["student1"]; got != (types.StudentPresence{Joins: 2, Leaves: 1, Connected: true}) {
+		t.Errorf("Unexpected presence for student1: %+v", got)
+	}
+	if got := presence["student2"]; got != (types.StudentPresence{Joins: 2, Leaves: 1, Connected: true}) {
+		t.Errorf("Unexpected presence for student2: %+v", got)
+	}
+	if registry.VisitSessions() != 1 {
+		t.Fatalf("Expected one session with visits, got %d", registry.VisitSessions())
+	}
+	registry.ForgetStudentVisits("session456")
+	if registry.VisitSessions() != 0 || len(registry.StudentPresence("session456")) != 0 {
+		t.Error("Ending the session should drop its visits")
+	}
+}
+
 func TestRegistry_UnregisterNonexistentConnection(t *testing.T) {
 	registry := NewRegistry()
 	
@@ -240,6 +377,38 @@ func TestRegistry_EmptySessionLookups(t *testing.T) {
 	}
 }
 
+func TestRegistry_SessionConnectionCounts(t *testing.T) {
+	registry := NewRegistry()
+	
+	register := func(userID, role, sessionID string) *Connection {
+		wsConn := createTestWebSocketConnection(t)
+		t.Cleanup(func() { _ = wsConn.Close() })
+		conn := NewConnection(wsConn)
+		t.Cleanup(func() { _ = conn.Close() })
+		_ = conn.SetCredentials(userID, role, sessionID)
+		_ = registry.RegisterConnection(conn)
+		return conn
+	}
+	register("instructor1", "instructor", "session-a")
+	register("student1", "student", "session-a")
+	register("student2", "student", "session-a")
+	leaving := register("student3", "student", "session-b")
+	
+	counts := registry.GetSessionConnectionCounts()
+	if len(counts) != 2 || counts["session-a"] != 3 || counts["session-b"] != 1 {
+		t.Errorf("Expected session-a:3 session-b:1, got %v", counts)
+	}
+	
+	registry.UnregisterConnection(leaving)
+	counts = registry.GetSessionConnectionCounts()
+	if _, exists := counts["session-b"]; exists || len(counts) != 1 {
+		t.Errorf("Expected session-b to drop out once empty, got %v", counts)
+	}
+	if stats := registry.GetStats(); stats["active_sessions"] != len(counts) {
+		t.Errorf("Expected stats to agree with counts, got %d active sessions", stats["active_sessions"])
+	}
+}
+
 // Technical Validation Tests (Race Detection)
 func TestRegistry_ConcurrentRegistration(t *testing.T) {
 	registry := NewRegistry()
@@ -385,4 +554,59 @@ func TestRegistry_LookupPerformance(t *testing.T) {
 	}
 }
 
+// BenchmarkRegistry_DashboardRefresh compares counting connections for a dashboard
+// listing 500 active sessions of 31 connections each: per-session lookups, as
+// listSessions used to do, against one GetSessionConnectionCounts pass
+func BenchmarkRegistry_DashboardRefresh(b *testing.B) {
+	const sessions, perSession = 500, 31
+	registry := NewRegistry()
+	sessionIDs := make([]string, sessions)
+	for i := range sessionIDs {
+		sessionID := fmt.Sprintf("session%d", i)
+		sessionIDs[i] = sessionID
+		registry.sessionInstructors[sessionID] = make(map[string]*Connection)
+		registry.sessionStudents[sessionID] = make(map[string]*Connection)
+		for j := 0; j < perSession; j++ {
+			conn := &Connection{userID: fmt.Sprintf("%s-user%d", sessionID, j), role: "student", sessionID: sessionID}
+			if j == 0 {
+				conn.role = "instructor"
+				registry.sessionInstructors[sessionID][conn.userID] = conn
+			} else {
+				registry.sessionStudents[sessionID][conn.userID] = conn
+			}
+			registry.globalConnections[conn.userID] = conn
+		}
+	}
+	
+	// GetSessionConnections logs every connection at DEBUG
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+	
+	b.Run("per-session", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			total := 0
+			for _, sessionID := range sessionIDs {
+				total += len(registry.GetSessionWriters(sessionID))
+			}
+			if total != sessions*perSession {
+				b.Fatalf("Expected %d connections, got %d", sessions*perSession, total)
+			}
+		}
+	})
+	b.Run("batched", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			counts := registry.GetSessionConnectionCounts()
+			total := 0
+			for _, sessionID := range sessionIDs {
+				total += counts[sessionID]
+			}
+			if total != sessions*perSession {
+				b.Fatalf("Expected %d connections, got %d", sessions*perSession, total)
+			}
+		}
+	})
+}
+
 // Test completed - fmt imported at top
\ No newline at end of file
@@ -0,0 +1,24 @@
+package websocket
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"switchboard/pkg/types"
+)
+
+// reject answers a handshake refused before the upgrade with a HandshakeRejection body
+func reject(w http.ResponseWriter, code int, reason, message string) {
+	writeRejection(w, &types.HandshakeRejection{Error: message, Reason: reason, Code: code})
+}
+
+// writeRejection writes rejection with its status code
+// TECHNICAL DISCOVERY: Browsers expose neither the status nor the body of a failed
+// upgrade to page scripts, but SDKs and friendly error pages that fetch the URL first
+// read both
+func writeRejection(w http.ResponseWriter, rejection *types.HandshakeRejection) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(rejection.Code)
+	_ = json.NewEncoder(w).Encode(rejection)
+}
@@ -0,0 +1,104 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// UnknownFieldError reports a top-level field a strict connection sent that the
+// message envelope does not define, e.g. a misspelled "contnet"
+type UnknownFieldError struct {
+	Field string
+}
+
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q", e.Field)
+}
+
+// envelopeFields are the top-level field names of types.Message
+// TECHNICAL DISCOVERY: Read from the struct tags so MessagePack strict mode accepts
+// exactly the fields JSON's DisallowUnknownFields does
+var envelopeFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	messageType := reflect.TypeOf(types.Message{})
+	for i := 0; i < messageType.NumField(); i++ {
+		name, _, _ := strings.Cut(messageType.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// decodeMessage decodes an inbound frame with the connection's codec
+// FUNCTIONAL DISCOVERY: Lenient decoding ignores unknown fields, which is what existing
+// clients rely on; strict decoding refuses them with an *UnknownFieldError so client
+// developers find typos instead of silently losing data
+func decodeMessage(codec Codec, data []byte, strict bool) (*types.Message, error) {
+	var message types.Message
+	if !strict {
+		if err := codec.Unmarshal(data, &message); err != nil {
+			return nil, err
+		}
+		return &message, nil
+	}
+
+	if codec == JSONCodec {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&message); err != nil {
+			// TECHNICAL DISCOVERY: encoding/json has no typed error for unknown fields
+			if quoted, found := strings.CutPrefix(err.Error(), "json: unknown field "); found {
+				if field, unquoteErr := strconv.Unquote(quoted); unquoteErr == nil {
+					return nil, &UnknownFieldError{Field: field}
+				}
+			}
+			return nil, err
+		}
+		return &message, nil
+	}
+
+	var generic interface{}
+	if err := codec.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	if fields, ok := generic.(map[string]interface{}); ok {
+		var unknown []string
+		for name := range fields {
+			if !envelopeFields[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			sort.Strings(unknown) // Report the same field for the same frame every time
+			return nil, &UnknownFieldError{Field: unknown[0]}
+		}
+	}
+	if err := codec.Unmarshal(data, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// unknownFieldNotice is the system error sent for a refused strict-mode frame
+func unknownFieldNotice(err *UnknownFieldError) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "system",
+		"content": map[string]interface{}{
+			"event":   "message_error",
+			"message": "Message not sent: it has a field the protocol does not define",
+			"error":   err.Error(),
+			"code":    types.ErrorCodeUnknownField,
+			"field":   err.Field,
+		},
+		"timestamp": time.Now(),
+	}
+}
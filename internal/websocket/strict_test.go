@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestDecodeMessage_Strict tests functional validation - strict decoding names the unknown field in either codec
+func TestDecodeMessage_Strict(t *testing.T) {
+	typo := map[string]interface{}{
+		"type":    "instructor_inbox",
+		"contnet": map[string]interface{}{"text": "help"},
+		"zz_tag":  "extra",
+	}
+	valid := map[string]interface{}{
+		"type":    "instructor_inbox",
+		"context": "general",
+		"to_user": nil,
+		"content": map[string]interface{}{"text": "help", "anything": "nested keys are content"},
+	}
+
+	for _, codec := range []Codec{JSONCodec, MsgpackCodec} {
+		t.Run(codec.Name(), func(t *testing.T) {
+			typoFrame, err := codec.Marshal(typo)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+			validFrame, err := codec.Marshal(valid)
+			if err != nil {
+				t.Fatalf("Marshal failed: %v", err)
+			}
+
+			_, err = decodeMessage(codec, typoFrame, true)
+			var unknownField *UnknownFieldError
+			if !errors.As(err, &unknownField) || unknownField.Field != "contnet" {
+				t.Errorf("Expected an UnknownFieldError naming contnet, got %v", err)
+			}
+
+			message, err := decodeMessage(codec, typoFrame, false)
+			if err != nil || message.Type != "instructor_inbox" || message.Content != nil {
+				t.Errorf("Expected lenient decoding to drop the unknown field, got %+v, %v", message, err)
+			}
+
+			message, err = decodeMessage(codec, validFrame, true)
+			if err != nil || message.Content["anything"] != "nested keys are content" {
+				t.Errorf("Expected a valid frame to decode strictly, got %+v, %v", message, err)
+			}
+		})
+	}
+
+	if _, err := decodeMessage(JSONCodec, []byte(`{"type": `), true); err == nil || errors.As(err, new(*UnknownFieldError)) {
+		t.Errorf("Expected a syntax error for a truncated frame, got %v", err)
+	}
+}
+
+// TestEnvelopeFields tests technical validation - strict mode accepts every envelope field
+func TestEnvelopeFields(t *testing.T) {
+	for _, field := range []string{"id", "session_id", "type", "context", "from_user", "to_user", "content", "timestamp"} {
+		if !envelopeFields[field] {
+			t.Errorf("Expected %s to be an envelope field", field)
+		}
+	}
+	if envelopeFields["contnet"] || envelopeFields[""] {
+		t.Error("Unexpected envelope field")
+	}
+}
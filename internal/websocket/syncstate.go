@@ -0,0 +1,78 @@
+package websocket
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// historySync is what a connection's sync_state says it already holds
+// FUNCTIONAL DISCOVERY: A state that cannot be used never refuses the handshake; the
+// client gets a sync_state_rejected notice and the full replay instead
+type historySync struct {
+	state    *types.SyncState // nil when none was sent or it was rejected
+	rejected string           // One of the types.SyncState* reasons, or empty
+	skipped  int              // History messages the client already had
+}
+
+// parseSyncState reads the sync_state handshake parameter
+func parseSyncState(raw string) historySync {
+	if raw == "" {
+		return historySync{}
+	}
+	state, err := types.DecodeSyncState(raw)
+	if errors.Is(err, types.ErrSyncStateTooLarge) {
+		return historySync{rejected: types.SyncStateTooLarge}
+	}
+	if err != nil {
+		return historySync{rejected: types.SyncStateUnparsable}
+	}
+	return historySync{state: state}
+}
+
+// missing returns the part of history the client does not have: everything after its
+// cursor, preceded by earlier messages edited since it synced
+// FUNCTIONAL DISCOVERY: A cursor not in the visible history - a message since deleted,
+// or one from another session - rejects the state and everything is replayed
+func (s *historySync) missing(visible []*types.Message) []*types.Message {
+	if s.state == nil {
+		return visible
+	}
+	cursor := -1
+	for i, message := range visible {
+		if message.ID == s.state.LastMessageID {
+			cursor = i
+			break
+		}
+	}
+	if cursor < 0 {
+		s.state, s.rejected = nil, types.SyncStateUnknownCursor
+		return visible
+	}
+
+	missing := make([]*types.Message, 0, len(visible)-cursor-1)
+	for _, message := range visible[:cursor+1] {
+		if message.EditedAt != nil && message.EditedAt.After(s.state.SyncedAt) {
+			missing = append(missing, message)
+		}
+	}
+	s.skipped = cursor + 1 - len(missing)
+	return append(missing, visible[cursor+1:]...)
+}
+
+// sendSyncStateRejected tells the client its sync_state was set aside and why
+func (h *Handler) sendSyncStateRejected(conn *Connection, reason string) {
+	notice := map[string]interface{}{
+		"type": "system",
+		"content": map[string]interface{}{
+			"event":  "sync_state_rejected",
+			"reason": reason,
+		},
+		"timestamp": time.Now(),
+	}
+	if err := conn.writeReplay(notice); err != nil {
+		log.Printf("Failed to send sync_state_rejected to %s: %v", conn.GetUserID(), err)
+	}
+}
@@ -0,0 +1,11 @@
+-- Version 001 rollback: Initial schema
+-- FUNCTIONAL DISCOVERY: Deletes every session and message - only useful to start over
+
+DROP INDEX idx_messages_to_user;
+DROP INDEX idx_messages_session_type;
+DROP INDEX idx_messages_session_time;
+DROP INDEX idx_sessions_created_by;
+DROP INDEX idx_sessions_status;
+
+DROP TABLE messages;
+DROP TABLE sessions;
@@ -0,0 +1,6 @@
+-- Version 002 rollback: Message metadata side table
+-- FUNCTIONAL DISCOVERY: Recorded client IPs and user agents are deleted
+
+DROP INDEX idx_message_metadata_connection;
+
+DROP TABLE message_metadata;
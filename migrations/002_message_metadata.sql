@@ -0,0 +1,16 @@
+-- Version 002: Message metadata side table
+-- FUNCTIONAL DISCOVERY: Connection-scoped details (client IP, user agent) for
+-- incident investigations, stored apart from messages so normal history
+-- queries and envelopes never expose them
+
+CREATE TABLE message_metadata (
+    message_id TEXT PRIMARY KEY,
+    connection_id TEXT NOT NULL,
+    client_ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    recorded_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
+
+-- Connection lookup for "what else did this device send" investigations
+CREATE INDEX idx_message_metadata_connection ON message_metadata(connection_id);
@@ -0,0 +1,4 @@
+-- Version 003 rollback: Session duration limits
+-- FUNCTIONAL DISCOVERY: Sessions no longer auto-end; they run until ended manually
+
+ALTER TABLE sessions DROP COLUMN duration_minutes;
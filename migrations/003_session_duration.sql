@@ -0,0 +1,5 @@
+-- Version 003: Session duration limits
+-- FUNCTIONAL DISCOVERY: Sessions may auto-end after a fixed class length; expiry is
+-- recomputed from start_time + duration_minutes so timers survive restarts
+
+ALTER TABLE sessions ADD COLUMN duration_minutes INTEGER NOT NULL DEFAULT 0;
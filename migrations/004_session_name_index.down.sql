@@ -0,0 +1,3 @@
+-- Version 004 rollback: Active session name lookup
+
+DROP INDEX idx_sessions_active_name;
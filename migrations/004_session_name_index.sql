@@ -0,0 +1,6 @@
+-- Version 004: Active session name lookup
+-- FUNCTIONAL DISCOVERY: The duplicate-name policy checks a creator's active session
+-- names when the manager cache cannot answer; a partial index keeps that lookup cheap
+-- TECHNICAL DISCOVERY: Not UNIQUE because the default "allow" policy permits duplicates
+
+CREATE INDEX idx_sessions_active_name ON sessions(created_by, name) WHERE status = 'active';
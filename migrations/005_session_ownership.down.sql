@@ -0,0 +1,11 @@
+-- Version 005 rollback: Session ownership and audit events
+-- FUNCTIONAL DISCOVERY: Ownership transfers and the audit trail are deleted;
+-- created_by still names each session's creator
+-- TECHNICAL DISCOVERY: SQLite keeps the sqlite_sequence table AUTOINCREMENT created,
+-- so this rollback is the one that does not restore sqlite_master exactly
+
+DROP INDEX idx_session_events_session;
+
+DROP TABLE session_events;
+
+ALTER TABLE sessions DROP COLUMN owner_id;
@@ -0,0 +1,22 @@
+-- Version 005: Session ownership and audit events
+-- FUNCTIONAL DISCOVERY: Ownership can move to a co-instructor, so the current owner is
+-- kept apart from created_by, which stays the historical creator
+-- ARCHITECTURAL DISCOVERY: session_events is an append-only audit trail; details is a
+-- JSON object whose shape depends on event_type
+
+ALTER TABLE sessions ADD COLUMN owner_id TEXT NOT NULL DEFAULT '';
+
+UPDATE sessions SET owner_id = created_by;
+
+CREATE TABLE session_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    details TEXT NOT NULL DEFAULT '{}', -- JSON object
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+-- Audit trail lookup for a session in chronological order
+CREATE INDEX idx_session_events_session ON session_events(session_id, created_at);
@@ -0,0 +1,4 @@
+-- Version 006 rollback: Session lock
+-- FUNCTIONAL DISCOVERY: Locked sessions become unlocked
+
+ALTER TABLE sessions DROP COLUMN locked;
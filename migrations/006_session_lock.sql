@@ -0,0 +1,5 @@
+-- Version 006: Session lock
+-- FUNCTIONAL DISCOVERY: A locked session refuses student messages (e.g. during an exam)
+-- without ending; the flag lives on the session row so it survives a restart
+
+ALTER TABLE sessions ADD COLUMN locked BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+-- Version 007 rollback: Message annotations
+-- FUNCTIONAL DISCOVERY: Every instructor star, tag and note is deleted
+
+DROP TABLE message_annotations;
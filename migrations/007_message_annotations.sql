@@ -0,0 +1,15 @@
+-- Version 007: Message annotations
+-- FUNCTIONAL DISCOVERY: Instructors star or tag persisted messages to revisit later;
+-- one row per message and instructor, so co-instructors keep separate annotations
+-- ARCHITECTURAL DISCOVERY: Kept apart from messages like message_metadata, so the
+-- envelopes students receive never carry them; tags is a JSON array of strings
+
+CREATE TABLE message_annotations (
+    message_id TEXT NOT NULL,
+    instructor_id TEXT NOT NULL,
+    tags TEXT NOT NULL DEFAULT '[]', -- JSON array
+    note TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (message_id, instructor_id),
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+-- Version 008 rollback: Session time zone
+-- FUNCTIONAL DISCOVERY: Sessions lose their zone; exports fall back to UTC
+
+ALTER TABLE sessions DROP COLUMN timezone;
@@ -0,0 +1,5 @@
+-- Version 008: Session time zone
+-- FUNCTIONAL DISCOVERY: IANA zone name used when exports render timestamps for
+-- instructors; stored timestamps stay UTC, existing sessions default to UTC
+
+ALTER TABLE sessions ADD COLUMN timezone TEXT NOT NULL DEFAULT 'UTC';
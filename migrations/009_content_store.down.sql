@@ -0,0 +1,11 @@
+-- Version 009 rollback: Content-addressable storage for large message content
+-- FUNCTIONAL DISCOVERY: Shared bodies are copied back into each message row first,
+-- so no content is lost; the database grows by every duplicate again
+
+UPDATE messages SET content = (SELECT body FROM content_store WHERE hash = messages.content_hash)
+WHERE content_hash IS NOT NULL;
+
+DROP TRIGGER content_store_release;
+DROP TRIGGER content_store_reference;
+ALTER TABLE messages DROP COLUMN content_hash;
+DROP TABLE content_store;
@@ -0,0 +1,28 @@
+-- Version 009: Content-addressable storage for large message content
+-- FUNCTIONAL DISCOVERY: Thirty students submitting the same starter code store its
+-- body once; content at or above the dedup threshold is kept in content_store keyed
+-- by the SHA-256 of its canonical JSON, and the message row holds only the hash
+-- ARCHITECTURAL DISCOVERY: Reference counts are kept by triggers on messages, so every
+-- delete - direct, or cascaded from a removed session - releases its body and the
+-- last reference deletes it; content_hash is not a foreign key so 009 can be rolled back
+
+CREATE TABLE content_store (
+    hash TEXT PRIMARY KEY, -- SHA-256 hex of body
+    body TEXT NOT NULL, -- JSON, exactly as it would be stored in messages.content
+    refcount INTEGER NOT NULL DEFAULT 0
+);
+
+ALTER TABLE messages ADD COLUMN content_hash TEXT; -- NULL stores content inline
+
+CREATE TRIGGER content_store_reference AFTER INSERT ON messages
+WHEN NEW.content_hash IS NOT NULL
+BEGIN
+    UPDATE content_store SET refcount = refcount + 1 WHERE hash = NEW.content_hash;
+END;
+
+CREATE TRIGGER content_store_release AFTER DELETE ON messages
+WHEN OLD.content_hash IS NOT NULL
+BEGIN
+    UPDATE content_store SET refcount = refcount - 1 WHERE hash = OLD.content_hash;
+    DELETE FROM content_store WHERE hash = OLD.content_hash AND refcount <= 0;
+END;
@@ -0,0 +1,4 @@
+-- Version 010 rollback: Self-test probe storage
+
+DROP INDEX idx_selftest_probes_time;
+DROP TABLE selftest_probes;
@@ -0,0 +1,15 @@
+-- Version 010: Self-test probe storage
+-- FUNCTIONAL DISCOVERY: The periodic self-test persists each probe like a real message
+-- so a failing database fails the probe, but keeps probes out of messages, where they
+-- would show up in exports, verification and history
+-- ARCHITECTURAL DISCOVERY: No foreign key - the self-test session has no sessions row;
+-- only the most recent probes are kept
+
+CREATE TABLE selftest_probes (
+    id TEXT PRIMARY KEY,
+    from_user TEXT NOT NULL,
+    content TEXT NOT NULL, -- JSON
+    timestamp DATETIME NOT NULL
+);
+
+CREATE INDEX idx_selftest_probes_time ON selftest_probes(timestamp);
@@ -0,0 +1,4 @@
+-- Version 011 rollback: Session-scoped API keys
+-- FUNCTIONAL DISCOVERY: Every issued key stops working
+
+DROP TABLE api_keys;
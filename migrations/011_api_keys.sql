@@ -0,0 +1,20 @@
+-- Version 011: Session-scoped API keys
+-- FUNCTIONAL DISCOVERY: Graders and bots authenticate with a key limited to one
+-- session and a set of scopes; scopes is a JSON array of strings
+-- TECHNICAL DISCOVERY: Only the SHA-256 of the secret is stored - secrets are long
+-- random strings, so a fast hash is enough - and lookups go by that hash
+
+CREATE TABLE api_keys (
+    id TEXT PRIMARY KEY,
+    session_id TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL DEFAULT '',
+    scopes TEXT NOT NULL DEFAULT '[]', -- JSON array
+    created_by TEXT NOT NULL,
+    created_at DATETIME NOT NULL,
+    expires_at DATETIME NOT NULL,
+    revoked_at DATETIME,
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_api_keys_session ON api_keys(session_id);
@@ -0,0 +1,4 @@
+-- Version 012 rollback: Message reactions
+-- FUNCTIONAL DISCOVERY: Every student reaction is deleted
+
+DROP TABLE message_reactions;
@@ -0,0 +1,15 @@
+-- Version 012: Message reactions
+-- FUNCTIONAL DISCOVERY: Students react to a message with a short code such as
+-- thumbs_up; one row per message and student, so reacting again replaces the row
+-- ARCHITECTURAL DISCOVERY: Kept out of messages - a reaction is a vote, not a message,
+-- and a class of thirty reacting would otherwise add thirty envelopes per broadcast.
+-- WITHOUT ROWID keeps each row to its primary key plus the code and time
+
+CREATE TABLE message_reactions (
+    message_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    reaction TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (message_id, user_id),
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+) WITHOUT ROWID;
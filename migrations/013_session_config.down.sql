@@ -0,0 +1,4 @@
+-- Version 013 rollback: Session configuration snapshot
+-- FUNCTIONAL DISCOVERY: Every session's snapshot is deleted; audits lose delivery explanations
+
+ALTER TABLE sessions DROP COLUMN session_config;
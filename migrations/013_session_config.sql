@@ -0,0 +1,8 @@
+-- Version 013: Session configuration snapshot
+-- FUNCTIONAL DISCOVERY: The routing table, permissions and limits in force when a
+-- session was created, so audits can explain deliveries after the config changes
+-- ARCHITECTURAL DISCOVERY: Versioned JSON rather than columns - the snapshot's shape
+-- follows the router, and types.ParseSessionConfig upgrades old versions on read.
+-- NULL for sessions created before this migration
+
+ALTER TABLE sessions ADD COLUMN session_config TEXT;
@@ -0,0 +1,4 @@
+-- Version 014 rollback: Poison message quarantine
+-- FUNCTIONAL DISCOVERY: Every quarantined message and its error is deleted
+
+DROP TABLE poison_messages;
@@ -0,0 +1,19 @@
+-- Version 014: Poison message quarantine
+-- FUNCTIONAL DISCOVERY: A message whose write panicked or whose content could not be
+-- serialized is kept here with the error, instead of vanishing with a crashed writer
+-- ARCHITECTURAL DISCOVERY: No foreign key to sessions or messages - a poison message
+-- never reached messages, and the record must outlive a session deleted afterwards.
+-- payload is the message as JSON, with content reduced to text when it would not encode
+
+CREATE TABLE poison_messages (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    from_user TEXT NOT NULL,
+    type TEXT NOT NULL,
+    payload TEXT NOT NULL,
+    error TEXT NOT NULL,
+    quarantined_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_poison_messages_session ON poison_messages(session_id, quarantined_at);
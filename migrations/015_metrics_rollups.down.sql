@@ -0,0 +1,5 @@
+-- Version 015 rollback: Throughput rollups
+-- FUNCTIONAL DISCOVERY: All stored throughput history is deleted; the in-memory last
+-- 24 hours starts empty after the next restart
+
+DROP TABLE metrics_rollups;
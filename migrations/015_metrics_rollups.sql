@@ -0,0 +1,15 @@
+-- Version 015: Throughput rollups
+-- FUNCTIONAL DISCOVERY: Messages routed and connections open per minute, for the
+-- whole server (session_id '') and per session, kept after metrics scrapes expire so
+-- exam-week load can be read back when sizing hardware
+-- ARCHITECTURAL DISCOVERY: bucket_start is Unix seconds rather than DATETIME so range
+-- scans compare integers; the router rewrites a minute's row when it flushes it again.
+-- No foreign key - history outlives the sessions it counts
+
+CREATE TABLE metrics_rollups (
+    bucket_start INTEGER NOT NULL,
+    session_id TEXT NOT NULL,
+    messages INTEGER NOT NULL,
+    connections INTEGER NOT NULL,
+    PRIMARY KEY (bucket_start, session_id)
+) WITHOUT ROWID;
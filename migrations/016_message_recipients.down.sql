@@ -0,0 +1,5 @@
+-- Version 016 rollback: Targeted broadcast recipients
+-- FUNCTIONAL DISCOVERY: Targeted broadcasts lose their recipient lists and become
+-- visible to every student in history replay
+
+ALTER TABLE messages DROP COLUMN to_users;
@@ -0,0 +1,8 @@
+-- Version 016: Targeted broadcast recipients
+-- FUNCTIONAL DISCOVERY: An instructor_broadcast sent with to_users reaches only the
+-- listed students, live and in history replay; NULL is a broadcast to the whole session
+-- ARCHITECTURAL DISCOVERY: A JSON array on the message row rather than a join table -
+-- the list is written once with the message, read back with it and never queried by
+-- recipient, since instructors see every message anyway
+
+ALTER TABLE messages ADD COLUMN to_users TEXT;
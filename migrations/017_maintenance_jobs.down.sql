@@ -0,0 +1,4 @@
+-- Version 017 rollback: Maintenance jobs
+-- FUNCTIONAL DISCOVERY: The record of past maintenance runs is deleted
+
+DROP TABLE maintenance_jobs;
@@ -0,0 +1,16 @@
+-- Version 017: Maintenance jobs
+-- FUNCTIONAL DISCOVERY: Each POST /api/admin/maintenance run is kept with its task
+-- results (space freed by vacuum, the integrity verdict), so a job can still be read
+-- back after the process that ran it restarts
+-- ARCHITECTURAL DISCOVERY: tasks is a JSON array of per-task results, rewritten as
+-- each task finishes; jobs are few and small, so they are never pruned
+
+CREATE TABLE maintenance_jobs (
+    id TEXT PRIMARY KEY,
+    status TEXT NOT NULL,
+    tasks TEXT NOT NULL,
+    started_at DATETIME NOT NULL,
+    finished_at DATETIME
+);
+
+CREATE INDEX idx_maintenance_jobs_started ON maintenance_jobs(started_at);
@@ -0,0 +1,4 @@
+-- Version 018 rollback: Instructor inbox preferences
+-- FUNCTIONAL DISCOVERY: Instructors receive every fan-out message again
+
+DROP TABLE instructor_preferences;
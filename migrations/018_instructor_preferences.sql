@@ -0,0 +1,14 @@
+-- Version 018: Instructor inbox preferences
+-- FUNCTIONAL DISCOVERY: Which instructor fan-out messages each instructor wants, per
+-- session, kept so a TA's filter still applies after a reconnect or a restart
+-- ARCHITECTURAL DISCOVERY: filters is a JSON object of message type to contexts,
+-- always read whole by the router; rows go with their session
+
+CREATE TABLE instructor_preferences (
+    session_id TEXT NOT NULL,
+    instructor_id TEXT NOT NULL,
+    filters TEXT NOT NULL,
+    updated_at DATETIME NOT NULL,
+    PRIMARY KEY (session_id, instructor_id),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
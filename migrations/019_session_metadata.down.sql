@@ -0,0 +1,4 @@
+-- Version 019 rollback: Session metadata
+-- FUNCTIONAL DISCOVERY: Sessions lose their metadata; list filters on it match nothing
+
+ALTER TABLE sessions DROP COLUMN metadata;
@@ -0,0 +1,5 @@
+-- Version 019: Session metadata
+-- FUNCTIONAL DISCOVERY: Frontend key/value data such as course code or room number,
+-- stored as a JSON object of strings; existing sessions start with none
+
+ALTER TABLE sessions ADD COLUMN metadata TEXT NOT NULL DEFAULT '{}';
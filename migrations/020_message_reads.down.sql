@@ -0,0 +1,4 @@
+-- Version 020 rollback: Message read receipts
+-- FUNCTIONAL DISCOVERY: Read state is lost; instructors see delivery receipts only
+
+DROP TABLE message_reads;
@@ -0,0 +1,13 @@
+-- Version 020: Message read receipts
+-- FUNCTIONAL DISCOVERY: Students report when a message was displayed; one row per
+-- message and student holding the first read, so repeated receipts change nothing
+-- ARCHITECTURAL DISCOVERY: Cascades with the message, like message_reactions, so
+-- deleting a session's data removes its receipts
+
+CREATE TABLE message_reads (
+    message_id TEXT NOT NULL,
+    user_id TEXT NOT NULL,
+    read_at DATETIME NOT NULL,
+    PRIMARY KEY (message_id, user_id),
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+) WITHOUT ROWID;
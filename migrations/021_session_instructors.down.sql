@@ -0,0 +1,5 @@
+-- Version 021 rollback: Session instructors
+-- FUNCTIONAL DISCOVERY: Sessions lose their co-instructor lists; in "members" scope
+-- only creators and owners can join until the lists are set again
+
+ALTER TABLE sessions DROP COLUMN instructor_ids;
@@ -0,0 +1,6 @@
+-- Version 021: Session instructors
+-- FUNCTIONAL DISCOVERY: Co-instructors allowed into a session when instructors are
+-- scoped to their sessions, stored as a JSON array of user IDs; existing sessions
+-- list none, leaving their creator and owner as members
+
+ALTER TABLE sessions ADD COLUMN instructor_ids TEXT NOT NULL DEFAULT '[]';
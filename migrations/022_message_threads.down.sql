@@ -0,0 +1,5 @@
+-- Version 022 rollback: Conversation thread indexes
+-- FUNCTIONAL DISCOVERY: Threads are still served, by filtering the session's history
+
+DROP INDEX idx_messages_session_to;
+DROP INDEX idx_messages_session_from;
@@ -0,0 +1,8 @@
+-- Version 022: Conversation thread indexes
+-- FUNCTIONAL DISCOVERY: Serve one student's thread (what they sent and what was sent
+-- to them) without scanning the session's history; timestamp completes each index so
+-- pages come back in order
+-- TECHNICAL DISCOVERY: idx_messages_to_user stays for lookups across sessions
+
+CREATE INDEX idx_messages_session_from ON messages(session_id, from_user, timestamp);
+CREATE INDEX idx_messages_session_to ON messages(session_id, to_user, timestamp) WHERE to_user IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Version 023 rollback: Inline attachment accounting
+-- FUNCTIONAL DISCOVERY: Attachments stay in message content; only the accounting
+-- columns are dropped
+
+ALTER TABLE messages DROP COLUMN attachment_bytes;
+ALTER TABLE messages DROP COLUMN attachment_mime;
@@ -0,0 +1,9 @@
+-- Version 023: Inline attachment accounting
+-- FUNCTIONAL DISCOVERY: A message carrying an inline attachment records its media type
+-- and decoded size, so storage used by attachments can be summed per session or type
+-- without decoding content; NULL on messages without one
+-- ARCHITECTURAL DISCOVERY: Columns on the message row rather than a table of their
+-- own - a message carries at most one attachment, written once with it
+
+ALTER TABLE messages ADD COLUMN attachment_mime TEXT;
+ALTER TABLE messages ADD COLUMN attachment_bytes INTEGER;
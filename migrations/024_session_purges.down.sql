@@ -0,0 +1,5 @@
+-- Version 024 rollback: Session purges
+-- FUNCTIONAL DISCOVERY: Purged data stays deleted; only the record of the purges, and
+-- the progress of any unfinished one, is dropped
+
+DROP TABLE session_purges;
@@ -0,0 +1,18 @@
+-- Version 024: Session purges
+-- FUNCTIONAL DISCOVERY: A purge deletes a session and everything attached to it in
+-- many small transactions; this row records how far it got and how many rows each
+-- table lost, so an interrupted purge resumes where it stopped and a finished one
+-- leaves a record of the deletion without any of the deleted data
+-- ARCHITECTURAL DISCOVERY: No foreign key to sessions - the row must outlive the
+-- session it deleted. removed is a JSON object of row counts per table, rewritten in
+-- the same transaction as each batch it counts
+
+CREATE TABLE session_purges (
+    session_id TEXT PRIMARY KEY,
+    requested_by TEXT NOT NULL,
+    stage TEXT NOT NULL,
+    removed TEXT NOT NULL,
+    started_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL,
+    completed_at DATETIME
+);
@@ -0,0 +1,8 @@
+-- Version 025 rollback: Message revisions
+-- FUNCTIONAL DISCOVERY: Edited messages keep their current content; the content
+-- they replaced and the edited marker are dropped
+
+DROP TRIGGER content_store_rehash;
+ALTER TABLE messages DROP COLUMN edited_at;
+ALTER TABLE messages DROP COLUMN revision;
+DROP TABLE message_revisions;
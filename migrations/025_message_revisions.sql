@@ -0,0 +1,31 @@
+-- Version 025: Message revisions
+-- FUNCTIONAL DISCOVERY: An instructor can correct a message they sent; the messages
+-- row holds the current content and its revision number, and every content it
+-- replaced - the original as revision 0 - is kept here for instructors to review
+-- ARCHITECTURAL DISCOVERY: Superseded content is stored inline, never through
+-- content_store, so rewriting a message cannot release a body a revision still needs.
+-- Cascades with the message, like message_reads
+-- TECHNICAL DISCOVERY: The content_store triggers of 009 count references on insert
+-- and delete only; an edit changes content_hash in place, so the reference moves with
+-- an update trigger
+
+CREATE TABLE message_revisions (
+    message_id TEXT NOT NULL,
+    revision INTEGER NOT NULL, -- 0 is the content the message was sent with
+    content TEXT NOT NULL, -- JSON, as it read before the edit that replaced it
+    edited_by TEXT NOT NULL, -- Who replaced it
+    replaced_at DATETIME NOT NULL,
+    PRIMARY KEY (message_id, revision),
+    FOREIGN KEY (message_id) REFERENCES messages(id) ON DELETE CASCADE
+) WITHOUT ROWID;
+
+ALTER TABLE messages ADD COLUMN revision INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE messages ADD COLUMN edited_at DATETIME;
+
+CREATE TRIGGER content_store_rehash AFTER UPDATE OF content_hash ON messages
+WHEN OLD.content_hash IS NOT NEW.content_hash
+BEGIN
+    UPDATE content_store SET refcount = refcount + 1 WHERE hash = NEW.content_hash;
+    UPDATE content_store SET refcount = refcount - 1 WHERE hash = OLD.content_hash;
+    DELETE FROM content_store WHERE hash = OLD.content_hash AND refcount <= 0;
+END;
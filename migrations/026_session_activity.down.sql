@@ -0,0 +1,5 @@
+-- Version 026 rollback: Session activity heartbeat
+-- FUNCTIONAL DISCOVERY: Every active session is resumed at startup, however long it
+-- has been idle
+
+ALTER TABLE sessions DROP COLUMN last_activity;
@@ -0,0 +1,8 @@
+-- Version 026: Session activity heartbeat
+-- FUNCTIONAL DISCOVERY: When an active session last routed a message or gained or lost
+-- a connection, so startup can tell sessions that were live when the server stopped
+-- from ones left idle, and the sessions list can show how long ago each was active
+-- TECHNICAL DISCOVERY: Written in batches at most once per activity interval, never
+-- per message; NULL on sessions from before this version
+
+ALTER TABLE sessions ADD COLUMN last_activity DATETIME;
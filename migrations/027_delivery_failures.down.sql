@@ -0,0 +1,5 @@
+-- Version 027 rollback: Delivery failures
+-- FUNCTIONAL DISCOVERY: The recorded failures are deleted; sessions keep the
+-- delivery_reliability events written when they ended
+
+DROP TABLE delivery_failures;
@@ -0,0 +1,19 @@
+-- Version 027: Delivery failures
+-- FUNCTIONAL DISCOVERY: Recipients a routed message did not reach and why, for
+-- reliability analysis after the fact. Above a failure rate only a sample is kept;
+-- weight is how many failures a row stands for
+-- ARCHITECTURAL DISCOVERY: Bounded by row count - each batch written drops the oldest
+-- rows beyond the configured cap. No foreign keys; a session purge deletes its rows
+-- TECHNICAL DISCOVERY: The router writes rows in batches, never per failure
+
+CREATE TABLE delivery_failures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    message_id TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    recipient TEXT NOT NULL,
+    reason TEXT NOT NULL, -- buffer_full, write_timeout, connection_closed, filtered or encode_failed
+    weight INTEGER NOT NULL DEFAULT 1,
+    failed_at DATETIME NOT NULL
+);
+
+CREATE INDEX idx_delivery_failures_session ON delivery_failures(session_id, failed_at);
@@ -0,0 +1,5 @@
+-- Version 028 rollback: Message content sizes
+-- FUNCTIONAL DISCOVERY: Content is untouched; only the recorded sizes are dropped
+
+DROP INDEX idx_messages_type_size;
+ALTER TABLE messages DROP COLUMN content_bytes;
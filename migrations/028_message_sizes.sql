@@ -0,0 +1,19 @@
+-- Version 028: Message content sizes
+-- FUNCTIONAL DISCOVERY: Each message records the byte length of its encoded content,
+-- so storage can be broken down by message type - code submissions against analytics -
+-- without loading content. Content kept in content_store counts at its full length on
+-- every message referencing it
+-- ARCHITECTURAL DISCOVERY: idx_messages_type_size covers the size stats queries: counts
+-- and totals per type, and percentiles read in size order within one type, filtered on
+-- timestamp without touching the table
+-- TECHNICAL DISCOVERY: Existing rows are backfilled from their stored content here;
+-- new rows are written with the size by the application
+
+ALTER TABLE messages ADD COLUMN content_bytes INTEGER NOT NULL DEFAULT 0;
+
+UPDATE messages SET content_bytes = length(CAST(COALESCE(
+    (SELECT body FROM content_store WHERE hash = messages.content_hash),
+    content
+) AS BLOB));
+
+CREATE INDEX idx_messages_type_size ON messages(type, content_bytes, timestamp);
@@ -0,0 +1,10 @@
+package migrations
+
+import "embed"
+
+// Files holds the migration SQL compiled into the binary
+// ARCHITECTURAL DISCOVERY: Lets servers embedded in other modules' tests migrate
+// without knowing where this repository's migrations directory lives on disk
+//
+//go:embed *.sql
+var Files embed.FS
@@ -3,6 +3,7 @@ package database
 import (
 	"database/sql"
 	"errors"
+	"io/fs"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -17,8 +18,37 @@ type Config struct {
 	ConnMaxLifetime time.Duration `json:"conn_max_lifetime"`
 	ConnMaxIdleTime time.Duration `json:"conn_max_idle_time"`
 	MigrationsPath  string        `json:"migrations_path"`
+	ImportBatchSize int           `json:"import_batch_size"` // Messages per import transaction, 0 = default
+	CompactContent  bool          `json:"compact_content"`   // Canonicalize content JSON before storing
+	NoiseKeys       []string      `json:"noise_keys"`        // Top-level content keys dropped when compacting
+	DedupThreshold  int           `json:"dedup_threshold"`   // Stored content bytes at which bodies are shared, 0 = never
+	ReadOnly        bool          `json:"read_only"`         // Open an existing file without write access
+	Migrations      fs.FS         `json:"-"`                 // Takes precedence over MigrationsPath when set
+	// How long to keep retrying a database found locked at startup, 0 = fail fast
+	LockRecoveryTimeout time.Duration `json:"lock_recovery_timeout"`
 }
 
+// MemoryPath as DatabasePath keeps the database in process memory for the lifetime
+// of the manager, e.g. for servers embedded in tests
+const MemoryPath = ":memory:"
+
+// DefaultImportBatchSize bounds each bulk import transaction
+// TECHNICAL DISCOVERY: 500 rows per transaction keeps the single writer responsive
+// to live traffic between batches during large transcript imports
+const DefaultImportBatchSize = 500
+
+// DefaultDedupThreshold is the stored content size at which identical bodies are
+// kept once in content_store
+// FUNCTIONAL DISCOVERY: 8KB catches code submissions and starter files while chat
+// messages, which rarely repeat byte for byte, stay inline
+const DefaultDedupThreshold = 8 << 10
+
+// DefaultLockRecoveryTimeout bounds startup lock recovery
+// FUNCTIONAL DISCOVERY: A process killed mid-write can leave its lock behind for a
+// few seconds while the kernel tears it down; 30s covers that without outlasting a
+// typical supervisor restart delay
+const DefaultLockRecoveryTimeout = 30 * time.Second
+
 // DefaultConfig returns production-ready database configuration
 // FUNCTIONAL DISCOVERY: SQLite performs optimally with 10 connections for
 // classroom-scale concurrent access (20-50 users)
@@ -29,6 +59,10 @@ func DefaultConfig() *Config {
 		ConnMaxLifetime: time.Hour,
 		ConnMaxIdleTime: time.Minute * 10,
 		MigrationsPath:  "./migrations",
+		ImportBatchSize: DefaultImportBatchSize,
+		DedupThreshold:  DefaultDedupThreshold,
+
+		LockRecoveryTimeout: DefaultLockRecoveryTimeout,
 	}
 }
 
@@ -48,9 +82,18 @@ func (c *Config) Validate() error {
 	if c.ConnMaxIdleTime <= 0 {
 		return errors.New("connection max idle time must be greater than 0")
 	}
-	if c.MigrationsPath == "" {
+	if c.MigrationsPath == "" && c.Migrations == nil {
 		return errors.New("migrations path cannot be empty")
 	}
+	if c.ImportBatchSize < 0 {
+		return errors.New("import batch size cannot be negative")
+	}
+	if c.DedupThreshold < 0 {
+		return errors.New("dedup threshold cannot be negative")
+	}
+	if c.LockRecoveryTimeout < 0 {
+		return errors.New("lock recovery timeout cannot be negative")
+	}
 	return nil
 }
 
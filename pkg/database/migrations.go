@@ -1,14 +1,30 @@
 package database
 
 import (
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 )
 
+// downSuffix marks the rollback half of a migration, e.g. 008_session_timezone.down.sql
+const downSuffix = ".down.sql"
+
+var (
+	// ErrChecksumMismatch means an applied migration's file was edited afterwards
+	ErrChecksumMismatch = errors.New("applied migration does not match its file")
+	// ErrNoDownMigration means a rollback needs a .down.sql file that does not exist
+	ErrNoDownMigration = errors.New("migration has no down file")
+)
+
 // Migration represents a database migration
 // ARCHITECTURAL DISCOVERY: Migration struct encapsulates all information needed
 // for safe schema evolution and rollback capability
@@ -16,23 +32,54 @@ type Migration struct {
 	Version     string
 	Description string
 	SQL         string
+	DownSQL     string // Empty when the migration has no .down.sql file
+	Checksum    string // SHA-256 of SQL, recorded in schema_migrations when applied
+}
+
+// MigrationStep is one migration applied (up) or rolled back (down)
+type MigrationStep struct {
+	Migration
+	Down bool
+}
+
+// Statement returns the SQL the step executes
+func (s MigrationStep) Statement() string {
+	if s.Down {
+		return s.DownSQL
+	}
+	return s.SQL
+}
+
+// String describes the step, e.g. "down 008_session_timezone"
+func (s MigrationStep) String() string {
+	direction := "up"
+	if s.Down {
+		direction = "down"
+	}
+	return fmt.Sprintf("%s %s_%s", direction, s.Version, s.Description)
 }
 
 // MigrationManager handles database migrations
 // FUNCTIONAL DISCOVERY: Manager pattern encapsulates migration state and operations
 // enabling safe schema evolution across development and production environments
 type MigrationManager struct {
-	db             *sql.DB
-	migrationsPath string
+	db    *sql.DB
+	files fs.FS // Directory holding the NNN_description[.down].sql files
 }
 
 // NewMigrationManager creates a new migration manager
 // TECHNICAL DISCOVERY: Constructor pattern ensures proper initialization
 // and dependency injection for database operations
 func NewMigrationManager(db *sql.DB, migrationsPath string) *MigrationManager {
+	return NewMigrationManagerFS(db, os.DirFS(migrationsPath))
+}
+
+// NewMigrationManagerFS creates a migration manager reading migrations from files,
+// e.g. the embedded migrations.Files
+func NewMigrationManagerFS(db *sql.DB, files fs.FS) *MigrationManager {
 	return &MigrationManager{
-		db:             db,
-		migrationsPath: migrationsPath,
+		db:    db,
+		files: files,
 	}
 }
 
@@ -52,6 +99,13 @@ func (m *MigrationManager) ApplyMigrations() error {
 		return fmt.Errorf("failed to load migrations: %w", err)
 	}
 
+	// FUNCTIONAL DISCOVERY: An edited historical migration means this database was
+	// built from different SQL than the files describe; refusing to start is safer
+	// than running on a schema nobody can reproduce
+	if err := m.verifyChecksums(migrations, true); err != nil {
+		return err
+	}
+
 	appliedMigrations, err := m.getAppliedMigrations()
 	if err != nil {
 		return fmt.Errorf("failed to get applied migrations: %w", err)
@@ -71,6 +125,158 @@ func (m *MigrationManager) ApplyMigrations() error {
 	return nil
 }
 
+// VerifyChecksums reports applied migrations whose file changed or disappeared
+// TECHNICAL DISCOVERY: Read-only; rows applied before checksums were recorded have
+// an empty checksum and are accepted until ApplyMigrations or MigrateTo fills it in
+func (m *MigrationManager) VerifyChecksums() error {
+	migrations, err := m.loadMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+	return m.verifyChecksums(migrations, false)
+}
+
+// Plan returns the steps that bring the database to target without running them
+// FUNCTIONAL DISCOVERY: A negative target means the latest migration and 0 rolls
+// everything back. Rollbacks run newest first and every one needs a .down.sql file;
+// a missing one fails the whole plan before anything is executed
+func (m *MigrationManager) Plan(target int) ([]MigrationStep, error) {
+	migrations, err := m.loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if err := m.verifyChecksums(migrations, false); err != nil {
+		return nil, err
+	}
+	return m.plan(migrations, target)
+}
+
+// MigrateTo applies or rolls back migrations until the database is at target
+// ARCHITECTURAL DISCOVERY: Each step runs in its own transaction together with its
+// schema_migrations row, so a failure leaves the database at the last good version
+func (m *MigrationManager) MigrateTo(target int) ([]MigrationStep, error) {
+	if err := m.createMigrationTable(); err != nil {
+		return nil, fmt.Errorf("failed to create migration table: %w", err)
+	}
+	migrations, err := m.loadMigrations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if err := m.verifyChecksums(migrations, true); err != nil {
+		return nil, err
+	}
+	steps, err := m.plan(migrations, target)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, step := range steps {
+		if step.Down {
+			err = m.rollbackMigration(step.Migration)
+		} else {
+			err = m.applyMigration(step.Migration)
+		}
+		if err != nil {
+			return steps[:i], fmt.Errorf("failed to run %s: %w", step, err)
+		}
+	}
+	return steps, nil
+}
+
+// plan computes the steps from the applied versions to target
+func (m *MigrationManager) plan(migrations []Migration, target int) ([]MigrationStep, error) {
+	applied, err := m.appliedIfTracked()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	var steps []MigrationStep
+	for i := len(migrations) - 1; i >= 0; i-- {
+		migration := migrations[i]
+		number, err := versionNumber(migration.Version)
+		if err != nil {
+			return nil, err
+		}
+		if target >= 0 && number > target && contains(applied, migration.Version) {
+			if migration.DownSQL == "" {
+				return nil, fmt.Errorf("cannot roll back %s_%s: %w", migration.Version, migration.Description, ErrNoDownMigration)
+			}
+			steps = append(steps, MigrationStep{Migration: migration, Down: true})
+		}
+	}
+	for _, migration := range migrations {
+		number, _ := versionNumber(migration.Version)
+		if (target < 0 || number <= target) && !contains(applied, migration.Version) {
+			steps = append(steps, MigrationStep{Migration: migration})
+		}
+	}
+	return steps, nil
+}
+
+// verifyChecksums compares recorded checksums with the migration files
+// TECHNICAL DISCOVERY: With record set, empty checksums left by older versions are
+// filled in from the current files - the first start after upgrading trusts them
+func (m *MigrationManager) verifyChecksums(migrations []Migration, record bool) error {
+	exists, err := m.tableExists("schema_migrations")
+	if err != nil || !exists {
+		return err
+	}
+	hasChecksums, err := m.columnExists("schema_migrations", "checksum")
+	if err != nil {
+		return err
+	}
+	if !hasChecksums {
+		return nil // Only reachable read-only; createMigrationTable adds the column
+	}
+
+	files := make(map[string]Migration, len(migrations))
+	for _, migration := range migrations {
+		files[migration.Version] = migration
+	}
+
+	rows, err := m.db.Query("SELECT version, checksum FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	var problems []string
+	var unrecorded []string
+	for rows.Next() {
+		var version, checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			_ = rows.Close()
+			return err
+		}
+		migration, exists := files[version]
+		switch {
+		case !exists:
+			problems = append(problems, fmt.Sprintf("%s has no file", version))
+		case checksum == "":
+			unrecorded = append(unrecorded, version)
+		case checksum != migration.Checksum:
+			problems = append(problems, fmt.Sprintf("%s_%s was edited after it was applied", version, migration.Description))
+		}
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrChecksumMismatch, strings.Join(problems, "; "))
+	}
+
+	if record {
+		for _, version := range unrecorded {
+			if _, err := m.db.Exec("UPDATE schema_migrations SET checksum = ? WHERE version = ?", files[version].Checksum, version); err != nil {
+				return fmt.Errorf("failed to record checksum for migration %s: %w", version, err)
+			}
+		}
+		if len(unrecorded) > 0 {
+			log.Printf("Recorded checksums for %d previously applied migration(s)", len(unrecorded))
+		}
+	}
+	return nil
+}
+
 // ValidateSchema ensures database matches expected structure
 // FUNCTIONAL DISCOVERY: Schema validation prevents runtime errors from
 // structural mismatches between code expectations and database reality
@@ -111,14 +317,24 @@ func (m *MigrationManager) ValidateSchema() error {
 }
 
 // createMigrationTable creates the migration tracking table
+// TECHNICAL DISCOVERY: Tables created before checksums existed gain the column here
 func (m *MigrationManager) createMigrationTable() error {
 	sql := `
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version TEXT PRIMARY KEY,
-			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+			applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			checksum TEXT NOT NULL DEFAULT ''
 		)
 	`
-	_, err := m.db.Exec(sql)
+	if _, err := m.db.Exec(sql); err != nil {
+		return err
+	}
+
+	hasChecksums, err := m.columnExists("schema_migrations", "checksum")
+	if err != nil || hasChecksums {
+		return err
+	}
+	_, err = m.db.Exec("ALTER TABLE schema_migrations ADD COLUMN checksum TEXT NOT NULL DEFAULT ''")
 	return err
 }
 
@@ -126,31 +342,48 @@ func (m *MigrationManager) createMigrationTable() error {
 // TECHNICAL DISCOVERY: File-based migrations enable version control integration
 // and collaborative schema evolution
 func (m *MigrationManager) loadMigrations() ([]Migration, error) {
-	files, err := os.ReadDir(m.migrationsPath)
+	files, err := fs.ReadDir(m.files, ".")
 	if err != nil {
 		return nil, err
 	}
 
 	var migrations []Migration
+	downs := make(map[string]string) // version -> down SQL
 	for _, file := range files {
 		if filepath.Ext(file.Name()) == ".sql" {
-			content, err := os.ReadFile(filepath.Join(m.migrationsPath, file.Name()))
+			content, err := fs.ReadFile(m.files, file.Name())
 			if err != nil {
 				return nil, err
 			}
 
 			// Extract version from filename (e.g., "001_initial_schema.sql" -> "001")
 			version := strings.Split(file.Name(), "_")[0]
+			if _, err := versionNumber(version); err != nil {
+				return nil, fmt.Errorf("migration file %s: %w", file.Name(), err)
+			}
+			if strings.HasSuffix(file.Name(), downSuffix) {
+				downs[version] = string(content)
+				continue
+			}
 			description := strings.TrimSuffix(strings.Join(strings.Split(file.Name(), "_")[1:], "_"), ".sql")
 
 			migrations = append(migrations, Migration{
 				Version:     version,
 				Description: description,
 				SQL:         string(content),
+				Checksum:    checksum(content),
 			})
 		}
 	}
 
+	for i := range migrations {
+		migrations[i].DownSQL = downs[migrations[i].Version]
+		delete(downs, migrations[i].Version)
+	}
+	for version := range downs {
+		return nil, fmt.Errorf("down migration %s has no matching up migration", version)
+	}
+
 	// Sort migrations by version
 	sort.Slice(migrations, func(i, j int) bool {
 		return migrations[i].Version < migrations[j].Version
@@ -207,14 +440,44 @@ func (m *MigrationManager) applyMigration(migration Migration) error {
 	}
 
 	// Record the migration as applied
-	_, err = tx.Exec("INSERT INTO schema_migrations (version) VALUES (?)", migration.Version)
+	_, err = tx.Exec("INSERT INTO schema_migrations (version, checksum) VALUES (?, ?)", migration.Version, migration.Checksum)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rollbackMigration runs a migration's down SQL and forgets it was applied
+func (m *MigrationManager) rollbackMigration(migration Migration) error {
+	tx, err := m.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = tx.Rollback() // No-op after a successful commit
+	}()
+
+	if _, err := tx.Exec(migration.DownSQL); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version); err != nil {
+		return err
+	}
 
 	return tx.Commit()
 }
 
+// appliedIfTracked returns applied versions, or none when the tracking table is missing
+// TECHNICAL DISCOVERY: Lets Plan describe a fresh database without creating anything
+func (m *MigrationManager) appliedIfTracked() ([]string, error) {
+	exists, err := m.tableExists("schema_migrations")
+	if err != nil || !exists {
+		return nil, err
+	}
+	return m.getAppliedMigrations()
+}
+
 // tableExists checks if a table exists in the database
 func (m *MigrationManager) tableExists(tableName string) (bool, error) {
 	var count int
@@ -241,6 +504,35 @@ func (m *MigrationManager) indexExists(indexName string) (bool, error) {
 	return count > 0, nil
 }
 
+// columnExists checks if a table has a column
+func (m *MigrationManager) columnExists(tableName, columnName string) (bool, error) {
+	var count int
+	err := m.db.QueryRow(
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name=?",
+		tableName, columnName,
+	).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// versionNumber parses a migration version such as "008"
+func versionNumber(version string) (int, error) {
+	number, err := strconv.Atoi(version)
+	if err != nil || number < 1 {
+		return 0, fmt.Errorf("invalid migration version %q", version)
+	}
+	return number, nil
+}
+
+// checksum hashes migration SQL with line endings normalized, so a checkout that
+// converts to CRLF does not look like an edit
+func checksum(content []byte) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(string(content), "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
 // contains checks if a slice contains a string
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -0,0 +1,288 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"switchboard/migrations"
+)
+
+// openMigrationTestDB opens an empty database in a temporary directory
+func openMigrationTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+// schemaSnapshot returns every sqlite_master entry keyed by type and name
+func schemaSnapshot(t *testing.T, db *sql.DB) map[string]string {
+	t.Helper()
+	rows, err := db.Query("SELECT type, name, COALESCE(sql, '') FROM sqlite_master")
+	if err != nil {
+		t.Fatalf("Failed to read sqlite_master: %v", err)
+	}
+	defer rows.Close()
+
+	schema := make(map[string]string)
+	for rows.Next() {
+		var kind, name, statement string
+		if err := rows.Scan(&kind, &name, &statement); err != nil {
+			t.Fatal(err)
+		}
+		schema[kind+" "+name] = statement
+	}
+	return schema
+}
+
+// copyMigrations copies the repository migrations into a directory the test may edit
+func copyMigrations(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("Failed to find migrations: %v", err)
+	}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, filepath.Base(file)), content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// Functional Validation Tests - Rollback
+
+func TestMigrationManager_RollbackRestoresSchema(t *testing.T) {
+	db := openMigrationTestDB(t)
+	mgr := NewMigrationManager(db, "../../migrations")
+
+	steps, err := mgr.MigrateTo(8)
+	if err != nil {
+		t.Fatalf("MigrateTo(8) failed: %v", err)
+	}
+	if len(steps) != 8 || steps[7].Version != "008" || steps[7].Down {
+		t.Fatalf("Expected eight up steps ending at 008, got %v", steps)
+	}
+	before := schemaSnapshot(t, db)
+
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	steps, err = mgr.MigrateTo(8)
+	if err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if len(steps) != 20 || !steps[0].Down || steps[0].Version != "028" || steps[1].Version != "027" || steps[2].Version != "026" || steps[3].Version != "025" || steps[4].Version != "024" || steps[5].Version != "023" || steps[6].Version != "022" || steps[7].Version != "021" || steps[8].Version != "020" || steps[9].Version != "019" || steps[10].Version != "018" || steps[11].Version != "017" || steps[12].Version != "016" || steps[13].Version != "015" || steps[14].Version != "014" || steps[15].Version != "013" || steps[16].Version != "012" || steps[17].Version != "011" || steps[18].Version != "010" || steps[19].Version != "009" {
+		t.Fatalf("Expected 019, 018, 017, 016, 015, 014, 013, 012, 011, 010 then 009 rolled back, got %v", steps)
+	}
+
+	after := schemaSnapshot(t, db)
+	if len(after) != len(before) {
+		t.Errorf("Expected %d schema objects after rollback, got %d", len(before), len(after))
+	}
+	for name, statement := range before {
+		if after[name] != statement {
+			t.Errorf("%s differs after rollback:\nbefore: %s\nafter:  %s", name, statement, after[name])
+		}
+	}
+
+	// Everything can be rolled back and re-applied
+	if _, err := mgr.MigrateTo(0); err != nil {
+		t.Fatalf("Rollback to 0 failed: %v", err)
+	}
+	if exists, _ := mgr.tableExists("sessions"); exists {
+		t.Error("Rolling back 001 should drop the sessions table")
+	}
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("Re-applying migrations failed: %v", err)
+	}
+	if err := mgr.ValidateSchema(); err != nil {
+		t.Errorf("Schema invalid after re-applying: %v", err)
+	}
+}
+
+func TestMigrationManager_PlanIsDryRun(t *testing.T) {
+	db := openMigrationTestDB(t)
+	mgr := NewMigrationManager(db, "../../migrations")
+
+	steps, err := mgr.Plan(-1)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(steps) != 28 || !strings.Contains(steps[0].Statement(), "CREATE TABLE sessions") {
+		t.Fatalf("Expected all twenty-eight migrations planned, got %v", steps)
+	}
+	if exists, _ := mgr.tableExists("schema_migrations"); exists {
+		t.Error("Plan must not create anything")
+	}
+
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	steps, err = mgr.Plan(7)
+	if err != nil {
+		t.Fatalf("Plan failed: %v", err)
+	}
+	if len(steps) != 21 || steps[0].String() != "down 028_message_sizes" || steps[1].String() != "down 027_delivery_failures" || steps[2].String() != "down 026_session_activity" || steps[3].String() != "down 025_message_revisions" || steps[4].String() != "down 024_session_purges" || steps[5].String() != "down 023_message_attachments" || steps[6].String() != "down 022_message_threads" || steps[7].String() != "down 021_session_instructors" || steps[8].String() != "down 020_message_reads" || steps[9].String() != "down 019_session_metadata" || steps[10].String() != "down 018_instructor_preferences" || steps[11].String() != "down 017_maintenance_jobs" || steps[12].String() != "down 016_message_recipients" || steps[13].String() != "down 015_metrics_rollups" || steps[14].String() != "down 014_poison_messages" || steps[15].String() != "down 013_session_config" || steps[16].String() != "down 012_message_reactions" || steps[17].String() != "down 011_api_keys" || steps[18].String() != "down 010_selftest_probes" || steps[19].String() != "down 009_content_store" || steps[20].String() != "down 008_session_timezone" {
+		t.Fatalf("Expected 019, 018, 017, 016, 015, 014, 013, 012, 011, 010, 009 then 008 rolled back, got %v", steps)
+	}
+	if exists, _ := mgr.columnExists("messages", "content_hash"); !exists {
+		t.Error("Plan must not roll anything back")
+	}
+}
+
+func TestMigrationManager_MissingDownFile(t *testing.T) {
+	dir := copyMigrations(t)
+	if err := os.Remove(filepath.Join(dir, "007_message_annotations.down.sql")); err != nil {
+		t.Fatal(err)
+	}
+	db := openMigrationTestDB(t)
+	mgr := NewMigrationManager(db, dir)
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+
+	if _, err := mgr.MigrateTo(6); !errors.Is(err, ErrNoDownMigration) {
+		t.Fatalf("Expected ErrNoDownMigration, got %v", err)
+	}
+	if exists, _ := mgr.columnExists("sessions", "timezone"); !exists {
+		t.Error("A plan that cannot finish must not roll back 008 either")
+	}
+}
+
+// Technical Validation Tests - Checksums
+
+func TestMigrationManager_ChecksumMismatch(t *testing.T) {
+	dir := copyMigrations(t)
+	db := openMigrationTestDB(t)
+	mgr := NewMigrationManager(db, dir)
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	if err := mgr.VerifyChecksums(); err != nil {
+		t.Fatalf("Unedited migrations should verify: %v", err)
+	}
+
+	// Line endings alone are not an edit
+	path := filepath.Join(dir, "006_session_lock.sql")
+	content, _ := os.ReadFile(path)
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(string(content), "\n", "\r\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("CRLF line endings should not count as an edit: %v", err)
+	}
+
+	if err := os.WriteFile(path, append(content, []byte("\nCREATE INDEX idx_sessions_locked ON sessions(locked);\n")...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := mgr.ApplyMigrations()
+	if !errors.Is(err, ErrChecksumMismatch) || !strings.Contains(err.Error(), "006_session_lock") {
+		t.Fatalf("Expected a checksum mismatch naming 006, got %v", err)
+	}
+	if _, err := mgr.MigrateTo(5); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Expected MigrateTo to refuse as well, got %v", err)
+	}
+}
+
+func TestMigrationManager_RecordsLegacyChecksums(t *testing.T) {
+	db := openMigrationTestDB(t)
+	if _, err := db.Exec(`CREATE TABLE schema_migrations (
+		version TEXT PRIMARY KEY,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile("../../migrations/001_initial_schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(string(content)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("INSERT INTO schema_migrations (version) VALUES ('001')"); err != nil {
+		t.Fatal(err)
+	}
+
+	mgr := NewMigrationManager(db, "../../migrations")
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations on a pre-checksum database failed: %v", err)
+	}
+	var recorded string
+	if err := db.QueryRow("SELECT checksum FROM schema_migrations WHERE version = '001'").Scan(&recorded); err != nil {
+		t.Fatal(err)
+	}
+	if recorded != checksum(content) {
+		t.Errorf("Expected the 001 checksum to be recorded, got %q", recorded)
+	}
+}
+
+// Functional Validation Tests - Data migrations
+
+func TestMigrationManager_BackfillsMessageSizes(t *testing.T) {
+	db := openMigrationTestDB(t)
+	mgr := NewMigrationManager(db, "../../migrations")
+	if _, err := mgr.MigrateTo(27); err != nil {
+		t.Fatalf("MigrateTo(27) failed: %v", err)
+	}
+	shared := `{"code":"print('hello, world')"}`
+	for _, statement := range []string{
+		"INSERT INTO sessions (id, name, created_by, student_ids, start_time, status) VALUES ('s1', 'Lab', 'teacher', '[]', CURRENT_TIMESTAMP, 'active')",
+		"INSERT INTO content_store (hash, body) VALUES ('h1', '" + strings.ReplaceAll(shared, "'", "''") + "')",
+		`INSERT INTO messages (id, session_id, type, from_user, content, content_hash) VALUES ('inline', 's1', 'analytics', 'alice', '{"event":"é"}', NULL)`,
+		"INSERT INTO messages (id, session_id, type, from_user, content, content_hash) VALUES ('shared', 's1', 'request_response', 'alice', '', 'h1')",
+	} {
+		if _, err := db.Exec(statement); err != nil {
+			t.Fatalf("Failed to seed %q: %v", statement, err)
+		}
+	}
+
+	if err := mgr.ApplyMigrations(); err != nil {
+		t.Fatalf("ApplyMigrations failed: %v", err)
+	}
+	for id, want := range map[string]int{"inline": len(`{"event":"é"}`), "shared": len(shared)} {
+		var size int
+		if err := db.QueryRow("SELECT content_bytes FROM messages WHERE id = ?", id).Scan(&size); err != nil {
+			t.Fatal(err)
+		}
+		if size != want {
+			t.Errorf("Expected %s backfilled with %d bytes, got %d", id, want, size)
+		}
+	}
+}
+
+// Functional Validation Tests - Embedded migrations
+
+func TestMigrationManager_EmbeddedMatchesDirectory(t *testing.T) {
+	fromDir := openMigrationTestDB(t)
+	if err := NewMigrationManager(fromDir, "../../migrations").ApplyMigrations(); err != nil {
+		t.Fatalf("Directory migrations failed: %v", err)
+	}
+
+	embedded := openMigrationTestDB(t)
+	if err := NewMigrationManagerFS(embedded, migrations.Files).ApplyMigrations(); err != nil {
+		t.Fatalf("Embedded migrations failed: %v", err)
+	}
+
+	want, got := schemaSnapshot(t, fromDir), schemaSnapshot(t, embedded)
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d schema objects from embedded migrations, got %d", len(want), len(got))
+	}
+	for name, statement := range want {
+		if got[name] != statement {
+			t.Errorf("Schema object %s differs: %q vs %q", name, got[name], statement)
+		}
+	}
+}
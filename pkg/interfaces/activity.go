@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+)
+
+// ActivityStore persists when active sessions were last active
+// ARCHITECTURAL DISCOVERY: Separate from DatabaseManager, like MessageEditor; only the
+// session manager's heartbeat writes it, and only startup reconciliation reads it back
+type ActivityStore interface {
+	// RecordSessionActivity stores the last activity of each session in one write
+	// FUNCTIONAL DISCOVERY: Never moves a session's last activity backwards
+	RecordSessionActivity(ctx context.Context, activity map[string]time.Time) error
+
+	// GetSessionActivity returns the stored last activity of active sessions; sessions
+	// with none recorded are absent
+	GetSessionActivity(ctx context.Context) (map[string]time.Time, error)
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+	"switchboard/pkg/types"
+)
+
+// AnalyticsSink receives analytics messages after they have been routed
+// ARCHITECTURAL DISCOVERY: Export is a separate concern from delivery - live
+// instructor dashboards get analytics through routing, while sinks forward the same
+// messages to external stores such as a data warehouse
+type AnalyticsSink interface {
+	// Consume accepts one routed analytics message
+	// FUNCTIONAL DISCOVERY: Called on the routing path, so implementations used
+	// directly by the router must return quickly; wrap slow sinks in a dispatcher
+	Consume(ctx context.Context, message *types.Message) error
+}
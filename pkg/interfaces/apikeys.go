@@ -0,0 +1,28 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// APIKeyManager issues and checks session-scoped API keys
+// ARCHITECTURAL DISCOVERY: The API server and the WebSocket handler both authenticate
+// keys, and the API server may not depend on internal packages, so they share this
+type APIKeyManager interface {
+	// CreateKey issues a key for sessionID and returns its secret, which is not stored
+	CreateKey(ctx context.Context, sessionID, createdBy, name string, scopes []string, ttl time.Duration) (secret string, key *types.APIKey, err error)
+
+	// ListKeys returns the session's keys, revoked and expired ones included
+	ListKeys(ctx context.Context, sessionID string) ([]*types.APIKey, error)
+
+	// RevokeKey stops a key working at once; ErrNotFound if the session has no such key
+	RevokeKey(ctx context.Context, sessionID, keyID string) error
+
+	// Authenticate resolves a secret to its key
+	// TECHNICAL DISCOVERY: Runs on every WebSocket handshake made with a key, so
+	// implementations must answer from memory after the first use; ErrInvalidAPIKey
+	// covers unknown, expired and revoked keys and keys of ended sessions alike
+	Authenticate(ctx context.Context, secret string) (*types.APIKey, error)
+}
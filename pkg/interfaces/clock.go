@@ -0,0 +1,36 @@
+package interfaces
+
+import "time"
+
+// Clock is the source of time for features that schedule or compare against now
+// ARCHITECTURAL DISCOVERY: Injected by the application into the hub, session manager,
+// router and database manager, so tests can move time with a fake clock instead of
+// sleeping through rate limit windows and session durations
+type Clock interface {
+	Now() time.Time
+
+	// After is time.After on this clock
+	After(d time.Duration) <-chan time.Time
+
+	// NewTimer is time.NewTimer on this clock
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker is time.NewTicker on this clock
+	NewTicker(d time.Duration) Ticker
+
+	// AfterFunc is time.AfterFunc on this clock; the returned Timer's C is nil
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is the part of *time.Timer features use
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the part of *time.Ticker features use
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
@@ -38,4 +38,27 @@ type Connection interface {
 	// TECHNICAL DISCOVERY: Separate authentication step allows WebSocket
 	// upgrade before credential validation, improving connection establishment
 	SetCredentials(userID, role, sessionID string) error
-}
\ No newline at end of file
+}
+
+// ConnectionWriter is the slice of a connection that HTTP handlers need to notify clients
+// ARCHITECTURAL DISCOVERY: Lets internal/api broadcast to session connections without
+// importing internal/websocket; *websocket.Connection satisfies it unchanged
+type ConnectionWriter interface {
+	WriteJSON(v interface{}) error
+	Close() error
+	GetUserID() string
+	GetRole() string
+}
+
+// ConnectionRegistry tracks the connections an embedding service wants to see
+// ARCHITECTURAL DISCOVERY: The application routes through its own registry and reports
+// each connection that joins or leaves it here, so a host service can list and close
+// the connections it serves without depending on internal/websocket
+type ConnectionRegistry interface {
+	// RegisterConnection is called once conn is authenticated and routable; a reconnect
+	// of the same user registers the new connection without unregistering the old one
+	RegisterConnection(conn Connection) error
+
+	// UnregisterConnection is called once conn has left and receives nothing more
+	UnregisterConnection(conn Connection)
+}
@@ -2,6 +2,8 @@ package interfaces
 
 import (
 	"context"
+	"time"
+
 	"switchboard/pkg/types"
 )
 
@@ -33,6 +35,58 @@ type DatabaseManager interface {
 	// when loading multiple sessions for cache initialization
 	ListActiveSessions(ctx context.Context) ([]*types.Session, error)
 
+	// ActiveSessionNames returns the names of a creator's active sessions
+	// FUNCTIONAL DISCOVERY: Backs the duplicate-name policy when the session cache
+	// is cold, without loading every active session
+	ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error)
+
+	// TransferSessionOwner moves an active session from previousOwner to newOwner
+	// ARCHITECTURAL DISCOVERY: The owner change and its session_events audit entry are
+	// one transaction; the update only applies while previousOwner still owns the
+	// session, so concurrent transfers cannot both succeed (ErrSessionNotFound otherwise)
+	TransferSessionOwner(ctx context.Context, sessionID, previousOwner, newOwner, actor string) error
+
+	// SetSessionLocked sets the lock flag of an active session
+	// FUNCTIONAL DISCOVERY: Touches only the locked column, so it cannot race a duration
+	// change or ownership transfer into overwriting their fields (ErrSessionNotFound
+	// when the session is missing or no longer active)
+	SetSessionLocked(ctx context.Context, sessionID string, locked bool) error
+
+	// SetSessionTimezone sets the IANA time zone of an active session
+	// FUNCTIONAL DISCOVERY: Touches only the timezone column (ErrSessionNotFound when
+	// the session is missing or no longer active)
+	SetSessionTimezone(ctx context.Context, sessionID, timezone string) error
+
+	// SetSessionMetadata replaces the metadata of an active session
+	// FUNCTIONAL DISCOVERY: Touches only the metadata column (ErrSessionNotFound when
+	// the session is missing or no longer active)
+	SetSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error
+
+	// SetSessionInstructors replaces the instructor_ids of an active session
+	// FUNCTIONAL DISCOVERY: Touches only the instructor_ids column (ErrSessionNotFound
+	// when the session is missing or no longer active)
+	SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) error
+
+	// SetSessionStudents replaces the student_ids of an active session
+	// FUNCTIONAL DISCOVERY: Touches only the student_ids column (ErrSessionNotFound
+	// when the session is missing or no longer active)
+	SetSessionStudents(ctx context.Context, sessionID string, studentIDs []string) error
+
+	// ActiveSessionsByMetadata returns the IDs of active sessions whose metadata
+	// matches every key/value pair of filters
+	ActiveSessionsByMetadata(ctx context.Context, filters map[string]string) ([]string, error)
+
+	// GetSessionConfig returns the routing configuration a session was created under
+	// FUNCTIONAL DISCOVERY: nil without an error for sessions created before snapshots
+	// were recorded (ErrSessionNotFound when the session is missing)
+	GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error)
+
+	// GetSessionEvents returns a session's audit trail, oldest first
+	GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error)
+
+	// RecordSessionEvent appends an entry to a session's audit trail
+	RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error
+
 	// Message operations
 	// ARCHITECTURAL DISCOVERY: Message operations grouped with session operations
 	// in single interface to enable transaction coordination
@@ -47,6 +101,64 @@ type DatabaseManager interface {
 	// for efficient history replay during WebSocket connection setup
 	GetSessionHistory(ctx context.Context, sessionID string) ([]*types.Message, error)
 
+	// GetSessionHistoryAsOf retrieves the messages of a session sent at or before asOf
+	// FUNCTIONAL DISCOVERY: Same order as GetSessionHistory; reconstructs what had
+	// been sent by a point in time, e.g. for grading disputes
+	GetSessionHistoryAsOf(ctx context.Context, sessionID string, asOf time.Time) ([]*types.Message, error)
+
+	// GetStudentThread retrieves a page of one student's conversation thread: the
+	// instructor_inbox and request_response messages they sent and the inbox_response
+	// and request messages sent to them
+	// FUNCTIONAL DISCOVERY: Same order as GetSessionHistory, starting after the message
+	// afterID when it is given; ErrNotFound when afterID is not in the session
+	GetStudentThread(ctx context.Context, sessionID, studentID, afterID string, limit int) ([]*types.Message, error)
+
+	// GetMessage retrieves one message of a session by ID
+	// FUNCTIONAL DISCOVERY: Returns ErrNotFound when the message is missing or belongs
+	// to another session, so a client-supplied ID cannot reach across sessions
+	GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error)
+
+	// GetAnalyticsBuckets counts a user's analytics messages per context and UTC minute
+	// TECHNICAL DISCOVERY: Grouped in SQL so summaries never load message content
+	GetAnalyticsBuckets(ctx context.Context, sessionID, userID string) ([]*types.AnalyticsBucket, error)
+
+	// GetSessionMessageCounts counts a session's messages per type, UTC minute and sender
+	GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error)
+
+	// ImportMessages persists historical messages in batched transactions
+	// FUNCTIONAL DISCOVERY: Messages whose IDs already exist are skipped rather
+	// than failing the import, so re-running an import is idempotent
+	// Returns the IDs that were skipped as duplicates
+	ImportMessages(ctx context.Context, messages []*types.Message) ([]string, error)
+
+	// StoreMessageMetadata records the sending connection's details for a message
+	// ARCHITECTURAL DISCOVERY: Side table write, called off the routing hot path
+	StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error
+
+	// GetMessageMetadata retrieves connection details for a message (admin only)
+	GetMessageMetadata(ctx context.Context, messageID string) (*types.MessageMetadata, error)
+
+	// SetMessageAnnotation stores an instructor's annotation on a message in sessionID
+	// FUNCTIONAL DISCOVERY: Replaces that instructor's previous annotation; empty tags
+	// and note delete it. Returns ErrNotFound if the message is not in the session
+	SetMessageAnnotation(ctx context.Context, sessionID string, annotation *types.MessageAnnotation) error
+
+	// GetSessionAnnotations retrieves every instructor's annotations in a session
+	GetSessionAnnotations(ctx context.Context, sessionID string) ([]*types.MessageAnnotation, error)
+
+	// SetMessageReaction stores a student's reaction to a message in sessionID
+	// FUNCTIONAL DISCOVERY: Replaces that student's previous reaction to the message.
+	// Returns ErrNotFound if the message is not in the session
+	SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error
+	
+	// GetReactionCounts counts a message's reactions per code
+	GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error)
+	
+	// GetSessionReactionCounts counts reactions per code for a session's messages,
+	// keyed by message ID
+	// TECHNICAL DISCOVERY: Grouped in SQL so history replay never loads reaction rows
+	GetSessionReactionCounts(ctx context.Context, sessionID string) (map[string]map[string]int, error)
+
 	// Health and lifecycle operations
 	// ARCHITECTURAL DISCOVERY: Health checking and lifecycle management
 	// grouped with data operations for comprehensive database status
@@ -56,6 +168,11 @@ type DatabaseManager interface {
 	// hanging health checks from blocking application startup
 	HealthCheck(ctx context.Context) error
 
+	// HealthStatus reports the watchdog's degraded-mode state and counters
+	// FUNCTIONAL DISCOVERY: Unlike HealthCheck this never touches the database, so
+	// /health can report a full disk even while queries still succeed
+	HealthStatus() types.DatabaseHealth
+
 	// Close closes the database connection and cleans up resources
 	// TECHNICAL DISCOVERY: Synchronous close ensures all pending operations
 	// complete before application shutdown
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// DelegationManager grants students instructor message types for a while
+// ARCHITECTURAL DISCOVERY: Kept apart from SessionManager, like SessionPurger from
+// DatabaseManager; only the delegation endpoints manage grants, and the router reads
+// them through a function
+type DelegationManager interface {
+	// GrantDelegation lets userID, a student on the roster, send messageTypes in an
+	// active session for ttl, replacing any grant they already hold
+	GrantDelegation(ctx context.Context, sessionID, grantedBy, userID string, messageTypes []string, ttl time.Duration) (*types.Delegation, error)
+
+	// RevokeDelegation ends userID's grant before it expires and returns it
+	RevokeDelegation(ctx context.Context, sessionID, userID, revokedBy string) (*types.Delegation, error)
+
+	// ListDelegations returns a session's unexpired grants, ordered by user ID
+	ListDelegations(sessionID string) []*types.Delegation
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// DeliveryFailureStore keeps a bounded record of messages that did not reach recipients
+// ARCHITECTURAL DISCOVERY: Separate from DatabaseManager, like RollupStore; only the
+// router writes it, and the rows are for post-hoc analysis in SQL rather than serving
+type DeliveryFailureStore interface {
+	// RecordDeliveryFailures stores failures in one write, then drops the oldest rows
+	// beyond keep; keep 0 keeps every row
+	RecordDeliveryFailures(ctx context.Context, failures []types.DeliveryFailure, keep int) error
+}
@@ -4,6 +4,13 @@ import "errors"
 
 // Common interface errors used across components
 var (
-	ErrSessionNotFound = errors.New("session not found")
-	ErrUnauthorized    = errors.New("unauthorized access")
-)
\ No newline at end of file
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionEnded        = errors.New("session has ended")
+	ErrUnauthorized        = errors.New("unauthorized access")
+	ErrNotFound            = errors.New("record not found")
+	ErrSessionLocked       = errors.New("session is locked: students cannot send messages")
+	ErrDeadlinePassed      = errors.New("the request's deadline has passed")
+	ErrInvalidAPIKey       = errors.New("invalid, expired or revoked API key")
+	ErrInstructorNotMember = errors.New("instructor is not a member of this session")
+	ErrPersistSkipped      = errors.New("message not persisted: database in route-only mode") // Routed without persistence in route-only mode
+)
@@ -3,6 +3,7 @@ package interfaces_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"switchboard/pkg/interfaces"
 	"switchboard/pkg/types"
@@ -25,6 +26,9 @@ type mockSessionManager struct{}
 func (m *mockSessionManager) CreateSession(ctx context.Context, name string, createdBy string, studentIDs []string) (*types.Session, error) {
 	return nil, nil
 }
+func (m *mockSessionManager) ValidateSession(ctx context.Context, name string, createdBy string, studentIDs []string) (*types.SessionValidation, error) {
+	return nil, nil
+}
 func (m *mockSessionManager) GetSession(ctx context.Context, sessionID string) (*types.Session, error) {
 	return nil, nil
 }
@@ -35,12 +39,36 @@ func (m *mockSessionManager) ListActiveSessions(ctx context.Context) ([]*types.S
 func (m *mockSessionManager) ValidateSessionMembership(sessionID, userID, role string) error {
 	return nil
 }
+func (m *mockSessionManager) SetSessionTimezone(ctx context.Context, sessionID, timezone string) (*types.Session, error) {
+	return nil, nil
+}
+func (m *mockSessionManager) UpdateSessionMetadata(ctx context.Context, sessionID string, patch map[string]*string) (*types.Session, error) {
+	return nil, nil
+}
+func (m *mockSessionManager) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) (*types.Session, error) {
+	return nil, nil
+}
+func (m *mockSessionManager) IsSessionLocked(sessionID string) bool {
+	return false
+}
+func (m *mockSessionManager) SetSessionLocked(ctx context.Context, sessionID string, locked bool) (*types.Session, error) {
+	return nil, nil
+}
+func (m *mockSessionManager) TransferOwnership(ctx context.Context, sessionID, newOwner, actor string) (*types.Session, error) {
+	return nil, nil
+}
+func (m *mockSessionManager) SetSessionDuration(ctx context.Context, sessionID string, durationMinutes int) (*types.Session, error) {
+	return nil, nil
+}
+func (m *mockSessionManager) ListUserSessions(ctx context.Context, userID, role string) ([]*types.Session, error) {
+	return nil, nil
+}
 
 type mockRouter struct{}
 
-func (m *mockRouter) RouteMessage(ctx context.Context, message *types.Message) error { return nil }
-func (m *mockRouter) GetRecipients(message *types.Message) ([]*types.Client, error) { return nil, nil }
-func (m *mockRouter) ValidateMessage(message *types.Message, sender *types.Client) error { return nil }
+func (m *mockRouter) RouteMessage(ctx context.Context, message *types.Message, sender interfaces.ConnectionInfo) (types.RouteResult, error) {
+	return types.RouteResult{}, nil
+}
 
 type mockDB struct{}
 
@@ -51,6 +79,31 @@ func (m *mockDB) ListActiveSessions(ctx context.Context) ([]*types.Session, erro
 func (m *mockDB) StoreMessage(ctx context.Context, message *types.Message) error { return nil }
 func (m *mockDB) GetSessionHistory(ctx context.Context, sessionID string) ([]*types.Message, error) { return nil, nil }
 func (m *mockDB) HealthCheck(ctx context.Context) error { return nil }
+func (m *mockDB) ImportMessages(ctx context.Context, messages []*types.Message) ([]string, error) { return nil, nil }
+func (m *mockDB) StoreMessageMetadata(ctx context.Context, metadata *types.MessageMetadata) error { return nil }
+func (m *mockDB) GetMessageMetadata(ctx context.Context, messageID string) (*types.MessageMetadata, error) { return nil, nil }
+func (m *mockDB) HealthStatus() types.DatabaseHealth { return types.DatabaseHealth{} }
+func (m *mockDB) ActiveSessionNames(ctx context.Context, createdBy string) ([]string, error) { return nil, nil }
+func (m *mockDB) TransferSessionOwner(ctx context.Context, sessionID, previousOwner, newOwner, actor string) error { return nil }
+func (m *mockDB) GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error) { return nil, nil }
+func (m *mockDB) GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error) { return nil, nil }
+func (m *mockDB) SetSessionLocked(ctx context.Context, sessionID string, locked bool) error { return nil }
+func (m *mockDB) SetMessageAnnotation(ctx context.Context, sessionID string, annotation *types.MessageAnnotation) error { return nil }
+func (m *mockDB) GetSessionAnnotations(ctx context.Context, sessionID string) ([]*types.MessageAnnotation, error) { return nil, nil }
+func (m *mockDB) SetMessageReaction(ctx context.Context, sessionID string, reaction *types.MessageReaction) error { return nil }
+func (m *mockDB) GetReactionCounts(ctx context.Context, messageID string) (map[string]int, error) { return nil, nil }
+func (m *mockDB) GetSessionReactionCounts(ctx context.Context, sessionID string) (map[string]map[string]int, error) { return nil, nil }
+func (m *mockDB) GetSessionHistoryAsOf(ctx context.Context, sessionID string, asOf time.Time) ([]*types.Message, error) { return nil, nil }
+func (m *mockDB) GetStudentThread(ctx context.Context, sessionID, studentID, afterID string, limit int) ([]*types.Message, error) { return nil, nil }
+func (m *mockDB) GetAnalyticsBuckets(ctx context.Context, sessionID, userID string) ([]*types.AnalyticsBucket, error) { return nil, nil }
+func (m *mockDB) SetSessionTimezone(ctx context.Context, sessionID, timezone string) error { return nil }
+func (m *mockDB) SetSessionMetadata(ctx context.Context, sessionID string, metadata map[string]string) error { return nil }
+func (m *mockDB) SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) error { return nil }
+func (m *mockDB) SetSessionStudents(ctx context.Context, sessionID string, studentIDs []string) error { return nil }
+func (m *mockDB) ActiveSessionsByMetadata(ctx context.Context, filters map[string]string) ([]string, error) { return nil, nil }
+func (m *mockDB) GetMessage(ctx context.Context, sessionID, messageID string) (*types.Message, error) { return nil, interfaces.ErrNotFound }
+func (m *mockDB) RecordSessionEvent(ctx context.Context, event *types.SessionEvent) error { return nil }
+func (m *mockDB) GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error) { return nil, nil }
 func (m *mockDB) Close() error { return nil }
 
 // Architectural Validation Tests - Ensure interfaces are properly defined
@@ -116,12 +169,9 @@ func TestMessageRouter_InterfaceContract(t *testing.T) {
 	var router interfaces.MessageRouter = &mockRouter{}
 	ctx := context.Background()
 	msg := &types.Message{}
-	client := &types.Client{}
 	
 	// Test method existence
-	_ = router.RouteMessage(ctx, msg)
-	_, _ = router.GetRecipients(msg)
-	_ = router.ValidateMessage(msg, client)
+	_, _ = router.RouteMessage(ctx, msg, nil)
 }
 
 // Functional Validation Tests - DatabaseManager Interface
@@ -177,7 +227,7 @@ func TestInterfaces_SingleResponsibility(t *testing.T) {
 		{
 			name:           "MessageRouter interface",
 			interfaceName:  "MessageRouter",
-			methodCount:    3, // RouteMessage, GetRecipients, ValidateMessage
+			methodCount:    1, // RouteMessage
 			responsibility: "Message routing logic",
 		},
 		{
@@ -208,13 +258,13 @@ func TestInterfaces_TypeUsage(t *testing.T) {
 		session *types.Session
 	}
 	
-	// MessageRouter should use types.Message and types.Client
+	// MessageRouter should use types.Message and types.RouteResult
 	type routerTest struct {
 		message *types.Message
-		client  *types.Client
+		result  types.RouteResult
 	}
 	
 	// Verify types exist and are properly imported
 	_ = sessionTest{session: &types.Session{}}
-	_ = routerTest{message: &types.Message{}, client: &types.Client{}}
+	_ = routerTest{message: &types.Message{}, result: types.RouteResult{}}
 }
\ No newline at end of file
@@ -0,0 +1,27 @@
+package interfaces
+
+import (
+	"context"
+	"errors"
+
+	"switchboard/pkg/types"
+)
+
+// Reasons StartMaintenance refuses a job
+var (
+	ErrUnknownMaintenanceTask = errors.New("unknown maintenance task")
+	ErrMaintenanceRunning     = errors.New("a maintenance job is already running")
+	ErrBackupInProgress       = errors.New("a backup is in progress")
+)
+
+// MaintenanceRunner runs database maintenance jobs in the background
+// ARCHITECTURAL DISCOVERY: Kept apart from DatabaseManager, like ReportReader; only
+// the admin maintenance endpoints start or read jobs
+type MaintenanceRunner interface {
+	// StartMaintenance validates tasks and starts running them in order, returning
+	// the job as it was when it started
+	StartMaintenance(tasks []string) (*types.MaintenanceJob, error)
+
+	// MaintenanceJob returns a job by ID, running or finished; ErrNotFound when unknown
+	MaintenanceJob(ctx context.Context, jobID string) (*types.MaintenanceJob, error)
+}
@@ -0,0 +1,20 @@
+package interfaces
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// PreferenceStore persists instructors' inbox preferences so they survive reconnects
+// and restarts
+// ARCHITECTURAL DISCOVERY: Kept apart from DatabaseManager, like RollupStore; only the
+// router's instructor fan-out reads or writes preferences
+type PreferenceStore interface {
+	// SetInboxPreferences stores an instructor's preferences, replacing earlier ones;
+	// empty filters delete them
+	SetInboxPreferences(ctx context.Context, preferences *types.InboxPreferences) error
+
+	// GetInboxPreferences returns every instructor's preferences in a session
+	GetInboxPreferences(ctx context.Context, sessionID string) ([]*types.InboxPreferences, error)
+}
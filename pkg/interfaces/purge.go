@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// SessionPurger permanently deletes sessions and everything attached to them
+// ARCHITECTURAL DISCOVERY: Kept apart from DatabaseManager, like MaintenanceRunner;
+// only the purge endpoints delete data
+type SessionPurger interface {
+	// PurgeSession deletes a session's data in batches and then the session, resuming
+	// an earlier interrupted purge of it
+	// FUNCTIONAL DISCOVERY: Returns the progress so far with ctx's error when ctx ends
+	// between batches; calling again continues from there. A finished purge is
+	// returned as it ended
+	PurgeSession(ctx context.Context, sessionID, requestedBy string) (*types.SessionPurge, error)
+}
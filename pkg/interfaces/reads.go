@@ -0,0 +1,23 @@
+package interfaces
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// ReadReceiptStore persists which students have read which messages
+// ARCHITECTURAL DISCOVERY: Separate from DatabaseManager so only the router, which
+// records receipts, and the API, which reports them, depend on it
+type ReadReceiptStore interface {
+	// RecordMessageRead stores a student's first read of a message in sessionID
+	// FUNCTIONAL DISCOVERY: Returns false without an error when the student already
+	// read it; ErrNotFound when the message is not in the session
+	RecordMessageRead(ctx context.Context, sessionID string, read *types.MessageRead) (bool, error)
+
+	// GetMessageReads returns a message's reads, oldest first
+	GetMessageReads(ctx context.Context, messageID string) ([]*types.MessageRead, error)
+
+	// CountMessageReads counts the students who have read a message
+	CountMessageReads(ctx context.Context, messageID string) (int, error)
+}
@@ -0,0 +1,35 @@
+package interfaces
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// ReportReader reads what session reports are built from
+// ARCHITECTURAL DISCOVERY: Kept apart from DatabaseManager so the database can answer
+// it from a read-only handle; a report of a huge session then never competes with
+// live traffic for the connections history and stats use
+type ReportReader interface {
+	// GetSessionMessageCounts is DatabaseManager.GetSessionMessageCounts
+	GetSessionMessageCounts(ctx context.Context, sessionID string) (*types.SessionMessageCounts, error)
+
+	// GetSessionEvents is DatabaseManager.GetSessionEvents
+	GetSessionEvents(ctx context.Context, sessionID string) ([]*types.SessionEvent, error)
+
+	// GetSessionConfig is DatabaseManager.GetSessionConfig
+	GetSessionConfig(ctx context.Context, sessionID string) (*types.SessionConfig, error)
+
+	// GetSessionAnalytics counts a session's analytics messages per context and minute,
+	// summed over all senders
+	GetSessionAnalytics(ctx context.Context, sessionID string) ([]*types.AnalyticsBucket, error)
+
+	// StreamQuestions calls fn for each student question, grouped by student and oldest
+	// first within a student; an error from fn stops the scan and is returned
+	// TECHNICAL DISCOVERY: Only one question and its responses are held at a time
+	StreamQuestions(ctx context.Context, sessionID string, fn func(*types.ReportQuestion) error) error
+
+	// StreamStarredMessages calls fn for each message an instructor tagged "star",
+	// oldest first; an error from fn stops the scan and is returned
+	StreamStarredMessages(ctx context.Context, sessionID string, fn func(*types.Message) error) error
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// MessageEditor replaces the content of persisted messages, keeping what it replaced
+// ARCHITECTURAL DISCOVERY: Separate from DatabaseManager, like ReadReceiptStore; only
+// the edit and revision endpoints change or read revisions
+type MessageEditor interface {
+	// EditMessage stores content as the message's next revision and returns the
+	// message as it now reads
+	// FUNCTIONAL DISCOVERY: ErrNotFound when the message is not in sessionID. Who may
+	// edit what is for the caller to decide
+	EditMessage(ctx context.Context, sessionID, messageID, editedBy string, content map[string]interface{}) (*types.Message, error)
+
+	// GetMessageRevisions returns the content a message's edits replaced, oldest first
+	GetMessageRevisions(ctx context.Context, messageID string) ([]*types.MessageRevision, error)
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// RollupStore persists per-minute throughput buckets across restarts
+// ARCHITECTURAL DISCOVERY: Kept apart from DatabaseManager, like ReportReader; only
+// the router's rollup writer reads or writes throughput
+type RollupStore interface {
+	// StoreThroughputBuckets writes buckets, replacing any stored for the same minutes
+	StoreThroughputBuckets(ctx context.Context, buckets []types.ThroughputBucket) error
+
+	// LoadThroughputBuckets returns the buckets starting at or after since, oldest first
+	LoadThroughputBuckets(ctx context.Context, since time.Time) ([]types.ThroughputBucket, error)
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+
+	"switchboard/pkg/types"
+)
+
+// RosterEditor adds and removes students on an active session's roster
+// ARCHITECTURAL DISCOVERY: Kept apart from SessionManager, like DelegationManager;
+// only the roster import changes rosters after a session is created
+type RosterEditor interface {
+	// UpdateRoster applies change and returns the session as it is, or would be on a
+	// dry run, with the effective change
+	UpdateRoster(ctx context.Context, sessionID string, change types.RosterChange, dryRun bool) (*types.Session, types.RosterChange, error)
+}
@@ -5,24 +5,26 @@ import (
 	"switchboard/pkg/types"
 )
 
+// ConnectionInfo identifies the connection a message was sent on
+// ARCHITECTURAL DISCOVERY: Read-only subset of a WebSocket connection, so routers
+// can validate and audit the sender without depending on the websocket package
+type ConnectionInfo interface {
+	GetUserID() string
+	GetRole() string
+	GetSessionID() string
+	GetConnectionID() string
+	GetClientIP() string
+	GetUserAgent() string
+}
+
 // MessageRouter handles message routing between clients
 // ARCHITECTURAL DISCOVERY: Routing logic abstracted from message delivery
 // enables different routing strategies and simplifies testing with mocks
 type MessageRouter interface {
-	// RouteMessage routes a message to appropriate recipients
+	// RouteMessage validates, persists and delivers a message from sender
 	// FUNCTIONAL DISCOVERY: Context enables timeout and cancellation during
 	// database persistence and recipient delivery phases
-	RouteMessage(ctx context.Context, message *types.Message) error
-
-	// GetRecipients determines recipients for a message
-	// ARCHITECTURAL DISCOVERY: Recipient calculation separated from delivery
-	// enables testing routing logic without actual message delivery
-	// FUNCTIONAL DISCOVERY: Returns Client slice for efficient iteration
-	// during bulk message delivery operations
-	GetRecipients(message *types.Message) ([]*types.Client, error)
-
-	// ValidateMessage validates message content and permissions
-	// FUNCTIONAL DISCOVERY: Sender context required for role-based validation
-	// and rate limiting checks before message processing
-	ValidateMessage(message *types.Message, sender *types.Client) error
-}
\ No newline at end of file
+	// TECHNICAL DISCOVERY: The sender is the connection the message arrived on,
+	// passed in rather than looked up so a reconnect mid-route cannot swap it
+	RouteMessage(ctx context.Context, message *types.Message, sender ConnectionInfo) (types.RouteResult, error)
+}
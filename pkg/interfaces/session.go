@@ -14,6 +14,12 @@ type SessionManager interface {
 	// to ensure consistent business rules across different creation paths
 	CreateSession(ctx context.Context, name string, createdBy string, studentIDs []string) (*types.Session, error)
 
+	// ValidateSession reports whether CreateSession would accept a request, without
+	// creating anything
+	// ARCHITECTURAL DISCOVERY: Must share CreateSession's validation, so a dry run can
+	// never disagree with the real call; the error is for failed checks, not refusals
+	ValidateSession(ctx context.Context, name string, createdBy string, studentIDs []string) (*types.SessionValidation, error)
+
 	// GetSession retrieves a session by ID
 	// ARCHITECTURAL DISCOVERY: Returns pointer to enable efficient caching
 	// and reduce memory allocation for frequently accessed sessions
@@ -21,7 +27,8 @@ type SessionManager interface {
 
 	// EndSession ends an active session
 	// FUNCTIONAL DISCOVERY: Session termination updates both database and cache
-	// atomically to prevent stale session access after termination
+	// atomically to prevent stale session access after termination; ending an ended
+	// session fails with an "already ended" error and has no side effects
 	EndSession(ctx context.Context, sessionID string) error
 
 	// ListActiveSessions returns all active sessions
@@ -33,4 +40,40 @@ type SessionManager interface {
 	// ARCHITECTURAL DISCOVERY: Role-based validation abstracted to interface
 	// enables different validation strategies (cache-first, database-only, etc.)
 	ValidateSessionMembership(sessionID, userID, role string) error
-}
\ No newline at end of file
+
+	// ListUserSessions returns the active sessions a user may join
+	// FUNCTIONAL DISCOVERY: Lets clients discover their sessions instead of
+	// receiving session IDs out of band
+	ListUserSessions(ctx context.Context, userID, role string) ([]*types.Session, error)
+
+	// SetSessionDuration sets or changes a session's duration limit in minutes
+	// FUNCTIONAL DISCOVERY: Counted from start_time; changing it reschedules the
+	// countdown warnings and automatic end, 0 removes the limit
+	SetSessionDuration(ctx context.Context, sessionID string, durationMinutes int) (*types.Session, error)
+
+	// SetSessionLocked locks or unlocks student sending in an active session
+	SetSessionLocked(ctx context.Context, sessionID string, locked bool) (*types.Session, error)
+
+	// SetSessionTimezone sets the IANA time zone exports of an active session use
+	SetSessionTimezone(ctx context.Context, sessionID, timezone string) (*types.Session, error)
+
+	// UpdateSessionMetadata applies a merge patch to an active session's metadata
+	// FUNCTIONAL DISCOVERY: A null value removes its key; the result must pass
+	// types.ValidateSessionMetadata or nothing changes
+	UpdateSessionMetadata(ctx context.Context, sessionID string, patch map[string]*string) (*types.Session, error)
+
+	// SetSessionInstructors replaces the co-instructors listed on an active session
+	// FUNCTIONAL DISCOVERY: The creator and owner are always members and need not be
+	// listed; an empty list leaves only them
+	SetSessionInstructors(ctx context.Context, sessionID string, instructorIDs []string) (*types.Session, error)
+
+	// IsSessionLocked reports whether students are currently barred from sending
+	// TECHNICAL DISCOVERY: Answered from the in-memory cache - called for every routed
+	// student message, so it never touches the database
+	IsSessionLocked(sessionID string) bool
+
+	// TransferOwnership makes newOwner the owner of an active session
+	// ARCHITECTURAL DISCOVERY: actor is recorded in the audit trail only; deciding who
+	// may transfer is left to the caller, which knows who is connected
+	TransferOwnership(ctx context.Context, sessionID, newOwner, actor string) (*types.Session, error)
+}
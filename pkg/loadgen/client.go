@@ -0,0 +1,125 @@
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"switchboard/pkg/types"
+)
+
+// Client is one simulated participant's WebSocket connection
+// TECHNICAL DISCOVERY: Writes are serialized by the mutex; gorilla connections allow
+// one concurrent writer and the sender and failure injector share the connection
+type Client struct {
+	UserID    string
+	Role      string
+	SessionID string
+
+	baseURL   string
+	readDelay time.Duration // Slow reader pause before every read; 0 reads at full speed
+
+	mu   sync.Mutex
+	conn *websocket.Conn
+
+	stateMu sync.Mutex
+	state   types.SyncState // What the client has received, sent as sync_state on reconnect
+}
+
+// NewClient creates a disconnected client for a server base URL like http://host:port
+func NewClient(baseURL, userID, role, sessionID string) *Client {
+	return &Client{UserID: userID, Role: role, SessionID: sessionID, baseURL: baseURL}
+}
+
+// wsURL is the client's /ws URL with its identity in the query, and its sync state
+// once it has received a message
+func (c *Client) wsURL() string {
+	base := "ws" + strings.TrimPrefix(c.baseURL, "http")
+	query := url.Values{"user_id": {c.UserID}, "role": {c.Role}, "session_id": {c.SessionID}}
+	if state := c.SyncState(); state.LastMessageID != "" {
+		query.Set("sync_state", state.Encode())
+	}
+	return base + "/ws?" + query.Encode()
+}
+
+// SyncState returns what the client has received so far
+func (c *Client) SyncState() types.SyncState {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	return c.state
+}
+
+// track moves the sync state past a received frame
+// FUNCTIONAL DISCOVERY: Any frame's timestamp advances SyncedAt, since it is the
+// server's clock; only messages with an ID move the cursor, which server notices lack
+func (c *Client) track(frame map[string]interface{}) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+	if raw, ok := frame["timestamp"].(string); ok {
+		if timestamp, err := time.Parse(time.RFC3339Nano, raw); err == nil && timestamp.After(c.state.SyncedAt) {
+			c.state.SyncedAt = timestamp
+		}
+	}
+	if id, ok := frame["id"].(string); ok && id != "" && frame["type"] != "system" {
+		c.state.LastMessageID = id
+	}
+}
+
+// Connect dials the server; receive is called for every frame until the connection
+// closes, after which done is closed
+// FUNCTIONAL DISCOVERY: A reconnect sends the sync state, so the server only replays
+// the history the client missed while it was away
+func (c *Client) Connect(ctx context.Context, receive func(frame map[string]interface{})) (done <-chan struct{}, err error) {
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, c.wsURL(), nil)
+	if err != nil {
+		if resp != nil {
+			return nil, fmt.Errorf("connect %s: %w (status %d)", c.UserID, err, resp.StatusCode)
+		}
+		return nil, fmt.Errorf("connect %s: %w", c.UserID, err)
+	}
+	c.mu.Lock()
+	c.conn = conn
+	c.mu.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if c.readDelay > 0 {
+				time.Sleep(c.readDelay)
+			}
+			var frame map[string]interface{}
+			if err := conn.ReadJSON(&frame); err != nil {
+				return
+			}
+			c.track(frame)
+			receive(frame)
+		}
+	}()
+	return closed, nil
+}
+
+// Send writes a message; it fails when the client is not connected
+func (c *Client) Send(message *types.Message) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return fmt.Errorf("%s is not connected", c.UserID)
+	}
+	_ = c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return c.conn.WriteJSON(message)
+}
+
+// Close drops the connection without a close handshake, like a lost network
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn = nil
+	}
+}
@@ -0,0 +1,115 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/pkg/testserver"
+	"switchboard/pkg/types"
+)
+
+// FUNCTIONAL VALIDATION TEST: A short run against an embedded server delivers the mix,
+// measures per-type latency and survives injected failures
+func TestRun_WithFailureInjection(t *testing.T) {
+	server := testserver.New(t, testserver.Options{InMemoryDB: true})
+	profile := DefaultProfile()
+	profile.URL = server.URL
+	profile.Instructors = 2
+	profile.Students = 6
+	profile.Duration = 2 * time.Second
+	profile.MessagesPerMinute = 90
+	profile.Failures = Failures{
+		DisconnectEvery: 400 * time.Millisecond,
+		ReconnectAfter:  100 * time.Millisecond,
+		SlowReaders:     0.25,
+		SlowReadDelay:   20 * time.Millisecond,
+		Oversized:       0.1,
+	}
+
+	report, err := Run(context.Background(), profile, t.Logf)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.ConnectionsEstablished != 8 || report.MessagesSent == 0 || report.MessagesReceived == 0 {
+		t.Fatalf("Expected every client connected and messages delivered, got %+v", report)
+	}
+	if report.Disconnects == 0 || report.SlowReaders != 2 {
+		t.Errorf("Expected injected disconnects and 2 slow readers, got %+v", report)
+	}
+	if latency, ok := report.LatencyByType[types.MessageTypeInstructorInbox]; !ok || latency.Count == 0 || latency.P99Ms < latency.P50Ms {
+		t.Errorf("Expected instructor_inbox latency percentiles, got %+v", report.LatencyByType)
+	}
+
+	var csv bytes.Buffer
+	if err := report.WriteCSV(&csv); err != nil || !strings.Contains(csv.String(), "latency_instructor_inbox,") {
+		t.Errorf("Expected a CSV row per message type, got %v\n%s", err, csv.String())
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: YAML and JSON profiles layer over the defaults
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	yamlPath := filepath.Join(dir, "soak.yaml")
+	os.WriteFile(yamlPath, []byte(`# Nightly soak
+url: http://switchboard.internal:8080
+students: 120
+duration: 30m
+mix:
+  instructor_inbox: 4
+  analytics: 1 # telemetry
+failures:
+  disconnect_every: 5s
+  oversized: 0.01
+`), 0o644)
+
+	profile, err := LoadProfile(yamlPath)
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+	if profile.URL != "http://switchboard.internal:8080" || profile.Students != 120 || profile.Instructors != 2 || profile.Duration != 30*time.Minute {
+		t.Errorf("Expected file values over defaults, got %+v", profile)
+	}
+	if len(profile.Mix) != 2 || profile.Mix[types.MessageTypeAnalytics] != 1 {
+		t.Errorf("Expected the file's mix to replace the default, got %v", profile.Mix)
+	}
+	if profile.Failures.DisconnectEvery != 5*time.Second || profile.Failures.Oversized != 0.01 || profile.Failures.ReconnectAfter != time.Second {
+		t.Errorf("Expected failures layered over defaults, got %+v", profile.Failures)
+	}
+	if err := profile.Validate(); err != nil {
+		t.Errorf("Expected a valid profile, got %v", err)
+	}
+
+	jsonPath := filepath.Join(dir, "soak.json")
+	os.WriteFile(jsonPath, []byte(`{"mix": {"reaction": 1}}`), 0o644)
+	if profile, err := LoadProfile(jsonPath); err != nil || profile.Validate() == nil {
+		t.Errorf("Expected reactions refused in the mix, got %v", err)
+	}
+
+	if _, err := ParseMix("instructor_inbox=5,analytics"); err == nil {
+		t.Error("Expected a mix entry without a weight refused")
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: Clients track the last message they received and send it
+// as sync_state when they reconnect
+func TestClient_TracksSyncState(t *testing.T) {
+	client := NewClient("http://localhost:8080", "student1", "student", "s1")
+	if strings.Contains(client.wsURL(), "sync_state") {
+		t.Error("Expected no sync_state before any message arrives")
+	}
+
+	client.track(map[string]interface{}{"type": "instructor_broadcast", "id": "m1", "timestamp": "2026-03-02T10:32:00Z"})
+	client.track(map[string]interface{}{"type": "system", "timestamp": "2026-03-02T10:33:00Z", "content": map[string]interface{}{"event": "history_complete"}})
+	state := client.SyncState()
+	if state.LastMessageID != "m1" || state.SyncedAt.Minute() != 33 {
+		t.Errorf("Expected the cursor on m1 and the latest server time, got %+v", state)
+	}
+	if !strings.Contains(client.wsURL(), "sync_state="+state.Encode()) {
+		t.Errorf("Expected the state in the handshake, got %s", client.wsURL())
+	}
+}
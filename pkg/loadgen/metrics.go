@@ -0,0 +1,379 @@
+package loadgen
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics tracks what a load run sent, received and measured
+// ARCHITECTURAL DISCOVERY: Shared by the loadgen command and the load tests under
+// tests/scenarios, so a soak run and a test report the same numbers. Counters are
+// updated with sync/atomic; latencies and resource peaks go through the mutex
+type Metrics struct {
+	MessagesSent           int64
+	MessagesReceived       int64
+	ConnectionsEstablished int64
+	ConnectionsFailed      int64
+	AverageLatency         time.Duration
+	MaxLatency             time.Duration
+	MinLatency             time.Duration
+	ErrorCount             int64
+	StartTime              time.Time
+	EndTime                time.Time
+
+	// Resource monitoring of the process generating load
+	MaxGoroutines       int
+	MaxMemoryMB         uint64
+	DatabaseConnections int
+
+	// Failure injection
+	Disconnects   int64 // Connections dropped on purpose
+	Reconnects    int64 // Dropped connections that came back
+	SlowReaders   int64 // Connections reading with a delay
+	OversizedSent int64 // Messages sent over the server's content limit
+	ServerErrors  int64 // message_error replies, including refused oversized messages
+
+	mu        sync.RWMutex
+	latencies []time.Duration
+	byType    map[string][]time.Duration
+}
+
+// NewMetrics creates metrics for a run starting now
+func NewMetrics() *Metrics {
+	return &Metrics{StartTime: time.Now()}
+}
+
+// AddLatency records a latency measurement not tied to a message type
+func (m *Metrics) AddLatency(latency time.Duration) {
+	m.AddTypedLatency("", latency)
+}
+
+// AddTypedLatency records a latency measurement for messageType
+func (m *Metrics) AddTypedLatency(messageType string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.latencies = append(m.latencies, latency)
+	if messageType != "" {
+		if m.byType == nil {
+			m.byType = make(map[string][]time.Duration)
+		}
+		m.byType[messageType] = append(m.byType[messageType], latency)
+	}
+
+	if latency > m.MaxLatency {
+		m.MaxLatency = latency
+	}
+	if m.MinLatency == 0 || latency < m.MinLatency {
+		m.MinLatency = latency
+	}
+}
+
+// RecordSend counts a send that started at start, recording how long it took
+// FUNCTIONAL DISCOVERY: Send time is all a caller without receipts can measure; the
+// loadgen runner also records end-to-end latency when the message arrives
+func (m *Metrics) RecordSend(messageType string, start time.Time, err error) {
+	if err != nil {
+		atomic.AddInt64(&m.ErrorCount, 1)
+		return
+	}
+	atomic.AddInt64(&m.MessagesSent, 1)
+	m.AddTypedLatency(messageType, time.Since(start))
+}
+
+// CalculateAverageLatency computes average latency from recorded measurements
+func (m *Metrics) CalculateAverageLatency() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.latencies) == 0 {
+		return
+	}
+
+	var total time.Duration
+	for _, latency := range m.latencies {
+		total += latency
+	}
+
+	m.AverageLatency = total / time.Duration(len(m.latencies))
+}
+
+// observeResources raises the resource peaks to the current readings
+func (m *Metrics) observeResources(goroutines int, memoryMB uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if goroutines > m.MaxGoroutines {
+		m.MaxGoroutines = goroutines
+	}
+	if memoryMB > m.MaxMemoryMB {
+		m.MaxMemoryMB = memoryMB
+	}
+}
+
+// LatencyPercentiles summarizes the latencies recorded for one message type
+type LatencyPercentiles struct {
+	Count int     `json:"count"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// percentiles summarizes latencies, sorting them in place
+func percentiles(latencies []time.Duration) LatencyPercentiles {
+	if len(latencies) == 0 {
+		return LatencyPercentiles{}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	at := func(p float64) float64 {
+		index := int(p*float64(len(latencies))+0.5) - 1
+		if index < 0 {
+			index = 0
+		}
+		if index >= len(latencies) {
+			index = len(latencies) - 1
+		}
+		return milliseconds(latencies[index])
+	}
+	return LatencyPercentiles{
+		Count: len(latencies),
+		P50Ms: at(0.50),
+		P95Ms: at(0.95),
+		P99Ms: at(0.99),
+		MaxMs: milliseconds(latencies[len(latencies)-1]),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// Report is a finished run's metrics in the shape written as JSON or CSV
+type Report struct {
+	DurationSeconds        float64                       `json:"duration_seconds"`
+	MessagesSent           int64                         `json:"messages_sent"`
+	MessagesReceived       int64                         `json:"messages_received"`
+	SuccessRate            float64                       `json:"success_rate"` // MessagesReceived as a percent of MessagesSent
+	MessagesPerSecond      float64                       `json:"messages_per_second"`
+	ConnectionsEstablished int64                         `json:"connections_established"`
+	ConnectionsFailed      int64                         `json:"connections_failed"`
+	ErrorCount             int64                         `json:"error_count"`
+	AverageLatencyMs       float64                       `json:"average_latency_ms"`
+	MinLatencyMs           float64                       `json:"min_latency_ms"`
+	MaxLatencyMs           float64                       `json:"max_latency_ms"`
+	MaxGoroutines          int                           `json:"max_goroutines"`
+	MaxMemoryMB            uint64                        `json:"max_memory_mb"`
+	DatabaseConnections    int                           `json:"database_connections"`
+	Disconnects            int64                         `json:"disconnects"`
+	Reconnects             int64                         `json:"reconnects"`
+	SlowReaders            int64                         `json:"slow_readers"`
+	OversizedSent          int64                         `json:"oversized_sent"`
+	ServerErrors           int64                         `json:"server_errors"`
+	LatencyByType          map[string]LatencyPercentiles `json:"latency_by_type"`
+}
+
+// Report summarizes the metrics; EndTime must be set
+func (m *Metrics) Report() *Report {
+	m.CalculateAverageLatency()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	duration := m.EndTime.Sub(m.StartTime)
+	report := &Report{
+		DurationSeconds:        duration.Seconds(),
+		MessagesSent:           atomic.LoadInt64(&m.MessagesSent),
+		MessagesReceived:       atomic.LoadInt64(&m.MessagesReceived),
+		ConnectionsEstablished: atomic.LoadInt64(&m.ConnectionsEstablished),
+		ConnectionsFailed:      atomic.LoadInt64(&m.ConnectionsFailed),
+		ErrorCount:             atomic.LoadInt64(&m.ErrorCount),
+		AverageLatencyMs:       milliseconds(m.AverageLatency),
+		MinLatencyMs:           milliseconds(m.MinLatency),
+		MaxLatencyMs:           milliseconds(m.MaxLatency),
+		MaxGoroutines:          m.MaxGoroutines,
+		MaxMemoryMB:            m.MaxMemoryMB,
+		DatabaseConnections:    m.DatabaseConnections,
+		Disconnects:            atomic.LoadInt64(&m.Disconnects),
+		Reconnects:             atomic.LoadInt64(&m.Reconnects),
+		SlowReaders:            atomic.LoadInt64(&m.SlowReaders),
+		OversizedSent:          atomic.LoadInt64(&m.OversizedSent),
+		ServerErrors:           atomic.LoadInt64(&m.ServerErrors),
+		LatencyByType:          make(map[string]LatencyPercentiles, len(m.byType)),
+	}
+	if report.MessagesSent > 0 {
+		report.SuccessRate = float64(report.MessagesReceived) / float64(report.MessagesSent) * 100
+	}
+	if duration > 0 {
+		report.MessagesPerSecond = float64(report.MessagesReceived) / duration.Seconds()
+	}
+	for messageType, latencies := range m.byType {
+		report.LatencyByType[messageType] = percentiles(append([]time.Duration(nil), latencies...))
+	}
+	return report
+}
+
+// GetReport renders the metrics as the text block load tests log
+func (m *Metrics) GetReport() string {
+	report := m.Report()
+	text := fmt.Sprintf(`
+Load Test Performance Report
+============================
+Duration: %v
+Messages Sent: %d
+Messages Received: %d
+Success Rate: %.2f%%
+Messages/Second: %.2f
+Connections Established: %d
+Connection Failures: %d
+Errors: %d
+
+Latency Metrics:
+  Average: %v
+  Min: %v
+  Max: %v
+
+Resource Usage:
+  Max Goroutines: %d
+  Max Memory (MB): %d
+  DB Connections: %d
+`,
+		m.EndTime.Sub(m.StartTime),
+		report.MessagesSent,
+		report.MessagesReceived,
+		report.SuccessRate,
+		report.MessagesPerSecond,
+		report.ConnectionsEstablished,
+		report.ConnectionsFailed,
+		report.ErrorCount,
+		m.AverageLatency,
+		m.MinLatency,
+		m.MaxLatency,
+		report.MaxGoroutines,
+		report.MaxMemoryMB,
+		report.DatabaseConnections,
+	)
+	for _, messageType := range sortedTypes(report.LatencyByType) {
+		p := report.LatencyByType[messageType]
+		text += fmt.Sprintf("  %s: n=%d p50=%.2fms p95=%.2fms p99=%.2fms\n", messageType, p.Count, p.P50Ms, p.P95Ms, p.P99Ms)
+	}
+	return text
+}
+
+func sortedTypes(byType map[string]LatencyPercentiles) []string {
+	names := make([]string, 0, len(byType))
+	for name := range byType {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteCSV writes the report as metric,value rows followed by one row per message
+// type: latency_<type>,count,p50_ms,p95_ms,p99_ms,max_ms
+// FUNCTIONAL DISCOVERY: Two columns for the totals keep the file readable in a
+// spreadsheet; the per-type rows carry their own columns after the name
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	i := func(v int64) string { return strconv.FormatInt(v, 10) }
+	rows := [][]string{
+		{"metric", "value"},
+		{"duration_seconds", f(r.DurationSeconds)},
+		{"messages_sent", i(r.MessagesSent)},
+		{"messages_received", i(r.MessagesReceived)},
+		{"success_rate", f(r.SuccessRate)},
+		{"messages_per_second", f(r.MessagesPerSecond)},
+		{"connections_established", i(r.ConnectionsEstablished)},
+		{"connections_failed", i(r.ConnectionsFailed)},
+		{"error_count", i(r.ErrorCount)},
+		{"average_latency_ms", f(r.AverageLatencyMs)},
+		{"min_latency_ms", f(r.MinLatencyMs)},
+		{"max_latency_ms", f(r.MaxLatencyMs)},
+		{"max_goroutines", i(int64(r.MaxGoroutines))},
+		{"max_memory_mb", i(int64(r.MaxMemoryMB))},
+		{"database_connections", i(int64(r.DatabaseConnections))},
+		{"disconnects", i(r.Disconnects)},
+		{"reconnects", i(r.Reconnects)},
+		{"slow_readers", i(r.SlowReaders)},
+		{"oversized_sent", i(r.OversizedSent)},
+		{"server_errors", i(r.ServerErrors)},
+	}
+	for _, messageType := range sortedTypes(r.LatencyByType) {
+		p := r.LatencyByType[messageType]
+		rows = append(rows, []string{"latency_" + messageType, i(int64(p.Count)), f(p.P50Ms), f(p.P95Ms), f(p.P99Ms), f(p.MaxMs)})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// ResourceMonitor samples goroutines and heap size into Metrics while a run lasts
+type ResourceMonitor struct {
+	metrics *Metrics
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewResourceMonitor creates and starts resource monitoring
+func NewResourceMonitor(metrics *Metrics) *ResourceMonitor {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	monitor := &ResourceMonitor{
+		metrics: metrics,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+
+	monitor.start()
+	return monitor
+}
+
+// start begins resource monitoring in the background
+func (rm *ResourceMonitor) start() {
+	rm.wg.Add(1)
+	go func() {
+		defer rm.wg.Done()
+
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-rm.ctx.Done():
+				return
+			case <-ticker.C:
+				rm.recordMetrics()
+			}
+		}
+	}()
+}
+
+// recordMetrics captures current system metrics
+func (rm *ResourceMonitor) recordMetrics() {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	rm.metrics.observeResources(runtime.NumGoroutine(), memStats.Alloc/1024/1024)
+}
+
+// Stop halts resource monitoring
+func (rm *ResourceMonitor) Stop() {
+	rm.cancel()
+	rm.wg.Wait()
+}
@@ -0,0 +1,303 @@
+package loadgen
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Profile describes one load run: where, how many clients, what they send and which
+// failures to inject
+type Profile struct {
+	URL               string         // Server base URL, e.g. http://localhost:8080
+	SessionID         string         // Join this active session; empty creates one and ends it afterwards
+	SessionName       string         // Name of a created session
+	Instructors       int            // Instructor connections
+	Students          int            // Student connections
+	Duration          time.Duration  // How long clients send
+	MessagesPerMinute int            // Per client; keep under the server's rate limit
+	Mix               map[string]int // Message type -> relative weight; each role sends the types it may
+	Failures          Failures
+}
+
+// Failures configures failure injection; zero values inject nothing
+type Failures struct {
+	DisconnectEvery time.Duration // One random client is dropped per interval
+	ReconnectAfter  time.Duration // How long a dropped client stays away
+	SlowReaders     float64       // Share of clients that pause before every read
+	SlowReadDelay   time.Duration // The pause
+	Oversized       float64       // Share of messages padded past the content limit
+}
+
+// DefaultProfile is a small classroom against a local server for one minute
+func DefaultProfile() *Profile {
+	return &Profile{
+		URL:               "http://localhost:8080",
+		SessionName:       "Load test",
+		Instructors:       2,
+		Students:          30,
+		Duration:          time.Minute,
+		MessagesPerMinute: 30,
+		Mix: map[string]int{
+			types.MessageTypeInstructorInbox:     5,
+			types.MessageTypeAnalytics:           3,
+			types.MessageTypeRequestResponse:     1,
+			types.MessageTypeInstructorBroadcast: 1,
+			types.MessageTypeInboxResponse:       3,
+			types.MessageTypeRequest:             1,
+		},
+		Failures: Failures{
+			ReconnectAfter: time.Second,
+			SlowReadDelay:  200 * time.Millisecond,
+		},
+	}
+}
+
+// senderRoles lists which role sends each message type the profile may mix
+// TECHNICAL DISCOVERY: Mirrors the router's permissions; reactions are left out
+// because they need a target message a student has received
+var senderRoles = map[string]string{
+	types.MessageTypeInstructorInbox:     "student",
+	types.MessageTypeRequestResponse:     "student",
+	types.MessageTypeAnalytics:           "student",
+	types.MessageTypeInstructorBroadcast: "instructor",
+	types.MessageTypeInboxResponse:       "instructor",
+	types.MessageTypeRequest:             "instructor",
+}
+
+// Validate checks the profile before a run
+func (p *Profile) Validate() error {
+	if !strings.HasPrefix(p.URL, "http://") && !strings.HasPrefix(p.URL, "https://") {
+		return fmt.Errorf("url must start with http:// or https://, got %q", p.URL)
+	}
+	if p.Instructors < 1 || p.Students < 1 {
+		return fmt.Errorf("instructors and students must each be at least 1")
+	}
+	if p.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if p.MessagesPerMinute < 1 {
+		return fmt.Errorf("messages_per_minute must be at least 1")
+	}
+	total := 0
+	for messageType, weight := range p.Mix {
+		if _, ok := senderRoles[messageType]; !ok {
+			return fmt.Errorf("mix: %q cannot be generated", messageType)
+		}
+		if weight < 0 {
+			return fmt.Errorf("mix: weight for %s must not be negative", messageType)
+		}
+		total += weight
+	}
+	if total == 0 {
+		return fmt.Errorf("mix must give at least one message type a weight")
+	}
+	if p.Failures.SlowReaders < 0 || p.Failures.SlowReaders > 1 || p.Failures.Oversized < 0 || p.Failures.Oversized > 1 {
+		return fmt.Errorf("failures: slow_readers and oversized are shares between 0 and 1")
+	}
+	if p.Failures.DisconnectEvery < 0 || p.Failures.ReconnectAfter < 0 || p.Failures.SlowReadDelay < 0 {
+		return fmt.Errorf("failures: durations must not be negative")
+	}
+	return nil
+}
+
+// profileFile is the on-disk shape of a profile; durations are strings like "5m"
+type profileFile struct {
+	URL               string         `json:"url"`
+	SessionID         string         `json:"session_id"`
+	SessionName       string         `json:"session_name"`
+	Instructors       *int           `json:"instructors"`
+	Students          *int           `json:"students"`
+	Duration          string         `json:"duration"`
+	MessagesPerMinute *int           `json:"messages_per_minute"`
+	Mix               map[string]int `json:"mix"`
+	Failures          *struct {
+		DisconnectEvery string   `json:"disconnect_every"`
+		ReconnectAfter  string   `json:"reconnect_after"`
+		SlowReaders     *float64 `json:"slow_readers"`
+		SlowReadDelay   string   `json:"slow_read_delay"`
+		Oversized       *float64 `json:"oversized"`
+	} `json:"failures"`
+}
+
+// LoadProfile reads a JSON or YAML profile over DefaultProfile
+// FUNCTIONAL DISCOVERY: Like the server's config file, only keys present change the
+// defaults, and a mix given in the file replaces the default mix entirely
+// ARCHITECTURAL DISCOVERY: .yaml and .yml files are read with parseYAML, which covers
+// the nested key: value maps a profile needs, so the tool adds no dependency
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profile %s: %w", path, err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parsed, err := parseYAML(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+		}
+		if data, err = json.Marshal(parsed); err != nil {
+			return nil, err
+		}
+	}
+
+	var file profileFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse profile %s: %w", path, err)
+	}
+	profile := DefaultProfile()
+	if err := file.apply(profile); err != nil {
+		return nil, fmt.Errorf("invalid profile %s: %w", path, err)
+	}
+	return profile, nil
+}
+
+// apply overrides profile with the settings present in the file
+func (f *profileFile) apply(profile *Profile) error {
+	duration := func(value string, target *time.Duration, name string) error {
+		if value == "" {
+			return nil
+		}
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		*target = parsed
+		return nil
+	}
+
+	if f.URL != "" {
+		profile.URL = f.URL
+	}
+	if f.SessionID != "" {
+		profile.SessionID = f.SessionID
+	}
+	if f.SessionName != "" {
+		profile.SessionName = f.SessionName
+	}
+	if f.Instructors != nil {
+		profile.Instructors = *f.Instructors
+	}
+	if f.Students != nil {
+		profile.Students = *f.Students
+	}
+	if f.MessagesPerMinute != nil {
+		profile.MessagesPerMinute = *f.MessagesPerMinute
+	}
+	if f.Mix != nil {
+		profile.Mix = f.Mix
+	}
+	if err := duration(f.Duration, &profile.Duration, "duration"); err != nil {
+		return err
+	}
+	if f.Failures == nil {
+		return nil
+	}
+	if f.Failures.SlowReaders != nil {
+		profile.Failures.SlowReaders = *f.Failures.SlowReaders
+	}
+	if f.Failures.Oversized != nil {
+		profile.Failures.Oversized = *f.Failures.Oversized
+	}
+	if err := duration(f.Failures.DisconnectEvery, &profile.Failures.DisconnectEvery, "failures.disconnect_every"); err != nil {
+		return err
+	}
+	if err := duration(f.Failures.ReconnectAfter, &profile.Failures.ReconnectAfter, "failures.reconnect_after"); err != nil {
+		return err
+	}
+	return duration(f.Failures.SlowReadDelay, &profile.Failures.SlowReadDelay, "failures.slow_read_delay")
+}
+
+// ParseMix parses a flag value like "instructor_inbox=5,analytics=2"
+func ParseMix(value string) (map[string]int, error) {
+	mix := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		messageType, weight, found := strings.Cut(entry, "=")
+		parsed, err := strconv.Atoi(strings.TrimSpace(weight))
+		if !found || err != nil {
+			return nil, fmt.Errorf("mix entry %q must be type=weight", entry)
+		}
+		mix[strings.TrimSpace(messageType)] = parsed
+	}
+	return mix, nil
+}
+
+// parseYAML reads the YAML subset profiles use: "key: value" lines nested by
+// indentation, with # comments. Values become numbers or booleans when they parse as
+// one, strings otherwise; lists and multi-line values are not supported
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	type level struct {
+		indent int
+		values map[string]interface{}
+	}
+	root := map[string]interface{}{}
+	stack := []level{{indent: -1, values: root}}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := scanner.Text()
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		if trimmed := strings.TrimSpace(line); trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if strings.Contains(line, "\t") {
+			return nil, fmt.Errorf("line %d: indent with spaces, not tabs", lineNumber)
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		key, value, found := strings.Cut(strings.TrimSpace(line), ":")
+		if !found || key == "" {
+			return nil, fmt.Errorf("line %d: expected key: value", lineNumber)
+		}
+		if strings.HasPrefix(key, "- ") {
+			return nil, fmt.Errorf("line %d: lists are not supported", lineNumber)
+		}
+
+		for indent <= stack[len(stack)-1].indent {
+			stack = stack[:len(stack)-1]
+		}
+		current := stack[len(stack)-1].values
+		value = strings.TrimSpace(value)
+		if value == "" {
+			nested := map[string]interface{}{}
+			current[key] = nested
+			stack = append(stack, level{indent: indent, values: nested})
+			continue
+		}
+		current[key] = yamlScalar(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// yamlScalar converts a YAML scalar to the JSON value it stands for
+func yamlScalar(value string) interface{} {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	return value
+}
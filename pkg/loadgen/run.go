@@ -0,0 +1,383 @@
+package loadgen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"switchboard/pkg/types"
+)
+
+// Content keys the runner adds to every message it sends
+const (
+	contentLoadgenID = "loadgen_id"      // Counts each message received once however many recipients it has
+	contentSentAt    = "loadgen_sent_at" // Unix nanoseconds at send, for end-to-end latency
+)
+
+// deliveryGrace is how long Run keeps reading after the last send
+const deliveryGrace = time.Second
+
+// runner holds one run's clients and counters
+type runner struct {
+	profile *Profile
+	metrics *Metrics
+	logf    func(format string, args ...interface{})
+
+	instructors []*participant
+	students    []*participant
+	seq         int64
+	received    sync.Map // loadgen_id -> struct{}; messages counted as received
+}
+
+// participant is a client and whether failure injection has dropped it
+type participant struct {
+	client *Client
+	down   atomic.Bool
+	done   <-chan struct{}
+}
+
+// Run drives profile's clients against a running server and reports what happened
+// ARCHITECTURAL DISCOVERY: A client of the public HTTP and WebSocket APIs only, so it
+// measures a deployed server exactly as classrooms reach it; the resource peaks are
+// those of this process, and database connections are not visible from here
+// FUNCTIONAL DISCOVERY: Latency is end to end - the send time travels in the
+// message content and is compared on arrival, so sender and receivers must share a
+// clock, which they do in one process
+func Run(ctx context.Context, profile *Profile, logf func(format string, args ...interface{})) (*Report, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	if logf == nil {
+		logf = func(string, ...interface{}) {}
+	}
+	admin := &http.Client{Timeout: 10 * time.Second}
+
+	sessionID, studentIDs, err := prepareSession(ctx, admin, profile)
+	if err != nil {
+		return nil, err
+	}
+	if profile.SessionID == "" {
+		defer func() {
+			if err := endSession(admin, profile.URL, sessionID); err != nil {
+				logf("Failed to end load test session %s: %v", sessionID, err)
+			}
+		}()
+	}
+
+	r := &runner{profile: profile, metrics: NewMetrics(), logf: logf}
+	monitor := NewResourceMonitor(r.metrics)
+	defer monitor.Stop()
+
+	for i := 1; i <= profile.Instructors; i++ {
+		r.instructors = append(r.instructors, &participant{client: NewClient(profile.URL, instructorID(i), "instructor", sessionID)})
+	}
+	for _, studentID := range studentIDs {
+		r.students = append(r.students, &participant{client: NewClient(profile.URL, studentID, "student", sessionID)})
+	}
+	all := append(append([]*participant{}, r.instructors...), r.students...)
+	slow := int(profile.Failures.SlowReaders*float64(len(all)) + 0.5)
+	for _, i := range rand.Perm(len(all))[:slow] {
+		all[i].client.readDelay = profile.Failures.SlowReadDelay
+		atomic.AddInt64(&r.metrics.SlowReaders, 1)
+	}
+
+	r.connectAll(ctx, all)
+	logf("Connected %d/%d clients to session %s", atomic.LoadInt64(&r.metrics.ConnectionsEstablished), len(all), sessionID)
+
+	loadCtx, cancel := context.WithTimeout(ctx, profile.Duration)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, p := range all {
+		wg.Add(1)
+		go func(p *participant) {
+			defer wg.Done()
+			r.send(loadCtx, p)
+		}(p)
+	}
+	if profile.Failures.DisconnectEvery > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.injectDisconnects(loadCtx, all, &wg)
+		}()
+	}
+	wg.Wait()
+
+	// Give the last messages time to arrive before counting
+	select {
+	case <-time.After(deliveryGrace):
+	case <-ctx.Done():
+	}
+	r.metrics.EndTime = time.Now()
+	for _, p := range all {
+		p.client.Close()
+	}
+	return r.metrics.Report(), nil
+}
+
+func instructorID(i int) string { return "loadgen-instructor-" + strconv.Itoa(i) }
+func studentID(i int) string    { return "loadgen-student-" + strconv.Itoa(i) }
+
+// prepareSession creates the run's session, or reads the roster of the one to join
+func prepareSession(ctx context.Context, admin *http.Client, profile *Profile) (string, []string, error) {
+	if profile.SessionID != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, profile.URL+"/api/sessions/"+profile.SessionID, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		var response struct {
+			Session *types.Session `json:"session"`
+		}
+		if err := doJSON(admin, req, http.StatusOK, &response); err != nil {
+			return "", nil, fmt.Errorf("failed to read session %s: %w", profile.SessionID, err)
+		}
+		roster := response.Session.StudentIDs
+		if len(roster) < profile.Students {
+			return "", nil, fmt.Errorf("session %s has %d students, profile needs %d", profile.SessionID, len(roster), profile.Students)
+		}
+		return profile.SessionID, roster[:profile.Students], nil
+	}
+
+	studentIDs := make([]string, profile.Students)
+	for i := range studentIDs {
+		studentIDs[i] = studentID(i + 1)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"name":          profile.SessionName,
+		"instructor_id": instructorID(1),
+		"student_ids":   studentIDs,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, profile.URL+"/api/sessions", bytes.NewReader(body))
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	var created struct {
+		Session *types.Session `json:"session"`
+	}
+	if err := doJSON(admin, req, http.StatusCreated, &created); err != nil {
+		return "", nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return created.Session.ID, studentIDs, nil
+}
+
+// endSession ends a session the run created
+func endSession(admin *http.Client, baseURL, sessionID string) error {
+	req, err := http.NewRequest(http.MethodDelete, baseURL+"/api/sessions/"+sessionID, nil)
+	if err != nil {
+		return err
+	}
+	return doJSON(admin, req, http.StatusOK, nil)
+}
+
+// doJSON sends req and decodes a response with the wanted status into out
+func doJSON(client *http.Client, req *http.Request, want int, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// connectAll connects every participant concurrently
+func (r *runner) connectAll(ctx context.Context, all []*participant) {
+	var wg sync.WaitGroup
+	for _, p := range all {
+		wg.Add(1)
+		go func(p *participant) {
+			defer wg.Done()
+			if err := r.connect(ctx, p); err != nil {
+				atomic.AddInt64(&r.metrics.ConnectionsFailed, 1)
+				p.down.Store(true)
+				r.logf("%v", err)
+				return
+			}
+			atomic.AddInt64(&r.metrics.ConnectionsEstablished, 1)
+		}(p)
+	}
+	wg.Wait()
+}
+
+// connect dials p's client with the runner's receive handler
+func (r *runner) connect(ctx context.Context, p *participant) error {
+	done, err := p.client.Connect(ctx, r.receive)
+	if err != nil {
+		return err
+	}
+	p.done = done
+	return nil
+}
+
+// receive counts a delivered frame and measures the latency of generated messages
+func (r *runner) receive(frame map[string]interface{}) {
+	content, _ := frame["content"].(map[string]interface{})
+	if frame["type"] == "system" {
+		if content["event"] == "message_error" {
+			atomic.AddInt64(&r.metrics.ServerErrors, 1)
+		}
+		return
+	}
+	sentAt, ok := content[contentSentAt].(float64)
+	if !ok {
+		return // Not generated by this run, e.g. history replay from an earlier one
+	}
+	messageType, _ := frame["type"].(string)
+	r.metrics.AddTypedLatency(messageType, time.Since(time.Unix(0, int64(sentAt))))
+	if id, ok := content[contentLoadgenID].(string); ok {
+		if _, seen := r.received.LoadOrStore(id, struct{}{}); !seen {
+			atomic.AddInt64(&r.metrics.MessagesReceived, 1)
+		}
+	}
+}
+
+// send generates p's share of the message mix until ctx ends
+// TECHNICAL DISCOVERY: Each client starts at a random point in its first interval so
+// a classroom's sends spread out instead of arriving in lockstep
+func (r *runner) send(ctx context.Context, p *participant) {
+	names, weights := r.typesFor(p.client.Role)
+	if len(names) == 0 {
+		return
+	}
+	interval := time.Minute / time.Duration(r.profile.MessagesPerMinute)
+	select {
+	case <-time.After(time.Duration(rand.Int63n(int64(interval)))):
+	case <-ctx.Done():
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if !p.down.Load() {
+			r.sendOne(p, pickWeighted(names, weights))
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// typesFor returns the mix's types role may send, with their weights
+func (r *runner) typesFor(role string) ([]string, []int) {
+	var names []string
+	var weights []int
+	for messageType, weight := range r.profile.Mix {
+		if weight > 0 && senderRoles[messageType] == role {
+			names = append(names, messageType)
+			weights = append(weights, weight)
+		}
+	}
+	return names, weights
+}
+
+func pickWeighted(names []string, weights []int) string {
+	total := 0
+	for _, w := range weights {
+		total += w
+	}
+	n := rand.Intn(total)
+	for i, w := range weights {
+		if n < w {
+			return names[i]
+		}
+		n -= w
+	}
+	return names[len(names)-1]
+}
+
+// sendOne sends one message of messageType from p
+func (r *runner) sendOne(p *participant, messageType string) {
+	id := strconv.FormatInt(atomic.AddInt64(&r.seq, 1), 10)
+	message := &types.Message{
+		Type:    messageType,
+		Context: "loadgen",
+		Content: map[string]interface{}{
+			"text":           "Load test message " + id,
+			contentLoadgenID: id,
+			contentSentAt:    time.Now().UnixNano(),
+		},
+	}
+	if messageType == types.MessageTypeInboxResponse || messageType == types.MessageTypeRequest {
+		toUser := r.students[rand.Intn(len(r.students))].client.UserID
+		message.ToUser = &toUser
+	}
+	oversized := rand.Float64() < r.profile.Failures.Oversized
+	if oversized {
+		message.Content["padding"] = strings.Repeat("x", types.MaxContentBytes)
+	}
+
+	if err := p.client.Send(message); err != nil {
+		if !p.down.Load() {
+			atomic.AddInt64(&r.metrics.ErrorCount, 1)
+		}
+		return
+	}
+	if oversized {
+		atomic.AddInt64(&r.metrics.OversizedSent, 1) // Expected to be refused, so not counted as sent
+		return
+	}
+	atomic.AddInt64(&r.metrics.MessagesSent, 1)
+}
+
+// injectDisconnects drops one random connected client per interval and brings it
+// back after the reconnect delay
+func (r *runner) injectDisconnects(ctx context.Context, all []*participant, wg *sync.WaitGroup) {
+	ticker := time.NewTicker(r.profile.Failures.DisconnectEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p := all[rand.Intn(len(all))]
+		if !p.down.CompareAndSwap(false, true) {
+			continue // Already down
+		}
+		p.client.Close()
+		atomic.AddInt64(&r.metrics.Disconnects, 1)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case <-time.After(r.profile.Failures.ReconnectAfter):
+			case <-ctx.Done():
+				return
+			}
+			if p.done != nil {
+				<-p.done // The old reader has stopped
+			}
+			if err := r.connect(ctx, p); err != nil {
+				atomic.AddInt64(&r.metrics.ConnectionsFailed, 1)
+				r.logf("Reconnect failed: %v", err)
+				return
+			}
+			atomic.AddInt64(&r.metrics.Reconnects, 1)
+			p.down.Store(false)
+		}()
+	}
+}
@@ -0,0 +1,64 @@
+package switchboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	pkgdatabase "switchboard/pkg/database"
+	"switchboard/pkg/switchboard"
+)
+
+// Embedding switchboard in another service: an in-memory database, no HTTP server of
+// its own, and the API and WebSocket handlers mounted on the host's mux. Nothing
+// under internal/ is imported, as in a service outside this module
+func ExampleNewApplication_embedded() {
+	cfg := switchboard.DefaultConfig()
+	cfg.Database.Path = pkgdatabase.MemoryPath
+	cfg.Database.EmbeddedMigrations = true
+
+	application, err := switchboard.NewApplication(cfg, switchboard.WithHTTPServer(nil))
+	if err != nil {
+		fmt.Println("build failed:", err)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := application.Start(ctx); err != nil {
+		fmt.Println("start failed:", err)
+		return
+	}
+	defer application.Stop(context.Background())
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "host service up")
+	})
+	mux.Handle("/api/", application.APIServer())
+	mux.Handle("/health", application.APIServer())
+	mux.Handle("/classroom/ws", application.WebSocketHandler())
+
+	host := httptest.NewServer(mux)
+	defer host.Close()
+
+	resp, err := http.Get(host.URL + "/health")
+	if err != nil {
+		fmt.Println("health failed:", err)
+		return
+	}
+	defer resp.Body.Close()
+	var health struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		fmt.Println("decode failed:", err)
+		return
+	}
+	fmt.Printf("switchboard address: %q\n", application.Addr())
+	fmt.Println("health:", health.Status)
+	// Output:
+	// switchboard address: ""
+	// health: healthy
+}
@@ -0,0 +1,125 @@
+// Package switchboard embeds the switchboard application in another Go service
+// ARCHITECTURAL DISCOVERY: A thin facade over internal/app - the wiring stays in one
+// place, and services outside this module get NewApplication, its options and
+// plain net/http handlers without importing anything under internal/
+package switchboard
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"switchboard/internal/app"
+	"switchboard/internal/config"
+	"switchboard/pkg/interfaces"
+)
+
+// Config is the application configuration; start from DefaultConfig
+type Config = config.Config
+
+// Store holds the settings a running application reloads; see WithStore
+type Store = config.Store
+
+// Option replaces one component NewApplication would otherwise build itself
+type Option = app.Option
+
+// DefaultConfig returns the configuration the switchboard binary starts from
+func DefaultConfig() *Config {
+	return config.DefaultConfig()
+}
+
+// NewStore returns a store holding cfg's runtime-mutable settings
+func NewStore(cfg *Config) *Store {
+	return config.NewStore(cfg)
+}
+
+// WithStore has the router and WebSocket handler read rate limits and heartbeat timing
+// from store, which the caller can share and reload
+func WithStore(store *Store) Option {
+	return app.WithStore(store)
+}
+
+// WithClock has the hub, session manager, router and database manager read time from clk
+func WithClock(clk interfaces.Clock) Option {
+	return app.WithClock(clk)
+}
+
+// WithDatabaseManager uses the caller's open database manager; Stop leaves it open
+// FUNCTIONAL DISCOVERY: Only interfaces.DatabaseManager is required - optional
+// features such as read receipts or API keys are served when dbManager also has
+// their methods
+func WithDatabaseManager(dbManager interfaces.DatabaseManager) Option {
+	return app.WithDatabaseManager(dbManager)
+}
+
+// WithRegistry reports every WebSocket connection the application registers and
+// unregisters to registry
+func WithRegistry(registry interfaces.ConnectionRegistry) Option {
+	return app.WithRegistry(registry)
+}
+
+// WithHTTPServer serves through server; nil skips serving, so the caller mounts Handler,
+// APIServer or WebSocketHandler on its own mux
+func WithHTTPServer(server *http.Server) Option {
+	return app.WithHTTPServer(server)
+}
+
+// WithListener has Start serve on listener instead of binding the server's address
+func WithListener(listener net.Listener) Option {
+	return app.WithListener(listener)
+}
+
+// Application is a switchboard hub, router and API ready to start
+type Application struct {
+	app *app.Application
+}
+
+// NewApplication builds an application from cfg, or DefaultConfig when cfg is nil
+func NewApplication(cfg *Config, opts ...Option) (*Application, error) {
+	application, err := app.NewApplication(cfg, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Application{app: application}, nil
+}
+
+// Start starts the hub and background workers, and the HTTP server unless built
+// WithHTTPServer(nil); it returns once the listener is bound
+func (a *Application) Start(ctx context.Context) error {
+	return a.app.Start(ctx)
+}
+
+// Stop shuts down what Start started and flushes pending writes
+func (a *Application) Stop(ctx context.Context) error {
+	return a.app.Stop(ctx)
+}
+
+// Errors delivers fatal HTTP server errors that occur after Start has returned
+func (a *Application) Errors() <-chan error {
+	return a.app.Errors()
+}
+
+// HealthCheck reports whether the database can still be reached
+func (a *Application) HealthCheck(ctx context.Context) error {
+	return a.app.HealthCheck(ctx)
+}
+
+// Addr returns the bound address; empty without an HTTP server
+func (a *Application) Addr() string {
+	return a.app.Addr()
+}
+
+// Handler returns the mux serving /api/, /health, /metrics and /ws
+func (a *Application) Handler() http.Handler {
+	return a.app.Handler()
+}
+
+// APIServer returns the handler for /api/, /health and /metrics
+func (a *Application) APIServer() http.Handler {
+	return a.app.APIServer()
+}
+
+// WebSocketHandler returns the handler that upgrades client connections, for any path
+func (a *Application) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(a.app.WebSocketHandler().HandleWebSocket)
+}
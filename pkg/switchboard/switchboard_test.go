@@ -0,0 +1,132 @@
+package switchboard_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"switchboard/internal/database"
+	pkgdatabase "switchboard/pkg/database"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/switchboard"
+	"switchboard/pkg/types"
+)
+
+// narrowDatabase offers only interfaces.DatabaseManager and GetDB, as a caller's own
+// implementation would; every optional capability of the wrapped manager is hidden
+type narrowDatabase struct {
+	interfaces.DatabaseManager
+	db *sql.DB
+}
+
+func (n narrowDatabase) GetDB() *sql.DB { return n.db }
+
+// recordingRegistry is a caller-side registry fed through WithRegistry
+type recordingRegistry struct {
+	registered   chan interfaces.Connection
+	unregistered chan interfaces.Connection
+}
+
+func (r *recordingRegistry) RegisterConnection(conn interfaces.Connection) error {
+	r.registered <- conn
+	return nil
+}
+
+func (r *recordingRegistry) UnregisterConnection(conn interfaces.Connection) {
+	r.unregistered <- conn
+}
+
+// FUNCTIONAL VALIDATION TEST: A caller-supplied database and registry, typed only by
+// pkg/interfaces, carry sessions and connections through the embedded application
+func TestNewApplication_CallerDatabaseAndRegistry(t *testing.T) {
+	manager, err := database.NewManager(&pkgdatabase.Config{
+		DatabasePath:    pkgdatabase.MemoryPath,
+		MaxConnections:  10,
+		ConnMaxLifetime: time.Hour,
+		ConnMaxIdleTime: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer manager.Close()
+
+	registry := &recordingRegistry{
+		registered:   make(chan interfaces.Connection, 1),
+		unregistered: make(chan interfaces.Connection, 1),
+	}
+	cfg := switchboard.DefaultConfig()
+	cfg.Database.EmbeddedMigrations = true
+	application, err := switchboard.NewApplication(cfg,
+		switchboard.WithDatabaseManager(narrowDatabase{DatabaseManager: manager, db: manager.GetDB()}),
+		switchboard.WithRegistry(registry),
+		switchboard.WithHTTPServer(nil),
+	)
+	if err != nil {
+		t.Fatalf("NewApplication failed: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := application.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer application.Stop(context.Background())
+
+	host := httptest.NewServer(application.Handler())
+	defer host.Close()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"name":          "Embedded Session",
+		"instructor_id": "instructor_1",
+		"student_ids":   []string{"student_1"},
+	})
+	resp, err := http.Post(host.URL+"/api/sessions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("Create session failed: %v", err)
+	}
+	var created struct {
+		Session *types.Session `json:"session"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&created)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || err != nil || created.Session == nil {
+		t.Fatalf("Expected a created session, got status %d (%v)", resp.StatusCode, err)
+	}
+	if _, err := manager.GetSession(context.Background(), created.Session.ID); err != nil {
+		t.Fatalf("Session not stored through the supplied manager: %v", err)
+	}
+
+	query := url.Values{"user_id": {"student_1"}, "role": {"student"}, "session_id": {created.Session.ID}}
+	wsURL := "ws" + strings.TrimPrefix(host.URL, "http") + "/ws?" + query.Encode()
+	client, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("WebSocket dial failed: %v", err)
+	}
+
+	select {
+	case conn := <-registry.registered:
+		if conn.GetUserID() != "student_1" || conn.GetSessionID() != created.Session.ID {
+			t.Errorf("Unexpected registered connection %s in %s", conn.GetUserID(), conn.GetSessionID())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Supplied registry never saw the connection")
+	}
+
+	client.Close()
+	select {
+	case conn := <-registry.unregistered:
+		if conn.GetUserID() != "student_1" {
+			t.Errorf("Unexpected unregistered connection %s", conn.GetUserID())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Supplied registry never saw the connection leave")
+	}
+}
@@ -0,0 +1,197 @@
+package testserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"switchboard/internal/app"
+	"switchboard/internal/config"
+	"switchboard/internal/database"
+	pkgdatabase "switchboard/pkg/database"
+	"switchboard/pkg/interfaces"
+	"switchboard/pkg/types"
+)
+
+// Options adjusts the embedded server
+type Options struct {
+	// InMemoryDB keeps the database in process memory instead of a file under t.TempDir()
+	InMemoryDB bool
+
+	// Configure adjusts the test configuration before the application is built,
+	// e.g. to enable transcripts or analytics export
+	Configure func(*config.Config)
+
+	// Clock replaces the wall clock in the hub, session manager, router and database,
+	// e.g. with a testsupport.FakeClock to expire sessions without waiting
+	Clock interfaces.Clock
+}
+
+// Server is a complete switchboard application listening on a kernel-assigned
+// loopback port
+// ARCHITECTURAL DISCOVERY: Built on the same Application plumbing as the binary, so
+// HTTP and WebSocket tests exercise exactly what production serves
+type Server struct {
+	// URL is the base URL, e.g. http://127.0.0.1:41234
+	URL string
+
+	// Admin creates and ends sessions through the REST API
+	Admin *Admin
+
+	// DatabasePath is the database file, empty for an in-memory database
+	DatabasePath string
+
+	app       *app.Application
+	cancel    context.CancelFunc
+	closeOnce sync.Once
+}
+
+// New starts a server for the duration of t
+// FUNCTIONAL DISCOVERY: Migrations are compiled in and the port is chosen by the
+// kernel, so callers need no working directory or port conventions; the server is
+// stopped by t.Cleanup
+func New(t testing.TB, opts Options) *Server {
+	t.Helper()
+
+	dbPath := pkgdatabase.MemoryPath
+	if !opts.InMemoryDB {
+		dbPath = filepath.Join(t.TempDir(), "switchboard.db")
+	}
+
+	cfg := &config.Config{
+		HTTP: &config.HTTPConfig{
+			Host:         "127.0.0.1",
+			Port:         0, // Kernel-assigned; read back through Addr()
+			ReadTimeout:  10 * time.Second,
+			WriteTimeout: 10 * time.Second,
+		},
+		Database: &config.DatabaseConfig{
+			Path:               dbPath,
+			Timeout:            30 * time.Second,
+			EmbeddedMigrations: true,
+		},
+		WebSocket: &config.WebSocketConfig{
+			PingInterval:      30 * time.Second,
+			ReadTimeout:       60 * time.Second,
+			WriteTimeout:      10 * time.Second,
+			BufferSize:        100,
+			HistoryBatchSize:  100,
+			HistoryBatchDelay: 10 * time.Millisecond,
+		},
+	}
+	if opts.Configure != nil {
+		opts.Configure(cfg)
+	}
+
+	var application *app.Application
+	var err error
+	if opts.Clock != nil {
+		application, err = app.NewApplicationWithClock(cfg, config.NewStore(cfg), opts.Clock)
+	} else {
+		application, err = app.NewApplication(cfg)
+	}
+	if err != nil {
+		t.Fatalf("testserver: failed to create application: %v", err)
+	}
+
+	// TECHNICAL DISCOVERY: Start returns once the listener is bound, so the server
+	// accepts connections immediately - no port probing or /health polling
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := application.Start(ctx); err != nil {
+		cancel()
+		t.Fatalf("testserver: server did not start: %v", err)
+	}
+
+	server := &Server{
+		URL:    "http://" + application.Addr(),
+		app:    application,
+		cancel: cancel,
+	}
+	if !opts.InMemoryDB {
+		server.DatabasePath = dbPath
+	}
+	server.Admin = &Admin{
+		baseURL: server.URL,
+		client:  &http.Client{Timeout: 10 * time.Second},
+	}
+	t.Cleanup(server.Close)
+	return server
+}
+
+// Database returns the manager the server persists through, e.g. to count stored messages
+func (s *Server) Database() *database.Manager {
+	return s.app.Database()
+}
+
+// Close stops the server; it runs automatically when the test ends, so calling it
+// early is only needed to test shutdown behaviour
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		s.cancel()
+
+		// Extended timeout for tests that leave many clients connected
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		s.app.Stop(ctx)
+	})
+}
+
+// Admin manages sessions the way an instructor tool would
+type Admin struct {
+	baseURL string
+	client  *http.Client
+}
+
+// CreateSession creates a session through POST /api/sessions
+func (a *Admin) CreateSession(name, instructorID string, studentIDs []string) (*types.Session, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"name":          name,
+		"instructor_id": instructorID,
+		"student_ids":   studentIDs,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := a.client.Post(a.baseURL+"/api/sessions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("failed to create session: status %d", resp.StatusCode)
+	}
+
+	var created struct {
+		Session *types.Session `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil || created.Session == nil {
+		return nil, fmt.Errorf("invalid create session response: %v", err)
+	}
+	return created.Session, nil
+}
+
+// EndSession ends a session through DELETE /api/sessions/{id}
+func (a *Admin) EndSession(sessionID string) error {
+	req, err := http.NewRequest(http.MethodDelete, a.baseURL+"/api/sessions/"+sessionID, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to end session %s: %w", sessionID, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to end session %s: status %d", sessionID, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package testserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"switchboard/pkg/interfaces"
+	"switchboard/internal/testsupport"
+)
+
+// FUNCTIONAL VALIDATION TEST: In-memory server serves the REST API without a working directory
+func TestNew_InMemory(t *testing.T) {
+	server := New(t, Options{InMemoryDB: true})
+
+	if server.DatabasePath != "" {
+		t.Errorf("Expected no database path for an in-memory server, got %q", server.DatabasePath)
+	}
+
+	resp, err := http.Get(server.URL + "/health")
+	if err != nil {
+		t.Fatalf("Health request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected healthy server, got status %d", resp.StatusCode)
+	}
+
+	session, err := server.Admin.CreateSession("Testserver Session", "instructor_1", []string{"student_1", "student_2"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	stored, err := server.Database().GetSession(context.Background(), session.ID)
+	if err != nil {
+		t.Fatalf("Created session not persisted: %v", err)
+	}
+	if stored.Name != "Testserver Session" || len(stored.StudentIDs) != 2 {
+		t.Errorf("Unexpected stored session: %+v", stored)
+	}
+
+	if err := server.Admin.EndSession(session.ID); err != nil {
+		t.Fatalf("EndSession failed: %v", err)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: In-memory servers in one process do not share data
+func TestNew_InMemoryIsolation(t *testing.T) {
+	first := New(t, Options{InMemoryDB: true})
+	second := New(t, Options{InMemoryDB: true})
+
+	session, err := first.Admin.CreateSession("Isolated Session", "instructor_1", []string{"student_1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if _, err := second.Database().GetSession(context.Background(), session.ID); !errors.Is(err, interfaces.ErrSessionNotFound) {
+		t.Errorf("Expected second server not to see the first server's session, got %v", err)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: A server built with a fake clock reads time from it
+func TestNew_FakeClock(t *testing.T) {
+	clock := testsupport.NewFakeClock(time.Date(2026, 10, 5, 9, 0, 0, 0, time.UTC))
+	server := New(t, Options{InMemoryDB: true, Clock: clock})
+
+	session, err := server.Admin.CreateSession("Clocked Session", "instructor_1", []string{"student_1"})
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	if !session.StartTime.Equal(clock.Now()) {
+		t.Errorf("Expected the session to start at the fake time %v, got %v", clock.Now(), session.StartTime)
+	}
+
+	clock.Advance(50 * time.Minute)
+	if err := server.Admin.EndSession(session.ID); err != nil {
+		t.Fatalf("EndSession failed: %v", err)
+	}
+	stored, err := server.Database().GetSession(context.Background(), session.ID)
+	if err != nil || stored.EndTime == nil || !stored.EndTime.Equal(clock.Now()) {
+		t.Errorf("Expected the session to end at the advanced fake time, got %+v, %v", stored, err)
+	}
+}
+
+// FUNCTIONAL VALIDATION TEST: File-backed server exposes its database path and stops on Close
+func TestNew_FileDatabase(t *testing.T) {
+	server := New(t, Options{})
+
+	if _, err := os.Stat(server.DatabasePath); err != nil {
+		t.Fatalf("Expected database file at %q: %v", server.DatabasePath, err)
+	}
+
+	server.Close()
+	server.Close() // Cleanup closes again; must be harmless
+
+	if _, err := http.Get(server.URL + "/health"); err == nil {
+		t.Error("Expected closed server to refuse connections")
+	}
+}
@@ -0,0 +1,46 @@
+package types
+
+import "time"
+
+// Metrics alert rules can watch, each measured over one evaluation interval
+const (
+	AlertMetricWriteLatencyP95     = "write_latency_p95_ms"        // p95 of database writes, in milliseconds
+	AlertMetricWriteFailureRate    = "write_failure_rate"          // Failed share of database writes, 0-1
+	AlertMetricDeliveryFailureRate = "delivery_failure_rate"       // Failed share of live deliveries, 0-1; filtered recipients excluded
+	AlertMetricConnectionDrops     = "connection_drops_per_minute" // Connections lost without a close frame
+)
+
+// AlertMetrics lists the metrics alert rules can watch
+var AlertMetrics = []string{AlertMetricWriteLatencyP95, AlertMetricWriteFailureRate, AlertMetricDeliveryFailureRate, AlertMetricConnectionDrops}
+
+// Actions an alert rule can take when it fires or resolves
+const (
+	AlertActionLog       = "log"       // An ERROR: line when firing, an info line when resolved
+	AlertActionWebhook   = "webhook"   // POST the Alert as JSON to the configured URL
+	AlertActionBroadcast = "broadcast" // A system_alert message to every connected instructor
+)
+
+// AlertActions lists the actions alert rules can take
+var AlertActions = []string{AlertActionLog, AlertActionWebhook, AlertActionBroadcast}
+
+// Alert statuses
+const (
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+// ContextSystemAlert is the context of the system messages the broadcast action sends
+const ContextSystemAlert = "system_alert"
+
+// Alert is one rule firing or resolving
+// FUNCTIONAL DISCOVERY: A rule fires once when its breach has lasted its duration and
+// resolves once when the breach ends, so every firing is followed by one resolution
+type Alert struct {
+	Rule      string    `json:"rule"`
+	Metric    string    `json:"metric"`
+	Status    string    `json:"status"` // "firing" or "resolved"
+	Value     float64   `json:"value"`  // The metric at this evaluation
+	Threshold float64   `json:"threshold"`
+	Since     time.Time `json:"since"` // When the breach began
+	At        time.Time `json:"at"`
+}
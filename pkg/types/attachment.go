@@ -0,0 +1,59 @@
+package types
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+)
+
+// AttachmentContentKey is the top-level content key an inline attachment is embedded
+// under: {"attachment": {"mime": "image/png", "data_base64": "...", "filename": "a.png"}}
+const AttachmentContentKey = "attachment"
+
+// ErrMalformedAttachment means content carries an attachment key that does not follow
+// the convention
+var ErrMalformedAttachment = errors.New("attachment must be an object with a media type in mime, base64 data in data_base64 and an optional filename")
+
+// Attachment describes an inline attachment found in message content
+type Attachment struct {
+	MIME     string // Media type in lower case, without parameters
+	Filename string
+	Size     int // Decoded bytes
+}
+
+// ParseAttachment reads content's inline attachment; nil without one
+// TECHNICAL DISCOVERY: The data is decoded to measure it, so padding and alphabet
+// errors surface as ErrMalformedAttachment rather than as a wrong size
+func ParseAttachment(content map[string]interface{}) (*Attachment, error) {
+	raw, exists := content[AttachmentContentKey]
+	if !exists {
+		return nil, nil
+	}
+	fields, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, ErrMalformedAttachment
+	}
+	declared, ok := fields["mime"].(string)
+	if !ok {
+		return nil, ErrMalformedAttachment
+	}
+	data, ok := fields["data_base64"].(string)
+	if !ok {
+		return nil, ErrMalformedAttachment
+	}
+	filename, ok := fields["filename"].(string)
+	if !ok && fields["filename"] != nil {
+		return nil, ErrMalformedAttachment
+	}
+
+	mediaType, _, err := mime.ParseMediaType(declared)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid mime %q", ErrMalformedAttachment, declared)
+	}
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid data_base64", ErrMalformedAttachment)
+	}
+	return &Attachment{MIME: mediaType, Filename: filename, Size: len(decoded)}, nil
+}
@@ -0,0 +1,34 @@
+package types
+
+// BandwidthUnparsed is the BandwidthStats.ByType key for inbound frames that were not
+// a message of a known type
+const BandwidthUnparsed = "unparsed"
+
+// ByteCounts is WebSocket traffic both ways, counted in frame payload bytes
+// TECHNICAL DISCOVERY: Payloads as the codec encoded them; WebSocket framing, TLS and
+// heartbeat pings are not counted
+type ByteCounts struct {
+	BytesSent      uint64 `json:"bytes_sent"`
+	BytesReceived  uint64 `json:"bytes_received"`
+	FramesSent     uint64 `json:"frames_sent"`
+	FramesReceived uint64 `json:"frames_received"`
+}
+
+// Add adds other's counts to c
+func (c *ByteCounts) Add(other ByteCounts) {
+	c.BytesSent += other.BytesSent
+	c.BytesReceived += other.BytesReceived
+	c.FramesSent += other.FramesSent
+	c.FramesReceived += other.FramesReceived
+}
+
+// BandwidthStats is a session's WebSocket traffic, in GET /api/sessions/{id}/stats and
+// the bandwidth event written when a session ends
+// FUNCTIONAL DISCOVERY: Sent bytes are counted per recipient, so a broadcast to 30
+// students counts its frame 30 times - what actually crossed the network. ByType is
+// keyed by message type; server notices are "system" and undecodable inbound frames
+// BandwidthUnparsed
+type BandwidthStats struct {
+	ByteCounts
+	ByType map[string]ByteCounts `json:"by_type"`
+}
@@ -0,0 +1,104 @@
+package types
+
+import "sort"
+
+// ProtocolVersion is the client protocol revision spoken by this server
+// FUNCTIONAL DISCOVERY: Matches the ".v1" suffix of the WebSocket subprotocols
+const ProtocolVersion = 1
+
+// MaxContentBytes bounds the JSON-encoded size of a message's content
+const MaxContentBytes = 64 * 1024
+
+// Optional feature names reported in Capabilities.Features
+// FUNCTIONAL DISCOVERY: Features the server does not implement are still listed as
+// false, so clients can tell "unsupported" apart from "server too old to say"
+const (
+	FeatureMsgpack               = "msgpack"
+	FeatureAcks                  = "acks"
+	FeatureSequenceNumbers       = "sequence_numbers"
+	FeatureResumeTokens          = "resume_tokens"
+	FeaturePolls                 = "polls"
+	FeatureSessionDuration       = "session_duration"
+	FeatureMessageImport         = "message_import"
+	FeaturePersistFailureNotices = "persist_failure_notices"
+	FeatureTranscripts           = "transcripts"
+	FeatureAnalyticsExport       = "analytics_export"
+	FeaturePersistenceWatchdog   = "persistence_watchdog"
+	FeatureRouteOnlyDegradation  = "route_only_degradation"
+	FeatureSessionLock           = "session_lock"
+	FeatureMessageAnnotations    = "message_annotations"
+	FeatureBroadcastDedup        = "broadcast_dedup"
+	FeatureDiagnosticsOverlay    = "diagnostics_overlay"
+	FeatureAPIKeys               = "api_keys"
+	FeatureReactions             = "reactions"
+	FeatureSenderOrdering        = "sender_ordering" // One sender's messages are routed in the order sent
+	FeatureTargetedBroadcasts    = "targeted_broadcasts"
+	FeatureJoinCodes             = "join_codes"    // join_code accepted in place of session_id
+	FeatureSystemAlerts          = "system_alerts" // Instructors receive "system_alert" notices
+)
+
+// Capabilities describes what a server supports so clients can feature-detect
+// ARCHITECTURAL DISCOVERY: Built once from configuration at startup and served
+// unchanged by GET /api/capabilities; the WebSocket handshake carries CompactCapabilities
+type Capabilities struct {
+	ProtocolVersions []int            `json:"protocol_versions"`
+	Encodings        []string         `json:"encodings"` // WebSocket subprotocols, preferred first
+	Features         map[string]bool  `json:"features"`
+	Limits           CapabilityLimits `json:"limits"`
+	Routing          []RouteSummary   `json:"routing"`
+	// ValidationRulesHash is the Hash of GET /api/validation-rules, so a client can
+	// keep its copy until the hash changes
+	ValidationRulesHash string `json:"validation_rules_hash"`
+}
+
+// CapabilityLimits lists the limits a well-behaved client should stay within
+type CapabilityLimits struct {
+	MaxContentBytes           int `json:"max_content_bytes"`
+	RateLimitMessages         int `json:"rate_limit_messages"` // Per user per window
+	RateLimitWindowSeconds    int `json:"rate_limit_window_seconds"`
+	MaxSessionDurationMinutes int `json:"max_session_duration_minutes"`
+	MaxStudentsPerSession     int `json:"max_students_per_session"`
+	MaxBroadcastRecipients    int `json:"max_broadcast_recipients"` // Students a targeted broadcast's to_users may list
+	// Inline attachment rules; both omitted while attachments are unchecked
+	AttachmentMIMETypes []string `json:"attachment_mime_types,omitempty"`
+	MaxAttachmentBytes  int      `json:"max_attachment_bytes,omitempty"` // Decoded bytes
+}
+
+// RouteSummary describes who may send a message type and who receives it
+type RouteSummary struct {
+	Type           string `json:"type"`
+	SenderRole     string `json:"sender_role"`
+	Recipients     string `json:"recipients"` // "session_instructors", "session_students" or "to_user"
+	RequiresToUser bool   `json:"requires_to_user"`
+	DefaultContext string `json:"default_context"` // Context applied when a message omits it
+}
+
+// CompactCapabilities is the subset of Capabilities sent in the "connected" message
+// FUNCTIONAL DISCOVERY: WebSocket-only clients learn versions, encodings and enabled
+// features without an HTTP round trip; limits and routing stay on the HTTP endpoint
+type CompactCapabilities struct {
+	ProtocolVersions []int    `json:"protocol_versions"`
+	Encodings        []string `json:"encodings"`
+	Features         []string `json:"features"` // Enabled features only
+	// ValidationRulesHash is Capabilities.ValidationRulesHash
+	ValidationRulesHash string `json:"validation_rules_hash"`
+}
+
+// Compact returns the handshake form of c with enabled features in sorted order
+func (c *Capabilities) Compact() CompactCapabilities {
+	features := make([]string, 0, len(c.Features))
+	for name, enabled := range c.Features {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+
+	return CompactCapabilities{
+		ProtocolVersions: c.ProtocolVersions,
+		Encodings:        c.Encodings,
+		Features:         features,
+
+		ValidationRulesHash: c.ValidationRulesHash,
+	}
+}
@@ -0,0 +1,47 @@
+package types
+
+import "time"
+
+// DelegableMessageTypes are the instructor message types a student can be granted
+// FUNCTIONAL DISCOVERY: Broadcasts only - a presenter speaks to the class; direct
+// messages to other students stay with instructors
+var DelegableMessageTypes = []string{MessageTypeInstructorBroadcast}
+
+// IsDelegableMessageType reports whether messageType may be delegated to a student
+func IsDelegableMessageType(messageType string) bool {
+	for _, delegable := range DelegableMessageTypes {
+		if messageType == delegable {
+			return true
+		}
+	}
+	return false
+}
+
+// Delegation lets one student send instructor message types until it expires or is
+// revoked
+type Delegation struct {
+	SessionID string    `json:"session_id"`
+	UserID    string    `json:"user_id"` // The student presenting
+	Types     []string  `json:"types"`
+	GrantedBy string    `json:"granted_by"`
+	GrantedAt time.Time `json:"granted_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Allows reports whether the delegation covers messageType
+func (d *Delegation) Allows(messageType string) bool {
+	for _, allowed := range d.Types {
+		if allowed == messageType {
+			return true
+		}
+	}
+	return false
+}
+
+// MessageDelegation flags a message a student sent under a delegation
+// FUNCTIONAL DISCOVERY: Lets clients render the sender as presenting, and clear that
+// state at expires_at without waiting for a notice
+type MessageDelegation struct {
+	GrantedBy string    `json:"granted_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
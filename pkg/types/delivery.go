@@ -0,0 +1,37 @@
+package types
+
+import "time"
+
+// Reasons a routed message did not reach a recipient, stored in delivery_failures
+const (
+	DeliveryFailureBufferFull       = "buffer_full"       // Held-back backlog full during history replay
+	DeliveryFailureWriteTimeout     = "write_timeout"     // Write buffer stayed full past the write timeout
+	DeliveryFailureConnectionClosed = "connection_closed" // Connection closed or gone before the write
+	DeliveryFailureFiltered         = "filtered"          // Excluded by the recipient's inbox preferences
+	DeliveryFailureEncodeFailed     = "encode_failed"     // The connection's codec could not encode it
+)
+
+// DeliveryFailure is one recipient a routed message did not reach
+// FUNCTIONAL DISCOVERY: Above the sampling threshold one row stands for Weight
+// failures, so counts read back from delivery_failures are SUM(weight), not COUNT(*)
+type DeliveryFailure struct {
+	MessageID string    `json:"message_id"`
+	SessionID string    `json:"session_id"`
+	Recipient string    `json:"recipient"`
+	Reason    string    `json:"reason"`
+	Weight    int       `json:"weight"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// DeliveryStats is a session's delivery record, in GET /api/sessions/{id}/stats and
+// the delivery_reliability event written when a session ends
+// FUNCTIONAL DISCOVERY: Filtered recipients were never meant to receive the message,
+// so they are reported by reason but left out of Failed and both rates. Reliability
+// is 1 when nothing has been attempted
+type DeliveryStats struct {
+	Delivered   int            `json:"delivered"`
+	Failed      int            `json:"failed"`
+	ByReason    map[string]int `json:"by_reason"`
+	FailureRate float64        `json:"failure_rate"` // Failed / (Delivered + Failed)
+	Reliability float64        `json:"reliability"`  // 1 - FailureRate
+}
@@ -13,4 +13,10 @@ var (
 	ErrInvalidContext      = errors.New("context must be 1-50 characters, alphanumeric + underscore/hyphen")
 	ErrInvalidContent      = errors.New("invalid JSON content")
 	ErrContentTooLarge     = errors.New("message content exceeds 64KB limit")
+	ErrToUsersNotAllowed   = errors.New("to_users is only allowed on instructor_broadcast")
+	
+	ErrInvalidInstructorID   = errors.New("instructor_id must be valid user ID")
+	ErrInvalidAnnotationTag  = errors.New("annotation tags must be 1-50 characters, alphanumeric + underscore/hyphen")
+	ErrTooManyAnnotationTags = errors.New("annotations allow at most 10 tags")
+	ErrAnnotationNoteTooLong = errors.New("annotation note exceeds 1000 bytes")
 )
\ No newline at end of file
@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+// Reasons a WebSocket handshake is refused before the upgrade
+// FUNCTIONAL DISCOVERY: Client SDKs map these to their own messages; the prose in
+// HandshakeRejection.Error may change wording, these do not
+const (
+	RejectMissingParameters   = "missing_parameters"
+	RejectReservedUserID      = "reserved_user_id"
+	RejectInvalidUserID       = "invalid_user_id"
+	RejectInvalidRole         = "invalid_role"
+	RejectInvalidStrict       = "invalid_strict"
+	RejectDraining            = "draining"
+	RejectStartingUp          = "starting_up"
+	RejectSessionNotFound     = "session_not_found"
+	RejectSessionEnded        = "session_ended"
+	RejectInstructorNotMember = "instructor_not_member"
+	RejectNotAuthorized       = "not_authorized"
+	RejectValidationFailed    = "validation_failed"
+	RejectDuplicateUser       = "duplicate_user"
+	RejectAPIKeysDisabled     = "api_keys_disabled"
+	RejectInvalidAPIKey       = "invalid_api_key"
+	RejectAPIKeyFailed        = "api_key_failed"
+	RejectAPIKeyWrongSession  = "api_key_wrong_session"
+	RejectJoinCodeAmbiguous   = "join_code_ambiguous"
+)
+
+// HandshakeRejection is the JSON body of a handshake refused before the upgrade
+type HandshakeRejection struct {
+	Error   string     `json:"error"`              // Human-readable explanation
+	Reason  string     `json:"reason"`             // One of the Reject* reasons
+	Code    int        `json:"code"`               // The HTTP status, repeated for clients that only keep the body
+	EndTime *time.Time `json:"end_time,omitempty"` // When the session ended, for session_ended
+}
@@ -0,0 +1,58 @@
+package types
+
+import "time"
+
+// Maintenance tasks POST /api/admin/maintenance accepts
+const (
+	MaintenanceVacuum         = "vacuum"          // Return free pages to the filesystem
+	MaintenanceIntegrityCheck = "integrity_check" // Run SQLite's integrity check
+	MaintenanceAnalyze        = "analyze"         // Refresh the query planner's statistics
+)
+
+// IsMaintenanceTask reports whether task is one of the maintenance tasks
+func IsMaintenanceTask(task string) bool {
+	switch task {
+	case MaintenanceVacuum, MaintenanceIntegrityCheck, MaintenanceAnalyze:
+		return true
+	}
+	return false
+}
+
+// Status of a maintenance job or one of its tasks
+const (
+	MaintenancePending   = "pending"
+	MaintenanceRunning   = "running"
+	MaintenanceCompleted = "completed"
+	MaintenanceFailed    = "failed" // A job fails when any of its tasks did
+)
+
+// MaintenanceTask is one task of a MaintenanceJob and its outcome
+type MaintenanceTask struct {
+	Task        string `json:"task"`
+	Status      string `json:"status"`
+	FreedBytes  int64  `json:"freed_bytes,omitempty"` // vacuum: file size given back
+	Incremental bool   `json:"incremental,omitempty"` // vacuum: ran as incremental_vacuum
+	Verdict     string `json:"verdict,omitempty"`     // integrity_check: "ok" or the problems found
+	Error       string `json:"error,omitempty"`
+	DurationMs  int64  `json:"duration_ms"`
+}
+
+// MaintenanceJob is one run of maintenance tasks, executed in order
+type MaintenanceJob struct {
+	ID         string            `json:"job_id"`
+	Status     string            `json:"status"`
+	Tasks      []MaintenanceTask `json:"tasks"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+}
+
+// Copy returns a copy of the job that shares nothing with it
+func (j *MaintenanceJob) Copy() *MaintenanceJob {
+	copied := *j
+	copied.Tasks = append([]MaintenanceTask(nil), j.Tasks...)
+	if j.FinishedAt != nil {
+		finishedAt := *j.FinishedAt
+		copied.FinishedAt = &finishedAt
+	}
+	return &copied
+}
@@ -0,0 +1,35 @@
+package types
+
+import "time"
+
+// MessageSizeBuckets are the upper bounds, in bytes, of the message size histogram
+// buckets; a last +Inf bucket holds every message
+// FUNCTIONAL DISCOVERY: Routed content is capped at MaxContentBytes, so the top bound
+// is that cap
+var MessageSizeBuckets = [...]int{128, 512, 2048, 8192, 32768, MaxContentBytes}
+
+// MessageSizeHistogram counts one message type's stored content sizes since start
+type MessageSizeHistogram struct {
+	Buckets  []uint64 `json:"buckets"` // Cumulative counts at or under each MessageSizeBuckets bound
+	Count    uint64   `json:"count"`
+	SumBytes uint64   `json:"sum_bytes"`
+}
+
+// MessageSizeStats is one message type's stored content sizes over a window
+// FUNCTIONAL DISCOVERY: Percentiles are nearest-rank over the stored sizes, so each is
+// the size of an actual message
+type MessageSizeStats struct {
+	Type       string `json:"type"`
+	Count      int    `json:"count"`
+	TotalBytes int64  `json:"total_bytes"`
+	P50Bytes   int    `json:"p50_bytes"`
+	P95Bytes   int    `json:"p95_bytes"`
+	MaxBytes   int    `json:"max_bytes"`
+}
+
+// MessageSizeReport is served by GET /api/stats/message-sizes
+type MessageSizeReport struct {
+	Since time.Time          `json:"since"`
+	Hours int                `json:"hours"`
+	Types []MessageSizeStats `json:"types"` // By type name; types with no messages are omitted
+}
@@ -0,0 +1,121 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Session metadata limits
+const (
+	MaxSessionMetadataKeys       = 20
+	MaxSessionMetadataKeyLength  = 64
+	MaxSessionMetadataValueBytes = 1024
+)
+
+// Metadata keys the server reads
+// FUNCTIONAL DISCOVERY: Ordinary keys, set through the metadata API like any other, so
+// an instructor can give any session a join code; only their meaning is fixed
+const (
+	MetadataKeyJoinCode = "join_code" // Accepted by the WebSocket handshake in place of session_id
+	MetadataKeyDemo     = "demo"      // "true" on the session -bootstrap-demo creates
+)
+
+// ReservedMetadataPrefixes are key prefixes kept for Switchboard's own use
+var ReservedMetadataPrefixes = []string{"switchboard_", "sb_", "_"}
+
+// ErrInvalidMetadata refuses session metadata over the limits or using reserved keys
+var ErrInvalidMetadata = errors.New("invalid session metadata")
+
+// MetadataError lists every problem with a metadata map, keyed by field path such as
+// "metadata.course"
+// FUNCTIONAL DISCOVERY: All problems are reported at once so a form can mark each
+// offending field instead of fixing them one request at a time
+type MetadataError struct {
+	Fields map[string]string
+}
+
+func (e *MetadataError) Error() string {
+	paths := make([]string, 0, len(e.Fields))
+	for path := range e.Fields {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	problems := make([]string, len(paths))
+	for i, path := range paths {
+		problems[i] = path + ": " + e.Fields[path]
+	}
+	return fmt.Sprintf("%v: %s", ErrInvalidMetadata, strings.Join(problems, "; "))
+}
+
+func (e *MetadataError) Unwrap() error { return ErrInvalidMetadata }
+
+// IsValidMetadataKey reports whether key may name a metadata entry: 1-64 letters,
+// digits, underscores or hyphens
+// TECHNICAL DISCOVERY: Dots are excluded so a key maps one-to-one onto both the
+// ?metadata.<key>= list filter and a JSON path
+func IsValidMetadataKey(key string) bool {
+	if key == "" || len(key) > MaxSessionMetadataKeyLength {
+		return false
+	}
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateSessionMetadata checks metadata against the key format, reserved prefixes
+// and size limits, returning a *MetadataError listing every problem
+func ValidateSessionMetadata(metadata map[string]string) error {
+	fields := make(map[string]string)
+	if len(metadata) > MaxSessionMetadataKeys {
+		fields["metadata"] = fmt.Sprintf("at most %d keys allowed, got %d", MaxSessionMetadataKeys, len(metadata))
+	}
+	for key, value := range metadata {
+		path := "metadata." + key
+		switch {
+		case !IsValidMetadataKey(key):
+			fields[path] = fmt.Sprintf("key must be 1-%d letters, digits, underscores or hyphens", MaxSessionMetadataKeyLength)
+		case reservedMetadataKey(key):
+			fields[path] = "key uses a reserved prefix"
+		case len(value) > MaxSessionMetadataValueBytes:
+			fields[path] = fmt.Sprintf("value exceeds %d bytes", MaxSessionMetadataValueBytes)
+		}
+	}
+	if len(fields) > 0 {
+		return &MetadataError{Fields: fields}
+	}
+	return nil
+}
+
+func reservedMetadataKey(key string) bool {
+	lower := strings.ToLower(key)
+	for _, prefix := range ReservedMetadataPrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeSessionMetadata applies a PATCH to current: a key with a null value is removed,
+// any other key is set. current is not modified
+// FUNCTIONAL DISCOVERY: JSON merge patch semantics, so a frontend changes the room
+// number without resending the course code
+func MergeSessionMetadata(current map[string]string, patch map[string]*string) map[string]string {
+	merged := make(map[string]string, len(current)+len(patch))
+	for key, value := range current {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = *value
+		}
+	}
+	return merged
+}
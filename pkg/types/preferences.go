@@ -0,0 +1,87 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// MaxInboxFilterContexts caps the contexts one message type's filter may list
+const MaxInboxFilterContexts = 50
+
+// ErrInvalidInboxFilter refuses preferences naming an unfilterable type or a bad context
+var ErrInvalidInboxFilter = errors.New("invalid inbox preference filter")
+
+// IsFilterableInboxType reports whether instructors may filter messageType: the
+// types fanned out to every instructor in a session
+// FUNCTIONAL DISCOVERY: Reactions are left out - instructors see tallies, not the
+// reactions themselves, so there is nothing per-message to filter
+func IsFilterableInboxType(messageType string) bool {
+	switch messageType {
+	case MessageTypeInstructorInbox, MessageTypeRequestResponse, MessageTypeAnalytics:
+		return true
+	}
+	return false
+}
+
+// InboxPreferences restricts which instructor fan-out messages reach one instructor
+// in one session
+// FUNCTIONAL DISCOVERY: Filters maps a message type to the contexts the instructor
+// receives; a type without a filter arrives in full and an empty list mutes it, so a
+// TA can take only {"instructor_inbox": ["technical_issue"]} while the lead teacher,
+// with no preferences, takes everything
+type InboxPreferences struct {
+	SessionID    string              `json:"session_id"`
+	InstructorID string              `json:"instructor_id"`
+	Filters      map[string][]string `json:"filters"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+// FilterFor returns the contexts of messageType the instructor receives, and false
+// when the type is not filtered at all
+func (p *InboxPreferences) FilterFor(messageType string) ([]string, bool) {
+	if p == nil {
+		return nil, false
+	}
+	contexts, filtered := p.Filters[messageType]
+	return contexts, filtered
+}
+
+// Receives reports whether a message of messageType in context reaches the instructor
+func (p *InboxPreferences) Receives(messageType, context string) bool {
+	contexts, filtered := p.FilterFor(messageType)
+	if !filtered {
+		return true
+	}
+	for _, c := range contexts {
+		if c == context {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateInboxFilters checks preference filters before they are stored
+func ValidateInboxFilters(filters map[string][]string) error {
+	for messageType, contexts := range filters {
+		if !IsFilterableInboxType(messageType) {
+			return fmt.Errorf("%w: %q is not an instructor fan-out type", ErrInvalidInboxFilter, messageType)
+		}
+		if len(contexts) > MaxInboxFilterContexts {
+			return fmt.Errorf("%w: %s lists more than %d contexts", ErrInvalidInboxFilter, messageType, MaxInboxFilterContexts)
+		}
+		for _, context := range contexts {
+			if !IsValidContext(context) {
+				return fmt.Errorf("%w: %q is not a valid context", ErrInvalidInboxFilter, context)
+			}
+		}
+	}
+	return nil
+}
+
+// InboxPreferencesUpdate answers a preferences change with what was stored and
+// warnings the instructor should see, such as contexts no instructor now receives
+type InboxPreferencesUpdate struct {
+	Preferences *InboxPreferences `json:"preferences"`
+	Warnings    []string          `json:"warnings,omitempty"`
+}
@@ -0,0 +1,39 @@
+package types
+
+import "time"
+
+// Stages of a session purge, in the order they run; each names the table it empties
+// FUNCTIONAL DISCOVERY: Rows that point at messages go first and the session row goes
+// last, so an interrupted purge always leaves a session to purge again
+const (
+	PurgeStageMessageReads          = "message_reads"
+	PurgeStageMessageReactions      = "message_reactions"
+	PurgeStageMessageAnnotations    = "message_annotations"
+	PurgeStageMessageMetadata       = "message_metadata"
+	PurgeStageMessageRevisions      = "message_revisions"
+	PurgeStageMessages              = "messages"
+	PurgeStagePoisonMessages        = "poison_messages"
+	PurgeStageDeliveryFailures      = "delivery_failures"
+	PurgeStageSessionEvents         = "session_events"
+	PurgeStageAPIKeys               = "api_keys"
+	PurgeStageInstructorPreferences = "instructor_preferences"
+	PurgeStageMetricsRollups        = "metrics_rollups"
+	PurgeStageSession               = "sessions"
+	PurgeStageCompleted             = "completed"
+)
+
+// PurgeContentBlobs counts shared content bodies deleted because the purged messages
+// held their last references
+const PurgeContentBlobs = "content_store"
+
+// SessionPurge is the progress and outcome of deleting a session and all its data
+type SessionPurge struct {
+	SessionID   string           `json:"session_id"`
+	RequestedBy string           `json:"requested_by"`
+	Stage       string           `json:"stage"`   // The stage running or next to run; completed when done
+	Removed     map[string]int64 `json:"removed"` // Rows deleted per table, across every attempt
+	StartedAt   time.Time        `json:"started_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	Resumed     bool             `json:"resumed,omitempty"` // An earlier attempt was interrupted
+}
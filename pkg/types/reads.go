@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+// ReadReceiptContentMessage is the content key naming the message a read_receipt marks read
+const ReadReceiptContentMessage = "message_id"
+
+// MessageRead records the first time a student reported displaying a message
+// FUNCTIONAL DISCOVERY: Later receipts for the same message keep the first ReadAt,
+// so clients may resend receipts freely, e.g. after a reconnect
+type MessageRead struct {
+	MessageID string    `json:"message_id"`
+	UserID    string    `json:"user_id"`
+	ReadAt    time.Time `json:"read_at"`
+}
+
+// MessageReads lists who has and has not read a message
+type MessageReads struct {
+	MessageID      string         `json:"message_id"`
+	RecipientCount int            `json:"recipient_count"` // Students the message reached
+	ReadCount      int            `json:"read_count"`
+	Read           []*MessageRead `json:"read"`   // Oldest first
+	Unread         []string       `json:"unread"` // Recipients without a receipt, in roster order
+}
@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+// MessageRevision is content an edit replaced
+// FUNCTIONAL DISCOVERY: Revision 0 is what the message was sent with; the message
+// itself always holds the newest revision
+type MessageRevision struct {
+	MessageID  string                 `json:"message_id"`
+	Revision   int                    `json:"revision"`
+	Content    map[string]interface{} `json:"content"`
+	EditedBy   string                 `json:"edited_by"` // Who replaced this content
+	ReplacedAt time.Time              `json:"replaced_at"`
+}
+
+// IsEditableMessageType reports whether messages of messageType may be edited after
+// they are sent: the instructor types only, so students' messages stay as submitted
+func IsEditableMessageType(messageType string) bool {
+	switch messageType {
+	case MessageTypeInstructorBroadcast, MessageTypeInboxResponse, MessageTypeRequest:
+		return true
+	}
+	return false
+}
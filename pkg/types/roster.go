@@ -0,0 +1,48 @@
+package types
+
+// RosterChange is the students added to and removed from a session's roster
+// FUNCTIONAL DISCOVERY: As returned, it is the effective change - students already on
+// the roster are not in Add and students not on it are not in Remove
+type RosterChange struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// StudentPresence is a student's WebSocket connections to a session
+// FUNCTIONAL DISCOVERY: Counted since the server started and dropped when the session
+// ends; a reconnect that takes over a connection counts as a leave and a join. The byte
+// counts are the current connection's, so they start again from zero on every join
+// and are zero while disconnected
+type StudentPresence struct {
+	Joins         int    `json:"joins"`
+	Leaves        int    `json:"leaves"`
+	Connected     bool   `json:"connected"`
+	BytesSent     uint64 `json:"bytes_sent"`     // Written to the student
+	BytesReceived uint64 `json:"bytes_received"` // Read from the student
+}
+
+// RosterEntry is one student in GET /api/sessions/{id}/roster
+type RosterEntry struct {
+	StudentID string `json:"student_id"`
+	StudentPresence
+}
+
+// RosterLineError is an import row that was not used
+// FUNCTIONAL DISCOVERY: Line counts from 1 at the top of a CSV body, header included;
+// for JSON it is the 1-based position in the students array
+type RosterLineError struct {
+	Line      int    `json:"line"`
+	StudentID string `json:"student_id,omitempty"`
+	Error     string `json:"error"`
+}
+
+// RosterImport is the result of POST /api/sessions/{id}/roster
+type RosterImport struct {
+	Mode      string            `json:"mode"` // "replace" or "merge"
+	DryRun    bool              `json:"dry_run"`
+	Added     []string          `json:"added"`
+	Removed   []string          `json:"removed"`
+	Unchanged int               `json:"unchanged"` // Students in the upload already on the roster
+	Errors    []RosterLineError `json:"errors"`
+	Session   *Session          `json:"session,omitempty"` // The roster after the change; omitted on a dry run
+}
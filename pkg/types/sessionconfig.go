@@ -0,0 +1,113 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SessionConfigVersion is the snapshot shape written by this server
+// TECHNICAL DISCOVERY: Bump it whenever SessionConfig changes shape, and teach
+// ParseSessionConfig to upgrade the previous version
+const SessionConfigVersion = 1
+
+// ErrUnknownSessionConfigVersion means a stored snapshot is newer than this server
+var ErrUnknownSessionConfigVersion = errors.New("unknown session config version")
+
+// SessionConfig is the routing configuration a session was created under
+// FUNCTIONAL DISCOVERY: Snapshotted once at creation and never updated, so audits
+// explain a delivery with the rules in force at the time rather than after a reload
+// ARCHITECTURAL DISCOVERY: Stored as JSON in sessions.session_config; unset and false
+// fields are omitted to keep one row per session small
+type SessionConfig struct {
+	Version                  int            `json:"v"`
+	Routing                  []RouteSummary `json:"routing"` // Who may send each type and who receives it
+	Limits                   SessionLimits  `json:"limits"`
+	LockExemptTypes          []string       `json:"lock_exempt_types,omitempty"` // Student types routed while locked
+	RejectLateResponses      bool           `json:"reject_late_responses,omitempty"`
+	PersistUnknownRecipients bool           `json:"persist_unknown_recipients,omitempty"`
+	ReactionCodes            []string       `json:"reaction_codes,omitempty"`
+}
+
+// SessionLimits are the per-message limits of a SessionConfig
+type SessionLimits struct {
+	MaxContentBytes        int `json:"max_content_bytes"`
+	RateLimitMessages      int `json:"rate_limit_messages,omitempty"` // Per user per window
+	RateLimitWindowSeconds int `json:"rate_limit_window_seconds,omitempty"`
+	MaxBroadcastRecipients int `json:"max_broadcast_recipients,omitempty"` // Unset in snapshots older than targeted broadcasts
+}
+
+// ParseSessionConfig decodes a stored snapshot, upgrading older versions to the current shape
+// FUNCTIONAL DISCOVERY: A snapshot from a newer server is refused with
+// ErrUnknownSessionConfigVersion rather than read with fields silently missing
+func ParseSessionConfig(data []byte) (*SessionConfig, error) {
+	var header struct {
+		Version int `json:"v"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("invalid session config: %w", err)
+	}
+
+	switch header.Version {
+	case SessionConfigVersion:
+		var config SessionConfig
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("invalid session config: %w", err)
+		}
+		return &config, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnknownSessionConfigVersion, header.Version)
+	}
+}
+
+// Route returns the snapshot's route for messageType
+func (c *SessionConfig) Route(messageType string) (RouteSummary, bool) {
+	for _, route := range c.Routing {
+		if route.Type == messageType {
+			return route, true
+		}
+	}
+	return RouteSummary{}, false
+}
+
+// Reasons a viewer had a message, reported in DeliveryExplanation
+const (
+	DeliveryReasonSender          = "sender"              // The viewer sent it
+	DeliveryReasonAddressed       = "addressed_to_viewer" // Its to_user is the viewer, or to_users lists them
+	DeliveryReasonRouteRecipient  = "route_recipient"     // The viewer's role is the route's recipients
+	DeliveryReasonHistoryReplay   = "history_replay"      // Not delivered live; only shown in history
+	DeliveryReasonUnroutedMessage = "unrouted_type"       // The snapshot has no route for its type
+)
+
+// DeliveryExplanation says why a viewer had a message, under a session's SessionConfig
+type DeliveryExplanation struct {
+	MessageID  string `json:"message_id"`
+	Recipients string `json:"recipients,omitempty"` // The route's recipients; empty for unrouted types
+	Reason     string `json:"reason"`
+}
+
+// ExplainDelivery says why viewer, in role, has message in history
+// TECHNICAL DISCOVERY: Assumes message.VisibleTo(viewer, role); the sender and to_user
+// are checked before the route, since they hold whatever the route says
+func (c *SessionConfig) ExplainDelivery(message *Message, viewer, role string) DeliveryExplanation {
+	explanation := DeliveryExplanation{MessageID: message.ID}
+	route, routed := c.Route(message.Type)
+	if routed {
+		explanation.Recipients = route.Recipients
+	}
+
+	switch {
+	case message.FromUser == viewer:
+		explanation.Reason = DeliveryReasonSender
+	case message.AddressedTo(viewer):
+		explanation.Reason = DeliveryReasonAddressed
+	case !routed:
+		explanation.Reason = DeliveryReasonUnroutedMessage
+	case route.Recipients == "session_students" && role == "student",
+		route.Recipients == "session_instructors" && role == "instructor":
+		explanation.Reason = DeliveryReasonRouteRecipient
+	default:
+		explanation.Reason = DeliveryReasonHistoryReplay
+	}
+	return explanation
+}
@@ -0,0 +1,18 @@
+package types
+
+// Reasons POST /api/sessions is refused with 403 Forbidden
+// FUNCTIONAL DISCOVERY: Sent as ErrorResponse.Reason so a dashboard can tell "you may
+// not create sessions" from "end one of yours first" without parsing the message
+const (
+	CreateRejectCreatorNotAllowed = "creator_not_allowed"
+	CreateRejectQuotaExceeded     = "creator_quota_exceeded"
+)
+
+// CreateRejectReasons lists every CreateReject* reason
+var CreateRejectReasons = []string{CreateRejectCreatorNotAllowed, CreateRejectQuotaExceeded}
+
+// SessionCreationStats counts session creations refused by the creator policy and
+// the per-creator quota since start
+type SessionCreationStats struct {
+	Rejected map[string]uint64 `json:"rejected"` // By CreateReject* reason; every reason is present
+}
@@ -0,0 +1,86 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SessionState is where a session is in its lifecycle, stored in sessions.status
+// FUNCTIONAL DISCOVERY: Scheduled → Active → Ended, one way only; Transition is the
+// only way a session's state changes. Locked is not a state but a flag that can only
+// change while Active, so a locked session is still Active and ends the same way
+type SessionState string
+
+const (
+	// SessionScheduled is a session created ahead of its start that nobody can join yet
+	// TECHNICAL DISCOVERY: Not stored yet - the sessions table's CHECK admits only
+	// active and ended, and widening it means rebuilding the table
+	SessionScheduled SessionState = "scheduled"
+	SessionActive    SessionState = "active"
+	SessionEnded     SessionState = "ended" // Final
+)
+
+// SessionStates lists every session state in lifecycle order
+var SessionStates = []SessionState{SessionScheduled, SessionActive, SessionEnded}
+
+// sessionTransitions lists the states each state may move to
+var sessionTransitions = map[SessionState][]SessionState{
+	SessionScheduled: {SessionActive},
+	SessionActive:    {SessionEnded},
+}
+
+var (
+	ErrUnknownSessionState      = errors.New("unknown session state")
+	ErrIllegalSessionTransition = errors.New("illegal session state transition")
+)
+
+// SessionTransitionError reports a refused move from From to To; it matches
+// ErrIllegalSessionTransition, or ErrUnknownSessionState when either state is unknown
+type SessionTransitionError struct {
+	From SessionState
+	To   SessionState
+}
+
+func (e *SessionTransitionError) Error() string {
+	return fmt.Sprintf("session cannot go from %q to %q", e.From, e.To)
+}
+
+func (e *SessionTransitionError) Unwrap() error {
+	if !e.From.Valid() || !e.To.Valid() {
+		return ErrUnknownSessionState
+	}
+	return ErrIllegalSessionTransition
+}
+
+// Valid reports whether s is one of SessionStates
+func (s SessionState) Valid() bool {
+	switch s {
+	case SessionScheduled, SessionActive, SessionEnded:
+		return true
+	}
+	return false
+}
+
+// CanTransition reports whether a session in s may move to next; staying put is not a move
+func (s SessionState) CanTransition(next SessionState) bool {
+	for _, allowed := range sessionTransitions[s] {
+		if next == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition returns next if a session in s may move there, and a
+// *SessionTransitionError otherwise
+func (s SessionState) Transition(next SessionState) (SessionState, error) {
+	if !s.CanTransition(next) {
+		return s, &SessionTransitionError{From: s, To: next}
+	}
+	return next, nil
+}
+
+// Lockable reports whether a session in s may be locked or unlocked
+func (s SessionState) Lockable() bool {
+	return s == SessionActive
+}
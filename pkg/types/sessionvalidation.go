@@ -0,0 +1,45 @@
+package types
+
+// SessionValidation is a dry-run verdict on a create-session request
+// FUNCTIONAL DISCOVERY: Lists every problem at once, where CreateSession stops at the
+// first, so a roster import can be fixed in one pass instead of one error per attempt
+type SessionValidation struct {
+	Valid      bool             `json:"valid"`  // CreateSession would accept the request
+	Errors     []string         `json:"errors"` // Every reason it would be refused
+	Name       SessionNameCheck `json:"name"`
+	Students   []StudentVerdict `json:"students"`   // One per submitted ID, in submitted order
+	Duplicates []string         `json:"duplicates"` // IDs submitted more than once; creation keeps the first
+	Capacity   CapacityCheck    `json:"capacity"`
+	Creator    CreatorCheck     `json:"creator"`
+}
+
+// SessionNameCheck is the duplicate-name policy's verdict on a requested name
+type SessionNameCheck struct {
+	Requested string `json:"requested"`
+	Final     string `json:"final,omitempty"` // Name the session would get; empty when refused
+	Policy    string `json:"policy"`
+	Conflict  bool   `json:"conflict"` // One of the creator's active sessions already has the name
+}
+
+// StudentVerdict is the verdict on one submitted student ID
+type StudentVerdict struct {
+	StudentID string `json:"student_id"`
+	Valid     bool   `json:"valid"`
+	Duplicate bool   `json:"duplicate,omitempty"` // Repeats an earlier entry and would be removed
+	Error     string `json:"error,omitempty"`
+}
+
+// CapacityCheck compares a roster with the per-session student cap
+type CapacityCheck struct {
+	Students    int  `json:"students"` // Distinct IDs, after duplicates are removed
+	MaxStudents int  `json:"max_students"`
+	WithinLimit bool `json:"within_limit"`
+}
+
+// CreatorCheck is the creator policy's and per-creator quota's verdict on the creator
+type CreatorCheck struct {
+	Allowed     bool `json:"allowed"`    // The creator policy lets this creator create sessions
+	Active      int  `json:"active"`     // The creator's active sessions, counting creates in flight
+	MaxActive   int  `json:"max_active"` // 0 means unlimited
+	WithinQuota bool `json:"within_quota"`
+}
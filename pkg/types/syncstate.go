@@ -0,0 +1,67 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// MaxSyncStateBytes caps the encoded sync_state handshake parameter
+const MaxSyncStateBytes = 512
+
+// MaxHistoryPage caps the limit of one page of GET /api/sessions/{id}/history, which
+// is where a truncated replay's history_url points
+const MaxHistoryPage = 1000
+
+// Reasons a sync_state is set aside for a full replay, sent in the sync_state_rejected notice
+const (
+	SyncStateTooLarge      = "too_large"
+	SyncStateUnparsable    = "unparsable"
+	SyncStateUnknownCursor = "unknown_message"
+)
+
+var (
+	ErrSyncStateTooLarge   = errors.New("sync_state exceeds 512 bytes")
+	ErrSyncStateUnparsable = errors.New("sync_state must be base64-encoded JSON with a last_message_id")
+)
+
+// SyncState is what a reconnecting client already holds, sent base64-encoded as the
+// sync_state handshake parameter so history replay only sends what it is missing
+// ARCHITECTURAL DISCOVERY: History has no sequence numbers, so the cursor is the ID of
+// the last message the client received; replay skips everything up to and including
+// it. Unknown fields are ignored, so clients can send state newer servers will use
+// FUNCTIONAL DISCOVERY: SyncedAt is the server timestamp of the last frame the client
+// received; messages before the cursor edited after it are sent again
+type SyncState struct {
+	LastMessageID string    `json:"last_message_id"`
+	SyncedAt      time.Time `json:"synced_at"`
+}
+
+// Encode returns the state as the sync_state parameter: unpadded URL-safe base64 of
+// its JSON
+func (s *SyncState) Encode() string {
+	encoded, _ := json.Marshal(s) // A string and a time
+	return base64.RawURLEncoding.EncodeToString(encoded)
+}
+
+// DecodeSyncState parses a sync_state parameter, accepting URL-safe or standard
+// base64 with or without padding
+func DecodeSyncState(raw string) (*SyncState, error) {
+	if len(raw) > MaxSyncStateBytes {
+		return nil, ErrSyncStateTooLarge
+	}
+	raw = strings.TrimRight(raw, "=")
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		if decoded, err = base64.RawStdEncoding.DecodeString(raw); err != nil {
+			return nil, ErrSyncStateUnparsable
+		}
+	}
+	var state SyncState
+	if err := json.Unmarshal(decoded, &state); err != nil || state.LastMessageID == "" {
+		return nil, ErrSyncStateUnparsable
+	}
+	return &state, nil
+}
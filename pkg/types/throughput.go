@@ -0,0 +1,30 @@
+package types
+
+import "time"
+
+// ThroughputBucketSeconds is the width of one ThroughputBucket
+const ThroughputBucketSeconds = 60
+
+// ThroughputBucket counts one minute of traffic, across the server and per session
+// FUNCTIONAL DISCOVERY: Kept for capacity planning - messages per minute per session
+// during an exam week is what sizes the hardware for the next one
+type ThroughputBucket struct {
+	Start       time.Time                    `json:"start"`
+	Messages    int                          `json:"messages"`    // Messages routed during the minute
+	Connections int                          `json:"connections"` // Most connections open at a sample in the minute
+	Sessions    map[string]SessionThroughput `json:"sessions,omitempty"`
+}
+
+// SessionThroughput is one session's share of a ThroughputBucket
+type SessionThroughput struct {
+	Messages    int `json:"messages"`
+	Connections int `json:"connections"`
+}
+
+// ThroughputHistory is the per-minute series served by GET /api/stats/history
+type ThroughputHistory struct {
+	Hours         int                `json:"hours"`
+	BucketSeconds int                `json:"bucket_seconds"`
+	SessionID     string             `json:"session_id,omitempty"` // Set when the series is one session's
+	Buckets       []ThroughputBucket `json:"buckets"`              // Oldest first, one per minute
+}
@@ -13,10 +13,51 @@ const (
 	MessageTypeRequestResponse     = "request_response"
 	MessageTypeAnalytics           = "analytics"
 	MessageTypeInstructorBroadcast = "instructor_broadcast"
+	MessageTypeReaction            = "reaction" // Tallied in message_reactions, never stored as a message
 )
 
+// MessageTypes lists every message type IsValidMessageType accepts
+var MessageTypes = []string{
+	MessageTypeInstructorInbox,
+	MessageTypeInboxResponse,
+	MessageTypeRequest,
+	MessageTypeRequestResponse,
+	MessageTypeAnalytics,
+	MessageTypeInstructorBroadcast,
+	MessageTypeReaction,
+}
+
+// SystemUserPrefix is the user ID namespace reserved for senders the server generates;
+// SystemSenderID sends admin announcements and SystemRole is the role of every system sender
+// ARCHITECTURAL DISCOVERY: ":" is not allowed in client user IDs, so no client can
+// connect, import messages or be addressed as a system sender
+const (
+	SystemUserPrefix = "sys:"
+	SystemSenderID   = SystemUserPrefix + "announcements"
+	SystemRole       = "system"
+)
+
+// DefaultContext is the context of a message sent without one, unless the router is
+// configured with a default for its type
+const DefaultContext = "general"
+
+// ControlTypeDiagnostics is the control message an instructor sends to turn the
+// session's diagnostic overlay on or off: {"type": "diagnostics", "content": {"enabled": true}}
+// ARCHITECTURAL DISCOVERY: Control messages are handled by the hub and never routed
+// or stored, so they are deliberately not a MessageType
+const ControlTypeDiagnostics = "diagnostics"
+
+// ControlTypeInboxPreferences is the control message an instructor sends to choose
+// which instructor fan-out messages reach them in this session:
+// {"type": "inbox_preferences", "content": {"filters": {"instructor_inbox": ["technical_issue"]}}}
+const ControlTypeInboxPreferences = "inbox_preferences"
+
+// ControlTypeReadReceipt is the control message a student sends after displaying a
+// message: {"type": "read_receipt", "content": {"message_id": "..."}}
+const ControlTypeReadReceipt = "read_receipt"
+
 // Session represents an educational session
-// FUNCTIONAL DISCOVERY: Session is immutable after creation except for end_time and status
+// FUNCTIONAL DISCOVERY: Session is immutable after creation except for end_time, status, duration and owner
 // This prevents race conditions and simplifies session validation caching
 type Session struct {
 	ID         string    `json:"id" db:"id"`
@@ -25,7 +66,93 @@ type Session struct {
 	StudentIDs []string  `json:"student_ids" db:"student_ids"`
 	StartTime  time.Time `json:"start_time" db:"start_time"`
 	EndTime    *time.Time `json:"end_time,omitempty" db:"end_time"`
-	Status     string    `json:"status" db:"status"`
+	Status     SessionState `json:"status" db:"status"`
+	// FUNCTIONAL DISCOVERY: Optional class length; 0 means the session runs until ended manually
+	DurationMinutes int `json:"duration_minutes,omitempty" db:"duration_minutes"`
+	// FUNCTIONAL DISCOVERY: Current owner; starts as CreatedBy and changes on transfer,
+	// while CreatedBy keeps the historical creator
+	OwnerID string `json:"owner_id" db:"owner_id"`
+	// FUNCTIONAL DISCOVERY: While locked, students cannot send messages; the session
+	// otherwise stays open and instructors keep full use of it
+	Locked bool `json:"locked" db:"locked"`
+	// FUNCTIONAL DISCOVERY: IANA zone exports render timestamps in; stored times stay UTC
+	Timezone string `json:"timezone" db:"timezone"`
+	// FUNCTIONAL DISCOVERY: Frontend key/value data such as course code or room number,
+	// checked by ValidateSessionMetadata; the map is replaced on change, never mutated
+	Metadata map[string]string `json:"metadata,omitempty" db:"metadata"`
+	// FUNCTIONAL DISCOVERY: Co-instructors besides the creator and owner; only consulted
+	// when the deployment's instructor scope is "members"
+	InstructorIDs []string `json:"instructor_ids,omitempty" db:"instructor_ids"`
+	// ARCHITECTURAL DISCOVERY: Set by the session manager at creation and written by
+	// CreateSession only; sessions read back leave it nil, so audits load it with
+	// GetSessionConfig rather than every session query carrying it
+	Config *SessionConfig `json:"-" db:"session_config"`
+}
+
+// ListsInstructor reports whether userID created, owns or is listed as an instructor of
+// the session
+func (s *Session) ListsInstructor(userID string) bool {
+	if userID == s.CreatedBy || userID == s.OwnerID {
+		return true
+	}
+	for _, instructorID := range s.InstructorIDs {
+		if instructorID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultTimezone is the zone of sessions created without one
+const DefaultTimezone = "UTC"
+
+// Location returns the session's time zone, falling back to UTC for sessions built
+// without one or with a name this system cannot load
+func (s *Session) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// Zone returns the session's IANA zone name, DefaultTimezone when unset
+func (s *Session) Zone() string {
+	if s.Timezone == "" {
+		return DefaultTimezone
+	}
+	return s.Timezone
+}
+
+// State returns the session's lifecycle state, SessionActive for sessions built
+// without one like the status column's default
+func (s *Session) State() SessionState {
+	if s.Status == "" {
+		return SessionActive
+	}
+	return s.Status
+}
+
+// Owner returns the current owner, falling back to the creator for sessions built
+// without an owner
+func (s *Session) Owner() string {
+	if s.OwnerID == "" {
+		return s.CreatedBy
+	}
+	return s.OwnerID
+}
+
+// ExpiresAt returns when a duration-limited session ends automatically
+// TECHNICAL DISCOVERY: Derived from start_time + duration rather than stored, so
+// extending a session and recovering timers after a restart use the same arithmetic
+func (s *Session) ExpiresAt() (time.Time, bool) {
+	if s.DurationMinutes <= 0 {
+		return time.Time{}, false
+	}
+	return s.StartTime.Add(time.Duration(s.DurationMinutes) * time.Minute), true
 }
 
 // Message represents a communication message
@@ -40,6 +167,520 @@ type Message struct {
 	ToUser    *string                `json:"to_user,omitempty"`
 	Content   map[string]interface{} `json:"content"`
 	Timestamp time.Time              `json:"timestamp"`
+	// FUNCTIONAL DISCOVERY: Narrows an instructor_broadcast to the listed students, live
+	// and in history replay; empty broadcasts to the whole session
+	ToUsers []string `json:"to_users,omitempty"`
+	// FUNCTIONAL DISCOVERY: Only filled in on history sent to instructors; live
+	// routing never sets it, so students never see annotations
+	Annotations []*MessageAnnotation `json:"annotations,omitempty"`
+	// FUNCTIONAL DISCOVERY: Set only on sampled live deliveries to instructors while
+	// the session's diagnostic mode is on; never stored or sent to students
+	Diag *MessageDiagnostics `json:"diag,omitempty"`
+	// FUNCTIONAL DISCOVERY: Set on messages from system senders so clients can render
+	// announcements distinctly; derived from from_user rather than stored
+	System bool `json:"system,omitempty"`
+	// FUNCTIONAL DISCOVERY: Reaction counts per code, filled in on history sent to
+	// instructors like Annotations; students never see the tallies
+	Reactions map[string]int `json:"reactions,omitempty"`
+	// FUNCTIONAL DISCOVERY: Set on live deliveries of messages a student sent under an
+	// instructor's delegation; never accepted from clients or stored
+	Delegation *MessageDelegation `json:"delegation,omitempty"`
+	// FUNCTIONAL DISCOVERY: Edited messages carry their current revision, counted from
+	// 0 for the content they were sent with, and when they were last edited
+	Revision int        `json:"revision,omitempty"`
+	EditedAt *time.Time `json:"edited_at,omitempty"`
+}
+
+// MessageDiagnostics is the latency overlay attached to a delivered message
+// FUNCTIONAL DISCOVERY: Stages add up to the server's share of the delay a teacher
+// notices; queue depths show whether the hub or the recipient's link was backed up
+type MessageDiagnostics struct {
+	IngestMs  float64 `json:"ingest_ms"`  // Read from the sender's socket until the hub picked it up
+	RouteMs   float64 `json:"route_ms"`   // Validation, rate limiting and persistence
+	WriteMs   float64 `json:"write_ms"`   // Delivery start until queued on this recipient's connection
+	HubQueue  int     `json:"hub_queue"`  // Messages waiting in its hub worker's queue when it was picked up
+	SendQueue int     `json:"send_queue"` // Frames waiting on this recipient's connection
+}
+
+// VisibleTo reports whether a user in role sees message in session history
+// FUNCTIONAL DISCOVERY: Instructors see everything; students see broadcasts and
+// messages sent by or addressed to them. Shared by history replay and the as-of
+// history endpoint so both reconstruct the same view
+func (m *Message) VisibleTo(userID, role string) bool {
+	switch role {
+	case "instructor":
+		return true
+	case "student":
+		return (m.ToUser == nil && len(m.ToUsers) == 0) || m.AddressedTo(userID) || m.FromUser == userID
+	default:
+		return false
+	}
+}
+
+// AddressedTo reports whether userID is the message's to_user or one of its to_users
+func (m *Message) AddressedTo(userID string) bool {
+	if m.ToUser != nil && *m.ToUser == userID {
+		return true
+	}
+	for _, toUser := range m.ToUsers {
+		if toUser == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// ReachesStudent reports whether a student in the message's session received it: a
+// broadcast to the whole session, or a message addressed to them
+func (m *Message) ReachesStudent(userID string) bool {
+	wholeSession := m.Type == MessageTypeInstructorBroadcast && len(m.ToUsers) == 0
+	return wholeSession || m.AddressedTo(userID)
+}
+
+// Annotation limits
+const (
+	MaxAnnotationTags      = 10
+	MaxAnnotationNoteBytes = 1000
+)
+
+// MessageAnnotation is one instructor's tags and note on a persisted message
+// FUNCTIONAL DISCOVERY: Private to instructors - returned in instructor history only
+// and never broadcast. Empty tags and note remove the annotation
+type MessageAnnotation struct {
+	MessageID    string    `json:"message_id"`
+	InstructorID string    `json:"instructor_id"`
+	Tags         []string  `json:"tags"`
+	Note         string    `json:"note,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// HasTag reports whether the annotation carries tag
+func (a *MessageAnnotation) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachAnnotations sets each message's Annotations from annotations
+// TECHNICAL DISCOVERY: One pass over each slice; callers fetch a session's
+// annotations in one query instead of one per message
+func AttachAnnotations(messages []*Message, annotations []*MessageAnnotation) {
+	byMessage := make(map[string][]*MessageAnnotation, len(annotations))
+	for _, annotation := range annotations {
+		byMessage[annotation.MessageID] = append(byMessage[annotation.MessageID], annotation)
+	}
+	for _, message := range messages {
+		message.Annotations = byMessage[message.ID]
+	}
+}
+
+// Content keys of a reaction message, e.g.
+// {"type": "reaction", "content": {"target_message_id": "...", "reaction": "thumbs_up"}}
+const (
+	ReactionContentTarget = "target_message_id"
+	ReactionContentCode   = "reaction"
+)
+
+// DefaultReactionCodes is the reaction allowlist used unless configuration replaces it
+var DefaultReactionCodes = []string{"thumbs_up", "thumbs_down", "question"}
+
+// MessageReaction is one student's current reaction to a message
+// FUNCTIONAL DISCOVERY: One row per message and student; reacting again replaces
+// the earlier reaction, so tallies count each student once
+type MessageReaction struct {
+	MessageID string    `json:"message_id"`
+	UserID    string    `json:"user_id"`
+	Reaction  string    `json:"reaction"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AttachReactions sets each message's Reactions from counts keyed by message ID
+func AttachReactions(messages []*Message, counts map[string]map[string]int) {
+	for _, message := range messages {
+		message.Reactions = counts[message.ID]
+	}
+}
+
+// MessageMetadata records which connection sent a persisted message
+// ARCHITECTURAL DISCOVERY: Kept out of Message so client IPs and user agents never
+// appear in normal message envelopes - only admin endpoints read this side table
+type MessageMetadata struct {
+	MessageID    string    `json:"message_id"`
+	ConnectionID string    `json:"connection_id"`
+	ClientIP     string    `json:"client_ip"`
+	UserAgent    string    `json:"user_agent"`
+	RecordedAt   time.Time `json:"recorded_at"`
+}
+
+// Error codes carried by message_error system messages
+// FUNCTIONAL DISCOVERY: Lets clients react to a specific refusal, e.g. grey out the
+// send button, without parsing the human-readable error text
+const (
+	ErrorCodeSessionLocked    = "SESSION_LOCKED"
+	ErrorCodeDeadlinePassed   = "DEADLINE_PASSED"
+	ErrorCodeUnknownField     = "UNKNOWN_FIELD" // Strict connections only; content.field names it
+	ErrorCodeReservedUserID   = "RESERVED_USER_ID"
+	ErrorCodeUnknownRecipient = "UNKNOWN_RECIPIENT" // content.reason is "unknown" or "not_in_session"
+	ErrorCodeScopeDenied      = "SCOPE_DENIED"      // API key connections lacking send_messages
+	ErrorCodeSessionMismatch  = "SESSION_MISMATCH"  // A frame's session_id names another session
+	// content.reason is "malformed", "mime_not_allowed" or "too_large"
+	ErrorCodeAttachmentRejected = "ATTACHMENT_REJECTED"
+)
+
+// Session event types recorded in the session_events audit trail
+const (
+	SessionEventOwnerTransferred      = "owner_transferred"
+	SessionEventRoutingLatency        = "routing_latency"        // Latency summary written when a session ends
+	SessionEventImpersonationRejected = "impersonation_rejected" // Actor is the claimed user_id
+	SessionEventConnectionReplaced    = "connection_replaced"    // A user_id taken over from another IP
+	SessionEventDelegationGranted     = "delegation_granted"     // Actor is the granting instructor
+	SessionEventDelegationRevoked     = "delegation_revoked"     // Actor is the revoking instructor
+	SessionEventDelegationExpired     = "delegation_expired"     // Actor is the granting instructor
+	SessionEventIdleEnded             = "idle_ended"             // Ended at startup; actor is the system sender
+	SessionEventDeliveryReliability   = "delivery_reliability"   // Delivery summary written when a session ends
+	SessionEventBandwidth             = "bandwidth"              // Byte totals written when a session ends
+)
+
+// SessionEvent is one entry in a session's audit trail
+// ARCHITECTURAL DISCOVERY: Details varies by Type, e.g. previous_owner and new_owner for
+// ownership transfers, so new event kinds need no schema change
+type SessionEvent struct {
+	ID        int64                  `json:"id"`
+	SessionID string                 `json:"session_id"`
+	Type      string                 `json:"type"`
+	Actor     string                 `json:"actor"` // User who caused the event
+	Details   map[string]interface{} `json:"details"`
+	CreatedAt time.Time              `json:"created_at"`
+}
+
+// DatabaseHealth is the database watchdog's view of persistence health
+// FUNCTIONAL DISCOVERY: Degraded means history may not be saved; RouteOnly reports
+// whether live routing continues without persistence while degraded
+type DatabaseHealth struct {
+	Degraded             bool      `json:"degraded"`
+	Reason               string    `json:"reason,omitempty"`
+	Since                time.Time `json:"since,omitempty"`
+	RouteOnly            bool      `json:"route_only"`
+	FreeBytes            uint64    `json:"free_bytes,omitempty"`
+	WriteSuccessRate     float64   `json:"write_success_rate"`
+	DegradedCount        int64     `json:"degraded_count"`         // Transitions into degraded mode
+	RecoveredCount       int64     `json:"recovered_count"`        // Transitions back to healthy
+	SkippedWrites        int64     `json:"skipped_writes"`         // Messages routed without persistence
+	PersistFailures      int64     `json:"persist_failures"`       // Message writes that failed after retry
+	PoisonMessages       int64     `json:"poison_messages"`        // Messages quarantined in poison_messages
+	ContentBytesOriginal int64     `json:"content_bytes_original"` // Message content size as serialized before compaction
+	ContentBytesStored   int64     `json:"content_bytes_stored"`   // Message content size actually stored
+	// Shared content bodies that were already stored once and so not stored again
+	ContentBytesDeduplicated int64 `json:"content_bytes_deduplicated"`
+	// The most recent maintenance job, running or finished
+	LastMaintenance *MaintenanceJob `json:"last_maintenance,omitempty"`
+	// Startup found the database locked and recovered it; set in the first report only
+	RecoveredFromUncleanShutdown bool `json:"recovered_from_unclean_shutdown,omitempty"`
+}
+
+// WriteLatencyStats summarizes enqueue-to-complete latency of one kind of database write
+// FUNCTIONAL DISCOVERY: Percentiles are the upper bound of the histogram bucket they fall
+// in, within 12.5% of the exact sample
+type WriteLatencyStats struct {
+	Count uint64  `json:"count"`
+	SumMs float64 `json:"sum_ms"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+	MaxMs float64 `json:"max_ms"`
+}
+
+// DatabaseWriteStats reports the single writer's queue and write latency since start
+type DatabaseWriteStats struct {
+	QueueDepth    int                             `json:"queue_depth"`
+	QueueCapacity int                             `json:"queue_capacity"`
+	MaxQueueDepth int                             `json:"max_queue_depth"` // Deepest the queue has been since start
+	Latency       map[string]WriteLatencyStats    `json:"latency"`         // By kind: session, message, other
+	MessageSizes  map[string]MessageSizeHistogram `json:"message_sizes"`   // By message type, of messages stored since start
+}
+
+// ServerStats is served by GET /api/stats
+type ServerStats struct {
+	UptimeSeconds   float64              `json:"uptime_seconds"`
+	Database        DatabaseWriteStats   `json:"database"`
+	SessionCreation SessionCreationStats `json:"session_creation"`
+}
+
+// ContentFilterStats counts the router's content allowlist enforcement
+// FUNCTIONAL DISCOVERY: Reported in /health so operators can spot clients sending
+// fields the deployment does not allow; all zero while no allowlist is configured
+type ContentFilterStats struct {
+	KeysStripped     int64 `json:"keys_stripped"`     // Disallowed keys removed, counted at every nesting level
+	MessagesStripped int64 `json:"messages_stripped"` // Messages routed with at least one key removed
+	MessagesRejected int64 `json:"messages_rejected"` // Messages refused in strict mode
+	// Inline attachments removed, or messages refused in strict mode, for breaking the
+	// attachment MIME allowlist or size cap
+	AttachmentsStripped int64 `json:"attachments_stripped"`
+	AttachmentsRejected int64 `json:"attachments_rejected"`
+}
+
+// SelfTestSessionID is the hidden session the self-test probes are routed in
+// ARCHITECTURAL DISCOVERY: Not a UUID, so it can never collide with a real session; it
+// has no sessions row, and its probes are stored in selftest_probes instead of messages
+const SelfTestSessionID = "_selftest"
+
+// SelfTestStatus reports the periodic loopback probe through the routing pipeline
+// FUNCTIONAL DISCOVERY: Degraded is set after the configured number of consecutive
+// misses and fails readiness in /health; one successful probe clears it
+type SelfTestStatus struct {
+	Degraded            bool      `json:"degraded"`
+	LastSuccess         time.Time `json:"last_success,omitempty"`
+	LatencyMs           float64   `json:"latency_ms"`           // End to end, for the last successful probe
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	Probes              int64     `json:"probes"`
+	Failures            int64     `json:"failures"`
+}
+
+// API key scopes; a key carries any combination of them
+// FUNCTIONAL DISCOVERY: send_messages lets a key's connection send as an instructor,
+// read_history gives it history replay and the history and stats endpoints, and manage
+// lets it annotate messages and list or revoke the session's keys
+const (
+	APIKeyScopeSendMessages = "send_messages"
+	APIKeyScopeReadHistory  = "read_history"
+	APIKeyScopeManage       = "manage"
+)
+
+// APIKeyUserPrefix namespaces the synthetic users API keys act as, e.g. "apikey:<key id>"
+// ARCHITECTURAL DISCOVERY: ":" is not allowed in client user IDs, so nobody can connect
+// as a key's user without the key; unlike SystemUserPrefix its messages are not system
+// messages and are rate limited like anyone's
+const APIKeyUserPrefix = "apikey:"
+
+// APIKey is a credential limited to one session, for graders and bots
+// FUNCTIONAL DISCOVERY: Only a hash of the secret is stored; the secret itself is
+// shown once, when the key is created. A key stops working when it expires, when it
+// is revoked and when its session ends
+type APIKey struct {
+	ID        string     `json:"id"`
+	SessionID string     `json:"session_id"`
+	Name      string     `json:"name,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// UserID returns the synthetic user the key acts as
+func (k *APIKey) UserID() string {
+	return APIKeyUserPrefix + k.ID
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidAPIKeyScope reports whether scope is one of the APIKeyScope constants
+func IsValidAPIKeyScope(scope string) bool {
+	return scope == APIKeyScopeSendMessages || scope == APIKeyScopeReadHistory || scope == APIKeyScopeManage
+}
+
+// AdmissionStats describes connection admission pacing after startup
+// FUNCTIONAL DISCOVERY: Reported in /health so operators can tell a reconnect wave
+// being paced from clients that cannot reach the server at all
+type AdmissionStats struct {
+	Active   bool      `json:"active"`   // Upgrades are still being paced
+	Rate     int       `json:"rate"`     // Upgrades admitted per second while active
+	EndsAt   time.Time `json:"ends_at"`  // When pacing stops
+	Admitted int64     `json:"admitted"` // Upgrades let through while pacing
+	Rejected int64     `json:"rejected"` // Upgrades turned away with 503 and Retry-After
+}
+
+// DrainStatus reports whether a node is moving its connections to other nodes
+// FUNCTIONAL DISCOVERY: Served by the drain endpoints and in /health; the times are
+// zero while the node is not draining
+type DrainStatus struct {
+	Draining  bool      `json:"draining"`
+	StartedAt time.Time `json:"started_at"`
+	ClosesAt  time.Time `json:"closes_at"` // Remaining connections are closed with 1001 here
+	Notified  int       `json:"notified"`  // Connections told when to reconnect
+	Closed    int       `json:"closed"`    // Connections still open when the window ended
+}
+
+// AnalyticsBucket counts one user's analytics messages in one context and minute
+type AnalyticsBucket struct {
+	Context string    `json:"context"`
+	Minute  time.Time `json:"minute"` // Start of the minute, UTC
+	Count   int       `json:"count"`
+}
+
+// AnalyticsPoint is one minute of an analytics time series
+type AnalyticsPoint struct {
+	Minute time.Time `json:"minute"`
+	Count  int       `json:"count"`
+}
+
+// AnalyticsSeries is the per-minute activity of one analytics context
+type AnalyticsSeries struct {
+	Total  int              `json:"total"`
+	Points []AnalyticsPoint `json:"points"` // Ascending; minutes without messages are omitted
+}
+
+// AnalyticsSummary is one user's analytics activity in a session, keyed by context
+// FUNCTIONAL DISCOVERY: Counts only - analytics content has no fixed schema, so the
+// summary reports when and how often each context was sent, not what it said
+type AnalyticsSummary struct {
+	SessionID string                      `json:"session_id"`
+	UserID    string                      `json:"user_id"`
+	Total     int                         `json:"total"`
+	Contexts  map[string]*AnalyticsSeries `json:"contexts"` // e.g. "engagement", "progress", "error"
+}
+
+// ScalingHint is the autoscaling signal served by GET /api/scaling-hint
+// FUNCTIONAL DISCOVERY: SuggestedReplicaDelta is +1, 0 or -1 for this instance; the
+// orchestrator combines hints from all replicas and owns the actual decision
+type ScalingHint struct {
+	TotalConnections      int       `json:"total_connections"`
+	MaxConnections        int       `json:"max_connections"`     // Connections one instance is sized for
+	ActiveSessions        int       `json:"active_sessions"`     // Sessions with at least one connection
+	WriteQueuePercent     float64   `json:"write_queue_percent"` // Share of the database write queue in use
+	SuggestedReplicaDelta int       `json:"suggested_replica_delta"`
+	ComputedAt            time.Time `json:"computed_at"`
+}
+
+// MinuteCount is the number of messages in one UTC minute
+type MinuteCount struct {
+	Minute time.Time `json:"minute"` // Start of the minute, UTC
+	Count  int       `json:"count"`
+}
+
+// SessionMessageCounts aggregates a session's persisted messages
+// TECHNICAL DISCOVERY: Grouped in SQL, so no message content is loaded
+type SessionMessageCounts struct {
+	Total       int              `json:"total"`
+	ByType      map[string]int   `json:"by_type"`
+	ByMinute    []MinuteCount    `json:"by_minute"` // Minutes without messages are omitted
+	BySender    map[string]int   `json:"by_sender"`
+	BytesByType map[string]int64 `json:"bytes_by_type"` // Stored content bytes
+}
+
+// LatencyStats summarizes the routing latency of a session's messages
+// FUNCTIONAL DISCOVERY: Percentiles come from histogram buckets, so they are the upper
+// bound of the bucket the percentile falls in rather than an exact sample
+type LatencyStats struct {
+	Samples int     `json:"samples"`
+	P50Ms   float64 `json:"p50_ms"`
+	P95Ms   float64 `json:"p95_ms"`
+}
+
+// SessionStats is served by GET /api/sessions/{id}/stats
+type SessionStats struct {
+	SessionID                string         `json:"session_id"`
+	Status                   SessionState   `json:"status"`
+	TotalMessages            int            `json:"total_messages"`
+	MessagesByType           map[string]int `json:"messages_by_type"`
+	MessagesPerMinute        []MinuteCount  `json:"messages_per_minute"`
+	AverageMessagesPerMinute float64        `json:"average_messages_per_minute"` // Over the session so far
+	Participation            map[string]int `json:"participation"`               // Messages sent per rostered student
+	RoutingLatency           LatencyStats   `json:"routing_latency"`
+	Delivery                 DeliveryStats  `json:"delivery"`
+	Bandwidth                BandwidthStats `json:"bandwidth"`
+	CountsAsOf               time.Time      `json:"counts_as_of"` // Counts are cached for a few seconds
+}
+
+// SessionReport is the aggregate part of GET /api/sessions/{id}/report
+// TECHNICAL DISCOVERY: Everything here is grouped in SQL and sized by roster, minutes
+// and contexts rather than by message count; questions and starred messages are
+// streamed after it and never collected
+type SessionReport struct {
+	Session       *Session            `json:"session"`
+	GeneratedAt   time.Time           `json:"generated_at"`
+	Summary       ReportSummary       `json:"summary"`
+	Participation []ReportParticipant `json:"participation"` // Every rostered student, by user ID
+	Analytics     []*AnalyticsBucket  `json:"analytics"`     // Summed over all students
+	Events        []*SessionEvent     `json:"events"`        // The session's audit trail, oldest first
+	Config        *SessionConfig      `json:"session_config"` // Routing at creation; null for sessions older than snapshots
+}
+
+// ReportSummary is a session's overall activity
+type ReportSummary struct {
+	TotalMessages            int              `json:"total_messages"`
+	MessagesByType           map[string]int   `json:"messages_by_type"`
+	BytesByType              map[string]int64 `json:"bytes_by_type"` // Stored content bytes
+	MessagesPerMinute        []MinuteCount    `json:"messages_per_minute"`
+	AverageMessagesPerMinute float64          `json:"average_messages_per_minute"`
+	DurationMinutes          float64          `json:"duration_minutes"` // Until now for active sessions
+	Students                 int              `json:"students"`
+	Attended                 int              `json:"attended"`        // Rostered students who sent anything
+	RoutingLatency           LatencyStats     `json:"routing_latency"` // As recorded at session end
+	Delivery                 DeliveryStats    `json:"delivery"`        // As recorded at session end
+	Bandwidth                BandwidthStats   `json:"bandwidth"`       // As recorded at session end
+}
+
+// ReportParticipant is one rostered student's row in the participation table
+// FUNCTIONAL DISCOVERY: Connections are not recorded, so a student counts as attending
+// once they have sent a message of any type, analytics included
+type ReportParticipant struct {
+	UserID   string `json:"user_id"`
+	Messages int    `json:"messages"`
+	Attended bool   `json:"attended"`
+}
+
+// ReportQuestion is a student's instructor_inbox question with the inbox responses
+// sent to that student before their next question
+type ReportQuestion struct {
+	Question  *Message   `json:"question"`
+	Responses []*Message `json:"responses"`
+}
+
+// RouteResult reports what happened to each recipient of a routed message
+// ARCHITECTURAL DISCOVERY: Shared by the hub's logging and delivery receipts so both
+// describe a delivery the same way; each list holds recipient user IDs
+// FUNCTIONAL DISCOVERY: Resolved is everyone the routing rules selected; each of them
+// then ends up in exactly one of Delivered, Queued or Dropped
+type RouteResult struct {
+	MessageID string       `json:"message_id"`
+	Resolved  []string     `json:"resolved"`
+	Delivered []string     `json:"delivered"` // Accepted by the recipient's connection
+	Queued    []string     `json:"queued"`    // Held for a recipient that is not connected
+	Dropped   []string     `json:"dropped"`   // Neither delivered nor queued
+	Timings   RouteTimings `json:"-"`         // Not part of receipts; see RouteTimings
+}
+
+// Recipient statuses reported by RouteResult.RecipientStatuses
+const (
+	RecipientDelivered = "delivered"
+	RecipientQueued    = "queued"
+	RecipientDropped   = "dropped"
+)
+
+// RecipientStatuses maps each resolved recipient to what happened to it
+func (r RouteResult) RecipientStatuses() map[string]string {
+	statuses := make(map[string]string, len(r.Resolved))
+	for _, recipient := range r.Delivered {
+		statuses[recipient] = RecipientDelivered
+	}
+	for _, recipient := range r.Queued {
+		statuses[recipient] = RecipientQueued
+	}
+	for _, recipient := range r.Dropped {
+		statuses[recipient] = RecipientDropped
+	}
+	return statuses
+}
+
+// RouteTimings are the router's stage timings for one message
+// ARCHITECTURAL DISCOVERY: The one place routing latency is measured; hub logs and
+// the instructor diagnostic overlay both read these rather than keeping own timers
+type RouteTimings struct {
+	Route time.Duration // Validation, rate limiting and persistence
+	Write time.Duration // Queuing the message on every recipient connection
 }
 
 // Client represents a connected WebSocket client
@@ -2,6 +2,7 @@ package types
 
 import (
 	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 	"time"
@@ -271,6 +272,10 @@ func TestClient_ValidateUserID(t *testing.T) {
 		{"special chars", "user@123", false},
 		{"spaces", "user 123", false},
 		{"unicode", "user🎉", false},
+		{"reserved system", "system", false},
+		{"reserved system any case", "System", false},
+		{"reserved namespace", SystemSenderID, false},
+		{"system as a prefix only", "system_admin", true},
 	}
 
 	for _, tt := range tests {
@@ -363,7 +368,270 @@ func TestMessage_ContentSizeValidation(t *testing.T) {
 	}
 }
 
+func TestMessageAnnotation_Validate(t *testing.T) {
+	annotation := MessageAnnotation{InstructorID: "instructor1", Tags: []string{"star", "followup", "star"}}
+	if err := annotation.Validate(); err != nil {
+		t.Fatalf("Expected valid annotation, got %v", err)
+	}
+	if len(annotation.Tags) != 2 || !annotation.HasTag("followup") {
+		t.Errorf("Expected duplicate tags dropped, got %v", annotation.Tags)
+	}
+
+	tooMany := make([]string, MaxAnnotationTags+1)
+	for i := range tooMany {
+		tooMany[i] = "tag" + strings.Repeat("x", i)
+	}
+	tests := []struct {
+		name       string
+		annotation MessageAnnotation
+		want       error
+	}{
+		{"missing instructor", MessageAnnotation{Tags: []string{"star"}}, ErrInvalidInstructorID},
+		{"invalid tag", MessageAnnotation{InstructorID: "instructor1", Tags: []string{"two words"}}, ErrInvalidAnnotationTag},
+		{"too many tags", MessageAnnotation{InstructorID: "instructor1", Tags: tooMany}, ErrTooManyAnnotationTags},
+		{"long note", MessageAnnotation{InstructorID: "instructor1", Note: strings.Repeat("x", MaxAnnotationNoteBytes+1)}, ErrAnnotationNoteTooLong},
+	}
+	for _, tt := range tests {
+		if err := tt.annotation.Validate(); err != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestAttachAnnotations(t *testing.T) {
+	messages := []*Message{{ID: "m1"}, {ID: "m2"}}
+	AttachAnnotations(messages, []*MessageAnnotation{
+		{MessageID: "m2", InstructorID: "instructor1", Tags: []string{"star"}},
+		{MessageID: "m2", InstructorID: "instructor2", Tags: []string{"followup"}},
+	})
+	if messages[0].Annotations != nil || len(messages[1].Annotations) != 2 {
+		t.Errorf("Expected both annotations on m2 only, got %v / %v", messages[0].Annotations, messages[1].Annotations)
+	}
+}
+
+func TestMessage_VisibleTo(t *testing.T) {
+	broadcast := &Message{FromUser: "instructor1"}
+	reply := &Message{FromUser: "instructor1", ToUser: stringPtr("student1")}
+	question := &Message{FromUser: "student2"}
+	question.ToUser = stringPtr("instructor1")
+	targeted := &Message{FromUser: "instructor1", ToUsers: []string{"student1", "student3"}}
+	
+	tests := []struct {
+		name    string
+		message *Message
+		userID  string
+		role    string
+		want    bool
+	}{
+		{"broadcast to student", broadcast, "student1", "student", true},
+		{"reply to its recipient", reply, "student1", "student", true},
+		{"reply to another student", reply, "student2", "student", false},
+		{"question to its sender", question, "student2", "student", true},
+		{"question to another student", question, "student1", "student", false},
+		{"targeted broadcast to a listed student", targeted, "student3", "student", true},
+		{"targeted broadcast to an unlisted student", targeted, "student2", "student", false},
+		{"anything to an instructor", reply, "instructor2", "instructor", true},
+		{"unknown role", broadcast, "student1", "observer", false},
+	}
+	for _, tt := range tests {
+		if got := tt.message.VisibleTo(tt.userID, tt.role); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMessage_ValidateToUsers(t *testing.T) {
+	broadcast := func(toUsers ...string) *Message {
+		return &Message{Type: MessageTypeInstructorBroadcast, FromUser: "instructor1", ToUsers: toUsers, Content: map[string]interface{}{}}
+	}
+	
+	message := broadcast("student1", "student2", "student1")
+	if err := message.Validate(); err != nil {
+		t.Fatalf("Expected a targeted broadcast to validate, got %v", err)
+	}
+	if strings.Join(message.ToUsers, ",") != "student1,student2" {
+		t.Errorf("Expected repeated recipients dropped in order, got %v", message.ToUsers)
+	}
+	if message = broadcast(); message.Validate() != nil || message.ToUsers != nil {
+		t.Errorf("Expected an empty list to mean the whole session, got %v", message.ToUsers)
+	}
+	if err := broadcast("student1", "bad id").Validate(); !errors.Is(err, ErrInvalidUserID) {
+		t.Errorf("Expected ErrInvalidUserID for a malformed recipient, got %v", err)
+	}
+	question := &Message{Type: MessageTypeInstructorInbox, FromUser: "student1", ToUsers: []string{"instructor1"}, Content: map[string]interface{}{}}
+	if err := question.Validate(); err != ErrToUsersNotAllowed {
+		t.Errorf("Expected ErrToUsersNotAllowed outside broadcasts, got %v", err)
+	}
+}
+
+func TestParseSessionConfig(t *testing.T) {
+	stored, err := json.Marshal(&SessionConfig{
+		Version: SessionConfigVersion,
+		Routing: []RouteSummary{{Type: MessageTypeRequest, SenderRole: "instructor", Recipients: "to_user", RequiresToUser: true}},
+		Limits:  SessionLimits{MaxContentBytes: MaxContentBytes},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(stored), "lock_exempt_types") || strings.Contains(string(stored), "reject_late_responses") {
+		t.Errorf("Expected unset fields omitted, got %s", stored)
+	}
+
+	config, err := ParseSessionConfig(stored)
+	if err != nil {
+		t.Fatalf("ParseSessionConfig failed: %v", err)
+	}
+	if route, ok := config.Route(MessageTypeRequest); !ok || !route.RequiresToUser {
+		t.Errorf("Expected the request route back, got %+v", config)
+	}
+	if _, ok := config.Route(MessageTypeAnalytics); ok {
+		t.Error("Expected no route for a type the snapshot lacks")
+	}
+
+	for _, data := range []string{`{"v":2,"routing":[]}`, `{"routing":[]}`} {
+		if _, err := ParseSessionConfig([]byte(data)); !errors.Is(err, ErrUnknownSessionConfigVersion) {
+			t.Errorf("%s: expected ErrUnknownSessionConfigVersion, got %v", data, err)
+		}
+	}
+	if _, err := ParseSessionConfig([]byte(`not json`)); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+}
+
+func TestSessionConfig_ExplainDelivery(t *testing.T) {
+	config := &SessionConfig{Version: SessionConfigVersion, Routing: []RouteSummary{
+		{Type: MessageTypeInstructorInbox, SenderRole: "student", Recipients: "session_instructors"},
+		{Type: MessageTypeInboxResponse, SenderRole: "instructor", Recipients: "to_user", RequiresToUser: true},
+		{Type: MessageTypeInstructorBroadcast, SenderRole: "instructor", Recipients: "session_students"},
+	}}
+	question := &Message{ID: "q", Type: MessageTypeInstructorInbox, FromUser: "student1"}
+	reply := &Message{ID: "r", Type: MessageTypeInboxResponse, FromUser: "instructor1", ToUser: stringPtr("student1")}
+	broadcast := &Message{ID: "b", Type: MessageTypeInstructorBroadcast, FromUser: "instructor1"}
+	analytics := &Message{ID: "a", Type: MessageTypeAnalytics, FromUser: "student2"}
+
+	tests := []struct {
+		message *Message
+		viewer  string
+		role    string
+		want    string
+	}{
+		{question, "student1", "student", DeliveryReasonSender},
+		{question, "instructor2", "instructor", DeliveryReasonRouteRecipient},
+		{reply, "student1", "student", DeliveryReasonAddressed},
+		{reply, "instructor2", "instructor", DeliveryReasonHistoryReplay},
+		{broadcast, "student1", "student", DeliveryReasonRouteRecipient},
+		{broadcast, "instructor2", "instructor", DeliveryReasonHistoryReplay},
+		{analytics, "instructor2", "instructor", DeliveryReasonUnroutedMessage},
+	}
+	for _, tt := range tests {
+		got := config.ExplainDelivery(tt.message, tt.viewer, tt.role)
+		if got.MessageID != tt.message.ID || got.Reason != tt.want {
+			t.Errorf("%s for %s: expected %s, got %+v", tt.message.ID, tt.viewer, tt.want, got)
+		}
+	}
+}
+
+// TestSessionState_Transitions enumerates every pair of states: only scheduled to
+// active and active to ended are allowed, and unknown states match their own error
+func TestSessionState_Transitions(t *testing.T) {
+	allowed := map[[2]SessionState]bool{
+		{SessionScheduled, SessionActive}: true,
+		{SessionActive, SessionEnded}:     true,
+	}
+	states := append([]SessionState{"", "paused"}, SessionStates...)
+	for _, from := range states {
+		for _, to := range states {
+			next, err := from.Transition(to)
+			if allowed[[2]SessionState{from, to}] {
+				if err != nil || next != to || !from.CanTransition(to) {
+					t.Errorf("%q -> %q should be allowed, got %q, %v", from, to, next, err)
+				}
+				continue
+			}
+			var transitionErr *SessionTransitionError
+			if !errors.As(err, &transitionErr) || transitionErr.From != from || transitionErr.To != to || next != from || from.CanTransition(to) {
+				t.Errorf("%q -> %q should be refused, got %q, %v", from, to, next, err)
+				continue
+			}
+			wantErr := ErrIllegalSessionTransition
+			if !from.Valid() || !to.Valid() {
+				wantErr = ErrUnknownSessionState
+			}
+			if !errors.Is(err, wantErr) {
+				t.Errorf("%q -> %q: expected %v, got %v", from, to, wantErr, err)
+			}
+		}
+	}
+
+	for _, state := range states {
+		if state.Lockable() != (state == SessionActive) {
+			t.Errorf("Only active sessions should be lockable, got %q lockable=%v", state, state.Lockable())
+		}
+	}
+	if (&Session{}).State() != SessionActive || (&Session{Status: SessionEnded}).State() != SessionEnded {
+		t.Error("A session without a status should read as active")
+	}
+}
+
 // Helper functions
 func stringPtr(s string) *string {
 	return &s
-}
\ No newline at end of file
+}
+// TestValidationRules_Hash tests functional validation - equal rules hash alike, any
+// change moves the hash, and the schema lists exactly the valid message types
+func TestValidationRules_Hash(t *testing.T) {
+	routes := []RouteSummary{{Type: MessageTypeAnalytics, SenderRole: "student", Recipients: "session_instructors", DefaultContext: DefaultContext}}
+	first, second := NewValidationRules(routes), NewValidationRules(routes)
+	first.Seal()
+	second.Seal()
+	if first.Hash == "" || first.Hash != second.Hash {
+		t.Fatalf("Expected equal rules to hash alike, got %q and %q", first.Hash, second.Hash)
+	}
+	second.Seal()
+	if second.Hash != first.Hash {
+		t.Errorf("Expected resealing to keep the hash, got %q", second.Hash)
+	}
+	second.MessageTypes[0].ContentKeys = []string{"score"}
+	second.Seal()
+	if second.Hash == first.Hash {
+		t.Error("Expected a content allowlist to change the hash")
+	}
+	
+	for _, messageType := range MessageTypes {
+		if !IsValidMessageType(messageType) {
+			t.Errorf("MessageTypes lists %q, which IsValidMessageType refuses", messageType)
+		}
+	}
+	if got := anyCasePattern("sys_1"); got != "^[sS][yY][sS]_1$" {
+		t.Errorf("Unexpected any-case pattern %q", got)
+	}
+}
+
+// TestSyncState_Decode tests functional validation - an encoded state round-trips,
+// standard and padded base64 are accepted, and oversized or cursorless states are not
+func TestSyncState_Decode(t *testing.T) {
+	state := &SyncState{LastMessageID: "msg-1423", SyncedAt: time.Date(2026, 3, 2, 10, 32, 0, 0, time.UTC)}
+	decoded, err := DecodeSyncState(state.Encode())
+	if err != nil || decoded.LastMessageID != state.LastMessageID || !decoded.SyncedAt.Equal(state.SyncedAt) {
+		t.Errorf("Expected the state to round-trip, got %+v (%v)", decoded, err)
+	}
+	if decoded, err := DecodeSyncState("eyJsYXN0X21lc3NhZ2VfaWQiOiJtMSIsInBpbm5lZCI6WzFdfQ=="); err != nil || decoded.LastMessageID != "m1" {
+		t.Errorf("Expected padded standard base64 with unknown fields accepted, got %+v (%v)", decoded, err)
+	}
+	
+	tests := []struct {
+		name string
+		raw  string
+		want error
+	}{
+		{"too large", strings.Repeat("A", MaxSyncStateBytes+1), ErrSyncStateTooLarge},
+		{"not base64", "not base64!", ErrSyncStateUnparsable},
+		{"not JSON", "bm90IGpzb24", ErrSyncStateUnparsable},
+		{"no cursor", (&SyncState{}).Encode(), ErrSyncStateUnparsable},
+	}
+	for _, tt := range tests {
+		if _, err := DecodeSyncState(tt.raw); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
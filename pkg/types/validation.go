@@ -2,14 +2,28 @@ package types
 
 import (
 	"encoding/json"
+	"fmt"
 	"regexp"
+	"strings"
+)
+
+// Identifier formats, shared by the validators below and the validation rules
+// descriptor so clients are told exactly what is enforced
+const (
+	UserIDPattern       = `^[a-zA-Z0-9_-]+$`
+	ContextPattern      = `^[a-zA-Z0-9_-]+$`
+	ReactionCodePattern = `^[a-z0-9_]{1,20}$`
+	MaxUserIDLength     = 50
+	MaxContextLength    = 50
+	ReservedUserID      = "system" // Refused in any letter case, as is the SystemUserPrefix namespace
 )
 
 // FUNCTIONAL DISCOVERY: Regex compiled once at package initialization
 // for better performance in high-frequency validation scenarios
 var (
-	userIDRegex  = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
-	contextRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	userIDRegex       = regexp.MustCompile(UserIDPattern)
+	contextRegex      = regexp.MustCompile(ContextPattern)
+	reactionCodeRegex = regexp.MustCompile(ReactionCodePattern)
 )
 
 // Validate ensures the session meets all requirements
@@ -39,7 +53,7 @@ func (m *Message) Validate() error {
 	// FUNCTIONAL DISCOVERY: Default context applied here ensures
 	// all messages have valid context even if client omits it
 	if m.Context == "" {
-		m.Context = "general"
+		m.Context = DefaultContext
 	}
 	
 	if !IsValidContext(m.Context) {
@@ -52,10 +66,67 @@ func (m *Message) Validate() error {
 	if err != nil {
 		return ErrInvalidContent
 	}
-	if len(contentBytes) > 65536 { // 64KB = 65536 bytes
+	if len(contentBytes) > MaxContentBytes {
 		return ErrContentTooLarge
 	}
 	
+	return m.validateToUsers()
+}
+
+// validateToUsers checks a targeted broadcast's recipient list
+// FUNCTIONAL DISCOVERY: Repeated IDs are dropped, as duplicate annotation tags are, so
+// a student listed twice is delivered to and stored once
+func (m *Message) validateToUsers() error {
+	if len(m.ToUsers) == 0 {
+		m.ToUsers = nil
+		return nil
+	}
+	if m.Type != MessageTypeInstructorBroadcast {
+		return ErrToUsersNotAllowed
+	}
+	
+	seen := make(map[string]bool, len(m.ToUsers))
+	toUsers := make([]string, 0, len(m.ToUsers))
+	for _, toUser := range m.ToUsers {
+		if !IsValidUserID(toUser) {
+			return fmt.Errorf("to_users: %w", ErrInvalidUserID)
+		}
+		if !seen[toUser] {
+			seen[toUser] = true
+			toUsers = append(toUsers, toUser)
+		}
+	}
+	m.ToUsers = toUsers
+	return nil
+}
+
+// Validate ensures the annotation meets all requirements
+// FUNCTIONAL DISCOVERY: Duplicate tags are dropped here, the same way message
+// validation defaults the context, so storage never sees them
+func (a *MessageAnnotation) Validate() error {
+	if !IsValidUserID(a.InstructorID) && !IsAPIKeyUserID(a.InstructorID) {
+		return ErrInvalidInstructorID
+	}
+	
+	seen := make(map[string]bool, len(a.Tags))
+	tags := make([]string, 0, len(a.Tags))
+	for _, tag := range a.Tags {
+		if !IsValidContext(tag) {
+			return ErrInvalidAnnotationTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > MaxAnnotationTags {
+		return ErrTooManyAnnotationTags
+	}
+	a.Tags = tags
+	
+	if len(a.Note) > MaxAnnotationNoteBytes {
+		return ErrAnnotationNoteTooLong
+	}
 	return nil
 }
 
@@ -63,12 +134,34 @@ func (m *Message) Validate() error {
 // FUNCTIONAL DISCOVERY: 1-50 character limit prevents database issues
 // and ensures reasonable display in UI components
 func IsValidUserID(userID string) bool {
-	if len(userID) < 1 || len(userID) > 50 {
+	if len(userID) < 1 || len(userID) > MaxUserIDLength {
+		return false
+	}
+	if IsReservedUserID(userID) {
 		return false
 	}
 	return userIDRegex.MatchString(userID)
 }
 
+// IsReservedUserID reports whether userID belongs to the server: the SystemUserPrefix
+// namespace, or "system" in any letter case
+// FUNCTIONAL DISCOVERY: Reserved IDs are refused wherever user IDs are validated, so
+// nobody can connect, be enrolled or import messages under a name clients would take
+// for the server
+func IsReservedUserID(userID string) bool {
+	return IsSystemUserID(userID) || strings.EqualFold(userID, ReservedUserID)
+}
+
+// IsSystemUserID reports whether userID is a sender the server generates
+func IsSystemUserID(userID string) bool {
+	return strings.HasPrefix(userID, SystemUserPrefix)
+}
+
+// IsAPIKeyUserID reports whether userID is the synthetic user of an API key
+func IsAPIKeyUserID(userID string) bool {
+	return strings.HasPrefix(userID, APIKeyUserPrefix) && len(userID) > len(APIKeyUserPrefix)
+}
+
 // IsValidMessageType checks if the message type is one of the allowed types
 // ARCHITECTURAL DISCOVERY: Explicit validation prevents undefined message
 // types from entering the routing system
@@ -79,18 +172,25 @@ func IsValidMessageType(msgType string) bool {
 		MessageTypeRequest,
 		MessageTypeRequestResponse,
 		MessageTypeAnalytics,
-		MessageTypeInstructorBroadcast:
+		MessageTypeInstructorBroadcast,
+		MessageTypeReaction:
 		return true
 	default:
 		return false
 	}
 }
 
+// IsValidReactionCode reports whether code can name a reaction: 1-20 characters of
+// lowercase letters, digits and underscores
+func IsValidReactionCode(code string) bool {
+	return reactionCodeRegex.MatchString(code)
+}
+
 // IsValidContext checks if the context string meets requirements
 // FUNCTIONAL DISCOVERY: Context validation ensures compatibility with
 // client-defined semantic categorization systems
 func IsValidContext(context string) bool {
-	if len(context) < 1 || len(context) > 50 {
+	if len(context) < 1 || len(context) > MaxContextLength {
 		return false
 	}
 	return contextRegex.MatchString(context)
@@ -0,0 +1,134 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+)
+
+// ValidationRules describes what the server accepts from clients, as data
+// ARCHITECTURAL DISCOVERY: Generated from the same constants and routing table the
+// server enforces, so client-side pre-validation cannot drift from Message.Validate;
+// served by GET /api/validation-rules with Hash in the capabilities document
+// FUNCTIONAL DISCOVERY: MessageSchema is a JSON Schema of exactly what
+// Message.Validate accepts. The rest is enforced while routing - who may send each
+// type, which need a to_user, content allowlists - and a schema cannot say it
+type ValidationRules struct {
+	Hash                   string            `json:"hash"` // Of everything else; changes whenever a rule does
+	UserID                 StringRule        `json:"user_id"`
+	Context                StringRule        `json:"context"` // An omitted or empty context gets the type's default_context
+	ReactionCode           StringRule        `json:"reaction_code"`
+	MaxContentBytes        int               `json:"max_content_bytes"` // Of content encoded as JSON
+	MaxBroadcastRecipients int               `json:"max_broadcast_recipients"`
+	MessageTypes           []MessageTypeRule `json:"message_types"`
+	StrictContent          bool              `json:"strict_content"` // Keys outside content_keys refuse the message instead of being stripped
+	Attachments            *AttachmentRule   `json:"attachments,omitempty"`
+	MessageSchema          map[string]any    `json:"message_schema"`
+}
+
+// StringRule describes an identifier format
+type StringRule struct {
+	MinLength int      `json:"min_length"`
+	MaxLength int      `json:"max_length"`
+	Pattern   string   `json:"pattern"`
+	Reserved  []string `json:"reserved,omitempty"` // Refused in any letter case
+}
+
+// MessageTypeRule describes how one message type is routed
+type MessageTypeRule struct {
+	Type           string   `json:"type"`
+	SenderRole     string   `json:"sender_role"`
+	Recipients     string   `json:"recipients"` // As in RouteSummary
+	RequiresToUser bool     `json:"requires_to_user"`
+	AllowsToUsers  bool     `json:"allows_to_users"`
+	DefaultContext string   `json:"default_context"`
+	ContentKeys    []string `json:"content_keys,omitempty"` // Allowed content paths; omitted when content is unchecked
+}
+
+// AttachmentRule describes inline attachments in content
+type AttachmentRule struct {
+	MIMETypes []string `json:"mime_types"` // "image/*" allows every image type
+	MaxBytes  int      `json:"max_bytes"`  // Decoded
+	Strict    bool     `json:"strict"`     // A bad attachment refuses the message instead of being stripped
+}
+
+// NewValidationRules returns the rules Message.Validate enforces and one
+// MessageTypeRule per route; callers add the configured parts, then Seal
+func NewValidationRules(routes []RouteSummary) *ValidationRules {
+	rules := &ValidationRules{
+		UserID:          StringRule{MinLength: 1, MaxLength: MaxUserIDLength, Pattern: UserIDPattern, Reserved: []string{ReservedUserID}},
+		Context:         StringRule{MinLength: 1, MaxLength: MaxContextLength, Pattern: ContextPattern},
+		ReactionCode:    StringRule{MinLength: 1, MaxLength: 20, Pattern: ReactionCodePattern},
+		MaxContentBytes: MaxContentBytes,
+		MessageTypes:    make([]MessageTypeRule, len(routes)),
+		MessageSchema:   MessageSchema(),
+	}
+	for i, route := range routes {
+		rules.MessageTypes[i] = MessageTypeRule{
+			Type:           route.Type,
+			SenderRole:     route.SenderRole,
+			Recipients:     route.Recipients,
+			RequiresToUser: route.RequiresToUser,
+			AllowsToUsers:  route.Type == MessageTypeInstructorBroadcast,
+			DefaultContext: route.DefaultContext,
+		}
+	}
+	return rules
+}
+
+// Seal sets Hash from the rest of the rules
+// TECHNICAL DISCOVERY: encoding/json sorts map keys, so equal rules always hash alike
+func (r *ValidationRules) Seal() {
+	r.Hash = ""
+	encoded, _ := json.Marshal(r) // Only strings, numbers, bools, slices and maps
+	sum := sha256.Sum256(encoded)
+	r.Hash = hex.EncodeToString(sum[:8])
+}
+
+// MessageSchema returns a JSON Schema (draft 2020-12) of the messages Message.Validate
+// accepts, but for the content size, which MaxContentBytes gives
+func MessageSchema() map[string]any {
+	userID := map[string]any{
+		"type":      "string",
+		"minLength": 1,
+		"maxLength": MaxUserIDLength,
+		"pattern":   UserIDPattern,
+		"not":       map[string]any{"pattern": anyCasePattern(ReservedUserID)},
+	}
+	return map[string]any{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"type":     "object",
+		"required": []string{"type"},
+		"properties": map[string]any{
+			"type": map[string]any{"enum": MessageTypes},
+			"context": map[string]any{"anyOf": []any{
+				map[string]any{"const": ""},
+				map[string]any{"type": "string", "minLength": 1, "maxLength": MaxContextLength, "pattern": ContextPattern},
+			}},
+			"content":  map[string]any{"type": []string{"object", "null"}},
+			"to_user":  map[string]any{"type": []string{"string", "null"}},
+			"to_users": map[string]any{"type": []string{"array", "null"}, "items": userID},
+		},
+		// Only a broadcast may narrow its recipients
+		"if":   map[string]any{"properties": map[string]any{"type": map[string]any{"const": MessageTypeInstructorBroadcast}}},
+		"else": map[string]any{"properties": map[string]any{"to_users": map[string]any{"maxItems": 0}}},
+	}
+}
+
+// anyCasePattern returns a pattern matching exactly word in any letter case, for
+// regex dialects without a case-insensitive flag
+func anyCasePattern(word string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range word {
+		upper, lower := strings.ToUpper(string(r)), strings.ToLower(string(r))
+		if upper == lower {
+			b.WriteString(string(r))
+			continue
+		}
+		b.WriteString("[" + lower + upper + "]")
+	}
+	b.WriteString("$")
+	return b.String()
+}
@@ -16,15 +16,20 @@ type ClassroomData struct {
 
 // TestMessage represents a message for testing with timing information
 type TestMessage struct {
+	ID        string // Sent in content under ContentFixtureID; set by ExpectRouting
 	Type      string
 	Context   string
 	FromUser  string
 	ToUser    string
 	Content   map[string]interface{}
 	DelayMs   int // Realistic timing delay before sending
+	
+	ExpectedRecipients []string // Users the routing table delivers this message to
 }
 
 // MessagePattern represents a realistic communication pattern
+// FUNCTIONAL DISCOVERY: The Generate*Flow functions call ExpectRouting, so each of
+// their messages carries an ID and its expected recipients
 type MessagePattern struct {
 	Name        string
 	Description string
@@ -140,11 +145,13 @@ func GenerateQASessionFlow(scenario *ClassroomData) *MessagePattern {
 		})
 	}
 	
-	return &MessagePattern{
+	pattern := &MessagePattern{
 		Name:        "Q&A Session Flow",
 		Description: "Realistic classroom Q&A session with instructor announcements and student questions",
 		Messages:    messages,
 	}
+	pattern.ExpectRouting(scenario)
+	return pattern
 }
 
 // GenerateCodeReviewFlow creates code review session message flow
@@ -206,11 +213,13 @@ def bubble_sort(arr):
 		})
 	}
 	
-	return &MessagePattern{
+	pattern := &MessagePattern{
 		Name:        "Code Review Flow",
 		Description: "Code submission, review, and feedback cycle",
 		Messages:    messages,
 	}
+	pattern.ExpectRouting(scenario)
+	return pattern
 }
 
 // GenerateAnalyticsFlow creates student analytics reporting flow
@@ -269,11 +278,13 @@ func GenerateAnalyticsFlow(scenario *ClassroomData) *MessagePattern {
 		}
 	}
 	
-	return &MessagePattern{
+	pattern := &MessagePattern{
 		Name:        "Analytics Flow",
 		Description: "Student analytics reporting during active learning session",
 		Messages:    messages,
 	}
+	pattern.ExpectRouting(scenario)
+	return pattern
 }
 
 // GenerateEmergencyFlow creates emergency communication pattern
@@ -309,11 +320,13 @@ func GenerateEmergencyFlow(scenario *ClassroomData) *MessagePattern {
 		})
 	}
 	
-	return &MessagePattern{
+	pattern := &MessagePattern{
 		Name:        "Emergency Communication",
 		Description: "Emergency announcement and student acknowledgment",
 		Messages:    messages,
 	}
+	pattern.ExpectRouting(scenario)
+	return pattern
 }
 
 // GenerateMultiContextFlow creates complex multi-context communication
@@ -362,11 +375,13 @@ func GenerateMultiContextFlow(scenario *ClassroomData) *MessagePattern {
 		}
 	}
 	
-	return &MessagePattern{
+	pattern := &MessagePattern{
 		Name:        "Multi-Context Communication",
 		Description: "All message types with all contexts happening simultaneously",
 		Messages:    messages,
 	}
+	pattern.ExpectRouting(scenario)
+	return pattern
 }
 
 // generateContentForContext creates realistic content based on message type and context
@@ -0,0 +1,401 @@
+package fixtures
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"switchboard/internal/config"
+	"switchboard/pkg/testserver"
+	"switchboard/pkg/types"
+)
+
+// ExternalServerURLEnv points scenario runners at an already running server
+// FUNCTIONAL DISCOVERY: e.g. SWITCHBOARD_SCENARIO_SERVER_URL=https://staging.example.edu
+// runs the same scenarios against a staging deployment instead of an embedded app
+const ExternalServerURLEnv = "SWITCHBOARD_SCENARIO_SERVER_URL"
+
+// Capability names an optional feature an environment may provide
+type Capability string
+
+const (
+	// CapabilityDatabaseAccess means the test can read the server's database directly,
+	// e.g. to count persisted messages
+	CapabilityDatabaseAccess Capability = "database_access"
+)
+
+// ErrCapabilityUnavailable is returned by helpers that need a capability the
+// current environment does not provide
+var ErrCapabilityUnavailable = errors.New("capability not available in this environment")
+
+// Environment provides the server a scenario runs against and the session it runs in
+// ARCHITECTURAL DISCOVERY: Separates "where is the server" from scenario logic so the
+// same scenarios run against an embedded app or an external deployment
+type Environment interface {
+	// Setup prepares the server and creates the scenario's session
+	Setup(t *testing.T, scenario *ClassroomData) (serverURL string, session *TestSession, err error)
+
+	// Supports reports whether the environment provides a capability
+	Supports(capability Capability) bool
+
+	// Teardown releases server resources; the session is cleaned up separately
+	Teardown()
+}
+
+// DefaultEnvironment returns an external environment when ExternalServerURLEnv is
+// set, otherwise an embedded one
+func DefaultEnvironment() Environment {
+	if serverURL := os.Getenv(ExternalServerURLEnv); serverURL != "" {
+		return NewExternalEnvironment(serverURL)
+	}
+	return NewEmbeddedEnvironment()
+}
+
+// embeddedEnvironment runs a full application in-process on a temporary database
+// ARCHITECTURAL DISCOVERY: Delegates to pkg/testserver so scenarios and downstream
+// HTTP tests share a single embedded-server codepath
+type embeddedEnvironment struct {
+	server    *testserver.Server
+	configure func(*config.Config)
+}
+
+// NewEmbeddedEnvironment creates an environment that starts its own server
+func NewEmbeddedEnvironment() Environment {
+	return &embeddedEnvironment{}
+}
+
+// NewEmbeddedEnvironmentWithConfig creates an embedded environment whose test
+// configuration is adjusted by configure before the application is built
+// FUNCTIONAL DISCOVERY: Lets scenarios enable optional features such as transcripts
+// or analytics export without duplicating the embedded setup
+func NewEmbeddedEnvironmentWithConfig(configure func(*config.Config)) Environment {
+	return &embeddedEnvironment{configure: configure}
+}
+
+func (e *embeddedEnvironment) Setup(t *testing.T, scenario *ClassroomData) (string, *TestSession, error) {
+	// TECHNICAL DISCOVERY: Scenarios keep a file database - load scenarios rely on
+	// WAL letting readers proceed alongside the single writer
+	e.server = testserver.New(t, testserver.Options{Configure: e.configure})
+
+	session, err := e.server.Admin.CreateSession(scenario.SessionName, scenario.InstructorIDs[0], scenario.StudentIDs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// The session and database go away with the server, so no cleanup is registered
+	testSession := &TestSession{
+		SessionID:    session.ID,
+		Session:      session,
+		DatabasePath: e.server.DatabasePath,
+		DbManager:    e.server.Database(),
+	}
+	return e.server.URL, testSession, nil
+}
+
+func (e *embeddedEnvironment) Supports(capability Capability) bool {
+	return capability == CapabilityDatabaseAccess
+}
+
+func (e *embeddedEnvironment) Teardown() {
+	if e.server != nil {
+		e.server.Close()
+	}
+}
+
+// externalEnvironment drives a server that is already running elsewhere
+// FUNCTIONAL DISCOVERY: Sessions are created and ended through the REST API, exactly
+// as a real instructor tool would, and the database is never touched
+type externalEnvironment struct {
+	serverURL string
+	client    *http.Client
+}
+
+// NewExternalEnvironment creates an environment for the server at serverURL
+func NewExternalEnvironment(serverURL string) Environment {
+	return &externalEnvironment{
+		serverURL: strings.TrimRight(serverURL, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (e *externalEnvironment) Setup(t *testing.T, scenario *ClassroomData) (string, *TestSession, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"name":          scenario.SessionName,
+		"instructor_id": scenario.InstructorIDs[0],
+		"student_ids":   scenario.StudentIDs,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	resp, err := e.client.Post(e.serverURL+"/api/sessions", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create session on %s: %w", e.serverURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return "", nil, fmt.Errorf("failed to create session on %s: status %d", e.serverURL, resp.StatusCode)
+	}
+
+	var created struct {
+		Session *types.Session `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil || created.Session == nil {
+		return "", nil, fmt.Errorf("invalid create session response: %v", err)
+	}
+
+	sessionID := created.Session.ID
+	testSession := &TestSession{
+		SessionID: sessionID,
+		Session:   created.Session,
+		cleanup: func() error {
+			return e.endSession(sessionID)
+		},
+	}
+
+	// Register cleanup with testing framework
+	t.Cleanup(func() {
+		if err := testSession.CleanupAll(); err != nil {
+			t.Errorf("Test cleanup failed: %v", err)
+		}
+	})
+
+	return e.serverURL, testSession, nil
+}
+
+// endSession ends the scenario's session so staging servers do not accumulate them
+func (e *externalEnvironment) endSession(sessionID string) error {
+	req, err := http.NewRequest(http.MethodDelete, e.serverURL+"/api/sessions/"+sessionID, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to end session %s: %w", sessionID, err)
+	}
+	resp.Body.Close()
+
+	// Already ended counts as cleaned up - cleanup may run more than once
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
+		return fmt.Errorf("failed to end session %s: status %d", sessionID, resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *externalEnvironment) Supports(capability Capability) bool {
+	return false
+}
+
+func (e *externalEnvironment) Teardown() {}
+
+// FetchCapabilities reads the server's advertised capabilities from GET /api/capabilities
+func FetchCapabilities(serverURL string) (*types.Capabilities, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(serverURL, "/") + "/api/capabilities")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch capabilities from %s: %w", serverURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch capabilities from %s: status %d", serverURL, resp.StatusCode)
+	}
+
+	var capabilities types.Capabilities
+	if err := json.NewDecoder(resp.Body).Decode(&capabilities); err != nil {
+		return nil, fmt.Errorf("invalid capabilities response: %w", err)
+	}
+	return &capabilities, nil
+}
+
+// TransferSession asks the server to hand a session to newOwner via POST /api/sessions/{id}/transfer
+func TransferSession(serverURL, sessionID, newOwner, requestedBy string) (*types.Session, error) {
+	body, err := json.Marshal(map[string]string{
+		"new_owner":    newOwner,
+		"requested_by": requestedBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(strings.TrimRight(serverURL, "/")+"/api/sessions/"+sessionID+"/transfer", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to transfer session %s: %w", sessionID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to transfer session %s: status %d", sessionID, resp.StatusCode)
+	}
+
+	var transferred struct {
+		Session *types.Session `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&transferred); err != nil || transferred.Session == nil {
+		return nil, fmt.Errorf("invalid transfer session response: %v", err)
+	}
+	return transferred.Session, nil
+}
+
+// LockSession locks or unlocks student sending through PATCH /api/sessions/{id}
+func LockSession(serverURL, sessionID string, locked bool) (*types.Session, error) {
+	body, err := json.Marshal(map[string]bool{"locked": locked})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, strings.TrimRight(serverURL, "/")+"/api/sessions/"+sessionID, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock session %s: %w", sessionID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to lock session %s: status %d", sessionID, resp.StatusCode)
+	}
+
+	var updated struct {
+		Session *types.Session `json:"session"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil || updated.Session == nil {
+		return nil, fmt.Errorf("invalid lock session response: %v", err)
+	}
+	return updated.Session, nil
+}
+
+// FetchAdmission reads the upgrade pacing state reported by GET /health
+func FetchAdmission(serverURL string) (*types.AdmissionStats, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(serverURL, "/") + "/health")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch health from %s: %w", serverURL, err)
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		Admission *types.AdmissionStats `json:"admission"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("invalid health response: %w", err)
+	}
+	if health.Admission == nil {
+		return nil, fmt.Errorf("health response has no admission details")
+	}
+	return health.Admission, nil
+}
+
+// EndSession ends a session through DELETE /api/sessions/{id}
+func EndSession(serverURL, sessionID string) error {
+	req, err := http.NewRequest(http.MethodDelete, strings.TrimRight(serverURL, "/")+"/api/sessions/"+sessionID, nil)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to end session %s: %w", sessionID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to end session %s: status %d", sessionID, resp.StatusCode)
+	}
+	return nil
+}
+
+// FetchSessionResources reads the per-session resource counts reported by GET /health
+func FetchSessionResources(serverURL string) (map[string]int, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(serverURL, "/") + "/health")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch health from %s: %w", serverURL, err)
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		SessionResources map[string]int `json:"session_resources"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return nil, fmt.Errorf("invalid health response: %w", err)
+	}
+	if health.SessionResources == nil {
+		return nil, fmt.Errorf("health response has no session resource counts")
+	}
+	return health.SessionResources, nil
+}
+
+// AnnotateMessage sets an instructor's tags and note through
+// PATCH /api/sessions/{id}/messages/{message_id}/annotations
+func AnnotateMessage(serverURL, sessionID, messageID, instructorID string, tags []string, note string) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"instructor_id": instructorID,
+		"tags":          tags,
+		"note":          note,
+	})
+	if err != nil {
+		return err
+	}
+
+	endpoint := strings.TrimRight(serverURL, "/") + "/api/sessions/" + sessionID + "/messages/" + messageID + "/annotations"
+	req, err := http.NewRequest(http.MethodPatch, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to annotate message %s: %w", messageID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to annotate message %s: status %d", messageID, resp.StatusCode)
+	}
+	return nil
+}
+
+// FetchHistory reads a session's annotated history through GET /api/sessions/{id}/messages,
+// keeping only messages tagged with tag when it is not empty
+func FetchHistory(serverURL, sessionID, instructorID, tag string) ([]*types.Message, error) {
+	query := url.Values{"instructor_id": {instructorID}}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(serverURL, "/") + "/api/sessions/" + sessionID + "/messages?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch history for %s: %w", sessionID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch history for %s: status %d", sessionID, resp.StatusCode)
+	}
+
+	var history struct {
+		Messages []*types.Message `json:"messages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
+		return nil, fmt.Errorf("invalid history response: %w", err)
+	}
+	return history.Messages, nil
+}
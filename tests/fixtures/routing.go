@@ -0,0 +1,143 @@
+package fixtures
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"switchboard/pkg/types"
+	"switchboard/internal/router"
+)
+
+// ContentFixtureID is the content key carrying a TestMessage's ID through routing
+// TECHNICAL DISCOVERY: The server assigns its own message IDs and never tells the
+// sender, so the fixture's ID travels in the content, which routing leaves untouched
+// unless a content allowlist is configured
+const ContentFixtureID = "fixture_id"
+
+// ExpectRouting gives every message an ID and the recipients the routing table names
+// ARCHITECTURAL DISCOVERY: Recipients come from router.RoutingTable, the same table
+// the capabilities document publishes, so expectations cannot drift from the router
+// FUNCTIONAL DISCOVERY: Recipients are the scenario's participants, whether or not
+// they end up connected; ValidateRouting only checks clients the runner knows
+func (p *MessagePattern) ExpectRouting(scenario *ClassroomData) {
+	routes := make(map[string]types.RouteSummary)
+	for _, route := range router.RoutingTable(nil) {
+		routes[route.Type] = route
+	}
+
+	for _, msg := range p.Messages {
+		if msg.ID == "" {
+			msg.ID = uuid.New().String()
+		}
+		switch routes[msg.Type].Recipients {
+		case "session_instructors":
+			msg.ExpectedRecipients = append([]string(nil), scenario.InstructorIDs...)
+		case "session_students":
+			msg.ExpectedRecipients = append([]string(nil), scenario.StudentIDs...)
+		case "to_user":
+			msg.ExpectedRecipients = []string{msg.ToUser}
+		default:
+			msg.ExpectedRecipients = nil // Unroutable types reach nobody
+		}
+	}
+}
+
+// FixtureIDOf returns the TestMessage ID a received message carries
+func FixtureIDOf(message *types.Message) (string, bool) {
+	id, ok := message.Content[ContentFixtureID].(string)
+	return id, ok && id != ""
+}
+
+// RoutingDiff lists how one client's deliveries differ from a pattern's expectations
+type RoutingDiff struct {
+	UserID     string
+	Missing    []string // IDs expected but never received
+	Unexpected []string // IDs received but not expected, once per extra delivery
+}
+
+// Empty reports whether the client received exactly what was expected
+func (d RoutingDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Unexpected) == 0
+}
+
+// DiffRouting compares the fixture-tagged messages each client received with pattern
+// FUNCTIONAL DISCOVERY: Untagged messages (system notices, history) are ignored, and
+// a message delivered twice to the same client is reported as unexpected
+func DiffRouting(pattern *MessagePattern, result *ScenarioResult) []RoutingDiff {
+	var diffs []RoutingDiff
+	for userID, clientResult := range result.ClientResults {
+		expected := make(map[string]int)
+		for _, msg := range pattern.Messages {
+			for _, recipient := range msg.ExpectedRecipients {
+				if recipient == userID {
+					expected[msg.ID]++
+				}
+			}
+		}
+
+		diff := RoutingDiff{UserID: userID}
+		for _, message := range clientResult.Received {
+			id, ok := FixtureIDOf(message)
+			if !ok {
+				continue
+			}
+			if expected[id] > 0 {
+				expected[id]--
+				continue
+			}
+			diff.Unexpected = append(diff.Unexpected, id)
+		}
+		for id, count := range expected {
+			for ; count > 0; count-- {
+				diff.Missing = append(diff.Missing, id)
+			}
+		}
+
+		if !diff.Empty() {
+			sort.Strings(diff.Missing)
+			sort.Strings(diff.Unexpected)
+			diffs = append(diffs, diff)
+		}
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].UserID < diffs[j].UserID })
+	return diffs
+}
+
+// ValidateRouting fails t for every client whose deliveries differ from pattern's expectations
+// TECHNICAL DISCOVERY: pattern must have been through ExpectRouting before it was executed
+func (sr *ScenarioRunner) ValidateRouting(t *testing.T, pattern *MessagePattern, result *ScenarioResult) {
+	t.Helper()
+
+	byID := make(map[string]*TestMessage, len(pattern.Messages))
+	for _, msg := range pattern.Messages {
+		if msg.ID == "" {
+			t.Fatalf("Pattern %q has messages without IDs; call ExpectRouting before executing it", pattern.Name)
+		}
+		byID[msg.ID] = msg
+	}
+
+	describe := func(ids []string) string {
+		described := make([]string, len(ids))
+		for i, id := range ids {
+			if msg, ok := byID[id]; ok {
+				described[i] = fmt.Sprintf("%s (%s/%s from %s)", id, msg.Type, msg.Context, msg.FromUser)
+			} else {
+				described[i] = id + " (not in this pattern)"
+			}
+		}
+		return strings.Join(described, ", ")
+	}
+
+	for _, diff := range DiffRouting(pattern, result) {
+		if len(diff.Missing) > 0 {
+			t.Errorf("%s missed %d messages: %s", diff.UserID, len(diff.Missing), describe(diff.Missing))
+		}
+		if len(diff.Unexpected) > 0 {
+			t.Errorf("%s received %d unexpected messages: %s", diff.UserID, len(diff.Unexpected), describe(diff.Unexpected))
+		}
+	}
+}
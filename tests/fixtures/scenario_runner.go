@@ -3,16 +3,12 @@ package fixtures
 import (
 	"context"
 	"fmt"
-	"net"
 	"net/http"
-	"os"
-	"path/filepath"
 	"sync"
 	"testing"
 	"time"
-	
-	"switchboard/internal/app"
-	"switchboard/internal/config"
+
+	"switchboard/pkg/types"
 )
 
 // ScenarioRunner orchestrates complex test scenarios with multiple clients
@@ -20,9 +16,8 @@ type ScenarioRunner struct {
 	ServerURL     string
 	TestSession   *TestSession
 	Clients       map[string]*TestClient
-	testApp       *app.Application
-	serverContext context.Context
-	serverCancel  context.CancelFunc
+	Strict        bool // Clients created afterwards connect in strict mode
+	env           Environment
 	
 	mu        sync.RWMutex
 	running   bool
@@ -42,115 +37,43 @@ type ScenarioResult struct {
 // ClientResult contains per-client test results
 type ClientResult struct {
 	UserID           string
+	Received         []*types.Message // Everything the client read during the pattern
 	MessagesReceived int
 	MessagesSent     int
 	ConnectionUptime time.Duration
 	Errors           []error
 }
 
-// NewScenarioRunner creates a new scenario runner with test session setup and starts a test server
+// NewScenarioRunner creates a new scenario runner with test session setup
+// FUNCTIONAL DISCOVERY: Runs against the server named by SWITCHBOARD_SCENARIO_SERVER_URL
+// when set, otherwise starts an embedded test server
 func NewScenarioRunner(t *testing.T, scenario *ClassroomData) (*ScenarioRunner, error) {
-	return NewScenarioRunnerWithServer(t, scenario)
+	return NewScenarioRunnerWithEnvironment(t, scenario, DefaultEnvironment())
 }
 
 // NewScenarioRunnerWithServer creates a scenario runner with an embedded test server
 func NewScenarioRunnerWithServer(t *testing.T, scenario *ClassroomData) (*ScenarioRunner, error) {
-	// Create test session with database cleanup
-	testSession := SetupCleanSession(t, scenario.SessionName, scenario.InstructorIDs[0], scenario.StudentIDs)
-	
-	// Find available port for test server with retry logic
-	port, err := findAvailablePortWithRetry()
-	if err != nil {
-		return nil, fmt.Errorf("failed to find available port: %w", err)
-	}
-	
-	// Find project root directory containing migrations
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get working directory: %w", err)
-	}
-	
-	// Navigate to project root (go up from tests/scenarios or tests/fixtures)
-	projectRoot := wd
-	for !fileExists(filepath.Join(projectRoot, "migrations")) && projectRoot != "/" {
-		projectRoot = filepath.Dir(projectRoot)
-	}
-	if projectRoot == "/" || !fileExists(filepath.Join(projectRoot, "migrations")) {
-		return nil, fmt.Errorf("could not find migrations directory from %s", wd)
-	}
-	
-	// Create test configuration with temporary database
-	cfg := &config.Config{
-		HTTP: &config.HTTPConfig{
-			Host:         "127.0.0.1",
-			Port:         port,
-			ReadTimeout:  10 * time.Second,
-			WriteTimeout: 10 * time.Second,
-		},
-		Database: &config.DatabaseConfig{
-			Path:    testSession.DatabasePath,
-			Timeout: 30 * time.Second,
-		},
-		WebSocket: &config.WebSocketConfig{
-			PingInterval: 30 * time.Second,
-			ReadTimeout:  60 * time.Second,
-			WriteTimeout: 10 * time.Second,
-			BufferSize:   100,
-		},
-	}
-	
-	// Create application instance with correct migrations path
-	// Temporarily override the migrations path to use absolute path
-	originalDir, _ := os.Getwd()
-	os.Chdir(projectRoot)
-	defer os.Chdir(originalDir)
-	
-	testApp, err := app.NewApplication(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create test application: %w", err)
-	}
-	
-	// Start server
-	serverCtx, serverCancel := context.WithCancel(context.Background())
-	go func() {
-		if err := testApp.Start(serverCtx); err != nil && err != context.Canceled {
-			t.Errorf("Test server failed to start: %v", err)
-		}
-	}()
-	
-	// Wait for server to be ready
-	serverURL := fmt.Sprintf("http://127.0.0.1:%d", port)
-	if err := waitForServer(serverURL, 5*time.Second); err != nil {
-		serverCancel()
-		return nil, fmt.Errorf("test server did not start: %w", err)
-	}
-	
-	runner := &ScenarioRunner{
-		ServerURL:     serverURL,
-		TestSession:   testSession,
-		Clients:       make(map[string]*TestClient),
-		testApp:       testApp,
-		serverContext: serverCtx,
-		serverCancel:  serverCancel,
-	}
-	
-	// Setup cleanup
-	t.Cleanup(func() {
-		runner.Cleanup()
-	})
-	
-	return runner, nil
+	return NewScenarioRunnerWithEnvironment(t, scenario, NewEmbeddedEnvironment())
 }
 
-// NewScenarioRunnerWithURL creates a scenario runner with custom server URL
+// NewScenarioRunnerWithURL creates a scenario runner against an already running server
 func NewScenarioRunnerWithURL(t *testing.T, scenario *ClassroomData, serverURL string) (*ScenarioRunner, error) {
-	// Create test session with database cleanup
-	testSession := SetupCleanSession(t, scenario.SessionName, scenario.InstructorIDs[0], scenario.StudentIDs)
+	return NewScenarioRunnerWithEnvironment(t, scenario, NewExternalEnvironment(serverURL))
+}
+
+// NewScenarioRunnerWithEnvironment creates a scenario runner on the given environment
+func NewScenarioRunnerWithEnvironment(t *testing.T, scenario *ClassroomData, env Environment) (*ScenarioRunner, error) {
+	serverURL, testSession, err := env.Setup(t, scenario)
+	if err != nil {
+		env.Teardown()
+		return nil, err
+	}
 	
 	runner := &ScenarioRunner{
 		ServerURL:   serverURL,
 		TestSession: testSession,
 		Clients:     make(map[string]*TestClient),
+		env:         env,
 	}
 	
 	// Setup cleanup
@@ -161,6 +84,13 @@ func NewScenarioRunnerWithURL(t *testing.T, scenario *ClassroomData, serverURL s
 	return runner, nil
 }
 
+// Supports reports whether the runner's environment provides a capability
+// TECHNICAL DISCOVERY: Scenarios gate database assertions on this so they still
+// run against external servers, where only the wire protocol is observable
+func (sr *ScenarioRunner) Supports(capability Capability) bool {
+	return sr.env != nil && sr.env.Supports(capability)
+}
+
 // CreateClient creates and registers a new test client
 func (sr *ScenarioRunner) CreateClient(userID, role string) (*TestClient, error) {
 	sr.mu.Lock()
@@ -171,6 +101,7 @@ func (sr *ScenarioRunner) CreateClient(userID, role string) (*TestClient, error)
 	}
 	
 	client := NewTestClient(userID, role, sr.TestSession.SessionID, sr.ServerURL)
+	client.Strict = sr.Strict
 	sr.Clients[userID] = client
 	
 	return client, nil
@@ -275,7 +206,7 @@ func (sr *ScenarioRunner) ExecuteMessagePattern(pattern *MessagePattern) (*Scena
 			}
 			
 			// Send the message
-			err := client.SendMessage(testMsg.Type, testMsg.Context, testMsg.Content, testMsg.ToUser)
+			err := client.SendTestMessage(testMsg)
 			if err != nil {
 				messageErrors <- fmt.Errorf("failed to send message from %s: %w", testMsg.FromUser, err)
 				return
@@ -302,6 +233,7 @@ func (sr *ScenarioRunner) ExecuteMessagePattern(pattern *MessagePattern) (*Scena
 	for userID, client := range sr.Clients {
 		receivedMessages := client.GetReceivedMessages()
 		result.MessagesReceived += len(receivedMessages)
+		result.ClientResults[userID].Received = receivedMessages
 		result.ClientResults[userID].MessagesReceived = len(receivedMessages)
 		
 		// Collect any client errors
@@ -326,44 +258,8 @@ func (sr *ScenarioRunner) ValidateMessageDelivery(t *testing.T, pattern *Message
 		t.Error("No messages were received")
 	}
 	
-	// More sophisticated validation could check:
-	// - Message routing correctness (broadcasts vs direct messages)
-	// - Message ordering within sessions
-	// - Role-based permission enforcement
-	// - Context preservation
-	
-	sr.mu.RLock()
-	defer sr.mu.RUnlock()
-	
-	// Validate each message type was handled correctly
-	messageTypesSent := make(map[string]int)
-	for _, msg := range pattern.Messages {
-		messageTypesSent[msg.Type]++
-	}
-	
-	// Check that instructor broadcasts reached all students
-	broadcasts := messageTypesSent["instructor_broadcast"]
-	if broadcasts > 0 {
-		studentCount := 0
-		for userID := range sr.Clients {
-			// Check if this is a student (not in instructor list)
-			isInstructor := false
-			// This is a simplified check - in real implementation would check against session data
-			if len(userID) > 10 && userID[:10] == "instructor" {
-				isInstructor = true
-			}
-			if !isInstructor {
-				studentCount++
-			}
-		}
-		
-		// Each broadcast should reach each student
-		expectedBroadcastMessages := broadcasts * studentCount
-		if result.MessagesReceived < expectedBroadcastMessages {
-			t.Errorf("Broadcast delivery incomplete: expected at least %d messages, got %d", 
-				expectedBroadcastMessages, result.MessagesReceived)
-		}
-	}
+	// Each client received exactly the messages the routing table sends it
+	sr.ValidateRouting(t, pattern, result)
 }
 
 // WaitForStableState waits for message processing to complete
@@ -481,6 +377,7 @@ func (sr *ScenarioRunner) ReconnectClient(ctx context.Context, userID string) er
 	
 	// Create new client with same parameters
 	newClient := NewTestClient(client.UserID, client.Role, client.SessionID, client.ServerURL)
+	newClient.Strict = client.Strict
 	
 	sr.mu.Lock()
 	sr.Clients[userID] = newClient
@@ -509,15 +406,8 @@ func (sr *ScenarioRunner) Cleanup() {
 	time.Sleep(100 * time.Millisecond)
 	
 	// Stop test server if running
-	if sr.serverCancel != nil {
-		sr.serverCancel()
-	}
-	
-	// Stop application with extended timeout for complex tests
-	if sr.testApp != nil {
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		sr.testApp.Stop(shutdownCtx)
+	if sr.env != nil {
+		sr.env.Teardown()
 	}
 	
 	// Additional wait to ensure all goroutines are cleaned up
@@ -529,54 +419,6 @@ func (sr *ScenarioRunner) Cleanup() {
 	}
 }
 
-// waitForServer waits for the test server to become available
-func waitForServer(serverURL string, timeout time.Duration) error {
-	client := &http.Client{Timeout: 1 * time.Second}
-	deadline := time.Now().Add(timeout)
-	
-	for time.Now().Before(deadline) {
-		resp, err := client.Get(serverURL + "/health")
-		if err == nil {
-			resp.Body.Close()
-			if resp.StatusCode == http.StatusOK {
-				return nil
-			}
-		}
-		time.Sleep(100 * time.Millisecond)
-	}
-	
-	return fmt.Errorf("server did not become available within %v", timeout)
-}
-
-// fileExists checks if a file or directory exists
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
-	return err == nil
-}
-
-// findAvailablePortWithRetry finds an available port with retry logic to avoid race conditions
-func findAvailablePortWithRetry() (int, error) {
-	for attempts := 0; attempts < 10; attempts++ {
-		listener, err := net.Listen("tcp", ":0")
-		if err != nil {
-			continue
-		}
-		port := listener.Addr().(*net.TCPAddr).Port
-		listener.Close()
-		
-		// Add small delay and verify port is still available
-		time.Sleep(time.Duration(attempts*10) * time.Millisecond)
-		
-		// Test if port is actually available by trying to bind to it
-		testListener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
-		if err == nil {
-			testListener.Close()
-			return port, nil
-		}
-	}
-	return 0, fmt.Errorf("failed to find available port after 10 attempts")
-}
-
 // GetClient returns a client by user ID
 func (sr *ScenarioRunner) GetClient(userID string) (*TestClient, bool) {
 	sr.mu.RLock()
@@ -604,7 +446,10 @@ func (sr *ScenarioRunner) ValidateSessionIsolation(t *testing.T) {
 	// This would be implemented to validate that messages in this session
 	// don't appear in other concurrent sessions
 	// For now, it's a placeholder for session isolation testing
-	
+	if !sr.Supports(CapabilityDatabaseAccess) {
+		return
+	}
+
 	messageCount, err := sr.TestSession.GetMessageCount()
 	if err != nil {
 		t.Errorf("Failed to get message count: %v", err)
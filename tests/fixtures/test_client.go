@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
+	"strconv"
 	"sync"
 	"time"
 
@@ -12,6 +14,7 @@ import (
 	"switchboard/pkg/types"
 	"switchboard/pkg/interfaces"
 	wsConnection "switchboard/internal/websocket"
+	"switchboard/internal/retry"
 )
 
 // TestClient represents a WebSocket client for testing
@@ -21,6 +24,10 @@ type TestClient struct {
 	SessionID string
 	ServerURL string
 	
+	// Strict connects with strict=true, so the server refuses frames with unknown
+	// fields; the refusal surfaces as an error from the receive methods
+	Strict bool
+	
 	conn     interfaces.Connection  // Use production Connection interface
 	rawConn  *websocket.Conn       // Keep for reading (like server does)
 	messages chan *types.Message
@@ -72,11 +79,25 @@ func (tc *TestClient) Connect(ctx context.Context) error {
 	query.Set("user_id", tc.UserID)
 	query.Set("role", tc.Role)
 	query.Set("session_id", tc.SessionID)
+	if tc.Strict {
+		query.Set("strict", "true")
+	}
 	u.RawQuery = query.Encode()
 	
-	// Establish WebSocket connection
+	// Establish WebSocket connection, backing off while the server paces admissions
 	dialer := websocket.DefaultDialer
-	rawConn, _, err := dialer.DialContext(ctx, u.String(), nil)
+	var rawConn *websocket.Conn
+	err = admissionRetry.Do(ctx, func(ctx context.Context) error {
+		conn, resp, err := dialer.DialContext(ctx, u.String(), nil)
+		if err != nil {
+			if retryAfter, paced := admissionRetryAfter(resp); paced {
+				return retry.After(retryAfter, err)
+			}
+			return err
+		}
+		rawConn = conn
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
@@ -99,6 +120,27 @@ func (tc *TestClient) Connect(ctx context.Context) error {
 	return nil
 }
 
+// admissionRetry retries a dial only when the server paced it with a 503 Retry-After,
+// waiting as long as asked plus up to a fifth more, at most 10 times
+var admissionRetry = retry.Policy{
+	MaxAttempts: 11,
+	Jitter:      0.2,
+	Retryable:   retry.Requested,
+}
+
+// admissionRetryAfter reports the Retry-After delay when the server turned the
+// upgrade away while pacing admissions after startup
+func admissionRetryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
+
 // readLoop continuously reads messages from the WebSocket connection
 func (tc *TestClient) readLoop() {
 	defer func() {
@@ -161,6 +203,15 @@ func (tc *TestClient) readLoop() {
 				continue
 			}
 			
+			// Our own frames must be accepted by strict connections
+			if message.Type == "system" && message.Content["code"] == types.ErrorCodeUnknownField {
+				select {
+				case tc.errors <- fmt.Errorf("server refused frame: unknown field %v", message.Content["field"]):
+				default:
+				}
+				continue
+			}
+			
 			// Send message to channel (non-blocking)
 			select {
 			case tc.messages <- &message:
@@ -207,6 +258,19 @@ func (tc *TestClient) SendMessage(msgType, context string, content map[string]in
 	return nil
 }
 
+// SendTestMessage sends msg with its ID in the content, so deliveries can be matched to it
+// TECHNICAL DISCOVERY: The ID is added to a copy; msg.Content may be shared
+func (tc *TestClient) SendTestMessage(msg *TestMessage) error {
+	content := make(map[string]interface{}, len(msg.Content)+1)
+	for key, value := range msg.Content {
+		content[key] = value
+	}
+	if msg.ID != "" {
+		content[ContentFixtureID] = msg.ID
+	}
+	return tc.SendMessage(msg.Type, msg.Context, content, msg.ToUser)
+}
+
 // ReceiveMessage waits for a message with timeout
 func (tc *TestClient) ReceiveMessage(timeout time.Duration) (*types.Message, error) {
 	select {
@@ -108,7 +108,12 @@ func (ts *TestSession) CleanupAll() error {
 }
 
 // GetMessageCount returns the number of messages in the test session
+// FUNCTIONAL DISCOVERY: Sessions created against an external server have no
+// database handle, so this reports ErrCapabilityUnavailable instead of panicking
 func (ts *TestSession) GetMessageCount() (int, error) {
+	if ts.DbManager == nil {
+		return 0, ErrCapabilityUnavailable
+	}
 	ctx := context.Background()
 	messages, err := ts.DbManager.GetSessionHistory(ctx, ts.SessionID)
 	if err != nil {
@@ -45,6 +45,9 @@ func TestCompleteQASession(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to create scenario runner: %v", err)
 	}
+	// FUNCTIONAL DISCOVERY: Run in strict mode so a frame our own clients send with a
+	// field the protocol does not define fails this scenario instead of being ignored
+	runner.Strict = true
 	
 	// Create instructor client
 	instructorClient, err := runner.CreateClient(scenario.InstructorIDs[0], "instructor")
@@ -236,14 +239,16 @@ func TestCompleteQASession(t *testing.T) {
 	}
 	
 	// Validate message persistence
-	messageCount, err := runner.TestSession.GetMessageCount()
-	if err != nil {
-		t.Errorf("Failed to get message count: %v", err)
-	} else {
-		// Expected: 1 broadcast + 6 student responses + 3 instructor responses = 10 messages
-		expectedCount := 10
-		if messageCount != expectedCount {
-			t.Errorf("Message persistence failed: expected %d messages, got %d", expectedCount, messageCount)
+	if runner.Supports(fixtures.CapabilityDatabaseAccess) {
+		messageCount, err := runner.TestSession.GetMessageCount()
+		if err != nil {
+			t.Errorf("Failed to get message count: %v", err)
+		} else {
+			// Expected: 1 broadcast + 6 student responses + 3 instructor responses = 10 messages
+			expectedCount := 10
+			if messageCount != expectedCount {
+				t.Errorf("Message persistence failed: expected %d messages, got %d", expectedCount, messageCount)
+			}
 		}
 	}
 	
@@ -502,14 +507,16 @@ func TestCodeReviewSession(t *testing.T) {
 	}
 	
 	// Validate complete workflow message count
-	messageCount, err := runner.TestSession.GetMessageCount()
-	if err != nil {
-		t.Errorf("Failed to get message count: %v", err)
-	} else {
-		// Expected: 3 requests + 3 submissions + 3 feedback = 9 messages
-		expectedCount := 9
-		if messageCount != expectedCount {
-			t.Errorf("Message persistence failed: expected %d messages, got %d", expectedCount, messageCount)
+	if runner.Supports(fixtures.CapabilityDatabaseAccess) {
+		messageCount, err := runner.TestSession.GetMessageCount()
+		if err != nil {
+			t.Errorf("Failed to get message count: %v", err)
+		} else {
+			// Expected: 3 requests + 3 submissions + 3 feedback = 9 messages
+			expectedCount := 9
+			if messageCount != expectedCount {
+				t.Errorf("Message persistence failed: expected %d messages, got %d", expectedCount, messageCount)
+			}
 		}
 	}
 	
@@ -754,14 +761,16 @@ func TestRealTimeAnalytics(t *testing.T) {
 	}
 	
 	// Validate comprehensive analytics message flow
-	messageCount, err := runner.TestSession.GetMessageCount()
-	if err != nil {
-		t.Errorf("Failed to get message count: %v", err)
-	} else {
-		// Expected: 10 engagement + 7 progress + 3 errors + 10 performance = 30 messages
-		expectedCount := 30
-		if messageCount != expectedCount {
-			t.Errorf("Analytics message count mismatch: expected %d, got %d", expectedCount, messageCount)
+	if runner.Supports(fixtures.CapabilityDatabaseAccess) {
+		messageCount, err := runner.TestSession.GetMessageCount()
+		if err != nil {
+			t.Errorf("Failed to get message count: %v", err)
+		} else {
+			// Expected: 10 engagement + 7 progress + 3 errors + 10 performance = 30 messages
+			expectedCount := 30
+			if messageCount != expectedCount {
+				t.Errorf("Analytics message count mismatch: expected %d, got %d", expectedCount, messageCount)
+			}
 		}
 	}
 	
@@ -811,39 +820,9 @@ func TestMultiContextCommunication(t *testing.T) {
 	// Generate multi-context communication pattern
 	pattern := fixtures.GenerateMultiContextFlow(scenario)
 	
-	// Track expected message routing for validation
-	expectedInstructorMessages := make(map[string]int) // instructor_id -> expected message count
-	expectedStudentMessages := make(map[string]int)    // student_id -> expected message count
-	
-	// Initialize counters
-	for _, instructorID := range scenario.InstructorIDs {
-		expectedInstructorMessages[instructorID] = 0
-	}
-	for _, studentID := range scenario.StudentIDs {
-		expectedStudentMessages[studentID] = 0
-	}
-	
-	// Calculate expected message counts based on routing rules
-	for _, msg := range pattern.Messages {
-		switch msg.Type {
-		case "instructor_broadcast":
-			// Should reach all students
-			for _, studentID := range scenario.StudentIDs {
-				expectedStudentMessages[studentID]++
-			}
-		
-		case "instructor_inbox", "request_response", "analytics":
-			// Should reach all instructors
-			for _, instructorID := range scenario.InstructorIDs {
-				expectedInstructorMessages[instructorID]++
-			}
-		
-		case "inbox_response", "request":
-			// Should reach specific student (to_user)
-			if msg.ToUser != "" {
-				expectedStudentMessages[msg.ToUser]++
-			}
-		}
+	// Every message carries an ID and the recipients the routing table names
+	if len(pattern.Messages) == 0 || pattern.Messages[0].ID == "" {
+		t.Fatal("Expected the generated pattern to declare its routing")
 	}
 	
 	// Execute the complex message pattern
@@ -879,17 +858,17 @@ func TestMultiContextCommunication(t *testing.T) {
 		t.Logf("Total messages received across all clients: %d", totalMessagesReceived)
 	}
 	
-	// Check that the result indicates successful delivery 
-	if result.Success && totalMessagesReceived > 0 {
-		t.Logf("Multi-context communication succeeded - messages were delivered")
-	}
+	// Each client received exactly the messages routed to it, no more and no fewer
+	runner.ValidateRouting(t, pattern, result)
 	
 	// Validate database persistence for complex flow
-	messageCount, err := runner.TestSession.GetMessageCount()
-	if err != nil {
-		t.Errorf("Failed to get message count: %v", err)
-	} else {
-		t.Logf("Database stored %d messages from pattern of %d", messageCount, len(pattern.Messages))
+	if runner.Supports(fixtures.CapabilityDatabaseAccess) {
+		messageCount, err := runner.TestSession.GetMessageCount()
+		if err != nil {
+			t.Errorf("Failed to get message count: %v", err)
+		} else {
+			t.Logf("Database stored %d messages from pattern of %d", messageCount, len(pattern.Messages))
+		}
 	}
 	
 	// Final validation: no resource leaks or session contamination
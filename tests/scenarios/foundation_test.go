@@ -3,9 +3,11 @@ package scenarios
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"switchboard/internal/config"
 	"switchboard/tests/fixtures"
 	"switchboard/pkg/types"
 )
@@ -18,6 +20,12 @@ func TestFoundation(t *testing.T) {
 	t.Run("SingleConnectionScenarios", TestSingleConnectionScenarios)
 	t.Run("RoleBasedPermissions", TestRoleBasedPermissions)
 	t.Run("ContextFieldHandling", TestContextFieldHandling)
+	t.Run("ServerCapabilities", TestServerCapabilities)
+	t.Run("SessionOwnerHandoff", TestSessionOwnerHandoff)
+	t.Run("WarmStandbyFailover", TestWarmStandbyFailover)
+	t.Run("LockTheRoom", TestLockTheRoom)
+	t.Run("PacedReconnectWave", TestPacedReconnectWave)
+	t.Run("StarQuestions", TestStarQuestions)
 }
 
 // TestDatabaseIntegration validates clean database operations and schema
@@ -415,4 +423,443 @@ func TestContextFieldHandling(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+// TestServerCapabilities validates that advertised capabilities match configured features
+func TestServerCapabilities(t *testing.T) {
+	scenario := fixtures.GenerateClassroomScenario(1, 1)
+	
+	env := fixtures.NewEmbeddedEnvironmentWithConfig(func(cfg *config.Config) {
+		cfg.Transcripts = &config.TranscriptsConfig{
+			Dir:           t.TempDir(),
+			FlushInterval: time.Second,
+			MaxFileBytes:  1024 * 1024,
+		}
+		cfg.Analytics = &config.AnalyticsConfig{
+			Sink:          "noop",
+			FlushInterval: time.Second,
+			QueueSize:     100,
+		}
+	})
+	runner, err := fixtures.NewScenarioRunnerWithEnvironment(t, scenario, env)
+	if err != nil {
+		t.Fatalf("Failed to create scenario runner: %v", err)
+	}
+	
+	capabilities, err := fixtures.FetchCapabilities(runner.ServerURL)
+	if err != nil {
+		t.Fatalf("Failed to fetch capabilities: %v", err)
+	}
+	
+	expected := map[string]bool{
+		types.FeatureTranscripts:         true,
+		types.FeatureAnalyticsExport:     true,
+		types.FeaturePersistenceWatchdog: false,
+	}
+	for feature, enabled := range expected {
+		if capabilities.Features[feature] != enabled {
+			t.Errorf("Feature %s: expected %v, got %v", feature, enabled, capabilities.Features[feature])
+		}
+	}
+	if len(capabilities.Routing) == 0 {
+		t.Error("Capabilities should include the routing table")
+	}
+	
+	// The connected handshake should advertise the same enabled features
+	studentClient, _ := runner.CreateClient(scenario.StudentIDs[0], "student")
+	if err := runner.ConnectAllClients(context.Background()); err != nil {
+		t.Fatalf("Failed to connect clients: %v", err)
+	}
+	
+	connected, err := studentClient.ReceiveMessageOfType("system", 3*time.Second)
+	if err != nil {
+		t.Fatalf("Student did not receive connected message: %v", err)
+	}
+	if connected.Content["event"] != "connected" {
+		t.Fatalf("First system message should be the connected handshake, got %v", connected.Content)
+	}
+	
+	handshake, _ := connected.Content["capabilities"].(map[string]interface{})
+	features, _ := handshake["features"].([]interface{})
+	advertised := make(map[string]bool, len(features))
+	for _, feature := range features {
+		advertised[fmt.Sprint(feature)] = true
+	}
+	for name, enabled := range capabilities.Features {
+		if advertised[name] != enabled {
+			t.Errorf("Handshake feature %s: expected %v, got %v", name, enabled, advertised[name])
+		}
+	}
+}
+
+// TestSessionOwnerHandoff validates a co-instructor taking over from an absent owner
+func TestSessionOwnerHandoff(t *testing.T) {
+	scenario := fixtures.GenerateClassroomScenario(2, 1)
+	owner, coInstructor := scenario.InstructorIDs[0], scenario.InstructorIDs[1]
+	
+	// No grace period, so the owner counts as absent as soon as they are not connected
+	env := fixtures.NewEmbeddedEnvironmentWithConfig(func(cfg *config.Config) {
+		cfg.Sessions = config.DefaultConfig().Sessions
+		cfg.Sessions.OwnerTransferGrace = 0
+	})
+	runner, err := fixtures.NewScenarioRunnerWithEnvironment(t, scenario, env)
+	if err != nil {
+		t.Fatalf("Failed to create scenario runner: %v", err)
+	}
+	
+	// Only the co-instructor and a student connect; the owner's laptop is gone
+	coClient, _ := runner.CreateClient(coInstructor, "instructor")
+	studentClient, _ := runner.CreateClient(scenario.StudentIDs[0], "student")
+	if err := runner.ConnectAllClients(context.Background()); err != nil {
+		t.Fatalf("Failed to connect clients: %v", err)
+	}
+	// The handshake is sent after registration, so the server now sees the co-instructor
+	receiveSystemEvent(t, coClient, "connected", 3*time.Second)
+	
+	transferred, err := fixtures.TransferSession(runner.ServerURL, runner.TestSession.SessionID, coInstructor, coInstructor)
+	if err != nil {
+		t.Fatalf("Co-instructor transfer failed: %v", err)
+	}
+	if transferred.Owner() != coInstructor || transferred.CreatedBy != owner {
+		t.Errorf("Expected owner %s with creator %s kept, got owner=%s created_by=%s", coInstructor, owner, transferred.Owner(), transferred.CreatedBy)
+	}
+	
+	// Connected instructors are told about the new owner
+	notice := receiveSystemEvent(t, coClient, "owner_transferred", 3*time.Second)
+	if notice.Content["previous_owner"] != owner || notice.Content["new_owner"] != coInstructor {
+		t.Errorf("Unexpected transfer notice: %v", notice.Content)
+	}
+	
+	// Students are not
+	if message, err := studentClient.ReceiveMessageOfType("system", 500*time.Millisecond); err == nil && message.Content["event"] == "owner_transferred" {
+		t.Error("Students should not receive ownership transfer notices")
+	}
+}
+
+// TestWarmStandbyFailover validates a standby taking over from a primary's snapshot
+// FUNCTIONAL DISCOVERY: The standby starts on its own empty database, so the session
+// can only exist there because the snapshot carried it across
+func TestWarmStandbyFailover(t *testing.T) {
+	scenario := fixtures.GenerateClassroomScenario(1, 1)
+	instructorID, studentID := scenario.InstructorIDs[0], scenario.StudentIDs[0]
+	snapshotPath := filepath.Join(t.TempDir(), "state.json")
+	
+	primary := fixtures.NewEmbeddedEnvironmentWithConfig(func(cfg *config.Config) {
+		cfg.Snapshot = &config.SnapshotConfig{Path: snapshotPath, Interval: time.Hour}
+	})
+	runner, err := fixtures.NewScenarioRunnerWithEnvironment(t, scenario, primary)
+	if err != nil {
+		t.Fatalf("Failed to create scenario runner: %v", err)
+	}
+	sessionID := runner.TestSession.SessionID
+	
+	studentClient, _ := runner.CreateClient(studentID, "student")
+	if err := runner.ConnectAllClients(context.Background()); err != nil {
+		t.Fatalf("Failed to connect clients: %v", err)
+	}
+	receiveSystemEvent(t, studentClient, "connected", 3*time.Second)
+	
+	// Stopping the primary writes the final snapshot; the hourly tick never fires
+	runner.Cleanup()
+	
+	standby := fixtures.NewEmbeddedEnvironmentWithConfig(func(cfg *config.Config) {
+		cfg.Snapshot = &config.SnapshotConfig{RestoreFrom: snapshotPath}
+	})
+	standbyURL, standbySession, err := standby.Setup(t, scenario)
+	if err != nil {
+		t.Fatalf("Standby failed to start from snapshot: %v", err)
+	}
+	defer standbySession.CleanupAll()
+	defer standby.Teardown()
+	
+	// Clients reconnect to the standby with the session ID they already had
+	instructor := fixtures.NewTestClient(instructorID, "instructor", sessionID, standbyURL)
+	student := fixtures.NewTestClient(studentID, "student", sessionID, standbyURL)
+	for _, client := range []*fixtures.TestClient{instructor, student} {
+		if err := client.Connect(context.Background()); err != nil {
+			t.Fatalf("Reconnect to standby failed: %v", err)
+		}
+		defer client.Close()
+		receiveSystemEvent(t, client, "connected", 3*time.Second)
+	}
+	
+	if err := student.SendMessage("instructor_inbox", "question", map[string]interface{}{"text": "still there?"}, ""); err != nil {
+		t.Fatalf("Failed to send after failover: %v", err)
+	}
+	if _, err := instructor.WaitForMessageFrom(studentID, 3*time.Second); err != nil {
+		t.Errorf("Instructor did not receive the student's message after failover: %v", err)
+	}
+}
+
+// TestLockTheRoom validates instructors pausing student sending without ending the session
+func TestLockTheRoom(t *testing.T) {
+	scenario := fixtures.GenerateClassroomScenario(1, 1)
+	instructorID, studentID := scenario.InstructorIDs[0], scenario.StudentIDs[0]
+	
+	runner, err := fixtures.NewScenarioRunnerWithEnvironment(t, scenario, fixtures.NewEmbeddedEnvironment())
+	if err != nil {
+		t.Fatalf("Failed to create scenario runner: %v", err)
+	}
+	defer runner.Cleanup()
+	
+	instructorClient, _ := runner.CreateClient(instructorID, "instructor")
+	studentClient, _ := runner.CreateClient(studentID, "student")
+	if err := runner.ConnectAllClients(context.Background()); err != nil {
+		t.Fatalf("Failed to connect clients: %v", err)
+	}
+	receiveSystemEvent(t, instructorClient, "connected", 3*time.Second)
+	receiveSystemEvent(t, studentClient, "connected", 3*time.Second)
+	
+	sessionID := runner.TestSession.SessionID
+	if _, err := fixtures.LockSession(runner.ServerURL, sessionID, true); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	receiveSystemEvent(t, studentClient, "session_locked", 3*time.Second)
+	receiveSystemEvent(t, instructorClient, "session_locked", 3*time.Second)
+	
+	// The student is refused with a code their client can act on
+	studentClient.SendMessage("instructor_inbox", "question", map[string]interface{}{"text": "sent while locked"}, "")
+	refusal := receiveSystemEvent(t, studentClient, "message_error", 3*time.Second)
+	if refusal.Content["code"] != types.ErrorCodeSessionLocked {
+		t.Errorf("Expected code %s, got %v", types.ErrorCodeSessionLocked, refusal.Content)
+	}
+	
+	// Instructors can still broadcast
+	instructorClient.SendMessage("instructor_broadcast", "announcement", map[string]interface{}{"text": "10 minutes left"}, "")
+	if _, err := studentClient.WaitForMessageFrom(instructorID, 3*time.Second); err != nil {
+		t.Errorf("Student should still receive broadcasts while locked: %v", err)
+	}
+	
+	if _, err := fixtures.LockSession(runner.ServerURL, sessionID, false); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	receiveSystemEvent(t, studentClient, "session_unlocked", 3*time.Second)
+	
+	// Unlocking flushes nothing - only the message sent afterwards arrives
+	studentClient.SendMessage("instructor_inbox", "question", map[string]interface{}{"text": "sent after unlock"}, "")
+	message, err := instructorClient.WaitForMessageFrom(studentID, 3*time.Second)
+	if err != nil {
+		t.Fatalf("Instructor did not receive the message sent after unlock: %v", err)
+	}
+	if message.Content["text"] != "sent after unlock" {
+		t.Errorf("Expected only the post-unlock message, got %v", message.Content["text"])
+	}
+}
+
+// TestPacedReconnectWave validates admission pacing right after startup
+// FUNCTIONAL DISCOVERY: The whole class connects at once; clients over the rate are
+// turned away with Retry-After and still all get in by honoring it
+func TestPacedReconnectWave(t *testing.T) {
+	scenario := fixtures.GenerateClassroomScenario(1, 8)
+	
+	env := fixtures.NewEmbeddedEnvironmentWithConfig(func(cfg *config.Config) {
+		cfg.WebSocket.AdmissionRate = 2
+		cfg.WebSocket.AdmissionWindow = 3 * time.Second
+	})
+	runner, err := fixtures.NewScenarioRunnerWithEnvironment(t, scenario, env)
+	if err != nil {
+		t.Fatalf("Failed to create scenario runner: %v", err)
+	}
+	defer runner.Cleanup()
+	
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	
+	errs := make(chan error, len(scenario.StudentIDs))
+	for _, studentID := range scenario.StudentIDs {
+		client, _ := runner.CreateClient(studentID, "student")
+		go func() { errs <- client.Connect(ctx) }()
+	}
+	for range scenario.StudentIDs {
+		if err := <-errs; err != nil {
+			t.Errorf("Student failed to connect through pacing: %v", err)
+		}
+	}
+	
+	admission, err := fixtures.FetchAdmission(runner.ServerURL)
+	if err != nil {
+		t.Fatalf("Failed to read admission state: %v", err)
+	}
+	if admission.Rate != 2 || admission.Rejected == 0 {
+		t.Errorf("Expected some upgrades paced at 2/sec, got %+v", admission)
+	}
+}
+
+// TestStarQuestions validates instructors starring questions to revisit after class
+// FUNCTIONAL DISCOVERY: The student who asked never hears about the star, while a
+// reconnecting instructor sees it inline in history replay
+func TestStarQuestions(t *testing.T) {
+	scenario := fixtures.GenerateClassroomScenario(1, 1)
+	instructorID, studentID := scenario.InstructorIDs[0], scenario.StudentIDs[0]
+	
+	runner, err := fixtures.NewScenarioRunnerWithEnvironment(t, scenario, fixtures.NewEmbeddedEnvironment())
+	if err != nil {
+		t.Fatalf("Failed to create scenario runner: %v", err)
+	}
+	defer runner.Cleanup()
+	
+	instructorClient, _ := runner.CreateClient(instructorID, "instructor")
+	studentClient, _ := runner.CreateClient(studentID, "student")
+	if err := runner.ConnectAllClients(context.Background()); err != nil {
+		t.Fatalf("Failed to connect clients: %v", err)
+	}
+	receiveSystemEvent(t, instructorClient, "connected", 3*time.Second)
+	receiveSystemEvent(t, studentClient, "connected", 3*time.Second)
+	
+	studentClient.SendMessage("instructor_inbox", "question", map[string]interface{}{"text": "why does the loop stop early?"}, "")
+	studentClient.SendMessage("instructor_inbox", "question", map[string]interface{}{"text": "can I leave early?"}, "")
+	good, err := instructorClient.WaitForMessageFrom(studentID, 3*time.Second)
+	if err != nil {
+		t.Fatalf("Instructor did not receive the question: %v", err)
+	}
+	if _, err := instructorClient.WaitForMessageFrom(studentID, 3*time.Second); err != nil {
+		t.Fatalf("Instructor did not receive the second question: %v", err)
+	}
+	
+	sessionID := runner.TestSession.SessionID
+	if err := fixtures.AnnotateMessage(runner.ServerURL, sessionID, good.ID, instructorID, []string{"star"}, "revisit Monday"); err != nil {
+		t.Fatalf("Annotation failed: %v", err)
+	}
+	
+	starred, err := fixtures.FetchHistory(runner.ServerURL, sessionID, instructorID, "star")
+	if err != nil {
+		t.Fatalf("Failed to fetch starred history: %v", err)
+	}
+	if len(starred) != 1 || starred[0].ID != good.ID || len(starred[0].Annotations) != 1 {
+		t.Fatalf("Expected only the starred question with its annotation, got %+v", starred)
+	}
+	if _, err := fixtures.FetchHistory(runner.ServerURL, sessionID, studentID, ""); err == nil {
+		t.Error("Students should not be able to read annotated history")
+	}
+	
+	// A reconnecting instructor sees the star inline in history replay
+	rejoined, _ := runner.CreateClient("rejoining_instructor", "instructor")
+	if err := rejoined.Connect(context.Background()); err != nil {
+		t.Fatalf("Instructor reconnect failed: %v", err)
+	}
+	replayed, err := rejoined.WaitForMessageFrom(studentID, 3*time.Second)
+	if err != nil {
+		t.Fatalf("Instructor history replay missing: %v", err)
+	}
+	if replayed.ID != good.ID || len(replayed.Annotations) != 1 || replayed.Annotations[0].Note != "revisit Monday" {
+		t.Errorf("Expected the annotation in instructor replay, got %+v", replayed)
+	}
+	
+	// Nothing about the star reached the student
+	for {
+		message, err := studentClient.ReceiveMessage(300 * time.Millisecond)
+		if err != nil {
+			break
+		}
+		if message.Annotations != nil {
+			t.Errorf("Student received annotation data: %+v", message)
+		}
+	}
+}
+
+// receiveSystemEvent waits for a system message carrying the given content event
+func receiveSystemEvent(t *testing.T, client *fixtures.TestClient, event string, timeout time.Duration) *types.Message {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		message, err := client.ReceiveMessageOfType("system", time.Until(deadline))
+		if err != nil {
+			break
+		}
+		if message.Type == "system" && message.Content["event"] == event {
+			return message
+		}
+	}
+	t.Fatalf("Timed out waiting for %s system event", event)
+	return nil
+}
+
+// TestEndSessionReleasesResources validates that ending one session frees what
+// features held for it while the server keeps running
+// FUNCTIONAL DISCOVERY: Every per-session structure is populated first, then the
+// /health counts must fall to zero - and stay there when clients leave afterwards
+func TestEndSessionReleasesResources(t *testing.T) {
+	scenario := fixtures.GenerateClassroomScenario(2, 1)
+	instructorID, coInstructorID, studentID := scenario.InstructorIDs[0], scenario.InstructorIDs[1], scenario.StudentIDs[0]
+	
+	env := fixtures.NewEmbeddedEnvironmentWithConfig(func(cfg *config.Config) {
+		cfg.Transcripts = &config.TranscriptsConfig{
+			Dir:           t.TempDir(),
+			FlushInterval: time.Second,
+			MaxFileBytes:  1024 * 1024,
+		}
+		cfg.Router = &config.RouterConfig{BroadcastDedupWindow: time.Minute}
+	})
+	runner, err := fixtures.NewScenarioRunnerWithEnvironment(t, scenario, env)
+	if err != nil {
+		t.Fatalf("Failed to create scenario runner: %v", err)
+	}
+	defer runner.Cleanup()
+	
+	instructorClient, _ := runner.CreateClient(instructorID, "instructor")
+	coInstructorClient, _ := runner.CreateClient(coInstructorID, "instructor")
+	studentClient, _ := runner.CreateClient(studentID, "student")
+	if err := runner.ConnectAllClients(context.Background()); err != nil {
+		t.Fatalf("Failed to connect clients: %v", err)
+	}
+	receiveSystemEvent(t, studentClient, "connected", 3*time.Second)
+	
+	// Broadcast (dedup cache, transcript, rate limit), question (rate limit) and a
+	// co-instructor leaving (departure record)
+	instructorClient.SendMessage("instructor_broadcast", "announcement", map[string]interface{}{"text": "Quiz at 10"}, "")
+	if _, err := studentClient.WaitForMessageFrom(instructorID, 3*time.Second); err != nil {
+		t.Fatalf("Student did not receive the broadcast: %v", err)
+	}
+	studentClient.SendMessage("instructor_inbox", "question", map[string]interface{}{"text": "Open book?"}, "")
+	if _, err := instructorClient.WaitForMessageFrom(studentID, 3*time.Second); err != nil {
+		t.Fatalf("Instructor did not receive the question: %v", err)
+	}
+	coInstructorClient.Close()
+	
+	waitForResources(t, runner.ServerURL, func(resources map[string]int) bool {
+		for _, name := range []string{"broadcast_dedup", "instructor_departures", "rate_limits", "transcripts"} {
+			if resources[name] == 0 {
+				return false
+			}
+		}
+		return true
+	})
+	
+	if err := fixtures.EndSession(runner.ServerURL, runner.TestSession.SessionID); err != nil {
+		t.Fatalf("End failed: %v", err)
+	}
+	receiveSystemEvent(t, studentClient, "session_ended", 3*time.Second)
+	
+	released := func(resources map[string]int) bool {
+		for _, count := range resources {
+			if count != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	waitForResources(t, runner.ServerURL, released)
+	
+	// Clients still connected to the ended session leave without leaving anything behind
+	instructorClient.Close()
+	studentClient.Close()
+	time.Sleep(200 * time.Millisecond)
+	waitForResources(t, runner.ServerURL, released)
+}
+
+// waitForResources polls /health until the session resource counts satisfy done
+func waitForResources(t *testing.T, serverURL string, done func(map[string]int) bool) {
+	t.Helper()
+	var resources map[string]int
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		var err error
+		if resources, err = fixtures.FetchSessionResources(serverURL); err != nil {
+			t.Fatalf("Failed to read session resources: %v", err)
+		}
+		if done(resources) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("Session resources did not reach the expected state: %v", resources)
+}
@@ -10,180 +10,10 @@ import (
 	"testing"
 	"time"
 
+	"switchboard/pkg/loadgen"
 	"switchboard/tests/fixtures"
 )
 
-// LoadTestMetrics tracks performance metrics during load testing
-type LoadTestMetrics struct {
-	MessagesSent          int64
-	MessagesReceived      int64
-	ConnectionsEstablished int64
-	ConnectionsFailed     int64
-	AverageLatency        time.Duration
-	MaxLatency           time.Duration
-	MinLatency           time.Duration
-	ErrorCount           int64
-	StartTime            time.Time
-	EndTime              time.Time
-	
-	// Resource monitoring
-	MaxGoroutines        int
-	MaxMemoryMB          uint64
-	DatabaseConnections  int
-	
-	mu sync.RWMutex
-	latencies []time.Duration
-}
-
-// AddLatency records a message latency measurement
-func (m *LoadTestMetrics) AddLatency(latency time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-	
-	m.latencies = append(m.latencies, latency)
-	
-	if latency > m.MaxLatency {
-		m.MaxLatency = latency
-	}
-	
-	if m.MinLatency == 0 || latency < m.MinLatency {
-		m.MinLatency = latency
-	}
-}
-
-// CalculateAverageLatency computes average latency from recorded measurements
-func (m *LoadTestMetrics) CalculateAverageLatency() {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	
-	if len(m.latencies) == 0 {
-		return
-	}
-	
-	var total time.Duration
-	for _, latency := range m.latencies {
-		total += latency
-	}
-	
-	m.AverageLatency = total / time.Duration(len(m.latencies))
-}
-
-// GetReport generates a comprehensive performance report
-func (m *LoadTestMetrics) GetReport() string {
-	m.CalculateAverageLatency()
-	
-	duration := m.EndTime.Sub(m.StartTime)
-	messagesPerSecond := float64(m.MessagesReceived) / duration.Seconds()
-	
-	successRate := float64(m.MessagesReceived) / float64(m.MessagesSent) * 100
-	if m.MessagesSent == 0 {
-		successRate = 0
-	}
-	
-	return fmt.Sprintf(`
-Load Test Performance Report
-============================
-Duration: %v
-Messages Sent: %d
-Messages Received: %d
-Success Rate: %.2f%%
-Messages/Second: %.2f
-Connections Established: %d
-Connection Failures: %d
-Errors: %d
-
-Latency Metrics:
-  Average: %v
-  Min: %v  
-  Max: %v
-
-Resource Usage:
-  Max Goroutines: %d
-  Max Memory (MB): %d
-  DB Connections: %d
-`,
-		duration,
-		m.MessagesSent,
-		m.MessagesReceived,
-		successRate,
-		messagesPerSecond,
-		m.ConnectionsEstablished,
-		m.ConnectionsFailed,
-		m.ErrorCount,
-		m.AverageLatency,
-		m.MinLatency,
-		m.MaxLatency,
-		m.MaxGoroutines,
-		m.MaxMemoryMB,
-		m.DatabaseConnections,
-	)
-}
-
-// ResourceMonitor tracks system resource usage during load tests
-type ResourceMonitor struct {
-	metrics *LoadTestMetrics
-	ctx     context.Context
-	cancel  context.CancelFunc
-	wg      sync.WaitGroup
-}
-
-// NewResourceMonitor creates and starts resource monitoring
-func NewResourceMonitor(metrics *LoadTestMetrics) *ResourceMonitor {
-	ctx, cancel := context.WithCancel(context.Background())
-	
-	monitor := &ResourceMonitor{
-		metrics: metrics,
-		ctx:     ctx,
-		cancel:  cancel,
-	}
-	
-	monitor.start()
-	return monitor
-}
-
-// start begins resource monitoring in the background
-func (rm *ResourceMonitor) start() {
-	rm.wg.Add(1)
-	go func() {
-		defer rm.wg.Done()
-		
-		ticker := time.NewTicker(100 * time.Millisecond)
-		defer ticker.Stop()
-		
-		for {
-			select {
-			case <-rm.ctx.Done():
-				return
-			case <-ticker.C:
-				rm.recordMetrics()
-			}
-		}
-	}()
-}
-
-// recordMetrics captures current system metrics
-func (rm *ResourceMonitor) recordMetrics() {
-	// Get goroutine count
-	goroutines := runtime.NumGoroutine()
-	if goroutines > rm.metrics.MaxGoroutines {
-		rm.metrics.MaxGoroutines = goroutines
-	}
-	
-	// Get memory stats
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
-	memoryMB := memStats.Alloc / 1024 / 1024
-	if memoryMB > rm.metrics.MaxMemoryMB {
-		rm.metrics.MaxMemoryMB = memoryMB
-	}
-}
-
-// Stop halts resource monitoring
-func (rm *ResourceMonitor) Stop() {
-	rm.cancel()
-	rm.wg.Wait()
-}
-
 // TestClassroomScaleLoad simulates realistic classroom size (30 students, 3 instructors)
 // Target: <50ms message routing, >99% delivery success, 5-minute duration
 func TestClassroomScaleLoad(t *testing.T) {
@@ -201,11 +31,8 @@ func TestClassroomScaleLoad(t *testing.T) {
 	}
 	
 	// Initialize metrics and monitoring
-	metrics := &LoadTestMetrics{
-		StartTime: time.Now(),
-		MinLatency: time.Hour, // Will be overwritten by first measurement
-	}
-	resourceMonitor := NewResourceMonitor(metrics)
+	metrics := loadgen.NewMetrics()
+	resourceMonitor := loadgen.NewResourceMonitor(metrics)
 	defer resourceMonitor.Stop()
 	
 	// Create all clients (33 total: 3 instructors + 30 students)
@@ -348,8 +175,8 @@ func TestMessageBurstHandling(t *testing.T) {
 		t.Fatalf("Failed to create scenario runner: %v", err)
 	}
 	
-	metrics := &LoadTestMetrics{StartTime: time.Now()}
-	resourceMonitor := NewResourceMonitor(metrics)
+	metrics := loadgen.NewMetrics()
+	resourceMonitor := loadgen.NewResourceMonitor(metrics)
 	defer resourceMonitor.Stop()
 	
 	// Create and connect all clients
@@ -402,13 +229,7 @@ func TestMessageBurstHandling(t *testing.T) {
 					sendStart := time.Now()
 					err := client.SendQuickMessage("instructor_inbox", 
 						fmt.Sprintf("Question from %s at %v", studentID, time.Now().Format("15:04:05")))
-					
-					if err != nil {
-						atomic.AddInt64(&metrics.ErrorCount, 1)
-					} else {
-						atomic.AddInt64(&metrics.MessagesSent, 1)
-						metrics.AddLatency(time.Since(sendStart))
-					}
+					metrics.RecordSend("instructor_inbox", sendStart, err)
 					
 					time.Sleep(1 * time.Second) // 1 message per second per student
 				}
@@ -437,13 +258,7 @@ func TestMessageBurstHandling(t *testing.T) {
 				sendStart := time.Now()
 				err := client.SendQuickMessage("instructor_broadcast", 
 					fmt.Sprintf("Broadcast during high load: %v", time.Now().Format("15:04:05")))
-				
-				if err != nil {
-					atomic.AddInt64(&metrics.ErrorCount, 1)
-				} else {
-					atomic.AddInt64(&metrics.MessagesSent, 1)
-					metrics.AddLatency(time.Since(sendStart))
-				}
+				metrics.RecordSend("instructor_broadcast", sendStart, err)
 			}
 		}
 	}()
@@ -483,13 +298,7 @@ func TestMessageBurstHandling(t *testing.T) {
 				sendStart := time.Now()
 				err := client.SendQuickMessage("instructor_inbox", 
 					fmt.Sprintf("Burst response from %s", id))
-				
-				if err != nil {
-					atomic.AddInt64(&metrics.ErrorCount, 1)
-				} else {
-					atomic.AddInt64(&metrics.MessagesSent, 1)
-					metrics.AddLatency(time.Since(sendStart))
-				}
+				metrics.RecordSend("instructor_inbox", sendStart, err)
 			}(studentID, i)
 		}
 		
@@ -545,8 +354,8 @@ func TestConcurrentSessionsLoad(t *testing.T) {
 		runners[i] = runner
 	}
 	
-	metrics := &LoadTestMetrics{StartTime: time.Now()}
-	resourceMonitor := NewResourceMonitor(metrics)
+	metrics := loadgen.NewMetrics()
+	resourceMonitor := loadgen.NewResourceMonitor(metrics)
 	defer resourceMonitor.Stop()
 	
 	// Setup all sessions concurrently
@@ -647,13 +456,15 @@ func TestConcurrentSessionsLoad(t *testing.T) {
 		runner.ValidateSessionIsolation(t)
 		
 		// Check message counts are reasonable for the session
-		messageCount, err := runner.TestSession.GetMessageCount()
-		if err != nil {
-			t.Errorf("Session %d: Failed to get message count: %v", i, err)
-		} else if messageCount == 0 {
-			t.Errorf("Session %d: No messages recorded", i)
-		} else {
-			t.Logf("Session %d: %d messages recorded", i, messageCount)
+		if runner.Supports(fixtures.CapabilityDatabaseAccess) {
+			messageCount, err := runner.TestSession.GetMessageCount()
+			if err != nil {
+				t.Errorf("Session %d: Failed to get message count: %v", i, err)
+			} else if messageCount == 0 {
+				t.Errorf("Session %d: No messages recorded", i)
+			} else {
+				t.Logf("Session %d: %d messages recorded", i, messageCount)
+			}
 		}
 	}
 	
@@ -693,8 +504,8 @@ func TestConnectionStabilityStress(t *testing.T) {
 		t.Fatalf("Failed to create scenario runner: %v", err)
 	}
 	
-	metrics := &LoadTestMetrics{StartTime: time.Now()}
-	resourceMonitor := NewResourceMonitor(metrics)
+	metrics := loadgen.NewMetrics()
+	resourceMonitor := loadgen.NewResourceMonitor(metrics)
 	defer resourceMonitor.Stop()
 	
 	// Create and connect all clients initially
@@ -839,12 +650,14 @@ func TestConnectionStabilityStress(t *testing.T) {
 				return
 			case <-ticker.C:
 				// Verify session data integrity
-				messageCount, err := runner.TestSession.GetMessageCount()
-				if err != nil {
-					atomic.AddInt64(&metrics.ErrorCount, 1)
-					t.Logf("Session recovery check failed: %v", err)
-				} else {
-					t.Logf("Session recovery check: %d messages in database", messageCount)
+				if runner.Supports(fixtures.CapabilityDatabaseAccess) {
+					messageCount, err := runner.TestSession.GetMessageCount()
+					if err != nil {
+						atomic.AddInt64(&metrics.ErrorCount, 1)
+						t.Logf("Session recovery check failed: %v", err)
+					} else {
+						t.Logf("Session recovery check: %d messages in database", messageCount)
+					}
 				}
 			}
 		}
@@ -878,20 +691,22 @@ func TestConnectionStabilityStress(t *testing.T) {
 	}
 	
 	// Session data should remain consistent
-	finalMessageCount, err := runner.TestSession.GetMessageCount()
-	if err != nil {
-		t.Errorf("Failed to get final message count: %v", err)
-	} else if finalMessageCount == 0 {
-		t.Error("No messages persisted after stability test")
-	} else {
-		t.Logf("Final session message count: %d", finalMessageCount)
+	if runner.Supports(fixtures.CapabilityDatabaseAccess) {
+		finalMessageCount, err := runner.TestSession.GetMessageCount()
+		if err != nil {
+			t.Errorf("Failed to get final message count: %v", err)
+		} else if finalMessageCount == 0 {
+			t.Error("No messages persisted after stability test")
+		} else {
+			t.Logf("Final session message count: %d", finalMessageCount)
+		}
 	}
 }
 
 // Helper functions for load test message generation
 
 // sendInstructorBroadcasts sends periodic broadcast messages from instructors
-func sendInstructorBroadcasts(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *LoadTestMetrics) {
+func sendInstructorBroadcasts(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *loadgen.Metrics) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 	
@@ -911,13 +726,7 @@ func sendInstructorBroadcasts(ctx context.Context, runner *fixtures.ScenarioRunn
 			sendStart := time.Now()
 			err := client.SendQuickMessage("instructor_broadcast", 
 				fmt.Sprintf("Broadcast #%d from %s", messageCounter, instructorID))
-			
-			if err != nil {
-				atomic.AddInt64(&metrics.ErrorCount, 1)
-			} else {
-				atomic.AddInt64(&metrics.MessagesSent, 1)
-				metrics.AddLatency(time.Since(sendStart))
-			}
+			metrics.RecordSend("instructor_broadcast", sendStart, err)
 			
 			messageCounter++
 		}
@@ -925,7 +734,7 @@ func sendInstructorBroadcasts(ctx context.Context, runner *fixtures.ScenarioRunn
 }
 
 // sendStudentQuestions sends continuous student questions to instructors
-func sendStudentQuestions(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *LoadTestMetrics) {
+func sendStudentQuestions(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *loadgen.Metrics) {
 	ticker := time.NewTicker(2 * time.Second) // One question every 2 seconds across all students
 	defer ticker.Stop()
 	
@@ -953,13 +762,7 @@ func sendStudentQuestions(ctx context.Context, runner *fixtures.ScenarioRunner,
 			sendStart := time.Now()
 			err := client.SendQuickMessage("instructor_inbox", 
 				fmt.Sprintf("%s from %s", question, studentID))
-			
-			if err != nil {
-				atomic.AddInt64(&metrics.ErrorCount, 1)
-			} else {
-				atomic.AddInt64(&metrics.MessagesSent, 1)
-				metrics.AddLatency(time.Since(sendStart))
-			}
+			metrics.RecordSend("instructor_inbox", sendStart, err)
 			
 			messageCounter++
 		}
@@ -967,7 +770,7 @@ func sendStudentQuestions(ctx context.Context, runner *fixtures.ScenarioRunner,
 }
 
 // sendInstructorResponses sends instructor responses to students
-func sendInstructorResponses(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *LoadTestMetrics) {
+func sendInstructorResponses(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *loadgen.Metrics) {
 	ticker := time.NewTicker(5 * time.Second) // Response every 5 seconds
 	defer ticker.Stop()
 	
@@ -1000,13 +803,7 @@ func sendInstructorResponses(ctx context.Context, runner *fixtures.ScenarioRunne
 			response := responses[messageCounter % len(responses)]
 			sendStart := time.Now()
 			err := client.SendDirectMessage("inbox_response", response, studentID)
-			
-			if err != nil {
-				atomic.AddInt64(&metrics.ErrorCount, 1)
-			} else {
-				atomic.AddInt64(&metrics.MessagesSent, 1)
-				metrics.AddLatency(time.Since(sendStart))
-			}
+			metrics.RecordSend("inbox_response", sendStart, err)
 			
 			messageCounter++
 		}
@@ -1014,7 +811,7 @@ func sendInstructorResponses(ctx context.Context, runner *fixtures.ScenarioRunne
 }
 
 // sendAnalyticsData sends periodic analytics data from students
-func sendAnalyticsData(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *LoadTestMetrics) {
+func sendAnalyticsData(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *loadgen.Metrics) {
 	ticker := time.NewTicker(60 * time.Second) // Analytics every minute
 	defer ticker.Stop()
 	
@@ -1036,13 +833,7 @@ func sendAnalyticsData(ctx context.Context, runner *fixtures.ScenarioRunner, sce
 					"participation":   rand.Intn(100),
 					"timestamp":       time.Now().Unix(),
 				}, "")
-				
-				if err != nil {
-					atomic.AddInt64(&metrics.ErrorCount, 1)
-				} else {
-					atomic.AddInt64(&metrics.MessagesSent, 1)
-					metrics.AddLatency(time.Since(sendStart))
-				}
+				metrics.RecordSend("analytics", sendStart, err)
 				
 				time.Sleep(100 * time.Millisecond) // Small delay between students
 			}
@@ -1051,7 +842,7 @@ func sendAnalyticsData(ctx context.Context, runner *fixtures.ScenarioRunner, sce
 }
 
 // sendCodeReviewRequests sends periodic code review requests from instructors
-func sendCodeReviewRequests(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *LoadTestMetrics) {
+func sendCodeReviewRequests(ctx context.Context, runner *fixtures.ScenarioRunner, scenario *fixtures.ClassroomData, metrics *loadgen.Metrics) {
 	ticker := time.NewTicker(2 * time.Minute) // Code review every 2 minutes
 	defer ticker.Stop()
 	
@@ -1077,13 +868,7 @@ func sendCodeReviewRequests(ctx context.Context, runner *fixtures.ScenarioRunner
 			sendStart := time.Now()
 			err := client.SendDirectMessage("request", 
 				fmt.Sprintf("Please share your solution for assignment %d", messageCounter+1), studentID)
-			
-			if err != nil {
-				atomic.AddInt64(&metrics.ErrorCount, 1)
-			} else {
-				atomic.AddInt64(&metrics.MessagesSent, 1)
-				metrics.AddLatency(time.Since(sendStart))
-			}
+			metrics.RecordSend("request", sendStart, err)
 			
 			messageCounter++
 		}
@@ -1091,7 +876,7 @@ func sendCodeReviewRequests(ctx context.Context, runner *fixtures.ScenarioRunner
 }
 
 // collectMessages continuously collects incoming messages from all clients
-func collectMessages(ctx context.Context, runner *fixtures.ScenarioRunner, metrics *LoadTestMetrics) {
+func collectMessages(ctx context.Context, runner *fixtures.ScenarioRunner, metrics *loadgen.Metrics) {
 	ticker := time.NewTicker(500 * time.Millisecond) // Check every 500ms
 	defer ticker.Stop()
 	